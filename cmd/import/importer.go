@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/hasher"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// beginner — пул соединений или внешняя транзакция (в dry-run режиме)
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type stats struct {
+	Total    int
+	Imported int
+	Skipped  int // запись уже есть в базе
+	Invalid  int // запись не прошла валидацию
+	Failed   int // ошибка базы данных
+}
+
+func (s stats) String() string {
+	return fmt.Sprintf("total=%d imported=%d skipped=%d invalid=%d failed=%d",
+		s.Total, s.Imported, s.Skipped, s.Invalid, s.Failed)
+}
+
+// insertFunc вставляет одну запись. inserted=false — запись уже существует
+type insertFunc func(ctx context.Context, tx pgx.Tx, rec record) (inserted bool, err error)
+
+type importer struct {
+	db            beginner
+	batchSize     int
+	progressEvery int
+}
+
+// run импортирует записи пачками. Каждая запись выполняется в savepoint,
+// поэтому ошибка одной записи не откатывает всю пачку.
+func (im *importer) run(ctx context.Context, entity string, records []record, insert insertFunc) (stats, error) {
+	st := stats{Total: len(records)}
+	start := time.Now()
+
+	for from := 0; from < len(records); from += im.batchSize {
+		to := min(from+im.batchSize, len(records))
+
+		tx, err := im.db.Begin(ctx)
+		if err != nil {
+			return st, fmt.Errorf("%s: begin batch: %w", entity, err)
+		}
+
+		for i := from; i < to; i++ {
+			im.apply(ctx, entity, tx, records[i], insert, &st)
+
+			if done := i + 1; done%im.progressEvery == 0 && done != len(records) {
+				im.progress(entity, done, st, start)
+			}
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return st, fmt.Errorf("%s: commit batch: %w", entity, err)
+		}
+	}
+
+	im.progress(entity, len(records), st, start)
+	return st, nil
+}
+
+func (im *importer) apply(ctx context.Context, entity string, tx pgx.Tx, rec record, insert insertFunc, st *stats) {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		st.Failed++
+		log.Printf("%s: line %d: savepoint: %v", entity, rec.line, err)
+		return
+	}
+
+	inserted, err := insert(ctx, sp, rec)
+	if err != nil {
+		_ = sp.Rollback(ctx)
+
+		var vErr *validationError
+		if errors.As(err, &vErr) {
+			st.Invalid++
+		} else {
+			st.Failed++
+		}
+		log.Printf("%s: %v", entity, err)
+		return
+	}
+
+	if err := sp.Commit(ctx); err != nil {
+		st.Failed++
+		log.Printf("%s: line %d: release savepoint: %v", entity, rec.line, err)
+		return
+	}
+
+	if inserted {
+		st.Imported++
+	} else {
+		st.Skipped++
+	}
+}
+
+func (im *importer) progress(entity string, done int, st stats, start time.Time) {
+	pct := 100.0
+	if st.Total > 0 {
+		pct = float64(done) / float64(st.Total) * 100
+	}
+
+	rate := float64(done) / max(time.Since(start).Seconds(), 0.001)
+	log.Printf("%s: %d/%d (%.1f%%) imported=%d skipped=%d invalid=%d failed=%d, %.0f rec/s",
+		entity, done, st.Total, pct, st.Imported, st.Skipped, st.Invalid, st.Failed, rate)
+}
+
+/* ========================= drivers ========================= */
+
+func insertDriver(ctx context.Context, tx pgx.Tx, rec record) (bool, error) {
+	d, err := mapDriver(rec)
+	if err != nil {
+		return false, err
+	}
+
+	// пароль legacy системы не переносится: водитель сбрасывает его через auth-service
+	if err := ensureUser(ctx, tx, d.ID, d.Email, types.RoleDriver, d.CreatedAt); err != nil {
+		return false, fmt.Errorf("line %d: %w", rec.line, err)
+	}
+
+	const q = `
+		INSERT INTO drivers (id, name, license_number, vehicle_type, vehicle_attrs,
+			rating, total_rides, total_earnings, is_verified, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, coalesce($9, now()))
+		ON CONFLICT DO NOTHING`
+
+	tag, err := tx.Exec(ctx, q,
+		d.ID,
+		d.Name,
+		d.LicenseNumber,
+		d.VehicleType,
+		d.Vehicle,
+		d.Rating,
+		d.TotalRides,
+		d.TotalEarnings,
+		d.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("line %d: insert driver %s: %w", rec.line, d.ID, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+/* ======================= coordinates ======================= */
+
+func insertCoordinate(ctx context.Context, tx pgx.Tx, rec record) (bool, error) {
+	c, err := mapCoordinate(rec)
+	if err != nil {
+		return false, err
+	}
+
+	// дубликаты определяются по сущности и времени записи
+	const existsQ = `
+		SELECT EXISTS (
+			SELECT 1 FROM coordinates
+			WHERE entity_id = $1 AND entity_type = $2 AND created_at = $3
+		)`
+
+	var exists bool
+	if err := tx.QueryRow(ctx, existsQ, c.EntityID, c.EntityType, c.RecordedAt).Scan(&exists); err != nil {
+		return false, fmt.Errorf("line %d: check coordinate: %w", rec.line, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	// триггер trg_set_is_current_false снимает is_current с предыдущих записей,
+	// поэтому координаты импортируются в порядке recorded_at
+	const q = `
+		INSERT INTO coordinates (entity_id, entity_type, address, latitude, longitude, is_current, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, true, $6, $6)
+		RETURNING id`
+
+	var coordinateID uuid.UUID
+	if err := tx.QueryRow(ctx, q,
+		c.EntityID,
+		c.EntityType,
+		c.Location.Address,
+		c.Location.Latitude,
+		c.Location.Longitude,
+		c.RecordedAt,
+	).Scan(&coordinateID); err != nil {
+		return false, fmt.Errorf("line %d: insert coordinate: %w", rec.line, err)
+	}
+
+	if c.EntityType != types.Driver {
+		return true, nil
+	}
+
+	const historyQ = `
+		INSERT INTO location_history (coordinate_id, driver_id, latitude, longitude,
+			accuracy_meters, speed_kmh, heading_degrees, recorded_at, ride_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	if _, err := tx.Exec(ctx, historyQ,
+		coordinateID,
+		c.EntityID,
+		c.Location.Latitude,
+		c.Location.Longitude,
+		c.AccuracyMeters,
+		c.SpeedKmh,
+		c.HeadingDegrees,
+		c.RecordedAt,
+		c.RideID,
+	); err != nil {
+		return false, fmt.Errorf("line %d: insert location history: %w", rec.line, err)
+	}
+
+	return true, nil
+}
+
+// sortCoordinates упорядочивает записи по recorded_at, чтобы текущей осталась последняя координата
+func sortCoordinates(records []record) {
+	recordedAt := func(rec record) time.Time {
+		p := newFieldParser(rec)
+		if t := p.time("recorded_at", false); t != nil {
+			return *t
+		}
+		return time.Time{}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return recordedAt(records[i]).Before(recordedAt(records[j]))
+	})
+}
+
+/* ========================== rides ========================== */
+
+func insertRide(ctx context.Context, tx pgx.Tx, rec record) (bool, error) {
+	r, err := mapRide(rec)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM rides WHERE id = $1 OR ride_number = $2)`,
+		r.ID, r.RideNumber,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("line %d: check ride: %w", rec.line, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := ensureUser(ctx, tx, r.PassengerID, r.PassengerEmail, types.RolePassenger, &r.RequestedAt); err != nil {
+		return false, fmt.Errorf("line %d: %w", rec.line, err)
+	}
+
+	// координаты поездки исторические и не должны становиться текущими
+	const coordQ = `
+		INSERT INTO coordinates (entity_id, entity_type, address, latitude, longitude, is_current, created_at, updated_at)
+		VALUES ($1, 'passenger', $2, $3, $4, false, $5, $5)
+		RETURNING id`
+
+	var pickupID, destinationID uuid.UUID
+	if err := tx.QueryRow(ctx, coordQ,
+		r.PassengerID, r.Pickup.Address, r.Pickup.Latitude, r.Pickup.Longitude, r.RequestedAt,
+	).Scan(&pickupID); err != nil {
+		return false, fmt.Errorf("line %d: insert pickup coordinate: %w", rec.line, err)
+	}
+	if err := tx.QueryRow(ctx, coordQ,
+		r.PassengerID, r.Destination.Address, r.Destination.Latitude, r.Destination.Longitude, r.RequestedAt,
+	).Scan(&destinationID); err != nil {
+		return false, fmt.Errorf("line %d: insert destination coordinate: %w", rec.line, err)
+	}
+
+	const q = `
+		INSERT INTO rides (id, ride_number, passenger_id, driver_id, vehicle_type, status,
+			requested_at, matched_at, started_at, completed_at, cancelled_at, cancellation_reason,
+			estimated_fare, final_fare, pickup_coordinate_id, destination_coordinate_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $7)`
+
+	if _, err := tx.Exec(ctx, q,
+		r.ID,
+		r.RideNumber,
+		r.PassengerID,
+		r.DriverID,
+		r.VehicleType,
+		r.Status,
+		r.RequestedAt,
+		r.MatchedAt,
+		r.StartedAt,
+		r.CompletedAt,
+		r.CancelledAt,
+		r.CancellationReason,
+		r.EstimatedFare,
+		r.FinalFare,
+		pickupID,
+		destinationID,
+	); err != nil {
+		return false, fmt.Errorf("line %d: insert ride %s: %w", rec.line, r.RideNumber, err)
+	}
+
+	return true, nil
+}
+
+/* ========================== users ========================== */
+
+// ensureUser создает пользователя, если его еще нет, и проверяет, что роль совпадает
+func ensureUser(ctx context.Context, tx pgx.Tx, id uuid.UUID, email string, role types.UserRole, createdAt *time.Time) error {
+	const q = `
+		INSERT INTO users (id, email, role, status, password_hash, created_at)
+		VALUES ($1, $2, $3, 'ACTIVE', $4, coalesce($5, now()))
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(ctx, q, id, email, role, hasher.Hash(uuid.New().String()), createdAt); err != nil {
+		return fmt.Errorf("insert user %s: %w", id, err)
+	}
+
+	var existing string
+	if err := tx.QueryRow(ctx, `SELECT role FROM users WHERE id = $1`, id).Scan(&existing); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user %s: email %s already belongs to another user", id, email)
+		}
+		return fmt.Errorf("get user %s: %w", id, err)
+	}
+	if existing != role.String() {
+		return fmt.Errorf("user %s has role %s, expected %s", id, existing, role)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+// Command import переносит исторические данные legacy системы (водители, координаты, поездки)
+// из CSV/JSON выгрузок в схему nomad-go.
+//
+//	go run ./cmd/import -drivers drivers.csv -coordinates coords.jsonl -rides rides.json -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/pkg/configparser"
+	pgclient "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+)
+
+var (
+	configPath      = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+	driversPath     = flag.String("drivers", "", "Drivers export (.csv, .json or .jsonl)")
+	ridesPath       = flag.String("rides", "", "Rides export (.csv, .json or .jsonl)")
+	coordinatesPath = flag.String("coordinates", "", "Coordinates export (.csv, .json or .jsonl)")
+	mappingPath     = flag.String("mapping", "", "Optional JSON file with legacy column renames per entity")
+	dryRun          = flag.Bool("dry-run", false, "Validate and insert inside a transaction that is rolled back")
+	batchSize       = flag.Int("batch", 500, "Records per transaction")
+	progressEvery   = flag.Int("progress-every", 1000, "Report progress every N records")
+)
+
+// columnMapping — переименование колонок legacy выгрузки: {"drivers": {"driver_uuid": "id"}}
+type columnMapping struct {
+	Drivers     map[string]string `json:"drivers"`
+	Rides       map[string]string `json:"rides"`
+	Coordinates map[string]string `json:"coordinates"`
+}
+
+func main() {
+	flag.Parse()
+
+	if *driversPath == "" && *ridesPath == "" && *coordinatesPath == "" {
+		log.Fatal("nothing to import: provide at least one of -drivers, -rides, -coordinates")
+	}
+	if *batchSize <= 0 || *progressEvery <= 0 {
+		log.Fatal("-batch and -progress-every must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// config.NewConfig требует --mode, импорту нужна только база
+	cfg := &config.Config{}
+	if err := configparser.LoadAndParseYaml(*configPath, cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	mapping, err := loadMapping(*mappingPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := pgclient.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Pool.Close()
+
+	im := &importer{
+		db:            client.Pool,
+		batchSize:     *batchSize,
+		progressEvery: *progressEvery,
+	}
+
+	// в dry-run все пишется во внешнюю транзакцию: внешние ключи между сущностями
+	// проверяются как при настоящем импорте, а в конце транзакция откатывается
+	if *dryRun {
+		tx, err := client.Pool.Begin(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer tx.Rollback(context.Background())
+
+		im.db = tx
+		log.Println("dry-run: no changes will be committed")
+	}
+
+	// порядок важен: поездки и история координат ссылаются на водителей,
+	// а история координат (location_history.ride_id) — на поездки
+	steps := []struct {
+		entity  string
+		path    string
+		aliases map[string]string
+		insert  insertFunc
+	}{
+		{"drivers", *driversPath, mapping.Drivers, insertDriver},
+		{"rides", *ridesPath, mapping.Rides, insertRide},
+		{"coordinates", *coordinatesPath, mapping.Coordinates, insertCoordinate},
+	}
+
+	summary := make(map[string]stats)
+	for _, step := range steps {
+		if step.path == "" {
+			continue
+		}
+
+		records, err := readRecords(step.path, step.aliases)
+		if err != nil {
+			log.Fatalf("%s: %v", step.entity, err)
+		}
+		if step.entity == "coordinates" {
+			sortCoordinates(records)
+		}
+
+		log.Printf("%s: importing %d records from %s", step.entity, len(records), step.path)
+
+		st, err := im.run(ctx, step.entity, records, step.insert)
+		if err != nil {
+			log.Fatal(err)
+		}
+		summary[step.entity] = st
+	}
+
+	fmt.Println("import summary:")
+	failed := false
+	for _, step := range steps {
+		st, ok := summary[step.entity]
+		if !ok {
+			continue
+		}
+		fmt.Printf("  %-12s %s\n", step.entity, st)
+		failed = failed || st.Invalid > 0 || st.Failed > 0
+	}
+	if *dryRun {
+		fmt.Println("dry-run: all changes rolled back")
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func loadMapping(path string) (columnMapping, error) {
+	var m columnMapping
+	if path == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, fmt.Errorf("read mapping: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parse mapping: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// legacyID переводит идентификатор legacy системы в UUID.
+// Валидный UUID используется как есть, иначе UUID детерминированно выводится из kind и id,
+// поэтому поездки ссылаются на водителей по тем же legacy идентификаторам.
+func legacyID(kind, id string) uuid.UUID {
+	if u, err := uuid.Parse(id); err == nil {
+		return u
+	}
+
+	sum := sha1.Sum([]byte("nomad-go-import:" + kind + ":" + id))
+
+	var u uuid.UUID
+	copy(u[:], sum[:16])
+	u[6] = (u[6] & 0x0f) | 0x50 // версия 5 (name-based, SHA-1)
+	u[8] = (u[8] & 0x3f) | 0x80 // вариант RFC 4122
+	return u
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// fieldParser накапливает ошибки разбора полей в validator
+type fieldParser struct {
+	rec record
+	v   *validator.Validator
+}
+
+func newFieldParser(rec record) *fieldParser {
+	return &fieldParser{rec: rec, v: validator.New()}
+}
+
+func (p *fieldParser) str(key string, required bool) string {
+	s := p.rec.get(key)
+	if required {
+		p.v.Check(s != "", key, "must be provided")
+	}
+	return s
+}
+
+func (p *fieldParser) float(key string, required bool) float64 {
+	s := p.str(key, required)
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	p.v.Check(err == nil, key, "must be a number")
+	return f
+}
+
+func (p *fieldParser) int(key string) int {
+	s := p.str(key, false)
+	if s == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(s)
+	p.v.Check(err == nil, key, "must be an integer")
+	return i
+}
+
+func (p *fieldParser) time(key string, required bool) *time.Time {
+	s := p.str(key, required)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	p.v.AddError(key, "must be a timestamp (RFC3339 or 2006-01-02 15:04:05)")
+	return nil
+}
+
+func (p *fieldParser) location(prefix string, required bool) models.Location {
+	loc := models.Location{
+		Latitude:  p.float(prefix+"latitude", required),
+		Longitude: p.float(prefix+"longitude", required),
+		Address:   p.str(prefix+"address", false),
+	}
+	p.v.Check(loc.Latitude >= -90 && loc.Latitude <= 90, prefix+"latitude", "must be between -90 and 90")
+	p.v.Check(loc.Longitude >= -180 && loc.Longitude <= 180, prefix+"longitude", "must be between -180 and 180")
+	return loc
+}
+
+// err возвращает ошибку валидации записи или nil
+func (p *fieldParser) err() error {
+	if p.v.Valid() {
+		return nil
+	}
+
+	keys := make([]string, 0, len(p.v.Errors))
+	for k := range p.v.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		msgs = append(msgs, fmt.Sprintf("%s %s", k, p.v.Errors[k]))
+	}
+	return &validationError{line: p.rec.line, msg: strings.Join(msgs, "; ")}
+}
+
+type validationError struct {
+	line int
+	msg  string
+}
+
+func (e *validationError) Error() string {
+	return fmt.Sprintf("line %d: invalid record: %s", e.line, e.msg)
+}
+
+/* ========================= drivers ========================= */
+
+type driverRow struct {
+	ID            uuid.UUID
+	Email         string
+	Name          string
+	LicenseNumber string
+	VehicleType   types.VehicleClass
+	Vehicle       models.Vehicle
+	Rating        float64
+	TotalRides    int
+	TotalEarnings float64
+	CreatedAt     *time.Time
+}
+
+func mapDriver(rec record) (driverRow, error) {
+	p := newFieldParser(rec)
+
+	id := p.str("id", true)
+	row := driverRow{
+		ID:            legacyID("driver", id),
+		Email:         strings.ToLower(p.str("email", false)),
+		Name:          p.str("name", true),
+		LicenseNumber: strings.ToUpper(p.str("license_number", true)),
+		VehicleType:   types.VehicleClass(strings.ToUpper(p.str("vehicle_type", false))),
+		Vehicle: models.Vehicle{
+			Make:  p.str("vehicle_make", false),
+			Model: p.str("vehicle_model", false),
+			Color: p.str("vehicle_color", false),
+			Plate: p.str("vehicle_plate", false),
+			Year:  p.int("vehicle_year"),
+		},
+		Rating:        p.float("rating", false),
+		TotalRides:    p.int("total_rides"),
+		TotalEarnings: p.float("total_earnings", false),
+		CreatedAt:     p.time("created_at", false),
+	}
+
+	if row.Email == "" {
+		row.Email = fmt.Sprintf("driver-%s@import.local", row.ID)
+	}
+	if row.VehicleType == "" {
+		row.VehicleType = types.ClassEconomy
+	}
+	if row.Rating == 0 {
+		row.Rating = 5.0
+	}
+	row.Vehicle.Type = row.VehicleType
+
+	p.v.Check(validator.Matches(row.Email, validator.EmailRX), "email", "must be a valid email address")
+	p.v.Check(len(row.Name) < 100, "name", "must be less than 100 characters")
+	p.v.Check(len(row.LicenseNumber) <= 50, "license_number", "must be at most 50 characters")
+	p.v.Check(validator.PermittedValue(row.VehicleType, types.ClassEconomy, types.ClassPremium, types.ClassXL), "vehicle_type", "must be one of ECONOMY, PREMIUM, XL")
+	p.v.Check(row.Rating >= 1 && row.Rating <= 5, "rating", "must be between 1 and 5")
+	p.v.Check(row.TotalRides >= 0, "total_rides", "must not be negative")
+	p.v.Check(row.TotalEarnings >= 0, "total_earnings", "must not be negative")
+
+	return row, p.err()
+}
+
+/* ========================== rides ========================== */
+
+type rideRow struct {
+	ID                 uuid.UUID
+	RideNumber         string
+	PassengerID        uuid.UUID
+	PassengerEmail     string
+	DriverID           *uuid.UUID
+	VehicleType        types.VehicleClass
+	Status             types.RideStatus
+	RequestedAt        time.Time
+	MatchedAt          *time.Time
+	StartedAt          *time.Time
+	CompletedAt        *time.Time
+	CancelledAt        *time.Time
+	CancellationReason *string
+	EstimatedFare      float64
+	FinalFare          *float64
+	Pickup             models.Location
+	Destination        models.Location
+}
+
+func mapRide(rec record) (rideRow, error) {
+	p := newFieldParser(rec)
+
+	id := p.str("id", true)
+	passengerID := p.str("passenger_id", true)
+
+	row := rideRow{
+		ID:             legacyID("ride", id),
+		RideNumber:     p.str("ride_number", false),
+		PassengerID:    legacyID("passenger", passengerID),
+		PassengerEmail: strings.ToLower(p.str("passenger_email", false)),
+		VehicleType:    types.VehicleClass(strings.ToUpper(p.str("vehicle_type", false))),
+		Status:         types.RideStatus(strings.ToUpper(p.str("status", true))),
+		MatchedAt:      p.time("matched_at", false),
+		StartedAt:      p.time("started_at", false),
+		CompletedAt:    p.time("completed_at", false),
+		CancelledAt:    p.time("cancelled_at", false),
+		EstimatedFare:  p.float("estimated_fare", false),
+		Pickup:         p.location("pickup_", true),
+		Destination:    p.location("destination_", true),
+	}
+
+	if requestedAt := p.time("requested_at", true); requestedAt != nil {
+		row.RequestedAt = *requestedAt
+	}
+	if driverID := p.str("driver_id", false); driverID != "" {
+		id := legacyID("driver", driverID)
+		row.DriverID = &id
+	}
+	if reason := p.str("cancellation_reason", false); reason != "" {
+		row.CancellationReason = &reason
+	}
+	if p.str("final_fare", false) != "" {
+		fare := p.float("final_fare", false)
+		row.FinalFare = &fare
+	}
+	if row.RideNumber == "" {
+		row.RideNumber = "LEGACY_" + id
+	}
+	if row.PassengerEmail == "" {
+		row.PassengerEmail = fmt.Sprintf("passenger-%s@import.local", row.PassengerID)
+	}
+	if row.VehicleType == "" {
+		row.VehicleType = types.ClassEconomy
+	}
+	if row.Pickup.Address == "" {
+		row.Pickup.Address = "imported"
+	}
+	if row.Destination.Address == "" {
+		row.Destination.Address = "imported"
+	}
+
+	p.v.Check(len(row.RideNumber) <= 50, "ride_number", "must be at most 50 characters")
+	p.v.Check(validator.Matches(row.PassengerEmail, validator.EmailRX), "passenger_email", "must be a valid email address")
+	p.v.Check(validator.PermittedValue(row.VehicleType, types.ClassEconomy, types.ClassPremium, types.ClassXL), "vehicle_type", "must be one of ECONOMY, PREMIUM, XL")
+	p.v.Check(types.IsValidRideStatus(row.Status), "status", "must be a valid ride status")
+	p.v.Check(row.EstimatedFare >= 0, "estimated_fare", "must not be negative")
+	p.v.Check(row.FinalFare == nil || *row.FinalFare >= 0, "final_fare", "must not be negative")
+	p.v.Check(row.Status != types.StatusCompleted || row.CompletedAt != nil, "completed_at", "must be provided for COMPLETED rides")
+	p.v.Check(row.Status != types.StatusCancelled || row.CancelledAt != nil, "cancelled_at", "must be provided for CANCELLED rides")
+	p.v.Check(row.Status == types.StatusRequested || row.Status == types.StatusCancelled || row.DriverID != nil, "driver_id", "must be provided for matched rides")
+
+	return row, p.err()
+}
+
+/* ======================= coordinates ======================= */
+
+type coordinateRow struct {
+	EntityID       uuid.UUID
+	EntityType     types.EntityType
+	Location       models.Location
+	RecordedAt     time.Time
+	RideID         *uuid.UUID
+	AccuracyMeters *float64
+	SpeedKmh       *float64
+	HeadingDegrees *float64
+}
+
+func mapCoordinate(rec record) (coordinateRow, error) {
+	p := newFieldParser(rec)
+
+	entityType := types.EntityType(strings.ToLower(p.str("entity_type", true)))
+	entityID := p.str("entity_id", true)
+
+	row := coordinateRow{
+		EntityID:   legacyID(string(entityType), entityID),
+		EntityType: entityType,
+		Location:   p.location("", true),
+	}
+
+	if recordedAt := p.time("recorded_at", true); recordedAt != nil {
+		row.RecordedAt = *recordedAt
+	}
+	if rideID := p.str("ride_id", false); rideID != "" {
+		id := legacyID("ride", rideID)
+		row.RideID = &id
+	}
+	optional := func(key string) *float64 {
+		if p.str(key, false) == "" {
+			return nil
+		}
+		f := p.float(key, false)
+		return &f
+	}
+	row.AccuracyMeters = optional("accuracy_meters")
+	row.SpeedKmh = optional("speed_kmh")
+	row.HeadingDegrees = optional("heading_degrees")
+
+	if row.Location.Address == "" {
+		row.Location.Address = "imported"
+	}
+
+	p.v.Check(validator.PermittedValue(row.EntityType, types.Driver, types.Passenger), "entity_type", "must be driver or passenger")
+	p.v.Check(row.HeadingDegrees == nil || (*row.HeadingDegrees >= 0 && *row.HeadingDegrees <= 360), "heading_degrees", "must be between 0 and 360")
+
+	return row, p.err()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// record — одна запись входного файла: колонка -> значение
+type record struct {
+	line   int
+	fields map[string]string
+}
+
+func (r record) get(key string) string {
+	return strings.TrimSpace(r.fields[key])
+}
+
+// readRecords читает CSV, JSON (массив объектов) или JSONL выгрузку.
+// aliases переименовывает колонки legacy системы в имена, которые понимает импорт.
+func readRecords(path string, aliases map[string]string) ([]record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []record
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readCSV(f)
+	case ".json":
+		records, err = readJSON(f)
+	case ".jsonl", ".ndjson":
+		records, err = readJSONL(f)
+	default:
+		return nil, fmt.Errorf("unsupported file format %q: use .csv, .json or .jsonl", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(aliases) > 0 {
+		for _, rec := range records {
+			for from, to := range aliases {
+				from := strings.ToLower(from)
+				if v, ok := rec.fields[from]; ok {
+					delete(rec.fields, from)
+					rec.fields[strings.ToLower(to)] = v
+				}
+			}
+		}
+	}
+
+	return records, nil
+}
+
+func readCSV(r io.Reader) ([]record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	var records []record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		fields := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(row) {
+				fields[col] = row[i]
+			}
+		}
+		records = append(records, record{line: line, fields: fields})
+	}
+
+	return records, nil
+}
+
+func readJSON(r io.Reader) ([]record, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var rows []map[string]any
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("decode json array: %w", err)
+	}
+
+	records := make([]record, 0, len(rows))
+	for i, row := range rows {
+		records = append(records, record{line: i + 1, fields: flatten(row)})
+	}
+
+	return records, nil
+}
+
+func readJSONL(r io.Reader) ([]record, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var records []record
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+
+		var row map[string]any
+		if err := dec.Decode(&row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record{line: line, fields: flatten(row)})
+	}
+
+	return records, sc.Err()
+}
+
+// flatten приводит значения JSON объекта к строкам, как в CSV
+func flatten(row map[string]any) map[string]string {
+	fields := make(map[string]string, len(row))
+	for k, v := range row {
+		key := strings.ToLower(k)
+		switch val := v.(type) {
+		case nil:
+			fields[key] = ""
+		case string:
+			fields[key] = val
+		case json.Number:
+			fields[key] = val.String()
+		case bool:
+			fields[key] = fmt.Sprint(val)
+		default:
+			b, _ := json.Marshal(val)
+			fields[key] = string(b)
+		}
+	}
+	return fields
+}
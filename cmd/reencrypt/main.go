@@ -0,0 +1,108 @@
+// Command reencrypt перешифровывает PII (телефоны в users.attrs, адреса в coordinates)
+// активным ключом keyring. Используется после добавления нового ключа в PII_KEYS и смены
+// PII_ACTIVE_KEY, а также для шифрования данных, записанных до включения шифрования.
+// Команда идемпотентна: уже перешифрованные значения пропускаются, прерванный запуск можно повторить.
+//
+//	PII_ACTIVE_KEY=k2 PII_KEYS=k1:...,k2:... go run ./cmd/reencrypt
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/configparser"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	pgclient "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+var (
+	configPath = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+	batchSize  = flag.Int("batch", 500, "Rows per batch")
+)
+
+// rotateFunc перешифровывает одну пачку строк с id больше after
+type rotateFunc func(ctx context.Context, after uuid.UUID, limit int) (updated int, next uuid.UUID, err error)
+
+func main() {
+	flag.Parse()
+
+	if *batchSize <= 0 {
+		log.Fatal("-batch must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// config.NewConfig требует --mode, команде нужны только база и ключи
+	cfg := &config.Config{}
+	if err := configparser.LoadAndParseYaml(*configPath, cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	pii, err := keyring.New(cfg.PII.ActiveKey, cfg.PII.Keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !pii.Enabled() {
+		log.Fatal("PII encryption is disabled: set PII_ACTIVE_KEY and PII_KEYS")
+	}
+
+	client, err := pgclient.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Pool.Close()
+
+	repo := postgres.NewPIIRepo(client.Pool, pii)
+	log.Printf("re-encrypting PII with key %s", pii.ActiveKeyID())
+
+	steps := []struct {
+		entity string
+		rotate rotateFunc
+	}{
+		{"users.attrs", repo.RotateUserAttrs},
+		{"coordinates.address", repo.RotateAddresses},
+	}
+
+	for _, step := range steps {
+		total, err := run(ctx, step.entity, step.rotate)
+		if err != nil {
+			log.Fatalf("%s: %v (re-encrypted %d rows before failure, rerun to resume)", step.entity, err, total)
+		}
+		log.Printf("%s: re-encrypted %d rows", step.entity, total)
+	}
+}
+
+func run(ctx context.Context, entity string, rotate rotateFunc) (int, error) {
+	var (
+		after uuid.UUID
+		total int
+		start = time.Now()
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		updated, next, err := rotate(ctx, after, *batchSize)
+		total += updated
+		if err != nil {
+			return total, err
+		}
+		if next == after {
+			return total, nil
+		}
+		after = next
+
+		log.Printf("%s: %d rows so far, %.0f rows/s", entity, total, float64(total)/max(time.Since(start).Seconds(), 0.001))
+	}
+}
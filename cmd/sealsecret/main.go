@@ -0,0 +1,41 @@
+// Command sealsecret шифрует секрет для конфига активным ключом SECRETS_KEYS и печатает ссылку
+// ${enc:<key id>:<base64>}, которую можно записать в config.yaml или переменную окружения вместо открытого значения.
+// Секрет читается из stdin, чтобы не попасть в историю shell.
+//
+//	printf '%s' "$JWT_SECRET" | SECRETS_ACTIVE_KEY=k1 SECRETS_KEYS=k1:... go run ./cmd/sealsecret
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+)
+
+func main() {
+	ring, err := keyring.New(os.Getenv("SECRETS_ACTIVE_KEY"), os.Getenv("SECRETS_KEYS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !ring.Enabled() {
+		log.Fatal("no encryption keys: set SECRETS_ACTIVE_KEY and SECRETS_KEYS")
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		log.Fatal("empty secret on stdin")
+	}
+
+	sealed, err := ring.Encrypt(secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("${%s}\n", sealed)
+}
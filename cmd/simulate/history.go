@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/simulation"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// loadHistory читает запросы поездок за период по ride_events и координаты водителей из location_history
+func loadHistory(ctx context.Context, db *pgxpool.Pool, from, to time.Time) (simulation.History, error) {
+	h := simulation.History{From: from, To: to}
+
+	requests, err := loadRequests(ctx, db, from, to)
+	if err != nil {
+		return h, err
+	}
+	pings, err := loadPings(ctx, db, from, to)
+	if err != nil {
+		return h, err
+	}
+
+	h.Requests = requests
+	h.Pings = pings
+	return h, nil
+}
+
+func loadRequests(ctx context.Context, db *pgxpool.Pool, from, to time.Time) ([]simulation.Request, error) {
+	// время событий берется из ride_events, для импортированных поездок без событий — из rides
+	const query = `
+		with window_rides as (
+			select id from rides
+			where requested_at >= $1::timestamptz - interval '1 day' and requested_at < $2::timestamptz + interval '1 day'
+		), events as (
+			select ride_id,
+				min(created_at) filter (where event_type = 'RIDE_REQUESTED') as requested_at,
+				min(created_at) filter (where event_type = 'DRIVER_MATCHED') as matched_at,
+				min(created_at) filter (where event_type = 'RIDE_STARTED') as started_at,
+				min(created_at) filter (where event_type = 'RIDE_COMPLETED') as completed_at
+			from ride_events
+			where ride_id in (select id from window_rides)
+			group by ride_id
+		), history as (
+			select r.id, r.vehicle_type,
+				coalesce(e.requested_at, r.requested_at) as requested_at,
+				coalesce(e.matched_at, r.matched_at) as matched_at,
+				coalesce(e.started_at, r.started_at) as started_at,
+				coalesce(e.completed_at, r.completed_at) as completed_at,
+				coalesce(r.final_fare, r.estimated_fare, 0) as fare,
+				r.pickup_coordinate_id, r.destination_coordinate_id
+			from rides r
+			join window_rides w on w.id = r.id
+			left join events e on e.ride_id = r.id
+		)
+		select h.id, h.vehicle_type, h.requested_at, h.matched_at, h.started_at, h.completed_at, h.fare,
+			pc.latitude, pc.longitude, dc.latitude, dc.longitude
+		from history h
+		join coordinates pc on pc.id = h.pickup_coordinate_id
+		join coordinates dc on dc.id = h.destination_coordinate_id
+		where h.requested_at >= $1 and h.requested_at < $2
+		order by h.requested_at, h.id`
+
+	rows, err := db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("load ride requests: %w", err)
+	}
+
+	requests, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (simulation.Request, error) {
+		var r simulation.Request
+		err := row.Scan(&r.RideID, &r.VehicleType, &r.RequestedAt, &r.MatchedAt, &r.StartedAt, &r.CompletedAt, &r.Fare,
+			&r.Pickup.Latitude, &r.Pickup.Longitude, &r.Destination.Latitude, &r.Destination.Longitude)
+		return r, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load ride requests: %w", err)
+	}
+	return requests, nil
+}
+
+func loadPings(ctx context.Context, db *pgxpool.Pool, from, to time.Time) ([]simulation.Ping, error) {
+	const query = `
+		select lh.driver_id, d.vehicle_type, lh.latitude, lh.longitude, lh.recorded_at
+		from location_history lh
+		join drivers d on d.id = lh.driver_id
+		where lh.recorded_at >= $1 and lh.recorded_at < $2
+		order by lh.recorded_at, lh.id`
+
+	rows, err := db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("load driver locations: %w", err)
+	}
+
+	pings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (simulation.Ping, error) {
+		var p simulation.Ping
+		err := row.Scan(&p.DriverID, &p.VehicleType, &p.Location.Latitude, &p.Location.Longitude, &p.At)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load driver locations: %w", err)
+	}
+	return pings, nil
+}
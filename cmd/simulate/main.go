@@ -0,0 +1,154 @@
+// Command simulate проигрывает исторические ride_events и координаты водителей
+// против альтернативных политик матчинга и surge и печатает сравнительные KPI.
+// Данные только читаются.
+//
+//	go run ./cmd/simulate -from 2025-01-01T00:00:00Z -to 2025-01-08T00:00:00Z -scenarios scenarios.json
+//
+// scenarios.json — массив политик (см. internal/simulation.Policy), не заданные поля
+// берутся из текущих правил driver-service:
+//
+//	[{"name": "radius-8km", "matching": {"radius_km": 8}},
+//	 {"name": "surge", "surge": {"enabled": true, "threshold": 1.5, "step": 0.3, "cap": 2.5, "elasticity": 0.4}}]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/internal/simulation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/configparser"
+	pgclient "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+)
+
+var (
+	configPath    = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+	fromFlag      = flag.String("from", "", "Period start, RFC 3339 (default: 7 days before -to)")
+	toFlag        = flag.String("to", "", "Period end, RFC 3339 (default: now)")
+	scenariosPath = flag.String("scenarios", "", "JSON file with policies to compare (default: current policy only)")
+	format        = flag.String("format", "table", "Output format: table or json")
+)
+
+func main() {
+	flag.Parse()
+
+	if *format != "table" && *format != "json" {
+		log.Fatal("-format must be table or json")
+	}
+
+	from, to, err := period(*fromFlag, *toFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	policies, err := loadScenarios(*scenariosPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// config.NewConfig требует --mode, симуляции нужна только база
+	cfg := &config.Config{}
+	if err := configparser.LoadAndParseYaml(*configPath, cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := pgclient.New(ctx, cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Pool.Close()
+
+	history, err := loadHistory(ctx, client.Pool, from, to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("loaded %d ride requests and %d driver locations from %s to %s",
+		len(history.Requests), len(history.Pings), from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	results := simulation.Compare(history, policies, ridecalc.New())
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printTable(results)
+}
+
+func period(fromStr, toStr string) (time.Time, time.Time, error) {
+	to := time.Now()
+	if toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("-to: %w", err)
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -7)
+	if fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("-from: %w", err)
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("-from must be before -to")
+	}
+	return from, to, nil
+}
+
+func loadScenarios(path string) ([]simulation.Policy, error) {
+	if path == "" {
+		return []simulation.Policy{simulation.DefaultPolicy()}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenarios: %w", err)
+	}
+
+	var policies []simulation.Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("parse scenarios: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("scenarios file has no policies")
+	}
+
+	for _, p := range policies {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+func printTable(results []simulation.KPI) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "policy\trequests\tmatched\tunmatched\tpriced out\tmatch rate\tavg match\tp90 match\tutilization\trevenue\tavg surge\t")
+	for _, k := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%s\t%s\t%.1f%%\t%.2f\t%.2f\t\n",
+			k.Policy, k.Requests, k.Matched, k.Unmatched, k.PricedOut,
+			k.MatchRate*100, k.AvgTimeToMatch.Round(time.Second), k.P90TimeToMatch.Round(time.Second),
+			k.Utilization*100, k.Revenue, k.AvgMultiplier)
+	}
+	w.Flush()
+}
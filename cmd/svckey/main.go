@@ -0,0 +1,31 @@
+// Command svckey создает пару ключей Ed25519 для подписи вызовов и сообщений сервиса
+// и печатает закрытый ключ для SERVICE_AUTH_PRIVATE_KEYS этого сервиса и открытый
+// для SERVICE_AUTH_PUBLIC_KEYS всех сервисов.
+//
+//	go run ./cmd/svckey -service driver-service -id 2024a
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+)
+
+func main() {
+	service := flag.String("service", "", "service name (--mode), e.g. driver-service")
+	id := flag.String("id", "", "key id")
+	flag.Parse()
+
+	if *service == "" || *id == "" {
+		log.Fatal("both -service and -id are required")
+	}
+
+	private, public, err := svcauth.GenerateKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("SERVICE_AUTH_PRIVATE_KEYS entry (%s only): %s:%s\n", *service, *id, private)
+	fmt.Printf("SERVICE_AUTH_PUBLIC_KEYS entry (all services): %s/%s:%s\n", *service, *id, public)
+}
@@ -0,0 +1,578 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// seedOptions — объем синтетических данных
+type seedOptions struct {
+	Prefix     string
+	Drivers    int
+	Passengers int
+	Rides      int
+	From, To   time.Time
+	Seed       uint64
+}
+
+// city — город из city_settings, вокруг центра которого генерируются поездки
+type city struct {
+	Code        string
+	Name        string
+	Center      models.Location
+	RadiusKm    float64
+	Location    *time.Location
+	LegalEntity string
+}
+
+// defaultCity используется, если в city_settings нет ни одного города
+var defaultCity = city{
+	Code:     "ALA",
+	Name:     "Almaty",
+	Center:   models.Location{Latitude: 43.238949, Longitude: 76.889709},
+	RadiusKm: 30,
+	Location: time.UTC,
+}
+
+type seedUser struct {
+	ID        uuid.UUID
+	Email     string
+	Role      types.UserRole
+	Name      string
+	CreatedAt time.Time
+}
+
+type seedDriver struct {
+	seedUser
+	License string
+	City    int
+	Vehicle models.DriverVehicle
+	Quality float64 // средняя оценка водителя, вокруг которой генерируются оценки пассажиров
+}
+
+type seedEvent struct {
+	Type types.RideEvent
+	At   time.Time
+	Data models.RideEvent
+}
+
+type seedPing struct {
+	Location models.Location
+	SpeedKmh float64
+	Heading  float64
+	At       time.Time
+}
+
+type seedOffer struct {
+	ID       uuid.UUID
+	DriverID uuid.UUID
+	Outcome  types.OfferOutcome
+	At       time.Time
+}
+
+type seedRating struct {
+	Score   int
+	Comment *string
+	At      time.Time
+}
+
+type seedRide struct {
+	ID          uuid.UUID
+	Number      string // назначается при записи из ride_number_seq
+	City        int
+	PassengerID uuid.UUID
+	Driver      *seedDriver
+	Class       types.VehicleClass
+	Status      types.RideStatus
+	Payment     types.PaymentMethod
+
+	Pickup, Destination models.Location
+	PickupCoordID       uuid.UUID
+	DestinationCoordID  uuid.UUID
+
+	RequestedAt        time.Time
+	MatchedAt          *time.Time
+	ArrivedAt          *time.Time
+	StartedAt          *time.Time
+	CompletedAt        *time.Time
+	CancelledAt        *time.Time
+	CancellationReason *string
+
+	DistanceKm    float64 // по маршруту при заказе
+	DurationMin   int
+	Surge         float64
+	EstimatedFare float64
+	Fare          models.FareBreakdown // строки чека завершенной поездки
+	ActualKm      float64
+	CO2Grams      float64
+
+	Events []seedEvent
+	Pings  []seedPing
+	Offers []seedOffer
+	Rating *seedRating
+}
+
+// dataset — все сгенерированные записи
+type dataset struct {
+	Passengers []seedUser
+	Drivers    []*seedDriver
+	Rides      []*seedRide
+}
+
+type generator struct {
+	r      *rand.Rand
+	opts   seedOptions
+	calc   *ridecalc.CalculatorImpl
+	co2    map[types.VehicleClass]float64
+	cities []city
+
+	byClass map[int]map[types.VehicleClass][]*seedDriver // водители по городу и классу
+}
+
+func newGenerator(opts seedOptions, calc *ridecalc.CalculatorImpl, co2 map[types.VehicleClass]float64, cities []city) *generator {
+	if len(cities) == 0 {
+		cities = []city{defaultCity}
+	}
+	return &generator{
+		r:       rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)),
+		opts:    opts,
+		calc:    calc,
+		co2:     co2,
+		cities:  cities,
+		byClass: make(map[int]map[types.VehicleClass][]*seedDriver),
+	}
+}
+
+func (g *generator) generate() dataset {
+	var d dataset
+
+	for i := range g.opts.Passengers {
+		d.Passengers = append(d.Passengers, seedUser{
+			ID:        g.id(),
+			Email:     fmt.Sprintf("%s-passenger-%05d@seed.ride.kz", g.opts.Prefix, i+1),
+			Role:      types.RolePassenger,
+			Name:      g.name(),
+			CreatedAt: g.before(g.opts.From, 365),
+		})
+	}
+
+	for i := range g.opts.Drivers {
+		driver := g.driver(i)
+		d.Drivers = append(d.Drivers, driver)
+
+		if g.byClass[driver.City] == nil {
+			g.byClass[driver.City] = make(map[types.VehicleClass][]*seedDriver)
+		}
+		g.byClass[driver.City][driver.Vehicle.Class] = append(g.byClass[driver.City][driver.Vehicle.Class], driver)
+	}
+
+	for range g.opts.Rides {
+		passenger := d.Passengers[g.r.IntN(len(d.Passengers))]
+		d.Rides = append(d.Rides, g.ride(passenger))
+	}
+
+	// номера поездок выдаются по порядку заказа
+	slices.SortFunc(d.Rides, func(a, b *seedRide) int { return a.RequestedAt.Compare(b.RequestedAt) })
+	return d
+}
+
+/* ======================= drivers ======================= */
+
+type vehicleModel struct {
+	Make, Model string
+	Body        string
+	MinYear     int
+}
+
+var vehicleModels = map[types.VehicleClass][]vehicleModel{
+	types.ClassEconomy: {
+		{"Toyota", "Corolla", "SEDAN", 2012},
+		{"Hyundai", "Elantra", "SEDAN", 2014},
+		{"Hyundai", "Accent", "SEDAN", 2013},
+		{"Kia", "Rio", "SEDAN", 2014},
+		{"Chevrolet", "Cobalt", "SEDAN", 2014},
+		{"Volkswagen", "Polo", "SEDAN", 2013},
+		{"Skoda", "Rapid", "HATCHBACK", 2015},
+		{"Lada", "Vesta", "SEDAN", 2016},
+	},
+	types.ClassPremium: {
+		{"Toyota", "Camry", "SEDAN", 2018},
+		{"Mercedes-Benz", "E-Class", "SEDAN", 2017},
+		{"BMW", "5 Series", "SEDAN", 2017},
+		{"Lexus", "ES", "SEDAN", 2018},
+		{"Hyundai", "Grandeur", "SEDAN", 2019},
+		{"Kia", "K8", "SEDAN", 2021},
+	},
+	types.ClassXL: {
+		{"Toyota", "Alphard", "MINIVAN", 2016},
+		{"Hyundai", "Staria", "VAN", 2021},
+		{"Kia", "Carnival", "MINIVAN", 2019},
+		{"Mercedes-Benz", "V-Class", "VAN", 2016},
+		{"Toyota", "Land Cruiser Prado", "SUV", 2016},
+		{"Hyundai", "Palisade", "SUV", 2019},
+	},
+}
+
+var vehicleColors = []string{"White", "White", "Black", "Silver", "Silver", "Gray", "Blue", "Red", "Beige"}
+
+// plateLetters — латиница, совпадающая по начертанию с кириллицей, как на казахстанских номерах
+const plateLetters = "ABCEHKMOPTXY"
+
+func (g *generator) driver(i int) *seedDriver {
+	c := g.pick(len(g.cities), g.cityWeight)
+	class := g.class()
+	m := vehicleModels[class][g.r.IntN(len(vehicleModels[class]))]
+	joined := g.before(g.opts.From, 730)
+
+	return &seedDriver{
+		seedUser: seedUser{
+			ID:        g.id(),
+			Email:     fmt.Sprintf("%s-driver-%05d@seed.ride.kz", g.opts.Prefix, i+1),
+			Role:      types.RoleDriver,
+			Name:      g.name(),
+			CreatedAt: joined,
+		},
+		License: fmt.Sprintf("%s-DL-%06d", strings.ToUpper(g.opts.Prefix), i+1),
+		City:    c,
+		Vehicle: models.DriverVehicle{
+			Class:     class,
+			BodyType:  m.Body,
+			Make:      m.Make,
+			Model:     m.Model,
+			Color:     vehicleColors[g.r.IntN(len(vehicleColors))],
+			Plate:     g.plate(),
+			Year:      m.MinYear + g.r.IntN(max(g.opts.To.Year()-m.MinYear, 0)+1),
+			IsActive:  true,
+			CreatedAt: joined,
+			UpdatedAt: joined,
+		},
+		// большинство водителей оценивают высоко, единицы — заметно ниже
+		Quality: min(5, max(3.2, 4.75+g.r.NormFloat64()*0.2)),
+	}
+}
+
+func (g *generator) plate() string {
+	letters := make([]byte, 3)
+	for i := range letters {
+		letters[i] = plateLetters[g.r.IntN(len(plateLetters))]
+	}
+	return fmt.Sprintf("%03d %s %02d", 1+g.r.IntN(999), letters, 1+g.r.IntN(20))
+}
+
+var (
+	firstNames = []string{"Aidar", "Arman", "Askar", "Bekzat", "Daniyar", "Yerlan", "Nurlan", "Marat", "Timur", "Ruslan",
+		"Aigerim", "Aruzhan", "Dana", "Madina", "Saule", "Alina", "Dmitry", "Sergey", "Olga", "Irina"}
+	lastNames = []string{"Abenov", "Akhmetov", "Bekov", "Dzhaksybekov", "Ibraev", "Kassymov", "Nurpeisov", "Omarov",
+		"Sadykov", "Tulegenov", "Zhunusov", "Ivanov", "Petrov", "Kim", "Li"}
+)
+
+func (g *generator) name() string {
+	return firstNames[g.r.IntN(len(firstNames))] + " " + lastNames[g.r.IntN(len(lastNames))]
+}
+
+// class — доли классов: ECONOMY 70%, PREMIUM 20%, XL 10%
+func (g *generator) class() types.VehicleClass {
+	switch p := g.r.Float64(); {
+	case p < 0.7:
+		return types.ClassEconomy
+	case p < 0.9:
+		return types.ClassPremium
+	default:
+		return types.ClassXL
+	}
+}
+
+/* ======================= rides ======================= */
+
+var streets = []string{"Abay Ave", "Dostyk Ave", "Al-Farabi Ave", "Tole Bi St", "Satpayev St", "Zhibek Zholy St",
+	"Nazarbayev Ave", "Timiryazev St", "Rozybakiev St", "Seifullin Ave", "Kabanbay Batyr St", "Mangilik El Ave"}
+
+// hourWeights — доля заказов по часам местного времени: утренний и вечерний пики, ночной спад
+var hourWeights = []float64{2, 1.2, 0.8, 0.5, 0.5, 0.8, 2, 5, 8, 6, 4, 4, 5, 5, 4, 4, 5, 7, 9, 8, 6, 5, 4, 3}
+
+const (
+	cancelBeforeMatch = 0.07 // водитель не найден или пассажир отменил до назначения
+	cancelAfterMatch  = 0.05 // отмена после назначения водителя
+	ratedShare        = 0.7  // доля завершенных поездок с оценкой
+	roadFactor        = 1.25 // во сколько раз путь по дорогам длиннее прямой
+)
+
+func (g *generator) ride(passenger seedUser) *seedRide {
+	c := g.pick(len(g.cities), g.cityWeight)
+	cty := g.cities[c]
+	class := g.class()
+
+	ride := &seedRide{
+		ID:                 g.id(),
+		City:               c,
+		PassengerID:        passenger.ID,
+		Class:              class,
+		Payment:            types.PaymentCard,
+		Pickup:             g.point(cty.Center, cty.RadiusKm*0.6),
+		PickupCoordID:      g.id(),
+		DestinationCoordID: g.id(),
+		RequestedAt:        g.requestTime(cty),
+		Surge:              1,
+	}
+	if g.r.Float64() < 0.15 {
+		ride.Payment = types.PaymentWallet
+	}
+
+	// поездки в пределах города: 1-20 км, чаще короткие
+	tripKm := min(1+g.r.ExpFloat64()*5, 20)
+	ride.Destination = g.offset(ride.Pickup, tripKm/roadFactor, g.r.Float64()*360)
+	ride.Pickup.Address = g.address(cty)
+	ride.Destination.Address = g.address(cty)
+
+	ride.DistanceKm = math.Round(g.calc.Distance(ride.Pickup, ride.Destination)*roadFactor*100) / 100
+	ride.DurationMin = g.calc.Duration(ride.DistanceKm)
+	if peak(ride.RequestedAt.In(cty.Location).Hour()) && g.r.Float64() < 0.4 {
+		ride.Surge = 1 + float64(1+g.r.IntN(8))/10
+	}
+	fare := g.calc.Fare(string(class), ride.DistanceKm, ride.DurationMin)
+	ride.EstimatedFare = math.Round(fare.Total * ride.Surge)
+
+	ride.Events = append(ride.Events, seedEvent{Type: types.EventRideRequested, At: ride.RequestedAt, Data: models.RideEvent{
+		NewStatus: types.StatusRequested,
+		Location:  ride.Pickup,
+	}})
+
+	drivers := g.byClass[c][class]
+	if len(drivers) == 0 || g.r.Float64() < cancelBeforeMatch {
+		reason := "Changed my mind"
+		cancelledAt := ride.RequestedAt.Add(g.seconds(20, 110))
+		if len(drivers) == 0 || g.r.Float64() < 0.5 {
+			reason = "failed to find a driver"
+			cancelledAt = ride.RequestedAt.Add(2 * time.Minute)
+		}
+		g.cancel(ride, types.StatusRequested, cancelledAt, reason)
+		return ride
+	}
+
+	driver := drivers[g.r.IntN(len(drivers))]
+	ride.Driver = driver
+	matchedAt := ride.RequestedAt.Add(g.seconds(5, 90))
+	ride.MatchedAt = &matchedAt
+
+	// до принятия оффер могли отклонить или пропустить другие водители класса
+	for i := range g.r.IntN(3) {
+		other := drivers[g.r.IntN(len(drivers))]
+		if other == driver {
+			continue
+		}
+		outcome := types.OfferDeclined
+		if g.r.Float64() < 0.4 {
+			outcome = types.OfferExpired
+		}
+		ride.Offers = append(ride.Offers, seedOffer{ID: g.id(), DriverID: other.ID, Outcome: outcome, At: ride.RequestedAt.Add(time.Duration(i+1) * 5 * time.Second)})
+	}
+	ride.Offers = append(ride.Offers, seedOffer{ID: g.id(), DriverID: driver.ID, Outcome: types.OfferAccepted, At: matchedAt})
+
+	approachKm := 0.3 + g.r.Float64()*3
+	driverStart := g.offset(ride.Pickup, approachKm, g.r.Float64()*360)
+	ride.Events = append(ride.Events, seedEvent{Type: types.EventDriverMatched, At: matchedAt, Data: models.RideEvent{
+		OldStatus:        types.StatusRequested,
+		NewStatus:        types.StatusMatched,
+		DriverID:         driver.ID,
+		Location:         driverStart,
+		EstimatedArrival: matchedAt.Add(time.Duration(g.calc.Duration(approachKm*roadFactor)+2) * time.Minute),
+	}})
+
+	if g.r.Float64() < cancelAfterMatch {
+		reason := "Driver is too far"
+		if g.r.Float64() < 0.4 {
+			reason = "Plans changed"
+		}
+		g.cancel(ride, types.StatusMatched, matchedAt.Add(g.seconds(30, 240)), reason)
+		return ride
+	}
+
+	arrivedAt := matchedAt.Add(time.Duration(g.calc.Duration(approachKm*roadFactor)+2)*time.Minute + g.seconds(0, 120))
+	startedAt := arrivedAt.Add(g.seconds(30, 240))
+	// пробки удлиняют поездку относительно расчета
+	completedAt := startedAt.Add(time.Duration(float64(ride.DurationMin)*(1+g.r.Float64()*0.6)*float64(time.Minute)) + g.seconds(60, 300))
+	ride.ArrivedAt, ride.StartedAt, ride.CompletedAt = &arrivedAt, &startedAt, &completedAt
+	ride.Status = types.StatusCompleted
+
+	ride.ActualKm = math.Round(ride.DistanceKm*(0.95+g.r.Float64()*0.2)*100) / 100
+	ride.CO2Grams = math.Round(ride.ActualKm * g.co2[class])
+	ride.Fare = g.calc.Settle(fare, ride.Surge, 0, ride.EstimatedFare)
+
+	ride.Events = append(ride.Events,
+		seedEvent{Type: types.EventDriverArrived, At: arrivedAt, Data: models.RideEvent{
+			OldStatus: types.StatusMatched, NewStatus: types.StatusArrived, DriverID: driver.ID, Location: ride.Pickup,
+		}},
+		seedEvent{Type: types.EventRideStarted, At: startedAt, Data: models.RideEvent{
+			OldStatus: types.StatusArrived, NewStatus: types.StatusInProgress, DriverID: driver.ID, Location: ride.Pickup,
+		}},
+		seedEvent{Type: types.EventRideCompleted, At: completedAt, Data: models.RideEvent{
+			OldStatus: types.StatusInProgress, NewStatus: types.StatusCompleted, DriverID: driver.ID, Location: ride.Destination,
+		}},
+	)
+	ride.Pings = g.pings(driverStart, ride.Pickup, matchedAt, arrivedAt)
+	ride.Pings = append(ride.Pings, g.pings(ride.Pickup, ride.Destination, startedAt, completedAt)...)
+
+	if g.r.Float64() < ratedShare {
+		ride.Rating = g.rating(driver, completedAt)
+	}
+	return ride
+}
+
+func (g *generator) cancel(ride *seedRide, from types.RideStatus, at time.Time, reason string) {
+	ride.Status = types.StatusCancelled
+	ride.CancelledAt = &at
+	ride.CancellationReason = &reason
+
+	event := models.RideEvent{OldStatus: from, NewStatus: types.StatusCancelled, Location: ride.Pickup}
+	if ride.Driver != nil {
+		event.DriverID = ride.Driver.ID
+	}
+	ride.Events = append(ride.Events, seedEvent{Type: types.EventRideCancelled, At: at, Data: event})
+}
+
+// pings — координаты водителя примерно раз в минуту на отрезке from-to
+func (g *generator) pings(from, to models.Location, start, end time.Time) []seedPing {
+	dur := end.Sub(start)
+	n := max(int(dur/time.Minute), 1)
+	km := g.calc.Distance(from, to) * roadFactor
+	speed := math.Round(km/max(dur.Hours(), 1.0/60)*100) / 100
+	heading := bearing(from, to)
+
+	res := make([]seedPing, 0, n+1)
+	for i := 0; i <= n; i++ {
+		f := float64(i) / float64(n)
+		res = append(res, seedPing{
+			Location: models.Location{
+				Latitude:  from.Latitude + (to.Latitude-from.Latitude)*f + g.r.NormFloat64()*0.0002,
+				Longitude: from.Longitude + (to.Longitude-from.Longitude)*f + g.r.NormFloat64()*0.0002,
+			},
+			SpeedKmh: min(speed*(0.7+g.r.Float64()*0.6), 120),
+			Heading:  heading,
+			At:       start.Add(time.Duration(f * float64(dur))),
+		})
+	}
+	return res
+}
+
+var ratingComments = map[int][]string{
+	5: {"Great driver", "Clean car, smooth ride", "Thank you!"},
+	4: {"Good ride", "A bit late but fine"},
+	3: {"Took a longer route", "Music was too loud"},
+	2: {"Rude driver", "Car was dirty"},
+	1: {"Unsafe driving", "Driver was late and rude"},
+}
+
+// rating — оценка вокруг качества водителя, комментарий оставляет каждый пятый
+func (g *generator) rating(driver *seedDriver, completedAt time.Time) *seedRating {
+	score := int(math.Round(min(5, max(1, driver.Quality+g.r.NormFloat64()*0.6))))
+	rating := &seedRating{Score: score, At: completedAt.Add(g.seconds(10, 600))}
+	if g.r.Float64() < 0.2 {
+		comments := ratingComments[score]
+		comment := comments[g.r.IntN(len(comments))]
+		rating.Comment = &comment
+	}
+	return rating
+}
+
+// requestTime — случайный момент периода с учетом суточного профиля спроса города
+func (g *generator) requestTime(c city) time.Time {
+	for {
+		at := g.opts.From.Add(time.Duration(g.r.Int64N(int64(g.opts.To.Sub(g.opts.From)))))
+		// выходные чуть спокойнее будних
+		weight := hourWeights[at.In(c.Location).Hour()] / 9
+		if wd := at.In(c.Location).Weekday(); wd == time.Saturday || wd == time.Sunday {
+			weight *= 0.85
+		}
+		if g.r.Float64() < weight {
+			return at
+		}
+	}
+}
+
+func peak(hour int) bool {
+	return (hour >= 7 && hour <= 9) || (hour >= 17 && hour <= 19)
+}
+
+func (g *generator) address(c city) string {
+	return fmt.Sprintf("%s %d, %s", streets[g.r.IntN(len(streets))], 1+g.r.IntN(250), c.Name)
+}
+
+/* ======================= helpers ======================= */
+
+// cityWeight — большие города получают больше водителей и поездок
+func (g *generator) cityWeight(i int) float64 {
+	return g.cities[i].RadiusKm
+}
+
+func (g *generator) pick(n int, weight func(int) float64) int {
+	total := 0.0
+	for i := range n {
+		total += weight(i)
+	}
+	p := g.r.Float64() * total
+	for i := range n {
+		p -= weight(i)
+		if p < 0 {
+			return i
+		}
+	}
+	return n - 1
+}
+
+// point — случайная точка вокруг центра, плотнее к центру города
+func (g *generator) point(center models.Location, radiusKm float64) models.Location {
+	dist := min(math.Abs(g.r.NormFloat64())*radiusKm/2, radiusKm)
+	return g.offset(center, dist, g.r.Float64()*360)
+}
+
+// offset сдвигает точку на distKm по направлению bearing (градусы)
+func (g *generator) offset(from models.Location, distKm, bearingDeg float64) models.Location {
+	const kmPerDegree = 111.32
+	rad := bearingDeg * math.Pi / 180
+	lat := from.Latitude + distKm*math.Cos(rad)/kmPerDegree
+	lon := from.Longitude + distKm*math.Sin(rad)/(kmPerDegree*math.Cos(from.Latitude*math.Pi/180))
+	return models.Location{Latitude: round6(lat), Longitude: round6(lon)}
+}
+
+func bearing(from, to models.Location) float64 {
+	lat1, lat2 := from.Latitude*math.Pi/180, to.Latitude*math.Pi/180
+	dLon := (to.Longitude - from.Longitude) * math.Pi / 180
+	deg := math.Atan2(math.Sin(dLon)*math.Cos(lat2), math.Cos(lat1)*math.Sin(lat2)-math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)) * 180 / math.Pi
+	return math.Round(math.Mod(deg+360, 360)*100) / 100
+}
+
+func round6(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// before — случайный момент за days дней до t
+func (g *generator) before(t time.Time, days int) time.Time {
+	return t.Add(-time.Duration(g.r.Int64N(int64(days) * int64(24*time.Hour))))
+}
+
+func (g *generator) seconds(from, to int) time.Duration {
+	return time.Duration(from+g.r.IntN(to-from+1)) * time.Second
+}
+
+// id — UUID v4 из генератора, чтобы одинаковый -seed давал одинаковые данные
+func (g *generator) id() uuid.UUID {
+	var u uuid.UUID
+	for i := 0; i < len(u); i += 8 {
+		v := g.r.Uint64()
+		for j := range 8 {
+			u[i+j] = byte(v >> (8 * j))
+		}
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u
+}
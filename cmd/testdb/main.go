@@ -1,3 +1,8 @@
+// Command testdb готовит базу для локальной разработки: создает пользователей по умолчанию
+// и заполняет базу синтетическими водителями, пассажирами и историей поездок, чтобы
+// аналитику и админские эндпоинты можно было показать и нагрузить без реальных данных.
+//
+//	go run ./cmd/testdb -drivers 300 -passengers 1000 -rides 5000 -days 90
 package main
 
 import (
@@ -6,25 +11,57 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/config"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/configparser"
 	"github.com/Temutjin2k/ride-hail-system/pkg/hasher"
 	pgclient "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var configPath = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+var (
+	configPath = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+	drivers    = flag.Int("drivers", 300, "Synthetic drivers to create, 0 — only default users")
+	passengers = flag.Int("passengers", 1000, "Synthetic passengers to create")
+	rides      = flag.Int("rides", 5000, "Historical rides to generate")
+	days       = flag.Int("days", 90, "Rides are spread over the last N days")
+	toFlag     = flag.String("to", "", "End of the ride history, RFC 3339 (default: now)")
+	prefix     = flag.String("prefix", "seed", "Prefix of synthetic emails and license numbers; use a new one to seed again")
+	seed       = flag.Uint64("seed", 1, "Random seed, the same seed generates the same data")
+	password   = flag.String("password", "password", "Password of every synthetic user")
+)
 
 func main() {
 	flag.Parse()
 
-	ctx := context.Background()
+	if *drivers < 0 || *passengers < 0 || *rides < 0 || *days <= 0 {
+		log.Fatal("-drivers, -passengers and -rides must not be negative, -days must be positive")
+	}
+	if *rides > 0 && (*drivers == 0 || *passengers == 0) {
+		log.Fatal("-rides needs at least one driver and one passenger")
+	}
 
-	cfg, err := config.NewConfig(*configPath)
-	if err != nil {
+	to := time.Now()
+	if *toFlag != "" {
+		t, err := time.Parse(time.RFC3339, *toFlag)
+		if err != nil {
+			log.Fatalf("-to: %v", err)
+		}
+		to = t
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// config.NewConfig требует --mode, сидеру нужны только база, тарифы и коэффициенты CO2
+	cfg := &config.Config{}
+	if err := configparser.LoadAndParseYaml(*configPath, cfg); err != nil {
 		log.Fatal(err)
 	}
 
@@ -32,12 +69,30 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer client.Pool.Close()
 
 	migrateDefaultUsers(client.Pool)
+
+	if *drivers == 0 && *passengers == 0 {
+		return
+	}
+
+	opts := seedOptions{
+		Prefix:     *prefix,
+		Drivers:    *drivers,
+		Passengers: *passengers,
+		Rides:      *rides,
+		From:       to.AddDate(0, 0, -*days),
+		To:         to,
+		Seed:       *seed,
+	}
+	if err := runSeed(ctx, client.Pool, cfg, opts, hasher.Hash(*password)); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func GetRideDetails(db *pgxpool.Pool) {
-	rideRepo := postgres.NewRideRepo(db)
+	rideRepo := postgres.NewRideRepo(db, nil)
 
 	id, err := uuid.Parse("96ab18a1-5bb1-45ca-bce9-dffc240b9eb5")
 	if err != nil {
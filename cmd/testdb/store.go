@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runSeed генерирует данные и записывает их одной транзакцией: при ошибке база не меняется
+func runSeed(ctx context.Context, db *pgxpool.Pool, cfg *config.Config, opts seedOptions, passwordHash string) error {
+	var seeded bool
+	if err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE email LIKE $1 || '-%@seed.ride.kz')`, opts.Prefix).Scan(&seeded); err != nil {
+		return fmt.Errorf("check prefix: %w", err)
+	}
+	if seeded {
+		return fmt.Errorf("prefix %q is already seeded, pass another -prefix to add more data", opts.Prefix)
+	}
+
+	cities, err := loadCities(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// тарифы, налог и комиссия те же, что у ride-service, поэтому суммы совпадают с настоящими поездками
+	calc := ridecalc.New().WithPricing(ridecalc.PricingOptions{
+		TaxRate:        cfg.Pricing.TaxRate,
+		CommissionRate: cfg.Pricing.CommissionRate,
+	})
+	co2 := map[types.VehicleClass]float64{
+		types.ClassEconomy: cfg.Carbon.EconomyGramsPerKm,
+		types.ClassPremium: cfg.Carbon.PremiumGramsPerKm,
+		types.ClassXL:      cfg.Carbon.XLGramsPerKm,
+	}
+
+	start := time.Now()
+	g := newGenerator(opts, calc, co2, cities)
+	data := g.generate()
+	log.Printf("seed: generated %d drivers, %d passengers, %d rides in %d cities from %s to %s",
+		len(data.Drivers), len(data.Passengers), len(data.Rides), len(g.cities),
+		opts.From.Format(time.DateOnly), opts.To.Format(time.DateOnly))
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	s := &store{tx: tx, passwordHash: passwordHash, defaultLegalEntity: cfg.Ride.InvoiceLegalEntity, cities: g.cities}
+	if err := s.assignRideNumbers(ctx, data.Rides); err != nil {
+		return err
+	}
+
+	// порядок важен: внешние ключи
+	steps := []struct {
+		table string
+		write func(context.Context, dataset) (int64, error)
+	}{
+		{"users", s.users},
+		{"drivers", s.drivers},
+		{"driver_vehicles", s.vehicles},
+		{"coordinates", s.coordinates},
+		{"rides", s.rides},
+		{"ride_events", s.events},
+		{"driver_offers", s.offers},
+		{"location_history", s.locations},
+		{"ride_ratings", s.ratings},
+		{"ride_receipts", s.receipts},
+	}
+	for _, step := range steps {
+		n, err := step.write(ctx, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", step.table, err)
+		}
+		log.Printf("seed: %-16s %d rows", step.table, n)
+	}
+
+	if err := s.aggregate(ctx, opts.Prefix); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	log.Printf("seed: done in %s, every synthetic user has the -password password", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// loadCities читает города из city_settings; поездки генерируются вокруг их центров
+func loadCities(ctx context.Context, db *pgxpool.Pool) ([]city, error) {
+	const q = `
+		SELECT code, name, center_latitude::float, center_longitude::float, radius_km::float, timezone, coalesce(legal_entity, '')
+		FROM city_settings
+		ORDER BY code`
+
+	rows, err := db.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("load cities: %w", err)
+	}
+
+	cities, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (city, error) {
+		var (
+			c  city
+			tz string
+		)
+		if err := row.Scan(&c.Code, &c.Name, &c.Center.Latitude, &c.Center.Longitude, &c.RadiusKm, &tz, &c.LegalEntity); err != nil {
+			return c, err
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			log.Printf("seed: city %s: unknown timezone %q, using UTC", c.Code, tz)
+			loc = time.UTC
+		}
+		c.Location = loc
+		return c, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load cities: %w", err)
+	}
+	return cities, nil
+}
+
+type store struct {
+	tx                 pgx.Tx
+	passwordHash       string
+	defaultLegalEntity string
+	cities             []city
+}
+
+// assignRideNumbers выдает номера из ride_number_seq в формате ride-service: RIDE_<yyyymmdd>_<10 цифр>
+func (s *store) assignRideNumbers(ctx context.Context, rides []*seedRide) error {
+	rows, err := s.tx.Query(ctx, `SELECT nextval('ride_number_seq') FROM generate_series(1, $1)`, len(rides))
+	if err != nil {
+		return fmt.Errorf("reserve ride numbers: %w", err)
+	}
+	seqs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("reserve ride numbers: %w", err)
+	}
+	if len(seqs) != len(rides) {
+		return errors.New("reserve ride numbers: sequence returned fewer values")
+	}
+
+	for i, ride := range rides {
+		ride.Number = fmt.Sprintf("RIDE_%s_%010d", ride.RequestedAt.UTC().Format("20060102"), seqs[i])
+	}
+	return nil
+}
+
+func (s *store) copy(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	return s.tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+}
+
+func (s *store) users(ctx context.Context, d dataset) (int64, error) {
+	rows := make([][]any, 0, len(d.Passengers)+len(d.Drivers))
+	add := func(u seedUser) {
+		attrs := map[string]string{"name": u.Name}
+		rows = append(rows, []any{u.ID, u.Email, string(u.Role), "ACTIVE", s.passwordHash, attrs, u.CreatedAt, u.CreatedAt})
+	}
+	for _, p := range d.Passengers {
+		add(p)
+	}
+	for _, dr := range d.Drivers {
+		add(dr.seedUser)
+	}
+	return s.copy(ctx, "users", []string{"id", "email", "role", "status", "password_hash", "attrs", "created_at", "updated_at"}, rows)
+}
+
+func (s *store) drivers(ctx context.Context, d dataset) (int64, error) {
+	rows := make([][]any, 0, len(d.Drivers))
+	for _, dr := range d.Drivers {
+		rows = append(rows, []any{dr.ID, dr.Name, dr.License, string(dr.Vehicle.Class), dr.Vehicle.Vehicle(), true, dr.CreatedAt, dr.CreatedAt})
+	}
+	return s.copy(ctx, "drivers", []string{"id", "name", "license_number", "vehicle_type", "vehicle_attrs", "is_verified", "created_at", "updated_at"}, rows)
+}
+
+func (s *store) vehicles(ctx context.Context, d dataset) (int64, error) {
+	rows := make([][]any, 0, len(d.Drivers))
+	for _, dr := range d.Drivers {
+		v := dr.Vehicle
+		rows = append(rows, []any{dr.ID, string(v.Class), v.BodyType, v.Make, v.Model, v.Color, v.Plate, v.Year, v.IsActive, v.CreatedAt, v.UpdatedAt})
+	}
+	return s.copy(ctx, "driver_vehicles", []string{"driver_id", "vehicle_type", "body_type", "make", "model", "color", "plate", "year", "is_active", "created_at", "updated_at"}, rows)
+}
+
+// coordinates — точки посадки и назначения. Они исторические (is_current = false)
+// и не заменяют текущие координаты пассажиров.
+func (s *store) coordinates(ctx context.Context, d dataset) (int64, error) {
+	rows := make([][]any, 0, 2*len(d.Rides))
+	for _, r := range d.Rides {
+		for _, c := range []struct {
+			id  any
+			loc models.Location
+		}{{r.PickupCoordID, r.Pickup}, {r.DestinationCoordID, r.Destination}} {
+			rows = append(rows, []any{c.id, r.PassengerID, string(types.Passenger), c.loc.Address, c.loc.Latitude, c.loc.Longitude, false, r.RequestedAt, r.RequestedAt})
+		}
+	}
+	return s.copy(ctx, "coordinates", []string{"id", "entity_id", "entity_type", "address", "latitude", "longitude", "is_current", "created_at", "updated_at"}, rows)
+}
+
+func (s *store) rides(ctx context.Context, d dataset) (int64, error) {
+	rows := make([][]any, 0, len(d.Rides))
+	for _, r := range d.Rides {
+		var (
+			driverID              any
+			finalFare, distance   any
+			co2                   any
+			updatedAt             = r.RequestedAt
+			servedType            any
+			cancelledAt, finished *time.Time
+		)
+		if r.Driver != nil {
+			driverID = r.Driver.ID
+			servedType = string(r.Class)
+		}
+		if r.Status == types.StatusCompleted {
+			finalFare, distance, co2 = r.Fare.Total, r.ActualKm, r.CO2Grams
+			finished = r.CompletedAt
+		} else {
+			cancelledAt = r.CancelledAt
+			finished = r.CancelledAt
+		}
+		if finished != nil {
+			updatedAt = *finished
+		}
+
+		rows = append(rows, []any{
+			r.ID, r.RequestedAt, updatedAt, r.Number, r.PassengerID, driverID, string(r.Class), servedType, string(r.Status),
+			r.RequestedAt, r.MatchedAt, r.ArrivedAt, r.StartedAt, r.CompletedAt, cancelledAt, r.CancellationReason,
+			r.EstimatedFare, finalFare, r.PickupCoordID, r.DestinationCoordID, distance, co2, string(r.Payment), r.Surge,
+		})
+	}
+	return s.copy(ctx, "rides", []string{
+		"id", "created_at", "updated_at", "ride_number", "passenger_id", "driver_id", "vehicle_type", "served_vehicle_type", "status",
+		"requested_at", "matched_at", "arrived_at", "started_at", "completed_at", "cancelled_at", "cancellation_reason",
+		"estimated_fare", "final_fare", "pickup_coordinate_id", "destination_coordinate_id", "distance_km", "co2_grams", "payment_method", "surge_multiplier",
+	}, rows)
+}
+
+func (s *store) events(ctx context.Context, d dataset) (int64, error) {
+	var rows [][]any
+	for _, r := range d.Rides {
+		for _, e := range r.Events {
+			rows = append(rows, []any{r.ID, string(e.Type), e.Data, e.At})
+		}
+	}
+	return s.copy(ctx, "ride_events", []string{"ride_id", "event_type", "event_data", "created_at"}, rows)
+}
+
+func (s *store) offers(ctx context.Context, d dataset) (int64, error) {
+	var rows [][]any
+	for _, r := range d.Rides {
+		for _, o := range r.Offers {
+			rows = append(rows, []any{o.ID, o.DriverID, r.ID, string(o.Outcome), o.At})
+		}
+	}
+	return s.copy(ctx, "driver_offers", []string{"offer_id", "driver_id", "ride_id", "outcome", "created_at"}, rows)
+}
+
+func (s *store) locations(ctx context.Context, d dataset) (int64, error) {
+	var rows [][]any
+	for _, r := range d.Rides {
+		for _, p := range r.Pings {
+			rows = append(rows, []any{r.Driver.ID, p.Location.Latitude, p.Location.Longitude, 5.0, p.SpeedKmh, p.Heading, p.At, r.ID})
+		}
+	}
+	return s.copy(ctx, "location_history", []string{"driver_id", "latitude", "longitude", "accuracy_meters", "speed_kmh", "heading_degrees", "recorded_at", "ride_id"}, rows)
+}
+
+func (s *store) ratings(ctx context.Context, d dataset) (int64, error) {
+	var rows [][]any
+	for _, r := range d.Rides {
+		if r.Rating == nil {
+			continue
+		}
+		rows = append(rows, []any{r.ID, r.Driver.ID, r.PassengerID, int16(r.Rating.Score), r.Rating.Comment, r.Rating.At})
+	}
+	return s.copy(ctx, "ride_ratings", []string{"ride_id", "driver_id", "passenger_id", "score", "comment", "created_at"}, rows)
+}
+
+// receipts — чеки завершенных поездок. Номера счетов не выдаются: последовательность
+// invoice_sequences ведет только ride-service, исторические чеки остаются без номера.
+func (s *store) receipts(ctx context.Context, d dataset) (int64, error) {
+	var rows [][]any
+	for _, r := range d.Rides {
+		if r.Status != types.StatusCompleted {
+			continue
+		}
+		legalEntity := s.cities[r.City].LegalEntity
+		if legalEntity == "" {
+			legalEntity = s.defaultLegalEntity
+		}
+		f := r.Fare
+		rows = append(rows, []any{
+			r.ID, r.PassengerID, r.Driver.ID, string(r.Class), string(r.Payment), r.DistanceKm, r.DurationMin, r.Surge,
+			f.BaseFare, f.DistanceFare, f.TimeFare, f.Surge, f.Adjustment, f.Discount, f.Tax, f.Total, f.DriverEarnings, f.PlatformCommission,
+			*r.CompletedAt, legalEntity,
+		})
+	}
+	return s.copy(ctx, "ride_receipts", []string{
+		"ride_id", "passenger_id", "driver_id", "vehicle_type", "payment_method", "distance_km", "duration_min", "surge_multiplier",
+		"base_fare", "distance_fare", "time_fare", "surge", "adjustment", "discount", "tax", "total", "driver_earnings", "platform_commission",
+		"created_at", "legal_entity",
+	}, rows)
+}
+
+// aggregate пересчитывает рейтинг и итоги водителей и строит смены по дням работы:
+// смена начинается за полчаса до первого заказа дня и заканчивается через 20 минут после последнего
+func (s *store) aggregate(ctx context.Context, prefix string) error {
+	const driversQ = `
+		WITH seeded AS (
+			SELECT id FROM drivers WHERE license_number LIKE upper($1) || '-DL-%'
+		), stats AS (
+			SELECT r.driver_id,
+				count(*) FILTER (WHERE r.status = 'COMPLETED') AS rides,
+				coalesce(sum(rc.driver_earnings), 0) AS earnings
+			FROM rides r
+			LEFT JOIN ride_receipts rc ON rc.ride_id = r.id
+			WHERE r.driver_id IN (SELECT id FROM seeded)
+			GROUP BY r.driver_id
+		), scores AS (
+			SELECT driver_id, avg(score) AS rating, count(*) AS ratings
+			FROM ride_ratings
+			WHERE driver_id IN (SELECT id FROM seeded)
+			GROUP BY driver_id
+		)
+		UPDATE drivers d
+		SET total_rides = coalesce(st.rides, 0),
+			total_earnings = coalesce(st.earnings, 0),
+			rating = coalesce(round(sc.rating, 2), 5.0),
+			ratings_count = coalesce(sc.ratings, 0)
+		FROM seeded
+		LEFT JOIN stats st ON st.driver_id = seeded.id
+		LEFT JOIN scores sc ON sc.driver_id = seeded.id
+		WHERE d.id = seeded.id`
+
+	if _, err := s.tx.Exec(ctx, driversQ, prefix); err != nil {
+		return fmt.Errorf("update driver totals: %w", err)
+	}
+
+	const sessionsQ = `
+		INSERT INTO driver_sessions (driver_id, started_at, ended_at, total_rides, total_earnings)
+		SELECT r.driver_id,
+			min(r.matched_at) - interval '30 minutes',
+			max(coalesce(r.completed_at, r.cancelled_at)) + interval '20 minutes',
+			count(*) FILTER (WHERE r.status = 'COMPLETED'),
+			coalesce(sum(rc.driver_earnings), 0)
+		FROM rides r
+		JOIN drivers d ON d.id = r.driver_id
+		LEFT JOIN ride_receipts rc ON rc.ride_id = r.id
+		WHERE d.license_number LIKE upper($1) || '-DL-%'
+		GROUP BY r.driver_id, (r.matched_at AT TIME ZONE 'UTC')::date`
+
+	tag, err := s.tx.Exec(ctx, sessionsQ, prefix)
+	if err != nil {
+		return fmt.Errorf("insert driver sessions: %w", err)
+	}
+	log.Printf("seed: %-16s %d rows", "driver_sessions", tag.RowsAffected())
+	return nil
+}
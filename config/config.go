@@ -1,35 +1,132 @@
 package config
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/pkg/configparser"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	"github.com/Temutjin2k/ride-hail-system/pkg/secrets"
 )
 
 // Flags
 var (
-	modeFlag = flag.String("mode", "", "application mode")
+	modeFlag            = flag.String("mode", "", "application mode")
+	skipSchemaCheckFlag = flag.Bool("skip-schema-check", false, "start without checking the database schema against migrations")
 )
 
 // Errors
 var (
-	ErrModeNotProvided = errors.New("mode flag not provided")
+	ErrModeNotProvided    = errors.New("mode flag not provided")
+	ErrUnknownBroker      = errors.New("unknown broker backend")
+	ErrInvalidBroker      = errors.New("invalid broker config")
+	ErrInvalidServiceArea = errors.New("invalid service area")
+	ErrInvalidSurge       = errors.New("invalid surge config")
+	ErrInvalidWarehouse   = errors.New("invalid warehouse export config")
+	ErrInvalidPricing     = errors.New("invalid pricing config")
+	ErrInvalidWebSocket   = errors.New("invalid websocket config")
+	ErrInvalidDispatch    = errors.New("invalid dispatch config")
+	ErrInvalidSupply      = errors.New("invalid supply monitor config")
+	ErrInvalidPayout      = errors.New("invalid payout config")
+	ErrInvalidTracing     = errors.New("invalid tracing config")
+	ErrInvalidSecrets     = errors.New("invalid secrets config")
+	ErrInvalidHealth      = errors.New("invalid health config")
+	ErrInvalidRide        = errors.New("invalid ride config")
+)
+
+// Broker backends
+const (
+	BrokerRabbitMQ = "rabbitmq"
+	BrokerPostgres = "postgres"
+	BrokerKafka    = "kafka" // только для координат водителей
 )
 
 // Config contains all configuration variables of the application
 type (
 	Config struct {
 		Mode types.ServiceMode
+		// SkipSchemaCheck отключает сверку схемы базы с миграциями при старте
+		SkipSchemaCheck bool
 
 		Database          DatabaseConfig
 		RabbitMQ          RabbitMQConfig
+		Broker            BrokerConfig
+		Kafka             KafkaConfig
 		ExternalAPIConfig ExternalAPIConfig
 		Services          ServicesConfig
 		Auth              Auth
+		Ride              RideConfig
+		Pricing           PricingConfig
+		WebSocket         WebSocketConfig
+		Driver            DriverConfig
+		Dispatch          DispatchConfig
+		Payout            PayoutConfig
+		Positioning       PositioningConfig
+		Fraud             FraudConfig
+		Ops               OpsConfig
+		Incident          IncidentConfig
+		Export            ExportConfig
+		Warehouse         WarehouseConfig
+		Alert             AlertConfig
+		Push              PushConfig
+		Supply            SupplyConfig
+		Cache             CacheConfig
+		Location          LocationConfig
+		ServiceArea       ServiceAreaConfig
+		Carbon            CarbonConfig
+		Observability     ObservabilityConfig
+		Health            HealthConfig
+		Mock              MockConfig
+		PII               PIIConfig
+		ServiceAuth       ServiceAuthConfig
+		Secrets           SecretsConfig
+
+		// SecretStore подставил секреты по ссылкам ${provider:key} и следит за их ротацией
+		SecretStore *secrets.Resolver
+	}
+
+	// SecretsConfig — хранилища, на которые ссылаются значения конфига вида ${provider:key}.
+	// Сами эти настройки могут ссылаться только на env и file.
+	SecretsConfig struct {
+		FileDir         string        `env:"SECRETS_FILE_DIR" default:"/run/secrets"`
+		VaultAddr       string        `env:"SECRETS_VAULT_ADDR"` // пусто — провайдер vault не подключен
+		VaultToken      string        `env:"SECRETS_VAULT_TOKEN"`
+		VaultMount      string        `env:"SECRETS_VAULT_MOUNT" default:"secret"`
+		AWSRegion       string        `env:"SECRETS_AWS_REGION"` // пусто — провайдер aws не подключен
+		AWSAccessKey    string        `env:"SECRETS_AWS_ACCESS_KEY"`
+		AWSSecretKey    string        `env:"SECRETS_AWS_SECRET_KEY"`
+		AWSSessionToken string        `env:"SECRETS_AWS_SESSION_TOKEN"`
+		AWSEndpoint     string        `env:"SECRETS_AWS_ENDPOINT"`
+		ActiveKey       string        `env:"SECRETS_ACTIVE_KEY"` // ключи значений ${enc:...}, формат как у PII_KEYS
+		Keys            string        `env:"SECRETS_KEYS"`
+		Timeout         time.Duration `env:"SECRETS_TIMEOUT" default:"5s"`
+		// RefreshInterval — как часто перечитывать секреты с хуками ротации, 0 — только при старте
+		RefreshInterval time.Duration `env:"SECRETS_REFRESH_INTERVAL" default:"5m"`
+	}
+
+	// PIIConfig — ключи шифрования персональных данных (телефон, адреса).
+	// Keys — список "id:base64key" через запятую, старые ключи оставляются для чтения до ротации.
+	PIIConfig struct {
+		ActiveKey string `env:"PII_ACTIVE_KEY"`
+		Keys      string `env:"PII_KEYS"`
+	}
+
+	// ServiceAuthConfig — ключи подписи внутренних вызовов и сообщений очередей между сервисами.
+	// PrivateKeys — закрытые ключи Ed25519 этого сервиса "id:base64 seed", PublicKeys — открытые ключи
+	// всех сервисов "service/id:base64 key", одинаковые у всех. Пустые — подписи выключены.
+	ServiceAuthConfig struct {
+		ActiveKey   string        `env:"SERVICE_AUTH_ACTIVE_KEY"`
+		PrivateKeys string        `env:"SERVICE_AUTH_PRIVATE_KEYS"`
+		PublicKeys  string        `env:"SERVICE_AUTH_PUBLIC_KEYS"`
+		TokenTTL    time.Duration `env:"SERVICE_AUTH_TOKEN_TTL" default:"30s"`
+		// RequireSigned — отклонять сообщения очередей без подписи. Выключено на время
+		// раскатки, пока в очередях остаются сообщения от сервисов без ключей.
+		RequireSigned bool `env:"SERVICE_AUTH_REQUIRE_SIGNED" default:"false"`
 	}
 
 	DatabaseConfig struct {
@@ -46,6 +143,10 @@ type (
 		MinConns        int32         `env:"DATABASE_MINCONNS" default:"2"`          // минимум соединений в пуле
 		MaxConnLifetime time.Duration `env:"DATABASE_MAXCONNLIFETIME" default:"30m"` // макс. "время жизни" соединения
 		MaxConnIdleTime time.Duration `env:"DATABASE_MAXCONNIDLETIME" default:"5m"`  // макс. "время простоя" соединения
+
+		// реплика для чтения в режиме инцидента, пусто — реплики нет
+		ReplicaHost string `env:"DATABASE_REPLICA_HOST"`
+		ReplicaPort string `env:"DATABASE_REPLICA_PORT" default:"5432"`
 	}
 
 	ExternalAPIConfig struct {
@@ -57,6 +158,25 @@ type (
 		Port     string `env:"RABBITMQ_PORT" default:"5672"`
 		User     string `env:"RABBITMQ_USER" default:"guest"`
 		Password string `env:"RABBITMQ_PASSWORD" default:"guest"`
+		// DeadLetterTTL — сколько сообщение хранится в очереди dead_messages, если admin-service его не забрал
+		DeadLetterTTL time.Duration `env:"RABBITMQ_DEAD_LETTER_TTL" default:"168h"`
+	}
+
+	// BrokerConfig выбирает брокер сообщений между сервисами.
+	// postgres — очередь в таблице broker_messages с LISTEN/NOTIFY, для установок без RabbitMQ.
+	BrokerConfig struct {
+		Backend           string        `env:"BROKER_BACKEND" default:"rabbitmq"`      // rabbitmq | postgres
+		LocationBackend   string        `env:"BROKER_LOCATION_BACKEND"`                // координаты водителей: rabbitmq | postgres | kafka, пусто — как Backend
+		PollInterval      time.Duration `env:"BROKER_POLL_INTERVAL" default:"2s"`      // postgres: проверка очереди, если NOTIFY потерян
+		VisibilityTimeout time.Duration `env:"BROKER_VISIBILITY_TIMEOUT" default:"5m"` // postgres: через сколько необработанное сообщение выдается снова
+		Prefetch          int           `env:"BROKER_PREFETCH" default:"16"`           // postgres: сколько сообщений очереди обрабатывается одновременно
+	}
+
+	// KafkaConfig — подключение к Kafka для BROKER_LOCATION_BACKEND=kafka
+	KafkaConfig struct {
+		Brokers       string `env:"KAFKA_BROKERS" default:"localhost:9092"`          // адреса через запятую
+		LocationTopic string `env:"KAFKA_LOCATION_TOPIC" default:"driver.locations"` // топик координат, ключ — ID водителя
+		GroupID       string `env:"KAFKA_GROUP_ID" default:"ride-service"`           // consumer group ride-service
 	}
 
 	ServicesConfig struct {
@@ -64,12 +184,278 @@ type (
 		DriverLocationService string `env:"SERVICES_DRIVER_LOCATION_SERVICE" default:"3001"`
 		AdminService          string `env:"SERVICES_ADMIN_SERVICE" default:"3004"`
 		AuthService           string `env:"SERVICES_AUTH_SERVICE" default:"3005"`
+		LocationService       string `env:"SERVICES_LOCATION_SERVICE" default:"3002"`
 	}
 
 	Auth struct {
 		AccessTokenTTL  time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" default:"15m"`
 		RefreshTokenTTL time.Duration `env:"AUTH_REFRESH_TOKEN_TTL" default:"168h"`
 		JWTSecret       string        `env:"AUTH_JWT_SECRET" default:"supersecretkey"`
+
+		// Подтверждение телефона одноразовым кодом из SMS
+		PhoneCodeTTL            time.Duration `env:"AUTH_PHONE_CODE_TTL" default:"5m"`             // срок жизни кода
+		PhoneCodeResendInterval time.Duration `env:"AUTH_PHONE_CODE_RESEND_INTERVAL" default:"1m"` // пауза перед отправкой нового кода
+		PhoneCodeMaxAttempts    int           `env:"AUTH_PHONE_CODE_MAX_ATTEMPTS" default:"5"`     // попыток ввода одного кода
+	}
+
+	// RideConfig — настройки ride-service
+	RideConfig struct {
+		DispatchRetryInterval  time.Duration `env:"RIDE_DISPATCH_RETRY_INTERVAL" default:"5s"`  // как часто relay публикует сообщения outbox, не отправленные после commit
+		DispatchPendingTimeout time.Duration `env:"RIDE_DISPATCH_PENDING_TIMEOUT" default:"5m"` // через сколько отменить поездку, если брокер так и не стал доступен
+
+		PreAuthBuffer float64 `env:"RIDE_PREAUTH_BUFFER" default:"0.2"` // запас предавторизации карты сверх расчетной стоимости, 0.2 — +20%
+
+		InvoiceLegalEntity string `env:"RIDE_INVOICE_LEGAL_ENTITY" default:"DEFAULT"` // юрлицо чеков для городов без своего юрлица, префикс номера счета
+
+		// Уведомление пассажира о скором прибытии водителя, 0 — порог не используется
+		ApproachDistanceKm   float64       `env:"RIDE_APPROACH_DISTANCE_KM" default:"0.5"`   // расстояние до точки посадки
+		ApproachETA          time.Duration `env:"RIDE_APPROACH_ETA" default:"1m"`            // расчетное время до точки посадки
+		ApproachHysteresisKm float64       `env:"RIDE_APPROACH_HYSTERESIS_KM" default:"0.3"` // насколько водитель должен отъехать за порог, чтобы уведомление повторилось
+
+		// Сверка поездок, зависших после падения экземпляра посреди поиска или назначения
+		ReconcileInterval       time.Duration `env:"RIDE_RECONCILE_INTERVAL" default:"1m"`        // как часто искать зависшие поездки, 0 — сверка выключена
+		ReconcileRequestedAfter time.Duration `env:"RIDE_RECONCILE_REQUESTED_AFTER" default:"5m"` // поездка в REQUESTED дольше отменяется: водитель не найден
+		ReconcileMatchedAfter   time.Duration `env:"RIDE_RECONCILE_MATCHED_AFTER" default:"3m"`   // водитель, не выехавший за это время, снимается, поиск перезапускается
+
+		Surge SurgeConfig
+	}
+
+	// SurgeConfig — надбавка за спрос при нехватке свободных водителей в зоне посадки
+	SurgeConfig struct {
+		Enabled       bool          `env:"SURGE_ENABLED" default:"false"`
+		CellPrecision int           `env:"SURGE_CELL_PRECISION" default:"5"` // длина geohash ячейки зоны, 5 — около 5км x 5км
+		Window        time.Duration `env:"SURGE_WINDOW" default:"10m"`       // за какое время считаются запросы в зоне
+		Threshold     float64       `env:"SURGE_THRESHOLD" default:"1"`      // отношение запросов к свободным водителям, с которого включается надбавка
+		Step          float64       `env:"SURGE_STEP" default:"0.25"`        // прирост множителя на единицу отношения выше порога
+	}
+
+	// PricingConfig — налог и комиссия платформы в чеке поездки и заработке водителя.
+	// Одинаковые у ride-service (чеки) и driver-service (заработок в оффере).
+	PricingConfig struct {
+		TaxRate        float64 `env:"PRICING_TAX_RATE" default:"0"`        // ставка налога, включенного в стоимость, 0.12 — 12%
+		CommissionRate float64 `env:"PRICING_COMMISSION_RATE" default:"0"` // комиссия платформы от стоимости без налога, 0.2 — 20%
+	}
+
+	// WebSocketConfig — протоколы WebSocket пассажиров и водителей.
+	// MaxProtocol=1 выключает v2: клиенты, запросившие его, получают v1 до готовности к переходу.
+	WebSocketConfig struct {
+		MaxProtocol int `env:"WS_MAX_PROTOCOL" default:"2"`  // наибольшая версия протокола, которую получают клиенты
+		SendBuffer  int `env:"WS_SEND_BUFFER" default:"256"` // очередь отправки соединения, переполнение отключает медленного клиента
+	}
+
+	// DriverConfig — настройки driver-service
+	DriverConfig struct {
+		TierRecomputeInterval time.Duration `env:"DRIVER_TIER_RECOMPUTE_INTERVAL" default:"1h"` // как часто пересчитывать уровни водителей
+		TierWindow            time.Duration `env:"DRIVER_TIER_WINDOW" default:"720h"`           // окно метрик для расчёта уровня
+
+		RequireLocationSignature bool `env:"DRIVER_REQUIRE_LOCATION_SIGNATURE" default:"false"` // отклонять неподписанные координаты всех водителей
+		RequireVerifiedPhone     bool `env:"DRIVER_REQUIRE_VERIFIED_PHONE" default:"false"`     // не пускать на линию водителей с неподтвержденным телефоном
+
+		RedispatchGrace         time.Duration `env:"DRIVER_REDISPATCH_GRACE" default:"30s"`         // сколько ждать переподключения водителя в пути, прежде чем снять его с поездки
+		RedispatchSweepInterval time.Duration `env:"DRIVER_REDISPATCH_SWEEP_INTERVAL" default:"1s"` // как часто проверять истекшие сроки переподключения
+
+		ArrivalPoints int           `env:"DRIVER_ARRIVAL_POINTS" default:"3"`  // точек подряд в радиусе посадки, чтобы считать водителя прибывшим
+		ArrivalDwell  time.Duration `env:"DRIVER_ARRIVAL_DWELL" default:"10s"` // или столько времени в радиусе, 0 — только по числу точек
+
+		StatsPushInterval time.Duration `env:"DRIVER_STATS_PUSH_INTERVAL" default:"5m"` // как часто отправлять водителям статистику за сегодня, 0 — только при подключении и завершении поездки
+
+		ClassFallbackAfterTicks int `env:"DRIVER_CLASS_FALLBACK_AFTER_TICKS" default:"0"` // попыток поиска до оффера смежным классам (XL для ECONOMY), 0 — выключено
+
+		DrainTimeout time.Duration `env:"DRIVER_DRAIN_TIMEOUT" default:"20s"` // сколько при остановке ждать начатых поисков водителя, прежде чем вернуть их в очередь
+	}
+
+	// DispatchConfig — как driver-service ищет водителя для поездки.
+	// Каждая попытка поиска расширяет радиус на RadiusStepKm, пока он не достигнет MaxRadiusKm.
+	DispatchConfig struct {
+		RadiusKm      float64       `env:"DISPATCH_RADIUS_KM" default:"5"`       // радиус первой попытки поиска
+		RadiusStepKm  float64       `env:"DISPATCH_RADIUS_STEP_KM" default:"0"`  // расширение радиуса с каждой следующей попыткой, 0 — радиус не меняется
+		MaxRadiusKm   float64       `env:"DISPATCH_MAX_RADIUS_KM" default:"5"`   // предел расширения радиуса
+		OfferTimeout  time.Duration `env:"DISPATCH_OFFER_TIMEOUT" default:"30s"` // сколько водитель может отвечать на оффер
+		RetryInterval time.Duration `env:"DISPATCH_RETRY_INTERVAL" default:"5s"` // пауза между попытками поиска
+		SearchTimeout time.Duration `env:"DISPATCH_SEARCH_TIMEOUT" default:"2m"` // общий таймаут поиска водителя
+
+		Mode           string `env:"DISPATCH_MODE" default:"sequential"`   // sequential — водителям по очереди, parallel — нескольким сразу
+		ParallelOffers int    `env:"DISPATCH_PARALLEL_OFFERS" default:"3"` // в режиме parallel: скольким ближайшим водителям оффер уходит одновременно
+
+		DeclineCooldown time.Duration `env:"DISPATCH_DECLINE_COOLDOWN" default:"2m"` // сколько водитель, отклонивший или пропустивший оффер, не получает оффер той же поездки, 0 — получает на следующей попытке
+
+		GeoIndex             bool          `env:"DISPATCH_GEO_INDEX" default:"false"`             // искать водителей рядом в индексе координат в памяти вместо запроса PostGIS
+		GeoIndexSyncInterval time.Duration `env:"DISPATCH_GEO_INDEX_SYNC_INTERVAL" default:"15s"` // как часто индекс перестраивается из Postgres
+	}
+
+	// PayoutConfig — мгновенный вывод заработка водителя через провайдера выплат.
+	// Комиссия FeeFixed + FeePercent% удерживается из суммы вывода.
+	PayoutConfig struct {
+		FeeFixed   float64 `env:"PAYOUT_FEE_FIXED" default:"50"`  // фиксированная часть комиссии
+		FeePercent float64 `env:"PAYOUT_FEE_PERCENT" default:"1"` // комиссия от суммы вывода, %
+
+		MinAmount  float64 `env:"PAYOUT_MIN_AMOUNT" default:"500"`     // минимальная сумма вывода
+		MaxAmount  float64 `env:"PAYOUT_MAX_AMOUNT" default:"100000"`  // максимальная сумма вывода
+		DailyLimit float64 `env:"PAYOUT_DAILY_LIMIT" default:"200000"` // сумма выводов водителя за 24 часа
+		DailyCount int     `env:"PAYOUT_DAILY_COUNT" default:"3"`      // число выводов водителя за 24 часа
+
+		MinAccountAge     time.Duration `env:"PAYOUT_MIN_ACCOUNT_AGE" default:"168h"`   // сколько водитель должен быть зарегистрирован для вывода
+		MinCompletedRides int           `env:"PAYOUT_MIN_COMPLETED_RIDES" default:"10"` // сколько поездок водитель должен завершить для вывода
+
+		WebhookSecret     string        `env:"PAYOUT_WEBHOOK_SECRET"`                  // секрет подписи вебхуков провайдера, пусто — вебхуки отклоняются
+		ReconcileInterval time.Duration `env:"PAYOUT_RECONCILE_INTERVAL" default:"1m"` // как часто сверять зависшие выводы с провайдером, 0 — выключено
+		ReconcileAfter    time.Duration `env:"PAYOUT_RECONCILE_AFTER" default:"10m"`   // через сколько без ответа или вебхука вывод сверяется
+	}
+
+	// PositioningConfig — рекомендации свободным водителям, куда переместиться по прогнозу спроса
+	PositioningConfig struct {
+		Interval       time.Duration `env:"POSITIONING_INTERVAL" default:"5m"`          // как часто отправлять рекомендации, 0 — выключено
+		DemandWindow   time.Duration `env:"POSITIONING_DEMAND_WINDOW" default:"30m"`    // окно запросов поездок для прогноза спроса
+		MinRequests    int           `env:"POSITIONING_MIN_REQUESTS" default:"3"`       // минимум запросов в районе за окно
+		MaxDistanceKm  float64       `env:"POSITIONING_MAX_DISTANCE_KM" default:"5"`    // максимальное расстояние до района
+		MaxWait        time.Duration `env:"POSITIONING_MAX_WAIT" default:"10m"`         // рекомендовать районы с ожиданием не дольше
+		EvaluateAfter  time.Duration `env:"POSITIONING_EVALUATE_AFTER" default:"15m"`   // через сколько оценивать, доехал ли водитель и получил ли поездку
+		ArrivalRadiusM float64       `env:"POSITIONING_ARRIVAL_RADIUS_M" default:"800"` // радиус, в котором водитель считается доехавшим
+	}
+
+	// FraudConfig — расчет графа подозрительных связей в admin-service
+	FraudConfig struct {
+		GraphInterval    time.Duration `env:"FRAUD_GRAPH_INTERVAL" default:"1h"`     // как часто пересчитывать граф, 0 — выключено
+		Window           time.Duration `env:"FRAUD_WINDOW" default:"720h"`           // окно поездок для анализа
+		RepeatedPairMin  int           `env:"FRAUD_REPEATED_PAIR_MIN" default:"5"`   // поездок одной пары водитель-пассажир
+		CoLocatedRadiusM float64       `env:"FRAUD_COLOCATED_RADIUS_M" default:"30"` // водитель в точке посадки в момент заказа
+		CoLocatedMin     int           `env:"FRAUD_COLOCATED_MIN" default:"2"`       // таких поездок одной пары
+	}
+
+	// OpsConfig — выполнение операционных действий (/admin/ops/actions) экземплярами ride и driver сервисов
+	OpsConfig struct {
+		PollInterval time.Duration `env:"OPS_POLL_INTERVAL" default:"5s"` // как часто проверять назначенные экземпляру действия, 0 — выключено
+		InstanceID   string        `env:"INSTANCE_ID"`                    // имя экземпляра в параметре instance, пусто — hostname
+	}
+
+	// IncidentConfig — режим только для чтения при недоступности основной базы.
+	// Чтения обслуживаются репликой и кэшами, запросы на запись получают 503.
+	IncidentConfig struct {
+		Enabled           bool          `env:"INCIDENT_ENABLED" default:"true"`
+		CheckInterval     time.Duration `env:"INCIDENT_CHECK_INTERVAL" default:"5s"`    // как часто проверять основную базу
+		CheckTimeout      time.Duration `env:"INCIDENT_CHECK_TIMEOUT" default:"2s"`     // таймаут одной проверки
+		FailureThreshold  int           `env:"INCIDENT_FAILURE_THRESHOLD" default:"3"`  // неудачных проверок подряд до включения режима
+		RecoveryThreshold int           `env:"INCIDENT_RECOVERY_THRESHOLD" default:"2"` // успешных проверок подряд до выключения
+	}
+
+	// ExportConfig — выгрузка снимка состояния системы (/admin/export/state)
+	ExportConfig struct {
+		SyncMaxItems int           `env:"EXPORT_SYNC_MAX_ITEMS" default:"2000"` // больше строк — снимок собирается в фоне
+		MaxItems     int           `env:"EXPORT_MAX_ITEMS" default:"50000"`     // максимум строк в снимке
+		Timeout      time.Duration `env:"EXPORT_TIMEOUT" default:"2m"`          // таймаут фоновой сборки
+	}
+
+	// WarehouseConfig — выгрузка обезличенных поездок и событий в хранилище аналитики из admin-service.
+	// Target выбирает приемник и регион хранения данных, пусто — выгрузка выключена.
+	WarehouseConfig struct {
+		Target         string        `env:"WAREHOUSE_TARGET"`                        // s3 | bigquery
+		Interval       time.Duration `env:"WAREHOUSE_INTERVAL" default:"1h"`         // как часто выгружать новые строки
+		SettleDelay    time.Duration `env:"WAREHOUSE_SETTLE_DELAY" default:"5m"`     // строки моложе не выгружаются
+		BatchSize      int           `env:"WAREHOUSE_BATCH_SIZE" default:"1000"`     // строк в одном объекте или вставке
+		PseudonymKey   string        `env:"WAREHOUSE_PSEUDONYM_KEY"`                 // ключ HMAC псевдонимов ID, смена ключа разрывает связь с выгруженным
+		RequestTimeout time.Duration `env:"WAREHOUSE_REQUEST_TIMEOUT" default:"30s"` // таймаут запроса к хранилищу
+
+		S3       WarehouseS3Config
+		BigQuery WarehouseBigQueryConfig
+	}
+
+	// AlertConfig — куда отправлять алерты дежурным, пустой URL — только в лог
+	AlertConfig struct {
+		WebhookURL    string        `env:"ALERT_WEBHOOK_URL"`
+		WebhookSecret string        `env:"ALERT_WEBHOOK_SECRET"`        // секрет подписи X-Webhook-Signature
+		Timeout       time.Duration `env:"ALERT_TIMEOUT" default:"10s"` // таймаут запроса к вебхуку
+	}
+
+	// PushConfig — push уведомления пассажирам из ride-service через FCM.
+	// Пустой файл ключа — push провайдер не подключен (кроме mock режима).
+	PushConfig struct {
+		FCMCredentialsFile string        `env:"PUSH_FCM_CREDENTIALS_FILE"`  // JSON ключ сервисного аккаунта Firebase проекта
+		Timeout            time.Duration `env:"PUSH_TIMEOUT" default:"10s"` // таймаут запроса к FCM
+	}
+
+	// SupplyConfig — алерт admin-service о резком падении числа водителей онлайн по городу и классу
+	SupplyConfig struct {
+		Interval    time.Duration `env:"SUPPLY_MONITOR_INTERVAL" default:"1m"` // как часто считать водителей онлайн, 0 — выключено
+		Window      time.Duration `env:"SUPPLY_DROP_WINDOW" default:"10m"`     // окно, за которое считается падение
+		DropPercent float64       `env:"SUPPLY_DROP_PERCENT" default:"30"`     // падение от максимума за окно, %
+		MinDrivers  int           `env:"SUPPLY_MIN_DRIVERS" default:"10"`      // меньший максимум не проверяется
+	}
+
+	WarehouseS3Config struct {
+		Endpoint     string `env:"WAREHOUSE_S3_ENDPOINT"` // пусто — AWS, иначе S3 совместимое хранилище (path-style)
+		Region       string `env:"WAREHOUSE_S3_REGION"`
+		Bucket       string `env:"WAREHOUSE_S3_BUCKET"`
+		Prefix       string `env:"WAREHOUSE_S3_PREFIX" default:"ride-hail"`
+		AccessKey    string `env:"WAREHOUSE_S3_ACCESS_KEY"`
+		SecretKey    string `env:"WAREHOUSE_S3_SECRET_KEY"`
+		SessionToken string `env:"WAREHOUSE_S3_SESSION_TOKEN"`
+	}
+
+	WarehouseBigQueryConfig struct {
+		Project         string `env:"WAREHOUSE_BQ_PROJECT"`
+		Dataset         string `env:"WAREHOUSE_BQ_DATASET"`
+		CredentialsFile string `env:"WAREHOUSE_BQ_CREDENTIALS_FILE"` // JSON ключ сервисного аккаунта
+	}
+
+	// CacheConfig — локальные кэши экземпляров. Изменения администратора сбрасывают их
+	// событием в Postgres NOTIFY, TTL ограничивает устаревание, если событие потерялось.
+	CacheConfig struct {
+		CityTTL     time.Duration `env:"CACHE_CITY_TTL" default:"1m"`      // правила городов в ride-service
+		FlatRateTTL time.Duration `env:"CACHE_FLAT_RATE_TTL" default:"1m"` // фиксированные тарифы в ride-service
+		BlackoutTTL time.Duration `env:"CACHE_BLACKOUT_TTL" default:"1m"`  // окна приостановки подбора в ride-service
+		DriverTTL   time.Duration `env:"CACHE_DRIVER_TTL" default:"5s"`    // профили водителей в driver-service
+	}
+
+	// LocationConfig — выделенный location-service. Если ServiceURL пуст, driver-service
+	// сам записывает координаты, иначе пересылает их во внутренний API location-service.
+	LocationConfig struct {
+		ServiceURL     string        `env:"LOCATION_SERVICE_URL"`
+		InternalToken  string        `env:"LOCATION_INTERNAL_TOKEN"`               // Deprecated: общий секрет внутреннего API, используйте SERVICE_AUTH_PRIVATE_KEYS
+		RequestTimeout time.Duration `env:"LOCATION_REQUEST_TIMEOUT" default:"3s"` // таймаут запроса driver-service к location-service
+		// DuplicateWindow — точка с теми же координатами, пришедшая в пределах окна
+		// после принятой (например, одновременно по HTTP и WebSocket), отбрасывается
+		DuplicateWindow time.Duration `env:"LOCATION_DUPLICATE_WINDOW" default:"2s"`
+		// RepairInterval — как часто admin-service исправляет сущности с несколькими текущими координатами, 0 — выключено
+		RepairInterval time.Duration `env:"LOCATION_REPAIR_INTERVAL" default:"10m"`
+	}
+
+	// ServiceAreaConfig — границы зоны обслуживания. Координаты вне прямоугольника
+	// отклоняются при приеме (HTTP, WebSocket, брокер), по умолчанию — весь земной шар.
+	ServiceAreaConfig struct {
+		MinLatitude  float64 `env:"SERVICE_AREA_MIN_LAT" default:"-90"`
+		MaxLatitude  float64 `env:"SERVICE_AREA_MAX_LAT" default:"90"`
+		MinLongitude float64 `env:"SERVICE_AREA_MIN_LNG" default:"-180"`
+		MaxLongitude float64 `env:"SERVICE_AREA_MAX_LNG" default:"180"`
+	}
+
+	// CarbonConfig — коэффициенты выбросов CO2 по классам автомобилей, граммы на км
+	CarbonConfig struct {
+		EconomyGramsPerKm float64 `env:"CARBON_ECONOMY_G_PER_KM" default:"120"`
+		PremiumGramsPerKm float64 `env:"CARBON_PREMIUM_G_PER_KM" default:"170"`
+		XLGramsPerKm      float64 `env:"CARBON_XL_G_PER_KM" default:"210"`
+	}
+
+	// MockConfig включает детерминированные заглушки внешних сервисов (geocoder, payments, push, sms)
+	MockConfig struct {
+		Enabled bool          `env:"MOCK_ENABLED" default:"false"`
+		Latency time.Duration `env:"MOCK_LATENCY" default:"0s"` // искусственная задержка каждого вызова
+	}
+
+	ObservabilityConfig struct {
+		PrometheusURL string `env:"OBSERVABILITY_PROMETHEUS_URL" default:"http://prometheus:9090"`
+
+		// Трассировка: спаны HTTP, RabbitMQ и запросов к базе выгружаются в коллектор OpenTelemetry по OTLP/HTTP
+		TracingEnabled     bool          `env:"OBSERVABILITY_TRACING_ENABLED" default:"false"`
+		OTLPEndpoint       string        `env:"OBSERVABILITY_OTLP_ENDPOINT" default:"http://otel-collector:4318/v1/traces"`
+		TraceSampleRatio   float64       `env:"OBSERVABILITY_TRACE_SAMPLE_RATIO" default:"1"` // доля записываемых новых трасс
+		TraceBatchSize     int           `env:"OBSERVABILITY_TRACE_BATCH_SIZE" default:"512"`
+		TraceFlushInterval time.Duration `env:"OBSERVABILITY_TRACE_FLUSH_INTERVAL" default:"5s"`
+	}
+
+	// HealthConfig — пробы /health/live и /health/ready
+	HealthConfig struct {
+		CheckTimeout  time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s"`  // таймаут проверки одной зависимости
+		LivenessGrace time.Duration `env:"HEALTH_LIVENESS_GRACE" default:"1m"` // сколько критичная зависимость может быть недоступна до провала liveness
 	}
 )
 
@@ -84,6 +470,16 @@ func (c DatabaseConfig) GetDSN() string {
 	)
 }
 
+// Replica возвращает настройки подключения к реплике. false — реплика не задана.
+func (c DatabaseConfig) Replica() (DatabaseConfig, bool) {
+	if c.ReplicaHost == "" {
+		return c, false
+	}
+	c.Host = c.ReplicaHost
+	c.Port = c.ReplicaPort
+	return c, true
+}
+
 func (c RabbitMQConfig) GetDSN() string {
 	return fmt.Sprintf("amqp://%s:%s@%s:%s/",
 		c.User,
@@ -106,6 +502,58 @@ func NewConfig(filepath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse flags: %w", err)
 	}
 
+	if err := resolveSecrets(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Broker.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ServiceArea.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Ride.Validate(cfg.Dispatch.SearchTimeout); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Ride.Surge.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Pricing.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.WebSocket.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Warehouse.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Dispatch.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Supply.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Payout.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Observability.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Health.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -115,6 +563,291 @@ func parseFlags(cfg *Config) error {
 	}
 
 	cfg.Mode = types.ServiceMode(*modeFlag)
+	cfg.SkipSchemaCheck = skipSchemaCheckFlag != nil && *skipSchemaCheckFlag
+
+	return nil
+}
+
+func (c BrokerConfig) Validate() error {
+	switch c.Backend {
+	case BrokerRabbitMQ, BrokerPostgres:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownBroker, c.Backend)
+	}
+
+	switch c.LocationBackend {
+	case "", BrokerRabbitMQ, BrokerPostgres, BrokerKafka:
+	default:
+		return fmt.Errorf("%w for locations: %q", ErrUnknownBroker, c.LocationBackend)
+	}
+
+	if c.Prefetch <= 0 {
+		return fmt.Errorf("%w: prefetch must be positive, got %d", ErrInvalidBroker, c.Prefetch)
+	}
+
+	return nil
+}
+
+// Приемники выгрузки в хранилище аналитики
+const (
+	WarehouseS3       = "s3"
+	WarehouseBigQuery = "bigquery"
+)
+
+func (c WarehouseConfig) Validate() error {
+	switch c.Target {
+	case "":
+		return nil
+	case WarehouseS3:
+		if c.S3.Bucket == "" || c.S3.Region == "" || c.S3.AccessKey == "" || c.S3.SecretKey == "" {
+			return fmt.Errorf("%w: s3 needs bucket, region, access key and secret key", ErrInvalidWarehouse)
+		}
+	case WarehouseBigQuery:
+		if c.BigQuery.Project == "" || c.BigQuery.Dataset == "" || c.BigQuery.CredentialsFile == "" {
+			return fmt.Errorf("%w: bigquery needs project, dataset and credentials file", ErrInvalidWarehouse)
+		}
+	default:
+		return fmt.Errorf("%w: unknown target %q", ErrInvalidWarehouse, c.Target)
+	}
+
+	if len(c.PseudonymKey) < 16 {
+		return fmt.Errorf("%w: pseudonym key must be at least 16 characters", ErrInvalidWarehouse)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("%w: batch size must be positive", ErrInvalidWarehouse)
+	}
+	return nil
+}
+
+// Location возвращает брокер для координат водителей
+func (c BrokerConfig) Location() string {
+	if c.LocationBackend == "" {
+		return c.Backend
+	}
+	return c.LocationBackend
+}
+
+// BrokerList возвращает адреса брокеров Kafka
+func (c KafkaConfig) BrokerList() []string {
+	var brokers []string
+	for b := range strings.SplitSeq(c.Brokers, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	return brokers
+}
+
+// Validate проверяет, что границы зоны обслуживания образуют непустой прямоугольник
+func (c ServiceAreaConfig) Validate() error {
+	if c.MinLatitude < -90 || c.MaxLatitude > 90 || c.MinLatitude >= c.MaxLatitude {
+		return fmt.Errorf("%w: latitude %v..%v", ErrInvalidServiceArea, c.MinLatitude, c.MaxLatitude)
+	}
+	if c.MinLongitude < -180 || c.MaxLongitude > 180 || c.MinLongitude >= c.MaxLongitude {
+		return fmt.Errorf("%w: longitude %v..%v", ErrInvalidServiceArea, c.MinLongitude, c.MaxLongitude)
+	}
+	return nil
+}
+
+func (c PricingConfig) Validate() error {
+	if c.TaxRate < 0 || c.TaxRate >= 1 {
+		return fmt.Errorf("%w: tax rate must be in [0, 1)", ErrInvalidPricing)
+	}
+	if c.CommissionRate < 0 || c.CommissionRate >= 1 {
+		return fmt.Errorf("%w: commission rate must be in [0, 1)", ErrInvalidPricing)
+	}
+	return nil
+}
+
+func (c WebSocketConfig) Validate() error {
+	if c.MaxProtocol < 1 || c.MaxProtocol > 2 {
+		return fmt.Errorf("%w: max protocol must be 1 or 2", ErrInvalidWebSocket)
+	}
+	if c.SendBuffer <= 0 {
+		return fmt.Errorf("%w: send buffer must be positive", ErrInvalidWebSocket)
+	}
+	return nil
+}
+
+// Режимы отправки офферов водителям
+const (
+	DispatchSequential = "sequential"
+	DispatchParallel   = "parallel"
+)
+
+func (c DispatchConfig) Validate() error {
+	switch c.Mode {
+	case DispatchSequential, DispatchParallel:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidDispatch, c.Mode)
+	}
+	if c.RadiusKm <= 0 || c.RadiusStepKm < 0 || c.MaxRadiusKm < c.RadiusKm {
+		return fmt.Errorf("%w: radius must be positive, step must not be negative, max radius must not be less than radius", ErrInvalidDispatch)
+	}
+	if c.OfferTimeout <= 0 || c.RetryInterval <= 0 || c.SearchTimeout <= 0 {
+		return fmt.Errorf("%w: offer timeout, retry interval and search timeout must be positive", ErrInvalidDispatch)
+	}
+	if c.Mode == DispatchParallel && c.ParallelOffers < 1 {
+		return fmt.Errorf("%w: parallel offers must be at least 1", ErrInvalidDispatch)
+	}
+	if c.DeclineCooldown < 0 {
+		return fmt.Errorf("%w: decline cooldown must not be negative", ErrInvalidDispatch)
+	}
+	if c.GeoIndex && c.GeoIndexSyncInterval <= 0 {
+		return fmt.Errorf("%w: geo index sync interval must be positive", ErrInvalidDispatch)
+	}
+	return nil
+}
+
+func (c SupplyConfig) Validate() error {
+	if c.Interval <= 0 {
+		return nil
+	}
+	if c.Window < c.Interval {
+		return fmt.Errorf("%w: window must not be shorter than interval", ErrInvalidSupply)
+	}
+	if c.DropPercent <= 0 || c.DropPercent > 100 {
+		return fmt.Errorf("%w: drop percent must be in (0, 100]", ErrInvalidSupply)
+	}
+	if c.MinDrivers < 1 {
+		return fmt.Errorf("%w: min drivers must be at least 1", ErrInvalidSupply)
+	}
+	return nil
+}
+
+// Validate проверяет настройки ride-service. searchTimeout — общий таймаут поиска водителя в driver-service:
+// поездка не считается зависшей, пока поиск и ожидание ответа водителя могут еще идти.
+func (c RideConfig) Validate(searchTimeout time.Duration) error {
+	if c.ReconcileInterval < 0 {
+		return fmt.Errorf("%w: reconcile interval must not be negative", ErrInvalidRide)
+	}
+	if c.ReconcileInterval == 0 {
+		return nil
+	}
+	if c.ReconcileMatchedAfter <= 0 {
+		return fmt.Errorf("%w: reconcile matched threshold must be positive", ErrInvalidRide)
+	}
+	if minimum := searchTimeout + types.DriverResponseTimeout; c.ReconcileRequestedAfter <= minimum {
+		return fmt.Errorf("%w: reconcile requested threshold must exceed the search timeout plus the driver response timeout (%s)", ErrInvalidRide, minimum)
+	}
+	return nil
+}
+
+func (c SurgeConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CellPrecision < 1 || c.CellPrecision > 12 {
+		return fmt.Errorf("%w: cell_precision must be between 1 and 12", ErrInvalidSurge)
+	}
+	if c.Window <= 0 || c.Threshold < 0 || c.Step <= 0 {
+		return fmt.Errorf("%w: window and step must be positive, threshold must not be negative", ErrInvalidSurge)
+	}
+	return nil
+}
 
+func (c PayoutConfig) Validate() error {
+	if c.FeeFixed < 0 || c.FeePercent < 0 || c.FeePercent >= 100 {
+		return fmt.Errorf("%w: fee must not be negative, fee percent must be below 100", ErrInvalidPayout)
+	}
+	if c.MinAmount <= c.FeeFixed || c.MaxAmount < c.MinAmount || c.DailyLimit < c.MaxAmount {
+		return fmt.Errorf("%w: min amount must exceed the fixed fee, max amount must not be less than min, daily limit must not be less than max", ErrInvalidPayout)
+	}
+	if c.DailyCount < 1 {
+		return fmt.Errorf("%w: daily count must be at least 1", ErrInvalidPayout)
+	}
+	if c.MinAccountAge < 0 || c.MinCompletedRides < 0 || c.ReconcileAfter < 0 {
+		return fmt.Errorf("%w: account age, completed rides and reconcile delay must not be negative", ErrInvalidPayout)
+	}
 	return nil
 }
+
+func (c ObservabilityConfig) Validate() error {
+	if !c.TracingEnabled {
+		return nil
+	}
+	if c.OTLPEndpoint == "" {
+		return fmt.Errorf("%w: otlp endpoint is required", ErrInvalidTracing)
+	}
+	if c.TraceSampleRatio < 0 || c.TraceSampleRatio > 1 {
+		return fmt.Errorf("%w: sample ratio must be in [0, 1]", ErrInvalidTracing)
+	}
+	if c.TraceBatchSize < 1 || c.TraceFlushInterval <= 0 {
+		return fmt.Errorf("%w: batch size and flush interval must be positive", ErrInvalidTracing)
+	}
+	return nil
+}
+
+func (c HealthConfig) Validate() error {
+	if c.CheckTimeout <= 0 {
+		return fmt.Errorf("%w: check timeout must be positive", ErrInvalidHealth)
+	}
+	if c.LivenessGrace < 0 {
+		return fmt.Errorf("%w: liveness grace must not be negative", ErrInvalidHealth)
+	}
+	return nil
+}
+
+// resolveSecrets подставляет секреты по ссылкам. Сначала настройки хранилищ (из env и файлов),
+// затем весь конфиг.
+func resolveSecrets(cfg *Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	bootstrap := secrets.NewResolver(map[string]secrets.Provider{
+		"env":  secrets.Env{},
+		"file": secrets.File{Dir: cfg.Secrets.FileDir},
+	})
+	if err := bootstrap.ResolveStruct(ctx, &cfg.Secrets); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecrets, err)
+	}
+
+	providers, err := cfg.Secrets.Providers()
+	if err != nil {
+		return err
+	}
+	cfg.SecretStore = secrets.NewResolver(providers)
+	if err := cfg.SecretStore.ResolveStruct(ctx, cfg); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSecrets, err)
+	}
+	return nil
+}
+
+// Providers возвращает провайдеры секретов по именам в ссылках: env и file есть всегда,
+// vault, aws и enc — если настроены
+func (c SecretsConfig) Providers() (map[string]secrets.Provider, error) {
+	providers := map[string]secrets.Provider{
+		"env":  secrets.Env{},
+		"file": secrets.File{Dir: c.FileDir},
+	}
+
+	if c.VaultAddr != "" {
+		if c.VaultToken == "" {
+			return nil, fmt.Errorf("%w: vault token is required", ErrInvalidSecrets)
+		}
+		providers["vault"] = secrets.NewVault(c.VaultAddr, c.VaultToken, c.VaultMount, c.Timeout)
+	}
+
+	if c.AWSRegion != "" {
+		if c.AWSAccessKey == "" || c.AWSSecretKey == "" {
+			return nil, fmt.Errorf("%w: aws access key and secret key are required", ErrInvalidSecrets)
+		}
+		providers["aws"] = secrets.NewAWS(secrets.AWSConfig{
+			Region:       c.AWSRegion,
+			AccessKey:    c.AWSAccessKey,
+			SecretKey:    c.AWSSecretKey,
+			SessionToken: c.AWSSessionToken,
+			Endpoint:     c.AWSEndpoint,
+		}, c.Timeout)
+	}
+
+	if c.Keys != "" {
+		ring, err := keyring.New(c.ActiveKey, c.Keys)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSecrets, err)
+		}
+		providers["enc"] = secrets.Encrypted{Keyring: ring}
+	}
+
+	return providers, nil
+}
@@ -19,3 +19,8 @@ package docs
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+
+// @securityDefinitions.apikey PartnerAPIKey
+// @in header
+// @name X-API-Key
+// @description API key issued to a fleet partner by POST /partners.
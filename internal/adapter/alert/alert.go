@@ -0,0 +1,46 @@
+// Package alert содержит приемники алертов для дежурных: вебхук системы оповещения
+// и запись в лог, когда вебхук не настроен.
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/webhook"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+)
+
+// Webhook отправляет алерт JSON POST запросом, тело подписывается так же,
+// как вебхуки партнёров (заголовок X-Webhook-Signature)
+type Webhook struct {
+	client *webhook.Client
+	url    string
+	secret string
+}
+
+func NewWebhook(url, secret string, timeout time.Duration) *Webhook {
+	return &Webhook{
+		client: webhook.New(timeout),
+		url:    url,
+		secret: secret,
+	}
+}
+
+func (w *Webhook) Send(ctx context.Context, alert models.Alert) error {
+	return w.client.Send(ctx, w.url, w.secret, alert)
+}
+
+// Log пишет алерт в лог сервиса
+type Log struct {
+	l logger.Logger
+}
+
+func NewLog(l logger.Logger) *Log {
+	return &Log{l: l}
+}
+
+func (s *Log) Send(ctx context.Context, alert models.Alert) error {
+	s.l.Warn(ctx, "alert", "key", alert.Key, "status", alert.Status, "summary", alert.Summary, "labels", alert.Labels)
+	return nil
+}
@@ -3,16 +3,58 @@ package handler
 import (
 	"context"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
 )
 
 type AdminService interface {
 	Overview(ctx context.Context) (*models.OverviewResponse, error)
 	ActiveRides(ctx context.Context, filters models.Filters) (*models.ActiveRidesResponse, error)
+	SearchRides(ctx context.Context, filter models.RideSearchFilter) (*models.RideSearchResponse, error)
+	RideStateAt(ctx context.Context, rideID uuid.UUID, ts time.Time) (*models.RideStateAt, error)
+	RideCommunications(ctx context.Context, rideID uuid.UUID) (*models.RideCommunications, error)
+	SLO(ctx context.Context) (*models.SLOReport, error)
+	Blocklist(ctx context.Context, filter models.BlocklistFilter, filters models.Filters) (*models.BlocklistResponse, error)
+	Cities(ctx context.Context) ([]models.CitySettings, error)
+	UpdateCity(ctx context.Context, city *models.CitySettings) error
+	FlatRates(ctx context.Context) ([]models.FlatRate, error)
+	FlatRateHistory(ctx context.Context, code string) ([]models.FlatRate, error)
+	SetFlatRate(ctx context.Context, rate *models.FlatRate) error
+	EndFlatRate(ctx context.Context, code string) error
+	MatchingBlackouts(ctx context.Context) ([]models.MatchingBlackout, error)
+	CreateMatchingBlackout(ctx context.Context, b *models.MatchingBlackout) error
+	UpdateMatchingBlackout(ctx context.Context, b *models.MatchingBlackout) error
+	EndMatchingBlackout(ctx context.Context, id uuid.UUID) error
+	Anomalies(ctx context.Context, kind types.AnomalyKind) (*models.AnomaliesResponse, error)
+	Remediate(ctx context.Context, kind types.AnomalyKind, entityID uuid.UUID, dryRun bool) (*models.AdminEffects, error)
+	Broadcast(ctx context.Context, b *models.Broadcast) error
+	GetBroadcast(ctx context.Context, id uuid.UUID) (*models.Broadcast, error)
+	SetDriverSimulator(ctx context.Context, driverID uuid.UUID, simulator bool) error
+	SetPriorityBoardingEligible(ctx context.Context, passengerID uuid.UUID, eligible bool) error
+	PositioningEffectiveness(ctx context.Context, days int) (*models.PositioningEffectiveness, error)
+	TelemetryReport(ctx context.Context, days int) (*models.TelemetryReport, error)
+	CoverageReport(ctx context.Context, days, precision, minRequests int) (*models.CoverageReport, error)
+	FraudGraph(ctx context.Context) (*models.FraudGraph, error)
+	DriverChanges(ctx context.Context) ([]models.DriverChangeRequest, error)
+	ApproveDriverChange(ctx context.Context, changeID, adminID uuid.UUID) (*models.DriverChangeRequest, error)
+	RejectDriverChange(ctx context.Context, changeID, adminID uuid.UUID, reason string) (*models.DriverChangeRequest, error)
+	RunOpsAction(ctx context.Context, a *models.OpsAction) error
+	OpsActions(ctx context.Context) ([]models.OpsAction, error)
+	OpsAction(ctx context.Context, id uuid.UUID) (*models.OpsAction, error)
+	ExportState(ctx context.Context, requestedBy uuid.UUID) (*models.StateSnapshot, *models.StateExport, error)
+	StateExport(ctx context.Context, id uuid.UUID) (*models.StateExport, error)
+	FailedMessages(ctx context.Context, f models.FailedMessageFilter) ([]models.FailedMessage, error)
+	FailedMessage(ctx context.Context, id uuid.UUID) (*models.FailedMessage, error)
+	ReplayFailedMessage(ctx context.Context, id uuid.UUID) (*models.FailedMessage, error)
 }
 
 type Admin struct {
@@ -57,10 +99,43 @@ func (h *Admin) GetOverview(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-var activeRidesSortSafeList = []string{"ride_number", "started_at", "estimated_completion", "created_at", "-ride_number", "-started_at", "-estimated_completion", "-created_at"}
+// GetFraudGraph godoc
+// @Summary      Get fraud ring graph
+// @Description  Get the graph of suspicious driver-passenger relationships (shared devices, repeated pairs, drivers already at the pickup point when the ride is requested) as nodes and edges for visualization tools. The graph is computed periodically by the anti-fraud job; before the first run it is empty without computed_at
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} models.FraudGraph "Fraud graph"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/fraud/graph [get]
+func (h *Admin) GetFraudGraph(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx = wrap.WithAction(ctx, "admin_get_fraud_graph")
+
+	graph, err := h.s.FraudGraph(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get fraud graph", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, graph, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// ActiveRidesListing - параметры пагинации и сортировки GET /admin/rides/active
+var ActiveRidesListing = models.ListOptions{
+	DefaultSort:  "created_at",
+	SortSafelist: []string{"ride_number", "started_at", "estimated_completion", "created_at", "-ride_number", "-started_at", "-estimated_completion", "-created_at"},
+}
 
 // GetActiveRides godoc
 // @Summary      Get active rides
+// @Deprecated
 // @Description  Get list of all currently active rides with pagination and filtering
 // @Tags         admin
 // @Produce      json
@@ -79,37 +154,1080 @@ func (h *Admin) GetActiveRides(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ctx = wrap.WithAction(ctx, "admin_get_active_rides")
 
+	filters, ok := models.FiltersFromContext(ctx)
+	if !ok {
+		h.l.Warn(ctx, "pagination filters are missing in context")
+		internalErrorResponse(w, "intenal error")
+		return
+	}
+
+	rides, err := h.s.ActiveRides(ctx, filters)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get active rides", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	h.l.Debug(ctx, "fetched active rides", "total", rides.Metadata.TotalRecords)
+
+	if err := writeJSON(w, http.StatusOK, rides, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// SearchRides godoc
+// @Summary      Search rides
+// @Description  Search rides in any status with composable filters and keyset pagination. Pass next_cursor from the previous page as cursor; a cursor is only valid with the same sort.
+// @Tags         admin
+// @Produce      json
+// @Param        status query string false "Comma-separated ride statuses, e.g. COMPLETED,CANCELLED"
+// @Param        passenger_id query string false "Passenger ID"
+// @Param        driver_id query string false "Driver ID"
+// @Param        min_fare query number false "Minimum fare (final, or estimated for unfinished rides)"
+// @Param        max_fare query number false "Maximum fare"
+// @Param        from query string false "Requested at or after, RFC 3339"
+// @Param        to query string false "Requested before, RFC 3339"
+// @Param        vehicle_class query string false "ECONOMY, PREMIUM or XL"
+// @Param        city query string false "City code, pickup inside the city radius"
+// @Param        sort query string false "created_at, requested_at, fare or ride_number, prefix - for descending" default(-created_at)
+// @Param        limit query int false "Page size" default(20)
+// @Param        cursor query string false "next_cursor from the previous page"
+// @Success      200 {object} models.RideSearchResponse "Rides"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/rides [get]
+func (h *Admin) SearchRides(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_search_rides")
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	filter := models.RideSearchFilter{
+		PassengerID:  readUUID(qs, "passenger_id", v),
+		DriverID:     readUUID(qs, "driver_id", v),
+		MinFare:      readFloat(qs, "min_fare", v),
+		MaxFare:      readFloat(qs, "max_fare", v),
+		From:         readTime(qs, "from", v),
+		To:           readTime(qs, "to", v),
+		VehicleClass: types.VehicleClass(strings.ToUpper(readString(qs, "vehicle_class", ""))),
+		City:         strings.ToUpper(readString(qs, "city", "")),
+		Sort:         readString(qs, "sort", "-created_at"),
+		Limit:        readInt(qs, "limit", 20, v),
+	}
+	for _, status := range readCSV(qs, "status", nil) {
+		filter.Statuses = append(filter.Statuses, types.RideStatus(strings.ToUpper(strings.TrimSpace(status))))
+	}
+
+	dto.ValidateRideSearch(v, &filter)
+
+	if cursor := readString(qs, "cursor", ""); cursor != "" {
+		after, err := models.DecodeRideCursor(cursor, filter.Sort)
+		if err != nil {
+			v.AddError("cursor", "must be next_cursor of a previous page with the same sort")
+		}
+		filter.After = after
+	}
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	rides, err := h.s.SearchRides(ctx, filter)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to search rides", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, rides, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetRideStateAt godoc
+// @Summary      Get ride state at a point in time
+// @Description  Reconstructs ride status and driver position at the given timestamp from ride events and location history
+// @Tags         admin
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Param        ts query string true "Timestamp in RFC3339 format"
+// @Success      200 {object} models.RideStateAt "Reconstructed ride state"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Ride not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/rides/{ride_id}/state-at [get]
+func (h *Admin) GetRideStateAt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx = wrap.WithAction(ctx, "admin_get_ride_state_at")
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	v := validator.New()
+	rawTs := r.URL.Query().Get("ts")
+	v.Check(rawTs != "", "ts", "must be provided")
+
+	var ts time.Time
+	if rawTs != "" {
+		ts, err = time.Parse(time.RFC3339, rawTs)
+		v.Check(err == nil, "ts", "must be a valid RFC3339 timestamp")
+	}
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	state, err := h.s.RideStateAt(ctx, rideID, ts)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to reconstruct ride state", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	h.l.Debug(ctx, "reconstructed ride state", "ride_id", rideID, "status", state.Status, "events_applied", state.EventsApplied)
+
+	if err := writeJSON(w, http.StatusOK, state, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetRideCommunications godoc
+// @Summary      Get ride communication log
+// @Description  Every WebSocket message, push, SMS, email and voice call sent to the passenger about the ride with its channel, time and delivery result (DELIVERED, FAILED or SKIPPED with the reason). Driver location updates are not logged
+// @Tags         admin
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Success      200 {object} models.RideCommunications "Ride communication log"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Ride not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/rides/{ride_id}/communications [get]
+func (h *Admin) GetRideCommunications(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_ride_communications")
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	comms, err := h.s.RideCommunications(ctx, rideID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ride communications", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, comms, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetSLO godoc
+// @Summary      Get SLO compliance
+// @Description  Get SLI compliance and remaining error budget for each SLO over rolling windows (1h, 24h, 7d)
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} models.SLOReport "SLO report"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/slo [get]
+func (h *Admin) GetSLO(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx = wrap.WithAction(ctx, "admin_get_slo")
+
+	report, err := h.s.SLO(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get slo report", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, report, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetPositioningEffectiveness godoc
+// @Summary      Get positioning tips effectiveness
+// @Description  Count positioning tips sent to idle drivers over the last days and, among evaluated ones, how many drivers moved to the recommended area and got a ride
+// @Tags         admin
+// @Produce      json
+// @Param        days query int false "Number of days, 1-90, defaults to 7"
+// @Success      200 {object} models.PositioningEffectiveness "Effectiveness report"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/positioning/effectiveness [get]
+func (h *Admin) GetPositioningEffectiveness(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx = wrap.WithAction(ctx, "admin_get_positioning_effectiveness")
+
+	v := validator.New()
+	days := readInt(r.URL.Query(), "days", 7, v)
+	v.Check(days >= 1 && days <= 90, "days", "must be between 1 and 90")
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	report, err := h.s.PositioningEffectiveness(ctx, days)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get positioning effectiveness", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, report, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetTelemetryReport godoc
+// @Summary      Get app quality telemetry report
+// @Description  Anonymous client telemetry over the last days, grouped by role, platform and app version. Problems limited to one platform or version point to the app or network; all groups degrading together point to the backend
+// @Tags         admin
+// @Produce      json
+// @Param        days query int false "Number of days, 1-90" default(7)
+// @Success      200 {object} models.TelemetryReport "Telemetry report"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/telemetry [get]
+func (h *Admin) GetTelemetryReport(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_telemetry_report")
+
+	v := validator.New()
+	days := readInt(r.URL.Query(), "days", 7, v)
+	v.Check(days >= 1 && days <= 90, "days", "must be between 1 and 90")
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	report, err := h.s.TelemetryReport(ctx, days)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get telemetry report", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, report, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetCoverageReport godoc
+// @Summary      Get dispatch coverage report
+// @Description  Match rate, wait from request to match and cancellations because no driver was found, grouped by the geohash of the pickup point over the last days. Only rides with a matching outcome are counted. Areas with at least min_requests requests are listed; areas well behind the city-wide rates are marked underserved with the reasons, to guide driver incentives
+// @Tags         admin
+// @Produce      json
+// @Param        days query int false "Number of days, 1-90" default(7)
+// @Param        precision query int false "Geohash precision, 4-7 (5 is about 4.9km x 4.9km)" default(5)
+// @Param        min_requests query int false "Minimum requests for an area to be listed, 1-1000" default(10)
+// @Success      200 {object} models.CoverageReport "Coverage report"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/reports/coverage [get]
+func (h *Admin) GetCoverageReport(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_coverage_report")
+
 	v := validator.New()
 	qs := r.URL.Query()
+	days := readInt(qs, "days", 7, v)
+	precision := readInt(qs, "precision", 5, v)
+	minRequests := readInt(qs, "min_requests", 10, v)
+	v.Check(days >= 1 && days <= 90, "days", "must be between 1 and 90")
+	v.Check(precision >= 4 && precision <= 7, "precision", "must be between 4 and 7")
+	v.Check(minRequests >= 1 && minRequests <= 1000, "min_requests", "must be between 1 and 1000")
 
-	// filter options
-	page := readInt(qs, "page", 1, v)
-	pageSize := readInt(qs, "page_size", 20, v)
-	sort := readString(qs, "sort", "created_at")
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
 
-	filters, err := models.NewFilters(page, pageSize, sort, activeRidesSortSafeList)
+	report, err := h.s.CoverageReport(ctx, days, precision, minRequests)
 	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get coverage report", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, report, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// BlocklistListing - параметры пагинации, сортировки и фильтров GET /admin/blocklist
+var BlocklistListing = models.ListOptions{
+	DefaultSort:  "-created_at",
+	SortSafelist: []string{"created_at", "-created_at"},
+	FilterKeys:   []string{"driver_id", "passenger_id"},
+}
+
+// GetBlocklist godoc
+// @Summary      Get passenger blocklist
+// @Description  Get passengers blocked by drivers with optional driver and passenger filters
+// @Tags         admin
+// @Produce      json
+// @Param        driver_id query string false "Filter by driver ID"
+// @Param        passenger_id query string false "Filter by passenger ID"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Page size" default(20)
+// @Param        sort query string false "Sort field" default(-created_at)
+// @Success      200 {object} models.BlocklistResponse "List of blocks"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/blocklist [get]
+func (h *Admin) GetBlocklist(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx = wrap.WithAction(ctx, "admin_get_blocklist")
+
+	filters, ok := models.FiltersFromContext(ctx)
+	if !ok {
+		h.l.Warn(ctx, "pagination filters are missing in context")
 		internalErrorResponse(w, "intenal error")
 		return
 	}
 
-	filters.Validate(v)
+	v := validator.New()
+
+	var filter models.BlocklistFilter
+	if raw := filters.Param("driver_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		v.Check(err == nil, "driver_id", "must be a valid uuid")
+		filter.DriverID = &id
+	}
+	if raw := filters.Param("passenger_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		v.Check(err == nil, "passenger_id", "must be a valid uuid")
+		filter.PassengerID = &id
+	}
 
 	if !v.Valid() {
 		failedValidationResponse(w, v.Errors)
 		return
 	}
 
-	rides, err := h.s.ActiveRides(ctx, filters)
+	blocks, err := h.s.Blocklist(ctx, filter, filters)
 	if err != nil {
-		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get active rides", err)
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get blocklist", err)
 		errorResponse(w, GetCode(err), err.Error())
 		return
 	}
 
-	h.l.Debug(ctx, "fetched active rides", "total", rides.Metadata.TotalRecords)
+	if err := writeJSON(w, http.StatusOK, blocks, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
 
-	if err := writeJSON(w, http.StatusOK, rides, nil); err != nil {
+// GetCitySettings godoc
+// @Summary      Get city settings
+// @Description  Get operational hours and night-ride rules of all cities
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "City settings"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/settings/cities [get]
+func (h *Admin) GetCitySettings(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_city_settings")
+
+	cities, err := h.s.Cities(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get city settings", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"cities": cities}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// UpdateCitySettings godoc
+// @Summary      Create or update city settings
+// @Description  Set operational hours and night-ride rules of a city. Rides requested outside hours are rejected (REJECT) or priced with the night multiplier (SURCHARGE).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        code path string true "City code, e.g. ALA"
+// @Param        request body dto.UpdateCitySettingsRequest true "City settings"
+// @Success      200 {object} map[string]interface{} "Updated city settings"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/settings/cities/{code} [put]
+func (h *Admin) UpdateCitySettings(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_update_city_settings")
+
+	code := r.PathValue("code")
+
+	var req dto.UpdateCitySettingsRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v, code)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	city := req.ToModel(code)
+	if err := h.s.UpdateCity(ctx, city); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update city settings", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"city": city}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetAnomalies godoc
+// @Summary      Get anomalies
+// @Description  Detect inconsistent states: busy drivers without an active ride, rides assigned to offline drivers, sessions open for more than 24h and duplicate is_current coordinates. Every anomaly carries a one-click remediation action.
+// @Tags         admin
+// @Produce      json
+// @Param        kind query string false "Filter by anomaly kind"
+// @Success      200 {object} models.AnomaliesResponse "Detected anomalies"
+// @Failure      400 {object} map[string]interface{} "Unknown anomaly kind"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/anomalies [get]
+func (h *Admin) GetAnomalies(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_anomalies")
+
+	kind := types.AnomalyKind(readString(r.URL.Query(), "kind", ""))
+
+	anomalies, err := h.s.Anomalies(ctx, kind)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get anomalies", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, anomalies, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// RemediateAnomaly godoc
+// @Summary      Remediate anomaly
+// @Description  Apply the remediation action of an anomaly. The anomaly condition is re-checked, already resolved anomalies return 404.
+// @Description  With dry_run=true the remediation runs in a rolled back transaction and only the would-be effects are returned.
+// @Tags         admin
+// @Produce      json
+// @Param        kind path string true "Anomaly kind"
+// @Param        entity_id path string true "Entity ID from the anomaly"
+// @Param        dry_run query bool false "Validate and return effects without committing"
+// @Success      200 {object} map[string]interface{} "Remediation effects"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Anomaly not found or already resolved"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/anomalies/{kind}/{entity_id}/remediate [post]
+func (h *Admin) RemediateAnomaly(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_remediate_anomaly")
+
+	kind := types.AnomalyKind(r.PathValue("kind"))
+	entityID, err := uuid.Parse(r.PathValue("entity_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid entity uuid format")
+		return
+	}
+
+	v := validator.New()
+	dryRun := readBool(r.URL.Query(), "dry_run", false, v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	effects, err := h.s.Remediate(ctx, kind, entityID, dryRun)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to remediate anomaly", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"kind":      kind,
+		"entity_id": entityID,
+		"effects":   effects,
+	}
+
+	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// Broadcast godoc
+// @Summary      Broadcast announcement
+// @Description  Send a "broadcast" WebSocket message to all drivers and/or passengers, optionally only to users whose current location is in a city. Offline users receive it when they connect. Delivery counters are filled by the ride and driver services, poll GET /admin/broadcasts/{broadcast_id} for up-to-date stats.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.BroadcastRequest true "Announcement"
+// @Success      202 {object} models.Broadcast "Broadcast accepted"
+// @Failure      400 {object} map[string]interface{} "Bad request or unknown city"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/broadcast [post]
+func (h *Admin) Broadcast(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_broadcast")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	var req dto.BroadcastRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	broadcast := req.ToModel(user.ID)
+	if err := h.s.Broadcast(ctx, broadcast); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to broadcast announcement", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, broadcast, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetBroadcast godoc
+// @Summary      Get broadcast
+// @Description  Get an announcement with delivery stats: recipients, delivered to open connections and stored for offline users until they connect
+// @Tags         admin
+// @Produce      json
+// @Param        broadcast_id path string true "Broadcast ID"
+// @Success      200 {object} models.Broadcast "Broadcast with delivery stats"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Broadcast not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/broadcasts/{broadcast_id} [get]
+func (h *Admin) GetBroadcast(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_broadcast")
+
+	id, err := uuid.Parse(r.PathValue("broadcast_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid broadcast uuid format")
+		return
+	}
+
+	broadcast, err := h.s.GetBroadcast(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get broadcast", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, broadcast, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// SetDriverSimulator godoc
+// @Summary      Mark driver as simulator
+// @Description  Simulator drivers receive only sandbox test rides and never real ones. They are excluded from overview driver counts.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.SetDriverSimulatorRequest true "Simulator flag"
+// @Success      200 {object} map[string]interface{} "Updated flag"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/drivers/{driver_id}/simulator [put]
+func (h *Admin) SetDriverSimulator(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_set_driver_simulator")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	var req dto.SetDriverSimulatorRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.s.SetDriverSimulator(ctx, driverID, *req.Simulator); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to set driver simulator flag", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"driver_id": driverID, "simulator": *req.Simulator}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// SetPriorityBoarding godoc
+// @Summary      Set passenger priority boarding eligibility
+// @Description  Allows or forbids a passenger account to request rides with priority boarding (MEDICAL or ACCESSIBILITY). Such rides get maximum dispatch priority and no surcharges
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        passenger_id path string true "Passenger ID"
+// @Param        request body dto.SetPriorityBoardingRequest true "Eligibility flag"
+// @Success      200 {object} map[string]interface{} "Updated flag"
+// @Failure      400 {object} map[string]interface{} "Invalid passenger ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Passenger not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/passengers/{passenger_id}/priority-boarding [put]
+func (h *Admin) SetPriorityBoarding(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_set_priority_boarding")
+
+	passengerID, err := uuid.Parse(r.PathValue("passenger_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid passenger uuid format")
+		return
+	}
+
+	var req dto.SetPriorityBoardingRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.s.SetPriorityBoardingEligible(ctx, passengerID, *req.Eligible); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to set priority boarding eligibility", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"passenger_id": passengerID, "priority_boarding_eligible": *req.Eligible}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetDriverChanges godoc
+// @Summary      Get pending driver changes
+// @Description  Get driver profile changes waiting for admin approval (license number changes), oldest first
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Pending changes"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/driver-changes [get]
+func (h *Admin) GetDriverChanges(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_driver_changes")
+
+	changes, err := h.s.DriverChanges(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver changes", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"changes": changes, "total": len(changes)}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// ApproveDriverChange godoc
+// @Summary      Approve driver change
+// @Description  Approve a pending driver profile change and apply it to the driver profile
+// @Tags         admin
+// @Produce      json
+// @Param        change_id path string true "Change request ID"
+// @Success      200 {object} models.DriverChangeRequest "Approved change"
+// @Failure      400 {object} map[string]interface{} "Invalid change ID or license already taken"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Change not found or already reviewed"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/driver-changes/{change_id}/approve [post]
+func (h *Admin) ApproveDriverChange(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_approve_driver_change")
+
+	changeID, err := uuid.Parse(r.PathValue("change_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid change uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	change, err := h.s.ApproveDriverChange(ctx, changeID, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to approve driver change", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, change, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// RejectDriverChange godoc
+// @Summary      Reject driver change
+// @Description  Reject a pending driver profile change; the driver profile is left unchanged
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        change_id path string true "Change request ID"
+// @Param        request body dto.RejectDriverChangeRequest true "Rejection reason"
+// @Success      200 {object} models.DriverChangeRequest "Rejected change"
+// @Failure      400 {object} map[string]interface{} "Invalid change ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Change not found or already reviewed"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/driver-changes/{change_id}/reject [post]
+func (h *Admin) RejectDriverChange(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_reject_driver_change")
+
+	changeID, err := uuid.Parse(r.PathValue("change_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid change uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	var req dto.RejectDriverChangeRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	change, err := h.s.RejectDriverChange(ctx, changeID, user.ID, req.Reason)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to reject driver change", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, change, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// RunOpsAction godoc
+// @Summary      Run ops action
+// @Description  Run an auditable operational action. PAUSE_MATCHING {"city","paused"} is applied immediately: new rides in the city are rejected with 409. DRAIN_WS {"service":"ride|driver","instance"} is queued as PENDING and applied by that instance on its next poll. Every action, including failed ones, is recorded in the audit log
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.OpsActionRequest true "Action and its params"
+// @Success      201 {object} models.OpsAction "Recorded action"
+// @Failure      400 {object} map[string]interface{} "Unknown action, invalid params or unknown city"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/ops/actions [post]
+func (h *Admin) RunOpsAction(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_run_ops_action")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	var req dto.OpsActionRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	action := req.ToModel(user.ID)
+	if err := h.s.RunOpsAction(ctx, action); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to run ops action", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, action, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetOpsActions godoc
+// @Summary      List ops actions
+// @Description  Audit log of the last 100 operational actions, newest first
+// @Tags         admin
+// @Produce      json
+// @Success      200 {array} models.OpsAction "Ops actions"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/ops/actions [get]
+func (h *Admin) GetOpsActions(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_ops_actions")
+
+	actions, err := h.s.OpsActions(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ops actions", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"actions": actions}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetOpsAction godoc
+// @Summary      Get ops action
+// @Description  Get an operational action with its status and result. Instance actions stay PENDING until the target instance applies them
+// @Tags         admin
+// @Produce      json
+// @Param        action_id path string true "Ops action ID"
+// @Success      200 {object} models.OpsAction "Ops action"
+// @Failure      400 {object} map[string]interface{} "Invalid action ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Ops action not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/ops/actions/{action_id} [get]
+func (h *Admin) GetOpsAction(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_ops_action")
+
+	id, err := uuid.Parse(r.PathValue("action_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid action uuid format")
+		return
+	}
+
+	action, err := h.s.OpsAction(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ops action", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, action, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// ExportState godoc
+// @Summary      Export live system state
+// @Description  Consistent snapshot of active rides, online drivers and open driver sessions, read in a single read-only transaction, for incident analysis. Addresses are not exported. A small fleet gets the snapshot right away; above EXPORT_SYNC_MAX_ITEMS rows the snapshot is built in the background and the response is 202 with an export ID to poll. Snapshots are capped at EXPORT_MAX_ITEMS rows and marked truncated
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} models.StateSnapshot "Snapshot"
+// @Success      202 {object} map[string]interface{} "Export started in background"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/export/state [get]
+func (h *Admin) ExportState(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_export_state")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	snapshot, export, err := h.s.ExportState(ctx, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to export state", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	status, data := http.StatusOK, any(snapshot)
+	if export != nil {
+		status = http.StatusAccepted
+		data = envelope{
+			"export_id": export.ID,
+			"status":    export.Status,
+			"message":   "Snapshot is being generated, poll /admin/export/state/" + export.ID.String(),
+		}
+	}
+
+	if err := writeJSON(w, status, data, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetStateExport godoc
+// @Summary      Get background state export
+// @Description  Status of a background state export. The snapshot is included once the status is READY
+// @Tags         admin
+// @Produce      json
+// @Param        export_id path string true "Export ID"
+// @Success      200 {object} models.StateExport "State export"
+// @Failure      400 {object} map[string]interface{} "Invalid export ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "State export not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/export/state/{export_id} [get]
+func (h *Admin) GetStateExport(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_state_export")
+
+	id, err := uuid.Parse(r.PathValue("export_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid export uuid format")
+		return
+	}
+
+	export, err := h.s.StateExport(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get state export", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, export, nil); err != nil {
 		h.l.Error(ctx, "failed to write response", err)
 		w.WriteHeader(http.StatusInternalServerError)
 	}
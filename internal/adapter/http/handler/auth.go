@@ -6,6 +6,7 @@ import (
 
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
@@ -14,9 +15,14 @@ import (
 
 type AuthService interface {
 	Register(ctx context.Context, newUser *models.UserCreateRequest) (uuid.UUID, error)
-	Login(ctx context.Context, email, password string) (*models.TokenPair, error)
+	Login(ctx context.Context, email, password, deviceID string) (*models.TokenPair, error)
 	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, accessToken, refreshToken string) error
+	LogoutAll(ctx context.Context, accessToken string) error
 	RoleCheck(ctx context.Context, token string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, upd models.ProfileUpdate) (*models.User, error)
+	RequestPhoneVerification(ctx context.Context, userID uuid.UUID) (*models.PhoneVerification, error)
+	VerifyPhone(ctx context.Context, userID uuid.UUID, code string) error
 }
 
 type Auth struct {
@@ -77,7 +83,7 @@ func (h *Auth) Register(w http.ResponseWriter, r *http.Request) {
 
 // Login godoc
 // @Summary      User login
-// @Description  Authenticate user and receive JWT tokens
+// @Description  Authenticate user and receive JWT tokens. Drivers logging in with device_id also receive device_secret for signing location updates
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -104,7 +110,7 @@ func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.auth.Login(ctx, req.Email, req.Password)
+	tokens, err := h.auth.Login(ctx, req.Email, req.Password, req.DeviceID)
 	if err != nil {
 		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to login user", err)
 		errorResponse(w, GetCode(err), err.Error())
@@ -115,6 +121,9 @@ func (h *Auth) Login(w http.ResponseWriter, r *http.Request) {
 		"access_token":  tokens.AccessToken,
 		"refresh_token": tokens.RefreshToken,
 	}
+	if tokens.DeviceSecret != "" {
+		response["device_secret"] = tokens.DeviceSecret
+	}
 
 	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
 		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write JSON response", err)
@@ -169,6 +178,73 @@ func (h *Auth) Refresh(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Logout godoc
+// @Summary      Log out
+// @Description  End the current session: the access token of the request is rejected from now on and the refresh token can no longer be exchanged
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RefreshTokenRequest true "Refresh token of the session"
+// @Success      200 {object} map[string]interface{} "Logged out"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized or refresh token of another user"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /auth/logout [post]
+func (h *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "logout")
+
+	req := &dto.RefreshTokenRequest{}
+	if err := readJSON(w, r, req); err != nil {
+		badRequestResponse(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateRefreshToken(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.auth.Logout(ctx, bearerToken(r), req.RefreshToken); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to log out", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"message": "logged out"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write JSON response", err)
+		internalErrorResponse(w, "failed to write JSON response")
+	}
+}
+
+// LogoutAll godoc
+// @Summary      Log out from all devices
+// @Description  End every session of the user: all refresh tokens are revoked and access tokens issued before the request are rejected
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Logged out from all devices"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /auth/logout-all [post]
+func (h *Auth) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "logout_all")
+
+	if err := h.auth.LogoutAll(ctx, bearerToken(r)); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to log out from all devices", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"message": "logged out from all devices"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write JSON response", err)
+		internalErrorResponse(w, "failed to write JSON response")
+	}
+}
+
 // Profile godoc
 // @Summary      Get user profile
 // @Description  Get current authenticated user's profile information
@@ -199,3 +275,137 @@ func (h *Auth) Profile(w http.ResponseWriter, r *http.Request) {
 		internalErrorResponse(w, "failed to write JSON response")
 	}
 }
+
+// UpdateProfile godoc
+// @Summary      Update profile
+// @Description  Update name, phone and attrs of the current user. Only provided fields change; attrs are merged into the saved ones. A new phone must be verified again
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.UpdateProfileRequest true "Profile changes"
+// @Success      200 {object} map[string]interface{} "Updated profile"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /auth/me [put]
+func (h *Auth) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "update_profile")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	req := &dto.UpdateProfileRequest{}
+	if err := readJSON(w, r, req); err != nil {
+		badRequestResponse(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	updated, err := h.auth.UpdateProfile(ctx, user.ID, req.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update profile", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"user": updated}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write JSON response", err)
+		internalErrorResponse(w, "failed to write JSON response")
+	}
+}
+
+// RequestPhoneVerification godoc
+// @Summary      Send phone verification code
+// @Description  Send a one-time code by SMS to the phone in the profile. A new code replaces the previous one and can be requested once the resend interval has passed
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} models.PhoneVerification "Code sent"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      409 {object} map[string]interface{} "Phone is not set or already verified"
+// @Failure      429 {object} map[string]interface{} "Code was sent recently"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "SMS provider is not available"
+// @Security     BearerAuth
+// @Router       /auth/me/phone/verification [post]
+func (h *Auth) RequestPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "request_phone_verification")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	verification, err := h.auth.RequestPhoneVerification(ctx, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to send phone verification code", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, verification, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write JSON response", err)
+		internalErrorResponse(w, "failed to write JSON response")
+	}
+}
+
+// VerifyPhone godoc
+// @Summary      Verify phone
+// @Description  Confirm the phone in the profile with the code from SMS. A code expires after its TTL or the allowed number of attempts
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.VerifyPhoneRequest true "Code from SMS"
+// @Success      200 {object} map[string]interface{} "Phone verified"
+// @Failure      400 {object} map[string]interface{} "Invalid code"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      409 {object} map[string]interface{} "No code requested, code expired or phone already verified"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      429 {object} map[string]interface{} "Too many attempts"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /auth/me/phone/verify [post]
+func (h *Auth) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "verify_phone")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	req := &dto.VerifyPhoneRequest{}
+	if err := readJSON(w, r, req); err != nil {
+		badRequestResponse(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.auth.VerifyPhone(ctx, user.ID, req.Code); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to verify phone", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"message": "phone verified"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write JSON response", err)
+		internalErrorResponse(w, "failed to write JSON response")
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// ListMatchingBlackouts godoc
+// @Summary      List matching blackouts
+// @Description  List matching blackout windows that are active now or scheduled. Ended windows are not returned
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Matching blackouts"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/matching-blackouts [get]
+func (h *Admin) ListMatchingBlackouts(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_list_matching_blackouts")
+
+	blackouts, err := h.s.MatchingBlackouts(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to list matching blackouts", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"blackouts": blackouts}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// CreateMatchingBlackout godoc
+// @Summary      Create a matching blackout
+// @Description  Pause automatic matching in a zone (optionally for one vehicle class) from starts_at until ends_at. Rides requested in the zone are created, but the driver search starts only when the window ends; the passenger is told the expected delay
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MatchingBlackoutRequest true "Matching blackout"
+// @Success      201 {object} map[string]interface{} "Created matching blackout"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/matching-blackouts [post]
+func (h *Admin) CreateMatchingBlackout(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_create_matching_blackout")
+
+	blackout, ok := h.readMatchingBlackout(w, r, uuid.NilUUID)
+	if !ok {
+		return
+	}
+
+	if err := h.s.CreateMatchingBlackout(ctx, blackout); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to create matching blackout", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"blackout": blackout}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// UpdateMatchingBlackout godoc
+// @Summary      Update a matching blackout
+// @Description  Replace the zone, class, reason and window of a blackout that has not ended yet
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        blackout_id path string true "Blackout ID"
+// @Param        request body dto.MatchingBlackoutRequest true "Matching blackout"
+// @Success      200 {object} map[string]interface{} "Updated matching blackout"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Blackout not found or already ended"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/matching-blackouts/{blackout_id} [put]
+func (h *Admin) UpdateMatchingBlackout(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_update_matching_blackout")
+
+	id, err := uuid.Parse(r.PathValue("blackout_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid blackout uuid format")
+		return
+	}
+
+	blackout, ok := h.readMatchingBlackout(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := h.s.UpdateMatchingBlackout(ctx, blackout); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update matching blackout", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"blackout": blackout}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// EndMatchingBlackout godoc
+// @Summary      End a matching blackout
+// @Description  End an active blackout now or remove a scheduled one. Held rides are dispatched within a minute
+// @Tags         admin
+// @Produce      json
+// @Param        blackout_id path string true "Blackout ID"
+// @Success      200 {object} map[string]interface{} "Blackout ended"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Blackout not found or already ended"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/matching-blackouts/{blackout_id} [delete]
+func (h *Admin) EndMatchingBlackout(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_end_matching_blackout")
+
+	id, err := uuid.Parse(r.PathValue("blackout_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid blackout uuid format")
+		return
+	}
+
+	if err := h.s.EndMatchingBlackout(ctx, id); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to end matching blackout", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"blackout_id": id, "message": "matching blackout ended"}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// readMatchingBlackout читает и проверяет окно из тела запроса, ответ с ошибкой уже отправлен при false
+func (h *Admin) readMatchingBlackout(w http.ResponseWriter, r *http.Request, id uuid.UUID) (*models.MatchingBlackout, bool) {
+	ctx := r.Context()
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return nil, false
+	}
+
+	var req dto.MatchingBlackoutRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return nil, false
+	}
+
+	return req.ToModel(id, user.ID), true
+}
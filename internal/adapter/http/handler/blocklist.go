@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// GetBlocklist godoc
+// @Summary      Get driver blocklist
+// @Description  Get passengers blocked by the driver
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} map[string]interface{} "Blocked passengers"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/blocklist [get]
+func (h *Driver) GetBlocklist(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_blocklist")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	blocks, err := h.service.GetBlocklist(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get blocklist", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"blocks": blocks}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// BlockPassenger godoc
+// @Summary      Block a passenger
+// @Description  Add passenger to the driver's blocklist, rides of blocked passengers are never offered to the driver
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.BlockPassengerReq true "Passenger to block"
+// @Success      201 {object} map[string]interface{} "Passenger blocked"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Passenger not found"
+// @Failure      409 {object} map[string]interface{} "Blocklist size limit reached"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/blocklist [post]
+func (h *Driver) BlockPassenger(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "block_passenger")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	var req dto.BlockPassengerReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.service.BlockPassenger(ctx, models.BlockedPassenger{
+		DriverID:    driverID,
+		PassengerID: req.PassengerID,
+		Reason:      req.Reason,
+	}); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to block passenger", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"passenger_id": req.PassengerID,
+		"message":      "Passenger blocked, their rides will not be offered to you",
+	}
+
+	if err := writeJSON(w, http.StatusCreated, response, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// UnblockPassenger godoc
+// @Summary      Unblock a passenger
+// @Description  Remove passenger from the driver's blocklist
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        passenger_id path string true "Passenger ID"
+// @Success      200 {object} map[string]interface{} "Passenger unblocked"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Passenger is not blocked"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/blocklist/{passenger_id} [delete]
+func (h *Driver) UnblockPassenger(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "unblock_passenger")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	passengerID, err := uuid.Parse(r.PathValue("passenger_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid passenger uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid passenger uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	if err := h.service.UnblockPassenger(ctx, driverID, passengerID); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to unblock passenger", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"passenger_id": passengerID, "message": "Passenger unblocked"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// GetCurrentRide godoc
+// @Summary      Get driver's current ride
+// @Description  Returns the ride the driver is assigned to and has not completed yet (MATCHED, EN_ROUTE, ARRIVED or IN_PROGRESS): status, pickup and destination, passenger alias with a masked phone, and navigation to the current target. Lets a restarted driver app restore its ride screen without waiting for a WebSocket push
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} models.CurrentRide "Current ride"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found or has no active ride"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/rides/current [get]
+func (h *Driver) GetCurrentRide(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_current_ride")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID != driverID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	ride, err := h.service.CurrentRide(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get current ride", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, ride, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// GetFailedMessages godoc
+// @Summary      List dead-lettered messages
+// @Description  Messages rejected by RabbitMQ consumers without requeue. admin-service collects them from the dead_messages queue. Newest first.
+// @Tags         admin
+// @Produce      json
+// @Param        queue query string false "Source queue, e.g. driver_responses"
+// @Param        replayed query bool false "Only replayed (true) or not yet replayed (false) messages"
+// @Param        limit query int false "Max messages (1-500, default 50)"
+// @Success      200 {object} map[string]interface{} "Failed messages"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/failed-messages [get]
+func (h *Admin) GetFailedMessages(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_failed_messages")
+
+	qs := r.URL.Query()
+	v := validator.New()
+	filter := models.FailedMessageFilter{
+		Queue: readString(qs, "queue", ""),
+		Limit: readInt(qs, "limit", 50, v),
+	}
+	if qs.Get("replayed") != "" {
+		replayed := readBool(qs, "replayed", false, v)
+		filter.Replayed = &replayed
+	}
+	v.Check(filter.Limit >= 1 && filter.Limit <= 500, "limit", "must be between 1 and 500")
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	messages, err := h.s.FailedMessages(ctx, filter)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get failed messages", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"messages": messages}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetFailedMessage godoc
+// @Summary      Get dead-lettered message
+// @Tags         admin
+// @Produce      json
+// @Param        message_id path string true "Failed message ID"
+// @Success      200 {object} models.FailedMessage "Failed message"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Failed message not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/failed-messages/{message_id} [get]
+func (h *Admin) GetFailedMessage(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_failed_message")
+
+	id, err := uuid.Parse(r.PathValue("message_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid message uuid format")
+		return
+	}
+
+	message, err := h.s.FailedMessage(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get failed message", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, message, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// ReplayFailedMessage godoc
+// @Summary      Replay dead-lettered message
+// @Description  Publish the message again to the queue it was dead-lettered from. If the consumer rejects it again, it is stored as a new failed message.
+// @Tags         admin
+// @Produce      json
+// @Param        message_id path string true "Failed message ID"
+// @Success      200 {object} models.FailedMessage "Replayed message"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Failed message not found"
+// @Failure      409 {object} map[string]interface{} "Message has no source queue"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Broker backend has no dead letter queue"
+// @Security     BearerAuth
+// @Router       /admin/failed-messages/{message_id}/replay [post]
+func (h *Admin) ReplayFailedMessage(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_replay_failed_message")
+
+	id, err := uuid.Parse(r.PathValue("message_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid message uuid format")
+		return
+	}
+
+	message, err := h.s.ReplayFailedMessage(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to replay failed message", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, message, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -33,6 +33,10 @@ type DriverServiceOptions struct {
 	WsConnections *wshub.ConnectionHub
 	Service       DriverService
 	Auth          TokenValidator
+	// Partner - partner API таксопарков, nil - partner API выключен
+	Partner PartnerService
+	// Payouts - баланс и мгновенный вывод заработка
+	Payouts PayoutService
 }
 
 type DriverService interface {
@@ -43,6 +47,26 @@ type DriverService interface {
 	StartRide(ctx context.Context, startTime time.Time, driverID, rideID uuid.UUID, location models.Location) error
 	CompleteRide(ctx context.Context, rideID uuid.UUID, data drivergo.CompleteRideData) (earnings float64, err error)
 	UpdateLocation(ctx context.Context, data models.RideLocationUpdate) (coordinateID uuid.UUID, err error)
+	GetProfile(ctx context.Context, driverID uuid.UUID) (*models.Driver, error)
+	UpdateProfile(ctx context.Context, driverID uuid.UUID, upd models.DriverProfileUpdate) (*models.Driver, []models.DriverChangeRequest, error)
+	Vehicles(ctx context.Context, driverID uuid.UUID) ([]models.DriverVehicle, error)
+	ActiveVehicle(ctx context.Context, driverID uuid.UUID) (*models.DriverVehicle, error)
+	UpdateVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.DriverVehicle) (*models.DriverVehicle, error)
+	AddVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.DriverVehicle, activate bool) (*models.DriverVehicle, error)
+	ActivateVehicle(ctx context.Context, driverID, vehicleID uuid.UUID) (*models.DriverVehicle, error)
+	BlockPassenger(ctx context.Context, block models.BlockedPassenger) error
+	UnblockPassenger(ctx context.Context, driverID, passengerID uuid.UUID) error
+	GetBlocklist(ctx context.Context, driverID uuid.UUID) ([]models.BlockedPassenger, error)
+	Reconcile(ctx context.Context, driverID uuid.UUID, actions []models.OfflineAction) ([]models.ReconcileResult, error)
+	TaxSummary(ctx context.Context, driverID uuid.UUID, year int) (*models.TaxSummary, error)
+	EarningsReport(ctx context.Context, driverID uuid.UUID, period types.EarningsPeriod) (*models.EarningsReport, error)
+	RideHistory(ctx context.Context, driverID uuid.UUID, filter models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error)
+	CurrentRide(ctx context.Context, driverID uuid.UUID) (*models.CurrentRide, error)
+	SessionHistory(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverSessionHistory, error)
+	RatingHistory(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverRatingHistory, error)
+	DriverConnected(ctx context.Context, driverID uuid.UUID)
+	DriverDisconnected(ctx context.Context, driverID uuid.UUID)
+	PushStats(ctx context.Context, driverID uuid.UUID)
 }
 
 var upgrader = websocket.Upgrader{
@@ -54,6 +78,9 @@ var upgrader = websocket.Upgrader{
 }
 
 func NewDriver(option *DriverServiceOptions, l logger.Logger) *Driver {
+	if option == nil {
+		return &Driver{l: l}
+	}
 	return &Driver{
 		service:       option.Service,
 		wsConnections: option.WsConnections,
@@ -113,6 +140,132 @@ func (h *Driver) Register(w http.ResponseWriter, r *http.Request) {
 	h.l.Info(ctx, "driver registered successfully", "driver_id", driver.ID)
 }
 
+// GetProfile godoc
+// @Summary      Get driver profile
+// @Description  Get driver profile with statistics and ranking tier. Drivers may only read their own profile; passengers get public fields only (no earnings, verification or tier timestamps)
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} map[string]interface{} "Driver profile"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id} [get]
+func (h *Driver) GetProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_profile")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	// водитель может смотреть только свой профиль
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	role := types.UserRole(user.Role)
+	if role == types.RoleDriver && user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	driver, err := h.service.GetProfile(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver profile", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := shapeFor(role, dto.NewDriverProfileResponse(driver))
+
+	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// UpdateProfile godoc
+// @Summary      Update driver profile
+// @Description  Update driver name, phone and vehicle attributes; omitted fields are left unchanged. A new license number is not applied immediately: it is queued for admin approval and returned in pending_changes
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.UpdateDriverProfileRequest true "Fields to update"
+// @Success      200 {object} map[string]interface{} "Updated profile and pending changes"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id} [patch]
+func (h *Driver) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "update_driver_profile")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	var req dto.UpdateDriverProfileRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	driver, pending, err := h.service.UpdateProfile(ctx, driverID, req.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update driver profile", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"driver":          shapeFor(types.RoleDriver, dto.NewDriverProfileResponse(driver)),
+		"pending_changes": pending,
+	}
+
+	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
 // GoOnline godoc
 // @Summary      Driver goes online
 // @Description  Set driver status to online and available for ride requests
@@ -194,7 +347,7 @@ func (h *Driver) GoOnline(w http.ResponseWriter, r *http.Request) {
 
 // GoOffline godoc
 // @Summary      Driver goes offline
-// @Description  Set driver status to offline and get session summary
+// @Description  Set driver status to offline and get session summary with comparison to yesterday and the weekly average and an hourly earnings breakdown. The summary is also pushed over WebSocket as shift_summary.
 // @Tags         driver
 // @Produce      json
 // @Param        driver_id path string true "Driver ID"
@@ -243,9 +396,14 @@ func (h *Driver) GoOffline(w http.ResponseWriter, r *http.Request) {
 		"message":    "You are now offline",
 		"session_id": summary.SessionID,
 		"session_summary": envelope{
-			"duration_hours":  summary.DurationHours,
-			"rides_completed": summary.RidesCompleted,
-			"earnings":        summary.Earnings,
+			"started_at":        summary.StartedAt,
+			"ended_at":          summary.EndedAt,
+			"duration_hours":    summary.DurationHours,
+			"rides_completed":   summary.RidesCompleted,
+			"earnings":          summary.Earnings,
+			"earnings_per_hour": summary.EarningsPerHour,
+			"comparison":        summary.Comparison,
+			"hourly_earnings":   summary.HourlyEarnings,
 		},
 	}
 
@@ -439,6 +597,9 @@ func (h *Driver) CompleteRide(w http.ResponseWriter, r *http.Request) {
 // @Produce      json
 // @Param        driver_id path string true "Driver ID"
 // @Param        request body dto.UpdateLocationReq true "Location update with coordinates"
+// @Param        X-Device-ID header string false "Device ID the signing secret was issued for"
+// @Param        X-Signature-Timestamp header integer false "Unix seconds included in the signature"
+// @Param        X-Signature header string false "Hex HMAC-SHA256 of timestamp, latitude and longitude (see README)"
 // @Success      200 {object} map[string]interface{} "Location updated successfully"
 // @Failure      400 {object} map[string]interface{} "Bad request"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
@@ -448,77 +609,7 @@ func (h *Driver) CompleteRide(w http.ResponseWriter, r *http.Request) {
 // @Security     BearerAuth
 // @Router       /drivers/{driver_id}/location [post]
 func (h *Driver) UpdateLocation(w http.ResponseWriter, r *http.Request) {
-	ctx := wrap.WithAction(r.Context(), "update_driver_location")
-
-	driverID, err := uuid.Parse(r.PathValue("driver_id"))
-	if err != nil {
-		h.l.Warn(ctx, "invalid driver uuid format")
-		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
-		return
-	}
-
-	// провереяем что драйвер хочет изменить именно себя
-	user := models.UserFromContext(ctx)
-	if user == nil {
-		h.l.Warn(ctx, "failed to get user form context")
-		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
-		return
-	}
-
-	if user.ID.String() != driverID.String() {
-		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
-		return
-	}
-
-	var req dto.UpdateLocationReq
-	if err := readJSON(w, r, &req); err != nil {
-		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
-		errorResponse(w, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	v := validator.New()
-	req.Validate(v)
-	if !v.Valid() {
-		h.l.Warn(ctx, "invalid request data")
-		failedValidationResponse(w, v.Errors)
-		return
-	}
-
-	now := time.Now()
-
-	coordinateID, err := h.service.UpdateLocation(ctx, models.RideLocationUpdate{
-		DriverID:  driverID,
-		RideID:    nil,
-		TimeStamp: now,
-		Coordinates: models.Coordinates{
-			AccuracyMeters: req.AccuracyMeters,
-			SpeedKmh:       req.SpeedKmH,
-			HeadingDegrees: req.HeadingDegrees,
-			Location: models.Location{
-				Latitude:  *req.Latitude,
-				Longitude: *req.Longitude,
-			},
-		},
-	})
-	if err != nil {
-		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update driver location", err)
-		errorResponse(w, GetCode(err), err.Error())
-		return
-	}
-
-	response := envelope{
-		"coordinate_id": coordinateID,
-		"updated_at":    now,
-	}
-
-	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
-		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
-		internalErrorResponse(w, err.Error())
-		return
-	}
-
-	h.l.Info(ctx, "driver location has been updated", "driver_id", driverID, "coordinate_id", coordinateID)
+	serveLocationUpdate(w, r, h.l, h.service.UpdateLocation)
 }
 
 // HandleWS godoc
@@ -547,8 +638,17 @@ func (h *Driver) UpdateLocation(w http.ResponseWriter, r *http.Request) {
 // @Description
 // @Description  **Message Types:**
 // @Description  - Client → Server: `{"type":"auth","token":"string"}`
+// @Description  - Client → Server (v2): `{"type":"ack","seq":42}`
 // @Description  - Server → Client: `{"type":"auth_ok"}` | `{"type":"ride_assigned"}` | `{"type":"ride_cancelled"}` | `{"type":"notification"}` | `{"type":"ping"}`
 // @Description
+// @Description  **Protocol versions:**
+// @Description  Clients without `protocol` in auth get v1: events are sent as plain JSON objects.
+// @Description  With `"protocol":2` the server answers `{"type":"auth_ok","protocol":2}` (or 1 while v2 is disabled) and wraps events
+// @Description  in `{"v":2,"seq":42,"type":"ride_offer","data":{...}}`. The client acknowledges with `{"type":"ack","seq":42}`
+// @Description  and after reconnecting sends `"resume_from":<last seq>` to receive unacknowledged events again.
+// @Description  `"capabilities":{"delta_updates":true,"chat":true,"v2_envelope":true,"locale":"ru"}` in auth describes the client;
+// @Description  `v2_envelope` stands for `"protocol":2`, `locale` selects the language of `positioning_tip`. `auth_ok` echoes the accepted capabilities.
+// @Description
 // @Description  **Authentication Flow:**
 // @Description  ```json
 // @Description  // 1. Client sends (within 5s):
@@ -592,7 +692,7 @@ func (h *Driver) HandleWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Authenticate the WebSocket connection
-	driver, err := h.wsAuthenticate(ctx, wsConn, driverID)
+	driver, session, err := h.wsAuthenticate(ctx, wsConn, driverID)
 	if err != nil {
 		h.l.Error(ctx, "websocket authentication failed", err)
 		return
@@ -610,6 +710,8 @@ func (h *Driver) HandleWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	conn := wshub.NewConn(driver.ID, wsConn, h.l)
+	conn.SetProtocol(session.protocol, session.resumeFrom)
+	conn.SetCapabilities(session.caps)
 	if err := h.wsConnections.Add(conn); err != nil {
 		h.l.Error(ctx, "failed to register WS connection", err)
 		wsConn.WriteJSON(map[string]any{"error": "failed to register"})
@@ -617,12 +719,20 @@ func (h *Driver) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	metrics.WebSocketConnectionsGauge.WithLabelValues("driver_service").Inc()
+	untrack := trackWSProtocol("driver_service", conn)
+	h.service.DriverConnected(ctx, driver.ID)
 	defer func() {
-		h.wsConnections.Delete(driver.ID)
+		h.wsConnections.Remove(conn)
 		metrics.WebSocketConnectionsGauge.WithLabelValues("driver_service").Dec()
+		untrack()
+		// водитель в пути, не переподключившийся за grace период, снимается с поездки
+		h.service.DriverDisconnected(ctx, driver.ID)
 	}()
 
-	h.l.Info(ctx, "websocket connection registered")
+	h.l.Info(ctx, "websocket connection registered", "protocol", session.protocol.String())
+
+	// снимок статистики для плитки заработка сразу после подключения
+	h.service.PushStats(ctx, driver.ID)
 
 	// Heartbeat
 	go func() {
@@ -645,11 +755,11 @@ func (h *Driver) HandleWS(w http.ResponseWriter, r *http.Request) {
 
 // wsAuthenticate enforces a 5s auth window, expects a JSON text message:
 //
-//	{"type":"auth","token":"Bearer <jwt>"}
+//	{"type":"auth","token":"Bearer <jwt>","protocol":2,"resume_from":0}
 //
-// It validates the JWT via RideService and returns the driver UUID.
+// It validates the JWT via RideService and returns the driver with the negotiated protocol.
 // On any error, it sends an appropriate WebSocket close frame and closes the connection.
-func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, driver uuid.UUID) (*models.User, error) {
+func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, driver uuid.UUID) (*models.User, wsSession, error) {
 	const authTimeout = 5 * time.Second
 
 	// Enforce "client must authenticate within 5 seconds".
@@ -661,7 +771,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	msgType, payload, err := conn.ReadMessage()
@@ -679,7 +789,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if msgType != websocket.TextMessage {
@@ -690,7 +800,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, errors.New("first message must be text")
+		return nil, wsSession{}, errors.New("first message must be text")
 	}
 
 	var req dto.AuthWebSocketReq
@@ -702,7 +812,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if req.Type != "auth" {
@@ -712,8 +822,21 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, errors.New("unexpected message type")
+		return nil, wsSession{}, errors.New("unexpected message type")
+	}
+
+	session, err := negotiateProtocol(h.wsConnections, "driver_service", req)
+	if err != nil {
+		_ = conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unsupported protocol"),
+			time.Now().Add(time.Second),
+		)
+		_ = conn.Close()
+		return nil, wsSession{}, err
 	}
+	// у водителя одно соединение, новое заменяет прежнее
+	session.deviceID = wshub.DefaultDevice
 
 	// Validate the token and get the driverInfo info
 	driverInfo, err := h.auth.RoleCheck(ctx, req.Token)
@@ -725,7 +848,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if driverInfo == nil {
@@ -736,7 +859,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if driverInfo.ID != driver {
@@ -747,7 +870,7 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, errors.New("driver ID mismatch")
+		return nil, wsSession{}, errors.New("driver ID mismatch")
 	}
 
 	// Auth succeeded; clear the read deadline for normal operation.
@@ -759,13 +882,11 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	// Send an explicit acknowledgment so the client can transition its state machine.
-	ack := dto.AuthWebSocketResp{
-		Type: "auth_ok",
-	}
+	ack := authOK(req, session)
 	if err := conn.WriteJSON(ack); err != nil {
 		h.l.Error(ctx, "failed to send auth_ok", err)
 		_ = conn.WriteControl(
@@ -774,8 +895,8 @@ func (h *Driver) wsAuthenticate(ctx context.Context, conn *websocket.Conn, drive
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
-	return driverInfo, nil
+	return driverInfo, session, nil
 }
@@ -0,0 +1,411 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+type UpdateCitySettingsRequest struct {
+	Name               string                   `json:"name"`
+	CenterLatitude     float64                  `json:"center_latitude"`
+	CenterLongitude    float64                  `json:"center_longitude"`
+	RadiusKm           float64                  `json:"radius_km"`
+	Timezone           string                   `json:"timezone"`
+	OpenTime           string                   `json:"open_time"`
+	CloseTime          string                   `json:"close_time"`
+	NightStart         string                   `json:"night_start"`
+	NightEnd           string                   `json:"night_end"`
+	NightMultiplier    float64                  `json:"night_multiplier"`
+	OutsideHoursPolicy types.OutsideHoursPolicy `json:"outside_hours_policy"`
+	LegalEntity        string                   `json:"legal_entity,omitempty"`
+}
+
+func (r *UpdateCitySettingsRequest) Validate(v *validator.Validator, code string) {
+	v.Check(code != "", "code", "must be provided")
+	v.Check(len(code) <= 20, "code", "must be at most 20 characters")
+
+	v.Check(r.Name != "", "name", "must be provided")
+	v.Check(len(r.Name) <= 100, "name", "must be at most 100 characters")
+
+	checkCoordinates(v, "center_latitude", "center_longitude", r.CenterLatitude, r.CenterLongitude)
+	v.Check(r.RadiusKm > 0 && r.RadiusKm <= 500, "radius_km", "must be between 0 and 500")
+
+	_, err := time.LoadLocation(r.Timezone)
+	v.Check(r.Timezone != "" && err == nil, "timezone", "must be a valid IANA timezone, e.g. Asia/Almaty")
+
+	for field, value := range map[string]string{
+		"open_time":   r.OpenTime,
+		"close_time":  r.CloseTime,
+		"night_start": r.NightStart,
+		"night_end":   r.NightEnd,
+	} {
+		_, err := models.ParseClock(value)
+		v.Check(err == nil, field, "must be a time of day in HH:MM format")
+	}
+
+	v.Check(r.NightMultiplier >= 1 && r.NightMultiplier <= 5, "night_multiplier", "must be between 1 and 5")
+	v.Check(validator.PermittedValue(r.OutsideHoursPolicy, types.PolicyReject, types.PolicySurcharge), "outside_hours_policy", "must be REJECT or SURCHARGE")
+	v.Check(len(r.LegalEntity) <= 50, "legal_entity", "must be at most 50 characters")
+}
+
+func (r *UpdateCitySettingsRequest) ToModel(code string) *models.CitySettings {
+	return &models.CitySettings{
+		Code: strings.ToUpper(code),
+		Name: r.Name,
+		Center: models.Location{
+			Latitude:  r.CenterLatitude,
+			Longitude: r.CenterLongitude,
+		},
+		RadiusKm:           r.RadiusKm,
+		Timezone:           r.Timezone,
+		OpenTime:           r.OpenTime,
+		CloseTime:          r.CloseTime,
+		NightStart:         r.NightStart,
+		NightEnd:           r.NightEnd,
+		NightMultiplier:    r.NightMultiplier,
+		OutsideHoursPolicy: r.OutsideHoursPolicy,
+		LegalEntity:        strings.TrimSpace(r.LegalEntity),
+	}
+}
+
+type BroadcastRequest struct {
+	Title   string           `json:"title"`
+	Message string           `json:"message"`
+	Roles   []types.UserRole `json:"roles"` // пусто — водители и пассажиры
+	City    string           `json:"city"`
+}
+
+func (r *BroadcastRequest) Validate(v *validator.Validator) {
+	v.Check(strings.TrimSpace(r.Title) != "", "title", "must be provided")
+	v.Check(len(r.Title) <= 100, "title", "must be at most 100 characters")
+	v.Check(strings.TrimSpace(r.Message) != "", "message", "must be provided")
+	v.Check(len(r.Message) <= 2000, "message", "must be at most 2000 characters")
+	v.Check(len(r.City) <= 20, "city", "must be at most 20 characters")
+
+	for _, role := range r.Roles {
+		v.Check(validator.PermittedValue(role, types.RoleDriver, types.RolePassenger), "roles", "must contain only DRIVER or PASSENGER")
+	}
+	v.Check(validator.Unique(r.Roles), "roles", "must not contain duplicates")
+}
+
+func (r *BroadcastRequest) ToModel(adminID uuid.UUID) *models.Broadcast {
+	roles := r.Roles
+	if len(roles) == 0 {
+		roles = []types.UserRole{types.RoleDriver, types.RolePassenger}
+	}
+
+	return &models.Broadcast{
+		Title:     strings.TrimSpace(r.Title),
+		Message:   strings.TrimSpace(r.Message),
+		Roles:     roles,
+		City:      strings.ToUpper(strings.TrimSpace(r.City)),
+		CreatedBy: adminID,
+	}
+}
+
+type CreatePromoCodeRequest struct {
+	Code         string     `json:"code"`
+	DiscountType string     `json:"discount_type"` // PERCENT или FLAT
+	Value        float64    `json:"value"`
+	MaxDiscount  *float64   `json:"max_discount,omitempty"` // только для PERCENT
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxUses      *int       `json:"max_uses,omitempty"`
+	// по умолчанию 1
+	PerPassengerLimit int `json:"per_passenger_limit,omitempty"`
+}
+
+func (r *CreatePromoCodeRequest) Validate(v *validator.Validator) {
+	code := strings.TrimSpace(r.Code)
+	v.Check(code != "", "code", "must be provided")
+	v.Check(len(code) <= 32, "code", "must be at most 32 characters")
+	v.Check(!strings.ContainsAny(code, " \t\n"), "code", "must not contain spaces")
+
+	v.Check(validator.PermittedValue(types.DiscountType(r.DiscountType), types.DiscountPercent, types.DiscountFlat), "discount_type", "must be one of PERCENT or FLAT")
+	v.Check(r.Value > 0, "value", "must be greater than zero")
+	if r.DiscountType == types.DiscountPercent.String() {
+		v.Check(r.Value <= 100, "value", "must not be more than 100 percent")
+	}
+	if r.MaxDiscount != nil {
+		v.Check(r.DiscountType == types.DiscountPercent.String(), "max_discount", "is only allowed for PERCENT discounts")
+		v.Check(*r.MaxDiscount > 0, "max_discount", "must be greater than zero")
+	}
+
+	if r.ExpiresAt != nil {
+		v.Check(r.ExpiresAt.After(time.Now()), "expires_at", "must be in the future")
+	}
+	if r.MaxUses != nil {
+		v.Check(*r.MaxUses > 0, "max_uses", "must be greater than zero")
+	}
+	v.Check(r.PerPassengerLimit >= 0, "per_passenger_limit", "must not be negative")
+}
+
+func (r *CreatePromoCodeRequest) ToModel(adminID uuid.UUID) *models.PromoCode {
+	return &models.PromoCode{
+		Code:              strings.TrimSpace(r.Code),
+		DiscountType:      types.DiscountType(r.DiscountType),
+		Value:             r.Value,
+		MaxDiscount:       r.MaxDiscount,
+		ExpiresAt:         r.ExpiresAt,
+		MaxUses:           r.MaxUses,
+		PerPassengerLimit: r.PerPassengerLimit,
+		CreatedBy:         &adminID,
+	}
+}
+
+// UpdatePromoCodeRequest — частичное изменение промокода, код и тип скидки не меняются
+type UpdatePromoCodeRequest struct {
+	Value             *float64   `json:"value,omitempty"`
+	MaxDiscount       *float64   `json:"max_discount,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	MaxUses           *int       `json:"max_uses,omitempty"`
+	PerPassengerLimit *int       `json:"per_passenger_limit,omitempty"`
+	Active            *bool      `json:"active,omitempty"`
+}
+
+func (r *UpdatePromoCodeRequest) Validate(v *validator.Validator) {
+	if r.Value != nil {
+		v.Check(*r.Value > 0, "value", "must be greater than zero")
+	}
+	if r.MaxDiscount != nil {
+		v.Check(*r.MaxDiscount > 0, "max_discount", "must be greater than zero")
+	}
+	if r.ExpiresAt != nil {
+		v.Check(r.ExpiresAt.After(time.Now()), "expires_at", "must be in the future")
+	}
+	if r.MaxUses != nil {
+		v.Check(*r.MaxUses > 0, "max_uses", "must be greater than zero")
+	}
+	if r.PerPassengerLimit != nil {
+		v.Check(*r.PerPassengerLimit > 0, "per_passenger_limit", "must be greater than zero")
+	}
+}
+
+func (r *UpdatePromoCodeRequest) ToModel() models.PromoCodeUpdate {
+	return models.PromoCodeUpdate{
+		Value:             r.Value,
+		MaxDiscount:       r.MaxDiscount,
+		ExpiresAt:         r.ExpiresAt,
+		MaxUses:           r.MaxUses,
+		PerPassengerLimit: r.PerPassengerLimit,
+		Active:            r.Active,
+	}
+}
+
+// RideSearchSortSafelist — поля сортировки поиска поездок, "-" — по убыванию
+var RideSearchSortSafelist = []string{
+	"created_at", "requested_at", "fare", "ride_number",
+	"-created_at", "-requested_at", "-fare", "-ride_number",
+}
+
+func ValidateRideSearch(v *validator.Validator, f *models.RideSearchFilter) {
+	for _, s := range f.Statuses {
+		v.Check(types.IsValidRideStatus(s), "status", "must contain only valid ride statuses")
+	}
+	v.Check(validator.Unique(f.Statuses), "status", "must not contain duplicates")
+
+	if f.MinFare != nil {
+		v.Check(*f.MinFare >= 0, "min_fare", "must not be negative")
+	}
+	if f.MaxFare != nil {
+		v.Check(*f.MaxFare >= 0, "max_fare", "must not be negative")
+	}
+	if f.MinFare != nil && f.MaxFare != nil {
+		v.Check(*f.MinFare <= *f.MaxFare, "max_fare", "must be greater than or equal to min_fare")
+	}
+	if f.From != nil && f.To != nil {
+		v.Check(f.From.Before(*f.To), "to", "must be after from")
+	}
+
+	if f.VehicleClass != "" {
+		v.Check(validator.PermittedValue(f.VehicleClass, types.ClassEconomy, types.ClassPremium, types.ClassXL), "vehicle_class", "must be ECONOMY, PREMIUM or XL")
+	}
+	v.Check(len(f.City) <= 20, "city", "must be at most 20 characters")
+
+	v.Check(validator.PermittedValue(f.Sort, RideSearchSortSafelist...), "sort", fmt.Sprintf("invalid sort value, available: %s", strings.Join(RideSearchSortSafelist, ",")))
+	v.Check(f.Limit > 0, "limit", "must be greater than zero")
+	v.Check(f.Limit <= 100, "limit", "must be a maximum of 100")
+}
+
+// SetDriverSimulatorRequest включает режим симулятора водителя для песочницы
+type SetDriverSimulatorRequest struct {
+	Simulator *bool `json:"simulator"`
+}
+
+func (r *SetDriverSimulatorRequest) Validate(v *validator.Validator) {
+	v.Check(r.Simulator != nil, "simulator", "must be provided")
+}
+
+// SetPriorityBoardingRequest разрешает пассажиру приоритетную посадку
+type SetPriorityBoardingRequest struct {
+	Eligible *bool `json:"eligible"`
+}
+
+func (r *SetPriorityBoardingRequest) Validate(v *validator.Validator) {
+	v.Check(r.Eligible != nil, "eligible", "must be provided")
+}
+
+// RejectDriverChangeRequest — причина отклонения изменения профиля водителя
+type RejectDriverChangeRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r *RejectDriverChangeRequest) Validate(v *validator.Validator) {
+	v.Check(r.Reason != "", "reason", "must be provided")
+	v.Check(len(r.Reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+// OpsActionRequest — операционное действие дежурного инженера, params зависят от action
+type OpsActionRequest struct {
+	Action types.OpsActionType `json:"action"`
+	Params json.RawMessage     `json:"params"`
+}
+
+func (r *OpsActionRequest) Validate(v *validator.Validator) {
+	v.Check(strings.TrimSpace(string(r.Action)) != "", "action", "must be provided")
+}
+
+func (r *OpsActionRequest) ToModel(adminID uuid.UUID) *models.OpsAction {
+	return &models.OpsAction{
+		Action:      types.OpsActionType(strings.ToUpper(strings.TrimSpace(string(r.Action)))),
+		Params:      r.Params,
+		RequestedBy: adminID,
+	}
+}
+
+type FlatRateZone struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	RadiusKm  float64 `json:"radius_km"`
+}
+
+func (z *FlatRateZone) validate(v *validator.Validator, field string) {
+	checkCoordinates(v, field+".latitude", field+".longitude", z.Latitude, z.Longitude)
+	v.Check(z.RadiusKm > 0 && z.RadiusKm <= 50, field+".radius_km", "must be between 0 and 50")
+}
+
+func (z *FlatRateZone) toModel() models.FareZone {
+	return models.FareZone{
+		Center: models.Location{
+			Latitude:  z.Latitude,
+			Longitude: z.Longitude,
+		},
+		RadiusKm: z.RadiusKm,
+	}
+}
+
+type SetFlatRateRequest struct {
+	Name     string             `json:"name"`
+	RateType types.FlatRateType `json:"rate_type"`
+	// Класс автомобиля, пусто — все классы
+	VehicleType string        `json:"vehicle_type"`
+	Origin      FlatRateZone  `json:"origin"`
+	Destination *FlatRateZone `json:"destination"`
+	// nil — маршрут двусторонний
+	Bidirectional *bool   `json:"bidirectional"`
+	Fare          float64 `json:"fare"`
+	// nil — версия действует сразу
+	EffectiveFrom *time.Time `json:"effective_from"`
+}
+
+func (r *SetFlatRateRequest) Validate(v *validator.Validator, code string) {
+	v.Check(code != "", "code", "must be provided")
+	v.Check(len(code) <= 40, "code", "must be at most 40 characters")
+
+	v.Check(strings.TrimSpace(r.Name) != "", "name", "must be provided")
+	v.Check(len(r.Name) <= 100, "name", "must be at most 100 characters")
+
+	v.Check(validator.PermittedValue(r.RateType, types.FlatRateFixed, types.FlatRateMinimum), "rate_type", "must be FLAT or MINIMUM")
+	if r.VehicleType != "" {
+		v.Check(validator.PermittedValue(types.VehicleClass(r.VehicleType), types.ClassEconomy, types.ClassPremium, types.ClassXL), "vehicle_type", "must be ECONOMY, PREMIUM or XL")
+	}
+
+	r.Origin.validate(v, "origin")
+	switch r.RateType {
+	case types.FlatRateFixed:
+		v.Check(r.Destination != nil, "destination", "must be provided for a FLAT rate")
+		if r.Destination != nil {
+			r.Destination.validate(v, "destination")
+		}
+	case types.FlatRateMinimum:
+		v.Check(r.Destination == nil, "destination", "must be empty for a MINIMUM rate")
+	}
+
+	v.Check(r.Fare > 0 && r.Fare <= 1000000, "fare", "must be between 0 and 1000000")
+	// небольшой запас на расхождение часов клиента
+	v.Check(r.EffectiveFrom == nil || r.EffectiveFrom.After(time.Now().Add(-time.Minute)), "effective_from", "must not be in the past")
+}
+
+func (r *SetFlatRateRequest) ToModel(code string, adminID uuid.UUID) *models.FlatRate {
+	rate := &models.FlatRate{
+		Code:          strings.ToUpper(code),
+		Name:          strings.TrimSpace(r.Name),
+		RateType:      r.RateType,
+		VehicleType:   r.VehicleType,
+		Origin:        r.Origin.toModel(),
+		Bidirectional: r.Bidirectional == nil || *r.Bidirectional,
+		Fare:          r.Fare,
+		CreatedBy:     &adminID,
+	}
+	if r.Destination != nil {
+		destination := r.Destination.toModel()
+		rate.Destination = &destination
+	}
+	if r.EffectiveFrom != nil {
+		rate.EffectiveFrom = *r.EffectiveFrom
+	}
+	return rate
+}
+
+// maxBlackoutDuration — самое длинное окно приостановки подбора, поездки в нем ждут водителя до конца окна
+const maxBlackoutDuration = 7 * 24 * time.Hour
+
+type MatchingBlackoutRequest struct {
+	Reason string `json:"reason"`
+	// Класс автомобиля, пусто — все классы
+	VehicleType string       `json:"vehicle_type"`
+	Zone        FlatRateZone `json:"zone"`
+	// nil — окно начинается сразу
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   time.Time  `json:"ends_at"`
+}
+
+func (r *MatchingBlackoutRequest) Validate(v *validator.Validator) {
+	v.Check(strings.TrimSpace(r.Reason) != "", "reason", "must be provided")
+	v.Check(len(r.Reason) <= 200, "reason", "must be at most 200 characters")
+
+	if r.VehicleType != "" {
+		v.Check(validator.PermittedValue(types.VehicleClass(r.VehicleType), types.ClassEconomy, types.ClassPremium, types.ClassXL), "vehicle_type", "must be ECONOMY, PREMIUM or XL")
+	}
+
+	r.Zone.validate(v, "zone")
+
+	startsAt := time.Now()
+	if r.StartsAt != nil && r.StartsAt.After(startsAt) {
+		startsAt = *r.StartsAt
+	}
+	v.Check(!r.EndsAt.IsZero(), "ends_at", "must be provided")
+	v.Check(r.EndsAt.After(startsAt), "ends_at", "must be after starts_at and in the future")
+	v.Check(r.EndsAt.Sub(startsAt) <= maxBlackoutDuration, "ends_at", "must be at most 7 days after starts_at")
+}
+
+func (r *MatchingBlackoutRequest) ToModel(id, adminID uuid.UUID) *models.MatchingBlackout {
+	b := &models.MatchingBlackout{
+		ID:          id,
+		Reason:      strings.TrimSpace(r.Reason),
+		VehicleType: r.VehicleType,
+		Zone:        r.Zone.toModel(),
+		EndsAt:      r.EndsAt,
+		CreatedBy:   &adminID,
+	}
+	if r.StartsAt != nil {
+		b.StartsAt = *r.StartsAt
+	}
+	return b
+}
@@ -2,6 +2,8 @@ package dto
 
 import (
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
 )
 
@@ -30,6 +32,7 @@ func (r *RegisterUserRequest) ToModel() *models.UserCreateRequest {
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	DeviceID string `json:"device_id,omitempty"` // водитель получает секрет для подписи координат
 }
 
 type RefreshTokenRequest struct {
@@ -52,17 +55,131 @@ func ValidateNewUser(v *validator.Validator, user *RegisterUserRequest) {
 func ValidateLogin(v *validator.Validator, user *LoginRequest) {
 	v.Check(user.Email != "", "email", "must be provided")
 	v.Check(user.Password != "", "password", "must be provided")
+	v.Check(len(user.DeviceID) <= 128, "device_id", "must not be more than 128 bytes long")
 }
 
 func ValidateRefreshToken(v *validator.Validator, req *RefreshTokenRequest) {
 	v.Check(req.RefreshToken != "", "refresh_token", "must be provided")
 }
 
+// UpdateProfileRequest — изменение профиля, отсутствующие поля не меняются
+type UpdateProfileRequest struct {
+	Name  *string        `json:"name,omitempty"`
+	Phone *string        `json:"phone,omitempty"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+func (r *UpdateProfileRequest) Validate(v *validator.Validator) {
+	v.Check(r.Name != nil || r.Phone != nil || len(r.Attrs) > 0, "profile", "at least one of name, phone, attrs must be provided")
+
+	if r.Name != nil {
+		v.Check(*r.Name != "", "name", "must not be empty")
+		v.Check(len(*r.Name) <= 500, "name", "must not be more than 500 bytes long")
+	}
+	if r.Phone != nil {
+		v.Check(validator.Matches(*r.Phone, phoneRX), "phone", "must be 10 to 15 digits with optional leading +")
+	}
+
+	_, hasName := r.Attrs["name"]
+	_, hasPhone := r.Attrs["phone"]
+	v.Check(!hasName && !hasPhone, "attrs", "must not contain name or phone, use the name and phone fields")
+}
+
+func (r *UpdateProfileRequest) ToModel() models.ProfileUpdate {
+	return models.ProfileUpdate{
+		Name:  r.Name,
+		Phone: r.Phone,
+		Attrs: r.Attrs,
+	}
+}
+
+type VerifyPhoneRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *VerifyPhoneRequest) Validate(v *validator.Validator) {
+	v.Check(r.Code != "", "code", "must be provided")
+	v.Check(len(r.Code) <= 10, "code", "must not be more than 10 characters long")
+}
+
+type UpdatePreferencesRequest struct {
+	Channels   []types.NotificationChannel `json:"channels"`
+	EventTypes []types.NotificationEvent   `json:"event_types"`
+	// VoiceReadout - дублировать прибытие водителя голосовым звонком
+	VoiceReadout bool `json:"voice_readout"`
+}
+
+func (r *UpdatePreferencesRequest) Validate(v *validator.Validator) {
+	v.Check(r.Channels != nil, "channels", "must be provided")
+	for _, c := range r.Channels {
+		v.Check(validator.PermittedValue(c, types.AllNotificationChannels...), "channels", "must contain only PUSH, SMS, EMAIL")
+	}
+
+	v.Check(r.EventTypes != nil, "event_types", "must be provided")
+	for _, e := range r.EventTypes {
+		v.Check(validator.PermittedValue(e, types.AllNotificationEvents...), "event_types", "must contain only RIDE_UPDATES, RECEIPTS, PROMOTIONS, ACCOUNT_SECURITY, POSITIONING_TIPS")
+	}
+}
+
+func (r *UpdatePreferencesRequest) ToModel(userID uuid.UUID) *models.NotificationPreferences {
+	return &models.NotificationPreferences{
+		UserID:       userID,
+		Channels:     r.Channels,
+		EventTypes:   r.EventTypes,
+		VoiceReadout: r.VoiceReadout,
+	}
+}
+
+type RegisterPushTokenRequest struct {
+	Platform types.PushPlatform `json:"platform"`
+	// Token — регистрационный токен FCM на устройстве
+	Token string `json:"token"`
+}
+
+func (r *RegisterPushTokenRequest) Validate(v *validator.Validator) {
+	v.Check(validator.PermittedValue(r.Platform, types.AllPushPlatforms...), "platform", "must be ANDROID or IOS")
+	v.Check(r.Token != "", "token", "must be provided")
+	v.Check(len(r.Token) <= 4096, "token", "must not be more than 4096 bytes long")
+}
+
+func (r *RegisterPushTokenRequest) ToModel(userID uuid.UUID, deviceID string) *models.PushToken {
+	return &models.PushToken{
+		UserID:   userID,
+		DeviceID: deviceID,
+		Platform: r.Platform,
+		Token:    r.Token,
+	}
+}
+
 type AuthWebSocketReq struct {
 	Type  string `json:"type"`
 	Token string `json:"token"`
+	// DeviceID различает устройства одного пользователя: у каждого свое соединение и свой буфер
+	DeviceID string `json:"device_id,omitempty"`
+	// Protocol — запрошенная версия протокола, пусто — v1
+	Protocol int `json:"protocol,omitempty"`
+	// ResumeFrom — v2: последний seq, полученный клиентом, сообщения после него отправляются повторно
+	ResumeFrom uint64 `json:"resume_from,omitempty"`
+	// Capabilities — возможности клиента, старые версии приложения их не передают
+	Capabilities *WSCapabilities `json:"capabilities,omitempty"`
+}
+
+// WSCapabilities — возможности клиента WebSocket
+type WSCapabilities struct {
+	DeltaUpdates bool `json:"delta_updates,omitempty"` // обновления местоположения только с изменившимися полями
+	Chat         bool `json:"chat,omitempty"`
+	// V2Envelope — клиент умеет конверты v2, заменяет protocol=2, если protocol не передан
+	V2Envelope bool   `json:"v2_envelope,omitempty"`
+	Locale     string `json:"locale,omitempty"` // например ru или ru-RU
 }
 
+// MaxDeviceIDLength — максимальная длина device_id
+const MaxDeviceIDLength = 64
+
 type AuthWebSocketResp struct {
-	Type string `json:"type"`
+	Type     string `json:"type"`
+	DeviceID string `json:"device_id,omitempty"`
+	Protocol int    `json:"protocol,omitempty"` // согласованная версия, только клиентам, передавшим protocol или capabilities
+	// Capabilities — принятые возможности, только клиентам, передавшим capabilities
+	Capabilities *WSCapabilities `json:"capabilities,omitempty"`
 }
@@ -2,9 +2,13 @@ package dto
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
 )
@@ -28,37 +32,167 @@ func (r *RegisterDriverRequest) Validate(v *validator.Validator) {
 	v.Check(r.LicenseNumber != "", "license_number", "must be provided")
 	v.Check(len(r.LicenseNumber) < 10, "license_number", "must be less than 10 characters")
 
+	validateVehicle(v, "vehicle", r.Vehicle)
+}
+
+func (r *RegisterDriverRequest) ToModel() *models.Driver {
+	return &models.Driver{
+		ID:            r.ID,
+		Name:          r.Name,
+		LicenseNumber: r.LicenseNumber,
+		Vehicle:       r.Vehicle,
+	}
+}
+
+// validateVehicle проверяет данные автомобиля, key - префикс поля в ошибках, пустой - поля верхнего уровня
+func validateVehicle(v *validator.Validator, key string, vehicle models.Vehicle) {
+	field := func(name string) string {
+		if key == "" {
+			return name
+		}
+		return key + "." + name
+	}
+
 	// Vehicle.Make
-	v.Check(r.Vehicle.Make != "", "vehicle.make", "must be provided")
-	v.Check(len(r.Vehicle.Make) < 50, "vehicle.make", "must be less than 50 characters")
+	v.Check(vehicle.Make != "", field("make"), "must be provided")
+	v.Check(len(vehicle.Make) < 50, field("make"), "must be less than 50 characters")
 
 	// Vehicle.Model
-	v.Check(r.Vehicle.Model != "", "vehicle.model", "must be provided")
-	v.Check(len(r.Vehicle.Model) < 50, "vehicle.model", "must be less than 50 characters")
+	v.Check(vehicle.Model != "", field("model"), "must be provided")
+	v.Check(len(vehicle.Model) < 50, field("model"), "must be less than 50 characters")
 
 	// Vehicle.Color
-	v.Check(r.Vehicle.Color != "", "vehicle.color", "must be provided")
-	v.Check(len(r.Vehicle.Color) < 30, "vehicle.color", "must be less than 30 characters")
+	v.Check(vehicle.Color != "", field("color"), "must be provided")
+	v.Check(len(vehicle.Color) < 30, field("color"), "must be less than 30 characters")
 
 	// Vehicle.Plate
-	v.Check(r.Vehicle.Plate != "", "vehicle.plate", "must be provided")
-	v.Check(len(r.Vehicle.Plate) < 12, "vehicle.plate", "must be less than 12 characters")
+	v.Check(vehicle.Plate != "", field("plate"), "must be provided")
+	v.Check(len(vehicle.Plate) < 12, field("plate"), "must be less than 12 characters")
 
 	// Vehicle.Year
-	v.Check(r.Vehicle.Year != 0, "vehicle.year", "must be provided")
+	v.Check(vehicle.Year != 0, field("year"), "must be provided")
 	v.Check(
-		r.Vehicle.Year >= 1886 && r.Vehicle.Year <= time.Now().Year(),
-		"vehicle.year",
+		vehicle.Year >= 1886 && vehicle.Year <= time.Now().Year(),
+		field("year"),
 		fmt.Sprintf("must be between 1886 and %d", time.Now().Year()),
 	)
 }
 
-func (r *RegisterDriverRequest) ToModel() *models.Driver {
-	return &models.Driver{
-		ID:            r.ID,
+// DriverVehicleRequest — автомобиль водителя целиком, PUT /drivers/{driver_id}/vehicle
+type DriverVehicleRequest struct {
+	BodyType string `json:"body_type"` // SEDAN, SUV, VAN и т.п., пусто — прежний тип кузова
+	Make     string `json:"make"`
+	Model    string `json:"model"`
+	Color    string `json:"color"`
+	Plate    string `json:"plate"`
+	Year     int    `json:"year"`
+}
+
+func (r *DriverVehicleRequest) Validate(v *validator.Validator) {
+	if body := normalizeBodyType(r.BodyType); body != "" {
+		v.Check(validator.PermittedValue(body, models.BodyTypes...), "body_type", "must be one of "+strings.Join(models.BodyTypes, ", "))
+	}
+	validateVehicle(v, "", models.Vehicle{Make: r.Make, Model: r.Model, Color: r.Color, Plate: r.Plate, Year: r.Year})
+}
+
+func (r *DriverVehicleRequest) ToModel() models.DriverVehicle {
+	return models.DriverVehicle{
+		BodyType: normalizeBodyType(r.BodyType),
+		Make:     r.Make,
+		Model:    r.Model,
+		Color:    r.Color,
+		Plate:    strings.TrimSpace(r.Plate),
+		Year:     r.Year,
+	}
+}
+
+func normalizeBodyType(bodyType string) string {
+	return strings.ToUpper(strings.TrimSpace(bodyType))
+}
+
+// AddDriverVehicleRequest — новый автомобиль водителя, POST /drivers/{driver_id}/vehicles
+type AddDriverVehicleRequest struct {
+	DriverVehicleRequest
+	Activate bool `json:"activate"` // сразу пересесть на этот автомобиль
+}
+
+// phoneRX — номер телефона в международном формате, например +77011234567
+var phoneRX = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+// UpdateDriverProfileRequest — частичное изменение профиля водителя, отсутствующие поля не меняются
+type UpdateDriverProfileRequest struct {
+	Name          *string               `json:"name"`
+	Phone         *string               `json:"phone"`
+	Vehicle       *models.VehicleUpdate `json:"vehicle"`
+	LicenseNumber *string               `json:"license_number"` // требует одобрения администратора
+	PhotoURL      *string               `json:"photo_url"`      // требует одобрения администратора
+}
+
+func (r *UpdateDriverProfileRequest) Validate(v *validator.Validator) {
+	v.Check(r.Name != nil || r.Phone != nil || r.Vehicle != nil || r.LicenseNumber != nil || r.PhotoURL != nil, "body", "at least one field must be provided")
+
+	if r.Name != nil {
+		v.Check(strings.TrimSpace(*r.Name) != "", "name", "must not be empty")
+		v.Check(len(*r.Name) < 100, "name", "must be less than 100 characters")
+	}
+
+	if r.Phone != nil {
+		v.Check(validator.Matches(*r.Phone, phoneRX), "phone", "must be 10 to 15 digits with optional leading +")
+	}
+
+	if r.LicenseNumber != nil {
+		v.Check(*r.LicenseNumber != "", "license_number", "must not be empty")
+		v.Check(len(*r.LicenseNumber) < 10, "license_number", "must be less than 10 characters")
+	}
+
+	if r.Vehicle != nil {
+		validateVehicleUpdate(v, "vehicle", *r.Vehicle)
+	}
+
+	if r.PhotoURL != nil {
+		u, err := url.Parse(*r.PhotoURL)
+		v.Check(err == nil && u.Scheme == "https" && u.Host != "", "photo_url", "must be an absolute https URL")
+		v.Check(len(*r.PhotoURL) <= 500, "photo_url", "must be at most 500 characters")
+	}
+}
+
+func (r *UpdateDriverProfileRequest) ToModel() models.DriverProfileUpdate {
+	return models.DriverProfileUpdate{
 		Name:          r.Name,
-		LicenseNumber: r.LicenseNumber,
+		Phone:         r.Phone,
 		Vehicle:       r.Vehicle,
+		LicenseNumber: r.LicenseNumber,
+		PhotoURL:      r.PhotoURL,
+	}
+}
+
+// validateVehicleUpdate проверяет только переданные атрибуты автомобиля по тем же правилам, что validateVehicle
+func validateVehicleUpdate(v *validator.Validator, key string, vehicle models.VehicleUpdate) {
+	v.Check(vehicle.Make != nil || vehicle.Model != nil || vehicle.Color != nil || vehicle.Plate != nil || vehicle.Year != nil,
+		key, "at least one attribute must be provided")
+
+	if vehicle.Make != nil {
+		v.Check(*vehicle.Make != "", key+".make", "must not be empty")
+		v.Check(len(*vehicle.Make) < 50, key+".make", "must be less than 50 characters")
+	}
+	if vehicle.Model != nil {
+		v.Check(*vehicle.Model != "", key+".model", "must not be empty")
+		v.Check(len(*vehicle.Model) < 50, key+".model", "must be less than 50 characters")
+	}
+	if vehicle.Color != nil {
+		v.Check(*vehicle.Color != "", key+".color", "must not be empty")
+		v.Check(len(*vehicle.Color) < 30, key+".color", "must be less than 30 characters")
+	}
+	if vehicle.Plate != nil {
+		v.Check(*vehicle.Plate != "", key+".plate", "must not be empty")
+		v.Check(len(*vehicle.Plate) < 12, key+".plate", "must be less than 12 characters")
+	}
+	if vehicle.Year != nil {
+		v.Check(
+			*vehicle.Year >= 1886 && *vehicle.Year <= time.Now().Year(),
+			key+".year",
+			fmt.Sprintf("must be between 1886 and %d", time.Now().Year()),
+		)
 	}
 }
 
@@ -69,8 +203,7 @@ type CoordinateUpdateReq struct {
 
 func (r *CoordinateUpdateReq) Validate(v *validator.Validator) {
 	if r.Latitude != nil && r.Longitude != nil {
-		v.Check(*r.Latitude >= -90 && *r.Latitude <= 90, "latitude", "must be between -90 and 90")
-		v.Check(*r.Longitude >= -180 && *r.Latitude <= 180, "longitude", "must be between -90 and 90")
+		checkCoordinates(v, "latitude", "longitude", *r.Latitude, *r.Longitude)
 	} else {
 		v.Check(r.Latitude != nil, "latitude", "must be provided")
 		v.Check(r.Longitude != nil, "longitude", "must be provided")
@@ -116,3 +249,175 @@ func (r *UpdateLocationReq) Validate(v *validator.Validator) {
 	v.Check(r.HeadingDegrees >= 0 && r.HeadingDegrees <= 360, "heading_degrees", "must be between 0 and 360")
 	r.CoordinateUpdateReq.Validate(v)
 }
+
+// LocationSignature — необязательная подпись координат секретом устройства.
+// В HTTP передается заголовками X-Device-ID, X-Signature-Timestamp, X-Signature,
+// в WebSocket — полями сообщения location_update.
+type LocationSignature struct {
+	DeviceID  string `json:"device_id,omitempty"`
+	Timestamp int64  `json:"signature_timestamp,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// IsSet сообщает, что приложение передало хотя бы одно поле подписи
+func (r *LocationSignature) IsSet() bool {
+	return r.DeviceID != "" || r.Timestamp != 0 || r.Signature != ""
+}
+
+func (r *LocationSignature) Validate(v *validator.Validator) {
+	if !r.IsSet() {
+		return
+	}
+	v.Check(r.DeviceID != "", "device_id", "must be provided with signature")
+	v.Check(len(r.DeviceID) <= 128, "device_id", "must not be more than 128 bytes long")
+	v.Check(r.Timestamp > 0, "signature_timestamp", "must be provided with signature")
+	v.Check(r.Signature != "", "signature", "must be provided with signature")
+}
+
+func (r *LocationSignature) ToModel() *models.LocationSignature {
+	if !r.IsSet() {
+		return nil
+	}
+	return &models.LocationSignature{
+		DeviceID:  r.DeviceID,
+		Timestamp: r.Timestamp,
+		Value:     r.Signature,
+	}
+}
+
+type BlockPassengerReq struct {
+	PassengerID uuid.UUID `json:"passenger_id"`
+	Reason      string    `json:"reason"`
+}
+
+func (r *BlockPassengerReq) Validate(v *validator.Validator) {
+	v.Check(r.PassengerID != uuid.UUID{}, "passenger_id", "must be provided")
+	v.Check(len(r.Reason) <= 500, "reason", "must be at most 500 characters")
+}
+
+// maxReconcileActions ограничивает размер пачки офлайн действий в одном запросе
+const maxReconcileActions = 100
+
+type ReconcileActionReq struct {
+	ActionID          string                  `json:"action_id"`
+	Type              types.OfflineActionType `json:"type"`
+	PerformedAt       time.Time               `json:"performed_at"`
+	RideID            *uuid.UUID              `json:"ride_id,omitempty"`
+	Location          *CoordinateUpdateReq    `json:"location,omitempty"`
+	ActualDistanceKm  float64                 `json:"actual_distance_km,omitempty"`
+	ActualDurationMin int                     `json:"actual_duration_minutes,omitempty"`
+}
+
+type ReconcileReq struct {
+	Actions []ReconcileActionReq `json:"actions"`
+}
+
+func (r *ReconcileReq) Validate(v *validator.Validator) {
+	v.Check(len(r.Actions) > 0, "actions", "must be provided")
+	v.Check(len(r.Actions) <= maxReconcileActions, "actions", fmt.Sprintf("must contain at most %d actions", maxReconcileActions))
+
+	seen := make(map[string]bool, len(r.Actions))
+	for i, a := range r.Actions {
+		key := fmt.Sprintf("actions[%d]", i)
+
+		v.Check(a.ActionID != "", key+".action_id", "must be provided")
+		v.Check(len(a.ActionID) <= 64, key+".action_id", "must be at most 64 characters")
+		v.Check(!seen[a.ActionID], key+".action_id", "must be unique within the batch")
+		seen[a.ActionID] = true
+
+		v.Check(validator.PermittedValue(a.Type, types.OfflineGoOnline, types.OfflineGoOffline, types.OfflineStartRide, types.OfflineCompleteRide),
+			key+".type", "must be one of GO_ONLINE, GO_OFFLINE, START_RIDE, COMPLETE_RIDE")
+		v.Check(!a.PerformedAt.IsZero(), key+".performed_at", "must be provided")
+
+		// действия применяются в том порядке, в котором выполнялись на устройстве
+		if i > 0 {
+			v.Check(!a.PerformedAt.Before(r.Actions[i-1].PerformedAt), key+".performed_at", "actions must be ordered by performed_at")
+		}
+
+		if a.Type != types.OfflineGoOffline {
+			loc := a.Location
+			if loc == nil || loc.Latitude == nil || loc.Longitude == nil {
+				v.AddError(key+".location", "latitude and longitude must be provided")
+			} else {
+				checkCoordinates(v, key+".location.latitude", key+".location.longitude", *loc.Latitude, *loc.Longitude)
+			}
+		}
+
+		if a.Type == types.OfflineStartRide || a.Type == types.OfflineCompleteRide {
+			v.Check(a.RideID != nil && *a.RideID != uuid.UUID{}, key+".ride_id", "must be provided")
+		}
+
+		if a.Type == types.OfflineCompleteRide {
+			v.Check(a.ActualDistanceKm > 0, key+".actual_distance_km", "must be positive float")
+			v.Check(a.ActualDurationMin > 0, key+".actual_duration_minutes", "must be positive integer")
+		}
+	}
+}
+
+func (r *ReconcileReq) ToModel() []models.OfflineAction {
+	actions := make([]models.OfflineAction, 0, len(r.Actions))
+	for _, a := range r.Actions {
+		action := models.OfflineAction{
+			ID:                a.ActionID,
+			Type:              a.Type,
+			PerformedAt:       a.PerformedAt,
+			RideID:            a.RideID,
+			ActualDistanceKm:  a.ActualDistanceKm,
+			ActualDurationMin: a.ActualDurationMin,
+		}
+		if a.Location != nil && a.Location.Latitude != nil && a.Location.Longitude != nil {
+			action.Location = models.Location{
+				Latitude:  *a.Location.Latitude,
+				Longitude: *a.Location.Longitude,
+			}
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// DriverProfileResponse — профиль водителя. Поля с тегом roles видны только перечисленным ролям.
+type DriverProfileResponse struct {
+	DriverID      uuid.UUID          `json:"driver_id"`
+	Name          string             `json:"name"`
+	Status        types.DriverStatus `json:"status"`
+	Rating        float64            `json:"rating"`
+	TotalRides    int                `json:"total_rides"`
+	TotalEarnings float64            `json:"total_earnings" roles:"DRIVER,ADMIN"`
+	IsVerified    bool               `json:"is_verified" roles:"DRIVER,ADMIN"`
+	Vehicle       models.Vehicle     `json:"vehicle"`
+	Class         types.VehicleClass `json:"class"`
+	Tier          types.DriverTier   `json:"tier"`
+	TierUpdatedAt *time.Time         `json:"tier_updated_at" roles:"DRIVER,ADMIN"`
+	PhotoURL      *string            `json:"photo_url"`
+	DeclineRate   *float64           `json:"decline_rate" roles:"DRIVER,ADMIN"`
+}
+
+func NewDriverProfileResponse(driver *models.Driver) DriverProfileResponse {
+	return DriverProfileResponse{
+		DriverID:      driver.ID,
+		Name:          driver.Name,
+		Status:        driver.Status,
+		Rating:        driver.Rating,
+		TotalRides:    driver.TotalRides,
+		TotalEarnings: driver.TotalEarnings,
+		IsVerified:    driver.IsVerified,
+		Vehicle:       driver.Vehicle,
+		Class:         driver.Vehicle.Type,
+		Tier:          driver.Tier,
+		TierUpdatedAt: driver.TierUpdatedAt,
+		PhotoURL:      driver.PhotoURL,
+		DeclineRate:   driver.DeclineRate,
+	}
+}
+
+// RequestPayoutRequest — мгновенный вывод заработка, без amount выводится весь доступный баланс
+type RequestPayoutRequest struct {
+	Amount *float64 `json:"amount"`
+}
+
+func (r *RequestPayoutRequest) Validate(v *validator.Validator) {
+	if r.Amount != nil {
+		v.Check(*r.Amount > 0, "amount", "must be greater than zero")
+	}
+}
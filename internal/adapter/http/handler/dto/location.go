@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// IngestLocationReq — координата водителя во внутреннем API location-service.
+// Подпись устройства передается как есть и проверяется location-service.
+type IngestLocationReq struct {
+	DriverID       uuid.UUID  `json:"driver_id"`
+	RideID         *uuid.UUID `json:"ride_id,omitempty"`
+	Timestamp      time.Time  `json:"timestamp"`
+	Latitude       float64    `json:"latitude"`
+	Longitude      float64    `json:"longitude"`
+	AccuracyMeters float64    `json:"accuracy_meters,omitempty"`
+	SpeedKmh       float64    `json:"speed_kmh,omitempty"`
+	HeadingDegrees float64    `json:"heading_degrees,omitempty"`
+	LocationSignature
+}
+
+func NewIngestLocationReq(data models.RideLocationUpdate) IngestLocationReq {
+	req := IngestLocationReq{
+		DriverID:       data.DriverID,
+		RideID:         data.RideID,
+		Timestamp:      data.TimeStamp,
+		Latitude:       data.Location.Latitude,
+		Longitude:      data.Location.Longitude,
+		AccuracyMeters: data.AccuracyMeters,
+		SpeedKmh:       data.SpeedKmh,
+		HeadingDegrees: data.HeadingDegrees,
+	}
+	if sig := data.Signature; sig != nil {
+		req.LocationSignature = LocationSignature{
+			DeviceID:  sig.DeviceID,
+			Timestamp: sig.Timestamp,
+			Signature: sig.Value,
+		}
+	}
+	return req
+}
+
+func (r *IngestLocationReq) Validate(v *validator.Validator) {
+	v.Check(r.DriverID != uuid.UUID{}, "driver_id", "must be provided")
+	v.Check(!r.Timestamp.IsZero(), "timestamp", "must be provided")
+	checkCoordinates(v, "latitude", "longitude", r.Latitude, r.Longitude)
+	v.Check(r.HeadingDegrees >= 0 && r.HeadingDegrees <= 360, "heading_degrees", "must be between 0 and 360")
+	r.LocationSignature.Validate(v)
+}
+
+func (r *IngestLocationReq) ToModel() models.RideLocationUpdate {
+	return models.RideLocationUpdate{
+		DriverID:  r.DriverID,
+		RideID:    r.RideID,
+		TimeStamp: r.Timestamp,
+		Signature: r.LocationSignature.ToModel(),
+		Coordinates: models.Coordinates{
+			AccuracyMeters: r.AccuracyMeters,
+			SpeedKmh:       r.SpeedKmh,
+			HeadingDegrees: r.HeadingDegrees,
+			Location: models.Location{
+				Latitude:  r.Latitude,
+				Longitude: r.Longitude,
+			},
+		},
+	}
+}
+
+// checkCoordinates проверяет пару координат общим валидатором models.ValidateCoordinates:
+// выход за диапазон отмечается по каждому полю, (0, 0) и точка вне зоны обслуживания — по широте.
+func checkCoordinates(v *validator.Validator, latKey, lngKey string, lat, lng float64) {
+	err := models.ValidateCoordinates(lat, lng)
+	switch {
+	case err == nil:
+	case errors.Is(err, types.ErrInvalidCoordinates):
+		v.Check(lat >= -90 && lat <= 90, latKey, "must be between -90 and 90")
+		v.Check(lng >= -180 && lng <= 180, lngKey, "must be between -180 and 180")
+	default:
+		v.AddError(latKey, err.Error())
+	}
+}
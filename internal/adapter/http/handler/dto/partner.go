@@ -0,0 +1,131 @@
+package dto
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// maxPartnerDrivers ограничивает размер пачки регистрации водителей партнёра
+const maxPartnerDrivers = 100
+
+type CreatePartnerReq struct {
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+func (r *CreatePartnerReq) Validate(v *validator.Validator) {
+	v.Check(r.Name != "", "name", "must be provided")
+	v.Check(len(r.Name) <= 100, "name", "must be at most 100 characters")
+
+	u, err := url.Parse(r.WebhookURL)
+	v.Check(err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "", "webhook_url", "must be an absolute http(s) URL")
+}
+
+type PartnerDriverReq struct {
+	Email         string         `json:"email"`
+	Phone         string         `json:"phone,omitempty"`
+	Name          string         `json:"name"`
+	LicenseNumber string         `json:"license_number"`
+	Vehicle       models.Vehicle `json:"vehicle"`
+}
+
+type RegisterPartnerDriversReq struct {
+	Drivers []PartnerDriverReq `json:"drivers"`
+}
+
+func (r *RegisterPartnerDriversReq) Validate(v *validator.Validator) {
+	v.Check(len(r.Drivers) > 0, "drivers", "must be provided")
+	v.Check(len(r.Drivers) <= maxPartnerDrivers, "drivers", fmt.Sprintf("must contain at most %d drivers", maxPartnerDrivers))
+
+	seen := make(map[string]bool, len(r.Drivers))
+	for i, d := range r.Drivers {
+		key := fmt.Sprintf("drivers[%d]", i)
+
+		v.Check(validator.Matches(d.Email, validator.EmailRX), key+".email", "must be a valid email address")
+		v.Check(!seen[d.Email], key+".email", "must be unique within the batch")
+		seen[d.Email] = true
+
+		v.Check(d.Name != "", key+".name", "must be provided")
+		v.Check(len(d.Name) < 100, key+".name", "must be less than 100 characters")
+		v.Check(d.LicenseNumber != "", key+".license_number", "must be provided")
+		v.Check(len(d.LicenseNumber) < 10, key+".license_number", "must be less than 10 characters")
+
+		validateVehicle(v, key+".vehicle", d.Vehicle)
+	}
+}
+
+func (r *RegisterPartnerDriversReq) ToModel() []models.PartnerDriver {
+	drivers := make([]models.PartnerDriver, 0, len(r.Drivers))
+	for _, d := range r.Drivers {
+		drivers = append(drivers, models.PartnerDriver{
+			Email:         d.Email,
+			Phone:         d.Phone,
+			Name:          d.Name,
+			LicenseNumber: d.LicenseNumber,
+			Vehicle:       d.Vehicle,
+		})
+	}
+	return drivers
+}
+
+type PartnerStatusReq struct {
+	Status            types.PartnerDriverStatus `json:"status"`
+	RideID            *uuid.UUID                `json:"ride_id,omitempty"`
+	Location          *CoordinateUpdateReq      `json:"location,omitempty"`
+	ActualDistanceKm  float64                   `json:"actual_distance_km,omitempty"`
+	ActualDurationMin int                       `json:"actual_duration_minutes,omitempty"`
+}
+
+func (r *PartnerStatusReq) Validate(v *validator.Validator) {
+	v.Check(validator.PermittedValue(r.Status, types.PartnerDriverOnline, types.PartnerDriverOffline, types.PartnerDriverInProgress, types.PartnerDriverCompleted),
+		"status", "must be one of ONLINE, OFFLINE, IN_PROGRESS, COMPLETED")
+
+	if r.Status != types.PartnerDriverOffline {
+		if r.Location == nil {
+			v.AddError("location", "must be provided")
+		} else {
+			r.Location.Validate(v)
+		}
+	}
+
+	if r.Status == types.PartnerDriverInProgress || r.Status == types.PartnerDriverCompleted {
+		v.Check(r.RideID != nil && *r.RideID != uuid.UUID{}, "ride_id", "must be provided")
+	}
+
+	if r.Status == types.PartnerDriverCompleted {
+		v.Check(r.ActualDistanceKm > 0, "actual_distance_km", "must be positive float")
+		v.Check(r.ActualDurationMin > 0, "actual_duration_minutes", "must be positive integer")
+	}
+}
+
+func (r *PartnerStatusReq) ToModel(driverID uuid.UUID) models.PartnerStatusReport {
+	report := models.PartnerStatusReport{
+		DriverID:          driverID,
+		Status:            r.Status,
+		ActualDistanceKm:  r.ActualDistanceKm,
+		ActualDurationMin: r.ActualDurationMin,
+	}
+	if r.RideID != nil {
+		report.RideID = *r.RideID
+	}
+	if r.Location != nil && r.Location.Latitude != nil && r.Location.Longitude != nil {
+		report.Location = models.Location{
+			Latitude:  *r.Location.Latitude,
+			Longitude: *r.Location.Longitude,
+		}
+	}
+	return report
+}
+
+type PartnerOfferResponseReq struct {
+	Accepted *bool `json:"accepted"`
+}
+
+func (r *PartnerOfferResponseReq) Validate(v *validator.Validator) {
+	v.Check(r.Accepted != nil, "accepted", "must be provided")
+}
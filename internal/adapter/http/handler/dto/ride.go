@@ -1,9 +1,12 @@
 package dto
 
 import (
+	"strings"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
 )
@@ -17,6 +20,12 @@ type CreateRideRequest struct {
 	DestinationLongitude *float64 `json:"destination_longitude"`
 	DestinationAddress   string   `json:"destination_address"`
 	RideType             string   `json:"ride_type"`
+	// MEDICAL или ACCESSIBILITY, доступно пассажирам с разрешением администратора
+	PriorityBoarding string `json:"priority_boarding,omitempty"`
+	// CARD (по умолчанию) или WALLET
+	PaymentMethod string `json:"payment_method,omitempty"`
+	// Промокод на скидку, необязательный
+	PromoCode string `json:"promo_code,omitempty"`
 }
 
 // для создания поездки
@@ -31,9 +40,10 @@ func (r *CreateRideRequest) Validate(v *validator.Validator) {
 	// Pickup Location
 	v.Check(r.PickupAddress != "", "pickup_address", "must be provided")
 	v.Check(len(r.PickupAddress) <= 255, "pickup_address", "must not be more than 255 characters long")
+	// без ключей шифрования адрес хранится как есть и при чтении был бы принят за шифротекст
+	v.Check(!keyring.IsEncrypted(r.PickupAddress), "pickup_address", "must not start with \"enc:\"")
 	if r.PickupLatitude != nil && r.PickupLongitude != nil {
-		v.Check(*r.PickupLatitude >= -90 && *r.PickupLatitude <= 90, "pickup_latitude", "must be between -90 and 90")
-		v.Check(*r.PickupLongitude >= -180 && *r.PickupLongitude <= 180, "pickup_longitude", "must be between -180 and 180")
+		checkCoordinates(v, "pickup_latitude", "pickup_longitude", *r.PickupLatitude, *r.PickupLongitude)
 	} else {
 		v.Check(r.PickupLatitude != nil, "pickup_latitude", "must be provided")
 		v.Check(r.PickupLongitude != nil, "pickup_longitude", "must be provided")
@@ -42,12 +52,12 @@ func (r *CreateRideRequest) Validate(v *validator.Validator) {
 	// Destination Location
 	v.Check(r.DestinationAddress != "", "destination_address", "must be provided")
 	v.Check(len(r.DestinationAddress) <= 255, "destination_address", "must not be more than 255 characters long")
+	v.Check(!keyring.IsEncrypted(r.DestinationAddress), "destination_address", "must not start with \"enc:\"")
 	if r.DestinationLatitude != nil && r.DestinationLongitude != nil {
-		v.Check(*r.DestinationLatitude >= -90 && *r.DestinationLatitude <= 90, "destination_latitude", "must be between -90 and 90")
-		v.Check(*r.DestinationLongitude >= -180 && *r.DestinationLongitude <= 180, "destination_longitude", "must be between -180 and 180")
+		checkCoordinates(v, "destination_latitude", "destination_longitude", *r.DestinationLatitude, *r.DestinationLongitude)
 	} else {
 		v.Check(r.DestinationLatitude != nil, "destination_latitude", "must be provided")
-		v.Check(r.PickupLongitude != nil, "destination_longitude", "must be provided")
+		v.Check(r.DestinationLongitude != nil, "destination_longitude", "must be provided")
 	}
 
 	// RideType
@@ -55,6 +65,60 @@ func (r *CreateRideRequest) Validate(v *validator.Validator) {
 	if r.RideType != "" {
 		v.Check(validator.PermittedValue(r.RideType, "ECONOMY", "PREMIUM", "XL"), "ride_type", "must be one of ECONOMY, PREMIUM, or XL")
 	}
+
+	if r.PriorityBoarding != "" {
+		v.Check(types.IsValidPriorityBoarding(types.PriorityBoarding(r.PriorityBoarding)), "priority_boarding", "must be one of MEDICAL or ACCESSIBILITY")
+	}
+
+	if r.PaymentMethod != "" {
+		v.Check(validator.PermittedValue(r.PaymentMethod, types.PaymentCard.String(), types.PaymentWallet.String()), "payment_method", "must be one of CARD or WALLET")
+	}
+
+	v.Check(len(r.PromoCode) <= 32, "promo_code", "must not be more than 32 characters long")
+}
+
+type EstimateRideRequest struct {
+	PickupLatitude       *float64 `json:"pickup_latitude"`
+	PickupLongitude      *float64 `json:"pickup_longitude"`
+	DestinationLatitude  *float64 `json:"destination_latitude"`
+	DestinationLongitude *float64 `json:"destination_longitude"`
+	RideType             string   `json:"ride_type"`
+}
+
+// для предварительного расчёта поездки
+func (r *EstimateRideRequest) Validate(v *validator.Validator) {
+	if r.PickupLatitude != nil && r.PickupLongitude != nil {
+		checkCoordinates(v, "pickup_latitude", "pickup_longitude", *r.PickupLatitude, *r.PickupLongitude)
+	} else {
+		v.Check(r.PickupLatitude != nil, "pickup_latitude", "must be provided")
+		v.Check(r.PickupLongitude != nil, "pickup_longitude", "must be provided")
+	}
+
+	if r.DestinationLatitude != nil && r.DestinationLongitude != nil {
+		checkCoordinates(v, "destination_latitude", "destination_longitude", *r.DestinationLatitude, *r.DestinationLongitude)
+	} else {
+		v.Check(r.DestinationLatitude != nil, "destination_latitude", "must be provided")
+		v.Check(r.DestinationLongitude != nil, "destination_longitude", "must be provided")
+	}
+
+	v.Check(r.RideType != "", "ride_type", "must be provided")
+	if r.RideType != "" {
+		v.Check(validator.PermittedValue(r.RideType, "ECONOMY", "PREMIUM", "XL"), "ride_type", "must be one of ECONOMY, PREMIUM, or XL")
+	}
+}
+
+func (r *EstimateRideRequest) ToModel() *models.Ride {
+	return &models.Ride{
+		RideType: r.RideType,
+		Pickup: models.Location{
+			Latitude:  *r.PickupLatitude,
+			Longitude: *r.PickupLongitude,
+		},
+		Destination: models.Location{
+			Latitude:  *r.DestinationLatitude,
+			Longitude: *r.DestinationLongitude,
+		},
+	}
 }
 
 type CreateRideResponse struct {
@@ -64,6 +128,9 @@ type CreateRideResponse struct {
 	Estimated_fare       float64   `json:"estimated_fare"`
 	EstimatedDurationMin int       `json:"estimated_duration_minutes"`
 	EstimatedDistanceKm  float64   `json:"estimated_distance_km"`
+	PendingDispatch      bool      `json:"pending_dispatch"` // брокер недоступен, поиск водителя начнется после его восстановления
+	// подбор в зоне посадки приостановлен, поиск водителя начнется после окончания окна
+	MatchingDelay *models.MatchingDelay `json:"matching_delay,omitempty"`
 }
 
 type CancelRideRequest struct {
@@ -76,11 +143,22 @@ func (r *CancelRideRequest) Validate(v *validator.Validator) {
 	v.Check(len(r.Reason) <= 500, "reason", "must not be more than 500 characters long")
 }
 
+type ReassignRideRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r *ReassignRideRequest) Validate(v *validator.Validator) {
+	v.Check(r.Reason != "", "reason", "must be provided")
+	v.Check(len(r.Reason) <= 500, "reason", "must not be more than 500 characters long")
+}
+
 type CancelRideResponse struct {
 	RideID      uuid.UUID `json:"ride_id"`
 	Status      string    `json:"status"`
 	CancelledAt time.Time `json:"cancelled_at"`
 	Message     string    `json:"message"`
+	// CancellationFee — штраф за отмену после назначения водителя, 0 — отмена бесплатна
+	CancellationFee float64 `json:"cancellation_fee"`
 }
 
 func (r *CreateRideRequest) ToModel() (*models.Ride, error) {
@@ -90,8 +168,11 @@ func (r *CreateRideRequest) ToModel() (*models.Ride, error) {
 	}
 
 	return &models.Ride{
-		PassengerID: passengerUUID,
-		RideType:    r.RideType,
+		PassengerID:      passengerUUID,
+		RideType:         r.RideType,
+		PriorityBoarding: types.PriorityBoarding(r.PriorityBoarding),
+		PaymentMethod:    types.PaymentMethod(r.PaymentMethod),
+		PromoCode:        strings.TrimSpace(r.PromoCode),
 		Pickup: models.Location{
 			Latitude:  *r.PickupLatitude,
 			Longitude: *r.PickupLongitude,
@@ -104,3 +185,93 @@ func (r *CreateRideRequest) ToModel() (*models.Ride, error) {
 		},
 	}, nil
 }
+
+// TopUpWalletRequest — пополнение кошелька с карты пассажира
+type TopUpWalletRequest struct {
+	Amount *float64 `json:"amount"`
+}
+
+func (r *TopUpWalletRequest) Validate(v *validator.Validator) {
+	v.Check(r.Amount != nil, "amount", "must be provided")
+	if r.Amount != nil {
+		v.Check(*r.Amount > 0, "amount", "must be greater than zero")
+		v.Check(*r.Amount <= 100000, "amount", "must not be more than 100000")
+	}
+}
+
+func ValidateRideHistory(v *validator.Validator, f *models.RideHistoryFilter) {
+	for _, s := range f.Statuses {
+		v.Check(types.IsValidRideStatus(s), "status", "must contain only valid ride statuses")
+	}
+	v.Check(validator.Unique(f.Statuses), "status", "must not contain duplicates")
+
+	if f.From != nil && f.To != nil {
+		v.Check(f.From.Before(*f.To), "to", "must be after from")
+	}
+}
+
+// SubmitTelemetryRequest — показатели качества приложения за поездку
+type SubmitTelemetryRequest struct {
+	RideID            string   `json:"ride_id"`
+	Platform          string   `json:"platform"`
+	AppVersion        string   `json:"app_version"`
+	WSDisconnects     *int     `json:"ws_disconnects"`
+	MaxLocationGapSec *float64 `json:"max_location_gap_sec"`
+	OfferRoundTripMs  *int     `json:"offer_round_trip_ms"`
+}
+
+func (r *SubmitTelemetryRequest) Validate(v *validator.Validator) {
+	_, err := uuid.Parse(r.RideID)
+	v.Check(err == nil, "ride_id", "must be a valid uuid")
+	v.Check(validator.PermittedValue(strings.ToLower(r.Platform), "ios", "android", "web"), "platform", "must be ios, android or web")
+	v.Check(strings.TrimSpace(r.AppVersion) != "", "app_version", "must be provided")
+	v.Check(len(r.AppVersion) <= 32, "app_version", "must be at most 32 characters")
+
+	v.Check(r.WSDisconnects != nil, "ws_disconnects", "must be provided")
+	if r.WSDisconnects != nil {
+		v.Check(*r.WSDisconnects >= 0 && *r.WSDisconnects <= 1000, "ws_disconnects", "must be between 0 and 1000")
+	}
+	v.Check(r.MaxLocationGapSec != nil, "max_location_gap_sec", "must be provided")
+	if r.MaxLocationGapSec != nil {
+		v.Check(*r.MaxLocationGapSec >= 0 && *r.MaxLocationGapSec <= 86400, "max_location_gap_sec", "must be between 0 and 86400")
+	}
+	if r.OfferRoundTripMs != nil {
+		v.Check(*r.OfferRoundTripMs >= 0 && *r.OfferRoundTripMs <= 600000, "offer_round_trip_ms", "must be between 0 and 600000")
+	}
+}
+
+func (r *SubmitTelemetryRequest) ToModel(role string) models.AppTelemetry {
+	rideID, _ := uuid.Parse(r.RideID)
+	return models.AppTelemetry{
+		RideID:            rideID,
+		Role:              role,
+		Platform:          strings.ToLower(r.Platform),
+		AppVersion:        strings.TrimSpace(r.AppVersion),
+		WSDisconnects:     *r.WSDisconnects,
+		MaxLocationGapSec: *r.MaxLocationGapSec,
+		OfferRoundTripMs:  r.OfferRoundTripMs,
+	}
+}
+
+// RateRideRequest — оценка водителя после завершенной поездки
+type RateRideRequest struct {
+	Score   *int   `json:"score"`
+	Comment string `json:"comment,omitempty"`
+}
+
+func (r *RateRideRequest) Validate(v *validator.Validator) {
+	v.Check(r.Score != nil, "score", "must be provided")
+	if r.Score != nil {
+		v.Check(*r.Score >= 1 && *r.Score <= 5, "score", "must be between 1 and 5")
+	}
+	v.Check(len(r.Comment) <= 500, "comment", "must be at most 500 characters")
+}
+
+// CommentOrNil возвращает комментарий без пробелов по краям, пустой комментарий не сохраняется
+func (r *RateRideRequest) CommentOrNil() *string {
+	comment := strings.TrimSpace(r.Comment)
+	if comment == "" {
+		return nil
+	}
+	return &comment
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// GetEarnings godoc
+// @Summary      Get driver earnings report
+// @Description  Earnings per day (last 30), week (last 12, starting Monday) or month (last 12), UTC, oldest first: session earnings, completed rides, online hours and average earnings per ride, plus totals. Available to the driver and to admins
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        period query string false "daily, weekly or monthly" default(daily)
+// @Success      200 {object} models.EarningsReport "Earnings report"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/earnings [get]
+func (h *Driver) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_earnings")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	// водитель видит только свой заработок, админ - любого водителя
+	if user.Role != types.RoleAdmin.String() && user.ID != driverID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	v := validator.New()
+	period := types.EarningsPeriod(readString(r.URL.Query(), "period", types.EarningsDaily.String()))
+	v.Check(validator.PermittedValue(period, types.EarningsDaily, types.EarningsWeekly, types.EarningsMonthly), "period", "must be daily, weekly or monthly")
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	report, err := h.service.EarningsReport(ctx, driverID, period)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get earnings report", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, report, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
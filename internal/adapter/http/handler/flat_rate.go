@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// ListFlatRates godoc
+// @Summary      List flat rates
+// @Description  List flat-rate corridors and zone minimum fares that are effective now or scheduled
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Flat rates"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/flat-rates [get]
+func (h *Admin) ListFlatRates(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_list_flat_rates")
+
+	rates, err := h.s.FlatRates(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to list flat rates", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"flat_rates": rates}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetFlatRateHistory godoc
+// @Summary      Get flat rate versions
+// @Description  Get every version of a flat rate, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        code path string true "Flat rate code, e.g. ALA-AIRPORT"
+// @Success      200 {object} map[string]interface{} "Flat rate versions"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Flat rate not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/flat-rates/{code} [get]
+func (h *Admin) GetFlatRateHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_flat_rate_history")
+
+	code := strings.ToUpper(r.PathValue("code"))
+
+	versions, err := h.s.FlatRateHistory(ctx, code)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get flat rate history", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"code": code, "versions": versions}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// SetFlatRate godoc
+// @Summary      Create a flat rate version
+// @Description  Create a new version of a flat-rate corridor (FLAT) or a zone minimum fare (MINIMUM). The current version is closed at effective_from; a FLAT rate replaces the formula fare with surge and night multipliers, a MINIMUM rate raises the fare of rides from the zone.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        code path string true "Flat rate code, e.g. ALA-AIRPORT"
+// @Param        request body dto.SetFlatRateRequest true "Flat rate version"
+// @Success      201 {object} map[string]interface{} "Created flat rate version"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      409 {object} map[string]interface{} "A version is already scheduled at or after effective_from"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/flat-rates/{code} [put]
+func (h *Admin) SetFlatRate(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_set_flat_rate")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	code := r.PathValue("code")
+
+	var req dto.SetFlatRateRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v, code)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	rate := req.ToModel(code, user.ID)
+	if err := h.s.SetFlatRate(ctx, rate); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to set flat rate", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"flat_rate": rate}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// EndFlatRate godoc
+// @Summary      End a flat rate
+// @Description  Stop a flat rate now: the current version is closed and scheduled versions are removed. Version history is kept.
+// @Tags         admin
+// @Produce      json
+// @Param        code path string true "Flat rate code, e.g. ALA-AIRPORT"
+// @Success      200 {object} map[string]interface{} "Flat rate ended"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Flat rate not found or already ended"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/flat-rates/{code} [delete]
+func (h *Admin) EndFlatRate(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_end_flat_rate")
+
+	code := strings.ToUpper(r.PathValue("code"))
+
+	if err := h.s.EndFlatRate(ctx, code); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to end flat rate", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"code": code, "message": "flat rate ended"}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -1,27 +1,43 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/Temutjin2k/ride-hail-system/pkg/health"
+	"github.com/Temutjin2k/ride-hail-system/pkg/incident"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 )
 
 type Health struct {
 	serviceName string
+	incident    *incident.Switch
+	checks      *health.Registry
 	log         logger.Logger
 }
 
-func NewHealth(serviceName string, log logger.Logger) *Health {
+func NewHealth(serviceName string, checks *health.Registry, log logger.Logger) *Health {
 	return &Health{
 		serviceName: serviceName,
+		checks:      checks,
 		log:         log,
 	}
 }
 
+// RegisterCheck добавляет зависимость в пробы liveness и readiness
+func (a *Health) RegisterCheck(name string, critical bool, fn health.CheckFunc) {
+	a.checks.Register(name, critical, fn)
+}
+
+// SetIncident добавляет в ответ состояние режима только для чтения
+func (a *Health) SetIncident(sw *incident.Switch) {
+	a.incident = sw
+}
+
 // HealthCheck godoc
 // @Summary      Health Check
-// @Description  Returns the health status of the service
+// @Description  Returns the health status of the service. While the primary database is unhealthy the status is "degraded" and the service is read-only: reads are served from the replica and caches, writes get 503.
 // @Tags         Health
 // @Accept       json
 // @Produce      json
@@ -37,9 +53,64 @@ func (a *Health) HealthCheck(w http.ResponseWriter, r *http.Request) {
 			"service-name": a.serviceName,
 		},
 	}
+	if a.incident != nil {
+		state := a.incident.State()
+		if state.ReadOnly {
+			response["status"] = "degraded"
+		}
+		response["database"] = state
+	}
 
 	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
 		a.log.Error(ctx, "healthcheck", err)
 		return
 	}
 }
+
+// Live godoc
+// @Summary      Liveness probe
+// @Description  Checks dependencies and returns 503 when a critical one (Postgres, RabbitMQ, WebSocket hub) has been down longer than HEALTH_LIVENESS_GRACE, so the orchestrator restarts the instance.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  health.Report
+// @Failure      503  {object}  health.Report
+// @Router       /health/live [get]
+func (a *Health) Live(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "health_live")
+	a.writeReport(ctx, w, a.checks.Live(ctx))
+}
+
+// Ready godoc
+// @Summary      Readiness probe
+// @Description  Checks dependencies with per-dependency latency and returns 503 while a critical one is down, so the instance is taken out of load balancing.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  health.Report
+// @Failure      503  {object}  health.Report
+// @Router       /health/ready [get]
+func (a *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "health_ready")
+	a.writeReport(ctx, w, a.checks.Ready(ctx))
+}
+
+func (a *Health) writeReport(ctx context.Context, w http.ResponseWriter, report health.Report) {
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+		a.log.Warn(ctx, "health probe failed", "checks", failedChecks(report))
+	}
+
+	if err := writeJSON(w, status, report, nil); err != nil {
+		a.log.Error(ctx, "failed to write health report", err)
+	}
+}
+
+func failedChecks(report health.Report) []string {
+	var failed []string
+	for name, res := range report.Checks {
+		if res.Status == health.StatusDown {
+			failed = append(failed, name+": "+res.Error)
+		}
+	}
+	return failed
+}
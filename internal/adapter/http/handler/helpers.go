@@ -11,9 +11,11 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	t "github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
 	"github.com/jackc/pgx/v5"
 )
@@ -106,6 +108,18 @@ func GetCode(err error) int {
 		t.ErrDriverAlreadyOnline,
 		t.ErrLicenseAlreadyExists,
 		t.ErrInvalidRideStatus,
+		t.ErrCannotBlockSelf,
+		t.ErrUnknownAnomalyKind,
+		t.ErrUnknownCity,
+		t.ErrInvalidCoordinates,
+		t.ErrNullIslandCoordinates,
+		t.ErrOutsideServiceArea,
+		t.ErrUnknownOpsAction,
+		t.ErrInvalidOpsParams,
+		t.ErrInvalidPromoDiscount,
+		t.ErrInvalidPayoutAmount,
+		t.ErrInvalidPayoutEvent,
+		t.ErrInvalidPhoneCode,
 	):
 		return http.StatusBadRequest
 
@@ -116,9 +130,27 @@ func GetCode(err error) int {
 		t.ErrDriverIDNotExist,
 		t.ErrNoCoordinates,
 		t.ErrRideNotFound,
+		t.ErrRideHasNoDriver,
+		t.ErrReceiptNotFound,
+		t.ErrVehicleNotFound,
 		t.ErrDriverLocationNotFound,
 		t.ErrNotFound,
 		t.ErrDriversNotFound,
+		t.ErrRideNotExistedAt,
+		t.ErrPassengerNotBlocked,
+		t.ErrAnomalyNotFound,
+		t.ErrBroadcastNotFound,
+		t.ErrOfferNotFound,
+		t.ErrChangeRequestNotFound,
+		t.ErrOpsActionNotFound,
+		t.ErrStateExportNotFound,
+		t.ErrPromoNotFound,
+		t.ErrFailedMessageNotFound,
+		t.ErrNoCurrentRide,
+		t.ErrFlatRateNotFound,
+		t.ErrBlackoutNotFound,
+		t.ErrPayoutNotFound,
+		t.ErrPushTokenNotFound,
 		sql.ErrNoRows,
 		pgx.ErrNoRows,
 	):
@@ -130,6 +162,7 @@ func GetCode(err error) int {
 		t.ErrDriverMustBeAvailable,
 		authSvc.ErrNotUniqueEmail,
 		t.ErrDriverAlreadyOnRide,
+		t.ErrVehicleExists,
 		t.ErrRideDriverMismatch,
 		t.ErrRideNotArrived,
 		t.ErrDriverMustBeArrived,
@@ -139,6 +172,26 @@ func GetCode(err error) int {
 		t.ErrPassengerHasActiveRide,
 		t.ErrRideStatusNotMatched,
 		t.ErrRideAlreadyHasDriver,
+		t.ErrBlocklistFull,
+		t.ErrOutsideOperatingHours,
+		t.ErrMatchingPaused,
+		t.ErrRideNotCompleted,
+		t.ErrRideAlreadyRated,
+		t.ErrPromoCodeExists,
+		t.ErrPromoInactive,
+		t.ErrPromoExpired,
+		t.ErrPromoExhausted,
+		t.ErrFailedMessageNoQueue,
+		t.ErrFlatRateScheduled,
+		t.ErrRideCannotBeReassigned,
+		t.ErrLocationDiscarded,
+		t.ErrInsufficientBalance,
+		t.ErrPayoutLimitExceeded,
+		t.ErrPayoutInProgress,
+		t.ErrPhoneNotSet,
+		t.ErrPhoneAlreadyVerified,
+		t.ErrPhoneCodeNotRequested,
+		t.ErrPhoneCodeExpired,
 	):
 		return http.StatusConflict
 
@@ -147,11 +200,17 @@ func GetCode(err error) int {
 		authSvc.ErrInvalidCredentials,
 		authSvc.ErrInvalidToken,
 		authSvc.ErrExpToken,
+		authSvc.ErrRevokedToken,
+		t.ErrLocationSignatureRequired,
+		t.ErrInvalidLocationSignature,
+		t.ErrLocationSignatureExpired,
+		t.ErrInvalidAPIKey,
+		t.ErrInvalidPayoutSignature,
 	):
 		return http.StatusUnauthorized
 
 	// 403 Forbidden — действия запрещены
-	case oneOf(err, authSvc.ErrCannotCreateAdmin, authSvc.ErrActionForbidden):
+	case oneOf(err, authSvc.ErrCannotCreateAdmin, authSvc.ErrActionForbidden, t.ErrDriverNotInFleet, t.ErrPriorityBoardingDenied, t.ErrPayoutNotAllowed, t.ErrPhoneNotVerified):
 		return http.StatusForbidden
 
 	// 408 Request Timeout — таймауты ожидания
@@ -161,6 +220,14 @@ func GetCode(err error) int {
 	):
 		return http.StatusRequestTimeout
 
+	// 429 Too Many Requests — повторный запрос раньше разрешенного или исчерпаны попытки
+	case oneOf(err, t.ErrPhoneCodeTooSoon, t.ErrPhoneCodeAttemptsExceeded):
+		return http.StatusTooManyRequests
+
+	// 503 Service Unavailable — внешний сервис не подключен или база в режиме только для чтения
+	case oneOf(err, t.ErrPaymentUnavailable, t.ErrDeadLetterUnavailable, t.ErrReadOnlyMode, t.ErrPayoutUnavailable, t.ErrSMSUnavailable):
+		return http.StatusServiceUnavailable
+
 	// 500 Internal Server Error — все остальные случаи
 	default:
 		return http.StatusInternalServerError
@@ -178,6 +245,15 @@ func oneOf(err error, targets ...error) bool {
 
 // The readString() helper returns a string value from the query string, or the provided
 // default value if no matching key could be found.
+// bearerToken возвращает токен из заголовка Authorization, пусто — заголовка нет или он другого формата
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return token
+}
+
 func readString(qs url.Values, key string, defaultValue string) string {
 	// Extract the value for a given key from the query string. If no key exists this
 	// will return the empty string "".
@@ -216,3 +292,83 @@ func readInt(qs url.Values, key string, defaultValue int, v *validator.Validator
 	// Otherwise, return the converted integer value.
 	return i
 }
+
+// The readBool() helper reads a boolean value from the query string. If no matching key
+// could be found it returns the provided default value, invalid values are recorded in
+// the provided Validator instance.
+func readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
+// The readCSV() helper reads a string value from the query string and then splits it
+// into a slice on the comma character. If no matching key could be found, it returns
+// the provided default value.
+func readCSV(qs url.Values, key string, defaultValue []string) []string {
+	csv := qs.Get(key)
+
+	if csv == "" {
+		return defaultValue
+	}
+
+	return strings.Split(csv, ",")
+}
+
+// The readFloat() helper reads an optional float from the query string. nil means the
+// key is missing, invalid values are recorded in the provided Validator instance.
+func readFloat(qs url.Values, key string, v *validator.Validator) *float64 {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		v.AddError(key, "must be a number")
+		return nil
+	}
+
+	return &f
+}
+
+// The readTime() helper reads an optional RFC 3339 timestamp from the query string.
+func readTime(qs url.Values, key string, v *validator.Validator) *time.Time {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		v.AddError(key, "must be an RFC 3339 timestamp")
+		return nil
+	}
+
+	return &ts
+}
+
+// The readUUID() helper reads an optional UUID from the query string.
+func readUUID(qs url.Values, key string, v *validator.Validator) *uuid.UUID {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		v.AddError(key, "must be a valid uuid")
+		return nil
+	}
+
+	return &id
+}
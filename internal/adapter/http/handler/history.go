@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// RideHistoryListing - параметры пагинации, сортировки и фильтров GET /rides и GET /drivers/{driver_id}/rides
+var RideHistoryListing = models.ListOptions{
+	DefaultSort:  "-requested_at",
+	SortSafelist: []string{"requested_at", "-requested_at", "fare", "-fare"},
+	FilterKeys:   []string{"status", "from", "to"},
+}
+
+// SessionHistoryListing - параметры пагинации и сортировки GET /drivers/{driver_id}/sessions
+var SessionHistoryListing = models.ListOptions{
+	DefaultSort:  "-started_at",
+	SortSafelist: []string{"started_at", "-started_at"},
+}
+
+// GetRideHistory godoc
+// @Summary      Get passenger ride history
+// @Description  Past and current rides of the authenticated passenger. Test rides are not listed
+// @Tags         ride
+// @Produce      json
+// @Param        status query string false "Comma-separated ride statuses"
+// @Param        from query string false "Requested at or after, RFC3339"
+// @Param        to query string false "Requested before, RFC3339"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Page size" default(20)
+// @Param        sort query string false "requested_at or fare, prefix - for descending" default(-requested_at)
+// @Success      200 {object} models.RideHistoryResponse "Rides"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /rides [get]
+func (h *Ride) GetRideHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_passenger_ride_history")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	filters, ok := models.FiltersFromContext(ctx)
+	if !ok {
+		h.l.Warn(ctx, "pagination filters are missing in context")
+		internalErrorResponse(w, "intenal error")
+		return
+	}
+
+	v := validator.New()
+	filter := readRideHistoryFilter(filters, v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	history, err := h.ride.History(ctx, user.ID, filter, filters)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ride history", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, history, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// GetRideHistory godoc
+// @Summary      Get driver ride history
+// @Description  Rides assigned to the driver. Test rides are not listed
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        status query string false "Comma-separated ride statuses"
+// @Param        from query string false "Requested at or after, RFC3339"
+// @Param        to query string false "Requested before, RFC3339"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Page size" default(20)
+// @Param        sort query string false "requested_at or fare, prefix - for descending" default(-requested_at)
+// @Success      200 {object} models.RideHistoryResponse "Rides"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/rides [get]
+func (h *Driver) GetRideHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_ride_history")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID != driverID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	filters, ok := models.FiltersFromContext(ctx)
+	if !ok {
+		h.l.Warn(ctx, "pagination filters are missing in context")
+		internalErrorResponse(w, "intenal error")
+		return
+	}
+
+	v := validator.New()
+	filter := readRideHistoryFilter(filters, v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	history, err := h.service.RideHistory(ctx, driverID, filter, filters)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ride history", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, history, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// GetSessionHistory godoc
+// @Summary      Get driver session history
+// @Description  Driver's online sessions, newest first: start and end time, duration, rides completed, earnings and distance driven (from the location history). The current session has no ended_at
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Page size" default(20)
+// @Param        sort query string false "started_at, prefix - for descending" default(-started_at)
+// @Success      200 {object} models.DriverSessionHistory "Sessions"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/sessions [get]
+func (h *Driver) GetSessionHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_session_history")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	// водитель видит только свои сессии, админ - любого водителя
+	if user.Role != types.RoleAdmin.String() && user.ID != driverID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	filters, ok := models.FiltersFromContext(ctx)
+	if !ok {
+		h.l.Warn(ctx, "pagination filters are missing in context")
+		internalErrorResponse(w, "intenal error")
+		return
+	}
+
+	history, err := h.service.SessionHistory(ctx, driverID, filters)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get session history", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, history, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// readRideHistoryFilter разбирает фильтры истории поездок, разрешенные RideHistoryListing
+func readRideHistoryFilter(filters models.Filters, v *validator.Validator) models.RideHistoryFilter {
+	var filter models.RideHistoryFilter
+
+	if raw := filters.Param("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			filter.Statuses = append(filter.Statuses, types.RideStatus(strings.ToUpper(strings.TrimSpace(status))))
+		}
+	}
+	for key, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := filters.Param(key)
+		if raw == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			v.AddError(key, "must be an RFC 3339 timestamp")
+			continue
+		}
+		*dst = &ts
+	}
+
+	dto.ValidateRideHistory(v, &filter)
+	return filter
+}
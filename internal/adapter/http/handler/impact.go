@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// maxImpactMonths — сколько месяцев назад можно смотреть углеродный след
+const maxImpactMonths = 24
+
+// GetImpact godoc
+// @Summary      Get passenger carbon footprint
+// @Description  Monthly CO2 estimate (UTC) of completed rides: distance multiplied by the emission factor of the vehicle class. Includes the current month.
+// @Tags         ride
+// @Produce      json
+// @Param        passenger_id path string true "Passenger ID"
+// @Param        months query int false "Number of months, 1-24, defaults to 12"
+// @Success      200 {object} models.PassengerImpact "Carbon footprint"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /passengers/{passenger_id}/impact [get]
+func (h *Ride) GetImpact(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_passenger_impact")
+
+	passengerID, err := uuid.Parse(r.PathValue("passenger_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid passenger uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != passengerID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	v := validator.New()
+	months := readInt(r.URL.Query(), "months", 12, v)
+	v.Check(months >= 1 && months <= maxImpactMonths, "months", "must be between 1 and 24")
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	impact, err := h.ride.Impact(ctx, passengerID, months)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get passenger impact", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, impact, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// AdminCancelRide godoc
+// @Summary      Force-cancel a ride
+// @Description  Cancel a stuck ride in any status except COMPLETED and CANCELLED. No cancellation fee is charged. The driver is released to AVAILABLE (unless offline), and both the passenger and the driver are notified via WebSocket.
+// @Description  With dry_run=true the cancellation runs in a rolled back transaction: nothing is published, nobody is notified, and only the would-be effects are returned.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Param        request body dto.CancelRideRequest true "Cancellation reason"
+// @Param        dry_run query bool false "Validate and return effects without committing"
+// @Success      200 {object} map[string]interface{} "Dry run effects"
+// @Success      202 {object} map[string]interface{} "Ride cancelled"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Ride not found"
+// @Failure      409 {object} map[string]interface{} "Ride is already completed or cancelled"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/rides/{ride_id}/cancel [post]
+func (h *Ride) AdminCancelRide(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_cancel_ride")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	var request dto.CancelRideRequest
+	if err := readJSON(w, r, &request); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	request.Validate(v)
+	dryRun := readBool(r.URL.Query(), "dry_run", false, v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if dryRun {
+		effects, err := h.ride.AdminCancelDryRun(ctx, rideID, request.Reason)
+		if err != nil {
+			h.l.Error(wrap.ErrorCtx(ctx, err), "failed to cancel ride", err)
+			errorResponse(w, GetCode(err), err.Error())
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, envelope{"ride_id": rideID, "effects": effects}, nil); err != nil {
+			h.l.Error(ctx, "failed to write response", err)
+			internalErrorResponse(w, err.Error())
+		}
+		return
+	}
+
+	ride, err := h.ride.AdminCancel(ctx, rideID, user.ID, request.Reason)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to cancel ride", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"ride_id":      ride.ID,
+		"status":       ride.Status,
+		"cancelled_at": ride.CancelledAt,
+		"message":      ride.CancellationReason,
+	}
+	if err := writeJSON(w, http.StatusAccepted, response, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// ReassignRide godoc
+// @Summary      Reassign a ride
+// @Description  Release the assigned driver of a MATCHED, EN_ROUTE or ARRIVED ride and restart matching without that driver. The ride returns to REQUESTED, the released driver becomes AVAILABLE (unless offline), and both parties are notified via WebSocket.
+// @Description  With dry_run=true the reassignment runs in a rolled back transaction: no status or RideRequested message is published, nobody is notified, and only the would-be effects are returned.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Param        request body dto.ReassignRideRequest true "Reassignment reason"
+// @Param        dry_run query bool false "Validate and return effects without committing"
+// @Success      200 {object} map[string]interface{} "Dry run effects"
+// @Success      202 {object} map[string]interface{} "Matching restarted"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Ride not found"
+// @Failure      409 {object} map[string]interface{} "Ride has no driver or the passenger is already picked up"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/rides/{ride_id}/reassign [post]
+func (h *Ride) ReassignRide(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_reassign_ride")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	var request dto.ReassignRideRequest
+	if err := readJSON(w, r, &request); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	request.Validate(v)
+	dryRun := readBool(r.URL.Query(), "dry_run", false, v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if dryRun {
+		effects, err := h.ride.ReassignDryRun(ctx, rideID, request.Reason)
+		if err != nil {
+			h.l.Error(wrap.ErrorCtx(ctx, err), "failed to reassign ride", err)
+			errorResponse(w, GetCode(err), err.Error())
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, envelope{"ride_id": rideID, "effects": effects}, nil); err != nil {
+			h.l.Error(ctx, "failed to write response", err)
+			internalErrorResponse(w, err.Error())
+		}
+		return
+	}
+
+	ride, err := h.ride.Reassign(ctx, rideID, user.ID, request.Reason)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to reassign ride", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, envelope{"ride_id": ride.ID, "status": ride.Status, "message": "driver released, matching restarted"}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+type LocationService interface {
+	Ingest(ctx context.Context, data models.RideLocationUpdate) (coordinateID uuid.UUID, err error)
+}
+
+// Location — HTTP API location-service: прием координат от водителей
+// и внутренний API для других сервисов
+type Location struct {
+	service LocationService
+	l       logger.Logger
+}
+
+func NewLocation(service LocationService, l logger.Logger) *Location {
+	return &Location{
+		service: service,
+		l:       l,
+	}
+}
+
+// UpdateLocation godoc
+// @Summary      Update driver location
+// @Description  Update driver's current GPS location. Served by location-service, same contract as driver-service
+// @Tags         location
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.UpdateLocationReq true "Location update with coordinates"
+// @Param        X-Device-ID header string false "Device ID the signing secret was issued for"
+// @Param        X-Signature-Timestamp header integer false "Unix seconds included in the signature"
+// @Param        X-Signature header string false "Hex HMAC-SHA256 of timestamp, latitude and longitude (see README)"
+// @Success      200 {object} map[string]interface{} "Location updated successfully"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/location [post]
+func (h *Location) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	serveLocationUpdate(w, r, h.l, h.service.Ingest)
+}
+
+// Ingest godoc
+// @Summary      Ingest driver location (internal)
+// @Description  Stores a driver coordinate forwarded by another service. The device signature, if any, is verified here
+// @Tags         location
+// @Accept       json
+// @Produce      json
+// @Param        X-Internal-Token header string true "Shared internal API secret"
+// @Param        request body dto.IngestLocationReq true "Driver coordinate"
+// @Success      200 {object} map[string]interface{} "Coordinate stored"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Router       /internal/locations [post]
+func (h *Location) Ingest(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "ingest_driver_location")
+
+	var req dto.IngestLocationReq
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	coordinateID, err := h.service.Ingest(ctx, req.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to ingest driver location", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"coordinate_id": coordinateID}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// serveLocationUpdate обрабатывает POST /drivers/{driver_id}/location: один контракт
+// в driver-service и location-service, отличается только тем, кто записывает координату
+func serveLocationUpdate(w http.ResponseWriter, r *http.Request, l logger.Logger, ingest func(ctx context.Context, data models.RideLocationUpdate) (uuid.UUID, error)) {
+	ctx := wrap.WithAction(r.Context(), "update_driver_location")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	// провереяем что драйвер хочет изменить именно себя
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	var req dto.UpdateLocationReq
+	if err := readJSON(w, r, &req); err != nil {
+		l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sig := dto.LocationSignature{
+		DeviceID:  r.Header.Get("X-Device-ID"),
+		Signature: r.Header.Get("X-Signature"),
+	}
+	if ts := r.Header.Get("X-Signature-Timestamp"); ts != "" {
+		sig.Timestamp, err = strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid X-Signature-Timestamp header")
+			return
+		}
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	sig.Validate(v)
+	if !v.Valid() {
+		l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	now := time.Now()
+
+	coordinateID, err := ingest(ctx, models.RideLocationUpdate{
+		DriverID:  driverID,
+		RideID:    nil,
+		TimeStamp: now,
+		Signature: sig.ToModel(),
+		Coordinates: models.Coordinates{
+			AccuracyMeters: req.AccuracyMeters,
+			SpeedKmh:       req.SpeedKmH,
+			HeadingDegrees: req.HeadingDegrees,
+			Location: models.Location{
+				Latitude:  *req.Latitude,
+				Longitude: *req.Longitude,
+			},
+		},
+	})
+	if err != nil {
+		l.Error(wrap.ErrorCtx(ctx, err), "failed to update driver location", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"coordinate_id": coordinateID,
+		"updated_at":    now,
+	}
+
+	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
+		l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+
+	l.Info(ctx, "driver location has been updated", "driver_id", driverID, "coordinate_id", coordinateID)
+}
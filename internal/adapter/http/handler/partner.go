@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+type PartnerService interface {
+	CreatePartner(ctx context.Context, name, webhookURL string) (*models.PartnerCredentials, error)
+	Authenticate(ctx context.Context, apiKey string) (*models.Partner, error)
+	RegisterDrivers(ctx context.Context, partnerID uuid.UUID, drivers []models.PartnerDriver) []models.PartnerDriverResult
+	ReportStatus(ctx context.Context, partnerID uuid.UUID, report models.PartnerStatusReport) error
+	ReportLocation(ctx context.Context, partnerID uuid.UUID, update models.RideLocationUpdate) (tracked bool, coordinateID uuid.UUID, err error)
+	RespondOffer(ctx context.Context, partnerID, offerID uuid.UUID, accepted bool) error
+}
+
+type Partner struct {
+	service PartnerService
+	l       logger.Logger
+}
+
+func NewPartner(service PartnerService, l logger.Logger) *Partner {
+	return &Partner{
+		service: service,
+		l:       l,
+	}
+}
+
+// Authenticate используется middleware partner API
+func (h *Partner) Authenticate(ctx context.Context, apiKey string) (*models.Partner, error) {
+	return h.service.Authenticate(ctx, apiKey)
+}
+
+// CreatePartner godoc
+// @Summary      Create fleet partner
+// @Description  Register a taxi fleet company for the partner API. The API key and webhook secret are returned only once.
+// @Tags         partner
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreatePartnerReq true "Partner name and webhook URL"
+// @Success      201 {object} map[string]interface{} "Partner with credentials"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /partners [post]
+func (h *Partner) CreatePartner(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "create_partner")
+
+	var req dto.CreatePartnerReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	creds, err := h.service.CreatePartner(ctx, req.Name, req.WebhookURL)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to create partner", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"partner": creds.Partner, "api_key": creds.APIKey, "webhook_secret": creds.WebhookSecret}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// RegisterDrivers godoc
+// @Summary      Register fleet drivers in bulk
+// @Description  Create user accounts and driver profiles for the partner fleet. Each driver is registered independently; the response contains a result per driver.
+// @Tags         partner
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RegisterPartnerDriversReq true "Drivers to register (max 100)"
+// @Success      200 {object} map[string]interface{} "Per-driver results"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Invalid API key"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     PartnerAPIKey
+// @Router       /partner/drivers [post]
+func (h *Partner) RegisterDrivers(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "partner_register_drivers")
+	partner := models.PartnerFromContext(ctx)
+
+	var req dto.RegisterPartnerDriversReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	results := h.service.RegisterDrivers(ctx, partner.ID, req.ToModel())
+
+	if err := writeJSON(w, http.StatusOK, envelope{"results": results}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// ReportStatus godoc
+// @Summary      Report fleet driver status
+// @Description  Apply a status reported by the partner for its driver: ONLINE, OFFLINE, IN_PROGRESS (ride started) or COMPLETED (ride finished).
+// @Tags         partner
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.PartnerStatusReq true "Driver status"
+// @Success      200 {object} map[string]interface{} "Status applied"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Invalid API key"
+// @Failure      403 {object} map[string]interface{} "Driver is not in the partner fleet"
+// @Failure      409 {object} map[string]interface{} "Status conflicts with driver state"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     PartnerAPIKey
+// @Router       /partner/drivers/{driver_id}/status [post]
+func (h *Partner) ReportStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "partner_report_status")
+	partner := models.PartnerFromContext(ctx)
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	var req dto.PartnerStatusReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.service.ReportStatus(ctx, partner.ID, req.ToModel(driverID)); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to apply partner driver status", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"driver_id": driverID, "status": req.Status}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// ReportLocation godoc
+// @Summary      Report fleet driver location
+// @Description  Save the driver location. During an active ride the location is used for ride tracking (arrival at pickup) and the request returns 202.
+// @Tags         partner
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.UpdateLocationReq true "Location update with coordinates"
+// @Success      200 {object} map[string]interface{} "Location saved"
+// @Success      202 {object} map[string]interface{} "Location passed to ride tracking"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Invalid API key"
+// @Failure      403 {object} map[string]interface{} "Driver is not in the partner fleet"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     PartnerAPIKey
+// @Router       /partner/drivers/{driver_id}/location [post]
+func (h *Partner) ReportLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "partner_report_location")
+	partner := models.PartnerFromContext(ctx)
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	var req dto.UpdateLocationReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	tracked, coordinateID, err := h.service.ReportLocation(ctx, partner.ID, models.RideLocationUpdate{
+		DriverID:  driverID,
+		TimeStamp: time.Now(),
+		Coordinates: models.Coordinates{
+			AccuracyMeters: req.AccuracyMeters,
+			SpeedKmh:       req.SpeedKmH,
+			HeadingDegrees: req.HeadingDegrees,
+			Location: models.Location{
+				Latitude:  *req.Latitude,
+				Longitude: *req.Longitude,
+			},
+		},
+	})
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to report partner driver location", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	status, response := http.StatusOK, envelope{"coordinate_id": coordinateID}
+	if tracked {
+		status, response = http.StatusAccepted, envelope{"tracked": true}
+	}
+
+	if err := writeJSON(w, status, response, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// RespondOffer godoc
+// @Summary      Respond to a ride offer
+// @Description  Accept or decline a ride offer delivered to the partner webhook. Offers expire 30 seconds after delivery.
+// @Tags         partner
+// @Accept       json
+// @Produce      json
+// @Param        offer_id path string true "Offer ID"
+// @Param        request body dto.PartnerOfferResponseReq true "Offer decision"
+// @Success      200 {object} map[string]interface{} "Response recorded"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Invalid API key"
+// @Failure      404 {object} map[string]interface{} "Offer not found or expired"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     PartnerAPIKey
+// @Router       /partner/offers/{offer_id}/response [post]
+func (h *Partner) RespondOffer(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "partner_respond_offer")
+	partner := models.PartnerFromContext(ctx)
+
+	offerID, err := uuid.Parse(r.PathValue("offer_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid offer uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid offer uuid format")
+		return
+	}
+
+	var req dto.PartnerOfferResponseReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.service.RespondOffer(ctx, partner.ID, offerID, *req.Accepted); err != nil {
+		h.l.Warn(ctx, "failed to record partner offer response", "error", err.Error())
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"offer_id": offerID, "accepted": *req.Accepted}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/webhook"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// maxWebhookBody — предел тела вебхука провайдера выплат
+const maxWebhookBody = 64 << 10
+
+type PayoutService interface {
+	Balance(ctx context.Context, driverID uuid.UUID) (*models.DriverBalance, error)
+	Payouts(ctx context.Context, driverID uuid.UUID) ([]models.Payout, error)
+	RequestPayout(ctx context.Context, driverID uuid.UUID, amount *float64) (*models.Payout, error)
+	HandleWebhook(ctx context.Context, body []byte, signature string) error
+}
+
+type Payout struct {
+	service PayoutService
+	l       logger.Logger
+}
+
+func NewPayout(service PayoutService, l logger.Logger) *Payout {
+	return &Payout{
+		service: service,
+		l:       l,
+	}
+}
+
+// GetBalance godoc
+// @Summary      Get driver balance
+// @Description  Balance available for instant payout and the last 20 balance ledger entries (newest first). Ride earnings and cancellation fees are credited, payouts are debited with their fee, failed payouts are reversed
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} models.DriverBalance "Balance"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/balance [get]
+func (h *Payout) GetBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_balance")
+
+	driverID, ok := h.ownDriver(w, r)
+	if !ok {
+		return
+	}
+
+	balance, err := h.service.Balance(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver balance", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, balance, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// GetPayouts godoc
+// @Summary      Get driver payouts
+// @Description  The last 20 instant payouts of the driver (newest first)
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} map[string]interface{} "Payouts"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/payouts [get]
+func (h *Payout) GetPayouts(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_payouts")
+
+	driverID, ok := h.ownDriver(w, r)
+	if !ok {
+		return
+	}
+
+	payouts, err := h.service.Payouts(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver payouts", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"payouts": payouts}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// RequestPayout godoc
+// @Summary      Request instant payout
+// @Description  Debits the amount (the whole available balance when omitted) and transfers it minus the fee through the payout provider. Allowed to verified drivers with enough account age and completed rides, within per-payout and daily limits, one payout at a time. The payout is PROCESSING once the provider accepted it and becomes PAID or FAILED by the provider webhook; a failed payout is returned to the balance
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.RequestPayoutRequest true "Payout amount"
+// @Success      201 {object} models.Payout "Payout"
+// @Failure      400 {object} map[string]interface{} "Bad request or amount outside the allowed range"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Driver is not allowed instant payouts"
+// @Failure      409 {object} map[string]interface{} "Insufficient balance, daily limit reached or another payout in progress"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Payout provider is not available"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/payouts [post]
+func (h *Payout) RequestPayout(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "request_payout")
+
+	driverID, ok := h.ownDriver(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.RequestPayoutRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	payout, err := h.service.RequestPayout(ctx, driverID, req.Amount)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to request payout", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, payout, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// PayoutWebhook godoc
+// @Summary      Payout provider webhook
+// @Description  Payout status event from the payout provider, signed with HMAC-SHA256 of the body in X-Webhook-Signature. Repeated events are acknowledged without changes
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        X-Webhook-Signature header string true "Hex HMAC-SHA256 of the body"
+// @Param        request body models.ProviderPayout true "Payout status event"
+// @Success      200 {object} map[string]interface{} "Event accepted"
+// @Failure      400 {object} map[string]interface{} "Invalid event"
+// @Failure      401 {object} map[string]interface{} "Invalid signature"
+// @Failure      404 {object} map[string]interface{} "Payout not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Router       /webhooks/payouts [post]
+func (h *Payout) PayoutWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "payout_webhook")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.HandleWebhook(ctx, body, r.Header.Get(webhook.SignatureHeader)); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle payout webhook", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"status": "accepted"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// ownDriver разбирает driver_id из пути и проверяет, что водитель работает со своим аккаунтом
+func (h *Payout) ownDriver(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return uuid.NilUUID, false
+	}
+
+	user := models.UserFromContext(r.Context())
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return uuid.NilUUID, false
+	}
+
+	if user.ID != driverID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return uuid.NilUUID, false
+	}
+
+	return driverID, true
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+type PreferenceService interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, prefs *models.NotificationPreferences) error
+	RegisterPushToken(ctx context.Context, t *models.PushToken) error
+	RemovePushToken(ctx context.Context, userID uuid.UUID, deviceID string) error
+}
+
+// maxDeviceIDLen — предел длины ID устройства в пути
+const maxDeviceIDLen = 128
+
+type Preferences struct {
+	service PreferenceService
+	l       logger.Logger
+}
+
+func NewPreferences(service PreferenceService, l logger.Logger) *Preferences {
+	return &Preferences{
+		service: service,
+		l:       l,
+	}
+}
+
+// GetPreferences godoc
+// @Summary      Get notification preferences
+// @Description  Get channels and event types the current user receives notifications for. ACCOUNT_SECURITY notifications are always delivered.
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Notification preferences"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /me/preferences [get]
+func (h *Preferences) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_notification_preferences")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(ctx, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get notification preferences", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, preferencesEnvelope(prefs), nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// UpdatePreferences godoc
+// @Summary      Update notification preferences
+// @Description  Replace channels and event types the current user receives notifications for. An empty list opts out of all non-critical notifications. voice_readout additionally reads driver arrival notifications aloud via an automated voice call.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.UpdatePreferencesRequest true "Opted-in channels and event types"
+// @Success      200 {object} map[string]interface{} "Updated notification preferences"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /me/preferences [put]
+func (h *Preferences) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "update_notification_preferences")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	var req dto.UpdatePreferencesRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	prefs := req.ToModel(user.ID)
+	if err := h.service.UpdatePreferences(ctx, prefs); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update notification preferences", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, preferencesEnvelope(prefs), nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// RegisterPushToken godoc
+// @Summary      Register push token
+// @Description  Register or replace the FCM registration token of the current user's device. Ride status changes (driver matched, arrived, ride completed or cancelled) are then delivered as push notifications when the PUSH channel is enabled in preferences, also while the app has no WebSocket connection. The same token registered for another user or device is released
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        device_id path string true "Device ID, the same as in the WebSocket auth message"
+// @Param        request body dto.RegisterPushTokenRequest true "Platform and FCM token"
+// @Success      200 {object} map[string]interface{} "Registered push token"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /me/push-tokens/{device_id} [put]
+func (h *Preferences) RegisterPushToken(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "register_push_token")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	deviceID := r.PathValue("device_id")
+	if deviceID == "" || len(deviceID) > maxDeviceIDLen {
+		errorResponse(w, http.StatusBadRequest, "invalid device id")
+		return
+	}
+
+	var req dto.RegisterPushTokenRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token := req.ToModel(user.ID, deviceID)
+	if err := h.service.RegisterPushToken(ctx, token); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to register push token", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"push_token": token}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// RemovePushToken godoc
+// @Summary      Remove push token
+// @Description  Remove the push token of the current user's device, e.g. on logout. The device stops receiving push notifications
+// @Tags         auth
+// @Produce      json
+// @Param        device_id path string true "Device ID"
+// @Success      200 {object} map[string]interface{} "Push token removed"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Push token not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /me/push-tokens/{device_id} [delete]
+func (h *Preferences) RemovePushToken(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "remove_push_token")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if err := h.service.RemovePushToken(ctx, user.ID, r.PathValue("device_id")); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to remove push token", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"status": "removed"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+func preferencesEnvelope(prefs *models.NotificationPreferences) envelope {
+	var critical []types.NotificationEvent
+	for _, e := range types.AllNotificationEvents {
+		if e.IsCritical() {
+			critical = append(critical, e)
+		}
+	}
+
+	return envelope{
+		"preferences":      prefs,
+		"always_delivered": critical,
+	}
+}
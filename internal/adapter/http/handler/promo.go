@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+type PromoService interface {
+	Create(ctx context.Context, p *models.PromoCode) error
+	List(ctx context.Context) ([]models.PromoCode, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.PromoCode, error)
+	Update(ctx context.Context, id uuid.UUID, u models.PromoCodeUpdate) (*models.PromoCode, error)
+	Deactivate(ctx context.Context, id uuid.UUID) (*models.PromoCode, error)
+}
+
+type Promo struct {
+	s PromoService
+	l logger.Logger
+}
+
+func NewPromo(s PromoService, l logger.Logger) *Promo {
+	return &Promo{
+		s: s,
+		l: l,
+	}
+}
+
+// CreatePromoCode godoc
+// @Summary      Create promo code
+// @Description  Create a percentage or flat discount code. Passengers apply it with promo_code in POST /rides, the discount is taken off the estimated and final fare.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreatePromoCodeRequest true "Promo code"
+// @Success      201 {object} models.PromoCode "Created promo code"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      409 {object} map[string]interface{} "Promo code already exists"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/promo-codes [post]
+func (h *Promo) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_create_promo_code")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	var req dto.CreatePromoCodeRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	promo := req.ToModel(user.ID)
+	if err := h.s.Create(ctx, promo); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to create promo code", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, promo, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// ListPromoCodes godoc
+// @Summary      List promo codes
+// @Description  All promo codes with usage counters, newest first
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Promo codes"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/promo-codes [get]
+func (h *Promo) ListPromoCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_list_promo_codes")
+
+	promos, err := h.s.List(ctx)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to list promo codes", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"promo_codes": promos}, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// GetPromoCode godoc
+// @Summary      Get promo code
+// @Tags         admin
+// @Produce      json
+// @Param        promo_id path string true "Promo code ID"
+// @Success      200 {object} models.PromoCode "Promo code"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Promo code not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/promo-codes/{promo_id} [get]
+func (h *Promo) GetPromoCode(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_get_promo_code")
+
+	id, err := uuid.Parse(r.PathValue("promo_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid promo code uuid format")
+		return
+	}
+
+	promo, err := h.s.Get(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get promo code", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, promo, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// UpdatePromoCode godoc
+// @Summary      Update promo code
+// @Description  Change the discount, expiry, usage limits or active flag. Code and discount type cannot be changed. Discounts already applied to rides are kept.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        promo_id path string true "Promo code ID"
+// @Param        request body dto.UpdatePromoCodeRequest true "Fields to change"
+// @Success      200 {object} models.PromoCode "Updated promo code"
+// @Failure      400 {object} map[string]interface{} "Bad request or discount not valid for the code type"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Promo code not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/promo-codes/{promo_id} [patch]
+func (h *Promo) UpdatePromoCode(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_update_promo_code")
+
+	id, err := uuid.Parse(r.PathValue("promo_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid promo code uuid format")
+		return
+	}
+
+	var req dto.UpdatePromoCodeRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	promo, err := h.s.Update(ctx, id, req.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update promo code", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, promo, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// DeletePromoCode godoc
+// @Summary      Deactivate promo code
+// @Description  The code stops applying to new rides. It is kept with its redemption history.
+// @Tags         admin
+// @Produce      json
+// @Param        promo_id path string true "Promo code ID"
+// @Success      200 {object} models.PromoCode "Deactivated promo code"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      404 {object} map[string]interface{} "Promo code not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/promo-codes/{promo_id} [delete]
+func (h *Promo) DeletePromoCode(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "admin_delete_promo_code")
+
+	id, err := uuid.Parse(r.PathValue("promo_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid promo code uuid format")
+		return
+	}
+
+	promo, err := h.s.Deactivate(ctx, id)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to deactivate promo code", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, promo, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// RatingsListing - параметры пагинации и сортировки GET /drivers/{driver_id}/ratings
+var RatingsListing = models.ListOptions{
+	DefaultSort:  "-created_at",
+	SortSafelist: []string{"created_at", "-created_at"},
+}
+
+// RateRide godoc
+// @Summary      Rate the driver of a completed ride
+// @Description  Passenger rates the driver from 1 to 5 with an optional comment. Only completed rides can be rated, once per ride. The driver's average rating is recalculated and a DRIVER_RATED ride event is recorded
+// @Tags         ride
+// @Accept       json
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Param        request body dto.RateRideRequest true "Rating"
+// @Success      201 {object} map[string]interface{} "Rating saved"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Ride belongs to another passenger"
+// @Failure      404 {object} map[string]interface{} "Ride not found"
+// @Failure      409 {object} map[string]interface{} "Ride is not completed or already rated"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /rides/{ride_id}/rating [post]
+func (h *Ride) RateRide(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "rate_driver")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	var req dto.RateRideRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	rating, driverRating, err := h.ride.Rate(ctx, rideID, user.ID, *req.Score, req.CommentOrNil())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to rate driver", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"ride_id":       rating.RideID,
+		"score":         rating.Score,
+		"driver_rating": driverRating.Rating,
+		"created_at":    rating.CreatedAt,
+		"message":       "Rating saved",
+	}
+
+	if err := writeJSON(w, http.StatusCreated, response, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// GetRatings godoc
+// @Summary      Get driver rating history
+// @Description  Average rating of the driver and passenger ratings with comments, newest first. Available to the driver and to admins
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Page size" default(20)
+// @Param        sort query string false "created_at, prefix - for descending" default(-created_at)
+// @Success      200 {object} models.DriverRatingHistory "Ratings"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/ratings [get]
+func (h *Driver) GetRatings(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_ratings")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	// водитель видит только свои оценки, админ - любого водителя
+	if user.Role != types.RoleAdmin.String() && user.ID != driverID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	filters, ok := models.FiltersFromContext(ctx)
+	if !ok {
+		h.l.Warn(ctx, "pagination filters are missing in context")
+		internalErrorResponse(w, "intenal error")
+		return
+	}
+
+	history, err := h.service.RatingHistory(ctx, driverID, filters)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver ratings", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, history, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// Reconcile godoc
+// @Summary      Reconcile offline actions
+// @Description  Apply actions the driver app performed without network (status changes, ride start/complete) in performed_at order.
+// @Description  Actions are idempotent by action_id; server state wins on conflicts. Returns a result per action.
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.ReconcileReq true "Offline actions ordered by performed_at"
+// @Success      200 {object} map[string]interface{} "Per-action results"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/reconcile [post]
+func (h *Driver) Reconcile(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "reconcile_offline_actions")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	var req dto.ReconcileReq
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	results, err := h.service.Reconcile(ctx, driverID, req.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to reconcile offline actions", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	summary := make(map[types.ReconcileStatus]int)
+	for _, res := range results {
+		summary[res.Status]++
+	}
+
+	response := envelope{
+		"driver_id": driverID,
+		"results":   results,
+		"summary":   summary,
+	}
+
+	if err := writeJSON(w, http.StatusOK, response, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+
+	h.l.Info(ctx, "offline actions reconciled", "driver_id", driverID, "actions", len(results))
+}
@@ -2,8 +2,11 @@ package handler
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
@@ -23,7 +26,23 @@ import (
 type (
 	RideService interface {
 		Create(ctx context.Context, ride *models.Ride) (*models.Ride, error)
+		CreateSandbox(ctx context.Context, ride *models.Ride) (*models.Ride, error)
 		Cancel(ctx context.Context, rideID, passengerID uuid.UUID, reason string) (*models.Ride, error)
+		AdminCancel(ctx context.Context, rideID, adminID uuid.UUID, reason string) (*models.Ride, error)
+		Reassign(ctx context.Context, rideID, adminID uuid.UUID, reason string) (*models.Ride, error)
+		AdminCancelDryRun(ctx context.Context, rideID uuid.UUID, reason string) (*models.AdminEffects, error)
+		ReassignDryRun(ctx context.Context, rideID uuid.UUID, reason string) (*models.AdminEffects, error)
+		Estimate(ctx context.Context, ride *models.Ride) (*models.RideEstimate, error)
+		Rates(ctx context.Context, cityCode string) (*models.RateCard, error)
+		Impact(ctx context.Context, passengerID uuid.UUID, months int) (*models.PassengerImpact, error)
+		Wallet(ctx context.Context, passengerID uuid.UUID) (*models.Wallet, error)
+		TopUpWallet(ctx context.Context, passengerID uuid.UUID, amount float64) (*models.Wallet, error)
+		Payment(ctx context.Context, rideID, passengerID uuid.UUID) (*models.RidePayment, error)
+		DriverCard(ctx context.Context, rideID, passengerID uuid.UUID) (*models.DriverCard, error)
+		Receipt(ctx context.Context, rideID, passengerID uuid.UUID) (*models.Receipt, error)
+		History(ctx context.Context, passengerID uuid.UUID, filter models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error)
+		SubmitTelemetry(ctx context.Context, userID uuid.UUID, t models.AppTelemetry) error
+		Rate(ctx context.Context, rideID, passengerID uuid.UUID, score int, comment *string) (*models.RideRating, *models.DriverRating, error)
 	}
 
 	TokenValidator interface {
@@ -32,7 +51,8 @@ type (
 
 	ConnectionHub interface {
 		Add(newConn *wshub.Conn) error
-		Delete(entityID uuid.UUID) error
+		Remove(conn *wshub.Conn) error
+		Negotiate(requested int) (wshub.Protocol, error)
 	}
 
 	Ride struct {
@@ -62,7 +82,9 @@ func NewRide(ride RideService, auth TokenValidator, wsConnections ConnectionHub,
 // @Success      201 {object} map[string]interface{} "Created ride details"
 // @Failure      400 {object} map[string]interface{} "Bad request"
 // @Failure      401 {object} map[string]interface{} "Unauthorized"
-// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      403 {object} map[string]interface{} "Forbidden or passenger not eligible for priority boarding"
+// @Failure      404 {object} map[string]interface{} "Promo code not found"
+// @Failure      409 {object} map[string]interface{} "Active ride exists or promo code is inactive, expired or used up"
 // @Failure      422 {object} map[string]interface{} "Validation error"
 // @Failure      500 {object} map[string]interface{} "Internal server error"
 // @Security     BearerAuth
@@ -121,8 +143,25 @@ func (h *Ride) CreateRide(w http.ResponseWriter, r *http.Request) {
 		"ride_number":                createdRide.RideNumber,
 		"status":                     createdRide.Status,
 		"estimated_fare":             createdRide.EstimatedFare,
+		"surge_multiplier":           createdRide.SurgeMultiplier,
 		"estimated_duration_minutes": createdRide.EstimatedDurationMin,
 		"estimated_distance_km":      createdRide.EstimatedDistanceKm,
+		"pending_dispatch":           createdRide.PendingDispatch,
+		"pickup":                     createdRide.PickupSuggestion,
+		"payment_method":             createdRide.PaymentMethod,
+	}
+	if createdRide.PriorityBoarding != "" {
+		response["priority_boarding"] = createdRide.PriorityBoarding
+	}
+	if createdRide.MatchingDelay != nil {
+		response["matching_delay"] = createdRide.MatchingDelay
+	}
+	if createdRide.FlatRateCode != "" {
+		response["flat_rate"] = createdRide.FlatRateCode
+	}
+	if createdRide.PromoCode != "" {
+		response["promo_code"] = createdRide.PromoCode
+		response["discount"] = createdRide.Discount
 	}
 
 	if err := writeJSON(w, http.StatusCreated, response, nil); err != nil {
@@ -131,9 +170,169 @@ func (h *Ride) CreateRide(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// CreateSandboxRide godoc
+// @Summary      Create a sandbox test ride
+// @Description  Creates a synthetic ride on behalf of a passenger for smoke testing. The ride is flagged test=true, is matched only against simulator drivers and is excluded from revenue and metrics.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreateRideRequest true "Ride request details, passenger_id is the impersonated passenger"
+// @Success      201 {object} map[string]interface{} "Created test ride details"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden - Admin only"
+// @Failure      409 {object} map[string]interface{} "Passenger already has an active ride"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/sandbox/rides [post]
+func (h *Ride) CreateSandboxRide(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "create_sandbox_ride")
+
+	var request dto.CreateRideRequest
+	if err := readJSON(w, r, &request); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	request.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	domainModel, err := request.ToModel()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid passenger_id format")
+		return
+	}
+
+	// метрики поездок не трогаем: тестовые поездки не должны влиять на дашборды
+	createdRide, err := h.ride.CreateSandbox(ctx, domainModel)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to create sandbox ride", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	response := envelope{
+		"ride_id":                    createdRide.ID,
+		"ride_number":                createdRide.RideNumber,
+		"status":                     createdRide.Status,
+		"test":                       createdRide.IsTest,
+		"estimated_fare":             createdRide.EstimatedFare,
+		"estimated_duration_minutes": createdRide.EstimatedDurationMin,
+		"estimated_distance_km":      createdRide.EstimatedDistanceKm,
+		"pending_dispatch":           createdRide.PendingDispatch,
+	}
+
+	if err := writeJSON(w, http.StatusCreated, response, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// EstimateRide godoc
+// @Summary      Estimate a ride
+// @Description  Calculates fare, duration and suggested pickup point (snapped to the nearest road) without creating a ride
+// @Tags         ride
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.EstimateRideRequest true "Ride estimate details"
+// @Success      200 {object} models.RideEstimate "Ride estimate"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /rides/estimate [post]
+func (h *Ride) EstimateRide(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "estimate_ride")
+
+	var request dto.EstimateRideRequest
+	if err := readJSON(w, r, &request); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	request.Validate(v)
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	estimate, err := h.ride.Estimate(ctx, request.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to estimate ride", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, estimate, nil); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// ratesMaxAge — время, на которое клиент может закешировать тарифную сетку
+const ratesMaxAge = 5 * time.Minute
+
+// GetRates godoc
+// @Summary      Get rate card
+// @Description  Returns the current tariff table (base fare, per-km, per-min, surge cap, cancellation and wait fees) per vehicle class and city. Responses carry Cache-Control and ETag headers; a matching If-None-Match yields 304
+// @Tags         ride
+// @Produce      json
+// @Param        city query string false "City code filter"
+// @Success      200 {object} models.RateCard "Rate card"
+// @Success      304 "Not modified"
+// @Failure      400 {object} map[string]interface{} "Unknown city"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Router       /rates [get]
+func (h *Ride) GetRates(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_rates")
+
+	card, err := h.ride.Rates(ctx, r.URL.Query().Get("city"))
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get rates", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	body, err := json.Marshal(card)
+	if err != nil {
+		h.l.Error(ctx, "failed to encode rates", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+
+	headers := http.Header{}
+	headers.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ratesMaxAge.Seconds())))
+	headers.Set("ETag", etag)
+	if !card.UpdatedAt.IsZero() {
+		headers.Set("Last-Modified", card.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Header.Get("If-None-Match") == etag {
+		maps.Copy(w.Header(), headers)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, card, headers); err != nil {
+		h.l.Error(ctx, "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
 // CancelRide godoc
 // @Summary      Cancel a ride
-// @Description  Cancel an existing ride request by passenger
+// @Description  Cancel an existing ride request by passenger. Cancelling after a driver was matched is free for the first 2 minutes while the driver has driven less than 0.5 km; after that cancellation_fee is charged and credited to the driver
 // @Tags         ride
 // @Accept       json
 // @Produce      json
@@ -187,10 +386,14 @@ func (h *Ride) CancelRide(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := envelope{
-		"ride_id":      cancelledRide.ID,
-		"status":       cancelledRide.Status,
-		"cancelled_at": cancelledRide.CancelledAt,
-		"message":      cancelledRide.CancellationReason,
+		"ride_id":          cancelledRide.ID,
+		"status":           cancelledRide.Status,
+		"cancelled_at":     cancelledRide.CancelledAt,
+		"message":          cancelledRide.CancellationReason,
+		"cancellation_fee": 0.0,
+	}
+	if cancelledRide.CancellationFee != nil {
+		response["cancellation_fee"] = *cancelledRide.CancellationFee
 	}
 
 	if err := writeJSON(w, http.StatusAccepted, response, nil); err != nil {
@@ -200,6 +403,90 @@ func (h *Ride) CancelRide(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetDriverCard godoc
+// @Summary      Get driver card
+// @Description  Driver and vehicle of the ride for the passenger to recognize the car: name, photo, rating, vehicle class, make, model, color, plate and current driver position. eta_minutes is the time to the pickup point while the driver is on the way, null after arrival
+// @Tags         ride
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Success      200 {object} models.DriverCard "Driver card"
+// @Failure      400 {object} map[string]interface{} "Invalid ride ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Ride belongs to another passenger"
+// @Failure      404 {object} map[string]interface{} "Ride not found or no driver assigned yet"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /rides/{ride_id}/driver [get]
+func (h *Ride) GetDriverCard(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_card")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	card, err := h.ride.DriverCard(ctx, rideID, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver card", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, card, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// GetRideReceipt godoc
+// @Summary      Get ride receipt
+// @Description  Receipt issued on ride completion with the fare breakdown: base fare, distance and time components, surge, adjustment (night tariff, flat or minimum zone fare), discount, total, included tax, driver earnings and platform commission
+// @Tags         ride
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Success      200 {object} models.Receipt "Ride receipt"
+// @Failure      400 {object} map[string]interface{} "Invalid ride ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Ride belongs to another passenger"
+// @Failure      404 {object} map[string]interface{} "Ride not found or not completed"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /rides/{ride_id}/receipt [get]
+func (h *Ride) GetRideReceipt(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_ride_receipt")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	receipt, err := h.ride.Receipt(ctx, rideID, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ride receipt", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, receipt, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
 // HandleWebSocket godoc
 // @Summary      WebSocket connection for ride updates
 // @Description  Establishes a WebSocket connection for real-time ride updates. Client must send authentication message within 5 seconds: {"type":"auth","token":"Bearer <jwt>"}
@@ -221,8 +508,14 @@ func (h *Ride) CancelRide(w http.ResponseWriter, r *http.Request) {
 // @Description  5. Server pushes ride updates: `{"type":"ride_update","data":{...}}`
 // @Description
 // @Description  **Message Types:**
-// @Description  - Client → Server: `{"type":"auth","token":"string"}`
+// @Description  - Client → Server: `{"type":"auth","token":"string","protocol":2,"resume_from":0}`
+// @Description  - Client → Server (v2): `{"type":"ack","seq":42}`
 // @Description  - Server → Client: `{"type":"auth_ok"}` | `{"type":"ride_update","data":{}}` | `{"type":"ping"}`
+// @Description
+// @Description  **Protocol versions:** without `protocol` the connection uses v1. With `"protocol":2` events arrive as
+// @Description  `{"v":2,"seq":42,"type":"...","data":{...}}`, are acknowledged with `ack` and resent after reconnect with `resume_from`.
+// @Description  `"capabilities":{"delta_updates":true,"chat":true,"v2_envelope":true,"locale":"ru"}` in auth describes the client:
+// @Description  with `delta_updates` location updates after the first carry `"delta":true` and only changed fields. `auth_ok` echoes the accepted capabilities.
 func (h *Ride) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	passengerIdStr := r.PathValue("passenger_id")
 
@@ -243,7 +536,7 @@ func (h *Ride) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Authenticate the WebSocket connection
-	passenger, err := h.wsAuthenticate(ctx, wsConn, passengerID)
+	passenger, session, err := h.wsAuthenticate(ctx, wsConn, passengerID)
 	if err != nil {
 		h.l.Error(ctx, "websocket authentication failed", err)
 		return
@@ -260,7 +553,10 @@ func (h *Ride) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	conn := wshub.NewConn(passenger.ID, wsConn, h.l)
+	// у каждого устройства пассажира свое соединение, они не вытесняют друг друга
+	conn := wshub.NewDeviceConn(passenger.ID, session.deviceID, wsConn, h.l)
+	conn.SetProtocol(session.protocol, session.resumeFrom)
+	conn.SetCapabilities(session.caps)
 	if err := h.wsConnections.Add(conn); err != nil {
 		h.l.Error(ctx, "failed to register WS connection", err)
 		wsConn.WriteJSON(map[string]any{"error": "failed to register"})
@@ -268,12 +564,14 @@ func (h *Ride) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	metrics.WebSocketConnectionsGauge.WithLabelValues("ride_service").Inc()
+	untrack := trackWSProtocol("ride_service", conn)
 	defer func() {
-		h.wsConnections.Delete(passenger.ID)
+		h.wsConnections.Remove(conn)
 		metrics.WebSocketConnectionsGauge.WithLabelValues("ride_service").Dec()
+		untrack()
 	}()
 
-	h.l.Info(ctx, "websocket connection registered")
+	h.l.Info(ctx, "websocket connection registered", "device_id", session.deviceID, "protocol", session.protocol.String())
 	// Heartbeat
 	go func() {
 		if err := conn.HeartbeatLoop(time.Second*60, time.Second*30); err != nil {
@@ -295,11 +593,11 @@ func (h *Ride) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 // wsAuthenticate enforces a 5s auth window, expects a JSON text message:
 //
-//	{"type":"auth","token":"Bearer <jwt>"}
+//	{"type":"auth","token":"Bearer <jwt>","device_id":"<optional>","protocol":2,"resume_from":0}
 //
-// It validates the JWT via RideService and returns the passenger UUID.
+// It validates the JWT via RideService and returns the passenger with the device ID and negotiated protocol.
 // On any error, it sends an appropriate WebSocket close frame and closes the connection.
-func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passengerID uuid.UUID) (*models.User, error) {
+func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passengerID uuid.UUID) (*models.User, wsSession, error) {
 	const authTimeout = 5 * time.Second
 
 	// Enforce "client must authenticate within 5 seconds".
@@ -311,7 +609,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	msgType, payload, err := conn.ReadMessage()
@@ -329,7 +627,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if msgType != websocket.TextMessage {
@@ -340,7 +638,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, errors.New("first message must be text")
+		return nil, wsSession{}, errors.New("first message must be text")
 	}
 
 	var req dto.AuthWebSocketReq
@@ -352,7 +650,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if req.Type != "auth" {
@@ -362,7 +660,28 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, errors.New("unexpected message type")
+		return nil, wsSession{}, errors.New("unexpected message type")
+	}
+
+	if len(req.DeviceID) > dto.MaxDeviceIDLength {
+		_ = conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "device_id is too long"),
+			time.Now().Add(time.Second),
+		)
+		_ = conn.Close()
+		return nil, wsSession{}, errors.New("device_id is too long")
+	}
+
+	session, err := negotiateProtocol(h.wsConnections, "ride_service", req)
+	if err != nil {
+		_ = conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unsupported protocol"),
+			time.Now().Add(time.Second),
+		)
+		_ = conn.Close()
+		return nil, wsSession{}, err
 	}
 
 	// Validate the token and get the passenger info
@@ -375,7 +694,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if passenger == nil {
@@ -386,7 +705,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	if passenger.ID != passengerID {
@@ -397,7 +716,7 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, errors.New("passenger ID mismatch")
+		return nil, wsSession{}, errors.New("passenger ID mismatch")
 	}
 
 	// Auth succeeded; clear the read deadline for normal operation.
@@ -409,13 +728,11 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
 	// Send an explicit acknowledgment so the client can transition its state machine.
-	ack := dto.AuthWebSocketResp{
-		Type: "auth_ok",
-	}
+	ack := authOK(req, session)
 	if err := conn.WriteJSON(ack); err != nil {
 		h.l.Error(ctx, "failed to send auth_ok", err)
 		_ = conn.WriteControl(
@@ -424,8 +741,8 @@ func (h *Ride) wsAuthenticate(ctx context.Context, conn *websocket.Conn, passeng
 			time.Now().Add(time.Second),
 		)
 		_ = conn.Close()
-		return nil, err
+		return nil, wsSession{}, err
 	}
 
-	return passenger, nil
+	return passenger, session, nil
 }
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// roleTag — тег, ограничивающий видимость поля ролями, например `roles:"DRIVER,ADMIN"`.
+// Поля без тега видны всем.
+const roleTag = "roles"
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// shapeFor убирает из ответа поля, которые не видны роли запрашивающего.
+// Структуры превращаются в map с ключами из json-тегов, вложенные структуры и слайсы обрабатываются рекурсивно.
+func shapeFor(role types.UserRole, data any) any {
+	if data == nil {
+		return nil
+	}
+	return shapeValue(role, reflect.ValueOf(data))
+}
+
+func shapeValue(role types.UserRole, v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return shapeValue(role, v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range v.Len() {
+			out[i] = shapeValue(role, v.Index(i))
+		}
+		return out
+	case reflect.Struct:
+		// типы со своей сериализацией (time.Time, uuid.UUID) отдаем как есть
+		if v.Type().Implements(jsonMarshalerType) || reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+			return v.Interface()
+		}
+		return shapeStruct(role, v)
+	default:
+		return v.Interface()
+	}
+}
+
+func shapeStruct(role types.UserRole, v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || !visibleTo(role, field.Tag.Get(roleTag)) {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		value := v.Field(i)
+		flags := strings.Split(opts, ",")
+		if slices.Contains(flags, "omitempty") && isEmpty(value) || slices.Contains(flags, "omitzero") && value.IsZero() {
+			continue
+		}
+
+		out[name] = shapeValue(role, value)
+	}
+
+	return out
+}
+
+// isEmpty повторяет правило omitempty из encoding/json
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	default:
+		return v.IsZero()
+	}
+}
+
+func visibleTo(role types.UserRole, tag string) bool {
+	if tag == "" {
+		return true
+	}
+	for allowed := range strings.SplitSeq(tag, ",") {
+		if types.UserRole(strings.TrimSpace(allowed)) == role {
+			return true
+		}
+	}
+	return false
+}
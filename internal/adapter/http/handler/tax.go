@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// firstTaxYear — раньше этого года поездок в системе не было
+const firstTaxYear = 2020
+
+// GetTaxSummary godoc
+// @Summary      Get driver tax summary
+// @Description  Yearly earnings per month (UTC): gross, commission, tips, bonuses and net, for income declaration. Returns CSV when format=csv or the Accept header is text/csv.
+// @Tags         driver
+// @Produce      json
+// @Produce      text/csv
+// @Param        driver_id path string true "Driver ID"
+// @Param        year query int false "Year, defaults to the current year"
+// @Param        format query string false "json (default) or csv"
+// @Success      200 {object} models.TaxSummary "Tax summary"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/tax-summary [get]
+func (h *Driver) GetTaxSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_tax_summary")
+
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		h.l.Warn(ctx, "invalid driver uuid format")
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return
+	}
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		h.l.Warn(ctx, "failed to get user form context")
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	if user.ID.String() != driverID.String() {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return
+	}
+
+	qs := r.URL.Query()
+	v := validator.New()
+	currentYear := time.Now().UTC().Year()
+	year := readInt(qs, "year", currentYear, v)
+	v.Check(year >= firstTaxYear && year <= currentYear, "year", fmt.Sprintf("must be between %d and %d", firstTaxYear, currentYear))
+
+	format := readString(qs, "format", "")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+	v.Check(validator.PermittedValue(format, "", "json", "csv"), "format", "must be json or csv")
+
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	summary, err := h.service.TaxSummary(ctx, driverID, year)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get tax summary", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if format == "csv" {
+		if err := writeTaxSummaryCSV(w, summary); err != nil {
+			h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write csv response", err)
+		}
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, summary, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+		return
+	}
+}
+
+// writeTaxSummaryCSV пишет сводку по месяцам и итоговую строку total
+func writeTaxSummaryCSV(w http.ResponseWriter, summary *models.TaxSummary) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tax-summary-%d.csv"`, summary.Year))
+	w.WriteHeader(http.StatusOK)
+
+	money := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	row := func(month string, m models.MonthlyEarnings) []string {
+		return []string{
+			month,
+			strconv.Itoa(m.Rides),
+			money(m.Gross),
+			money(m.Commission),
+			money(m.Tips),
+			money(m.Bonuses),
+			money(m.Net),
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	records := [][]string{{"month", "rides", "gross", "commission", "tips", "bonuses", "net"}}
+	for _, m := range summary.Months {
+		records = append(records, row(fmt.Sprintf("%d-%02d", summary.Year, int(m.Month)), m))
+	}
+	records = append(records, row("total", summary.Total))
+
+	return cw.WriteAll(records)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// SubmitTelemetry godoc
+// @Summary      Submit app quality telemetry
+// @Description  Per-ride app quality report from the passenger or driver client: WebSocket disconnects, the longest gap between driver location updates and, for drivers, offer round-trip latency. Stored anonymously with the reporter role only; a repeated report for the same ride replaces the previous one
+// @Tags         ride
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.SubmitTelemetryRequest true "Telemetry"
+// @Success      202 {object} map[string]interface{} "Telemetry accepted"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      404 {object} map[string]interface{} "Ride not found or the user did not take part in it"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /telemetry [post]
+func (h *Ride) SubmitTelemetry(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "submit_app_telemetry")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	var req dto.SubmitTelemetryRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := h.ride.SubmitTelemetry(ctx, user.ID, req.ToModel(user.Role)); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to submit telemetry", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, envelope{"message": "Telemetry accepted"}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
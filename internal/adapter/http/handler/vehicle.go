@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// GetVehicle godoc
+// @Summary      Get active vehicle
+// @Description  Vehicle the driver currently receives rides with. vehicle_class is derived from body type, make and year
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} models.DriverVehicle "Active vehicle"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver has no vehicle"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/vehicle [get]
+func (h *Driver) GetVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_vehicle")
+
+	driverID, ok := h.vehicleDriver(w, r, true)
+	if !ok {
+		return
+	}
+
+	vehicle, err := h.service.ActiveVehicle(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver vehicle", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, vehicle, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// UpdateVehicle godoc
+// @Summary      Update active vehicle
+// @Description  Replace attributes of the active vehicle and re-classify it: VAN, MINIVAN, SUV and CROSSOVER up to 10 years old are XL, premium brands up to 6 years old are PREMIUM, the rest ECONOMY. An omitted body_type keeps the current one. Not allowed during a ride
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.DriverVehicleRequest true "Vehicle"
+// @Success      200 {object} models.DriverVehicle "Updated vehicle"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      409 {object} map[string]interface{} "Driver is on a ride or has a vehicle with this plate"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/vehicle [put]
+func (h *Driver) UpdateVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "update_driver_vehicle")
+
+	driverID, ok := h.vehicleDriver(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req dto.DriverVehicleRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	vehicle, err := h.service.UpdateVehicle(ctx, driverID, req.ToModel())
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to update driver vehicle", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, vehicle, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// GetVehicles godoc
+// @Summary      List driver vehicles
+// @Description  All vehicles of the driver, the active one first
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Success      200 {object} map[string]interface{} "Vehicles"
+// @Failure      400 {object} map[string]interface{} "Invalid driver ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/vehicles [get]
+func (h *Driver) GetVehicles(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_driver_vehicles")
+
+	driverID, ok := h.vehicleDriver(w, r, true)
+	if !ok {
+		return
+	}
+
+	vehicles, err := h.service.Vehicles(ctx, driverID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get driver vehicles", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"vehicles": vehicles}, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// AddVehicle godoc
+// @Summary      Add vehicle
+// @Description  Add a vehicle to the driver. It becomes active when activate is true or it is the driver's first vehicle; activation is not allowed during a ride
+// @Tags         driver
+// @Accept       json
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        request body dto.AddDriverVehicleRequest true "Vehicle"
+// @Success      201 {object} models.DriverVehicle "Added vehicle"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Driver not found"
+// @Failure      409 {object} map[string]interface{} "Driver is on a ride or has a vehicle with this plate"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/vehicles [post]
+func (h *Driver) AddVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "add_driver_vehicle")
+
+	driverID, ok := h.vehicleDriver(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req dto.AddDriverVehicleRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to read request JSON data", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		h.l.Warn(ctx, "invalid request data")
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	vehicle, err := h.service.AddVehicle(ctx, driverID, req.ToModel(), req.Activate)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to add driver vehicle", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, vehicle, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// ActivateVehicle godoc
+// @Summary      Switch active vehicle
+// @Description  Make another vehicle of the driver active. The vehicle is re-classified, because a car can age out of XL or PREMIUM. Not allowed during a ride
+// @Tags         driver
+// @Produce      json
+// @Param        driver_id path string true "Driver ID"
+// @Param        vehicle_id path string true "Vehicle ID"
+// @Success      200 {object} models.DriverVehicle "Active vehicle"
+// @Failure      400 {object} map[string]interface{} "Invalid ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      404 {object} map[string]interface{} "Vehicle not found"
+// @Failure      409 {object} map[string]interface{} "Driver is on a ride"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /drivers/{driver_id}/vehicles/{vehicle_id}/activate [post]
+func (h *Driver) ActivateVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "activate_driver_vehicle")
+
+	driverID, ok := h.vehicleDriver(w, r, false)
+	if !ok {
+		return
+	}
+
+	vehicleID, err := uuid.Parse(r.PathValue("vehicle_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid vehicle uuid format")
+		return
+	}
+
+	vehicle, err := h.service.ActivateVehicle(ctx, driverID, vehicleID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to activate driver vehicle", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, vehicle, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// vehicleDriver разбирает driver_id из пути: водитель работает только со своими автомобилями,
+// админ при allowAdmin может смотреть автомобили любого водителя
+func (h *Driver) vehicleDriver(w http.ResponseWriter, r *http.Request, allowAdmin bool) (uuid.UUID, bool) {
+	driverID, err := uuid.Parse(r.PathValue("driver_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid driver uuid format")
+		return uuid.NilUUID, false
+	}
+
+	user := models.UserFromContext(r.Context())
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return uuid.NilUUID, false
+	}
+
+	if user.ID != driverID && !(allowAdmin && user.Role == types.RoleAdmin.String()) {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return uuid.NilUUID, false
+	}
+
+	return driverID, true
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+// GetWallet godoc
+// @Summary      Get passenger wallet
+// @Description  Wallet balance, amount held for active rides and the last 20 ledger entries (newest first)
+// @Tags         ride
+// @Produce      json
+// @Param        passenger_id path string true "Passenger ID"
+// @Success      200 {object} models.Wallet "Wallet"
+// @Failure      400 {object} map[string]interface{} "Invalid passenger ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /passengers/{passenger_id}/wallet [get]
+func (h *Ride) GetWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_wallet")
+
+	passengerID, ok := h.ownPassenger(w, r)
+	if !ok {
+		return
+	}
+
+	wallet, err := h.ride.Wallet(ctx, passengerID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get wallet", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, wallet, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// TopUpWallet godoc
+// @Summary      Top up passenger wallet
+// @Description  Charges the amount to the passenger's card through the payment provider and credits it to the wallet. The top-up is recorded in the ledger with the provider transaction ID
+// @Tags         ride
+// @Accept       json
+// @Produce      json
+// @Param        passenger_id path string true "Passenger ID"
+// @Param        request body dto.TopUpWalletRequest true "Top-up amount"
+// @Success      200 {object} models.Wallet "Updated wallet"
+// @Failure      400 {object} map[string]interface{} "Bad request"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Forbidden"
+// @Failure      422 {object} map[string]interface{} "Validation error"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Failure      503 {object} map[string]interface{} "Payment provider is not available"
+// @Security     BearerAuth
+// @Router       /passengers/{passenger_id}/wallet/top-up [post]
+func (h *Ride) TopUpWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "top_up_wallet")
+
+	passengerID, ok := h.ownPassenger(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.TopUpWalletRequest
+	if err := readJSON(w, r, &req); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	req.Validate(v)
+	if !v.Valid() {
+		failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	wallet, err := h.ride.TopUpWallet(ctx, passengerID, *req.Amount)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to top up wallet", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, wallet, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
+
+// ownPassenger разбирает passenger_id из пути и проверяет, что пассажир работает со своим аккаунтом
+func (h *Ride) ownPassenger(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	passengerID, err := uuid.Parse(r.PathValue("passenger_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid passenger uuid format")
+		return uuid.NilUUID, false
+	}
+
+	user := models.UserFromContext(r.Context())
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return uuid.NilUUID, false
+	}
+
+	if user.ID != passengerID {
+		errorResponse(w, http.StatusForbidden, auth.ErrActionForbidden.Error())
+		return uuid.NilUUID, false
+	}
+
+	return passengerID, true
+}
+
+// GetRidePayment godoc
+// @Summary      Get ride payment
+// @Description  Payment method of the ride and its payment hold: card pre-authorization of the estimated fare plus buffer, or wallet hold. The hold is AUTHORIZED after creation, CAPTURED on completion, RELEASED on cancellation and FAILED when the provider declined. hold is null when no hold was placed
+// @Tags         ride
+// @Produce      json
+// @Param        ride_id path string true "Ride ID"
+// @Success      200 {object} models.RidePayment "Ride payment"
+// @Failure      400 {object} map[string]interface{} "Invalid ride ID"
+// @Failure      401 {object} map[string]interface{} "Unauthorized"
+// @Failure      403 {object} map[string]interface{} "Ride belongs to another passenger"
+// @Failure      404 {object} map[string]interface{} "Ride not found"
+// @Failure      500 {object} map[string]interface{} "Internal server error"
+// @Security     BearerAuth
+// @Router       /rides/{ride_id}/payment [get]
+func (h *Ride) GetRidePayment(w http.ResponseWriter, r *http.Request) {
+	ctx := wrap.WithAction(r.Context(), "get_ride_payment")
+
+	user := models.UserFromContext(ctx)
+	if user == nil {
+		errorResponse(w, http.StatusUnauthorized, auth.ErrUnauthorized)
+		return
+	}
+
+	rideID, err := uuid.Parse(r.PathValue("ride_id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid ride ID format")
+		return
+	}
+
+	payment, err := h.ride.Payment(ctx, rideID, user.ID)
+	if err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to get ride payment", err)
+		errorResponse(w, GetCode(err), err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, payment, nil); err != nil {
+		h.l.Error(wrap.ErrorCtx(ctx, err), "failed to write response", err)
+		internalErrorResponse(w, err.Error())
+	}
+}
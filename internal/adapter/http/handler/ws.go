@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	wshub "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
+)
+
+// wsSession — параметры соединения, согласованные в сообщении auth
+type wsSession struct {
+	deviceID   string
+	protocol   wshub.Protocol
+	resumeFrom uint64
+	caps       wshub.Capabilities
+}
+
+// negotiateProtocol выбирает версию протокола и возможности клиента по сообщению auth.
+// Клиенты, получившие версию ниже запрошенной, учитываются в метрике поэтапного включения.
+func negotiateProtocol(hub ConnectionHub, service string, req dto.AuthWebSocketReq) (wsSession, error) {
+	requested := req.Protocol
+	if requested == 0 && req.Capabilities != nil && req.Capabilities.V2Envelope {
+		requested = int(wshub.ProtocolV2)
+	}
+
+	protocol, err := hub.Negotiate(requested)
+	if err != nil {
+		return wsSession{}, err
+	}
+	if int(protocol) < requested {
+		metrics.WebSocketProtocolDowngradesTotal.WithLabelValues(service).Inc()
+	}
+
+	session := wsSession{deviceID: req.DeviceID, protocol: protocol}
+	if protocol == wshub.ProtocolV2 {
+		session.resumeFrom = req.ResumeFrom
+	}
+	if c := req.Capabilities; c != nil {
+		session.caps = wshub.Capabilities{
+			DeltaUpdates: c.DeltaUpdates,
+			Chat:         c.Chat,
+			Locale:       language(c.Locale),
+		}
+	}
+	return session, nil
+}
+
+// authOK — подтверждение auth. Клиенты без protocol и capabilities получают прежний ответ v1 без версии.
+func authOK(req dto.AuthWebSocketReq, session wsSession) dto.AuthWebSocketResp {
+	ack := dto.AuthWebSocketResp{
+		Type:     "auth_ok",
+		DeviceID: session.deviceID,
+	}
+	if req.Protocol != 0 || req.Capabilities != nil {
+		ack.Protocol = int(session.protocol)
+	}
+	if req.Capabilities != nil {
+		ack.Capabilities = &dto.WSCapabilities{
+			DeltaUpdates: session.caps.DeltaUpdates,
+			Chat:         session.caps.Chat,
+			V2Envelope:   session.protocol == wshub.ProtocolV2,
+			Locale:       session.caps.Locale,
+		}
+	}
+	return ack
+}
+
+// maxLanguageLength — самый длинный код языка BCP 47, длиннее — locale не учитывается
+const maxLanguageLength = 8
+
+// language оставляет от locale код языка в нижнем регистре: ru-RU и ru_RU — ru
+func language(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if len(lang) > maxLanguageLength {
+		return ""
+	}
+	return strings.ToLower(lang)
+}
+
+// trackWSProtocol учитывает соединение в метрике версий протокола, возвращает функцию для отключения.
+// Продолжение сессии v2, которой нет на экземпляре, считается отдельно: значит, балансировщик
+// направил клиента не на экземпляр его прошлого соединения или экземпляр перезапускался.
+func trackWSProtocol(service string, conn *wshub.Conn) func() {
+	if conn.ResumeMissed() {
+		metrics.WebSocketResumeMissesTotal.WithLabelValues(service).Inc()
+	}
+	gauge := metrics.WebSocketProtocolConnectionsGauge.WithLabelValues(service, conn.Protocol().String())
+	gauge.Inc()
+	return gauge.Dec
+}
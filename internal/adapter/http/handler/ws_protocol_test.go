@@ -0,0 +1,524 @@
+package handler_test
+
+// Conformance tests for the documented WebSocket protocol of
+// /ws/drivers/{driver_id} and /ws/passengers/{passenger_id}.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	wshandler "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/ws"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	wshub "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	driverID    = uuid.New()
+	passengerID = uuid.New()
+
+	driverToken    = "Bearer driver-token"
+	passengerToken = "Bearer passenger-token"
+)
+
+type fakeAuth struct {
+	users map[string]*models.User
+}
+
+func (a *fakeAuth) RoleCheck(_ context.Context, token string) (*models.User, error) {
+	u, ok := a.users[token]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return u, nil
+}
+
+type fakeDriverService struct {
+	handler.DriverService
+}
+
+func (fakeDriverService) IsExist(context.Context, uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+func (fakeDriverService) DriverConnected(context.Context, uuid.UUID) {}
+
+func (fakeDriverService) DriverDisconnected(context.Context, uuid.UUID) {}
+
+func (fakeDriverService) PushStats(context.Context, uuid.UUID) {}
+
+type testEnv struct {
+	server    *httptest.Server
+	driverHub *wshub.ConnectionHub
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	l := logger.InitLogger("ws-protocol-test", logger.LevelError)
+	auth := &fakeAuth{users: map[string]*models.User{
+		driverToken:    {ID: driverID, Role: types.RoleDriver.String()},
+		passengerToken: {ID: passengerID, Role: types.RolePassenger.String()},
+	}}
+
+	driverHub := wshub.NewConnHub(l)
+	passengerHub := wshub.NewConnHub(l)
+
+	driver := handler.NewDriver(&handler.DriverServiceOptions{
+		WsConnections: driverHub,
+		Service:       fakeDriverService{},
+		Auth:          auth,
+	}, l)
+	ride := handler.NewRide(nil, auth, passengerHub, l)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/drivers/{driver_id}", driver.HandleWS)
+	mux.HandleFunc("GET /ws/passengers/{passenger_id}", ride.HandleWebSocket)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return &testEnv{server: srv, driverHub: driverHub}
+}
+
+func (e *testEnv) dial(t *testing.T, path string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(e.server.URL, "http") + path
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", path, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// authenticate sends the auth frame and expects auth_ok
+func authenticate(t *testing.T, conn *websocket.Conn, token string) {
+	t.Helper()
+
+	if err := conn.WriteJSON(map[string]string{"type": "auth", "token": token}); err != nil {
+		t.Fatalf("write auth: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var ack map[string]any
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("read auth_ok: %v", err)
+	}
+	if ack["type"] != "auth_ok" {
+		t.Fatalf("expected auth_ok, got %v", ack)
+	}
+}
+
+// expectClose reads until the server closes the connection and checks the close code
+func expectClose(t *testing.T, conn *websocket.Conn, code int, within time.Duration) *websocket.CloseError {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(within))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			t.Fatalf("expected close frame with code %d, got %v", code, err)
+		}
+		if closeErr.Code != code {
+			t.Fatalf("expected close code %d, got %d (%s)", code, closeErr.Code, closeErr.Text)
+		}
+		return closeErr
+	}
+}
+
+func TestWS_AuthOK(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("driver", func(t *testing.T) {
+		conn := env.dial(t, "/ws/drivers/"+driverID.String())
+		authenticate(t, conn, driverToken)
+	})
+
+	t.Run("passenger", func(t *testing.T) {
+		conn := env.dial(t, "/ws/passengers/"+passengerID.String())
+		authenticate(t, conn, passengerToken)
+	})
+}
+
+func TestWS_AuthTimeout(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/drivers/"+driverID.String())
+
+	start := time.Now()
+	closeErr := expectClose(t, conn, websocket.ClosePolicyViolation, 7*time.Second)
+
+	if elapsed := time.Since(start); elapsed < 4*time.Second {
+		t.Fatalf("connection closed too early: %s", elapsed)
+	}
+	if !strings.Contains(closeErr.Text, "timeout") {
+		t.Fatalf("expected timeout close reason, got %q", closeErr.Text)
+	}
+}
+
+func TestWS_FirstMessageMustBeAuth(t *testing.T) {
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/passengers/"+passengerID.String())
+	if err := conn.WriteJSON(map[string]string{"type": "location_update"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	expectClose(t, conn, websocket.ClosePolicyViolation, 2*time.Second)
+}
+
+func TestWS_InvalidToken(t *testing.T) {
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/drivers/"+driverID.String())
+	if err := conn.WriteJSON(map[string]string{"type": "auth", "token": "Bearer unknown"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	expectClose(t, conn, websocket.ClosePolicyViolation, 2*time.Second)
+}
+
+func TestWS_IDMismatch(t *testing.T) {
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/passengers/"+uuid.New().String())
+	if err := conn.WriteJSON(map[string]string{"type": "auth", "token": passengerToken}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	expectClose(t, conn, websocket.ClosePolicyViolation, 2*time.Second)
+}
+
+func TestWS_InvalidRole(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("passenger on driver endpoint", func(t *testing.T) {
+		// the path ID must match the token, so the passenger connects to its own ID
+		conn := env.dial(t, "/ws/drivers/"+passengerID.String())
+		authenticate(t, conn, passengerToken)
+		expectClose(t, conn, websocket.ClosePolicyViolation, 2*time.Second)
+	})
+
+	t.Run("driver on passenger endpoint", func(t *testing.T) {
+		conn := env.dial(t, "/ws/passengers/"+driverID.String())
+		authenticate(t, conn, driverToken)
+		expectClose(t, conn, websocket.ClosePolicyViolation, 2*time.Second)
+	})
+}
+
+func TestWS_HeartbeatPing(t *testing.T) {
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/drivers/"+driverID.String())
+	authenticate(t, conn, driverToken)
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// control frames are processed only while reading
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-pinged:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not send heartbeat ping after authentication")
+	}
+}
+
+func TestWS_OfferResponseFlow(t *testing.T) {
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/drivers/"+driverID.String())
+	authenticate(t, conn, driverToken)
+
+	// connection is registered in the hub right after auth_ok
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := env.driverHub.GetConn(driverID); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("driver connection was not registered in hub")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	offer := models.RideOffer{
+		ID:         uuid.New(),
+		RideID:     uuid.New(),
+		RideNumber: "RIDE_20240101_120000_001",
+		ExpiresAt:  time.Now().Add(30 * time.Second),
+	}
+
+	type result struct {
+		accepted bool
+		err      error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		accepted, err := wshandler.NewDriverHub(env.driverHub).GetRideOffer(context.Background(), driverID, offer)
+		resCh <- result{accepted, err}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var got map[string]any
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatalf("read offer: %v", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if got["type"] != "ride_offer" {
+		t.Fatalf("expected ride_offer, got %v", got["type"])
+	}
+	if got["offer_id"] != offer.ID.String() || got["ride_id"] != offer.RideID.String() {
+		t.Fatalf("offer ids mismatch: %v", got)
+	}
+
+	if err := conn.WriteJSON(map[string]any{
+		"type":     "ride_response",
+		"offer_id": offer.ID,
+		"ride_id":  offer.RideID,
+		"accepted": true,
+		"current_location": map[string]float64{
+			"latitude":  43.238949,
+			"longitude": 76.889709,
+		},
+	}); err != nil {
+		t.Fatalf("write ride_response: %v", err)
+	}
+
+	select {
+	case res := <-resCh:
+		if res.err != nil {
+			t.Fatalf("GetRideOffer: %v", res.err)
+		}
+		if !res.accepted {
+			t.Fatal("expected offer to be accepted")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("server did not process ride_response")
+	}
+}
+
+// authenticateV2 requests protocol v2 and returns auth_ok
+func authenticateV2(t *testing.T, conn *websocket.Conn, token string, resumeFrom uint64) map[string]any {
+	t.Helper()
+
+	if err := conn.WriteJSON(map[string]any{
+		"type":        "auth",
+		"token":       token,
+		"protocol":    2,
+		"resume_from": resumeFrom,
+	}); err != nil {
+		t.Fatalf("write auth: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var ack map[string]any
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("read auth_ok: %v", err)
+	}
+	if ack["type"] != "auth_ok" {
+		t.Fatalf("expected auth_ok, got %v", ack)
+	}
+	return ack
+}
+
+func waitConn(t *testing.T, hub *wshub.ConnectionHub, id uuid.UUID, protocol wshub.Protocol) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if conn, err := hub.GetConn(id); err == nil && conn.Protocol() == protocol {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("connection with protocol %s was not registered in hub", protocol)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readEnvelope(t *testing.T, conn *websocket.Conn) wshub.Envelope {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var env wshub.Envelope
+	if err := conn.ReadJSON(&env); err != nil {
+		t.Fatalf("read envelope: %v", err)
+	}
+	if env.V != wshub.ProtocolV2 || env.Seq == 0 {
+		t.Fatalf("expected v2 envelope, got %+v", env)
+	}
+	return env
+}
+
+func TestWS_ProtocolNegotiation(t *testing.T) {
+	env := newTestEnv(t)
+
+	t.Run("v1 client gets unversioned auth_ok", func(t *testing.T) {
+		conn := env.dial(t, "/ws/passengers/"+passengerID.String())
+		if err := conn.WriteJSON(map[string]string{"type": "auth", "token": passengerToken}); err != nil {
+			t.Fatalf("write auth: %v", err)
+		}
+		var ack map[string]any
+		if err := conn.ReadJSON(&ack); err != nil {
+			t.Fatalf("read auth_ok: %v", err)
+		}
+		if _, ok := ack["protocol"]; ok {
+			t.Fatalf("v1 auth_ok must not carry protocol, got %v", ack)
+		}
+	})
+
+	t.Run("newer version is capped", func(t *testing.T) {
+		conn := env.dial(t, "/ws/passengers/"+passengerID.String())
+		if err := conn.WriteJSON(map[string]any{"type": "auth", "token": passengerToken, "protocol": 3}); err != nil {
+			t.Fatalf("write auth: %v", err)
+		}
+		var ack map[string]any
+		if err := conn.ReadJSON(&ack); err != nil {
+			t.Fatalf("read auth_ok: %v", err)
+		}
+		if ack["protocol"] != float64(wshub.LatestProtocol) {
+			t.Fatalf("expected protocol %d, got %v", wshub.LatestProtocol, ack)
+		}
+	})
+
+	t.Run("capabilities", func(t *testing.T) {
+		conn := env.dial(t, "/ws/passengers/"+passengerID.String())
+		auth := map[string]any{
+			"type":  "auth",
+			"token": passengerToken,
+			"capabilities": map[string]any{
+				"delta_updates": true,
+				"v2_envelope":   true,
+				"locale":        "ru-RU",
+			},
+		}
+		if err := conn.WriteJSON(auth); err != nil {
+			t.Fatalf("write auth: %v", err)
+		}
+		var ack dto.AuthWebSocketResp
+		if err := conn.ReadJSON(&ack); err != nil {
+			t.Fatalf("read auth_ok: %v", err)
+		}
+		want := dto.WSCapabilities{DeltaUpdates: true, V2Envelope: true, Locale: "ru"}
+		if ack.Protocol != int(wshub.ProtocolV2) || ack.Capabilities == nil || *ack.Capabilities != want {
+			t.Fatalf("expected v2 with accepted capabilities, got %+v", ack)
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		conn := env.dial(t, "/ws/drivers/"+driverID.String())
+		if err := conn.WriteJSON(map[string]any{"type": "auth", "token": driverToken, "protocol": -1}); err != nil {
+			t.Fatalf("write auth: %v", err)
+		}
+		expectClose(t, conn, websocket.ClosePolicyViolation, 2*time.Second)
+	})
+}
+
+func TestWS_V2OfferAndResume(t *testing.T) {
+	env := newTestEnv(t)
+
+	conn := env.dial(t, "/ws/drivers/"+driverID.String())
+	if ack := authenticateV2(t, conn, driverToken, 0); ack["protocol"] != float64(2) {
+		t.Fatalf("expected protocol 2, got %v", ack)
+	}
+	waitConn(t, env.driverHub, driverID, wshub.ProtocolV2)
+
+	offer := models.RideOffer{
+		ID:        uuid.New(),
+		RideID:    uuid.New(),
+		ExpiresAt: time.Now().Add(30 * time.Second),
+	}
+	resCh := make(chan bool, 1)
+	go func() {
+		accepted, _ := wshandler.NewDriverHub(env.driverHub).GetRideOffer(context.Background(), driverID, offer)
+		resCh <- accepted
+	}()
+
+	got := readEnvelope(t, conn)
+	if got.Type != "ride_offer" || !strings.Contains(string(got.Data), offer.ID.String()) {
+		t.Fatalf("expected ride_offer envelope, got %+v", got)
+	}
+
+	// an enveloped v2 response is handled the same as a flat v1 message
+	if err := conn.WriteJSON(map[string]any{
+		"type": "ride_response",
+		"data": map[string]any{
+			"offer_id":         offer.ID,
+			"ride_id":          offer.RideID,
+			"accepted":         true,
+			"current_location": map[string]float64{"latitude": 43.238949, "longitude": 76.889709},
+		},
+	}); err != nil {
+		t.Fatalf("write ride_response: %v", err)
+	}
+	select {
+	case accepted := <-resCh:
+		if !accepted {
+			t.Fatal("expected offer to be accepted")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("server did not process enveloped ride_response")
+	}
+
+	// the first event is acknowledged, the second one is not
+	if err := conn.WriteJSON(map[string]any{"type": "ack", "seq": got.Seq}); err != nil {
+		t.Fatalf("write ack: %v", err)
+	}
+	if err := env.driverHub.SendTo(driverID, map[string]string{"type": "driver_stats"}); err != nil {
+		t.Fatalf("send stats: %v", err)
+	}
+	stats := readEnvelope(t, conn)
+	if stats.Seq != got.Seq+1 {
+		t.Fatalf("expected seq %d, got %d", got.Seq+1, stats.Seq)
+	}
+	conn.Close()
+
+	// after reconnecting the unacknowledged event is resent with its original seq
+	reconn := env.dial(t, "/ws/drivers/"+driverID.String())
+	authenticateV2(t, reconn, driverToken, got.Seq)
+
+	resent := readEnvelope(t, reconn)
+	if resent.Seq != stats.Seq || resent.Type != "driver_stats" {
+		t.Fatalf("expected driver_stats with seq %d to be resent, got %+v", stats.Seq, resent)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/incident"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// readOnlyRetryAfter — подсказка клиенту в заголовке Retry-After, секунды
+const readOnlyRetryAfter = 30
+
+// ReadOnly в режиме инцидента направляет чтения в реплику, а запросы на запись
+// отклоняет с 503 до того, как они дойдут до основной базы
+func (h *Middleware) ReadOnly(sw *incident.Switch) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !sw.ReadOnly() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				ctx := r.Context()
+				if replica := sw.Replica(); replica != nil {
+					ctx = incident.WithReplica(ctx, replica)
+				}
+				next.ServeHTTP(w, r.WithContext(ctx))
+			default:
+				h.log.Warn(wrap.WithAction(r.Context(), "read_only_mode"), "rejected write request in read-only mode", "method", r.Method, "path", r.URL.Path)
+				w.Header().Set("Retry-After", strconv.Itoa(readOnlyRetryAfter))
+				errorResponse(w, http.StatusServiceUnavailable, types.ErrReadOnlyMode.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+)
+
+const (
+	// ServiceTokenHeader - заголовок с подписанным токеном сервиса (SERVICE_AUTH_PRIVATE_KEYS)
+	ServiceTokenHeader = "X-Service-Token"
+	// InternalTokenHeader - заголовок с общим секретом внутреннего API между сервисами.
+	// Deprecated: используйте ServiceTokenHeader, общий секрет не говорит, какой сервис вызывает.
+	InternalTokenHeader = "X-Internal-Token"
+)
+
+// RequireInternal пропускает только запросы других сервисов: с токеном сервиса, подписанным
+// ключом из identity, или с общим секретом legacyToken. Имя вызывающего сервиса попадает в контекст логов.
+// Без ключей и без legacyToken внутренний API выключен.
+func (h *Middleware) RequireInternal(identity *svcauth.Identity, legacyToken string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := wrap.WithAction(r.Context(), "internal_auth")
+
+		if token := r.Header.Get(ServiceTokenHeader); token != "" && identity.Enabled() {
+			service, err := identity.VerifyToken(token, r.Method, r.URL.Path)
+			if err != nil {
+				h.log.Warn(ctx, "rejected internal API request", "path", r.URL.Path, "reason", err.Error())
+				errorResponse(w, http.StatusUnauthorized, "invalid service token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(wrap.WithCallerService(r.Context(), service)))
+			return
+		}
+
+		got := r.Header.Get(InternalTokenHeader)
+		if legacyToken == "" || subtle.ConstantTimeCompare([]byte(got), []byte(legacyToken)) != 1 {
+			h.log.Warn(ctx, "rejected internal API request", "path", r.URL.Path)
+			errorResponse(w, http.StatusUnauthorized, "invalid internal token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
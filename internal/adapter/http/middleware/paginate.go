@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
+)
+
+const defaultPageSize = 20
+
+// Paginate parses page, page_size, sort and the endpoint's filter parameters from the
+// query string, validates them against opts and places models.Filters into the request
+// context. Invalid values are rejected with 422 before the handler runs.
+//
+//	mux.Handle("GET /admin/blocklist", m.RequireRoles(m.Paginate(h.GetBlocklist, handler.BlocklistListing), types.RoleAdmin))
+func (h *Middleware) Paginate(next http.HandlerFunc, opts models.ListOptions) http.HandlerFunc {
+	pageSize := opts.DefaultPageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		v := validator.New()
+		qs := r.URL.Query()
+
+		filters := models.Filters{
+			Page:         queryInt(qs.Get("page"), 1, "page", v),
+			PageSize:     queryInt(qs.Get("page_size"), pageSize, "page_size", v),
+			Sort:         opts.DefaultSort,
+			SortSafelist: opts.SortSafelist,
+			Params:       make(map[string]string, len(opts.FilterKeys)),
+		}
+		if sort := qs.Get("sort"); sort != "" {
+			filters.Sort = sort
+		}
+		for _, key := range opts.FilterKeys {
+			if value := qs.Get(key); value != "" {
+				filters.Params[key] = value
+			}
+		}
+
+		if v.Valid() {
+			filters.Validate(v)
+		}
+		if !v.Valid() {
+			errorResponse(w, http.StatusUnprocessableEntity, v.Errors)
+			return
+		}
+
+		next(w, r.WithContext(models.WithFilters(r.Context(), filters)))
+	}
+}
+
+func queryInt(s string, defaultValue int, key string, v *validator.Validator) int {
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// PartnerAPIKeyHeader - заголовок с API ключом таксопарка
+const PartnerAPIKeyHeader = "X-API-Key"
+
+type PartnerAuthenticator interface {
+	Authenticate(ctx context.Context, apiKey string) (*models.Partner, error)
+}
+
+// RequirePartner пропускает только запросы с действующим API ключом партнёра
+// и кладёт партнёра в контекст. JWT пользователей partner API не принимает.
+func (h *Middleware) RequirePartner(auth PartnerAuthenticator, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		apiKey := r.Header.Get(PartnerAPIKeyHeader)
+		if apiKey == "" {
+			errorResponse(w, http.StatusUnauthorized, "partner API key required")
+			return
+		}
+
+		partner, err := auth.Authenticate(ctx, apiKey)
+		if err != nil || partner == nil {
+			h.log.Warn(wrap.WithAction(ctx, "partner_auth"), "failed to authenticate partner", "error", err)
+			errorResponse(w, http.StatusUnauthorized, "invalid partner API key")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(models.WithPartner(ctx, partner)))
+	})
+}
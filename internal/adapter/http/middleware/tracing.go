@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/tracing"
+)
+
+// Trace starts a server span for every request, continuing the caller's trace from the traceparent header.
+// The span is named by the route pattern, so /rides/{ride_id}/cancel stays one operation in the collector.
+func (m *Middleware) Trace(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// WebSocket connections live for the whole ride, a span for them says nothing useful
+			if r.URL.Path == "/metrics" || strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := tracing.Extract(r.Context(), tracing.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(ctx, r.Method, tracing.KindServer)
+			if span == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			defer func() {
+				// Recover is outside, the panic goes on to it after being recorded
+				if p := recover(); p != nil {
+					span.RecordError(errors.New("panic"))
+					span.End()
+					panic(p)
+				}
+				span.End()
+			}()
+
+			route := r.URL.Path
+			if _, pattern := mux.Handler(r); pattern != "" {
+				route = pattern
+			}
+			span.SetName(route)
+			span.SetAttr("http.request.method", r.Method)
+			span.SetAttr("http.route", route)
+			span.SetAttr("url.path", r.URL.Path)
+			span.SetAttr("request_id", wrap.GetRequestID(ctx))
+
+			rw := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			span.SetAttr("http.response.status_code", rw.statusCode)
+			if rw.statusCode >= http.StatusInternalServerError {
+				span.RecordError(errors.New(http.StatusText(rw.statusCode)))
+			}
+		})
+	}
+}
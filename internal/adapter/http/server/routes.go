@@ -4,23 +4,28 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/middleware"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // setupRoutes - setups http routes
-func setupRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware, mode types.ServiceMode, log logger.Logger) {
+func setupRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware, cfg config.Config, identity *svcauth.Identity, log logger.Logger) {
 	// System Health
 	mux.HandleFunc("/health", routes.health.HealthCheck)
+	mux.HandleFunc("GET /health/live", routes.health.Live)
+	mux.HandleFunc("GET /health/ready", routes.health.Ready)
 
-	setupSwaggerRoutes(mux, mode, log)
+	setupSwaggerRoutes(mux, cfg.Mode, log)
 	setupMetricsRoute(mux)
 
-	switch mode {
+	switch cfg.Mode {
 	case types.AdminService:
 		setupAdminRoutes(mux, routes, m)
 	case types.RideService:
@@ -28,39 +33,141 @@ func setupRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware,
 	case types.DriverAndLocationService:
 		setupDriverAndLocationRoutes(mux, routes, m)
 	case types.AuthService:
-		setupAuthRoutes(mux, routes)
+		setupAuthRoutes(mux, routes, m)
+	case types.LocationService:
+		setupLocationRoutes(mux, routes, m, identity, cfg.Location.InternalToken)
 	}
 }
 
 // setupAdminRoutes setups routes for admin service
 func setupAdminRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware) {
-	mux.Handle("GET /admin/overview", m.RequireRoles(routes.admin.GetOverview, types.RoleAdmin))        // Get system metrics overview
-	mux.Handle("GET /admin/rides/active", m.RequireRoles(routes.admin.GetActiveRides, types.RoleAdmin)) // Get list of active rides
+	mux.Handle("GET /admin/overview", m.RequireRoles(routes.admin.GetOverview, types.RoleAdmin))                                                // Get system metrics overview
+	mux.Handle("GET /admin/rides", m.RequireRoles(routes.admin.SearchRides, types.RoleAdmin))                                                   // Search rides with filters
+	mux.Handle("GET /admin/rides/active", m.RequireRoles(m.Paginate(routes.admin.GetActiveRides, handler.ActiveRidesListing), types.RoleAdmin)) // Deprecated: use GET /admin/rides
+	mux.Handle("GET /admin/slo", m.RequireRoles(routes.admin.GetSLO, types.RoleAdmin))                                                          // Get SLO compliance and error budget
+	mux.Handle("GET /admin/blocklist", m.RequireRoles(m.Paginate(routes.admin.GetBlocklist, handler.BlocklistListing), types.RoleAdmin))        // Get drivers' passenger blocklists
+	mux.Handle("GET /admin/rides/{ride_id}/state-at", m.RequireRoles(routes.admin.GetRideStateAt, types.RoleAdmin))                             // Reconstruct ride state at timestamp
+	mux.Handle("GET /admin/rides/{ride_id}/communications", m.RequireRoles(routes.admin.GetRideCommunications, types.RoleAdmin))                // Ride communication log
+	mux.Handle("GET /admin/anomalies", m.RequireRoles(routes.admin.GetAnomalies, types.RoleAdmin))                                              // Detect stuck and inconsistent entities
+	mux.Handle("POST /admin/anomalies/{kind}/{entity_id}/remediate", m.RequireRoles(routes.admin.RemediateAnomaly, types.RoleAdmin))            // Apply anomaly remediation action
+	mux.Handle("GET /admin/settings/cities", m.RequireRoles(routes.admin.GetCitySettings, types.RoleAdmin))                                     // Get city operational hours and night rules
+	mux.Handle("PUT /admin/settings/cities/{code}", m.RequireRoles(routes.admin.UpdateCitySettings, types.RoleAdmin))                           // Create or update city settings
+	mux.Handle("GET /admin/flat-rates", m.RequireRoles(routes.admin.ListFlatRates, types.RoleAdmin))                                            // List effective and scheduled flat rates
+	mux.Handle("GET /admin/flat-rates/{code}", m.RequireRoles(routes.admin.GetFlatRateHistory, types.RoleAdmin))                                // Get flat rate versions
+	mux.Handle("PUT /admin/flat-rates/{code}", m.RequireRoles(routes.admin.SetFlatRate, types.RoleAdmin))                                       // Create a new flat rate version
+	mux.Handle("DELETE /admin/flat-rates/{code}", m.RequireRoles(routes.admin.EndFlatRate, types.RoleAdmin))                                    // End a flat rate
+	mux.Handle("GET /admin/matching-blackouts", m.RequireRoles(routes.admin.ListMatchingBlackouts, types.RoleAdmin))                            // List active and scheduled matching blackouts
+	mux.Handle("POST /admin/matching-blackouts", m.RequireRoles(routes.admin.CreateMatchingBlackout, types.RoleAdmin))                          // Pause matching in a zone
+	mux.Handle("PUT /admin/matching-blackouts/{blackout_id}", m.RequireRoles(routes.admin.UpdateMatchingBlackout, types.RoleAdmin))             // Update a matching blackout
+	mux.Handle("DELETE /admin/matching-blackouts/{blackout_id}", m.RequireRoles(routes.admin.EndMatchingBlackout, types.RoleAdmin))             // End a matching blackout
+	mux.Handle("POST /admin/broadcast", m.RequireRoles(routes.admin.Broadcast, types.RoleAdmin))                                                // Broadcast announcement to connected clients
+	mux.Handle("GET /admin/broadcasts/{broadcast_id}", m.RequireRoles(routes.admin.GetBroadcast, types.RoleAdmin))                              // Get broadcast delivery stats
+	mux.Handle("PUT /admin/drivers/{driver_id}/simulator", m.RequireRoles(routes.admin.SetDriverSimulator, types.RoleAdmin))                    // Mark driver as sandbox simulator
+	mux.Handle("PUT /admin/passengers/{passenger_id}/priority-boarding", m.RequireRoles(routes.admin.SetPriorityBoarding, types.RoleAdmin))     // Allow passenger priority boarding
+	mux.Handle("GET /admin/fraud/graph", m.RequireRoles(routes.admin.GetFraudGraph, types.RoleAdmin))                                           // Suspicious relationships graph
+	mux.Handle("GET /admin/driver-changes", m.RequireRoles(routes.admin.GetDriverChanges, types.RoleAdmin))                                     // Pending driver profile changes
+	mux.Handle("POST /admin/driver-changes/{change_id}/approve", m.RequireRoles(routes.admin.ApproveDriverChange, types.RoleAdmin))             // Approve and apply driver change
+	mux.Handle("POST /admin/driver-changes/{change_id}/reject", m.RequireRoles(routes.admin.RejectDriverChange, types.RoleAdmin))               // Reject driver change
+	mux.Handle("POST /admin/ops/actions", m.RequireRoles(routes.admin.RunOpsAction, types.RoleAdmin))                                           // Run auditable ops action
+	mux.Handle("GET /admin/ops/actions", m.RequireRoles(routes.admin.GetOpsActions, types.RoleAdmin))                                           // Ops actions audit log
+	mux.Handle("GET /admin/ops/actions/{action_id}", m.RequireRoles(routes.admin.GetOpsAction, types.RoleAdmin))                                // Get ops action result
+	mux.Handle("GET /admin/export/state", m.RequireRoles(routes.admin.ExportState, types.RoleAdmin))                                            // Consistent live state snapshot
+	mux.Handle("GET /admin/export/state/{export_id}", m.RequireRoles(routes.admin.GetStateExport, types.RoleAdmin))                             // Background state export result
+	mux.Handle("GET /admin/telemetry", m.RequireRoles(routes.admin.GetTelemetryReport, types.RoleAdmin))                                        // App quality telemetry report
+	mux.Handle("GET /admin/reports/coverage", m.RequireRoles(routes.admin.GetCoverageReport, types.RoleAdmin))                                  // Dispatch coverage by area
+	mux.Handle("GET /admin/positioning/effectiveness", m.RequireRoles(routes.admin.GetPositioningEffectiveness, types.RoleAdmin))               // Positioning tips effectiveness
+	mux.Handle("GET /admin/failed-messages", m.RequireRoles(routes.admin.GetFailedMessages, types.RoleAdmin))                                   // Dead-lettered broker messages
+	mux.Handle("GET /admin/failed-messages/{message_id}", m.RequireRoles(routes.admin.GetFailedMessage, types.RoleAdmin))                       // Get dead-lettered message
+	mux.Handle("POST /admin/failed-messages/{message_id}/replay", m.RequireRoles(routes.admin.ReplayFailedMessage, types.RoleAdmin))            // Replay message to its source queue
+	mux.Handle("POST /admin/promo-codes", m.RequireRoles(routes.promo.CreatePromoCode, types.RoleAdmin))                                        // Create promo code
+	mux.Handle("GET /admin/promo-codes", m.RequireRoles(routes.promo.ListPromoCodes, types.RoleAdmin))                                          // List promo codes
+	mux.Handle("GET /admin/promo-codes/{promo_id}", m.RequireRoles(routes.promo.GetPromoCode, types.RoleAdmin))                                 // Get promo code
+	mux.Handle("PATCH /admin/promo-codes/{promo_id}", m.RequireRoles(routes.promo.UpdatePromoCode, types.RoleAdmin))                            // Update promo code
+	mux.Handle("DELETE /admin/promo-codes/{promo_id}", m.RequireRoles(routes.promo.DeletePromoCode, types.RoleAdmin))                           // Deactivate promo code
 }
 
 // setupRideRoutes setups routes for ride service
 func setupRideRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware) {
-	mux.Handle("POST /rides", m.RequireRoles(routes.ride.CreateRide, types.RolePassenger))                  // Create a new ride request
-	mux.Handle("POST /rides/{ride_id}/cancel", m.RequireRoles(routes.ride.CancelRide, types.RolePassenger)) // Cancel a ride
-	mux.HandleFunc("GET /ws/passengers/{passenger_id}", routes.ride.HandleWebSocket)                        // WebSocket connection for passengers
+	mux.Handle("POST /rides", m.RequireRoles(routes.ride.CreateRide, types.RolePassenger))                                            // Create a new ride request
+	mux.Handle("GET /rides", m.RequireRoles(m.Paginate(routes.ride.GetRideHistory, handler.RideHistoryListing), types.RolePassenger)) // Passenger ride history
+	mux.Handle("POST /rides/estimate", m.RequireRoles(routes.ride.EstimateRide, types.RolePassenger))                                 // Estimate fare and suggest pickup point
+	mux.Handle("POST /rides/{ride_id}/cancel", m.RequireRoles(routes.ride.CancelRide, types.RolePassenger))                           // Cancel a ride
+	mux.Handle("POST /rides/{ride_id}/rating", m.RequireRoles(routes.ride.RateRide, types.RolePassenger))                             // Rate the driver of a completed ride
+	mux.Handle("GET /rides/{ride_id}/payment", m.RequireRoles(routes.ride.GetRidePayment, types.RolePassenger))                       // Payment method and pre-authorization hold
+	mux.Handle("GET /rides/{ride_id}/driver", m.RequireRoles(routes.ride.GetDriverCard, types.RolePassenger))                         // Driver and vehicle card with ETA to pickup
+	mux.Handle("GET /rides/{ride_id}/receipt", m.RequireRoles(routes.ride.GetRideReceipt, types.RolePassenger))                       // Fare breakdown of a completed ride
+	mux.Handle("GET /passengers/{passenger_id}/impact", m.RequireRoles(routes.ride.GetImpact, types.RolePassenger))                   // Monthly carbon footprint
+	mux.Handle("GET /passengers/{passenger_id}/wallet", m.RequireRoles(routes.ride.GetWallet, types.RolePassenger))                   // Wallet balance and ledger
+	mux.Handle("POST /passengers/{passenger_id}/wallet/top-up", m.RequireRoles(routes.ride.TopUpWallet, types.RolePassenger))         // Top up wallet from card
+	mux.Handle("POST /telemetry", m.RequireRoles(routes.ride.SubmitTelemetry, types.RolePassenger, types.RoleDriver))                 // Submit app quality telemetry
+	mux.Handle("POST /admin/sandbox/rides", m.RequireRoles(routes.ride.CreateSandboxRide, types.RoleAdmin))                           // Create a synthetic test ride
+	mux.Handle("POST /admin/rides/{ride_id}/cancel", m.RequireRoles(routes.ride.AdminCancelRide, types.RoleAdmin))                    // Force-cancel a stuck ride
+	mux.Handle("POST /admin/rides/{ride_id}/reassign", m.RequireRoles(routes.ride.ReassignRide, types.RoleAdmin))                     // Release the driver and restart matching
+	mux.HandleFunc("GET /rates", routes.ride.GetRates)                                                                                // Public rate card per vehicle class and city
+	mux.HandleFunc("GET /ws/passengers/{passenger_id}", routes.ride.HandleWebSocket)                                                  // WebSocket connection for passengers
 }
 
 // setupDriverAndLocationRoutes setups routes for driver and location service
 func setupDriverAndLocationRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware) {
 	mux.HandleFunc("POST /drivers", routes.driver.Register)
-	mux.Handle("POST /drivers/{driver_id}/online", m.RequireRoles(routes.driver.GoOnline, types.RoleDriver))         // Driver goes online
-	mux.Handle("POST /drivers/{driver_id}/offline", m.RequireRoles(routes.driver.GoOffline, types.RoleDriver))       // Driver goes offline
-	mux.Handle("POST /drivers/{driver_id}/location", m.RequireRoles(routes.driver.UpdateLocation, types.RoleDriver)) // Update driver location
-	mux.Handle("POST /drivers/{driver_id}/start", m.RequireRoles(routes.driver.StartRide, types.RoleDriver))         // Start a ride
-	mux.Handle("POST /drivers/{driver_id}/complete", m.RequireRoles(routes.driver.CompleteRide, types.RoleDriver))   // Complete a ride
-	mux.HandleFunc("GET /ws/drivers/{driver_id}", routes.driver.HandleWS)                                            // WebSocket connection for drivers
+	mux.Handle("GET /drivers/{driver_id}", m.RequireRoles(routes.driver.GetProfile, types.RoleDriver, types.RolePassenger, types.RoleAdmin))                                       // Get driver profile and tier
+	mux.Handle("PATCH /drivers/{driver_id}", m.RequireRoles(routes.driver.UpdateProfile, types.RoleDriver))                                                                        // Update own profile, license changes need approval
+	mux.Handle("GET /drivers/{driver_id}/vehicle", m.RequireRoles(routes.driver.GetVehicle, types.RoleDriver, types.RoleAdmin))                                                    // Active vehicle
+	mux.Handle("PUT /drivers/{driver_id}/vehicle", m.RequireRoles(routes.driver.UpdateVehicle, types.RoleDriver))                                                                  // Replace active vehicle attributes and re-classify
+	mux.Handle("GET /drivers/{driver_id}/vehicles", m.RequireRoles(routes.driver.GetVehicles, types.RoleDriver, types.RoleAdmin))                                                  // All driver vehicles
+	mux.Handle("POST /drivers/{driver_id}/vehicles", m.RequireRoles(routes.driver.AddVehicle, types.RoleDriver))                                                                   // Add a vehicle
+	mux.Handle("POST /drivers/{driver_id}/vehicles/{vehicle_id}/activate", m.RequireRoles(routes.driver.ActivateVehicle, types.RoleDriver))                                        // Switch active vehicle
+	mux.Handle("POST /drivers/{driver_id}/online", m.RequireRoles(routes.driver.GoOnline, types.RoleDriver))                                                                       // Driver goes online
+	mux.Handle("POST /drivers/{driver_id}/offline", m.RequireRoles(routes.driver.GoOffline, types.RoleDriver))                                                                     // Driver goes offline
+	mux.Handle("POST /drivers/{driver_id}/location", m.RequireRoles(routes.driver.UpdateLocation, types.RoleDriver))                                                               // Update driver location
+	mux.Handle("POST /drivers/{driver_id}/start", m.RequireRoles(routes.driver.StartRide, types.RoleDriver))                                                                       // Start a ride
+	mux.Handle("POST /drivers/{driver_id}/complete", m.RequireRoles(routes.driver.CompleteRide, types.RoleDriver))                                                                 // Complete a ride
+	mux.Handle("POST /drivers/{driver_id}/reconcile", m.RequireRoles(routes.driver.Reconcile, types.RoleDriver))                                                                   // Apply actions performed while offline
+	mux.Handle("GET /drivers/{driver_id}/blocklist", m.RequireRoles(routes.driver.GetBlocklist, types.RoleDriver))                                                                 // Get blocked passengers
+	mux.Handle("POST /drivers/{driver_id}/blocklist", m.RequireRoles(routes.driver.BlockPassenger, types.RoleDriver))                                                              // Block a passenger
+	mux.Handle("DELETE /drivers/{driver_id}/blocklist/{passenger_id}", m.RequireRoles(routes.driver.UnblockPassenger, types.RoleDriver))                                           // Unblock a passenger
+	mux.Handle("GET /drivers/{driver_id}/rides", m.RequireRoles(m.Paginate(routes.driver.GetRideHistory, handler.RideHistoryListing), types.RoleDriver))                           // Driver ride history
+	mux.Handle("GET /drivers/{driver_id}/rides/current", m.RequireRoles(routes.driver.GetCurrentRide, types.RoleDriver))                                                           // Active ride to restore the app after restart
+	mux.Handle("GET /drivers/{driver_id}/sessions", m.RequireRoles(m.Paginate(routes.driver.GetSessionHistory, handler.SessionHistoryListing), types.RoleDriver, types.RoleAdmin)) // Past online sessions
+	mux.Handle("GET /drivers/{driver_id}/ratings", m.RequireRoles(m.Paginate(routes.driver.GetRatings, handler.RatingsListing), types.RoleDriver, types.RoleAdmin))                // Driver rating and feedback history
+	mux.Handle("GET /drivers/{driver_id}/tax-summary", m.RequireRoles(routes.driver.GetTaxSummary, types.RoleDriver))                                                              // Yearly earnings for income declaration
+	mux.Handle("GET /drivers/{driver_id}/earnings", m.RequireRoles(routes.driver.GetEarnings, types.RoleDriver, types.RoleAdmin))                                                  // Earnings per day, week or month
+	mux.Handle("GET /drivers/{driver_id}/balance", m.RequireRoles(routes.payout.GetBalance, types.RoleDriver))                                                                     // Balance available for instant payout
+	mux.Handle("GET /drivers/{driver_id}/payouts", m.RequireRoles(routes.payout.GetPayouts, types.RoleDriver))                                                                     // Instant payouts history
+	mux.Handle("POST /drivers/{driver_id}/payouts", m.RequireRoles(routes.payout.RequestPayout, types.RoleDriver))                                                                 // Request instant payout
+	mux.HandleFunc("POST /webhooks/payouts", routes.payout.PayoutWebhook)                                                                                                          // Payout provider status webhook, signed with X-Webhook-Signature
+	mux.HandleFunc("GET /ws/drivers/{driver_id}", routes.driver.HandleWS)                                                                                                          // WebSocket connection for drivers
+
+	// Partner API для таксопарков, авторизация по X-API-Key
+	mux.Handle("POST /partners", m.RequireRoles(routes.partner.CreatePartner, types.RoleAdmin))                               // Create fleet partner and issue API key
+	mux.Handle("POST /partner/drivers", m.RequirePartner(routes.partner, routes.partner.RegisterDrivers))                     // Register fleet drivers in bulk
+	mux.Handle("POST /partner/drivers/{driver_id}/status", m.RequirePartner(routes.partner, routes.partner.ReportStatus))     // Report fleet driver status
+	mux.Handle("POST /partner/drivers/{driver_id}/location", m.RequirePartner(routes.partner, routes.partner.ReportLocation)) // Report fleet driver location
+	mux.Handle("POST /partner/offers/{offer_id}/response", m.RequirePartner(routes.partner, routes.partner.RespondOffer))     // Accept or decline ride offer
+}
+
+// setupLocationRoutes setups routes for location service: GPS ingestion from drivers
+// and internal API for other services, authorized by X-Service-Token (or deprecated X-Internal-Token)
+func setupLocationRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware, identity *svcauth.Identity, internalToken string) {
+	mux.Handle("POST /drivers/{driver_id}/location", m.RequireRoles(routes.location.UpdateLocation, types.RoleDriver)) // Update driver location
+
+	mux.Handle("POST /internal/locations", m.RequireInternal(identity, internalToken, routes.location.Ingest)) // Ingest forwarded driver location
 }
 
-func setupAuthRoutes(mux *http.ServeMux, routes *handlers) {
+func setupAuthRoutes(mux *http.ServeMux, routes *handlers, m *middleware.Middleware) {
 	mux.HandleFunc("POST /auth/register", routes.auth.Register)
 	mux.HandleFunc("POST /auth/login", routes.auth.Login)
 	mux.HandleFunc("POST /auth/refresh", routes.auth.Refresh)
 	mux.HandleFunc("GET /auth/me", routes.auth.Profile)
+	mux.Handle("PUT /auth/me", m.RequireRoles(routes.auth.UpdateProfile, types.RolePassenger, types.RoleDriver, types.RoleAdmin))                                // Update name, phone and attrs
+	mux.Handle("POST /auth/me/phone/verification", m.RequireRoles(routes.auth.RequestPhoneVerification, types.RolePassenger, types.RoleDriver, types.RoleAdmin)) // Send phone verification code
+	mux.Handle("POST /auth/me/phone/verify", m.RequireRoles(routes.auth.VerifyPhone, types.RolePassenger, types.RoleDriver, types.RoleAdmin))                    // Verify phone with the code
+	mux.Handle("POST /auth/logout", m.RequireRoles(routes.auth.Logout, types.RolePassenger, types.RoleDriver, types.RoleAdmin))                                  // Revoke current session tokens
+	mux.Handle("POST /auth/logout-all", m.RequireRoles(routes.auth.LogoutAll, types.RolePassenger, types.RoleDriver, types.RoleAdmin))                           // Revoke all user tokens
+	mux.Handle("GET /me/preferences", m.RequireRoles(routes.preferences.GetPreferences, types.RolePassenger, types.RoleDriver, types.RoleAdmin))                 // Get notification preferences
+	mux.Handle("PUT /me/preferences", m.RequireRoles(routes.preferences.UpdatePreferences, types.RolePassenger, types.RoleDriver, types.RoleAdmin))              // Update notification preferences
+	mux.Handle("PUT /me/push-tokens/{device_id}", m.RequireRoles(routes.preferences.RegisterPushToken, types.RolePassenger, types.RoleDriver, types.RoleAdmin))  // Register device push token
+	mux.Handle("DELETE /me/push-tokens/{device_id}", m.RequireRoles(routes.preferences.RemovePushToken, types.RolePassenger, types.RoleDriver, types.RoleAdmin)) // Remove device push token
 }
 
 // setupSwaggerRoutes configures Swagger UI endpoints based on service mode
@@ -76,6 +183,8 @@ func setupSwaggerRoutes(mux *http.ServeMux, mode types.ServiceMode, log logger.L
 		instanceName = "admin"
 	case types.AuthService:
 		instanceName = "auth"
+	case types.LocationService:
+		return // internal API, swagger is served by driver-service
 	default:
 		log.Warn(wrap.WithAction(context.Background(), "setup swagger routes"), "unknown service mode for swagger setup", "mode", mode)
 		return
@@ -11,13 +11,18 @@ import (
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/middleware"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/health"
+	"github.com/Temutjin2k/ride-hail-system/pkg/incident"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
 )
 
 type (
 	API struct {
 		server *http.Server
+		m      *middleware.Middleware
+		health *handler.Health
 		log    logger.Logger
 	}
 
@@ -26,6 +31,13 @@ type (
 		driver *handler.Driver
 		admin  *handler.Admin
 		auth   *handler.Auth
+		promo  *handler.Promo
+
+		partner  *handler.Partner
+		payout   *handler.Payout
+		location *handler.Location
+
+		preferences *handler.Preferences
 
 		health *handler.Health
 	}
@@ -35,9 +47,12 @@ func New(
 	ctx context.Context,
 	cfg config.Config,
 	driverService *handler.DriverServiceOptions,
+	locationService handler.LocationService,
 	rideService handler.RideService,
 	adminService handler.AdminService,
+	promoService handler.PromoService,
 	authService handler.AuthService,
+	preferenceService handler.PreferenceService,
 	wshub handler.ConnectionHub,
 	logger logger.Logger,
 ) (*API, error) {
@@ -47,29 +62,52 @@ func New(
 
 	handlers := newHandlers(cfg,
 		driverService,
+		locationService,
 		rideService,
 		adminService,
+		promoService,
 		authService,
+		preferenceService,
 		wshub,
 		logger,
 	)
 
+	// identity проверяет токены других сервисов во внутреннем API
+	identity, err := svcauth.New(cfg.Mode.String(), cfg.ServiceAuth.ActiveKey, cfg.ServiceAuth.PrivateKeys, cfg.ServiceAuth.PublicKeys, cfg.ServiceAuth.TokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup service auth: %w", err)
+	}
+
 	mux := http.NewServeMux()
 	m := middleware.NewMiddleware(authService, logger)
 
-	setupRoutes(mux, handlers, m, cfg.Mode, logger)
+	setupRoutes(mux, handlers, m, cfg, identity, logger)
 
 	api := &API{
 		server: &http.Server{
 			Addr:    serverAddress(cfg),
 			Handler: withMiddleware(mux, m, cfg.Mode),
 		},
-		log: logger,
+		m:      m,
+		health: handlers.health,
+		log:    logger,
 	}
 
 	return api, nil
 }
 
+// UseIncidentMode включает переключение в режим только для чтения при недоступной основной базе.
+// Middleware ставится первым, чтобы реплика попала в контекст и для проверки токена.
+func (a *API) UseIncidentMode(sw *incident.Switch) {
+	a.server.Handler = a.m.ReadOnly(sw)(a.server.Handler)
+	a.health.SetIncident(sw)
+}
+
+// RegisterHealthCheck добавляет зависимость в /health/live и /health/ready
+func (a *API) RegisterHealthCheck(name string, critical bool, fn health.CheckFunc) {
+	a.health.RegisterCheck(name, critical, fn)
+}
+
 func (a *API) Stop(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -102,6 +140,7 @@ func withMiddleware(mux *http.ServeMux, m *middleware.Middleware, mode types.Ser
 	var handler http.Handler = mux
 	handler = m.Auth(handler)
 	handler = m.Metrics(serviceName)(handler)
+	handler = m.Trace(mux)(handler)
 	handler = m.RequestID(handler)
 	handler = m.Recover(handler)
 
@@ -119,6 +158,8 @@ func serverAddress(cfg config.Config) string {
 		return fmt.Sprintf(serverIPAddress, "0.0.0.0", cfg.Services.AdminService)
 	case types.AuthService:
 		return fmt.Sprintf(serverIPAddress, "0.0.0.0", cfg.Services.AuthService)
+	case types.LocationService:
+		return fmt.Sprintf(serverIPAddress, "0.0.0.0", cfg.Services.LocationService)
 	}
 
 	return ""
@@ -127,17 +168,38 @@ func serverAddress(cfg config.Config) string {
 func newHandlers(
 	cfg config.Config,
 	driverService *handler.DriverServiceOptions,
+	locationService handler.LocationService,
 	rideService handler.RideService,
 	adminService handler.AdminService,
+	promoService handler.PromoService,
 	authService handler.AuthService,
+	preferenceService handler.PreferenceService,
 	wshub handler.ConnectionHub,
 	logger logger.Logger,
 ) *handlers {
+	var (
+		partnerService handler.PartnerService
+		payoutService  handler.PayoutService
+	)
+	if driverService != nil {
+		partnerService = driverService.Partner
+		payoutService = driverService.Payouts
+	}
+
 	return &handlers{
 		ride:   handler.NewRide(rideService, authService, wshub, logger),
 		driver: handler.NewDriver(driverService, logger),
 		admin:  handler.NewAdmin(adminService, logger),
 		auth:   handler.NewAuth(authService, logger),
-		health: handler.NewHealth(cfg.Mode.String(), logger),
+		promo:  handler.NewPromo(promoService, logger),
+		health: handler.NewHealth(cfg.Mode.String(), health.New(cfg.Mode.String(), health.Options{
+			Timeout:       cfg.Health.CheckTimeout,
+			LivenessGrace: cfg.Health.LivenessGrace,
+		}), logger),
+
+		preferences: handler.NewPreferences(preferenceService, logger),
+		partner:     handler.NewPartner(partnerService, logger),
+		payout:      handler.NewPayout(payoutService, logger),
+		location:    handler.NewLocation(locationService, logger),
 	}
 }
@@ -0,0 +1,43 @@
+package wshandler
+
+import (
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
+)
+
+// locationUpdate — местоположение водителя для пассажира. Клиенты с delta_updates получают
+// только изменившиеся поля, type и ride_id приходят всегда.
+type locationUpdate struct {
+	models.PassengerLocationUpdateDTO
+}
+
+func (u locationUpdate) DeltaKey() string {
+	return "location:" + u.RideID.String()
+}
+
+func (u locationUpdate) DeltaFixed() []string {
+	return []string{"type", "ride_id"}
+}
+
+// passengerMessage готовит сообщение пассажиру к отправке по возможностям каждого устройства
+func passengerMessage(data any) any {
+	if u, ok := data.(models.PassengerLocationUpdateDTO); ok {
+		return locationUpdate{u}
+	}
+	return data
+}
+
+// positioningTip — рекомендация водителю с текстом на языке клиента, без перевода — английский текст сервиса
+type positioningTip struct {
+	models.PositioningTip
+}
+
+func (t positioningTip) ForClient(caps ws.Capabilities) any {
+	tip := t.PositioningTip
+	if caps.Locale == "ru" {
+		tip.Message = fmt.Sprintf("Езжайте в %s, ожидание около %d мин", tip.Area, tip.ExpectedWaitMinutes)
+	}
+	return tip
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/ws/dto"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/Temutjin2k/ride-hail-system/pkg/validator"
 	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
@@ -37,12 +38,14 @@ func (h *DriverHub) GetRideOffer(ctx context.Context, driverID uuid.UUID, offer
 	conn.Subscribe(offer.ID.String(), ch)
 	defer conn.Unsubscribe(offer.ID.String())
 
+	start := time.Now()
 	if err := conn.Send(offer); err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	metrics.WebSocketDeliveryDuration.WithLabelValues("driver_service").Observe(time.Since(start).Seconds())
 
-	// Timeout: 30 seconds for driver responses
-	timer := time.NewTimer(30 * time.Second)
+	// водитель отвечает до истечения оффера (DISPATCH_OFFER_TIMEOUT)
+	timer := time.NewTimer(offer.ResponseWindow())
 	defer timer.Stop()
 
 	var resp dto.OfferResp
@@ -83,13 +86,110 @@ func (h *DriverHub) SendRideDetails(ctx context.Context, details models.RideDeta
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	start := time.Now()
 	if err := conn.Send(details); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	metrics.WebSocketDeliveryDuration.WithLabelValues("driver_service").Observe(time.Since(start).Seconds())
 
 	return nil
 }
 
+// SendStats отправляет водителю статистику за сегодня для плитки заработка
+func (h *DriverHub) SendStats(ctx context.Context, driverID uuid.UUID, stats models.DriverStats) error {
+	const op = "DriverHub.SendStats"
+	stats.MsgType = "driver_stats"
+
+	conn, err := h.connections.GetConn(driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := conn.Send(stats); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SendShiftSummary отправляет водителю итоги смены после выхода с линии
+func (h *DriverHub) SendShiftSummary(ctx context.Context, driverID uuid.UUID, summary models.SessionSummary) error {
+	const op = "DriverHub.SendShiftSummary"
+	summary.MsgType = "shift_summary"
+
+	conn, err := h.connections.GetConn(driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := conn.Send(summary); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SendRideReleased сообщает водителю об отмене или переназначении его поездки
+func (h *DriverHub) SendRideReleased(ctx context.Context, driverID uuid.UUID, msg models.RideReleased) error {
+	const op = "DriverHub.SendRideReleased"
+	msg.MsgType = "ride_released"
+
+	conn, err := h.connections.GetConn(driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := conn.Send(msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeRideOffer сообщает водителю, что оффер больше не действует
+func (h *DriverHub) RevokeRideOffer(ctx context.Context, driverID uuid.UUID, msg models.RideOfferRevoked) error {
+	const op = "DriverHub.RevokeRideOffer"
+	msg.MsgType = "ride_offer_revoked"
+
+	conn, err := h.connections.GetConn(driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := conn.Send(msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SendPositioningTip отправляет водителю рекомендацию переместиться в район с высоким спросом
+func (h *DriverHub) SendPositioningTip(ctx context.Context, driverID uuid.UUID, tip models.PositioningTip) error {
+	const op = "DriverHub.SendPositioningTip"
+	tip.MsgType = "positioning_tip"
+
+	conn, err := h.connections.GetConn(driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := conn.Send(positioningTip{tip}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConnectedDrivers возвращает водителей с открытым WebSocket
+func (h *DriverHub) ConnectedDrivers() []uuid.UUID {
+	clients := h.connections.Clients()
+	ids := make([]uuid.UUID, 0, len(clients))
+	for id := range clients {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (h *DriverHub) ListenLocationUpdates(ctx context.Context, driverID, rideID uuid.UUID, handler func(ctx context.Context, location models.RideLocationUpdate) error) error {
 	const op = "DriverHub.ListenLocationUpdates"
 
@@ -139,6 +239,7 @@ func (h *DriverHub) ListenLocationUpdates(ctx context.Context, driverID, rideID
 				DriverID:  driverID,
 				RideID:    &rideID,
 				TimeStamp: now,
+				Signature: req.LocationSignature.ToModel(),
 				Coordinates: models.Coordinates{
 					AccuracyMeters: req.AccuracyMeters,
 					SpeedKmh:       req.SpeedKmH,
@@ -25,9 +25,11 @@ func (r *OfferResp) Validate(v *validator.Validator) {
 type DriverLocationUpdate struct {
 	MsgType string `json:"type"` // by default must be: `location_update`
 	dto.UpdateLocationReq
+	dto.LocationSignature
 }
 
 func (r *DriverLocationUpdate) Validate(v *validator.Validator) {
 	v.Check(r.MsgType != "", "type", "must be: location_update type")
 	r.UpdateLocationReq.Validate(v)
+	r.LocationSignature.Validate(v)
 }
@@ -2,35 +2,149 @@ package wshandler
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
 )
 
+const (
+	// breakerThreshold — сколько ошибок отправки подряд размыкают отправки пассажиру
+	breakerThreshold = 3
+	// breakerCooldown — через сколько разомкнутый breaker пробует одну отправку
+	breakerCooldown = 30 * time.Second
+)
+
+// ErrPassengerUnreachable — отправки пассажиру приостановлены, сообщение отложено до переподключения
+var ErrPassengerUnreachable = errors.New("passenger websocket is unreachable, message buffered")
+
 type RideWsHandler struct {
 	connections *ws.ConnectionHub
+
+	mu       sync.Mutex
+	breakers map[deviceKey]*breaker
+}
+
+// deviceKey — одно устройство пассажира
+type deviceKey struct {
+	passengerID uuid.UUID
+	deviceID    string
+}
+
+// breaker считает ошибки отправки подряд на одном соединении пассажира
+type breaker struct {
+	conn     *ws.Conn
+	failures int
+	openedAt time.Time // нулевое — breaker замкнут
 }
 
 func NewRideWsHandler(connections *ws.ConnectionHub) *RideWsHandler {
 	return &RideWsHandler{
 		connections: connections,
+		breakers:    make(map[deviceKey]*breaker),
 	}
 }
 
-// SendToPassenger
+// SendToPassenger отправляет сообщение во все устройства пассажира.
+// После breakerThreshold ошибок подряд сообщения не отправляются в соединение устройства, а откладываются
+// в его буфер в хабе и доставляются при переподключении. Новое соединение сбрасывает breaker.
+// Ошибка возвращается, только если сообщение не доставлено ни в одно устройство.
+// Вид сообщения подбирается под возможности каждого устройства при записи в сокет.
 func (h *RideWsHandler) SendToPassenger(ctx context.Context, passengerID uuid.UUID, data any) error {
-	conn, err := h.connections.GetConn(passengerID)
-	if err != nil {
-		return err
+	data = passengerMessage(data)
+
+	conns := h.connections.Conns(passengerID)
+	if len(conns) == 0 {
+		h.reset(passengerID)
+		return ws.ErrConnIsNotFound
 	}
 
-	if err := conn.Send(data); err != nil {
-		return err
+	lastErr := ErrPassengerUnreachable
+	delivered := 0
+	for _, conn := range conns {
+		key := deviceKey{passengerID: passengerID, deviceID: conn.DeviceID()}
+
+		if !h.allow(key, conn) {
+			h.connections.BufferDevice(passengerID, key.deviceID, data)
+			continue
+		}
+
+		start := time.Now()
+		if err := conn.Send(data); err != nil {
+			h.failure(key, conn)
+			h.connections.BufferDevice(passengerID, key.deviceID, data)
+			lastErr = err
+			continue
+		}
+		metrics.WebSocketDeliveryDuration.WithLabelValues("ride_service").Observe(time.Since(start).Seconds())
+
+		h.mu.Lock()
+		delete(h.breakers, key)
+		h.mu.Unlock()
+		delivered++
 	}
 
+	if delivered == 0 {
+		return lastErr
+	}
 	return nil
 }
 
+// allow решает, отправлять ли в соединение. Разомкнутый breaker пропускает одну пробную отправку раз в breakerCooldown.
+func (h *RideWsHandler) allow(key deviceKey, conn *ws.Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.breakers[key]
+	if !ok {
+		return true
+	}
+	if b.conn != conn {
+		// устройство переподключилось
+		delete(h.breakers, key)
+		return true
+	}
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if time.Since(b.openedAt) >= breakerCooldown {
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+func (h *RideWsHandler) failure(key deviceKey, conn *ws.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, ok := h.breakers[key]
+	if !ok || b.conn != conn {
+		b = &breaker{conn: conn}
+		h.breakers[key] = b
+	}
+
+	b.failures++
+	if b.failures == breakerThreshold {
+		b.openedAt = time.Now()
+		metrics.WebSocketBreakerTripsTotal.WithLabelValues("ride_service").Inc()
+	}
+}
+
+// reset сбрасывает breakers всех устройств пассажира
+func (h *RideWsHandler) reset(passengerID uuid.UUID) {
+	h.mu.Lock()
+	for key := range h.breakers {
+		if key.passengerID == passengerID {
+			delete(h.breakers, key)
+		}
+	}
+	h.mu.Unlock()
+}
+
 // // TODO: imporove написал на скорую руку
 // // SendDriverLocation
 // func (h *RideWsHandler) SendDriverLocation(ctx context.Context, passengerID uuid.UUID, location *models.DriverLocationUpdate) error {
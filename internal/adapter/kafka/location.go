@@ -0,0 +1,294 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const headerCorrelationID = "correlation_id"
+
+// Заголовки подписи сообщения сервисом-отправителем, как в RabbitMQ
+const (
+	headerService   = "x-service"
+	headerKeyID     = "x-service-key"
+	headerSignature = "x-service-signature"
+)
+
+// ErrUnsignedMessage — сообщение без подписи при включенном requireSigned
+var ErrUnsignedMessage = errors.New("kafka: unsigned message")
+
+// writeBatchTimeout — сколько writer ждет других сообщений в пачку. Отправка синхронная,
+// поэтому при умолчании kafka-go (1s) каждое обновление координат ждало бы до секунды.
+const writeBatchTimeout = 5 * time.Millisecond
+
+// LocationUpdateHandler совпадает с RabbitMQ адаптером, чтобы брокеры были взаимозаменяемы
+type LocationUpdateHandler = rabbit.LocationUpdateHandler
+
+// LocationBroker публикует и читает координаты водителей через Kafka.
+// Ключ сообщения — ID водителя: координаты одного водителя попадают в одну партицию
+// и читаются по порядку, а экземпляры ride-service делят партиции внутри consumer group.
+type LocationBroker struct {
+	brokers []string
+	topic   string
+	groupID string
+
+	writer *kafka.Writer
+
+	// identity подписывает и проверяет сообщения, nil — подписи выключены
+	identity      *svcauth.Identity
+	requireSigned bool
+
+	l logger.Logger
+}
+
+func NewLocationBroker(brokers []string, topic, groupID string, l logger.Logger) *LocationBroker {
+	return &LocationBroker{
+		brokers: brokers,
+		topic:   topic,
+		groupID: groupID,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireOne,
+			BatchTimeout:           writeBatchTimeout,
+			AllowAutoTopicCreation: true,
+		},
+		l: l,
+	}
+}
+
+// SetIdentity включает подпись исходящих и проверку входящих сообщений.
+// requireSigned — отклонять сообщения без подписи, иначе они принимаются без имени отправителя.
+func (b *LocationBroker) SetIdentity(id *svcauth.Identity, requireSigned bool) {
+	b.identity = id
+	b.requireSigned = requireSigned
+}
+
+func (b *LocationBroker) PublishLocationUpdate(ctx context.Context, msg models.RideLocationUpdate) error {
+	ctx = wrap.WithAction(ctx, "kafka_publish_location_update")
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("marshal: %w", err))
+	}
+
+	headers := []kafka.Header{
+		{Key: headerCorrelationID, Value: []byte(wrap.GetRequestID(ctx))},
+	}
+	if b.identity.Enabled() {
+		kid, signature := b.identity.SignMessage(body)
+		headers = append(headers,
+			kafka.Header{Key: headerService, Value: []byte(b.identity.Service())},
+			kafka.Header{Key: headerKeyID, Value: []byte(kid)},
+			kafka.Header{Key: headerSignature, Value: []byte(signature)},
+		)
+	}
+
+	if err := b.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(msg.DriverID.String()),
+		Value:   body,
+		Time:    time.Now(),
+		Headers: headers,
+	}); err != nil {
+		return wrap.Error(ctx, fmt.Errorf("publish: %w", err))
+	}
+
+	return nil
+}
+
+// ConsumeDriverLocationUpdate читает координаты в составе consumer group.
+// Сообщения партиции обрабатываются последовательно, offset фиксируется после обработки.
+func (b *LocationBroker) ConsumeDriverLocationUpdate(ctx context.Context, handler LocationUpdateHandler) error {
+	ctx = wrap.WithAction(ctx, "kafka_consume_driver_location")
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  b.brokers,
+		Topic:    b.topic,
+		GroupID:  b.groupID,
+		MinBytes: 1,
+		MaxBytes: 10e6,
+	})
+	defer reader.Close()
+
+	b.l.Info(ctx, "start consuming location update", "topic", b.topic, "group_id", b.groupID)
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				b.l.Info(ctx, "driver location consumer shutting down")
+				return nil
+			}
+			b.l.Error(ctx, "fetch failed", err)
+			if !wait(ctx, 2*time.Second) {
+				b.l.Info(ctx, "driver location consumer shutting down")
+				return nil
+			}
+			continue
+		}
+
+		b.handle(ctx, handler, msg)
+
+		if err := reader.CommitMessages(context.WithoutCancel(ctx), msg); err != nil {
+			b.l.Error(ctx, "failed to commit offset", err, "partition", msg.Partition, "offset", msg.Offset)
+		}
+	}
+}
+
+// handle обрабатывает сообщение. Kafka не умеет nack, поэтому восстановимые ошибки
+// повторяются несколько раз, а остальные сообщения пропускаются.
+func (b *LocationBroker) handle(ctx context.Context, handler LocationUpdateHandler, msg kafka.Message) {
+	ctx, req, ok := b.decode(ctx, msg)
+	// координаты вне поездки ride-service не нужны
+	if !ok || req.RideID == nil {
+		return
+	}
+
+	ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), correlationID(msg))
+
+	for attempt := 1; ; attempt++ {
+		err := handler(ctxx, req)
+		if err == nil {
+			return
+		}
+
+		// при остановке повтор прерывается, не дожидаясь паузы
+		recoverable := errors.Is(err, types.ErrDatabaseFailed) || errors.Is(err, types.ErrFailedToPublishRideStatus)
+		if !recoverable || attempt == 5 || !wait(ctx, time.Second) {
+			b.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver location update", err, "attempts", attempt)
+			return
+		}
+	}
+}
+
+// wait ждет паузу перед повтором, false — ctx отменен раньше
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// decode проверяет подпись и разбирает сообщение, false — сообщение пропускается
+func (b *LocationBroker) decode(ctx context.Context, msg kafka.Message) (context.Context, models.RideLocationUpdate, bool) {
+	var req models.RideLocationUpdate
+
+	ctx, err := b.verify(ctx, msg)
+	if err != nil {
+		// dead letter у Kafka нет, сообщение с неверной подписью пропускается
+		b.l.Warn(ctx, "rejected driver location update with invalid signature", "partition", msg.Partition, "offset", msg.Offset, "reason", err.Error())
+		return ctx, req, false
+	}
+
+	if err := models.DecodeMessage(msg.Value, &req); err != nil {
+		b.l.Error(ctx, "failed to unmarshal driver location update", err)
+		return ctx, req, false
+	}
+	if err := req.Validate(); err != nil {
+		b.l.Warn(ctx, "dropping driver location update", "reason", err.Error(), "driver_id", req.DriverID)
+		return ctx, req, false
+	}
+	return ctx, req, true
+}
+
+// ConsumeLocationStream читает все координаты водителей, начиная с новых. У каждого вызова своя
+// consumer group, поэтому экземпляр получает все партиции, а не делит их с другими экземплярами.
+// Offset не фиксируется: после перезапуска чтение начинается с конца, пропущенное подхватит
+// перестроение из Postgres.
+func (b *LocationBroker) ConsumeLocationStream(ctx context.Context, handler LocationUpdateHandler) error {
+	ctx = wrap.WithAction(ctx, "kafka_consume_location_stream")
+
+	groupID := b.groupID + "-stream-" + uuid.New().String()
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     b.brokers,
+		Topic:       b.topic,
+		GroupID:     groupID,
+		StartOffset: kafka.LastOffset,
+		MinBytes:    1,
+		MaxBytes:    10e6,
+	})
+	defer reader.Close()
+
+	b.l.Info(ctx, "start consuming location stream", "topic", b.topic, "group_id", groupID)
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				b.l.Info(ctx, "location stream consumer shutting down")
+				return nil
+			}
+			b.l.Error(ctx, "fetch failed", err)
+			if !wait(ctx, 2*time.Second) {
+				b.l.Info(ctx, "location stream consumer shutting down")
+				return nil
+			}
+			continue
+		}
+
+		ctxx, req, ok := b.decode(ctx, msg)
+		if !ok {
+			continue
+		}
+		if err := handler(wrap.WithRequestID(ctxx, correlationID(msg)), req); err != nil {
+			b.l.Error(wrap.ErrorCtx(ctxx, err), "failed to handle driver location update", err)
+		}
+	}
+}
+
+// Close дожидается отправки буферизованных сообщений
+func (b *LocationBroker) Close() error {
+	return b.writer.Close()
+}
+
+// verify проверяет подпись сообщения и добавляет отправителя в контекст логов
+func (b *LocationBroker) verify(ctx context.Context, msg kafka.Message) (context.Context, error) {
+	if !b.identity.Enabled() {
+		return ctx, nil
+	}
+
+	service, kid, signature := header(msg, headerService), header(msg, headerKeyID), header(msg, headerSignature)
+	if signature == "" {
+		if b.requireSigned {
+			return ctx, ErrUnsignedMessage
+		}
+		return ctx, nil
+	}
+
+	if err := b.identity.VerifyMessage(service, kid, msg.Value, signature); err != nil {
+		return ctx, err
+	}
+	return wrap.WithCallerService(ctx, service), nil
+}
+
+func correlationID(msg kafka.Message) string {
+	return header(msg, headerCorrelationID)
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
@@ -13,14 +15,38 @@ import (
 
 var ErrLocationNotFound = fmt.Errorf("location not found")
 
+// requestTimeout ограничивает каждый запрос к LocationIQ, даже если контекст вызывающего без дедлайна
+const requestTimeout = 3 * time.Second
+
 type LocationIQClient struct {
-	apiKey string
+	apiKey atomic.Pointer[string]
+	http   *http.Client
 }
 
 func New(apiKey string) *LocationIQClient {
-	return &LocationIQClient{
-		apiKey: apiKey,
+	c := &LocationIQClient{
+		http: &http.Client{Timeout: requestTimeout},
+	}
+	c.SetAPIKey(apiKey)
+	return c
+}
+
+// get выполняет GET запрос с контекстом вызывающего
+func (c *LocationIQClient) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
 	}
+	return c.http.Do(req)
+}
+
+// SetAPIKey меняет ключ API без перезапуска, запросы в процессе дойдут со старым ключом
+func (c *LocationIQClient) SetAPIKey(apiKey string) {
+	c.apiKey.Store(&apiKey)
+}
+
+func (c *LocationIQClient) key() string {
+	return *c.apiKey.Load()
 }
 
 var domain = "https://us1.locationiq.com"
@@ -32,9 +58,9 @@ type AddressPayload struct {
 func (c *LocationIQClient) GetAddress(ctx context.Context, longitude, latitude float64) (string, error) {
 	const op = "LocationIQClient.GetAddress"
 
-	url := fmt.Sprintf("%s/v1/reverse?key=%s&lat=%f&lon=%f&format=json", domain, c.apiKey, latitude, longitude)
+	url := fmt.Sprintf("%s/v1/reverse?key=%s&lat=%f&lon=%f&format=json", domain, c.key(), latitude, longitude)
 
-	resp, err := http.Get(url)
+	resp, err := c.get(ctx, url)
 	if err != nil {
 		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
 		return "", wrap.Error(ctx, fmt.Errorf("%s: failed to make request to LocationIQ: %w", op, err))
@@ -59,9 +85,9 @@ func (c *LocationIQClient) GetAddress(ctx context.Context, longitude, latitude f
 func (c *LocationIQClient) GetLocation(ctx context.Context, address string) (float64, float64, error) {
 	ctx = wrap.WithAction(ctx, "locationiq_get_location")
 
-	url := fmt.Sprintf("%s/v1/search?key=%s&q=%s&format=json", domain, c.apiKey, address)
+	url := fmt.Sprintf("%s/v1/search?key=%s&q=%s&format=json", domain, c.key(), address)
 
-	resp, err := http.Get(url)
+	resp, err := c.get(ctx, url)
 	if err != nil {
 		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
 		return 0, 0, wrap.Error(ctx, fmt.Errorf("failed to make request to LocationIQ: %w", err))
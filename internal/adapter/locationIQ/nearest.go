@@ -0,0 +1,50 @@
+package locationIQ
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+type nearestPayload struct {
+	Code      string `json:"code"`
+	Waypoints []struct {
+		Location []float64 `json:"location"` // [longitude, latitude]
+	} `json:"waypoints"`
+}
+
+// NearestRoad returns (longitude, latitude) of the nearest point on a drivable road
+// using the LocationIQ Nearest routing API.
+func (c *LocationIQClient) NearestRoad(ctx context.Context, longitude, latitude float64) (float64, float64, error) {
+	const op = "LocationIQClient.NearestRoad"
+
+	url := fmt.Sprintf("%s/v1/nearest/driving/%f,%f?key=%s&number=1", domain, longitude, latitude, c.key())
+
+	resp, err := c.get(ctx, url)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return 0, 0, wrap.Error(ctx, fmt.Errorf("%s: failed to make request to LocationIQ: %w", op, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return 0, 0, wrap.Error(ctx, fmt.Errorf("%s: unexpected response status %d", op, resp.StatusCode))
+	}
+
+	var payload nearestPayload
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		ctx = wrap.WithAction(ctx, "decode_nearest_payload")
+		return 0, 0, wrap.Error(ctx, fmt.Errorf("%s: failed to decode data from LocationIQ response: %w", op, err))
+	}
+
+	if payload.Code != "Ok" || len(payload.Waypoints) == 0 || len(payload.Waypoints[0].Location) != 2 {
+		return 0, 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, ErrLocationNotFound))
+	}
+
+	return payload.Waypoints[0].Location[0], payload.Waypoints[0].Location[1], nil
+}
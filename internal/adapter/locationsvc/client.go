@@ -0,0 +1,115 @@
+// Package locationsvc — клиент внутреннего API location-service
+package locationsvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler/dto"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/middleware"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/tracing"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// knownErrors — ошибки location-service, которые возвращаются вызывающему как доменные,
+// чтобы driver-service отвечал водителю тем же статусом
+var knownErrors = []error{
+	types.ErrUserNotFound,
+	types.ErrLocationSignatureRequired,
+	types.ErrInvalidLocationSignature,
+	types.ErrLocationSignatureExpired,
+	types.ErrInvalidCoordinates,
+	types.ErrNullIslandCoordinates,
+	types.ErrOutsideServiceArea,
+	types.ErrLocationDiscarded,
+}
+
+// Client пересылает координаты водителей в location-service
+type Client struct {
+	baseURL  string
+	identity *svcauth.Identity // подписывает токен сервиса, без ключей используется token
+	token    string            // Deprecated: общий секрет LOCATION_INTERNAL_TOKEN
+	http     *http.Client
+}
+
+func New(baseURL string, identity *svcauth.Identity, token string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		identity: identity,
+		token:    token,
+		http:     &http.Client{Timeout: timeout, Transport: tracing.Transport(nil)},
+	}
+}
+
+// Ingest отправляет координату во внутренний API, подпись устройства проверяет location-service
+func (c *Client) Ingest(ctx context.Context, data models.RideLocationUpdate) (uuid.UUID, error) {
+	const op = "LocationClient.Ingest"
+
+	body, err := json.Marshal(dto.NewIngestLocationReq(data))
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%s: marshal request: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/locations", bytes.NewReader(body))
+	if err != nil {
+		return uuid.UUID{}, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.authorize(req); err != nil {
+		return uuid.UUID{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		CoordinateID uuid.UUID `json:"coordinate_id"`
+		Error        any       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: decode response (status %d): %w", op, resp.StatusCode, err))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if msg, ok := out.Error.(string); ok {
+			for _, known := range knownErrors {
+				if msg == known.Error() {
+					return uuid.UUID{}, known
+				}
+			}
+		}
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: unexpected response status %d: %v", op, resp.StatusCode, out.Error))
+	}
+
+	return out.CoordinateID, nil
+}
+
+// authorize ставит токен сервиса на этот запрос, а без ключей SERVICE_AUTH_PRIVATE_KEYS — общий секрет
+func (c *Client) authorize(req *http.Request) error {
+	if !c.identity.Enabled() {
+		req.Header.Set(middleware.InternalTokenHeader, c.token)
+		return nil
+	}
+
+	token, err := c.identity.Token(types.LocationService.String(), req.Method, req.URL.Path)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(middleware.ServiceTokenHeader, token)
+	return nil
+}
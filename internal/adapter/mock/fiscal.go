@@ -0,0 +1,44 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+)
+
+// FiscalProvider - заглушка провайдера фискализации чеков.
+// Повторная фискализация того же счета возвращает прежний ID, ID последовательные (mock-fiscal-000001, ...).
+type FiscalProvider struct {
+	latency latency
+
+	mu       sync.Mutex
+	seq      int
+	receipts map[string]string // номер счета -> ID фискального чека
+}
+
+func NewFiscalProvider(delay time.Duration) *FiscalProvider {
+	return &FiscalProvider{
+		latency:  latency(delay),
+		receipts: make(map[string]string),
+	}
+}
+
+func (p *FiscalProvider) Fiscalize(ctx context.Context, receipt models.Receipt) (string, error) {
+	if err := p.latency.wait(ctx); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.receipts[receipt.InvoiceNumber]; ok {
+		return id, nil
+	}
+	p.seq++
+	id := fmt.Sprintf("mock-fiscal-%06d", p.seq)
+	p.receipts[receipt.InvoiceNumber] = id
+	return id, nil
+}
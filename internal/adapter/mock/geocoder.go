@@ -0,0 +1,70 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// Almaty bounding box - все "найденные" адреса попадают в город
+const (
+	minLatitude  = 43.15
+	maxLatitude  = 43.35
+	minLongitude = 76.75
+	maxLongitude = 77.05
+
+	// шаг "дорожной сетки" в градусах (~100м)
+	roadGridStep = 0.001
+)
+
+// Geocoder - заглушка LocationIQ клиента.
+// Один и тот же вход всегда дает один и тот же результат.
+type Geocoder struct {
+	latency latency
+}
+
+func NewGeocoder(delay time.Duration) *Geocoder {
+	return &Geocoder{latency: latency(delay)}
+}
+
+func (g *Geocoder) GetAddress(ctx context.Context, longitude, latitude float64) (string, error) {
+	if err := g.latency.wait(ctx); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Mock street %.5f, %.5f, Almaty", latitude, longitude), nil
+}
+
+// GetLocation возвращает (longitude, latitude) как и LocationIQ клиент
+func (g *Geocoder) GetLocation(ctx context.Context, address string) (float64, float64, error) {
+	if err := g.latency.wait(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(address))
+	sum := h.Sum64()
+
+	latFrac := float64(sum&0xffffffff) / float64(0xffffffff)
+	lonFrac := float64(sum>>32) / float64(0xffffffff)
+
+	lat := minLatitude + latFrac*(maxLatitude-minLatitude)
+	lon := minLongitude + lonFrac*(maxLongitude-minLongitude)
+
+	return lon, lat, nil
+}
+
+// NearestRoad притягивает точку к узлу сетки roadGridStep, имитируя ближайшую дорогу.
+// Возвращает (longitude, latitude) как и LocationIQ клиент
+func (g *Geocoder) NearestRoad(ctx context.Context, longitude, latitude float64) (float64, float64, error) {
+	if err := g.latency.wait(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	snap := func(v float64) float64 {
+		return math.Round(v/roadGridStep) * roadGridStep
+	}
+
+	return snap(longitude), snap(latitude), nil
+}
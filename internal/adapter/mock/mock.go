@@ -0,0 +1,55 @@
+// Package mock содержит детерминированные in-process заглушки внешних сервисов
+// (геокодер, платежный провайдер, фискализация, push, SMS и email) для локальной разработки и e2e тестов.
+// Включается через конфиг: mock.enabled=true, задержка - mock.latency.
+package mock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// latency эмулирует сетевую задержку внешнего сервиса
+type latency time.Duration
+
+func (l latency) wait(ctx context.Context) error {
+	if l <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(l))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// recorder хранит отправленные сообщения, чтобы тесты могли их проверить
+type recorder[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+func (r *recorder[T]) add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, item)
+}
+
+func (r *recorder[T]) list() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]T, len(r.items))
+	copy(out, r.items)
+	return out
+}
+
+func (r *recorder[T]) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = nil
+}
@@ -0,0 +1,129 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// PushMessage - отправленное push уведомление
+type PushMessage struct {
+	UserID   uuid.UUID
+	DeviceID string
+	Title    string
+	Body     string
+	Data     map[string]string
+	SentAt   time.Time
+}
+
+// PushSender - заглушка push провайдера, сохраняет все сообщения в памяти
+type PushSender struct {
+	latency latency
+	sent    recorder[PushMessage]
+}
+
+func NewPushSender(delay time.Duration) *PushSender {
+	return &PushSender{latency: latency(delay)}
+}
+
+func (s *PushSender) SendPush(ctx context.Context, token models.PushToken, title, body string, data map[string]string) error {
+	if err := s.latency.wait(ctx); err != nil {
+		return err
+	}
+	s.sent.add(PushMessage{UserID: token.UserID, DeviceID: token.DeviceID, Title: title, Body: body, Data: data, SentAt: time.Now()})
+	return nil
+}
+
+func (s *PushSender) Sent() []PushMessage { return s.sent.list() }
+
+func (s *PushSender) Reset() { s.sent.reset() }
+
+// SMSMessage - отправленное SMS
+type SMSMessage struct {
+	Phone  string
+	Text   string
+	SentAt time.Time
+}
+
+// SMSSender - заглушка SMS провайдера, сохраняет все сообщения в памяти
+type SMSSender struct {
+	latency latency
+	sent    recorder[SMSMessage]
+}
+
+func NewSMSSender(delay time.Duration) *SMSSender {
+	return &SMSSender{latency: latency(delay)}
+}
+
+func (s *SMSSender) SendSMS(ctx context.Context, phone, text string) error {
+	if err := s.latency.wait(ctx); err != nil {
+		return err
+	}
+	s.sent.add(SMSMessage{Phone: phone, Text: text, SentAt: time.Now()})
+	return nil
+}
+
+func (s *SMSSender) Sent() []SMSMessage { return s.sent.list() }
+
+func (s *SMSSender) Reset() { s.sent.reset() }
+
+// EmailMessage - отправленное письмо
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+	SentAt  time.Time
+}
+
+// EmailSender - заглушка почтового провайдера, сохраняет все письма в памяти
+type EmailSender struct {
+	latency latency
+	sent    recorder[EmailMessage]
+}
+
+func NewEmailSender(delay time.Duration) *EmailSender {
+	return &EmailSender{latency: latency(delay)}
+}
+
+func (s *EmailSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	if err := s.latency.wait(ctx); err != nil {
+		return err
+	}
+	s.sent.add(EmailMessage{To: to, Subject: subject, Body: body, SentAt: time.Now()})
+	return nil
+}
+
+func (s *EmailSender) Sent() []EmailMessage { return s.sent.list() }
+
+func (s *EmailSender) Reset() { s.sent.reset() }
+
+// VoiceCall - совершенный голосовой звонок
+type VoiceCall struct {
+	Phone    string
+	Text     string
+	CalledAt time.Time
+}
+
+// VoiceCaller - заглушка провайдера телефонии, сохраняет все звонки в памяти
+type VoiceCaller struct {
+	latency latency
+	calls   recorder[VoiceCall]
+}
+
+func NewVoiceCaller(delay time.Duration) *VoiceCaller {
+	return &VoiceCaller{latency: latency(delay)}
+}
+
+func (c *VoiceCaller) Call(ctx context.Context, phone, text string) error {
+	if err := c.latency.wait(ctx); err != nil {
+		return err
+	}
+	c.calls.add(VoiceCall{Phone: phone, Text: text, CalledAt: time.Now()})
+	return nil
+}
+
+func (c *VoiceCaller) Calls() []VoiceCall { return c.calls.list() }
+
+func (c *VoiceCaller) Reset() { c.calls.reset() }
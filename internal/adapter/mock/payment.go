@@ -0,0 +1,173 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+var (
+	ErrInvalidAmount       = errors.New("mock payment: amount must be positive")
+	ErrTransactionNotFound = errors.New("mock payment: transaction not found")
+	ErrRefundExceedsCharge = errors.New("mock payment: refund exceeds charged amount")
+	ErrAuthNotFound        = errors.New("mock payment: authorization not found or already settled")
+	ErrCaptureExceedsAuth  = errors.New("mock payment: capture exceeds authorized amount")
+)
+
+// Charge - проведенный платеж
+type Charge struct {
+	TransactionID string
+	UserID        uuid.UUID
+	Amount        float64
+	Refunded      float64
+	CreatedAt     time.Time
+}
+
+// PaymentProvider - заглушка платежного провайдера.
+// ID транзакций последовательные (mock-tx-000001, ...), поэтому прогоны воспроизводимы.
+type PaymentProvider struct {
+	latency latency
+
+	mu      sync.Mutex
+	seq     int
+	charges map[string]*Charge
+
+	authSeq int
+	auths   map[string]float64 // открытые предавторизации: ID -> сумма
+}
+
+func NewPaymentProvider(delay time.Duration) *PaymentProvider {
+	return &PaymentProvider{
+		latency: latency(delay),
+		charges: make(map[string]*Charge),
+		auths:   make(map[string]float64),
+	}
+}
+
+func (p *PaymentProvider) Charge(ctx context.Context, userID uuid.UUID, amount float64) (string, error) {
+	if err := p.latency.wait(ctx); err != nil {
+		return "", err
+	}
+	if amount <= 0 {
+		return "", ErrInvalidAmount
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.addCharge(userID, amount), nil
+}
+
+// addCharge записывает платеж, вызывается под p.mu
+func (p *PaymentProvider) addCharge(userID uuid.UUID, amount float64) string {
+	p.seq++
+	txID := fmt.Sprintf("mock-tx-%06d", p.seq)
+	p.charges[txID] = &Charge{
+		TransactionID: txID,
+		UserID:        userID,
+		Amount:        amount,
+		CreatedAt:     time.Now(),
+	}
+	return txID
+}
+
+// Authorize блокирует amount на карте без списания
+func (p *PaymentProvider) Authorize(ctx context.Context, userID uuid.UUID, amount float64) (string, error) {
+	if err := p.latency.wait(ctx); err != nil {
+		return "", err
+	}
+	if amount <= 0 {
+		return "", ErrInvalidAmount
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.authSeq++
+	authID := fmt.Sprintf("mock-auth-%06d", p.authSeq)
+	p.auths[authID] = amount
+
+	return authID, nil
+}
+
+// CaptureAuthorization списывает amount в пределах предавторизации, остаток блокировки снимается
+func (p *PaymentProvider) CaptureAuthorization(ctx context.Context, authorizationID string, userID uuid.UUID, amount float64) (string, error) {
+	if err := p.latency.wait(ctx); err != nil {
+		return "", err
+	}
+	if amount <= 0 {
+		return "", ErrInvalidAmount
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	authorized, ok := p.auths[authorizationID]
+	if !ok {
+		return "", ErrAuthNotFound
+	}
+	if amount > authorized {
+		return "", ErrCaptureExceedsAuth
+	}
+	delete(p.auths, authorizationID)
+
+	return p.addCharge(userID, amount), nil
+}
+
+// VoidAuthorization снимает предавторизацию без списания
+func (p *PaymentProvider) VoidAuthorization(ctx context.Context, authorizationID string) error {
+	if err := p.latency.wait(ctx); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.auths[authorizationID]; !ok {
+		return ErrAuthNotFound
+	}
+	delete(p.auths, authorizationID)
+
+	return nil
+}
+
+func (p *PaymentProvider) Refund(ctx context.Context, transactionID string, amount float64) error {
+	if err := p.latency.wait(ctx); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, ok := p.charges[transactionID]
+	if !ok {
+		return ErrTransactionNotFound
+	}
+	if charge.Refunded+amount > charge.Amount {
+		return ErrRefundExceedsCharge
+	}
+	charge.Refunded += amount
+
+	return nil
+}
+
+// Charges возвращает копию всех проведенных платежей
+func (p *PaymentProvider) Charges() []Charge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]Charge, 0, len(p.charges))
+	for i := 1; i <= p.seq; i++ {
+		if c, ok := p.charges[fmt.Sprintf("mock-tx-%06d", i)]; ok {
+			out = append(out, *c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+var ErrPayoutNotFound = errors.New("mock payout: payout not found")
+
+// PayoutProvider - заглушка провайдера выплат водителям.
+// Перевод принимается в PROCESSING и зачисляется при первом запросе статуса, вебхуки не отправляются.
+// Повторный перевод с тем же reference возвращает прежний, ID последовательные (mock-payout-000001, ...).
+type PayoutProvider struct {
+	latency latency
+
+	mu      sync.Mutex
+	seq     int
+	refs    map[uuid.UUID]string // reference -> ID перевода
+	payouts map[string]*models.ProviderPayout
+}
+
+func NewPayoutProvider(delay time.Duration) *PayoutProvider {
+	return &PayoutProvider{
+		latency: latency(delay),
+		refs:    make(map[uuid.UUID]string),
+		payouts: make(map[string]*models.ProviderPayout),
+	}
+}
+
+func (p *PayoutProvider) Payout(ctx context.Context, reference, driverID uuid.UUID, amount float64) (*models.ProviderPayout, error) {
+	if err := p.latency.wait(ctx); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.refs[reference]; ok {
+		res := *p.payouts[id]
+		return &res, nil
+	}
+
+	p.seq++
+	id := fmt.Sprintf("mock-payout-%06d", p.seq)
+	p.refs[reference] = id
+	p.payouts[id] = &models.ProviderPayout{
+		ID:        id,
+		Reference: reference,
+		Status:    types.PayoutProcessing,
+	}
+
+	res := *p.payouts[id]
+	return &res, nil
+}
+
+func (p *PayoutProvider) Status(ctx context.Context, providerPayoutID string) (*models.ProviderPayout, error) {
+	if err := p.latency.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payout, ok := p.payouts[providerPayoutID]
+	if !ok {
+		return nil, ErrPayoutNotFound
+	}
+	payout.Status = types.PayoutPaid
+
+	res := *payout
+	return &res, nil
+}
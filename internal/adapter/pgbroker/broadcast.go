@@ -0,0 +1,75 @@
+package pgbroker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/pgbroker"
+)
+
+// BroadcastBroker — Postgres реализация рассылки объявлений.
+// Fanout exchange RabbitMQ заменяется публикацией копии сообщения в очередь каждой роли.
+type BroadcastBroker struct {
+	client *pgbroker.Client
+	l      logger.Logger
+}
+
+func NewBroadcastBroker(client *pgbroker.Client, l logger.Logger) *BroadcastBroker {
+	return &BroadcastBroker{
+		client: client,
+		l:      l,
+	}
+}
+
+func (r *BroadcastBroker) PublishBroadcast(ctx context.Context, msg models.BroadcastMessage) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_publish_broadcast")
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to marshal message: %w", err))
+	}
+
+	for _, role := range msg.Roles {
+		if err := r.client.Publish(ctx, pgbroker.Message{
+			Queue:         rabbit.BroadcastQueue(role),
+			RoutingKey:    "broadcast",
+			Body:          body,
+			CorrelationID: wrap.GetRequestID(ctx),
+		}); err != nil {
+			return wrap.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+// ConsumeBroadcast читает объявления для получателей с ролью role
+func (r *BroadcastBroker) ConsumeBroadcast(ctx context.Context, role types.UserRole, handler BroadcastHandler) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_consume_broadcast")
+	queue := rabbit.BroadcastQueue(role)
+	r.l.Info(ctx, "start consuming broadcasts", "queue", queue)
+
+	return r.client.Consume(ctx, queue, func(ctx context.Context, d pgbroker.Delivery) pgbroker.Outcome {
+		var req models.BroadcastMessage
+		if err := models.DecodeMessage(d.Body, &req); err != nil {
+			r.l.Error(ctx, "decode failed", err)
+			return pgbroker.Discard
+		}
+
+		if err := handler(wrap.WithRequestID(ctx, d.CorrelationID), req); err != nil {
+			r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle broadcast", err)
+			if isRecoverableError(err) {
+				return pgbroker.Requeue
+			}
+			return pgbroker.Discard
+		}
+
+		return pgbroker.Ack
+	})
+}
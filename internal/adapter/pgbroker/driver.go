@@ -0,0 +1,135 @@
+package pgbroker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/pgbroker"
+)
+
+// DriverBroker — Postgres реализация брокера driver-service
+type DriverBroker struct {
+	client *pgbroker.Client
+	l      logger.Logger
+}
+
+func NewDriverBroker(client *pgbroker.Client, l logger.Logger) *DriverBroker {
+	return &DriverBroker{
+		client: client,
+		l:      l,
+	}
+}
+
+func (r *DriverBroker) publish(ctx context.Context, queue, routingKey string, msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	return r.client.Publish(ctx, pgbroker.Message{
+		Queue:         queue,
+		RoutingKey:    routingKey,
+		Body:          body,
+		CorrelationID: wrap.GetRequestID(ctx),
+	})
+}
+
+func (r *DriverBroker) PublishDriverStatus(ctx context.Context, msg models.DriverStatusUpdateMessage) error {
+	ctx = wrap.WithAction(ctx, "publish_driver_status")
+	key := fmt.Sprintf("driver.status.%s", msg.DriverID)
+
+	if err := r.publish(ctx, QueueDriverStatusUpdate, key, msg); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	return nil
+}
+
+func (r *DriverBroker) PublishDriverResponse(ctx context.Context, msg models.DriverMatchResponse) error {
+	ctx = wrap.WithAction(ctx, "publish_driver_response")
+	key := fmt.Sprintf("driver.response.%s", msg.RideID)
+
+	if err := r.publish(ctx, QueueDriverResponse, key, msg); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	return nil
+}
+
+func (r *DriverBroker) PublishLocationUpdate(ctx context.Context, msg models.RideLocationUpdate) error {
+	ctx = wrap.WithAction(ctx, "publish_location_update")
+
+	if err := r.publish(ctx, QueueLocationUpdate, "location", msg); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	return nil
+}
+
+// -- Consumers
+
+// ConsumeRideRequest слушает ride.request.* события и передаёт их в обработчик fn.
+func (r *DriverBroker) ConsumeRideRequest(ctx context.Context, fn ConsumeRideHandlerFunc) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_handle_ride_requested")
+	r.l.Info(ctx, "start consuming ride requests", "queue", QueueRideRequests)
+
+	return r.client.Consume(ctx, QueueRideRequests, func(ctx context.Context, d pgbroker.Delivery) pgbroker.Outcome {
+		// остановка потребителя не обрывает начатый поиск: его дожидается или прерывает Drain сервиса
+		ctx = context.WithoutCancel(ctx)
+
+		var req models.RideRequestedMessage
+		if err := models.DecodeMessage(d.Body, &req); err != nil {
+			r.l.Error(ctx, "decode failed", err)
+			return pgbroker.Discard
+		}
+		if err := req.Validate(); err != nil {
+			r.l.Warn(ctx, "dropping ride request", "reason", err.Error(), "ride_id", req.RideID)
+			return pgbroker.Discard
+		}
+
+		ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideType), d.CorrelationID)
+
+		if err := fn(ctxx, req); err != nil {
+			// экземпляр останавливается — поиск продолжит другой
+			if errors.Is(err, types.ErrSearchInterrupted) {
+				r.l.Info(ctx, "returning ride request to the queue", "reason", err.Error())
+				return pgbroker.Requeue
+			}
+
+			r.l.Error(ctx, "failed to handle ride request", err)
+
+			// Если водителей нет — это не ошибка, просто игнор
+			if errors.Is(err, types.ErrDriversNotFound) || errors.Is(err, types.ErrDriverSearchTimeout) {
+				r.l.Warn(ctx, "dropping message", "reason", err.Error())
+			}
+			return pgbroker.Discard
+		}
+
+		return pgbroker.Ack
+	})
+}
+
+func (r *DriverBroker) ConsumeStatusUpdate(ctx context.Context, fn MatchConfHandlerFunc) error {
+	const op = "RideConsumer.ConsumeStatusUpdate"
+	r.l.Info(ctx, "start consuming ride status", "queue", QueueRideStatus)
+
+	return r.client.Consume(ctx, QueueRideStatus, func(ctx context.Context, d pgbroker.Delivery) pgbroker.Outcome {
+		var req models.RideStatusUpdateMessage
+		if err := models.DecodeMessage(d.Body, &req); err != nil {
+			r.l.Error(ctx, "decode failed", err, "op", op)
+			return pgbroker.Discard
+		}
+
+		ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), d.CorrelationID)
+
+		if err := fn(ctxx, req); err != nil {
+			r.l.Error(ctx, "failed to handle status update", err, "op", op)
+			return pgbroker.Discard
+		}
+
+		return pgbroker.Ack
+	})
+}
@@ -0,0 +1,32 @@
+package pgbroker
+
+import (
+	"errors"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// Очереди совпадают с очередями RabbitMQ (rabbitmq_definitions.json)
+const (
+	QueueRideRequests       = rabbit.QueueRideRequests
+	QueueRideStatus         = rabbit.QueueRideStatus
+	QueueDriverResponse     = rabbit.QueueDriverResponse
+	QueueDriverStatusUpdate = rabbit.QueueDriverStatusUpdate
+	QueueLocationUpdate     = rabbit.QueueLocationUpdate
+)
+
+// Обработчики совпадают с RabbitMQ адаптером, чтобы брокеры были взаимозаменяемы
+type (
+	ConsumeRideHandlerFunc    = rabbit.ConsumeRideHandlerFunc
+	MatchConfHandlerFunc      = rabbit.MatchConfHandlerFunc
+	DriverStatusUpdateHandler = rabbit.DriverStatusUpdateHandler
+	DriverResponseHandler     = rabbit.DriverResponseHandler
+	LocationUpdateHandler     = rabbit.LocationUpdateHandler
+	BroadcastHandler          = rabbit.BroadcastHandler
+)
+
+// isRecoverableError returns true if the provided error must be requeued
+func isRecoverableError(err error) bool {
+	return errors.Is(err, types.ErrDatabaseFailed) || errors.Is(err, types.ErrFailedToPublishRideStatus)
+}
@@ -0,0 +1,152 @@
+package pgbroker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/pgbroker"
+)
+
+// RideBroker — Postgres реализация брокера ride-service
+type RideBroker struct {
+	client *pgbroker.Client
+	l      logger.Logger
+}
+
+func NewRideBroker(client *pgbroker.Client, log logger.Logger) *RideBroker {
+	return &RideBroker{
+		client: client,
+		l:      log,
+	}
+}
+
+// публикует событие о новой поездке для поиска водителя с ключом 'ride.request.{ride_type}'.
+func (r *RideBroker) PublishRideRequested(ctx context.Context, msg models.RideRequestedMessage) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_publish_ride_request")
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to marshal message: %w", err))
+	}
+
+	if err := r.client.Publish(ctx, pgbroker.Message{
+		Queue:         QueueRideRequests,
+		RoutingKey:    fmt.Sprintf("ride.request.%s", msg.RideType),
+		Body:          body,
+		CorrelationID: msg.CorrelationID,
+		Priority:      msg.Priority,
+	}); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	return nil
+}
+
+// публикует событие об изменении статуса поездки с ключом 'ride.status.{status}'.
+func (r *RideBroker) PublishRideStatus(ctx context.Context, msg models.RideStatusUpdateMessage) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_publish_ride_status")
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to marshal message: %w", err))
+	}
+
+	if err := r.client.Publish(ctx, pgbroker.Message{
+		Queue:         QueueRideStatus,
+		RoutingKey:    fmt.Sprintf("ride.status.%s", msg.Status),
+		Body:          body,
+		CorrelationID: msg.CorrelationID,
+	}); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	return nil
+}
+
+func (r *RideBroker) ConsumeDriverStatusUpdate(ctx context.Context, handler DriverStatusUpdateHandler) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_consume_driver_status_update")
+	r.l.Info(ctx, "start consuming driver status update", "queue", QueueDriverStatusUpdate)
+
+	return r.client.Consume(ctx, QueueDriverStatusUpdate, func(ctx context.Context, d pgbroker.Delivery) pgbroker.Outcome {
+		var req models.DriverStatusUpdateMessage
+		if err := models.DecodeMessage(d.Body, &req); err != nil {
+			r.l.Error(ctx, "failed to unmarshal driver status update", err)
+			return pgbroker.Discard
+		}
+
+		ctxx := wrap.WithRequestID(ctx, d.CorrelationID)
+
+		if err := handler(ctxx, req); err != nil {
+			r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver status update", err)
+			return outcomeFor(err)
+		}
+
+		return pgbroker.Ack
+	})
+}
+
+// ConsumeDriverResponses слушает ответы водителей по всем поездкам
+func (r *RideBroker) ConsumeDriverResponses(ctx context.Context, handler DriverResponseHandler) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_consume_driver_response")
+	r.l.Info(ctx, "start consuming driver response", "queue", QueueDriverResponse)
+
+	return r.client.Consume(ctx, QueueDriverResponse, func(ctx context.Context, d pgbroker.Delivery) pgbroker.Outcome {
+		var req models.DriverMatchResponse
+		if err := models.DecodeMessage(d.Body, &req); err != nil {
+			r.l.Error(ctx, "failed to unmarshal driver match response", err)
+			return pgbroker.Discard
+		}
+
+		ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), d.CorrelationID)
+
+		if err := handler(ctxx, req); err != nil {
+			r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver response", err)
+			return outcomeFor(err)
+		}
+
+		return pgbroker.Ack
+	})
+}
+
+func (r *RideBroker) ConsumeDriverLocationUpdate(ctx context.Context, handler LocationUpdateHandler) error {
+	ctx = wrap.WithAction(ctx, "pgbroker_consume_driver_location")
+	r.l.Info(ctx, "start consuming location update", "queue", QueueLocationUpdate)
+
+	return r.client.Consume(ctx, QueueLocationUpdate, func(ctx context.Context, d pgbroker.Delivery) pgbroker.Outcome {
+		var req models.RideLocationUpdate
+		if err := models.DecodeMessage(d.Body, &req); err != nil {
+			r.l.Error(ctx, "failed to unmarshal driver location update", err)
+			return pgbroker.Discard
+		}
+		if err := req.Validate(); err != nil {
+			r.l.Warn(ctx, "dropping driver location update", "reason", err.Error(), "driver_id", req.DriverID)
+			return pgbroker.Discard
+		}
+
+		// координаты вне поездки ride-service не нужны
+		if req.RideID == nil {
+			return pgbroker.Ack
+		}
+
+		ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), d.CorrelationID)
+
+		if err := handler(ctxx, req); err != nil {
+			r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver location update", err)
+			return outcomeFor(err)
+		}
+
+		return pgbroker.Ack
+	})
+}
+
+// outcomeFor возвращает восстановимые ошибки в очередь, остальные отбрасывает
+func outcomeFor(err error) pgbroker.Outcome {
+	if isRecoverableError(err) {
+		return pgbroker.Requeue
+	}
+	return pgbroker.Discard
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// AccessTokenDenylistRepo хранит access токены, отозванные до истечения срока
+type AccessTokenDenylistRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAccessTokenDenylistRepo(db *pgxpool.Pool) *AccessTokenDenylistRepo {
+	return &AccessTokenDenylistRepo{
+		db: db,
+	}
+}
+
+// Revoke запрещает access токен до expiresAt. Заодно удаляются записи уже истекших токенов.
+func (r *AccessTokenDenylistRepo) Revoke(ctx context.Context, tokenID, userID uuid.UUID, expiresAt time.Time) error {
+	const op = "AccessTokenDenylistRepo.Revoke"
+	query := `
+		WITH expired AS (
+			DELETE FROM revoked_access_tokens WHERE expires_at < now()
+		)
+		INSERT INTO revoked_access_tokens(jti, user_id, expires_at)
+		VALUES($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, tokenID, userID, expiresAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// RevokeAllBefore запрещает все access токены пользователя, выданные раньше before.
+// Граница только растет: повторный выход не возвращает действие уже отозванным токенам.
+func (r *AccessTokenDenylistRepo) RevokeAllBefore(ctx context.Context, userID uuid.UUID, before time.Time) error {
+	const op = "AccessTokenDenylistRepo.RevokeAllBefore"
+	query := `
+		INSERT INTO access_token_cutoffs(user_id, revoked_before)
+		VALUES($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET revoked_before = greatest(access_token_cutoffs.revoked_before, EXCLUDED.revoked_before)`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, userID, before); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// IsRevoked сообщает, отозван ли токен выходом из его сессии
+func (r *AccessTokenDenylistRepo) IsRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	const op = "AccessTokenDenylistRepo.IsRevoked"
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE jti = $1)`
+
+	var revoked bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, tokenID).Scan(&revoked); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return revoked, nil
+}
+
+// RevokedBefore возвращает границу выхода пользователя со всех устройств, нулевое время — выхода не было
+func (r *AccessTokenDenylistRepo) RevokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	const op = "AccessTokenDenylistRepo.RevokedBefore"
+	query := `SELECT revoked_before FROM access_token_cutoffs WHERE user_id = $1`
+
+	var before time.Time
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, userID).Scan(&before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return time.Time{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return before, nil
+}
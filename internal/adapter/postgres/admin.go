@@ -3,22 +3,30 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
 type AdminRepo struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *keyring.Keyring // расшифровывает адреса
 }
 
-func NewAdminRepo(db *pgxpool.Pool) *AdminRepo {
+func NewAdminRepo(db *pgxpool.Pool, pii *keyring.Keyring) *AdminRepo {
 	return &AdminRepo{
-		db: db,
+		db:  db,
+		pii: pii,
 	}
 }
 
@@ -41,28 +49,28 @@ func (r *AdminRepo) GetOverview(ctx context.Context) (*models.OverviewResponse,
 	if err := db.QueryRow(ctx, `
         SELECT COUNT(*)
         FROM rides
-        WHERE status IN ('REQUESTED','MATCHED','EN_ROUTE','ARRIVED','IN_PROGRESS')
+        WHERE status IN ('REQUESTED','MATCHED','EN_ROUTE','ARRIVED','IN_PROGRESS') AND NOT is_test
     `).Scan(&activeRides); err != nil {
 		return nil, err
 	}
 
 	// Available drivers
 	if err := db.QueryRow(ctx, `
-        SELECT COUNT(*) FROM drivers WHERE status = 'AVAILABLE'
+        SELECT COUNT(*) FROM drivers WHERE status = 'AVAILABLE' AND NOT is_simulator
     `).Scan(&availableDrvs); err != nil {
 		return nil, err
 	}
 
 	// Busy drivers (BUSY or EN_ROUTE)
 	if err := db.QueryRow(ctx, `
-        SELECT COUNT(*) FROM drivers WHERE status IN ('BUSY','EN_ROUTE')
+        SELECT COUNT(*) FROM drivers WHERE status IN ('BUSY','EN_ROUTE') AND NOT is_simulator
     `).Scan(&busyDrvs); err != nil {
 		return nil, err
 	}
 
 	// Total rides today (by creation date)
 	if err := db.QueryRow(ctx, `
-        SELECT COUNT(*) FROM rides WHERE created_at::date = CURRENT_DATE
+        SELECT COUNT(*) FROM rides WHERE created_at::date = CURRENT_DATE AND NOT is_test
     `).Scan(&totalToday); err != nil {
 		return nil, err
 	}
@@ -71,7 +79,7 @@ func (r *AdminRepo) GetOverview(ctx context.Context) (*models.OverviewResponse,
 	if err := db.QueryRow(ctx, `
         SELECT COALESCE(SUM(final_fare), 0)::float
         FROM rides
-        WHERE completed_at::date = CURRENT_DATE
+        WHERE completed_at::date = CURRENT_DATE AND NOT is_test
     `).Scan(&revenueToday); err != nil {
 		return nil, err
 	}
@@ -80,7 +88,7 @@ func (r *AdminRepo) GetOverview(ctx context.Context) (*models.OverviewResponse,
 	if err := db.QueryRow(ctx, `
         SELECT COALESCE(AVG(EXTRACT(EPOCH FROM (matched_at - requested_at))), 0)::float / 60.0
         FROM rides
-        WHERE requested_at::date = CURRENT_DATE AND matched_at IS NOT NULL
+        WHERE requested_at::date = CURRENT_DATE AND matched_at IS NOT NULL AND NOT is_test
     `).Scan(&avgWaitMin); err != nil {
 		return nil, err
 	}
@@ -89,14 +97,14 @@ func (r *AdminRepo) GetOverview(ctx context.Context) (*models.OverviewResponse,
 	if err := db.QueryRow(ctx, `
         SELECT COALESCE(AVG(EXTRACT(EPOCH FROM (completed_at - started_at))), 0)::float / 60.0
         FROM rides
-        WHERE completed_at::date = CURRENT_DATE AND started_at IS NOT NULL
+        WHERE completed_at::date = CURRENT_DATE AND started_at IS NOT NULL AND NOT is_test
     `).Scan(&avgDurMin); err != nil {
 		return nil, err
 	}
 
 	// Cancellation rate components
 	if err := db.QueryRow(ctx, `
-        SELECT COUNT(*) FROM rides WHERE created_at::date = CURRENT_DATE AND status = 'CANCELLED'
+        SELECT COUNT(*) FROM rides WHERE created_at::date = CURRENT_DATE AND status = 'CANCELLED' AND NOT is_test
     `).Scan(&cancelledToday); err != nil {
 		return nil, err
 	}
@@ -112,7 +120,7 @@ func (r *AdminRepo) GetOverview(ctx context.Context) (*models.OverviewResponse,
 	distRows, err := db.Query(ctx, `
         SELECT COALESCE(vehicle_type, 'UNKNOWN') AS vt, COUNT(*)
         FROM drivers
-        WHERE status = 'AVAILABLE'
+        WHERE status = 'AVAILABLE' AND NOT is_simulator
         GROUP BY vt
     `)
 	if err != nil {
@@ -131,46 +139,65 @@ func (r *AdminRepo) GetOverview(ctx context.Context) (*models.OverviewResponse,
 		return nil, err
 	}
 
-	// Hotspots: combine active rides by pickup address and available drivers waiting by their current address
+	// Hotspots: combine active rides by pickup address and available drivers waiting by their current address.
+	// Адреса зашифрованы случайным nonce, поэтому группировка по адресу выполняется после расшифровки.
 	hotspotRows, err := db.Query(ctx, `
-        WITH active_by_pickup AS (
-            SELECT c.address, COUNT(*)::int AS active_rides
-            FROM rides r
-            JOIN coordinates c ON c.id = r.pickup_coordinate_id
-            WHERE r.status IN ('REQUESTED','MATCHED','EN_ROUTE','ARRIVED','IN_PROGRESS')
-            GROUP BY c.address
-        ), waiting_by_address AS (
-            SELECT c.address, COUNT(*)::int AS waiting_drivers
-            FROM coordinates c
-            JOIN drivers d ON d.id = c.entity_id
-            WHERE c.entity_type = 'driver' AND c.is_current = TRUE AND d.status = 'AVAILABLE'
-            GROUP BY c.address
-        )
-        SELECT COALESCE(a.address, w.address) AS location,
-               COALESCE(a.active_rides, 0)    AS active_rides,
-               COALESCE(w.waiting_drivers, 0) AS waiting_drivers
-        FROM active_by_pickup a
-        FULL OUTER JOIN waiting_by_address w ON a.address = w.address
-        ORDER BY (COALESCE(a.active_rides,0) + COALESCE(w.waiting_drivers,0)) DESC
-        LIMIT 5;
+        SELECT c.address, 1 AS active_rides, 0 AS waiting_drivers
+        FROM rides r
+        JOIN coordinates c ON c.id = r.pickup_coordinate_id
+        WHERE r.status IN ('REQUESTED','MATCHED','EN_ROUTE','ARRIVED','IN_PROGRESS') AND NOT r.is_test
+        UNION ALL
+        SELECT c.address, 0, 1
+        FROM coordinates c
+        JOIN drivers d ON d.id = c.entity_id
+        WHERE c.entity_type = 'driver' AND c.is_current = TRUE AND d.status = 'AVAILABLE' AND NOT d.is_simulator
     `)
 	if err != nil {
 		return nil, err
 	}
 	defer hotspotRows.Close()
 
-	hotspots := make([]models.Hotspot, 0, 5)
+	byAddress := make(map[string]*models.Hotspot)
 	for hotspotRows.Next() {
-		var h models.Hotspot
-		if err := hotspotRows.Scan(&h.Location, &h.ActiveRides, &h.WaitingDrivers); err != nil {
+		var (
+			address         string
+			active, waiting int
+		)
+		if err := hotspotRows.Scan(&address, &active, &waiting); err != nil {
 			return nil, err
 		}
-		hotspots = append(hotspots, h)
+		if err := decryptStrings(r.pii, &address); err != nil {
+			return nil, err
+		}
+
+		h, ok := byAddress[address]
+		if !ok {
+			h = &models.Hotspot{Location: address}
+			byAddress[address] = h
+		}
+		h.ActiveRides += active
+		h.WaitingDrivers += waiting
 	}
 	if err := hotspotRows.Err(); err != nil {
 		return nil, err
 	}
 
+	hotspots := make([]models.Hotspot, 0, len(byAddress))
+	for _, h := range byAddress {
+		hotspots = append(hotspots, *h)
+	}
+	sort.Slice(hotspots, func(i, j int) bool {
+		ti := hotspots[i].ActiveRides + hotspots[i].WaitingDrivers
+		tj := hotspots[j].ActiveRides + hotspots[j].WaitingDrivers
+		if ti != tj {
+			return ti > tj
+		}
+		return hotspots[i].Location < hotspots[j].Location
+	})
+	if len(hotspots) > 5 {
+		hotspots = hotspots[:5]
+	}
+
 	resp := &models.OverviewResponse{
 		Timestamp: time.Now().UTC(),
 		Metrics: models.Metrics{
@@ -235,7 +262,8 @@ func (r *AdminRepo) GetActiveRides(ctx context.Context, filters models.Filters)
             END AS estimated_completion,
             cur.latitude,
             cur.longitude,
-            COALESCE(cur.distance_km, 0)::float AS distance_completed_km
+            COALESCE(cur.distance_km, 0)::float AS distance_completed_km,
+            COALESCE(r.priority_boarding, '') AS priority_boarding
         FROM rides r
         LEFT JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
         LEFT JOIN coordinates dc ON dc.id = r.destination_coordinate_id
@@ -274,6 +302,7 @@ func (r *AdminRepo) GetActiveRides(ctx context.Context, filters models.Filters)
 			latNull      sql.NullFloat64
 			lonNull      sql.NullFloat64
 			distDoneKm   float64
+			boarding     types.PriorityBoarding
 		)
 
 		if err := rows.Scan(
@@ -292,12 +321,17 @@ func (r *AdminRepo) GetActiveRides(ctx context.Context, filters models.Filters)
 			&latNull,
 			&lonNull,
 			&distDoneKm,
+			&boarding,
 		); err != nil {
 			return nil, err
 		}
 
 		totalRecords = tr
 
+		if err := decryptStrings(r.pii, &pickupAddr, &destAddr); err != nil {
+			return nil, err
+		}
+
 		ri := models.RideInfo{
 			RideID:             rideID,
 			RideNumber:         rideNumber,
@@ -305,6 +339,7 @@ func (r *AdminRepo) GetActiveRides(ctx context.Context, filters models.Filters)
 			PassengerID:        passengerID,
 			PickupAddress:      pickupAddr,
 			DestinationAddress: destAddr,
+			PriorityBoarding:   boarding,
 			CurrentDriverLocation: models.Location{
 				Latitude:  0,
 				Longitude: 0,
@@ -350,3 +385,257 @@ func (r *AdminRepo) GetActiveRides(ctx context.Context, filters models.Filters)
 		Metadata: metadata,
 	}, nil
 }
+
+// GetRideNumber returns ride number and creation time of the ride
+func (r *AdminRepo) GetRideNumber(ctx context.Context, rideID uuid.UUID) (string, time.Time, error) {
+	const op = "AdminRepo.GetRideNumber"
+
+	var (
+		rideNumber string
+		createdAt  time.Time
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `SELECT ride_number, created_at FROM rides WHERE id = $1`, rideID).Scan(&rideNumber, &createdAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", time.Time{}, types.ErrRideNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return "", time.Time{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return rideNumber, createdAt, nil
+}
+
+// GetRideEventsUntil returns ride events created not later than ts, oldest first
+func (r *AdminRepo) GetRideEventsUntil(ctx context.Context, rideID uuid.UUID, ts time.Time) ([]models.RideEventRecord, error) {
+	const op = "AdminRepo.GetRideEventsUntil"
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, `
+        SELECT id, ride_id, event_type, event_data, created_at
+        FROM ride_events
+        WHERE ride_id = $1 AND created_at <= $2
+        ORDER BY created_at ASC, id ASC
+    `, rideID, ts)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer rows.Close()
+
+	events := make([]models.RideEventRecord, 0)
+	for rows.Next() {
+		var (
+			e         models.RideEventRecord
+			eventType string
+		)
+		if err := rows.Scan(&e.ID, &e.RideID, &eventType, &e.EventData, &e.CreatedAt); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		e.EventType = types.RideEvent(eventType)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return events, nil
+}
+
+// GetDriverLocationAt returns the last recorded driver position not later than ts
+func (r *AdminRepo) GetDriverLocationAt(ctx context.Context, driverID uuid.UUID, ts time.Time) (*models.LocationRecord, error) {
+	const op = "AdminRepo.GetDriverLocationAt"
+
+	var (
+		loc      models.LocationRecord
+		accuracy sql.NullFloat64
+		speed    sql.NullFloat64
+		heading  sql.NullFloat64
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `
+        SELECT latitude::float, longitude::float, accuracy_meters::float, speed_kmh::float, heading_degrees::float, recorded_at
+        FROM location_history
+        WHERE driver_id = $1 AND recorded_at <= $2
+        ORDER BY recorded_at DESC
+        LIMIT 1
+    `, driverID, ts).Scan(&loc.Latitude, &loc.Longitude, &accuracy, &speed, &heading, &loc.RecordedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrDriverLocationNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	loc.AccuracyMeters = accuracy.Float64
+	loc.SpeedKmh = speed.Float64
+	loc.HeadingDegrees = heading.Float64
+	loc.Source = "location_history"
+
+	return &loc, nil
+}
+
+// GetMatchSLI returns number of rides matched within threshold (good) and number of
+// rides that had a chance to be matched (total) among rides requested in the last window
+func (r *AdminRepo) GetMatchSLI(ctx context.Context, window, threshold time.Duration) (good, total int, err error) {
+	const op = "AdminRepo.GetMatchSLI"
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `
+        SELECT
+            COUNT(*) FILTER (WHERE matched_at IS NOT NULL AND matched_at - requested_at <= make_interval(secs => $2)),
+            COUNT(*) FILTER (WHERE matched_at IS NOT NULL OR requested_at < now() - make_interval(secs => $2))
+        FROM rides
+        WHERE requested_at >= now() - make_interval(secs => $1) AND NOT is_test
+    `, window.Seconds(), threshold.Seconds()).Scan(&good, &total); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return good, total, nil
+}
+
+// GetPositioningEffectiveness returns how many positioning tips were sent since the given time
+// and, among evaluated ones, how many led the driver to move to the area and to get a ride
+func (r *AdminRepo) GetPositioningEffectiveness(ctx context.Context, since time.Time) (*models.PositioningEffectiveness, error) {
+	const op = "AdminRepo.GetPositioningEffectiveness"
+
+	res := &models.PositioningEffectiveness{}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `
+        SELECT
+            COUNT(*),
+            COUNT(*) FILTER (WHERE evaluated_at IS NOT NULL),
+            COUNT(*) FILTER (WHERE moved),
+            COUNT(*) FILTER (WHERE got_ride)
+        FROM positioning_recommendations
+        WHERE sent_at >= $1
+    `, since).Scan(&res.Sent, &res.Evaluated, &res.Moved, &res.GotRide); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return res, nil
+}
+
+// GetBlocklist returns drivers' passenger blocks with optional driver/passenger filters
+func (r *AdminRepo) GetBlocklist(ctx context.Context, filter models.BlocklistFilter, filters models.Filters) (*models.BlocklistResponse, error) {
+	const op = "AdminRepo.GetBlocklist"
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER() AS total_count,
+		       driver_id, passenger_id, COALESCE(reason, ''), created_at
+		FROM driver_blocked_passengers
+		WHERE ($1::uuid IS NULL OR driver_id = $1)
+		  AND ($2::uuid IS NULL OR passenger_id = $2)
+		ORDER BY created_at %s, driver_id, passenger_id
+		LIMIT $3 OFFSET $4`, filters.SortDirection())
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, filter.DriverID, filter.PassengerID, filters.Limit(), filters.Offset())
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	blocks := make([]models.BlockedPassenger, 0, filters.Limit())
+	for rows.Next() {
+		var b models.BlockedPassenger
+		if err := rows.Scan(&totalRecords, &b.DriverID, &b.PassengerID, &b.Reason, &b.CreatedAt); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &models.BlocklistResponse{
+		Blocks:   blocks,
+		Metadata: models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize),
+	}, nil
+}
+
+// SetDriverSimulator помечает водителя как симулятор: он получает только тестовые поездки песочницы
+func (r *AdminRepo) SetDriverSimulator(ctx context.Context, driverID uuid.UUID, simulator bool) error {
+	const op = "AdminRepo.SetDriverSimulator"
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, `UPDATE drivers SET is_simulator = $2, updated_at = now() WHERE id = $1`, driverID, simulator)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrDriverIDNotExist
+	}
+
+	return nil
+}
+
+// SetPriorityBoardingEligible разрешает или запрещает пассажиру заказывать поездки с приоритетной посадкой
+func (r *AdminRepo) SetPriorityBoardingEligible(ctx context.Context, passengerID uuid.UUID, eligible bool) error {
+	const op = "AdminRepo.SetPriorityBoardingEligible"
+	query := `
+		UPDATE users
+		SET priority_boarding_eligible = $2, updated_at = now()
+		WHERE id = $1 AND role = 'PASSENGER'`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, passengerID, eligible)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetCoverage aggregates matching outcomes of rides requested since the given time by the geohash
+// of the pickup point. Rides still waiting for a driver are skipped.
+func (r *AdminRepo) GetCoverage(ctx context.Context, since time.Time, precision int) ([]models.CoverageArea, error) {
+	const op = "AdminRepo.GetCoverage"
+	query := `
+		SELECT ST_GeoHash(ST_SetSRID(ST_MakePoint(pc.longitude, pc.latitude), 4326), $2) AS cell,
+		       avg(pc.latitude)::float,
+		       avg(pc.longitude)::float,
+		       count(*),
+		       count(*) FILTER (WHERE r.matched_at IS NOT NULL),
+		       count(*) FILTER (WHERE r.matched_at IS NULL AND r.cancellation_reason = $3),
+		       avg(extract(epoch FROM r.matched_at - r.requested_at))::float,
+		       percentile_cont(0.9) WITHIN GROUP (ORDER BY extract(epoch FROM r.matched_at - r.requested_at))::float
+		FROM rides r
+		JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
+		WHERE r.requested_at >= $1 AND NOT r.is_test
+		  AND (r.matched_at IS NOT NULL OR r.status = $4)
+		GROUP BY cell
+		ORDER BY cell`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, since, precision, types.CancelReasonNoDriver, types.StatusCancelled.String())
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	areas, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CoverageArea, error) {
+		var a models.CoverageArea
+		err := row.Scan(
+			&a.Geohash,
+			&a.Latitude,
+			&a.Longitude,
+			&a.Requests,
+			&a.Matched,
+			&a.NoDriverCancellations,
+			&a.AvgWaitSec,
+			&a.P90WaitSec,
+		)
+		return a, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return areas, nil
+}
@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FindAnomalies ищет несогласованные состояния водителей, поездок, сессий и координат
+func (r *AdminRepo) FindAnomalies(ctx context.Context, sessionMaxAge time.Duration) ([]models.Anomaly, error) {
+	const op = "AdminRepo.FindAnomalies"
+	db := TxorDB(ctx, r.db)
+
+	anomalies := make([]models.Anomaly, 0)
+	fail := func(err error) ([]models.Anomaly, error) {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	// Водитель занят, но у него нет активной поездки
+	rows, err := db.Query(ctx, `
+		SELECT d.id, d.status, d.updated_at
+		FROM drivers d
+		WHERE d.status IN ('BUSY', 'EN_ROUTE', 'ARRIVED')
+		  AND NOT EXISTS (
+			SELECT 1 FROM rides r
+			WHERE r.driver_id = d.id AND r.status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED', 'IN_PROGRESS')
+		  )
+		ORDER BY d.updated_at`)
+	if err != nil {
+		return fail(err)
+	}
+	busy, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Anomaly, error) {
+		var (
+			a      = models.Anomaly{Kind: types.AnomalyDriverBusyWithoutRide}
+			status string
+		)
+		err := row.Scan(&a.EntityID, &status, &a.Since)
+		a.DriverID = &a.EntityID
+		a.Details = fmt.Sprintf("driver status is %s but there is no active ride", status)
+		return a, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+	anomalies = append(anomalies, busy...)
+
+	// Поездка назначена водителю, который уже офлайн
+	rows, err = db.Query(ctx, `
+		SELECT r.id, r.driver_id, r.status, coalesce(r.matched_at, r.requested_at)
+		FROM rides r
+		JOIN drivers d ON d.id = r.driver_id
+		WHERE r.status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED')
+		  AND d.status = 'OFFLINE'
+		ORDER BY r.requested_at`)
+	if err != nil {
+		return fail(err)
+	}
+	orphaned, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Anomaly, error) {
+		var (
+			a        = models.Anomaly{Kind: types.AnomalyRideMatchedDriverOffline}
+			driverID uuid.UUID
+			status   string
+		)
+		err := row.Scan(&a.EntityID, &driverID, &status, &a.Since)
+		a.RideID = &a.EntityID
+		a.DriverID = &driverID
+		a.Details = fmt.Sprintf("ride is %s but the assigned driver is OFFLINE", status)
+		return a, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+	anomalies = append(anomalies, orphaned...)
+
+	// Сессия водителя открыта дольше допустимого
+	rows, err = db.Query(ctx, `
+		SELECT id, driver_id, started_at
+		FROM driver_sessions
+		WHERE ended_at IS NULL
+		  AND started_at < now() - make_interval(secs => $1)
+		ORDER BY started_at`, sessionMaxAge.Seconds())
+	if err != nil {
+		return fail(err)
+	}
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Anomaly, error) {
+		var (
+			a        = models.Anomaly{Kind: types.AnomalySessionOpenTooLong}
+			driverID uuid.UUID
+		)
+		err := row.Scan(&a.EntityID, &driverID, &a.Since)
+		a.DriverID = &driverID
+		a.Details = fmt.Sprintf("session is open for %s", time.Since(a.Since).Truncate(time.Minute))
+		return a, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+	anomalies = append(anomalies, sessions...)
+
+	// Несколько текущих координат у одной сущности
+	rows, err = db.Query(ctx, `
+		SELECT entity_id, entity_type, count(*), min(created_at)
+		FROM coordinates
+		WHERE is_current = true
+		GROUP BY entity_id, entity_type
+		HAVING count(*) > 1
+		ORDER BY min(created_at)`)
+	if err != nil {
+		return fail(err)
+	}
+	duplicates, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Anomaly, error) {
+		var (
+			a          = models.Anomaly{Kind: types.AnomalyDuplicateCurrentCoordinate}
+			entityType string
+			count      int
+		)
+		err := row.Scan(&a.EntityID, &entityType, &count, &a.Since)
+		if types.EntityType(entityType) == types.Driver {
+			a.DriverID = &a.EntityID
+		}
+		a.Details = fmt.Sprintf("%s has %d coordinates marked is_current", entityType, count)
+		return a, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+	anomalies = append(anomalies, duplicates...)
+
+	return anomalies, nil
+}
+
+// ResetDriverStatus переводит занятого водителя без активной поездки в AVAILABLE,
+// если у него открыта сессия, иначе в OFFLINE
+func (r *AdminRepo) ResetDriverStatus(ctx context.Context, driverID uuid.UUID) (types.DriverStatus, error) {
+	const op = "AdminRepo.ResetDriverStatus"
+
+	var status types.DriverStatus
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `
+		UPDATE drivers d
+		SET status = CASE
+				WHEN EXISTS (SELECT 1 FROM driver_sessions s WHERE s.driver_id = d.id AND s.ended_at IS NULL)
+				THEN 'AVAILABLE' ELSE 'OFFLINE'
+			END,
+			updated_at = now()
+		WHERE d.id = $1
+		  AND d.status IN ('BUSY', 'EN_ROUTE', 'ARRIVED')
+		  AND NOT EXISTS (
+			SELECT 1 FROM rides r
+			WHERE r.driver_id = d.id AND r.status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED', 'IN_PROGRESS')
+		  )
+		RETURNING d.status`, driverID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", types.ErrAnomalyNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return "", wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return status, nil
+}
+
+// CancelRideWithOfflineDriver отменяет поездку, назначенную офлайн водителю, и пишет событие отмены.
+// Как и отмена в ride-service, возвращает на баланс блокировку кошелька и использование промокода
+// тем же запросом. Снятие предавторизации карты записывается в outbox, его выполняет relay ride-service.
+// Возвращает водителя поездки.
+func (r *AdminRepo) CancelRideWithOfflineDriver(ctx context.Context, rideID uuid.UUID, reason string) (uuid.UUID, error) {
+	const op = "AdminRepo.CancelRideWithOfflineDriver"
+
+	var driverID uuid.UUID
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `
+		WITH cancelled AS (
+			UPDATE rides r
+			SET status = 'CANCELLED', cancelled_at = now(), cancellation_reason = $2, updated_at = now()
+			FROM drivers d
+			WHERE r.id = $1
+			  AND d.id = r.driver_id
+			  AND d.status = 'OFFLINE'
+			  AND r.status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED')
+			RETURNING r.id, r.driver_id, r.cancelled_at
+		), open_hold AS (
+			SELECT h.passenger_id, h.ride_id, h.amount
+			FROM wallet_ledger h
+			JOIN cancelled c ON c.id = h.ride_id
+			WHERE h.entry_type = 'HOLD'
+			  AND NOT EXISTS (
+				SELECT 1 FROM wallet_ledger s
+				WHERE s.ride_id = h.ride_id AND s.entry_type IN ('CAPTURE', 'RELEASE')
+			  )
+		), wallet AS (
+			UPDATE wallets w
+			SET held = w.held - open_hold.amount, balance = w.balance + open_hold.amount, updated_at = now()
+			FROM open_hold
+			WHERE w.passenger_id = open_hold.passenger_id
+			RETURNING w.passenger_id, w.balance
+		), ledger AS (
+			INSERT INTO wallet_ledger (passenger_id, ride_id, entry_type, amount, balance_after)
+			SELECT open_hold.passenger_id, open_hold.ride_id, 'RELEASE', open_hold.amount, wallet.balance
+			FROM open_hold
+			JOIN wallet USING (passenger_id)
+		), hold AS (
+			UPDATE ride_payment_holds
+			SET status = 'RELEASED', updated_at = now()
+			WHERE ride_id IN (SELECT ride_id FROM open_hold) AND status = 'AUTHORIZED'
+		), promo AS (
+			UPDATE promo_redemptions
+			SET released_at = now()
+			WHERE ride_id IN (SELECT id FROM cancelled) AND released_at IS NULL
+			RETURNING promo_id
+		), promo_use AS (
+			UPDATE promo_codes p
+			SET used_count = greatest(p.used_count - 1, 0), updated_at = now()
+			FROM promo
+			WHERE p.id = promo.promo_id
+		), card_void AS (
+			INSERT INTO outbox (idempotency_key, topic, payload)
+			SELECT 'card_void:' || h.ride_id, 'card_void', jsonb_build_object('ride_id', h.ride_id)
+			FROM ride_payment_holds h
+			WHERE h.ride_id IN (SELECT id FROM cancelled)
+			  AND h.payment_method = 'CARD'
+			  AND h.status = 'AUTHORIZED'
+			ON CONFLICT (idempotency_key) DO NOTHING
+		)
+		INSERT INTO ride_events (ride_id, event_type, event_data)
+		SELECT id, 'RIDE_CANCELLED', jsonb_build_object(
+			'ride_id', id,
+			'status', 'CANCELLED',
+			'timestamp', cancelled_at,
+			'driver_id', driver_id,
+			'reason', $2::text
+		)
+		FROM cancelled
+		RETURNING (event_data->>'driver_id')::uuid`, rideID, reason).Scan(&driverID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, types.ErrAnomalyNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return driverID, nil
+}
+
+// CloseStaleSession закрывает зависшую сессию. Свободный водитель без сессии
+// не сможет уйти офлайн, поэтому он переводится в OFFLINE вместе с сессией. Возвращает водителя сессии.
+func (r *AdminRepo) CloseStaleSession(ctx context.Context, sessionID uuid.UUID, maxAge time.Duration) (uuid.UUID, error) {
+	const op = "AdminRepo.CloseStaleSession"
+
+	var driverID uuid.UUID
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `
+		WITH closed AS (
+			UPDATE driver_sessions
+			SET ended_at = now()
+			WHERE id = $1
+			  AND ended_at IS NULL
+			  AND started_at < now() - make_interval(secs => $2)
+			RETURNING driver_id
+		), offline AS (
+			UPDATE drivers
+			SET status = 'OFFLINE', updated_at = now()
+			WHERE id IN (SELECT driver_id FROM closed) AND status = 'AVAILABLE'
+		)
+		SELECT driver_id FROM closed`, sessionID, maxAge.Seconds()).Scan(&driverID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, types.ErrAnomalyNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return driverID, nil
+}
+
+// KeepLatestCoordinate оставляет is_current только у последней координаты сущности
+func (r *AdminRepo) KeepLatestCoordinate(ctx context.Context, entityID uuid.UUID) (int64, error) {
+	const op = "AdminRepo.KeepLatestCoordinate"
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, `
+		UPDATE coordinates
+		SET is_current = false
+		WHERE entity_id = $1
+		  AND is_current = true
+		  AND id <> (
+			SELECT id FROM coordinates
+			WHERE entity_id = $1 AND is_current = true
+			ORDER BY updated_at DESC, created_at DESC
+			LIMIT 1
+		  )`, entityID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if tag.RowsAffected() == 0 {
+		return 0, types.ErrAnomalyNotFound
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// RepairCurrentCoordinates оставляет is_current только у последней координаты каждой сущности
+// и возвращает число сущностей с несколькими текущими координатами по типам
+func (r *AdminRepo) RepairCurrentCoordinates(ctx context.Context) (map[types.EntityType]int, error) {
+	const op = "AdminRepo.RepairCurrentCoordinates"
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, `
+		WITH ranked AS (
+			SELECT id, entity_id, entity_type,
+			       row_number() OVER (
+			           PARTITION BY entity_id, entity_type
+			           ORDER BY updated_at DESC, created_at DESC, id DESC
+			       ) AS rn
+			FROM coordinates
+			WHERE is_current = true
+		), stale AS (
+			UPDATE coordinates c
+			SET is_current = false
+			FROM ranked
+			WHERE c.id = ranked.id AND ranked.rn > 1
+			RETURNING ranked.entity_id, ranked.entity_type
+		)
+		SELECT entity_type, count(DISTINCT entity_id)
+		FROM stale
+		GROUP BY entity_type`)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer rows.Close()
+
+	violations := make(map[types.EntityType]int)
+	for rows.Next() {
+		var (
+			entityType string
+			count      int
+		)
+		if err := rows.Scan(&entityType, &count); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		violations[types.EntityType(entityType)] = count
+	}
+	if err := rows.Err(); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return violations, nil
+}
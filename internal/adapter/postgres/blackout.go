@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// BlackoutRepo хранит окна приостановки подбора водителей
+type BlackoutRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewBlackoutRepo(db *pgxpool.Pool) *BlackoutRepo {
+	return &BlackoutRepo{
+		db: db,
+	}
+}
+
+// Active возвращает окна, которые действуют в момент at или начнутся позже, по времени начала
+func (r *BlackoutRepo) Active(ctx context.Context, at time.Time) ([]models.MatchingBlackout, error) {
+	const op = "BlackoutRepo.Active"
+	query := `
+		SELECT id, reason, coalesce(vehicle_type, ''), latitude::float, longitude::float, radius_km::float,
+		       starts_at, ends_at, created_by, created_at, updated_at
+		FROM matching_blackouts
+		WHERE ends_at > $1
+		ORDER BY starts_at, id`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, at)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	blackouts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.MatchingBlackout, error) {
+		var b models.MatchingBlackout
+		err := row.Scan(
+			&b.ID, &b.Reason, &b.VehicleType,
+			&b.Zone.Center.Latitude, &b.Zone.Center.Longitude, &b.Zone.RadiusKm,
+			&b.StartsAt, &b.EndsAt, &b.CreatedBy, &b.CreatedAt, &b.UpdatedAt,
+		)
+		return b, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return blackouts, nil
+}
+
+// Create сохраняет новое окно
+func (r *BlackoutRepo) Create(ctx context.Context, b *models.MatchingBlackout) error {
+	const op = "BlackoutRepo.Create"
+	query := `
+		INSERT INTO matching_blackouts(reason, vehicle_type, latitude, longitude, radius_km, starts_at, ends_at, created_by)
+		VALUES($1, nullif($2, ''), $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		b.Reason, b.VehicleType, b.Zone.Center.Latitude, b.Zone.Center.Longitude, b.Zone.RadiusKm,
+		b.StartsAt, b.EndsAt, b.CreatedBy,
+	).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Update переписывает окно, которое еще не закончилось к моменту at.
+// false — окна нет или оно уже закончилось.
+func (r *BlackoutRepo) Update(ctx context.Context, b *models.MatchingBlackout, at time.Time) (bool, error) {
+	const op = "BlackoutRepo.Update"
+	query := `
+		UPDATE matching_blackouts
+		SET reason = $2, vehicle_type = nullif($3, ''), latitude = $4, longitude = $5, radius_km = $6,
+		    starts_at = $7, ends_at = $8, updated_at = now()
+		WHERE id = $1 AND ends_at > $9
+		RETURNING created_by, created_at, updated_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		b.ID, b.Reason, b.VehicleType, b.Zone.Center.Latitude, b.Zone.Center.Longitude, b.Zone.RadiusKm,
+		b.StartsAt, b.EndsAt, at,
+	).Scan(&b.CreatedBy, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return true, nil
+}
+
+// End завершает окно в момент at: действующее закрывается, запланированное удаляется.
+// false — окна нет или оно уже закончилось.
+func (r *BlackoutRepo) End(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	const op = "BlackoutRepo.End"
+	query := `
+		WITH scheduled AS (
+			DELETE FROM matching_blackouts
+			WHERE id = $1 AND starts_at >= $2
+			RETURNING id
+		), closed AS (
+			UPDATE matching_blackouts
+			SET ends_at = $2, updated_at = now()
+			WHERE id = $1 AND starts_at < $2 AND ends_at > $2
+			RETURNING id
+		)
+		SELECT (SELECT count(*) FROM scheduled) + (SELECT count(*) FROM closed)`
+
+	var affected int64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, id, at).Scan(&affected); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BlocklistRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewBlocklistRepo(db *pgxpool.Pool) *BlocklistRepo {
+	return &BlocklistRepo{
+		db: db,
+	}
+}
+
+// Add добавляет пассажира в блоклист водителя, при повторной блокировке обновляет причину
+func (r *BlocklistRepo) Add(ctx context.Context, block models.BlockedPassenger) error {
+	const op = "BlocklistRepo.Add"
+	query := `
+		INSERT INTO driver_blocked_passengers(driver_id, passenger_id, reason)
+		VALUES($1, $2, $3)
+		ON CONFLICT (driver_id, passenger_id) DO UPDATE SET reason = EXCLUDED.reason`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, block.DriverID, block.PassengerID, sql.NullString{
+		String: block.Reason,
+		Valid:  block.Reason != "",
+	}); err != nil {
+		if postgres.IsForeignKeyViolation(err) {
+			return types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+func (r *BlocklistRepo) Remove(ctx context.Context, driverID, passengerID uuid.UUID) error {
+	const op = "BlocklistRepo.Remove"
+	query := `
+		DELETE FROM driver_blocked_passengers
+		WHERE driver_id = $1 AND passenger_id = $2`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, passengerID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if tag.RowsAffected() == 0 {
+		return types.ErrPassengerNotBlocked
+	}
+
+	return nil
+}
+
+func (r *BlocklistRepo) IsBlocked(ctx context.Context, driverID, passengerID uuid.UUID) (bool, error) {
+	const op = "BlocklistRepo.IsBlocked"
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM driver_blocked_passengers
+			WHERE driver_id = $1 AND passenger_id = $2
+		)`
+
+	var blocked bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, passengerID).Scan(&blocked); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return blocked, nil
+}
+
+func (r *BlocklistRepo) Count(ctx context.Context, driverID uuid.UUID) (int, error) {
+	const op = "BlocklistRepo.Count"
+	query := `
+		SELECT count(*) FROM driver_blocked_passengers
+		WHERE driver_id = $1`
+
+	var count int
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&count); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return count, nil
+}
+
+func (r *BlocklistRepo) List(ctx context.Context, driverID uuid.UUID) ([]models.BlockedPassenger, error) {
+	const op = "BlocklistRepo.List"
+	query := `
+		SELECT driver_id, passenger_id, COALESCE(reason, ''), created_at
+		FROM driver_blocked_passengers
+		WHERE driver_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	blocks, err := pgx.CollectRows(rows, scanBlockedPassenger)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return blocks, nil
+}
+
+// ListBlockers возвращает водителей, заблокировавших пассажира
+func (r *BlocklistRepo) ListBlockers(ctx context.Context, passengerID uuid.UUID) ([]uuid.UUID, error) {
+	const op = "BlocklistRepo.ListBlockers"
+	query := `
+		SELECT driver_id FROM driver_blocked_passengers
+		WHERE passenger_id = $1`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, passengerID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	drivers, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return drivers, nil
+}
+
+func scanBlockedPassenger(row pgx.CollectableRow) (models.BlockedPassenger, error) {
+	var b models.BlockedPassenger
+	err := row.Scan(&b.DriverID, &b.PassengerID, &b.Reason, &b.CreatedAt)
+	return b, err
+}
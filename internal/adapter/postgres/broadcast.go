@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// BroadcastRepo хранит объявления администратора и статистику их доставки
+type BroadcastRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewBroadcastRepo(db *pgxpool.Pool) *BroadcastRepo {
+	return &BroadcastRepo{
+		db: db,
+	}
+}
+
+// recipientsWhere — активные пользователи роли $1; если задан город $2, то только те,
+// чья текущая координата попадает в радиус города
+const recipientsWhere = `
+	FROM users u
+	WHERE u.role = $1
+	  AND u.status = 'ACTIVE'
+	  AND ($2::text = '' OR EXISTS (
+		SELECT 1
+		FROM coordinates c
+		JOIN city_settings cs ON cs.code = $2::text
+		WHERE c.entity_id = u.id
+		  AND c.is_current = true
+		  AND ST_DWithin(
+			ST_MakePoint(cs.center_longitude, cs.center_latitude)::geography,
+			ST_MakePoint(c.longitude, c.latitude)::geography,
+			cs.radius_km * 1000
+		  )
+	  ))`
+
+// CountRecipients возвращает число получателей объявления с ролью role
+func (r *BroadcastRepo) CountRecipients(ctx context.Context, role types.UserRole, city string) (int, error) {
+	const op = "BroadcastRepo.CountRecipients"
+
+	var count int
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `SELECT count(*)`+recipientsWhere, role, city).Scan(&count); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return count, nil
+}
+
+// Create сохраняет объявление, ID и время создания заполняются базой
+func (r *BroadcastRepo) Create(ctx context.Context, b *models.Broadcast) error {
+	const op = "BroadcastRepo.Create"
+
+	var city *string
+	if b.City != "" {
+		city = &b.City
+	}
+
+	query := `
+		INSERT INTO broadcasts(title, message, roles, city, created_by, recipients)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		b.Title,
+		b.Message,
+		rolesToStrings(b.Roles),
+		city,
+		b.CreatedBy,
+		b.Stats.Recipients,
+	).Scan(&b.ID, &b.CreatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Get возвращает объявление со статистикой доставки или types.ErrBroadcastNotFound
+func (r *BroadcastRepo) Get(ctx context.Context, id uuid.UUID) (*models.Broadcast, error) {
+	const op = "BroadcastRepo.Get"
+
+	query := `
+		SELECT id, title, message, roles, coalesce(city, ''), created_by,
+		       recipients, delivered, stored, created_at
+		FROM broadcasts
+		WHERE id = $1`
+
+	var (
+		b     models.Broadcast
+		roles []string
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&b.ID,
+		&b.Title,
+		&b.Message,
+		&roles,
+		&b.City,
+		&b.CreatedBy,
+		&b.Stats.Recipients,
+		&b.Stats.Delivered,
+		&b.Stats.Stored,
+		&b.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrBroadcastNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	b.Roles = make([]types.UserRole, 0, len(roles))
+	for _, role := range roles {
+		b.Roles = append(b.Roles, types.UserRole(role))
+	}
+
+	return &b, nil
+}
+
+// StoreRecipients записывает объявление во входящие всех его получателей с ролью role
+// и возвращает число записанных. Получатель удаляется из входящих отметкой MarkDelivered.
+func (r *BroadcastRepo) StoreRecipients(ctx context.Context, id uuid.UUID, role types.UserRole, city string) (int, error) {
+	const op = "BroadcastRepo.StoreRecipients"
+
+	query := `
+		WITH stored AS (
+			INSERT INTO broadcast_inbox(broadcast_id, user_id)
+			SELECT $3::uuid, u.id` + recipientsWhere + `
+			ON CONFLICT DO NOTHING
+			RETURNING user_id
+		), counted AS (
+			UPDATE broadcasts
+			SET stored = stored + (SELECT count(*) FROM stored)
+			WHERE id = $3
+		)
+		SELECT count(*) FROM stored`
+
+	var count int
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, role, city, id).Scan(&count); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return count, nil
+}
+
+// Undelivered возвращает тех из userIDs, кому объявление записано, но еще не доставлено
+func (r *BroadcastRepo) Undelivered(ctx context.Context, id uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	const op = "BroadcastRepo.Undelivered"
+
+	query := `
+		SELECT user_id
+		FROM broadcast_inbox
+		WHERE broadcast_id = $1
+		  AND user_id = ANY($2)
+		  AND delivered_at IS NULL`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, id, userIDs)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return ids, nil
+}
+
+// ListStored возвращает недоставленные пользователю объявления, созданные после since, от старых к новым
+func (r *BroadcastRepo) ListStored(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Broadcast, error) {
+	const op = "BroadcastRepo.ListStored"
+
+	query := `
+		SELECT b.id, b.title, b.message, b.created_at
+		FROM broadcast_inbox i
+		JOIN broadcasts b ON b.id = i.broadcast_id
+		WHERE i.user_id = $1
+		  AND i.delivered_at IS NULL
+		  AND b.created_at > $2
+		ORDER BY b.created_at`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, userID, since)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	broadcasts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Broadcast, error) {
+		var b models.Broadcast
+		err := row.Scan(&b.ID, &b.Title, &b.Message, &b.CreatedAt)
+		return b, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return broadcasts, nil
+}
+
+// MarkDelivered отмечает доставку каждого из объявлений broadcastIDs каждому из userIDs
+// и переносит их из счетчика stored в delivered
+func (r *BroadcastRepo) MarkDelivered(ctx context.Context, broadcastIDs, userIDs []uuid.UUID) error {
+	const op = "BroadcastRepo.MarkDelivered"
+
+	query := `
+		WITH marked AS (
+			UPDATE broadcast_inbox
+			SET delivered_at = now()
+			WHERE broadcast_id = ANY($1)
+			  AND user_id = ANY($2)
+			  AND delivered_at IS NULL
+			RETURNING broadcast_id
+		)
+		UPDATE broadcasts b
+		SET delivered = b.delivered + m.n,
+		    stored = greatest(b.stored - m.n, 0)
+		FROM (SELECT broadcast_id, count(*) AS n FROM marked GROUP BY broadcast_id) m
+		WHERE b.id = m.broadcast_id`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, broadcastIDs, userIDs); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+func rolesToStrings(roles []types.UserRole) []string {
+	res := make([]string, 0, len(roles))
+	for _, role := range roles {
+		res = append(res, role.String())
+	}
+	return res
+}
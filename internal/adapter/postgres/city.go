@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CityRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewCityRepo(db *pgxpool.Pool) *CityRepo {
+	return &CityRepo{
+		db: db,
+	}
+}
+
+const citySelect = `
+	SELECT code, name, center_latitude, center_longitude, radius_km, timezone,
+	       open_time, close_time, night_start, night_end, night_multiplier,
+	       outside_hours_policy, matching_paused, coalesce(legal_entity, ''), updated_at
+	FROM city_settings`
+
+func scanCity(row pgx.Row) (*models.CitySettings, error) {
+	c := &models.CitySettings{}
+	if err := row.Scan(
+		&c.Code,
+		&c.Name,
+		&c.Center.Latitude,
+		&c.Center.Longitude,
+		&c.RadiusKm,
+		&c.Timezone,
+		&c.OpenTime,
+		&c.CloseTime,
+		&c.NightStart,
+		&c.NightEnd,
+		&c.NightMultiplier,
+		&c.OutsideHoursPolicy,
+		&c.MatchingPaused,
+		&c.LegalEntity,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CityRepo) List(ctx context.Context) ([]models.CitySettings, error) {
+	const op = "CityRepo.List"
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, citySelect+` ORDER BY code`)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	cities, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CitySettings, error) {
+		c, err := scanCity(row)
+		if err != nil {
+			return models.CitySettings{}, err
+		}
+		return *c, nil
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return cities, nil
+}
+
+// FindByLocation возвращает ближайший город, в радиус которого попадает точка, или nil
+func (r *CityRepo) FindByLocation(ctx context.Context, location models.Location) (*models.CitySettings, error) {
+	const op = "CityRepo.FindByLocation"
+	query := citySelect + `
+		WHERE ST_DWithin(
+			ST_MakePoint(center_longitude, center_latitude)::geography,
+			ST_MakePoint($1, $2)::geography,
+			radius_km * 1000
+		)
+		ORDER BY ST_Distance(
+			ST_MakePoint(center_longitude, center_latitude)::geography,
+			ST_MakePoint($1, $2)::geography
+		)
+		LIMIT 1`
+
+	city, err := scanCity(TxorDB(ctx, r.db).QueryRow(ctx, query, location.Longitude, location.Latitude))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return city, nil
+}
+
+// Upsert создает или полностью обновляет настройки города
+func (r *CityRepo) Upsert(ctx context.Context, city *models.CitySettings) error {
+	const op = "CityRepo.Upsert"
+	query := `
+		INSERT INTO city_settings(code, name, center_latitude, center_longitude, radius_km, timezone,
+			open_time, close_time, night_start, night_end, night_multiplier, outside_hours_policy, legal_entity)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, nullif($13, ''))
+		ON CONFLICT (code) DO UPDATE
+		SET name = EXCLUDED.name,
+			center_latitude = EXCLUDED.center_latitude,
+			center_longitude = EXCLUDED.center_longitude,
+			radius_km = EXCLUDED.radius_km,
+			timezone = EXCLUDED.timezone,
+			open_time = EXCLUDED.open_time,
+			close_time = EXCLUDED.close_time,
+			night_start = EXCLUDED.night_start,
+			night_end = EXCLUDED.night_end,
+			night_multiplier = EXCLUDED.night_multiplier,
+			outside_hours_policy = EXCLUDED.outside_hours_policy,
+			legal_entity = EXCLUDED.legal_entity,
+			updated_at = now()
+		RETURNING updated_at, matching_paused`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		city.Code,
+		city.Name,
+		city.Center.Latitude,
+		city.Center.Longitude,
+		city.RadiusKm,
+		city.Timezone,
+		city.OpenTime,
+		city.CloseTime,
+		city.NightStart,
+		city.NightEnd,
+		city.NightMultiplier,
+		city.OutsideHoursPolicy,
+		city.LegalEntity,
+	).Scan(&city.UpdatedAt, &city.MatchingPaused); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// SetMatchingPaused приостанавливает или возобновляет прием поездок в городе
+func (r *CityRepo) SetMatchingPaused(ctx context.Context, code string, paused bool) error {
+	const op = "CityRepo.SetMatchingPaused"
+	query := `
+		UPDATE city_settings
+		SET matching_paused = $2, updated_at = now()
+		WHERE code = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, code, paused)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUnknownCity
+	}
+
+	return nil
+}
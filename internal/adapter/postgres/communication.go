@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// CommunicationRepo хранит журнал коммуникаций поездок
+type CommunicationRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewCommunicationRepo(db *pgxpool.Pool) *CommunicationRepo {
+	return &CommunicationRepo{
+		db: db,
+	}
+}
+
+// Create добавляет запись в журнал
+func (r *CommunicationRepo) Create(ctx context.Context, c *models.Communication) error {
+	const op = "CommunicationRepo.Create"
+	query := `
+		INSERT INTO ride_communications(ride_id, recipient_id, channel, message_type, status, detail)
+		VALUES($1, $2, $3, $4, $5, nullif($6, ''))
+		RETURNING id, created_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		c.RideID, c.RecipientID, c.Channel, c.MessageType, c.Status, c.Detail,
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// ListByRide возвращает журнал поездки в порядке отправки
+func (r *CommunicationRepo) ListByRide(ctx context.Context, rideID uuid.UUID) ([]models.Communication, error) {
+	const op = "CommunicationRepo.ListByRide"
+	query := `
+		SELECT id, ride_id, recipient_id, channel, message_type, status, coalesce(detail, ''), created_at
+		FROM ride_communications
+		WHERE ride_id = $1
+		ORDER BY created_at, id`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, rideID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	comms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Communication, error) {
+		var c models.Communication
+		err := row.Scan(&c.ID, &c.RideID, &c.RecipientID, &c.Channel, &c.MessageType, &c.Status, &c.Detail, &c.CreatedAt)
+		return c, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return comms, nil
+}
@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/jackc/pgx/v5"
@@ -14,12 +16,14 @@ import (
 )
 
 type CoordinateRepo struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *keyring.Keyring // шифрует адреса
 }
 
-func NewCoordinateRepo(db *pgxpool.Pool) *CoordinateRepo {
+func NewCoordinateRepo(db *pgxpool.Pool, pii *keyring.Keyring) *CoordinateRepo {
 	return &CoordinateRepo{
-		db: db,
+		db:  db,
+		pii: pii,
 	}
 }
 
@@ -31,8 +35,13 @@ func (r *CoordinateRepo) CreateCoordinate(ctx context.Context, entityID uuid.UUI
 		RETURNING id;
 	`
 
+	address, err := r.pii.Encrypt(location.Address)
+	if err != nil {
+		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
 	var id uuid.UUID
-	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, entityID, entityType, location.Address, location.Latitude, location.Longitude, updatedAt).Scan(&id); err != nil {
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, entityID, entityType, address, location.Latitude, location.Longitude, updatedAt).Scan(&id); err != nil {
 		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
 		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
 	}
@@ -73,3 +82,44 @@ func (r *CoordinateRepo) GetDriverLastCoordinate(ctx context.Context, driverID u
 	}
 	return location, nil
 }
+
+// driverPosition возвращает текущую координату водителя
+func (r *CoordinateRepo) driverPosition(ctx context.Context, driverID uuid.UUID) (*models.DriverPosition, error) {
+	const op = "CoordinateRepo.driverPosition"
+	query := `
+		SELECT latitude, longitude, updated_at
+		FROM coordinates
+		WHERE entity_id = $1 AND entity_type = 'driver' AND is_current = true;`
+
+	pos := &models.DriverPosition{DriverID: driverID}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&pos.Location.Latitude, &pos.Location.Longitude, &pos.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, types.ErrNoCoordinates
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return pos, nil
+}
+
+// LockDriverPosition берёт до конца транзакции блокировку координат водителя, которой триггер
+// coordinates упорядочивает записи сущности, и возвращает текущую координату. nil — координат ещё нет.
+// Координаты, параллельно записываемые другими экземплярами, проверяются по очереди и видят друг друга.
+func (r *CoordinateRepo) LockDriverPosition(ctx context.Context, driverID uuid.UUID) (*models.DriverPosition, error) {
+	const op = "CoordinateRepo.LockDriverPosition"
+
+	db := TxorDB(ctx, r.db)
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('driver:' || $1::text, 0))`, driverID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	pos, err := r.driverPosition(ctx, driverID)
+	if errors.Is(err, types.ErrNoCoordinates) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return pos, nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeviceRepo хранит секреты устройств водителей для подписи координат
+type DeviceRepo struct {
+	db  *pgxpool.Pool
+	pii *keyring.Keyring // шифрует секрет устройства
+}
+
+func NewDeviceRepo(db *pgxpool.Pool, pii *keyring.Keyring) *DeviceRepo {
+	return &DeviceRepo{
+		db:  db,
+		pii: pii,
+	}
+}
+
+// SaveSecret сохраняет секрет устройства. Повторный вход с того же устройства заменяет секрет.
+func (r *DeviceRepo) SaveSecret(ctx context.Context, driverID uuid.UUID, deviceID, secret string) error {
+	const op = "DeviceRepo.SaveSecret"
+
+	enc, err := r.pii.Encrypt(secret)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	query := `
+		INSERT INTO driver_devices(driver_id, device_id, secret)
+		VALUES($1, $2, $3)
+		ON CONFLICT (driver_id, device_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = now(), last_used_at = NULL`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, deviceID, enc); err != nil {
+		if postgres.IsForeignKeyViolation(err) {
+			return types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// GetSecret возвращает секрет устройства и отмечает его использование. Пустая строка — устройство не выдано.
+func (r *DeviceRepo) GetSecret(ctx context.Context, driverID uuid.UUID, deviceID string) (string, error) {
+	const op = "DeviceRepo.GetSecret"
+	query := `
+		UPDATE driver_devices
+		SET last_used_at = now()
+		WHERE driver_id = $1 AND device_id = $2
+		RETURNING secret`
+
+	var secret string
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, deviceID).Scan(&secret); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return "", wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	plain, err := r.pii.Decrypt(secret)
+	if err != nil {
+		return "", wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return plain, nil
+}
+
+// HasDevices сообщает, выдавался ли водителю хотя бы один секрет устройства
+func (r *DeviceRepo) HasDevices(ctx context.Context, driverID uuid.UUID) (bool, error) {
+	const op = "DeviceRepo.HasDevices"
+
+	var exists bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM driver_devices WHERE driver_id = $1)`, driverID,
+	).Scan(&exists); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return exists, nil
+}
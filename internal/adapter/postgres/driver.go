@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -106,6 +107,33 @@ func (r *DriverRepo) ChangeStatus(ctx context.Context, driverID uuid.UUID, newSt
 	return oldStatus, nil
 }
 
+// ReleaseFromRide возвращает водителя, снятого с поездки rideID, в статус AVAILABLE.
+// Статус не меняется, если водитель уже ушёл с линии, свободен или ему назначена другая поездка:
+// запоздавшее снятие с прошлой поездки не освобождает водителя, занятого новой.
+func (r *DriverRepo) ReleaseFromRide(ctx context.Context, driverID, rideID uuid.UUID) (bool, error) {
+	const op = "DriverRepo.ReleaseFromRide"
+	query := `
+		UPDATE drivers
+		SET status = 'AVAILABLE', updated_at = now()
+		WHERE id = $1
+		  AND status NOT IN ('OFFLINE', 'AVAILABLE')
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM rides
+			WHERE driver_id = $1
+			  AND id <> $2
+			  AND status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED', 'IN_PROGRESS')
+		  )`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, rideID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *DriverRepo) UpdateStats(ctx context.Context, driverID uuid.UUID, ridesCompleted int, earnings float64) error {
 	const op = "DriverRepo.UpdateStats"
 	query := `
@@ -137,7 +165,10 @@ func (r *DriverRepo) Get(ctx context.Context, driverID uuid.UUID) (*models.Drive
                total_rides, 
                total_earnings, 
                status, 
-               is_verified
+               is_verified,
+               tier,
+               tier_updated_at,
+               photo_url
         FROM drivers
         WHERE id = $1`
 
@@ -155,6 +186,9 @@ func (r *DriverRepo) Get(ctx context.Context, driverID uuid.UUID) (*models.Drive
 		&driver.TotalEarnings,
 		&driver.Status,
 		&driver.IsVerified,
+		&driver.Tier,
+		&driver.TierUpdatedAt,
+		&driver.PhotoURL,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -166,10 +200,10 @@ func (r *DriverRepo) Get(ctx context.Context, driverID uuid.UUID) (*models.Drive
 	return &driver, nil
 }
 
-func (r *DriverRepo) SearchDrivers(ctx context.Context, rideType string, pickUplocation models.Location) ([]models.DriverWithDistance, error) {
+func (r *DriverRepo) SearchDrivers(ctx context.Context, rideType string, pickUplocation models.Location, radiusKm float64, passengerID uuid.UUID) ([]models.DriverWithDistance, error) {
 	const op = "DriverRepo.SearchDrivers"
 	query := `
-		SELECT d.id, d.rating, c.latitude, c.longitude, d.vehicle_attrs, name,
+		SELECT d.id, d.rating, c.latitude, c.longitude, d.vehicle_attrs, name, d.tier, d.is_simulator,
        		ST_Distance(
          	ST_MakePoint(c.longitude, c.latitude)::geography,
          	ST_MakePoint($1, $2)::geography
@@ -184,19 +218,29 @@ func (r *DriverRepo) SearchDrivers(ctx context.Context, rideType string, pickUpl
   			AND ST_DWithin(
         		ST_MakePoint(c.longitude, c.latitude)::geography,
         		ST_MakePoint($1, $2)::geography,
-        		5000  -- 5km radius
+        		$5 * 1000  -- radius in meters
       		)
-		ORDER BY distance_km, d.rating DESC
+			-- пропускаем водителей, заблокировавших пассажира
+			AND NOT EXISTS (
+				SELECT 1 FROM driver_blocked_passengers b
+				WHERE b.driver_id = d.id AND b.passenger_id = $4
+			)
+		ORDER BY
+			-- PREMIUM поездки сначала предлагаются водителям высшего уровня
+			CASE WHEN $3 = 'PREMIUM' THEN
+				CASE d.tier WHEN 'GOLD' THEN 0 WHEN 'SILVER' THEN 1 ELSE 2 END
+			ELSE 0 END,
+			distance_km, d.rating DESC
 		LIMIT 10;`
 
-	rows, err := TxorDB(ctx, r.db).Query(ctx, query, pickUplocation.Longitude, pickUplocation.Latitude, rideType)
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, pickUplocation.Longitude, pickUplocation.Latitude, rideType, passengerID, radiusKm)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	drivers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverWithDistance, error) {
 		var driver models.DriverWithDistance
-		if err := rows.Scan(&driver.ID, &driver.Rating, &driver.Location.Latitude, &driver.Location.Longitude, &driver.Vehicle, &driver.Name, &driver.DistanceKm); err != nil {
+		if err := rows.Scan(&driver.ID, &driver.Rating, &driver.Location.Latitude, &driver.Location.Longitude, &driver.Vehicle, &driver.Name, &driver.Tier, &driver.Simulator, &driver.DistanceKm); err != nil {
 			return models.DriverWithDistance{}, fmt.Errorf("%s: %w", op, err)
 		}
 
@@ -212,3 +256,234 @@ func (r *DriverRepo) SearchDrivers(ctx context.Context, rideType string, pickUpl
 
 	return drivers, nil
 }
+
+// AvailablePositions возвращает текущие координаты свободных водителей, для перестроения индекса координат
+func (r *DriverRepo) AvailablePositions(ctx context.Context) ([]models.DriverPosition, error) {
+	const op = "DriverRepo.AvailablePositions"
+	query := `
+		SELECT c.entity_id, c.latitude, c.longitude, c.updated_at
+		FROM coordinates c
+		JOIN drivers d ON d.id = c.entity_id
+		WHERE c.entity_type = 'driver' AND c.is_current = true AND d.status = 'AVAILABLE'`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	positions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverPosition, error) {
+		var p models.DriverPosition
+		err := row.Scan(&p.DriverID, &p.Location.Latitude, &p.Location.Longitude, &p.UpdatedAt)
+		return p, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return positions, nil
+}
+
+// AvailableDrivers возвращает свободных водителей класса rideType из driverIDs с текущей координатой.
+// Индекс координат находит водителей рядом, а статус и данные для оффера берутся из БД.
+func (r *DriverRepo) AvailableDrivers(ctx context.Context, rideType string, driverIDs []uuid.UUID) ([]models.DriverWithDistance, error) {
+	const op = "DriverRepo.AvailableDrivers"
+	query := `
+		SELECT d.id, d.rating, c.latitude, c.longitude, d.vehicle_attrs, name, d.tier, d.is_simulator
+		FROM drivers d
+		JOIN users u ON d.id = u.id
+		JOIN coordinates c ON c.entity_id = d.id
+  			AND c.entity_type = 'driver'
+  			AND c.is_current = true
+		WHERE d.id = ANY($1)
+			AND d.status = 'AVAILABLE'
+			AND d.vehicle_type = $2`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverIDs, rideType)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	drivers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverWithDistance, error) {
+		var driver models.DriverWithDistance
+		err := row.Scan(&driver.ID, &driver.Rating, &driver.Location.Latitude, &driver.Location.Longitude, &driver.Vehicle, &driver.Name, &driver.Tier, &driver.Simulator)
+		return driver, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return drivers, nil
+}
+
+// RecordOfferOutcome сохраняет результат оффера, отправленного водителю
+func (r *DriverRepo) RecordOfferOutcome(ctx context.Context, offerID, driverID, rideID uuid.UUID, outcome types.OfferOutcome) error {
+	const op = "DriverRepo.RecordOfferOutcome"
+	query := `
+		INSERT INTO driver_offers(offer_id, driver_id, ride_id, outcome)
+		VALUES($1, $2, $3, $4)`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, offerID, driverID, rideID, outcome); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// DeclineRate возвращает долю отклоненных и пропущенных офферов водителя начиная с since, nil — офферов не было
+func (r *DriverRepo) DeclineRate(ctx context.Context, driverID uuid.UUID, since time.Time) (*float64, error) {
+	const op = "DriverRepo.DeclineRate"
+	query := `
+		SELECT count(*),
+		       count(*) FILTER (WHERE outcome <> 'ACCEPTED')
+		FROM driver_offers
+		WHERE driver_id = $1 AND created_at >= $2`
+
+	var total, declined int
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, since).Scan(&total, &declined); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	rate := float64(declined) / float64(total)
+	return &rate, nil
+}
+
+// RecentDeclines возвращает водителей, отклонивших или пропустивших оффер поездки начиная с since
+func (r *DriverRepo) RecentDeclines(ctx context.Context, rideID uuid.UUID, since time.Time) ([]uuid.UUID, error) {
+	const op = "DriverRepo.RecentDeclines"
+	query := `
+		SELECT DISTINCT driver_id
+		FROM driver_offers
+		WHERE ride_id = $1 AND outcome <> 'ACCEPTED' AND created_at >= $2`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, rideID, since)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return ids, nil
+}
+
+// GetTierMetrics возвращает рейтинг, статистику офферов и отмен по всем водителям начиная с since
+func (r *DriverRepo) GetTierMetrics(ctx context.Context, since time.Time) ([]models.DriverTierMetrics, error) {
+	const op = "DriverRepo.GetTierMetrics"
+	query := `
+		SELECT d.id,
+		       d.tier,
+		       d.rating,
+		       COALESCE(o.total, 0),
+		       COALESCE(o.accepted, 0),
+		       COALESCE(rd.total, 0),
+		       COALESCE(rd.cancelled, 0)
+		FROM drivers d
+		LEFT JOIN (
+			SELECT driver_id,
+			       count(*) AS total,
+			       count(*) FILTER (WHERE outcome = 'ACCEPTED') AS accepted
+			FROM driver_offers
+			WHERE created_at >= $1
+			GROUP BY driver_id
+		) o ON o.driver_id = d.id
+		LEFT JOIN (
+			SELECT driver_id,
+			       count(*) AS total,
+			       count(*) FILTER (WHERE status = 'CANCELLED') AS cancelled
+			FROM rides
+			WHERE driver_id IS NOT NULL AND NOT is_test AND created_at >= $1
+			GROUP BY driver_id
+		) rd ON rd.driver_id = d.id`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, since)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	metrics, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverTierMetrics, error) {
+		var m models.DriverTierMetrics
+		err := row.Scan(&m.DriverID, &m.Tier, &m.Rating, &m.OffersTotal, &m.OffersAccepted, &m.RidesTotal, &m.RidesCancelled)
+		return m, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return metrics, nil
+}
+
+// UpdateTier устанавливает новый уровень водителя
+func (r *DriverRepo) UpdateTier(ctx context.Context, driverID uuid.UUID, tier types.DriverTier) error {
+	const op = "DriverRepo.UpdateTier"
+	query := `
+		UPDATE drivers
+		SET tier = $1, tier_updated_at = now(), updated_at = now()
+		WHERE id = $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, tier, driverID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// GetMonthlyEarnings возвращает число и стоимость завершенных поездок водителя по месяцам года (UTC).
+// Месяцы без поездок не возвращаются.
+func (r *DriverRepo) GetMonthlyEarnings(ctx context.Context, driverID uuid.UUID, year int) ([]models.MonthlyEarnings, error) {
+	const op = "DriverRepo.GetMonthlyEarnings"
+	query := `
+		SELECT extract(month FROM r.completed_at AT TIME ZONE 'UTC')::int AS month,
+		       count(*),
+		       coalesce(sum(coalesce(r.final_fare, r.estimated_fare)), 0),
+		       coalesce(sum(rc.platform_commission + rc.tax), 0)::float
+		FROM rides r
+		LEFT JOIN ride_receipts rc ON rc.ride_id = r.id
+		WHERE r.driver_id = $1
+		  AND r.status = 'COMPLETED'
+		  AND NOT r.is_test
+		  AND r.completed_at >= make_timestamptz($2, 1, 1, 0, 0, 0, 'UTC')
+		  AND r.completed_at < make_timestamptz($2 + 1, 1, 1, 0, 0, 0, 'UTC')
+		GROUP BY month
+		ORDER BY month`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, year)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	months, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.MonthlyEarnings, error) {
+		var m models.MonthlyEarnings
+		err := row.Scan(&m.Month, &m.Rides, &m.Gross, &m.Commission)
+		return m, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return months, nil
+}
+
+// CreditBalance зачисляет заработок водителя на баланс для мгновенного вывода
+func (r *DriverRepo) CreditBalance(ctx context.Context, driverID uuid.UUID, rideID *uuid.UUID, entryType types.DriverLedgerEntryType, amount float64) error {
+	const op = "DriverRepo.CreditBalance"
+	if err := creditDriverBalance(ctx, TxorDB(ctx, r.db), driverID, rideID, nil, entryType, amount); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const driverChangeColumns = `id, driver_id, field, old_value, new_value, status, reason, created_at, reviewed_at, reviewed_by`
+
+func scanDriverChange(row pgx.Row) (models.DriverChangeRequest, error) {
+	var c models.DriverChangeRequest
+	err := row.Scan(
+		&c.ID,
+		&c.DriverID,
+		&c.Field,
+		&c.OldValue,
+		&c.NewValue,
+		&c.Status,
+		&c.Reason,
+		&c.CreatedAt,
+		&c.ReviewedAt,
+		&c.ReviewedBy,
+	)
+	return c, err
+}
+
+// UpdateName обновляет имя водителя. Автомобиль в профиле меняется только через driver_vehicles
+// (SetVehicle после сохранения активного автомобиля), чтобы профиль не расходился с ним.
+func (r *DriverRepo) UpdateName(ctx context.Context, driverID uuid.UUID, name string) error {
+	const op = "DriverRepo.UpdateName"
+	query := `
+		UPDATE drivers
+		SET name = $2, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, name)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SubmitChange ставит изменение в очередь на одобрение, заменяя ожидающее изменение того же поля.
+// Заполняет ID, Status и CreatedAt.
+func (r *DriverRepo) SubmitChange(ctx context.Context, change *models.DriverChangeRequest) error {
+	const op = "DriverRepo.SubmitChange"
+	query := `
+		INSERT INTO driver_change_requests(driver_id, field, old_value, new_value)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (driver_id, field) WHERE status = 'PENDING'
+		DO UPDATE SET old_value = EXCLUDED.old_value,
+			new_value = EXCLUDED.new_value,
+			created_at = now()
+		RETURNING id, status, created_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		change.DriverID,
+		change.Field,
+		change.OldValue,
+		change.NewValue,
+	).Scan(&change.ID, &change.Status, &change.CreatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// PendingChanges возвращает изменения водителя, ожидающие одобрения
+func (r *DriverRepo) PendingChanges(ctx context.Context, driverID uuid.UUID) ([]models.DriverChangeRequest, error) {
+	const op = "DriverRepo.PendingChanges"
+	query := `
+		SELECT ` + driverChangeColumns + `
+		FROM driver_change_requests
+		WHERE driver_id = $1 AND status = 'PENDING'
+		ORDER BY created_at`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverChangeRequest, error) {
+		return scanDriverChange(row)
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return changes, nil
+}
+
+// GetPendingDriverChanges возвращает очередь изменений водителей на одобрение, старые первыми
+func (r *AdminRepo) GetPendingDriverChanges(ctx context.Context) ([]models.DriverChangeRequest, error) {
+	const op = "AdminRepo.GetPendingDriverChanges"
+	query := `
+		SELECT ` + driverChangeColumns + `
+		FROM driver_change_requests
+		WHERE status = 'PENDING'
+		ORDER BY created_at`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverChangeRequest, error) {
+		return scanDriverChange(row)
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return changes, nil
+}
+
+// ReviewDriverChange фиксирует решение по ожидающему изменению.
+// Уже рассмотренное или несуществующее изменение возвращает ErrChangeRequestNotFound.
+func (r *AdminRepo) ReviewDriverChange(ctx context.Context, changeID, adminID uuid.UUID, status types.DriverChangeStatus, reason *string) (*models.DriverChangeRequest, error) {
+	const op = "AdminRepo.ReviewDriverChange"
+	query := `
+		UPDATE driver_change_requests
+		SET status = $2, reason = $3, reviewed_at = now(), reviewed_by = $4
+		WHERE id = $1 AND status = 'PENDING'
+		RETURNING ` + driverChangeColumns
+
+	change, err := scanDriverChange(TxorDB(ctx, r.db).QueryRow(ctx, query, changeID, status, reason, adminID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, types.ErrChangeRequestNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &change, nil
+}
+
+// SetDriverPhoto меняет фото водителя в карточке для пассажира
+func (r *AdminRepo) SetDriverPhoto(ctx context.Context, driverID uuid.UUID, photoURL string) error {
+	const op = "AdminRepo.SetDriverPhoto"
+	query := `
+		UPDATE drivers
+		SET photo_url = $2, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, photoURL)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetDriverLicense меняет номер водительского удостоверения
+func (r *AdminRepo) SetDriverLicense(ctx context.Context, driverID uuid.UUID, license string) error {
+	const op = "AdminRepo.SetDriverLicense"
+	query := `
+		UPDATE drivers
+		SET license_number = $2, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, license)
+	if err != nil {
+		if postgres.IsUniqueViolation(err) {
+			return types.ErrLicenseAlreadyExists
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DriverConnectionRepo хранит подключения водителей по WebSocket и сроки снятия назначения
+// после разрыва, общие для всех экземпляров driver-service
+type DriverConnectionRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewDriverConnectionRepo(db *pgxpool.Pool) *DriverConnectionRepo {
+	return &DriverConnectionRepo{db: db}
+}
+
+// Connected отмечает подключение водителя и отменяет отложенное снятие назначения.
+// Возвращает время подключения по часам базы.
+func (r *DriverConnectionRepo) Connected(ctx context.Context, driverID uuid.UUID) (time.Time, error) {
+	const op = "DriverConnectionRepo.Connected"
+	query := `
+		INSERT INTO driver_connections(driver_id, connected_at)
+		VALUES($1, clock_timestamp())
+		ON CONFLICT (driver_id) DO UPDATE
+		SET connected_at = EXCLUDED.connected_at, release_at = NULL
+		RETURNING connected_at`
+
+	var connectedAt time.Time
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&connectedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return time.Time{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return connectedAt, nil
+}
+
+// Disconnected назначает снятие назначения через grace после закрытия последнего соединения
+// экземпляра, открытого в connectedAt. Если водитель позже подключился к другому экземпляру,
+// срок не назначается.
+func (r *DriverConnectionRepo) Disconnected(ctx context.Context, driverID uuid.UUID, connectedAt time.Time, grace time.Duration) error {
+	const op = "DriverConnectionRepo.Disconnected"
+	query := `
+		UPDATE driver_connections
+		SET release_at = clock_timestamp() + make_interval(secs => $3)
+		WHERE driver_id = $1 AND connected_at <= $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, connectedAt, grace.Seconds()); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// ClaimReleases забирает водителей, не переподключившихся до срока. Срок сбрасывается,
+// поэтому каждого водителя снимает только один экземпляр.
+func (r *DriverConnectionRepo) ClaimReleases(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	const op = "DriverConnectionRepo.ClaimReleases"
+	query := `
+		UPDATE driver_connections
+		SET release_at = NULL
+		WHERE driver_id IN (
+			SELECT driver_id
+			FROM driver_connections
+			WHERE release_at <= clock_timestamp()
+			ORDER BY release_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING driver_id`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return ids, nil
+}
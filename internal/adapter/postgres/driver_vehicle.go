@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const driverVehicleColumns = `id, driver_id, vehicle_type, coalesce(body_type, ''), make, model, color, plate, year, is_active, created_at, updated_at`
+
+func scanDriverVehicle(row pgx.Row) (models.DriverVehicle, error) {
+	var v models.DriverVehicle
+	err := row.Scan(&v.ID, &v.DriverID, &v.Class, &v.BodyType, &v.Make, &v.Model, &v.Color, &v.Plate, &v.Year, &v.IsActive, &v.CreatedAt, &v.UpdatedAt)
+	return v, err
+}
+
+// Vehicles возвращает автомобили водителя, активный первым
+func (r *DriverRepo) Vehicles(ctx context.Context, driverID uuid.UUID) ([]models.DriverVehicle, error) {
+	const op = "DriverRepo.Vehicles"
+	query := `
+		SELECT ` + driverVehicleColumns + `
+		FROM driver_vehicles
+		WHERE driver_id = $1
+		ORDER BY is_active DESC, created_at`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	vehicles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverVehicle, error) {
+		return scanDriverVehicle(row)
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return vehicles, nil
+}
+
+// GetVehicle возвращает автомобиль водителя, ErrVehicleNotFound — у водителя нет такого автомобиля
+func (r *DriverRepo) GetVehicle(ctx context.Context, driverID, vehicleID uuid.UUID) (*models.DriverVehicle, error) {
+	const op = "DriverRepo.GetVehicle"
+	query := `
+		SELECT ` + driverVehicleColumns + `
+		FROM driver_vehicles
+		WHERE driver_id = $1 AND id = $2`
+
+	v, err := scanDriverVehicle(TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, vehicleID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrVehicleNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &v, nil
+}
+
+// ActiveVehicle возвращает активный автомобиль водителя, ErrVehicleNotFound — активного нет
+func (r *DriverRepo) ActiveVehicle(ctx context.Context, driverID uuid.UUID) (*models.DriverVehicle, error) {
+	const op = "DriverRepo.ActiveVehicle"
+	query := `
+		SELECT ` + driverVehicleColumns + `
+		FROM driver_vehicles
+		WHERE driver_id = $1 AND is_active`
+
+	v, err := scanDriverVehicle(TxorDB(ctx, r.db).QueryRow(ctx, query, driverID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrVehicleNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &v, nil
+}
+
+// CreateVehicle добавляет автомобиль водителю. Заполняет ID, CreatedAt и UpdatedAt.
+// Активным автомобиль делает ActivateVehicle.
+func (r *DriverRepo) CreateVehicle(ctx context.Context, v *models.DriverVehicle) error {
+	const op = "DriverRepo.CreateVehicle"
+	query := `
+		INSERT INTO driver_vehicles(driver_id, vehicle_type, body_type, make, model, color, plate, year)
+		VALUES ($1, $2, nullif($3, ''), $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		v.DriverID, v.Class, v.BodyType, v.Make, v.Model, v.Color, v.Plate, v.Year,
+	).Scan(&v.ID, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		if postgres.IsUniqueViolation(err) {
+			return types.ErrVehicleExists
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// UpdateVehicle заменяет атрибуты и класс автомобиля. Заполняет UpdatedAt.
+func (r *DriverRepo) UpdateVehicle(ctx context.Context, v *models.DriverVehicle) error {
+	const op = "DriverRepo.UpdateVehicle"
+	query := `
+		UPDATE driver_vehicles
+		SET vehicle_type = $3, body_type = nullif($4, ''), make = $5, model = $6, color = $7, plate = $8, year = $9,
+		    updated_at = now()
+		WHERE driver_id = $1 AND id = $2
+		RETURNING updated_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		v.DriverID, v.ID, v.Class, v.BodyType, v.Make, v.Model, v.Color, v.Plate, v.Year,
+	).Scan(&v.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.ErrVehicleNotFound
+		}
+		if postgres.IsUniqueViolation(err) {
+			return types.ErrVehicleExists
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// ActivateVehicle делает автомобиль активным, предыдущий активный перестает быть активным.
+// Профиль водителя (drivers.vehicle_type, vehicle_attrs) обновляет SetVehicle.
+func (r *DriverRepo) ActivateVehicle(ctx context.Context, driverID, vehicleID uuid.UUID) error {
+	const op = "DriverRepo.ActivateVehicle"
+	q := TxorDB(ctx, r.db)
+
+	// снимаем отметку отдельным запросом: уникальный индекс активного автомобиля проверяется построчно
+	if _, err := q.Exec(ctx, `
+		UPDATE driver_vehicles SET is_active = false, updated_at = now()
+		WHERE driver_id = $1 AND is_active AND id <> $2`, driverID, vehicleID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	tag, err := q.Exec(ctx, `
+		UPDATE driver_vehicles SET is_active = true, updated_at = now()
+		WHERE driver_id = $1 AND id = $2`, driverID, vehicleID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrVehicleNotFound
+	}
+
+	return nil
+}
+
+// SetVehicle записывает активный автомобиль в профиль водителя, по которому идет подбор
+func (r *DriverRepo) SetVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.Vehicle) error {
+	const op = "DriverRepo.SetVehicle"
+	query := `
+		UPDATE drivers
+		SET vehicle_type = $2, vehicle_attrs = $3, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, vehicle.Type, vehicle)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
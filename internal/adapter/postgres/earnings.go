@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// earningsUnits — единица date_trunc для периода отчета
+var earningsUnits = map[types.EarningsPeriod]string{
+	types.EarningsDaily:   "day",
+	types.EarningsWeekly:  "week",
+	types.EarningsMonthly: "month",
+}
+
+// GetEarnings возвращает заработок водителя за последние buckets периодов (UTC), включая текущий.
+// Заработок и часы онлайн берутся из смен по времени их начала, завершенные поездки — из rides.
+// Периоды без смен и поездок возвращаются с нулями.
+func (r *DriverRepo) GetEarnings(ctx context.Context, driverID uuid.UUID, period types.EarningsPeriod, buckets int) ([]models.EarningsBucket, error) {
+	const op = "DriverRepo.GetEarnings"
+
+	unit, ok := earningsUnits[period]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported earnings period %q", op, period)
+	}
+
+	query := `
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc($2::text, now() AT TIME ZONE 'UTC') - ($3::int - 1) * ('1 ' || $2::text)::interval,
+				date_trunc($2::text, now() AT TIME ZONE 'UTC'),
+				('1 ' || $2::text)::interval
+			) AS bucket
+		),
+		sessions AS (
+			SELECT date_trunc($2::text, started_at AT TIME ZONE 'UTC') AS bucket,
+			       sum(coalesce(total_earnings, 0)) AS earnings,
+			       sum(extract(epoch FROM coalesce(ended_at, now()) - started_at)) / 3600 AS online_hours
+			FROM driver_sessions
+			WHERE driver_id = $1
+			  AND started_at >= (SELECT min(bucket) FROM buckets) AT TIME ZONE 'UTC'
+			GROUP BY 1
+		),
+		completed AS (
+			SELECT date_trunc($2::text, completed_at AT TIME ZONE 'UTC') AS bucket,
+			       count(*) AS rides
+			FROM rides
+			WHERE driver_id = $1
+			  AND status = 'COMPLETED'
+			  AND NOT is_test
+			  AND completed_at >= (SELECT min(bucket) FROM buckets) AT TIME ZONE 'UTC'
+			GROUP BY 1
+		)
+		SELECT b.bucket AT TIME ZONE 'UTC',
+		       coalesce(s.earnings, 0)::float8,
+		       coalesce(c.rides, 0)::int,
+		       coalesce(s.online_hours, 0)::float8
+		FROM buckets b
+		LEFT JOIN sessions s ON s.bucket = b.bucket
+		LEFT JOIN completed c ON c.bucket = b.bucket
+		ORDER BY b.bucket`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, unit, buckets)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	result, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.EarningsBucket, error) {
+		var b models.EarningsBucket
+		err := row.Scan(&b.PeriodStart, &b.Earnings, &b.CompletedRides, &b.OnlineHours)
+		return b, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// GetStateCounts считает строки каждого раздела снимка. Внутри транзакции снимка
+// now() — время начала транзакции, оно и возвращается как время снимка.
+func (r *AdminRepo) GetStateCounts(ctx context.Context) (models.StateCounts, time.Time, error) {
+	const op = "AdminRepo.GetStateCounts"
+	query := `
+		SELECT
+			(SELECT count(*) FROM rides WHERE status IN ('REQUESTED','MATCHED','EN_ROUTE','ARRIVED','IN_PROGRESS')),
+			(SELECT count(*) FROM drivers WHERE status <> 'OFFLINE'),
+			(SELECT count(*) FROM driver_sessions WHERE ended_at IS NULL),
+			now()`
+
+	var (
+		counts  models.StateCounts
+		takenAt time.Time
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query).Scan(
+		&counts.ActiveRides,
+		&counts.OnlineDrivers,
+		&counts.OpenSessions,
+		&takenAt,
+	); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return counts, takenAt, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return counts, takenAt, nil
+}
+
+// GetSnapshotRides возвращает до limit активных поездок, старые первыми
+func (r *AdminRepo) GetSnapshotRides(ctx context.Context, limit int) ([]models.SnapshotRide, error) {
+	const op = "AdminRepo.GetSnapshotRides"
+	query := `
+		SELECT r.id, r.ride_number, r.status, r.passenger_id, r.driver_id,
+		       coalesce(r.vehicle_type, ''),
+		       pc.latitude::float, pc.longitude::float, dc.latitude::float, dc.longitude::float,
+		       coalesce(r.estimated_fare, 0)::float, coalesce(r.priority, 1), r.is_test,
+		       r.requested_at, r.matched_at, r.arrived_at, r.started_at
+		FROM rides r
+		LEFT JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
+		LEFT JOIN coordinates dc ON dc.id = r.destination_coordinate_id
+		WHERE r.status IN ('REQUESTED','MATCHED','EN_ROUTE','ARRIVED','IN_PROGRESS')
+		ORDER BY r.created_at, r.id
+		LIMIT $1`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	rides, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SnapshotRide, error) {
+		var (
+			ride             models.SnapshotRide
+			pickLat, pickLng *float64
+			destLat, destLng *float64
+		)
+		err := row.Scan(
+			&ride.ID,
+			&ride.RideNumber,
+			&ride.Status,
+			&ride.PassengerID,
+			&ride.DriverID,
+			&ride.VehicleType,
+			&pickLat, &pickLng,
+			&destLat, &destLng,
+			&ride.EstimatedFare,
+			&ride.Priority,
+			&ride.IsTest,
+			&ride.RequestedAt,
+			&ride.MatchedAt,
+			&ride.ArrivedAt,
+			&ride.StartedAt,
+		)
+		ride.Pickup = snapshotLocation(pickLat, pickLng)
+		ride.Destination = snapshotLocation(destLat, destLng)
+		return ride, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return rides, nil
+}
+
+// GetSnapshotDrivers возвращает до limit водителей не в статусе OFFLINE с текущей позицией
+func (r *AdminRepo) GetSnapshotDrivers(ctx context.Context, limit int) ([]models.SnapshotDriver, error) {
+	const op = "AdminRepo.GetSnapshotDrivers"
+	query := `
+		SELECT d.id, d.status, coalesce(d.vehicle_type, ''), d.is_simulator,
+		       c.latitude::float, c.longitude::float, c.updated_at
+		FROM drivers d
+		LEFT JOIN coordinates c ON c.entity_id = d.id AND c.entity_type = 'driver' AND c.is_current
+		WHERE d.status <> 'OFFLINE'
+		ORDER BY d.id
+		LIMIT $1`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	drivers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SnapshotDriver, error) {
+		var (
+			driver   models.SnapshotDriver
+			lat, lng *float64
+		)
+		err := row.Scan(
+			&driver.ID,
+			&driver.Status,
+			&driver.VehicleType,
+			&driver.IsSimulator,
+			&lat, &lng,
+			&driver.LocationUpdatedAt,
+		)
+		driver.Location = snapshotLocation(lat, lng)
+		return driver, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return drivers, nil
+}
+
+// GetSnapshotSessions возвращает до limit незакрытых смен водителей, старые первыми
+func (r *AdminRepo) GetSnapshotSessions(ctx context.Context, limit int) ([]models.SnapshotSession, error) {
+	const op = "AdminRepo.GetSnapshotSessions"
+	query := `
+		SELECT id, driver_id, started_at, coalesce(total_rides, 0), coalesce(total_earnings, 0)::float
+		FROM driver_sessions
+		WHERE ended_at IS NULL
+		ORDER BY started_at, id
+		LIMIT $1`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SnapshotSession, error) {
+		var s models.SnapshotSession
+		err := row.Scan(&s.ID, &s.DriverID, &s.StartedAt, &s.TotalRides, &s.TotalEarnings)
+		return s, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return sessions, nil
+}
+
+// CreateStateExport сохраняет фоновую выгрузку в статусе PENDING, заполняя ID и CreatedAt
+func (r *AdminRepo) CreateStateExport(ctx context.Context, e *models.StateExport) error {
+	const op = "AdminRepo.CreateStateExport"
+	query := `
+		INSERT INTO state_exports(status, requested_by)
+		VALUES($1, $2)
+		RETURNING id, created_at`
+
+	e.Status = types.StateExportPending
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, e.Status, e.RequestedBy).Scan(&e.ID, &e.CreatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// CompleteStateExport записывает готовый снимок или ошибку выгрузки
+func (r *AdminRepo) CompleteStateExport(ctx context.Context, id uuid.UUID, snapshot *models.StateSnapshot, errMsg *string) error {
+	const op = "AdminRepo.CompleteStateExport"
+	query := `
+		UPDATE state_exports
+		SET status = $2, item_count = $3, truncated = $4, snapshot = $5, error = $6, completed_at = now()
+		WHERE id = $1`
+
+	status := types.StateExportFailed
+	itemCount, truncated := 0, false
+	var data []byte
+	if snapshot != nil {
+		var err error
+		if data, err = json.Marshal(snapshot); err != nil {
+			return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		status = types.StateExportReady
+		itemCount = len(snapshot.ActiveRides) + len(snapshot.OnlineDrivers) + len(snapshot.OpenSessions)
+		truncated = snapshot.Truncated
+	}
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, id, status, itemCount, truncated, data, errMsg)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrStateExportNotFound
+	}
+
+	return nil
+}
+
+// GetStateExport возвращает выгрузку вместе со снимком, если он готов
+func (r *AdminRepo) GetStateExport(ctx context.Context, id uuid.UUID) (*models.StateExport, error) {
+	const op = "AdminRepo.GetStateExport"
+	query := `
+		SELECT id, status, requested_by, item_count, truncated, snapshot, error, created_at, completed_at
+		FROM state_exports
+		WHERE id = $1`
+
+	var (
+		e        models.StateExport
+		snapshot []byte
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&e.ID,
+		&e.Status,
+		&e.RequestedBy,
+		&e.ItemCount,
+		&e.Truncated,
+		&snapshot,
+		&e.Error,
+		&e.CreatedAt,
+		&e.CompletedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrStateExportNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if len(snapshot) > 0 {
+		e.Snapshot = &models.StateSnapshot{}
+		if err := json.Unmarshal(snapshot, e.Snapshot); err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+	}
+
+	return &e, nil
+}
+
+func snapshotLocation(lat, lng *float64) *models.Location {
+	if lat == nil || lng == nil {
+		return nil
+	}
+	return &models.Location{Latitude: *lat, Longitude: *lng}
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FailedMessageRepo хранит сообщения, собранные из dead letter очереди брокера
+type FailedMessageRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewFailedMessageRepo(db *pgxpool.Pool) *FailedMessageRepo {
+	return &FailedMessageRepo{
+		db: db,
+	}
+}
+
+const failedMessageSelect = `
+	SELECT id, queue, exchange, routing_key, reason, death_count, correlation_id, content_type, producer,
+	       body, failed_at, replay_count, replayed_at, created_at
+	FROM failed_messages`
+
+func scanFailedMessage(row pgx.Row) (*models.FailedMessage, error) {
+	var m models.FailedMessage
+	err := row.Scan(
+		&m.ID, &m.Queue, &m.Exchange, &m.RoutingKey, &m.Reason, &m.DeathCount, &m.CorrelationID, &m.ContentType, &m.Producer,
+		&m.Body, &m.FailedAt, &m.ReplayCount, &m.ReplayedAt, &m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.SetPayload()
+	return &m, nil
+}
+
+// Create сохраняет сообщение из dead letter очереди
+func (r *FailedMessageRepo) Create(ctx context.Context, m *models.FailedMessage) error {
+	const op = "FailedMessageRepo.Create"
+	query := `
+		INSERT INTO failed_messages(queue, exchange, routing_key, reason, death_count, correlation_id, content_type, producer, body, failed_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		m.Queue, m.Exchange, m.RoutingKey, m.Reason, m.DeathCount, m.CorrelationID, m.ContentType, m.Producer, m.Body, m.FailedAt,
+	).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// List возвращает сообщения по фильтру, новые первыми
+func (r *FailedMessageRepo) List(ctx context.Context, f models.FailedMessageFilter) ([]models.FailedMessage, error) {
+	const op = "FailedMessageRepo.List"
+	query := failedMessageSelect + `
+		WHERE ($1 = '' OR queue = $1)
+		  AND ($2::boolean IS NULL OR (replayed_at IS NOT NULL) = $2)
+		ORDER BY failed_at DESC
+		LIMIT $3`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, f.Queue, f.Replayed, f.Limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.FailedMessage, error) {
+		m, err := scanFailedMessage(row)
+		if err != nil {
+			return models.FailedMessage{}, err
+		}
+		return *m, nil
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return messages, nil
+}
+
+// Get возвращает сообщение по id
+func (r *FailedMessageRepo) Get(ctx context.Context, id uuid.UUID) (*models.FailedMessage, error) {
+	const op = "FailedMessageRepo.Get"
+
+	m, err := scanFailedMessage(TxorDB(ctx, r.db).QueryRow(ctx, failedMessageSelect+` WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrFailedMessageNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return m, nil
+}
+
+// MarkReplayed отмечает повторную отправку сообщения
+func (r *FailedMessageRepo) MarkReplayed(ctx context.Context, id uuid.UUID) error {
+	const op = "FailedMessageRepo.MarkReplayed"
+	query := `
+		UPDATE failed_messages
+		SET replay_count = replay_count + 1, replayed_at = now()
+		WHERE id = $1`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, id); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+type FlatRateRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewFlatRateRepo(db *pgxpool.Pool) *FlatRateRepo {
+	return &FlatRateRepo{
+		db: db,
+	}
+}
+
+const flatRateSelect = `
+	SELECT id, code, name, rate_type, coalesce(vehicle_type, ''),
+	       origin_latitude::float, origin_longitude::float, origin_radius_km::float,
+	       destination_latitude::float, destination_longitude::float, destination_radius_km::float,
+	       bidirectional, fare::float, effective_from, effective_to, created_by, created_at
+	FROM flat_rates`
+
+func scanFlatRate(row pgx.Row) (*models.FlatRate, error) {
+	var (
+		r                              models.FlatRate
+		destLat, destLon, destRadiusKm *float64
+	)
+	err := row.Scan(
+		&r.ID, &r.Code, &r.Name, &r.RateType, &r.VehicleType,
+		&r.Origin.Center.Latitude, &r.Origin.Center.Longitude, &r.Origin.RadiusKm,
+		&destLat, &destLon, &destRadiusKm,
+		&r.Bidirectional, &r.Fare, &r.EffectiveFrom, &r.EffectiveTo, &r.CreatedBy, &r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if destLat != nil && destLon != nil && destRadiusKm != nil {
+		r.Destination = &models.FareZone{
+			Center:   models.Location{Latitude: *destLat, Longitude: *destLon},
+			RadiusKm: *destRadiusKm,
+		}
+	}
+	return &r, nil
+}
+
+func (r *FlatRateRepo) list(ctx context.Context, op, query string, args ...any) ([]models.FlatRate, error) {
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	rates, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.FlatRate, error) {
+		rate, err := scanFlatRate(row)
+		if err != nil {
+			return models.FlatRate{}, err
+		}
+		return *rate, nil
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return rates, nil
+}
+
+// Active возвращает версии, которые действуют в момент at или начнут действовать позже
+func (r *FlatRateRepo) Active(ctx context.Context, at time.Time) ([]models.FlatRate, error) {
+	return r.list(ctx, "FlatRateRepo.Active", flatRateSelect+`
+		WHERE effective_to IS NULL OR effective_to > $1
+		ORDER BY code, effective_from`, at)
+}
+
+// History возвращает все версии тарифа, новые первыми
+func (r *FlatRateRepo) History(ctx context.Context, code string) ([]models.FlatRate, error) {
+	return r.list(ctx, "FlatRateRepo.History", flatRateSelect+`
+		WHERE code = $1
+		ORDER BY effective_from DESC`, code)
+}
+
+// Lock блокирует изменения версий тарифа до конца транзакции, в том числе еще не созданного
+func (r *FlatRateRepo) Lock(ctx context.Context, code string) error {
+	const op = "FlatRateRepo.Lock"
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('flat_rates:' || $1))`, code); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
+
+// Create сохраняет новую версию тарифа
+func (r *FlatRateRepo) Create(ctx context.Context, rate *models.FlatRate) error {
+	const op = "FlatRateRepo.Create"
+	query := `
+		INSERT INTO flat_rates(code, name, rate_type, vehicle_type,
+			origin_latitude, origin_longitude, origin_radius_km,
+			destination_latitude, destination_longitude, destination_radius_km,
+			bidirectional, fare, effective_from, created_by)
+		VALUES($1, $2, $3, nullif($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at`
+
+	var destLat, destLon, destRadiusKm *float64
+	if rate.Destination != nil {
+		destLat, destLon, destRadiusKm = &rate.Destination.Center.Latitude, &rate.Destination.Center.Longitude, &rate.Destination.RadiusKm
+	}
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		rate.Code, rate.Name, rate.RateType, rate.VehicleType,
+		rate.Origin.Center.Latitude, rate.Origin.Center.Longitude, rate.Origin.RadiusKm,
+		destLat, destLon, destRadiusKm,
+		rate.Bidirectional, rate.Fare, rate.EffectiveFrom, rate.CreatedBy,
+	).Scan(&rate.ID, &rate.CreatedAt)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// End завершает тариф в момент at: действующая версия закрывается, запланированные удаляются.
+// Возвращает число затронутых версий.
+func (r *FlatRateRepo) End(ctx context.Context, code string, at time.Time) (int64, error) {
+	const op = "FlatRateRepo.End"
+	query := `
+		WITH scheduled AS (
+			DELETE FROM flat_rates
+			WHERE code = $1 AND effective_from >= $2
+			RETURNING id
+		), closed AS (
+			UPDATE flat_rates
+			SET effective_to = $2
+			WHERE code = $1 AND effective_from < $2 AND (effective_to IS NULL OR effective_to > $2)
+			RETURNING id
+		)
+		SELECT (SELECT count(*) FROM scheduled) + (SELECT count(*) FROM closed)`
+
+	var affected int64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, code, at).Scan(&affected); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return affected, nil
+}
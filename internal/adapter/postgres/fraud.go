@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetSharedDeviceEdges returns pairs of drivers registered on the same device
+func (r *AdminRepo) GetSharedDeviceEdges(ctx context.Context) ([]models.FraudEdge, error) {
+	const op = "AdminRepo.GetSharedDeviceEdges"
+	query := `
+		SELECT a.driver_id, b.driver_id, COUNT(*)
+		FROM driver_devices a
+		JOIN driver_devices b ON b.device_id = a.device_id AND a.driver_id < b.driver_id
+		GROUP BY a.driver_id, b.driver_id`
+
+	return r.collectFraudEdges(ctx, op, types.FraudSharedDevice, types.RoleDriver, types.RoleDriver, query)
+}
+
+// GetRepeatedPairEdges returns driver-passenger pairs with at least minRides completed rides since the given time
+func (r *AdminRepo) GetRepeatedPairEdges(ctx context.Context, since time.Time, minRides int) ([]models.FraudEdge, error) {
+	const op = "AdminRepo.GetRepeatedPairEdges"
+	query := `
+		SELECT driver_id, passenger_id, COUNT(*)
+		FROM rides
+		WHERE status = 'COMPLETED'
+			AND completed_at >= $1
+			AND driver_id IS NOT NULL
+			AND NOT is_test
+		GROUP BY driver_id, passenger_id
+		HAVING COUNT(*) >= $2`
+
+	return r.collectFraudEdges(ctx, op, types.FraudRepeatedPair, types.RoleDriver, types.RolePassenger, query, since, minRides)
+}
+
+// GetCoLocatedEdges returns driver-passenger pairs where, at least minRides times since the given time,
+// the driver's last known position at request time was within radiusM of the pickup point
+func (r *AdminRepo) GetCoLocatedEdges(ctx context.Context, since time.Time, radiusM float64, minRides int) ([]models.FraudEdge, error) {
+	const op = "AdminRepo.GetCoLocatedEdges"
+	query := `
+		SELECT r.driver_id, r.passenger_id, COUNT(*)
+		FROM rides r
+		JOIN coordinates p ON p.id = r.pickup_coordinate_id
+		JOIN LATERAL (
+			SELECT c.latitude, c.longitude
+			FROM coordinates c
+			WHERE c.entity_id = r.driver_id
+				AND c.entity_type = 'driver'
+				AND c.created_at <= r.requested_at
+			ORDER BY c.created_at DESC
+			LIMIT 1
+		) d ON true
+		WHERE r.requested_at >= $1
+			AND r.driver_id IS NOT NULL
+			AND NOT r.is_test
+			AND ST_DWithin(
+				ST_MakePoint(d.longitude, d.latitude)::geography,
+				ST_MakePoint(p.longitude, p.latitude)::geography,
+				$2
+			)
+		GROUP BY r.driver_id, r.passenger_id
+		HAVING COUNT(*) >= $3`
+
+	return r.collectFraudEdges(ctx, op, types.FraudCoLocated, types.RoleDriver, types.RolePassenger, query, since, radiusM, minRides)
+}
+
+func (r *AdminRepo) collectFraudEdges(ctx context.Context, op string, kind types.FraudSignal, sourceRole, targetRole types.UserRole, query string, args ...any) ([]models.FraudEdge, error) {
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	edges, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.FraudEdge, error) {
+		e := models.FraudEdge{Kind: kind, SourceRole: sourceRole, TargetRole: targetRole}
+		err := row.Scan(&e.Source, &e.Target, &e.Weight)
+		return e, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return edges, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationPreferenceRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationPreferenceRepo(db *pgxpool.Pool) *NotificationPreferenceRepo {
+	return &NotificationPreferenceRepo{
+		db: db,
+	}
+}
+
+// Get возвращает сохраненные настройки пользователя или nil, если их нет
+func (r *NotificationPreferenceRepo) Get(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	const op = "NotificationPreferenceRepo.Get"
+	query := `
+		SELECT user_id, channels, event_types, voice_readout, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1`
+
+	var (
+		prefs                = &models.NotificationPreferences{}
+		channels, eventTypes []string
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, userID).Scan(
+		&prefs.UserID,
+		&channels,
+		&eventTypes,
+		&prefs.VoiceReadout,
+		&prefs.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	for _, c := range channels {
+		prefs.Channels = append(prefs.Channels, types.NotificationChannel(c))
+	}
+	for _, e := range eventTypes {
+		prefs.EventTypes = append(prefs.EventTypes, types.NotificationEvent(e))
+	}
+
+	return prefs, nil
+}
+
+// Upsert сохраняет настройки пользователя целиком
+func (r *NotificationPreferenceRepo) Upsert(ctx context.Context, prefs *models.NotificationPreferences) error {
+	const op = "NotificationPreferenceRepo.Upsert"
+	query := `
+		INSERT INTO notification_preferences(user_id, channels, event_types, voice_readout)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET channels = EXCLUDED.channels,
+			event_types = EXCLUDED.event_types,
+			voice_readout = EXCLUDED.voice_readout,
+			updated_at = now()
+		RETURNING updated_at`
+
+	channels := make([]string, 0, len(prefs.Channels))
+	for _, c := range prefs.Channels {
+		channels = append(channels, c.String())
+	}
+	eventTypes := make([]string, 0, len(prefs.EventTypes))
+	for _, e := range prefs.EventTypes {
+		eventTypes = append(eventTypes, e.String())
+	}
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		prefs.UserID,
+		channels,
+		eventTypes,
+		prefs.VoiceReadout,
+	).Scan(&prefs.UpdatedAt); err != nil {
+		if postgres.IsForeignKeyViolation(err) {
+			return types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OfflineActionRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOfflineActionRepo(db *pgxpool.Pool) *OfflineActionRepo {
+	return &OfflineActionRepo{
+		db: db,
+	}
+}
+
+// Get возвращает сохраненный результат действия или nil, если действие еще не сверялось
+func (r *OfflineActionRepo) Get(ctx context.Context, driverID uuid.UUID, actionID string) (*models.ReconcileResult, error) {
+	const op = "OfflineActionRepo.Get"
+	query := `
+		SELECT action_id, action_type, status, coalesce(message, ''), performed_at, processed_at
+		FROM driver_offline_actions
+		WHERE driver_id = $1 AND action_id = $2`
+
+	res := &models.ReconcileResult{}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, actionID).Scan(
+		&res.ActionID,
+		&res.Type,
+		&res.Status,
+		&res.Message,
+		&res.PerformedAt,
+		&res.ProcessedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return res, nil
+}
+
+// Save сохраняет результат сверки. Повторное сохранение того же action_id игнорируется.
+func (r *OfflineActionRepo) Save(ctx context.Context, driverID uuid.UUID, action models.OfflineAction, res models.ReconcileResult) error {
+	const op = "OfflineActionRepo.Save"
+	query := `
+		INSERT INTO driver_offline_actions(driver_id, action_id, action_type, performed_at, ride_id, status, message, processed_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (driver_id, action_id) DO NOTHING`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query,
+		driverID,
+		action.ID,
+		action.Type,
+		action.PerformedAt,
+		action.RideID,
+		res.Status,
+		sql.NullString{String: res.Message, Valid: res.Message != ""},
+		res.ProcessedAt,
+	); err != nil {
+		if postgres.IsForeignKeyViolation(err) {
+			return types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// LastPerformedAt возвращает время последнего действия, которое изменило или подтвердило состояние сервера
+func (r *OfflineActionRepo) LastPerformedAt(ctx context.Context, driverID uuid.UUID) (*time.Time, error) {
+	const op = "OfflineActionRepo.LastPerformedAt"
+	query := `
+		SELECT max(performed_at)
+		FROM driver_offline_actions
+		WHERE driver_id = $1 AND status IN ('APPLIED', 'SKIPPED')`
+
+	var last *time.Time
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&last); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return last, nil
+}
+
+// StatusChangedAt возвращает время последней смены статуса водителя
+// и время обработки последнего примененного офлайн действия (nil — не было)
+func (r *OfflineActionRepo) StatusChangedAt(ctx context.Context, driverID uuid.UUID) (time.Time, *time.Time, error) {
+	const op = "OfflineActionRepo.StatusChangedAt"
+	query := `
+		SELECT d.status_changed_at, (
+			SELECT max(a.processed_at)
+			FROM driver_offline_actions a
+			WHERE a.driver_id = d.id AND a.status = 'APPLIED'
+		)
+		FROM drivers d
+		WHERE d.id = $1`
+
+	var (
+		changedAt   time.Time
+		lastApplied *time.Time
+	)
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&changedAt, &lastApplied); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil, types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return time.Time{}, nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return changedAt, lastApplied, nil
+}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type OpsRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOpsRepo(db *pgxpool.Pool) *OpsRepo {
+	return &OpsRepo{
+		db: db,
+	}
+}
+
+const opsActionColumns = `id, action, params, status, target_service, target_instance, result, error, requested_by, created_at, completed_at`
+
+func scanOpsAction(row pgx.Row) (models.OpsAction, error) {
+	var a models.OpsAction
+	err := row.Scan(
+		&a.ID,
+		&a.Action,
+		&a.Params,
+		&a.Status,
+		&a.TargetService,
+		&a.TargetInstance,
+		&a.Result,
+		&a.Error,
+		&a.RequestedBy,
+		&a.CreatedAt,
+		&a.CompletedAt,
+	)
+	return a, err
+}
+
+// Create сохраняет действие в журнал, заполняя ID и CreatedAt
+func (r *OpsRepo) Create(ctx context.Context, a *models.OpsAction) error {
+	const op = "OpsRepo.Create"
+	query := `
+		INSERT INTO ops_actions(action, params, status, target_service, target_instance, result, error, requested_by, completed_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		a.Action,
+		a.Params,
+		a.Status,
+		a.TargetService,
+		a.TargetInstance,
+		a.Result,
+		a.Error,
+		a.RequestedBy,
+		a.CompletedAt,
+	).Scan(&a.ID, &a.CreatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+func (r *OpsRepo) Get(ctx context.Context, id uuid.UUID) (*models.OpsAction, error) {
+	const op = "OpsRepo.Get"
+	query := `SELECT ` + opsActionColumns + ` FROM ops_actions WHERE id = $1`
+
+	a, err := scanOpsAction(TxorDB(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrOpsActionNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &a, nil
+}
+
+// List возвращает последние действия, новые первыми
+func (r *OpsRepo) List(ctx context.Context, limit int) ([]models.OpsAction, error) {
+	const op = "OpsRepo.List"
+	query := `SELECT ` + opsActionColumns + ` FROM ops_actions ORDER BY created_at DESC LIMIT $1`
+
+	return r.collect(ctx, op, query, limit)
+}
+
+// Pending возвращает невыполненные действия экземпляра сервиса, старые первыми
+func (r *OpsRepo) Pending(ctx context.Context, service, instance string) ([]models.OpsAction, error) {
+	const op = "OpsRepo.Pending"
+	query := `
+		SELECT ` + opsActionColumns + `
+		FROM ops_actions
+		WHERE status = 'PENDING' AND target_service = $1 AND target_instance = $2
+		ORDER BY created_at`
+
+	return r.collect(ctx, op, query, service, instance)
+}
+
+// Complete фиксирует результат выполнения действия
+func (r *OpsRepo) Complete(ctx context.Context, id uuid.UUID, status types.OpsActionStatus, result []byte, errMsg *string) error {
+	const op = "OpsRepo.Complete"
+	query := `
+		UPDATE ops_actions
+		SET status = $2, result = $3, error = $4, completed_at = now()
+		WHERE id = $1 AND status = 'PENDING'`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, id, status, result, errMsg); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+func (r *OpsRepo) collect(ctx context.Context, op, query string, args ...any) ([]models.OpsAction, error) {
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	actions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OpsAction, error) {
+		return scanOpsAction(row)
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return actions, nil
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// OutboxRepo хранит сообщения брокера до их публикации
+type OutboxRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepo(db *pgxpool.Pool) *OutboxRepo {
+	return &OutboxRepo{
+		db: db,
+	}
+}
+
+const outboxSelect = `
+	SELECT id, idempotency_key, topic, payload, attempts, held_until, created_at
+	FROM outbox`
+
+func scanOutbox(row pgx.Row) (*models.OutboxMessage, error) {
+	var m models.OutboxMessage
+	if err := row.Scan(&m.ID, &m.IdempotencyKey, &m.Topic, &m.Payload, &m.Attempts, &m.HeldUntil, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Add записывает сообщение. false — сообщение с таким ключом уже записано, m.ID не заполняется.
+func (r *OutboxRepo) Add(ctx context.Context, m *models.OutboxMessage) (bool, error) {
+	const op = "OutboxRepo.Add"
+	query := `
+		INSERT INTO outbox(idempotency_key, topic, payload)
+		VALUES($1, $2, $3)
+		ON CONFLICT (idempotency_key) DO NOTHING
+		RETURNING id, created_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query, m.IdempotencyKey, m.Topic, m.Payload).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return true, nil
+}
+
+// GetPendingForUpdate возвращает неопубликованное сообщение и блокирует его до конца транзакции.
+// nil — сообщение уже опубликовано или его сейчас публикует другой экземпляр.
+func (r *OutboxRepo) GetPendingForUpdate(ctx context.Context, id uuid.UUID) (*models.OutboxMessage, error) {
+	const op = "OutboxRepo.GetPendingForUpdate"
+	query := outboxSelect + `
+		WHERE id = $1 AND published_at IS NULL AND discarded_at IS NULL
+		FOR UPDATE SKIP LOCKED`
+
+	m, err := scanOutbox(TxorDB(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return m, nil
+}
+
+// ListPendingForUpdate возвращает самые старые сообщения, готовые к отправке, и блокирует их.
+// Строки, заблокированные другим экземпляром, пропускаются.
+func (r *OutboxRepo) ListPendingForUpdate(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	const op = "OutboxRepo.ListPendingForUpdate"
+	query := outboxSelect + `
+		WHERE published_at IS NULL AND discarded_at IS NULL AND next_attempt_at <= now()
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.OutboxMessage, error) {
+		return scanOutbox(row)
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return messages, nil
+}
+
+// MarkPublished отмечает успешную публикацию
+func (r *OutboxRepo) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	const op = "OutboxRepo.MarkPublished"
+	query := `
+		UPDATE outbox
+		SET published_at = now(), attempts = attempts + 1, last_error = NULL
+		WHERE id = $1`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, id); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// MarkFailed записывает ошибку публикации и откладывает следующую попытку
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id uuid.UUID, reason string, retryAfter time.Duration) error {
+	const op = "OutboxRepo.MarkFailed"
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + make_interval(secs => $3)
+		WHERE id = $1`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, id, reason, retryAfter.Seconds()); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Hold откладывает публикацию до until, не считая попытку: поиск водителя в зоне приостановлен
+func (r *OutboxRepo) Hold(ctx context.Context, id uuid.UUID, until time.Time) error {
+	const op = "OutboxRepo.Hold"
+	query := `
+		UPDATE outbox
+		SET held_until = $2, next_attempt_at = $2
+		WHERE id = $1`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, id, until); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Discard снимает сообщение с отправки, например запрос поиска водителя для уже отмененной поездки
+func (r *OutboxRepo) Discard(ctx context.Context, id uuid.UUID, reason string) error {
+	const op = "OutboxRepo.Discard"
+	query := `
+		UPDATE outbox
+		SET discarded_at = now(), last_error = $2
+		WHERE id = $1`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, id, reason); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
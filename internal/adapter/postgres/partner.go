@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PartnerRepo хранит таксопарки partner API и принадлежность водителей
+type PartnerRepo struct {
+	db  *pgxpool.Pool
+	pii *keyring.Keyring // шифрует секрет вебхука
+}
+
+func NewPartnerRepo(db *pgxpool.Pool, pii *keyring.Keyring) *PartnerRepo {
+	return &PartnerRepo{
+		db:  db,
+		pii: pii,
+	}
+}
+
+// Create сохраняет партнёра с хешем API ключа
+func (r *PartnerRepo) Create(ctx context.Context, p *models.Partner, apiKeyHash string) error {
+	const op = "PartnerRepo.Create"
+
+	secret, err := r.pii.Encrypt(p.WebhookSecret)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	query := `
+		INSERT INTO partners(name, api_key_hash, webhook_url, webhook_secret)
+		VALUES($1, $2, $3, $4)
+		RETURNING id, is_active, created_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, p.Name, apiKeyHash, p.WebhookURL, secret).Scan(&p.ID, &p.IsActive, &p.CreatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// GetByAPIKey возвращает активного партнёра по хешу API ключа
+func (r *PartnerRepo) GetByAPIKey(ctx context.Context, apiKeyHash string) (*models.Partner, error) {
+	const op = "PartnerRepo.GetByAPIKey"
+	query := `
+		SELECT id, name, webhook_url, webhook_secret, is_active, created_at
+		FROM partners
+		WHERE api_key_hash = $1 AND is_active`
+
+	p, err := r.scanPartner(TxorDB(ctx, r.db).QueryRow(ctx, query, apiKeyHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrInvalidAPIKey
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// GetByDriver возвращает активного партнёра водителя, nil — водитель не из таксопарка
+func (r *PartnerRepo) GetByDriver(ctx context.Context, driverID uuid.UUID) (*models.Partner, error) {
+	const op = "PartnerRepo.GetByDriver"
+	query := `
+		SELECT p.id, p.name, p.webhook_url, p.webhook_secret, p.is_active, p.created_at
+		FROM drivers d
+		JOIN partners p ON p.id = d.partner_id
+		WHERE d.id = $1 AND p.is_active`
+
+	p, err := r.scanPartner(TxorDB(ctx, r.db).QueryRow(ctx, query, driverID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// AttachDriver закрепляет водителя за партнёром
+func (r *PartnerRepo) AttachDriver(ctx context.Context, partnerID, driverID uuid.UUID) error {
+	const op = "PartnerRepo.AttachDriver"
+	query := `
+		UPDATE drivers SET partner_id = $1
+		WHERE id = $2`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, partnerID, driverID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrDriverIDNotExist
+	}
+
+	return nil
+}
+
+// OwnsDriver проверяет, что водитель принадлежит партнёру
+func (r *PartnerRepo) OwnsDriver(ctx context.Context, partnerID, driverID uuid.UUID) (bool, error) {
+	const op = "PartnerRepo.OwnsDriver"
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM drivers
+			WHERE id = $1 AND partner_id = $2
+		)`
+
+	var owns bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, partnerID).Scan(&owns); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return owns, nil
+}
+
+func (r *PartnerRepo) scanPartner(row pgx.Row) (*models.Partner, error) {
+	var p models.Partner
+	if err := row.Scan(&p.ID, &p.Name, &p.WebhookURL, &p.WebhookSecret, &p.IsActive, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := decryptStrings(r.pii, &p.WebhookSecret); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PartnerDispatchRepo хранит офферы, отправленные на вебхук партнёра, и отслеживание поездок
+// водителей таксопарков: ответ и координаты партнёра приходят на любой экземпляр driver-service
+type PartnerDispatchRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPartnerDispatchRepo(db *pgxpool.Pool) *PartnerDispatchRepo {
+	return &PartnerDispatchRepo{db: db}
+}
+
+// CreateOffer сохраняет оффер, ожидающий ответа партнёра до expiresAt
+func (r *PartnerDispatchRepo) CreateOffer(ctx context.Context, offer models.PartnerOffer) error {
+	const op = "PartnerDispatchRepo.CreateOffer"
+	query := `
+		INSERT INTO partner_offers(offer_id, partner_id, driver_id, ride_id, expires_at)
+		VALUES($1, $2, $3, $4, $5)`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, offer.OfferID, offer.PartnerID, offer.DriverID, offer.RideID, offer.ExpiresAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// RespondOffer записывает ответ партнёра. false — оффер не найден, чужой, истёк или уже отвечен.
+func (r *PartnerDispatchRepo) RespondOffer(ctx context.Context, partnerID, offerID uuid.UUID, accepted bool) (bool, error) {
+	const op = "PartnerDispatchRepo.RespondOffer"
+	query := `
+		UPDATE partner_offers
+		SET accepted = $3, responded_at = now()
+		WHERE offer_id = $1
+		  AND partner_id = $2
+		  AND accepted IS NULL
+		  AND expires_at > now()`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, offerID, partnerID, accepted)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// OfferResponse возвращает ответ партнёра на оффер, nil — ответа ещё нет
+func (r *PartnerDispatchRepo) OfferResponse(ctx context.Context, offerID uuid.UUID) (*bool, error) {
+	const op = "PartnerDispatchRepo.OfferResponse"
+	query := `
+		SELECT accepted
+		FROM partner_offers
+		WHERE offer_id = $1`
+
+	var accepted *bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, offerID).Scan(&accepted); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return accepted, nil
+}
+
+// DeleteOffer удаляет оффер после ответа или истечения
+func (r *PartnerDispatchRepo) DeleteOffer(ctx context.Context, offerID uuid.UUID) error {
+	const op = "PartnerDispatchRepo.DeleteOffer"
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, `DELETE FROM partner_offers WHERE offer_id = $1`, offerID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// StartTracking отмечает поездку водителя отслеживаемой до expiresAt. Координаты прошлой поездки отбрасываются.
+func (r *PartnerDispatchRepo) StartTracking(ctx context.Context, driverID, rideID uuid.UUID, expiresAt time.Time) error {
+	const op = "PartnerDispatchRepo.StartTracking"
+	query := `
+		WITH stale AS (
+			DELETE FROM partner_track_updates
+			WHERE driver_id = $1 AND ride_id <> $2
+		)
+		INSERT INTO partner_ride_tracking(driver_id, ride_id, expires_at)
+		VALUES($1, $2, $3)
+		ON CONFLICT (driver_id) DO UPDATE
+		SET ride_id = EXCLUDED.ride_id, expires_at = EXCLUDED.expires_at, created_at = now()`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, rideID, expiresAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// StopTracking снимает отслеживание поездки и удаляет её невзятые координаты
+func (r *PartnerDispatchRepo) StopTracking(ctx context.Context, driverID, rideID uuid.UUID) error {
+	const op = "PartnerDispatchRepo.StopTracking"
+	query := `
+		WITH stopped AS (
+			DELETE FROM partner_ride_tracking
+			WHERE driver_id = $1 AND ride_id = $2
+		)
+		DELETE FROM partner_track_updates
+		WHERE driver_id = $1 AND ride_id = $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, rideID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// AddTrackUpdate ставит координаты в очередь отслеживаемой поездки водителя.
+// false — поездка водителя сейчас не отслеживается.
+func (r *PartnerDispatchRepo) AddTrackUpdate(ctx context.Context, update models.RideLocationUpdate) (bool, error) {
+	const op = "PartnerDispatchRepo.AddTrackUpdate"
+
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	query := `
+		INSERT INTO partner_track_updates(driver_id, ride_id, payload)
+		SELECT driver_id, ride_id, $2
+		FROM partner_ride_tracking
+		WHERE driver_id = $1 AND expires_at > now()`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, update.DriverID, payload)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// TakeTrackUpdates забирает координаты поездки из очереди в порядке поступления
+func (r *PartnerDispatchRepo) TakeTrackUpdates(ctx context.Context, driverID, rideID uuid.UUID) ([]models.RideLocationUpdate, error) {
+	const op = "PartnerDispatchRepo.TakeTrackUpdates"
+	query := `
+		WITH taken AS (
+			DELETE FROM partner_track_updates
+			WHERE driver_id = $1 AND ride_id = $2
+			RETURNING id, payload
+		)
+		SELECT payload FROM taken ORDER BY id`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, rideID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer rows.Close()
+
+	var updates []models.RideLocationUpdate
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+
+		var update models.RideLocationUpdate
+		if err := json.Unmarshal(payload, &update); err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		updates = append(updates, update)
+	}
+	if err := rows.Err(); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return updates, nil
+}
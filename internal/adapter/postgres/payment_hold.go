@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// CreateHold записывает блокировку оплаты поездки
+func (r *WalletRepo) CreateHold(ctx context.Context, hold *models.PaymentHold) error {
+	const op = "WalletRepo.CreateHold"
+	query := `
+		INSERT INTO ride_payment_holds(ride_id, passenger_id, payment_method, amount, status, authorization_id, error)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		hold.RideID, hold.PassengerID, hold.PaymentMethod, hold.Amount, hold.Status, hold.AuthorizationID, hold.Error,
+	).Scan(&hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// GetHold возвращает блокировку оплаты поездки или nil, если ее не было
+func (r *WalletRepo) GetHold(ctx context.Context, rideID uuid.UUID) (*models.PaymentHold, error) {
+	const op = "WalletRepo.GetHold"
+	query := `
+		SELECT ride_id, passenger_id, payment_method, amount::float, status, authorization_id,
+		       captured_amount::float, transaction_id, error, created_at, updated_at
+		FROM ride_payment_holds
+		WHERE ride_id = $1`
+
+	var h models.PaymentHold
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query, rideID).Scan(
+		&h.RideID, &h.PassengerID, &h.PaymentMethod, &h.Amount, &h.Status, &h.AuthorizationID,
+		&h.CapturedAmount, &h.TransactionID, &h.Error, &h.CreatedAt, &h.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &h, nil
+}
+
+// SettleHold закрывает открытую (AUTHORIZED) блокировку: списание, снятие или ошибка провайдера.
+// Уже закрытая блокировка не меняется.
+func (r *WalletRepo) SettleHold(ctx context.Context, rideID uuid.UUID, status types.PaymentHoldStatus, capturedAmount *float64, transactionID, errMsg *string) error {
+	const op = "WalletRepo.SettleHold"
+	query := `
+		UPDATE ride_payment_holds
+		SET status = $2, captured_amount = $3, transaction_id = $4, error = $5, updated_at = now()
+		WHERE ride_id = $1 AND status = 'AUTHORIZED'`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID, status, capturedAmount, transactionID, errMsg); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
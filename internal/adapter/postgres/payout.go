@@ -0,0 +1,309 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// PayoutRepo хранит баланс водителей, его журнал и мгновенные выводы
+type PayoutRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPayoutRepo(db *pgxpool.Pool) *PayoutRepo {
+	return &PayoutRepo{
+		db: db,
+	}
+}
+
+const payoutColumns = `id, driver_id, amount::float, fee::float, net_amount::float, status, provider_payout_id, failure_reason, created_at, updated_at`
+
+func scanPayout(row pgx.Row) (*models.Payout, error) {
+	var p models.Payout
+	err := row.Scan(&p.ID, &p.DriverID, &p.Amount, &p.Fee, &p.NetAmount, &p.Status, &p.ProviderPayoutID, &p.FailureReason, &p.CreatedAt, &p.UpdatedAt)
+	return &p, err
+}
+
+// creditDriverBalance зачисляет amount на баланс водителя и пишет запись журнала одним запросом.
+// Баланс создается при первом зачислении.
+func creditDriverBalance(ctx context.Context, q Querier, driverID uuid.UUID, rideID, payoutID *uuid.UUID, entryType types.DriverLedgerEntryType, amount float64) error {
+	query := `
+		WITH b AS (
+			INSERT INTO driver_balances(driver_id, available)
+			VALUES($1, $2)
+			ON CONFLICT (driver_id)
+			DO UPDATE SET available = driver_balances.available + EXCLUDED.available, updated_at = now()
+			RETURNING available
+		)
+		INSERT INTO driver_ledger(driver_id, ride_id, payout_id, entry_type, amount, balance_after)
+		SELECT $1, $3, $4, $5, $2, available FROM b`
+
+	_, err := q.Exec(ctx, query, driverID, amount, rideID, payoutID, entryType)
+	return err
+}
+
+// Balance возвращает баланс водителя, у водителя без зачислений — нулевой
+func (r *PayoutRepo) Balance(ctx context.Context, driverID uuid.UUID) (*models.DriverBalance, error) {
+	const op = "PayoutRepo.Balance"
+	query := `
+		SELECT available::float, updated_at
+		FROM driver_balances
+		WHERE driver_id = $1`
+
+	b := &models.DriverBalance{DriverID: driverID}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&b.Available, &b.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return b, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return b, nil
+}
+
+// LockBalance блокирует баланс водителя до конца транзакции и возвращает доступную сумму
+func (r *PayoutRepo) LockBalance(ctx context.Context, driverID uuid.UUID) (float64, error) {
+	const op = "PayoutRepo.LockBalance"
+	query := `
+		SELECT available::float
+		FROM driver_balances
+		WHERE driver_id = $1
+		FOR UPDATE`
+
+	var available float64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&available); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return available, nil
+}
+
+// Entries возвращает последние записи журнала баланса, новые первыми
+func (r *PayoutRepo) Entries(ctx context.Context, driverID uuid.UUID, limit int) ([]models.DriverLedgerEntry, error) {
+	const op = "PayoutRepo.Entries"
+	query := `
+		SELECT id, driver_id, ride_id, payout_id, entry_type, amount::float, balance_after::float, created_at
+		FROM driver_ledger
+		WHERE driver_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverLedgerEntry, error) {
+		var e models.DriverLedgerEntry
+		err := row.Scan(&e.ID, &e.DriverID, &e.RideID, &e.PayoutID, &e.Type, &e.Amount, &e.BalanceAfter, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return entries, nil
+}
+
+// Credit зачисляет amount на баланс водителя
+func (r *PayoutRepo) Credit(ctx context.Context, driverID uuid.UUID, rideID, payoutID *uuid.UUID, entryType types.DriverLedgerEntryType, amount float64) error {
+	const op = "PayoutRepo.Credit"
+	if err := creditDriverBalance(ctx, TxorDB(ctx, r.db), driverID, rideID, payoutID, entryType, amount); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
+
+// Debit списывает вывод с баланса. false — баланса не хватает, баланс не меняется.
+func (r *PayoutRepo) Debit(ctx context.Context, driverID, payoutID uuid.UUID, amount float64) (bool, error) {
+	const op = "PayoutRepo.Debit"
+	query := `
+		WITH b AS (
+			UPDATE driver_balances
+			SET available = available - $2, updated_at = now()
+			WHERE driver_id = $1 AND available >= $2
+			RETURNING available
+		)
+		INSERT INTO driver_ledger(driver_id, payout_id, entry_type, amount, balance_after)
+		SELECT $1, $3, $4, $2, available FROM b`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, driverID, amount, payoutID, types.DriverLedgerPayout)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// Eligibility возвращает данные водителя для антифрод-проверок вывода
+func (r *PayoutRepo) Eligibility(ctx context.Context, driverID uuid.UUID) (*models.PayoutEligibility, error) {
+	const op = "PayoutRepo.Eligibility"
+	query := `
+		SELECT d.is_verified, d.created_at,
+		       (SELECT count(*) FROM rides r WHERE r.driver_id = d.id AND r.status = 'COMPLETED')
+		FROM drivers d
+		WHERE d.id = $1`
+
+	var e models.PayoutEligibility
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&e.Verified, &e.RegisteredAt, &e.CompletedRides); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrDriverIDNotExist
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &e, nil
+}
+
+// Usage возвращает выводы водителя с since, кроме неудавшихся, и есть ли незавершенный вывод
+func (r *PayoutRepo) Usage(ctx context.Context, driverID uuid.UUID, since time.Time) (models.PayoutUsage, error) {
+	const op = "PayoutRepo.Usage"
+	query := `
+		SELECT count(*) FILTER (WHERE created_at >= $2 AND status <> 'FAILED'),
+		       coalesce(sum(amount) FILTER (WHERE created_at >= $2 AND status <> 'FAILED'), 0)::float,
+		       bool_or(status IN ('PENDING', 'PROCESSING')) IS TRUE
+		FROM driver_payouts
+		WHERE driver_id = $1`
+
+	var u models.PayoutUsage
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, since).Scan(&u.Count, &u.Amount, &u.InFlight); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return models.PayoutUsage{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return u, nil
+}
+
+// Create сохраняет вывод
+func (r *PayoutRepo) Create(ctx context.Context, p *models.Payout) error {
+	const op = "PayoutRepo.Create"
+	query := `
+		INSERT INTO driver_payouts(id, driver_id, amount, fee, net_amount, status)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, p.ID, p.DriverID, p.Amount, p.Fee, p.NetAmount, p.Status).Scan(&p.CreatedAt, &p.UpdatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Get возвращает вывод по ID
+func (r *PayoutRepo) Get(ctx context.Context, payoutID uuid.UUID) (*models.Payout, error) {
+	const op = "PayoutRepo.Get"
+	query := `SELECT ` + payoutColumns + ` FROM driver_payouts WHERE id = $1`
+
+	p, err := scanPayout(TxorDB(ctx, r.db).QueryRow(ctx, query, payoutID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrPayoutNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// List возвращает последние выводы водителя, новые первыми
+func (r *PayoutRepo) List(ctx context.Context, driverID uuid.UUID, limit int) ([]models.Payout, error) {
+	const op = "PayoutRepo.List"
+	query := `SELECT ` + payoutColumns + ` FROM driver_payouts WHERE driver_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	return r.collect(ctx, op, query, driverID, limit)
+}
+
+// Stale возвращает незавершенные выводы в статусе status, не менявшиеся с before, старые первыми
+func (r *PayoutRepo) Stale(ctx context.Context, status types.PayoutStatus, before time.Time, limit int) ([]models.Payout, error) {
+	const op = "PayoutRepo.Stale"
+	query := `SELECT ` + payoutColumns + ` FROM driver_payouts WHERE status = $1 AND updated_at < $2 ORDER BY updated_at LIMIT $3`
+
+	return r.collect(ctx, op, query, status, before, limit)
+}
+
+func (r *PayoutRepo) collect(ctx context.Context, op, query string, args ...any) ([]models.Payout, error) {
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	payouts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Payout, error) {
+		p, err := scanPayout(row)
+		if err != nil {
+			return models.Payout{}, err
+		}
+		return *p, nil
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return payouts, nil
+}
+
+// Transition переводит незавершенный вывод в status. Повторный PROCESSING только обновляет updated_at,
+// чтобы сверка со статусом провайдера не повторялась на каждом проходе.
+// nil — вывод уже завершен, статус не меняется.
+func (r *PayoutRepo) Transition(ctx context.Context, payoutID uuid.UUID, status types.PayoutStatus, providerPayoutID, failureReason *string) (*models.Payout, error) {
+	const op = "PayoutRepo.Transition"
+	query := `
+		UPDATE driver_payouts
+		SET status = $2,
+		    provider_payout_id = coalesce($3, provider_payout_id),
+		    failure_reason = $4,
+		    updated_at = now()
+		WHERE id = $1 AND status IN ('PENDING', 'PROCESSING')
+		RETURNING ` + payoutColumns
+
+	p, err := scanPayout(TxorDB(ctx, r.db).QueryRow(ctx, query, payoutID, status, providerPayoutID, failureReason))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// SaveWebhookEvent сохраняет событие вебхука провайдера. false — событие уже обработано.
+func (r *PayoutRepo) SaveWebhookEvent(ctx context.Context, eventID string, payoutID uuid.UUID, status types.PayoutStatus, payload []byte) (bool, error) {
+	const op = "PayoutRepo.SaveWebhookEvent"
+	query := `
+		INSERT INTO payout_webhook_events(event_id, payout_id, status, payload)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (event_id) DO NOTHING`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, eventID, payoutID, status, payload)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// PhoneCodeRepo хранит коды подтверждения телефона, у пользователя действует только последний код
+type PhoneCodeRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPhoneCodeRepo(db *pgxpool.Pool) *PhoneCodeRepo {
+	return &PhoneCodeRepo{
+		db: db,
+	}
+}
+
+// Save заменяет код пользователя новым, счетчик попыток обнуляется
+func (r *PhoneCodeRepo) Save(ctx context.Context, c *models.PhoneCode) error {
+	const op = "PhoneCodeRepo.Save"
+	query := `
+		INSERT INTO phone_verification_codes(user_id, code_hash, expires_at)
+		VALUES($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at, attempts = 0, created_at = now()
+		RETURNING attempts, created_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, c.UserID, c.CodeHash, c.ExpiresAt).Scan(&c.Attempts, &c.CreatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Get возвращает код пользователя или nil, если код не запрашивался
+func (r *PhoneCodeRepo) Get(ctx context.Context, userID uuid.UUID) (*models.PhoneCode, error) {
+	const op = "PhoneCodeRepo.Get"
+	query := `
+		SELECT user_id, code_hash, expires_at, attempts, created_at
+		FROM phone_verification_codes
+		WHERE user_id = $1`
+
+	return r.scan(ctx, op, query, userID)
+}
+
+// Attempt учитывает попытку ввода кода и возвращает код с обновленным счетчиком, nil — код не запрашивался.
+// Попытка сохраняется сразу, чтобы перебор не обходил лимит откатом транзакции.
+func (r *PhoneCodeRepo) Attempt(ctx context.Context, userID uuid.UUID) (*models.PhoneCode, error) {
+	const op = "PhoneCodeRepo.Attempt"
+	query := `
+		UPDATE phone_verification_codes
+		SET attempts = attempts + 1
+		WHERE user_id = $1
+		RETURNING user_id, code_hash, expires_at, attempts, created_at`
+
+	return r.scan(ctx, op, query, userID)
+}
+
+// Delete удаляет код пользователя
+func (r *PhoneCodeRepo) Delete(ctx context.Context, userID uuid.UUID) error {
+	const op = "PhoneCodeRepo.Delete"
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, `DELETE FROM phone_verification_codes WHERE user_id = $1`, userID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+func (r *PhoneCodeRepo) scan(ctx context.Context, op, query string, userID uuid.UUID) (*models.PhoneCode, error) {
+	c := &models.PhoneCode{}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, userID).Scan(&c.UserID, &c.CodeHash, &c.ExpiresAt, &c.Attempts, &c.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return c, nil
+}
@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// piiAttrs — ключи users.attrs, которые хранятся зашифрованными
+var piiAttrs = []string{"phone"}
+
+// encryptAttrs возвращает копию attrs с зашифрованными PII значениями
+func encryptAttrs(k *keyring.Keyring, attrs map[string]any) (map[string]any, error) {
+	if attrs == nil || !k.Enabled() {
+		return attrs, nil
+	}
+
+	out := make(map[string]any, len(attrs))
+	for key, v := range attrs {
+		out[key] = v
+	}
+
+	for _, key := range piiAttrs {
+		s, ok := out[key].(string)
+		if !ok {
+			continue
+		}
+		enc, err := k.Encrypt(s)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt attrs.%s: %w", key, err)
+		}
+		out[key] = enc
+	}
+
+	return out, nil
+}
+
+// decryptAttrs расшифровывает PII значения attrs на месте
+func decryptAttrs(k *keyring.Keyring, attrs map[string]any) error {
+	for _, key := range piiAttrs {
+		s, ok := attrs[key].(string)
+		if !ok {
+			continue
+		}
+		dec, err := k.Decrypt(s)
+		if err != nil {
+			return fmt.Errorf("decrypt attrs.%s: %w", key, err)
+		}
+		attrs[key] = dec
+	}
+	return nil
+}
+
+// decryptStrings расшифровывает значения на месте, nil указатели пропускаются
+func decryptStrings(k *keyring.Keyring, values ...*string) error {
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		dec, err := k.Decrypt(*v)
+		if err != nil {
+			return err
+		}
+		*v = dec
+	}
+	return nil
+}
+
+// PIIRepo перешифровывает PII значения активным ключом keyring
+type PIIRepo struct {
+	db  *pgxpool.Pool
+	pii *keyring.Keyring
+}
+
+func NewPIIRepo(db *pgxpool.Pool, pii *keyring.Keyring) *PIIRepo {
+	return &PIIRepo{
+		db:  db,
+		pii: pii,
+	}
+}
+
+// RotateAddresses перешифровывает адреса координат с id больше after.
+// Возвращает число обновленных строк и последний просмотренный id для следующей пачки.
+func (r *PIIRepo) RotateAddresses(ctx context.Context, after uuid.UUID, limit int) (int, uuid.UUID, error) {
+	const op = "PIIRepo.RotateAddresses"
+	db := TxorDB(ctx, r.db)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, address
+		FROM coordinates
+		WHERE id > $1 AND address NOT LIKE $2
+		ORDER BY id
+		LIMIT $3`, after, r.pii.ActivePrefix()+"%", limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, after, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	type row struct {
+		ID      uuid.UUID
+		Address string
+	}
+	batch, err := pgx.CollectRows(rows, pgx.RowToStructByPos[row])
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, after, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	updated := 0
+	for _, c := range batch {
+		after = c.ID
+		if !r.pii.NeedsRotation(c.Address) {
+			continue
+		}
+
+		enc, err := r.reencrypt(c.Address)
+		if err != nil {
+			return updated, after, fmt.Errorf("%s: coordinate %s: %w", op, c.ID, err)
+		}
+
+		if _, err := db.Exec(ctx, `UPDATE coordinates SET address = $2 WHERE id = $1`, c.ID, enc); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+			return updated, after, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		updated++
+	}
+
+	return updated, after, nil
+}
+
+// RotateUserAttrs перешифровывает PII ключи users.attrs у пользователей с id больше after
+func (r *PIIRepo) RotateUserAttrs(ctx context.Context, after uuid.UUID, limit int) (int, uuid.UUID, error) {
+	const op = "PIIRepo.RotateUserAttrs"
+	db := TxorDB(ctx, r.db)
+
+	rows, err := db.Query(ctx, `
+		SELECT id, attrs
+		FROM users
+		WHERE id > $1 AND attrs ?| $2
+		ORDER BY id
+		LIMIT $3`, after, piiAttrs, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, after, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	type row struct {
+		ID    uuid.UUID
+		Attrs map[string]any
+	}
+	batch, err := pgx.CollectRows(rows, pgx.RowToStructByPos[row])
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, after, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	updated := 0
+	for _, u := range batch {
+		after = u.ID
+
+		changed := false
+		for _, key := range piiAttrs {
+			s, ok := u.Attrs[key].(string)
+			if !ok || !r.pii.NeedsRotation(s) {
+				continue
+			}
+			enc, err := r.reencrypt(s)
+			if err != nil {
+				return updated, after, fmt.Errorf("%s: user %s attrs.%s: %w", op, u.ID, key, err)
+			}
+			u.Attrs[key] = enc
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		attrsJSON, err := json.Marshal(u.Attrs)
+		if err != nil {
+			return updated, after, fmt.Errorf("%s: user %s: %w", op, u.ID, err)
+		}
+		if _, err := db.Exec(ctx, `UPDATE users SET attrs = $2::jsonb WHERE id = $1`, u.ID, string(attrsJSON)); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+			return updated, after, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		updated++
+	}
+
+	return updated, after, nil
+}
+
+// reencrypt расшифровывает значение старым ключом (или берет открытый текст) и шифрует активным
+func (r *PIIRepo) reencrypt(value string) (string, error) {
+	plain, err := r.pii.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return r.pii.Encrypt(plain)
+}
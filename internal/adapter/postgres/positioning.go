@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PositioningRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPositioningRepo(db *pgxpool.Pool) *PositioningRepo {
+	return &PositioningRepo{
+		db: db,
+	}
+}
+
+// RecentPickups возвращает точки посадки поездок, запрошенных начиная с since (без тестовых)
+func (r *PositioningRepo) RecentPickups(ctx context.Context, since time.Time) ([]models.Location, error) {
+	const op = "PositioningRepo.RecentPickups"
+	query := `
+		SELECT c.latitude, c.longitude
+		FROM rides r
+		JOIN coordinates c ON c.id = r.pickup_coordinate_id
+		WHERE r.requested_at >= $1 AND NOT r.is_test`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, since)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	pickups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Location, error) {
+		var l models.Location
+		err := row.Scan(&l.Latitude, &l.Longitude)
+		return l, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return pickups, nil
+}
+
+// IdleDrivers возвращает свободных водителей с текущей позицией (без симуляторов).
+// TipsEnabled ложно у водителей, убравших POSITIONING_TIPS из настроек уведомлений.
+func (r *PositioningRepo) IdleDrivers(ctx context.Context) ([]models.IdleDriver, error) {
+	const op = "PositioningRepo.IdleDrivers"
+	query := `
+		SELECT d.id, c.latitude, c.longitude,
+			p.user_id IS NULL OR 'POSITIONING_TIPS' = ANY(p.event_types) AS tips_enabled
+		FROM drivers d
+		JOIN coordinates c ON c.entity_id = d.id
+			AND c.entity_type = 'driver'
+			AND c.is_current = true
+		LEFT JOIN notification_preferences p ON p.user_id = d.id
+		WHERE d.status = 'AVAILABLE'
+			AND NOT d.is_simulator`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	drivers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.IdleDriver, error) {
+		var d models.IdleDriver
+		err := row.Scan(&d.ID, &d.Location.Latitude, &d.Location.Longitude, &d.TipsEnabled)
+		return d, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return drivers, nil
+}
+
+// Save сохраняет отправленную рекомендацию, заполняя ID и SentAt
+func (r *PositioningRepo) Save(ctx context.Context, rec *models.PositioningRecommendation) error {
+	const op = "PositioningRepo.Save"
+	query := `
+		INSERT INTO positioning_recommendations(
+			driver_id, cell, target_latitude, target_longitude,
+			origin_latitude, origin_longitude, expected_wait_minutes)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, sent_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		rec.DriverID,
+		rec.Cell,
+		rec.Target.Latitude,
+		rec.Target.Longitude,
+		rec.Origin.Latitude,
+		rec.Origin.Longitude,
+		rec.ExpectedWaitMinutes,
+	).Scan(&rec.ID, &rec.SentAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Evaluate оценивает рекомендации старше horizon: доехал ли водитель до района
+// (координата в радиусе radiusM за horizon) и получил ли поездку за horizon.
+// Возвращает количество оцененных рекомендаций.
+func (r *PositioningRepo) Evaluate(ctx context.Context, horizon time.Duration, radiusM float64) (int, error) {
+	const op = "PositioningRepo.Evaluate"
+	query := `
+		UPDATE positioning_recommendations p
+		SET evaluated_at = now(),
+			moved = EXISTS (
+				SELECT 1 FROM coordinates c
+				WHERE c.entity_id = p.driver_id
+					AND c.entity_type = 'driver'
+					AND c.created_at BETWEEN p.sent_at AND p.sent_at + $1 * interval '1 second'
+					AND ST_DWithin(
+						ST_MakePoint(c.longitude, c.latitude)::geography,
+						ST_MakePoint(p.target_longitude, p.target_latitude)::geography,
+						$2
+					)
+			),
+			got_ride = EXISTS (
+				SELECT 1 FROM rides r
+				WHERE r.driver_id = p.driver_id
+					AND r.matched_at BETWEEN p.sent_at AND p.sent_at + $1 * interval '1 second'
+			)
+		WHERE p.evaluated_at IS NULL
+			AND p.sent_at <= now() - $1 * interval '1 second'`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, horizon.Seconds(), radiusM)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// ProcessedMessageRepo запоминает обработанные сообщения брокера, чтобы повторная доставка
+// (at-least-once) не обрабатывалась дважды
+type ProcessedMessageRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewProcessedMessageRepo(db *pgxpool.Pool) *ProcessedMessageRepo {
+	return &ProcessedMessageRepo{
+		db: db,
+	}
+}
+
+// Claim отмечает сообщение обработанным потребителем. false — сообщение уже обрабатывалось.
+func (r *ProcessedMessageRepo) Claim(ctx context.Context, consumer, messageID string) (bool, error) {
+	const op = "ProcessedMessageRepo.Claim"
+	query := `
+		INSERT INTO processed_messages(consumer, message_id)
+		VALUES($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, consumer, messageID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// Processed сообщает, что сообщение уже отмечено обработанным потребителем
+func (r *ProcessedMessageRepo) Processed(ctx context.Context, consumer, messageID string) (bool, error) {
+	const op = "ProcessedMessageRepo.Processed"
+	query := `SELECT EXISTS(SELECT 1 FROM processed_messages WHERE consumer = $1 AND message_id = $2)`
+
+	var exists bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, consumer, messageID).Scan(&exists); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return exists, nil
+}
+
+// Release снимает отметку, чтобы сообщение можно было обработать снова (например, после replay из dead letter)
+func (r *ProcessedMessageRepo) Release(ctx context.Context, consumer, messageID string) error {
+	const op = "ProcessedMessageRepo.Release"
+	query := `DELETE FROM processed_messages WHERE consumer = $1 AND message_id = $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, consumer, messageID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
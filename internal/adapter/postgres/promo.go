@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type PromoRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPromoRepo(db *pgxpool.Pool) *PromoRepo {
+	return &PromoRepo{
+		db: db,
+	}
+}
+
+const promoSelect = `
+	SELECT id, code, discount_type, value::float, max_discount::float, expires_at, max_uses,
+	       per_passenger_limit, used_count, active, created_by, created_at, updated_at
+	FROM promo_codes`
+
+func scanPromo(row pgx.Row) (*models.PromoCode, error) {
+	var p models.PromoCode
+	err := row.Scan(
+		&p.ID, &p.Code, &p.DiscountType, &p.Value, &p.MaxDiscount, &p.ExpiresAt, &p.MaxUses,
+		&p.PerPassengerLimit, &p.UsedCount, &p.Active, &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Create сохраняет новый промокод
+func (r *PromoRepo) Create(ctx context.Context, p *models.PromoCode) error {
+	const op = "PromoRepo.Create"
+	query := `
+		INSERT INTO promo_codes(code, discount_type, value, max_discount, expires_at, max_uses, per_passenger_limit, active, created_by)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, used_count, created_at, updated_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		p.Code, p.DiscountType, p.Value, p.MaxDiscount, p.ExpiresAt, p.MaxUses, p.PerPassengerLimit, p.Active, p.CreatedBy,
+	).Scan(&p.ID, &p.UsedCount, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if postgres.IsUniqueViolation(err) {
+			return types.ErrPromoCodeExists
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// List возвращает все промокоды, новые первыми
+func (r *PromoRepo) List(ctx context.Context) ([]models.PromoCode, error) {
+	const op = "PromoRepo.List"
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, promoSelect+` ORDER BY created_at DESC`)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	promos, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PromoCode, error) {
+		p, err := scanPromo(row)
+		if err != nil {
+			return models.PromoCode{}, err
+		}
+		return *p, nil
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return promos, nil
+}
+
+// Get возвращает промокод по id
+func (r *PromoRepo) Get(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	const op = "PromoRepo.Get"
+
+	p, err := scanPromo(TxorDB(ctx, r.db).QueryRow(ctx, promoSelect+` WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrPromoNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// GetByCodeForUpdate возвращает промокод по коду и блокирует строку до конца транзакции,
+// чтобы параллельные поездки не превысили лимит применений
+func (r *PromoRepo) GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error) {
+	const op = "PromoRepo.GetByCodeForUpdate"
+
+	p, err := scanPromo(TxorDB(ctx, r.db).QueryRow(ctx, promoSelect+` WHERE code = upper($1) FOR UPDATE`, code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrPromoNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// Update меняет переданные поля промокода
+func (r *PromoRepo) Update(ctx context.Context, id uuid.UUID, u models.PromoCodeUpdate) (*models.PromoCode, error) {
+	const op = "PromoRepo.Update"
+	query := `
+		UPDATE promo_codes
+		SET value = coalesce($2, value),
+		    max_discount = coalesce($3, max_discount),
+		    expires_at = coalesce($4, expires_at),
+		    max_uses = coalesce($5, max_uses),
+		    per_passenger_limit = coalesce($6, per_passenger_limit),
+		    active = coalesce($7, active),
+		    updated_at = now()
+		WHERE id = $1
+		RETURNING id, code, discount_type, value::float, max_discount::float, expires_at, max_uses,
+		          per_passenger_limit, used_count, active, created_by, created_at, updated_at`
+
+	p, err := scanPromo(TxorDB(ctx, r.db).QueryRow(ctx, query,
+		id, u.Value, u.MaxDiscount, u.ExpiresAt, u.MaxUses, u.PerPassengerLimit, u.Active,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrPromoNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return p, nil
+}
+
+// PassengerUses возвращает число действующих применений промокода пассажиром
+func (r *PromoRepo) PassengerUses(ctx context.Context, promoID, passengerID uuid.UUID) (int, error) {
+	const op = "PromoRepo.PassengerUses"
+	query := `
+		SELECT count(*)
+		FROM promo_redemptions
+		WHERE promo_id = $1 AND passenger_id = $2 AND released_at IS NULL`
+
+	var uses int
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, promoID, passengerID).Scan(&uses); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return uses, nil
+}
+
+// Redeem записывает применение промокода к поездке и увеличивает счетчик применений
+func (r *PromoRepo) Redeem(ctx context.Context, d models.PromoDiscount, rideID uuid.UUID) error {
+	const op = "PromoRepo.Redeem"
+	query := `
+		WITH redemption AS (
+			INSERT INTO promo_redemptions(ride_id, promo_id, passenger_id, discount)
+			VALUES($1, $2, $3, $4)
+		)
+		UPDATE promo_codes SET used_count = used_count + 1, updated_at = now()
+		WHERE id = $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID, d.PromoID, d.PassengerID, d.Discount); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Release возвращает применение промокода отмененной поездки. false — промокод к поездке не применялся.
+func (r *PromoRepo) Release(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	const op = "PromoRepo.Release"
+	query := `
+		WITH released AS (
+			UPDATE promo_redemptions SET released_at = now()
+			WHERE ride_id = $1 AND released_at IS NULL
+			RETURNING promo_id
+		)
+		UPDATE promo_codes p SET used_count = greatest(p.used_count - 1, 0), updated_at = now()
+		FROM released
+		WHERE p.id = released.promo_id`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PushTokenRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPushTokenRepo(db *pgxpool.Pool) *PushTokenRepo {
+	return &PushTokenRepo{
+		db: db,
+	}
+}
+
+// Save сохраняет токен устройства. Тот же токен у другого пользователя или устройства удаляется:
+// токен принадлежит одной установке приложения, и push не должен уйти прежнему владельцу.
+func (r *PushTokenRepo) Save(ctx context.Context, t *models.PushToken) error {
+	const op = "PushTokenRepo.Save"
+	query := `
+		WITH released AS (
+			DELETE FROM push_tokens
+			WHERE token = $4 AND (user_id, device_id) <> ($1, $2)
+		)
+		INSERT INTO push_tokens(user_id, device_id, platform, token)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (user_id, device_id) DO UPDATE
+		SET platform = EXCLUDED.platform,
+			token = EXCLUDED.token,
+			updated_at = now()
+		RETURNING updated_at`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		t.UserID,
+		t.DeviceID,
+		t.Platform.String(),
+		t.Token,
+	).Scan(&t.UpdatedAt); err != nil {
+		if postgres.IsForeignKeyViolation(err) {
+			return types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// Delete удаляет токен устройства пользователя
+func (r *PushTokenRepo) Delete(ctx context.Context, userID uuid.UUID, deviceID string) error {
+	const op = "PushTokenRepo.Delete"
+	query := `DELETE FROM push_tokens WHERE user_id = $1 AND device_id = $2`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, userID, deviceID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrPushTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteToken удаляет токен, который push провайдер больше не принимает
+func (r *PushTokenRepo) DeleteToken(ctx context.Context, token string) error {
+	const op = "PushTokenRepo.DeleteToken"
+	query := `DELETE FROM push_tokens WHERE token = $1`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, token); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// List возвращает токены всех устройств пользователя
+func (r *PushTokenRepo) List(ctx context.Context, userID uuid.UUID) ([]models.PushToken, error) {
+	const op = "PushTokenRepo.List"
+	query := `
+		SELECT user_id, device_id, platform, token, updated_at
+		FROM push_tokens
+		WHERE user_id = $1
+		ORDER BY updated_at DESC`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer rows.Close()
+
+	var tokens []models.PushToken
+	for rows.Next() {
+		var (
+			t        models.PushToken
+			platform string
+		)
+		if err := rows.Scan(&t.UserID, &t.DeviceID, &platform, &t.Token, &t.UpdatedAt); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+		t.Platform = types.PushPlatform(platform)
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// CreateRating сохраняет оценку водителя. Поездку можно оценить только один раз.
+func (r *RideRepo) CreateRating(ctx context.Context, rating *models.RideRating) error {
+	const op = "RideRepo.CreateRating"
+	query := `
+		INSERT INTO ride_ratings(ride_id, driver_id, passenger_id, score, comment)
+		VALUES($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		rating.RideID,
+		rating.DriverID,
+		rating.PassengerID,
+		rating.Score,
+		rating.Comment,
+	).Scan(&rating.CreatedAt)
+	if err != nil {
+		if postgres.IsUniqueViolation(err) {
+			return types.ErrRideAlreadyRated
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// UpdateDriverRating пересчитывает среднюю оценку водителя по всем его оценкам
+func (r *RideRepo) UpdateDriverRating(ctx context.Context, driverID uuid.UUID) (*models.DriverRating, error) {
+	const op = "RideRepo.UpdateDriverRating"
+	query := `
+		UPDATE drivers d
+		SET rating = s.avg_score, ratings_count = s.cnt, updated_at = now()
+		FROM (
+			SELECT round(avg(score), 2) AS avg_score, count(*) AS cnt
+			FROM ride_ratings
+			WHERE driver_id = $1
+		) s
+		WHERE d.id = $1 AND s.cnt > 0
+		RETURNING d.rating::float, d.ratings_count`
+
+	var rating models.DriverRating
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&rating.Rating, &rating.RatingsCount); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &rating, nil
+}
+
+// GetRatings возвращает среднюю оценку водителя и страницу его оценок, новые первыми
+func (r *DriverRepo) GetRatings(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverRatingHistory, error) {
+	const op = "DriverRepo.GetRatings"
+	fail := func(err error) (*models.DriverRatingHistory, error) {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	history := &models.DriverRatingHistory{DriverID: driverID}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx,
+		`SELECT rating::float, ratings_count FROM drivers WHERE id = $1`, driverID,
+	).Scan(&history.Rating, &history.RatingsCount); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrUserNotFound
+		}
+		return fail(err)
+	}
+
+	order := "created_at DESC"
+	if filters.Sort == "created_at" {
+		order = "created_at ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER() AS total_count, ride_id, driver_id, score, comment, created_at
+		FROM ride_ratings
+		WHERE driver_id = $1
+		ORDER BY %s, ride_id
+		LIMIT $2 OFFSET $3`, order)
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return fail(err)
+	}
+
+	totalRecords := 0
+	ratings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.RideRating, error) {
+		var rating models.RideRating
+		err := row.Scan(
+			&totalRecords,
+			&rating.RideID,
+			&rating.DriverID,
+			&rating.Score,
+			&rating.Comment,
+			&rating.CreatedAt,
+		)
+		return rating, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	history.Ratings = ratings
+	history.Metadata = models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return history, nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// CreateReceipt записывает чек поездки и выдает ему следующий номер счета юрлица.
+// Повторная запись (повторная доставка сообщения) не меняет выданный чек и не расходует номер.
+// Номер выдается в транзакции завершения поездки, поэтому номера идут без пропусков.
+func (r *RideRepo) CreateReceipt(ctx context.Context, receipt *models.Receipt) error {
+	const op = "RideRepo.CreateReceipt"
+	query := `
+		WITH seq AS (
+			INSERT INTO invoice_sequences(legal_entity, last_number)
+			SELECT $19, 1
+			WHERE NOT EXISTS (SELECT 1 FROM ride_receipts WHERE ride_id = $1)
+			ON CONFLICT (legal_entity) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+			RETURNING last_number
+		)
+		INSERT INTO ride_receipts(ride_id, passenger_id, driver_id, vehicle_type, payment_method, distance_km, duration_min,
+		                          surge_multiplier, base_fare, distance_fare, time_fare, surge, adjustment, discount, tax, total,
+		                          driver_earnings, platform_commission, legal_entity, invoice_number)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+		       $19, $19 || '-' || lpad(seq.last_number::text, 8, '0')
+		FROM seq
+		ON CONFLICT (ride_id) DO NOTHING
+		RETURNING invoice_number`
+
+	f := receipt.Fare
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query,
+		receipt.RideID, receipt.PassengerID, receipt.DriverID, receipt.VehicleClass, receipt.PaymentMethod,
+		receipt.DistanceKm, receipt.DurationMin, receipt.SurgeMultiplier,
+		f.BaseFare, f.DistanceFare, f.TimeFare, f.Surge, f.Adjustment, f.Discount, f.Tax, f.Total,
+		f.DriverEarnings, f.PlatformCommission, receipt.LegalEntity,
+	).Scan(&receipt.InvoiceNumber)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
+
+// SetFiscalReceipt сохраняет ID фискального чека, уже фискализированный чек не меняется
+func (r *RideRepo) SetFiscalReceipt(ctx context.Context, rideID uuid.UUID, fiscalReceiptID string) error {
+	const op = "RideRepo.SetFiscalReceipt"
+	query := `
+		UPDATE ride_receipts
+		SET fiscal_receipt_id = $2, fiscalized_at = now()
+		WHERE ride_id = $1 AND fiscal_receipt_id IS NULL`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID, fiscalReceiptID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
+
+// GetReceipt возвращает чек поездки, ErrReceiptNotFound — чек не выдан
+func (r *RideRepo) GetReceipt(ctx context.Context, rideID uuid.UUID) (*models.Receipt, error) {
+	const op = "RideRepo.GetReceipt"
+	query := `
+		SELECT rc.ride_id, r.ride_number, rc.passenger_id, rc.driver_id, rc.vehicle_type, rc.payment_method,
+		       rc.distance_km::float, rc.duration_min, rc.surge_multiplier::float,
+		       rc.base_fare::float, rc.distance_fare::float, rc.time_fare::float, rc.surge::float, rc.adjustment::float,
+		       rc.discount::float, rc.tax::float, rc.total::float, rc.driver_earnings::float, rc.platform_commission::float,
+		       rc.created_at, coalesce(rc.legal_entity, ''), coalesce(rc.invoice_number, ''), rc.fiscal_receipt_id, rc.fiscalized_at
+		FROM ride_receipts rc
+		JOIN rides r ON r.id = rc.ride_id
+		WHERE rc.ride_id = $1`
+
+	var rc models.Receipt
+	f := &rc.Fare
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query, rideID).Scan(
+		&rc.RideID, &rc.RideNumber, &rc.PassengerID, &rc.DriverID, &rc.VehicleClass, &rc.PaymentMethod,
+		&rc.DistanceKm, &rc.DurationMin, &rc.SurgeMultiplier,
+		&f.BaseFare, &f.DistanceFare, &f.TimeFare, &f.Surge, &f.Adjustment,
+		&f.Discount, &f.Tax, &f.Total, &f.DriverEarnings, &f.PlatformCommission,
+		&rc.IssuedAt, &rc.LegalEntity, &rc.InvoiceNumber, &rc.FiscalReceiptID, &rc.FiscalizedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrReceiptNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &rc, nil
+}
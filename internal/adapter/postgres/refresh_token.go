@@ -86,3 +86,30 @@ func (r *RefreshTokenRepo) MarkUsed(ctx context.Context, tokenID uuid.UUID) erro
 	_, err := TxorDB(ctx, r.db).Exec(ctx, q, tokenID, time.Now().UTC())
 	return err
 }
+
+// Revoke отзывает refresh токен, он больше не обменивается на новую пару
+func (r *RefreshTokenRepo) Revoke(ctx context.Context, tokenID uuid.UUID) error {
+	const q = `
+		UPDATE refresh_tokens
+		SET revoked = true
+		WHERE id = $1;
+	`
+
+	_, err := TxorDB(ctx, r.db).Exec(ctx, q, tokenID)
+	return err
+}
+
+// RevokeAllForUser отзывает все действующие refresh токены пользователя и возвращает их число
+func (r *RefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	const q = `
+		UPDATE refresh_tokens
+		SET revoked = true
+		WHERE user_id = $1 AND NOT revoked AND expires_at > now();
+	`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, q, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
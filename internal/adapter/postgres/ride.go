@@ -11,43 +11,54 @@ import (
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
 type RideRepo struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *keyring.Keyring // шифрует адреса и телефон пассажира
 }
 
-func NewRideRepo(db *pgxpool.Pool) *RideRepo {
-	return &RideRepo{db: db}
+func NewRideRepo(db *pgxpool.Pool, pii *keyring.Keyring) *RideRepo {
+	return &RideRepo{db: db, pii: pii}
 }
 
 func (r *RideRepo) Create(ctx context.Context, ride *models.Ride) (*models.Ride, error) {
 	q := TxorDB(ctx, r.db)
 
+	pickupAddr, err := r.pii.Encrypt(ride.Pickup.Address)
+	if err != nil {
+		return nil, fmt.Errorf("ride repo: Create (encrypt pickup address): %w", err)
+	}
+	destAddr, err := r.pii.Encrypt(ride.Destination.Address)
+	if err != nil {
+		return nil, fmt.Errorf("ride repo: Create (encrypt dest address): %w", err)
+	}
+
 	var pickupCoordID uuid.UUID
 	coordQuery := `INSERT INTO coordinates (entity_id, entity_type, address, latitude, longitude)
                    VALUES ($1, 'passenger', $2, $3, $4) RETURNING id;`
 
-	err := q.QueryRow(ctx, coordQuery, ride.PassengerID, ride.Pickup.Address, ride.Pickup.Latitude, ride.Pickup.Longitude).Scan(&pickupCoordID)
+	err = q.QueryRow(ctx, coordQuery, ride.PassengerID, pickupAddr, ride.Pickup.Latitude, ride.Pickup.Longitude).Scan(&pickupCoordID)
 	if err != nil {
 		return nil, fmt.Errorf("rideRide Repo: Create (pickup coord): %w", err)
 	}
 
 	var destCoordID uuid.UUID
-	err = q.QueryRow(ctx, coordQuery, ride.PassengerID, ride.Destination.Address, ride.Destination.Latitude, ride.Destination.Longitude).Scan(&destCoordID)
+	err = q.QueryRow(ctx, coordQuery, ride.PassengerID, destAddr, ride.Destination.Latitude, ride.Destination.Longitude).Scan(&destCoordID)
 	if err != nil {
 		return nil, fmt.Errorf("ride repo: Create (dest coord): %w", err)
 	}
 
 	rideQuery := `INSERT INTO rides (ride_number, passenger_id, vehicle_type, status, estimated_fare, 
-                                     pickup_coordinate_id, destination_coordinate_id, priority )
-                  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+                                     pickup_coordinate_id, destination_coordinate_id, priority, pending_dispatch, is_test, priority_boarding, payment_method, surge_multiplier, flat_rate_id)
+                  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), coalesce(NULLIF($12, ''), 'CARD'), greatest($13, 1), $14)
                   RETURNING id, created_at;`
 
-	err = q.QueryRow(ctx, rideQuery, ride.RideNumber, ride.PassengerID, ride.RideType, ride.Status, ride.EstimatedFare, pickupCoordID, destCoordID, ride.Priority).Scan(&ride.ID, &ride.CreatedAt)
+	err = q.QueryRow(ctx, rideQuery, ride.RideNumber, ride.PassengerID, ride.RideType, ride.Status, ride.EstimatedFare, pickupCoordID, destCoordID, ride.Priority, ride.PendingDispatch, ride.IsTest, ride.PriorityBoarding, ride.PaymentMethod, ride.SurgeMultiplier, ride.FlatRateID).Scan(&ride.ID, &ride.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("ride repo: Create (ride): %w", err)
 	}
@@ -55,17 +66,15 @@ func (r *RideRepo) Create(ctx context.Context, ride *models.Ride) (*models.Ride,
 	return ride, nil
 }
 
-func (r *RideRepo) CountByDate(ctx context.Context) (int, error) {
+// NextRideNumber возвращает следующее значение последовательности номеров поездок
+func (r *RideRepo) NextRideNumber(ctx context.Context) (int64, error) {
 	q := TxorDB(ctx, r.db)
 
-	var count int
-	query := "SELECT COUNT(*) FROM rides WHERE DATE(created_at) = CURRENT_DATE;"
-
-	err := q.QueryRow(ctx, query).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("ride repo: CountByDate: %w", err)
+	var seq int64
+	if err := q.QueryRow(ctx, "SELECT nextval('ride_number_seq')").Scan(&seq); err != nil {
+		return 0, fmt.Errorf("ride repo: NextRideNumber: %w", err)
 	}
-	return count, nil
+	return seq, nil
 }
 
 func (r *RideRepo) Get(ctx context.Context, rideID uuid.UUID) (*models.Ride, error) {
@@ -75,23 +84,33 @@ func (r *RideRepo) Get(ctx context.Context, rideID uuid.UUID) (*models.Ride, err
 	// JOIN чтобы сразу получить адреса
 	query := `
         SELECT
-            r.id, r.ride_number, r.status, r.passenger_id, r.driver_id, r.vehicle_type,
-            r.estimated_fare, r.final_fare, r.cancellation_reason,
-            r.created_at, r.matched_at, r.arrived_at, r.started_at, r.completed_at, r.cancelled_at,
+            r.id, r.ride_number, r.status, r.passenger_id, r.driver_id, r.vehicle_type, r.served_vehicle_type,
+            r.estimated_fare, r.final_fare, r.cancellation_reason, r.cancellation_fee::float, r.pending_dispatch, r.is_test,
+            coalesce(r.priority_boarding, ''), r.payment_method, r.surge_multiplier::float, r.created_at, r.matched_at, r.arrived_at, r.started_at, r.completed_at, r.cancelled_at,
+            r.approach_notified_at,
             p.address as pickup_address, p.latitude as pickup_lat, p.longitude as pickup_lon,
-            d.address as dest_address, d.latitude as dest_lat, d.longitude as dest_lon
+            d.address as dest_address, d.latitude as dest_lat, d.longitude as dest_lon,
+            coalesce(pc.code, ''), coalesce(pr.discount, 0)::float,
+            r.flat_rate_id, coalesce(fr.code, '')
         FROM rides r
         JOIN coordinates p ON r.pickup_coordinate_id = p.id
         JOIN coordinates d ON r.destination_coordinate_id = d.id
+        LEFT JOIN promo_redemptions pr ON pr.ride_id = r.id AND pr.released_at IS NULL
+        LEFT JOIN promo_codes pc ON pc.id = pr.promo_id
+        LEFT JOIN flat_rates fr ON fr.id = r.flat_rate_id
         WHERE r.id = $1;`
 
 	row := q.QueryRow(ctx, query, rideID)
 	err := row.Scan(
-		&ride.ID, &ride.RideNumber, &ride.Status, &ride.PassengerID, &ride.DriverID, &ride.RideType,
-		&ride.EstimatedFare, &ride.FinalFare, &ride.CancellationReason,
+		&ride.ID, &ride.RideNumber, &ride.Status, &ride.PassengerID, &ride.DriverID, &ride.RideType, &ride.ServedVehicleClass,
+		&ride.EstimatedFare, &ride.FinalFare, &ride.CancellationReason, &ride.CancellationFee, &ride.PendingDispatch, &ride.IsTest,
+		&ride.PriorityBoarding, &ride.PaymentMethod, &ride.SurgeMultiplier,
 		&ride.CreatedAt, &ride.MatchedAt, &ride.ArrivedAt, &ride.StartedAt, &ride.CompletedAt, &ride.CancelledAt,
+		&ride.ApproachNotifiedAt,
 		&ride.Pickup.Address, &ride.Pickup.Latitude, &ride.Pickup.Longitude,
 		&ride.Destination.Address, &ride.Destination.Latitude, &ride.Destination.Longitude,
+		&ride.PromoCode, &ride.Discount,
+		&ride.FlatRateID, &ride.FlatRateCode,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -100,6 +119,10 @@ func (r *RideRepo) Get(ctx context.Context, rideID uuid.UUID) (*models.Ride, err
 		return nil, fmt.Errorf("ride repo: Get: %w", err)
 	}
 
+	if err := decryptStrings(r.pii, &ride.Pickup.Address, &ride.Destination.Address); err != nil {
+		return nil, fmt.Errorf("ride repo: Get: %w", err)
+	}
+
 	return &ride, nil
 }
 
@@ -118,6 +141,7 @@ func (r *RideRepo) Update(ctx context.Context, ride *models.Ride) error {
             started_at = $8,
             completed_at = $9,
             cancelled_at = $10,
+            cancellation_fee = $11,
             updated_at = now()
         WHERE id = $1;`
 
@@ -132,6 +156,7 @@ func (r *RideRepo) Update(ctx context.Context, ride *models.Ride) error {
 		ride.StartedAt,
 		ride.CompletedAt,
 		ride.CancelledAt,
+		ride.CancellationFee,
 	)
 	if err != nil {
 		return fmt.Errorf("ride repo: Update: %w", err)
@@ -311,6 +336,10 @@ func (r *RideRepo) GetDetails(ctx context.Context, rideID uuid.UUID) (*models.Ri
 		return nil, wrap.Error(ctx, fmt.Errorf("%s: failed to get ride details: %w", op, err))
 	}
 
+	if err := decryptStrings(r.pii, details.Phone); err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
 	return &details, nil
 }
 
@@ -329,9 +358,233 @@ func (r *RideRepo) GetPickupCoordinate(ctx context.Context, rideID uuid.UUID) (*
 		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
 	}
 
+	if err := decryptStrings(r.pii, &location.Address); err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
 	return &location, nil
 }
 
+// GetAssignedRideID возвращает поездку, назначенную водителю и еще не начатую (MATCHED, EN_ROUTE).
+// nil — у водителя нет назначения.
+func (r *RideRepo) GetAssignedRideID(ctx context.Context, driverID uuid.UUID) (*uuid.UUID, error) {
+	const op = "RideRepo.GetAssignedRideID"
+	query := `
+		SELECT id FROM rides
+		WHERE driver_id = $1 AND status IN ('MATCHED', 'EN_ROUTE')
+		ORDER BY matched_at DESC
+		LIMIT 1`
+
+	var rideID uuid.UUID
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&rideID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &rideID, nil
+}
+
+// GetCurrentRide возвращает поездку, которую водитель выполняет сейчас (от назначения до завершения), nil — такой нет.
+// Имя и телефон пассажира возвращаются как есть, маскирует их сервис.
+func (r *RideRepo) GetCurrentRide(ctx context.Context, driverID uuid.UUID) (*models.CurrentRide, error) {
+	const op = "RideRepo.GetCurrentRide"
+	query := `
+		SELECT r.id, r.ride_number, r.status,
+			pc.latitude, pc.longitude, pc.address,
+			dc.latitude, dc.longitude, dc.address,
+			coalesce(r.estimated_fare, 0)::float, coalesce(r.priority_boarding, ''),
+			r.matched_at, r.arrived_at, r.started_at,
+			coalesce(u.attrs->>'name', ''), coalesce(u.attrs->>'phone', '')
+		FROM rides r
+		INNER JOIN users u ON r.passenger_id = u.id
+		INNER JOIN coordinates pc ON r.pickup_coordinate_id = pc.id
+		INNER JOIN coordinates dc ON r.destination_coordinate_id = dc.id
+		WHERE r.driver_id = $1 AND r.status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED', 'IN_PROGRESS')
+		ORDER BY r.matched_at DESC
+		LIMIT 1`
+
+	var ride models.CurrentRide
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(
+		&ride.RideID, &ride.RideNumber, &ride.Status,
+		&ride.Pickup.Latitude, &ride.Pickup.Longitude, &ride.Pickup.Address,
+		&ride.Destination.Latitude, &ride.Destination.Longitude, &ride.Destination.Address,
+		&ride.EstimatedFare, &ride.PriorityBoarding,
+		&ride.MatchedAt, &ride.ArrivedAt, &ride.StartedAt,
+		&ride.Passenger.Alias, &ride.Passenger.PhoneMasked,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if err := decryptStrings(r.pii, &ride.Pickup.Address, &ride.Destination.Address, &ride.Passenger.PhoneMasked); err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &ride, nil
+}
+
+// DriverDistanceSince возвращает путь водителя в км по истории координат начиная с since
+func (r *RideRepo) DriverDistanceSince(ctx context.Context, driverID uuid.UUID, since time.Time) (float64, error) {
+	const op = "RideRepo.DriverDistanceSince"
+	query := `
+		SELECT COALESCE(sum(ST_Distance(
+			ST_MakePoint(h.prev_longitude, h.prev_latitude)::geography,
+			ST_MakePoint(h.longitude, h.latitude)::geography
+		)) / 1000, 0)::float
+		FROM (
+			SELECT latitude, longitude,
+				lag(latitude) OVER (ORDER BY recorded_at) AS prev_latitude,
+				lag(longitude) OVER (ORDER BY recorded_at) AS prev_longitude
+			FROM location_history
+			WHERE driver_id = $1 AND recorded_at >= $2
+		) h
+		WHERE h.prev_latitude IS NOT NULL`
+
+	var distanceKm float64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, since).Scan(&distanceKm); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return distanceKm, nil
+}
+
+// DriverCard возвращает карточку водителя для пассажира с текущей координатой водителя, если она есть
+func (r *RideRepo) DriverCard(ctx context.Context, driverID uuid.UUID) (*models.DriverCard, error) {
+	const op = "RideRepo.DriverCard"
+	query := `
+		SELECT d.id, d.name, d.photo_url, coalesce(d.rating, 5)::float, coalesce(d.vehicle_type, ''),
+		       coalesce(d.vehicle_attrs, '{}'), c.latitude::float, c.longitude::float
+		FROM drivers d
+		LEFT JOIN coordinates c ON c.entity_id = d.id AND c.entity_type = 'driver' AND c.is_current = true
+		WHERE d.id = $1`
+
+	var (
+		card     models.DriverCard
+		vehicle  models.Vehicle
+		lat, lng *float64
+	)
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(
+		&card.DriverID, &card.Name, &card.PhotoURL, &card.Rating, &card.Vehicle.Class,
+		&vehicle, &lat, &lng,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	card.Vehicle.Make, card.Vehicle.Model = vehicle.Make, vehicle.Model
+	card.Vehicle.Color, card.Vehicle.Plate = vehicle.Color, vehicle.Plate
+	if lat != nil && lng != nil {
+		card.DriverLocation = &models.Location{Latitude: *lat, Longitude: *lng}
+	}
+	return &card, nil
+}
+
+// ReleaseDriver снимает водителя с еще не начатой поездки и возвращает ее в REQUESTED
+func (r *RideRepo) ReleaseDriver(ctx context.Context, rideID, driverID uuid.UUID) error {
+	const op = "RideRepo.ReleaseDriver"
+	query := `
+		UPDATE rides
+		SET status = 'REQUESTED', driver_id = NULL, matched_at = NULL, arrived_at = NULL, final_fare = NULL, updated_at = now()
+		WHERE id = $1 AND driver_id = $2 AND status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED')`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID, driverID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrInvalidRideStatus
+	}
+
+	return nil
+}
+
+// ClaimStuckRides забирает на сверку поездки, зависшие в REQUESTED с requestedBefore и в MATCHED
+// с matchedBefore. REQUESTED, чей запрос поиска еще ждет отправки из outbox, не зависли: их ведет relay.
+// Возраст REQUESTED считается от updated_at, который MarkDispatched сдвигает при отправке запроса,
+// поэтому время в outbox (брокер недоступен, окно приостановки подбора) в него не входит.
+// Забранным поездкам сдвигается updated_at, и экземпляры, сверяющие параллельно, их пропускают.
+func (r *RideRepo) ClaimStuckRides(ctx context.Context, requestedBefore, matchedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	const op = "RideRepo.ClaimStuckRides"
+	query := `
+		UPDATE rides
+		SET updated_at = now()
+		WHERE id IN (
+			SELECT r.id FROM rides r
+			WHERE (
+				r.status = 'REQUESTED' AND r.updated_at < $1 AND NOT r.pending_dispatch
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox o
+					WHERE o.topic = $4 AND o.published_at IS NULL AND o.discarded_at IS NULL
+						AND o.payload->>'ride_id' = r.id::text
+				)
+			) OR (r.status = 'MATCHED' AND r.updated_at < $2)
+			ORDER BY r.updated_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, requestedBefore, matchedBefore, limit, types.OutboxRideRequested)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return ids, nil
+}
+
+// SetPaymentMethod меняет способ оплаты поездки (переход с кошелька на карту)
+func (r *RideRepo) SetPaymentMethod(ctx context.Context, rideID uuid.UUID, method types.PaymentMethod) error {
+	const op = "RideRepo.SetPaymentMethod"
+	query := `UPDATE rides SET payment_method = $2, updated_at = now() WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID, method)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrRideNotFound
+	}
+
+	return nil
+}
+
+// IsPriorityBoardingEligible проверяет, разрешена ли пассажиру приоритетная посадка
+func (r *RideRepo) IsPriorityBoardingEligible(ctx context.Context, passengerID uuid.UUID) (bool, error) {
+	const op = "RideRepo.IsPriorityBoardingEligible"
+	query := `SELECT priority_boarding_eligible FROM users WHERE id = $1`
+
+	var eligible bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, passengerID).Scan(&eligible); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return eligible, nil
+}
+
 func (r *RideRepo) CheckActiveRideByPassengerID(ctx context.Context, passengerID uuid.UUID) (*models.Ride, error) {
 	q := TxorDB(ctx, r.db)
 
@@ -366,11 +619,16 @@ func (r *RideRepo) CheckActiveRideByPassengerID(ctx context.Context, passengerID
 		return nil, err
 	}
 
+	if err := decryptStrings(r.pii, &ride.Pickup.Address, &ride.Destination.Address); err != nil {
+		return nil, err
+	}
+
 	return &ride, nil
 }
 
-// DriverMatchedForRide updates ride status to MATCHED and sets driver_id
-func (r *RideRepo) DriverMatchedForRide(ctx context.Context, rideID, driverID uuid.UUID, finalFare float64) error {
+// DriverMatchedForRide updates ride status to MATCHED and sets driver_id.
+// servedClass is set when the driver's vehicle class differs from the requested one.
+func (r *RideRepo) DriverMatchedForRide(ctx context.Context, rideID, driverID uuid.UUID, finalFare float64, servedClass *types.VehicleClass) error {
 	q := TxorDB(ctx, r.db)
 
 	query := `
@@ -379,12 +637,13 @@ func (r *RideRepo) DriverMatchedForRide(ctx context.Context, rideID, driverID uu
 		driver_id = $1,
 		final_fare = $2,
 		status = 'MATCHED',
+		served_vehicle_type = $4,
 		matched_at = now(),
 		updated_at = now()
 	WHERE id = $3`
 
 	// используем Exec, так как это UPDATE
-	cmdTag, err := q.Exec(ctx, query, driverID, finalFare, rideID)
+	cmdTag, err := q.Exec(ctx, query, driverID, finalFare, rideID, servedClass)
 	if err != nil {
 		return wrap.Error(wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed), err)
 	}
@@ -414,3 +673,123 @@ func (r *RideRepo) Status(ctx context.Context, rideID uuid.UUID) (*types.RideSta
 
 	return &status, nil
 }
+
+// SetFootprint сохраняет расстояние и углеродный след завершенной поездки
+func (r *RideRepo) SetFootprint(ctx context.Context, rideID uuid.UUID, distanceKm, co2Grams float64) error {
+	const op = "RideRepo.SetFootprint"
+	query := `
+		UPDATE rides
+		SET distance_km = $2, co2_grams = $3, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID, distanceKm, co2Grams)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrRideNotFound
+	}
+
+	return nil
+}
+
+// GetMonthlyImpact возвращает углеродный след завершенных поездок пассажира по месяцам (UTC),
+// начиная с since. Месяцы без поездок не возвращаются.
+func (r *RideRepo) GetMonthlyImpact(ctx context.Context, passengerID uuid.UUID, since time.Time) ([]models.MonthlyImpact, error) {
+	const op = "RideRepo.GetMonthlyImpact"
+	query := `
+		SELECT to_char(date_trunc('month', completed_at AT TIME ZONE 'UTC'), 'YYYY-MM') AS month,
+		       count(*),
+		       coalesce(sum(distance_km), 0)::float,
+		       coalesce(sum(co2_grams), 0)::float
+		FROM rides
+		WHERE passenger_id = $1
+		  AND status = 'COMPLETED'
+		  AND NOT is_test
+		  AND completed_at >= $2
+		  AND co2_grams IS NOT NULL
+		GROUP BY month
+		ORDER BY month`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, passengerID, since)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	months, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.MonthlyImpact, error) {
+		var m models.MonthlyImpact
+		err := row.Scan(&m.Month, &m.Rides, &m.DistanceKm, &m.CO2Grams)
+		return m, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return months, nil
+}
+
+// SetPendingDispatch помечает поездку, запрос поиска водителя для которой не удалось отправить в брокер
+func (r *RideRepo) SetPendingDispatch(ctx context.Context, rideID uuid.UUID) error {
+	const op = "RideRepo.SetPendingDispatch"
+	query := `
+		UPDATE rides
+		SET pending_dispatch = true, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrRideNotFound
+	}
+
+	return nil
+}
+
+// MarkDispatched отмечает, что запрос поиска водителя ушел в брокер: снимает pending_dispatch и сдвигает
+// updated_at, от которого сверка зависших поездок отсчитывает поиск. Задержка брокером или окном
+// приостановки подбора в поиск не входит. Поездка, вышедшая из REQUESTED, не меняется.
+// Строка остается заблокированной до конца транзакции.
+func (r *RideRepo) MarkDispatched(ctx context.Context, rideID uuid.UUID) error {
+	const op = "RideRepo.MarkDispatched"
+	query := `
+		UPDATE rides
+		SET pending_dispatch = false, updated_at = now()
+		WHERE id = $1 AND status = 'REQUESTED'`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// SetApproachNotified отмечает (notified) или снимает отметку об уведомлении пассажира о приближении водителя.
+// Возвращает false, если отметка уже в нужном состоянии — уведомление отправил другой экземпляр.
+func (r *RideRepo) SetApproachNotified(ctx context.Context, rideID uuid.UUID, notified bool) (bool, error) {
+	const op = "RideRepo.SetApproachNotified"
+	query := `
+		UPDATE rides
+		SET approach_notified_at = now(), updated_at = now()
+		WHERE id = $1 AND approach_notified_at IS NULL`
+	if !notified {
+		query = `
+		UPDATE rides
+		SET approach_notified_at = NULL, updated_at = now()
+		WHERE id = $1 AND approach_notified_at IS NOT NULL`
+	}
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, rideID)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/querybuilder"
+)
+
+// History возвращает страницу поездок пассажира или водителя. Тестовые поездки не показываются.
+func (r *RideRepo) History(ctx context.Context, f models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error) {
+	const op = "RideRepo.History"
+	fail := func(err error) (*models.RideHistoryResponse, error) {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	sort, err := querybuilder.ParseSort(filters.Sort, "r.id", rideSearchSortFields...)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	statuses := make([]string, 0, len(f.Statuses))
+	for _, s := range f.Statuses {
+		statuses = append(statuses, s.String())
+	}
+
+	b := querybuilder.New().
+		Where("NOT r.is_test").
+		WhereIf(f.PassengerID != nil, "r.passenger_id = ?", f.PassengerID).
+		WhereIf(f.DriverID != nil, "r.driver_id = ?", f.DriverID).
+		WhereIf(len(statuses) > 0, "r.status = ANY(?)", statuses).
+		WhereIf(f.From != nil, "coalesce(r.requested_at, r.created_at) >= ?", f.From).
+		WhereIf(f.To != nil, "coalesce(r.requested_at, r.created_at) < ?", f.To)
+	limit := b.Arg(filters.Limit())
+	offset := b.Arg(filters.Offset())
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER() AS total_count,
+		       r.id, r.ride_number, r.status, r.passenger_id, r.driver_id,
+		       coalesce(r.vehicle_type, ''), r.served_vehicle_type,
+		       coalesce(r.estimated_fare, 0)::float, r.final_fare::float,
+		       coalesce(pc.address, ''), coalesce(dc.address, ''),
+		       coalesce(r.requested_at, r.created_at), r.completed_at, r.cancelled_at
+		FROM rides r
+		LEFT JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
+		LEFT JOIN coordinates dc ON dc.id = r.destination_coordinate_id
+		%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`, b.WhereSQL(), sort.OrderBy(), limit, offset)
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, b.Args()...)
+	if err != nil {
+		return fail(err)
+	}
+
+	totalRecords := 0
+	rides, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.RideHistoryItem, error) {
+		var item models.RideHistoryItem
+		err := row.Scan(
+			&totalRecords,
+			&item.RideID,
+			&item.RideNumber,
+			&item.Status,
+			&item.PassengerID,
+			&item.DriverID,
+			&item.VehicleClass,
+			&item.ServedVehicleClass,
+			&item.EstimatedFare,
+			&item.FinalFare,
+			&item.PickupAddress,
+			&item.DestinationAddress,
+			&item.RequestedAt,
+			&item.CompletedAt,
+			&item.CancelledAt,
+		)
+		return item, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	for i := range rides {
+		if err := decryptStrings(r.pii, &rides[i].PickupAddress, &rides[i].DestinationAddress); err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+	}
+
+	return &models.RideHistoryResponse{
+		Rides:    rides,
+		Metadata: models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize),
+	}, nil
+}
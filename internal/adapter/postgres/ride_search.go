@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/querybuilder"
+)
+
+// rideSearchSortFields — поля сортировки поиска поездок. Выражения не возвращают NULL,
+// иначе сравнение строк в keyset условии пропускало бы записи.
+var rideSearchSortFields = []querybuilder.SortField{
+	{Name: "created_at", Expr: "r.created_at", Cast: "timestamptz"},
+	{Name: "requested_at", Expr: "coalesce(r.requested_at, r.created_at)", Cast: "timestamptz"},
+	{Name: "fare", Expr: "coalesce(r.final_fare, r.estimated_fare, 0)", Cast: "numeric"},
+	{Name: "ride_number", Expr: "r.ride_number", Cast: "text"},
+}
+
+// SearchRides ищет поездки по фильтрам с keyset пагинацией
+func (r *AdminRepo) SearchRides(ctx context.Context, f models.RideSearchFilter) (*models.RideSearchResponse, error) {
+	const op = "AdminRepo.SearchRides"
+	fail := func(err error) (*models.RideSearchResponse, error) {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	sort, err := querybuilder.ParseSort(f.Sort, "r.id", rideSearchSortFields...)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	statuses := make([]string, 0, len(f.Statuses))
+	for _, s := range f.Statuses {
+		statuses = append(statuses, s.String())
+	}
+
+	b := querybuilder.New().
+		WhereIf(len(statuses) > 0, "r.status = ANY(?)", statuses).
+		WhereIf(f.PassengerID != nil, "r.passenger_id = ?", f.PassengerID).
+		WhereIf(f.DriverID != nil, "r.driver_id = ?", f.DriverID).
+		WhereIf(f.MinFare != nil, "coalesce(r.final_fare, r.estimated_fare, 0) >= ?", f.MinFare).
+		WhereIf(f.MaxFare != nil, "coalesce(r.final_fare, r.estimated_fare, 0) <= ?", f.MaxFare).
+		WhereIf(f.From != nil, "coalesce(r.requested_at, r.created_at) >= ?", f.From).
+		WhereIf(f.To != nil, "coalesce(r.requested_at, r.created_at) < ?", f.To).
+		WhereIf(f.VehicleClass != "", "r.vehicle_type = ?", string(f.VehicleClass)).
+		WhereIf(f.City != "", `EXISTS (
+			SELECT 1 FROM city_settings cs
+			WHERE cs.code = ?
+			  AND ST_DWithin(
+				ST_MakePoint(cs.center_longitude, cs.center_latitude)::geography,
+				ST_MakePoint(pc.longitude, pc.latitude)::geography,
+				cs.radius_km * 1000
+			  ))`, f.City)
+	if f.After != nil {
+		b.After(sort, f.After.Value, f.After.ID)
+	}
+
+	// берем на одну строку больше, чтобы понять, есть ли следующая страница
+	limit := b.Arg(f.Limit + 1)
+
+	query := fmt.Sprintf(`
+		SELECT r.id, r.ride_number, r.status, r.passenger_id, r.driver_id,
+		       coalesce(r.vehicle_type, ''), coalesce(r.priority, 1), coalesce(r.priority_boarding, ''),
+		       coalesce(r.estimated_fare, 0)::float, r.final_fare::float,
+		       coalesce(pc.address, ''), coalesce(dc.address, ''),
+		       coalesce(r.requested_at, r.created_at), r.completed_at, r.cancelled_at,
+		       (%s)::text AS sort_key
+		FROM rides r
+		LEFT JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
+		LEFT JOIN coordinates dc ON dc.id = r.destination_coordinate_id
+		%s
+		ORDER BY %s
+		LIMIT %s`, sort.Field.Expr, b.WhereSQL(), sort.OrderBy(), limit)
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, b.Args()...)
+	if err != nil {
+		return fail(err)
+	}
+
+	var sortKeys []string
+	rides, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.RideSummary, error) {
+		var (
+			s       models.RideSummary
+			sortKey string
+		)
+		err := row.Scan(
+			&s.RideID,
+			&s.RideNumber,
+			&s.Status,
+			&s.PassengerID,
+			&s.DriverID,
+			&s.VehicleClass,
+			&s.Priority,
+			&s.PriorityBoarding,
+			&s.EstimatedFare,
+			&s.FinalFare,
+			&s.PickupAddress,
+			&s.DestinationAddress,
+			&s.RequestedAt,
+			&s.CompletedAt,
+			&s.CancelledAt,
+			&sortKey,
+		)
+		sortKeys = append(sortKeys, sortKey)
+		return s, err
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	res := &models.RideSearchResponse{Rides: rides}
+	if len(rides) > f.Limit {
+		res.Rides = rides[:f.Limit]
+		last := res.Rides[f.Limit-1]
+		res.NextCursor = models.RideCursor{Sort: f.Sort, Value: sortKeys[f.Limit-1], ID: last.RideID}.Encode()
+	}
+
+	for i := range res.Rides {
+		if err := decryptStrings(r.pii, &res.Rides[i].PickupAddress, &res.Rides[i].DestinationAddress); err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+	}
+
+	return res, nil
+}
@@ -2,7 +2,9 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
@@ -22,19 +24,39 @@ func NewSessionRepo(db *pgxpool.Pool) *SessionRepo {
 	}
 }
 
-func (r *SessionRepo) Create(ctx context.Context, driverID uuid.UUID) (sessiondID uuid.UUID, err error) {
+// Create открывает сессию водителя. Открытая сессия у водителя одна (уникальный частичный индекс):
+// если параллельный запрос уже открыл ее, возвращается существующая сессия и created=false.
+func (r *SessionRepo) Create(ctx context.Context, driverID uuid.UUID) (sessionID uuid.UUID, created bool, err error) {
 	const op = "SessionRepo.Create"
 	query := `
 		INSERT INTO driver_sessions(driver_id)
 		VALUES($1)
+		ON CONFLICT (driver_id) WHERE ended_at IS NULL DO NOTHING
 		RETURNING id;`
 
-	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&sessiondID); err != nil {
+	err = TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&sessionID)
+	if err == nil {
+		return sessionID, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
 		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
-		return uuid.UUID{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		return uuid.UUID{}, false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
 	}
 
-	return sessiondID, nil
+	// вставка ждала commit конкурирующей транзакции, новый запрос уже видит ее сессию
+	open := `
+		SELECT id FROM driver_sessions
+		WHERE driver_id = $1 AND ended_at IS NULL`
+
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, open, driverID).Scan(&sessionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, false, types.ErrSessionNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return uuid.UUID{}, false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return sessionID, false, nil
 }
 
 func (r *SessionRepo) GetSummary(ctx context.Context, driverID uuid.UUID) (models.SessionSummary, error) {
@@ -43,10 +65,12 @@ func (r *SessionRepo) GetSummary(ctx context.Context, driverID uuid.UUID) (model
 		UPDATE driver_sessions
 		SET ended_at = now()
 		WHERE ended_at IS NULL AND driver_id = $1
-		RETURNING id, total_rides, total_earnings, EXTRACT(EPOCH FROM (now() - started_at)) / 3600.0 AS hours`
+		RETURNING id, started_at, ended_at, total_rides, total_earnings, EXTRACT(EPOCH FROM (now() - started_at)) / 3600.0 AS hours`
 
 	var summary models.SessionSummary
-	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(&summary.SessionID, &summary.RidesCompleted, &summary.Earnings, &summary.DurationHours); err != nil {
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID).Scan(
+		&summary.SessionID, &summary.StartedAt, &summary.EndedAt, &summary.RidesCompleted, &summary.Earnings, &summary.DurationHours,
+	); err != nil {
 		if err == pgx.ErrNoRows {
 			return models.SessionSummary{}, types.ErrSessionNotFound
 		}
@@ -65,8 +89,13 @@ func (r *SessionRepo) Update(ctx context.Context, driverID uuid.UUID, ridesCompl
 		SET 
 			total_rides = total_rides + $1,
 			total_earnings = total_earnings + $2
-		WHERE 
-			driver_id = $3`
+		WHERE id = (
+			-- итоги пишутся только в последнюю сессию, иначе история сессий суммирует их повторно
+			SELECT id FROM driver_sessions
+			WHERE driver_id = $3
+			ORDER BY started_at DESC
+			LIMIT 1
+		)`
 
 	res, err := TxorDB(ctx, r.db).Exec(ctx, query, ridesCompleted, earnings, driverID)
 	if err != nil {
@@ -80,3 +109,194 @@ func (r *SessionRepo) Update(ctx context.Context, driverID uuid.UUID, ridesCompl
 
 	return nil
 }
+
+// GetStats считает статистику водителя начиная с since: завершенные поездки и заработок,
+// время на линии (с обрезкой сессий по since) и долю принятых офферов
+func (r *SessionRepo) GetStats(ctx context.Context, driverID uuid.UUID, since time.Time) (models.DriverStats, error) {
+	const op = "SessionRepo.GetStats"
+	query := `
+		SELECT
+			(SELECT count(*) FROM rides
+			 WHERE driver_id = $1 AND status = 'COMPLETED' AND NOT is_test AND completed_at >= $2),
+			(SELECT COALESCE(sum(estimated_fare), 0) FROM rides
+			 WHERE driver_id = $1 AND status = 'COMPLETED' AND NOT is_test AND completed_at >= $2),
+			(SELECT COALESCE(sum(EXTRACT(EPOCH FROM (COALESCE(ended_at, now()) - greatest(started_at, $2)))), 0) / 60.0
+			 FROM driver_sessions
+			 WHERE driver_id = $1 AND COALESCE(ended_at, now()) > $2),
+			o.total,
+			o.accepted
+		FROM (
+			SELECT count(*) AS total, count(*) FILTER (WHERE outcome = 'ACCEPTED') AS accepted
+			FROM driver_offers
+			WHERE driver_id = $1 AND created_at >= $2
+		) o`
+
+	var (
+		stats           models.DriverStats
+		total, accepted int
+	)
+	err := TxorDB(ctx, r.db).QueryRow(ctx, query, driverID, since).Scan(
+		&stats.RidesToday, &stats.EarningsToday, &stats.OnlineMinutes, &total, &accepted,
+	)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return models.DriverStats{}, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if total > 0 {
+		rate := float64(accepted) / float64(total)
+		stats.AcceptanceRate = &rate
+	}
+
+	return stats, nil
+}
+
+// GetDailyTotals возвращает итоги водителя по суткам [since, until), since и until — начала суток.
+// Поездки и заработок считаются по завершенным поездкам, часы — по пересечению сессий с сутками.
+func (r *SessionRepo) GetDailyTotals(ctx context.Context, driverID uuid.UUID, since, until time.Time) ([]models.DailyTotals, error) {
+	const op = "SessionRepo.GetDailyTotals"
+	query := `
+		WITH days AS (
+			SELECT day, day + interval '1 day' AS day_end
+			FROM generate_series($2::timestamptz, $3::timestamptz - interval '1 day', interval '1 day') AS day
+		)
+		SELECT d.day,
+			COALESCE(r.rides, 0),
+			COALESCE(r.earnings, 0),
+			COALESCE((
+				SELECT sum(EXTRACT(EPOCH FROM (least(COALESCE(s.ended_at, now()), d.day_end) - greatest(s.started_at, d.day))))
+				FROM driver_sessions s
+				WHERE s.driver_id = $1 AND s.started_at < d.day_end AND COALESCE(s.ended_at, now()) > d.day
+			), 0) / 3600.0
+		FROM days d
+		LEFT JOIN (
+			SELECT date_trunc('day', completed_at, 'UTC') AS day,
+				count(*)::float AS rides,
+				sum(COALESCE(final_fare, estimated_fare))::float AS earnings
+			FROM rides
+			WHERE driver_id = $1 AND status = 'COMPLETED' AND NOT is_test AND completed_at >= $2 AND completed_at < $3
+			GROUP BY 1
+		) r ON r.day = d.day
+		ORDER BY d.day`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, since, until)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	days, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DailyTotals, error) {
+		var d models.DailyTotals
+		err := row.Scan(&d.Day, &d.Rides, &d.Earnings, &d.OnlineHours)
+		return d, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return days, nil
+}
+
+// GetHourlyEarnings возвращает заработок водителя по часам [since, until). Часы без поездок пропускаются.
+func (r *SessionRepo) GetHourlyEarnings(ctx context.Context, driverID uuid.UUID, since, until time.Time) ([]models.HourlyEarnings, error) {
+	const op = "SessionRepo.GetHourlyEarnings"
+	query := `
+		SELECT date_trunc('hour', completed_at, 'UTC'), count(*), sum(COALESCE(final_fare, estimated_fare))::float
+		FROM rides
+		WHERE driver_id = $1 AND status = 'COMPLETED' AND NOT is_test AND completed_at >= $2 AND completed_at < $3
+		GROUP BY 1
+		ORDER BY 1`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, since, until)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	hours, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.HourlyEarnings, error) {
+		var h models.HourlyEarnings
+		err := row.Scan(&h.Hour, &h.Rides, &h.Earnings)
+		return h, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return hours, nil
+}
+
+// List возвращает страницу сессий водителя. Расстояние считается по парам соседних точек location_history
+// внутри сессии, только для сессий страницы.
+func (r *SessionRepo) List(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverSessionHistory, error) {
+	const op = "SessionRepo.List"
+
+	order := "started_at DESC"
+	if filters.Sort == "started_at" {
+		order = "started_at ASC"
+	}
+
+	query := fmt.Sprintf(`
+		WITH page AS (
+			SELECT count(*) OVER() AS total_count, id, driver_id, started_at, ended_at,
+				COALESCE(total_rides, 0) AS total_rides, COALESCE(total_earnings, 0)::float AS total_earnings
+			FROM driver_sessions
+			WHERE driver_id = $1
+			ORDER BY %[1]s, id
+			LIMIT $2 OFFSET $3
+		)
+		SELECT p.total_count, p.id, p.started_at, p.ended_at,
+			EXTRACT(EPOCH FROM (COALESCE(p.ended_at, now()) - p.started_at)) / 3600.0,
+			p.total_rides, p.total_earnings,
+			COALESCE((
+				SELECT sum(ST_Distance(
+					ST_MakePoint(h.prev_longitude, h.prev_latitude)::geography,
+					ST_MakePoint(h.longitude, h.latitude)::geography
+				)) / 1000
+				FROM (
+					SELECT latitude, longitude,
+						lag(latitude) OVER (ORDER BY recorded_at) AS prev_latitude,
+						lag(longitude) OVER (ORDER BY recorded_at) AS prev_longitude
+					FROM location_history
+					WHERE driver_id = p.driver_id
+						AND recorded_at >= p.started_at
+						AND recorded_at <= COALESCE(p.ended_at, now())
+				) h
+				WHERE h.prev_latitude IS NOT NULL
+			), 0)::float
+		FROM page p
+		ORDER BY %[1]s, p.id`, order)
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, driverID, filters.Limit(), filters.Offset())
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	totalRecords := 0
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DriverSession, error) {
+		var session models.DriverSession
+		err := row.Scan(
+			&totalRecords,
+			&session.ID,
+			&session.StartedAt,
+			&session.EndedAt,
+			&session.DurationHours,
+			&session.RidesCompleted,
+			&session.Earnings,
+			&session.DistanceKm,
+		)
+		return session, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return &models.DriverSessionHistory{
+		DriverID: driverID,
+		Sessions: sessions,
+		Metadata: models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+type SupplyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSupplyRepo(db *pgxpool.Pool) *SupplyRepo {
+	return &SupplyRepo{
+		db: db,
+	}
+}
+
+// OnlineDrivers считает водителей онлайн по городу текущей координаты и классу автомобиля.
+// Город — ближайший, в радиус которого попадает водитель; водители-симуляторы не учитываются.
+func (r *SupplyRepo) OnlineDrivers(ctx context.Context) ([]models.SupplyCount, error) {
+	const op = "SupplyRepo.OnlineDrivers"
+	query := `
+		SELECT coalesce(city.code, ''), d.vehicle_type, count(*)
+		FROM drivers d
+		LEFT JOIN coordinates c ON c.entity_id = d.id
+			AND c.entity_type = 'driver'
+			AND c.is_current = true
+		LEFT JOIN LATERAL (
+			SELECT cs.code
+			FROM city_settings cs
+			WHERE ST_DWithin(
+				ST_MakePoint(cs.center_longitude, cs.center_latitude)::geography,
+				ST_MakePoint(c.longitude, c.latitude)::geography,
+				cs.radius_km * 1000
+			)
+			ORDER BY ST_Distance(
+				ST_MakePoint(cs.center_longitude, cs.center_latitude)::geography,
+				ST_MakePoint(c.longitude, c.latitude)::geography
+			)
+			LIMIT 1
+		) city ON true
+		WHERE d.status <> 'OFFLINE'
+		  AND d.is_simulator = false
+		  AND d.vehicle_type IS NOT NULL
+		GROUP BY 1, 2`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	counts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SupplyCount, error) {
+		var c models.SupplyCount
+		err := row.Scan(&c.City, &c.Class, &c.Online)
+		return c, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return counts, nil
+}
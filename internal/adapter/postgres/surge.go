@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// ZoneDemand считает ожидающие водителя запросы класса rideType в geohash ячейке zone с since
+// и свободных водителей этого класса в ячейке. Тестовые поездки и симуляторы не учитываются.
+func (r *RideRepo) ZoneDemand(ctx context.Context, zone, rideType string, since time.Time) (models.ZoneDemand, error) {
+	const op = "RideRepo.ZoneDemand"
+	query := `
+		SELECT
+			(SELECT count(*)
+			 FROM rides r
+			 JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
+			 WHERE r.status = 'REQUESTED'
+			   AND r.created_at >= $3
+			   AND r.vehicle_type = $2
+			   AND NOT r.is_test
+			   AND ST_GeoHash(ST_SetSRID(ST_MakePoint(pc.longitude, pc.latitude), 4326), length($1)) = $1),
+			(SELECT count(*)
+			 FROM drivers d
+			 JOIN coordinates c ON c.entity_id = d.id AND c.entity_type = 'driver' AND c.is_current
+			 WHERE d.status = 'AVAILABLE'
+			   AND d.vehicle_type = $2
+			   AND NOT d.is_simulator
+			   AND ST_GeoHash(ST_SetSRID(ST_MakePoint(c.longitude, c.latitude), 4326), length($1)) = $1)`
+
+	demand := models.ZoneDemand{Zone: zone}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, zone, rideType, since).Scan(&demand.Requests, &demand.AvailableDrivers); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return demand, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return demand, nil
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// SaveTelemetry сохраняет отчет о качестве приложения, повторный отчет той же роли заменяет прежний
+func (r *RideRepo) SaveTelemetry(ctx context.Context, t models.AppTelemetry) error {
+	const op = "RideRepo.SaveTelemetry"
+	query := `
+		INSERT INTO ride_telemetry(ride_id, role, platform, app_version, ws_disconnects, max_location_gap_sec, offer_round_trip_ms)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (ride_id, role)
+		DO UPDATE SET platform = EXCLUDED.platform,
+			app_version = EXCLUDED.app_version,
+			ws_disconnects = EXCLUDED.ws_disconnects,
+			max_location_gap_sec = EXCLUDED.max_location_gap_sec,
+			offer_round_trip_ms = EXCLUDED.offer_round_trip_ms,
+			created_at = now()`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query,
+		t.RideID,
+		t.Role,
+		t.Platform,
+		t.AppVersion,
+		t.WSDisconnects,
+		t.MaxLocationGapSec,
+		t.OfferRoundTripMs,
+	); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// GetTelemetryReport агрегирует отчеты о качестве приложения с since по роли, платформе и версии
+func (r *AdminRepo) GetTelemetryReport(ctx context.Context, since time.Time) ([]models.TelemetryGroup, error) {
+	const op = "AdminRepo.GetTelemetryReport"
+	query := `
+		SELECT role, platform, app_version,
+		       count(*),
+		       avg((ws_disconnects > 0)::int)::float,
+		       avg(ws_disconnects)::float,
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY max_location_gap_sec)::float,
+		       percentile_cont(0.95) WITHIN GROUP (ORDER BY max_location_gap_sec)::float,
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY offer_round_trip_ms)::float,
+		       percentile_cont(0.95) WITHIN GROUP (ORDER BY offer_round_trip_ms)::float
+		FROM ride_telemetry
+		WHERE created_at >= $1
+		GROUP BY role, platform, app_version
+		ORDER BY role, platform, app_version`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, since)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TelemetryGroup, error) {
+		var g models.TelemetryGroup
+		err := row.Scan(
+			&g.Role,
+			&g.Platform,
+			&g.AppVersion,
+			&g.Reports,
+			&g.DisconnectRate,
+			&g.AvgDisconnects,
+			&g.P50LocationGapSec,
+			&g.P95LocationGapSec,
+			&g.P50OfferRoundTripMs,
+			&g.P95OfferRoundTripMs,
+		)
+		return g, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return groups, nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 
+	"github.com/Temutjin2k/ride-hail-system/pkg/incident"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
 
 	"github.com/jackc/pgx/v5"
@@ -16,10 +17,14 @@ type Querier interface {
 	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
 }
 
+// TxorDB возвращает транзакцию из контекста, иначе реплику в режиме инцидента, иначе пул
 func TxorDB(ctx context.Context, db *pgxpool.Pool) Querier {
 	tx, ok := ctx.Value(trm.TxKey).(pgx.Tx)
-	if !ok {
-		return db
+	if ok {
+		return tx
 	}
-	return tx
+	if replica, ok := incident.ReplicaFromContext(ctx); ok {
+		return replica
+	}
+	return db
 }
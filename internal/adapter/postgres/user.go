@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 	"github.com/jackc/pgx/v5"
@@ -16,12 +18,14 @@ import (
 )
 
 type UserRepo struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	pii *keyring.Keyring // шифрует телефон в attrs
 }
 
-func NewUserRepo(db *pgxpool.Pool) *UserRepo {
+func NewUserRepo(db *pgxpool.Pool, pii *keyring.Keyring) *UserRepo {
 	return &UserRepo{
-		db: db,
+		db:  db,
+		pii: pii,
 	}
 }
 
@@ -34,8 +38,11 @@ func (r *UserRepo) CreateUser(ctx context.Context, u *models.User) (uuid.UUID, e
 
 	var attrsJSON []byte
 	if u.Attrs != nil {
-		var err error
-		attrsJSON, err = json.Marshal(u.Attrs)
+		attrs, err := encryptAttrs(r.pii, u.Attrs)
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+		attrsJSON, err = json.Marshal(attrs)
 		if err != nil {
 			return uuid.UUID{}, err
 		}
@@ -76,7 +83,7 @@ func (r *UserRepo) GetUser(ctx context.Context, email string) (*models.User, err
 	}
 
 	const q = `
-		SELECT id, created_at, updated_at, email, role, status, password_hash, attrs
+		SELECT id, created_at, updated_at, email, role, status, password_hash, attrs, phone_verified_at
 		FROM users
 		WHERE email = $1;
 	`
@@ -94,6 +101,7 @@ func (r *UserRepo) GetUser(ctx context.Context, email string) (*models.User, err
 		&u.Status,
 		&u.PasswordHash,
 		&attrsJSON,
+		&u.PhoneVerifiedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -105,6 +113,9 @@ func (r *UserRepo) GetUser(ctx context.Context, email string) (*models.User, err
 	if len(attrsJSON) > 0 {
 		_ = json.Unmarshal(attrsJSON, &u.Attrs) // tolerate malformed attrs; optionally handle error
 	}
+	if err := decryptAttrs(r.pii, u.Attrs); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
 
@@ -115,7 +126,7 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User,
 	}
 
 	const q = `
-		SELECT id, created_at, updated_at, email, role, status, password_hash, attrs
+		SELECT id, created_at, updated_at, email, role, status, password_hash, attrs, phone_verified_at
 		FROM users
 		WHERE id = $1;
 	`
@@ -134,6 +145,7 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User,
 		&u.Status,
 		&u.PasswordHash,
 		&attrsJSON,
+		&u.PhoneVerifiedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -145,6 +157,9 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User,
 	if len(attrsJSON) > 0 {
 		_ = json.Unmarshal(attrsJSON, &u.Attrs)
 	}
+	if err := decryptAttrs(r.pii, u.Attrs); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
 
@@ -173,3 +188,90 @@ func (r *UserRepo) ChangeRole(ctx context.Context, userID uuid.UUID, new types.U
 
 	return old, nil
 }
+
+// UpdateAttrs дописывает ключи в users.attrs, PII значения шифруются
+func (r *UserRepo) UpdateAttrs(ctx context.Context, userID uuid.UUID, attrs map[string]any) error {
+	const op = "UserRepo.UpdateAttrs"
+
+	attrs, err := encryptAttrs(r.pii, attrs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	attrsJSON, err := json.Marshal(attrs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+		UPDATE users
+		SET attrs = attrs || $2::jsonb, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, userID, string(attrsJSON))
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetPhoneVerified отмечает телефон пользователя подтвержденным в момент at, nil сбрасывает подтверждение
+func (r *UserRepo) SetPhoneVerified(ctx context.Context, userID uuid.UUID, at *time.Time) error {
+	const op = "UserRepo.SetPhoneVerified"
+	query := `
+		UPDATE users
+		SET phone_verified_at = $2, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, userID, at)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	if tag.RowsAffected() == 0 {
+		return types.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// IsPhoneVerified сообщает, подтвердил ли пользователь телефон
+func (r *UserRepo) IsPhoneVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	const op = "UserRepo.IsPhoneVerified"
+
+	var verified bool
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, `SELECT phone_verified_at IS NOT NULL FROM users WHERE id = $1`, userID).Scan(&verified); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, types.ErrUserNotFound
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return verified, nil
+}
+
+// UpdatePhone сохраняет телефон в attrs. Новый номер нужно подтвердить заново, поэтому при смене
+// номера подтверждение сбрасывается. Телефон зашифрован, поэтому сравнивается после расшифровки.
+func (r *UserRepo) UpdatePhone(ctx context.Context, userID uuid.UUID, phone string) error {
+	user, err := r.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return types.ErrUserNotFound
+	}
+
+	if current, _ := user.Attrs["phone"].(string); current == phone {
+		return nil
+	}
+
+	if err := r.UpdateAttrs(ctx, userID, map[string]any{"phone": phone}); err != nil {
+		return err
+	}
+	return r.SetPhoneVerified(ctx, userID, nil)
+}
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type WalletRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletRepo(db *pgxpool.Pool) *WalletRepo {
+	return &WalletRepo{
+		db: db,
+	}
+}
+
+// Get возвращает кошелек пассажира, у пассажира без кошелька — нулевой баланс
+func (r *WalletRepo) Get(ctx context.Context, passengerID uuid.UUID) (*models.Wallet, error) {
+	const op = "WalletRepo.Get"
+	query := `
+		SELECT balance::float, held::float, updated_at
+		FROM wallets
+		WHERE passenger_id = $1`
+
+	w := &models.Wallet{PassengerID: passengerID}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, passengerID).Scan(&w.Balance, &w.Held, &w.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return w, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return w, nil
+}
+
+// Entries возвращает последние записи журнала кошелька, новые первыми
+func (r *WalletRepo) Entries(ctx context.Context, passengerID uuid.UUID, limit int) ([]models.LedgerEntry, error) {
+	const op = "WalletRepo.Entries"
+	query := `
+		SELECT id, passenger_id, ride_id, entry_type, amount::float, balance_after::float, transaction_id, created_at
+		FROM wallet_ledger
+		WHERE passenger_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, passengerID, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.LedgerEntry, error) {
+		var e models.LedgerEntry
+		err := row.Scan(&e.ID, &e.PassengerID, &e.RideID, &e.Type, &e.Amount, &e.BalanceAfter, &e.TransactionID, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return entries, nil
+}
+
+// TopUp зачисляет оплаченное у провайдера пополнение, создавая кошелек при первом пополнении
+func (r *WalletRepo) TopUp(ctx context.Context, passengerID uuid.UUID, amount float64, transactionID string) (*models.Wallet, error) {
+	const op = "WalletRepo.TopUp"
+	query := `
+		INSERT INTO wallets(passenger_id, balance)
+		VALUES($1, $2)
+		ON CONFLICT (passenger_id)
+		DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, updated_at = now()
+		RETURNING balance::float, held::float, updated_at`
+
+	w := &models.Wallet{PassengerID: passengerID}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, passengerID, amount).Scan(&w.Balance, &w.Held, &w.UpdatedAt); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if err := r.addEntry(ctx, passengerID, nil, types.LedgerTopUp, amount, w.Balance, &transactionID); err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return w, nil
+}
+
+// Hold блокирует amount под поездку. false — баланса не хватает, кошелек не меняется.
+func (r *WalletRepo) Hold(ctx context.Context, passengerID, rideID uuid.UUID, amount float64) (bool, error) {
+	const op = "WalletRepo.Hold"
+	query := `
+		UPDATE wallets
+		SET balance = balance - $2, held = held + $2, updated_at = now()
+		WHERE passenger_id = $1 AND balance >= $2
+		RETURNING balance::float`
+
+	var balance float64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, passengerID, amount).Scan(&balance); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if err := r.addEntry(ctx, passengerID, &rideID, types.LedgerHold, amount, balance, nil); err != nil {
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return true, nil
+}
+
+// OpenHold возвращает сумму блокировки поездки, еще не списанной и не возвращенной
+func (r *WalletRepo) OpenHold(ctx context.Context, rideID uuid.UUID) (float64, bool, error) {
+	const op = "WalletRepo.OpenHold"
+	query := `
+		SELECT h.amount::float
+		FROM wallet_ledger h
+		WHERE h.ride_id = $1 AND h.entry_type = 'HOLD'
+			AND NOT EXISTS (
+				SELECT 1 FROM wallet_ledger s
+				WHERE s.ride_id = h.ride_id AND s.entry_type IN ('CAPTURE', 'RELEASE')
+			)`
+
+	var amount float64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, rideID).Scan(&amount); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return 0, false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return amount, true, nil
+}
+
+// Capture списывает стоимость поездки: блокировка held снимается, разница с amount
+// возвращается на баланс или доплачивается с него. false — баланса не хватает на доплату.
+func (r *WalletRepo) Capture(ctx context.Context, passengerID, rideID uuid.UUID, held, amount float64) (bool, error) {
+	const op = "WalletRepo.Capture"
+	query := `
+		UPDATE wallets
+		SET held = held - $2, balance = balance + $2 - $3, updated_at = now()
+		WHERE passenger_id = $1 AND balance + $2 >= $3
+		RETURNING balance::float`
+
+	var balance float64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, passengerID, held, amount).Scan(&balance); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if err := r.addEntry(ctx, passengerID, &rideID, types.LedgerCapture, amount, balance, nil); err != nil {
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return true, nil
+}
+
+// Release возвращает блокировку поездки на баланс
+func (r *WalletRepo) Release(ctx context.Context, passengerID, rideID uuid.UUID, held float64) error {
+	const op = "WalletRepo.Release"
+	query := `
+		UPDATE wallets
+		SET held = held - $2, balance = balance + $2, updated_at = now()
+		WHERE passenger_id = $1
+		RETURNING balance::float`
+
+	var balance float64
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, passengerID, held).Scan(&balance); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	if err := r.addEntry(ctx, passengerID, &rideID, types.LedgerRelease, held, balance, nil); err != nil {
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+// RecordCardFallback записывает в журнал оплату поездки картой вместо кошелька.
+// transactionID пуст, если списание с карты не проводилось.
+func (r *WalletRepo) RecordCardFallback(ctx context.Context, passengerID, rideID uuid.UUID, amount float64, transactionID *string) error {
+	const op = "WalletRepo.RecordCardFallback"
+	query := `
+		INSERT INTO wallet_ledger(passenger_id, ride_id, entry_type, amount, balance_after, transaction_id)
+		VALUES($1, $2, $3, $4, coalesce((SELECT balance FROM wallets WHERE passenger_id = $1), 0), $5)`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, passengerID, rideID, types.LedgerCardFallback, amount, transactionID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return nil
+}
+
+func (r *WalletRepo) addEntry(ctx context.Context, passengerID uuid.UUID, rideID *uuid.UUID, entryType types.LedgerEntryType, amount, balanceAfter float64, transactionID *string) error {
+	query := `
+		INSERT INTO wallet_ledger(passenger_id, ride_id, entry_type, amount, balance_after, transaction_id)
+		VALUES($1, $2, $3, $4, $5, $6)`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, passengerID, rideID, entryType, amount, balanceAfter, transactionID); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+type WarehouseRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWarehouseRepo(db *pgxpool.Pool) *WarehouseRepo {
+	return &WarehouseRepo{
+		db: db,
+	}
+}
+
+// Lease берет набор данных в версии схемы на время выгрузки одной пачки и возвращает прогресс.
+// Для новой версии схемы прогресс создается с пустым курсором. nil — набор выгружает другой
+// экземпляр admin-service, аренда которого еще не истекла.
+func (r *WarehouseRepo) Lease(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int, lease time.Duration) (*models.WarehouseWatermark, error) {
+	const op = "WarehouseRepo.Lease"
+	query := `
+		INSERT INTO warehouse_exports (dataset, schema_version, leased_until)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (dataset, schema_version) DO UPDATE SET leased_until = excluded.leased_until
+		WHERE warehouse_exports.leased_until IS NULL OR warehouse_exports.leased_until <= now()
+		RETURNING cursor_at, cursor_id, rows_exported`
+
+	wm := models.WarehouseWatermark{Dataset: dataset, SchemaVersion: schemaVersion}
+	if err := TxorDB(ctx, r.db).QueryRow(ctx, query, dataset, schemaVersion, lease.Seconds()).Scan(
+		&wm.Cursor.At,
+		&wm.Cursor.ID,
+		&wm.RowsExported,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return &wm, nil
+}
+
+// Release снимает аренду набора, когда новых строк нет
+func (r *WarehouseRepo) Release(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int) error {
+	const op = "WarehouseRepo.Release"
+	query := `
+		UPDATE warehouse_exports
+		SET leased_until = NULL, last_run_at = now(), last_error = NULL
+		WHERE dataset = $1 AND schema_version = $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, dataset, schemaVersion); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
+
+// Advance сдвигает курсор с from на to после записи пачки в хранилище и снимает аренду.
+// false — курсор уже не from: аренда истекла и пачку записал другой экземпляр.
+func (r *WarehouseRepo) Advance(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int, from, to models.WarehouseCursor, rows int) (bool, error) {
+	const op = "WarehouseRepo.Advance"
+	query := `
+		UPDATE warehouse_exports
+		SET cursor_at = $5, cursor_id = $6, rows_exported = rows_exported + $7,
+		    leased_until = NULL, last_run_at = now(), last_error = NULL
+		WHERE dataset = $1 AND schema_version = $2 AND cursor_at = $3 AND cursor_id = $4`
+
+	tag, err := TxorDB(ctx, r.db).Exec(ctx, query, dataset, schemaVersion, from.At, from.ID, to.At, to.ID, rows)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return false, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Fail записывает ошибку последнего запуска и снимает аренду, курсор не меняется
+func (r *WarehouseRepo) Fail(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int, errMsg string) error {
+	const op = "WarehouseRepo.Fail"
+	query := `
+		UPDATE warehouse_exports
+		SET leased_until = NULL, last_run_at = now(), last_error = $3
+		WHERE dataset = $1 AND schema_version = $2`
+
+	if _, err := TxorDB(ctx, r.db).Exec(ctx, query, dataset, schemaVersion, errMsg); err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return nil
+}
+
+// Rides возвращает до limit завершенных или отмененных поездок после курсора и не позже until.
+// Тестовые поездки не выгружаются.
+func (r *WarehouseRepo) Rides(ctx context.Context, after models.WarehouseCursor, until time.Time, limit int) ([]models.ExportRide, error) {
+	const op = "WarehouseRepo.Rides"
+	query := `
+		SELECT r.id, r.passenger_id, r.driver_id, coalesce(r.vehicle_type, ''), coalesce(r.served_vehicle_type, ''),
+		       r.status, r.payment_method,
+		       pc.latitude::float, pc.longitude::float, dc.latitude::float, dc.longitude::float,
+		       r.requested_at, r.matched_at, r.started_at, r.completed_at, r.cancelled_at,
+		       r.estimated_fare::float, r.final_fare::float, r.cancellation_fee::float,
+		       r.surge_multiplier::float, r.distance_km::float, r.co2_grams::float,
+		       coalesce(r.completed_at, r.cancelled_at) AS finished_at
+		FROM rides r
+		LEFT JOIN coordinates pc ON pc.id = r.pickup_coordinate_id
+		LEFT JOIN coordinates dc ON dc.id = r.destination_coordinate_id
+		WHERE r.status IN ('COMPLETED', 'CANCELLED') AND NOT r.is_test
+		  AND (coalesce(r.completed_at, r.cancelled_at), r.id) > ($1, $2)
+		  AND coalesce(r.completed_at, r.cancelled_at) <= $3
+		ORDER BY coalesce(r.completed_at, r.cancelled_at), r.id
+		LIMIT $4`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, after.At, after.ID, until, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	rides, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.ExportRide, error) {
+		var (
+			ride             models.ExportRide
+			pickLat, pickLng *float64
+			destLat, destLng *float64
+		)
+		err := row.Scan(
+			&ride.ID, &ride.PassengerID, &ride.DriverID, &ride.VehicleType, &ride.ServedVehicleType,
+			&ride.Status, &ride.PaymentMethod,
+			&pickLat, &pickLng, &destLat, &destLng,
+			&ride.RequestedAt, &ride.MatchedAt, &ride.StartedAt, &ride.CompletedAt, &ride.CancelledAt,
+			&ride.EstimatedFare, &ride.FinalFare, &ride.CancellationFee,
+			&ride.SurgeMultiplier, &ride.DistanceKm, &ride.Co2Grams,
+			&ride.FinishedAt,
+		)
+		if pickLat != nil && pickLng != nil {
+			ride.Pickup = &models.Location{Latitude: *pickLat, Longitude: *pickLng}
+		}
+		if destLat != nil && destLng != nil {
+			ride.Destination = &models.Location{Latitude: *destLat, Longitude: *destLng}
+		}
+		return ride, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return rides, nil
+}
+
+// RideEvents возвращает до limit событий поездок после курсора и не позже until.
+// События тестовых поездок не выгружаются.
+func (r *WarehouseRepo) RideEvents(ctx context.Context, after models.WarehouseCursor, until time.Time, limit int) ([]models.ExportRideEvent, error) {
+	const op = "WarehouseRepo.RideEvents"
+	query := `
+		SELECT e.id, e.ride_id, coalesce(e.event_type, ''), e.created_at
+		FROM ride_events e
+		JOIN rides r ON r.id = e.ride_id
+		WHERE NOT r.is_test
+		  AND (e.created_at, e.id) > ($1, $2)
+		  AND e.created_at <= $3
+		ORDER BY e.created_at, e.id
+		LIMIT $4`
+
+	rows, err := TxorDB(ctx, r.db).Query(ctx, query, after.At, after.ID, until, limit)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.ExportRideEvent, error) {
+		var e models.ExportRideEvent
+		err := row.Scan(&e.ID, &e.RideID, &e.EventType, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionDatabaseTransactionFailed)
+		return nil, wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	return events, nil
+}
@@ -0,0 +1,93 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+var ErrNoData = errors.New("prometheus query returned no data")
+
+// Client - минимальный клиент Prometheus HTTP API (только instant queries)
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Value [2]any `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Query выполняет instant query и возвращает значение первого элемента вектора (или скаляра)
+func (c *Client) Query(ctx context.Context, query string) (float64, error) {
+	const op = "PrometheusClient.Query"
+
+	endpoint := fmt.Sprintf("%s/api/v1/query?query=%s", c.baseURL, url.QueryEscape(query))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: failed to make request to prometheus: %w", op, err))
+	}
+	defer resp.Body.Close()
+
+	var payload queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: failed to decode prometheus response: %w", op, err))
+	}
+
+	if resp.StatusCode != http.StatusOK || payload.Status != "success" {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return 0, wrap.Error(ctx, fmt.Errorf("%s: unexpected response status %d: %s", op, resp.StatusCode, payload.Error))
+	}
+
+	var raw any
+	switch payload.Data.ResultType {
+	case "vector":
+		if len(payload.Data.Result) == 0 {
+			return 0, ErrNoData
+		}
+		raw = payload.Data.Result[0].Value[1]
+	default:
+		return 0, fmt.Errorf("%s: unsupported result type %q", op, payload.Data.ResultType)
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return 0, fmt.Errorf("%s: unexpected value type %T", op, raw)
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to parse value: %w", op, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,148 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/gcpauth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+const (
+	fcmURL   = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCM отправляет push уведомления через Firebase Cloud Messaging (HTTP v1 API).
+// FCM доставляет и на Android, и на iOS: на iOS сообщение уходит через APNs по ключу, загруженному в Firebase.
+// Уведомления о поездке отправляются с высоким приоритетом, чтобы дойти до устройства в режиме сна.
+type FCM struct {
+	endpoint string
+	tokens   *gcpauth.TokenSource
+	http     *http.Client
+}
+
+// NewFCM читает JSON ключ сервисного аккаунта Firebase проекта
+func NewFCM(credentialsFile string, timeout time.Duration) (*FCM, error) {
+	const op = "NewFCM"
+
+	account, err := gcpauth.Load(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if account.ProjectID == "" {
+		return nil, fmt.Errorf("%s: credentials must contain project_id", op)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	return &FCM{
+		endpoint: fmt.Sprintf(fcmURL, url.PathEscape(account.ProjectID)),
+		tokens:   gcpauth.NewTokenSource(account, fcmScope, client),
+		http:     client,
+	}, nil
+}
+
+type (
+	fcmRequest struct {
+		Message fcmMessage `json:"message"`
+	}
+
+	fcmMessage struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+		Android      fcmAndroid        `json:"android"`
+		APNs         fcmAPNs           `json:"apns"`
+	}
+
+	fcmNotification struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+
+	fcmAndroid struct {
+		Priority string `json:"priority"`
+	}
+
+	fcmAPNs struct {
+		Headers map[string]string `json:"headers"`
+	}
+)
+
+func (f *FCM) SendPush(ctx context.Context, token models.PushToken, title, body string, data map[string]string) error {
+	const op = "FCM.SendPush"
+
+	accessToken, err := f.tokens.Token(ctx)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	payload, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        token.Token,
+		Notification: fcmNotification{Title: title, Body: body},
+		Data:         data,
+		Android:      fcmAndroid{Priority: "HIGH"},
+		APNs:         fcmAPNs{Headers: map[string]string{"apns-priority": "10"}},
+	}})
+	if err != nil {
+		return fmt.Errorf("%s: marshal message: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var out struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Details []struct {
+				ErrorCode       string `json:"errorCode"`
+				FieldViolations []struct {
+					Field string `json:"field"`
+				} `json:"fieldViolations"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("%s: decode response (status %d): %w", op, resp.StatusCode, err)
+	}
+
+	// UNREGISTERED — приложение удалено или токен обновлен, INVALID_ARGUMENT с нарушением в message.token —
+	// строка не является токеном FCM. Повторять отправку на такой токен бессмысленно.
+	for _, d := range out.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" {
+			return types.ErrPushTokenInvalid
+		}
+		for _, v := range d.FieldViolations {
+			if v.Field == "message.token" {
+				return types.ErrPushTokenInvalid
+			}
+		}
+	}
+
+	ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+	return wrap.Error(ctx, fmt.Errorf("%s: unexpected response status %d: %s %s", op, resp.StatusCode, out.Error.Status, out.Error.Message))
+}
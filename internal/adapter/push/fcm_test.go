@@ -0,0 +1,147 @@
+package push
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// fcmServer — OAuth сервер Google и FCM: на /send отвечает status и body, пока они заданы
+type fcmServer struct {
+	*httptest.Server
+	status int
+	body   string
+
+	authorization string
+	message       fcmMessage
+}
+
+// newFCM создает отправителя с ключом сервисного аккаунта, обращающегося к fcmServer
+func newFCM(t *testing.T) (*FCM, *fcmServer) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fcmServer{status: http.StatusOK, body: `{"name":"projects/project/messages/1"}`}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "access", "expires_in": 3600})
+	})
+	mux.HandleFunc("POST /send", func(w http.ResponseWriter, r *http.Request) {
+		s.authorization = r.Header.Get("Authorization")
+		var req fcmRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.message = req.Message
+
+		w.WriteHeader(s.status)
+		w.Write([]byte(s.body))
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	credentials, err := json.Marshal(map[string]string{
+		"project_id":   "project",
+		"client_email": "push@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    s.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, credentials, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewFCM(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.endpoint != "https://fcm.googleapis.com/v1/projects/project/messages:send" {
+		t.Fatalf("unexpected endpoint %s", f.endpoint)
+	}
+	f.endpoint = s.URL + "/send"
+	return f, s
+}
+
+func TestFCM_SendPush(t *testing.T) {
+	f, server := newFCM(t)
+	token := models.PushToken{Token: "device-token"}
+
+	if err := f.SendPush(context.Background(), token, "Driver arrived", "Your driver is waiting", map[string]string{"event_type": "DRIVER_ARRIVED"}); err != nil {
+		t.Fatal(err)
+	}
+	if server.authorization != "Bearer access" {
+		t.Fatalf("authorization %q, want the exchanged access token", server.authorization)
+	}
+	msg := server.message
+	if msg.Token != "device-token" || msg.Notification.Title != "Driver arrived" || msg.Data["event_type"] != "DRIVER_ARRIVED" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if msg.Android.Priority != "HIGH" || msg.APNs.Headers["apns-priority"] != "10" {
+		t.Fatalf("ride push must be sent with high priority, got %+v %+v", msg.Android, msg.APNs)
+	}
+}
+
+// Токены, которые FCM больше не примет, отмечаются ErrPushTokenInvalid и удаляются вызывающим
+func TestFCM_SendPush_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantInvalid bool
+	}{
+		{
+			name:        "unregistered",
+			status:      http.StatusNotFound,
+			body:        `{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`,
+			wantInvalid: true,
+		},
+		{
+			name:        "invalid registration token",
+			status:      http.StatusBadRequest,
+			body:        `{"error":{"code":400,"message":"The registration token is not a valid FCM registration token","status":"INVALID_ARGUMENT","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"INVALID_ARGUMENT"},{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"message.token","description":"Invalid registration token"}]}]}}`,
+			wantInvalid: true,
+		},
+		{
+			name:   "invalid payload",
+			status: http.StatusBadRequest,
+			body:   `{"error":{"code":400,"message":"Invalid value at 'message.data'","status":"INVALID_ARGUMENT","details":[{"@type":"type.googleapis.com/google.rpc.BadRequest","fieldViolations":[{"field":"message.data","description":"Invalid value"}]}]}}`,
+		},
+		{
+			name:   "unavailable",
+			status: http.StatusServiceUnavailable,
+			body:   `{"error":{"code":503,"message":"The service is currently unavailable.","status":"UNAVAILABLE"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, server := newFCM(t)
+			server.status, server.body = tt.status, tt.body
+
+			err := f.SendPush(context.Background(), models.PushToken{Token: "device-token"}, "title", "body", nil)
+			if err == nil {
+				t.Fatal("failed send must return an error")
+			}
+			if got := errors.Is(err, types.ErrPushTokenInvalid); got != tt.wantInvalid {
+				t.Fatalf("token invalid %v, want %v: %v", got, tt.wantInvalid, err)
+			}
+		})
+	}
+}
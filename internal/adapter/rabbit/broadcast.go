@@ -0,0 +1,158 @@
+package rabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/rabbit"
+)
+
+const (
+	ExchangeBroadcastFanout = "broadcast_fanout"
+
+	QueueDriverBroadcasts    = "driver_broadcasts"
+	QueuePassengerBroadcasts = "passenger_broadcasts"
+)
+
+// BroadcastQueue возвращает очередь объявлений для роли получателя
+func BroadcastQueue(role types.UserRole) string {
+	if role == types.RoleDriver {
+		return QueueDriverBroadcasts
+	}
+	return QueuePassengerBroadcasts
+}
+
+// BroadcastHandler доставляет объявление администратора подключенным клиентам
+type BroadcastHandler func(ctx context.Context, msg models.BroadcastMessage) error
+
+// BroadcastBroker рассылает объявления администратора через exchange 'broadcast_fanout'.
+// Каждый сервис с WebSocket соединениями читает свою очередь.
+type BroadcastBroker struct {
+	client *rabbit.RabbitMQ
+	l      logger.Logger
+}
+
+func NewBroadcastBroker(client *rabbit.RabbitMQ, l logger.Logger) *BroadcastBroker {
+	return &BroadcastBroker{
+		client: client,
+		l:      l,
+	}
+}
+
+func (r *BroadcastBroker) PublishBroadcast(ctx context.Context, msg models.BroadcastMessage) error {
+	ctx = wrap.WithAction(ctx, "rabbitmq_publish_broadcast")
+
+	if err := r.client.EnsureConnection(ctx); err != nil {
+		r.l.Error(ctx, "ensure connection failed", err)
+		return wrap.Error(ctx, err)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to marshal message: %w", err))
+	}
+
+	pub := amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: wrap.GetRequestID(ctx),
+		Body:          body,
+		Timestamp:     time.Now(),
+	}
+	ctx, span := rabbit.StartPublish(ctx, ExchangeBroadcastFanout, "broadcast", &pub)
+	defer span.End()
+	r.client.Sign(&pub)
+
+	if err := retry(5, time.Second, func() error {
+		return r.client.Channel.PublishWithContext(
+			ctx,
+			ExchangeBroadcastFanout, // exchange
+			"broadcast",             // routing key
+			false,                   // mandatory
+			false,                   // immediate
+			pub,
+		)
+	}); err != nil {
+		span.RecordError(err)
+		return wrap.Error(ctx, fmt.Errorf("failed to publish with context: %w", err))
+	}
+
+	return nil
+}
+
+// ConsumeBroadcast читает объявления для получателей с ролью role
+func (r *BroadcastBroker) ConsumeBroadcast(ctx context.Context, role types.UserRole, handler BroadcastHandler) error {
+	ctx = wrap.WithAction(ctx, "rabbitmq_consume_broadcast")
+	queue := BroadcastQueue(role)
+
+	for {
+		if ctx.Err() != nil {
+			r.l.Debug(ctx, "consume broadcast stopped by context")
+			return nil
+		}
+
+		if err := r.client.EnsureConnection(ctx); err != nil {
+			r.l.Error(ctx, "ensure connection failed", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		msgs, err := r.client.Channel.Consume(queue, "", false, false, false, false, nil)
+		if err != nil {
+			r.l.Error(ctx, "consume failed", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		r.l.Info(ctx, "start consuming broadcasts", "queue", queue)
+
+	consumeLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				r.l.Info(ctx, "broadcast consumer shutting down")
+				return nil
+
+			case msg, ok := <-msgs:
+				if !ok {
+					r.l.Warn(ctx, "message channel closed, reconnecting...")
+					time.Sleep(2 * time.Second)
+					break consumeLoop
+				}
+
+				var req models.BroadcastMessage
+				if err := models.DecodeMessage(msg.Body, &req); err != nil {
+					r.l.Error(ctx, "failed to unmarshal broadcast", err)
+					msg.Nack(false, false)
+					continue
+				}
+
+				ctxx, err := r.client.Verify(wrap.WithRequestID(ctx, msg.CorrelationId), msg)
+				if err != nil {
+					r.l.Warn(ctxx, "rejecting message that failed service signature check", "reason", err.Error())
+					msg.Nack(false, false)
+					continue
+				}
+
+				ctxx, span := rabbit.StartConsume(ctxx, queue, msg)
+				err = handler(ctxx, req)
+				span.RecordError(err)
+				span.End()
+				if err != nil {
+					r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle broadcast", err)
+					msg.Nack(false, isRecoverableError(err))
+					continue
+				}
+
+				msg.Ack(false)
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package rabbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/rabbit"
+)
+
+const (
+	// ExchangeDeadLetter — x-dead-letter-exchange всех очередей (rabbitmq_definitions.json)
+	ExchangeDeadLetter = "dlx"
+
+	QueueDeadMessages      = "dead_messages"
+	RoutingKeyDeadMessages = "dead_messages"
+)
+
+// DeadLetterHandler сохраняет сообщение из dead letter очереди
+type DeadLetterHandler func(ctx context.Context, msg *models.FailedMessage) error
+
+// DeadLetterBroker собирает сообщения, которые потребители отклонили без возврата в очередь,
+// и отправляет их повторно в исходную очередь
+type DeadLetterBroker struct {
+	client *rabbit.RabbitMQ
+	ttl    time.Duration
+
+	l logger.Logger
+}
+
+func NewDeadLetterBroker(client *rabbit.RabbitMQ, ttl time.Duration, l logger.Logger) *DeadLetterBroker {
+	return &DeadLetterBroker{
+		client: client,
+		ttl:    ttl,
+		l:      l,
+	}
+}
+
+// declare объявляет exchange dlx и очередь dead_messages. Повторное объявление с теми же
+// параметрами ничего не меняет, поэтому вызывается при каждом (пере)подключении.
+func (r *DeadLetterBroker) declare() error {
+	ch := r.client.Channel
+
+	if err := ch.ExchangeDeclare(ExchangeDeadLetter, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead letter exchange: %w", err)
+	}
+
+	var args amqp.Table
+	if r.ttl > 0 {
+		args = amqp.Table{amqp.QueueMessageTTLArg: r.ttl.Milliseconds()}
+	}
+	if _, err := ch.QueueDeclare(QueueDeadMessages, true, false, false, false, args); err != nil {
+		return fmt.Errorf("declare dead letter queue: %w", err)
+	}
+
+	if err := ch.QueueBind(QueueDeadMessages, RoutingKeyDeadMessages, ExchangeDeadLetter, false, nil); err != nil {
+		return fmt.Errorf("bind dead letter queue: %w", err)
+	}
+	return nil
+}
+
+// ConsumeDeadLetters читает очередь dead_messages. Сообщение подтверждается после сохранения,
+// при ошибке сохранения оно возвращается в очередь.
+func (r *DeadLetterBroker) ConsumeDeadLetters(ctx context.Context, handler DeadLetterHandler) error {
+	ctx = wrap.WithAction(ctx, "rabbitmq_consume_dead_letters")
+
+	for {
+		if ctx.Err() != nil {
+			r.l.Debug(ctx, "consume dead letters stopped by context")
+			return nil
+		}
+
+		if err := r.client.EnsureConnection(ctx); err != nil {
+			r.l.Error(ctx, "ensure connection failed", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		if err := r.declare(); err != nil {
+			r.l.Error(ctx, "failed to declare dead letter queue", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		msgs, err := r.client.Channel.Consume(QueueDeadMessages, "", false, false, false, false, nil)
+		if err != nil {
+			r.l.Error(ctx, "consume failed", err)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		r.l.Info(ctx, "start consuming dead letters", "queue", QueueDeadMessages, "ttl", r.ttl.String())
+
+	consumeLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				r.l.Info(ctx, "dead letter consumer shutting down")
+				return nil
+
+			case msg, ok := <-msgs:
+				if !ok {
+					r.l.Warn(ctx, "message channel closed, reconnecting...")
+					time.Sleep(2 * time.Second)
+					break consumeLoop
+				}
+
+				failed := failedMessage(msg)
+				ctxx := wrap.WithRequestID(ctx, msg.CorrelationId)
+
+				// отправитель записывается, только если подпись верна; сообщение с неверной
+				// подписью все равно сохраняется — оно могло попасть в dead letter именно из-за нее
+				if verified, err := r.client.Verify(ctxx, msg); err == nil {
+					ctxx = verified
+					if producer := wrap.GetLogCtx(ctxx).CallerService; producer != "" {
+						failed.Producer = &producer
+					}
+				}
+
+				r.l.Warn(ctxx, "message dead-lettered",
+					"queue", failed.Queue,
+					"routing_key", failed.RoutingKey,
+					"reason", failed.Reason,
+					"death_count", failed.DeathCount,
+				)
+
+				if err := handler(ctxx, failed); err != nil {
+					r.l.Error(wrap.ErrorCtx(ctxx, err), "failed to store dead letter", err)
+					// не теряем сообщение, пауза — чтобы не крутить его при недоступной базе
+					time.Sleep(time.Second)
+					_ = msg.Nack(false, true)
+					continue
+				}
+
+				if err := msg.Ack(false); err != nil {
+					r.l.Error(ctx, "failed to ack message", err)
+				}
+			}
+		}
+	}
+}
+
+// Replay отправляет сообщение напрямую в исходную очередь через default exchange,
+// чтобы его получил только тот потребитель, который его отклонил
+func (r *DeadLetterBroker) Replay(ctx context.Context, msg *models.FailedMessage) error {
+	ctx = wrap.WithAction(ctx, "rabbitmq_replay_dead_letter")
+
+	if err := r.client.EnsureConnection(ctx); err != nil {
+		r.l.Error(ctx, "ensure connection failed", err)
+		return wrap.Error(ctx, err)
+	}
+
+	publishing := amqp.Publishing{
+		Body:      msg.Body,
+		Timestamp: time.Now(),
+	}
+	if msg.ContentType != nil {
+		publishing.ContentType = *msg.ContentType
+	}
+	if msg.CorrelationID != nil {
+		publishing.CorrelationId = *msg.CorrelationID
+	}
+	// повтор подписывается заново: отправителем становится admin-service, запустивший replay
+	ctx, span := rabbit.StartPublish(ctx, "", msg.Queue, &publishing)
+	defer span.End()
+	r.client.Sign(&publishing)
+
+	if err := r.client.Channel.PublishWithContext(
+		ctx,
+		"",        // default exchange
+		msg.Queue, // routing key — имя очереди
+		true,      // mandatory
+		false,     // immediate
+		publishing,
+	); err != nil {
+		span.RecordError(err)
+		return wrap.Error(ctx, fmt.Errorf("failed to replay message: %w", err))
+	}
+
+	return nil
+}
+
+// failedMessage читает исходную очередь и причину из заголовка x-death.
+// Первый элемент x-death — последний переход в dead letter.
+func failedMessage(d amqp.Delivery) *models.FailedMessage {
+	m := &models.FailedMessage{
+		Exchange:   d.Exchange,
+		RoutingKey: d.RoutingKey,
+		DeathCount: 1,
+		Body:       d.Body,
+		FailedAt:   time.Now(),
+	}
+	if d.CorrelationId != "" {
+		m.CorrelationID = &d.CorrelationId
+	}
+	if d.ContentType != "" {
+		m.ContentType = &d.ContentType
+	}
+
+	deaths, _ := d.Headers["x-death"].([]any)
+	if len(deaths) == 0 {
+		return m
+	}
+	death, ok := deaths[0].(amqp.Table)
+	if !ok {
+		return m
+	}
+
+	if queue, ok := death["queue"].(string); ok {
+		m.Queue = queue
+	}
+	if exchange, ok := death["exchange"].(string); ok {
+		m.Exchange = exchange
+	}
+	if keys, ok := death["routing-keys"].([]any); ok && len(keys) > 0 {
+		if key, ok := keys[0].(string); ok {
+			m.RoutingKey = key
+		}
+	}
+	if reason, ok := death["reason"].(string); ok {
+		m.Reason = reason
+	}
+	if count, ok := death["count"].(int64); ok {
+		m.DeathCount = int(count)
+	}
+	if ts, ok := death["time"].(time.Time); ok {
+		m.FailedAt = ts
+	}
+	return m
+}
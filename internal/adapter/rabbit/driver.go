@@ -54,6 +54,9 @@ func (r *DriverBroker) publish(ctx context.Context, exchange, routingKey string,
 		Timestamp:     time.Now(),
 		CorrelationId: wrap.GetRequestID(ctx),
 	}
+	ctx, span := rabbit.StartPublish(ctx, exchange, routingKey, &pub)
+	defer span.End()
+	r.client.Sign(&pub)
 
 	if err := retry(5, time.Second*2,
 		func() error {
@@ -66,6 +69,7 @@ func (r *DriverBroker) publish(ctx context.Context, exchange, routingKey string,
 				pub,
 			)
 		}); err != nil {
+		span.RecordError(err)
 		return fmt.Errorf("publish: %w", err)
 	}
 
@@ -149,7 +153,8 @@ func (r *DriverBroker) ConsumeRideRequest(ctx context.Context, fn ConsumeRideHan
 					break consumeLoop
 				}
 
-				go r.handleRideRequested(ctx, fn, msg)
+				// остановка потребителя не обрывает начатый поиск: его дожидается или прерывает Drain сервиса
+				go r.handleRideRequested(context.WithoutCancel(ctx), fn, msg)
 			}
 		}
 	}
@@ -200,16 +205,25 @@ func (r *DriverBroker) ConsumeStatusUpdate(ctx context.Context, fn MatchConfHand
 				// Обрабатываем сообщение
 				go func(msg amqp.Delivery) {
 					var req models.RideStatusUpdateMessage
-					if err := json.Unmarshal(msg.Body, &req); err != nil {
+					if err := models.DecodeMessage(msg.Body, &req); err != nil {
 						r.l.Error(ctx, "decode failed", err, "op", op)
 						_ = msg.Nack(false, false)
 						return
 					}
 
-					ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), msg.CorrelationId)
+					ctxx, err := r.client.Verify(wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), msg.CorrelationId), msg)
+					if err != nil {
+						r.l.Warn(ctxx, "rejecting message that failed service signature check", "reason", err.Error())
+						_ = msg.Nack(false, false)
+						return
+					}
+
+					ctxx, span := rabbit.StartConsume(ctxx, QueueRideStatus, msg)
+					defer span.End()
 
 					// Вызов обработчика
 					if err := fn(ctxx, req); err != nil {
+						span.RecordError(err)
 						r.l.Error(ctx, "failed to handle status update", err, "op", op)
 						_ = msg.Nack(false, false)
 						return
@@ -228,16 +242,37 @@ func (r *DriverBroker) handleRideRequested(ctx context.Context, fn ConsumeRideHa
 	ctx = wrap.WithAction(ctx, "rabbitmq_handle_ride_requested")
 
 	var req models.RideRequestedMessage
-	if err := json.Unmarshal(msg.Body, &req); err != nil {
+	if err := models.DecodeMessage(msg.Body, &req); err != nil {
 		r.l.Error(ctx, "decode failed", err)
 		_ = msg.Nack(false, false)
 		return
 	}
+	if err := req.Validate(); err != nil {
+		r.l.Warn(ctx, "dropping ride request", "reason", err.Error(), "ride_id", req.RideID)
+		_ = msg.Reject(false)
+		return
+	}
+
+	ctxx, err := r.client.Verify(wrap.WithRequestID(wrap.WithRideID(ctx, req.RideType), msg.CorrelationId), msg)
+	if err != nil {
+		r.l.Warn(ctxx, "rejecting message that failed service signature check", "reason", err.Error())
+		_ = msg.Nack(false, false)
+		return
+	}
 
-	ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideType), msg.CorrelationId)
+	ctxx, span := rabbit.StartConsume(ctxx, QueueRideRequests, msg)
+	defer span.End()
 
 	// Вызываем бизнес-обработчик
 	if err := fn(ctxx, req); err != nil {
+		// экземпляр останавливается — поиск продолжит другой
+		if errors.Is(err, types.ErrSearchInterrupted) {
+			r.l.Info(ctx, "returning ride request to the queue", "reason", err.Error())
+			_ = msg.Nack(false, true)
+			return
+		}
+
+		span.RecordError(err)
 		r.l.Error(ctx, "failed to handle ride request", err)
 
 		// Если водителей нет — это не ошибка, просто игнор
@@ -256,3 +291,80 @@ func (r *DriverBroker) handleRideRequested(ctx context.Context, fn ConsumeRideHa
 		r.l.Warn(ctx, "ack failed", err)
 	}
 }
+
+// ConsumeLocationStream читает все координаты водителей из location_fanout. В отличие от
+// location_updates, которую делят экземпляры ride-service, у каждого экземпляра своя временная
+// очередь: она удаляется вместе с соединением, поэтому координаты без получателя не копятся.
+// Сообщения не подтверждаются — пропущенную координату подхватит перестроение из Postgres.
+func (r *DriverBroker) ConsumeLocationStream(ctx context.Context, handler LocationUpdateHandler) error {
+	const op = "DriverBroker.ConsumeLocationStream"
+	ctx = wrap.WithAction(ctx, "rabbitmq_consume_location_stream")
+
+	for {
+		if ctx.Err() != nil {
+			r.l.Debug(ctx, "consume location stream stopped by context")
+			return nil
+		}
+
+		if err := r.client.EnsureConnection(ctx); err != nil {
+			r.l.Error(ctx, "ensure connection failed", err, "op", op)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		// имя очереди выдает сервер, exclusive — очередь живет, пока живо соединение
+		queue, err := r.client.Channel.QueueDeclare("", false, true, true, false, nil)
+		if err == nil {
+			err = r.client.Channel.QueueBind(queue.Name, "", ExchangeLocationFanout, false, nil)
+		}
+		if err != nil {
+			r.l.Error(ctx, "declare location stream queue failed", err, "op", op)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		msgs, err := r.client.Channel.Consume(queue.Name, "", true, true, false, false, nil)
+		if err != nil {
+			r.l.Error(ctx, "consume failed", err, "op", op)
+			time.Sleep(2 * time.Second)
+			continue
+		}
+
+		r.l.Info(ctx, "start consuming location stream", "queue", queue.Name)
+
+	consumeLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				r.l.Info(ctx, "location stream consumer shutting down", "op", op)
+				return nil
+
+			case msg, ok := <-msgs:
+				if !ok {
+					r.l.Warn(ctx, "message channel closed, reconnecting...", "op", op)
+					time.Sleep(2 * time.Second)
+					break consumeLoop
+				}
+
+				var req models.RideLocationUpdate
+				if err := models.DecodeMessage(msg.Body, &req); err != nil {
+					r.l.Error(ctx, "failed to unmarshal driver location update", err, "op", op)
+					continue
+				}
+				if err := req.Validate(); err != nil {
+					continue
+				}
+
+				ctxx, err := r.client.Verify(wrap.WithRequestID(ctx, msg.CorrelationId), msg)
+				if err != nil {
+					r.l.Warn(ctxx, "skipping message that failed service signature check", "reason", err.Error())
+					continue
+				}
+
+				if err := handler(ctxx, req); err != nil {
+					r.l.Error(wrap.ErrorCtx(ctxx, err), "failed to handle driver location update", err, "op", op)
+				}
+			}
+		}
+	}
+}
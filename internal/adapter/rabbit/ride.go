@@ -3,7 +3,6 @@ package rabbit
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"time"
 
@@ -13,7 +12,6 @@ import (
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/rabbit"
-	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
 const (
@@ -61,6 +59,18 @@ func (r *RideBroker) PublishRideRequested(ctx context.Context, msg models.RideRe
 	// ключ маршрутизации, example, "ride.request.ECONOMY"
 	key := fmt.Sprintf("ride.request.%s", msg.RideType)
 
+	pub := amqp091.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: msg.CorrelationID, // для трассировки
+		MessageId:     msg.MessageID,     // ключ идемпотентности из outbox
+		Body:          body,
+		Timestamp:     time.Now(),
+		Priority:      msg.Priority,
+	}
+	ctx, span := rabbit.StartPublish(ctx, r.RideExchange, key, &pub)
+	defer span.End()
+	r.client.Sign(&pub)
+
 	if err := retry(5, time.Second, func() error {
 		if err := r.client.Channel.PublishWithContext(
 			ctx,
@@ -68,19 +78,14 @@ func (r *RideBroker) PublishRideRequested(ctx context.Context, msg models.RideRe
 			key,            // routing key
 			true,           // mandatory
 			false,          // immediate
-			amqp091.Publishing{
-				ContentType:   "application/json",
-				CorrelationId: msg.CorrelationID, // для трассировки
-				Body:          body,
-				Timestamp:     time.Now(),
-				Priority:      msg.Priority,
-			},
+			pub,
 		); err != nil {
 			return wrap.Error(ctx, fmt.Errorf("failed to publish with context: %w", err))
 		}
 
 		return nil
 	}); err != nil {
+		span.RecordError(err)
 		return wrap.Error(ctx, err)
 	}
 
@@ -105,6 +110,17 @@ func (r *RideBroker) PublishRideStatus(ctx context.Context, msg models.RideStatu
 
 	key := fmt.Sprintf("ride.status.%s", msg.Status)
 
+	pub := amqp091.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: msg.CorrelationID,
+		MessageId:     msg.MessageID,
+		Body:          body,
+		Timestamp:     time.Now(),
+	}
+	ctx, span := rabbit.StartPublish(ctx, r.RideExchange, key, &pub)
+	defer span.End()
+	r.client.Sign(&pub)
+
 	if err := retry(5, time.Second, func() error {
 		if err := r.client.Channel.PublishWithContext(
 			ctx,
@@ -112,18 +128,14 @@ func (r *RideBroker) PublishRideStatus(ctx context.Context, msg models.RideStatu
 			key,            // routing key
 			false,          // mandatory
 			false,          // immediate
-			amqp091.Publishing{
-				ContentType:   "application/json",
-				CorrelationId: msg.CorrelationID,
-				Body:          body,
-				Timestamp:     time.Now(),
-			},
+			pub,
 		); err != nil {
 			return fmt.Errorf("failed to publish with context: %w", err)
 		}
 
 		return nil
 	}); err != nil {
+		span.RecordError(err)
 		return wrap.Error(ctx, err)
 	}
 
@@ -177,16 +189,25 @@ func (r *RideBroker) ConsumeDriverStatusUpdate(ctx context.Context, handler Driv
 
 				go func(d amqp091.Delivery) {
 					var req models.DriverStatusUpdateMessage
-					if err := json.Unmarshal(d.Body, &req); err != nil {
+					if err := models.DecodeMessage(d.Body, &req); err != nil {
 						r.l.Error(ctx, "failed to unmarshal driver match response", err)
 						d.Nack(false, false) // не подтверждаем сообщение
 						return
 					}
 
 					// добавляем в контекст переменные для логирования и трассировки
-					ctxx := wrap.WithRequestID(ctx, d.CorrelationId)
+					ctxx, err := r.client.Verify(wrap.WithRequestID(ctx, d.CorrelationId), d)
+					if err != nil {
+						r.l.Warn(ctxx, "rejecting message that failed service signature check", "reason", err.Error())
+						d.Nack(false, false)
+						return
+					}
+
+					ctxx, span := rabbit.StartConsume(ctxx, QueueDriverStatusUpdate, d)
+					defer span.End()
 
 					if err := handler(ctxx, req); err != nil {
+						span.RecordError(err)
 						r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver status update", err)
 
 						// если ошибка восстановимая, повторно помещаем в очередь
@@ -204,7 +225,9 @@ func (r *RideBroker) ConsumeDriverStatusUpdate(ctx context.Context, handler Driv
 
 type DriverResponseHandler func(ctx context.Context, req models.DriverMatchResponse) error
 
-func (r *RideBroker) ConsumeDriverResponse(ctx context.Context, targetRideID uuid.UUID, handler DriverResponseHandler) error {
+// ConsumeDriverResponses слушает ответы водителей по всем поездкам. Один потребитель
+// на экземпляр: ответ обрабатывается там, куда его доставила очередь, без возврата в очередь.
+func (r *RideBroker) ConsumeDriverResponses(ctx context.Context, handler DriverResponseHandler) error {
 	ctx = wrap.WithAction(ctx, "rabbitmq_consume_driver_response")
 
 	for {
@@ -232,9 +255,8 @@ func (r *RideBroker) ConsumeDriverResponse(ctx context.Context, targetRideID uui
 		for {
 			select {
 			case <-ctx.Done():
-				r.l.Info(ctx, "driver response consumer shutting down by context")
-				// не возвращаем внутреннюю ошибку — вызывающая сторона сама решит, что делать
-				return errors.New("consume driver response: context cancelled")
+				r.l.Info(ctx, "driver response consumer shutting down")
+				return nil
 			case msg, ok := <-msgs:
 				if !ok {
 					r.l.Warn(ctx, "message channel closed, reconnecting...")
@@ -242,56 +264,39 @@ func (r *RideBroker) ConsumeDriverResponse(ctx context.Context, targetRideID uui
 					break consumeLoop
 				}
 
-				var req models.DriverMatchResponse
-				if err := json.Unmarshal(msg.Body, &req); err != nil {
-					r.l.Error(ctx, "failed to unmarshal driver match response", err)
-					// отбросить сообщение (не подтверждаем и не перекинем)
-					if err2 := msg.Nack(false, false); err2 != nil {
-						r.l.Error(ctx, "nack failed", err2)
+				go func(d amqp091.Delivery) {
+					var req models.DriverMatchResponse
+					if err := models.DecodeMessage(d.Body, &req); err != nil {
+						r.l.Error(ctx, "failed to unmarshal driver match response", err)
+						d.Nack(false, false)
+						return
 					}
-					continue consumeLoop
-				}
 
-				if req.RideID != targetRideID {
-					// не наш rideID - вернуть в очередь для других потребителей
-					if err := msg.Nack(false, true); err != nil {
-						r.l.Error(ctx, "nack requeue failed", err)
+					ctxx, err := r.client.Verify(wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), d.CorrelationId), d)
+					if err != nil {
+						r.l.Warn(ctxx, "rejecting message that failed service signature check", "reason", err.Error())
+						d.Nack(false, false)
+						return
 					}
-					continue consumeLoop
-				}
-
-				ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), msg.CorrelationId)
 
-				// Выполняем обработчик
-				if err := handler(ctxx, req); err != nil {
-					r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver response", err)
+					ctxx, span := rabbit.StartConsume(ctxx, QueueDriverResponse, d)
+					defer span.End()
 
-					// решаем как ответить брокеру
-					if isRecoverableError(err) {
-						// перекидываем обратно в очередь
-						if derr := msg.Nack(false, true); derr != nil {
-							r.l.Error(ctx, "nack(requeue) failed", derr)
-						}
-					} else {
-						// отбросить сообщение
-						if derr := msg.Nack(false, false); derr != nil {
-							r.l.Error(ctx, "nack(discard) failed", derr)
+					if err := handler(ctxx, req); err != nil {
+						span.RecordError(err)
+						r.l.Error(wrap.ErrorCtx(ctxx, err), "failed to handle driver response", err)
+						if isRecoverableError(err) {
+							d.Nack(false, true)
+						} else {
+							d.Nack(false, false)
 						}
+						return
 					}
 
-					// После обработанного сообщения выходим (мы завершили ожидание для этого rideID)
-					return nil
-				}
-
-				// всё успешно -> подтверждаем
-				if err := msg.Ack(false); err != nil {
-					r.l.Error(ctx, "failed to ack message", err)
-					// Если ack не удался — пробуем nack без requeue (чтобы не застрять)
-					_ = msg.Nack(false, false)
-				}
-
-				// Успешная обработка — завершить потребителя для данного ride
-				return nil
+					if err := d.Ack(false); err != nil {
+						r.l.Error(ctxx, "failed to ack message", err)
+					}
+				}(msg)
 			}
 		}
 	}
@@ -341,11 +346,16 @@ func (r *RideBroker) ConsumeDriverLocationUpdate(ctx context.Context, handler Lo
 				// handle each message in its own goroutine
 				go func(d amqp091.Delivery) {
 					var req models.RideLocationUpdate
-					if err := json.Unmarshal(d.Body, &req); err != nil {
+					if err := models.DecodeMessage(d.Body, &req); err != nil {
 						r.l.Error(ctx, "failed to unmarshal driver location update", err)
 						_ = d.Nack(false, false)
 						return
 					}
+					if err := req.Validate(); err != nil {
+						r.l.Warn(ctx, "dropping driver location update", "reason", err.Error(), "driver_id", req.DriverID)
+						_ = d.Nack(false, false)
+						return
+					}
 
 					// enrich context for logging/tracing
 					if req.RideID == nil {
@@ -354,9 +364,18 @@ func (r *RideBroker) ConsumeDriverLocationUpdate(ctx context.Context, handler Lo
 						return
 					}
 
-					ctxx := wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), d.CorrelationId)
+					ctxx, err := r.client.Verify(wrap.WithRequestID(wrap.WithRideID(ctx, req.RideID.String()), d.CorrelationId), d)
+					if err != nil {
+						r.l.Warn(ctxx, "rejecting message that failed service signature check", "reason", err.Error())
+						d.Nack(false, false)
+						return
+					}
+
+					ctxx, span := rabbit.StartConsume(ctxx, QueueLocationUpdate, d)
+					defer span.End()
 
 					if err := handler(ctxx, req); err != nil {
+						span.RecordError(err)
 						r.l.Error(wrap.ErrorCtx(ctx, err), "failed to handle driver location update", err)
 						if isRecoverableError(err) {
 							_ = d.Nack(false, true) // requeue
@@ -0,0 +1,140 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/gcpauth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+const (
+	bigQueryURL   = "https://bigquery.googleapis.com/bigquery/v2"
+	bigQueryScope = "https://www.googleapis.com/auth/bigquery.insertdata"
+	// bigQueryChunk — строк в одном запросе insertAll, с запасом до лимита 10 МБ на запрос
+	bigQueryChunk = 500
+)
+
+type BigQueryConfig struct {
+	Project         string
+	Dataset         string
+	CredentialsFile string // JSON ключ сервисного аккаунта
+}
+
+// BigQuery вставляет пачки потоковой вставкой (tabledata.insertAll) в таблицу {dataset}_v{version}.
+// Таблицы создаются заранее. insertId — псевдоним строки, BigQuery отбрасывает повтор вставки.
+type BigQuery struct {
+	cfg    BigQueryConfig
+	tokens *gcpauth.TokenSource
+	http   *http.Client
+}
+
+func NewBigQuery(cfg BigQueryConfig, timeout time.Duration) (*BigQuery, error) {
+	const op = "NewBigQuery"
+
+	account, err := gcpauth.Load(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	return &BigQuery{
+		cfg:    cfg,
+		tokens: gcpauth.NewTokenSource(account, bigQueryScope, client),
+		http:   client,
+	}, nil
+}
+
+func (b *BigQuery) Write(ctx context.Context, batch models.WarehouseBatch) error {
+	const op = "BigQuerySink.Write"
+
+	token, err := b.tokens.Token(ctx)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+
+	table := fmt.Sprintf("%s_v%d", batch.Dataset, batch.SchemaVersion)
+	endpoint := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll",
+		bigQueryURL, url.PathEscape(b.cfg.Project), url.PathEscape(b.cfg.Dataset), url.PathEscape(table))
+
+	for start := 0; start < len(batch.Rows); start += bigQueryChunk {
+		end := min(start+bigQueryChunk, len(batch.Rows))
+		if err := b.insert(ctx, endpoint, token, batch.Rows[start:end]); err != nil {
+			ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+			return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+		}
+	}
+	return nil
+}
+
+func (b *BigQuery) insert(ctx context.Context, endpoint, token string, rows []models.WarehouseRow) error {
+	type insertRow struct {
+		InsertID string `json:"insertId"`
+		JSON     any    `json:"json"`
+	}
+	payload := struct {
+		Rows []insertRow `json:"rows"`
+	}{Rows: make([]insertRow, 0, len(rows))}
+	for _, row := range rows {
+		payload.Rows = append(payload.Rows, insertRow{InsertID: row.ID, JSON: row.Data})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal rows: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("decode response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg := ""
+		if out.Error != nil {
+			msg = out.Error.Message
+		}
+		return fmt.Errorf("unexpected response status %d: %s", resp.StatusCode, msg)
+	}
+	if len(out.InsertErrors) > 0 {
+		first := out.InsertErrors[0]
+		reason := ""
+		if len(first.Errors) > 0 {
+			reason = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		return fmt.Errorf("%d rows rejected, first at index %d: %s", len(out.InsertErrors), first.Index, reason)
+	}
+	return nil
+}
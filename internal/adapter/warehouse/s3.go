@@ -0,0 +1,229 @@
+// Package warehouse содержит приемники выгрузки в хранилище аналитики: S3 совместимое
+// объектное хранилище и BigQuery. Запросы подписываются без SDK, только стандартной библиотекой.
+package warehouse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+type S3Config struct {
+	Endpoint     string // пусто — AWS, иначе адрес S3 совместимого хранилища (MinIO и т.п.), path-style
+	Region       string
+	Bucket       string
+	Prefix       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// S3 пишет каждую пачку отдельным объектом gzip NDJSON (не Parquet: колоночного формата
+// без сторонней библиотеки нет, NDJSON читают Athena, BigQuery и Spark):
+// {prefix}/{dataset}/v{version}/dt={YYYY-MM-DD}/{name}.ndjson.gz.
+// Имя пачки зависит только от курсора до нее, повторная запись перезаписывает тот же объект.
+type S3 struct {
+	cfg  S3Config
+	http *http.Client
+}
+
+func NewS3(cfg S3Config, timeout time.Duration) *S3 {
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &S3{
+		cfg:  cfg,
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *S3) Write(ctx context.Context, batch models.WarehouseBatch) error {
+	const op = "S3Sink.Write"
+
+	body, err := encodeNDJSON(batch.Rows)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := s.objectKey(batch)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: unexpected response status %d: %s", op, resp.StatusCode, msg))
+	}
+	return nil
+}
+
+func (s *S3) objectKey(batch models.WarehouseBatch) string {
+	name := fmt.Sprintf("%s/v%d/dt=%s/%s.ndjson.gz",
+		batch.Dataset, batch.SchemaVersion, batch.To.At.UTC().Format(time.DateOnly), batch.Name)
+	if s.cfg.Prefix == "" {
+		return name
+	}
+	return s.cfg.Prefix + "/" + name
+}
+
+func (s *S3) objectURL(key string) string {
+	if s.cfg.Endpoint != "" {
+		return s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + escapePath(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.cfg.Bucket, s.cfg.Region, escapePath(key))
+}
+
+// sign подписывает запрос AWS Signature Version 4
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+
+	signed := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if s.cfg.SessionToken != "" {
+		signed = append(signed, "x-amz-security-token")
+	}
+	signV4(req, signed, payloadHash, sigV4Credentials{
+		AccessKey: s.cfg.AccessKey,
+		SecretKey: s.cfg.SecretKey,
+		Region:    s.cfg.Region,
+		Service:   "s3",
+	}, now)
+}
+
+const amzDateFormat = "20060102T150405Z"
+
+type sigV4Credentials struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+	Service   string
+}
+
+// signV4 ставит заголовок Authorization по AWS Signature Version 4. Подписываются заголовки
+// signed (в нижнем регистре) и payloadHash — hex SHA-256 тела, X-Amz-Date уже должен быть задан.
+func signV4(req *http.Request, signed []string, payloadHash string, creds sigV4Credentials, now time.Time) {
+	amzDate := now.Format(amzDateFormat)
+	date := now.Format("20060102")
+
+	signed = slices.Sorted(slices.Values(signed))
+	var headers strings.Builder
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(h + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + creds.Region + "/" + creds.Service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretKey), date)
+	key = hmacSHA256(key, creds.Region)
+	key = hmacSHA256(key, creds.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery — параметры запроса, отсортированные по имени и значению, в кодировке SigV4
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, escapeSegment(name)+"="+escapeSegment(v))
+		}
+	}
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+// encodeNDJSON кодирует строки в gzip NDJSON, по одной записи в строке
+func encodeNDJSON(rows []models.WarehouseRow) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, row := range rows {
+		if err := enc.Encode(row.Data); err != nil {
+			return nil, fmt.Errorf("encode row: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress rows: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// escapePath кодирует сегменты ключа как в канонической строке SigV4:
+// все, кроме A-Z a-z 0-9 - _ . ~, поэтому "=" в dt= передается как %3D
+func escapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = escapeSegment(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+func escapeSegment(p string) string {
+	var b strings.Builder
+	for _, c := range []byte(p) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// SignatureHeader - HMAC-SHA256 тела запроса секретом вебхука партнёра (hex)
+const SignatureHeader = "X-Webhook-Signature"
+
+// Client отправляет события на вебхуки партнёров
+type Client struct {
+	http *http.Client
+}
+
+func New(timeout time.Duration) *Client {
+	return &Client{
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send отправляет событие POST запросом, любой ответ кроме 2xx считается ошибкой
+func (c *Client) Send(ctx context.Context, url, secret string, event any) error {
+	const op = "WebhookClient.Send"
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: marshal event: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: %w", op, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		ctx = wrap.WithAction(ctx, types.ActionExternalServiceFailed)
+		return wrap.Error(ctx, fmt.Errorf("%s: unexpected response status %d", op, resp.StatusCode))
+	}
+
+	return nil
+}
+
+// Sign возвращает подпись тела вебхука, партнёр проверяет её тем же секретом
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
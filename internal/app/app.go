@@ -4,13 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/config"
 	"github.com/Temutjin2k/ride-hail-system/internal/app/microservices"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/migrations"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/tracing"
 )
 
+// tracingFlushTimeout — сколько сервис ждет выгрузки последних спанов при остановке
+const tracingFlushTimeout = 5 * time.Second
+
 var (
 	ErrInvalidMode           = errors.New("invalid mode")
 	ErrServiceNotInitialized = errors.New("service not initialized")
@@ -23,6 +32,7 @@ type Service interface {
 type App struct {
 	mode    types.ServiceMode
 	service Service
+	tracer  *tracing.Tracer // nil — трассировка выключена
 
 	cfg config.Config
 	log logger.Logger
@@ -30,12 +40,38 @@ type App struct {
 
 // NewApplication
 func NewApplication(ctx context.Context, cfg config.Config, log logger.Logger) (*App, error) {
+	models.SetServiceArea(models.ServiceArea{
+		MinLatitude:  cfg.ServiceArea.MinLatitude,
+		MaxLatitude:  cfg.ServiceArea.MaxLatitude,
+		MinLongitude: cfg.ServiceArea.MinLongitude,
+		MaxLongitude: cfg.ServiceArea.MaxLongitude,
+	})
+
 	app := &App{
 		mode: cfg.Mode,
 		cfg:  cfg,
 		log:  log,
 	}
 
+	if cfg.Observability.TracingEnabled {
+		tracer, err := tracing.Init(tracing.Config{
+			ServiceName:   cfg.Mode.String(),
+			Endpoint:      cfg.Observability.OTLPEndpoint,
+			SampleRatio:   cfg.Observability.TraceSampleRatio,
+			BatchSize:     cfg.Observability.TraceBatchSize,
+			FlushInterval: cfg.Observability.TraceFlushInterval,
+		}, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init tracing: %w", err)
+		}
+		app.tracer = tracer
+		log.Info(ctx, "tracing enabled", "endpoint", cfg.Observability.OTLPEndpoint, "sample_ratio", cfg.Observability.TraceSampleRatio)
+	}
+
+	if err := app.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+
 	if err := app.initService(ctx, app.mode); err != nil {
 		return nil, err
 	}
@@ -47,6 +83,10 @@ func (a *App) Run(ctx context.Context) error {
 	if a.service == nil {
 		return ErrServiceNotInitialized
 	}
+	defer a.shutdownTracing()
+
+	// секреты, заданные ссылками, перечитываются для хуков ротации сервисов
+	go a.cfg.SecretStore.Watch(ctx, a.cfg.Secrets.RefreshInterval, a.log)
 
 	if err := a.service.Start(ctx); err != nil {
 		return err
@@ -55,6 +95,19 @@ func (a *App) Run(ctx context.Context) error {
 	return nil
 }
 
+// shutdownTracing выгружает спаны, накопленные к остановке сервиса
+func (a *App) shutdownTracing() {
+	if a.tracer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := a.tracer.Shutdown(ctx); err != nil {
+		a.log.Warn(ctx, "failed to flush traces", "error", err.Error())
+	}
+}
+
 func (a *App) initService(ctx context.Context, mode types.ServiceMode) error {
 	var (
 		service Service
@@ -69,6 +122,8 @@ func (a *App) initService(ctx context.Context, mode types.ServiceMode) error {
 		service, err = microservices.NewAdmin(ctx, a.cfg, a.log)
 	case types.AuthService:
 		service, err = microservices.NewAuth(ctx, a.cfg, a.log)
+	case types.LocationService:
+		service, err = microservices.NewLocation(ctx, a.cfg, a.log)
 	default:
 		return ErrInvalidMode
 	}
@@ -84,3 +139,31 @@ func (a *App) initService(ctx context.Context, mode types.ServiceMode) error {
 
 	return nil
 }
+
+// checkSchema сверяет схему базы с миграциями до инициализации сервиса,
+// чтобы не выполненная миграция давала понятный отчет, а не ошибку на первом запросе
+func (a *App) checkSchema(ctx context.Context) error {
+	ctx = wrap.WithAction(ctx, "schema_check")
+	if a.cfg.SkipSchemaCheck {
+		a.log.Warn(ctx, "database schema check skipped")
+		return nil
+	}
+
+	expected, err := postgres.ExpectedSchema(migrations.FS)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	db, err := postgres.New(ctx, a.cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Pool.Close()
+
+	if err := postgres.CheckSchema(ctx, db.Pool, expected); err != nil {
+		return err
+	}
+
+	a.log.Info(ctx, "database schema matches migrations", "tables", len(expected.Tables), "indexes", len(expected.Indexes))
+	return nil
+}
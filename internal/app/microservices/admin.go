@@ -8,11 +8,22 @@ import (
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/alert"
 	httpserver "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/server"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/prometheus"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	warehousesink "github.com/Temutjin2k/ride-hail-system/internal/adapter/warehouse"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/internal/service/admin"
 	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
 	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/promo"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/supply"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/warehouse"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	postgresclient "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
@@ -21,6 +32,16 @@ import (
 type AdminService struct {
 	postgresDB *postgresclient.PostgreDB
 	httpServer *httpserver.API
+	incident   *incidentMode
+	brokers    *brokers
+	admin      *admin.AdminService
+	// deadLetters — nil с BROKER_BACKEND=postgres
+	deadLetters *rabbit.DeadLetterBroker
+	// warehouse — nil, если WAREHOUSE_TARGET не задан
+	warehouse *warehouse.Exporter
+	// supply — алерты о резком падении числа водителей онлайн
+	supply *supply.Monitor
+	caches *invalidation.Bus
 
 	cfg config.Config
 	log logger.Logger
@@ -33,27 +54,94 @@ func NewAdmin(ctx context.Context, cfg config.Config, log logger.Logger) (*Admin
 	}
 
 	// repositories
-	adminRepo := postgres.NewAdminRepo(db.Pool)
-	userRepo := postgres.NewUserRepo(db.Pool)
+	// PII encryption keyring
+	pii, err := keyring.New(cfg.PII.ActiveKey, cfg.PII.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	adminRepo := postgres.NewAdminRepo(db.Pool, pii)
+	cityRepo := postgres.NewCityRepo(db.Pool)
+	flatRateRepo := postgres.NewFlatRateRepo(db.Pool)
+	blackoutRepo := postgres.NewBlackoutRepo(db.Pool)
+	communicationRepo := postgres.NewCommunicationRepo(db.Pool)
+	userRepo := postgres.NewUserRepo(db.Pool, pii)
 	refreshTokenRepo := postgres.NewRefreshTokenRepo(db.Pool)
+	accessTokenDenylist := postgres.NewAccessTokenDenylistRepo(db.Pool)
+	deviceRepo := postgres.NewDeviceRepo(db.Pool, pii)
+	broadcastRepo := postgres.NewBroadcastRepo(db.Pool)
+	opsRepo := postgres.NewOpsRepo(db.Pool)
+	promoRepo := postgres.NewPromoRepo(db.Pool)
+	failedMessageRepo := postgres.NewFailedMessageRepo(db.Pool)
+
+	// message broker для объявлений
+	msgBrokers, err := newBrokers(cfg, db.Pool, log)
+	if err != nil {
+		return nil, err
+	}
+	broadcasts, err := msgBrokers.broadcastBroker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := msgBrokers.deadLetterBroker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var replayer admin.DeadLetterReplayer
+	if deadLetters != nil {
+		replayer = deadLetters
+	}
 
 	// services
-	calculator := ridecalc.New()
-	adminSvc := admin.NewAdminService(adminRepo, calculator, log)
+	clk := clock.New()
+	calculator := ridecalc.New().WithClock(clk)
+	prometheusClient := prometheus.New(cfg.Observability.PrometheusURL)
 	txManager := trm.New(db.Pool)
-	tokenSvc := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, txManager, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, log)
-	authSvc := auth.NewAuthService(userRepo, tokenSvc, log)
+	exportOpts := admin.ExportOptions{
+		SyncMaxItems: cfg.Export.SyncMaxItems,
+		MaxItems:     cfg.Export.MaxItems,
+		Timeout:      cfg.Export.Timeout,
+	}
+	// admin-service публикует сбросы кэшей ride и driver сервисов, сам слушает только отзыв токенов
+	caches := invalidation.New(db.Pool, log)
+	adminSvc := admin.NewAdminService(adminRepo, calculator, prometheusClient, cityRepo, flatRateRepo, blackoutRepo, communicationRepo, broadcastRepo, broadcasts, opsRepo, failedMessageRepo, replayer, exportOpts, caches, txManager, log)
+	promoSvc := promo.New(promoRepo, log)
+	warehouseExporter, err := newWarehouseExporter(cfg, postgres.NewWarehouseRepo(db.Pool), log)
+	if err != nil {
+		return nil, err
+	}
+	tokenSvc := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, accessTokenDenylist, caches, txManager, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, clk, log)
+	caches.Subscribe(types.CacheAccessTokens, tokenSvc.Invalidate)
+	watchJWTSecret(cfg, tokenSvc)
+	authSvc := auth.NewAuthService(userRepo, tokenSvc, deviceRepo, nil, nil, auth.PhoneVerificationOptions{}, txManager, clk, log)
 
-	server, err := httpserver.New(ctx, cfg, nil, nil, adminSvc, authSvc, nil, log)
+	server, err := httpserver.New(ctx, cfg, nil, nil, nil, adminSvc, promoSvc, authSvc, nil, nil, log)
 	if err != nil {
 		return nil, err
 	}
+	supplyMonitor := supply.New(postgres.NewSupplyRepo(db.Pool), newAlertSink(cfg, log), supply.Options{
+		Interval:    cfg.Supply.Interval,
+		Window:      cfg.Supply.Window,
+		DropPercent: cfg.Supply.DropPercent,
+		MinDrivers:  cfg.Supply.MinDrivers,
+	}, log)
+
+	incidentMode := newIncidentMode(ctx, cfg, db.Pool, log)
+	server.UseIncidentMode(incidentMode.sw)
+	registerHealthChecks(server, cfg, db.Pool, incidentMode, msgBrokers, nil)
 
 	return &AdminService{
-		postgresDB: db,
-		httpServer: server,
-		cfg:        cfg,
-		log:        log,
+		postgresDB:  db,
+		httpServer:  server,
+		incident:    incidentMode,
+		brokers:     msgBrokers,
+		admin:       adminSvc,
+		deadLetters: deadLetters,
+		warehouse:   warehouseExporter,
+		supply:      supplyMonitor,
+		caches:      caches,
+		cfg:         cfg,
+		log:         log,
 	}, nil
 }
 
@@ -64,8 +152,54 @@ func (s *AdminService) Start(ctx context.Context) error {
 	}()
 
 	errCh := make(chan error, 1)
+	s.incident.run(ctx)
 	s.httpServer.Run(ctx, errCh)
 
+	go func() {
+		s.log.Info(ctx, "fraud graph job has been started")
+		s.admin.RunFraudJob(ctx, s.cfg.Fraud.GraphInterval, admin.FraudOptions{
+			Window:           s.cfg.Fraud.Window,
+			RepeatedPairMin:  s.cfg.Fraud.RepeatedPairMin,
+			CoLocatedRadiusM: s.cfg.Fraud.CoLocatedRadiusM,
+			CoLocatedMin:     s.cfg.Fraud.CoLocatedMin,
+		})
+		s.log.Info(ctx, "fraud graph job has been finished")
+	}()
+
+	go func() {
+		s.log.Info(ctx, "coordinate repair job has been started")
+		s.admin.RunCoordinateRepairJob(ctx, s.cfg.Location.RepairInterval)
+		s.log.Info(ctx, "coordinate repair job has been finished")
+	}()
+
+	go func() {
+		s.log.Info(ctx, "cache invalidation listener has been started")
+		s.caches.Listen(ctx)
+		s.log.Info(ctx, "cache invalidation listener has been finished")
+	}()
+
+	go func() {
+		s.log.Info(ctx, "driver supply monitor has been started")
+		s.supply.RunJob(ctx)
+		s.log.Info(ctx, "driver supply monitor has been finished")
+	}()
+
+	if s.warehouse != nil {
+		go func() {
+			s.log.Info(ctx, "warehouse export job has been started", "target", s.cfg.Warehouse.Target)
+			s.warehouse.RunJob(ctx)
+			s.log.Info(ctx, "warehouse export job has been finished")
+		}()
+	}
+
+	if s.deadLetters != nil {
+		go func() {
+			if err := s.deadLetters.ConsumeDeadLetters(ctx, s.admin.RecordFailedMessage); err != nil {
+				s.log.Error(ctx, "dead letter consumer failed", err)
+			}
+		}()
+	}
+
 	// Waiting signal
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
@@ -80,6 +214,51 @@ func (s *AdminService) Start(ctx context.Context) error {
 	}
 }
 
+// newAlertSink возвращает вебхук системы оповещения, без ALERT_WEBHOOK_URL алерты пишутся в лог
+func newAlertSink(cfg config.Config, log logger.Logger) supply.Sink {
+	if cfg.Alert.WebhookURL == "" {
+		return alert.NewLog(log)
+	}
+	return alert.NewWebhook(cfg.Alert.WebhookURL, cfg.Alert.WebhookSecret, cfg.Alert.Timeout)
+}
+
+// newWarehouseExporter собирает выгрузку в хранилище аналитики по WAREHOUSE_TARGET
+func newWarehouseExporter(cfg config.Config, repo warehouse.Repo, log logger.Logger) (*warehouse.Exporter, error) {
+	var sink warehouse.Sink
+	switch cfg.Warehouse.Target {
+	case "":
+		return nil, nil
+	case config.WarehouseS3:
+		s3 := cfg.Warehouse.S3
+		sink = warehousesink.NewS3(warehousesink.S3Config{
+			Endpoint:     s3.Endpoint,
+			Region:       s3.Region,
+			Bucket:       s3.Bucket,
+			Prefix:       s3.Prefix,
+			AccessKey:    s3.AccessKey,
+			SecretKey:    s3.SecretKey,
+			SessionToken: s3.SessionToken,
+		}, cfg.Warehouse.RequestTimeout)
+	case config.WarehouseBigQuery:
+		bq, err := warehousesink.NewBigQuery(warehousesink.BigQueryConfig{
+			Project:         cfg.Warehouse.BigQuery.Project,
+			Dataset:         cfg.Warehouse.BigQuery.Dataset,
+			CredentialsFile: cfg.Warehouse.BigQuery.CredentialsFile,
+		}, cfg.Warehouse.RequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		sink = bq
+	}
+
+	return warehouse.New(repo, sink, warehouse.Options{
+		Interval:     cfg.Warehouse.Interval,
+		SettleDelay:  cfg.Warehouse.SettleDelay,
+		BatchSize:    cfg.Warehouse.BatchSize,
+		PseudonymKey: cfg.Warehouse.PseudonymKey,
+	}, log), nil
+}
+
 func (s *AdminService) close(ctx context.Context) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
@@ -88,5 +267,8 @@ func (s *AdminService) close(ctx context.Context) {
 		s.log.Error(ctx, "failed to shutdown HTTP server", err)
 	}
 
+	s.brokers.close(ctx)
+
+	s.incident.close()
 	s.postgresDB.Pool.Close()
 }
@@ -9,8 +9,14 @@ import (
 
 	"github.com/Temutjin2k/ride-hail-system/config"
 	httpserver "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/server"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/mock"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/notification"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	postgresclient "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
@@ -19,6 +25,7 @@ import (
 type AuthService struct {
 	postgresDB *postgresclient.PostgreDB
 	httpServer *httpserver.API
+	caches     *invalidation.Bus
 
 	cfg config.Config
 	log logger.Logger
@@ -31,22 +38,53 @@ func NewAuth(ctx context.Context, cfg config.Config, log logger.Logger) (*AuthSe
 	}
 
 	// repositories
-	userRepo := postgres.NewUserRepo(db.Pool)
+	// PII encryption keyring
+	pii, err := keyring.New(cfg.PII.ActiveKey, cfg.PII.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo := postgres.NewUserRepo(db.Pool, pii)
 	refreshTokenRepo := postgres.NewRefreshTokenRepo(db.Pool)
+	accessTokenDenylist := postgres.NewAccessTokenDenylistRepo(db.Pool)
+	deviceRepo := postgres.NewDeviceRepo(db.Pool, pii)
+	preferenceRepo := postgres.NewNotificationPreferenceRepo(db.Pool)
+	pushTokenRepo := postgres.NewPushTokenRepo(db.Pool)
+	phoneCodeRepo := postgres.NewPhoneCodeRepo(db.Pool)
+
+	// коды подтверждения телефона, без mock режима SMS провайдер не подключен и подтверждение недоступно
+	var smsSender auth.SMSSender
+	if cfg.Mock.Enabled {
+		smsSender = mock.NewSMSSender(cfg.Mock.Latency)
+	}
+	phoneOpts := auth.PhoneVerificationOptions{
+		CodeTTL:        cfg.Auth.PhoneCodeTTL,
+		ResendInterval: cfg.Auth.PhoneCodeResendInterval,
+		MaxAttempts:    cfg.Auth.PhoneCodeMaxAttempts,
+	}
 
 	// services
 	txManager := trm.New(db.Pool)
-	tokenSvc := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, txManager, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, log)
-	authSvc := auth.NewAuthService(userRepo, tokenSvc, log)
-
-	server, err := httpserver.New(ctx, cfg, nil, nil, nil, authSvc, nil, log)
+	clk := clock.New()
+	// отзыв токенов рассылается всем экземплярам сервисов, границы выхода в кэше сбрасываются по нему
+	caches := invalidation.New(db.Pool, log)
+	tokenSvc := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, accessTokenDenylist, caches, txManager, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, clk, log)
+	caches.Subscribe(types.CacheAccessTokens, tokenSvc.Invalidate)
+	watchJWTSecret(cfg, tokenSvc)
+	authSvc := auth.NewAuthService(userRepo, tokenSvc, deviceRepo, phoneCodeRepo, smsSender, phoneOpts, txManager, clk, log)
+	// auth-service только хранит настройки и push токены, рассылкой занимаются другие сервисы
+	notificationSvc := notification.New(preferenceRepo, userRepo, pushTokenRepo, nil, nil, nil, nil, nil, log)
+
+	server, err := httpserver.New(ctx, cfg, nil, nil, nil, nil, nil, authSvc, notificationSvc, nil, log)
 	if err != nil {
 		return nil, err
 	}
+	registerHealthChecks(server, cfg, db.Pool, nil, nil, nil)
 
 	return &AuthService{
 		postgresDB: db,
 		httpServer: server,
+		caches:     caches,
 		cfg:        cfg,
 		log:        log,
 	}, nil
@@ -61,6 +99,12 @@ func (s *AuthService) Start(ctx context.Context) error {
 	errCh := make(chan error, 1)
 	s.httpServer.Run(ctx, errCh)
 
+	go func() {
+		s.log.Info(ctx, "cache invalidation listener has been started")
+		s.caches.Listen(ctx)
+		s.log.Info(ctx, "cache invalidation listener has been finished")
+	}()
+
 	// Waiting signal
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,243 @@
+package microservices
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	kafkaAdapter "github.com/Temutjin2k/ride-hail-system/internal/adapter/kafka"
+	pgbrokerAdapter "github.com/Temutjin2k/ride-hail-system/internal/adapter/pgbroker"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	drivergo "github.com/Temutjin2k/ride-hail-system/internal/service/driver"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/location"
+	ridego "github.com/Temutjin2k/ride-hail-system/internal/service/ride"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/pgbroker"
+	rabbitmq "github.com/Temutjin2k/ride-hail-system/pkg/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+)
+
+// rideBroker — брокер ride-service
+type rideBroker interface {
+	ridego.RideMsgBroker
+	locationConsumer
+	ConsumeDriverStatusUpdate(ctx context.Context, handler rabbit.DriverStatusUpdateHandler) error
+}
+
+// driverBroker — брокер driver-service
+type driverBroker interface {
+	drivergo.Publisher
+	ConsumeRideRequest(ctx context.Context, fn rabbit.ConsumeRideHandlerFunc) error
+	ConsumeStatusUpdate(ctx context.Context, fn rabbit.MatchConfHandlerFunc) error
+}
+
+// broadcastBroker рассылает объявления администратора
+type broadcastBroker interface {
+	PublishBroadcast(ctx context.Context, msg models.BroadcastMessage) error
+	ConsumeBroadcast(ctx context.Context, role types.UserRole, handler rabbit.BroadcastHandler) error
+}
+
+type locationConsumer interface {
+	ConsumeDriverLocationUpdate(ctx context.Context, handler rabbit.LocationUpdateHandler) error
+}
+
+// locationStream читает все координаты водителей на каждом экземпляре, а не делит их с другими
+type locationStream interface {
+	ConsumeLocationStream(ctx context.Context, handler rabbit.LocationUpdateHandler) error
+}
+
+// brokers создает клиентов брокеров по требованию (BROKER_BACKEND, BROKER_LOCATION_BACKEND)
+// и переиспользует их, если разные типы сообщений идут через один backend
+type brokers struct {
+	cfg config.Config
+	db  *pgxpool.Pool
+	log logger.Logger
+
+	// identity подписывает сообщения и вызовы внутреннего API от имени сервиса
+	identity *svcauth.Identity
+
+	rabbit *rabbitmq.RabbitMQ
+	pg     *pgbroker.Client
+	kafka  *kafkaAdapter.LocationBroker
+}
+
+func newBrokers(cfg config.Config, db *pgxpool.Pool, log logger.Logger) (*brokers, error) {
+	identity, err := svcauth.New(cfg.Mode.String(), cfg.ServiceAuth.ActiveKey, cfg.ServiceAuth.PrivateKeys, cfg.ServiceAuth.PublicKeys, cfg.ServiceAuth.TokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup service auth: %w", err)
+	}
+
+	return &brokers{
+		cfg:      cfg,
+		db:       db,
+		identity: identity,
+		log:      log,
+	}, nil
+}
+
+func (b *brokers) rabbitMQ(ctx context.Context) (*rabbitmq.RabbitMQ, error) {
+	if b.rabbit == nil {
+		client, err := rabbitmq.New(ctx, b.cfg.RabbitMQ.GetDSN(), b.log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup rabbitmq: %w", err)
+		}
+		client.SetIdentity(b.identity, b.cfg.ServiceAuth.RequireSigned)
+		b.rabbit = client
+	}
+	return b.rabbit, nil
+}
+
+func (b *brokers) postgres(ctx context.Context) *pgbroker.Client {
+	if b.pg == nil {
+		b.log.Info(ctx, "using postgres message broker")
+		b.pg = pgbroker.New(ctx, b.db, b.cfg.Broker.PollInterval, b.cfg.Broker.VisibilityTimeout, b.cfg.Broker.Prefetch, b.log)
+		b.pg.SetIdentity(b.identity, b.cfg.ServiceAuth.RequireSigned)
+	}
+	return b.pg
+}
+
+func (b *brokers) kafkaLocation(ctx context.Context) *kafkaAdapter.LocationBroker {
+	if b.kafka == nil {
+		b.log.Info(ctx, "using kafka for driver locations", "topic", b.cfg.Kafka.LocationTopic)
+		b.kafka = kafkaAdapter.NewLocationBroker(b.cfg.Kafka.BrokerList(), b.cfg.Kafka.LocationTopic, b.cfg.Kafka.GroupID, b.log)
+		b.kafka.SetIdentity(b.identity, b.cfg.ServiceAuth.RequireSigned)
+	}
+	return b.kafka
+}
+
+// rideBroker возвращает брокер ride-service, координаты читаются через BROKER_LOCATION_BACKEND
+func (b *brokers) rideBroker(ctx context.Context) (rideBroker, error) {
+	var broker rideBroker
+	switch b.cfg.Broker.Backend {
+	case config.BrokerPostgres:
+		broker = pgbrokerAdapter.NewRideBroker(b.postgres(ctx), b.log)
+	default:
+		client, err := b.rabbitMQ(ctx)
+		if err != nil {
+			return nil, err
+		}
+		broker = rabbit.NewRideBroker(client, b.log)
+	}
+
+	location := b.cfg.Broker.Location()
+	if location == b.cfg.Broker.Backend {
+		return broker, nil
+	}
+
+	var consumer locationConsumer
+	switch location {
+	case config.BrokerKafka:
+		consumer = b.kafkaLocation(ctx)
+	case config.BrokerPostgres:
+		consumer = pgbrokerAdapter.NewRideBroker(b.postgres(ctx), b.log)
+	default:
+		client, err := b.rabbitMQ(ctx)
+		if err != nil {
+			return nil, err
+		}
+		consumer = rabbit.NewRideBroker(client, b.log)
+	}
+
+	return rideLocationBroker{rideBroker: broker, location: consumer}, nil
+}
+
+// driverBroker возвращает брокер driver-service
+func (b *brokers) driverBroker(ctx context.Context) (driverBroker, error) {
+	if b.cfg.Broker.Backend == config.BrokerPostgres {
+		return pgbrokerAdapter.NewDriverBroker(b.postgres(ctx), b.log), nil
+	}
+
+	client, err := b.rabbitMQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rabbit.NewDriverClient(client, b.log), nil
+}
+
+// locationBroker возвращает публикатор координат водителей, он работает через BROKER_LOCATION_BACKEND
+func (b *brokers) locationBroker(ctx context.Context) (location.Publisher, error) {
+	switch b.cfg.Broker.Location() {
+	case config.BrokerKafka:
+		return b.kafkaLocation(ctx), nil
+	case config.BrokerPostgres:
+		return pgbrokerAdapter.NewDriverBroker(b.postgres(ctx), b.log), nil
+	}
+
+	client, err := b.rabbitMQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rabbit.NewDriverClient(client, b.log), nil
+}
+
+// locationStream возвращает поток координат водителей через BROKER_LOCATION_BACKEND.
+// У postgres брокера нет рассылки всем экземплярам, с ним поток — nil.
+func (b *brokers) locationStream(ctx context.Context) (locationStream, error) {
+	switch b.cfg.Broker.Location() {
+	case config.BrokerKafka:
+		return b.kafkaLocation(ctx), nil
+	case config.BrokerPostgres:
+		return nil, nil
+	}
+
+	client, err := b.rabbitMQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rabbit.NewDriverClient(client, b.log), nil
+}
+
+// broadcastBroker возвращает брокер объявлений администратора, он работает через BROKER_BACKEND
+func (b *brokers) broadcastBroker(ctx context.Context) (broadcastBroker, error) {
+	if b.cfg.Broker.Backend == config.BrokerPostgres {
+		return pgbrokerAdapter.NewBroadcastBroker(b.postgres(ctx), b.log), nil
+	}
+
+	client, err := b.rabbitMQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rabbit.NewBroadcastBroker(client, b.log), nil
+}
+
+// deadLetterBroker возвращает dead letter очередь RabbitMQ, с BROKER_BACKEND=postgres — nil:
+// отклоненные сообщения остаются в broker_messages с dead_at
+func (b *brokers) deadLetterBroker(ctx context.Context) (*rabbit.DeadLetterBroker, error) {
+	if b.cfg.Broker.Backend == config.BrokerPostgres {
+		return nil, nil
+	}
+
+	client, err := b.rabbitMQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rabbit.NewDeadLetterBroker(client, b.cfg.RabbitMQ.DeadLetterTTL, b.log), nil
+}
+
+func (b *brokers) close(ctx context.Context) {
+	if b.kafka != nil {
+		if err := b.kafka.Close(); err != nil {
+			b.log.Warn(ctx, "failed to close kafka writer", "error", err.Error())
+		}
+	}
+
+	if b.rabbit != nil {
+		if err := b.rabbit.Close(ctx); err != nil {
+			b.log.Warn(ctx, "failed to close rabbitmq connection", "error", err.Error())
+		}
+	}
+}
+
+// rideLocationBroker читает координаты через отдельный брокер
+type rideLocationBroker struct {
+	rideBroker
+	location locationConsumer
+}
+
+func (b rideLocationBroker) ConsumeDriverLocationUpdate(ctx context.Context, handler rabbit.LocationUpdateHandler) error {
+	return b.location.ConsumeDriverLocationUpdate(ctx, handler)
+}
@@ -6,49 +6,141 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/config"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/handler"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/server"
 	wshandler "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/ws"
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/locationIQ"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/locationsvc"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/mock"
 	repo "github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
-	rabbitAdapter "github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/webhook"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/broadcast"
 	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
 	drivergo "github.com/Temutjin2k/ride-hail-system/internal/service/driver"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/location"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/ops"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/partner"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/payout"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/positioning"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
-	"github.com/Temutjin2k/ride-hail-system/pkg/rabbit"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
 	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
 )
 
+// partnerWebhookTimeout - таймаут доставки события на вебхук партнёра
+const partnerWebhookTimeout = 5 * time.Second
+
 type DriverService struct {
 	postgresDB *postgres.PostgreDB
 	httpServer *server.API
-	rabbitMQ   *rabbit.RabbitMQ
+	incident   *incidentMode
+	brokers    *brokers
 	consumers  Consumers
 	cfg        config.Config
 	log        logger.Logger
 }
 
 type Consumers struct {
-	rideConsumer *rabbitAdapter.DriverBroker
-	uc           *drivergo.Service
-	log          logger.Logger
+	rideConsumer      driverBroker
+	uc                *drivergo.Service
+	broadcastConsumer broadcastBroker
+	locationStream    locationStream
+	broadcasts        *broadcast.Deliverer
+	positioning       *positioning.Service
+	ops               *ops.Executor
+	payouts           *payout.Service
+	caches            *invalidation.Bus
+	cfg               config.DriverConfig
+	positioningCfg    config.PositioningConfig
+	opsCfg            config.OpsConfig
+	payoutCfg         config.PayoutConfig
+	log               logger.Logger
+
+	// stopRideRequests останавливает только чтение запросов поиска, начатые поиски дожидается Drain
+	stopRideRequests context.CancelFunc
 }
 
 func (c *Consumers) Start(ctx context.Context, errCh chan error) {
+	requestsCtx, stopRideRequests := context.WithCancel(ctx)
+	c.stopRideRequests = stopRideRequests
+
 	go func() {
 		c.log.Info(ctx, "ConsumeRideRequest has been started")
-		if err := c.rideConsumer.ConsumeRideRequest(ctx, c.uc.SearchDriver); err != nil {
+		if err := c.rideConsumer.ConsumeRideRequest(requestsCtx, c.uc.SearchDriver); err != nil {
 			errCh <- fmt.Errorf("failed to start ConsumeRideRequest: %w", err)
 			return
 		}
 		c.log.Info(ctx, "ConsumeRideRequest has been finished")
 	}()
 
+	go func() {
+		c.log.Info(ctx, "driver tier job has been started")
+		c.uc.RunTierJob(ctx, c.cfg.TierRecomputeInterval, c.cfg.TierWindow)
+		c.log.Info(ctx, "driver tier job has been finished")
+	}()
+
+	go func() {
+		c.log.Info(ctx, "driver geo index job has been started")
+		c.uc.RunGeoIndexJob(ctx)
+		c.log.Info(ctx, "driver geo index job has been finished")
+	}()
+
+	if c.locationStream != nil {
+		go func() {
+			c.log.Info(ctx, "ConsumeLocationStream has been started")
+			if err := c.locationStream.ConsumeLocationStream(ctx, c.uc.TrackLocation); err != nil {
+				errCh <- fmt.Errorf("failed to start ConsumeLocationStream: %w", err)
+				return
+			}
+			c.log.Info(ctx, "ConsumeLocationStream has been finished")
+		}()
+	}
+
+	go func() {
+		c.log.Info(ctx, "driver redispatch job has been started")
+		c.uc.RunRedispatchJob(ctx, c.cfg.RedispatchSweepInterval)
+		c.log.Info(ctx, "driver redispatch job has been finished")
+	}()
+
+	go func() {
+		c.log.Info(ctx, "driver stats job has been started")
+		c.uc.RunStatsJob(ctx, c.cfg.StatsPushInterval)
+		c.log.Info(ctx, "driver stats job has been finished")
+	}()
+
+	go func() {
+		c.log.Info(ctx, "positioning job has been started")
+		c.positioning.RunJob(ctx, c.positioningCfg.Interval)
+		c.log.Info(ctx, "positioning job has been finished")
+	}()
+
+	go func() {
+		c.log.Info(ctx, "ops actions job has been started")
+		c.ops.RunJob(ctx, c.opsCfg.PollInterval)
+		c.log.Info(ctx, "ops actions job has been finished")
+	}()
+
+	go func() {
+		c.log.Info(ctx, "payout reconcile job has been started")
+		c.payouts.RunReconcileJob(ctx, c.payoutCfg.ReconcileInterval)
+		c.log.Info(ctx, "payout reconcile job has been finished")
+	}()
+
+	go func() {
+		c.log.Info(ctx, "cache invalidation listener has been started")
+		c.caches.Listen(ctx)
+		c.log.Info(ctx, "cache invalidation listener has been finished")
+	}()
+
 	go func() {
 		c.log.Info(ctx, "ConsumeStatusUpdate has been started")
 		if err := c.rideConsumer.ConsumeStatusUpdate(ctx, c.uc.HandleRideStatus); err != nil {
@@ -57,6 +149,15 @@ func (c *Consumers) Start(ctx context.Context, errCh chan error) {
 		}
 		c.log.Info(ctx, "ConsumeStatusUpdate has been finished")
 	}()
+
+	go func() {
+		c.log.Info(ctx, "ConsumeBroadcast has been started")
+		if err := c.broadcastConsumer.ConsumeBroadcast(ctx, types.RoleDriver, c.broadcasts.Deliver); err != nil {
+			errCh <- fmt.Errorf("failed to start ConsumeBroadcast: %w", err)
+			return
+		}
+		c.log.Info(ctx, "ConsumeBroadcast has been finished")
+	}()
 }
 
 func NewDriver(ctx context.Context, cfg config.Config, log logger.Logger) (*DriverService, error) {
@@ -66,34 +167,106 @@ func NewDriver(ctx context.Context, cfg config.Config, log logger.Logger) (*Driv
 		return nil, err
 	}
 
-	rabbitMq, err := rabbit.New(ctx, cfg.RabbitMQ.GetDSN(), log)
+	// Message Broker
+	msgBrokers, err := newBrokers(cfg, postgresDB.Pool, log)
 	if err != nil {
-		log.Error(ctx, "Failed to setup rabbitmq", err)
+		log.Error(ctx, "Failed to setup message broker", err)
 		return nil, err
 	}
+	driverProducer, err := msgBrokers.driverBroker(ctx)
+	if err != nil {
+		log.Error(ctx, "Failed to setup message broker", err)
+		return nil, err
+	}
+	broadcastBroker, err := msgBrokers.broadcastBroker(ctx)
+	if err != nil {
+		log.Error(ctx, "Failed to setup message broker", err)
+		return nil, err
+	}
+	// индекс координат обновляется потоком координат всех экземпляров, без потока — только перестроением
+	var locationStream locationStream
+	if cfg.Dispatch.GeoIndex {
+		if locationStream, err = msgBrokers.locationStream(ctx); err != nil {
+			log.Error(ctx, "Failed to setup message broker", err)
+			return nil, err
+		}
+		if locationStream == nil {
+			log.Warn(ctx, "location broker has no per-instance stream: driver geo index is refreshed only by sync", "sync_interval", cfg.Dispatch.GeoIndexSyncInterval.String())
+		}
+	}
 
 	// Repo adapters
 	trm := trm.New(postgresDB.Pool)
-	driverRepo := repo.NewDriverRepo(postgresDB.Pool)
+	// PII encryption keyring
+	pii, err := keyring.New(cfg.PII.ActiveKey, cfg.PII.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	caches := invalidation.New(postgresDB.Pool, log)
+	// профиль водителя читается на каждом шаге поездки и обновлении координат
+	driverRepo := drivergo.NewDriverCache(repo.NewDriverRepo(postgresDB.Pool), caches, cfg.Cache.DriverTTL, log)
+	caches.Subscribe(types.CacheDrivers, driverRepo.Invalidate)
 	sessionRepo := repo.NewSessionRepo(postgresDB.Pool)
-	coordinateRepo := repo.NewCoordinateRepo(postgresDB.Pool)
-	userRepo := repo.NewUserRepo(postgresDB.Pool)
-	rideRepo := repo.NewRideRepo(postgresDB.Pool)
+	coordinateRepo := repo.NewCoordinateRepo(postgresDB.Pool, pii)
+	userRepo := repo.NewUserRepo(postgresDB.Pool, pii)
+	rideRepo := repo.NewRideRepo(postgresDB.Pool, pii)
 	refreshTokenRepo := repo.NewRefreshTokenRepo(postgresDB.Pool)
+	accessTokenDenylist := repo.NewAccessTokenDenylistRepo(postgresDB.Pool)
+	deviceRepo := repo.NewDeviceRepo(postgresDB.Pool, pii)
 	eventRepo := repo.NewRideEvent(postgresDB.Pool)
-
-	// Message Broker
-	driverProducer := rabbitAdapter.NewDriverClient(rabbitMq, log)
+	blocklistRepo := repo.NewBlocklistRepo(postgresDB.Pool)
+	offlineActionRepo := repo.NewOfflineActionRepo(postgresDB.Pool)
+	processedMessageRepo := repo.NewProcessedMessageRepo(postgresDB.Pool)
+	broadcastRepo := repo.NewBroadcastRepo(postgresDB.Pool)
+	partnerRepo := repo.NewPartnerRepo(postgresDB.Pool, pii)
+	positioningRepo := repo.NewPositioningRepo(postgresDB.Pool)
+	payoutRepo := repo.NewPayoutRepo(postgresDB.Pool)
 
 	// External API client
-	locationIQclient := locationIQ.New(cfg.ExternalAPIConfig.LocationIQapiKey)
+	locationiq := locationIQ.New(cfg.ExternalAPIConfig.LocationIQapiKey)
+	watchLocationIQKey(cfg, locationiq)
+	var geocoder location.GeoCoder = locationiq
+	if cfg.Mock.Enabled {
+		log.Warn(ctx, "mock mode enabled: geocoder is replaced with in-process fake", "latency", cfg.Mock.Latency.String())
+		geocoder = mock.NewGeocoder(cfg.Mock.Latency)
+	}
 
 	// Calculator service
-	calculator := ridecalc.New()
+	clk := clock.New()
+	calculator := ridecalc.New().WithClock(clk).WithPricing(ridecalc.PricingOptions{
+		TaxRate:        cfg.Pricing.TaxRate,
+		CommissionRate: cfg.Pricing.CommissionRate,
+	})
+
+	// Location ingestion: в процессе или через внутренний API location-service
+	locations, err := newLocationIngester(ctx, cfg, msgBrokers, driverRepo, coordinateRepo, deviceRepo, geocoder, trm, log)
+	if err != nil {
+		log.Error(ctx, "Failed to setup message broker", err)
+		return nil, err
+	}
 
 	// Websocket service
-	wsHub := ws.NewConnHub(log)
+	wsHub := ws.NewConnHub(log).
+		WithMaxProtocol(ws.Protocol(cfg.WebSocket.MaxProtocol)).
+		WithSendBuffer(cfg.WebSocket.SendBuffer)
 	sender := wshandler.NewDriverHub(wsHub)
+	// офферы водителям таксопарков уходят на вебхук партнёра, остальным - по WebSocket
+	dispatcher := partner.NewDispatcher(sender, partnerRepo, repo.NewPartnerDispatchRepo(postgresDB.Pool), caches, webhook.New(partnerWebhookTimeout), log)
+	caches.Subscribe(types.CachePartnerOffers, dispatcher.OfferResponded)
+	caches.Subscribe(types.CachePartnerTracking, dispatcher.TrackUpdated)
+	broadcasts := broadcast.New(types.RoleDriver, broadcastRepo, wsHub, caches, log)
+	caches.Subscribe(types.CacheBroadcasts, broadcasts.Notified)
+	// объявления, записанные во входящие пока клиент был офлайн, досылаются при подключении
+	wsHub.WithOnConnect(broadcasts.DeliverStored)
+	positioningService := positioning.New(positioningRepo, sender, geocoder, calculator, positioning.Options{
+		DemandWindow:   cfg.Positioning.DemandWindow,
+		MinRequests:    cfg.Positioning.MinRequests,
+		MaxDistanceKm:  cfg.Positioning.MaxDistanceKm,
+		MaxWait:        cfg.Positioning.MaxWait,
+		EvaluateAfter:  cfg.Positioning.EvaluateAfter,
+		ArrivalRadiusM: cfg.Positioning.ArrivalRadiusM,
+	}, log)
 
 	// Main Service
 	driverService := drivergo.New(
@@ -101,49 +274,151 @@ func NewDriver(ctx context.Context, cfg config.Config, log logger.Logger) (*Driv
 		sessionRepo,
 		coordinateRepo,
 		userRepo, rideRepo,
-		locationIQclient,
+		geocoder,
 		driverProducer,
 		calculator,
-		sender,
+		dispatcher,
 		trm,
 		eventRepo,
+		blocklistRepo,
+		offlineActionRepo,
+		processedMessageRepo,
+		repo.NewDriverConnectionRepo(postgresDB.Pool),
+		locations,
+		caches,
+		cfg.Driver.RedispatchGrace,
+		cfg.Driver.TierWindow,
+		cfg.Driver.RequireVerifiedPhone,
+		drivergo.ArrivalPolicy{Points: cfg.Driver.ArrivalPoints, Dwell: cfg.Driver.ArrivalDwell},
+		drivergo.ClassFallbackPolicy{AfterTicks: cfg.Driver.ClassFallbackAfterTicks},
+		drivergo.DispatchPolicy{
+			RadiusKm:        cfg.Dispatch.RadiusKm,
+			RadiusStepKm:    cfg.Dispatch.RadiusStepKm,
+			MaxRadiusKm:     cfg.Dispatch.MaxRadiusKm,
+			OfferTimeout:    cfg.Dispatch.OfferTimeout,
+			RetryInterval:   cfg.Dispatch.RetryInterval,
+			SearchTimeout:   cfg.Dispatch.SearchTimeout,
+			Mode:            drivergo.DispatchMode(cfg.Dispatch.Mode),
+			ParallelOffers:  cfg.Dispatch.ParallelOffers,
+			DeclineCooldown: cfg.Dispatch.DeclineCooldown,
+		},
+		drivergo.GeoIndexPolicy{Enabled: cfg.Dispatch.GeoIndex, SyncInterval: cfg.Dispatch.GeoIndexSyncInterval},
+		clk,
 		log,
 	)
-	tokenService := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, trm, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, log)
-	authService := auth.NewAuthService(userRepo, tokenService, log)
+	caches.Subscribe(types.CacheDriverCandidates, driverService.InvalidateCandidates)
+
+	tokenService := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, accessTokenDenylist, caches, trm, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, clk, log)
+	caches.Subscribe(types.CacheAccessTokens, tokenService.Invalidate)
+	caches.Subscribe(types.CacheAccessTokens, closeRevokedSessions(wsHub))
+	watchJWTSecret(cfg, tokenService)
+	authService := auth.NewAuthService(userRepo, tokenService, deviceRepo, nil, nil, auth.PhoneVerificationOptions{}, trm, clk, log)
+	partnerService := partner.New(partnerRepo, userRepo, driverService, dispatcher, caches, trm, log)
+
+	// Провайдер выплат, без mock режима реальный провайдер не подключен и мгновенный вывод недоступен
+	var payoutProvider payout.Provider
+	if cfg.Mock.Enabled {
+		payoutProvider = mock.NewPayoutProvider(cfg.Mock.Latency)
+	}
+	payoutService := payout.New(payoutRepo, payoutProvider, trm, payout.Options{
+		FeeFixed:          cfg.Payout.FeeFixed,
+		FeePercent:        cfg.Payout.FeePercent,
+		MinAmount:         cfg.Payout.MinAmount,
+		MaxAmount:         cfg.Payout.MaxAmount,
+		DailyLimit:        cfg.Payout.DailyLimit,
+		DailyCount:        cfg.Payout.DailyCount,
+		MinAccountAge:     cfg.Payout.MinAccountAge,
+		MinCompletedRides: cfg.Payout.MinCompletedRides,
+		WebhookSecret:     cfg.Payout.WebhookSecret,
+		ReconcileAfter:    cfg.Payout.ReconcileAfter,
+	}, log)
 
 	options := &handler.DriverServiceOptions{
 		WsConnections: wsHub,
 		Service:       driverService,
 		Auth:          authService,
+		Partner:       partnerService,
+		Payouts:       payoutService,
 	}
 
-	httpServer, err := server.New(ctx, cfg, options, nil, nil, authService, nil, log)
+	httpServer, err := server.New(ctx, cfg, options, nil, nil, nil, nil, authService, nil, nil, log)
 	if err != nil {
 		log.Error(ctx, "Failed to setup http server", err)
 		return nil, err
 	}
+	incidentMode := newIncidentMode(ctx, cfg, postgresDB.Pool, log)
+	httpServer.UseIncidentMode(incidentMode.sw)
+	registerHealthChecks(httpServer, cfg, postgresDB.Pool, incidentMode, msgBrokers, wsHub)
 
 	return &DriverService{
 		httpServer: httpServer,
+		incident:   incidentMode,
 		postgresDB: postgresDB,
-		rabbitMQ:   rabbitMq,
+		brokers:    msgBrokers,
 		consumers: Consumers{
-			rideConsumer: driverProducer,
-			uc:           driverService,
-			log:          log,
+			rideConsumer:      driverProducer,
+			uc:                driverService,
+			broadcastConsumer: broadcastBroker,
+			locationStream:    locationStream,
+			broadcasts:        broadcasts,
+			positioning:       positioningService,
+			ops:               newOpsExecutor("driver", cfg.Ops, postgresDB.Pool, wsHub, log),
+			payouts:           payoutService,
+			caches:            caches,
+			cfg:               cfg.Driver,
+			positioningCfg:    cfg.Positioning,
+			opsCfg:            cfg.Ops,
+			payoutCfg:         cfg.Payout,
+			log:               log,
 		},
 		cfg: cfg,
 		log: log,
 	}, nil
 }
 
+// newLocationIngester возвращает клиент location-service, если задан LOCATION_SERVICE_URL,
+// иначе driver-service сам записывает координаты и публикует их через BROKER_LOCATION_BACKEND
+func newLocationIngester(
+	ctx context.Context,
+	cfg config.Config,
+	msgBrokers *brokers,
+	driverRepo location.DriverChecker,
+	coordinateRepo location.CoordinateRepo,
+	deviceRepo location.DeviceRepo,
+	geocoder location.GeoCoder,
+	trm trm.TxManager,
+	log logger.Logger,
+) (drivergo.LocationIngester, error) {
+	if cfg.Location.ServiceURL != "" {
+		log.Info(ctx, "driver locations are forwarded to location-service", "url", cfg.Location.ServiceURL)
+		return locationsvc.New(cfg.Location.ServiceURL, msgBrokers.identity, cfg.Location.InternalToken, cfg.Location.RequestTimeout), nil
+	}
+
+	publisher, err := msgBrokers.locationBroker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return location.New(driverRepo, coordinateRepo, deviceRepo, geocoder, publisher, trm, cfg.Driver.RequireLocationSignature, cfg.Location.DuplicateWindow, log), nil
+}
+
+// Drain перестает забирать запросы поиска водителя и дожидается начатых поисков не дольше timeout.
+// Не завершившиеся поиски возвращаются в очередь и продолжаются на другом экземпляре.
+func (c *Consumers) Drain(ctx context.Context, timeout time.Duration) {
+	if c.stopRideRequests != nil {
+		c.stopRideRequests()
+	}
+	c.uc.Drain(ctx, timeout)
+}
+
 func (s *DriverService) Start(ctx context.Context) error {
 	errCh := make(chan error, 2)
 
+	s.incident.run(ctx)
 	s.httpServer.Run(ctx, errCh)
 	s.consumers.Start(ctx, errCh)
 	defer func() {
+		// брокер и база закрываются после drain: прерванным поискам нужно вернуть сообщения в очередь
+		s.consumers.Drain(ctx, s.cfg.Driver.DrainTimeout)
 		s.close(ctx)
 		s.log.Info(ctx, "driver service closed")
 	}()
@@ -170,11 +445,15 @@ func (s *DriverService) close(ctx context.Context) {
 		}
 	}
 
+	if s.incident != nil {
+		s.incident.close()
+	}
+
 	if s.postgresDB != nil && s.postgresDB.Pool != nil {
 		s.postgresDB.Pool.Close()
 	}
 
-	if s.rabbitMQ != nil && s.rabbitMQ.Conn != nil {
-		s.rabbitMQ.Conn.Close()
+	if s.brokers != nil {
+		s.brokers.close(ctx)
 	}
 }
@@ -0,0 +1,52 @@
+package microservices
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	httpserver "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/server"
+	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
+)
+
+// registerHealthChecks добавляет в пробы /health/live и /health/ready базу, RabbitMQ, если сервис
+// к нему подключен, и WebSocket хаб (hub может быть nil). С режимом инцидента база некритична:
+// сервис продолжает обслуживать чтения из реплики и кэшей, снимать его с балансировки незачем.
+func registerHealthChecks(api *httpserver.API, cfg config.Config, db *pgxpool.Pool, incident *incidentMode, b *brokers, hub *ws.ConnectionHub) {
+	dbCritical := incident == nil || !cfg.Incident.Enabled
+	api.RegisterHealthCheck("postgres", dbCritical, func(ctx context.Context) (any, error) {
+		stat := db.Stat()
+		details := map[string]int32{
+			"total_conns":    stat.TotalConns(),
+			"idle_conns":     stat.IdleConns(),
+			"acquired_conns": stat.AcquiredConns(),
+			"max_conns":      stat.MaxConns(),
+		}
+		return details, db.Ping(ctx)
+	})
+
+	if b != nil && b.rabbit != nil {
+		client := b.rabbit
+		api.RegisterHealthCheck("rabbitmq", true, func(context.Context) (any, error) {
+			// переподключается сам клиент при следующей публикации или чтении,
+			// закрытое дольше HEALTH_LIVENESS_GRACE соединение перезапускает сервис
+			if client.IsConnectionClosed() {
+				return nil, errors.New("connection is closed")
+			}
+			return nil, nil
+		})
+	}
+
+	if hub != nil {
+		api.RegisterHealthCheck("websocket_hub", true, func(context.Context) (any, error) {
+			connections, open := hub.Status()
+			details := map[string]int{"connections": connections}
+			if !open {
+				return details, errors.New("hub is closed")
+			}
+			return details, nil
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package microservices
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/pkg/incident"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+)
+
+// incidentMode — переключатель режима только для чтения и пул реплики сервиса
+type incidentMode struct {
+	sw      *incident.Switch
+	replica *postgres.PostgreDB
+}
+
+// newIncidentMode подключает реплику, если она задана. Недоступная при старте реплика
+// не мешает запуску: в режиме инцидента чтения тогда обслуживаются только кэшами.
+func newIncidentMode(ctx context.Context, cfg config.Config, primary *pgxpool.Pool, log logger.Logger) *incidentMode {
+	m := &incidentMode{}
+
+	var replicaPool *pgxpool.Pool
+	if replicaCfg, ok := cfg.Database.Replica(); ok {
+		replica, err := postgres.New(ctx, replicaCfg)
+		if err != nil {
+			log.Warn(ctx, "failed to connect to database replica, read-only mode will not serve database reads", "host", replicaCfg.Host, "error", err.Error())
+		} else {
+			m.replica = replica
+			replicaPool = replica.Pool
+		}
+	}
+
+	m.sw = incident.New(primary, replicaPool, incident.Options{
+		Enabled:           cfg.Incident.Enabled,
+		CheckInterval:     cfg.Incident.CheckInterval,
+		CheckTimeout:      cfg.Incident.CheckTimeout,
+		FailureThreshold:  cfg.Incident.FailureThreshold,
+		RecoveryThreshold: cfg.Incident.RecoveryThreshold,
+	}, log)
+
+	return m
+}
+
+func (m *incidentMode) run(ctx context.Context) {
+	go m.sw.Run(ctx)
+}
+
+func (m *incidentMode) close() {
+	if m.replica != nil && m.replica.Pool != nil {
+		m.replica.Pool.Close()
+	}
+}
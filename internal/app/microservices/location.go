@@ -0,0 +1,154 @@
+package microservices
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/http/server"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/locationIQ"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/mock"
+	repo "github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/location"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/postgres"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+)
+
+// LocationService — отдельный сервис приема координат водителей. Масштабируется
+// независимо от driver-service под объем GPS записей.
+type LocationService struct {
+	postgresDB *postgres.PostgreDB
+	httpServer *server.API
+	brokers    *brokers
+	caches     *invalidation.Bus
+	cfg        config.Config
+	log        logger.Logger
+}
+
+func NewLocation(ctx context.Context, cfg config.Config, log logger.Logger) (*LocationService, error) {
+	postgresDB, err := postgres.New(ctx, cfg.Database)
+	if err != nil {
+		log.Error(ctx, "Failed to setup database", err)
+		return nil, err
+	}
+
+	// Message Broker
+	msgBrokers, err := newBrokers(cfg, postgresDB.Pool, log)
+	if err != nil {
+		log.Error(ctx, "Failed to setup message broker", err)
+		return nil, err
+	}
+	publisher, err := msgBrokers.locationBroker(ctx)
+	if err != nil {
+		log.Error(ctx, "Failed to setup message broker", err)
+		return nil, err
+	}
+
+	// PII encryption keyring
+	pii, err := keyring.New(cfg.PII.ActiveKey, cfg.PII.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	trm := trm.New(postgresDB.Pool)
+	driverRepo := repo.NewDriverRepo(postgresDB.Pool)
+	coordinateRepo := repo.NewCoordinateRepo(postgresDB.Pool, pii)
+	deviceRepo := repo.NewDeviceRepo(postgresDB.Pool, pii)
+	userRepo := repo.NewUserRepo(postgresDB.Pool, pii)
+	refreshTokenRepo := repo.NewRefreshTokenRepo(postgresDB.Pool)
+	accessTokenDenylist := repo.NewAccessTokenDenylistRepo(postgresDB.Pool)
+
+	// External API client
+	locationiq := locationIQ.New(cfg.ExternalAPIConfig.LocationIQapiKey)
+	watchLocationIQKey(cfg, locationiq)
+	var geocoder location.GeoCoder = locationiq
+	if cfg.Mock.Enabled {
+		log.Warn(ctx, "mock mode enabled: geocoder is replaced with in-process fake", "latency", cfg.Mock.Latency.String())
+		geocoder = mock.NewGeocoder(cfg.Mock.Latency)
+	}
+
+	switch {
+	case cfg.Location.InternalToken != "":
+		log.Warn(ctx, "LOCATION_INTERNAL_TOKEN is deprecated: internal API callers should use SERVICE_AUTH_PRIVATE_KEYS")
+	case cfg.ServiceAuth.PrivateKeys == "":
+		log.Warn(ctx, "SERVICE_AUTH_PRIVATE_KEYS and LOCATION_INTERNAL_TOKEN are empty: internal API is disabled")
+	}
+
+	locationService := location.New(driverRepo, coordinateRepo, deviceRepo, geocoder, publisher, trm, cfg.Driver.RequireLocationSignature, cfg.Location.DuplicateWindow, log)
+	clk := clock.New()
+	caches := invalidation.New(postgresDB.Pool, log)
+	tokenService := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, accessTokenDenylist, caches, trm, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, clk, log)
+	caches.Subscribe(types.CacheAccessTokens, tokenService.Invalidate)
+	watchJWTSecret(cfg, tokenService)
+	authService := auth.NewAuthService(userRepo, tokenService, deviceRepo, nil, nil, auth.PhoneVerificationOptions{}, trm, clk, log)
+
+	httpServer, err := server.New(ctx, cfg, nil, locationService, nil, nil, nil, authService, nil, nil, log)
+	if err != nil {
+		log.Error(ctx, "Failed to setup http server", err)
+		return nil, err
+	}
+	registerHealthChecks(httpServer, cfg, postgresDB.Pool, nil, msgBrokers, nil)
+
+	return &LocationService{
+		postgresDB: postgresDB,
+		httpServer: httpServer,
+		brokers:    msgBrokers,
+		caches:     caches,
+		cfg:        cfg,
+		log:        log,
+	}, nil
+}
+
+func (s *LocationService) Start(ctx context.Context) error {
+	errCh := make(chan error, 1)
+
+	s.httpServer.Run(ctx, errCh)
+	defer func() {
+		s.close(ctx)
+		s.log.Info(ctx, "location service closed")
+	}()
+
+	go func() {
+		s.log.Info(ctx, "cache invalidation listener has been started")
+		s.caches.Listen(ctx)
+		s.log.Info(ctx, "cache invalidation listener has been finished")
+	}()
+
+	// Waiting signal
+	shutdownCh := make(chan os.Signal, 1)
+	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
+
+	s.log.Info(ctx, "Location service has been started")
+
+	select {
+	case errRun := <-errCh:
+		return errRun
+	case sig := <-shutdownCh:
+		s.log.Info(ctx, "shuting down application", "signal", sig.String())
+		return nil
+	}
+}
+
+func (s *LocationService) close(ctx context.Context) {
+	if s.httpServer != nil {
+		if err := s.httpServer.Stop(ctx); err != nil {
+			s.log.Warn(ctx, "Failed to gracefully close http server", "error", err.Error())
+		}
+	}
+
+	if s.postgresDB != nil && s.postgresDB.Pool != nil {
+		s.postgresDB.Pool.Close()
+	}
+
+	if s.brokers != nil {
+		s.brokers.close(ctx)
+	}
+}
@@ -0,0 +1,28 @@
+package microservices
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/config"
+	repo "github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/ops"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
+)
+
+// newOpsExecutor создает исполнитель операционных действий экземпляра ride или driver сервиса
+func newOpsExecutor(service string, cfg config.OpsConfig, pool *pgxpool.Pool, hub *ws.ConnectionHub, log logger.Logger) *ops.Executor {
+	executor := ops.New(service, cfg.InstanceID, repo.NewOpsRepo(pool), log)
+
+	executor.Handle(types.OpsDrainWS, func(ctx context.Context, _ models.OpsAction) (any, error) {
+		closed := hub.Drain()
+		log.Warn(ctx, "websocket connections drained by ops action", "closed", closed)
+		return map[string]int{"closed_connections": closed}, nil
+	})
+
+	return executor
+}
@@ -12,14 +12,23 @@ import (
 	"github.com/Temutjin2k/ride-hail-system/config"
 	httpserver "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/server"
 	wshandler "github.com/Temutjin2k/ride-hail-system/internal/adapter/http/ws"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/locationIQ"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/mock"
 	repo "github.com/Temutjin2k/ride-hail-system/internal/adapter/postgres"
-	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/push"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/broadcast"
 	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/notification"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/ops"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/promo"
 	ridego "github.com/Temutjin2k/ride-hail-system/internal/service/ride"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	postgres "github.com/Temutjin2k/ride-hail-system/pkg/postgres"
-	rabbitmq "github.com/Temutjin2k/ride-hail-system/pkg/rabbit"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
 	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
 )
@@ -27,7 +36,8 @@ import (
 type RideService struct {
 	postgresDB *postgres.PostgreDB
 	httpServer *httpserver.API
-	rabbitMQ   *rabbitmq.RabbitMQ
+	incident   *incidentMode
+	brokers    *brokers
 	consumers  *RideConsumers
 
 	cfg config.Config
@@ -35,9 +45,15 @@ type RideService struct {
 }
 
 type RideConsumers struct {
-	rideConsumer *rabbit.RideBroker
-	rideService  *ridego.RideService
-	log          logger.Logger
+	rideConsumer      rideBroker
+	rideService       *ridego.RideService
+	broadcastConsumer broadcastBroker
+	broadcasts        *broadcast.Deliverer
+	ops               *ops.Executor
+	caches            *invalidation.Bus
+	cfg               config.RideConfig
+	opsCfg            config.OpsConfig
+	log               logger.Logger
 
 	// sync и cancel для корректного завершения
 	wg     sync.WaitGroup
@@ -85,6 +101,78 @@ func (c *RideConsumers) Start(parentCtx context.Context, errCh chan error) {
 		}
 		c.log.Info(ctx, "ConsumeDriverStatusUpdate has been finished")
 	}()
+
+	// ответы водителей по всем поездкам, ожидающие горутины получают их через dispatcher
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.log.Info(ctx, "ConsumeDriverResponses has been started")
+		if err := c.rideConsumer.ConsumeDriverResponses(ctx, c.rideService.DispatchDriverResponse); err != nil {
+			select {
+			case errCh <- fmt.Errorf("failed to start ConsumeDriverResponses: %w", err):
+			default:
+				c.log.Error(ctx, "ConsumeDriverResponses error, errCh blocked", err)
+			}
+			return
+		}
+		c.log.Info(ctx, "ConsumeDriverResponses has been finished")
+	}()
+
+	// публикация сообщений outbox, не отправленных сразу после commit
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.log.Info(ctx, "outbox relay has been started")
+		c.rideService.RunOutboxRelay(ctx, c.cfg.DispatchRetryInterval, c.cfg.DispatchPendingTimeout)
+		c.log.Info(ctx, "outbox relay has been finished")
+	}()
+
+	// отмена и переназначение поездок, зависших после падения экземпляра
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.log.Info(ctx, "stuck rides reconciler has been started")
+		c.rideService.RunReconciler(ctx, ridego.ReconcileOptions{
+			Interval:       c.cfg.ReconcileInterval,
+			RequestedAfter: c.cfg.ReconcileRequestedAfter,
+			MatchedAfter:   c.cfg.ReconcileMatchedAfter,
+		})
+		c.log.Info(ctx, "stuck rides reconciler has been finished")
+	}()
+
+	// объявления администратора для пассажиров
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.log.Info(ctx, "ConsumeBroadcast has been started")
+		if err := c.broadcastConsumer.ConsumeBroadcast(ctx, types.RolePassenger, c.broadcasts.Deliver); err != nil {
+			select {
+			case errCh <- fmt.Errorf("failed to start ConsumeBroadcast: %w", err):
+			default:
+				c.log.Error(ctx, "ConsumeBroadcast error, errCh blocked", err)
+			}
+			return
+		}
+		c.log.Info(ctx, "ConsumeBroadcast has been finished")
+	}()
+
+	// операционные действия, назначенные экземпляру
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.log.Info(ctx, "ops actions job has been started")
+		c.ops.RunJob(ctx, c.opsCfg.PollInterval)
+		c.log.Info(ctx, "ops actions job has been finished")
+	}()
+
+	// сброс локальных кэшей по изменениям администратора и отзыву токенов
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.log.Info(ctx, "cache invalidation listener has been started")
+		c.caches.Listen(ctx)
+		c.log.Info(ctx, "cache invalidation listener has been finished")
+	}()
 }
 
 // Stop отменяет внутренний контекст и ждёт завершения горутин с заданным таймаутом.
@@ -121,44 +209,161 @@ func NewRide(ctx context.Context, cfg config.Config, log logger.Logger) (*RideSe
 		return nil, fmt.Errorf("failed to setup database: %w", err)
 	}
 
-	// init RabbitMQ
-	rabbitClient, err := rabbitmq.New(ctx, cfg.RabbitMQ.GetDSN(), log)
+	// init message broker
+	msgBrokers, err := newBrokers(cfg, postgresDB.Pool, log)
+	if err != nil {
+		return nil, err
+	}
+	broker, err := msgBrokers.rideBroker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	broadcastBroker, err := msgBrokers.broadcastBroker(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to setup rabbitmq: %w", err)
+		return nil, err
 	}
-	rabbitRideBroker := rabbit.NewRideBroker(rabbitClient, log)
 
 	// init repositories
-	rideRepo := repo.NewRideRepo(postgresDB.Pool)
-	userRepo := repo.NewUserRepo(postgresDB.Pool)
+	// PII encryption keyring
+	pii, err := keyring.New(cfg.PII.ActiveKey, cfg.PII.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	rideRepo := repo.NewRideRepo(postgresDB.Pool, pii)
+	userRepo := repo.NewUserRepo(postgresDB.Pool, pii)
 	refreshTokenRepo := repo.NewRefreshTokenRepo(postgresDB.Pool)
+	accessTokenDenylist := repo.NewAccessTokenDenylistRepo(postgresDB.Pool)
+	deviceRepo := repo.NewDeviceRepo(postgresDB.Pool, pii)
 	eventRepo := repo.NewRideEvent(postgresDB.Pool)
+	preferenceRepo := repo.NewNotificationPreferenceRepo(postgresDB.Pool)
+	pushTokenRepo := repo.NewPushTokenRepo(postgresDB.Pool)
+	cityRepo := repo.NewCityRepo(postgresDB.Pool)
+	flatRateRepo := repo.NewFlatRateRepo(postgresDB.Pool)
+	blackoutRepo := repo.NewBlackoutRepo(postgresDB.Pool)
+	communicationRepo := repo.NewCommunicationRepo(postgresDB.Pool)
+	broadcastRepo := repo.NewBroadcastRepo(postgresDB.Pool)
+	walletRepo := repo.NewWalletRepo(postgresDB.Pool)
+	promoRepo := repo.NewPromoRepo(postgresDB.Pool)
 
 	// init services
 	trm := trm.New(postgresDB.Pool)
-	calculator := ridecalc.New()
+	clk := clock.New()
+	calculator := ridecalc.New().WithClock(clk).WithSurge(ridecalc.SurgeOptions{
+		Enabled:       cfg.Ride.Surge.Enabled,
+		CellPrecision: cfg.Ride.Surge.CellPrecision,
+		Window:        cfg.Ride.Surge.Window,
+		Threshold:     cfg.Ride.Surge.Threshold,
+		Step:          cfg.Ride.Surge.Step,
+	}).WithPricing(ridecalc.PricingOptions{
+		TaxRate:        cfg.Pricing.TaxRate,
+		CommissionRate: cfg.Pricing.CommissionRate,
+	})
 
-	wsHub := ws.NewConnHub(log)
+	wsHub := ws.NewConnHub(log).
+		WithMaxProtocol(ws.Protocol(cfg.WebSocket.MaxProtocol)).
+		WithSendBuffer(cfg.WebSocket.SendBuffer)
 	wsRide := wshandler.NewRideWsHandler(wsHub)
 
-	rideService := ridego.NewRideService(rideRepo, calculator, trm, rabbitRideBroker, wsRide, eventRepo, log)
-	tokenSvc := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, trm, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, log)
-	authSvc := auth.NewAuthService(userRepo, tokenSvc, log)
+	// Routing adapter для притягивания точки посадки к дороге
+	locationiq := locationIQ.New(cfg.ExternalAPIConfig.LocationIQapiKey)
+	watchLocationIQKey(cfg, locationiq)
+	var snapper ridego.RoadSnapper = locationiq
+	if cfg.Mock.Enabled {
+		log.Warn(ctx, "mock mode enabled: routing is replaced with in-process fake", "latency", cfg.Mock.Latency.String())
+		snapper = mock.NewGeocoder(cfg.Mock.Latency)
+	}
+
+	// Провайдеры push/SMS/email/телефонии, без mock режима подключается только FCM (если задан ключ),
+	// остальные каналы пропускаются
+	var (
+		pushSender  notification.PushSender
+		smsSender   notification.SMSSender
+		emailSender notification.EmailSender
+		voiceCaller notification.VoiceCaller
+	)
+	if cfg.Mock.Enabled {
+		pushSender = mock.NewPushSender(cfg.Mock.Latency)
+		smsSender = mock.NewSMSSender(cfg.Mock.Latency)
+		emailSender = mock.NewEmailSender(cfg.Mock.Latency)
+		voiceCaller = mock.NewVoiceCaller(cfg.Mock.Latency)
+	} else if cfg.Push.FCMCredentialsFile != "" {
+		fcm, err := push.NewFCM(cfg.Push.FCMCredentialsFile, cfg.Push.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		pushSender = fcm
+	}
+	notifier := notification.New(preferenceRepo, userRepo, pushTokenRepo, communicationRepo, pushSender, smsSender, emailSender, voiceCaller, log)
+
+	// Платежный провайдер, без mock режима реальный провайдер не подключен и пополнение кошелька недоступно
+	var payments ridego.PaymentProvider
+	if cfg.Mock.Enabled {
+		payments = mock.NewPaymentProvider(cfg.Mock.Latency)
+	}
+
+	// Провайдер фискализации, без mock режима чеки только нумеруются и не фискализируются
+	var fiscal ridego.FiscalProvider
+	if cfg.Mock.Enabled {
+		fiscal = mock.NewFiscalProvider(cfg.Mock.Latency)
+	}
+
+	emissions := ridego.EmissionFactors{
+		types.ClassEconomy: cfg.Carbon.EconomyGramsPerKm,
+		types.ClassPremium: cfg.Carbon.PremiumGramsPerKm,
+		types.ClassXL:      cfg.Carbon.XLGramsPerKm,
+	}
+
+	// правила городов читаются на каждую поездку, кэш сбрасывается изменениями администратора
+	caches := invalidation.New(postgresDB.Pool, log)
+	cityCache := ridego.NewCityCache(cityRepo, calculator, cfg.Cache.CityTTL)
+	caches.Subscribe(types.CacheCitySettings, cityCache.Invalidate)
+	flatRateCache := ridego.NewFlatRateCache(flatRateRepo, cfg.Cache.FlatRateTTL)
+	caches.Subscribe(types.CacheFlatRates, flatRateCache.Invalidate)
+	blackoutCache := ridego.NewBlackoutCache(blackoutRepo, cfg.Cache.BlackoutTTL)
+	caches.Subscribe(types.CacheBlackouts, blackoutCache.Invalidate)
+	broadcasts := broadcast.New(types.RolePassenger, broadcastRepo, wsHub, caches, log)
+	caches.Subscribe(types.CacheBroadcasts, broadcasts.Notified)
+	// объявления, записанные во входящие пока клиент был офлайн, досылаются при подключении
+	wsHub.WithOnConnect(broadcasts.DeliverStored)
+
+	promos := promo.New(promoRepo, log)
+	outboxRepo := repo.NewOutboxRepo(postgresDB.Pool)
+	rideService := ridego.NewRideService(rideRepo, calculator, trm, broker, wsRide, eventRepo, snapper, cityCache, flatRateCache, blackoutCache, notifier, communicationRepo, emissions, walletRepo, payments, promos, outboxRepo, ridego.PaymentOptions{PreAuthBuffer: cfg.Ride.PreAuthBuffer}, fiscal, ridego.InvoiceOptions{DefaultLegalEntity: cfg.Ride.InvoiceLegalEntity}, ridego.ApproachOptions{
+		DistanceKm:   cfg.Ride.ApproachDistanceKm,
+		ETA:          cfg.Ride.ApproachETA,
+		HysteresisKm: cfg.Ride.ApproachHysteresisKm,
+	}, clk, log)
+	tokenSvc := auth.NewTokenService(cfg.Auth.JWTSecret, userRepo, refreshTokenRepo, accessTokenDenylist, caches, trm, cfg.Auth.RefreshTokenTTL, cfg.Auth.AccessTokenTTL, clk, log)
+	caches.Subscribe(types.CacheAccessTokens, tokenSvc.Invalidate)
+	caches.Subscribe(types.CacheAccessTokens, closeRevokedSessions(wsHub))
+	watchJWTSecret(cfg, tokenSvc)
+	authSvc := auth.NewAuthService(userRepo, tokenSvc, deviceRepo, nil, nil, auth.PhoneVerificationOptions{}, trm, clk, log)
 
 	// init http server
-	httpServer, err := httpserver.New(ctx, cfg, nil, rideService, nil, authSvc, wsHub, log)
+	httpServer, err := httpserver.New(ctx, cfg, nil, nil, rideService, nil, nil, authSvc, nil, wsHub, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup http server: %w", err)
 	}
+	incidentMode := newIncidentMode(ctx, cfg, postgresDB.Pool, log)
+	httpServer.UseIncidentMode(incidentMode.sw)
+	registerHealthChecks(httpServer, cfg, postgresDB.Pool, incidentMode, msgBrokers, wsHub)
 
 	return &RideService{
 		httpServer: httpServer,
+		incident:   incidentMode,
 		postgresDB: postgresDB,
-		rabbitMQ:   rabbitClient,
+		brokers:    msgBrokers,
 		consumers: &RideConsumers{
-			rideConsumer: rabbitRideBroker,
-			rideService:  rideService,
-			log:          log,
+			rideConsumer:      broker,
+			rideService:       rideService,
+			broadcastConsumer: broadcastBroker,
+			broadcasts:        broadcasts,
+			ops:               newOpsExecutor("ride", cfg.Ops, postgresDB.Pool, wsHub, log),
+			caches:            caches,
+			cfg:               cfg.Ride,
+			opsCfg:            cfg.Ops,
+			log:               log,
 		},
 
 		cfg: cfg,
@@ -168,6 +373,7 @@ func NewRide(ctx context.Context, cfg config.Config, log logger.Logger) (*RideSe
 
 func (s *RideService) Start(ctx context.Context) error {
 	errCh := make(chan error, 1)
+	s.incident.run(ctx)
 	s.httpServer.Run(ctx, errCh)
 	s.consumers.Start(ctx, errCh)
 
@@ -201,10 +407,12 @@ func (s *RideService) close(ctx context.Context) {
 		}
 	}
 
-	if s.rabbitMQ != nil {
-		if err := s.rabbitMQ.Close(ctx); err != nil {
-			s.log.Warn(ctx, "failed to close rabbitmq connection", "error", err.Error())
-		}
+	if s.brokers != nil {
+		s.brokers.close(ctx)
+	}
+
+	if s.incident != nil {
+		s.incident.close()
 	}
 
 	if s.postgresDB != nil && s.postgresDB.Pool != nil {
@@ -0,0 +1,20 @@
+package microservices
+
+import (
+	"github.com/Temutjin2k/ride-hail-system/config"
+	"github.com/Temutjin2k/ride-hail-system/internal/adapter/locationIQ"
+	"github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+)
+
+// watchJWTSecret подписывает сервис токенов на ротацию ключа JWT, если ключ задан ссылкой на секрет.
+// Новый ключ начинает подписывать через интервал перечитывания секретов: к этому времени его прочитают все сервисы.
+func watchJWTSecret(cfg config.Config, tokens *auth.TokenService) {
+	cfg.SecretStore.OnRotate("AUTH_JWT_SECRET", func(secret string) {
+		tokens.RotateSecret(secret, cfg.Secrets.RefreshInterval)
+	})
+}
+
+// watchLocationIQKey меняет ключ LocationIQ без перезапуска, если ключ задан ссылкой на секрет
+func watchLocationIQKey(cfg config.Config, client *locationIQ.LocationIQClient) {
+	cfg.SecretStore.OnRotate("LOCATIONIQ_API_KEY", client.SetAPIKey)
+}
@@ -0,0 +1,22 @@
+package microservices
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+	ws "github.com/Temutjin2k/ride-hail-system/pkg/wsHub"
+)
+
+// closeRevokedSessions закрывает WebSocket соединения пользователя, чьи токены отозваны.
+// Токен проверяется только при подключении, поэтому соединение закрывается целиком:
+// переподключиться можно лишь с токеном, который еще действует.
+func closeRevokedSessions(hub *ws.ConnectionHub) invalidation.Handler {
+	return func(_ context.Context, e invalidation.Event) {
+		userID, err := uuid.Parse(e.Key)
+		if err != nil || len(hub.Conns(userID)) == 0 {
+			return
+		}
+		_ = hub.Delete(userID)
+	}
+}
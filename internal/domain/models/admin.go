@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
 
 type ActiveRidesResponse struct {
 	Rides    []RideInfo `json:"rides"`
@@ -30,3 +36,78 @@ type Hotspot struct {
 	ActiveRides    int    `json:"active_rides"`
 	WaitingDrivers int    `json:"waiting_drivers"`
 }
+
+// RideEventRecord - строка из таблицы ride_events
+type RideEventRecord struct {
+	ID        uuid.UUID       `json:"id"`
+	RideID    uuid.UUID       `json:"ride_id"`
+	EventType types.RideEvent `json:"event_type"`
+	EventData json.RawMessage `json:"event_data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// LocationRecord - зафиксированная позиция водителя в конкретный момент времени
+type LocationRecord struct {
+	Location
+	AccuracyMeters float64   `json:"accuracy_meters,omitempty"`
+	SpeedKmh       float64   `json:"speed_kmh,omitempty"`
+	HeadingDegrees float64   `json:"heading_degrees,omitempty"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	Source         string    `json:"source"` // location_history | ride_event
+}
+
+// RideStateAt - состояние поездки, восстановленное из ride_events и location_history на момент At
+type RideStateAt struct {
+	RideID         uuid.UUID       `json:"ride_id"`
+	RideNumber     string          `json:"ride_number"`
+	At             time.Time       `json:"at"`
+	Status         string          `json:"status"`
+	DriverID       *uuid.UUID      `json:"driver_id,omitempty"`
+	DriverLocation *LocationRecord `json:"driver_location,omitempty"`
+	LastEventType  string          `json:"last_event_type,omitempty"`
+	LastEventAt    *time.Time      `json:"last_event_at,omitempty"`
+	EventsApplied  int             `json:"events_applied"`
+}
+
+// SLOReport - отчет о соблюдении SLO по всем скользящим окнам
+type SLOReport struct {
+	Timestamp time.Time   `json:"timestamp"`
+	SLOs      []SLOStatus `json:"slos"`
+}
+
+// SLOStatus - состояние одного SLO
+type SLOStatus struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Target      float64     `json:"target"`
+	Source      string      `json:"source"` // database | prometheus
+	Windows     []SLOWindow `json:"windows"`
+}
+
+// SLOWindow - SLI и остаток error budget в конкретном окне
+type SLOWindow struct {
+	Window               string   `json:"window"`
+	SLI                  *float64 `json:"sli"`         // nil если данных нет
+	GoodEvents           float64  `json:"good_events"` // из prometheus приходят экстраполированные значения
+	TotalEvents          float64  `json:"total_events"`
+	Compliant            bool     `json:"compliant"`
+	ErrorBudgetRemaining float64  `json:"error_budget_remaining"` // доля от 1; отрицательная если бюджет исчерпан
+	Error                string   `json:"error,omitempty"`
+}
+
+// AdminEffects — последствия административной операции. При DryRun изменения
+// выполнены в транзакции и откачены, база не изменилась.
+type AdminEffects struct {
+	DryRun          bool                  `json:"dry_run"`
+	Result          string                `json:"result"`
+	AffectedRides   []uuid.UUID           `json:"affected_rides"`
+	AffectedDrivers []uuid.UUID           `json:"affected_drivers"`
+	Notifications   []PlannedNotification `json:"notifications"`
+}
+
+// PlannedNotification — уведомление, которое операция отправит пользователю
+type PlannedNotification struct {
+	RecipientID uuid.UUID `json:"recipient_id"`
+	Channel     string    `json:"channel"`
+	Event       string    `json:"event"`
+}
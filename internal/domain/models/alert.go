@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// Alert — оповещение для дежурных, отправляется в систему алертов
+type Alert struct {
+	// Key — ключ дедупликации: одно и то же условие дает один ключ во всех экземплярах сервиса
+	Key     string            `json:"key"`
+	Name    string            `json:"name"`
+	Status  types.AlertStatus `json:"status"`
+	Summary string            `json:"summary"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	At      time.Time         `json:"at"`
+}
+
+// SupplyCount — число водителей онлайн в городе по классу автомобиля
+type SupplyCount struct {
+	City   string // код города, пусто — водители вне настроенных городов
+	Class  types.VehicleClass
+	Online int
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Anomaly — несогласованное состояние, найденное детектором аномалий
+type Anomaly struct {
+	Kind        types.AnomalyKind `json:"kind"`
+	EntityID    uuid.UUID         `json:"entity_id"` // водитель, поездка, сессия или владелец координат в зависимости от kind
+	DriverID    *uuid.UUID        `json:"driver_id,omitempty"`
+	RideID      *uuid.UUID        `json:"ride_id,omitempty"`
+	Details     string            `json:"details"`
+	Since       time.Time         `json:"since"`
+	Remediation Remediation       `json:"remediation"`
+}
+
+// Remediation — действие, которое исправляет аномалию одним запросом
+type Remediation struct {
+	Action      string `json:"action"`
+	Description string `json:"description"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+}
+
+type AnomaliesResponse struct {
+	Anomalies []Anomaly                 `json:"anomalies"`
+	Total     int                       `json:"total"`
+	ByKind    map[types.AnomalyKind]int `json:"by_kind"`
+	CheckedAt time.Time                 `json:"checked_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// MatchingBlackout — окно, в котором автоматический подбор водителей в зоне приостановлен
+// (парад, перекрытие дорог). Поездки создаются, поиск водителя начинается после окончания окна.
+type MatchingBlackout struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+	// Класс автомобиля, пусто — все классы
+	VehicleType string    `json:"vehicle_type,omitempty"`
+	Zone        FareZone  `json:"zone"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ActiveAt сообщает, действует ли окно в момент at
+func (b *MatchingBlackout) ActiveAt(at time.Time) bool {
+	return !at.Before(b.StartsAt) && at.Before(b.EndsAt)
+}
+
+// AppliesTo сообщает, распространяется ли окно на класс автомобиля
+func (b *MatchingBlackout) AppliesTo(rideType string) bool {
+	return b.VehicleType == "" || b.VehicleType == rideType
+}
+
+// MatchingDelay сообщает пассажиру, что поиск водителя отложен до окончания окна
+type MatchingDelay struct {
+	RideID uuid.UUID `json:"ride_id"`
+	Reason string    `json:"reason"`
+	// Поиск водителя начнется не раньше этого момента
+	Until                time.Time `json:"until"`
+	ExpectedDelayMinutes int       `json:"expected_delay_minutes"`
+}
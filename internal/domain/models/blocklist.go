@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// BlockedPassenger — пассажир, заблокированный водителем
+type BlockedPassenger struct {
+	DriverID    uuid.UUID `json:"driver_id"`
+	PassengerID uuid.UUID `json:"passenger_id"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BlocklistFilter — фильтры списка блокировок для админки
+type BlocklistFilter struct {
+	DriverID    *uuid.UUID
+	PassengerID *uuid.UUID
+}
+
+type BlocklistResponse struct {
+	Blocks   []BlockedPassenger `json:"blocks"`
+	Metadata Metadata           `json:"metadata"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Broadcast — объявление администратора для всех водителей и/или пассажиров
+type Broadcast struct {
+	ID        uuid.UUID        `json:"broadcast_id"`
+	Title     string           `json:"title"`
+	Message   string           `json:"message"`
+	Roles     []types.UserRole `json:"roles"`
+	City      string           `json:"city,omitempty"` // пусто — все города
+	CreatedBy uuid.UUID        `json:"created_by"`
+	CreatedAt time.Time        `json:"created_at"`
+	Stats     BroadcastStats   `json:"stats"`
+}
+
+// BroadcastStats — статистика доставки объявления.
+// Delivered — отправлено в открытое WS соединение, Stored — записано во входящие
+// получателя в базе и ждет его подключения.
+type BroadcastStats struct {
+	Recipients int `json:"recipients"`
+	Delivered  int `json:"delivered"`
+	Stored     int `json:"stored"`
+}
+
+// BroadcastMessage — сообщение брокера, которое доставляют ride и driver сервисы
+type BroadcastMessage struct {
+	Schema
+
+	BroadcastID uuid.UUID        `json:"broadcast_id"`
+	Title       string           `json:"title"`
+	Message     string           `json:"message"`
+	Roles       []types.UserRole `json:"roles"`
+	City        string           `json:"city,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+/* ======================= Websocket ======================= */
+
+type BroadcastNotification struct {
+	MsgType     string    `json:"type"` // By default must be: "broadcast"
+	BroadcastID uuid.UUID `json:"broadcast_id"`
+	Title       string    `json:"title"`
+	Message     string    `json:"message"`
+	SentAt      time.Time `json:"sent_at"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// CitySettings — операционные правила города: рабочие часы и ночной тариф.
+// Время задается в формате "HH:MM" по местному часовому поясу города.
+type CitySettings struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Center   Location `json:"center"`
+	RadiusKm float64  `json:"radius_km"`
+	Timezone string   `json:"timezone"`
+
+	// Рабочие часы, OpenTime == CloseTime - город работает круглосуточно
+	OpenTime  string `json:"open_time"`
+	CloseTime string `json:"close_time"`
+
+	// Ночное окно, в котором применяется NightMultiplier
+	NightStart      string  `json:"night_start"`
+	NightEnd        string  `json:"night_end"`
+	NightMultiplier float64 `json:"night_multiplier"`
+
+	OutsideHoursPolicy types.OutsideHoursPolicy `json:"outside_hours_policy"`
+
+	// Юрлицо, выдающее чеки поездок из города, пусто — юрлицо по умолчанию
+	LegalEntity string `json:"legal_entity,omitempty"`
+
+	// Прием поездок приостановлен действием PAUSE_MATCHING, меняется только через /admin/ops/actions
+	MatchingPaused bool `json:"matching_paused"`
+
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+// IsOpen сообщает, принимает ли город поездки в момент at
+func (c *CitySettings) IsOpen(at time.Time) (bool, error) {
+	if c.OpenTime == c.CloseTime {
+		return true, nil
+	}
+	return c.inWindow(at, c.OpenTime, c.CloseTime)
+}
+
+// IsNight сообщает, попадает ли момент at в ночное окно
+func (c *CitySettings) IsNight(at time.Time) (bool, error) {
+	if c.NightStart == c.NightEnd {
+		return false, nil
+	}
+	return c.inWindow(at, c.NightStart, c.NightEnd)
+}
+
+// inWindow проверяет попадание местного времени в окно [from, to), окно может переходить через полночь
+func (c *CitySettings) inWindow(at time.Time, from, to string) (bool, error) {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return false, fmt.Errorf("city %s: %w", c.Code, err)
+	}
+
+	start, err := ParseClock(from)
+	if err != nil {
+		return false, fmt.Errorf("city %s: %w", c.Code, err)
+	}
+	end, err := ParseClock(to)
+	if err != nil {
+		return false, fmt.Errorf("city %s: %w", c.Code, err)
+	}
+
+	local := at.In(loc)
+	now := local.Hour()*60 + local.Minute()
+
+	if start < end {
+		return now >= start && now < end, nil
+	}
+	return now >= start || now < end, nil
+}
+
+// ParseClock переводит "HH:MM" в минуты от полуночи
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Communication — запись журнала коммуникаций поездки: какое сообщение, кому и по какому каналу
+// отправлено и чем закончилась доставка
+type Communication struct {
+	ID          uuid.UUID                 `json:"id"`
+	RideID      uuid.UUID                 `json:"ride_id"`
+	RecipientID uuid.UUID                 `json:"recipient_id"`
+	Channel     types.NotificationChannel `json:"channel"`
+	// MessageType — тип WS события или уведомления (RIDE_STATUS_UPDATE, ride_updates, ...)
+	MessageType string                    `json:"message_type"`
+	Status      types.CommunicationStatus `json:"status"`
+	// Detail — причина ошибки или пропуска
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RideCommunications — журнал коммуникаций поездки для администратора
+type RideCommunications struct {
+	RideID         uuid.UUID       `json:"ride_id"`
+	RideNumber     string          `json:"ride_number"`
+	Communications []Communication `json:"communications"`
+}
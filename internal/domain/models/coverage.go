@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// CoverageReport — обслуживание районов за период по geohash ячейкам точки посадки.
+// Учитываются поездки с исходом подбора: водитель найден или поездка отменена до этого.
+type CoverageReport struct {
+	Since        time.Time      `json:"since"`
+	Precision    int            `json:"precision"`
+	Requests     int            `json:"requests"`
+	MatchRate    float64        `json:"match_rate"`     // по городу, для сравнения районов
+	NoDriverRate float64        `json:"no_driver_rate"` // доля отмен из-за отсутствия водителя по городу
+	AvgWaitSec   *float64       `json:"avg_wait_sec"`   // nil — ни одна поездка не подобрана
+	Underserved  int            `json:"underserved"`
+	Areas        []CoverageArea `json:"areas"` // сначала недообслуженные
+}
+
+// CoverageArea — показатели подбора в одной geohash ячейке
+type CoverageArea struct {
+	Geohash               string                `json:"geohash"`
+	Latitude              float64               `json:"latitude"` // средняя точка посадки в ячейке
+	Longitude             float64               `json:"longitude"`
+	Requests              int                   `json:"requests"`
+	Matched               int                   `json:"matched"`
+	NoDriverCancellations int                   `json:"no_driver_cancellations"`
+	MatchRate             float64               `json:"match_rate"`
+	NoDriverRate          float64               `json:"no_driver_rate"`
+	AvgWaitSec            *float64              `json:"avg_wait_sec"` // от запроса до подбора, nil — подобранных нет
+	P90WaitSec            *float64              `json:"p90_wait_sec"`
+	Underserved           bool                  `json:"underserved"`
+	Issues                []types.CoverageIssue `json:"issues,omitempty"`
+}
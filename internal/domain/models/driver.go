@@ -19,16 +19,49 @@ type Driver struct {
 	TotalEarnings float64            // in smallest currency unit, e.g., tyin for KZT, cents for USD
 	Status        types.DriverStatus // e.g., "available", "on_trip", "offline"
 	IsVerified    bool               // Indicates if the driver's documents have been verified
+	Tier          types.DriverTier   // ranking tier: BRONZE, SILVER, GOLD
+	TierUpdatedAt *time.Time         // last time the tier was recomputed
+	PhotoURL      *string            // photo shown to passengers, changes need admin approval
+	DeclineRate   *float64           // share of declined and missed offers over the tier window, nil if there were no offers
+}
+
+// DriverTierMetrics — показатели водителя за окно расчёта уровня
+type DriverTierMetrics struct {
+	DriverID       uuid.UUID
+	Tier           types.DriverTier // текущий уровень
+	Rating         float64
+	OffersTotal    int
+	OffersAccepted int
+	RidesTotal     int
+	RidesCancelled int
+}
+
+// AcceptanceRate — доля принятых офферов (1, если офферов не было)
+func (m DriverTierMetrics) AcceptanceRate() float64 {
+	if m.OffersTotal == 0 {
+		return 1
+	}
+	return float64(m.OffersAccepted) / float64(m.OffersTotal)
+}
+
+// CancellationRate — доля отменённых поездок (0, если поездок не было)
+func (m DriverTierMetrics) CancellationRate() float64 {
+	if m.RidesTotal == 0 {
+		return 0
+	}
+	return float64(m.RidesCancelled) / float64(m.RidesTotal)
 }
 
 // DriverWithDistance представляет водителя с координатами и расстоянием до точки
 type DriverWithDistance struct {
-	ID         uuid.UUID `json:"id"`
-	Name       string    `json:"name"`
-	Rating     float64   `json:"rating"`
-	Location   Location  `json:"location"`
-	Vehicle    Vehicle   `json:"vehicle"`
-	DistanceKm float64   `json:"distance_km"`
+	ID         uuid.UUID        `json:"id"`
+	Name       string           `json:"name"`
+	Rating     float64          `json:"rating"`
+	Location   Location         `json:"location"`
+	Vehicle    Vehicle          `json:"vehicle"`
+	DistanceKm float64          `json:"distance_km"`
+	Tier       types.DriverTier `json:"tier"`
+	Simulator  bool             `json:"simulator"`
 }
 
 type Vehicle struct {
@@ -42,10 +75,13 @@ type Vehicle struct {
 
 // DriverStatusUpdateMessage — структура сообщения для обновления статуса водителя
 type DriverStatusUpdateMessage struct {
-	DriverID  uuid.UUID  `json:"driver_id"`
-	Status    string     `json:"status"`
-	RideID    *uuid.UUID `json:"ride_id,omitempty"`
-	Timestamp time.Time  `json:"timestamp"`
+	Schema
+
+	DriverID   uuid.UUID  `json:"driver_id"`
+	Status     string     `json:"status"`
+	RideID     *uuid.UUID `json:"ride_id,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+	DistanceKm *float64   `json:"distance_km,omitempty"` // фактическое расстояние, только для COMPLETED
 }
 
 type DriverInfo struct {
@@ -55,6 +91,8 @@ type DriverInfo struct {
 }
 
 type DriverMatchResponse struct {
+	Schema
+
 	RideID                  uuid.UUID  `json:"ride_id"`
 	DriverID                uuid.UUID  `json:"driver_id"`
 	Accepted                bool       `json:"accepted"`
@@ -62,4 +100,7 @@ type DriverMatchResponse struct {
 	DriverLocation          Location   `json:"driver_location"`
 	DriverInfo              DriverInfo `json:"driver_info"`
 	CorrelationID           string     `json:"correlation_id"`
+
+	// Класс автомобиля водителя, если поиск перешел на смежный класс; поездка оплачивается по заказанному классу
+	ServedVehicleClass *types.VehicleClass `json:"served_vehicle_class,omitempty"`
 }
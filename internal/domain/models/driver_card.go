@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// DriverCard — водитель и автомобиль, которых пассажир видит до посадки, чтобы узнать машину
+type DriverCard struct {
+	DriverID uuid.UUID         `json:"driver_id"`
+	Name     string            `json:"name"`
+	PhotoURL *string           `json:"photo_url"`
+	Rating   float64           `json:"rating"`
+	Vehicle  DriverCardVehicle `json:"vehicle"`
+	// ETAMinutes — минут до точки посадки, nil после прибытия водителя или без его координат
+	ETAMinutes     *int      `json:"eta_minutes"`
+	DriverLocation *Location `json:"driver_location,omitempty"`
+}
+
+// DriverCardVehicle — автомобиль водителя. Class — фактический класс, он может быть смежным с заказанным.
+type DriverCardVehicle struct {
+	Class types.VehicleClass `json:"class"`
+	Make  string             `json:"make"`
+	Model string             `json:"model"`
+	Color string             `json:"color"`
+	Plate string             `json:"plate"`
+}
+
+// DriverMatchedEvent — событие driver_matched для пассажира: ответ водителя и карточка водителя.
+// Поля ответа остаются на верхнем уровне, как до появления карточки.
+type DriverMatchedEvent struct {
+	DriverMatchResponse
+	DriverCard *DriverCard `json:"driver_card,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// DriverProfileUpdate — изменения профиля водителя, nil поля не меняются
+type DriverProfileUpdate struct {
+	Name          *string
+	Phone         *string
+	Vehicle       *VehicleUpdate
+	LicenseNumber *string // применяется только после одобрения администратора
+	PhotoURL      *string // применяется только после одобрения администратора
+}
+
+// VehicleUpdate — частичное изменение атрибутов автомобиля
+type VehicleUpdate struct {
+	Make  *string `json:"make"`
+	Model *string `json:"model"`
+	Color *string `json:"color"`
+	Plate *string `json:"plate"`
+	Year  *int    `json:"year"`
+}
+
+// Apply возвращает копию v с примененными изменениями
+func (u VehicleUpdate) Apply(v Vehicle) Vehicle {
+	if u.Make != nil {
+		v.Make = *u.Make
+	}
+	if u.Model != nil {
+		v.Model = *u.Model
+	}
+	if u.Color != nil {
+		v.Color = *u.Color
+	}
+	if u.Plate != nil {
+		v.Plate = *u.Plate
+	}
+	if u.Year != nil {
+		v.Year = *u.Year
+	}
+	return v
+}
+
+// DriverChangeRequest — изменение чувствительного поля профиля, ожидающее одобрения администратора
+type DriverChangeRequest struct {
+	ID         uuid.UUID                `json:"id"`
+	DriverID   uuid.UUID                `json:"driver_id"`
+	Field      string                   `json:"field"`
+	OldValue   string                   `json:"old_value"`
+	NewValue   string                   `json:"new_value"`
+	Status     types.DriverChangeStatus `json:"status"`
+	Reason     *string                  `json:"reason,omitempty"`
+	CreatedAt  time.Time                `json:"created_at"`
+	ReviewedAt *time.Time               `json:"reviewed_at,omitempty"`
+	ReviewedBy *uuid.UUID               `json:"reviewed_by,omitempty"`
+}
+
+// Поля профиля, изменение которых требует одобрения
+const (
+	DriverFieldLicenseNumber = "license_number"
+	DriverFieldPhotoURL      = "photo_url" // по фото пассажир узнает водителя при посадке
+)
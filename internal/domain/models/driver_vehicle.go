@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// BodyTypes — типы кузова автомобиля. VAN, MINIVAN, SUV и CROSSOVER не старше 10 лет дают класс XL.
+var BodyTypes = []string{"SEDAN", "HATCHBACK", "WAGON", "COUPE", "SUV", "CROSSOVER", "VAN", "MINIVAN"}
+
+// DriverVehicle — автомобиль водителя. У водителя может быть несколько автомобилей,
+// заказы он получает в классе активного.
+type DriverVehicle struct {
+	ID        uuid.UUID          `json:"id"`
+	DriverID  uuid.UUID          `json:"driver_id"`
+	Class     types.VehicleClass `json:"vehicle_class"`       // определяется по кузову, марке и году
+	BodyType  string             `json:"body_type,omitempty"` // SEDAN, SUV, VAN и т.п., пусто у перенесенных из профиля
+	Make      string             `json:"make"`
+	Model     string             `json:"model"`
+	Color     string             `json:"color"`
+	Plate     string             `json:"plate"`
+	Year      int                `json:"year"`
+	IsActive  bool               `json:"is_active"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// Vehicle — атрибуты автомобиля в профиле водителя
+func (v DriverVehicle) Vehicle() Vehicle {
+	return Vehicle{
+		Type:  v.Class,
+		Make:  v.Make,
+		Model: v.Model,
+		Color: v.Color,
+		Plate: v.Plate,
+		Year:  v.Year,
+	}
+}
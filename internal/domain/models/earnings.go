@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// EarningsBucket — заработок водителя за один день, неделю или месяц (UTC)
+type EarningsBucket struct {
+	PeriodStart    time.Time `json:"period_start,omitzero"`
+	Earnings       float64   `json:"earnings"` // заработок смен, начатых в периоде
+	CompletedRides int       `json:"completed_rides"`
+	OnlineHours    float64   `json:"online_hours"`
+	AvgPerRide     float64   `json:"avg_earnings_per_ride"`
+}
+
+// EarningsReport — отчет о заработке водителя за последние периоды, старые первыми
+type EarningsReport struct {
+	DriverID uuid.UUID            `json:"driver_id"`
+	Period   types.EarningsPeriod `json:"period"`
+	Buckets  []EarningsBucket     `json:"buckets"`
+	Total    EarningsBucket       `json:"total"`
+}
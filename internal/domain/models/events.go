@@ -16,6 +16,14 @@ type PassengerLocationUpdateDTO struct {
 	DistanceToPickupKm float64   `json:"distance_to_pickup_km"`
 }
 
+// DriverApproachingEvent — водитель почти у точки посадки, отправляется пассажиру один раз до отъезда за порог
+type DriverApproachingEvent struct {
+	RideID             uuid.UUID `json:"ride_id"`
+	DriverLocation     Location  `json:"driver_location"`
+	DistanceToPickupKm float64   `json:"distance_to_pickup_km"`
+	EstimatedArrival   time.Time `json:"estimated_arrival"`
+}
+
 type PassengerRideStatusUpdateDTO struct {
 	Type          string    `json:"type"`
 	RideID        uuid.UUID `json:"ride_id"`
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// StateSnapshot — согласованный снимок живого состояния системы для разбора инцидентов.
+// Все разделы читаются в одной read-only транзакции, адреса (PII) не выгружаются.
+type StateSnapshot struct {
+	TakenAt       time.Time         `json:"taken_at"` // начало транзакции снимка
+	Counts        StateCounts       `json:"counts"`   // строк в каждом разделе до ограничения размера
+	Truncated     bool              `json:"truncated"`
+	ActiveRides   []SnapshotRide    `json:"active_rides"`
+	OnlineDrivers []SnapshotDriver  `json:"online_drivers"`
+	OpenSessions  []SnapshotSession `json:"open_sessions"`
+}
+
+type StateCounts struct {
+	ActiveRides   int `json:"active_rides"`
+	OnlineDrivers int `json:"online_drivers"`
+	OpenSessions  int `json:"open_sessions"`
+}
+
+func (c StateCounts) Total() int {
+	return c.ActiveRides + c.OnlineDrivers + c.OpenSessions
+}
+
+type SnapshotRide struct {
+	ID            uuid.UUID  `json:"id"`
+	RideNumber    string     `json:"ride_number"`
+	Status        string     `json:"status"`
+	PassengerID   uuid.UUID  `json:"passenger_id"`
+	DriverID      *uuid.UUID `json:"driver_id,omitempty"`
+	VehicleType   string     `json:"vehicle_type"`
+	Pickup        *Location  `json:"pickup,omitempty"`
+	Destination   *Location  `json:"destination,omitempty"`
+	EstimatedFare float64    `json:"estimated_fare"`
+	Priority      int        `json:"priority"`
+	IsTest        bool       `json:"is_test"`
+	RequestedAt   *time.Time `json:"requested_at,omitempty"`
+	MatchedAt     *time.Time `json:"matched_at,omitempty"`
+	ArrivedAt     *time.Time `json:"arrived_at,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+}
+
+type SnapshotDriver struct {
+	ID                uuid.UUID  `json:"id"`
+	Status            string     `json:"status"`
+	VehicleType       string     `json:"vehicle_type"`
+	IsSimulator       bool       `json:"is_simulator"`
+	Location          *Location  `json:"location,omitempty"`
+	LocationUpdatedAt *time.Time `json:"location_updated_at,omitempty"`
+}
+
+type SnapshotSession struct {
+	ID            uuid.UUID `json:"id"`
+	DriverID      uuid.UUID `json:"driver_id"`
+	StartedAt     time.Time `json:"started_at"`
+	TotalRides    int       `json:"total_rides"`
+	TotalEarnings float64   `json:"total_earnings"`
+}
+
+// StateExport — фоновая выгрузка снимка для большого парка. Snapshot есть только в статусе READY.
+type StateExport struct {
+	ID          uuid.UUID               `json:"id"`
+	Status      types.StateExportStatus `json:"status"`
+	RequestedBy uuid.UUID               `json:"requested_by"`
+	ItemCount   int                     `json:"item_count"`
+	Truncated   bool                    `json:"truncated"`
+	Snapshot    *StateSnapshot          `json:"snapshot,omitempty"`
+	Error       *string                 `json:"error,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	CompletedAt *time.Time              `json:"completed_at,omitempty"`
+}
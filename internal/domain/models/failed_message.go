@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FailedMessage — сообщение брокера, которое потребитель отклонил без возврата в очередь
+type FailedMessage struct {
+	ID            uuid.UUID `json:"id"`
+	Queue         string    `json:"queue"` // очередь, из которой сообщение ушло в dead letter
+	Exchange      string    `json:"exchange"`
+	RoutingKey    string    `json:"routing_key"`
+	Reason        string    `json:"reason"` // rejected, expired, maxlen
+	DeathCount    int       `json:"death_count"`
+	CorrelationID *string   `json:"correlation_id,omitempty"`
+	ContentType   *string   `json:"content_type,omitempty"`
+	Producer      *string   `json:"producer,omitempty"` // сервис-отправитель по проверенной подписи
+	Body          []byte    `json:"-"`
+	// Payload — тело сообщения, если это JSON, иначе RawPayload
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	RawPayload  string          `json:"raw_payload,omitempty"`
+	FailedAt    time.Time       `json:"failed_at"`
+	ReplayCount int             `json:"replay_count"`
+	ReplayedAt  *time.Time      `json:"replayed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// SetPayload заполняет представление тела сообщения для ответа API
+func (m *FailedMessage) SetPayload() {
+	if json.Valid(m.Body) {
+		m.Payload = m.Body
+		return
+	}
+	m.RawPayload = string(m.Body)
+}
+
+// FailedMessageFilter — фильтр списка сообщений dead letter
+type FailedMessageFilter struct {
+	Queue    string
+	Replayed *bool // nil — все
+	Limit    int
+}
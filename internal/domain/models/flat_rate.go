@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FareZone — круглая зона тарифа
+type FareZone struct {
+	Center   Location `json:"center"`
+	RadiusKm float64  `json:"radius_km"`
+}
+
+// FlatRate — версия фиксированного тарифа: цена маршрута между двумя зонами (FLAT)
+// или минимальная стоимость поездки из зоны (MINIMUM).
+// Версии одного кода не пересекаются по времени, изменение тарифа создает новую версию.
+type FlatRate struct {
+	ID       uuid.UUID          `json:"id"`
+	Code     string             `json:"code"`
+	Name     string             `json:"name"`
+	RateType types.FlatRateType `json:"rate_type"`
+	// Класс автомобиля, пусто — все классы
+	VehicleType string   `json:"vehicle_type,omitempty"`
+	Origin      FareZone `json:"origin"`
+	// Зона назначения маршрута FLAT, у MINIMUM ее нет
+	Destination *FareZone `json:"destination,omitempty"`
+	// Маршрут действует и в обратную сторону
+	Bidirectional bool    `json:"bidirectional"`
+	Fare          float64 `json:"fare"`
+
+	EffectiveFrom time.Time `json:"effective_from"`
+	// nil — версия действует, пока ее не заменит новая
+	EffectiveTo *time.Time `json:"effective_to,omitempty"`
+
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// EffectiveAt сообщает, действует ли версия в момент at
+func (r *FlatRate) EffectiveAt(at time.Time) bool {
+	if at.Before(r.EffectiveFrom) {
+		return false
+	}
+	return r.EffectiveTo == nil || at.Before(*r.EffectiveTo)
+}
+
+// AppliesTo сообщает, распространяется ли тариф на класс автомобиля
+func (r *FlatRate) AppliesTo(rideType string) bool {
+	return r.VehicleType == "" || r.VehicleType == rideType
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FraudEdge — подозрительная связь между двумя пользователями
+type FraudEdge struct {
+	Source     uuid.UUID         `json:"source"`
+	SourceRole types.UserRole    `json:"-"`
+	Target     uuid.UUID         `json:"target"`
+	TargetRole types.UserRole    `json:"-"`
+	Kind       types.FraudSignal `json:"kind"`
+	Weight     int               `json:"weight"` // общих устройств или поездок пары
+}
+
+// FraudNode — пользователь, участвующий в подозрительных связях
+type FraudNode struct {
+	ID    uuid.UUID      `json:"id"`
+	Role  types.UserRole `json:"role"`
+	Score int            `json:"score"` // сумма весов связей
+}
+
+// FraudGraph — граф подозрительных связей водителей и пассажиров для инструментов визуализации
+type FraudGraph struct {
+	Nodes      []FraudNode `json:"nodes"`
+	Edges      []FraudEdge `json:"edges"`
+	Since      time.Time   `json:"since"` // начало окна анализа поездок
+	ComputedAt time.Time   `json:"computed_at,omitzero"`
+}
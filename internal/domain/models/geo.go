@@ -0,0 +1,63 @@
+package models
+
+import (
+	"math"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// nullIslandEpsilon — координаты ближе этого значения к (0, 0) считаются незаполненными
+const nullIslandEpsilon = 1e-6
+
+// ServiceArea — прямоугольник, в котором сервис принимает координаты
+type ServiceArea struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+// serviceArea по умолчанию — весь земной шар. Задается один раз при старте сервиса.
+var serviceArea = ServiceArea{MinLatitude: -90, MaxLatitude: 90, MinLongitude: -180, MaxLongitude: 180}
+
+// SetServiceArea задает границы зоны обслуживания. Вызывается до запуска обработчиков.
+func SetServiceArea(area ServiceArea) {
+	serviceArea = area
+}
+
+// Contains сообщает, что точка лежит внутри зоны
+func (a ServiceArea) Contains(lat, lng float64) bool {
+	return lat >= a.MinLatitude && lat <= a.MaxLatitude && lng >= a.MinLongitude && lng <= a.MaxLongitude
+}
+
+// ValidateCoordinates проверяет координаты на допустимый диапазон, (0, 0) и зону обслуживания.
+// Используется во всех точках приема координат: HTTP, WebSocket и консьюмерах брокера.
+func ValidateCoordinates(lat, lng float64) error {
+	switch {
+	case math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180:
+		return types.ErrInvalidCoordinates
+	case math.Abs(lat) < nullIslandEpsilon && math.Abs(lng) < nullIslandEpsilon:
+		return types.ErrNullIslandCoordinates
+	case !serviceArea.Contains(lat, lng):
+		return types.ErrOutsideServiceArea
+	}
+	return nil
+}
+
+// Validate проверяет координаты точки
+func (l Location) Validate() error {
+	return ValidateCoordinates(l.Latitude, l.Longitude)
+}
+
+// Validate проверяет координаты сообщения о местоположении водителя
+func (m RideLocationUpdate) Validate() error {
+	return m.Location.Validate()
+}
+
+// Validate проверяет точки подачи и назначения заказа
+func (m RideRequestedMessage) Validate() error {
+	if err := m.PickupLocation.Validate(); err != nil {
+		return err
+	}
+	return m.DestinationLocation.Validate()
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// MonthlyImpact — углеродный след пассажира за месяц (UTC)
+type MonthlyImpact struct {
+	Month      string  `json:"month,omitempty"` // YYYY-MM, пусто в итоговой строке
+	Rides      int     `json:"rides"`
+	DistanceKm float64 `json:"distance_km"`
+	CO2Grams   float64 `json:"co2_grams"`
+}
+
+// PassengerImpact — углеродный след завершенных поездок пассажира по месяцам
+type PassengerImpact struct {
+	PassengerID uuid.UUID       `json:"passenger_id"`
+	Since       time.Time       `json:"since"`
+	Months      []MonthlyImpact `json:"months"`
+	Total       MonthlyImpact   `json:"total"`
+}
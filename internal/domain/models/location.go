@@ -21,9 +21,28 @@ type Coordinates struct {
 
 // RabbitMQ message: For Location Update → <location_fanout> exchange
 type RideLocationUpdate struct {
+	Schema
+
 	DriverID  uuid.UUID  `json:"driver_id"`
 	RideID    *uuid.UUID `json:"ride_id,omitempty"`
 	TimeStamp time.Time  `json:"timestamp"`
 
 	Coordinates
+
+	// Signature — подпись устройства, nil если приложение не подписывает координаты
+	Signature *LocationSignature `json:"-"`
+}
+
+// LocationSignature — HMAC подпись координат секретом устройства (см. pkg/locsign)
+type LocationSignature struct {
+	DeviceID  string
+	Timestamp int64 // unix секунды, входят в подпись
+	Value     string
+}
+
+// DriverPosition — текущая координата водителя
+type DriverPosition struct {
+	DriverID  uuid.UUID `json:"driver_id"`
+	Location  Location  `json:"location"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
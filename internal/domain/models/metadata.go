@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -20,6 +21,32 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	// Params — значения общих фильтров из query string, разрешенных для эндпоинта
+	Params map[string]string
+}
+
+// ListOptions describes how a list endpoint is paginated: defaults, allowed sort keys
+// and the query parameters accepted as filters.
+type ListOptions struct {
+	DefaultPageSize int
+	DefaultSort     string
+	SortSafelist    []string
+	FilterKeys      []string
+}
+
+// Param returns the value of an allowed filter, or "" if it was not provided.
+func (f Filters) Param(key string) string {
+	return f.Params[key]
+}
+
+// FiltersFromContext returns filters parsed by the pagination middleware.
+func FiltersFromContext(ctx context.Context) (Filters, bool) {
+	f, ok := ctx.Value(filtersCtxKey).(Filters)
+	return f, ok
+}
+
+func WithFilters(ctx context.Context, f Filters) context.Context {
+	return context.WithValue(ctx, filtersCtxKey, f)
 }
 
 func NewFilters(page int, pageSize int, sort string, sortSafelist []string) (Filters, error) {
@@ -73,11 +100,12 @@ func (f Filters) Offset() int {
 }
 
 type Metadata struct {
-	CurrentPage  int `json:"current_page"`
-	PageSize     int `json:"page_size"`
-	FirstPage    int `json:"first_page"`
-	LastPage     int `json:"last_page"`
-	TotalRecords int `json:"total_records"`
+	CurrentPage  int  `json:"current_page"`
+	PageSize     int  `json:"page_size"`
+	FirstPage    int  `json:"first_page"`
+	LastPage     int  `json:"last_page"`
+	TotalRecords int  `json:"total_records"`
+	HasNext      bool `json:"has_next"`
 }
 
 // The CalculateMetadata() function calculates the appropriate pagination metadata
@@ -96,11 +124,13 @@ func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
 			TotalRecords: 0,
 		}
 	}
+	lastPage := int(math.Ceil(float64(totalRecords) / float64(pageSize)))
 	return Metadata{
 		CurrentPage:  page,
 		PageSize:     pageSize,
 		FirstPage:    1,
-		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		LastPage:     lastPage,
 		TotalRecords: totalRecords,
+		HasNext:      page < lastPage,
 	}
 }
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// NotificationPreferences — каналы и типы уведомлений, на которые подписан пользователь
+type NotificationPreferences struct {
+	UserID     uuid.UUID                   `json:"user_id"`
+	Channels   []types.NotificationChannel `json:"channels"`
+	EventTypes []types.NotificationEvent   `json:"event_types"`
+	// VoiceReadout — озвучивать важные уведомления автоматическим звонком (для незрячих пассажиров)
+	VoiceReadout bool      `json:"voice_readout"`
+	UpdatedAt    time.Time `json:"updated_at,omitzero"`
+}
+
+// DefaultNotificationPreferences — пользователь без сохраненных настроек получает все уведомления
+func DefaultNotificationPreferences(userID uuid.UUID) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:     userID,
+		Channels:   slices.Clone(types.AllNotificationChannels),
+		EventTypes: slices.Clone(types.AllNotificationEvents),
+	}
+}
+
+// AllowsEvent сообщает, подписан ли пользователь на тип уведомления независимо от канала
+func (p *NotificationPreferences) AllowsEvent(event types.NotificationEvent) bool {
+	return event.IsCritical() || slices.Contains(p.EventTypes, event)
+}
+
+// Allows сообщает, можно ли отправить уведомление event по каналу channel.
+// Критичные уведомления разрешены всегда.
+func (p *NotificationPreferences) Allows(channel types.NotificationChannel, event types.NotificationEvent) bool {
+	if event.IsCritical() {
+		return true
+	}
+	return slices.Contains(p.Channels, channel) && slices.Contains(p.EventTypes, event)
+}
+
+// Notification — сообщение пользователю вне приложения (push, SMS, email)
+type Notification struct {
+	UserID uuid.UUID
+	// RideID — поездка, к которой относится уведомление, результат доставки попадает в ее журнал коммуникаций
+	RideID *uuid.UUID
+	Event  types.NotificationEvent
+	Title  string
+	Body   string
+	// ReadAloud — текст для озвучивания голосовым звонком, пустой - звонок не нужен
+	ReadAloud string
+	// Data — данные для приложения в push уведомлении (ride_id, status), по ним открывается нужный экран
+	Data map[string]string
+}
+
+// PushToken — токен push провайдера на устройстве пользователя
+type PushToken struct {
+	UserID    uuid.UUID          `json:"-"`
+	DeviceID  string             `json:"device_id"`
+	Platform  types.PushPlatform `json:"platform"`
+	Token     string             `json:"-"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// OfflineAction — действие, которое приложение водителя выполнило без сети.
+// ID генерирует приложение, по нему повторная отправка не применяется дважды.
+type OfflineAction struct {
+	ID                string
+	Type              types.OfflineActionType
+	PerformedAt       time.Time
+	RideID            *uuid.UUID
+	Location          Location
+	ActualDistanceKm  float64
+	ActualDurationMin int
+}
+
+// ReconcileResult — результат сверки одного офлайн действия с состоянием сервера
+type ReconcileResult struct {
+	ActionID    string                  `json:"action_id"`
+	Type        types.OfflineActionType `json:"type"`
+	Status      types.ReconcileStatus   `json:"status"`
+	Message     string                  `json:"message,omitempty"`
+	Replayed    bool                    `json:"replayed,omitempty"` // результат уже был сохранен при прошлой отправке
+	PerformedAt time.Time               `json:"performed_at"`
+	ProcessedAt time.Time               `json:"processed_at"`
+}
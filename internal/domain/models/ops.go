@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// OpsAction — запись журнала операционных действий дежурного инженера.
+// Действия экземпляра сервиса (TargetService, TargetInstance) выполняет сам экземпляр.
+type OpsAction struct {
+	ID             uuid.UUID             `json:"id"`
+	Action         types.OpsActionType   `json:"action"`
+	Params         json.RawMessage       `json:"params"`
+	Status         types.OpsActionStatus `json:"status"`
+	TargetService  *string               `json:"target_service,omitempty"`
+	TargetInstance *string               `json:"target_instance,omitempty"`
+	Result         json.RawMessage       `json:"result,omitempty"`
+	Error          *string               `json:"error,omitempty"`
+	RequestedBy    uuid.UUID             `json:"requested_by"`
+	CreatedAt      time.Time             `json:"created_at"`
+	CompletedAt    *time.Time            `json:"completed_at,omitempty"`
+}
+
+// DrainWSParams — параметры DRAIN_WS
+type DrainWSParams struct {
+	Service  string `json:"service"`  // ride или driver
+	Instance string `json:"instance"` // INSTANCE_ID экземпляра, по умолчанию hostname
+}
+
+// PauseMatchingParams — параметры PAUSE_MATCHING
+type PauseMatchingParams struct {
+	City   string `json:"city"`
+	Paused *bool  `json:"paused"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// OutboxMessage — сообщение брокера, записанное в одной транзакции с изменением поездки
+type OutboxMessage struct {
+	ID             uuid.UUID
+	IdempotencyKey string // уходит потребителям как message_id
+	Topic          types.OutboxTopic
+	Payload        json.RawMessage
+	Attempts       int
+	// Запрос поиска водителя задержан ограничением подбора до этого момента, nil — не задерживался
+	HeldUntil *time.Time
+	CreatedAt time.Time
+}
+
+// WaitingSince возвращает момент, с которого сообщение ждет публикации: задержка ограничением не считается ожиданием
+func (m *OutboxMessage) WaitingSince() time.Time {
+	if m.HeldUntil != nil && m.HeldUntil.After(m.CreatedAt) {
+		return *m.HeldUntil
+	}
+	return m.CreatedAt
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Partner — таксопарк, подключающий своих водителей через partner API
+type Partner struct {
+	ID            uuid.UUID `json:"partner_id"`
+	Name          string    `json:"name"`
+	WebhookURL    string    `json:"webhook_url"`
+	WebhookSecret string    `json:"-"` // подпись вебхуков, выдаётся один раз при создании
+	IsActive      bool      `json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PartnerCredentials — ключи партнёра, показываются только при создании
+type PartnerCredentials struct {
+	Partner       *Partner `json:"partner"`
+	APIKey        string   `json:"api_key"`
+	WebhookSecret string   `json:"webhook_secret"`
+}
+
+// PartnerFromContext возвращает партнёра, аутентифицированного по API ключу, или nil
+func PartnerFromContext(ctx context.Context) *Partner {
+	p, ok := ctx.Value(partnerCtxKey).(*Partner)
+	if !ok {
+		return nil
+	}
+	return p
+}
+
+func WithPartner(ctx context.Context, p *Partner) context.Context {
+	return context.WithValue(ctx, partnerCtxKey, p)
+}
+
+// PartnerDriver — водитель из пакетной регистрации партнёра
+type PartnerDriver struct {
+	Email         string
+	Phone         string
+	Name          string
+	LicenseNumber string
+	Vehicle       Vehicle
+}
+
+// PartnerDriverResult — результат регистрации одного водителя из пачки
+type PartnerDriverResult struct {
+	Index    int        `json:"index"`
+	DriverID *uuid.UUID `json:"driver_id,omitempty"`
+	Class    string     `json:"class,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// PartnerStatusReport — статус водителя, который сообщает партнёр
+type PartnerStatusReport struct {
+	DriverID          uuid.UUID
+	Status            types.PartnerDriverStatus
+	RideID            uuid.UUID
+	Location          Location
+	ActualDistanceKm  float64
+	ActualDurationMin int
+}
+
+// PartnerWebhookEvent — событие, отправляемое на вебхук партнёра
+type PartnerWebhookEvent struct {
+	Type     string    `json:"type"` // ride_offer, ride_details
+	DriverID uuid.UUID `json:"driver_id"`
+	Data     any       `json:"data"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// PartnerOffer — оффер водителю таксопарка, ожидающий ответа партнёра
+type PartnerOffer struct {
+	OfferID   uuid.UUID
+	PartnerID uuid.UUID
+	DriverID  uuid.UUID
+	RideID    uuid.UUID
+	ExpiresAt time.Time
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// DriverBalance — баланс водителя, доступный для мгновенного вывода
+type DriverBalance struct {
+	DriverID  uuid.UUID           `json:"driver_id"`
+	Available float64             `json:"available"`
+	UpdatedAt *time.Time          `json:"updated_at,omitempty"`
+	Entries   []DriverLedgerEntry `json:"entries"`
+}
+
+// DriverLedgerEntry — запись журнала баланса водителя
+type DriverLedgerEntry struct {
+	ID           uuid.UUID                   `json:"id"`
+	DriverID     uuid.UUID                   `json:"driver_id"`
+	RideID       *uuid.UUID                  `json:"ride_id,omitempty"`
+	PayoutID     *uuid.UUID                  `json:"payout_id,omitempty"`
+	Type         types.DriverLedgerEntryType `json:"type"`
+	Amount       float64                     `json:"amount"`
+	BalanceAfter float64                     `json:"balance_after"`
+	CreatedAt    time.Time                   `json:"created_at"`
+}
+
+// Payout — мгновенный вывод. Amount списывается с баланса, NetAmount = Amount - Fee получает водитель.
+type Payout struct {
+	ID               uuid.UUID          `json:"id"`
+	DriverID         uuid.UUID          `json:"driver_id"`
+	Amount           float64            `json:"amount"`
+	Fee              float64            `json:"fee"`
+	NetAmount        float64            `json:"net_amount"`
+	Status           types.PayoutStatus `json:"status"`
+	ProviderPayoutID *string            `json:"provider_payout_id,omitempty"`
+	FailureReason    *string            `json:"failure_reason,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// PayoutUsage — выводы водителя за последние сутки для проверки лимитов
+type PayoutUsage struct {
+	Count    int
+	Amount   float64
+	InFlight bool // есть вывод в PENDING или PROCESSING
+}
+
+// PayoutEligibility — данные водителя для антифрод-проверок вывода
+type PayoutEligibility struct {
+	Verified       bool
+	RegisteredAt   time.Time
+	CompletedRides int
+}
+
+// ProviderPayout — статус перевода у провайдера: ответ на запрос перевода и событие вебхука.
+// Reference — ID вывода в системе, передается провайдеру как ключ идемпотентности.
+type ProviderPayout struct {
+	EventID       string             `json:"event_id,omitempty"`
+	ID            string             `json:"payout_id"`
+	Reference     uuid.UUID          `json:"reference"`
+	Status        types.PayoutStatus `json:"status"`
+	FailureReason string             `json:"failure_reason,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// ProfileUpdate — изменение профиля пользователя, nil поля не меняются.
+// Attrs дописываются к сохраненным, имя и телефон задаются только своими полями.
+type ProfileUpdate struct {
+	Name  *string
+	Phone *string
+	Attrs map[string]any
+}
+
+// PhoneCode — последний одноразовый код подтверждения телефона, хранится только хэш
+type PhoneCode struct {
+	UserID    uuid.UUID
+	CodeHash  string
+	ExpiresAt time.Time
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// PhoneVerification — отправленный код подтверждения телефона
+type PhoneVerification struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	// ResendAfter — раньше этого времени новый код не отправляется
+	ResendAfter time.Time `json:"resend_after"`
+}
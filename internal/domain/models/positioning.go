@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// IdleDriver — свободный водитель с текущей позицией
+type IdleDriver struct {
+	ID          uuid.UUID
+	Location    Location
+	TipsEnabled bool // водитель не отписался от POSITIONING_TIPS
+}
+
+// PositioningRecommendation — рекомендация водителю переместиться в район с высоким спросом
+type PositioningRecommendation struct {
+	ID                  uuid.UUID
+	DriverID            uuid.UUID
+	Cell                string
+	Target              Location
+	Origin              Location // где был водитель в момент рекомендации
+	ExpectedWaitMinutes int
+	SentAt              time.Time
+}
+
+// PositioningTip — сообщение positioning_tip водителю по WebSocket
+type PositioningTip struct {
+	MsgType             string    `json:"type"`
+	RecommendationID    uuid.UUID `json:"recommendation_id"`
+	Area                string    `json:"area"`
+	Latitude            float64   `json:"latitude"`
+	Longitude           float64   `json:"longitude"`
+	DistanceKm          float64   `json:"distance_km"`
+	ExpectedWaitMinutes int       `json:"expected_wait_minutes"`
+	Message             string    `json:"message"`
+}
+
+// PositioningEffectiveness — эффективность рекомендаций за период
+type PositioningEffectiveness struct {
+	Sent      int      `json:"sent"`
+	Evaluated int      `json:"evaluated"`
+	Moved     int      `json:"moved"`    // водитель доехал до района
+	GotRide   int      `json:"got_ride"` // водитель получил поездку после рекомендации
+	MoveRate  *float64 `json:"move_rate"`
+	RideRate  *float64 `json:"ride_rate"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// PromoCode — промокод на скидку для поездки
+type PromoCode struct {
+	ID           uuid.UUID          `json:"id"`
+	Code         string             `json:"code"`
+	DiscountType types.DiscountType `json:"discount_type"`
+	// Процент (1-100) для PERCENT или сумма для FLAT
+	Value float64 `json:"value"`
+	// Ограничение процентной скидки, nil — без ограничения
+	MaxDiscount *float64   `json:"max_discount,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// Всего применений, nil — без ограничения
+	MaxUses           *int       `json:"max_uses,omitempty"`
+	PerPassengerLimit int        `json:"per_passenger_limit"`
+	UsedCount         int        `json:"used_count"`
+	Active            bool       `json:"active"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// PromoCodeUpdate — изменяемые поля промокода, nil — поле не меняется
+type PromoCodeUpdate struct {
+	Value             *float64
+	MaxDiscount       *float64
+	ExpiresAt         *time.Time
+	MaxUses           *int
+	PerPassengerLimit *int
+	Active            *bool
+}
+
+// PromoDiscount — скидка промокода, рассчитанная для поездки
+type PromoDiscount struct {
+	PromoID     uuid.UUID
+	Code        string
+	PassengerID uuid.UUID
+	Discount    float64
+}
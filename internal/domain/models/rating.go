@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// RideRating — оценка водителя пассажиром после завершенной поездки
+type RideRating struct {
+	RideID      uuid.UUID `json:"ride_id"`
+	DriverID    uuid.UUID `json:"driver_id"`
+	PassengerID uuid.UUID `json:"-"` // водителю не показывается
+	Score       int       `json:"score"`
+	Comment     *string   `json:"comment,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DriverRating — средняя оценка водителя и число оценок
+type DriverRating struct {
+	Rating       float64 `json:"rating"`
+	RatingsCount int     `json:"ratings_count"`
+}
+
+// DriverRatingHistory — страница оценок водителя, новые первыми
+type DriverRatingHistory struct {
+	DriverID uuid.UUID `json:"driver_id"`
+	DriverRating
+	Ratings  []RideRating `json:"ratings"`
+	Metadata Metadata     `json:"metadata"`
+}
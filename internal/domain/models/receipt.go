@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FareBreakdown — состав стоимости поездки.
+// base_fare + distance_fare + time_fare + surge + adjustment - discount = total.
+// Налог входит в total, total без налога делится между водителем и платформой.
+type FareBreakdown struct {
+	BaseFare     float64 `json:"base_fare"`
+	DistanceFare float64 `json:"distance_fare"`
+	TimeFare     float64 `json:"time_fare"`
+	Surge        float64 `json:"surge"`      // надбавка за спрос
+	Adjustment   float64 `json:"adjustment"` // ночной тариф города, фиксированный или минимальный тариф зоны
+	Discount     float64 `json:"discount"`   // скидка по промокоду
+	Total        float64 `json:"total"`      // к оплате пассажиром
+
+	Tax                float64 `json:"tax"`
+	DriverEarnings     float64 `json:"driver_earnings"`
+	PlatformCommission float64 `json:"platform_commission"`
+}
+
+// Subtotal — стоимость по тарифу без надбавок и скидок
+func (b FareBreakdown) Subtotal() float64 {
+	return b.BaseFare + b.DistanceFare + b.TimeFare
+}
+
+// Receipt — чек завершенной поездки для GET /rides/{ride_id}/receipt
+type Receipt struct {
+	RideID          uuid.UUID           `json:"ride_id"`
+	RideNumber      string              `json:"ride_number"`
+	PassengerID     uuid.UUID           `json:"passenger_id"`
+	DriverID        *uuid.UUID          `json:"driver_id,omitempty"`
+	VehicleClass    string              `json:"vehicle_class"`
+	PaymentMethod   types.PaymentMethod `json:"payment_method"`
+	DistanceKm      float64             `json:"distance_km"`  // расстояние, по которому рассчитана стоимость
+	DurationMin     int                 `json:"duration_min"` // длительность, по которой рассчитана стоимость
+	SurgeMultiplier float64             `json:"surge_multiplier"`
+	Fare            FareBreakdown       `json:"fare"`
+	IssuedAt        time.Time           `json:"issued_at"`
+
+	// Юрлицо, выдавшее чек, и его сквозной номер счета, пусто у чеков до нумерации
+	LegalEntity   string `json:"legal_entity,omitempty"`
+	InvoiceNumber string `json:"invoice_number,omitempty"`
+	// Фискальный чек провайдера фискализации, nil — еще не фискализирован
+	FiscalReceiptID *string    `json:"fiscal_receipt_id,omitempty"`
+	FiscalizedAt    *time.Time `json:"fiscalized_at,omitempty"`
+}
@@ -23,6 +23,8 @@ type RideInfo struct {
 	DestinationLocation   Location  `json:"destination_location"`
 	DistanceCompletedKm   float64   `json:"distance_completed_km"`
 	DistanceRemainingKm   float64   `json:"distance_remaining_km"`
+
+	PriorityBoarding types.PriorityBoarding `json:"priority_boarding,omitempty"`
 }
 
 type RideEvent struct {
@@ -45,17 +47,59 @@ type Ride struct {
 	Destination Location
 	DriverID    *uuid.UUID
 
+	// Точка посадки, указанная пассажиром, и предложенная (притянутая к дороге)
+	PickupSuggestion *PickupSuggestion
+
 	// Расчетные поля
 	EstimatedFare        float64
 	EstimatedDurationMin int
 	EstimatedDistanceKm  float64
 	Priority             int
 
+	// Надбавка за спрос в зоне посадки на момент запроса, 1 — без надбавки
+	SurgeMultiplier float64
+
+	// Класс автомобиля, если поездку выполнил водитель смежного класса, nil — заказанный класс
+	ServedVehicleClass *string
+
+	// Запрос поиска водителя не отправлен из-за недоступности брокера, его отправит relay
+	PendingDispatch bool
+
+	// Поиск водителя отложен окном приостановки подбора, nil — поиск начат сразу
+	MatchingDelay *MatchingDelay
+
+	// Тестовая поездка песочницы: подбирается только среди водителей-симуляторов, не входит в выручку и метрики
+	IsTest bool
+
+	// Приоритетная посадка (медицинская, доступная среда), пусто — обычная поездка
+	PriorityBoarding types.PriorityBoarding
+
+	// Способ оплаты. WALLET при нехватке баланса заменяется на CARD
+	PaymentMethod types.PaymentMethod
+
+	// Промокод и скидка по нему, уже вычтенная из EstimatedFare
+	PromoCode string
+	Discount  float64
+
+	// Версия фиксированного тарифа, которой задана стоимость, nil — стоимость по формуле
+	FlatRateID   *uuid.UUID
+	FlatRateCode string
+
 	// Финальная стоимость.
 	FinalFare *float64
 
+	// Углеродный след, есть только у завершенных поездок
+	DistanceKm *float64
+	CO2Grams   *float64
+
 	// Причина отмены, есть только у отмененных поездок
 	CancellationReason *string
+	// Штраф пассажиру за отмену после назначения водителя, nil — отмена бесплатна
+	CancellationFee *float64
+
+	// Когда пассажир получил уведомление о скором прибытии водителя, nil — не получал
+	// или водитель снова отъехал от точки посадки
+	ApproachNotifiedAt *time.Time
 
 	// Временные метки
 	CreatedAt   time.Time
@@ -66,11 +110,34 @@ type Ride struct {
 	CancelledAt *time.Time
 }
 
+// PickupSuggestion — исходная точка посадки и ближайшая доступная для машины точка
+type PickupSuggestion struct {
+	Original  Location `json:"original"`
+	Suggested Location `json:"suggested"`
+	Snapped   bool     `json:"snapped"`    // true если точка посадки была скорректирована
+	DistanceM float64  `json:"distance_m"` // расстояние между исходной и предложенной точкой
+}
+
+// RideEstimate — предварительный расчёт поездки без её создания
+type RideEstimate struct {
+	Pickup               PickupSuggestion `json:"pickup"`
+	RideType             string           `json:"ride_type"`
+	EstimatedFare        float64          `json:"estimated_fare"`
+	FareMultiplier       float64          `json:"fare_multiplier"`     // ночная надбавка города, 1 - без надбавки
+	SurgeMultiplier      float64          `json:"surge_multiplier"`    // надбавка за спрос в зоне посадки, 1 - без надбавки
+	FlatRate             string           `json:"flat_rate,omitempty"` // код примененного фиксированного тарифа
+	EstimatedDurationMin int              `json:"estimated_duration_minutes"`
+	EstimatedDistanceKm  float64          `json:"estimated_distance_km"`
+}
+
 /* ======================= rabbitmq ======================= */
 
 type RideRequestedMessage struct {
+	Schema
+
 	RideID              uuid.UUID `json:"ride_id"`
 	RideNumber          string    `json:"ride_number"`
+	PassengerID         uuid.UUID `json:"passenger_id"`
 	PickupLocation      Location  `json:"pickup_location"`
 	DestinationLocation Location  `json:"destination_location"`
 	RideType            string    `json:"ride_type"`
@@ -78,19 +145,47 @@ type RideRequestedMessage struct {
 	MaxDistanceKm       float64   `json:"max_distance_km"`
 	TimeoutSeconds      int       `json:"timeout_seconds"`
 	CorrelationID       string    `json:"correlation_id"`
+	MessageID           string    `json:"message_id,omitempty"` // ключ идемпотентности из outbox
 	Priority            uint8     `json:"priority"`
+	IsTest              bool      `json:"test,omitempty"`
+
+	PriorityBoarding types.PriorityBoarding `json:"priority_boarding,omitempty"`
+
+	// Водители, которым поездка не предлагается: снятый оператором водитель при переназначении
+	ExcludedDriverIDs []uuid.UUID `json:"excluded_driver_ids,omitempty"`
 }
 
 type RideStatusUpdateMessage struct {
+	Schema
+
 	RideID        uuid.UUID  `json:"ride_id"`
 	Status        string     `json:"status"`
 	Timestamp     time.Time  `json:"timestamp"`
 	DriverID      *uuid.UUID `json:"driver_id,omitempty"`
 	CorrelationID string     `json:"correlation_id"`
+	MessageID     string     `json:"message_id,omitempty"` // ключ идемпотентности из outbox
+
+	// Штраф за отмену пассажиром, driver-service зачисляет его водителю
+	CancellationFee *float64 `json:"cancellation_fee,omitempty"`
+
+	// Кто снял водителя с поездки: PASSENGER или ADMIN, и причина
+	ReleasedBy types.UserRole `json:"released_by,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
 }
 
 /* ======================= Websocket ======================= */
 
+// RideReleased — сообщение водителю о снятии с поездки: отмена (status CANCELLED)
+// или переназначение оператором другому водителю (status REQUESTED)
+type RideReleased struct {
+	MsgType    string         `json:"type"` // "ride_released"
+	RideID     uuid.UUID      `json:"ride_id"`
+	Status     string         `json:"status"`
+	ReleasedBy types.UserRole `json:"released_by,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
 type RideOffer struct {
 	MsgType                     string    `json:"type"` // By default must be: "ride_offer"
 	ID                          uuid.UUID `json:"offer_id"`
@@ -103,6 +198,32 @@ type RideOffer struct {
 	DistanceToPickupKm          float64   `json:"distance_to_pickup_km"`
 	EstimatedRideDurationMinute int       `json:"estimated_ride_duration_minutes"`
 	ExpiresAt                   time.Time `json:"expires_at"`
+
+	// водитель видит, что пассажиру нужна помощь при посадке
+	PriorityBoarding types.PriorityBoarding `json:"priority_boarding,omitempty"`
+
+	// Заказанный класс, если оффер отправлен водителю смежного класса по цене заказа
+	RequestedClass types.VehicleClass `json:"requested_class,omitempty"`
+}
+
+// RideOfferRevoked — оффер больше не действует: при параллельной рассылке поездку принял другой водитель
+type RideOfferRevoked struct {
+	MsgType   string    `json:"type"` // "ride_offer_revoked"
+	OfferID   uuid.UUID `json:"offer_id"`
+	RideID    uuid.UUID `json:"ride_id"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DefaultOfferTimeout — сколько ждать ответа на оффер без срока действия
+const DefaultOfferTimeout = 30 * time.Second
+
+// ResponseWindow возвращает, сколько ждать ответа водителя: до ExpiresAt, если срок задан
+func (o RideOffer) ResponseWindow() time.Duration {
+	if o.ExpiresAt.IsZero() {
+		return DefaultOfferTimeout
+	}
+	return max(time.Until(o.ExpiresAt), 0)
 }
 
 type RideOfferResponse struct {
@@ -121,3 +242,34 @@ type RideDetails struct {
 	Phone          *string    `json:"passenger_phone"`
 	PickupLocation Location   `json:"pickup_location"`
 }
+
+// CurrentRide — активная поездка водителя. По ней перезапущенное приложение восстанавливает экран поездки.
+type CurrentRide struct {
+	RideID           uuid.UUID              `json:"ride_id"`
+	RideNumber       string                 `json:"ride_number"`
+	Status           types.RideStatus       `json:"status"`
+	Pickup           Location               `json:"pickup_location"`
+	Destination      Location               `json:"destination_location"`
+	EstimatedFare    float64                `json:"estimated_fare"`
+	PriorityBoarding types.PriorityBoarding `json:"priority_boarding,omitempty"`
+	MatchedAt        *time.Time             `json:"matched_at,omitempty"`
+	ArrivedAt        *time.Time             `json:"arrived_at,omitempty"`
+	StartedAt        *time.Time             `json:"started_at,omitempty"`
+	Passenger        PassengerContact       `json:"passenger"`
+	Navigation       RideNavigation         `json:"navigation"`
+}
+
+// PassengerContact — как водитель видит пассажира: имя без фамилии и замаскированный телефон
+type PassengerContact struct {
+	Alias       string `json:"alias"`
+	PhoneMasked string `json:"phone_masked,omitempty"`
+}
+
+// RideNavigation — куда водителю ехать сейчас: к точке посадки до начала поездки, затем к точке назначения
+type RideNavigation struct {
+	Target           types.NavigationTarget `json:"target"`
+	TargetLocation   Location               `json:"target_location"`
+	DriverLocation   *Location              `json:"driver_location,omitempty"`
+	DistanceKm       *float64               `json:"distance_km,omitempty"`
+	EstimatedMinutes *int                   `json:"estimated_minutes,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// RideHistoryFilter — фильтры истории поездок пассажира или водителя. Пустые поля не фильтруют.
+type RideHistoryFilter struct {
+	PassengerID *uuid.UUID
+	DriverID    *uuid.UUID
+	Statuses    []types.RideStatus
+	From        *time.Time // requested_at >= From
+	To          *time.Time // requested_at < To
+}
+
+// RideHistoryItem — поездка в истории пассажира или водителя
+type RideHistoryItem struct {
+	RideID             uuid.UUID  `json:"ride_id"`
+	RideNumber         string     `json:"ride_number"`
+	Status             string     `json:"status"`
+	PassengerID        uuid.UUID  `json:"passenger_id"`
+	DriverID           *uuid.UUID `json:"driver_id,omitempty"`
+	VehicleClass       string     `json:"vehicle_class,omitempty"`
+	ServedVehicleClass *string    `json:"served_vehicle_class,omitempty"` // класс автомобиля, если поездку выполнил смежный класс
+	EstimatedFare      float64    `json:"estimated_fare"`
+	FinalFare          *float64   `json:"final_fare,omitempty"`
+	PickupAddress      string     `json:"pickup_address"`
+	DestinationAddress string     `json:"destination_address"`
+	RequestedAt        time.Time  `json:"requested_at"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+}
+
+type RideHistoryResponse struct {
+	Rides    []RideHistoryItem `json:"rides"`
+	Metadata Metadata          `json:"metadata"`
+}
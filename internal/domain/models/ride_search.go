@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// RideSearchFilter — фильтры поиска поездок администратором. Пустые поля не фильтруют.
+type RideSearchFilter struct {
+	Statuses     []types.RideStatus
+	PassengerID  *uuid.UUID
+	DriverID     *uuid.UUID
+	MinFare      *float64 // по final_fare, для незавершенных — по estimated_fare
+	MaxFare      *float64
+	From         *time.Time // requested_at >= From
+	To           *time.Time // requested_at < To
+	VehicleClass types.VehicleClass
+	City         string // точка посадки в радиусе города
+
+	Sort  string // поле сортировки, "-" в начале — по убыванию
+	Limit int
+	After *RideCursor // keyset пагинация: строки после курсора
+}
+
+// RideCursor указывает на последнюю строку страницы
+type RideCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Encode возвращает непрозрачную строку курсора для клиента
+func (c RideCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeRideCursor разбирает курсор. Курсор действителен только для той же сортировки.
+func DecodeRideCursor(s, sort string) (*RideCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c RideCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort != sort || c.Value == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+type RideSummary struct {
+	RideID             uuid.UUID  `json:"ride_id"`
+	RideNumber         string     `json:"ride_number"`
+	Status             string     `json:"status"`
+	PassengerID        uuid.UUID  `json:"passenger_id"`
+	DriverID           *uuid.UUID `json:"driver_id,omitempty"`
+	VehicleClass       string     `json:"vehicle_class,omitempty"`
+	Priority           int        `json:"priority"`
+	PriorityBoarding   string     `json:"priority_boarding,omitempty"`
+	EstimatedFare      float64    `json:"estimated_fare"`
+	FinalFare          *float64   `json:"final_fare,omitempty"`
+	PickupAddress      string     `json:"pickup_address"`
+	DestinationAddress string     `json:"destination_address"`
+	RequestedAt        time.Time  `json:"requested_at"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+}
+
+type RideSearchResponse struct {
+	Rides      []RideSummary `json:"rides"`
+	NextCursor string        `json:"next_cursor,omitempty"` // пусто — это последняя страница
+}
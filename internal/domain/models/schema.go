@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// Версии схемы сообщений брокера. Сервисы обновляются по одному, поэтому:
+//   - новое поле добавляется без смены версии: старые потребители его игнорируют,
+//     новые подставляют умолчание, если поля нет (applyDefaults);
+//   - удаление поля или смена его смысла повышает версию в два релиза: сначала
+//     MaxSchemaVersion (потребители понимают новую схему), затем SchemaVersionCurrent.
+const (
+	SchemaVersionCurrent SchemaVersion = 1 // пишется во все публикуемые сообщения
+	MinSchemaVersion     SchemaVersion = 0 // 0 — сообщения, опубликованные до появления schema_version
+	MaxSchemaVersion     SchemaVersion = 1
+)
+
+// SchemaVersion — поле schema_version сообщения брокера. Кодируется всегда текущей версией:
+// сообщение, собранное этим кодом, соответствует его схеме.
+type SchemaVersion int
+
+func (SchemaVersion) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(SchemaVersionCurrent), 10), nil
+}
+
+// Schema встраивается во все сообщения брокера
+type Schema struct {
+	SchemaVersion SchemaVersion `json:"schema_version"`
+}
+
+// Version возвращает версию схемы, с которой сообщение было опубликовано
+func (s Schema) Version() SchemaVersion {
+	return s.SchemaVersion
+}
+
+// BrokerMessage — сообщение брокера со встроенной Schema
+type BrokerMessage interface {
+	Version() SchemaVersion
+}
+
+// defaulter подставляет умолчания для полей, которых нет в сообщениях старых версий
+type defaulter interface {
+	applyDefaults()
+}
+
+// DecodeMessage разбирает сообщение брокера: неизвестные поля игнорируются, отсутствующие
+// получают умолчания. Версия вне [MinSchemaVersion, MaxSchemaVersion] — types.ErrUnsupportedSchema.
+func DecodeMessage(body []byte, msg BrokerMessage) error {
+	if err := json.Unmarshal(body, msg); err != nil {
+		return err
+	}
+
+	if v := msg.Version(); v < MinSchemaVersion || v > MaxSchemaVersion {
+		return fmt.Errorf("%w: %d, supported %d-%d", types.ErrUnsupportedSchema, v, MinSchemaVersion, MaxSchemaVersion)
+	}
+
+	if d, ok := msg.(defaulter); ok {
+		d.applyDefaults()
+	}
+	return nil
+}
+
+// Умолчания совпадают с тем, что ride-service публиковал до появления полей
+const (
+	defaultMaxDistanceKm  = 5.0
+	defaultTimeoutSeconds = 120
+)
+
+func (m *RideRequestedMessage) applyDefaults() {
+	if m.RideType == "" {
+		m.RideType = string(types.ClassEconomy)
+	}
+	if m.MaxDistanceKm == 0 {
+		m.MaxDistanceKm = defaultMaxDistanceKm
+	}
+	if m.TimeoutSeconds == 0 {
+		m.TimeoutSeconds = defaultTimeoutSeconds
+	}
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Матрица совместимости: каждое сообщение брокера в том виде, в котором его публикуют
+// сервис до появления schema_version (v0), текущая версия и более новый релиз с лишними полями.
+func TestDecodeMessage_Compatibility(t *testing.T) {
+	const (
+		rideID   = "3f1d1c1e-8f3a-4a55-9d4e-0c6f1b0a7b11"
+		driverID = "7b2e4c90-1d2f-4a8b-b7c3-5a9e2d4f6a22"
+	)
+
+	type producer struct {
+		name string
+		body string
+	}
+	tests := []struct {
+		name      string
+		newMsg    func() BrokerMessage
+		producers []producer
+		check     func(t *testing.T, msg BrokerMessage)
+	}{
+		{
+			name:   "ride requested",
+			newMsg: func() BrokerMessage { return &RideRequestedMessage{} },
+			producers: []producer{
+				{"v0 without optional fields", `{"ride_id":"` + rideID + `","pickup_location":{"latitude":43.23,"longitude":76.88},"destination_location":{"latitude":43.25,"longitude":76.94}}`},
+				{"v1", `{"schema_version":1,"ride_id":"` + rideID + `","ride_type":"ECONOMY","max_distance_km":5,"timeout_seconds":120,"pickup_location":{"latitude":43.23,"longitude":76.88},"destination_location":{"latitude":43.25,"longitude":76.94}}`},
+				{"v1 from newer release", `{"schema_version":1,"ride_id":"` + rideID + `","ride_type":"ECONOMY","max_distance_km":5,"timeout_seconds":120,"pickup_location":{"latitude":43.23,"longitude":76.88},"destination_location":{"latitude":43.25,"longitude":76.94},"surge_zone":"almaty-7","loyalty":{"tier":"gold"}}`},
+			},
+			check: func(t *testing.T, msg BrokerMessage) {
+				m := msg.(*RideRequestedMessage)
+				if m.RideID.String() != rideID || m.RideType != string(types.ClassEconomy) || m.MaxDistanceKm != defaultMaxDistanceKm || m.TimeoutSeconds != defaultTimeoutSeconds {
+					t.Fatalf("unexpected message: %+v", m)
+				}
+			},
+		},
+		{
+			name:   "ride status update",
+			newMsg: func() BrokerMessage { return &RideStatusUpdateMessage{} },
+			producers: []producer{
+				{"v0", `{"ride_id":"` + rideID + `","status":"CANCELLED","timestamp":"2026-10-15T10:00:00Z","correlation_id":"c1"}`},
+				{"v1", `{"schema_version":1,"ride_id":"` + rideID + `","status":"CANCELLED","timestamp":"2026-10-15T10:00:00Z","correlation_id":"c1","cancellation_fee":300}`},
+				{"v1 from newer release", `{"schema_version":1,"ride_id":"` + rideID + `","status":"CANCELLED","timestamp":"2026-10-15T10:00:00Z","correlation_id":"c1","refund":{"amount":100}}`},
+			},
+			check: func(t *testing.T, msg BrokerMessage) {
+				m := msg.(*RideStatusUpdateMessage)
+				if m.RideID.String() != rideID || m.Status != "CANCELLED" || m.Timestamp.IsZero() {
+					t.Fatalf("unexpected message: %+v", m)
+				}
+			},
+		},
+		{
+			name:   "driver status update",
+			newMsg: func() BrokerMessage { return &DriverStatusUpdateMessage{} },
+			producers: []producer{
+				{"v0", `{"driver_id":"` + driverID + `","status":"AVAILABLE","timestamp":"2026-10-15T10:00:00Z"}`},
+				{"v1", `{"schema_version":1,"driver_id":"` + driverID + `","status":"AVAILABLE","ride_id":"` + rideID + `","timestamp":"2026-10-15T10:00:00Z","distance_km":12.5}`},
+				{"v1 from newer release", `{"schema_version":1,"driver_id":"` + driverID + `","status":"AVAILABLE","timestamp":"2026-10-15T10:00:00Z","shift_id":42}`},
+			},
+			check: func(t *testing.T, msg BrokerMessage) {
+				m := msg.(*DriverStatusUpdateMessage)
+				if m.DriverID.String() != driverID || m.Status != "AVAILABLE" {
+					t.Fatalf("unexpected message: %+v", m)
+				}
+			},
+		},
+		{
+			name:   "driver match response",
+			newMsg: func() BrokerMessage { return &DriverMatchResponse{} },
+			producers: []producer{
+				{"v0", `{"ride_id":"` + rideID + `","driver_id":"` + driverID + `","accepted":true,"driver_info":{"name":"Aibek","rating":4.9}}`},
+				{"v1", `{"schema_version":1,"ride_id":"` + rideID + `","driver_id":"` + driverID + `","accepted":true,"estimated_arrival_minutes":4,"driver_info":{"name":"Aibek","rating":4.9},"served_vehicle_class":"XL"}`},
+				{"v1 from newer release", `{"schema_version":1,"ride_id":"` + rideID + `","driver_id":"` + driverID + `","accepted":true,"driver_info":{"name":"Aibek","rating":4.9,"languages":["kk","ru"]}}`},
+			},
+			check: func(t *testing.T, msg BrokerMessage) {
+				m := msg.(*DriverMatchResponse)
+				if m.RideID.String() != rideID || !m.Accepted || m.DriverInfo.Name != "Aibek" {
+					t.Fatalf("unexpected message: %+v", m)
+				}
+			},
+		},
+		{
+			name:   "driver location update",
+			newMsg: func() BrokerMessage { return &RideLocationUpdate{} },
+			producers: []producer{
+				{"v0", `{"driver_id":"` + driverID + `","ride_id":"` + rideID + `","timestamp":"2026-10-15T10:00:00Z","location":{"latitude":43.23,"longitude":76.88}}`},
+				{"v1", `{"schema_version":1,"driver_id":"` + driverID + `","ride_id":"` + rideID + `","timestamp":"2026-10-15T10:00:00Z","location":{"latitude":43.23,"longitude":76.88},"speed_kmh":40}`},
+				{"v1 from newer release", `{"schema_version":1,"driver_id":"` + driverID + `","ride_id":"` + rideID + `","timestamp":"2026-10-15T10:00:00Z","location":{"latitude":43.23,"longitude":76.88},"battery":0.8}`},
+			},
+			check: func(t *testing.T, msg BrokerMessage) {
+				m := msg.(*RideLocationUpdate)
+				if m.DriverID.String() != driverID || m.RideID == nil || m.Location.Latitude != 43.23 {
+					t.Fatalf("unexpected message: %+v", m)
+				}
+			},
+		},
+		{
+			name:   "broadcast",
+			newMsg: func() BrokerMessage { return &BroadcastMessage{} },
+			producers: []producer{
+				{"v0", `{"broadcast_id":"` + rideID + `","title":"Snow","message":"Expect delays","roles":["PASSENGER"],"created_at":"2026-10-15T10:00:00Z"}`},
+				{"v1", `{"schema_version":1,"broadcast_id":"` + rideID + `","title":"Snow","message":"Expect delays","roles":["PASSENGER"],"city":"Almaty","created_at":"2026-10-15T10:00:00Z"}`},
+				{"v1 from newer release", `{"schema_version":1,"broadcast_id":"` + rideID + `","title":"Snow","message":"Expect delays","roles":["PASSENGER"],"expires_at":"2026-10-16T10:00:00Z"}`},
+			},
+			check: func(t *testing.T, msg BrokerMessage) {
+				m := msg.(*BroadcastMessage)
+				if m.Title != "Snow" || len(m.Roles) != 1 || m.Roles[0] != types.RolePassenger {
+					t.Fatalf("unexpected message: %+v", m)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, p := range tt.producers {
+			t.Run(tt.name+"/"+p.name, func(t *testing.T) {
+				msg := tt.newMsg()
+				if err := DecodeMessage([]byte(p.body), msg); err != nil {
+					t.Fatal(err)
+				}
+				tt.check(t, msg)
+			})
+		}
+
+		t.Run(tt.name+"/unsupported version", func(t *testing.T) {
+			if err := DecodeMessage([]byte(`{"schema_version":99}`), tt.newMsg()); !errors.Is(err, types.ErrUnsupportedSchema) {
+				t.Fatalf("got %v, want ErrUnsupportedSchema", err)
+			}
+		})
+	}
+}
+
+// Сообщение, собранное текущим кодом, несет текущую версию и читается потребителем предыдущего
+// релиза, который о schema_version не знает.
+func TestSchemaVersion_Encode(t *testing.T) {
+	msg := RideRequestedMessage{
+		RideID:         uuid.New(),
+		RideType:       string(types.ClassPremium),
+		MaxDistanceKm:  3,
+		TimeoutSeconds: 60,
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["schema_version"] != float64(SchemaVersionCurrent) {
+		t.Fatalf("schema_version = %v, want %d", fields["schema_version"], SchemaVersionCurrent)
+	}
+
+	// потребитель v0 не знает schema_version: encoding/json пропускает лишние поля
+	var legacy struct {
+		RideID   uuid.UUID `json:"ride_id"`
+		RideType string    `json:"ride_type"`
+	}
+	if err := json.Unmarshal(body, &legacy); err != nil || legacy.RideID != msg.RideID || legacy.RideType != msg.RideType {
+		t.Fatalf("legacy consumer: %+v, %v", legacy, err)
+	}
+
+	var decoded RideRequestedMessage
+	if err := DecodeMessage(body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Version() != SchemaVersionCurrent || decoded.MaxDistanceKm != 3 || decoded.TimeoutSeconds != 60 {
+		t.Fatalf("round trip: %+v", decoded)
+	}
+}
@@ -1,8 +1,85 @@
 package models
 
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// SessionSummary — итоги смены, возвращаются в ответе GoOffline и отправляются
+// по WebSocket сообщением shift_summary
 type SessionSummary struct {
-	SessionID      string
-	DurationHours  float64
-	RidesCompleted int
-	Earnings       float64
+	MsgType        string    `json:"type,omitempty"`
+	SessionID      string    `json:"session_id"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+	DurationHours  float64   `json:"duration_hours"`
+	RidesCompleted int       `json:"rides_completed"`
+	Earnings       float64   `json:"earnings"`
+	// EarningsPerHour — заработок за час на линии, 0 для смены короче минуты
+	EarningsPerHour float64 `json:"earnings_per_hour"`
+	// Comparison пуст, если сравнение не удалось посчитать: итоги смены важнее
+	Comparison     *ShiftComparison `json:"comparison,omitempty"`
+	HourlyEarnings []HourlyEarnings `json:"hourly_earnings"`
+}
+
+// ShiftTotals — итоги водителя за период
+type ShiftTotals struct {
+	Rides       float64 `json:"rides"`
+	Earnings    float64 `json:"earnings"`
+	OnlineHours float64 `json:"online_hours"`
+}
+
+// ShiftComparison сравнивает смену со вчерашним днем и средним рабочим днем за неделю (UTC).
+// Дельты — в процентах, nil при нулевой базе.
+type ShiftComparison struct {
+	Yesterday           ShiftTotals `json:"yesterday"`
+	WeeklyAverage       ShiftTotals `json:"weekly_average"`
+	WeeklyActiveDays    int         `json:"weekly_active_days"`
+	EarningsVsYesterday *float64    `json:"earnings_vs_yesterday_pct"`
+	EarningsVsWeekly    *float64    `json:"earnings_vs_weekly_avg_pct"`
+	RidesVsYesterday    *float64    `json:"rides_vs_yesterday_pct"`
+	RidesVsWeekly       *float64    `json:"rides_vs_weekly_avg_pct"`
+}
+
+// DailyTotals — итоги водителя за сутки (UTC), начинающиеся в Day
+type DailyTotals struct {
+	Day time.Time
+	ShiftTotals
+}
+
+// HourlyEarnings — заработок за час смены, начинающийся в Hour
+type HourlyEarnings struct {
+	Hour     time.Time `json:"hour"`
+	Rides    int       `json:"rides"`
+	Earnings float64   `json:"earnings"`
+}
+
+// DriverSession — одна сессия водителя на линии. EndedAt пуст, пока водитель на линии.
+type DriverSession struct {
+	ID             uuid.UUID  `json:"session_id"`
+	StartedAt      time.Time  `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+	DurationHours  float64    `json:"duration_hours"`
+	RidesCompleted int        `json:"rides_completed"`
+	Earnings       float64    `json:"earnings"`
+	// DistanceKm — пройденное за сессию расстояние по истории координат водителя
+	DistanceKm float64 `json:"distance_km"`
+}
+
+type DriverSessionHistory struct {
+	DriverID uuid.UUID       `json:"driver_id"`
+	Sessions []DriverSession `json:"sessions"`
+	Metadata Metadata        `json:"metadata"`
+}
+
+// DriverStats — статистика водителя за текущие сутки (UTC) для плитки заработка в приложении,
+// отправляется по WebSocket сообщением driver_stats
+type DriverStats struct {
+	MsgType        string    `json:"type"`
+	RidesToday     int       `json:"rides_today"`
+	EarningsToday  float64   `json:"earnings_today"`
+	OnlineMinutes  float64   `json:"online_minutes_today"`
+	AcceptanceRate *float64  `json:"acceptance_rate"` // nil, если сегодня офферов не было
+	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// Tariff — тариф класса автомобиля
+type Tariff struct {
+	VehicleClass    types.VehicleClass `json:"vehicle_class"`
+	BaseFare        float64            `json:"base_fare"`
+	PerKm           float64            `json:"per_km"`
+	PerMin          float64            `json:"per_min"`
+	SurgeCap        float64            `json:"surge_cap"`        // максимальный множитель спроса
+	CancellationFee float64            `json:"cancellation_fee"` // штраф за отмену после назначения водителя
+	WaitFeePerMin   float64            `json:"wait_fee_per_min"` // плата за ожидание сверх бесплатного времени
+}
+
+// ZoneDemand — спрос и предложение класса автомобиля в geohash ячейке
+type ZoneDemand struct {
+	Zone             string `json:"zone"`
+	Requests         int    `json:"requests"`          // запросы поездок в ячейке за окно, включая текущий
+	AvailableDrivers int    `json:"available_drivers"` // свободные водители класса в ячейке
+}
+
+// CityRates — тарифы классов с учетом ночных правил города
+type CityRates struct {
+	Code            string   `json:"code"`
+	Name            string   `json:"name"`
+	Timezone        string   `json:"timezone"`
+	OpenTime        string   `json:"open_time"`
+	CloseTime       string   `json:"close_time"`
+	NightStart      string   `json:"night_start"`
+	NightEnd        string   `json:"night_end"`
+	NightMultiplier float64  `json:"night_multiplier"`
+	Rates           []Tariff `json:"rates"`
+}
+
+// RateCard — тарифная сетка для экранов с ценами в клиентских приложениях
+type RateCard struct {
+	Rates     []Tariff    `json:"rates"` // базовые тарифы вне городов
+	Cities    []CityRates `json:"cities"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
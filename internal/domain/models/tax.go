@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// MonthlyEarnings — доходы водителя за месяц для налоговой декларации
+type MonthlyEarnings struct {
+	Month      time.Month `json:"month"`
+	Rides      int        `json:"rides"`
+	Gross      float64    `json:"gross"`      // стоимость завершенных поездок
+	Commission float64    `json:"commission"` // удержание платформы: комиссия и налог из чеков поездок
+	Tips       float64    `json:"tips"`
+	Bonuses    float64    `json:"bonuses"`
+	Net        float64    `json:"net"` // gross - commission + tips + bonuses
+}
+
+// TaxSummary — годовая сводка доходов водителя по месяцам (UTC)
+type TaxSummary struct {
+	DriverID uuid.UUID         `json:"driver_id"`
+	Year     int               `json:"year"`
+	Months   []MonthlyEarnings `json:"months"`
+	Total    MonthlyEarnings   `json:"total"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// AppTelemetry — показатели качества приложения за поездку, присланные клиентом.
+// Хранится без ID пользователя, только роль отправителя.
+type AppTelemetry struct {
+	RideID            uuid.UUID
+	Role              string
+	Platform          string
+	AppVersion        string
+	WSDisconnects     int
+	MaxLocationGapSec float64 // самый долгий перерыв между обновлениями координат водителя на клиенте
+	OfferRoundTripMs  *int    // только водитель: от получения оффера до подтверждения ответа
+}
+
+// TelemetryReport — качество приложения за период по ролям, платформам и версиям
+type TelemetryReport struct {
+	Since  time.Time        `json:"since"`
+	Groups []TelemetryGroup `json:"groups"`
+}
+
+// TelemetryGroup — агрегат отчетов одной роли, платформы и версии приложения
+type TelemetryGroup struct {
+	Role                string   `json:"role"`
+	Platform            string   `json:"platform"`
+	AppVersion          string   `json:"app_version"`
+	Reports             int      `json:"reports"`
+	DisconnectRate      float64  `json:"disconnect_rate"` // доля поездок хотя бы с одним обрывом WebSocket
+	AvgDisconnects      float64  `json:"avg_disconnects"`
+	P50LocationGapSec   float64  `json:"p50_location_gap_sec"`
+	P95LocationGapSec   float64  `json:"p95_location_gap_sec"`
+	P50OfferRoundTripMs *float64 `json:"p50_offer_round_trip_ms"` // nil — в группе нет замеров офферов
+	P95OfferRoundTripMs *float64 `json:"p95_offer_round_trip_ms"`
+}
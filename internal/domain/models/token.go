@@ -21,6 +21,9 @@ type TokenPair struct {
 	AccessExpiresAt  time.Time
 	RefreshToken     string
 	RefreshExpiresAt time.Time
+
+	// DeviceSecret выдается водителю при входе с device_id для подписи координат
+	DeviceSecret string
 }
 
 type CustomClaims struct {
@@ -18,7 +18,11 @@ func AnonymousUser() *User {
 
 type ctxKey int
 
-const userCtxKey ctxKey = iota
+const (
+	userCtxKey ctxKey = iota
+	partnerCtxKey
+	filtersCtxKey
+)
 
 // UserFromContext returns authenticated user or nil.
 func UserFromContext(ctx context.Context) *User {
@@ -47,8 +51,10 @@ type User struct {
 	Status       string         `json:"status"`
 	PasswordHash string         `json:"-"`               // stored in DB as password_hash
 	Attrs        map[string]any `json:"attrs,omitempty"` // jsonb
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at,omitzero"`
+	// PhoneVerifiedAt — когда подтвержден телефон из attrs, nil — не подтвержден
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at,omitzero"`
 }
 
 func (u *User) IsAnonymous() bool {
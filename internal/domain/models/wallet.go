@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Wallet — кошелек пассажира. Held — заблокировано под активные поездки и не входит в Balance.
+type Wallet struct {
+	PassengerID uuid.UUID     `json:"passenger_id"`
+	Balance     float64       `json:"balance"`
+	Held        float64       `json:"held"`
+	UpdatedAt   *time.Time    `json:"updated_at,omitempty"`
+	Entries     []LedgerEntry `json:"entries,omitempty"`
+}
+
+// LedgerEntry — запись журнала кошелька
+type LedgerEntry struct {
+	ID            uuid.UUID             `json:"id"`
+	PassengerID   uuid.UUID             `json:"passenger_id"`
+	RideID        *uuid.UUID            `json:"ride_id,omitempty"`
+	Type          types.LedgerEntryType `json:"type"`
+	Amount        float64               `json:"amount"`
+	BalanceAfter  float64               `json:"balance_after"`
+	TransactionID *string               `json:"transaction_id,omitempty"` // ID платежа у провайдера
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+// PaymentHold — блокировка оплаты поездки: предавторизация карты или блокировка в кошельке
+type PaymentHold struct {
+	RideID          uuid.UUID               `json:"ride_id"`
+	PassengerID     uuid.UUID               `json:"-"`
+	PaymentMethod   types.PaymentMethod     `json:"payment_method"`
+	Amount          float64                 `json:"amount"`
+	Status          types.PaymentHoldStatus `json:"status"`
+	AuthorizationID *string                 `json:"authorization_id,omitempty"` // ID предавторизации у провайдера
+	CapturedAmount  *float64                `json:"captured_amount,omitempty"`
+	TransactionID   *string                 `json:"transaction_id,omitempty"` // ID списания у провайдера
+	Error           *string                 `json:"error,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+}
+
+// RidePayment — оплата поездки для GET /rides/{ride_id}/payment, Hold == nil — блокировки нет
+type RidePayment struct {
+	RideID        uuid.UUID           `json:"ride_id"`
+	Status        string              `json:"ride_status"`
+	PaymentMethod types.PaymentMethod `json:"payment_method"`
+	EstimatedFare float64             `json:"estimated_fare"`
+	FinalFare     *float64            `json:"final_fare,omitempty"`
+	Hold          *PaymentHold        `json:"hold"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// WarehouseCursor — позиция выгрузки: время и ID последней выгруженной строки
+type WarehouseCursor struct {
+	At time.Time
+	ID uuid.UUID
+}
+
+// WarehouseWatermark — прогресс выгрузки набора данных в версии схемы
+type WarehouseWatermark struct {
+	Dataset       types.WarehouseDataset
+	SchemaVersion int
+	Cursor        WarehouseCursor
+	RowsExported  int64
+}
+
+// WarehouseBatch — пачка обезличенных строк одного набора данных для хранилища аналитики
+type WarehouseBatch struct {
+	Dataset       types.WarehouseDataset
+	SchemaVersion int
+	Name          string          // имя пачки без исходных ID, строится по курсору до пачки: повтор перезаписывает тот же объект
+	From          WarehouseCursor // курсор до пачки
+	To            WarehouseCursor
+	Rows          []WarehouseRow
+}
+
+// WarehouseRow — строка пачки. ID — псевдоним строки для дедупликации повторной вставки.
+type WarehouseRow struct {
+	ID   string
+	Data any
+}
+
+// ExportRide — завершенная поездка, прочитанная для выгрузки, до обезличивания
+type ExportRide struct {
+	ID                uuid.UUID
+	PassengerID       uuid.UUID
+	DriverID          *uuid.UUID
+	VehicleType       string
+	ServedVehicleType string
+	Status            string
+	PaymentMethod     string
+	Pickup            *Location
+	Destination       *Location
+	RequestedAt       *time.Time
+	MatchedAt         *time.Time
+	StartedAt         *time.Time
+	CompletedAt       *time.Time
+	CancelledAt       *time.Time
+	EstimatedFare     *float64
+	FinalFare         *float64
+	CancellationFee   *float64
+	SurgeMultiplier   float64
+	DistanceKm        *float64
+	Co2Grams          *float64
+	FinishedAt        time.Time
+}
+
+// ExportRideEvent — событие поездки для выгрузки. event_data не читается: в нем бывают адреса и координаты.
+type ExportRideEvent struct {
+	ID        uuid.UUID
+	RideID    uuid.UUID
+	EventType string
+	CreatedAt time.Time
+}
+
+// WarehouseRideRecord — обезличенная поездка в хранилище аналитики.
+// ID заменены псевдонимами, координаты округлены, адресов и причины отмены нет.
+type WarehouseRideRecord struct {
+	SchemaVersion     int        `json:"schema_version"`
+	RideRef           string     `json:"ride_ref"`
+	PassengerRef      string     `json:"passenger_ref"`
+	DriverRef         *string    `json:"driver_ref"`
+	VehicleType       string     `json:"vehicle_type"`
+	ServedVehicleType *string    `json:"served_vehicle_type"`
+	Status            string     `json:"status"`
+	PaymentMethod     string     `json:"payment_method"`
+	PickupLat         *float64   `json:"pickup_lat"`
+	PickupLng         *float64   `json:"pickup_lng"`
+	DestinationLat    *float64   `json:"destination_lat"`
+	DestinationLng    *float64   `json:"destination_lng"`
+	RequestedAt       *time.Time `json:"requested_at"`
+	MatchedAt         *time.Time `json:"matched_at"`
+	StartedAt         *time.Time `json:"started_at"`
+	CompletedAt       *time.Time `json:"completed_at"`
+	CancelledAt       *time.Time `json:"cancelled_at"`
+	EstimatedFare     *float64   `json:"estimated_fare"`
+	FinalFare         *float64   `json:"final_fare"`
+	CancellationFee   *float64   `json:"cancellation_fee"`
+	SurgeMultiplier   float64    `json:"surge_multiplier"`
+	DistanceKm        *float64   `json:"distance_km"`
+	Co2Grams          *float64   `json:"co2_grams"`
+}
+
+// WarehouseRideEventRecord — обезличенное событие поездки в хранилище аналитики
+type WarehouseRideEventRecord struct {
+	SchemaVersion int       `json:"schema_version"`
+	EventRef      string    `json:"event_ref"`
+	RideRef       string    `json:"ride_ref"`
+	EventType     string    `json:"event_type"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -19,6 +19,8 @@ var (
 	ErrNoCoordinates             = errors.New("no coordinates found")
 	ErrDriverLocationNotFound    = errors.New("driver location not found")
 	ErrRideNotFound              = errors.New("ride not found")
+	ErrRideHasNoDriver           = errors.New("ride has no driver assigned")
+	ErrReceiptNotFound           = errors.New("receipt not found: ride is not completed")
 	ErrRideNotArrived            = errors.New("ride status is not 'arrived'")
 	ErrRideDriverMismatch        = errors.New("ride does not belong to the driver")
 	ErrRideCannotBeCancelled     = errors.New("ride cannot be cancelled")
@@ -26,6 +28,8 @@ var (
 	ErrInvalidRideStatus         = errors.New("invalid ride status")
 	ErrNotFound                  = errors.New("requested item not found")
 	ErrDriverSearchTimeout       = errors.New("driver search time exceeded")
+	ErrSearchInterrupted         = errors.New("driver search interrupted by shutdown")
+	ErrUnsupportedSchema         = errors.New("unsupported message schema version")
 	ErrDriversNotFound           = errors.New("drivers are not found")
 	ErrRideStatusNotMatched      = errors.New("ride status must be matched")
 	ErrListenTimeout             = errors.New("listen timeout")
@@ -34,4 +38,73 @@ var (
 	ErrDatabaseFailed            = errors.New("database failed")
 	ErrFailedToPublishRideStatus = errors.New("failed to publish ride status")
 	ErrRideAlreadyHasDriver      = errors.New("driver already has a driver")
+	ErrRideNotExistedAt          = errors.New("ride did not exist at the requested time")
+	ErrBlocklistFull             = errors.New("blocklist size limit reached")
+	ErrCannotBlockSelf           = errors.New("cannot block yourself")
+	ErrPassengerNotBlocked       = errors.New("passenger is not in the blocklist")
+	ErrOutsideOperatingHours     = errors.New("rides are not available in this city at this time")
+	ErrUnknownAnomalyKind        = errors.New("unknown anomaly kind")
+	ErrAnomalyNotFound           = errors.New("anomaly not found or already resolved")
+	ErrLocationSignatureRequired = errors.New("location update must be signed by the device")
+	ErrInvalidLocationSignature  = errors.New("invalid location signature")
+	ErrLocationSignatureExpired  = errors.New("location signature timestamp is out of range")
+	ErrLocationDiscarded         = errors.New("location update is older than or duplicates the last accepted one")
+	ErrBroadcastNotFound         = errors.New("broadcast not found")
+	ErrUnknownCity               = errors.New("unknown city")
+	ErrPartnerNotFound           = errors.New("partner not found")
+	ErrInvalidAPIKey             = errors.New("invalid partner API key")
+	ErrDriverNotInFleet          = errors.New("driver does not belong to the partner fleet")
+	ErrOfferNotFound             = errors.New("ride offer not found or already expired")
+	ErrInvalidCoordinates        = errors.New("coordinates are out of range")
+	ErrNullIslandCoordinates     = errors.New("coordinates (0, 0) are not a valid location")
+	ErrOutsideServiceArea        = errors.New("coordinates are outside the service area")
+	ErrChangeRequestNotFound     = errors.New("change request not found or already reviewed")
+	ErrPriorityBoardingDenied    = errors.New("passenger is not eligible for priority boarding")
+	ErrPaymentUnavailable        = errors.New("payment provider is not available")
+	ErrUnknownOpsAction          = errors.New("unknown ops action")
+	ErrInvalidOpsParams          = errors.New("invalid ops action params")
+	ErrOpsActionNotFound         = errors.New("ops action not found")
+	ErrMatchingPaused            = errors.New("matching is paused in this city")
+	ErrRideNotCompleted          = errors.New("only completed rides can be rated")
+	ErrRideAlreadyRated          = errors.New("ride is already rated")
+	ErrStateExportNotFound       = errors.New("state export not found")
+	ErrPromoNotFound             = errors.New("promo code not found")
+	ErrPromoCodeExists           = errors.New("promo code already exists")
+	ErrPromoInactive             = errors.New("promo code is not active")
+	ErrPromoExpired              = errors.New("promo code has expired")
+	ErrPromoExhausted            = errors.New("promo code usage limit reached")
+	ErrInvalidPromoDiscount      = errors.New("discount is not valid for the promo code type")
+	ErrFailedMessageNotFound     = errors.New("failed message not found")
+	ErrFailedMessageNoQueue      = errors.New("failed message has no source queue to replay to")
+	ErrDeadLetterUnavailable     = errors.New("dead letter replay is available only with the rabbitmq broker")
+	ErrFlatRateNotFound          = errors.New("flat rate not found")
+	ErrFlatRateScheduled         = errors.New("flat rate already has a version effective at or after this date")
+	ErrBlackoutNotFound          = errors.New("matching blackout not found or already ended")
+	ErrRideCannotBeReassigned    = errors.New("only a ride with an assigned driver before pickup can be reassigned")
+	ErrVehicleNotFound           = errors.New("vehicle not found")
+	ErrVehicleExists             = errors.New("driver already has a vehicle with this plate")
+	ErrNoCurrentRide             = errors.New("driver has no active ride")
+	ErrReadOnlyMode              = errors.New("service is temporarily read-only: primary database is unavailable, please retry later")
+	ErrPayoutUnavailable         = errors.New("payout provider is not available")
+	ErrInvalidPayoutAmount       = errors.New("payout amount is outside the allowed range")
+	ErrInsufficientBalance       = errors.New("insufficient available balance")
+	ErrPayoutLimitExceeded       = errors.New("daily payout limit reached")
+	ErrPayoutInProgress          = errors.New("another payout is still in progress")
+	ErrPayoutNotAllowed          = errors.New("instant payout is not allowed for this driver")
+	ErrPayoutNotFound            = errors.New("payout not found")
+	ErrInvalidPayoutSignature    = errors.New("invalid payout webhook signature")
+	ErrInvalidPayoutEvent        = errors.New("invalid payout webhook event")
+	ErrPushTokenNotFound         = errors.New("push token not found")
+	// ErrPushTokenInvalid — push провайдер больше не принимает токен (приложение удалено), токен удаляется
+	ErrPushTokenInvalid = errors.New("push token is no longer valid")
+
+	ErrPhoneNotSet               = errors.New("phone number is not set in the profile")
+	ErrPhoneAlreadyVerified      = errors.New("phone number is already verified")
+	ErrPhoneNotVerified          = errors.New("phone number is not verified")
+	ErrPhoneCodeNotRequested     = errors.New("no phone verification code was requested")
+	ErrPhoneCodeExpired          = errors.New("phone verification code has expired, request a new one")
+	ErrInvalidPhoneCode          = errors.New("invalid phone verification code")
+	ErrPhoneCodeTooSoon          = errors.New("phone verification code was sent recently, try again later")
+	ErrPhoneCodeAttemptsExceeded = errors.New("too many phone verification attempts, request a new code")
+	ErrSMSUnavailable            = errors.New("SMS provider is not available")
 )
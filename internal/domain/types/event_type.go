@@ -7,13 +7,17 @@ func (s RideEvent) String() string {
 }
 
 const (
-	EventRideRequested   RideEvent = "RIDE_REQUESTED"
-	EventDriverMatched   RideEvent = "DRIVER_MATCHED"
-	EventDriverArrived   RideEvent = "DRIVER_ARRIVED"
-	EventRideStarted     RideEvent = "RIDE_STARTED"
-	EventRideCompleted   RideEvent = "RIDE_COMPLETED"
-	EventRideCancelled   RideEvent = "RIDE_CANCELLED"
-	EventStatusChanged   RideEvent = "STATUS_CHANGED"
-	EventLocationUpdated RideEvent = "LOCATION_UPDATED"
-	EventFareAdjusted    RideEvent = "FARE_ADJUSTED"
+	EventRideRequested     RideEvent = "RIDE_REQUESTED"
+	EventDriverMatched     RideEvent = "DRIVER_MATCHED"
+	EventDriverArrived     RideEvent = "DRIVER_ARRIVED"
+	EventRideStarted       RideEvent = "RIDE_STARTED"
+	EventRideCompleted     RideEvent = "RIDE_COMPLETED"
+	EventRideCancelled     RideEvent = "RIDE_CANCELLED"
+	EventStatusChanged     RideEvent = "STATUS_CHANGED"
+	EventLocationUpdated   RideEvent = "LOCATION_UPDATED"
+	EventFareAdjusted      RideEvent = "FARE_ADJUSTED"
+	EventDispatchPending   RideEvent = "DISPATCH_PENDING"   // поездка создана, но запрос поиска водителя ждет доступности брокера
+	EventDriverRated       RideEvent = "DRIVER_RATED"       // пассажир оценил водителя после завершения поездки
+	EventDriverApproaching RideEvent = "DRIVER_APPROACHING" // водитель почти у точки посадки
+	EventMatchingDelayed   RideEvent = "MATCHING_DELAYED"   // подбор водителя в зоне посадки приостановлен, поиск отложен
 )
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type ServiceMode string
 
 func (s ServiceMode) String() string {
@@ -8,12 +10,14 @@ func (s ServiceMode) String() string {
 
 // Ride Service - Orchestrates the complete ride lifecycle and manages passenger interactions
 // Driver & Location Service - Handles driver operations, matching algorithms, and real-time location tracking
+// Location Service - Ingests driver coordinates and serves location history and geo queries
 // Admin Service - Provides monitoring, analytics, and system oversight capabilities
 const (
 	RideService              ServiceMode = "ride-service"
 	DriverAndLocationService ServiceMode = "driver-service"
 	AdminService             ServiceMode = "admin-service"
 	AuthService              ServiceMode = "auth-service"
+	LocationService          ServiceMode = "location-service"
 )
 
 // Enum для классов
@@ -40,6 +44,436 @@ func (r DriverStatus) String() string {
 	return string(r)
 }
 
+// Enum для уровня (ранга) водителя
+type DriverTier string
+
+const (
+	TierBronze DriverTier = "BRONZE"
+	TierSilver DriverTier = "SILVER"
+	TierGold   DriverTier = "GOLD"
+)
+
+func (t DriverTier) String() string {
+	return string(t)
+}
+
+// Rank возвращает порядковый номер уровня: чем выше, тем лучше
+func (t DriverTier) Rank() int {
+	switch t {
+	case TierGold:
+		return 2
+	case TierSilver:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Enum для результата оффера водителю
+type OfferOutcome string
+
+const (
+	OfferAccepted OfferOutcome = "ACCEPTED"
+	OfferDeclined OfferOutcome = "DECLINED"
+	OfferExpired  OfferOutcome = "EXPIRED"
+)
+
+func (o OfferOutcome) String() string {
+	return string(o)
+}
+
+// Enum для правила поездок вне рабочих часов города
+type OutsideHoursPolicy string
+
+const (
+	PolicyReject    OutsideHoursPolicy = "REJECT"    // поездка отклоняется
+	PolicySurcharge OutsideHoursPolicy = "SURCHARGE" // поездка тарифицируется с ночной надбавкой
+)
+
+func (p OutsideHoursPolicy) String() string {
+	return string(p)
+}
+
+// Enum для типа фиксированного тарифа
+type FlatRateType string
+
+const (
+	FlatRateFixed   FlatRateType = "FLAT"    // фиксированная цена маршрута между двумя зонами
+	FlatRateMinimum FlatRateType = "MINIMUM" // минимальная стоимость поездки из зоны
+)
+
+func (t FlatRateType) String() string {
+	return string(t)
+}
+
+// Enum для набора данных, выгружаемого в хранилище аналитики
+type WarehouseDataset string
+
+const (
+	WarehouseRides      WarehouseDataset = "rides"       // завершенные и отмененные поездки
+	WarehouseRideEvents WarehouseDataset = "ride_events" // события поездок
+)
+
+func (d WarehouseDataset) String() string {
+	return string(d)
+}
+
+// Enum для состояния алерта
+type AlertStatus string
+
+const (
+	AlertFiring   AlertStatus = "firing"   // условие алерта выполняется
+	AlertResolved AlertStatus = "resolved" // условие перестало выполняться
+)
+
+// Enum для типа аномалии — несогласованного состояния сущностей
+type AnomalyKind string
+
+const (
+	AnomalyDriverBusyWithoutRide      AnomalyKind = "DRIVER_BUSY_WITHOUT_RIDE"     // водитель занят, но активной поездки нет
+	AnomalyRideMatchedDriverOffline   AnomalyKind = "RIDE_MATCHED_DRIVER_OFFLINE"  // поездка назначена водителю, который офлайн
+	AnomalySessionOpenTooLong         AnomalyKind = "SESSION_OPEN_TOO_LONG"        // сессия водителя не закрыта дольше допустимого
+	AnomalyDuplicateCurrentCoordinate AnomalyKind = "DUPLICATE_CURRENT_COORDINATE" // у сущности несколько координат с is_current
+)
+
+func (k AnomalyKind) String() string {
+	return string(k)
+}
+
+// AllAnomalyKinds - все проверки, которые выполняет детектор аномалий
+var AllAnomalyKinds = []AnomalyKind{
+	AnomalyDriverBusyWithoutRide,
+	AnomalyRideMatchedDriverOffline,
+	AnomalySessionOpenTooLong,
+	AnomalyDuplicateCurrentCoordinate,
+}
+
+// Enum для действий, выполненных приложением водителя без сети
+type OfflineActionType string
+
+const (
+	OfflineGoOnline     OfflineActionType = "GO_ONLINE"
+	OfflineGoOffline    OfflineActionType = "GO_OFFLINE"
+	OfflineStartRide    OfflineActionType = "START_RIDE"
+	OfflineCompleteRide OfflineActionType = "COMPLETE_RIDE"
+)
+
+func (t OfflineActionType) String() string {
+	return string(t)
+}
+
+// Enum для результата сверки офлайн действия
+type ReconcileStatus string
+
+const (
+	ReconcileApplied  ReconcileStatus = "APPLIED"  // действие применено
+	ReconcileSkipped  ReconcileStatus = "SKIPPED"  // состояние сервера уже совпадает с действием
+	ReconcileConflict ReconcileStatus = "CONFLICT" // состояние сервера противоречит действию, сервер в приоритете
+	ReconcileRejected ReconcileStatus = "REJECTED" // действие не прошло проверку порядка или времени
+	ReconcileFailed   ReconcileStatus = "FAILED"   // внутренняя ошибка, действие можно отправить повторно
+)
+
+func (s ReconcileStatus) String() string {
+	return string(s)
+}
+
+// Enum для канала уведомлений
+type NotificationChannel string
+
+const (
+	ChannelPush  NotificationChannel = "PUSH"
+	ChannelSMS   NotificationChannel = "SMS"
+	ChannelEmail NotificationChannel = "EMAIL"
+
+	// каналы, которые попадают только в журнал коммуникаций поездки, пользователь их не выбирает
+	ChannelWebSocket NotificationChannel = "WS"
+	ChannelVoice     NotificationChannel = "VOICE"
+)
+
+func (c NotificationChannel) String() string {
+	return string(c)
+}
+
+// AllNotificationChannels - все поддерживаемые каналы
+var AllNotificationChannels = []NotificationChannel{ChannelPush, ChannelSMS, ChannelEmail}
+
+// Enum для результата доставки сообщения в журнале коммуникаций поездки
+type CommunicationStatus string
+
+const (
+	CommunicationDelivered CommunicationStatus = "DELIVERED" // канал принял сообщение
+	CommunicationFailed    CommunicationStatus = "FAILED"    // отправка завершилась ошибкой
+	CommunicationSkipped   CommunicationStatus = "SKIPPED"   // не отправлялось: настройки пользователя, нет контакта или провайдера
+)
+
+func (s CommunicationStatus) String() string {
+	return string(s)
+}
+
+// Enum для платформы устройства с push токеном
+type PushPlatform string
+
+const (
+	PlatformAndroid PushPlatform = "ANDROID"
+	PlatformIOS     PushPlatform = "IOS"
+)
+
+func (p PushPlatform) String() string {
+	return string(p)
+}
+
+// AllPushPlatforms - все поддерживаемые платформы
+var AllPushPlatforms = []PushPlatform{PlatformAndroid, PlatformIOS}
+
+// Enum для типа уведомления
+type NotificationEvent string
+
+const (
+	NotifyRideUpdates     NotificationEvent = "RIDE_UPDATES"     // водитель найден, прибыл, поездка отменена
+	NotifyReceipts        NotificationEvent = "RECEIPTS"         // чек после завершения поездки
+	NotifyPromotions      NotificationEvent = "PROMOTIONS"       // маркетинговые рассылки
+	NotifyAccountSecurity NotificationEvent = "ACCOUNT_SECURITY" // вход, смена пароля
+	NotifyPositioningTips NotificationEvent = "POSITIONING_TIPS" // рекомендации водителям, куда переместиться
+)
+
+func (e NotificationEvent) String() string {
+	return string(e)
+}
+
+// IsCritical - критичные уведомления отправляются независимо от настроек пользователя
+func (e NotificationEvent) IsCritical() bool {
+	return e == NotifyAccountSecurity
+}
+
+// AllNotificationEvents - все типы уведомлений
+var AllNotificationEvents = []NotificationEvent{NotifyRideUpdates, NotifyReceipts, NotifyPromotions, NotifyAccountSecurity, NotifyPositioningTips}
+
+// Enum для признака подозрительной связи в графе мошенничества
+type FraudSignal string
+
+const (
+	FraudSharedDevice FraudSignal = "SHARED_DEVICE" // водители входят с одного устройства
+	FraudRepeatedPair FraudSignal = "REPEATED_PAIR" // одна и та же пара водитель-пассажир ездит слишком часто
+	FraudCoLocated    FraudSignal = "CO_LOCATED"    // водитель уже в точке посадки в момент заказа
+)
+
+func (s FraudSignal) String() string {
+	return string(s)
+}
+
+// Enum для приоритетной посадки: поездка получает максимальный приоритет и не дорожает от надбавок
+type PriorityBoarding string
+
+const (
+	BoardingMedical       PriorityBoarding = "MEDICAL"       // поездка по медицинским показаниям
+	BoardingAccessibility PriorityBoarding = "ACCESSIBILITY" // пассажиру нужна доступная среда
+)
+
+func (p PriorityBoarding) String() string {
+	return string(p)
+}
+
+func IsValidPriorityBoarding(p PriorityBoarding) bool {
+	switch p {
+	case BoardingMedical, BoardingAccessibility:
+		return true
+	default:
+		return false
+	}
+}
+
+// Enum для способа оплаты поездки
+type PaymentMethod string
+
+const (
+	PaymentCard   PaymentMethod = "CARD"   // картой через платежного провайдера
+	PaymentWallet PaymentMethod = "WALLET" // с баланса кошелька пассажира
+)
+
+func (m PaymentMethod) String() string {
+	return string(m)
+}
+
+// Enum для типа записи журнала кошелька
+type LedgerEntryType string
+
+const (
+	LedgerTopUp        LedgerEntryType = "TOP_UP"        // пополнение через платежного провайдера
+	LedgerHold         LedgerEntryType = "HOLD"          // блокировка стоимости поездки при создании
+	LedgerCapture      LedgerEntryType = "CAPTURE"       // списание стоимости завершенной поездки
+	LedgerRelease      LedgerEntryType = "RELEASE"       // возврат блокировки на баланс
+	LedgerCardFallback LedgerEntryType = "CARD_FALLBACK" // баланса не хватило, поездка оплачивается картой
+)
+
+func (t LedgerEntryType) String() string {
+	return string(t)
+}
+
+// Enum для типа записи журнала баланса водителя
+type DriverLedgerEntryType string
+
+const (
+	DriverLedgerEarning         DriverLedgerEntryType = "EARNING"          // заработок за завершенную поездку
+	DriverLedgerCancellationFee DriverLedgerEntryType = "CANCELLATION_FEE" // штраф пассажира за отмену
+	DriverLedgerPayout          DriverLedgerEntryType = "PAYOUT"           // вывод вместе с комиссией
+	DriverLedgerPayoutReversal  DriverLedgerEntryType = "PAYOUT_REVERSAL"  // возврат неудавшегося вывода
+)
+
+func (t DriverLedgerEntryType) String() string {
+	return string(t)
+}
+
+// Enum для статуса мгновенного вывода
+type PayoutStatus string
+
+const (
+	PayoutPending    PayoutStatus = "PENDING"    // баланс списан, провайдер еще не принял перевод
+	PayoutProcessing PayoutStatus = "PROCESSING" // провайдер принял перевод
+	PayoutPaid       PayoutStatus = "PAID"       // деньги зачислены водителю
+	PayoutFailed     PayoutStatus = "FAILED"     // перевод отклонен, сумма возвращена на баланс
+)
+
+func (s PayoutStatus) String() string {
+	return string(s)
+}
+
+// Final — статус больше не меняется
+func (s PayoutStatus) Final() bool {
+	return s == PayoutPaid || s == PayoutFailed
+}
+
+// Enum для статуса блокировки оплаты поездки
+type PaymentHoldStatus string
+
+const (
+	HoldAuthorized PaymentHoldStatus = "AUTHORIZED" // сумма заблокирована при создании поездки
+	HoldCaptured   PaymentHoldStatus = "CAPTURED"   // списана финальная стоимость
+	HoldReleased   PaymentHoldStatus = "RELEASED"   // блокировка снята без списания
+	HoldFailed     PaymentHoldStatus = "FAILED"     // провайдер отклонил авторизацию, оплата при завершении
+)
+
+func (s PaymentHoldStatus) String() string {
+	return string(s)
+}
+
+// Enum для типа скидки промокода
+type DiscountType string
+
+const (
+	DiscountPercent DiscountType = "PERCENT" // процент от стоимости поездки
+	DiscountFlat    DiscountType = "FLAT"    // фиксированная сумма
+)
+
+func (d DiscountType) String() string {
+	return string(d)
+}
+
+// Enum для типа сообщения в outbox
+type OutboxTopic string
+
+const (
+	OutboxRideRequested OutboxTopic = "ride_requested" // запрос поиска водителя для driver-service
+	OutboxRideStatus    OutboxTopic = "ride_status"    // смена статуса поездки
+	OutboxFiscalReceipt OutboxTopic = "fiscal_receipt" // фискализация чека завершенной поездки
+	OutboxCardCharge    OutboxTopic = "card_charge"    // повтор списания с карты, не прошедшего сразу
+	OutboxCardVoid      OutboxTopic = "card_void"      // снятие предавторизации поездки, отмененной вне ride-service
+)
+
+func (t OutboxTopic) String() string {
+	return string(t)
+}
+
+// Enum для операционного действия дежурного инженера
+type OpsActionType string
+
+const (
+	OpsDrainWS       OpsActionType = "DRAIN_WS"       // закрыть WebSocket соединения экземпляра сервиса
+	OpsPauseMatching OpsActionType = "PAUSE_MATCHING" // приостановить или возобновить прием поездок в городе
+)
+
+func (a OpsActionType) String() string {
+	return string(a)
+}
+
+// Enum для статуса операционного действия
+type OpsActionStatus string
+
+const (
+	OpsPending OpsActionStatus = "PENDING" // ждет выполнения экземпляром сервиса
+	OpsApplied OpsActionStatus = "APPLIED"
+	OpsFailed  OpsActionStatus = "FAILED"
+)
+
+func (s OpsActionStatus) String() string {
+	return string(s)
+}
+
+// Enum для статуса выгрузки состояния системы
+type StateExportStatus string
+
+const (
+	StateExportPending StateExportStatus = "PENDING" // снимок собирается в фоне
+	StateExportReady   StateExportStatus = "READY"
+	StateExportFailed  StateExportStatus = "FAILED"
+)
+
+func (s StateExportStatus) String() string {
+	return string(s)
+}
+
+// Кэши экземпляров сервисов, которые сбрасываются событиями pkg/invalidation
+const (
+	CacheCitySettings     = "city_settings"      // правила городов в ride-service
+	CacheDriverCandidates = "driver_candidates"  // кандидаты на поездку в driver-service
+	CacheDrivers          = "drivers"            // профили водителей в driver-service
+	CacheFlatRates        = "flat_rates"         // фиксированные тарифы маршрутов в ride-service
+	CachePartnerOffers    = "partner_offers"     // ответы партнёров на офферы, ожидающие в driver-service
+	CachePartnerTracking  = "partner_tracking"   // координаты отслеживаемых поездок водителей партнёров
+	CacheBlackouts        = "matching_blackouts" // окна приостановки подбора в ride-service
+	CacheBroadcasts       = "broadcasts"         // новые объявления администратора, каждый экземпляр доставляет их своим подключенным
+	CacheAccessTokens     = "access_tokens"      // отзыв токенов пользователя: граница выхода со всех устройств и его WebSocket соединения
+)
+
+// Enum для периода отчета о заработке водителя
+type EarningsPeriod string
+
+const (
+	EarningsDaily   EarningsPeriod = "daily"
+	EarningsWeekly  EarningsPeriod = "weekly" // недели начинаются с понедельника
+	EarningsMonthly EarningsPeriod = "monthly"
+)
+
+func (p EarningsPeriod) String() string {
+	return string(p)
+}
+
+// Enum для статуса запроса на изменение данных водителя
+type DriverChangeStatus string
+
+const (
+	DriverChangePending  DriverChangeStatus = "PENDING"  // ждёт решения администратора
+	DriverChangeApproved DriverChangeStatus = "APPROVED" // применён к профилю водителя
+	DriverChangeRejected DriverChangeStatus = "REJECTED" // отклонён администратором
+)
+
+func (s DriverChangeStatus) String() string {
+	return string(s)
+}
+
+// Enum для статуса водителя партнёра, который партнёр сообщает через partner API
+type PartnerDriverStatus string
+
+const (
+	PartnerDriverOnline     PartnerDriverStatus = "ONLINE"      // водитель вышел на линию
+	PartnerDriverOffline    PartnerDriverStatus = "OFFLINE"     // водитель ушёл с линии
+	PartnerDriverInProgress PartnerDriverStatus = "IN_PROGRESS" // пассажир в машине, поездка началась
+	PartnerDriverCompleted  PartnerDriverStatus = "COMPLETED"   // поездка завершена
+)
+
+func (s PartnerDriverStatus) String() string {
+	return string(s)
+}
+
 // Enum для статуса пользователя
 type UserStatus string
 
@@ -64,6 +498,8 @@ const (
 	RolePassenger UserRole = "PASSENGER"
 	RoleDriver    UserRole = "DRIVER"
 	RoleAdmin     UserRole = "ADMIN"
+	// RoleSystem — поездку снял или отменил сам сервис (сверка зависших поездок), а не пользователь
+	RoleSystem UserRole = "SYSTEM"
 )
 
 // Enum для типов пользователей
@@ -90,6 +526,21 @@ func (s RideStatus) String() string {
 	return string(s)
 }
 
+// CancelReasonNoDriver — причина отмены поездки, для которой не нашелся водитель
+const CancelReasonNoDriver = "failed to find a driver"
+
+// DriverResponseTimeout — сколько ride-service ждет ответа водителя после отправки запроса поиска
+const DriverResponseTimeout = 2 * time.Minute
+
+// CoverageIssue — почему район считается недообслуженным в отчете покрытия
+type CoverageIssue string
+
+const (
+	CoverageLowMatchRate CoverageIssue = "LOW_MATCH_RATE" // водитель находится реже, чем в среднем по городу
+	CoverageLongWait     CoverageIssue = "LONG_WAIT"      // подбор водителя дольше, чем в среднем по городу
+	CoverageNoDriver     CoverageIssue = "NO_DRIVER"      // чаще отмены из-за отсутствия водителя
+)
+
 func IsValidRideStatus(status RideStatus) bool {
 	switch status {
 	case StatusRequested, StatusMatched, StatusEnRoute, StatusArrived, StatusInProgress, StatusCompleted, StatusCancelled:
@@ -98,3 +549,11 @@ func IsValidRideStatus(status RideStatus) bool {
 		return false
 	}
 }
+
+// NavigationTarget — точка, к которой водитель едет в текущем статусе поездки
+type NavigationTarget string
+
+const (
+	NavigationPickup      NavigationTarget = "PICKUP"
+	NavigationDestination NavigationTarget = "DESTINATION"
+)
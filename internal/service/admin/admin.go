@@ -2,23 +2,63 @@ package admin
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
 type AdminService struct {
 	adminRepo  AdminRepository
 	calculator Calculator
+	metrics    MetricsSource
+	cityRepo   CityRepo
+	flatRates  FlatRateRepo
+	blackouts  BlackoutRepo
+	comms      CommunicationRepo
 
-	l logger.Logger
+	broadcastRepo BroadcastRepo
+	broadcasts    BroadcastPublisher
+
+	opsRepo OpsRepo
+
+	failedMessages FailedMessageRepo
+	deadLetters    DeadLetterReplayer // nil — брокер без dead letter очереди (BROKER_BACKEND=postgres)
+
+	export ExportOptions
+
+	// caches — сброс кэшей ride и driver сервисов после изменений администратора
+	caches CacheInvalidator
+
+	// fraudGraph — последний граф, посчитанный RunFraudJob
+	fraudGraph atomic.Pointer[models.FraudGraph]
+
+	trm trm.TxManager
+	l   logger.Logger
 }
 
-func NewAdminService(adminRepo AdminRepository, calculator Calculator, l logger.Logger) *AdminService {
+func NewAdminService(adminRepo AdminRepository, calculator Calculator, metrics MetricsSource, cityRepo CityRepo, flatRates FlatRateRepo, blackouts BlackoutRepo, comms CommunicationRepo, broadcastRepo BroadcastRepo, broadcasts BroadcastPublisher, opsRepo OpsRepo, failedMessages FailedMessageRepo, deadLetters DeadLetterReplayer, export ExportOptions, caches CacheInvalidator, trm trm.TxManager, l logger.Logger) *AdminService {
 	return &AdminService{
-		adminRepo:  adminRepo,
-		calculator: calculator,
-		l:          l,
+		adminRepo:      adminRepo,
+		calculator:     calculator,
+		metrics:        metrics,
+		cityRepo:       cityRepo,
+		flatRates:      flatRates,
+		blackouts:      blackouts,
+		comms:          comms,
+		broadcastRepo:  broadcastRepo,
+		broadcasts:     broadcasts,
+		opsRepo:        opsRepo,
+		failedMessages: failedMessages,
+		deadLetters:    deadLetters,
+		export:         export,
+		caches:         caches,
+		trm:            trm,
+		l:              l,
 	}
 }
 
@@ -53,3 +93,51 @@ func (s *AdminService) ActiveRides(ctx context.Context, filters models.Filters)
 
 	return res, nil
 }
+
+// SearchRides ищет поездки в любом статусе по фильтрам администратора
+func (s *AdminService) SearchRides(ctx context.Context, filter models.RideSearchFilter) (*models.RideSearchResponse, error) {
+	return s.adminRepo.SearchRides(ctx, filter)
+}
+
+func (s *AdminService) Blocklist(ctx context.Context, filter models.BlocklistFilter, filters models.Filters) (*models.BlocklistResponse, error) {
+	return s.adminRepo.GetBlocklist(ctx, filter, filters)
+}
+
+// SetDriverSimulator включает или выключает режим симулятора водителя для песочницы
+func (s *AdminService) SetDriverSimulator(ctx context.Context, driverID uuid.UUID, simulator bool) error {
+	ctx = wrap.WithAction(wrap.WithDriverID(ctx, driverID.String()), "set_driver_simulator")
+
+	if err := s.adminRepo.SetDriverSimulator(ctx, driverID, simulator); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	// кандидаты хранят флаг симулятора, без сброса водитель до истечения TTL попадает не в ту песочницу;
+	// кэш профилей сбрасывается при любом изменении строки водителя
+	s.invalidateCache(ctx, types.CacheDriverCandidates, driverID.String())
+	s.invalidateCache(ctx, types.CacheDrivers, driverID.String())
+
+	s.l.Info(ctx, "driver simulator flag changed", "simulator", simulator)
+	return nil
+}
+
+// SetPriorityBoardingEligible разрешает или запрещает пассажиру приоритетную посадку
+func (s *AdminService) SetPriorityBoardingEligible(ctx context.Context, passengerID uuid.UUID, eligible bool) error {
+	ctx = wrap.WithAction(wrap.WithPassengerID(ctx, passengerID.String()), "set_priority_boarding")
+
+	if err := s.adminRepo.SetPriorityBoardingEligible(ctx, passengerID, eligible); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "passenger priority boarding eligibility changed", "eligible", eligible)
+	return nil
+}
+
+// invalidateCache рассылает сброс кэша. Ошибка только логируется: изменение уже сохранено,
+// а устаревшие записи вытеснит TTL кэша.
+func (s *AdminService) invalidateCache(ctx context.Context, cache, key string) {
+	if s.caches == nil {
+		return
+	}
+	if err := s.caches.Publish(ctx, cache, key); err != nil {
+		s.l.Warn(ctx, "failed to publish cache invalidation", "cache", cache, "key", key, "error", err)
+	}
+}
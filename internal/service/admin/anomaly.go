@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// sessionMaxAge — сессия, открытая дольше, считается зависшей
+	sessionMaxAge = 24 * time.Hour
+	// orphanedRideCancelReason — причина отмены поездки, назначенной офлайн водителю
+	orphanedRideCancelReason = "Cancelled by admin: assigned driver went offline"
+)
+
+// remediations — действие исправления для каждого типа аномалии
+var remediations = map[types.AnomalyKind]models.Remediation{
+	types.AnomalyDriverBusyWithoutRide: {
+		Action:      "reset_driver_status",
+		Description: "Set driver AVAILABLE if the session is open, otherwise OFFLINE",
+	},
+	types.AnomalyRideMatchedDriverOffline: {
+		Action:      "cancel_ride",
+		Description: "Cancel the ride and record RIDE_CANCELLED event",
+	},
+	types.AnomalySessionOpenTooLong: {
+		Action:      "close_session",
+		Description: "Close the session and set an AVAILABLE driver OFFLINE",
+	},
+	types.AnomalyDuplicateCurrentCoordinate: {
+		Action:      "keep_latest_coordinate",
+		Description: "Keep is_current only on the latest coordinate",
+	},
+}
+
+// RunCoordinateRepairJob периодически оставляет is_current только у последней координаты сущности.
+// Уникальный индекс не дает появиться второй текущей координате, job страхует от его отключения
+// и считает найденные нарушения в метрике.
+func (s *AdminService) RunCoordinateRepairJob(ctx context.Context, interval time.Duration) {
+	ctx = wrap.WithAction(ctx, "coordinate_repair_job")
+	if interval <= 0 {
+		s.l.Warn(ctx, "coordinate repair job disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RepairCurrentCoordinates(ctx); err != nil {
+			s.l.Error(wrap.ErrorCtx(ctx, err), "failed to repair current coordinates", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RepairCurrentCoordinates исправляет сущности с несколькими текущими координатами и возвращает их число
+func (s *AdminService) RepairCurrentCoordinates(ctx context.Context) (int, error) {
+	violations, err := s.adminRepo.RepairCurrentCoordinates(ctx)
+	if err != nil {
+		return 0, wrap.Error(ctx, err)
+	}
+
+	total := 0
+	for entityType, count := range violations {
+		metrics.CoordinateCurrentViolationsTotal.WithLabelValues(string(entityType)).Add(float64(count))
+		total += count
+	}
+	if total > 0 {
+		s.l.Warn(ctx, "repaired entities with several current coordinates", "total", total)
+	}
+	return total, nil
+}
+
+// Anomalies запускает проверки согласованности и возвращает найденные аномалии
+// с действием исправления. kind ограничивает результат одним типом.
+func (s *AdminService) Anomalies(ctx context.Context, kind types.AnomalyKind) (*models.AnomaliesResponse, error) {
+	ctx = wrap.WithAction(ctx, "find_anomalies")
+
+	if kind != "" {
+		if _, ok := remediations[kind]; !ok {
+			return nil, types.ErrUnknownAnomalyKind
+		}
+	}
+
+	found, err := s.adminRepo.FindAnomalies(ctx, sessionMaxAge)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	res := &models.AnomaliesResponse{
+		Anomalies: make([]models.Anomaly, 0, len(found)),
+		ByKind:    make(map[types.AnomalyKind]int, len(types.AllAnomalyKinds)),
+		CheckedAt: time.Now(),
+	}
+	for _, k := range types.AllAnomalyKinds {
+		res.ByKind[k] = 0
+	}
+
+	for _, a := range found {
+		res.ByKind[a.Kind]++
+		if kind != "" && a.Kind != kind {
+			continue
+		}
+
+		a.Remediation = remediations[a.Kind]
+		a.Remediation.Method = "POST"
+		a.Remediation.Path = fmt.Sprintf("/admin/anomalies/%s/%s/remediate", a.Kind, a.EntityID)
+		res.Anomalies = append(res.Anomalies, a)
+	}
+	res.Total = len(res.Anomalies)
+
+	if len(found) > 0 {
+		s.l.Warn(ctx, "anomalies detected", "total", len(found))
+	}
+
+	return res, nil
+}
+
+// Remediate исправляет аномалию. Условие аномалии перепроверяется в базе,
+// поэтому уже исправленная аномалия возвращает ErrAnomalyNotFound.
+// dryRun выполняет исправление в транзакции с откатом и только возвращает последствия.
+func (s *AdminService) Remediate(ctx context.Context, kind types.AnomalyKind, entityID uuid.UUID, dryRun bool) (*models.AdminEffects, error) {
+	ctx = wrap.WithAction(ctx, "remediate_anomaly")
+
+	effects := &models.AdminEffects{
+		DryRun:          dryRun,
+		AffectedRides:   []uuid.UUID{},
+		AffectedDrivers: []uuid.UUID{},
+		// исправление аномалий пользователей не уведомляет
+		Notifications: []models.PlannedNotification{},
+	}
+
+	apply := func(ctx context.Context) error {
+		return s.remediate(ctx, kind, entityID, effects)
+	}
+
+	var err error
+	if dryRun {
+		err = s.trm.DoRollbackOnly(ctx, apply)
+	} else {
+		err = s.trm.Do(ctx, apply)
+	}
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	if !dryRun {
+		// исправление меняет статус водителей, driver-service кэширует профили
+		for _, driverID := range effects.AffectedDrivers {
+			s.invalidateCache(ctx, types.CacheDrivers, driverID.String())
+		}
+	}
+
+	s.l.Info(ctx, "anomaly remediated", "kind", kind.String(), "entity_id", entityID.String(), "result", effects.Result, "dry_run", dryRun)
+	return effects, nil
+}
+
+func (s *AdminService) remediate(ctx context.Context, kind types.AnomalyKind, entityID uuid.UUID, effects *models.AdminEffects) error {
+	switch kind {
+	case types.AnomalyDriverBusyWithoutRide:
+		status, err := s.adminRepo.ResetDriverStatus(ctx, entityID)
+		if err != nil {
+			return err
+		}
+		effects.Result = "driver status set to " + status.String()
+		effects.AffectedDrivers = append(effects.AffectedDrivers, entityID)
+	case types.AnomalyRideMatchedDriverOffline:
+		driverID, err := s.adminRepo.CancelRideWithOfflineDriver(ctx, entityID, orphanedRideCancelReason)
+		if err != nil {
+			return err
+		}
+		effects.Result = "ride cancelled"
+		effects.AffectedRides = append(effects.AffectedRides, entityID)
+		effects.AffectedDrivers = append(effects.AffectedDrivers, driverID)
+	case types.AnomalySessionOpenTooLong:
+		driverID, err := s.adminRepo.CloseStaleSession(ctx, entityID, sessionMaxAge)
+		if err != nil {
+			return err
+		}
+		effects.Result = "session closed"
+		effects.AffectedDrivers = append(effects.AffectedDrivers, driverID)
+	case types.AnomalyDuplicateCurrentCoordinate:
+		reset, err := s.adminRepo.KeepLatestCoordinate(ctx, entityID)
+		if err != nil {
+			return err
+		}
+		effects.Result = fmt.Sprintf("%d stale coordinates unmarked", reset)
+	default:
+		return types.ErrUnknownAnomalyKind
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// MatchingBlackouts возвращает действующие и запланированные окна приостановки подбора.
+// Закончившиеся окна не возвращаются и перестают действовать сами.
+func (s *AdminService) MatchingBlackouts(ctx context.Context) ([]models.MatchingBlackout, error) {
+	return s.blackouts.Active(ctx, time.Now())
+}
+
+// CreateMatchingBlackout создает окно. Начало в прошлом или не заданное заменяется текущим моментом.
+func (s *AdminService) CreateMatchingBlackout(ctx context.Context, b *models.MatchingBlackout) error {
+	ctx = wrap.WithAction(ctx, "create_matching_blackout")
+
+	if now := time.Now(); b.StartsAt.Before(now) {
+		b.StartsAt = now
+	}
+
+	if err := s.blackouts.Create(ctx, b); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	s.invalidateCache(ctx, types.CacheBlackouts, b.ID.String())
+
+	s.l.Info(ctx, "matching blackout created",
+		"blackout_id", b.ID,
+		"vehicle_type", b.VehicleType,
+		"starts_at", b.StartsAt,
+		"ends_at", b.EndsAt,
+	)
+	return nil
+}
+
+// UpdateMatchingBlackout переписывает окно, которое еще не закончилось
+func (s *AdminService) UpdateMatchingBlackout(ctx context.Context, b *models.MatchingBlackout) error {
+	ctx = wrap.WithAction(ctx, "update_matching_blackout")
+
+	now := time.Now()
+	if b.StartsAt.Before(now) {
+		b.StartsAt = now
+	}
+
+	updated, err := s.blackouts.Update(ctx, b, now)
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+	if !updated {
+		return types.ErrBlackoutNotFound
+	}
+	s.invalidateCache(ctx, types.CacheBlackouts, b.ID.String())
+
+	s.l.Info(ctx, "matching blackout updated",
+		"blackout_id", b.ID,
+		"vehicle_type", b.VehicleType,
+		"starts_at", b.StartsAt,
+		"ends_at", b.EndsAt,
+	)
+	return nil
+}
+
+// EndMatchingBlackout завершает окно сейчас, запланированное окно удаляется.
+// Задержанные поездки уходят на поиск водителя при следующей проверке relay.
+func (s *AdminService) EndMatchingBlackout(ctx context.Context, id uuid.UUID) error {
+	ctx = wrap.WithAction(ctx, "end_matching_blackout")
+
+	ended, err := s.blackouts.End(ctx, id, time.Now())
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+	if !ended {
+		return types.ErrBlackoutNotFound
+	}
+	s.invalidateCache(ctx, types.CacheBlackouts, id.String())
+
+	s.l.Info(ctx, "matching blackout ended", "blackout_id", id)
+	return nil
+}
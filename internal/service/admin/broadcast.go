@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Broadcast сохраняет объявление и отправляет его через брокер ride и driver сервисам,
+// которые доставляют его в WebSocket соединения. Пользователи офлайн получат объявление
+// при подключении. Возвращаемая статистика содержит число получателей, счетчики доставки
+// заполняются сервисами и доступны через GetBroadcast.
+func (s *AdminService) Broadcast(ctx context.Context, b *models.Broadcast) error {
+	ctx = wrap.WithAction(ctx, "admin_broadcast")
+
+	if b.City != "" {
+		b.City = strings.ToUpper(b.City)
+		if err := s.checkCity(ctx, b.City); err != nil {
+			return wrap.Error(ctx, err)
+		}
+	}
+
+	for _, role := range b.Roles {
+		count, err := s.broadcastRepo.CountRecipients(ctx, role, b.City)
+		if err != nil {
+			return wrap.Error(ctx, fmt.Errorf("failed to count %s recipients: %w", role, err))
+		}
+		b.Stats.Recipients += count
+	}
+
+	if err := s.broadcastRepo.Create(ctx, b); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	if err := s.broadcasts.PublishBroadcast(ctx, models.BroadcastMessage{
+		BroadcastID: b.ID,
+		Title:       b.Title,
+		Message:     b.Message,
+		Roles:       b.Roles,
+		City:        b.City,
+		CreatedAt:   b.CreatedAt,
+	}); err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to publish broadcast: %w", err))
+	}
+
+	s.l.Info(ctx, "broadcast published",
+		"broadcast_id", b.ID,
+		"roles", b.Roles,
+		"city", b.City,
+		"recipients", b.Stats.Recipients,
+	)
+	return nil
+}
+
+// GetBroadcast возвращает объявление с текущей статистикой доставки
+func (s *AdminService) GetBroadcast(ctx context.Context, id uuid.UUID) (*models.Broadcast, error) {
+	return s.broadcastRepo.Get(ctx, id)
+}
+
+func (s *AdminService) checkCity(ctx context.Context, code string) error {
+	cities, err := s.cityRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, city := range cities {
+		if city.Code == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", types.ErrUnknownCity, code)
+}
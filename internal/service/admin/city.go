@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// Cities возвращает операционные правила всех городов
+func (s *AdminService) Cities(ctx context.Context) ([]models.CitySettings, error) {
+	return s.cityRepo.List(ctx)
+}
+
+// UpdateCity создает или обновляет правила города, изменения применяются к новым поездкам во всех экземплярах ride-service сразу после сброса кэша
+func (s *AdminService) UpdateCity(ctx context.Context, city *models.CitySettings) error {
+	ctx = wrap.WithAction(ctx, "update_city_settings")
+
+	if err := s.cityRepo.Upsert(ctx, city); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	s.invalidateCache(ctx, types.CacheCitySettings, city.Code)
+
+	s.l.Info(ctx, "city settings updated",
+		"city", city.Code,
+		"open_time", city.OpenTime,
+		"close_time", city.CloseTime,
+		"night_multiplier", city.NightMultiplier,
+		"outside_hours_policy", city.OutsideHoursPolicy,
+	)
+	return nil
+}
@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// Пороги недообслуженного района относительно показателей города
+const (
+	underservedMatchRatio    = 0.8 // доля подбора ниже 80% городской
+	underservedWaitRatio     = 1.5 // среднее ожидание подбора в полтора раза дольше городского
+	underservedNoDriverRatio = 1.5 // отмены без водителя в полтора раза чаще городских
+)
+
+// CoverageReport сравнивает подбор водителей по районам за последние days дней.
+// Районы с minRequests запросами и больше, заметно отстающие от города, отмечаются как недообслуженные —
+// там нужны стимулы для водителей. Районы с меньшим числом запросов в отчет не входят, но учитываются в городских показателях.
+func (s *AdminService) CoverageReport(ctx context.Context, days, precision, minRequests int) (*models.CoverageReport, error) {
+	since := time.Now().AddDate(0, 0, -days)
+
+	areas, err := s.adminRepo.GetCoverage(ctx, since, precision)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CoverageReport{Since: since, Precision: precision, Areas: make([]models.CoverageArea, 0, len(areas))}
+
+	var matched, noDriver int
+	var waitSum float64
+	for _, a := range areas {
+		report.Requests += a.Requests
+		matched += a.Matched
+		noDriver += a.NoDriverCancellations
+		if a.AvgWaitSec != nil {
+			waitSum += *a.AvgWaitSec * float64(a.Matched)
+		}
+	}
+	if report.Requests == 0 {
+		return report, nil
+	}
+	report.MatchRate = float64(matched) / float64(report.Requests)
+	report.NoDriverRate = float64(noDriver) / float64(report.Requests)
+	if matched > 0 {
+		avgWait := waitSum / float64(matched)
+		report.AvgWaitSec = &avgWait
+	}
+
+	for _, a := range areas {
+		if a.Requests < minRequests {
+			continue
+		}
+		a.MatchRate = float64(a.Matched) / float64(a.Requests)
+		a.NoDriverRate = float64(a.NoDriverCancellations) / float64(a.Requests)
+		a.Issues = coverageIssues(report, a)
+		if a.Underserved = len(a.Issues) > 0; a.Underserved {
+			report.Underserved++
+		}
+		report.Areas = append(report.Areas, a)
+	}
+
+	slices.SortStableFunc(report.Areas, func(a, b models.CoverageArea) int {
+		switch {
+		case a.Underserved != b.Underserved:
+			if a.Underserved {
+				return -1
+			}
+			return 1
+		case a.NoDriverCancellations != b.NoDriverCancellations:
+			return b.NoDriverCancellations - a.NoDriverCancellations
+		default:
+			return b.Requests - a.Requests
+		}
+	})
+
+	return report, nil
+}
+
+// coverageIssues сравнивает район с городом
+func coverageIssues(city *models.CoverageReport, a models.CoverageArea) []types.CoverageIssue {
+	var issues []types.CoverageIssue
+	if a.MatchRate < city.MatchRate*underservedMatchRatio {
+		issues = append(issues, types.CoverageLowMatchRate)
+	}
+	if a.AvgWaitSec != nil && city.AvgWaitSec != nil && *a.AvgWaitSec > *city.AvgWaitSec*underservedWaitRatio {
+		issues = append(issues, types.CoverageLongWait)
+	}
+	if a.NoDriverCancellations > 0 && a.NoDriverRate > city.NoDriverRate*underservedNoDriverRatio {
+		issues = append(issues, types.CoverageNoDriver)
+	}
+	return issues
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// RecordFailedMessage сохраняет сообщение из dead letter очереди, вызывается потребителем очереди
+func (s *AdminService) RecordFailedMessage(ctx context.Context, msg *models.FailedMessage) error {
+	if err := s.failedMessages.Create(ctx, msg); err != nil {
+		return err
+	}
+
+	s.l.Info(ctx, "failed message stored", "failed_message_id", msg.ID, "queue", msg.Queue, "reason", msg.Reason)
+	return nil
+}
+
+// FailedMessages возвращает сообщения, собранные из dead letter очереди
+func (s *AdminService) FailedMessages(ctx context.Context, f models.FailedMessageFilter) ([]models.FailedMessage, error) {
+	return s.failedMessages.List(ctx, f)
+}
+
+// FailedMessage возвращает сообщение из dead letter очереди по id
+func (s *AdminService) FailedMessage(ctx context.Context, id uuid.UUID) (*models.FailedMessage, error) {
+	return s.failedMessages.Get(ctx, id)
+}
+
+// ReplayFailedMessage отправляет сообщение повторно в очередь, из которой оно ушло в dead letter.
+// Если потребитель снова его отклонит, оно вернется в failed_messages новой записью.
+func (s *AdminService) ReplayFailedMessage(ctx context.Context, id uuid.UUID) (*models.FailedMessage, error) {
+	ctx = wrap.WithAction(ctx, "replay_failed_message")
+
+	if s.deadLetters == nil {
+		return nil, types.ErrDeadLetterUnavailable
+	}
+
+	msg, err := s.failedMessages.Get(ctx, id)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	if msg.Queue == "" {
+		return nil, types.ErrFailedMessageNoQueue
+	}
+
+	if err := s.deadLetters.Replay(ctx, msg); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	if err := s.failedMessages.MarkReplayed(ctx, id); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "failed message replayed", "failed_message_id", id, "queue", msg.Queue)
+	return s.failedMessages.Get(ctx, id)
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// DriverChanges возвращает изменения профилей водителей, ожидающие одобрения
+func (s *AdminService) DriverChanges(ctx context.Context) ([]models.DriverChangeRequest, error) {
+	return s.adminRepo.GetPendingDriverChanges(ctx)
+}
+
+// ApproveDriverChange одобряет изменение и применяет его к профилю водителя.
+// Если номер удостоверения успел занять другой водитель, изменение остается в очереди.
+func (s *AdminService) ApproveDriverChange(ctx context.Context, changeID, adminID uuid.UUID) (*models.DriverChangeRequest, error) {
+	ctx = wrap.WithAction(ctx, "approve_driver_change")
+
+	var change *models.DriverChangeRequest
+	fn := func(ctx context.Context) error {
+		var err error
+		change, err = s.adminRepo.ReviewDriverChange(ctx, changeID, adminID, types.DriverChangeApproved, nil)
+		if err != nil {
+			return err
+		}
+
+		switch change.Field {
+		case models.DriverFieldLicenseNumber:
+			return s.adminRepo.SetDriverLicense(ctx, change.DriverID, change.NewValue)
+		case models.DriverFieldPhotoURL:
+			return s.adminRepo.SetDriverPhoto(ctx, change.DriverID, change.NewValue)
+		default:
+			return fmt.Errorf("unsupported driver change field %q", change.Field)
+		}
+	}
+
+	if err := s.trm.Do(ctx, fn); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	s.invalidateCache(ctx, types.CacheDrivers, change.DriverID.String())
+
+	s.l.Info(ctx, "driver change approved", "change_id", changeID.String(), "driver_id", change.DriverID.String(), "field", change.Field)
+	return change, nil
+}
+
+// RejectDriverChange отклоняет изменение, профиль водителя не меняется
+func (s *AdminService) RejectDriverChange(ctx context.Context, changeID, adminID uuid.UUID, reason string) (*models.DriverChangeRequest, error) {
+	ctx = wrap.WithAction(ctx, "reject_driver_change")
+
+	change, err := s.adminRepo.ReviewDriverChange(ctx, changeID, adminID, types.DriverChangeRejected, &reason)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "driver change rejected", "change_id", changeID.String(), "driver_id", change.DriverID.String(), "field", change.Field)
+	return change, nil
+}
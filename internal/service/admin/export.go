@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// ExportOptions — ограничения выгрузки состояния системы
+type ExportOptions struct {
+	SyncMaxItems int           // до скольких строк снимок отдается сразу, больше — собирается в фоне
+	MaxItems     int           // максимум строк в снимке, остальные отбрасываются с truncated
+	Timeout      time.Duration // таймаут сборки снимка в фоне
+}
+
+// ExportState собирает снимок активных поездок, водителей на линии и открытых смен.
+// Небольшой снимок возвращается сразу, для большого парка создается фоновая выгрузка.
+func (s *AdminService) ExportState(ctx context.Context, requestedBy uuid.UUID) (*models.StateSnapshot, *models.StateExport, error) {
+	ctx = wrap.WithAction(ctx, "export_state")
+
+	// оценка размера вне транзакции снимка, точные числа считаются уже внутри нее
+	counts, _, err := s.adminRepo.GetStateCounts(ctx)
+	if err != nil {
+		return nil, nil, wrap.Error(ctx, err)
+	}
+
+	if counts.Total() <= s.export.SyncMaxItems {
+		snapshot, err := s.takeSnapshot(ctx)
+		if err != nil {
+			return nil, nil, wrap.Error(ctx, err)
+		}
+		return snapshot, nil, nil
+	}
+
+	export := &models.StateExport{RequestedBy: requestedBy}
+	if err := s.adminRepo.CreateStateExport(ctx, export); err != nil {
+		return nil, nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "state export started in background", "export_id", export.ID, "items", counts.Total())
+	go s.buildStateExport(context.WithoutCancel(ctx), export.ID)
+
+	return nil, export, nil
+}
+
+// StateExport возвращает фоновую выгрузку, снимок есть только в статусе READY
+func (s *AdminService) StateExport(ctx context.Context, id uuid.UUID) (*models.StateExport, error) {
+	return s.adminRepo.GetStateExport(ctx, id)
+}
+
+func (s *AdminService) buildStateExport(ctx context.Context, id uuid.UUID) {
+	if s.export.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.export.Timeout)
+		defer cancel()
+	}
+
+	snapshot, err := s.takeSnapshot(ctx)
+	var errMsg *string
+	if err != nil {
+		s.l.Error(wrap.ErrorCtx(ctx, err), "failed to build state export", err, "export_id", id)
+		msg := err.Error()
+		errMsg = &msg
+	}
+
+	// результат записываем даже после таймаута сборки
+	if err := s.adminRepo.CompleteStateExport(context.WithoutCancel(ctx), id, snapshot, errMsg); err != nil {
+		s.l.Error(wrap.ErrorCtx(ctx, err), "failed to complete state export", err, "export_id", id)
+		return
+	}
+
+	s.l.Info(ctx, "state export completed", "export_id", id, "failed", errMsg != nil)
+}
+
+// takeSnapshot читает все разделы в одной read-only транзакции REPEATABLE READ,
+// поэтому поездки, водители и смены видны на один и тот же момент.
+func (s *AdminService) takeSnapshot(ctx context.Context) (*models.StateSnapshot, error) {
+	ctx = trm.WithOptions(ctx, trm.Options{
+		IsoLevel:   trm.IsoRepeatableRead,
+		Deferrable: trm.NotDeferrable,
+	})
+
+	snapshot := &models.StateSnapshot{}
+	err := s.trm.DoReadOnly(ctx, func(ctx context.Context) error {
+		var err error
+		if snapshot.Counts, snapshot.TakenAt, err = s.adminRepo.GetStateCounts(ctx); err != nil {
+			return err
+		}
+
+		// лимит делится по разделам по порядку: поездки, водители, смены
+		budget := s.export.MaxItems
+		take := func(n int) int {
+			n = min(n, budget)
+			budget -= n
+			return n
+		}
+
+		if snapshot.ActiveRides, err = s.adminRepo.GetSnapshotRides(ctx, take(snapshot.Counts.ActiveRides)); err != nil {
+			return err
+		}
+		if snapshot.OnlineDrivers, err = s.adminRepo.GetSnapshotDrivers(ctx, take(snapshot.Counts.OnlineDrivers)); err != nil {
+			return err
+		}
+		if snapshot.OpenSessions, err = s.adminRepo.GetSnapshotSessions(ctx, take(snapshot.Counts.OpenSessions)); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Truncated = snapshot.Counts.Total() > s.export.MaxItems
+	return snapshot, nil
+}
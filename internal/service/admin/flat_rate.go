@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// FlatRates возвращает действующие и запланированные версии фиксированных тарифов
+func (s *AdminService) FlatRates(ctx context.Context) ([]models.FlatRate, error) {
+	return s.flatRates.Active(ctx, time.Now())
+}
+
+// FlatRateHistory возвращает все версии тарифа, новые первыми
+func (s *AdminService) FlatRateHistory(ctx context.Context, code string) ([]models.FlatRate, error) {
+	rates, err := s.flatRates.History(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return nil, types.ErrFlatRateNotFound
+	}
+	return rates, nil
+}
+
+// SetFlatRate создает новую версию тарифа с rate.EffectiveFrom, текущая версия закрывается в этот момент.
+// Уже созданные версии не переписываются: поездки, оцененные по ним, сохраняют цену в истории.
+func (s *AdminService) SetFlatRate(ctx context.Context, rate *models.FlatRate) error {
+	ctx = wrap.WithAction(ctx, "set_flat_rate")
+
+	now := time.Now()
+	if rate.EffectiveFrom.Before(now) {
+		rate.EffectiveFrom = now
+	}
+
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		if err := s.flatRates.Lock(ctx, rate.Code); err != nil {
+			return err
+		}
+
+		versions, err := s.flatRates.History(ctx, rate.Code)
+		if err != nil {
+			return err
+		}
+		// версии упорядочены от новых, достаточно проверить последнюю
+		if len(versions) > 0 && !versions[0].EffectiveFrom.Before(rate.EffectiveFrom) {
+			return types.ErrFlatRateScheduled
+		}
+
+		if _, err := s.flatRates.End(ctx, rate.Code, rate.EffectiveFrom); err != nil {
+			return err
+		}
+		return s.flatRates.Create(ctx, rate)
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+	s.invalidateCache(ctx, types.CacheFlatRates, rate.Code)
+
+	s.l.Info(ctx, "flat rate version created",
+		"code", rate.Code,
+		"rate_type", rate.RateType,
+		"fare", rate.Fare,
+		"effective_from", rate.EffectiveFrom,
+	)
+	return nil
+}
+
+// EndFlatRate прекращает действие тарифа сейчас, запланированные версии удаляются
+func (s *AdminService) EndFlatRate(ctx context.Context, code string) error {
+	ctx = wrap.WithAction(ctx, "end_flat_rate")
+
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		if err := s.flatRates.Lock(ctx, code); err != nil {
+			return err
+		}
+
+		affected, err := s.flatRates.End(ctx, code, time.Now())
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return types.ErrFlatRateNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+	s.invalidateCache(ctx, types.CacheFlatRates, code)
+
+	s.l.Info(ctx, "flat rate ended", "code", code)
+	return nil
+}
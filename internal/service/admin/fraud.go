@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// FraudOptions — окно анализа и пороги признаков графа мошенничества
+type FraudOptions struct {
+	Window           time.Duration // поездки за это окно учитываются в REPEATED_PAIR и CO_LOCATED
+	RepeatedPairMin  int           // минимум завершенных поездок пары для REPEATED_PAIR
+	CoLocatedRadiusM float64       // водитель ближе этого расстояния к точке посадки считается на месте
+	CoLocatedMin     int           // минимум таких поездок пары для CO_LOCATED
+}
+
+// RunFraudJob пересчитывает граф подозрительных связей сразу и затем каждые interval до отмены контекста.
+// Результат кэшируется и отдается FraudGraph.
+func (s *AdminService) RunFraudJob(ctx context.Context, interval time.Duration, opts FraudOptions) {
+	ctx = wrap.WithAction(ctx, "fraud_graph_job")
+	if interval <= 0 {
+		s.l.Warn(ctx, "fraud graph job disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		graph, err := s.ComputeFraudGraph(ctx, opts)
+		if err != nil {
+			s.l.Error(wrap.ErrorCtx(ctx, err), "failed to compute fraud graph", err)
+		} else {
+			s.fraudGraph.Store(graph)
+			s.l.Info(ctx, "fraud graph computed", "nodes", len(graph.Nodes), "edges", len(graph.Edges))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FraudGraph возвращает последний посчитанный граф. До первого расчета граф пустой и без computed_at.
+func (s *AdminService) FraudGraph(ctx context.Context) (*models.FraudGraph, error) {
+	if graph := s.fraudGraph.Load(); graph != nil {
+		return graph, nil
+	}
+	return &models.FraudGraph{Nodes: []models.FraudNode{}, Edges: []models.FraudEdge{}}, nil
+}
+
+// ComputeFraudGraph собирает связи по всем признакам и строит узлы с суммарным весом связей
+func (s *AdminService) ComputeFraudGraph(ctx context.Context, opts FraudOptions) (*models.FraudGraph, error) {
+	since := time.Now().Add(-opts.Window)
+
+	shared, err := s.adminRepo.GetSharedDeviceEdges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := s.adminRepo.GetRepeatedPairEdges(ctx, since, opts.RepeatedPairMin)
+	if err != nil {
+		return nil, err
+	}
+	colocated, err := s.adminRepo.GetCoLocatedEdges(ctx, since, opts.CoLocatedRadiusM, opts.CoLocatedMin)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.FraudGraph{
+		Edges:      slices.Concat(shared, pairs, colocated),
+		Since:      since,
+		ComputedAt: time.Now(),
+	}
+
+	nodes := make(map[uuid.UUID]*models.FraudNode)
+	addNode := func(id uuid.UUID, role types.UserRole, weight int) {
+		n, ok := nodes[id]
+		if !ok {
+			n = &models.FraudNode{ID: id, Role: role}
+			nodes[id] = n
+		}
+		n.Score += weight
+	}
+	for _, e := range graph.Edges {
+		addNode(e.Source, e.SourceRole, e.Weight)
+		addNode(e.Target, e.TargetRole, e.Weight)
+	}
+
+	graph.Nodes = make([]models.FraudNode, 0, len(nodes))
+	for _, n := range nodes {
+		graph.Nodes = append(graph.Nodes, *n)
+	}
+	slices.SortFunc(graph.Nodes, func(a, b models.FraudNode) int {
+		return b.Score - a.Score
+	})
+
+	return graph, nil
+}
@@ -2,13 +2,138 @@ package admin
 
 import (
 	"context"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
 type AdminRepository interface {
 	GetOverview(ctx context.Context) (*models.OverviewResponse, error)
 	GetActiveRides(ctx context.Context, filters models.Filters) (*models.ActiveRidesResponse, error)
+	SearchRides(ctx context.Context, filter models.RideSearchFilter) (*models.RideSearchResponse, error)
+	GetRideNumber(ctx context.Context, rideID uuid.UUID) (string, time.Time, error)
+	GetRideEventsUntil(ctx context.Context, rideID uuid.UUID, ts time.Time) ([]models.RideEventRecord, error)
+	GetDriverLocationAt(ctx context.Context, driverID uuid.UUID, ts time.Time) (*models.LocationRecord, error)
+	GetMatchSLI(ctx context.Context, window, threshold time.Duration) (good, total int, err error)
+	GetBlocklist(ctx context.Context, filter models.BlocklistFilter, filters models.Filters) (*models.BlocklistResponse, error)
+	SetDriverSimulator(ctx context.Context, driverID uuid.UUID, simulator bool) error
+	SetPriorityBoardingEligible(ctx context.Context, passengerID uuid.UUID, eligible bool) error
+	GetPositioningEffectiveness(ctx context.Context, since time.Time) (*models.PositioningEffectiveness, error)
+	GetTelemetryReport(ctx context.Context, since time.Time) ([]models.TelemetryGroup, error)
+	GetCoverage(ctx context.Context, since time.Time, precision int) ([]models.CoverageArea, error)
+	AnomalyRepository
+	StateExportRepository
+	FraudRepository
+	DriverChangeRepository
+}
+
+// StateExportRepository читает разделы снимка состояния и хранит фоновые выгрузки
+type StateExportRepository interface {
+	GetStateCounts(ctx context.Context) (models.StateCounts, time.Time, error)
+	GetSnapshotRides(ctx context.Context, limit int) ([]models.SnapshotRide, error)
+	GetSnapshotDrivers(ctx context.Context, limit int) ([]models.SnapshotDriver, error)
+	GetSnapshotSessions(ctx context.Context, limit int) ([]models.SnapshotSession, error)
+	CreateStateExport(ctx context.Context, e *models.StateExport) error
+	CompleteStateExport(ctx context.Context, id uuid.UUID, snapshot *models.StateSnapshot, errMsg *string) error
+	GetStateExport(ctx context.Context, id uuid.UUID) (*models.StateExport, error)
+}
+
+// DriverChangeRepository хранит очередь изменений профиля водителей на одобрение
+type DriverChangeRepository interface {
+	GetPendingDriverChanges(ctx context.Context) ([]models.DriverChangeRequest, error)
+	ReviewDriverChange(ctx context.Context, changeID, adminID uuid.UUID, status types.DriverChangeStatus, reason *string) (*models.DriverChangeRequest, error)
+	SetDriverLicense(ctx context.Context, driverID uuid.UUID, license string) error
+	SetDriverPhoto(ctx context.Context, driverID uuid.UUID, photoURL string) error
+}
+
+// AnomalyRepository находит несогласованные состояния и исправляет их
+type AnomalyRepository interface {
+	FindAnomalies(ctx context.Context, sessionMaxAge time.Duration) ([]models.Anomaly, error)
+	ResetDriverStatus(ctx context.Context, driverID uuid.UUID) (types.DriverStatus, error)
+	CancelRideWithOfflineDriver(ctx context.Context, rideID uuid.UUID, reason string) (driverID uuid.UUID, err error)
+	CloseStaleSession(ctx context.Context, sessionID uuid.UUID, maxAge time.Duration) (driverID uuid.UUID, err error)
+	KeepLatestCoordinate(ctx context.Context, entityID uuid.UUID) (int64, error)
+	RepairCurrentCoordinates(ctx context.Context) (map[types.EntityType]int, error)
+}
+
+// FraudRepository находит подозрительные связи между водителями и пассажирами
+type FraudRepository interface {
+	GetSharedDeviceEdges(ctx context.Context) ([]models.FraudEdge, error)
+	GetRepeatedPairEdges(ctx context.Context, since time.Time, minRides int) ([]models.FraudEdge, error)
+	GetCoLocatedEdges(ctx context.Context, since time.Time, radiusM float64, minRides int) ([]models.FraudEdge, error)
+}
+
+// CityRepo хранит операционные правила городов
+type CityRepo interface {
+	List(ctx context.Context) ([]models.CitySettings, error)
+	Upsert(ctx context.Context, city *models.CitySettings) error
+	SetMatchingPaused(ctx context.Context, code string, paused bool) error
+}
+
+// FlatRateRepo хранит версии фиксированных тарифов маршрутов и минимальных тарифов зон
+type FlatRateRepo interface {
+	Active(ctx context.Context, at time.Time) ([]models.FlatRate, error)
+	History(ctx context.Context, code string) ([]models.FlatRate, error)
+	Lock(ctx context.Context, code string) error
+	Create(ctx context.Context, rate *models.FlatRate) error
+	End(ctx context.Context, code string, at time.Time) (int64, error)
+}
+
+// BlackoutRepo хранит окна приостановки подбора водителей
+type BlackoutRepo interface {
+	Active(ctx context.Context, at time.Time) ([]models.MatchingBlackout, error)
+	Create(ctx context.Context, b *models.MatchingBlackout) error
+	Update(ctx context.Context, b *models.MatchingBlackout, at time.Time) (bool, error)
+	End(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+}
+
+// CommunicationRepo - журнал коммуникаций поездки
+type CommunicationRepo interface {
+	ListByRide(ctx context.Context, rideID uuid.UUID) ([]models.Communication, error)
+}
+
+// CacheInvalidator сбрасывает кэши во всех экземплярах сервисов.
+// Внутри транзакции событие доставляется после commit.
+type CacheInvalidator interface {
+	Publish(ctx context.Context, cache, key string) error
+}
+
+// OpsRepo хранит журнал операционных действий
+type OpsRepo interface {
+	Create(ctx context.Context, a *models.OpsAction) error
+	Get(ctx context.Context, id uuid.UUID) (*models.OpsAction, error)
+	List(ctx context.Context, limit int) ([]models.OpsAction, error)
+}
+
+// BroadcastRepo хранит объявления и статистику их доставки
+type BroadcastRepo interface {
+	CountRecipients(ctx context.Context, role types.UserRole, city string) (int, error)
+	Create(ctx context.Context, b *models.Broadcast) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Broadcast, error)
+}
+
+// FailedMessageRepo хранит сообщения, собранные из dead letter очереди брокера
+type FailedMessageRepo interface {
+	Create(ctx context.Context, m *models.FailedMessage) error
+	List(ctx context.Context, f models.FailedMessageFilter) ([]models.FailedMessage, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.FailedMessage, error)
+	MarkReplayed(ctx context.Context, id uuid.UUID) error
+}
+
+// DeadLetterReplayer повторно отправляет сообщение в исходную очередь
+type DeadLetterReplayer interface {
+	Replay(ctx context.Context, msg *models.FailedMessage) error
+}
+
+type BroadcastPublisher interface {
+	PublishBroadcast(ctx context.Context, msg models.BroadcastMessage) error
+}
+
+// MetricsSource выполняет PromQL запрос и возвращает скалярное значение
+type MetricsSource interface {
+	Query(ctx context.Context, query string) (float64, error)
 }
 
 type Calculator interface {
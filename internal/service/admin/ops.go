@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// opsActionsLimit — сколько последних действий возвращает журнал
+const opsActionsLimit = 100
+
+// opsHandler проверяет параметры действия и выполняет его.
+// Действие экземпляра сервиса только назначается: handler заполняет цель и оставляет PENDING.
+type opsHandler func(ctx context.Context, a *models.OpsAction) error
+
+func (s *AdminService) opsHandlers() map[types.OpsActionType]opsHandler {
+	return map[types.OpsActionType]opsHandler{
+		types.OpsDrainWS:       s.drainWS,
+		types.OpsPauseMatching: s.pauseMatching,
+	}
+}
+
+// descopedOps — действия, которые намеренно не выполняются через журнал, и причина.
+// Запрос такого действия отклоняется с причиной, а не как неизвестное действие.
+var descopedOps = map[types.OpsActionType]string{
+	"FLUSH_GEOCODE_CACHE": "geocoding results are not cached, the geocoder is called directly",
+	"ROTATE_JWT_KEY":      "rotate AUTH_JWT_SECRET in the secret store: every instance re-reads it within SECRETS_REFRESH_INTERVAL and keeps accepting the previous key until its tokens expire",
+}
+
+// RunOpsAction выполняет операционное действие и записывает его в журнал.
+// Неудачное действие тоже попадает в журнал со статусом FAILED.
+func (s *AdminService) RunOpsAction(ctx context.Context, a *models.OpsAction) error {
+	ctx = wrap.WithAction(ctx, "run_ops_action")
+
+	if reason, ok := descopedOps[a.Action]; ok {
+		return wrap.Error(ctx, fmt.Errorf("%w: %s is not supported: %s", types.ErrUnknownOpsAction, a.Action, reason))
+	}
+	handler, ok := s.opsHandlers()[a.Action]
+	if !ok {
+		return wrap.Error(ctx, fmt.Errorf("%w: %s", types.ErrUnknownOpsAction, a.Action))
+	}
+	if len(a.Params) == 0 {
+		a.Params = json.RawMessage(`{}`)
+	}
+
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		if err := handler(ctx, a); err != nil {
+			return err
+		}
+		return s.opsRepo.Create(ctx, a)
+	})
+	if err != nil {
+		s.recordFailedOps(ctx, a, err)
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "ops action recorded",
+		"ops_action_id", a.ID,
+		"action", a.Action,
+		"status", a.Status,
+		"requested_by", a.RequestedBy,
+	)
+	return nil
+}
+
+// OpsActions возвращает последние операционные действия, новые первыми
+func (s *AdminService) OpsActions(ctx context.Context) ([]models.OpsAction, error) {
+	return s.opsRepo.List(ctx, opsActionsLimit)
+}
+
+// OpsAction возвращает действие с результатом выполнения
+func (s *AdminService) OpsAction(ctx context.Context, id uuid.UUID) (*models.OpsAction, error) {
+	return s.opsRepo.Get(ctx, id)
+}
+
+// drainWS назначает закрытие WebSocket соединений экземпляру ride или driver сервиса.
+// Экземпляр выполняет действие при следующем опросе журнала, клиенты переподключаются к другим экземплярам.
+func (s *AdminService) drainWS(_ context.Context, a *models.OpsAction) error {
+	var p models.DrainWSParams
+	if err := json.Unmarshal(a.Params, &p); err != nil {
+		return fmt.Errorf("%w: %v", types.ErrInvalidOpsParams, err)
+	}
+
+	p.Service = strings.ToLower(strings.TrimSpace(p.Service))
+	p.Instance = strings.TrimSpace(p.Instance)
+	if p.Service != "ride" && p.Service != "driver" {
+		return fmt.Errorf("%w: service must be ride or driver", types.ErrInvalidOpsParams)
+	}
+	if p.Instance == "" {
+		return fmt.Errorf("%w: instance must be provided", types.ErrInvalidOpsParams)
+	}
+
+	a.Status = types.OpsPending
+	a.TargetService = &p.Service
+	a.TargetInstance = &p.Instance
+	return nil
+}
+
+// pauseMatching приостанавливает или возобновляет прием новых поездок в городе
+func (s *AdminService) pauseMatching(ctx context.Context, a *models.OpsAction) error {
+	var p models.PauseMatchingParams
+	if err := json.Unmarshal(a.Params, &p); err != nil {
+		return fmt.Errorf("%w: %v", types.ErrInvalidOpsParams, err)
+	}
+
+	p.City = strings.ToUpper(strings.TrimSpace(p.City))
+	if p.City == "" {
+		return fmt.Errorf("%w: city must be provided", types.ErrInvalidOpsParams)
+	}
+	if p.Paused == nil {
+		return fmt.Errorf("%w: paused must be provided", types.ErrInvalidOpsParams)
+	}
+
+	if err := s.cityRepo.SetMatchingPaused(ctx, p.City, *p.Paused); err != nil {
+		return err
+	}
+	// выполняется в транзакции RunOpsAction: сброс дойдет до экземпляров после commit,
+	// а ошибка NOTIFY прерывает транзакцию, поэтому возвращается как ошибка действия
+	if s.caches != nil {
+		if err := s.caches.Publish(ctx, types.CacheCitySettings, p.City); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	a.Status = types.OpsApplied
+	a.CompletedAt = &now
+	a.Result, _ = json.Marshal(map[string]any{"city": p.City, "matching_paused": *p.Paused})
+	return nil
+}
+
+// recordFailedOps записывает неудачное действие в журнал, чтобы попытка не потерялась
+func (s *AdminService) recordFailedOps(ctx context.Context, a *models.OpsAction, cause error) {
+	now := time.Now()
+	msg := cause.Error()
+
+	a.Status = types.OpsFailed
+	a.Error = &msg
+	a.Result = nil
+	a.CompletedAt = &now
+	if err := s.opsRepo.Create(ctx, a); err != nil {
+		s.l.Error(ctx, "failed to record failed ops action", err, "action", a.Action)
+	}
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+)
+
+// PositioningEffectiveness считает эффективность рекомендаций водителям за последние days дней.
+// Доли считаются от оцененных рекомендаций, nil — оцененных еще нет.
+func (s *AdminService) PositioningEffectiveness(ctx context.Context, days int) (*models.PositioningEffectiveness, error) {
+	res, err := s.adminRepo.GetPositioningEffectiveness(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Evaluated > 0 {
+		moveRate := float64(res.Moved) / float64(res.Evaluated)
+		rideRate := float64(res.GotRide) / float64(res.Evaluated)
+		res.MoveRate, res.RideRate = &moveRate, &rideRate
+	}
+
+	return res, nil
+}
+
+// TelemetryReport агрегирует анонимную телеметрию приложений за последние days дней
+func (s *AdminService) TelemetryReport(ctx context.Context, days int) (*models.TelemetryReport, error) {
+	since := time.Now().AddDate(0, 0, -days)
+
+	groups, err := s.adminRepo.GetTelemetryReport(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TelemetryReport{Since: since, Groups: groups}, nil
+}
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+const (
+	sloSourceDatabase   = "database"
+	sloSourcePrometheus = "prometheus"
+)
+
+// sloWindow - скользящее окно; promRange используется в PromQL
+type sloWindow struct {
+	name      string
+	duration  time.Duration
+	promRange string
+}
+
+var sloWindows = []sloWindow{
+	{name: "1h", duration: time.Hour, promRange: "1h"},
+	{name: "24h", duration: 24 * time.Hour, promRange: "24h"},
+	{name: "7d", duration: 7 * 24 * time.Hour, promRange: "7d"},
+}
+
+// sloDefinition описывает одно SLO.
+// Для source=database используется matchThreshold, для source=prometheus - good/total запросы.
+type sloDefinition struct {
+	name        string
+	description string
+	target      float64
+	source      string
+
+	matchThreshold time.Duration
+
+	goodQuery  string // fmt шаблон, %s - диапазон окна
+	totalQuery string
+}
+
+var sloDefinitions = []sloDefinition{
+	{
+		name:           "ride_match_latency",
+		description:    "Rides matched with a driver within 60s of request",
+		target:         0.95,
+		source:         sloSourceDatabase,
+		matchThreshold: 60 * time.Second,
+	},
+	{
+		name:        "ws_delivery_latency",
+		description: "WebSocket messages delivered in under 1s",
+		target:      0.99,
+		source:      sloSourcePrometheus,
+		goodQuery:   `sum(increase(websocket_delivery_duration_seconds_bucket{le="1"}[%s]))`,
+		totalQuery:  `sum(increase(websocket_delivery_duration_seconds_count[%s]))`,
+	},
+	{
+		name:        "api_latency_p99",
+		description: "API requests served in under 300ms (p99)",
+		target:      0.99,
+		source:      sloSourcePrometheus,
+		goodQuery:   `sum(increase(http_request_duration_seconds_bucket{le="0.3",path!~"/ws/.*|/metrics"}[%s]))`,
+		totalQuery:  `sum(increase(http_request_duration_seconds_count{path!~"/ws/.*|/metrics"}[%s]))`,
+	},
+}
+
+// SLO считает SLI по каждому SLO в каждом окне и остаток error budget.
+// Ошибка одного источника не ломает весь отчет - она попадает в поле error окна.
+func (s *AdminService) SLO(ctx context.Context) (*models.SLOReport, error) {
+	ctx = wrap.WithAction(ctx, "admin_slo_report")
+
+	report := &models.SLOReport{
+		Timestamp: time.Now().UTC(),
+		SLOs:      make([]models.SLOStatus, 0, len(sloDefinitions)),
+	}
+
+	for _, def := range sloDefinitions {
+		status := models.SLOStatus{
+			Name:        def.name,
+			Description: def.description,
+			Target:      def.target,
+			Source:      def.source,
+			Windows:     make([]models.SLOWindow, 0, len(sloWindows)),
+		}
+
+		for _, w := range sloWindows {
+			window := models.SLOWindow{Window: w.name}
+
+			good, total, err := s.sliEvents(ctx, def, w)
+			if err != nil {
+				s.l.Warn(ctx, "failed to compute SLI", "slo", def.name, "window", w.name, "error", err.Error())
+				window.Error = err.Error()
+				status.Windows = append(status.Windows, window)
+				continue
+			}
+
+			status.Windows = append(status.Windows, evaluateWindow(window, def.target, good, total))
+		}
+
+		report.SLOs = append(report.SLOs, status)
+	}
+
+	return report, nil
+}
+
+func (s *AdminService) sliEvents(ctx context.Context, def sloDefinition, w sloWindow) (good, total float64, err error) {
+	switch def.source {
+	case sloSourceDatabase:
+		g, t, err := s.adminRepo.GetMatchSLI(ctx, w.duration, def.matchThreshold)
+		if err != nil {
+			return 0, 0, err
+		}
+		return float64(g), float64(t), nil
+	case sloSourcePrometheus:
+		if s.metrics == nil {
+			return 0, 0, fmt.Errorf("metrics source is not configured")
+		}
+		good, err := s.metrics.Query(ctx, fmt.Sprintf(def.goodQuery, w.promRange))
+		if err != nil {
+			return 0, 0, err
+		}
+		total, err := s.metrics.Query(ctx, fmt.Sprintf(def.totalQuery, w.promRange))
+		if err != nil {
+			return 0, 0, err
+		}
+		return good, total, nil
+	default:
+		return 0, 0, fmt.Errorf("unknown SLO source: %s", def.source)
+	}
+}
+
+// evaluateWindow считает SLI и оставшийся error budget.
+// Если событий не было, SLI не определен, бюджет считается нетронутым.
+func evaluateWindow(window models.SLOWindow, target, good, total float64) models.SLOWindow {
+	window.GoodEvents = good
+	window.TotalEvents = total
+
+	if total <= 0 {
+		window.Compliant = true
+		window.ErrorBudgetRemaining = 1
+		return window
+	}
+
+	sli := good / total
+	window.SLI = &sli
+	window.Compliant = sli >= target
+
+	budget := 1 - target
+	if budget <= 0 {
+		if window.Compliant {
+			window.ErrorBudgetRemaining = 1
+		}
+		return window
+	}
+
+	window.ErrorBudgetRemaining = 1 - (1-sli)/budget
+	return window
+}
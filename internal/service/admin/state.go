@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// RideStateAt восстанавливает состояние поездки на момент ts:
+// сворачивает ride_events до ts и берет последнюю позицию водителя из location_history.
+func (s *AdminService) RideStateAt(ctx context.Context, rideID uuid.UUID, ts time.Time) (*models.RideStateAt, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "admin_ride_state_at")
+
+	rideNumber, createdAt, err := s.adminRepo.GetRideNumber(ctx, rideID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	if ts.Before(createdAt) {
+		return nil, wrap.Error(ctx, types.ErrRideNotExistedAt)
+	}
+
+	events, err := s.adminRepo.GetRideEventsUntil(ctx, rideID, ts)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	state := foldRideEvents(events)
+	state.RideID = rideID
+	state.RideNumber = rideNumber
+	state.At = ts
+
+	// Позиция из location_history точнее, чем из событий, поэтому предпочитаем ее
+	if state.DriverID != nil {
+		loc, err := s.adminRepo.GetDriverLocationAt(ctx, *state.DriverID, ts)
+		switch {
+		case err == nil:
+			state.DriverLocation = loc
+		case errors.Is(err, types.ErrDriverLocationNotFound):
+			// оставляем позицию из событий, если она есть
+		default:
+			return nil, wrap.Error(ctx, err)
+		}
+	}
+
+	return state, nil
+}
+
+// RideCommunications возвращает все сообщения, отправленные по поездке, с результатом доставки
+func (s *AdminService) RideCommunications(ctx context.Context, rideID uuid.UUID) (*models.RideCommunications, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "admin_ride_communications")
+
+	rideNumber, _, err := s.adminRepo.GetRideNumber(ctx, rideID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	comms, err := s.comms.ListByRide(ctx, rideID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	return &models.RideCommunications{
+		RideID:         rideID,
+		RideNumber:     rideNumber,
+		Communications: comms,
+	}, nil
+}
+
+// foldRideEvents применяет события по порядку и возвращает итоговое состояние.
+// Неизвестные или битые события пропускаются, чтобы одна запись не ломала всю историю.
+func foldRideEvents(events []models.RideEventRecord) *models.RideStateAt {
+	state := &models.RideStateAt{}
+
+	for _, e := range events {
+		switch e.EventType {
+		case types.EventRideRequested, types.EventDispatchPending, types.EventMatchingDelayed:
+			state.Status = types.StatusRequested.String()
+		case types.EventDriverMatched:
+			var data models.DriverMatchResponse
+			if err := json.Unmarshal(e.EventData, &data); err == nil && data.DriverID != uuid.NilUUID {
+				state.DriverID = &data.DriverID
+				state.DriverLocation = eventLocation(data.DriverLocation, e.CreatedAt)
+			}
+			state.Status = types.StatusMatched.String()
+		case types.EventStatusChanged:
+			var data models.DriverStatusUpdateMessage
+			if err := json.Unmarshal(e.EventData, &data); err == nil && data.DriverID != uuid.NilUUID {
+				state.DriverID = &data.DriverID
+			}
+			state.Status = types.StatusEnRoute.String()
+		case types.EventDriverArrived:
+			state.Status = types.StatusArrived.String()
+		case types.EventRideStarted:
+			state.Status = types.StatusInProgress.String()
+		case types.EventRideCompleted:
+			state.Status = types.StatusCompleted.String()
+		case types.EventRideCancelled:
+			state.Status = types.StatusCancelled.String()
+		case types.EventLocationUpdated:
+			var data models.PassengerLocationUpdateDTO
+			if err := json.Unmarshal(e.EventData, &data); err == nil {
+				state.DriverLocation = eventLocation(data.DriverLocation, e.CreatedAt)
+			}
+		case types.EventFareAdjusted:
+			// пишется driver сервисом при завершении поездки (drivergo.CompleteRideData без json тегов)
+			var data struct {
+				DriverID uuid.UUID
+				Location models.Location
+			}
+			if err := json.Unmarshal(e.EventData, &data); err == nil {
+				state.DriverLocation = eventLocation(data.Location, e.CreatedAt)
+			}
+		default:
+			continue
+		}
+
+		createdAt := e.CreatedAt
+		state.LastEventType = e.EventType.String()
+		state.LastEventAt = &createdAt
+		state.EventsApplied++
+	}
+
+	return state
+}
+
+func eventLocation(location models.Location, at time.Time) *models.LocationRecord {
+	if location.Latitude == 0 && location.Longitude == 0 {
+		return nil
+	}
+	return &models.LocationRecord{
+		Location:   location,
+		RecordedAt: at,
+		Source:     "ride_event",
+	}
+}
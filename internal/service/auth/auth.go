@@ -5,28 +5,47 @@ import (
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
 	"github.com/Temutjin2k/ride-hail-system/pkg/hasher"
+	"github.com/Temutjin2k/ride-hail-system/pkg/locsign"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
 type AuthService struct {
 	userRepo     UserRepo
 	tokenService TokenProvider
-	log          logger.Logger
+	deviceRepo   DeviceRepo
+
+	// подтверждение телефона, phoneCodes и sms нужны только auth-service
+	phoneCodes PhoneCodeRepo
+	sms        SMSSender // nil — SMS провайдер не подключен
+	phone      PhoneVerificationOptions
+
+	trm   trm.TxManager
+	clock clock.Clock
+	log   logger.Logger
 }
 
-func NewAuthService(UserDal UserRepo, TokenServ TokenProvider, log logger.Logger) *AuthService {
+func NewAuthService(UserDal UserRepo, TokenServ TokenProvider, deviceRepo DeviceRepo, phoneCodes PhoneCodeRepo, sms SMSSender, phone PhoneVerificationOptions, trm trm.TxManager, clk clock.Clock, log logger.Logger) *AuthService {
 	return &AuthService{
 		userRepo:     UserDal,
 		tokenService: TokenServ,
+		deviceRepo:   deviceRepo,
+		phoneCodes:   phoneCodes,
+		sms:          sms,
+		phone:        phone,
+		trm:          trm,
+		clock:        clk,
 		log:          log,
 	}
 }
 
 // Returns (AccessToken, RefreshToken, statusCode, error message)
-func (s *AuthService) Login(ctx context.Context, email, password string) (*models.TokenPair, error) {
+// Водитель, указавший deviceID, дополнительно получает секрет устройства для подписи координат.
+func (s *AuthService) Login(ctx context.Context, email, password, deviceID string) (*models.TokenPair, error) {
 	// Проверяем существует ли пользователь
 	user, err := s.userRepo.GetUser(ctx, email)
 	if err != nil {
@@ -48,6 +67,17 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*model
 		return nil, ErrTokenGenerateFail
 	}
 
+	if deviceID != "" && user.Role == types.RoleDriver.String() {
+		secret, err := locsign.NewSecret()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.deviceRepo.SaveSecret(ctx, user.ID, deviceID, secret); err != nil {
+			return nil, err
+		}
+		tokens.DeviceSecret = secret
+	}
+
 	return tokens, nil
 }
 
@@ -98,6 +128,15 @@ func (s *AuthService) RoleCheck(ctx context.Context, token string) (*models.User
 		return nil, wrap.Error(ctx, ErrInvalidToken)
 	}
 
+	// токен мог быть отозван выходом до истечения срока
+	revoked, err := s.tokenService.IsRevoked(ctx, claim)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	if revoked {
+		return nil, wrap.Error(ctx, ErrRevokedToken)
+	}
+
 	// Проверяем существует ли пользователь
 	user, err := s.userRepo.GetUser(ctx, claim.Email)
 	if err != nil {
@@ -114,3 +153,39 @@ func (s *AuthService) RoleCheck(ctx context.Context, token string) (*models.User
 func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
 	return s.tokenService.Refresh(ctx, refreshToken)
 }
+
+// Logout завершает текущую сессию: отзывает access токен запроса и переданный refresh токен
+func (s *AuthService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	ctx = wrap.WithAction(ctx, "logout")
+
+	claim, err := s.accessClaims(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return s.tokenService.Revoke(ctx, claim, refreshToken)
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах
+func (s *AuthService) LogoutAll(ctx context.Context, accessToken string) error {
+	ctx = wrap.WithAction(ctx, "logout_all")
+
+	claim, err := s.accessClaims(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return s.tokenService.RevokeAll(ctx, claim.UserID)
+}
+
+func (s *AuthService) accessClaims(ctx context.Context, accessToken string) (*models.CustomClaims, error) {
+	claim, err := s.tokenService.Validate(ctx, accessToken)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	if claim.TokenType != models.AccessToken {
+		return nil, wrap.Error(ctx, ErrInvalidToken)
+	}
+	return claim, nil
+}
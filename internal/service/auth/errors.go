@@ -10,6 +10,7 @@ var (
 	ErrCannotCreateAdmin     = errors.New("cannot create admin via API")
 	ErrInvalidToken          = errors.New("invalid token")
 	ErrExpToken              = errors.New("expired token")
+	ErrRevokedToken          = errors.New("token has been revoked")
 	ErrUserWithEmailNotFound = errors.New("user with this email not found")
 	ErrActionForbidden       = errors.New("action forbidden")
 	ErrUnauthorized          = errors.New("unauthorized")
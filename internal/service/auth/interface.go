@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
@@ -11,16 +12,57 @@ type UserRepo interface {
 	CreateUser(ctx context.Context, user *models.User) (uuid.UUID, error)
 	GetUser(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	UpdateAttrs(ctx context.Context, userID uuid.UUID, attrs map[string]any) error
+	// UpdatePhone сохраняет телефон и сбрасывает подтверждение, если номер изменился
+	UpdatePhone(ctx context.Context, userID uuid.UUID, phone string) error
+	SetPhoneVerified(ctx context.Context, userID uuid.UUID, at *time.Time) error
 }
 
 type TokenProvider interface {
 	GenerateTokens(ctx context.Context, user *models.User) (*models.TokenPair, error)
 	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
 	Validate(ctx context.Context, token string) (*models.CustomClaims, error)
+	Revoke(ctx context.Context, access *models.CustomClaims, refreshToken string) error
+	RevokeAll(ctx context.Context, userID uuid.UUID) error
+	IsRevoked(ctx context.Context, access *models.CustomClaims) (bool, error)
 }
 
 type RefreshTokenRepo interface {
 	Save(ctx context.Context, record *models.RefreshTokenRecord) error
 	Get(ctx context.Context, tokenID uuid.UUID) (*models.RefreshTokenRecord, error)
 	MarkUsed(ctx context.Context, tokenID uuid.UUID) error
+	Revoke(ctx context.Context, tokenID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// AccessTokenDenylist хранит access токены, отозванные выходом до истечения срока
+type AccessTokenDenylist interface {
+	Revoke(ctx context.Context, tokenID, userID uuid.UUID, expiresAt time.Time) error
+	RevokeAllBefore(ctx context.Context, userID uuid.UUID, before time.Time) error
+	IsRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error)
+	// RevokedBefore возвращает границу выхода пользователя со всех устройств, нулевое время — выхода не было
+	RevokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error)
+}
+
+// EventPublisher рассылает событие сброса кэша всем экземплярам (pkg/invalidation)
+type EventPublisher interface {
+	Publish(ctx context.Context, cache, key string) error
+}
+
+type DeviceRepo interface {
+	SaveSecret(ctx context.Context, driverID uuid.UUID, deviceID, secret string) error
+}
+
+// PhoneCodeRepo хранит последний код подтверждения телефона пользователя
+type PhoneCodeRepo interface {
+	Save(ctx context.Context, c *models.PhoneCode) error
+	Get(ctx context.Context, userID uuid.UUID) (*models.PhoneCode, error)
+	// Attempt учитывает попытку ввода, nil — код не запрашивался
+	Attempt(ctx context.Context, userID uuid.UUID) (*models.PhoneCode, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+// SMSSender отправляет код подтверждения телефона
+type SMSSender interface {
+	SendSMS(ctx context.Context, phone, text string) error
 }
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"math/big"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/hasher"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// phoneCodeDigits — длина одноразового кода
+const phoneCodeDigits = 6
+
+// PhoneVerificationOptions — срок жизни кода, пауза перед повторной отправкой и число попыток ввода
+type PhoneVerificationOptions struct {
+	CodeTTL        time.Duration
+	ResendInterval time.Duration
+	MaxAttempts    int
+}
+
+// UpdateProfile меняет имя, телефон и attrs пользователя и возвращает обновленный профиль.
+// Новый телефон нужно подтвердить заново.
+func (s *AuthService) UpdateProfile(ctx context.Context, userID uuid.UUID, upd models.ProfileUpdate) (*models.User, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "update_profile", UserID: userID.String()})
+
+	var updated *models.User
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		attrs := maps.Clone(upd.Attrs)
+		if attrs == nil {
+			attrs = make(map[string]any)
+		}
+		if upd.Name != nil {
+			attrs["name"] = *upd.Name
+		}
+		if len(attrs) > 0 {
+			if err := s.userRepo.UpdateAttrs(ctx, userID, attrs); err != nil {
+				return err
+			}
+		}
+
+		// код, отправленный на прежний номер, к новому не подходит
+		if upd.Phone != nil {
+			if err := s.userRepo.UpdatePhone(ctx, userID, *upd.Phone); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		updated, err = s.user(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.log.Info(ctx, "profile updated")
+	return updated, nil
+}
+
+// RequestPhoneVerification отправляет по SMS одноразовый код на телефон из профиля.
+// Предыдущий код перестает действовать.
+func (s *AuthService) RequestPhoneVerification(ctx context.Context, userID uuid.UUID) (*models.PhoneVerification, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "request_phone_verification", UserID: userID.String()})
+
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	phone := userPhone(user)
+	switch {
+	case phone == "":
+		return nil, wrap.Error(ctx, types.ErrPhoneNotSet)
+	case user.PhoneVerifiedAt != nil:
+		return nil, wrap.Error(ctx, types.ErrPhoneAlreadyVerified)
+	case s.sms == nil:
+		return nil, wrap.Error(ctx, types.ErrSMSUnavailable)
+	}
+
+	prev, err := s.phoneCodes.Get(ctx, userID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	now := s.clock.Now()
+	if prev != nil && now.Before(prev.CreatedAt.Add(s.phone.ResendInterval)) {
+		return nil, wrap.Error(ctx, types.ErrPhoneCodeTooSoon)
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	c := &models.PhoneCode{
+		UserID:    userID,
+		CodeHash:  phoneCodeHash(userID, phone, code),
+		ExpiresAt: now.Add(s.phone.CodeTTL),
+	}
+	if err := s.phoneCodes.Save(ctx, c); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	text := fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.phone.CodeTTL)
+	if err := s.sms.SendSMS(ctx, phone, text); err != nil {
+		// код не дошел — не заставляем ждать повторной отправки
+		if err := s.phoneCodes.Delete(ctx, userID); err != nil {
+			s.log.Warn(ctx, "failed to delete undelivered phone code", "error", err.Error())
+		}
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to send verification code: %w", err))
+	}
+
+	s.log.Info(ctx, "phone verification code sent", "expires_at", c.ExpiresAt)
+	return &models.PhoneVerification{
+		ExpiresAt:   c.ExpiresAt,
+		ResendAfter: c.CreatedAt.Add(s.phone.ResendInterval),
+	}, nil
+}
+
+// VerifyPhone подтверждает телефон из профиля кодом из SMS.
+// Код действует до истечения срока и MaxAttempts попыток ввода.
+func (s *AuthService) VerifyPhone(ctx context.Context, userID uuid.UUID, code string) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "verify_phone", UserID: userID.String()})
+
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+	if user.PhoneVerifiedAt != nil {
+		return wrap.Error(ctx, types.ErrPhoneAlreadyVerified)
+	}
+
+	c, err := s.phoneCodes.Attempt(ctx, userID)
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	now := s.clock.Now()
+	switch {
+	case c == nil:
+		return wrap.Error(ctx, types.ErrPhoneCodeNotRequested)
+	case c.Attempts > s.phone.MaxAttempts:
+		return wrap.Error(ctx, types.ErrPhoneCodeAttemptsExceeded)
+	case now.After(c.ExpiresAt):
+		return wrap.Error(ctx, types.ErrPhoneCodeExpired)
+	case !hasher.Verify(phoneCodeInput(userID, userPhone(user), code), c.CodeHash):
+		s.log.Info(ctx, "invalid phone verification code", "attempts", c.Attempts)
+		return wrap.Error(ctx, types.ErrInvalidPhoneCode)
+	}
+
+	err = s.trm.Do(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.SetPhoneVerified(ctx, userID, &now); err != nil {
+			return err
+		}
+		return s.phoneCodes.Delete(ctx, userID)
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.log.Info(ctx, "phone verified")
+	return nil
+}
+
+func (s *AuthService) user(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, types.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func userPhone(user *models.User) string {
+	phone, _ := user.Attrs["phone"].(string)
+	return phone
+}
+
+// newPhoneCode возвращает случайный код из phoneCodeDigits цифр
+func newPhoneCode() (string, error) {
+	limit := big.NewInt(1)
+	for range phoneCodeDigits {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", phoneCodeDigits, n), nil
+}
+
+// phoneCodeInput привязывает код к пользователю и телефону: после смены телефона код не подходит
+func phoneCodeInput(userID uuid.UUID, phone, code string) string {
+	return userID.String() + ":" + phone + ":" + code
+}
+
+func phoneCodeHash(userID uuid.UUID, phone, code string) string {
+	return hasher.Hash(phoneCodeInput(userID, phone, code))
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// cutoffCacheTTL — сколько граница выхода со всех устройств хранится в кэше.
+// Отзыв приходит событием invalidation, TTL страхует от потерянных событий.
+const cutoffCacheTTL = time.Minute
+
+type cutoffEntry struct {
+	before    time.Time // нулевое — выхода со всех устройств не было
+	loaded    bool      // false — запись сброшена
+	expiresAt time.Time
+	version   uint64
+}
+
+// cutoffCache хранит границы выхода со всех устройств: граница проверяется на каждом запросе
+// с access токеном, а меняется только при выходе пользователя
+type cutoffCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]cutoffEntry
+	// version растет при каждом сбросе и сохранении: чтение, начатое до сброса, не вернет старую границу в кэш
+	version uint64
+	// epoch растет при сбросе всего кэша
+	epoch   uint64
+	sweepAt time.Time
+}
+
+// revokedBefore возвращает границу выхода пользователя со всех устройств из кэша или из БД
+func (s *TokenService) revokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	c := &s.cutoffs
+	now := s.clock.Now()
+
+	c.mu.Lock()
+	entry, epoch := c.entries[userID], c.epoch
+	c.mu.Unlock()
+
+	if entry.loaded && now.Before(entry.expiresAt) {
+		return entry.before, nil
+	}
+
+	before, err := s.denylist.RevokedBefore(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.epoch == epoch && c.entries[userID].version == entry.version {
+		c.sweepLocked(now)
+		c.version++
+		c.entries[userID] = cutoffEntry{before: before, loaded: true, expiresAt: now.Add(cutoffCacheTTL), version: c.version}
+	}
+	return before, nil
+}
+
+// sweepLocked раз в TTL удаляет истекшие записи пользователей, которые больше не заходили
+func (c *cutoffCache) sweepLocked(now time.Time) {
+	if now.Before(c.sweepAt) {
+		return
+	}
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	c.sweepAt = now.Add(cutoffCacheTTL)
+}
+
+// forget сбрасывает границу пользователя. Сброшенная запись хранится до конца TTL с новой версией,
+// иначе начатое до сброса чтение вернет старую границу в кэш.
+func (s *TokenService) forget(userID uuid.UUID) {
+	c := &s.cutoffs
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.entries[userID] = cutoffEntry{expiresAt: s.clock.Now().Add(cutoffCacheTTL), version: c.version}
+}
+
+// Invalidate сбрасывает границу пользователя из ключа события, пустой ключ — весь кэш
+func (s *TokenService) Invalidate(_ context.Context, e invalidation.Event) {
+	if e.Key == "" {
+		c := &s.cutoffs
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.epoch++
+		c.entries = make(map[uuid.UUID]cutoffEntry)
+		return
+	}
+
+	userID, err := uuid.Parse(e.Key)
+	if err != nil {
+		return
+	}
+	s.forget(userID)
+}
+
+// revoked сбрасывает границу пользователя на этом экземпляре и рассылает отзыв остальным:
+// они сбрасывают свой кэш и закрывают WebSocket соединения пользователя.
+// Ошибка рассылки только логируется: токены уже отозваны, устаревшую границу вытеснит TTL.
+func (s *TokenService) revoked(ctx context.Context, userID uuid.UUID) {
+	s.forget(userID)
+
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, types.CacheAccessTokens, userID.String()); err != nil {
+		s.log.Warn(ctx, "failed to publish cache invalidation", "cache", types.CacheAccessTokens, "error", err)
+	}
+}
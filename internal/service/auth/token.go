@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
 	"github.com/Temutjin2k/ride-hail-system/pkg/hasher"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
@@ -19,31 +21,86 @@ import (
 type TokenService struct {
 	userRepo    UserRepo
 	refreshRepo RefreshTokenRepo
+	denylist    AccessTokenDenylist // nil — отзыв access токенов не проверяется
+	events      EventPublisher      // nil — отзыв не рассылается другим экземплярам
 	txManager   trm.TxManager
 
 	refreshTTL time.Duration
 	accessTTL  time.Duration
-	secret     string
 
-	log logger.Logger
+	// ключ подписи. Новый ключ после ротации сначала только проверяет подписи, пока его не получат
+	// остальные сервисы, прежний проверяет выданные им токены, пока они не истекут.
+	keyMu         sync.Mutex
+	secret        string
+	pending       string
+	pendingFrom   time.Time
+	previous      string
+	previousUntil time.Time
+
+	cutoffs cutoffCache
+
+	clock clock.Clock
+	log   logger.Logger
 }
 
-func NewTokenService(secret string, userRepo UserRepo, refreshRepo RefreshTokenRepo, txManager trm.TxManager, RefreshTTL time.Duration, AccessTTL time.Duration, log logger.Logger) *TokenService {
+func NewTokenService(secret string, userRepo UserRepo, refreshRepo RefreshTokenRepo, denylist AccessTokenDenylist, events EventPublisher, txManager trm.TxManager, RefreshTTL time.Duration, AccessTTL time.Duration, clk clock.Clock, log logger.Logger) *TokenService {
 	return &TokenService{
 		userRepo:    userRepo,
 		refreshRepo: refreshRepo,
+		denylist:    denylist,
+		events:      events,
 		txManager:   txManager,
 		refreshTTL:  RefreshTTL,
 		accessTTL:   AccessTTL,
 		secret:      secret,
+		cutoffs:     cutoffCache{entries: make(map[uuid.UUID]cutoffEntry)},
+		clock:       clk,
 		log:         log,
 	}
 }
 
 func (s *TokenService) getSecret() string {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	if s.pending != "" && !s.clock.Now().Before(s.pendingFrom) {
+		s.previous, s.previousUntil = s.secret, s.clock.Now().Add(max(s.refreshTTL, s.accessTTL))
+		s.secret, s.pending = s.pending, ""
+	}
 	return s.secret
 }
 
+// verificationKeys возвращает ключи проверки подписи: текущий, новый и прежний до истечения его токенов
+func (s *TokenService) verificationKeys() jwt.VerificationKeySet {
+	current := s.getSecret()
+
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{[]byte(current)}}
+	if s.pending != "" {
+		keys.Keys = append(keys.Keys, []byte(s.pending))
+	}
+	if s.previous != "" && s.clock.Now().Before(s.previousUntil) {
+		keys.Keys = append(keys.Keys, []byte(s.previous))
+	}
+	return keys
+}
+
+// RotateSecret переводит подпись токенов на новый ключ через signAfter: к этому времени его
+// прочитают остальные сервисы, и токены нового ключа нигде не будут отклонены.
+// Токены прежнего ключа принимаются, пока не истекут (refresh TTL), входить заново не нужно.
+func (s *TokenService) RotateSecret(secret string, signAfter time.Duration) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	if secret == "" || secret == s.secret {
+		s.pending = ""
+		return
+	}
+	s.pending, s.pendingFrom = secret, s.clock.Now().Add(signAfter)
+}
+
 // GenerateTokens creates a new pair of access and refresh tokens for the given user.
 // The refresh token is stored in the database
 // along with its hash, expiration time, and associated user ID.
@@ -53,7 +110,7 @@ func (s *TokenService) GenerateTokens(ctx context.Context, user *models.User) (*
 		return nil, wrap.Error(ctx, errors.New("user is nil"))
 	}
 
-	issuedAt := time.Now().UTC()
+	issuedAt := s.clock.Now().UTC()
 	accessID := uuid.New()
 	refreshID := uuid.New()
 
@@ -127,7 +184,7 @@ func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*model
 			return ErrInvalidToken
 		}
 
-		now := time.Now().UTC()
+		now := s.clock.Now().UTC()
 		if now.After(record.ExpiresAt) {
 			if err := s.refreshRepo.MarkUsed(txCtx, record.ID); err != nil {
 				return fmt.Errorf("failed to revoke expired refresh token: %w", err)
@@ -180,7 +237,7 @@ func (s *TokenService) Validate(ctx context.Context, token string) (*models.Cust
 		if t.Method != jwt.SigningMethodHS256 {
 			return nil, ErrInvalidToken
 		}
-		return []byte(s.getSecret()), nil
+		return s.verificationKeys(), nil
 	})
 	if err != nil || !parsedToken.Valid {
 		return nil, wrap.Error(ctx, ErrInvalidToken)
@@ -223,10 +280,16 @@ func (s *TokenService) Validate(ctx context.Context, token string) (*models.Cust
 	}
 
 	expTime := time.Unix(int64(expFloat), 0)
-	if time.Now().UTC().After(expTime) {
+	if s.clock.Now().UTC().After(expTime) {
 		return nil, wrap.Error(ctx, ErrExpToken)
 	}
 
+	// iat нужен для проверки выхода со всех устройств, в токенах без него он считается нулевым
+	var issuedAt time.Time
+	if iatFloat, ok := mc["iat"].(float64); ok {
+		issuedAt = time.Unix(int64(iatFloat), 0)
+	}
+
 	claims := &models.CustomClaims{
 		UserID:    userID,
 		TokenID:   tokenID,
@@ -235,12 +298,113 @@ func (s *TokenService) Validate(ctx context.Context, token string) (*models.Cust
 		Role:      role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expTime),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
 		},
 	}
 
 	return claims, nil
 }
 
+// Revoke завершает сессию: access токен запрещается до истечения срока, refresh токен больше не обменивается.
+// Пустой refreshToken — отзывается только access токен. Refresh токен другого пользователя не принимается.
+func (s *TokenService) Revoke(ctx context.Context, access *models.CustomClaims, refreshToken string) error {
+	ctx = wrap.WithAction(wrap.WithUserID(ctx, access.UserID.String()), "revoke_tokens")
+
+	var refreshID uuid.UUID
+	if refreshToken != "" {
+		claims, err := s.Validate(ctx, refreshToken)
+		if err != nil && !errors.Is(err, ErrExpToken) {
+			return wrap.Error(ctx, ErrInvalidToken)
+		}
+		// истекший refresh токен обменять уже нельзя, отзывать нечего
+		if err == nil {
+			if claims.TokenType != models.RefreshToken || claims.UserID != access.UserID {
+				return wrap.Error(ctx, ErrInvalidToken)
+			}
+			refreshID = claims.TokenID
+		}
+	}
+
+	err := s.txManager.Do(ctx, func(ctx context.Context) error {
+		if refreshID != uuid.NilUUID {
+			record, err := s.refreshRepo.Get(ctx, refreshID)
+			if err != nil {
+				return fmt.Errorf("failed to load refresh token record: %w", err)
+			}
+			if record == nil || record.TokenHash != hasher.Hash(refreshToken) {
+				return ErrInvalidToken
+			}
+			if err := s.refreshRepo.Revoke(ctx, refreshID); err != nil {
+				return fmt.Errorf("failed to revoke refresh token: %w", err)
+			}
+		}
+
+		if s.denylist == nil {
+			return nil
+		}
+		return s.denylist.Revoke(ctx, access.TokenID, access.UserID, access.ExpiresAt.Time)
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.revoked(ctx, access.UserID)
+	return nil
+}
+
+// RevokeAll завершает все сессии пользователя: отзываются его refresh токены
+// и запрещаются access токены, выданные до этого момента.
+// Время выдачи в токене хранится с точностью до секунды, поэтому граница округляется вниз до секунды:
+// токен, выданный сразу после выхода, остается действительным.
+func (s *TokenService) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	ctx = wrap.WithAction(wrap.WithUserID(ctx, userID.String()), "revoke_all_tokens")
+
+	before := s.clock.Now().Truncate(time.Second)
+	var revoked int64
+	err := s.txManager.Do(ctx, func(ctx context.Context) error {
+		var err error
+		revoked, err = s.refreshRepo.RevokeAllForUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+
+		if s.denylist == nil {
+			return nil
+		}
+		return s.denylist.RevokeAllBefore(ctx, userID, before)
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.revoked(ctx, userID)
+
+	s.log.Info(ctx, "all user tokens revoked", "refresh_tokens", revoked)
+	return nil
+}
+
+// IsRevoked сообщает, отозван ли access токен выходом из сессии или со всех устройств.
+// Граница выхода со всех устройств берется из кэша, отзыв сбрасывает ее на всех экземплярах.
+func (s *TokenService) IsRevoked(ctx context.Context, access *models.CustomClaims) (bool, error) {
+	if s.denylist == nil {
+		return false, nil
+	}
+
+	var issuedAt time.Time
+	if access.IssuedAt != nil {
+		issuedAt = access.IssuedAt.Time
+	}
+
+	before, err := s.revokedBefore(ctx, access.UserID)
+	if err != nil {
+		return false, err
+	}
+	if before.After(issuedAt) {
+		return true, nil
+	}
+	return s.denylist.IsRevoked(ctx, access.TokenID)
+}
+
 func (s *TokenService) signClaims(claims jwt.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.getSecret()))
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type inlineTxManager struct{}
+
+func (inlineTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// memRefreshTokens — refresh токены в памяти, общие для всех экземпляров
+type memRefreshTokens struct {
+	RefreshTokenRepo
+	records map[uuid.UUID]*models.RefreshTokenRecord
+}
+
+func (r *memRefreshTokens) Save(_ context.Context, record *models.RefreshTokenRecord) error {
+	r.records[record.ID] = record
+	return nil
+}
+
+func (r *memRefreshTokens) Get(_ context.Context, tokenID uuid.UUID) (*models.RefreshTokenRecord, error) {
+	return r.records[tokenID], nil
+}
+
+func (r *memRefreshTokens) Revoke(_ context.Context, tokenID uuid.UUID) error {
+	r.records[tokenID].Revoked = true
+	return nil
+}
+
+func (r *memRefreshTokens) RevokeAllForUser(_ context.Context, userID uuid.UUID) (int64, error) {
+	var n int64
+	for _, record := range r.records {
+		if record.UserID == userID && !record.Revoked {
+			record.Revoked = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memDenylist — отозванные токены и границы выхода в памяти, общие для всех экземпляров.
+// cutoffReads считает чтения границы, то есть промахи кэша.
+type memDenylist struct {
+	revoked     map[uuid.UUID]bool
+	cutoffs     map[uuid.UUID]time.Time
+	cutoffReads int
+}
+
+func newMemDenylist() *memDenylist {
+	return &memDenylist{revoked: make(map[uuid.UUID]bool), cutoffs: make(map[uuid.UUID]time.Time)}
+}
+
+func (d *memDenylist) Revoke(_ context.Context, tokenID, _ uuid.UUID, _ time.Time) error {
+	d.revoked[tokenID] = true
+	return nil
+}
+
+func (d *memDenylist) RevokeAllBefore(_ context.Context, userID uuid.UUID, before time.Time) error {
+	if before.After(d.cutoffs[userID]) {
+		d.cutoffs[userID] = before
+	}
+	return nil
+}
+
+func (d *memDenylist) IsRevoked(_ context.Context, tokenID uuid.UUID) (bool, error) {
+	return d.revoked[tokenID], nil
+}
+
+func (d *memDenylist) RevokedBefore(_ context.Context, userID uuid.UUID) (time.Time, error) {
+	d.cutoffReads++
+	return d.cutoffs[userID], nil
+}
+
+// notifyBus доставляет события всем экземплярам, как LISTEN/NOTIFY
+type notifyBus struct {
+	instances []*TokenService
+	published []string
+}
+
+func (b *notifyBus) Publish(ctx context.Context, cache, key string) error {
+	b.published = append(b.published, key)
+	for _, s := range b.instances {
+		if cache == types.CacheAccessTokens {
+			s.Invalidate(ctx, invalidation.Event{Cache: cache, Key: key})
+		}
+	}
+	return nil
+}
+
+// tokenCluster — экземпляры сервисов с общей БД и шиной
+type tokenCluster struct {
+	instances []*TokenService
+	denylist  *memDenylist
+	bus       *notifyBus
+	clock     *clock.Fake
+}
+
+func newTokenCluster(n int, now time.Time) *tokenCluster {
+	c := &tokenCluster{denylist: newMemDenylist(), bus: &notifyBus{}, clock: clock.NewFake(now)}
+	refresh := &memRefreshTokens{records: make(map[uuid.UUID]*models.RefreshTokenRecord)}
+	for range n {
+		s := NewTokenService("secret", nil, refresh, c.denylist, c.bus, inlineTxManager{}, time.Hour, 15*time.Minute, c.clock, logger.InitLogger("test", "error"))
+		c.instances = append(c.instances, s)
+		c.bus.instances = append(c.bus.instances, s)
+	}
+	return c
+}
+
+// login выдает пару токенов на экземпляре i и возвращает claims access токена
+func (c *tokenCluster) login(t *testing.T, i int, user *models.User) (*models.CustomClaims, string) {
+	t.Helper()
+	pair, err := c.instances[i].GenerateTokens(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := c.instances[i].Validate(context.Background(), pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims, pair.RefreshToken
+}
+
+func (c *tokenCluster) isRevoked(t *testing.T, i int, claims *models.CustomClaims) bool {
+	t.Helper()
+	revoked, err := c.instances[i].IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return revoked
+}
+
+// Выход отзывает только токены своей сессии, refresh токен больше не обменивается
+func TestRevoke_Logout(t *testing.T) {
+	c := newTokenCluster(2, time.Now())
+	user := &models.User{ID: uuid.New(), Email: "a@example.com"}
+	phone, phoneRefresh := c.login(t, 0, user)
+	laptop, _ := c.login(t, 0, user)
+
+	if err := c.instances[0].Revoke(context.Background(), phone, phoneRefresh); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range c.instances {
+		if !c.isRevoked(t, i, phone) {
+			t.Fatalf("instance %d: logged out token must be revoked", i)
+		}
+		if c.isRevoked(t, i, laptop) {
+			t.Fatalf("instance %d: token of another session must stay valid", i)
+		}
+	}
+	if _, err := c.instances[1].Refresh(context.Background(), phoneRefresh); err == nil {
+		t.Fatal("revoked refresh token must not be exchanged")
+	}
+	if len(c.bus.published) != 1 || c.bus.published[0] != user.ID.String() {
+		t.Fatalf("published %v, want revocation of %s", c.bus.published, user.ID)
+	}
+}
+
+// Выход со всех устройств отзывает токены на всех экземплярах, хотя граница уже была в их кэше
+func TestRevokeAll_InvalidatesCachedCutoff(t *testing.T) {
+	c := newTokenCluster(2, time.Now())
+	user := &models.User{ID: uuid.New(), Email: "a@example.com"}
+	claims, _ := c.login(t, 0, user)
+
+	for i := range c.instances {
+		if c.isRevoked(t, i, claims) {
+			t.Fatalf("instance %d: token must be valid before logout", i)
+		}
+	}
+	c.isRevoked(t, 1, claims)
+	if c.denylist.cutoffReads != 2 {
+		t.Fatalf("cutoff read %d times, want once per instance", c.denylist.cutoffReads)
+	}
+
+	c.clock.Advance(2 * time.Second)
+	if err := c.instances[0].RevokeAll(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range c.instances {
+		if !c.isRevoked(t, i, claims) {
+			t.Fatalf("instance %d: token issued before logout must be revoked", i)
+		}
+	}
+	fresh, _ := c.login(t, 1, user)
+	if c.isRevoked(t, 0, fresh) {
+		t.Fatal("token issued after logout must be valid")
+	}
+}
+
+// Граница выхода округляется вниз до секунды, как iat в токене
+func TestRevokeAll_SecondTruncatedCutoff(t *testing.T) {
+	// подпись токена проверяется по настоящему времени, поэтому время выхода — текущая секунда
+	logout := time.Now().Truncate(time.Second).Add(700 * time.Millisecond)
+	user := &models.User{ID: uuid.New(), Email: "a@example.com"}
+
+	tests := []struct {
+		name        string
+		issuedAt    time.Time
+		wantRevoked bool
+	}{
+		{"issued in an earlier second", logout.Add(-time.Second), true},
+		{"issued earlier in the same second", logout.Add(-500 * time.Millisecond), false},
+		{"issued later in the same second", logout.Add(200 * time.Millisecond), false},
+		{"issued in a later second", logout.Add(time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTokenCluster(1, tt.issuedAt)
+			claims, _ := c.login(t, 0, user)
+
+			c.clock.Set(logout)
+			if err := c.instances[0].RevokeAll(context.Background(), user.ID); err != nil {
+				t.Fatal(err)
+			}
+			if want := logout.Truncate(time.Second); !c.denylist.cutoffs[user.ID].Equal(want) {
+				t.Fatalf("cutoff %v, want %v", c.denylist.cutoffs[user.ID], want)
+			}
+			if got := c.isRevoked(t, 0, claims); got != tt.wantRevoked {
+				t.Fatalf("revoked %v, want %v", got, tt.wantRevoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package broadcast доставляет объявления администратора в WebSocket соединения сервиса.
+// Ride-service доставляет пассажирам, driver-service — водителям.
+//
+// Объявление из очереди получает один экземпляр: он записывает его во входящие получателей
+// и будит все экземпляры через шину pkg/invalidation. Каждый экземпляр отправляет объявление
+// своим подключенным получателям, поэтому доставка и счетчики не зависят от того,
+// к какому экземпляру подключен пользователь.
+package broadcast
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// storedTTL — объявления старше не досылаются при подключении: они уже неактуальны
+const storedTTL = 7 * 24 * time.Hour
+
+type (
+	// Repo хранит входящие объявлений: записи получателей, которым объявление еще не доставлено
+	Repo interface {
+		StoreRecipients(ctx context.Context, id uuid.UUID, role types.UserRole, city string) (int, error)
+		Undelivered(ctx context.Context, id uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error)
+		Get(ctx context.Context, id uuid.UUID) (*models.Broadcast, error)
+		ListStored(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Broadcast, error)
+		MarkDelivered(ctx context.Context, broadcastIDs, userIDs []uuid.UUID) error
+	}
+
+	// Sender отправляет сообщения только в открытые соединения экземпляра, ничего не буферизуя
+	Sender interface {
+		Online() []uuid.UUID
+		SendOnline(id uuid.UUID, msg any) error
+	}
+
+	// EventPublisher — шина pkg/invalidation, будит все экземпляры сервиса
+	EventPublisher interface {
+		Publish(ctx context.Context, cache, key string) error
+	}
+)
+
+type Deliverer struct {
+	role   types.UserRole
+	repo   Repo
+	hub    Sender
+	events EventPublisher
+
+	l logger.Logger
+}
+
+func New(role types.UserRole, repo Repo, hub Sender, events EventPublisher, l logger.Logger) *Deliverer {
+	return &Deliverer{
+		role:   role,
+		repo:   repo,
+		hub:    hub,
+		events: events,
+		l:      l,
+	}
+}
+
+// Deliver записывает объявление во входящие всех получателей своей роли и будит все экземпляры
+// сервиса, чтобы каждый отправил его своим подключенным (Notified). Остальные получат его
+// из входящих при подключении (DeliverStored), поэтому память сервиса не растет с числом
+// зарегистрированных пользователей.
+func (d *Deliverer) Deliver(ctx context.Context, msg models.BroadcastMessage) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "deliver_broadcast"})
+
+	if !slices.Contains(msg.Roles, d.role) {
+		return nil
+	}
+
+	stored, err := d.repo.StoreRecipients(ctx, msg.BroadcastID, d.role, msg.City)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to store broadcast recipients: %w", err))
+	}
+
+	if err := d.events.Publish(ctx, types.CacheBroadcasts, d.role.String()+":"+msg.BroadcastID.String()); err != nil {
+		// входящие уже записаны: подключенные получат объявление при переподключении
+		return wrap.Error(ctx, fmt.Errorf("failed to notify instances about broadcast: %w", err))
+	}
+
+	d.l.Info(ctx, "broadcast stored", "broadcast_id", msg.BroadcastID, "role", d.role, "stored", stored)
+	return nil
+}
+
+// Notified отправляет новое объявление получателям, подключенным к этому экземпляру.
+// Событие без ключа — слушатель шины переподключился и мог пропустить объявления:
+// всем подключенным досылаются их входящие.
+func (d *Deliverer) Notified(ctx context.Context, e invalidation.Event) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "deliver_broadcast_online"})
+
+	role, id, ok := strings.Cut(e.Key, ":")
+	if !ok {
+		// по запросу на каждого подключенного: не задерживаем остальные события шины
+		go func(online []uuid.UUID) {
+			for _, userID := range online {
+				d.DeliverStored(userID)
+			}
+		}(d.hub.Online())
+		return
+	}
+	if role != d.role.String() {
+		return
+	}
+	broadcastID, err := uuid.Parse(id)
+	if err != nil {
+		d.l.Warn(ctx, "invalid broadcast event", "key", e.Key)
+		return
+	}
+
+	if err := d.deliverOnline(ctx, broadcastID); err != nil {
+		d.l.Warn(ctx, "failed to deliver broadcast to connected recipients", "broadcast_id", broadcastID, "error", err.Error())
+	}
+}
+
+func (d *Deliverer) deliverOnline(ctx context.Context, broadcastID uuid.UUID) error {
+	online, err := d.repo.Undelivered(ctx, broadcastID, d.hub.Online())
+	if err != nil || len(online) == 0 {
+		return err
+	}
+
+	b, err := d.repo.Get(ctx, broadcastID)
+	if err != nil {
+		return err
+	}
+
+	notification := models.BroadcastNotification{
+		MsgType:     "broadcast",
+		BroadcastID: b.ID,
+		Title:       b.Title,
+		Message:     b.Message,
+		SentAt:      time.Now(),
+	}
+
+	delivered := make([]uuid.UUID, 0, len(online))
+	for _, id := range online {
+		if err := d.hub.SendOnline(id, notification); err != nil {
+			continue // соединение закрылось: запись остается во входящих
+		}
+		delivered = append(delivered, id)
+	}
+	if len(delivered) == 0 {
+		return nil
+	}
+
+	// счетчик delivered в базе суммирует доставки всех экземпляров
+	if err := d.repo.MarkDelivered(ctx, []uuid.UUID{broadcastID}, delivered); err != nil {
+		return fmt.Errorf("failed to mark broadcast delivered: %w", err)
+	}
+	d.l.Debug(ctx, "broadcast delivered", "broadcast_id", broadcastID, "role", d.role, "delivered", len(delivered))
+	return nil
+}
+
+// DeliverStored отправляет подключившемуся пользователю объявления из его входящих.
+// Вызывается хабом при каждом подключении; ошибки не фатальны — записи остаются до следующего.
+func (d *Deliverer) DeliverStored(userID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "deliver_stored_broadcasts", UserID: userID.String()})
+
+	broadcasts, err := d.repo.ListStored(ctx, userID, time.Now().Add(-storedTTL))
+	if err != nil {
+		d.l.Warn(ctx, "failed to list stored broadcasts", "error", err.Error())
+		return
+	}
+
+	delivered := make([]uuid.UUID, 0, len(broadcasts))
+	for _, b := range broadcasts {
+		notification := models.BroadcastNotification{
+			MsgType:     "broadcast",
+			BroadcastID: b.ID,
+			Title:       b.Title,
+			Message:     b.Message,
+			SentAt:      b.CreatedAt,
+		}
+		if err := d.hub.SendOnline(userID, notification); err != nil {
+			break // пользователь снова отключился
+		}
+		delivered = append(delivered, b.ID)
+	}
+	if len(delivered) == 0 {
+		return
+	}
+
+	if err := d.repo.MarkDelivered(ctx, delivered, []uuid.UUID{userID}); err != nil {
+		d.l.Warn(ctx, "failed to mark stored broadcasts delivered", "error", err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memInbox — входящие одного объявления: получатель → доставлено
+type memInbox struct {
+	broadcast  models.Broadcast
+	recipients []uuid.UUID
+	delivered  map[uuid.UUID]bool
+}
+
+func (r *memInbox) StoreRecipients(context.Context, uuid.UUID, types.UserRole, string) (int, error) {
+	for _, id := range r.recipients {
+		r.delivered[id] = false
+	}
+	return len(r.recipients), nil
+}
+
+func (r *memInbox) Undelivered(_ context.Context, _ uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, id := range userIDs {
+		if delivered, ok := r.delivered[id]; ok && !delivered {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memInbox) Get(context.Context, uuid.UUID) (*models.Broadcast, error) {
+	b := r.broadcast
+	return &b, nil
+}
+
+func (r *memInbox) ListStored(_ context.Context, userID uuid.UUID, _ time.Time) ([]models.Broadcast, error) {
+	if delivered, ok := r.delivered[userID]; ok && !delivered {
+		return []models.Broadcast{r.broadcast}, nil
+	}
+	return nil, nil
+}
+
+func (r *memInbox) MarkDelivered(_ context.Context, _, userIDs []uuid.UUID) error {
+	for _, id := range userIDs {
+		r.delivered[id] = true
+	}
+	return nil
+}
+
+type fakeHub struct {
+	online []uuid.UUID
+	sent   map[uuid.UUID]int
+}
+
+func (h *fakeHub) Online() []uuid.UUID { return h.online }
+
+func (h *fakeHub) SendOnline(id uuid.UUID, _ any) error {
+	if !slices.Contains(h.online, id) {
+		return errors.New("not connected")
+	}
+	h.sent[id]++
+	return nil
+}
+
+// notifyBus доставляет событие всем экземплярам, как Postgres NOTIFY
+type notifyBus struct {
+	instances []*Deliverer
+}
+
+func (b *notifyBus) Publish(ctx context.Context, cache, key string) error {
+	for _, d := range b.instances {
+		d.Notified(ctx, invalidation.Event{Cache: cache, Key: key})
+	}
+	return nil
+}
+
+// Объявление из очереди получает один экземпляр, но доставляется подключенным ко всем
+func TestDeliverer_DeliversOnEveryInstance(t *testing.T) {
+	first, second, offline, otherRole := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	b := models.Broadcast{ID: uuid.New(), Title: "Maintenance", Message: "03:00-03:30", CreatedAt: time.Now()}
+
+	repo := &memInbox{broadcast: b, recipients: []uuid.UUID{first, second, offline}, delivered: map[uuid.UUID]bool{}}
+	// водитель подключен к тому же экземпляру, но объявление ему не адресовано
+	hubA := &fakeHub{online: []uuid.UUID{first, otherRole}, sent: map[uuid.UUID]int{}}
+	hubB := &fakeHub{online: []uuid.UUID{second}, sent: map[uuid.UUID]int{}}
+	bus := &notifyBus{}
+	l := logger.InitLogger("test", "error")
+	a := New(types.RolePassenger, repo, hubA, bus, l)
+	bus.instances = []*Deliverer{a, New(types.RolePassenger, repo, hubB, bus, l)}
+
+	msg := models.BroadcastMessage{BroadcastID: b.ID, Title: b.Title, Message: b.Message, Roles: []types.UserRole{types.RolePassenger}}
+	if err := a.Deliver(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if hubA.sent[first] != 1 || hubB.sent[second] != 1 || hubA.sent[otherRole] != 0 || !repo.delivered[first] || !repo.delivered[second] {
+		t.Fatalf("connected recipients must get the broadcast once: sent %v %v, inbox %v", hubA.sent, hubB.sent, repo.delivered)
+	}
+	if repo.delivered[offline] {
+		t.Fatal("offline recipient must stay in the inbox")
+	}
+
+	// офлайн получатель подключился: объявление досылается из входящих один раз
+	hubB.online = append(hubB.online, offline)
+	bus.instances[1].DeliverStored(offline)
+	bus.instances[1].DeliverStored(offline)
+	if hubB.sent[offline] != 1 || !repo.delivered[offline] {
+		t.Fatalf("stored broadcast must be sent once on connect, sent %d", hubB.sent[offline])
+	}
+}
@@ -2,10 +2,13 @@ package ridecalc
 
 import (
 	"math"
+	"slices"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/geohash"
 )
 
 const (
@@ -18,16 +21,63 @@ const (
 type Calculator interface {
 	Distance(p1, p2 models.Location) float64
 	Duration(distanceKm float64) int
-	Fare(rideType string, distanceKm float64, durationMin int) float64
+	Fare(rideType string, distanceKm float64, durationMin int) models.FareBreakdown
+	Settle(fare models.FareBreakdown, surgeMultiplier, discount, total float64) models.FareBreakdown
+	SettleRide(ride *models.Ride, total float64) models.FareBreakdown
+	Tariffs() []models.Tariff
+	CityFare(fare float64, city *models.CitySettings, at time.Time) (float64, float64, error)
+	SurgeZone(pickup models.Location, at time.Time) (zone string, since time.Time)
+	SurgeMultiplier(rideType string, demand models.ZoneDemand) float64
 	Priority(ride *models.Ride) int
 	EstimatedArrival(startLat, startLon, destLat, destLon float64, vehicleClass types.VehicleClass) time.Time
 	IsDriverArrived(driverLat, driverLng, targetLat, targetLng float64) bool
+	CancellationFee(rideType string, sinceMatched time.Duration, driverDistanceKm float64) float64
+	FlatFare(fare float64, rideType string, pickup, destination models.Location, rates []models.FlatRate, at time.Time) (float64, *models.FlatRate)
 }
 
-type CalculatorImpl struct{}
+type CalculatorImpl struct {
+	surge   SurgeOptions
+	pricing PricingOptions
+	clock   clock.Clock
+}
 
+// New создает калькулятор без надбавки за спрос, комиссии и налога, на системных часах
 func New() *CalculatorImpl {
-	return &CalculatorImpl{}
+	return &CalculatorImpl{clock: clock.New()}
+}
+
+// WithClock задает часы для часа пик и расчетного времени прибытия
+func (c *CalculatorImpl) WithClock(clk clock.Clock) *CalculatorImpl {
+	c.clock = clk
+	return c
+}
+
+// SurgeOptions — надбавка за спрос при нехватке свободных водителей в geohash ячейке.
+// Множитель = 1 + Step * (спрос/предложение - Threshold), не больше SurgeCap тарифа.
+type SurgeOptions struct {
+	Enabled       bool
+	CellPrecision int           // длина geohash ячейки, 5 — около 5км x 5км
+	Window        time.Duration // за какое время считается спрос в ячейке
+	Threshold     float64       // отношение спрос/предложение, с которого включается надбавка
+	Step          float64       // прирост множителя на единицу отношения выше порога
+}
+
+// WithSurge включает надбавку за спрос
+func (c *CalculatorImpl) WithSurge(opts SurgeOptions) *CalculatorImpl {
+	c.surge = opts
+	return c
+}
+
+// PricingOptions — доли стоимости поездки, которые не достаются водителю
+type PricingOptions struct {
+	TaxRate        float64 // ставка налога, уже включенного в стоимость: 0.12 — налог равен total * 0.12 / 1.12
+	CommissionRate float64 // комиссия платформы от стоимости без налога
+}
+
+// WithPricing задает налог и комиссию платформы
+func (c *CalculatorImpl) WithPricing(opts PricingOptions) *CalculatorImpl {
+	c.pricing = opts
+	return c
 }
 
 // Проверяет, находится ли водитель в радиусе arrivalRadius от цели
@@ -79,38 +129,255 @@ func (c *CalculatorImpl) Duration(distanceKm float64) int {
 	return int(math.Ceil(durationMinutes))
 }
 
-// рассчет предварительную стоимость поездки на основе тарифов
-func (c *CalculatorImpl) Fare(rideType string, distanceKm float64, durationMin int) float64 {
-	var baseFare, ratePerKm, ratePerMin float64
-
-	switch rideType {
-	case "PREMIUM":
-		baseFare = 800
-		ratePerKm = 120
-		ratePerMin = 60
-	case "XL":
-		baseFare = 1000
-		ratePerKm = 150
-		ratePerMin = 75
-	case "ECONOMY":
-		fallthrough // Если тип не указан или ECONOMY, используем его по умолчанию
-	default:
-		baseFare = 500
-		ratePerKm = 100
-		ratePerMin = 50
+// tariffs — тарифная сетка по классам автомобилей.
+// Плата за ожидание пока не применяется, поэтому публикуется нейтральной.
+var tariffs = []models.Tariff{
+	{VehicleClass: types.ClassEconomy, BaseFare: 500, PerKm: 100, PerMin: 50, SurgeCap: 2, CancellationFee: 300},
+	{VehicleClass: types.ClassPremium, BaseFare: 800, PerKm: 120, PerMin: 60, SurgeCap: 1.5, CancellationFee: 500},
+	{VehicleClass: types.ClassXL, BaseFare: 1000, PerKm: 150, PerMin: 75, SurgeCap: 2, CancellationFee: 400},
+}
+
+// Tariffs возвращает копию тарифной сетки. При выключенной надбавке за спрос SurgeCap равен 1.
+func (c *CalculatorImpl) Tariffs() []models.Tariff {
+	res := slices.Clone(tariffs)
+	if !c.surge.Enabled {
+		for i := range res {
+			res[i].SurgeCap = 1
+		}
 	}
+	return res
+}
+
+// Tariff возвращает тариф класса, для неизвестного класса - ECONOMY
+func (c *CalculatorImpl) Tariff(rideType string) models.Tariff {
+	for _, t := range tariffs {
+		if string(t.VehicleClass) == rideType {
+			return t
+		}
+	}
+	return tariffs[0]
+}
+
+// рассчет предварительную стоимость поездки на основе тарифов
+func (c *CalculatorImpl) Fare(rideType string, distanceKm float64, durationMin int) models.FareBreakdown {
+	t := c.Tariff(rideType)
 
 	// Формула расчета: Базовая ставка + (стоимость за км) + (стоимость за минуты)
-	fare := baseFare + (distanceKm * ratePerKm) + (float64(durationMin) * ratePerMin)
+	fare := models.FareBreakdown{
+		BaseFare:     t.BaseFare,
+		DistanceFare: distanceKm * t.PerKm,
+		TimeFare:     float64(durationMin) * t.PerMin,
+	}
+	fare.Total = fare.Subtotal()
+	return c.split(fare)
+}
+
+// Settle раскладывает итоговую стоимость total поездки по строкам чека.
+// fare — стоимость по тарифу (Fare), surgeMultiplier и discount — примененные к поездке надбавка и скидка.
+// Остаток, который не объясняется тарифом, надбавкой и скидкой, — ночной или фиксированный тариф (Adjustment).
+func (c *CalculatorImpl) Settle(fare models.FareBreakdown, surgeMultiplier, discount, total float64) models.FareBreakdown {
+	fare.BaseFare = roundMoney(fare.BaseFare)
+	fare.DistanceFare = roundMoney(fare.DistanceFare)
+	fare.TimeFare = roundMoney(fare.TimeFare)
+	fare.Surge = roundMoney(fare.Subtotal() * max(surgeMultiplier-1, 0))
+	fare.Discount = roundMoney(discount)
+	fare.Total = roundMoney(total)
+	fare.Adjustment = roundMoney(fare.Total + fare.Discount - fare.Subtotal() - fare.Surge)
+	return c.split(fare)
+}
+
+// SettleRide раскладывает итоговую стоимость total завершенной поездки по строкам чека.
+// Чек ride-service и заработок, который driver-service зачисляет водителю, считаются одинаково.
+func (c *CalculatorImpl) SettleRide(ride *models.Ride, total float64) models.FareBreakdown {
+	distance := c.Distance(ride.Pickup, ride.Destination)
+	return c.Settle(c.Fare(ride.RideType, distance, c.Duration(distance)), max(ride.SurgeMultiplier, 1), ride.Discount, total)
+}
+
+// split делит стоимость на налог, комиссию платформы и заработок водителя
+func (c *CalculatorImpl) split(fare models.FareBreakdown) models.FareBreakdown {
+	fare.Tax = roundMoney(fare.Total * c.pricing.TaxRate / (1 + c.pricing.TaxRate))
+	fare.PlatformCommission = roundMoney((fare.Total - fare.Tax) * c.pricing.CommissionRate)
+	fare.DriverEarnings = roundMoney(fare.Total - fare.Tax - fare.PlatformCommission)
 	return fare
 }
 
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+const (
+	// freeCancellationWindow — сколько после назначения водителя пассажир может отменить бесплатно
+	freeCancellationWindow = 2 * time.Minute
+	// freeCancellationKm — путь водителя, после которого отмена платная и в бесплатное окно
+	freeCancellationKm = 0.5
+	// cancellationFeeCap — во сколько раз штраф может превысить базовый штраф тарифа
+	cancellationFeeCap = 3
+)
+
+// CancellationFee считает штраф пассажиру за отмену после назначения водителя.
+// В первые freeCancellationWindow, пока водитель проехал меньше freeCancellationKm, отмена бесплатна.
+// Иначе штраф = базовый штраф тарифа + поминутная ставка за время сверх окна + покилометровая за путь водителя,
+// но не больше cancellationFeeCap базовых штрафов.
+func (c *CalculatorImpl) CancellationFee(rideType string, sinceMatched time.Duration, driverDistanceKm float64) float64 {
+	t := c.Tariff(rideType)
+	if t.CancellationFee <= 0 {
+		return 0
+	}
+
+	if sinceMatched < freeCancellationWindow && driverDistanceKm < freeCancellationKm {
+		return 0
+	}
+
+	waited := max(sinceMatched-freeCancellationWindow, 0).Minutes()
+	fee := t.CancellationFee + waited*t.PerMin + max(driverDistanceKm, 0)*t.PerKm
+	fee = min(fee, t.CancellationFee*cancellationFeeCap)
+
+	return math.Round(fee*100) / 100
+}
+
+// CityFare применяет правила города к стоимости поездки, запрошенной в момент at.
+// Возвращает итоговую стоимость и примененный множитель.
+// Вне рабочих часов поездка отклоняется (ErrOutsideOperatingHours) либо тарифицируется по ночному тарифу.
+func (c *CalculatorImpl) CityFare(fare float64, city *models.CitySettings, at time.Time) (float64, float64, error) {
+	if city == nil {
+		return fare, 1, nil
+	}
+
+	open, err := city.IsOpen(at)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !open {
+		if city.OutsideHoursPolicy == types.PolicyReject {
+			return 0, 0, types.ErrOutsideOperatingHours
+		}
+		return nightFare(fare, city.NightMultiplier), city.NightMultiplier, nil
+	}
+
+	night, err := city.IsNight(at)
+	if err != nil {
+		return 0, 0, err
+	}
+	if night {
+		return nightFare(fare, city.NightMultiplier), city.NightMultiplier, nil
+	}
+
+	return fare, 1, nil
+}
+
+// FlatFare применяет к стоимости поездки фиксированные тарифы, действующие в момент at.
+// Маршрут FLAT заменяет рассчитанную стоимость целиком, MINIMUM поднимает ее до минимума зоны посадки.
+// Маршрут важнее минимума. Из нескольких подходящих тарифов берется тариф класса, затем с меньшими зонами.
+// Возвращает итоговую стоимость и примененный тариф, nil — стоимость не изменилась.
+func (c *CalculatorImpl) FlatFare(fare float64, rideType string, pickup, destination models.Location, rates []models.FlatRate, at time.Time) (float64, *models.FlatRate) {
+	var flat, minimum *models.FlatRate
+	for i := range rates {
+		rate := &rates[i]
+		if !rate.EffectiveAt(at) || !rate.AppliesTo(rideType) {
+			continue
+		}
+
+		switch rate.RateType {
+		case types.FlatRateFixed:
+			if c.matchesCorridor(rate, pickup, destination) && moreSpecific(rate, flat) {
+				flat = rate
+			}
+		case types.FlatRateMinimum:
+			if c.inZone(rate.Origin, pickup) && moreSpecific(rate, minimum) {
+				minimum = rate
+			}
+		}
+	}
+
+	if flat != nil {
+		return flat.Fare, flat
+	}
+	if minimum != nil && fare < minimum.Fare {
+		return minimum.Fare, minimum
+	}
+	return fare, nil
+}
+
+// matchesCorridor проверяет, что поездка идет из зоны отправления маршрута в зону назначения
+// или обратно, если маршрут двусторонний
+func (c *CalculatorImpl) matchesCorridor(rate *models.FlatRate, pickup, destination models.Location) bool {
+	if rate.Destination == nil {
+		return false
+	}
+	if c.inZone(rate.Origin, pickup) && c.inZone(*rate.Destination, destination) {
+		return true
+	}
+	return rate.Bidirectional && c.inZone(*rate.Destination, pickup) && c.inZone(rate.Origin, destination)
+}
+
+func (c *CalculatorImpl) inZone(zone models.FareZone, location models.Location) bool {
+	return c.Distance(zone.Center, location) <= zone.RadiusKm
+}
+
+// moreSpecific сообщает, точнее ли тариф rate текущего кандидата: тариф класса точнее общего,
+// среди равных точнее тариф с меньшей суммой радиусов зон
+func moreSpecific(rate, current *models.FlatRate) bool {
+	if current == nil {
+		return true
+	}
+	if (rate.VehicleType != "") != (current.VehicleType != "") {
+		return rate.VehicleType != ""
+	}
+	return zoneRadius(rate) < zoneRadius(current)
+}
+
+func zoneRadius(rate *models.FlatRate) float64 {
+	radius := rate.Origin.RadiusKm
+	if rate.Destination != nil {
+		radius += rate.Destination.RadiusKm
+	}
+	return radius
+}
+
+// SurgeZone возвращает geohash ячейку точки посадки и начало окна, за которое считается спрос.
+// При выключенной надбавке ячейка пустая.
+func (c *CalculatorImpl) SurgeZone(pickup models.Location, at time.Time) (string, time.Time) {
+	if !c.surge.Enabled {
+		return "", at
+	}
+	return geohash.Encode(pickup.Latitude, pickup.Longitude, c.surge.CellPrecision), at.Add(-c.surge.Window)
+}
+
+// SurgeMultiplier возвращает надбавку за спрос по числу запросов и свободных водителей класса в ячейке.
+// Множитель округляется до сотых и ограничен SurgeCap тарифа.
+func (c *CalculatorImpl) SurgeMultiplier(rideType string, demand models.ZoneDemand) float64 {
+	if !c.surge.Enabled {
+		return 1
+	}
+
+	ratio := float64(demand.Requests) / math.Max(float64(demand.AvailableDrivers), 1)
+	if ratio <= c.surge.Threshold {
+		return 1
+	}
+
+	multiplier := math.Min(c.Tariff(rideType).SurgeCap, 1+c.surge.Step*(ratio-c.surge.Threshold))
+	return math.Max(1, math.Round(multiplier*100)/100)
+}
+
+func nightFare(fare, multiplier float64) float64 {
+	return math.Round(fare*multiplier*100) / 100
+}
+
+// MaxPriority — максимальный приоритет поиска водителя
+const MaxPriority = 10
+
 func (c *CalculatorImpl) Priority(ride *models.Ride) int {
+	// приоритетная посадка (медицинская, доступная среда) всегда первая в очереди
+	if ride.PriorityBoarding != "" {
+		return MaxPriority
+	}
+
 	priority := 1
 
 	// Правило №1: Час пик
 	// Увеличиваем приоритет утром (7-10) и вечером (17-20).
-	currentHour := time.Now().Hour()
+	currentHour := c.clock.Now().Hour()
 	if (currentHour >= 7 && currentHour < 10) || (currentHour >= 17 && currentHour < 20) {
 		priority += 3
 	}
@@ -127,8 +394,8 @@ func (c *CalculatorImpl) Priority(ride *models.Ride) int {
 	// Правило №3 (на будущее): Статус пассажира
 	// Если бы у нас была система ролей с подписками по типу VIP, VIP++, SSS ранг, то давали бы доп приоритет
 
-	if priority > 10 {
-		priority = 10
+	if priority > MaxPriority {
+		priority = MaxPriority
 	}
 
 	return priority
@@ -153,5 +420,5 @@ func (c *CalculatorImpl) EstimatedArrival(startLat, startLon, destLat, destLon f
 	// Convert minutes to duration
 	timeDuration := time.Duration(timeMin) * time.Minute
 
-	return time.Now().Add(timeDuration)
+	return c.clock.Now().Add(timeDuration)
 }
@@ -0,0 +1,193 @@
+package ridecalc
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type breakdown struct{ surge, adjustment, tax, commission, earnings float64 }
+
+func TestSettle(t *testing.T) {
+	tests := []struct {
+		name    string
+		pricing PricingOptions
+		surge   float64
+		disc    float64
+		total   float64
+		want    breakdown
+	}{
+		{
+			name:  "without tax and commission driver gets the whole fare",
+			surge: 1,
+			total: 1300,
+			want:  breakdown{0, 0, 0, 0, 1300},
+		},
+		{
+			name:    "surge and promo discount",
+			pricing: PricingOptions{TaxRate: 0.12, CommissionRate: 0.2},
+			surge:   1.5,
+			disc:    150,
+			total:   1800,
+			want:    breakdown{650, 0, 192.86, 321.43, 1285.71},
+		},
+		{
+			name:  "flat rate below the tariff is an adjustment",
+			surge: 1,
+			total: 1000,
+			want:  breakdown{0, -300, 0, 0, 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New().WithPricing(tt.pricing)
+			// ECONOMY: 500 + 5км * 100 + 6мин * 50 = 1300
+			got := c.Settle(c.Fare("ECONOMY", 5, 6), tt.surge, tt.disc, tt.total)
+
+			if got.Subtotal() != 1300 {
+				t.Fatalf("subtotal = %v, want 1300", got.Subtotal())
+			}
+			if got.Surge != tt.want.surge || got.Adjustment != tt.want.adjustment {
+				t.Fatalf("surge, adjustment = %v, %v, want %v, %v", got.Surge, got.Adjustment, tt.want.surge, tt.want.adjustment)
+			}
+			if got.Tax != tt.want.tax || got.PlatformCommission != tt.want.commission || got.DriverEarnings != tt.want.earnings {
+				t.Fatalf("tax, commission, earnings = %v, %v, %v, want %v, %v, %v",
+					got.Tax, got.PlatformCommission, got.DriverEarnings, tt.want.tax, tt.want.commission, tt.want.earnings)
+			}
+
+			lines := got.Subtotal() + got.Surge + got.Adjustment - got.Discount
+			if math.Abs(lines-got.Total) > 1e-9 {
+				t.Fatalf("receipt lines sum to %v, total %v", lines, got.Total)
+			}
+			if split := got.Tax + got.PlatformCommission + got.DriverEarnings; math.Abs(split-got.Total) > 1e-9 {
+				t.Fatalf("split sums to %v, total %v", split, got.Total)
+			}
+		})
+	}
+}
+
+// Водителю зачисляется доля из чека, а не вся стоимость поездки
+func TestSettleRide_DriverShare(t *testing.T) {
+	c := New().WithPricing(PricingOptions{TaxRate: 0.12, CommissionRate: 0.2})
+	ride := &models.Ride{
+		RideType:    "ECONOMY",
+		Pickup:      models.Location{Latitude: 43.238949, Longitude: 76.945465},
+		Destination: models.Location{Latitude: 43.256654, Longitude: 76.928541},
+		Discount:    150,
+	}
+
+	got := c.SettleRide(ride, 1800)
+	if got.Total != 1800 || got.Discount != 150 || got.DriverEarnings != 1285.71 {
+		t.Fatalf("total, discount, earnings = %v, %v, %v, want 1800, 150, 1285.71", got.Total, got.Discount, got.DriverEarnings)
+	}
+}
+
+func TestPriority_RushHour(t *testing.T) {
+	ride := &models.Ride{
+		RideType:    "ECONOMY",
+		Pickup:      models.Location{Latitude: 43.238949, Longitude: 76.889709},
+		Destination: models.Location{Latitude: 43.35, Longitude: 77.05},
+	}
+
+	tests := []struct {
+		name string
+		hour int
+		want int
+	}{
+		{"night", 3, 1},
+		{"morning rush", 8, 4},
+		{"midday", 13, 1},
+		{"evening rush", 19, 4},
+		{"after evening rush", 20, 1},
+	}
+
+	clk := clock.NewFake(time.Time{})
+	c := New().WithClock(clk)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk.Set(time.Date(2026, 10, 15, tt.hour, 30, 0, 0, time.Local))
+			if got := c.Priority(ride); got != tt.want {
+				t.Fatalf("Priority() at %02d:30 = %d, want %d", tt.hour, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatFare(t *testing.T) {
+	downtownZone := models.FareZone{Center: models.Location{Latitude: 43.2389, Longitude: 76.8897}, RadiusKm: 4}
+	airportZone := models.FareZone{Center: models.Location{Latitude: 43.3521, Longitude: 77.0405}, RadiusKm: 3}
+	downtown := models.Location{Latitude: 43.2400, Longitude: 76.8900}
+	airport := models.Location{Latitude: 43.3500, Longitude: 77.0400}
+	outside := models.Location{Latitude: 43.0, Longitude: 76.0}
+
+	date := func(month time.Month) time.Time { return time.Date(2026, month, 1, 0, 0, 0, 0, time.UTC) }
+	until := func(month time.Month) *time.Time {
+		at := date(month)
+		return &at
+	}
+	corridor := func(code, vehicleType string, bidirectional bool, fare float64, from time.Month, to *time.Time) models.FlatRate {
+		return models.FlatRate{
+			ID: uuid.New(), Code: code, RateType: types.FlatRateFixed, VehicleType: vehicleType,
+			Origin: downtownZone, Destination: &airportZone, Bidirectional: bidirectional,
+			Fare: fare, EffectiveFrom: date(from), EffectiveTo: to,
+		}
+	}
+
+	rates := []models.FlatRate{
+		corridor("AIRPORT", "", true, 4000, time.January, until(time.June)),
+		corridor("AIRPORT", "", true, 5000, time.June, until(time.December)),
+		corridor("AIRPORT", "", true, 6000, time.December, nil),
+		corridor("AIRPORT_PREMIUM", "PREMIUM", false, 8000, time.January, nil),
+		{
+			ID: uuid.New(), Code: "DOWNTOWN_MIN", RateType: types.FlatRateMinimum,
+			Origin: downtownZone, Fare: 1500, EffectiveFrom: date(time.January),
+		},
+	}
+	october := date(time.October)
+
+	tests := []struct {
+		name         string
+		rideType     string
+		pickup, dest models.Location
+		fare         float64
+		at           time.Time
+		wantFare     float64
+		wantRate     *models.FlatRate
+	}{
+		{"corridor replaces the formula", "ECONOMY", downtown, airport, 3000, october, 5000, &rates[1]},
+		{"bidirectional corridor in reverse", "ECONOMY", airport, downtown, 3000, october, 5000, &rates[1]},
+		{"class rate wins over the general rate", "PREMIUM", downtown, airport, 3000, october, 8000, &rates[3]},
+		{"one-way class rate does not apply in reverse", "PREMIUM", airport, downtown, 3000, october, 5000, &rates[1]},
+		{"ended version applies to rides in its period", "ECONOMY", downtown, airport, 3000, date(time.March), 4000, &rates[0]},
+		{"scheduled version applies from its start", "ECONOMY", downtown, airport, 3000, date(time.December), 6000, &rates[2]},
+		{"zone minimum raises a cheap ride", "ECONOMY", downtown, downtown, 900, october, 1500, &rates[4]},
+		{"zone minimum keeps a ride above it", "ECONOMY", downtown, downtown, 2000, october, 2000, nil},
+		{"ride outside every zone", "ECONOMY", outside, airport, 900, october, 900, nil},
+		{"nothing is effective yet", "ECONOMY", downtown, airport, 3000, time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC), 3000, nil},
+	}
+
+	c := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fare, rate := c.FlatFare(tt.fare, tt.rideType, tt.pickup, tt.dest, rates, tt.at)
+			if fare != tt.wantFare {
+				t.Fatalf("fare %v, want %v", fare, tt.wantFare)
+			}
+			if tt.wantRate == nil {
+				if rate != nil {
+					t.Fatalf("applied %s from %v, want none", rate.Code, rate.EffectiveFrom)
+				}
+				return
+			}
+			if rate == nil || rate.ID != tt.wantRate.ID {
+				t.Fatalf("applied %+v, want %s from %v", rate, tt.wantRate.Code, tt.wantRate.EffectiveFrom)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package drivergo
+
+import "time"
+
+// ArrivalPolicy — когда водитель считается прибывшим к точке посадки.
+// Одна точка в радиусе может быть скачком GPS, поэтому ARRIVED публикуется,
+// только когда водитель держится в радиусе Points точек подряд или Dwell времени.
+type ArrivalPolicy struct {
+	Points int           // точек подряд в радиусе, 1 и меньше — первая же точка
+	Dwell  time.Duration // время в радиусе, 0 — только по числу точек
+}
+
+// arrivalDetector считает точки водителя в радиусе прибытия для одной поездки.
+// Не потокобезопасен: координаты поездки обрабатываются последовательно.
+type arrivalDetector struct {
+	policy ArrivalPolicy
+
+	inside int       // точек подряд в радиусе
+	since  time.Time // время первой точки текущей серии
+}
+
+func newArrivalDetector(policy ArrivalPolicy) *arrivalDetector {
+	return &arrivalDetector{policy: policy}
+}
+
+// observe учитывает очередную точку и возвращает true, если водитель прибыл.
+// Точка вне радиуса сбрасывает серию.
+func (d *arrivalDetector) observe(withinRadius bool, at time.Time) bool {
+	if !withinRadius {
+		d.inside = 0
+		d.since = time.Time{}
+		return false
+	}
+
+	if d.inside == 0 {
+		d.since = at
+	}
+	d.inside++
+
+	if d.inside >= max(d.policy.Points, 1) {
+		return true
+	}
+	return d.policy.Dwell > 0 && at.Sub(d.since) >= d.policy.Dwell
+}
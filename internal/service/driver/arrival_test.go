@@ -0,0 +1,70 @@
+package drivergo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArrivalDetector(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type point struct {
+		within bool
+		after  time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		policy ArrivalPolicy
+		trace  []point
+		want   []bool
+	}{
+		{
+			name:   "single point is enough without dwell",
+			policy: ArrivalPolicy{Points: 1},
+			trace:  []point{{false, 0}, {true, 3 * time.Second}},
+			want:   []bool{false, true},
+		},
+		{
+			name:   "jitter resets the streak",
+			policy: ArrivalPolicy{Points: 3},
+			trace: []point{
+				{true, 0}, {true, 3 * time.Second}, {false, 6 * time.Second},
+				{true, 9 * time.Second}, {true, 12 * time.Second}, {true, 15 * time.Second},
+			},
+			want: []bool{false, false, false, false, false, true},
+		},
+		{
+			name:   "isolated jumps into radius never arrive",
+			policy: ArrivalPolicy{Points: 3, Dwell: 10 * time.Second},
+			trace: []point{
+				{true, 0}, {false, 4 * time.Second}, {true, 8 * time.Second},
+				{false, 12 * time.Second}, {true, 16 * time.Second}, {false, 20 * time.Second},
+			},
+			want: []bool{false, false, false, false, false, false},
+		},
+		{
+			name:   "dwell arrives before enough points",
+			policy: ArrivalPolicy{Points: 5, Dwell: 10 * time.Second},
+			trace:  []point{{true, 0}, {true, 6 * time.Second}, {true, 11 * time.Second}},
+			want:   []bool{false, false, true},
+		},
+		{
+			name:   "dwell counts from the first point of the streak",
+			policy: ArrivalPolicy{Points: 10, Dwell: 10 * time.Second},
+			trace:  []point{{true, 0}, {false, 8 * time.Second}, {true, 9 * time.Second}, {true, 15 * time.Second}, {true, 19 * time.Second}},
+			want:   []bool{false, false, false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newArrivalDetector(tt.policy)
+			for i, p := range tt.trace {
+				if got := d.observe(p.within, start.Add(p.after)); got != tt.want[i] {
+					t.Errorf("point %d: observe() = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
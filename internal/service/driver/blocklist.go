@@ -0,0 +1,86 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// maxBlocklistSize ограничивает размер блоклиста, чтобы водитель не мог отсечь себя от большинства заказов
+const maxBlocklistSize = 50
+
+// BlockPassenger добавляет пассажира в блоклист водителя
+func (s *Service) BlockPassenger(ctx context.Context, block models.BlockedPassenger) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "block_passenger",
+		DriverID: block.DriverID.String(),
+	})
+
+	if block.DriverID == block.PassengerID {
+		return wrap.Error(ctx, types.ErrCannotBlockSelf)
+	}
+
+	fn := func(ctx context.Context) error {
+		blocked, err := s.repos.blocklist.IsBlocked(ctx, block.DriverID, block.PassengerID)
+		if err != nil {
+			return fmt.Errorf("failed to check blocklist: %w", err)
+		}
+
+		// повторная блокировка только обновляет причину и не расходует лимит
+		if !blocked {
+			count, err := s.repos.blocklist.Count(ctx, block.DriverID)
+			if err != nil {
+				return fmt.Errorf("failed to count blocklist: %w", err)
+			}
+			if count >= maxBlocklistSize {
+				return types.ErrBlocklistFull
+			}
+		}
+
+		if err := s.repos.blocklist.Add(ctx, block); err != nil {
+			return fmt.Errorf("failed to add passenger to blocklist: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := s.infra.trm.Do(ctx, fn); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "passenger blocked", "passenger_id", block.PassengerID.String())
+	return nil
+}
+
+// UnblockPassenger удаляет пассажира из блоклиста водителя
+func (s *Service) UnblockPassenger(ctx context.Context, driverID, passengerID uuid.UUID) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "unblock_passenger",
+		DriverID: driverID.String(),
+	})
+
+	if err := s.repos.blocklist.Remove(ctx, driverID, passengerID); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	return nil
+}
+
+// GetBlocklist возвращает блоклист водителя
+func (s *Service) GetBlocklist(ctx context.Context, driverID uuid.UUID) ([]models.BlockedPassenger, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "get_blocklist",
+		DriverID: driverID.String(),
+	})
+
+	blocks, err := s.repos.blocklist.List(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	return blocks, nil
+}
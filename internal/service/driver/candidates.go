@@ -0,0 +1,218 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/geohash"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// candidateCacheTTL — сколько живёт список кандидатов для ячейки
+	candidateCacheTTL = 3 * time.Second
+	// candidateCellPrecision — точность geohash ячейки (~1.2км x 0.6км)
+	candidateCellPrecision = 6
+)
+
+type candidateEntry struct {
+	drivers   []models.DriverWithDistance
+	expiresAt time.Time
+}
+
+// candidateCache кэширует кандидатов на поездку по geohash ячейке точки подачи и классу поездки.
+// Во время всплесков спроса соседние заказы переиспользуют один запрос в БД.
+//
+// Кэш свой у каждого экземпляра. Смена статуса водителя сбрасывает его на всех экземплярах событием
+// invalidation, которое внутри транзакции доставляется после commit. Поиск, прочитавший кандидатов
+// до commit и сохранивший их после события, держит устаревший список не дольше candidateCacheTTL.
+type candidateCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]candidateEntry
+}
+
+func newCandidateCache(ttl time.Duration) *candidateCache {
+	return &candidateCache{
+		ttl:     ttl,
+		entries: make(map[string]candidateEntry),
+	}
+}
+
+// candidateKey учитывает радиус: расширенный поиск не должен получать кандидатов из узкого
+func candidateKey(rideType string, loc models.Location, radiusKm float64) string {
+	return rideType + ":" + strconv.FormatFloat(radiusKm, 'f', -1, 64) + ":" + geohash.Encode(loc.Latitude, loc.Longitude, candidateCellPrecision)
+}
+
+func (c *candidateCache) get(key string) ([]models.DriverWithDistance, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.drivers, true
+}
+
+func (c *candidateCache) put(key string, drivers []models.DriverWithDistance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = candidateEntry{
+		drivers:   drivers,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *candidateCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+}
+
+// invalidate сбрасывает кэш при смене статуса водителя.
+// Освободившийся водитель может попасть в любую ячейку, поэтому кэш очищается целиком,
+// иначе удаляются только ячейки, где водитель был кандидатом.
+func (c *candidateCache) invalidate(driverID uuid.UUID, status types.DriverStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if status == types.StatusDriverAvailable {
+		clear(c.entries)
+		return
+	}
+
+	for key, entry := range c.entries {
+		if slices.ContainsFunc(entry.drivers, func(d models.DriverWithDistance) bool { return d.ID == driverID }) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// candidateStatusKey — ключ события invalidation о смене статуса водителя
+func candidateStatusKey(driverID uuid.UUID, status types.DriverStatus) string {
+	return driverID.String() + ":" + status.String()
+}
+
+// InvalidateCandidates сбрасывает кэш кандидатов по событию invalidation.
+// Событие о смене статуса (candidateStatusKey) сбрасывает ячейки водителя. Администратор меняет
+// данные водителя, которые хранятся в кандидатах (например, флаг симулятора), а ячейки с водителем
+// заранее неизвестны, поэтому остальные события очищают кэш целиком.
+func (s *Service) InvalidateCandidates(_ context.Context, e invalidation.Event) {
+	id, status, ok := strings.Cut(e.Key, ":")
+	if !ok {
+		s.logic.candidates.clear()
+		return
+	}
+	driverID, err := uuid.Parse(id)
+	if err != nil {
+		s.logic.candidates.clear()
+		return
+	}
+	s.statusChanged(driverID, types.DriverStatus(status))
+}
+
+// findCandidates возвращает кандидатов в радиусе radiusKm от точки подачи, используя кэш ячейки.
+// Блок-лист пассажира применяется к каждому запросу отдельно. Тестовые поездки песочницы
+// получают только водители-симуляторы, обычные — только реальные водители.
+func (s *Service) findCandidates(ctx context.Context, rideType string, loc models.Location, radiusKm float64, passengerID uuid.UUID, test bool) ([]models.DriverWithDistance, error) {
+	key := candidateKey(rideType, loc, radiusKm)
+
+	cached, ok := s.logic.candidates.get(key)
+	if ok {
+		metrics.DriverCandidateCacheTotal.WithLabelValues("driver_service", "hit").Inc()
+	} else {
+		metrics.DriverCandidateCacheTotal.WithLabelValues("driver_service", "miss").Inc()
+
+		var err error
+		cached, err = s.searchDrivers(ctx, rideType, loc, radiusKm)
+		if err != nil {
+			return nil, err
+		}
+		s.logic.candidates.put(key, cached)
+	}
+
+	blockers, err := s.repos.blocklist.ListBlockers(ctx, passengerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passenger blockers: %w", err)
+	}
+
+	// пересчитываем расстояние от фактической точки подачи, кэш общий для всей ячейки
+	drivers := make([]models.DriverWithDistance, 0, len(cached))
+	for _, d := range cached {
+		if d.Simulator != test || slices.Contains(blockers, d.ID) {
+			continue
+		}
+		d.DistanceKm = s.logic.calculate.Distance(loc, d.Location)
+		if d.DistanceKm > radiusKm {
+			continue
+		}
+		drivers = append(drivers, d)
+	}
+
+	sortCandidates(rideType, drivers)
+	return drivers, nil
+}
+
+// sortCandidates повторяет порядок запроса в БД: уровень для PREMIUM, расстояние, рейтинг
+func sortCandidates(rideType string, drivers []models.DriverWithDistance) {
+	premium := rideType == string(types.ClassPremium)
+	sort.SliceStable(drivers, func(i, j int) bool {
+		if premium {
+			if ri, rj := offerDelay(rideType, drivers[i].Tier), offerDelay(rideType, drivers[j].Tier); ri != rj {
+				return ri < rj
+			}
+		}
+		if drivers[i].DistanceKm != drivers[j].DistanceKm {
+			return drivers[i].DistanceKm < drivers[j].DistanceKm
+		}
+		return drivers[i].Rating > drivers[j].Rating
+	})
+}
+
+// changeStatus меняет статус водителя и рассылает сброс кэша кандидатов всем экземплярам.
+// Внутри транзакции событие доставляется после commit: иначе параллельный поиск успел бы
+// вернуть в кэш статус, который еще не закоммичен. Без рассылки кэш сбрасывается только локально.
+func (s *Service) changeStatus(ctx context.Context, driverID uuid.UUID, status types.DriverStatus) (types.DriverStatus, error) {
+	old, err := s.repos.driver.ChangeStatus(ctx, driverID, status)
+	if err != nil {
+		return old, err
+	}
+
+	s.publishStatusChange(ctx, driverID, status)
+	return old, nil
+}
+
+// publishStatusChange рассылает смену статуса водителя кэшам кандидатов всех экземпляров
+func (s *Service) publishStatusChange(ctx context.Context, driverID uuid.UUID, status types.DriverStatus) {
+	if s.infra.caches == nil {
+		s.statusChanged(driverID, status)
+		return
+	}
+	if err := s.infra.caches.Publish(ctx, types.CacheDriverCandidates, candidateStatusKey(driverID, status)); err != nil {
+		// статус уже изменен, устаревших кандидатов вытеснит TTL
+		s.l.Warn(ctx, "failed to publish cache invalidation", "cache", types.CacheDriverCandidates, "driver_id", driverID.String(), "error", err)
+	}
+}
+
+// statusChanged сбрасывает кандидатов и индекс координат экземпляра после смены статуса водителя
+func (s *Service) statusChanged(driverID uuid.UUID, status types.DriverStatus) {
+	s.logic.candidates.invalidate(driverID, status)
+	s.untrackDriver(driverID, status)
+}
@@ -0,0 +1,60 @@
+package drivergo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+)
+
+// pendingEvents копит события invalidation до "commit"
+type pendingEvents []invalidation.Event
+
+func (p *pendingEvents) Publish(_ context.Context, cache, key string) error {
+	*p = append(*p, invalidation.Event{Cache: cache, Key: key})
+	return nil
+}
+
+// Кэш кандидатов сбрасывается событием после commit, а не внутри транзакции смены статуса
+func TestChangeStatus_InvalidatesCandidatesOnCommit(t *testing.T) {
+	drivers := newCountingDriverRepo(2)
+	ids := drivers.ids()
+	events := &pendingEvents{}
+	s := &Service{
+		repos: repos{driver: drivers},
+		logic: logic{candidates: newCandidateCache(time.Minute)},
+		infra: infra{caches: events},
+		l:     logger.InitLogger("test", "error"),
+	}
+	s.logic.candidates.put("busy", []models.DriverWithDistance{{ID: ids[0]}})
+	s.logic.candidates.put("other", []models.DriverWithDistance{{ID: ids[1]}})
+
+	if _, err := s.changeStatus(context.Background(), ids[0], types.StatusDriverBusy); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.logic.candidates.get("busy"); !ok {
+		t.Fatal("candidates invalidated before commit")
+	}
+	if len(*events) != 1 || (*events)[0].Cache != types.CacheDriverCandidates {
+		t.Fatalf("unexpected events: %+v", *events)
+	}
+
+	// commit: событие приходит всем экземплярам, включая текущий
+	s.InvalidateCandidates(context.Background(), (*events)[0])
+	if _, ok := s.logic.candidates.get("busy"); ok {
+		t.Fatal("cell with the busy driver survived the event")
+	}
+	if _, ok := s.logic.candidates.get("other"); !ok {
+		t.Fatal("unrelated cell invalidated")
+	}
+
+	// событие без статуса (администратор) очищает кэш целиком
+	s.InvalidateCandidates(context.Background(), invalidation.Event{Cache: types.CacheDriverCandidates, Key: ids[1].String()})
+	if _, ok := s.logic.candidates.get("other"); ok {
+		t.Fatal("admin event must flush the cache")
+	}
+}
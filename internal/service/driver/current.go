@@ -0,0 +1,91 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// CurrentRide возвращает активную поездку водителя с навигацией до текущей цели,
+// чтобы перезапущенное посреди поездки приложение восстановило экран без ожидания события по WebSocket
+func (s *Service) CurrentRide(ctx context.Context, driverID uuid.UUID) (*models.CurrentRide, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "driver_current_ride",
+		DriverID: driverID.String(),
+	})
+
+	exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to check driver existence: %w", err))
+	}
+	if !exist {
+		return nil, wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	ride, err := s.repos.ride.GetCurrentRide(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	if ride == nil {
+		return nil, types.ErrNoCurrentRide
+	}
+
+	ride.Passenger = models.PassengerContact{
+		Alias:       passengerAlias(ride.Passenger.Alias),
+		PhoneMasked: maskPhone(ride.Passenger.PhoneMasked),
+	}
+
+	ride.Navigation = models.RideNavigation{
+		Target:         types.NavigationPickup,
+		TargetLocation: ride.Pickup,
+	}
+	if ride.Status == types.StatusInProgress {
+		ride.Navigation.Target = types.NavigationDestination
+		ride.Navigation.TargetLocation = ride.Destination
+	}
+
+	// без последней координаты водителя навигация отдается только с целью
+	location, err := s.repos.coordinate.GetDriverLastCoordinate(ctx, driverID)
+	switch {
+	case err == nil:
+		distance := s.logic.calculate.Distance(location, ride.Navigation.TargetLocation)
+		minutes := s.logic.calculate.Duration(distance)
+		ride.Navigation.DriverLocation = &location
+		ride.Navigation.DistanceKm = &distance
+		ride.Navigation.EstimatedMinutes = &minutes
+	case errors.Is(err, types.ErrNoCoordinates):
+	default:
+		s.l.Warn(ctx, "failed to get driver location for navigation", "error", err.Error())
+	}
+
+	return ride, nil
+}
+
+// passengerAlias оставляет от имени пассажира только первое слово
+func passengerAlias(name string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(name), " ")
+	if first == "" {
+		return "Passenger"
+	}
+	return first
+}
+
+// maskPhone скрывает телефон пассажира, кроме последних четырех цифр
+func maskPhone(phone string) string {
+	digits := make([]rune, 0, len(phone))
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) < 4 {
+		return ""
+	}
+	return "***" + string(digits[len(digits)-4:])
+}
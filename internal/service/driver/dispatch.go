@@ -0,0 +1,141 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+)
+
+// DispatchMode — как оффер рассылается найденным водителям
+type DispatchMode string
+
+const (
+	// DispatchSequential — водителям по очереди, следующий получает оффер после отказа или таймаута предыдущего
+	DispatchSequential DispatchMode = "sequential"
+	// DispatchParallel — группе ближайших водителей одновременно, поездку получает первый принявший
+	DispatchParallel DispatchMode = "parallel"
+)
+
+// DispatchPolicy — параметры поиска водителя для поездки.
+// Первая попытка ищет в радиусе RadiusKm, каждая следующая расширяет его на RadiusStepKm до MaxRadiusKm.
+type DispatchPolicy struct {
+	RadiusKm     float64 // радиус первой попытки
+	RadiusStepKm float64 // расширение радиуса с каждой попыткой, 0 — радиус не меняется
+	MaxRadiusKm  float64 // предел расширения радиуса
+
+	OfferTimeout  time.Duration // сколько водитель может отвечать на оффер
+	RetryInterval time.Duration // пауза между попытками поиска
+	SearchTimeout time.Duration // общий таймаут поиска
+
+	Mode           DispatchMode
+	ParallelOffers int // в режиме DispatchParallel — размер группы водителей
+
+	DeclineCooldown time.Duration // сколько отказавшийся или не ответивший водитель не получает оффер той же поездки
+}
+
+// radius возвращает радиус поиска для попытки attempt (с 1)
+func (p DispatchPolicy) radius(attempt int) float64 {
+	r := p.RadiusKm + p.RadiusStepKm*float64(max(attempt-1, 0))
+	return min(r, max(p.MaxRadiusKm, p.RadiusKm))
+}
+
+// batch возвращает, скольким водителям оффер отправляется одновременно
+func (p DispatchPolicy) batch() int {
+	if p.Mode == DispatchParallel {
+		return max(p.ParallelOffers, 1)
+	}
+	return 1
+}
+
+// offerRevokedTaken — причина отзыва оффера, когда поездку принял другой водитель
+const offerRevokedTaken = "accepted_by_another_driver"
+
+// offerRideToBatch отправляет оффер группе водителей одновременно.
+// Поездку получает первый принявший, остальным водителям оффер отзывается сообщением ride_offer_revoked.
+func (s *Service) offerRideToBatch(ctx context.Context, req models.RideRequestedMessage, drivers []models.DriverWithDistance, offer models.RideOffer, servedClass *types.VehicleClass) bool {
+	if len(drivers) == 1 {
+		accepted, _ := s.offerRideToDriver(ctx, req, drivers[0], offer, servedClass)
+		return accepted
+	}
+
+	// отменяет ожидание ответов остальных водителей после первого принятия
+	offerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		winner bool
+	)
+	for _, driver := range drivers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx := wrap.WithLogCtx(ctx, wrap.LogCtx{DriverID: driver.ID.String()})
+			s.l.Info(ctx, "sending offer to driver", "mode", string(DispatchParallel))
+
+			sent := s.offerFor(offer, driver)
+			accepted, err := s.infra.communicator.GetRideOffer(offerCtx, driver.ID, sent)
+			if offerCtx.Err() != nil && !accepted {
+				// поездку уже получил другой водитель, а этот еще не ответил
+				if ctx.Err() == nil {
+					s.revokeOffer(ctx, driver, sent)
+				}
+				return
+			}
+			s.recordOfferOutcome(ctx, driver.ID, sent, accepted, err)
+			if err != nil || !accepted {
+				return
+			}
+
+			// из одновременно принявших поездку получает только первый
+			mu.Lock()
+			defer mu.Unlock()
+			if winner {
+				s.l.Info(ctx, "driver accepted after ride was taken")
+				s.revokeOffer(ctx, driver, sent)
+				return
+			}
+			if err := s.assignDriver(ctx, req, driver, sent, servedClass); err != nil {
+				if !errors.Is(err, errRequestAlreadyServed) {
+					return
+				}
+				// водитель найден другой доставкой запроса, этот поиск закончен
+				s.revokeOffer(ctx, driver, sent)
+			}
+			winner = true
+			cancel()
+		}()
+	}
+	wg.Wait()
+
+	return winner
+}
+
+// revokeOffer отзывает оффер у водителя (non fatal): приложение убирает его с экрана
+func (s *Service) revokeOffer(ctx context.Context, driver models.DriverWithDistance, offer models.RideOffer) {
+	if err := s.infra.communicator.RevokeRideOffer(ctx, driver.ID, models.RideOfferRevoked{
+		OfferID:   offer.ID,
+		RideID:    offer.RideID,
+		Reason:    offerRevokedTaken,
+		Timestamp: s.infra.clock.Now().UTC(),
+	}); err != nil {
+		s.l.Debug(ctx, "failed to revoke ride offer", "error", err)
+		return
+	}
+	metrics.DriverOffersRevokedTotal.Inc()
+}
+
+// offerFor готовит оффер для отправки водителю: расстояние до точки подачи и срок ответа с момента отправки
+func (s *Service) offerFor(offer models.RideOffer, driver models.DriverWithDistance) models.RideOffer {
+	offer.DistanceToPickupKm = driver.DistanceKm
+	offer.ExpiresAt = s.infra.clock.Now().Add(s.logic.dispatch.OfferTimeout)
+	return offer
+}
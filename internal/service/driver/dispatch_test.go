@@ -0,0 +1,92 @@
+package drivergo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// offerOutcomes — водители без записи ответов на офферы
+type offerOutcomes struct {
+	*countingDriverRepo
+}
+
+func (offerOutcomes) RecordOfferOutcome(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, types.OfferOutcome) error {
+	return nil
+}
+
+// offerAnswers — водитель accepting принимает оффер сразу, остальные не отвечают до отмены ожидания
+type offerAnswers struct {
+	DriverCommunicator
+	accepting uuid.UUID
+
+	mu      sync.Mutex
+	revoked map[uuid.UUID]models.RideOfferRevoked
+}
+
+func (c *offerAnswers) GetRideOffer(ctx context.Context, driverID uuid.UUID, _ models.RideOffer) (bool, error) {
+	if driverID == c.accepting {
+		return true, nil
+	}
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func (c *offerAnswers) RevokeRideOffer(_ context.Context, driverID uuid.UUID, msg models.RideOfferRevoked) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revoked[driverID] = msg
+	return nil
+}
+
+// Когда поездку принял один водитель, у остальных оффер отзывается со временем часов сервиса
+func TestOfferRideToBatch_RevokesOthers(t *testing.T) {
+	drivers := newCountingDriverRepo(3)
+	ids := drivers.ids()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	communicator := &offerAnswers{accepting: ids[0], revoked: make(map[uuid.UUID]models.RideOfferRevoked)}
+
+	s := &Service{
+		repos: repos{driver: offerOutcomes{drivers}, processed: memProcessed{}},
+		logic: logic{calculate: ridecalc.New(), candidates: newCandidateCache(time.Minute), searches: newSearchTracker()},
+		infra: infra{communicator: communicator, publisher: &countingResponses{}, trm: inlineTxManager{}, clock: clock.NewFake(now)},
+		l:     logger.InitLogger("test", "error"),
+	}
+	req := models.RideRequestedMessage{RideID: uuid.New(), MessageID: uuid.New().String()}
+	offer := models.RideOffer{ID: uuid.New(), RideID: req.RideID}
+	batch := make([]models.DriverWithDistance, 0, len(ids))
+	for _, id := range ids {
+		batch = append(batch, models.DriverWithDistance{ID: id})
+	}
+
+	if !s.offerRideToBatch(context.Background(), req, batch, offer, nil) {
+		t.Fatal("ride was not assigned")
+	}
+	if drivers.drivers[ids[0]].Status != types.StatusDriverBusy {
+		t.Fatalf("accepting driver status %s, want BUSY", drivers.drivers[ids[0]].Status)
+	}
+
+	if len(communicator.revoked) != 2 {
+		t.Fatalf("revoked %d offers, want 2", len(communicator.revoked))
+	}
+	if _, ok := communicator.revoked[ids[0]]; ok {
+		t.Fatal("offer revoked from the driver who got the ride")
+	}
+	for _, id := range ids[1:] {
+		msg, ok := communicator.revoked[id]
+		if !ok {
+			t.Fatalf("offer of driver %s was not revoked", id)
+		}
+		if msg.OfferID != offer.ID || msg.RideID != offer.RideID || msg.Reason != offerRevokedTaken || !msg.Timestamp.Equal(now) {
+			t.Fatalf("unexpected revocation %+v", msg)
+		}
+	}
+}
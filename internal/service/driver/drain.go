@@ -0,0 +1,111 @@
+package drivergo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// searchTracker — активные поиски водителя экземпляра. При остановке сервиса новые поиски
+// не начинаются, а не завершившиеся за время drain прерываются и возвращаются в очередь.
+type searchTracker struct {
+	mu       sync.Mutex
+	draining bool
+	next     uint64
+	active   map[uint64]context.CancelCauseFunc
+	wg       sync.WaitGroup
+}
+
+// interruptWait — сколько ждать выхода прерванных поисков
+const interruptWait = 5 * time.Second
+
+func newSearchTracker() *searchTracker {
+	return &searchTracker{
+		active: make(map[uint64]context.CancelCauseFunc),
+	}
+}
+
+// begin регистрирует поиск. Во время drain возвращает types.ErrSearchInterrupted:
+// запрос возвращается в очередь и достается другому экземпляру.
+func (t *searchTracker) begin(ctx context.Context) (context.Context, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return ctx, nil, types.ErrSearchInterrupted
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	id := t.next
+	t.next++
+	t.active[id] = cancel
+	t.wg.Add(1)
+
+	done := func() {
+		t.mu.Lock()
+		delete(t.active, id)
+		t.mu.Unlock()
+		cancel(nil)
+		t.wg.Done()
+	}
+	return ctx, done, nil
+}
+
+// wait ждет завершения поисков, false — не дождался до отмены ctx
+func (t *searchTracker) wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Drain останавливает поиск водителей перед выключением: новые запросы возвращаются в очередь,
+// активные поиски получают timeout на завершение. Оставшиеся прерываются — их запросы
+// возвращаются в очередь и продолжаются на другом экземпляре. Возвращает число прерванных поисков.
+func (s *Service) Drain(ctx context.Context, timeout time.Duration) int {
+	ctx = wrap.WithAction(ctx, "drain_driver_searches")
+	t := s.logic.searches
+
+	t.mu.Lock()
+	t.draining = true
+	active := len(t.active)
+	t.mu.Unlock()
+
+	if active == 0 {
+		return 0
+	}
+	s.l.Info(ctx, "waiting for active driver searches", "active", active, "timeout", timeout.String())
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if t.wait(waitCtx) {
+		return 0
+	}
+
+	t.mu.Lock()
+	interrupted := len(t.active)
+	for _, stop := range t.active {
+		stop(types.ErrSearchInterrupted)
+	}
+	t.mu.Unlock()
+
+	// прерванный поиск освобождает сообщение и завершается сразу
+	exitCtx, cancelExit := context.WithTimeout(context.WithoutCancel(ctx), interruptWait)
+	defer cancelExit()
+	if !t.wait(exitCtx) {
+		s.l.Warn(ctx, "interrupted driver searches did not exit in time")
+	}
+	s.l.Warn(ctx, "driver searches interrupted by shutdown and returned to the queue", "count", interrupted)
+	return interrupted
+}
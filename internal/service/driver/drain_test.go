@@ -0,0 +1,55 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+)
+
+func TestDrain(t *testing.T) {
+	s := &Service{logic: logic{searches: newSearchTracker()}, l: logger.InitLogger("test", "error")}
+
+	// поиск, завершающийся за время drain, не прерывается
+	_, quickDone, err := s.logic.searches.begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// поиск, который не успевает завершиться
+	slowCtx, slowDone, err := s.logic.searches.begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cause := make(chan error, 1)
+	go func() {
+		defer slowDone()
+		<-slowCtx.Done()
+		cause <- context.Cause(slowCtx)
+	}()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		quickDone()
+	}()
+
+	if got := s.Drain(context.Background(), 100*time.Millisecond); got != 1 {
+		t.Fatalf("interrupted %d searches, want 1", got)
+	}
+	if err := <-cause; !errors.Is(err, types.ErrSearchInterrupted) {
+		t.Fatalf("slow search cancelled with %v, want ErrSearchInterrupted", err)
+	}
+
+	if _, _, err := s.logic.searches.begin(context.Background()); !errors.Is(err, types.ErrSearchInterrupted) {
+		t.Fatalf("new search during drain: got %v, want ErrSearchInterrupted", err)
+	}
+}
+
+func TestDrain_NoActiveSearches(t *testing.T) {
+	s := &Service{logic: logic{searches: newSearchTracker()}, l: logger.InitLogger("test", "error")}
+	if got := s.Drain(context.Background(), time.Second); got != 0 {
+		t.Fatalf("interrupted %d searches, want 0", got)
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
@@ -30,13 +32,34 @@ type Service struct {
 
 type logic struct {
 	calculate ridecalc.Calculator
+	// candidates — кэш кандидатов по ячейке точки подачи
+	candidates *candidateCache
+	// assignments — снятие назначения с водителя, потерявшего соединение
+	assignments *assignmentWatcher
+	// searches — активные поиски водителя, Drain дожидается их при остановке
+	searches *searchTracker
+	// arrival — сколько водитель должен пробыть у точки посадки до ARRIVED
+	arrival ArrivalPolicy
+	// fallback — когда поиск переходит на смежные классы автомобилей
+	fallback ClassFallbackPolicy
+	// dispatch — радиус, таймауты и режим рассылки офферов при поиске водителя
+	dispatch DispatchPolicy
+	// tierWindow — окно метрик уровня, за него же считается доля отказов в профиле
+	tierWindow time.Duration
+	// geo — индекс координат свободных водителей, nil — поиск только запросом PostGIS
+	geo *driverGeoIndex
+	// requireVerifiedPhone — на линию выходят только водители с подтвержденным телефоном
+	requireVerifiedPhone bool
 }
 
 type infra struct {
 	communicator  DriverCommunicator
 	addressGetter GeoCoder
 	publisher     Publisher
+	locations     LocationIngester
+	caches        CachePublisher
 	trm           trm.TxManager
+	clock         clock.Clock
 }
 
 type repos struct {
@@ -46,6 +69,11 @@ type repos struct {
 	user       UserRepo
 	coordinate CoordinateRepo
 	eventRepo  RideEventRepository
+	blocklist  BlocklistRepo
+	offline    OfflineActionRepo
+	processed  ProcessedMessageRepo
+	// connections — подключения водителей и сроки снятия назначения после разрыва
+	connections ConnectionRepo
 }
 
 // New returns a new instance of the driver service with all dependencies injected.
@@ -61,25 +89,55 @@ func New(
 	communicator DriverCommunicator,
 	trm trm.TxManager,
 	eventRepo RideEventRepository,
+	blocklistRepo BlocklistRepo,
+	offlineRepo OfflineActionRepo,
+	processedRepo ProcessedMessageRepo,
+	connectionRepo ConnectionRepo,
+	locations LocationIngester,
+	caches CachePublisher,
+	redispatchGrace time.Duration,
+	tierWindow time.Duration,
+	requireVerifiedPhone bool,
+	arrival ArrivalPolicy,
+	fallback ClassFallbackPolicy,
+	dispatch DispatchPolicy,
+	geo GeoIndexPolicy,
+	clk clock.Clock,
 	l logger.Logger,
 ) *Service {
 	return &Service{
 		repos: repos{
-			driver:     driverRepo,
-			session:    sessionRepo,
-			coordinate: coordinateRepo,
-			user:       userRepo,
-			ride:       rideRepo,
-			eventRepo:  eventRepo,
+			driver:      driverRepo,
+			session:     sessionRepo,
+			coordinate:  coordinateRepo,
+			user:        userRepo,
+			ride:        rideRepo,
+			eventRepo:   eventRepo,
+			blocklist:   blocklistRepo,
+			offline:     offlineRepo,
+			processed:   processedRepo,
+			connections: connectionRepo,
 		},
 		logic: logic{
-			calculate: calculate,
+			calculate:            calculate,
+			candidates:           newCandidateCache(candidateCacheTTL),
+			assignments:          newAssignmentWatcher(redispatchGrace),
+			searches:             newSearchTracker(),
+			arrival:              arrival,
+			fallback:             fallback,
+			dispatch:             dispatch,
+			tierWindow:           tierWindow,
+			requireVerifiedPhone: requireVerifiedPhone,
+			geo:                  newDriverGeoIndex(geo),
 		},
 		infra: infra{
 			addressGetter: addressGetter,
 			publisher:     publisher,
+			locations:     locations,
+			caches:        caches,
 			communicator:  communicator,
 			trm:           trm,
+			clock:         clk,
 		},
 		l: l,
 	}
@@ -135,7 +193,8 @@ func (s *Service) Register(ctx context.Context, newDriver *models.Driver) error
 		}
 
 		// Determine vehicle class (Economy / XL / Premium)
-		newDriver.Vehicle.Type = classify(newDriver)
+		bodyType := strings.ToUpper(string(newDriver.Vehicle.Type))
+		newDriver.Vehicle.Type = classify(newDriver.Vehicle)
 		newDriver.Rating = 5.0
 		newDriver.Status = types.StatusDriverOffline
 
@@ -144,6 +203,15 @@ func (s *Service) Register(ctx context.Context, newDriver *models.Driver) error
 			return fmt.Errorf("failed to create new driver: %w", err)
 		}
 
+		// vehicle from registration becomes the active one
+		if !slices.Contains(models.BodyTypes, bodyType) {
+			bodyType = ""
+		}
+		vehicle := newDriverVehicle(newDriver.ID, newDriver.Vehicle, bodyType)
+		if _, err := s.addVehicle(ctx, vehicle, true); err != nil {
+			return fmt.Errorf("failed to save driver vehicle: %w", err)
+		}
+
 		if _, err = s.repos.user.ChangeRole(ctx, newDriver.ID, types.RoleDriver); err != nil {
 			return fmt.Errorf("failed to change user role to driver: %w", err)
 		}
@@ -160,10 +228,9 @@ func (s *Service) Register(ctx context.Context, newDriver *models.Driver) error
 }
 
 // classify determines the vehicle class (Economy, XL, Premium)
-// based on car type, brand, and year.
-func classify(driver *models.Driver) types.VehicleClass {
+// based on car type, brand, and year. v.Type is the body type or the declared class.
+func classify(v models.Vehicle) types.VehicleClass {
 	currentYear := time.Now().Year()
-	v := driver.Vehicle
 
 	// Premium segment (luxury and business brands)
 	premiumBrands := map[string]bool{
@@ -202,7 +269,7 @@ func (s *Service) GoOnline(ctx context.Context, driverID uuid.UUID, location mod
 
 	var sessionID uuid.UUID
 	fn := func(ctx context.Context) error {
-		now := time.Now()
+		now := s.infra.clock.Now()
 
 		// Check if driver exists in DB
 		exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
@@ -213,8 +280,18 @@ func (s *Service) GoOnline(ctx context.Context, driverID uuid.UUID, location mod
 			return types.ErrUserNotFound
 		}
 
+		if s.logic.requireVerifiedPhone {
+			verified, err := s.repos.user.IsPhoneVerified(ctx, driverID)
+			if err != nil {
+				return fmt.Errorf("failed to check driver phone verification: %w", err)
+			}
+			if !verified {
+				return types.ErrPhoneNotVerified
+			}
+		}
+
 		// Change driver status to AVAILABLE
-		oldstatus, err := s.repos.driver.ChangeStatus(ctx, driverID, types.StatusDriverAvailable)
+		oldstatus, err := s.changeStatus(ctx, driverID, types.StatusDriverAvailable)
 		if err != nil {
 			return fmt.Errorf("failed to change driver status: %w", err)
 		}
@@ -222,11 +299,17 @@ func (s *Service) GoOnline(ctx context.Context, driverID uuid.UUID, location mod
 			return types.ErrDriverAlreadyOnline
 		}
 
-		// Create a new session for the driver
-		sessionID, err = s.repos.session.Create(ctx, driverID)
+		// Create a new session for the driver. Параллельный GoOnline мог прочитать статус OFFLINE
+		// до commit первого запроса: тогда сессия уже открыта им, и возвращается она
+		var created bool
+		sessionID, created, err = s.repos.session.Create(ctx, driverID)
 		if err != nil {
 			return fmt.Errorf("failed to create driver session: %w", err)
 		}
+		if !created {
+			s.l.Info(ctx, "driver session already opened by a concurrent request", "session_id", sessionID.String())
+			return nil
+		}
 
 		// Reverse geocoding: get address by latitude and longitude
 		location.Address, err = s.infra.addressGetter.GetAddress(ctx, location.Longitude, location.Latitude)
@@ -257,7 +340,7 @@ func (s *Service) GoOffline(ctx context.Context, driverID uuid.UUID) (models.Ses
 	})
 
 	var summary models.SessionSummary
-	fn := func(ctx context.Context) error {
+	fn := func(ctx context.Context) (err error) {
 		// Check if driver exists in DB
 		exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
 		if err != nil {
@@ -267,36 +350,52 @@ func (s *Service) GoOffline(ctx context.Context, driverID uuid.UUID) (models.Ses
 			return types.ErrUserNotFound
 		}
 
-		// Change driver status to OFFLINE
-		oldstatus, err := s.repos.driver.ChangeStatus(ctx, driverID, types.StatusDriverOffline)
-		if err != nil {
-			return fmt.Errorf("failed to change driver status: %w", err)
-		}
+		summary, err = s.goOffline(ctx, driverID)
+		return err
+	}
 
-		if oldstatus != types.StatusDriverAvailable {
-			if oldstatus == types.StatusDriverOffline {
-				return types.ErrDriverAlreadyOffline
-			} else {
-				return types.ErrDriverMustBeAvailable
-			}
-		}
+	// Execute logic within transaction
+	if err := s.infra.trm.Do(ctx, fn); err != nil {
+		return models.SessionSummary{}, wrap.Error(ctx, err)
+	}
 
-		// Get driver`s session summary
-		summary, err = s.repos.session.GetSummary(ctx, driverID)
-		if err != nil {
-			return fmt.Errorf("failed to get session summary: %w", err)
-		}
+	// Сравнение и разбивка читаются после коммита: сессия уже закрыта
+	s.enrichSummary(ctx, driverID, &summary)
+	s.pushShiftSummary(ctx, driverID, summary)
 
-		// Refresh driver total ride summary
-		if err := s.repos.driver.UpdateStats(ctx, driverID, summary.RidesCompleted, summary.Earnings); err != nil {
-			return fmt.Errorf("failed to update driver stats: %w", err)
+	return summary, nil
+}
+
+// goOffline переводит водителя в OFFLINE и закрывает сессию. Водитель в пути к пассажиру
+// снимается с поездки, и она возвращается в поиск. Вызывается внутри транзакции.
+func (s *Service) goOffline(ctx context.Context, driverID uuid.UUID) (models.SessionSummary, error) {
+	// Change driver status to OFFLINE
+	oldstatus, err := s.changeStatus(ctx, driverID, types.StatusDriverOffline)
+	if err != nil {
+		return models.SessionSummary{}, fmt.Errorf("failed to change driver status: %w", err)
+	}
+
+	switch oldstatus {
+	case types.StatusDriverAvailable:
+	case types.StatusDriverEnRoute:
+		if err := s.releaseAssignment(ctx, driverID); err != nil {
+			return models.SessionSummary{}, err
 		}
-		return nil
+	case types.StatusDriverOffline:
+		return models.SessionSummary{}, types.ErrDriverAlreadyOffline
+	default:
+		return models.SessionSummary{}, types.ErrDriverMustBeAvailable
 	}
 
-	// Execute logic within transaction
-	if err := s.infra.trm.Do(ctx, fn); err != nil {
-		return models.SessionSummary{}, wrap.Error(ctx, err)
+	// Get driver`s session summary
+	summary, err := s.repos.session.GetSummary(ctx, driverID)
+	if err != nil {
+		return models.SessionSummary{}, fmt.Errorf("failed to get session summary: %w", err)
+	}
+
+	// Refresh driver total ride summary
+	if err := s.repos.driver.UpdateStats(ctx, driverID, summary.RidesCompleted, summary.Earnings); err != nil {
+		return models.SessionSummary{}, fmt.Errorf("failed to update driver stats: %w", err)
 	}
 
 	return summary, nil
@@ -347,7 +446,7 @@ func (s *Service) StartRide(ctx context.Context, startTime time.Time, driverID,
 		}
 
 		// Change driver status in database
-		if _, err := s.repos.driver.ChangeStatus(ctx, driverID, types.StatusDriverBusy); err != nil {
+		if _, err := s.changeStatus(ctx, driverID, types.StatusDriverBusy); err != nil {
 			return fmt.Errorf("failed to change driver status: %w", err)
 		}
 
@@ -358,7 +457,7 @@ func (s *Service) StartRide(ctx context.Context, startTime time.Time, driverID,
 		}
 
 		// Save driver’s coordinates in the DB
-		if _, err := s.repos.coordinate.CreateCoordinate(ctx, driverID, types.Driver, location, time.Now()); err != nil {
+		if _, err := s.repos.coordinate.CreateCoordinate(ctx, driverID, types.Driver, location, s.infra.clock.Now()); err != nil {
 			return fmt.Errorf("failed to insert new coordinate data: %w", err)
 		}
 
@@ -405,7 +504,12 @@ func (s *Service) CompleteRide(ctx context.Context, rideID uuid.UUID, data Compl
 		if err != nil {
 			return fmt.Errorf("failed to get ride data: %w", err)
 		}
-		earnings = ride.EstimatedFare
+		// водителю зачисляется его доля из чека: без налога и комиссии платформы
+		fare := ride.EstimatedFare
+		if ride.FinalFare != nil {
+			fare = *ride.FinalFare
+		}
+		earnings = s.logic.calculate.SettleRide(ride, fare).DriverEarnings
 
 		// Ride status must be IN_PROGRESS
 		if ride.Status != types.StatusInProgress.String() {
@@ -440,7 +544,7 @@ func (s *Service) CompleteRide(ctx context.Context, rideID uuid.UUID, data Compl
 		}
 
 		// Change driver status to AVAILABLE
-		if _, err := s.repos.driver.ChangeStatus(ctx, data.DriverID, types.StatusDriverAvailable); err != nil {
+		if _, err := s.changeStatus(ctx, data.DriverID, types.StatusDriverAvailable); err != nil {
 			return fmt.Errorf("failed to change driver status: %w", err)
 		}
 
@@ -449,14 +553,20 @@ func (s *Service) CompleteRide(ctx context.Context, rideID uuid.UUID, data Compl
 			return fmt.Errorf("failed to update driver stats: %w", err)
 		}
 
+		// заработок становится доступен для мгновенного вывода
+		if err := s.repos.driver.CreditBalance(ctx, data.DriverID, &rideID, types.DriverLedgerEarning, earnings); err != nil {
+			return fmt.Errorf("failed to credit driver balance: %w", err)
+		}
+
 		// Publish driver status update
 		if err := s.infra.publisher.PublishDriverStatus(
 			ctx,
 			models.DriverStatusUpdateMessage{
-				DriverID:  data.DriverID,
-				Status:    types.StatusCompleted.String(),
-				Timestamp: data.CompleteTime,
-				RideID:    &rideID,
+				DriverID:   data.DriverID,
+				Status:     types.StatusCompleted.String(),
+				Timestamp:  data.CompleteTime,
+				RideID:     &rideID,
+				DistanceKm: &data.ActualDistanceKm,
 			}); err != nil {
 			return fmt.Errorf("failed to publish driver status: %w", err)
 		}
@@ -474,55 +584,20 @@ func (s *Service) CompleteRide(ctx context.Context, rideID uuid.UUID, data Compl
 		s.l.Warn(ctx, "failed to create ride event", "event_type", types.EventFareAdjusted, "error", err.Error())
 	}
 
+	// обновляем плитку заработка в приложении водителя
+	s.PushStats(ctx, data.DriverID)
+
 	return earnings, nil
 }
 
+// UpdateLocation записывает координату водителя через location сервис:
+// в процессе или во внутреннем API location-service (LOCATION_SERVICE_URL)
 func (s *Service) UpdateLocation(ctx context.Context, data models.RideLocationUpdate) (coordinateID uuid.UUID, err error) {
-	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
-		Action:   "update_driver_location",
-		DriverID: data.DriverID.String(),
-	})
-
-	if data.RideID != nil {
-		ctx = wrap.WithRideID(ctx, data.RideID.String())
-	}
-
-	fn := func(ctx context.Context) error {
-		// Check if driver exists in DB
-		exist, err := s.repos.driver.IsDriverExist(ctx, data.DriverID)
-		if err != nil {
-			return fmt.Errorf("failed to check driver existence: %w", err)
-		}
-		if !exist {
-			return types.ErrUserNotFound
-		}
-
-		// Get address by geocoding
-		data.Location.Address, err = s.infra.addressGetter.GetAddress(ctx, data.Location.Longitude, data.Location.Latitude)
-		if err != nil {
-			s.l.Warn(ctx, "Failed to get address", "error", err.Error())
-		}
-
-		coordinateID, err = s.repos.coordinate.CreateCoordinate(ctx, data.DriverID, types.Driver, data.Location, data.TimeStamp)
-		if err != nil {
-			return fmt.Errorf("failed to insert new coordinate data: %w", err)
-		}
-
-		if _, err := s.repos.coordinate.CreateLocationHistory(ctx, coordinateID, data.DriverID, data.RideID, data.Location, data.AccuracyMeters, data.SpeedKmh, data.HeadingDegrees); err != nil {
-			return fmt.Errorf("failed to create location history: %w", err)
-		}
-
-		if err := s.infra.publisher.PublishLocationUpdate(ctx, data); err != nil {
-			return fmt.Errorf("failed to publish location update: %w", err)
-		}
-
-		return nil
+	coordinateID, err = s.infra.locations.Ingest(ctx, data)
+	if err != nil {
+		return coordinateID, err
 	}
-
-	if err := s.infra.trm.Do(ctx, fn); err != nil {
-		return uuid.UUID{}, wrap.Error(ctx, err)
-	}
-
+	s.trackLocation(data)
 	return coordinateID, nil
 }
 
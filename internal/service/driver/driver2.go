@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
@@ -12,7 +13,24 @@ import (
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
+// errRequestAlreadyServed — запрос поиска уже отмечен обработанным другой доставкой
+var errRequestAlreadyServed = errors.New("ride request already served")
+
+const (
+	// searchDriverConsumer — имя потребителя запросов поиска водителя в processed_messages
+	searchDriverConsumer = "search_driver"
+	// cancellationFeeConsumer — имя потребителя зачисления штрафа за отмену в processed_messages
+	cancellationFeeConsumer = "cancellation_fee"
+)
+
 func (s *Service) SearchDriver(ctx context.Context, req models.RideRequestedMessage) error {
+	// во время остановки сервиса запрос возвращается в очередь до начала поиска
+	ctx, done, err := s.logic.searches.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	offer := s.prepareRideOffer(req)
 
 	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
@@ -23,6 +41,20 @@ func (s *Service) SearchDriver(ctx context.Context, req models.RideRequestedMess
 		OfferID:    offer.ID.String(),
 	})
 
+	// повторная доставка уже завершенного запроса не запускает второй поиск. Сообщение отмечается
+	// обработанным только в транзакции назначения водителя (assignDriver): поиск, прерванный
+	// ошибкой, остановкой или падением экземпляра, при повторной доставке выполняется заново.
+	if req.MessageID != "" {
+		done, err := s.repos.processed.Processed(ctx, searchDriverConsumer, req.MessageID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", types.ErrDatabaseFailed, err)
+		}
+		if done {
+			s.l.Info(ctx, "duplicate ride request skipped", "message_id", req.MessageID)
+			return nil
+		}
+	}
+
 	return s.waitForDriverAcceptance(ctx, req, offer)
 }
 
@@ -46,15 +78,15 @@ func (s *Service) prepareRideOffer(req models.RideRequestedMessage) models.RideO
 		DestinationLocation:         req.DestinationLocation,
 		EstimatedFare:               req.EstimatedFare,
 		EstimatedRideDurationMinute: durationMin,
-		DriverEarnings:              s.logic.calculate.Fare(req.RideType, distance, durationMin),
-		ExpiresAt:                   time.Now().Add(30 * time.Second),
+		DriverEarnings:              s.logic.calculate.Fare(req.RideType, distance, durationMin).DriverEarnings,
 		DistanceToPickupKm:          0,
+		PriorityBoarding:            req.PriorityBoarding,
 	}
 }
 
-// Поиск доступных водителей
-func (s *Service) searchAvailableDrivers(ctx context.Context, rideType string, loc models.Location) ([]models.DriverWithDistance, error) {
-	drivers, err := s.repos.driver.SearchDrivers(ctx, rideType, loc)
+// Поиск доступных водителей в радиусе radiusKm
+func (s *Service) searchAvailableDrivers(ctx context.Context, rideType string, loc models.Location, radiusKm float64, passengerID uuid.UUID, test bool) ([]models.DriverWithDistance, error) {
+	drivers, err := s.findCandidates(ctx, rideType, loc, radiusKm, passengerID, test)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find available drivers: %w", err)
 	}
@@ -64,17 +96,54 @@ func (s *Service) searchAvailableDrivers(ctx context.Context, rideType string, l
 	return drivers, nil
 }
 
+// offerRideToDrivers отправляет оффер водителям до первого принятия: по одному
+// или группами, если DispatchPolicy включает параллельную рассылку.
+// servedClass задается, когда водители относятся к смежному классу.
+func (s *Service) offerRideToDrivers(ctx context.Context, req models.RideRequestedMessage, drivers []models.DriverWithDistance, offer models.RideOffer, searchStart time.Time, servedClass *types.VehicleClass) bool {
+	if len(drivers) == 0 {
+		return false
+	}
+	declined := s.recentDeclines(ctx, req.RideID)
+
+	eligible := make([]models.DriverWithDistance, 0, len(drivers))
+	for _, driver := range drivers {
+		// водитель, снятый с этой поездки оператором, её повторно не получает
+		if slices.Contains(req.ExcludedDriverIDs, driver.ID) {
+			continue
+		}
+
+		// отказавшийся водитель не получает ту же поездку до конца cooldown
+		if slices.Contains(declined, driver.ID) {
+			continue
+		}
+
+		// водители низших уровней получают PREMIUM оффер с задержкой
+		if s.infra.clock.Now().Sub(searchStart) < offerDelay(req.RideType, driver.Tier) {
+			continue
+		}
+		eligible = append(eligible, driver)
+	}
+
+	for batch := range slices.Chunk(eligible, s.logic.dispatch.batch()) {
+		if s.offerRideToBatch(ctx, req, batch, offer, servedClass) {
+			return true
+		}
+	}
+	return false
+}
+
 // Отправка оффера водителю и обработка принятия
-func (s *Service) offerRideToDriver(ctx context.Context, correlationID string, driver models.DriverWithDistance, offer models.RideOffer) (bool, error) {
+func (s *Service) offerRideToDriver(ctx context.Context, req models.RideRequestedMessage, driver models.DriverWithDistance, offer models.RideOffer, servedClass *types.VehicleClass) (bool, error) {
 	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
 		DriverID: driver.ID.String(),
 		OfferID:  offer.ID.String(),
 	})
 
 	s.l.Info(ctx, "sending offer to driver")
-	offer.DistanceToPickupKm = driver.DistanceKm
+	offer = s.offerFor(offer, driver)
 
 	accepted, err := s.infra.communicator.GetRideOffer(ctx, driver.ID, offer)
+	s.recordOfferOutcome(ctx, driver.ID, offer, accepted, err)
 	if err != nil {
 		s.l.Debug(ctx, "failed to send ride offer", "error", err)
 		return false, nil // игнорируем ошибки отправки для поиска других водителей
@@ -85,9 +154,35 @@ func (s *Service) offerRideToDriver(ctx context.Context, correlationID string, d
 		return false, nil
 	}
 
+	if err := s.assignDriver(ctx, req, driver, offer, servedClass); err != nil {
+		if errors.Is(err, errRequestAlreadyServed) {
+			// водитель найден другой доставкой запроса, этот поиск закончен
+			s.l.Info(ctx, "ride request already served by another delivery", "message_id", req.MessageID)
+			s.revokeOffer(ctx, driver, offer)
+			return true, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// assignDriver занимает водителя, принявшего оффер, и публикует его ответ. В той же транзакции
+// запрос поиска отмечается обработанным; errRequestAlreadyServed — поездку уже назначила
+// параллельная доставка того же запроса, водитель не занимается.
+func (s *Service) assignDriver(ctx context.Context, req models.RideRequestedMessage, driver models.DriverWithDistance, offer models.RideOffer, servedClass *types.VehicleClass) error {
 	// Пытаемся заблокировать водителя
 	if err := s.infra.trm.Do(ctx, func(ctx context.Context) error {
-		old, err := s.repos.driver.ChangeStatus(ctx, driver.ID, types.StatusDriverBusy)
+		if req.MessageID != "" {
+			first, err := s.repos.processed.Claim(ctx, searchDriverConsumer, req.MessageID)
+			if err != nil {
+				return fmt.Errorf("%w: %w", types.ErrDatabaseFailed, err)
+			}
+			if !first {
+				return errRequestAlreadyServed
+			}
+		}
+
+		old, err := s.changeStatus(ctx, driver.ID, types.StatusDriverBusy)
 		if err != nil {
 			s.l.Error(ctx, "failed to change driver status", err)
 			return err
@@ -104,31 +199,66 @@ func (s *Service) offerRideToDriver(ctx context.Context, correlationID string, d
 			Accepted:                true,
 			EstimatedArrivalMinutes: s.logic.calculate.Duration(driver.DistanceKm),
 			DriverLocation:          driver.Location,
-			CorrelationID:           correlationID,
+			CorrelationID:           req.CorrelationID,
 			DriverInfo: models.DriverInfo{
 				Name:    driver.Name,
 				Rating:  driver.Rating,
 				Vehicle: driver.Vehicle,
 			},
+			ServedVehicleClass: servedClass,
 		}); err != nil {
 			s.l.Error(ctx, "failed to publish driver response", err)
 			return err
 		}
 		return nil
 	}); err != nil {
-		return false, err
+		return err
 	}
 
 	s.l.Info(ctx, "driver accepted the ride offer")
-	return true, nil
+	return nil
+}
+
+// recordOfferOutcome сохраняет ответ водителя для расчёта acceptance rate (non fatal)
+func (s *Service) recordOfferOutcome(ctx context.Context, driverID uuid.UUID, offer models.RideOffer, accepted bool, err error) {
+	var outcome types.OfferOutcome
+	switch {
+	case err == nil && accepted:
+		outcome = types.OfferAccepted
+	case err == nil:
+		outcome = types.OfferDeclined
+	case errors.Is(err, types.ErrListenTimeout):
+		outcome = types.OfferExpired
+	default:
+		return // оффер не был доставлен водителю
+	}
+
+	if err := s.repos.driver.RecordOfferOutcome(ctx, offer.ID, driverID, offer.RideID, outcome); err != nil {
+		s.l.Warn(ctx, "failed to record offer outcome", "outcome", outcome, "error", err.Error())
+	}
+}
+
+// recentDeclines возвращает водителей, отказавшихся от поездки в пределах DeclineCooldown (non fatal)
+func (s *Service) recentDeclines(ctx context.Context, rideID uuid.UUID) []uuid.UUID {
+	cooldown := s.logic.dispatch.DeclineCooldown
+	if cooldown <= 0 {
+		return nil
+	}
+
+	declined, err := s.repos.driver.RecentDeclines(ctx, rideID, s.infra.clock.Now().Add(-cooldown))
+	if err != nil {
+		s.l.Warn(ctx, "failed to get drivers who declined the ride", "error", err.Error())
+		return nil
+	}
+	return declined
 }
 
 // Основной цикл поиска водителя с тикером и таймером
 func (s *Service) waitForDriverAcceptance(ctx context.Context, req models.RideRequestedMessage, offer models.RideOffer) error {
 	// общий таймаут поиска
-	searchTimeout := 2 * time.Minute
+	searchTimeout := s.logic.dispatch.SearchTimeout
 	// интервал между попытками (отсчитывается после каждой попытки)
-	interval := 5 * time.Second
+	interval := s.logic.dispatch.RetryInterval
 
 	timeout := time.NewTimer(searchTimeout)
 	defer timeout.Stop()
@@ -139,25 +269,34 @@ func (s *Service) waitForDriverAcceptance(ctx context.Context, req models.RideRe
 	// а потом Reset после первой попытки. Но здесь мы просто сбросим его после первой попытки.
 	defer tick.Stop()
 
+	searchStart := s.infra.clock.Now()
+	attempt := 0
+
 	trySearch := func() (bool, error) {
+		attempt++
 		loc := models.Location{
 			Latitude:  req.PickupLocation.Latitude,
 			Longitude: req.PickupLocation.Longitude,
 			Address:   req.PickupLocation.Address,
 		}
 
-		drivers, err := s.searchAvailableDrivers(ctx, req.RideType, loc)
-		if err != nil {
+		// радиус растет с каждой попыткой до MaxRadiusKm
+		radius := s.logic.dispatch.radius(attempt)
+
+		drivers, err := s.searchAvailableDrivers(ctx, req.RideType, loc, radius, req.PassengerID, req.IsTest)
+		if err != nil && !errors.Is(err, types.ErrDriversNotFound) {
 			return false, err
 		}
 
-		for _, driver := range drivers {
-			accepted, _ := s.offerRideToDriver(ctx, req.CorrelationID, driver, offer)
-			if accepted {
-				return true, nil
-			}
+		if s.offerRideToDrivers(ctx, req, drivers, offer, searchStart, nil) {
+			return true, nil
 		}
-		return false, nil
+
+		// водители заказанного класса не найдены или отказались — после AfterTicks попыток пробуем смежные классы
+		if s.offerAdjacentClasses(ctx, req, loc, radius, offer, attempt, searchStart) {
+			return true, nil
+		}
+		return false, err
 	}
 
 	// Первая попытка сразу
@@ -183,6 +322,9 @@ func (s *Service) waitForDriverAcceptance(ctx context.Context, req models.RideRe
 	for {
 		select {
 		case <-ctx.Done():
+			if errors.Is(context.Cause(ctx), types.ErrSearchInterrupted) {
+				return types.ErrSearchInterrupted
+			}
 			return fmt.Errorf("driver search stop: (ctx Done)")
 		case <-timeout.C:
 			return types.ErrDriverSearchTimeout
@@ -240,9 +382,23 @@ func (s *Service) HandleRideStatus(ctx context.Context, req models.RideStatusUpd
 
 	switch req.Status {
 	case types.StatusCancelled.String():
-		if err := s.cancelRide(ctx, *req.DriverID); err != nil {
+		if err := s.cancelRide(ctx, *req.DriverID, req); err != nil {
 			return wrap.Error(ctx, err)
 		}
+		s.notifyRideReleased(ctx, *req.DriverID, req)
+
+	case types.StatusRequested.String():
+		// поездка вернулась в поиск при переназначении оператором или сверкой зависших поездок
+		if req.ReleasedBy != types.RoleAdmin && req.ReleasedBy != types.RoleSystem {
+			s.l.Warn(ctx, "unexpected requested status update", "released_by", req.ReleasedBy)
+			return nil
+		}
+		if err := s.infra.trm.Do(ctx, func(ctx context.Context) error {
+			return s.releaseDriver(ctx, *req.DriverID, req.RideID)
+		}); err != nil {
+			return wrap.Error(ctx, err)
+		}
+		s.notifyRideReleased(ctx, *req.DriverID, req)
 
 	case types.StatusMatched.String():
 		if err := s.processMatchedRide(ctx, *req.DriverID, req.RideID); err != nil {
@@ -254,10 +410,14 @@ func (s *Service) HandleRideStatus(ctx context.Context, req models.RideStatusUpd
 			return wrap.Error(ctx, err)
 		}
 
-		// Track him in a real time
+		// Track him in a real time, until the assignment is released
+		ctx, stop := s.logic.assignments.track(ctx, *req.DriverID)
+		defer stop()
+
+		arrival := newArrivalDetector(s.logic.arrival)
 		if err := s.infra.communicator.ListenLocationUpdates(ctx, *req.DriverID, req.RideID,
 			func(ctx context.Context, current models.RideLocationUpdate) error {
-				return s.processDriverLocation(ctx, current, *finalDest)
+				return s.processDriverLocation(ctx, current, *finalDest, arrival)
 			}); err != nil {
 			return wrap.Error(ctx, err)
 		}
@@ -268,10 +428,77 @@ func (s *Service) HandleRideStatus(ctx context.Context, req models.RideStatusUpd
 	return nil
 }
 
-func (s *Service) cancelRide(ctx context.Context, driverID uuid.UUID) error {
-	if _, err := s.repos.driver.ChangeStatus(ctx, driverID, types.StatusDriverAvailable); err != nil {
-		return fmt.Errorf("failed to change driver status to available after ride cancellation: %w", err)
+func (s *Service) cancelRide(ctx context.Context, driverID uuid.UUID, req models.RideStatusUpdateMessage) error {
+	return s.infra.trm.Do(ctx, func(ctx context.Context) error {
+		if err := s.releaseDriver(ctx, driverID, req.RideID); err != nil {
+			return err
+		}
+
+		if req.CancellationFee == nil || *req.CancellationFee <= 0 {
+			return nil
+		}
+		return s.creditCancellationFee(ctx, driverID, req)
+	})
+}
+
+// releaseDriver возвращает водителя, снятого с поездки rideID, в статус AVAILABLE.
+// Водитель, успевший уйти с линии или получить другую поездку, остаётся в своём статусе.
+func (s *Service) releaseDriver(ctx context.Context, driverID, rideID uuid.UUID) error {
+	released, err := s.repos.driver.ReleaseFromRide(ctx, driverID, rideID)
+	if err != nil {
+		return fmt.Errorf("failed to change driver status to available after ride release: %w", err)
 	}
+	if !released {
+		s.l.Info(ctx, "driver not released: not on the released ride", "driver_id", driverID.String(), "ride_id", rideID.String())
+		return nil
+	}
+
+	s.publishStatusChange(ctx, driverID, types.StatusDriverAvailable)
+	return nil
+}
+
+// notifyRideReleased прекращает отслеживание поездки и сообщает водителю, что он снят с неё
+func (s *Service) notifyRideReleased(ctx context.Context, driverID uuid.UUID, req models.RideStatusUpdateMessage) {
+	s.logic.assignments.stopTracking(driverID)
+
+	if err := s.infra.communicator.SendRideReleased(ctx, driverID, models.RideReleased{
+		RideID:     req.RideID,
+		Status:     req.Status,
+		ReleasedBy: req.ReleasedBy,
+		Reason:     req.Reason,
+		Timestamp:  s.infra.clock.Now(),
+	}); err != nil {
+		s.l.Warn(ctx, "failed to notify driver about ride release", "error", err)
+	}
+}
+
+// creditCancellationFee зачисляет водителю штраф за отмену пассажиром.
+// Повторная доставка того же сообщения не зачисляет штраф второй раз.
+func (s *Service) creditCancellationFee(ctx context.Context, driverID uuid.UUID, req models.RideStatusUpdateMessage) error {
+	if req.MessageID != "" {
+		first, err := s.repos.processed.Claim(ctx, cancellationFeeConsumer, req.MessageID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", types.ErrDatabaseFailed, err)
+		}
+		if !first {
+			s.l.Info(ctx, "duplicate cancellation fee skipped", "message_id", req.MessageID)
+			return nil
+		}
+	}
+
+	fee := *req.CancellationFee
+	if err := s.repos.driver.UpdateStats(ctx, driverID, 0, fee); err != nil {
+		return fmt.Errorf("failed to credit cancellation fee: %w", err)
+	}
+	if err := s.repos.driver.CreditBalance(ctx, driverID, &req.RideID, types.DriverLedgerCancellationFee, fee); err != nil {
+		return fmt.Errorf("failed to credit cancellation fee to balance: %w", err)
+	}
+	// у водителя, ни разу не выходившего на линию, сессии нет — штраф попадает только в общий заработок
+	if err := s.repos.session.Update(ctx, driverID, 0, fee); err != nil && !errors.Is(err, types.ErrSessionNotFound) {
+		return fmt.Errorf("failed to credit cancellation fee to session: %w", err)
+	}
+
+	s.l.Info(ctx, "cancellation fee credited to driver", "fee", fee)
 	return nil
 }
 
@@ -288,7 +515,7 @@ func (s *Service) processMatchedRide(ctx context.Context, driverID, rideID uuid.
 		}
 		details.DriverID = &driverID
 
-		if _, err := s.repos.driver.ChangeStatus(ctx, driverID, types.StatusDriverEnRoute); err != nil {
+		if _, err := s.changeStatus(ctx, driverID, types.StatusDriverEnRoute); err != nil {
 			return fmt.Errorf("failed to change driver status: %w", err)
 		}
 
@@ -300,7 +527,7 @@ func (s *Service) processMatchedRide(ctx context.Context, driverID, rideID uuid.
 		if err := s.infra.publisher.PublishDriverStatus(ctx, models.DriverStatusUpdateMessage{
 			DriverID:  driverID,
 			Status:    types.StatusDriverEnRoute.String(),
-			Timestamp: time.Now(),
+			Timestamp: s.infra.clock.Now(),
 			RideID:    &details.RideID,
 		}); err != nil {
 			return fmt.Errorf("failed to publish driver status: %w", err)
@@ -310,7 +537,7 @@ func (s *Service) processMatchedRide(ctx context.Context, driverID, rideID uuid.
 	})
 }
 
-func (s *Service) processDriverLocation(ctx context.Context, current models.RideLocationUpdate, destination models.Location) error {
+func (s *Service) processDriverLocation(ctx context.Context, current models.RideLocationUpdate, destination models.Location, arrival *arrivalDetector) error {
 	if current.RideID == nil {
 		c, ok := ctx.Value(wrap.LogCtxKey).(wrap.LogCtx)
 
@@ -330,6 +557,10 @@ func (s *Service) processDriverLocation(ctx context.Context, current models.Ride
 	}
 
 	if _, err := s.UpdateLocation(ctx, current); err != nil {
+		// точку уже приняли по другому каналу или пришла более свежая
+		if errors.Is(err, types.ErrLocationDiscarded) {
+			return nil
+		}
 		return err
 	}
 
@@ -342,12 +573,17 @@ func (s *Service) processDriverLocation(ctx context.Context, current models.Ride
 		return nil
 	}
 
-	if !s.logic.calculate.IsDriverArrived(current.Location.Latitude, current.Location.Longitude, destination.Latitude, destination.Longitude) {
+	at := current.TimeStamp
+	if at.IsZero() {
+		at = s.infra.clock.Now()
+	}
+	within := s.logic.calculate.IsDriverArrived(current.Location.Latitude, current.Location.Longitude, destination.Latitude, destination.Longitude)
+	if !arrival.observe(within, at) {
 		return nil
 	}
 
 	if err := s.infra.trm.Do(ctx, func(ctx context.Context) error {
-		if _, err := s.repos.driver.ChangeStatus(ctx, current.DriverID, types.StatusDriverArrived); err != nil {
+		if _, err := s.changeStatus(ctx, current.DriverID, types.StatusDriverArrived); err != nil {
 			return fmt.Errorf("failed to change driver status: %w", err)
 		}
 
@@ -356,7 +592,7 @@ func (s *Service) processDriverLocation(ctx context.Context, current models.Ride
 			models.DriverStatusUpdateMessage{
 				DriverID:  current.DriverID,
 				Status:    types.StatusDriverArrived.String(),
-				Timestamp: time.Now(),
+				Timestamp: s.infra.clock.Now(),
 				RideID:    current.RideID,
 			}); err != nil {
 			return fmt.Errorf("failed to publish driver status: %w", err)
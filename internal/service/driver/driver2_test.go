@@ -0,0 +1,135 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memProcessed повторяет processed_messages: первая отметка сообщения выигрывает
+type memProcessed map[string]bool
+
+func (p memProcessed) Claim(_ context.Context, consumer, messageID string) (bool, error) {
+	key := consumer + "/" + messageID
+	if p[key] {
+		return false, nil
+	}
+	p[key] = true
+	return true, nil
+}
+
+func (p memProcessed) Processed(_ context.Context, consumer, messageID string) (bool, error) {
+	return p[consumer+"/"+messageID], nil
+}
+
+type countingResponses struct {
+	Publisher
+	responses int
+}
+
+func (p *countingResponses) PublishDriverResponse(context.Context, models.DriverMatchResponse) error {
+	p.responses++
+	return nil
+}
+
+// Сообщение отмечается обработанным только вместе с назначением водителя: параллельная
+// доставка того же запроса водителя не занимает, а после назначения поиск не запускается.
+func TestAssignDriver_ClaimsRequest(t *testing.T) {
+	drivers := newCountingDriverRepo(2)
+	ids := drivers.ids()
+	processed := memProcessed{}
+	publisher := &countingResponses{}
+
+	s := &Service{
+		repos: repos{driver: drivers, processed: processed},
+		logic: logic{calculate: ridecalc.New(), candidates: newCandidateCache(time.Minute), searches: newSearchTracker()},
+		infra: infra{publisher: publisher, trm: inlineTxManager{}},
+		l:     logger.InitLogger("test", "error"),
+	}
+	ctx := context.Background()
+	req := models.RideRequestedMessage{RideID: uuid.New(), MessageID: uuid.New().String()}
+	offer := models.RideOffer{ID: uuid.New(), RideID: req.RideID}
+
+	if done, _ := processed.Processed(ctx, searchDriverConsumer, req.MessageID); done {
+		t.Fatal("request marked processed before a driver was assigned")
+	}
+
+	if err := s.assignDriver(ctx, req, models.DriverWithDistance{ID: ids[0]}, offer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if drivers.drivers[ids[0]].Status != types.StatusDriverBusy {
+		t.Fatalf("assigned driver status %s, want BUSY", drivers.drivers[ids[0]].Status)
+	}
+
+	// вторая доставка нашла другого водителя, но запрос уже обслужен
+	err := s.assignDriver(ctx, req, models.DriverWithDistance{ID: ids[1]}, offer, nil)
+	if !errors.Is(err, errRequestAlreadyServed) {
+		t.Fatalf("got %v, want errRequestAlreadyServed", err)
+	}
+	if drivers.drivers[ids[1]].Status != types.StatusDriverAvailable || publisher.responses != 1 {
+		t.Fatalf("duplicate delivery took driver %s, published %d responses", drivers.drivers[ids[1]].Status, publisher.responses)
+	}
+
+	if err := s.SearchDriver(ctx, req); err != nil {
+		t.Fatalf("redelivered served request: %v", err)
+	}
+	if publisher.responses != 1 {
+		t.Fatalf("redelivered served request published %d responses", publisher.responses)
+	}
+}
+
+// assignedDrivers помнит текущую поездку водителя, как rides в БД
+type assignedDrivers struct {
+	*countingDriverRepo
+	rides map[uuid.UUID]uuid.UUID
+}
+
+func (r *assignedDrivers) ReleaseFromRide(_ context.Context, driverID, rideID uuid.UUID) (bool, error) {
+	d := r.drivers[driverID]
+	if d.Status == types.StatusDriverOffline || d.Status == types.StatusDriverAvailable {
+		return false, nil
+	}
+	if current, ok := r.rides[driverID]; ok && current != rideID {
+		return false, nil
+	}
+	d.Status = types.StatusDriverAvailable
+	r.drivers[driverID] = d
+	return true, nil
+}
+
+// Запоздавшая отмена прошлой поездки не освобождает водителя, которому уже назначена новая
+func TestCancelRide_KeepsDriverOnAnotherRide(t *testing.T) {
+	drivers := &assignedDrivers{countingDriverRepo: newCountingDriverRepo(1), rides: map[uuid.UUID]uuid.UUID{}}
+	driverID := drivers.ids()[0]
+	s := &Service{
+		repos: repos{driver: drivers},
+		logic: logic{candidates: newCandidateCache(time.Minute), searches: newSearchTracker()},
+		infra: infra{trm: inlineTxManager{}},
+		l:     logger.InitLogger("test", "error"),
+	}
+	ctx := context.Background()
+
+	released, current := uuid.New(), uuid.New()
+	drivers.drivers[driverID] = models.Driver{ID: driverID, Status: types.StatusDriverBusy}
+	drivers.rides[driverID] = current
+	if err := s.cancelRide(ctx, driverID, models.RideStatusUpdateMessage{RideID: released, Status: types.StatusCancelled.String()}); err != nil {
+		t.Fatal(err)
+	}
+	if status := drivers.drivers[driverID].Status; status != types.StatusDriverBusy {
+		t.Fatalf("driver on another ride got status %s, want BUSY", status)
+	}
+
+	if err := s.cancelRide(ctx, driverID, models.RideStatusUpdateMessage{RideID: current, Status: types.StatusCancelled.String()}); err != nil {
+		t.Fatal(err)
+	}
+	if status := drivers.drivers[driverID].Status; status != types.StatusDriverAvailable {
+		t.Fatalf("driver released from the current ride got status %s, want AVAILABLE", status)
+	}
+}
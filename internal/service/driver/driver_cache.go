@@ -0,0 +1,196 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// CachePublisher рассылает сброс кэша всем экземплярам (pkg/invalidation)
+type CachePublisher interface {
+	Publish(ctx context.Context, cache, key string) error
+}
+
+type driverCacheEntry struct {
+	driver    *models.Driver // nil — запись сброшена
+	expiresAt time.Time
+	version   uint64
+	// dirty — водитель изменен через кэш, и изменение может быть еще не закоммичено
+	dirty bool
+}
+
+// DriverCache — read-through кэш профилей водителей поверх DriverRepo с коротким TTL.
+// Профиль читается на каждом шаге поездки и при каждом обновлении координат.
+//
+// Изменения статуса и профиля через кэш сбрасывают запись сразу и рассылают событие invalidation
+// остальным экземплярам (внутри транзакции — после commit). Изменения других сервисов
+// (администратор) приходят тем же событием, TTL страхует от потерянных событий и от изменений
+// без события (рейтинг). Транзакция, изменившая водителя, читает свои незакоммиченные данные,
+// поэтому после изменения чтение в транзакции не сохраняется в кэш до события о commit.
+type DriverCache struct {
+	DriverRepo
+	caches CachePublisher
+	ttl    time.Duration
+
+	mu      sync.RWMutex
+	entries map[uuid.UUID]driverCacheEntry
+	// version растет при каждом сбросе и сохранении: чтение, начатое до сброса, не вернет старые данные в кэш
+	version uint64
+
+	l logger.Logger
+}
+
+func NewDriverCache(repo DriverRepo, caches CachePublisher, ttl time.Duration, l logger.Logger) *DriverCache {
+	return &DriverCache{
+		DriverRepo: repo,
+		caches:     caches,
+		ttl:        ttl,
+		entries:    make(map[uuid.UUID]driverCacheEntry),
+		l:          l,
+	}
+}
+
+// Get возвращает копию профиля из кэша или читает его из БД
+func (c *DriverCache) Get(ctx context.Context, driverID uuid.UUID) (*models.Driver, error) {
+	c.mu.RLock()
+	entry := c.entries[driverID]
+	c.mu.RUnlock()
+
+	if entry.driver != nil && time.Now().Before(entry.expiresAt) {
+		metrics.DriverCacheTotal.WithLabelValues("hit").Inc()
+		driver := *entry.driver
+		return &driver, nil
+	}
+	metrics.DriverCacheTotal.WithLabelValues("miss").Inc()
+
+	driver, err := c.DriverRepo.Get(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	cached := *driver
+	c.mu.Lock()
+	if c.entries[driverID].version == entry.version && !(entry.dirty && trm.InTx(ctx)) {
+		c.version++
+		c.entries[driverID] = driverCacheEntry{
+			driver:    &cached,
+			expiresAt: time.Now().Add(c.ttl),
+			version:   c.version,
+		}
+	}
+	c.mu.Unlock()
+
+	return driver, nil
+}
+
+// IsDriverExist проверяется на каждом обновлении координат, водители не удаляются,
+// поэтому ответ берется из кэша профиля
+func (c *DriverCache) IsDriverExist(ctx context.Context, driverID uuid.UUID) (bool, error) {
+	if _, err := c.Get(ctx, driverID); err != nil {
+		if errors.Is(err, types.ErrUserNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *DriverCache) ChangeStatus(ctx context.Context, driverID uuid.UUID, newStatus types.DriverStatus) (types.DriverStatus, error) {
+	old, err := c.DriverRepo.ChangeStatus(ctx, driverID, newStatus)
+	if err != nil {
+		return old, err
+	}
+	c.changed(ctx, driverID)
+	return old, nil
+}
+
+func (c *DriverCache) ReleaseFromRide(ctx context.Context, driverID, rideID uuid.UUID) (bool, error) {
+	released, err := c.DriverRepo.ReleaseFromRide(ctx, driverID, rideID)
+	if err != nil || !released {
+		return released, err
+	}
+	c.changed(ctx, driverID)
+	return true, nil
+}
+
+func (c *DriverCache) UpdateStats(ctx context.Context, driverID uuid.UUID, ridesCompleted int, earnings float64) error {
+	if err := c.DriverRepo.UpdateStats(ctx, driverID, ridesCompleted, earnings); err != nil {
+		return err
+	}
+	c.changed(ctx, driverID)
+	return nil
+}
+
+func (c *DriverCache) UpdateTier(ctx context.Context, driverID uuid.UUID, tier types.DriverTier) error {
+	if err := c.DriverRepo.UpdateTier(ctx, driverID, tier); err != nil {
+		return err
+	}
+	c.changed(ctx, driverID)
+	return nil
+}
+
+func (c *DriverCache) UpdateName(ctx context.Context, driverID uuid.UUID, name string) error {
+	if err := c.DriverRepo.UpdateName(ctx, driverID, name); err != nil {
+		return err
+	}
+	c.changed(ctx, driverID)
+	return nil
+}
+
+func (c *DriverCache) SetVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.Vehicle) error {
+	if err := c.DriverRepo.SetVehicle(ctx, driverID, vehicle); err != nil {
+		return err
+	}
+	c.changed(ctx, driverID)
+	return nil
+}
+
+// Invalidate сбрасывает профиль водителя из ключа события, пустой ключ — весь кэш
+func (c *DriverCache) Invalidate(_ context.Context, e invalidation.Event) {
+	if e.Key == "" {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// сброшенные записи сохраняются с новой версией, иначе начатое до сброса чтение вернет их в кэш
+		c.version++
+		for id, entry := range c.entries {
+			c.entries[id] = driverCacheEntry{version: c.version, dirty: entry.dirty}
+		}
+		return
+	}
+
+	driverID, err := uuid.Parse(e.Key)
+	if err != nil {
+		return
+	}
+	c.invalidate(driverID, false)
+}
+
+func (c *DriverCache) invalidate(driverID uuid.UUID, dirty bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.entries[driverID] = driverCacheEntry{version: c.version, dirty: dirty}
+}
+
+// changed сбрасывает запись локально и рассылает сброс остальным экземплярам.
+// Ошибка рассылки только логируется: изменение уже сделано, устаревшую запись вытеснит TTL.
+func (c *DriverCache) changed(ctx context.Context, driverID uuid.UUID) {
+	c.invalidate(driverID, trm.InTx(ctx))
+
+	if c.caches == nil {
+		return
+	}
+	if err := c.caches.Publish(ctx, types.CacheDrivers, driverID.String()); err != nil {
+		c.l.Warn(ctx, "failed to publish cache invalidation", "cache", types.CacheDrivers, "driver_id", driverID.String(), "error", err)
+	}
+}
@@ -0,0 +1,186 @@
+package drivergo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// countingDriverRepo хранит водителей в памяти и считает чтения из "БД"
+type countingDriverRepo struct {
+	DriverRepo
+	drivers map[uuid.UUID]models.Driver
+	reads   atomic.Int64
+}
+
+func newCountingDriverRepo(n int) *countingDriverRepo {
+	r := &countingDriverRepo{drivers: make(map[uuid.UUID]models.Driver, n)}
+	for range n {
+		id := uuid.New()
+		r.drivers[id] = models.Driver{ID: id, Status: types.StatusDriverAvailable}
+	}
+	return r
+}
+
+func (r *countingDriverRepo) Get(_ context.Context, driverID uuid.UUID) (*models.Driver, error) {
+	r.reads.Add(1)
+	d, ok := r.drivers[driverID]
+	if !ok {
+		return nil, types.ErrUserNotFound
+	}
+	return &d, nil
+}
+
+func (r *countingDriverRepo) IsDriverExist(_ context.Context, driverID uuid.UUID) (bool, error) {
+	r.reads.Add(1)
+	_, ok := r.drivers[driverID]
+	return ok, nil
+}
+
+func (r *countingDriverRepo) ChangeStatus(_ context.Context, driverID uuid.UUID, status types.DriverStatus) (types.DriverStatus, error) {
+	d := r.drivers[driverID]
+	old := d.Status
+	d.Status = status
+	r.drivers[driverID] = d
+	return old, nil
+}
+
+func (r *countingDriverRepo) ids() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(r.drivers))
+	for id := range r.drivers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestDriverCache(t *testing.T) {
+	ctx := context.Background()
+
+	get := func(t *testing.T, c *DriverCache, id uuid.UUID) *models.Driver {
+		t.Helper()
+		d, err := c.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		return d
+	}
+
+	t.Run("read through", func(t *testing.T) {
+		repo := newCountingDriverRepo(1)
+		c := NewDriverCache(repo, nil, time.Minute, nil)
+		id := repo.ids()[0]
+
+		get(t, c, id)
+		get(t, c, id)
+		if got := repo.reads.Load(); got != 1 {
+			t.Fatalf("reads = %d, want 1", got)
+		}
+	})
+
+	t.Run("status change invalidates", func(t *testing.T) {
+		repo := newCountingDriverRepo(1)
+		c := NewDriverCache(repo, nil, time.Minute, nil)
+		id := repo.ids()[0]
+
+		get(t, c, id)
+		if _, err := c.ChangeStatus(ctx, id, types.StatusDriverBusy); err != nil {
+			t.Fatalf("ChangeStatus() error = %v", err)
+		}
+		if d := get(t, c, id); d.Status != types.StatusDriverBusy {
+			t.Fatalf("status = %s, want %s", d.Status, types.StatusDriverBusy)
+		}
+	})
+
+	t.Run("invalidation event", func(t *testing.T) {
+		repo := newCountingDriverRepo(2)
+		c := NewDriverCache(repo, nil, time.Minute, nil)
+		ids := repo.ids()
+
+		get(t, c, ids[0])
+		get(t, c, ids[1])
+		c.Invalidate(ctx, invalidation.Event{Cache: types.CacheDrivers, Key: ids[0].String()})
+		get(t, c, ids[0])
+		get(t, c, ids[1])
+		if got := repo.reads.Load(); got != 3 {
+			t.Fatalf("reads after keyed event = %d, want 3", got)
+		}
+
+		c.Invalidate(ctx, invalidation.Event{Cache: types.CacheDrivers})
+		get(t, c, ids[0])
+		get(t, c, ids[1])
+		if got := repo.reads.Load(); got != 5 {
+			t.Fatalf("reads after full flush = %d, want 5", got)
+		}
+	})
+
+	t.Run("expires after ttl", func(t *testing.T) {
+		repo := newCountingDriverRepo(1)
+		c := NewDriverCache(repo, nil, time.Millisecond, nil)
+		id := repo.ids()[0]
+
+		get(t, c, id)
+		time.Sleep(2 * time.Millisecond)
+		get(t, c, id)
+		if got := repo.reads.Load(); got != 2 {
+			t.Fatalf("reads = %d, want 2", got)
+		}
+	})
+
+	t.Run("returns copies", func(t *testing.T) {
+		repo := newCountingDriverRepo(1)
+		c := NewDriverCache(repo, nil, time.Minute, nil)
+		id := repo.ids()[0]
+
+		get(t, c, id).Name = "changed by caller"
+		if d := get(t, c, id); d.Name != "" {
+			t.Fatalf("cached name = %q, want unchanged", d.Name)
+		}
+	})
+
+	t.Run("unknown driver", func(t *testing.T) {
+		c := NewDriverCache(newCountingDriverRepo(0), nil, time.Minute, nil)
+
+		exist, err := c.IsDriverExist(ctx, uuid.New())
+		if err != nil || exist {
+			t.Fatalf("IsDriverExist() = %v, %v, want false, nil", exist, err)
+		}
+	})
+}
+
+// BenchmarkDriverLocationUpdates — поток обновлений координат 1000 водителей, каждое проверяет
+// водителя в БД, каждое 50-е обновление меняет статус водителя. db_reads/op — доля обращений к БД.
+func BenchmarkDriverLocationUpdates(b *testing.B) {
+	run := func(b *testing.B, repo *countingDriverRepo, drivers DriverRepo) {
+		ctx := context.Background()
+		ids := repo.ids()
+
+		b.ResetTimer()
+		for i := range b.N {
+			id := ids[i%len(ids)]
+			if _, err := drivers.IsDriverExist(ctx, id); err != nil {
+				b.Fatal(err)
+			}
+			if i%50 == 0 {
+				if _, err := drivers.ChangeStatus(ctx, id, types.StatusDriverBusy); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(repo.reads.Load())/float64(b.N), "db_reads/op")
+	}
+
+	b.Run("repo", func(b *testing.B) {
+		repo := newCountingDriverRepo(1000)
+		run(b, repo, repo)
+	})
+	b.Run("cache", func(b *testing.B) {
+		repo := newCountingDriverRepo(1000)
+		run(b, repo, NewDriverCache(repo, nil, 5*time.Second, nil))
+	})
+}
@@ -0,0 +1,76 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// earningsBuckets — сколько последних периодов входит в отчет, включая текущий
+var earningsBuckets = map[types.EarningsPeriod]int{
+	types.EarningsDaily:   30,
+	types.EarningsWeekly:  12,
+	types.EarningsMonthly: 12,
+}
+
+// EarningsReport собирает заработок водителя по дням, неделям или месяцам (UTC).
+// Средний заработок за поездку считается по заработку смен и завершенным поездкам периода.
+func (s *Service) EarningsReport(ctx context.Context, driverID uuid.UUID, period types.EarningsPeriod) (*models.EarningsReport, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "driver_earnings_report",
+		DriverID: driverID.String(),
+	})
+
+	buckets, ok := earningsBuckets[period]
+	if !ok {
+		return nil, wrap.Error(ctx, fmt.Errorf("unsupported earnings period %q", period))
+	}
+
+	exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to check driver existence: %w", err))
+	}
+	if !exist {
+		return nil, wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	earnings, err := s.repos.driver.GetEarnings(ctx, driverID, period, buckets)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to get earnings: %w", err))
+	}
+
+	report := &models.EarningsReport{
+		DriverID: driverID,
+		Period:   period,
+		Buckets:  earnings,
+	}
+
+	for i := range report.Buckets {
+		b := &report.Buckets[i]
+
+		report.Total.Earnings += b.Earnings
+		report.Total.CompletedRides += b.CompletedRides
+		report.Total.OnlineHours += b.OnlineHours
+
+		b.Earnings = roundMoney(b.Earnings)
+		b.OnlineHours = roundMoney(b.OnlineHours)
+		b.AvgPerRide = avgPerRide(b.Earnings, b.CompletedRides)
+	}
+
+	report.Total.Earnings = roundMoney(report.Total.Earnings)
+	report.Total.OnlineHours = roundMoney(report.Total.OnlineHours)
+	report.Total.AvgPerRide = avgPerRide(report.Total.Earnings, report.Total.CompletedRides)
+
+	return report, nil
+}
+
+func avgPerRide(earnings float64, rides int) float64 {
+	if rides == 0 {
+		return 0
+	}
+	return roundMoney(earnings / float64(rides))
+}
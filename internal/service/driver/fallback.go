@@ -0,0 +1,53 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// ClassFallbackPolicy — когда поиск водителя переходит на смежные классы.
+// Если за AfterTicks попыток поиска водители заказанного класса не приняли оффер,
+// оффер получают и водители смежных классов, пассажир платит по заказанному классу.
+type ClassFallbackPolicy struct {
+	AfterTicks int // попыток поиска до перехода, 0 — выключено
+}
+
+// adjacentClasses — классы, которые могут выполнить заказ вместо заказанного.
+// XL вмещает пассажиров ECONOMY, PREMIUM и XL не заменяются: другие классы не дают их уровня или вместимости.
+var adjacentClasses = map[string][]types.VehicleClass{
+	string(types.ClassEconomy): {types.ClassXL},
+}
+
+// classes возвращает смежные классы для попытки поиска attempt (с 1), nil — переход еще не разрешен
+func (p ClassFallbackPolicy) classes(rideType string, attempt int) []types.VehicleClass {
+	if p.AfterTicks <= 0 || attempt <= p.AfterTicks {
+		return nil
+	}
+	return adjacentClasses[rideType]
+}
+
+// offerAdjacentClasses предлагает поездку водителям смежных классов по цене заказанного класса
+func (s *Service) offerAdjacentClasses(ctx context.Context, req models.RideRequestedMessage, loc models.Location, radiusKm float64, offer models.RideOffer, attempt int, searchStart time.Time) bool {
+	for _, class := range s.logic.fallback.classes(req.RideType, attempt) {
+		drivers, err := s.searchAvailableDrivers(ctx, string(class), loc, radiusKm, req.PassengerID, req.IsTest)
+		if err != nil {
+			if !errors.Is(err, types.ErrDriversNotFound) {
+				s.l.Warn(ctx, "adjacent class search failed", "served_class", class, "error", err)
+			}
+			continue
+		}
+
+		s.l.Info(ctx, "offering ride to adjacent vehicle class", "requested_class", req.RideType, "served_class", class, "attempt", attempt)
+
+		fallbackOffer := offer
+		fallbackOffer.RequestedClass = types.VehicleClass(req.RideType)
+		if s.offerRideToDrivers(ctx, req, drivers, fallbackOffer, searchStart, &class) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package drivergo
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/geoindex"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// geoIndexCellKm — сторона ячейки индекса, поиск в радиусе 5км обходит около 25 ячеек
+	geoIndexCellKm = 2
+	// geoIndexStaleSyncs — сколько перестроений подряд можно пропустить, прежде чем индекс считается устаревшим
+	geoIndexStaleSyncs = 3
+	// searchLimit — сколько кандидатов возвращает поиск, как LIMIT в SearchDrivers
+	searchLimit = 10
+)
+
+// GeoIndexPolicy — индекс координат свободных водителей в памяти вместо запроса PostGIS на каждую попытку поиска
+type GeoIndexPolicy struct {
+	Enabled bool
+	// SyncInterval — как часто индекс перестраивается из Postgres. Координаты других экземпляров
+	// и location-service приходят из потока брокера (TrackLocation), перестроение подхватывает
+	// пропущенные и убирает ушедших водителей.
+	SyncInterval time.Duration
+}
+
+// driverGeoIndex — координаты водителей в памяти. Postgres остается источником истины:
+// индекс только сужает поиск до водителей рядом, статус и данные водителя читаются из БД.
+type driverGeoIndex struct {
+	idx          *geoindex.Index
+	syncInterval time.Duration
+	syncedAt     atomic.Int64 // unix nano последнего успешного перестроения, 0 — не было
+}
+
+func newDriverGeoIndex(policy GeoIndexPolicy) *driverGeoIndex {
+	if !policy.Enabled {
+		return nil
+	}
+	return &driverGeoIndex{
+		idx:          geoindex.New(geoIndexCellKm),
+		syncInterval: policy.SyncInterval,
+	}
+}
+
+// ready — индекс перестраивался недавно, и ему можно доверять поиск
+func (g *driverGeoIndex) ready() bool {
+	syncedAt := g.syncedAt.Load()
+	if syncedAt == 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, syncedAt)) < geoIndexStaleSyncs*g.syncInterval
+}
+
+// trackLocation обновляет координату водителя в индексе после записи в БД.
+// Время — серверное, как updated_at в снимке перестроения, а не время устройства.
+func (s *Service) trackLocation(data models.RideLocationUpdate) {
+	if s.logic.geo == nil {
+		return
+	}
+	s.logic.geo.idx.Set(data.DriverID, geoindex.Point{Lat: data.Location.Latitude, Lon: data.Location.Longitude, UpdatedAt: time.Now()})
+}
+
+// TrackLocation обновляет индекс координатой из потока брокера: так экземпляр видит координаты,
+// записанные другими экземплярами и location-service, не дожидаясь перестроения
+func (s *Service) TrackLocation(_ context.Context, data models.RideLocationUpdate) error {
+	s.trackLocation(data)
+	return nil
+}
+
+// untrackDriver убирает водителя, ушедшего в офлайн, до следующего перестроения
+func (s *Service) untrackDriver(driverID uuid.UUID, status types.DriverStatus) {
+	if s.logic.geo == nil || status != types.StatusDriverOffline {
+		return
+	}
+	s.logic.geo.idx.Remove(driverID)
+}
+
+// searchDrivers ищет свободных водителей класса rideType в радиусе: через индекс координат,
+// если он включен и актуален, иначе запросом PostGIS
+func (s *Service) searchDrivers(ctx context.Context, rideType string, loc models.Location, radiusKm float64) ([]models.DriverWithDistance, error) {
+	geo := s.logic.geo
+	if geo == nil || !geo.ready() {
+		if geo != nil {
+			metrics.DriverGeoIndexLookupsTotal.WithLabelValues("fallback").Inc()
+		}
+		return s.repos.driver.SearchDrivers(ctx, rideType, loc, radiusKm, uuid.NilUUID)
+	}
+	metrics.DriverGeoIndexLookupsTotal.WithLabelValues("index").Inc()
+
+	hits := geo.idx.Nearby(loc.Latitude, loc.Longitude, radiusKm)
+	if len(hits) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+
+	drivers, err := s.repos.driver.AvailableDrivers(ctx, rideType, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range drivers {
+		drivers[i].DistanceKm = s.logic.calculate.Distance(loc, drivers[i].Location)
+	}
+	sortCandidates(rideType, drivers)
+	if len(drivers) > searchLimit {
+		drivers = drivers[:searchLimit]
+	}
+	return drivers, nil
+}
+
+// SyncGeoIndex перестраивает индекс координат из текущих координат свободных водителей
+func (s *Service) SyncGeoIndex(ctx context.Context) error {
+	geo := s.logic.geo
+	if geo == nil {
+		return nil
+	}
+
+	asOf := time.Now()
+	positions, err := s.repos.driver.AvailablePositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make(map[uuid.UUID]geoindex.Point, len(positions))
+	for _, p := range positions {
+		snapshot[p.DriverID] = geoindex.Point{Lat: p.Location.Latitude, Lon: p.Location.Longitude, UpdatedAt: p.UpdatedAt}
+	}
+	geo.idx.Replace(snapshot, asOf)
+	geo.syncedAt.Store(asOf.UnixNano())
+
+	metrics.DriverGeoIndexSize.Set(float64(geo.idx.Len()))
+	return nil
+}
+
+// RunGeoIndexJob периодически перестраивает индекс координат до отмены контекста
+func (s *Service) RunGeoIndexJob(ctx context.Context) {
+	geo := s.logic.geo
+	if geo == nil {
+		return
+	}
+
+	ticker := time.NewTicker(geo.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SyncGeoIndex(ctx); err != nil {
+			s.l.Error(ctx, "failed to sync driver geo index", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
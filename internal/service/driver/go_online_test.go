@@ -0,0 +1,209 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// onlineDriverRepo — водитель со статусом. stale повторяет READ COMMITTED при гонке:
+// ChangeStatus всех параллельных запросов видит статус до commit первого из них.
+type onlineDriverRepo struct {
+	DriverRepo
+	stale bool
+
+	mu       sync.Mutex
+	snapshot types.DriverStatus
+	status   types.DriverStatus
+}
+
+func (r *onlineDriverRepo) IsDriverExist(context.Context, uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+func (r *onlineDriverRepo) ChangeStatus(_ context.Context, _ uuid.UUID, status types.DriverStatus) (types.DriverStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.status
+	if r.stale {
+		old = r.snapshot
+	}
+	r.status = status
+	return old, nil
+}
+
+// openSessionRepo повторяет уникальный индекс открытых сессий
+type openSessionRepo struct {
+	DriverSessionRepo
+
+	mu     sync.Mutex
+	open   map[uuid.UUID]uuid.UUID
+	opened int
+}
+
+func (r *openSessionRepo) Create(_ context.Context, driverID uuid.UUID) (uuid.UUID, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id, ok := r.open[driverID]; ok {
+		return id, false, nil
+	}
+	id := uuid.New()
+	r.open[driverID] = id
+	r.opened++
+	return id, true, nil
+}
+
+type countingCoordinateRepo struct {
+	CoordinateRepo
+
+	mu      sync.Mutex
+	created int
+}
+
+func (r *countingCoordinateRepo) CreateCoordinate(context.Context, uuid.UUID, types.EntityType, models.Location, time.Time) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.created++
+	return uuid.New(), nil
+}
+
+type noopGeoCoder struct{}
+
+func (noopGeoCoder) GetAddress(context.Context, float64, float64) (string, error) { return "", nil }
+
+// inlineTxManager выполняет функцию без транзакции, параллельные вызовы не сериализуются
+type inlineTxManager struct{}
+
+func (inlineTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newGoOnlineService(drivers *onlineDriverRepo, sessions *openSessionRepo, coords *countingCoordinateRepo) *Service {
+	return New(drivers, sessions, coords, nil, nil, noopGeoCoder{}, nil, nil, nil, inlineTxManager{},
+		nil, nil, nil, nil, nil, nil, nil, 0, 0, false, ArrivalPolicy{}, ClassFallbackPolicy{}, DispatchPolicy{}, GeoIndexPolicy{}, clock.New(),
+		logger.InitLogger("test", "error"))
+}
+
+func TestServiceGoOnlineConcurrent(t *testing.T) {
+	const requests = 50
+
+	drivers := &onlineDriverRepo{stale: true, snapshot: types.StatusDriverOffline, status: types.StatusDriverOffline}
+	sessions := &openSessionRepo{open: make(map[uuid.UUID]uuid.UUID)}
+	coords := &countingCoordinateRepo{}
+	s := newGoOnlineService(drivers, sessions, coords)
+
+	driverID := uuid.New()
+	loc := models.Location{Latitude: 43.238949, Longitude: 76.889709}
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		ids   = make([]uuid.UUID, requests)
+		errs  = make([]error, requests)
+	)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ids[i], errs[i] = s.GoOnline(context.Background(), driverID, loc)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for i := range requests {
+		if errs[i] != nil {
+			t.Fatalf("request %d: GoOnline() error = %v", i, errs[i])
+		}
+		if ids[i] != ids[0] {
+			t.Fatalf("request %d: session %s, want the same session %s", i, ids[i], ids[0])
+		}
+	}
+	if sessions.opened != 1 {
+		t.Fatalf("opened sessions = %d, want 1", sessions.opened)
+	}
+	if coords.created != 1 {
+		t.Fatalf("saved coordinates = %d, want 1", coords.created)
+	}
+}
+
+func TestServiceGoOnlineAlreadyOnline(t *testing.T) {
+	drivers := &onlineDriverRepo{status: types.StatusDriverOffline}
+	sessions := &openSessionRepo{open: make(map[uuid.UUID]uuid.UUID)}
+	s := newGoOnlineService(drivers, sessions, &countingCoordinateRepo{})
+
+	driverID := uuid.New()
+	loc := models.Location{Latitude: 43.238949, Longitude: 76.889709}
+
+	if _, err := s.GoOnline(context.Background(), driverID, loc); err != nil {
+		t.Fatalf("first GoOnline() error = %v", err)
+	}
+	if _, err := s.GoOnline(context.Background(), driverID, loc); !errors.Is(err, types.ErrDriverAlreadyOnline) {
+		t.Fatalf("second GoOnline() error = %v, want %v", err, types.ErrDriverAlreadyOnline)
+	}
+}
+
+// phoneUserRepo — пользователи с подтвержденным телефоном
+type phoneUserRepo struct {
+	UserRepo
+	verified map[uuid.UUID]bool
+}
+
+func (r *phoneUserRepo) IsPhoneVerified(_ context.Context, userID uuid.UUID) (bool, error) {
+	return r.verified[userID], nil
+}
+
+func TestServiceGoOnlineRequiresVerifiedPhone(t *testing.T) {
+	verifiedID, unverifiedID := uuid.New(), uuid.New()
+	users := &phoneUserRepo{verified: map[uuid.UUID]bool{verifiedID: true}}
+	loc := models.Location{Latitude: 43.238949, Longitude: 76.889709}
+
+	tests := []struct {
+		name     string
+		required bool
+		driverID uuid.UUID
+		wantErr  error
+	}{
+		{"not required", false, unverifiedID, nil},
+		{"verified", true, verifiedID, nil},
+		{"unverified", true, unverifiedID, types.ErrPhoneNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drivers := &onlineDriverRepo{status: types.StatusDriverOffline}
+			sessions := &openSessionRepo{open: make(map[uuid.UUID]uuid.UUID)}
+			s := New(drivers, sessions, &countingCoordinateRepo{}, users, nil, noopGeoCoder{}, nil, nil, nil, inlineTxManager{},
+				nil, nil, nil, nil, nil, nil, nil, 0, 0, tt.required, ArrivalPolicy{}, ClassFallbackPolicy{}, DispatchPolicy{}, GeoIndexPolicy{}, clock.New(),
+				logger.InitLogger("test", "error"))
+
+			_, err := s.GoOnline(context.Background(), tt.driverID, loc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GoOnline() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && drivers.status != types.StatusDriverOffline {
+				t.Fatalf("driver status = %s, want %s", drivers.status, types.StatusDriverOffline)
+			}
+		})
+	}
+}
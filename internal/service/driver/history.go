@@ -0,0 +1,58 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// RideHistory возвращает страницу поездок, назначенных водителю, с фильтрами по статусу и дате запроса
+func (s *Service) RideHistory(ctx context.Context, driverID uuid.UUID, filter models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "driver_ride_history",
+		DriverID: driverID.String(),
+	})
+
+	exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to check driver existence: %w", err))
+	}
+	if !exist {
+		return nil, wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	filter.DriverID = &driverID
+	filter.PassengerID = nil
+
+	history, err := s.repos.ride.History(ctx, filter, filters)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return history, nil
+}
+
+// SessionHistory возвращает страницу сессий водителя на линии: время, поездки, заработок и пройденное расстояние
+func (s *Service) SessionHistory(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverSessionHistory, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "driver_session_history",
+		DriverID: driverID.String(),
+	})
+
+	exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to check driver existence: %w", err))
+	}
+	if !exist {
+		return nil, wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	history, err := s.repos.session.List(ctx, driverID, filters)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return history, nil
+}
@@ -17,28 +17,125 @@ type DriverRepo interface {
 	Create(ctx context.Context, driver *models.Driver) error
 	IsDriverExist(ctx context.Context, id uuid.UUID) (bool, error)
 	Get(ctx context.Context, driverID uuid.UUID) (*models.Driver, error)
-	SearchDrivers(ctx context.Context, rideType string, pickUplocation models.Location) ([]models.DriverWithDistance, error)
+	SearchDrivers(ctx context.Context, rideType string, pickUplocation models.Location, radiusKm float64, passengerID uuid.UUID) ([]models.DriverWithDistance, error)
 	ChangeStatus(ctx context.Context, driverID uuid.UUID, newStatus types.DriverStatus) (oldStatus types.DriverStatus, err error)
+	// ReleaseFromRide переводит водителя, снятого с поездки, в AVAILABLE, если ему не назначена другая поездка.
+	// false — статус не изменен.
+	ReleaseFromRide(ctx context.Context, driverID, rideID uuid.UUID) (released bool, err error)
 	UpdateStats(ctx context.Context, driverID uuid.UUID, ridesCompleted int, earnings float64) error
+	// зачисление заработка на баланс для мгновенного вывода
+	CreditBalance(ctx context.Context, driverID uuid.UUID, rideID *uuid.UUID, entryType types.DriverLedgerEntryType, amount float64) error
+	GetMonthlyEarnings(ctx context.Context, driverID uuid.UUID, year int) ([]models.MonthlyEarnings, error)
+	GetEarnings(ctx context.Context, driverID uuid.UUID, period types.EarningsPeriod, buckets int) ([]models.EarningsBucket, error)
+	GetRatings(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverRatingHistory, error)
+	DriverTierRepo
+	DriverProfileRepo
+	DriverVehicleRepo
+	DriverGeoRepo
+}
+
+// DriverGeoRepo — выборки для индекса координат водителей в памяти
+type DriverGeoRepo interface {
+	AvailablePositions(ctx context.Context) ([]models.DriverPosition, error)
+	AvailableDrivers(ctx context.Context, rideType string, driverIDs []uuid.UUID) ([]models.DriverWithDistance, error)
+}
+
+// DriverVehicleRepo хранит автомобили водителя, активный автомобиль дублируется в профиль (SetVehicle)
+type DriverVehicleRepo interface {
+	Vehicles(ctx context.Context, driverID uuid.UUID) ([]models.DriverVehicle, error)
+	GetVehicle(ctx context.Context, driverID, vehicleID uuid.UUID) (*models.DriverVehicle, error)
+	ActiveVehicle(ctx context.Context, driverID uuid.UUID) (*models.DriverVehicle, error)
+	CreateVehicle(ctx context.Context, v *models.DriverVehicle) error
+	UpdateVehicle(ctx context.Context, v *models.DriverVehicle) error
+	ActivateVehicle(ctx context.Context, driverID, vehicleID uuid.UUID) error
+	SetVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.Vehicle) error
+}
+
+// DriverProfileRepo изменяет профиль водителя и очередь изменений на одобрение
+type DriverProfileRepo interface {
+	UpdateName(ctx context.Context, driverID uuid.UUID, name string) error
+	SubmitChange(ctx context.Context, change *models.DriverChangeRequest) error
+	PendingChanges(ctx context.Context, driverID uuid.UUID) ([]models.DriverChangeRequest, error)
+}
+
+type DriverTierRepo interface {
+	RecordOfferOutcome(ctx context.Context, offerID, driverID, rideID uuid.UUID, outcome types.OfferOutcome) error
+	GetTierMetrics(ctx context.Context, since time.Time) ([]models.DriverTierMetrics, error)
+	DeclineRate(ctx context.Context, driverID uuid.UUID, since time.Time) (*float64, error)
+	RecentDeclines(ctx context.Context, rideID uuid.UUID, since time.Time) ([]uuid.UUID, error)
+	UpdateTier(ctx context.Context, driverID uuid.UUID, tier types.DriverTier) error
 }
 
 type LicenseChecker interface {
 	IsLicenseExists(ctx context.Context, validLicenseNum string) (bool, error)
 }
 
+/*=================Blocklist Repository===========================*/
+
+type BlocklistRepo interface {
+	Add(ctx context.Context, block models.BlockedPassenger) error
+	Remove(ctx context.Context, driverID, passengerID uuid.UUID) error
+	IsBlocked(ctx context.Context, driverID, passengerID uuid.UUID) (bool, error)
+	Count(ctx context.Context, driverID uuid.UUID) (int, error)
+	List(ctx context.Context, driverID uuid.UUID) ([]models.BlockedPassenger, error)
+	ListBlockers(ctx context.Context, passengerID uuid.UUID) ([]uuid.UUID, error)
+}
+
+/*=================Offline Action Repository======================*/
+
+type OfflineActionRepo interface {
+	Get(ctx context.Context, driverID uuid.UUID, actionID string) (*models.ReconcileResult, error)
+	Save(ctx context.Context, driverID uuid.UUID, action models.OfflineAction, res models.ReconcileResult) error
+	LastPerformedAt(ctx context.Context, driverID uuid.UUID) (*time.Time, error)
+	StatusChangedAt(ctx context.Context, driverID uuid.UUID) (changedAt time.Time, lastApplied *time.Time, err error)
+}
+
+/*=================Connection Repository======================*/
+
+// ConnectionRepo хранит подключения водителей по WebSocket, общие для экземпляров,
+// и срок снятия назначения с водителя, потерявшего соединение
+type ConnectionRepo interface {
+	Connected(ctx context.Context, driverID uuid.UUID) (time.Time, error)
+	Disconnected(ctx context.Context, driverID uuid.UUID, connectedAt time.Time, grace time.Duration) error
+	ClaimReleases(ctx context.Context, limit int) ([]uuid.UUID, error)
+}
+
+/*=================Processed Message Repository======================*/
+
+// ProcessedMessageRepo отсекает повторную доставку сообщений брокера (outbox публикует at-least-once)
+type ProcessedMessageRepo interface {
+	Claim(ctx context.Context, consumer, messageID string) (bool, error)
+	Processed(ctx context.Context, consumer, messageID string) (bool, error)
+}
+
+/*=================Location Ingester======================*/
+
+// LocationIngester принимает координаты водителей: location.Service в процессе
+// или клиент внутреннего API location-service
+type LocationIngester interface {
+	Ingest(ctx context.Context, data models.RideLocationUpdate) (coordinateID uuid.UUID, err error)
+}
+
 /*=================Driver Session Repository======================*/
 
 type DriverSessionRepo interface {
-	Create(ctx context.Context, driverID uuid.UUID) (sessiondID uuid.UUID, err error)
+	// Create открывает сессию, created=false — сессия уже открыта параллельным запросом
+	Create(ctx context.Context, driverID uuid.UUID) (sessionID uuid.UUID, created bool, err error)
 	GetSummary(ctx context.Context, driverID uuid.UUID) (models.SessionSummary, error)
 	Update(ctx context.Context, driverID uuid.UUID, ridesCompleted int, earnings float64) error
+	GetStats(ctx context.Context, driverID uuid.UUID, since time.Time) (models.DriverStats, error)
+	// GetDailyTotals возвращает итоги водителя по суткам [since, until) для сравнения смен
+	GetDailyTotals(ctx context.Context, driverID uuid.UUID, since, until time.Time) ([]models.DailyTotals, error)
+	// GetHourlyEarnings возвращает заработок по часам [since, until), часы без поездок пропущены
+	GetHourlyEarnings(ctx context.Context, driverID uuid.UUID, since, until time.Time) ([]models.HourlyEarnings, error)
+	// List возвращает страницу сессий водителя, новые первыми
+	List(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverSessionHistory, error)
 }
 
 /*=================Coordinate Repository==========================*/
 
 type CoordinateRepo interface {
 	CreateCoordinate(ctx context.Context, entityID uuid.UUID, entityType types.EntityType, location models.Location, updatedAt time.Time) (uuid.UUID, error)
-	CreateLocationHistory(ctx context.Context, coordinateID, driverID uuid.UUID, rideID *uuid.UUID, location models.Location, accuracyMeters, speedKmh, headingDegrees float64) (uuid.UUID, error)
 	GetDriverLastCoordinate(ctx context.Context, driverID uuid.UUID) (models.Location, error)
 }
 
@@ -52,6 +149,9 @@ type GeoCoder interface {
 
 type UserRepo interface {
 	ChangeRole(ctx context.Context, userID uuid.UUID, new types.UserRole) (old types.UserRole, err error)
+	UpdateAttrs(ctx context.Context, userID uuid.UUID, attrs map[string]any) error
+	UpdatePhone(ctx context.Context, userID uuid.UUID, phone string) error
+	IsPhoneVerified(ctx context.Context, userID uuid.UUID) (bool, error)
 }
 
 /*=====================Ride Repository============================*/
@@ -59,12 +159,18 @@ type UserRepo interface {
 type RideRepo interface {
 	RideGetter
 	RideChecker
+	// History возвращает страницу истории поездок водителя
+	History(ctx context.Context, f models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error)
 }
 type RideGetter interface {
 	Get(ctx context.Context, rideID uuid.UUID) (*models.Ride, error)
 	Status(ctx context.Context, rideID uuid.UUID) (*types.RideStatus, error)
 	GetDetails(ctx context.Context, rideID uuid.UUID) (*models.RideDetails, error)
 	GetPickupCoordinate(ctx context.Context, rideID uuid.UUID) (*models.Location, error)
+	// GetAssignedRideID возвращает поездку, назначенную водителю и еще не начатую, nil — назначения нет
+	GetAssignedRideID(ctx context.Context, driverID uuid.UUID) (*uuid.UUID, error)
+	// GetCurrentRide возвращает поездку, которую водитель выполняет сейчас, nil — такой нет
+	GetCurrentRide(ctx context.Context, driverID uuid.UUID) (*models.CurrentRide, error)
 }
 
 type RideChecker interface {
@@ -76,7 +182,6 @@ type RideChecker interface {
 type Publisher interface {
 	PublishDriverStatus(ctx context.Context, msg models.DriverStatusUpdateMessage) error
 	PublishDriverResponse(ctx context.Context, resp models.DriverMatchResponse) error
-	PublishLocationUpdate(ctx context.Context, msg models.RideLocationUpdate) error
 }
 
 /*===========================Sender===============================*/
@@ -85,6 +190,15 @@ type DriverCommunicator interface {
 	GetRideOffer(ctx context.Context, driverID uuid.UUID, offer models.RideOffer) (bool, error)
 	SendRideDetails(ctx context.Context, details models.RideDetails) error
 	ListenLocationUpdates(ctx context.Context, driverID, rideID uuid.UUID, handler func(ctx context.Context, location models.RideLocationUpdate) error) error
+	SendStats(ctx context.Context, driverID uuid.UUID, stats models.DriverStats) error
+	// SendShiftSummary отправляет водителю итоги закрытой смены
+	SendShiftSummary(ctx context.Context, driverID uuid.UUID, summary models.SessionSummary) error
+	// SendRideReleased сообщает водителю, что его сняли с поездки
+	SendRideReleased(ctx context.Context, driverID uuid.UUID, msg models.RideReleased) error
+	// RevokeRideOffer отзывает у водителя оффер, который уже не нужно принимать
+	RevokeRideOffer(ctx context.Context, driverID uuid.UUID, msg models.RideOfferRevoked) error
+	// ConnectedDrivers возвращает водителей с открытым WebSocket
+	ConnectedDrivers() []uuid.UUID
 }
 
 type RideEventRepository interface {
@@ -0,0 +1,121 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// UpdateProfile применяет изменения имени, телефона и активного автомобиля водителя.
+// Новый номер удостоверения и фото не применяются сразу, а ставятся в очередь на одобрение администратором.
+// Возвращает обновленный профиль и изменения, ожидающие одобрения.
+func (s *Service) UpdateProfile(ctx context.Context, driverID uuid.UUID, upd models.DriverProfileUpdate) (*models.Driver, []models.DriverChangeRequest, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "update_driver_profile",
+		DriverID: driverID.String(),
+	})
+
+	var license string
+	if upd.LicenseNumber != nil {
+		license = strings.TrimSpace(*upd.LicenseNumber)
+		if !validLicenseFmt.MatchString(license) {
+			return nil, nil, wrap.Error(ctx, types.ErrInvalidLicenseFormat)
+		}
+	}
+
+	var (
+		driver  *models.Driver
+		pending []models.DriverChangeRequest
+	)
+
+	fn := func(ctx context.Context) error {
+		var err error
+		driver, err = s.repos.driver.Get(ctx, driverID)
+		if err != nil {
+			return err
+		}
+
+		// изменения автомобиля применяются к активному автомобилю водителя в driver_vehicles,
+		// а он переносится в профиль, по которому идет подбор
+		if upd.Vehicle != nil {
+			if err := vehicleChangeAllowed(driver.Status); err != nil {
+				return err
+			}
+			active, err := s.saveActiveVehicle(ctx, newDriverVehicle(driverID, upd.Vehicle.Apply(driver.Vehicle), ""))
+			if err != nil {
+				return fmt.Errorf("failed to update driver vehicle: %w", err)
+			}
+			driver.Vehicle = active.Vehicle()
+		}
+
+		// имя хранится и в профиле пользователя
+		if upd.Name != nil {
+			driver.Name = *upd.Name
+			if err := s.repos.driver.UpdateName(ctx, driverID, driver.Name); err != nil {
+				return fmt.Errorf("failed to update driver name: %w", err)
+			}
+			if err := s.repos.user.UpdateAttrs(ctx, driverID, map[string]any{"name": *upd.Name}); err != nil {
+				return fmt.Errorf("failed to update user attrs: %w", err)
+			}
+		}
+		// новый телефон нужно подтвердить заново
+		if upd.Phone != nil {
+			if err := s.repos.user.UpdatePhone(ctx, driverID, *upd.Phone); err != nil {
+				return fmt.Errorf("failed to update user phone: %w", err)
+			}
+		}
+
+		if upd.LicenseNumber != nil && license != driver.LicenseNumber {
+			uniq, err := s.repos.driver.IsLicenseExists(ctx, license)
+			if err != nil {
+				return fmt.Errorf("failed to check license num uniqueness: %w", err)
+			}
+			if !uniq {
+				return types.ErrLicenseAlreadyExists
+			}
+
+			if err := s.repos.driver.SubmitChange(ctx, &models.DriverChangeRequest{
+				DriverID: driverID,
+				Field:    models.DriverFieldLicenseNumber,
+				OldValue: driver.LicenseNumber,
+				NewValue: license,
+			}); err != nil {
+				return fmt.Errorf("failed to submit license change: %w", err)
+			}
+		}
+
+		if upd.PhotoURL != nil && (driver.PhotoURL == nil || *upd.PhotoURL != *driver.PhotoURL) {
+			var old string
+			if driver.PhotoURL != nil {
+				old = *driver.PhotoURL
+			}
+			if err := s.repos.driver.SubmitChange(ctx, &models.DriverChangeRequest{
+				DriverID: driverID,
+				Field:    models.DriverFieldPhotoURL,
+				OldValue: old,
+				NewValue: *upd.PhotoURL,
+			}); err != nil {
+				return fmt.Errorf("failed to submit photo change: %w", err)
+			}
+		}
+
+		pending, err = s.repos.driver.PendingChanges(ctx, driverID)
+		if err != nil {
+			return fmt.Errorf("failed to get pending changes: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := s.infra.trm.Do(ctx, fn); err != nil {
+		return nil, nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "driver profile updated", "pending_changes", len(pending))
+	return driver, pending, nil
+}
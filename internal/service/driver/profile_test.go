@@ -0,0 +1,86 @@
+package drivergo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// vehicleProfiles — профиль водителя и его активный автомобиль, как drivers и driver_vehicles в БД
+type vehicleProfiles struct {
+	*countingDriverRepo
+	active *models.DriverVehicle
+}
+
+func (r *vehicleProfiles) ActiveVehicle(context.Context, uuid.UUID) (*models.DriverVehicle, error) {
+	if r.active == nil {
+		return nil, types.ErrVehicleNotFound
+	}
+	v := *r.active
+	return &v, nil
+}
+
+func (r *vehicleProfiles) UpdateVehicle(_ context.Context, vehicle *models.DriverVehicle) error {
+	v := *vehicle
+	r.active = &v
+	return nil
+}
+
+func (r *vehicleProfiles) SetVehicle(_ context.Context, driverID uuid.UUID, vehicle models.Vehicle) error {
+	d := r.drivers[driverID]
+	d.Vehicle = vehicle
+	r.drivers[driverID] = d
+	return nil
+}
+
+func (r *vehicleProfiles) UpdateName(_ context.Context, driverID uuid.UUID, name string) error {
+	d := r.drivers[driverID]
+	d.Name = name
+	r.drivers[driverID] = d
+	return nil
+}
+
+func (r *vehicleProfiles) PendingChanges(context.Context, uuid.UUID) ([]models.DriverChangeRequest, error) {
+	return nil, nil
+}
+
+type attrUsers struct{ UserRepo }
+
+func (attrUsers) UpdateAttrs(context.Context, uuid.UUID, map[string]any) error { return nil }
+
+// Изменение автомобиля в профиле сохраняется в активный автомобиль, а профиль подбора берет его из driver_vehicles
+func TestUpdateProfile_VehicleGoesThroughDriverVehicles(t *testing.T) {
+	drivers := &vehicleProfiles{countingDriverRepo: newCountingDriverRepo(1)}
+	driverID := drivers.ids()[0]
+	profile := models.Vehicle{Type: types.ClassEconomy, Make: "Toyota", Model: "Camry", Plate: "123ABC01", Year: 2015}
+	drivers.drivers[driverID] = models.Driver{ID: driverID, Name: "Old", Status: types.StatusDriverOffline, Vehicle: profile}
+	drivers.active = &models.DriverVehicle{ID: uuid.New(), DriverID: driverID, Class: types.ClassEconomy, BodyType: "SUV",
+		Make: "Toyota", Model: "Camry", Plate: "123ABC01", Year: 2015, IsActive: true}
+
+	s := &Service{
+		repos: repos{driver: drivers, user: attrUsers{}},
+		infra: infra{trm: inlineTxManager{}},
+		l:     logger.InitLogger("test", "error"),
+	}
+
+	name, plate := "New", "777XYZ02"
+	driver, _, err := s.UpdateProfile(context.Background(), driverID, models.DriverProfileUpdate{
+		Name:    &name,
+		Vehicle: &models.VehicleUpdate{Plate: &plate},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if drivers.active.Plate != plate || drivers.active.BodyType != "SUV" {
+		t.Fatalf("active vehicle %+v, want plate %s and body type kept", drivers.active, plate)
+	}
+	stored := drivers.drivers[driverID]
+	if stored.Name != name || stored.Vehicle != drivers.active.Vehicle() || driver.Vehicle != stored.Vehicle {
+		t.Fatalf("profile %q %+v, returned %+v; want the active vehicle %+v", stored.Name, stored.Vehicle, driver.Vehicle, drivers.active.Vehicle())
+	}
+}
@@ -0,0 +1,23 @@
+package drivergo
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// RatingHistory возвращает средний рейтинг водителя и страницу оценок пассажиров
+func (s *Service) RatingHistory(ctx context.Context, driverID uuid.UUID, filters models.Filters) (*models.DriverRatingHistory, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "driver_rating_history",
+		DriverID: driverID.String(),
+	})
+
+	history, err := s.repos.driver.GetRatings(ctx, driverID, filters)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return history, nil
+}
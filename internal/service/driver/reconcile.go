@@ -0,0 +1,218 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// maxOfflineActionAge — действия старше этого возраста не применяются, их состояние уже неактуально
+	maxOfflineActionAge = 24 * time.Hour
+	// maxClockSkew — допустимое расхождение часов телефона и сервера
+	maxClockSkew = time.Minute
+)
+
+// conflictErrors — ошибки, при которых состояние сервера противоречит офлайн действию
+var conflictErrors = []error{
+	types.ErrDriverMustBeAvailable,
+	types.ErrDriverMustBeArrived,
+	types.ErrDriverMustBeBusy,
+	types.ErrRideNotArrived,
+	types.ErrRideNotInProgress,
+	types.ErrRideDriverMismatch,
+	types.ErrDriverLocationNotFound,
+}
+
+// Reconcile применяет действия, выполненные приложением без сети, в порядке performed_at.
+// Каждое действие применяется идемпотентно: повторная отправка возвращает сохраненный результат.
+// При расхождении с состоянием сервера приоритет у сервера, действие получает статус CONFLICT.
+func (s *Service) Reconcile(ctx context.Context, driverID uuid.UUID, actions []models.OfflineAction) ([]models.ReconcileResult, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "reconcile_offline_actions",
+		DriverID: driverID.String(),
+	})
+
+	exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to check driver existence: %w", err))
+	}
+	if !exist {
+		return nil, wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	// действия из прошлых отправок задают нижнюю границу для новых
+	var last time.Time
+	lastPerformed, err := s.repos.offline.LastPerformedAt(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to get last reconciled action: %w", err))
+	}
+	if lastPerformed != nil {
+		last = *lastPerformed
+	}
+
+	serverChange, err := s.serverStatusChange(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to get driver status change time: %w", err))
+	}
+
+	results := make([]models.ReconcileResult, 0, len(actions))
+	for _, action := range actions {
+		stored, err := s.repos.offline.Get(ctx, driverID, action.ID)
+		if err != nil {
+			return nil, wrap.Error(ctx, fmt.Errorf("failed to get reconciled action: %w", err))
+		}
+		if stored != nil {
+			stored.Replayed = true
+			results = append(results, *stored)
+			continue
+		}
+
+		res := models.ReconcileResult{
+			ActionID:    action.ID,
+			Type:        action.Type,
+			PerformedAt: action.PerformedAt,
+		}
+
+		if msg := checkActionTime(action, last, s.infra.clock.Now()); msg != "" {
+			res.Status, res.Message = types.ReconcileRejected, msg
+		} else if action.PerformedAt.Before(serverChange) {
+			res.Status, res.Message = types.ReconcileConflict, "driver status was changed on the server after the action"
+		} else {
+			res.Status, res.Message = s.applyOfflineAction(ctx, driverID, action)
+		}
+		res.ProcessedAt = s.infra.clock.Now()
+
+		// FAILED не сохраняется, чтобы приложение могло отправить действие повторно
+		if res.Status != types.ReconcileFailed {
+			if err := s.repos.offline.Save(ctx, driverID, action, res); err != nil {
+				return nil, wrap.Error(ctx, fmt.Errorf("failed to save reconciled action: %w", err))
+			}
+		}
+		if res.Status == types.ReconcileApplied || res.Status == types.ReconcileSkipped {
+			last = action.PerformedAt
+		}
+
+		s.l.Debug(ctx, "offline action reconciled",
+			"action_id", action.ID,
+			"type", action.Type.String(),
+			"status", res.Status.String(),
+		)
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// serverStatusChange возвращает время последней смены статуса водителя на сервере.
+// Смена статуса самой сверкой (не позже обработки последнего примененного действия) не учитывается,
+// иначе следующие офлайн действия той же смены конфликтовали бы с уже примененными.
+func (s *Service) serverStatusChange(ctx context.Context, driverID uuid.UUID) (time.Time, error) {
+	changedAt, lastApplied, err := s.repos.offline.StatusChangedAt(ctx, driverID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if lastApplied != nil && !changedAt.After(lastApplied.Add(maxClockSkew)) {
+		return time.Time{}, nil
+	}
+	return changedAt, nil
+}
+
+// checkActionTime проверяет время действия и возвращает причину отказа или пустую строку
+func checkActionTime(action models.OfflineAction, last, now time.Time) string {
+	switch {
+	case action.PerformedAt.After(now.Add(maxClockSkew)):
+		return "performed_at is in the future"
+	case action.PerformedAt.Before(now.Add(-maxOfflineActionAge)):
+		return "action is too old to be applied"
+	case action.PerformedAt.Before(last):
+		return "action is older than an already reconciled action"
+	}
+	return ""
+}
+
+// applyOfflineAction применяет действие через обычные сценарии водителя и переводит ошибку в статус сверки
+func (s *Service) applyOfflineAction(ctx context.Context, driverID uuid.UUID, action models.OfflineAction) (types.ReconcileStatus, string) {
+	var err error
+	switch action.Type {
+	case types.OfflineGoOnline:
+		_, err = s.GoOnline(ctx, driverID, action.Location)
+		if errors.Is(err, types.ErrDriverAlreadyOnline) {
+			return types.ReconcileSkipped, "driver is already online"
+		}
+
+	case types.OfflineGoOffline:
+		_, err = s.GoOffline(ctx, driverID)
+		if errors.Is(err, types.ErrDriverAlreadyOffline) {
+			return types.ReconcileSkipped, "driver is already offline"
+		}
+
+	case types.OfflineStartRide, types.OfflineCompleteRide:
+		if status, msg, done := s.checkOfflineRide(ctx, driverID, action); done {
+			return status, msg
+		}
+
+		if action.Type == types.OfflineStartRide {
+			err = s.StartRide(ctx, action.PerformedAt, driverID, *action.RideID, action.Location)
+		} else {
+			_, err = s.CompleteRide(ctx, *action.RideID, CompleteRideData{
+				CompleteTime:      action.PerformedAt,
+				DriverID:          driverID,
+				ActualDurationMin: action.ActualDurationMin,
+				ActualDistanceKm:  action.ActualDistanceKm,
+				Location:          action.Location,
+			})
+		}
+
+	default:
+		return types.ReconcileRejected, "unknown action type"
+	}
+
+	if err == nil {
+		return types.ReconcileApplied, ""
+	}
+	for _, conflict := range conflictErrors {
+		if errors.Is(err, conflict) {
+			return types.ReconcileConflict, conflict.Error()
+		}
+	}
+
+	s.l.Error(ctx, "failed to apply offline action", err)
+	return types.ReconcileFailed, "internal error, retry later"
+}
+
+// checkOfflineRide сверяет действие с текущим статусом поездки.
+// done=true означает, что результат известен и действие применять не нужно.
+func (s *Service) checkOfflineRide(ctx context.Context, driverID uuid.UUID, action models.OfflineAction) (types.ReconcileStatus, string, bool) {
+	ride, err := s.repos.ride.Get(ctx, *action.RideID)
+	if err != nil {
+		if errors.Is(err, types.ErrRideNotFound) {
+			return types.ReconcileRejected, types.ErrRideNotFound.Error(), true
+		}
+		s.l.Error(ctx, "failed to get ride for offline action", err)
+		return types.ReconcileFailed, "internal error, retry later", true
+	}
+
+	if ride.DriverID == nil || *ride.DriverID != driverID {
+		return types.ReconcileConflict, types.ErrRideDriverMismatch.Error(), true
+	}
+
+	switch types.RideStatus(ride.Status) {
+	case types.StatusCancelled:
+		return types.ReconcileConflict, "ride was cancelled", true
+	case types.StatusCompleted:
+		return types.ReconcileSkipped, "ride is already completed", true
+	case types.StatusInProgress:
+		if action.Type == types.OfflineStartRide {
+			return types.ReconcileSkipped, "ride is already in progress", true
+		}
+	}
+
+	return "", "", false
+}
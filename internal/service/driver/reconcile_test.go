@@ -0,0 +1,75 @@
+package drivergo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memOfflineActions — сверка без сохраненных действий с заданным временем смены статуса
+type memOfflineActions struct {
+	OfflineActionRepo
+	changedAt   time.Time
+	lastApplied *time.Time
+}
+
+func (r *memOfflineActions) Get(context.Context, uuid.UUID, string) (*models.ReconcileResult, error) {
+	return nil, nil
+}
+
+func (r *memOfflineActions) Save(context.Context, uuid.UUID, models.OfflineAction, models.ReconcileResult) error {
+	return nil
+}
+
+func (r *memOfflineActions) LastPerformedAt(context.Context, uuid.UUID) (*time.Time, error) {
+	return nil, nil
+}
+
+func (r *memOfflineActions) StatusChangedAt(context.Context, uuid.UUID) (time.Time, *time.Time, error) {
+	return r.changedAt, r.lastApplied, nil
+}
+
+// Действие, выполненное до смены статуса на сервере, не перезаписывает состояние сервера
+func TestReconcile_ServerStatusChangedAfterAction(t *testing.T) {
+	now := time.Now()
+	changedAt := now.Add(-10 * time.Minute)
+	appliedAt := changedAt.Add(time.Second)
+
+	tests := []struct {
+		name        string
+		lastApplied *time.Time
+		wantStatus  types.ReconcileStatus
+	}{
+		{"changed on the server", nil, types.ReconcileConflict},
+		{"changed by an earlier reconcile", &appliedAt, types.ReconcileSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drivers := newCountingDriverRepo(1)
+			driverID := drivers.ids()[0]
+			drivers.drivers[driverID] = models.Driver{ID: driverID, Status: types.StatusDriverOffline}
+			s := &Service{
+				repos: repos{driver: drivers, offline: &memOfflineActions{changedAt: changedAt, lastApplied: tt.lastApplied}},
+				logic: logic{candidates: newCandidateCache(time.Minute), searches: newSearchTracker()},
+				infra: infra{clock: clock.NewFake(now), trm: inlineTxManager{}},
+				l:     logger.InitLogger("test", "error"),
+			}
+
+			action := models.OfflineAction{ID: "a-1", Type: types.OfflineGoOffline, PerformedAt: now.Add(-20 * time.Minute)}
+			res, err := s.Reconcile(context.Background(), driverID, []models.OfflineAction{action})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res[0].Status; got != tt.wantStatus {
+				t.Fatalf("status %s (%s), want %s", got, res[0].Message, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// redispatchSweepBatch — сколько водителей снимается с поездок за один проход
+const redispatchSweepBatch = 100
+
+// assignmentWatcher связывает разрывы WebSocket водителей с активными назначениями.
+// Если водитель в пути к пассажиру не переподключился за grace, назначение снимается.
+// Срок снятия хранится в driver_connections: водитель может переподключиться к другому
+// экземпляру, а экземпляр, назначивший срок, — перезапуститься.
+type assignmentWatcher struct {
+	grace time.Duration
+
+	mu       sync.Mutex
+	conns    map[uuid.UUID]int                // driver_id -> число открытых соединений на экземпляре
+	since    map[uuid.UUID]time.Time          // driver_id -> время последнего подключения к экземпляру по часам базы
+	tracking map[uuid.UUID]context.CancelFunc // driver_id -> отслеживание поездки
+}
+
+func newAssignmentWatcher(grace time.Duration) *assignmentWatcher {
+	return &assignmentWatcher{
+		grace:    grace,
+		conns:    make(map[uuid.UUID]int),
+		since:    make(map[uuid.UUID]time.Time),
+		tracking: make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+func (w *assignmentWatcher) connected(driverID uuid.UUID, at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.conns[driverID]++
+	if at.After(w.since[driverID]) {
+		w.since[driverID] = at
+	}
+}
+
+// disconnected возвращает время последнего подключения и true, если у водителя
+// не осталось соединений с экземпляром
+func (w *assignmentWatcher) disconnected(driverID uuid.UUID) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conns[driverID]--; w.conns[driverID] > 0 {
+		return time.Time{}, false // соединение заменено новым
+	}
+	since := w.since[driverID]
+	delete(w.conns, driverID)
+	delete(w.since, driverID)
+	return since, true
+}
+
+// track возвращает контекст отслеживания поездки, который отменяется при снятии назначения
+func (w *assignmentWatcher) track(ctx context.Context, driverID uuid.UUID) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	w.mu.Lock()
+	if prev, ok := w.tracking[driverID]; ok {
+		prev()
+	}
+	w.tracking[driverID] = cancel
+	w.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		w.mu.Lock()
+		delete(w.tracking, driverID)
+		w.mu.Unlock()
+	}
+}
+
+// stopTracking прекращает отслеживание поездки водителя
+func (w *assignmentWatcher) stopTracking(driverID uuid.UUID) {
+	w.mu.Lock()
+	cancel, ok := w.tracking[driverID]
+	delete(w.tracking, driverID)
+	w.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// DriverConnected вызывается при открытии WebSocket водителя и отменяет снятие назначения,
+// назначенное любым экземпляром
+func (s *Service) DriverConnected(ctx context.Context, driverID uuid.UUID) {
+	at, err := s.repos.connections.Connected(ctx, driverID)
+	if err != nil {
+		s.l.Error(ctx, "failed to record driver connection", err)
+	}
+	s.logic.assignments.connected(driverID, at)
+}
+
+// DriverDisconnected вызывается при закрытии WebSocket водителя. Если водитель был в пути
+// к пассажиру и не переподключился за grace период ни к одному экземпляру, он уходит офлайн,
+// а поездка возвращается в поиск водителя (RunRedispatchJob).
+func (s *Service) DriverDisconnected(ctx context.Context, driverID uuid.UUID) {
+	connectedAt, last := s.logic.assignments.disconnected(driverID)
+	if !last {
+		return
+	}
+
+	// соединение уже закрыто, запрос не должен отменить запись срока
+	ctx = context.WithoutCancel(ctx)
+	if err := s.repos.connections.Disconnected(ctx, driverID, connectedAt, s.logic.assignments.grace); err != nil {
+		s.l.Error(ctx, "failed to schedule redispatch of disconnected driver", err)
+	}
+}
+
+// RunRedispatchJob раз в interval снимает с поездок водителей, не переподключившихся за grace
+func (s *Service) RunRedispatchJob(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.l.Warn(ctx, "driver redispatch job disabled, disconnected drivers keep their rides", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.sweepDisconnected(ctx)
+	}
+}
+
+func (s *Service) sweepDisconnected(ctx context.Context) {
+	driverIDs, err := s.repos.connections.ClaimReleases(ctx, redispatchSweepBatch)
+	if err != nil {
+		s.l.Error(ctx, "failed to claim disconnected drivers", err)
+		return
+	}
+
+	for _, driverID := range driverIDs {
+		if err := s.redispatchDisconnected(ctx, driverID); err != nil {
+			s.l.Error(ctx, "failed to redispatch ride of disconnected driver", err, "driver_id", driverID)
+		}
+	}
+}
+
+func (s *Service) redispatchDisconnected(ctx context.Context, driverID uuid.UUID) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "redispatch_disconnected_driver",
+		DriverID: driverID.String(),
+	})
+
+	var released bool
+	err := s.infra.trm.Do(ctx, func(ctx context.Context) error {
+		driver, err := s.repos.driver.Get(ctx, driverID)
+		if err != nil {
+			return fmt.Errorf("failed to get driver data: %w", err)
+		}
+		if driver.Status != types.StatusDriverEnRoute {
+			return nil
+		}
+
+		released = true
+		_, err = s.goOffline(ctx, driverID)
+		return err
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	if released {
+		s.l.Info(ctx, "driver did not reconnect in grace period, ride returned to matching", "grace", s.logic.assignments.grace.String())
+	}
+	return nil
+}
+
+// releaseAssignment снимает водителя с поездки, к которой он едет: ride-service
+// получает статус OFFLINE с ride_id, возвращает поездку в REQUESTED и перезапускает поиск.
+// Вызывается внутри транзакции перехода водителя в OFFLINE.
+func (s *Service) releaseAssignment(ctx context.Context, driverID uuid.UUID) error {
+	rideID, err := s.repos.ride.GetAssignedRideID(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("failed to get assigned ride: %w", err)
+	}
+	if rideID == nil {
+		return nil
+	}
+
+	if err := s.infra.publisher.PublishDriverStatus(ctx, models.DriverStatusUpdateMessage{
+		DriverID:  driverID,
+		Status:    types.StatusDriverOffline.String(),
+		Timestamp: s.infra.clock.Now(),
+		RideID:    rideID,
+	}); err != nil {
+		return fmt.Errorf("failed to publish driver status: %w", err)
+	}
+
+	s.logic.assignments.stopTracking(driverID)
+	s.l.Warn(ctx, "driver released from assigned ride", "ride_id", rideID.String())
+	return nil
+}
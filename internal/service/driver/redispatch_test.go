@@ -0,0 +1,112 @@
+package drivergo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memConnections повторяет driver_connections: время базы — счетчик, срок снятия — момент разрыва
+type memConnections struct {
+	mu          sync.Mutex
+	now         time.Time
+	connectedAt map[uuid.UUID]time.Time
+	releaseAt   map[uuid.UUID]time.Time
+}
+
+func newMemConnections() *memConnections {
+	return &memConnections{
+		now:         time.Unix(0, 0),
+		connectedAt: make(map[uuid.UUID]time.Time),
+		releaseAt:   make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *memConnections) tick() time.Time {
+	r.now = r.now.Add(time.Second)
+	return r.now
+}
+
+func (r *memConnections) Connected(_ context.Context, driverID uuid.UUID) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectedAt[driverID] = r.tick()
+	delete(r.releaseAt, driverID)
+	return r.connectedAt[driverID], nil
+}
+
+func (r *memConnections) Disconnected(_ context.Context, driverID uuid.UUID, connectedAt time.Time, grace time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.connectedAt[driverID].After(connectedAt) {
+		r.releaseAt[driverID] = r.tick().Add(grace)
+	}
+	return nil
+}
+
+func (r *memConnections) ClaimReleases(context.Context, int) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uuid.UUID
+	for id, at := range r.releaseAt {
+		if !at.After(r.now) {
+			ids = append(ids, id)
+			delete(r.releaseAt, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memConnections) pending(driverID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.releaseAt[driverID]
+	return ok
+}
+
+// Переподключение к другому экземпляру отменяет снятие назначения, назначенное первым экземпляром,
+// а закрытие старого соединения после переподключения срок не назначает
+func TestDriverDisconnected_ReconnectToAnotherInstance(t *testing.T) {
+	drivers := newCountingDriverRepo(1)
+	driverID := drivers.ids()[0]
+	conns := newMemConnections()
+	instance := func() *Service {
+		return &Service{
+			repos: repos{driver: drivers, connections: conns},
+			logic: logic{assignments: newAssignmentWatcher(0)},
+			infra: infra{trm: inlineTxManager{}},
+			l:     logger.InitLogger("test", "error"),
+		}
+	}
+	a, b := instance(), instance()
+	ctx := context.Background()
+
+	a.DriverConnected(ctx, driverID)
+	a.DriverDisconnected(ctx, driverID)
+	if !conns.pending(driverID) {
+		t.Fatal("disconnect did not schedule a release")
+	}
+
+	b.DriverConnected(ctx, driverID)
+	if conns.pending(driverID) {
+		t.Fatal("reconnect to another instance kept the release")
+	}
+
+	// старое соединение закрылось на A уже после подключения к B
+	a.DriverConnected(ctx, driverID)
+	b.DriverConnected(ctx, driverID)
+	a.DriverDisconnected(ctx, driverID)
+	if conns.pending(driverID) {
+		t.Fatal("stale connection scheduled a release while the driver is connected to another instance")
+	}
+
+	b.DriverDisconnected(ctx, driverID)
+	b.sweepDisconnected(ctx)
+	if conns.pending(driverID) {
+		t.Fatal("due release was not claimed by the sweep")
+	}
+}
@@ -0,0 +1,60 @@
+package drivergo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Stats возвращает статистику водителя за текущие сутки (UTC)
+func (s *Service) Stats(ctx context.Context, driverID uuid.UUID) (models.DriverStats, error) {
+	now := s.infra.clock.Now().UTC()
+	stats, err := s.repos.session.GetStats(ctx, driverID, now.Truncate(24*time.Hour))
+	if err != nil {
+		return models.DriverStats{}, err
+	}
+	stats.UpdatedAt = now
+	return stats, nil
+}
+
+// PushStats отправляет водителю статистику по WebSocket. Ошибки не фатальны:
+// плитка обновится при следующей отправке.
+func (s *Service) PushStats(ctx context.Context, driverID uuid.UUID) {
+	ctx = wrap.WithDriverID(ctx, driverID.String())
+
+	stats, err := s.Stats(ctx, driverID)
+	if err != nil {
+		s.l.Warn(ctx, "failed to get driver stats", "error", err.Error())
+		return
+	}
+
+	if err := s.infra.communicator.SendStats(ctx, driverID, stats); err != nil {
+		s.l.Debug(ctx, "failed to send driver stats", "error", err.Error())
+	}
+}
+
+// RunStatsJob периодически отправляет статистику всем подключенным водителям до отмены контекста
+func (s *Service) RunStatsJob(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.l.Warn(ctx, "driver stats job disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, driverID := range s.infra.communicator.ConnectedDrivers() {
+			s.PushStats(ctx, driverID)
+		}
+	}
+}
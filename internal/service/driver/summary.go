@@ -0,0 +1,118 @@
+package drivergo
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// comparisonDays — за сколько прошлых суток считается средний рабочий день
+	comparisonDays = 7
+	// maxHourlyBreakdown — разбивка по часам покрывает не больше последних суток смены,
+	// забытая на линии сессия не раздувает ответ
+	maxHourlyBreakdown = 24 * time.Hour
+)
+
+// enrichSummary дополняет итоги смены сравнением со вчера и с неделей и разбивкой по часам.
+// Вызывается после закрытия сессии; ошибки не фатальны — водитель уже вне линии.
+func (s *Service) enrichSummary(ctx context.Context, driverID uuid.UUID, summary *models.SessionSummary) {
+	if summary.DurationHours >= 1.0/60 {
+		summary.EarningsPerHour = roundMoney(summary.Earnings / summary.DurationHours)
+	}
+
+	today := summary.EndedAt.UTC().Truncate(24 * time.Hour)
+	days, err := s.repos.session.GetDailyTotals(ctx, driverID, today.AddDate(0, 0, -comparisonDays), today)
+	if err != nil {
+		s.l.Warn(ctx, "failed to get driver daily totals", "error", err.Error())
+	} else {
+		summary.Comparison = compareShift(*summary, days)
+	}
+
+	since := summary.StartedAt
+	if summary.EndedAt.Sub(since) > maxHourlyBreakdown {
+		since = summary.EndedAt.Add(-maxHourlyBreakdown)
+	}
+	hours, err := s.repos.session.GetHourlyEarnings(ctx, driverID, since, summary.EndedAt)
+	if err != nil {
+		s.l.Warn(ctx, "failed to get driver hourly earnings", "error", err.Error())
+		return
+	}
+	summary.HourlyEarnings = fillHours(since, summary.EndedAt, hours)
+}
+
+// pushShiftSummary отправляет итоги смены в приложение водителя. Ошибки не фатальны:
+// итоги уже вернулись в ответе GoOffline.
+func (s *Service) pushShiftSummary(ctx context.Context, driverID uuid.UUID, summary models.SessionSummary) {
+	if err := s.infra.communicator.SendShiftSummary(ctx, driverID, summary); err != nil {
+		s.l.Debug(ctx, "failed to send shift summary", "error", err.Error())
+	}
+}
+
+// compareShift сравнивает смену со вчерашними сутками и со средним за рабочие дни недели.
+// days — итоги за прошлые сутки по возрастанию, последние — вчера.
+func compareShift(summary models.SessionSummary, days []models.DailyTotals) *models.ShiftComparison {
+	var cmp models.ShiftComparison
+	if len(days) > 0 {
+		cmp.Yesterday = days[len(days)-1].ShiftTotals
+	}
+
+	var total models.ShiftTotals
+	for _, d := range days {
+		// дни без выхода на линию не тянут среднее вниз
+		if d.OnlineHours == 0 && d.Rides == 0 {
+			continue
+		}
+		cmp.WeeklyActiveDays++
+		total.Rides += d.Rides
+		total.Earnings += d.Earnings
+		total.OnlineHours += d.OnlineHours
+	}
+	if n := float64(cmp.WeeklyActiveDays); n > 0 {
+		cmp.WeeklyAverage = models.ShiftTotals{
+			Rides:       math.Round(total.Rides/n*10) / 10,
+			Earnings:    roundMoney(total.Earnings / n),
+			OnlineHours: math.Round(total.OnlineHours/n*100) / 100,
+		}
+	}
+
+	rides := float64(summary.RidesCompleted)
+	cmp.EarningsVsYesterday = deltaPct(summary.Earnings, cmp.Yesterday.Earnings)
+	cmp.EarningsVsWeekly = deltaPct(summary.Earnings, cmp.WeeklyAverage.Earnings)
+	cmp.RidesVsYesterday = deltaPct(rides, cmp.Yesterday.Rides)
+	cmp.RidesVsWeekly = deltaPct(rides, cmp.WeeklyAverage.Rides)
+
+	return &cmp
+}
+
+// deltaPct — изменение v относительно base в процентах, nil при нулевой базе
+func deltaPct(v, base float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	pct := math.Round((v-base)/base*1000) / 10
+	return &pct
+}
+
+// fillHours возвращает разбивку по каждому часу [since, until): часы без поездок получают нули
+func fillHours(since, until time.Time, hours []models.HourlyEarnings) []models.HourlyEarnings {
+	byHour := make(map[int64]models.HourlyEarnings, len(hours))
+	for _, h := range hours {
+		byHour[h.Hour.Unix()] = h
+	}
+
+	breakdown := make([]models.HourlyEarnings, 0, int(until.Sub(since).Hours())+1)
+	for hour := since.UTC().Truncate(time.Hour); hour.Before(until); hour = hour.Add(time.Hour) {
+		h, ok := byHour[hour.Unix()]
+		if !ok {
+			h = models.HourlyEarnings{Hour: hour}
+		}
+		h.Hour = hour
+		h.Earnings = roundMoney(h.Earnings)
+		breakdown = append(breakdown, h)
+	}
+	return breakdown
+}
@@ -0,0 +1,50 @@
+package drivergo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+)
+
+func TestCompareShift(t *testing.T) {
+	day := func(rides, earnings, hours float64) models.DailyTotals {
+		return models.DailyTotals{ShiftTotals: models.ShiftTotals{Rides: rides, Earnings: earnings, OnlineHours: hours}}
+	}
+	// выходные дни не входят в среднее, последний день — вчера
+	days := []models.DailyTotals{day(8, 12000, 6), day(0, 0, 0), day(12, 18000, 8), day(0, 0, 0), day(10, 15000, 7)}
+	summary := models.SessionSummary{RidesCompleted: 12, Earnings: 18000}
+
+	got := compareShift(summary, days)
+	if got.Yesterday.Earnings != 15000 || got.WeeklyActiveDays != 3 || got.WeeklyAverage.Earnings != 15000 || got.WeeklyAverage.Rides != 10 {
+		t.Fatalf("unexpected comparison: %+v", got)
+	}
+	if got.EarningsVsYesterday == nil || *got.EarningsVsYesterday != 20 || got.RidesVsWeekly == nil || *got.RidesVsWeekly != 20 {
+		t.Fatalf("unexpected deltas: %+v", got)
+	}
+
+	// первая неделя водителя: сравнивать не с чем
+	got = compareShift(summary, []models.DailyTotals{day(0, 0, 0)})
+	if got.WeeklyActiveDays != 0 || got.EarningsVsYesterday != nil || got.EarningsVsWeekly != nil {
+		t.Fatalf("no history: got %+v", got)
+	}
+}
+
+func TestFillHours(t *testing.T) {
+	start := time.Date(2026, 10, 15, 8, 40, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 15, 11, 10, 0, 0, time.UTC)
+	hours := []models.HourlyEarnings{
+		{Hour: time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC), Rides: 2, Earnings: 3100.004},
+	}
+
+	got := fillHours(start, end, hours)
+	if len(got) != 4 {
+		t.Fatalf("got %d hours, want 4 (08:00-11:00)", len(got))
+	}
+	if !got[0].Hour.Equal(start.Truncate(time.Hour)) || got[0].Rides != 0 {
+		t.Fatalf("first hour: %+v", got[0])
+	}
+	if got[2].Rides != 2 || got[2].Earnings != 3100 {
+		t.Fatalf("hour with rides: %+v", got[2])
+	}
+}
@@ -0,0 +1,77 @@
+package drivergo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// TaxSummary собирает годовую сводку доходов водителя по всем 12 месяцам.
+// Удержания (commission) берутся из чеков поездок: комиссия платформы и налог, включенный в стоимость.
+// Поездки без чека (завершенные до чеков) удержаний не имеют. Чаевые и бонусы пока не начисляются.
+func (s *Service) TaxSummary(ctx context.Context, driverID uuid.UUID, year int) (*models.TaxSummary, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "driver_tax_summary",
+		DriverID: driverID.String(),
+	})
+
+	exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to check driver existence: %w", err))
+	}
+	if !exist {
+		return nil, wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	earnings, err := s.repos.driver.GetMonthlyEarnings(ctx, driverID, year)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to get monthly earnings: %w", err))
+	}
+
+	summary := &models.TaxSummary{
+		DriverID: driverID,
+		Year:     year,
+		Months:   make([]models.MonthlyEarnings, 12),
+	}
+	for i := range summary.Months {
+		summary.Months[i].Month = time.Month(i + 1)
+	}
+
+	for _, m := range earnings {
+		if m.Month < time.January || m.Month > time.December {
+			continue
+		}
+		summary.Months[m.Month-1] = m
+	}
+
+	for i := range summary.Months {
+		m := &summary.Months[i]
+		m.Net = roundMoney(m.Gross - m.Commission + m.Tips + m.Bonuses)
+
+		summary.Total.Rides += m.Rides
+		summary.Total.Gross += m.Gross
+		summary.Total.Commission += m.Commission
+		summary.Total.Tips += m.Tips
+		summary.Total.Bonuses += m.Bonuses
+		summary.Total.Net += m.Net
+	}
+
+	summary.Total.Gross = roundMoney(summary.Total.Gross)
+	summary.Total.Commission = roundMoney(summary.Total.Commission)
+	summary.Total.Tips = roundMoney(summary.Total.Tips)
+	summary.Total.Bonuses = roundMoney(summary.Total.Bonuses)
+	summary.Total.Net = roundMoney(summary.Total.Net)
+
+	return summary, nil
+}
+
+// roundMoney округляет сумму до копеек, чтобы суммирование float не давало хвостов
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
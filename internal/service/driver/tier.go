@@ -0,0 +1,135 @@
+package drivergo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// minOffersForTier — меньше офферов за окно недостаточно для повышения уровня
+const minOffersForTier = 10
+
+// tierRequirement — минимальные показатели для получения уровня
+type tierRequirement struct {
+	tier            types.DriverTier
+	minRating       float64
+	minAcceptance   float64
+	maxCancellation float64
+}
+
+// tierRequirements отсортированы от высшего уровня к низшему
+var tierRequirements = []tierRequirement{
+	{tier: types.TierGold, minRating: 4.8, minAcceptance: 0.9, maxCancellation: 0.05},
+	{tier: types.TierSilver, minRating: 4.5, minAcceptance: 0.75, maxCancellation: 0.1},
+}
+
+// premiumOfferDelay — через сколько после начала поиска водитель уровня получает PREMIUM оффер
+var premiumOfferDelay = map[types.DriverTier]time.Duration{
+	types.TierGold:   0,
+	types.TierSilver: 10 * time.Second,
+	types.TierBronze: 20 * time.Second,
+}
+
+// ComputeTier определяет уровень водителя по рейтингу, принятию офферов и отменам
+func ComputeTier(m models.DriverTierMetrics) types.DriverTier {
+	if m.OffersTotal < minOffersForTier {
+		return types.TierBronze
+	}
+
+	for _, req := range tierRequirements {
+		if m.Rating >= req.minRating &&
+			m.AcceptanceRate() >= req.minAcceptance &&
+			m.CancellationRate() <= req.maxCancellation {
+			return req.tier
+		}
+	}
+
+	return types.TierBronze
+}
+
+// offerDelay возвращает задержку оффера для водителя в зависимости от класса поездки
+func offerDelay(rideType string, tier types.DriverTier) time.Duration {
+	if rideType != string(types.ClassPremium) {
+		return 0
+	}
+
+	delay, ok := premiumOfferDelay[tier]
+	if !ok {
+		return premiumOfferDelay[types.TierBronze]
+	}
+	return delay
+}
+
+// RecomputeTiers пересчитывает уровни всех водителей по метрикам за окно window
+func (s *Service) RecomputeTiers(ctx context.Context, window time.Duration) error {
+	ctx = wrap.WithAction(ctx, "recompute_driver_tiers")
+
+	metrics, err := s.repos.driver.GetTierMetrics(ctx, s.infra.clock.Now().Add(-window))
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	var changed int
+	for _, m := range metrics {
+		tier := ComputeTier(m)
+		if tier == m.Tier {
+			continue
+		}
+
+		if err := s.repos.driver.UpdateTier(ctx, m.DriverID, tier); err != nil {
+			s.l.Warn(ctx, "failed to update driver tier", "driver_id", m.DriverID.String(), "error", err.Error())
+			continue
+		}
+		changed++
+	}
+
+	s.l.Info(ctx, "driver tiers recomputed", "drivers", len(metrics), "changed", changed)
+	return nil
+}
+
+// RunTierJob периодически пересчитывает уровни водителей до отмены контекста
+func (s *Service) RunTierJob(ctx context.Context, interval, window time.Duration) {
+	if interval <= 0 {
+		s.l.Warn(ctx, "driver tier job disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RecomputeTiers(ctx, window); err != nil {
+			s.l.Error(ctx, "failed to recompute driver tiers", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetProfile возвращает профиль водителя вместе с текущим уровнем и долей отказов от офферов
+func (s *Service) GetProfile(ctx context.Context, driverID uuid.UUID) (*models.Driver, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "get_driver_profile",
+		DriverID: driverID.String(),
+	})
+
+	driver, err := s.repos.driver.Get(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	driver.DeclineRate, err = s.repos.driver.DeclineRate(ctx, driverID, s.infra.clock.Now().Add(-s.logic.tierWindow))
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	return driver, nil
+}
@@ -0,0 +1,245 @@
+package drivergo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Vehicles возвращает автомобили водителя, активный первым
+func (s *Service) Vehicles(ctx context.Context, driverID uuid.UUID) ([]models.DriverVehicle, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "get_driver_vehicles",
+		DriverID: driverID.String(),
+	})
+
+	vehicles, err := s.repos.driver.Vehicles(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return vehicles, nil
+}
+
+// ActiveVehicle возвращает автомобиль, на котором водитель получает заказы
+func (s *Service) ActiveVehicle(ctx context.Context, driverID uuid.UUID) (*models.DriverVehicle, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "get_driver_vehicle",
+		DriverID: driverID.String(),
+	})
+
+	vehicle, err := s.repos.driver.ActiveVehicle(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return vehicle, nil
+}
+
+// UpdateVehicle заменяет атрибуты активного автомобиля и заново определяет его класс.
+// Пустой тип кузова оставляет прежний. Во время поездки автомобиль менять нельзя.
+func (s *Service) UpdateVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.DriverVehicle) (*models.DriverVehicle, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "update_driver_vehicle",
+		DriverID: driverID.String(),
+	})
+
+	var saved *models.DriverVehicle
+	fn := func(ctx context.Context) error {
+		if err := s.checkVehicleChange(ctx, driverID); err != nil {
+			return err
+		}
+
+		vehicle.DriverID = driverID
+		var err error
+		saved, err = s.saveActiveVehicle(ctx, vehicle)
+		return err
+	}
+
+	if err := s.infra.trm.Do(ctx, fn); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "driver vehicle updated", "vehicle_id", saved.ID, "vehicle_class", saved.Class)
+	return saved, nil
+}
+
+// AddVehicle добавляет водителю автомобиль. Первый автомобиль водителя сразу становится активным,
+// остальные — если activate.
+func (s *Service) AddVehicle(ctx context.Context, driverID uuid.UUID, vehicle models.DriverVehicle, activate bool) (*models.DriverVehicle, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "add_driver_vehicle",
+		DriverID: driverID.String(),
+	})
+
+	var saved *models.DriverVehicle
+	fn := func(ctx context.Context) error {
+		exist, err := s.repos.driver.IsDriverExist(ctx, driverID)
+		if err != nil {
+			return fmt.Errorf("failed to check driver existence: %w", err)
+		}
+		if !exist {
+			return types.ErrUserNotFound
+		}
+
+		if _, err := s.repos.driver.ActiveVehicle(ctx, driverID); errors.Is(err, types.ErrVehicleNotFound) {
+			activate = true
+		} else if err != nil {
+			return err
+		}
+
+		if activate {
+			if err := s.checkVehicleChange(ctx, driverID); err != nil {
+				return err
+			}
+		}
+
+		vehicle.DriverID = driverID
+		saved, err = s.addVehicle(ctx, vehicle, activate)
+		return err
+	}
+
+	if err := s.infra.trm.Do(ctx, fn); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "driver vehicle added", "vehicle_id", saved.ID, "vehicle_class", saved.Class, "active", saved.IsActive)
+	return saved, nil
+}
+
+// ActivateVehicle переключает водителя на другой его автомобиль.
+// Класс определяется заново: с возрастом автомобиль может потерять класс XL или PREMIUM.
+func (s *Service) ActivateVehicle(ctx context.Context, driverID, vehicleID uuid.UUID) (*models.DriverVehicle, error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "activate_driver_vehicle",
+		DriverID: driverID.String(),
+	})
+
+	var vehicle *models.DriverVehicle
+	fn := func(ctx context.Context) error {
+		if err := s.checkVehicleChange(ctx, driverID); err != nil {
+			return err
+		}
+
+		var err error
+		vehicle, err = s.repos.driver.GetVehicle(ctx, driverID, vehicleID)
+		if err != nil {
+			return err
+		}
+
+		if class := classifyVehicle(*vehicle); class != vehicle.Class {
+			vehicle.Class = class
+			if err := s.repos.driver.UpdateVehicle(ctx, vehicle); err != nil {
+				return fmt.Errorf("failed to update vehicle class: %w", err)
+			}
+		}
+
+		return s.activate(ctx, vehicle)
+	}
+
+	if err := s.infra.trm.Do(ctx, fn); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "driver vehicle activated", "vehicle_id", vehicle.ID, "vehicle_class", vehicle.Class)
+	return vehicle, nil
+}
+
+// checkVehicleChange запрещает менять активный автомобиль во время поездки:
+// класс поездки уже выбран, а пассажир знает автомобиль по карточке водителя
+func (s *Service) checkVehicleChange(ctx context.Context, driverID uuid.UUID) error {
+	driver, err := s.repos.driver.Get(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	return vehicleChangeAllowed(driver.Status)
+}
+
+func vehicleChangeAllowed(status types.DriverStatus) error {
+	if status != types.StatusDriverOffline && status != types.StatusDriverAvailable {
+		return types.ErrDriverAlreadyOnRide
+	}
+	return nil
+}
+
+// addVehicle определяет класс и сохраняет автомобиль, activate — сделать его активным
+func (s *Service) addVehicle(ctx context.Context, vehicle models.DriverVehicle, activate bool) (*models.DriverVehicle, error) {
+	vehicle.Class = classifyVehicle(vehicle)
+	if err := s.repos.driver.CreateVehicle(ctx, &vehicle); err != nil {
+		return nil, err
+	}
+
+	if activate {
+		if err := s.activate(ctx, &vehicle); err != nil {
+			return nil, err
+		}
+	}
+	return &vehicle, nil
+}
+
+// saveActiveVehicle заменяет атрибуты активного автомобиля, а если активного нет — добавляет его
+func (s *Service) saveActiveVehicle(ctx context.Context, vehicle models.DriverVehicle) (*models.DriverVehicle, error) {
+	active, err := s.repos.driver.ActiveVehicle(ctx, vehicle.DriverID)
+	if errors.Is(err, types.ErrVehicleNotFound) {
+		return s.addVehicle(ctx, vehicle, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	vehicle.ID, vehicle.CreatedAt, vehicle.IsActive = active.ID, active.CreatedAt, true
+	if vehicle.BodyType == "" {
+		vehicle.BodyType = active.BodyType
+	}
+	if vehicle.Class == "" {
+		vehicle.Class = active.Class
+	}
+	vehicle.Class = classifyVehicle(vehicle)
+
+	if err := s.repos.driver.UpdateVehicle(ctx, &vehicle); err != nil {
+		return nil, err
+	}
+	if err := s.repos.driver.SetVehicle(ctx, vehicle.DriverID, vehicle.Vehicle()); err != nil {
+		return nil, fmt.Errorf("failed to update driver profile vehicle: %w", err)
+	}
+	return &vehicle, nil
+}
+
+// activate делает автомобиль активным и переносит его в профиль водителя, по которому идет подбор
+func (s *Service) activate(ctx context.Context, vehicle *models.DriverVehicle) error {
+	if err := s.repos.driver.ActivateVehicle(ctx, vehicle.DriverID, vehicle.ID); err != nil {
+		return err
+	}
+	if err := s.repos.driver.SetVehicle(ctx, vehicle.DriverID, vehicle.Vehicle()); err != nil {
+		return fmt.Errorf("failed to update driver profile vehicle: %w", err)
+	}
+	vehicle.IsActive = true
+	return nil
+}
+
+// classifyVehicle определяет класс автомобиля по типу кузова, а без него — по прежнему классу,
+// чтобы перенесенный из профиля автомобиль XL не терял класс без указания кузова
+func classifyVehicle(v models.DriverVehicle) types.VehicleClass {
+	hint := v.Class
+	if v.BodyType != "" {
+		hint = types.VehicleClass(v.BodyType)
+	}
+	return classify(models.Vehicle{Type: hint, Make: v.Make, Model: v.Model, Color: v.Color, Plate: v.Plate, Year: v.Year})
+}
+
+// newDriverVehicle — автомобиль водителя из атрибутов профиля
+func newDriverVehicle(driverID uuid.UUID, v models.Vehicle, bodyType string) models.DriverVehicle {
+	return models.DriverVehicle{
+		DriverID: driverID,
+		Class:    v.Type,
+		BodyType: bodyType,
+		Make:     v.Make,
+		Model:    v.Model,
+		Color:    v.Color,
+		Plate:    v.Plate,
+		Year:     v.Year,
+	}
+}
@@ -0,0 +1,122 @@
+package location
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// gateTTL — сколько экземпляр помнит последнюю точку водителя без новых координат.
+// Забытая точка ничего не ломает: порядок окончательно проверяется при записи в БД.
+const gateTTL = time.Minute
+
+// причины отброса координаты, они же значения метки reason в метрике
+const (
+	discardStale     = "stale"
+	discardDuplicate = "duplicate"
+)
+
+// acceptedPoint — последняя принятая координата водителя
+type acceptedPoint struct {
+	at       time.Time
+	lat, lon float64
+
+	seen time.Time // когда экземпляр принял точку, по нему запись вытесняется
+}
+
+// orderGate упорядочивает координаты водителя, приходящие одновременно по HTTP и WebSocket.
+// Точка старше последней принятой или повтор принятой точки в пределах окна отбрасывается
+// до записи в БД и публикации. Gate видит только координаты своего экземпляра и быстро отсекает
+// повторы, а координаты, пришедшие на разные экземпляры, упорядочивает запись в БД (см. Ingest).
+type orderGate struct {
+	mu        sync.Mutex
+	last      map[uuid.UUID]acceptedPoint
+	dupWindow time.Duration
+	sweepAt   time.Time
+	clock     clock.Clock
+}
+
+func newOrderGate(dupWindow time.Duration, clk clock.Clock) *orderGate {
+	return &orderGate{
+		last:      make(map[uuid.UUID]acceptedPoint),
+		dupWindow: dupWindow,
+		clock:     clk,
+	}
+}
+
+// discard возвращает причину отброса точки p после принятой prev, пустая строка — точку можно принять
+func (g *orderGate) discard(prev, p acceptedPoint) string {
+	if p.at.Before(prev.at) {
+		return discardStale
+	}
+	if p.lat == prev.lat && p.lon == prev.lon && p.at.Sub(prev.at) < g.dupWindow {
+		return discardDuplicate
+	}
+	return ""
+}
+
+// pointTime — время точки: время подписи устройства, если координата подписана,
+// иначе время приема сервером
+func pointTime(data models.RideLocationUpdate) time.Time {
+	if data.Signature != nil && data.Signature.Timestamp > 0 {
+		return time.Unix(data.Signature.Timestamp, 0)
+	}
+	return data.TimeStamp
+}
+
+// admit резервирует точку за водителем. Если точку нужно отбросить, возвращает причину.
+// Вызывающий должен вызвать release, если точку не удалось сохранить.
+func (g *orderGate) admit(driverID uuid.UUID, data models.RideLocationUpdate) (prev acceptedPoint, reason string) {
+	now := g.clock.Now()
+	p := acceptedPoint{at: pointTime(data), lat: data.Location.Latitude, lon: data.Location.Longitude, seen: now}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.sweep(now)
+
+	prev, seen := g.last[driverID]
+	if seen {
+		if reason := g.discard(prev, p); reason != "" {
+			return prev, reason
+		}
+	}
+
+	g.last[driverID] = p
+	return prev, ""
+}
+
+// sweep раз в gateTTL удаляет водителей, не присылавших координаты дольше gateTTL.
+// Вызывается под g.mu.
+func (g *orderGate) sweep(now time.Time) {
+	if now.Before(g.sweepAt) {
+		return
+	}
+	for id, p := range g.last {
+		if now.Sub(p.seen) >= gateTTL {
+			delete(g.last, id)
+		}
+	}
+	g.sweepAt = now.Add(gateTTL)
+}
+
+// release возвращает предыдущую точку, если зарезервированную не удалось сохранить
+// и после нее не была принята другая
+func (g *orderGate) release(driverID uuid.UUID, data models.RideLocationUpdate, prev acceptedPoint) {
+	at := pointTime(data)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if cur, ok := g.last[driverID]; !ok || !cur.at.Equal(at) || cur.lat != data.Location.Latitude || cur.lon != data.Location.Longitude {
+		return
+	}
+	if prev.at.IsZero() {
+		delete(g.last, driverID)
+		return
+	}
+	g.last[driverID] = prev
+}
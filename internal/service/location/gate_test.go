@@ -0,0 +1,101 @@
+package location
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+func TestOrderGate(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	driverID := uuid.New()
+
+	point := func(after time.Duration, lat, lon float64) models.RideLocationUpdate {
+		return models.RideLocationUpdate{
+			DriverID:  driverID,
+			TimeStamp: start.Add(after),
+			Coordinates: models.Coordinates{
+				Location: models.Location{Latitude: lat, Longitude: lon},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		trace []models.RideLocationUpdate
+		want  []string
+	}{
+		{
+			name:  "points in order are accepted",
+			trace: []models.RideLocationUpdate{point(0, 43.1, 76.1), point(time.Second, 43.2, 76.2)},
+			want:  []string{"", ""},
+		},
+		{
+			name:  "older point is stale",
+			trace: []models.RideLocationUpdate{point(3*time.Second, 43.1, 76.1), point(time.Second, 43.2, 76.2)},
+			want:  []string{"", discardStale},
+		},
+		{
+			name:  "same point from the other channel is a duplicate",
+			trace: []models.RideLocationUpdate{point(0, 43.1, 76.1), point(50*time.Millisecond, 43.1, 76.1)},
+			want:  []string{"", discardDuplicate},
+		},
+		{
+			name:  "parked driver keeps reporting after the window",
+			trace: []models.RideLocationUpdate{point(0, 43.1, 76.1), point(3*time.Second, 43.1, 76.1)},
+			want:  []string{"", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newOrderGate(2*time.Second, clock.NewFake(start))
+			for i, p := range tt.trace {
+				if _, got := g.admit(driverID, p); got != tt.want[i] {
+					t.Fatalf("point %d: got %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOrderGateRelease(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	driverID := uuid.New()
+	first := models.RideLocationUpdate{DriverID: driverID, TimeStamp: start}
+	failed := models.RideLocationUpdate{DriverID: driverID, TimeStamp: start.Add(5 * time.Second)}
+	retry := models.RideLocationUpdate{DriverID: driverID, TimeStamp: start.Add(3 * time.Second)}
+
+	g := newOrderGate(2*time.Second, clock.NewFake(start))
+	g.admit(driverID, first)
+	prev, _ := g.admit(driverID, failed)
+	g.release(driverID, failed, prev)
+
+	if _, reason := g.admit(driverID, retry); reason != "" {
+		t.Fatalf("point after a failed save must be accepted, got %q", reason)
+	}
+}
+
+// Водитель без новых координат дольше gateTTL забывается, его порядок проверяет запись в БД
+func TestOrderGateEviction(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	g := newOrderGate(2*time.Second, clk)
+
+	parked, active := uuid.New(), uuid.New()
+	g.admit(parked, models.RideLocationUpdate{DriverID: parked, TimeStamp: start})
+	for i := range 3 {
+		clk.Advance(gateTTL / 2)
+		g.admit(active, models.RideLocationUpdate{DriverID: active, TimeStamp: start.Add(time.Duration(i+1) * gateTTL / 2)})
+	}
+
+	if _, ok := g.last[parked]; ok {
+		t.Fatal("idle driver was not evicted")
+	}
+	if _, ok := g.last[active]; !ok {
+		t.Fatal("active driver was evicted")
+	}
+}
@@ -0,0 +1,34 @@
+package location
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type DriverChecker interface {
+	IsDriverExist(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type CoordinateRepo interface {
+	CreateCoordinate(ctx context.Context, entityID uuid.UUID, entityType types.EntityType, location models.Location, updatedAt time.Time) (uuid.UUID, error)
+	CreateLocationHistory(ctx context.Context, coordinateID, driverID uuid.UUID, rideID *uuid.UUID, location models.Location, accuracyMeters, speedKmh, headingDegrees float64) (uuid.UUID, error)
+	// LockDriverPosition блокирует координаты водителя до конца транзакции и возвращает текущую, nil — координат нет
+	LockDriverPosition(ctx context.Context, driverID uuid.UUID) (*models.DriverPosition, error)
+}
+
+type DeviceRepo interface {
+	GetSecret(ctx context.Context, driverID uuid.UUID, deviceID string) (string, error)
+	HasDevices(ctx context.Context, driverID uuid.UUID) (bool, error)
+}
+
+type GeoCoder interface {
+	GetAddress(ctx context.Context, longitude, latitude float64) (string, error)
+}
+
+type Publisher interface {
+	PublishLocationUpdate(ctx context.Context, msg models.RideLocationUpdate) error
+}
@@ -0,0 +1,148 @@
+// Package location принимает координаты водителей, хранит историю перемещений
+// и отвечает на гео запросы. Работает внутри driver-service или отдельным location-service.
+package location
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type Service struct {
+	repos struct {
+		driver     DriverChecker
+		coordinate CoordinateRepo
+		device     DeviceRepo
+	}
+	geocoder  GeoCoder
+	publisher Publisher
+	trm       trm.TxManager
+	gate      *orderGate
+
+	// requireSignature — принимать только подписанные устройством координаты
+	requireSignature bool
+
+	l logger.Logger
+}
+
+func New(
+	driverRepo DriverChecker,
+	coordinateRepo CoordinateRepo,
+	deviceRepo DeviceRepo,
+	geocoder GeoCoder,
+	publisher Publisher,
+	trm trm.TxManager,
+	requireSignature bool,
+	duplicateWindow time.Duration,
+	l logger.Logger,
+) *Service {
+	s := &Service{
+		geocoder:         geocoder,
+		publisher:        publisher,
+		trm:              trm,
+		gate:             newOrderGate(duplicateWindow, clock.New()),
+		requireSignature: requireSignature,
+		l:                l,
+	}
+	s.repos.driver = driverRepo
+	s.repos.coordinate = coordinateRepo
+	s.repos.device = deviceRepo
+	return s
+}
+
+// Ingest проверяет подпись устройства, сохраняет координату и точку истории
+// и публикует обновление для ride-service.
+func (s *Service) Ingest(ctx context.Context, data models.RideLocationUpdate) (coordinateID uuid.UUID, err error) {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "update_driver_location",
+		DriverID: data.DriverID.String(),
+	})
+
+	if data.RideID != nil {
+		ctx = wrap.WithRideID(ctx, data.RideID.String())
+	}
+
+	if err := data.Validate(); err != nil {
+		return uuid.UUID{}, wrap.Error(ctx, err)
+	}
+
+	if err := s.verifyLocationSignature(ctx, data); err != nil {
+		return uuid.UUID{}, wrap.Error(ctx, err)
+	}
+
+	prev, reason := s.gate.admit(data.DriverID, data)
+	if reason != "" {
+		return uuid.UUID{}, s.discarded(ctx, reason)
+	}
+
+	fn := func(ctx context.Context) error {
+		// Check if driver exists in DB
+		exist, err := s.repos.driver.IsDriverExist(ctx, data.DriverID)
+		if err != nil {
+			return fmt.Errorf("failed to check driver existence: %w", err)
+		}
+		if !exist {
+			return types.ErrUserNotFound
+		}
+
+		// координата могла прийти на другой экземпляр: порядок проверяется по текущей координате в БД
+		// под блокировкой водителя, иначе старая точка с другого экземпляра перезапишет новую
+		current, err := s.repos.coordinate.LockDriverPosition(ctx, data.DriverID)
+		if err != nil {
+			return fmt.Errorf("failed to lock driver position: %w", err)
+		}
+		if current != nil {
+			last := acceptedPoint{at: current.UpdatedAt, lat: current.Location.Latitude, lon: current.Location.Longitude}
+			if reason = s.gate.discard(last, acceptedPoint{at: pointTime(data), lat: data.Location.Latitude, lon: data.Location.Longitude}); reason != "" {
+				return types.ErrLocationDiscarded
+			}
+		}
+
+		// Get address by geocoding
+		data.Location.Address, err = s.geocoder.GetAddress(ctx, data.Location.Longitude, data.Location.Latitude)
+		if err != nil {
+			s.l.Warn(ctx, "Failed to get address", "error", err.Error())
+		}
+
+		coordinateID, err = s.repos.coordinate.CreateCoordinate(ctx, data.DriverID, types.Driver, data.Location, pointTime(data))
+		if err != nil {
+			return fmt.Errorf("failed to insert new coordinate data: %w", err)
+		}
+
+		if _, err := s.repos.coordinate.CreateLocationHistory(ctx, coordinateID, data.DriverID, data.RideID, data.Location, data.AccuracyMeters, data.SpeedKmh, data.HeadingDegrees); err != nil {
+			return fmt.Errorf("failed to create location history: %w", err)
+		}
+
+		if err := s.publisher.PublishLocationUpdate(ctx, data); err != nil {
+			return fmt.Errorf("failed to publish location update: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := s.trm.Do(ctx, fn); err != nil {
+		s.gate.release(data.DriverID, data, prev)
+		if reason != "" {
+			return uuid.UUID{}, s.discarded(ctx, reason)
+		}
+		return uuid.UUID{}, wrap.Error(ctx, err)
+	}
+
+	return coordinateID, nil
+}
+
+// discarded учитывает отброшенную координату в метрике
+func (s *Service) discarded(ctx context.Context, reason string) error {
+	metrics.LocationUpdatesDiscardedTotal.WithLabelValues("location", reason).Inc()
+	s.l.Debug(ctx, "location update discarded", "reason", reason)
+	return types.ErrLocationDiscarded
+}
@@ -0,0 +1,99 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type inlineTxManager struct{}
+
+func (inlineTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type knownDrivers struct{}
+
+func (knownDrivers) IsDriverExist(context.Context, uuid.UUID) (bool, error) { return true, nil }
+
+type noDevices struct{ DeviceRepo }
+
+func (noDevices) HasDevices(context.Context, uuid.UUID) (bool, error) { return false, nil }
+
+type noAddress struct{}
+
+func (noAddress) GetAddress(context.Context, float64, float64) (string, error) { return "", nil }
+
+type countingPublisher struct{ published int }
+
+func (p *countingPublisher) PublishLocationUpdate(context.Context, models.RideLocationUpdate) error {
+	p.published++
+	return nil
+}
+
+// sharedCoordinates — таблица coordinates, общая для всех экземпляров
+type sharedCoordinates struct {
+	CoordinateRepo
+	current *models.DriverPosition
+}
+
+func (r *sharedCoordinates) LockDriverPosition(context.Context, uuid.UUID) (*models.DriverPosition, error) {
+	return r.current, nil
+}
+
+func (r *sharedCoordinates) CreateCoordinate(_ context.Context, entityID uuid.UUID, _ types.EntityType, location models.Location, updatedAt time.Time) (uuid.UUID, error) {
+	r.current = &models.DriverPosition{DriverID: entityID, Location: location, UpdatedAt: updatedAt}
+	return uuid.New(), nil
+}
+
+func (r *sharedCoordinates) CreateLocationHistory(context.Context, uuid.UUID, uuid.UUID, *uuid.UUID, models.Location, float64, float64, float64) (uuid.UUID, error) {
+	return uuid.New(), nil
+}
+
+// Точка, пришедшая на другой экземпляр позже более новой, отбрасывается при записи в БД
+func TestIngest_OrdersPointsAcrossInstances(t *testing.T) {
+	coordinates := &sharedCoordinates{}
+	publisher := &countingPublisher{}
+	instance := func() *Service {
+		return New(knownDrivers{}, coordinates, noDevices{}, noAddress{}, publisher, inlineTxManager{}, false, 2*time.Second, logger.InitLogger("test", "error"))
+	}
+	first, second := instance(), instance()
+
+	start := time.Now()
+	driverID := uuid.New()
+	point := func(after time.Duration, lat float64) models.RideLocationUpdate {
+		return models.RideLocationUpdate{
+			DriverID:    driverID,
+			TimeStamp:   start.Add(after),
+			Coordinates: models.Coordinates{Location: models.Location{Latitude: lat, Longitude: 76.9}},
+		}
+	}
+
+	if _, err := first.Ingest(context.Background(), point(3*time.Second, 43.2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Ingest(context.Background(), point(time.Second, 43.1)); !errors.Is(err, types.ErrLocationDiscarded) {
+		t.Fatalf("older point on another instance: got %v, want ErrLocationDiscarded", err)
+	}
+	if _, err := second.Ingest(context.Background(), point(4*time.Second, 43.3)); err != nil {
+		t.Fatalf("newer point after a discarded one: %v", err)
+	}
+
+	if coordinates.current.Location.Latitude != 43.3 || publisher.published != 2 {
+		t.Fatalf("current latitude %v, published %d; want 43.3, 2", coordinates.current.Location.Latitude, publisher.published)
+	}
+}
@@ -0,0 +1,58 @@
+package location
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/locsign"
+)
+
+// maxSignatureSkew — насколько время подписи может отличаться от времени сервера
+const maxSignatureSkew = 5 * time.Minute
+
+// verifyLocationSignature проверяет HMAC подпись координат секретом устройства.
+// Неподписанные координаты принимаются, только если водителю еще не выдавался
+// секрет устройства и подпись не требуется настройкой сервиса: иначе украденный
+// токен позволил бы отправлять координаты без подписи.
+func (s *Service) verifyLocationSignature(ctx context.Context, data models.RideLocationUpdate) error {
+	sig := data.Signature
+	if sig == nil {
+		if s.requireSignature {
+			return types.ErrLocationSignatureRequired
+		}
+
+		provisioned, err := s.repos.device.HasDevices(ctx, data.DriverID)
+		if err != nil {
+			return fmt.Errorf("failed to check driver devices: %w", err)
+		}
+		if provisioned {
+			return types.ErrLocationSignatureRequired
+		}
+		return nil
+	}
+
+	signedAt := time.Unix(sig.Timestamp, 0)
+	if skew := time.Since(signedAt); skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return types.ErrLocationSignatureExpired
+	}
+
+	secret, err := s.repos.device.GetSecret(ctx, data.DriverID, sig.DeviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get device secret: %w", err)
+	}
+	if secret == "" {
+		s.l.Warn(ctx, "location signed by unknown device", "device_id", sig.DeviceID)
+		return types.ErrInvalidLocationSignature
+	}
+
+	payload := locsign.Payload(sig.Timestamp, data.Location.Latitude, data.Location.Longitude)
+	if !locsign.Verify(secret, payload, sig.Value) {
+		s.l.Warn(ctx, "location signature mismatch", "device_id", sig.DeviceID)
+		return types.ErrInvalidLocationSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type (
+	PreferenceRepo interface {
+		Get(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error)
+		Upsert(ctx context.Context, prefs *models.NotificationPreferences) error
+	}
+
+	UserRepo interface {
+		GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	}
+
+	PushTokenRepo interface {
+		Save(ctx context.Context, t *models.PushToken) error
+		Delete(ctx context.Context, userID uuid.UUID, deviceID string) error
+		DeleteToken(ctx context.Context, token string) error
+		List(ctx context.Context, userID uuid.UUID) ([]models.PushToken, error)
+	}
+
+	// CommunicationRepo - журнал коммуникаций поездки
+	CommunicationRepo interface {
+		Create(ctx context.Context, c *models.Communication) error
+	}
+
+	// PushSender доставляет push уведомление на устройство.
+	// types.ErrPushTokenInvalid — токен больше не действует и должен быть удален.
+	PushSender interface {
+		SendPush(ctx context.Context, token models.PushToken, title, body string, data map[string]string) error
+	}
+
+	SMSSender interface {
+		SendSMS(ctx context.Context, phone, text string) error
+	}
+
+	EmailSender interface {
+		SendEmail(ctx context.Context, to, subject, body string) error
+	}
+
+	// VoiceCaller - телефония, зачитывает текст автоматическим звонком
+	VoiceCaller interface {
+		Call(ctx context.Context, phone, text string) error
+	}
+)
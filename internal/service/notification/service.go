@@ -0,0 +1,249 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Service хранит настройки уведомлений и рассылает уведомления по разрешенным каналам.
+// Провайдер канала может быть nil - тогда канал пропускается.
+// Результаты уведомлений о поездке пишутся в журнал коммуникаций, если comms не nil.
+type Service struct {
+	prefRepo   PreferenceRepo
+	userRepo   UserRepo
+	pushTokens PushTokenRepo
+	comms      CommunicationRepo
+
+	push  PushSender
+	sms   SMSSender
+	email EmailSender
+	voice VoiceCaller
+
+	l logger.Logger
+}
+
+func New(prefRepo PreferenceRepo, userRepo UserRepo, pushTokens PushTokenRepo, comms CommunicationRepo, push PushSender, sms SMSSender, email EmailSender, voice VoiceCaller, l logger.Logger) *Service {
+	return &Service{
+		prefRepo:   prefRepo,
+		userRepo:   userRepo,
+		pushTokens: pushTokens,
+		comms:      comms,
+		push:       push,
+		sms:        sms,
+		email:      email,
+		voice:      voice,
+		l:          l,
+	}
+}
+
+// GetPreferences возвращает настройки пользователя, по умолчанию - подписка на все
+func (s *Service) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	prefs, err := s.prefRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefs == nil {
+		return models.DefaultNotificationPreferences(userID), nil
+	}
+
+	return prefs, nil
+}
+
+func (s *Service) UpdatePreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "update_notification_preferences", UserID: prefs.UserID.String()})
+
+	slices.Sort(prefs.Channels)
+	prefs.Channels = slices.Compact(prefs.Channels)
+	slices.Sort(prefs.EventTypes)
+	prefs.EventTypes = slices.Compact(prefs.EventTypes)
+
+	if err := s.prefRepo.Upsert(ctx, prefs); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "notification preferences updated", "channels", prefs.Channels, "event_types", prefs.EventTypes)
+	return nil
+}
+
+// RegisterPushToken сохраняет push токен устройства, повторная регистрация устройства заменяет токен
+func (s *Service) RegisterPushToken(ctx context.Context, t *models.PushToken) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "register_push_token", UserID: t.UserID.String()})
+
+	if err := s.pushTokens.Save(ctx, t); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "push token registered", "device_id", t.DeviceID, "platform", t.Platform)
+	return nil
+}
+
+// RemovePushToken удаляет push токен устройства, например при выходе из аккаунта
+func (s *Service) RemovePushToken(ctx context.Context, userID uuid.UUID, deviceID string) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "remove_push_token", UserID: userID.String()})
+
+	if err := s.pushTokens.Delete(ctx, userID, deviceID); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "push token removed", "device_id", deviceID)
+	return nil
+}
+
+// Notify отправляет уведомление по всем каналам, которые разрешил пользователь.
+// Ошибки отдельных каналов не прерывают рассылку и возвращаются вместе.
+func (s *Service) Notify(ctx context.Context, n models.Notification) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "notify_user", UserID: n.UserID.String()})
+
+	prefs, err := s.GetPreferences(ctx, n.UserID)
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, n.UserID)
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+	if user == nil {
+		return wrap.Error(ctx, types.ErrUserNotFound)
+	}
+
+	var errs []error
+	for _, channel := range types.AllNotificationChannels {
+		if !prefs.Allows(channel, n.Event) {
+			s.l.Debug(ctx, "notification skipped by user preferences", "channel", channel, "event_type", n.Event)
+			s.record(ctx, n, channel, types.CommunicationSkipped, "disabled in user preferences")
+			continue
+		}
+
+		sent, err := s.send(ctx, channel, user, n)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			s.record(ctx, n, channel, types.CommunicationFailed, err.Error())
+			continue
+		}
+		if sent {
+			s.l.Debug(ctx, "notification sent", "channel", channel, "event_type", n.Event)
+			s.record(ctx, n, channel, types.CommunicationDelivered, "")
+		} else {
+			s.record(ctx, n, channel, types.CommunicationSkipped, "no provider or contact for the channel")
+		}
+	}
+
+	// звонок попадает в журнал, только если он был нужен пользователю
+	if called, err := s.readAloud(ctx, prefs, user, n); err != nil {
+		errs = append(errs, fmt.Errorf("voice: %w", err))
+		s.record(ctx, n, types.ChannelVoice, types.CommunicationFailed, err.Error())
+	} else if called {
+		s.l.Debug(ctx, "notification read aloud", "event_type", n.Event)
+		s.record(ctx, n, types.ChannelVoice, types.CommunicationDelivered, "")
+	}
+
+	if len(errs) > 0 {
+		return wrap.Error(ctx, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// send отправляет уведомление в канал, sent=false - провайдер не настроен или нет контакта
+func (s *Service) send(ctx context.Context, channel types.NotificationChannel, user *models.User, n models.Notification) (bool, error) {
+	switch channel {
+	case types.ChannelPush:
+		if s.push == nil {
+			return false, nil
+		}
+		return s.sendPush(ctx, user.ID, n)
+	case types.ChannelSMS:
+		phone, _ := user.Attrs["phone"].(string)
+		if s.sms == nil || phone == "" {
+			return false, nil
+		}
+		return true, s.sms.SendSMS(ctx, phone, n.Title+": "+n.Body)
+	case types.ChannelEmail:
+		if s.email == nil || user.Email == "" {
+			return false, nil
+		}
+		return true, s.email.SendEmail(ctx, user.Email, n.Title, n.Body)
+	}
+
+	return false, nil
+}
+
+// sendPush отправляет уведомление на все устройства пользователя, sent=false - устройств нет.
+// Токены, которые провайдер больше не принимает, удаляются.
+func (s *Service) sendPush(ctx context.Context, userID uuid.UUID, n models.Notification) (bool, error) {
+	tokens, err := s.pushTokens.List(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		sent bool
+		errs []error
+	)
+	for _, t := range tokens {
+		err := s.push.SendPush(ctx, t, n.Title, n.Body, n.Data)
+		switch {
+		case errors.Is(err, types.ErrPushTokenInvalid):
+			s.l.Info(ctx, "removing invalid push token", "device_id", t.DeviceID, "platform", t.Platform)
+			if err := s.pushTokens.DeleteToken(ctx, t.Token); err != nil {
+				errs = append(errs, err)
+			}
+		case err != nil:
+			errs = append(errs, fmt.Errorf("device %s: %w", t.DeviceID, err))
+		default:
+			sent = true
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// readAloud дублирует уведомление голосовым звонком, если пользователь включил озвучивание.
+// Звонок не зависит от выбранных каналов, но учитывает подписку на тип уведомления.
+func (s *Service) readAloud(ctx context.Context, prefs *models.NotificationPreferences, user *models.User, n models.Notification) (bool, error) {
+	if !prefs.VoiceReadout || n.ReadAloud == "" || !prefs.AllowsEvent(n.Event) {
+		return false, nil
+	}
+
+	phone, _ := user.Attrs["phone"].(string)
+	if s.voice == nil || phone == "" {
+		return false, nil
+	}
+	return true, s.voice.Call(ctx, phone, n.ReadAloud)
+}
+
+// record пишет результат доставки уведомления о поездке в журнал коммуникаций.
+// Ошибка записи не влияет на рассылку.
+func (s *Service) record(ctx context.Context, n models.Notification, channel types.NotificationChannel, status types.CommunicationStatus, detail string) {
+	if s.comms == nil || n.RideID == nil {
+		return
+	}
+
+	// событие поездки точнее категории уведомления
+	messageType := n.Data["event_type"]
+	if messageType == "" {
+		messageType = n.Event.String()
+	}
+
+	c := &models.Communication{
+		RideID:      *n.RideID,
+		RecipientID: n.UserID,
+		Channel:     channel,
+		MessageType: messageType,
+		Status:      status,
+		Detail:      detail,
+	}
+	if err := s.comms.Create(ctx, c); err != nil {
+		s.l.Warn(ctx, "failed to record ride communication", "channel", channel, "error", err.Error())
+	}
+}
@@ -0,0 +1,72 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memPushTokens — push токены пользователя в памяти
+type memPushTokens struct {
+	PushTokenRepo
+	tokens []models.PushToken
+}
+
+func (r *memPushTokens) List(context.Context, uuid.UUID) ([]models.PushToken, error) {
+	return r.tokens, nil
+}
+
+func (r *memPushTokens) DeleteToken(_ context.Context, token string) error {
+	for i, t := range r.tokens {
+		if t.Token == token {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// rejectingSender отвечает на отправку ошибкой из errs по токену
+type rejectingSender struct {
+	errs map[string]error
+}
+
+func (s rejectingSender) SendPush(_ context.Context, token models.PushToken, _, _ string, _ map[string]string) error {
+	return s.errs[token.Token]
+}
+
+// Токены, которые провайдер больше не принимает, удаляются, остальные остаются
+func TestSendPush_PrunesInvalidTokens(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	tokens := &memPushTokens{tokens: []models.PushToken{
+		{Token: "valid", DeviceID: "phone"},
+		{Token: "unregistered", DeviceID: "old-phone"},
+		{Token: "retry", DeviceID: "tablet"},
+	}}
+	sender := rejectingSender{errs: map[string]error{
+		"unregistered": types.ErrPushTokenInvalid,
+		"retry":        unavailable,
+	}}
+	s := New(nil, nil, tokens, nil, sender, nil, nil, nil, logger.InitLogger("test", "error"))
+
+	sent, err := s.sendPush(context.Background(), uuid.New(), models.Notification{Title: "title", Body: "body"})
+	if !sent {
+		t.Fatal("push delivered to a valid token must be reported as sent")
+	}
+	if !errors.Is(err, unavailable) || errors.Is(err, types.ErrPushTokenInvalid) {
+		t.Fatalf("got %v, want only the temporary failure", err)
+	}
+
+	var left []string
+	for _, token := range tokens.tokens {
+		left = append(left, token.Token)
+	}
+	if len(left) != 2 || left[0] != "valid" || left[1] != "retry" {
+		t.Fatalf("tokens left %v, want [valid retry]", left)
+	}
+}
@@ -0,0 +1,110 @@
+// Package ops выполняет операционные действия, которые admin-service назначил
+// экземпляру сервиса (target_service, target_instance), и записывает результат в журнал.
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type Repo interface {
+	Pending(ctx context.Context, service, instance string) ([]models.OpsAction, error)
+	Complete(ctx context.Context, id uuid.UUID, status types.OpsActionStatus, result []byte, errMsg *string) error
+}
+
+// Handler выполняет действие и возвращает результат для журнала
+type Handler func(ctx context.Context, a models.OpsAction) (any, error)
+
+type Executor struct {
+	service  string
+	instance string
+	repo     Repo
+	handlers map[types.OpsActionType]Handler
+	l        logger.Logger
+}
+
+// New создает исполнитель действий экземпляра. Пустой instance заменяется hostname.
+func New(service, instance string, repo Repo, l logger.Logger) *Executor {
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	return &Executor{
+		service:  service,
+		instance: instance,
+		repo:     repo,
+		handlers: make(map[types.OpsActionType]Handler),
+		l:        l,
+	}
+}
+
+// Handle регистрирует обработчик действия. Вызывается до RunJob.
+func (e *Executor) Handle(action types.OpsActionType, h Handler) {
+	e.handlers[action] = h
+}
+
+// RunJob периодически выполняет назначенные экземпляру действия
+func (e *Executor) RunJob(ctx context.Context, interval time.Duration) {
+	ctx = wrap.WithAction(ctx, "ops_actions_job")
+	if interval <= 0 {
+		e.l.Warn(ctx, "ops actions job disabled", "interval", interval.String())
+		return
+	}
+
+	e.l.Info(ctx, "waiting for ops actions", "service", e.service, "instance", e.instance)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		actions, err := e.repo.Pending(ctx, e.service, e.instance)
+		if err != nil {
+			e.l.Error(wrap.ErrorCtx(ctx, err), "failed to get pending ops actions", err)
+			continue
+		}
+
+		for _, a := range actions {
+			e.apply(ctx, a)
+		}
+	}
+}
+
+func (e *Executor) apply(ctx context.Context, a models.OpsAction) {
+	status := types.OpsApplied
+	var result []byte
+	var errMsg *string
+
+	handler, ok := e.handlers[a.Action]
+	if !ok {
+		status = types.OpsFailed
+		msg := types.ErrUnknownOpsAction.Error()
+		errMsg = &msg
+	} else if res, err := handler(ctx, a); err != nil {
+		status = types.OpsFailed
+		msg := err.Error()
+		errMsg = &msg
+	} else {
+		result, _ = json.Marshal(res)
+	}
+
+	if err := e.repo.Complete(ctx, a.ID, status, result, errMsg); err != nil {
+		e.l.Error(wrap.ErrorCtx(ctx, err), "failed to complete ops action", err, "ops_action_id", a.ID)
+		return
+	}
+
+	e.l.Info(ctx, "ops action executed", "ops_action_id", a.ID, "action", a.Action, "status", status)
+}
@@ -0,0 +1,328 @@
+package partner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	drivergo "github.com/Temutjin2k/ride-hail-system/internal/service/driver"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// trackTimeout — максимальная длительность отслеживания поездки
+	trackTimeout = 72 * time.Hour
+)
+
+// Dispatcher доставляет офферы и детали поездки водителям таксопарков через вебхук партнёра,
+// остальным водителям — через WebSocket. Реализует drivergo.DriverCommunicator,
+// поэтому движок подбора работает с водителями партнёров без изменений.
+//
+// Партнёр отвечает на оффер и присылает координаты через partner API на любой экземпляр
+// driver-service, поэтому офферы и отслеживание поездок хранятся в Postgres. Экземпляр,
+// который ждёт ответа или координат, будят события pkg/invalidation.
+type Dispatcher struct {
+	ws       drivergo.DriverCommunicator
+	repo     PartnerRepo
+	dispatch DispatchRepo
+	events   EventPublisher
+	webhook  WebhookSender
+
+	mu       sync.Mutex
+	offers   map[uuid.UUID]chan struct{} // offer_id -> ожидание ответа партнёра на этом экземпляре
+	trackers map[uuid.UUID]chan struct{} // driver_id -> отслеживание поездки на этом экземпляре
+
+	l logger.Logger
+}
+
+func NewDispatcher(ws drivergo.DriverCommunicator, repo PartnerRepo, dispatch DispatchRepo, events EventPublisher, webhook WebhookSender, l logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		ws:       ws,
+		repo:     repo,
+		dispatch: dispatch,
+		events:   events,
+		webhook:  webhook,
+		offers:   make(map[uuid.UUID]chan struct{}),
+		trackers: make(map[uuid.UUID]chan struct{}),
+		l:        l,
+	}
+}
+
+func (d *Dispatcher) GetRideOffer(ctx context.Context, driverID uuid.UUID, offer models.RideOffer) (bool, error) {
+	const op = "PartnerDispatcher.GetRideOffer"
+
+	partner, err := d.repo.GetByDriver(ctx, driverID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if partner == nil {
+		return d.ws.GetRideOffer(ctx, driverID, offer)
+	}
+
+	offer.MsgType = "ride_offer"
+	// партнёр отвечает до истечения оффера, как и водитель по WebSocket
+	window := offer.ResponseWindow()
+	if err := d.dispatch.CreateOffer(ctx, models.PartnerOffer{
+		OfferID:   offer.ID,
+		PartnerID: partner.ID,
+		DriverID:  driverID,
+		RideID:    offer.RideID,
+		ExpiresAt: time.Now().Add(window),
+	}); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	wake := d.register(d.offers, offer.ID)
+	defer func() {
+		d.unregister(d.offers, offer.ID, wake)
+		if err := d.dispatch.DeleteOffer(context.WithoutCancel(ctx), offer.ID); err != nil {
+			d.l.Warn(ctx, "failed to delete partner offer", "offer_id", offer.ID, "error", err.Error())
+		}
+	}()
+
+	if err := d.send(ctx, partner, "ride_offer", driverID, offer); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("%s: %s", op, "ctx (Done)")
+		case <-timer.C:
+			return false, fmt.Errorf("%s: %w", op, types.ErrListenTimeout)
+		case <-wake:
+			accepted, err := d.dispatch.OfferResponse(ctx, offer.ID)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", op, err)
+			}
+			if accepted != nil {
+				return *accepted, nil
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) SendRideDetails(ctx context.Context, details models.RideDetails) error {
+	const op = "PartnerDispatcher.SendRideDetails"
+
+	partner, err := d.repo.GetByDriver(ctx, *details.DriverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if partner == nil {
+		return d.ws.SendRideDetails(ctx, details)
+	}
+
+	details.MsgType = "ride_details"
+	if err := d.send(ctx, partner, "ride_details", *details.DriverID, details); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) SendRideReleased(ctx context.Context, driverID uuid.UUID, msg models.RideReleased) error {
+	const op = "PartnerDispatcher.SendRideReleased"
+
+	partner, err := d.repo.GetByDriver(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if partner == nil {
+		return d.ws.SendRideReleased(ctx, driverID, msg)
+	}
+
+	msg.MsgType = "ride_released"
+	if err := d.send(ctx, partner, "ride_released", driverID, msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) RevokeRideOffer(ctx context.Context, driverID uuid.UUID, msg models.RideOfferRevoked) error {
+	const op = "PartnerDispatcher.RevokeRideOffer"
+
+	partner, err := d.repo.GetByDriver(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if partner == nil {
+		return d.ws.RevokeRideOffer(ctx, driverID, msg)
+	}
+
+	msg.MsgType = "ride_offer_revoked"
+	if err := d.send(ctx, partner, "ride_offer_revoked", driverID, msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SendStats — плитка статистики есть только в приложении водителя, партнёру не отправляется
+func (d *Dispatcher) SendStats(ctx context.Context, driverID uuid.UUID, stats models.DriverStats) error {
+	return d.ws.SendStats(ctx, driverID, stats)
+}
+
+// SendShiftSummary — итоги смены показываются только в приложении водителя, партнёру не отправляются
+func (d *Dispatcher) SendShiftSummary(ctx context.Context, driverID uuid.UUID, summary models.SessionSummary) error {
+	return d.ws.SendShiftSummary(ctx, driverID, summary)
+}
+
+func (d *Dispatcher) ConnectedDrivers() []uuid.UUID {
+	return d.ws.ConnectedDrivers()
+}
+
+// ListenLocationUpdates для водителя партнёра ждёт координаты, которые партнёр присылает в partner API
+func (d *Dispatcher) ListenLocationUpdates(ctx context.Context, driverID, rideID uuid.UUID, handler func(ctx context.Context, location models.RideLocationUpdate) error) error {
+	const op = "PartnerDispatcher.ListenLocationUpdates"
+
+	partner, err := d.repo.GetByDriver(ctx, driverID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if partner == nil {
+		return d.ws.ListenLocationUpdates(ctx, driverID, rideID, handler)
+	}
+
+	if err := d.dispatch.StartTracking(ctx, driverID, rideID, time.Now().Add(trackTimeout)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	wake := d.register(d.trackers, driverID)
+	defer func() {
+		d.unregister(d.trackers, driverID, wake)
+		if err := d.dispatch.StopTracking(context.WithoutCancel(ctx), driverID, rideID); err != nil {
+			d.l.Warn(ctx, "failed to stop partner ride tracking", "driver_id", driverID, "error", err.Error())
+		}
+	}()
+
+	timer := time.NewTimer(trackTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %s", op, "ctx (Done)")
+		case <-timer.C:
+			return fmt.Errorf("%s: %w", op, types.ErrListenTimeout)
+		case <-wake:
+			updates, err := d.dispatch.TakeTrackUpdates(ctx, driverID, rideID)
+			if err != nil {
+				d.l.Warn(ctx, "failed to take partner driver locations", "driver_id", driverID, "error", err.Error())
+				continue
+			}
+
+			for _, update := range updates {
+				update.RideID = &rideID
+				if err := handler(ctx, update); err != nil {
+					if errors.Is(err, types.ErrRideCancelled) {
+						return nil
+					}
+					d.l.Warn(ctx, "failed to handle partner driver location", "driver_id", driverID, "error", err.Error())
+				}
+			}
+		}
+	}
+}
+
+// RespondOffer записывает ответ партнёра на оффер и будит экземпляр, который его ждёт
+func (d *Dispatcher) RespondOffer(ctx context.Context, partnerID, offerID uuid.UUID, accepted bool) error {
+	// чужой оффер не раскрываем, отвечаем как на несуществующий
+	ok, err := d.dispatch.RespondOffer(ctx, partnerID, offerID, accepted)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return types.ErrOfferNotFound
+	}
+
+	if err := d.events.Publish(ctx, types.CachePartnerOffers, offerID.String()); err != nil {
+		d.l.Error(ctx, "failed to notify partner offer response", err, "offer_id", offerID)
+	}
+	return nil
+}
+
+// Track ставит координаты в отслеживание активной поездки водителя.
+// false — поездка водителя сейчас не отслеживается.
+func (d *Dispatcher) Track(ctx context.Context, update models.RideLocationUpdate) (bool, error) {
+	tracked, err := d.dispatch.AddTrackUpdate(ctx, update)
+	if err != nil || !tracked {
+		return false, err
+	}
+
+	if err := d.events.Publish(ctx, types.CachePartnerTracking, update.DriverID.String()); err != nil {
+		d.l.Error(ctx, "failed to notify partner driver location", err, "driver_id", update.DriverID)
+	}
+	return true, nil
+}
+
+// OfferResponded будит ожидание оффера на этом экземпляре. Событие без ключа
+// (переподключение шины) будит все ожидания: ответы могли прийти без уведомления.
+func (d *Dispatcher) OfferResponded(_ context.Context, e invalidation.Event) {
+	d.wake(d.offers, e.Key)
+}
+
+// TrackUpdated будит отслеживание поездки водителя на этом экземпляре
+func (d *Dispatcher) TrackUpdated(_ context.Context, e invalidation.Event) {
+	d.wake(d.trackers, e.Key)
+}
+
+func (d *Dispatcher) register(waiters map[uuid.UUID]chan struct{}, id uuid.UUID) chan struct{} {
+	wake := make(chan struct{}, 1)
+	d.mu.Lock()
+	waiters[id] = wake
+	d.mu.Unlock()
+	return wake
+}
+
+func (d *Dispatcher) unregister(waiters map[uuid.UUID]chan struct{}, id uuid.UUID, wake chan struct{}) {
+	d.mu.Lock()
+	if waiters[id] == wake {
+		delete(waiters, id)
+	}
+	d.mu.Unlock()
+}
+
+func (d *Dispatcher) wake(waiters map[uuid.UUID]chan struct{}, key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if key == "" {
+		for _, ch := range waiters {
+			notify(ch)
+		}
+		return
+	}
+
+	id, err := uuid.Parse(key)
+	if err != nil {
+		return
+	}
+	if ch, ok := waiters[id]; ok {
+		notify(ch)
+	}
+}
+
+// notify не блокируется: непрочитанного сигнала достаточно, чтобы ожидание перечитало состояние
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, partner *models.Partner, eventType string, driverID uuid.UUID, data any) error {
+	return d.webhook.Send(ctx, partner.WebhookURL, partner.WebhookSecret, models.PartnerWebhookEvent{
+		Type:     eventType,
+		DriverID: driverID,
+		Data:     data,
+		SentAt:   time.Now(),
+	})
+}
@@ -0,0 +1,232 @@
+package partner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memDispatch повторяет partner_offers и partner_track_updates, общие для экземпляров
+type memDispatch struct {
+	mu       sync.Mutex
+	offers   map[uuid.UUID]*models.PartnerOffer
+	answers  map[uuid.UUID]bool
+	tracking map[uuid.UUID]uuid.UUID
+	updates  map[uuid.UUID][]models.RideLocationUpdate
+}
+
+func newMemDispatch() *memDispatch {
+	return &memDispatch{
+		offers:   make(map[uuid.UUID]*models.PartnerOffer),
+		answers:  make(map[uuid.UUID]bool),
+		tracking: make(map[uuid.UUID]uuid.UUID),
+		updates:  make(map[uuid.UUID][]models.RideLocationUpdate),
+	}
+}
+
+func (r *memDispatch) CreateOffer(_ context.Context, offer models.PartnerOffer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.offers[offer.OfferID] = &offer
+	return nil
+}
+
+func (r *memDispatch) RespondOffer(_ context.Context, partnerID, offerID uuid.UUID, accepted bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	offer, ok := r.offers[offerID]
+	if _, answered := r.answers[offerID]; !ok || answered || offer.PartnerID != partnerID || time.Now().After(offer.ExpiresAt) {
+		return false, nil
+	}
+	r.answers[offerID] = accepted
+	return true, nil
+}
+
+func (r *memDispatch) OfferResponse(_ context.Context, offerID uuid.UUID) (*bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	accepted, ok := r.answers[offerID]
+	if !ok {
+		return nil, nil
+	}
+	return &accepted, nil
+}
+
+func (r *memDispatch) DeleteOffer(_ context.Context, offerID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.offers, offerID)
+	delete(r.answers, offerID)
+	return nil
+}
+
+func (r *memDispatch) StartTracking(_ context.Context, driverID, rideID uuid.UUID, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracking[driverID] = rideID
+	return nil
+}
+
+func (r *memDispatch) StopTracking(_ context.Context, driverID, rideID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tracking[driverID] == rideID {
+		delete(r.tracking, driverID)
+		delete(r.updates, driverID)
+	}
+	return nil
+}
+
+func (r *memDispatch) AddTrackUpdate(_ context.Context, update models.RideLocationUpdate) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tracking[update.DriverID]; !ok {
+		return false, nil
+	}
+	r.updates[update.DriverID] = append(r.updates[update.DriverID], update)
+	return true, nil
+}
+
+func (r *memDispatch) TakeTrackUpdates(_ context.Context, driverID, _ uuid.UUID) ([]models.RideLocationUpdate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	updates := r.updates[driverID]
+	delete(r.updates, driverID)
+	return updates, nil
+}
+
+// notifyBus доставляет событие всем экземплярам, как Postgres NOTIFY
+type notifyBus struct {
+	instances []*Dispatcher
+}
+
+func (b *notifyBus) Publish(ctx context.Context, cache, key string) error {
+	for _, d := range b.instances {
+		switch cache {
+		case types.CachePartnerOffers:
+			d.OfferResponded(ctx, invalidation.Event{Cache: cache, Key: key})
+		case types.CachePartnerTracking:
+			d.TrackUpdated(ctx, invalidation.Event{Cache: cache, Key: key})
+		}
+	}
+	return nil
+}
+
+type fleetRepo struct {
+	PartnerRepo
+	partner *models.Partner
+}
+
+func (r fleetRepo) GetByDriver(context.Context, uuid.UUID) (*models.Partner, error) {
+	return r.partner, nil
+}
+
+// webhookFunc вызывает партнёра на каждое событие вебхука
+type webhookFunc func(event models.PartnerWebhookEvent)
+
+func (f webhookFunc) Send(_ context.Context, _, _ string, event any) error {
+	f(event.(models.PartnerWebhookEvent))
+	return nil
+}
+
+// Партнёр отвечает на оффер через partner API, запрос попадает на другой экземпляр driver-service
+func TestGetRideOffer_PartnerRespondsOnAnotherInstance(t *testing.T) {
+	partner := &models.Partner{ID: uuid.New()}
+	repo := fleetRepo{partner: partner}
+	store := newMemDispatch()
+	bus := &notifyBus{}
+	log := logger.InitLogger("test", "error")
+
+	other := NewDispatcher(nil, repo, store, bus, nil, log)
+	wrongPartner := make(chan error, 1)
+	webhook := webhookFunc(func(event models.PartnerWebhookEvent) {
+		offer := event.Data.(models.RideOffer)
+		go func() {
+			wrongPartner <- other.RespondOffer(context.Background(), uuid.New(), offer.ID, true)
+			if err := other.RespondOffer(context.Background(), partner.ID, offer.ID, true); err != nil {
+				t.Error(err)
+			}
+		}()
+	})
+	matching := NewDispatcher(nil, repo, store, bus, webhook, log)
+	bus.instances = []*Dispatcher{matching, other}
+
+	offer := models.RideOffer{ID: uuid.New(), RideID: uuid.New(), ExpiresAt: time.Now().Add(5 * time.Second)}
+	accepted, err := matching.GetRideOffer(context.Background(), uuid.New(), offer)
+	if err != nil || !accepted {
+		t.Fatalf("got %v, %v; want accepted", accepted, err)
+	}
+	if err := <-wrongPartner; !errors.Is(err, types.ErrOfferNotFound) {
+		t.Fatalf("foreign partner response: got %v, want ErrOfferNotFound", err)
+	}
+	if len(store.offers) != 0 {
+		t.Fatal("settled offer was not deleted")
+	}
+	if err := other.RespondOffer(context.Background(), partner.ID, offer.ID, false); !errors.Is(err, types.ErrOfferNotFound) {
+		t.Fatalf("response after settle: got %v, want ErrOfferNotFound", err)
+	}
+}
+
+// Координаты, присланные партнёром на другой экземпляр, доходят до отслеживания поездки
+func TestTrack_DeliversToTrackingInstance(t *testing.T) {
+	repo := fleetRepo{partner: &models.Partner{ID: uuid.New()}}
+	store := newMemDispatch()
+	bus := &notifyBus{}
+	log := logger.InitLogger("test", "error")
+
+	tracking := NewDispatcher(nil, repo, store, bus, nil, log)
+	other := NewDispatcher(nil, repo, store, bus, nil, log)
+	bus.instances = []*Dispatcher{tracking, other}
+
+	driverID, rideID := uuid.New(), uuid.New()
+	received := make(chan models.RideLocationUpdate, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- tracking.ListenLocationUpdates(ctx, driverID, rideID, func(_ context.Context, update models.RideLocationUpdate) error {
+			received <- update
+			return types.ErrRideCancelled
+		})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tracked, err := other.Track(context.Background(), models.RideLocationUpdate{DriverID: driverID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tracked {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ride tracking never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case update := <-received:
+		if update.RideID == nil || *update.RideID != rideID {
+			t.Fatalf("update ride %v, want %s", update.RideID, rideID)
+		}
+	case <-time.After(5 * time.Second):
+		cancel()
+		t.Fatal("location was not delivered to the tracking instance")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	if tracked, _ := other.Track(context.Background(), models.RideLocationUpdate{DriverID: driverID}); tracked {
+		t.Fatal("finished ride is still tracked")
+	}
+}
@@ -0,0 +1,56 @@
+package partner
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	drivergo "github.com/Temutjin2k/ride-hail-system/internal/service/driver"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type (
+	PartnerRepo interface {
+		Create(ctx context.Context, p *models.Partner, apiKeyHash string) error
+		GetByAPIKey(ctx context.Context, apiKeyHash string) (*models.Partner, error)
+		GetByDriver(ctx context.Context, driverID uuid.UUID) (*models.Partner, error)
+		AttachDriver(ctx context.Context, partnerID, driverID uuid.UUID) error
+		OwnsDriver(ctx context.Context, partnerID, driverID uuid.UUID) (bool, error)
+	}
+
+	// DispatchRepo хранит офферы партнёрам и отслеживание поездок, общие для всех экземпляров
+	DispatchRepo interface {
+		CreateOffer(ctx context.Context, offer models.PartnerOffer) error
+		RespondOffer(ctx context.Context, partnerID, offerID uuid.UUID, accepted bool) (bool, error)
+		OfferResponse(ctx context.Context, offerID uuid.UUID) (*bool, error)
+		DeleteOffer(ctx context.Context, offerID uuid.UUID) error
+		StartTracking(ctx context.Context, driverID, rideID uuid.UUID, expiresAt time.Time) error
+		StopTracking(ctx context.Context, driverID, rideID uuid.UUID) error
+		AddTrackUpdate(ctx context.Context, update models.RideLocationUpdate) (bool, error)
+		TakeTrackUpdates(ctx context.Context, driverID, rideID uuid.UUID) ([]models.RideLocationUpdate, error)
+	}
+
+	// EventPublisher — шина pkg/invalidation, будит экземпляр, ожидающий ответа или координат
+	EventPublisher interface {
+		Publish(ctx context.Context, cache, key string) error
+	}
+
+	UserRepo interface {
+		GetUser(ctx context.Context, email string) (*models.User, error)
+		CreateUser(ctx context.Context, u *models.User) (uuid.UUID, error)
+	}
+
+	// DriverService - существующая логика водителей, партнёр действует от имени своих водителей
+	DriverService interface {
+		Register(ctx context.Context, newDriver *models.Driver) error
+		GoOnline(ctx context.Context, driverID uuid.UUID, location models.Location) (sessionID uuid.UUID, err error)
+		GoOffline(ctx context.Context, driverID uuid.UUID) (models.SessionSummary, error)
+		StartRide(ctx context.Context, startTime time.Time, driverID, rideID uuid.UUID, location models.Location) error
+		CompleteRide(ctx context.Context, rideID uuid.UUID, data drivergo.CompleteRideData) (earnings float64, err error)
+		UpdateLocation(ctx context.Context, data models.RideLocationUpdate) (coordinateID uuid.UUID, err error)
+	}
+
+	WebhookSender interface {
+		Send(ctx context.Context, url, secret string, event any) error
+	}
+)
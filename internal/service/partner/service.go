@@ -0,0 +1,250 @@
+package partner
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	drivergo "github.com/Temutjin2k/ride-hail-system/internal/service/driver"
+	"github.com/Temutjin2k/ride-hail-system/pkg/hasher"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Service — B2B канал предложения: таксопарки регистрируют водителей пачкой,
+// получают офферы на вебхук и сообщают статусы своих водителей.
+type Service struct {
+	repo       PartnerRepo
+	users      UserRepo
+	drivers    DriverService
+	dispatcher *Dispatcher
+	events     EventPublisher // сброс кэша профилей водителей driver-service
+	trm        trm.TxManager
+	l          logger.Logger
+}
+
+func New(repo PartnerRepo, users UserRepo, drivers DriverService, dispatcher *Dispatcher, events EventPublisher, trm trm.TxManager, l logger.Logger) *Service {
+	return &Service{
+		repo:       repo,
+		users:      users,
+		drivers:    drivers,
+		dispatcher: dispatcher,
+		events:     events,
+		trm:        trm,
+		l:          l,
+	}
+}
+
+// CreatePartner создаёт партнёра и выдаёт API ключ и секрет вебхука. Ключ хранится только в виде хеша.
+func (s *Service) CreatePartner(ctx context.Context, name, webhookURL string) (*models.PartnerCredentials, error) {
+	ctx = wrap.WithAction(ctx, "create_partner")
+
+	apiKey, err := randomToken("pk_")
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	secret, err := randomToken("whsec_")
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	partner := &models.Partner{
+		Name:          name,
+		WebhookURL:    webhookURL,
+		WebhookSecret: secret,
+	}
+	if err := s.repo.Create(ctx, partner, hashAPIKey(apiKey)); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "partner created", "partner_id", partner.ID, "name", partner.Name)
+	return &models.PartnerCredentials{
+		Partner:       partner,
+		APIKey:        apiKey,
+		WebhookSecret: secret,
+	}, nil
+}
+
+// Authenticate возвращает активного партнёра по API ключу
+func (s *Service) Authenticate(ctx context.Context, apiKey string) (*models.Partner, error) {
+	if apiKey == "" {
+		return nil, types.ErrInvalidAPIKey
+	}
+	return s.repo.GetByAPIKey(ctx, hashAPIKey(apiKey))
+}
+
+// RegisterDrivers регистрирует водителей партнёра. Каждый водитель в своей транзакции,
+// ошибка одного не отменяет остальных.
+func (s *Service) RegisterDrivers(ctx context.Context, partnerID uuid.UUID, drivers []models.PartnerDriver) []models.PartnerDriverResult {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{Action: "partner_register_drivers"})
+
+	results := make([]models.PartnerDriverResult, 0, len(drivers))
+	for i, d := range drivers {
+		res := models.PartnerDriverResult{Index: i}
+
+		driver, err := s.registerDriver(ctx, partnerID, d)
+		if err != nil {
+			s.l.Warn(ctx, "failed to register partner driver", "partner_id", partnerID, "index", i, "error", err.Error())
+			res.Error = err.Error()
+		} else {
+			res.DriverID = &driver.ID
+			res.Class = string(driver.Vehicle.Type)
+		}
+		results = append(results, res)
+	}
+
+	return results
+}
+
+func (s *Service) registerDriver(ctx context.Context, partnerID uuid.UUID, d models.PartnerDriver) (*models.Driver, error) {
+	driver := &models.Driver{
+		Name:          d.Name,
+		LicenseNumber: d.LicenseNumber,
+		Vehicle:       d.Vehicle,
+	}
+
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		existing, err := s.users.GetUser(ctx, d.Email)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return authSvc.ErrNotUniqueEmail
+		}
+
+		// водитель партнёра не входит в приложение, пароль случайный
+		password, err := randomToken("")
+		if err != nil {
+			return err
+		}
+
+		user := &models.User{
+			Email:        d.Email,
+			Role:         types.RoleDriver.String(),
+			Status:       types.StatusUserActive.String(),
+			PasswordHash: hasher.Hash(password),
+		}
+		if d.Phone != "" {
+			user.Attrs = map[string]any{"phone": d.Phone}
+		}
+
+		if driver.ID, err = s.users.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		if err := s.drivers.Register(ctx, driver); err != nil {
+			return err
+		}
+
+		return s.repo.AttachDriver(ctx, partnerID, driver.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// профиль мог попасть в кэш driver-service до закрепления за партнёром.
+	// Ошибка рассылки только логируется: водитель уже закреплен, запись вытеснит TTL кэша.
+	if s.events != nil {
+		if err := s.events.Publish(ctx, types.CacheDrivers, driver.ID.String()); err != nil {
+			s.l.Warn(ctx, "failed to publish cache invalidation", "cache", types.CacheDrivers, "driver_id", driver.ID, "error", err)
+		}
+	}
+
+	return driver, nil
+}
+
+// ReportStatus применяет статус водителя, который сообщил партнёр
+func (s *Service) ReportStatus(ctx context.Context, partnerID uuid.UUID, report models.PartnerStatusReport) error {
+	ctx = wrap.WithLogCtx(ctx, wrap.LogCtx{
+		Action:   "partner_report_status",
+		DriverID: report.DriverID.String(),
+	})
+
+	if err := s.checkFleet(ctx, partnerID, report.DriverID); err != nil {
+		return err
+	}
+
+	var err error
+	switch report.Status {
+	case types.PartnerDriverOnline:
+		_, err = s.drivers.GoOnline(ctx, report.DriverID, report.Location)
+	case types.PartnerDriverOffline:
+		_, err = s.drivers.GoOffline(ctx, report.DriverID)
+	case types.PartnerDriverInProgress:
+		err = s.drivers.StartRide(ctx, time.Now(), report.DriverID, report.RideID, report.Location)
+	case types.PartnerDriverCompleted:
+		_, err = s.drivers.CompleteRide(ctx, report.RideID, drivergo.CompleteRideData{
+			CompleteTime:      time.Now(),
+			DriverID:          report.DriverID,
+			ActualDurationMin: report.ActualDurationMin,
+			ActualDistanceKm:  report.ActualDistanceKm,
+			Location:          report.Location,
+		})
+	default:
+		return types.ErrInvalidRideStatus
+	}
+	if err != nil {
+		return err
+	}
+
+	s.l.Info(ctx, "partner driver status applied", "partner_id", partnerID, "status", report.Status)
+	return nil
+}
+
+// ReportLocation сохраняет координаты водителя партнёра. Во время поездки координаты
+// уходят в её отслеживание (прибытие к точке подачи), tracked=true.
+func (s *Service) ReportLocation(ctx context.Context, partnerID uuid.UUID, update models.RideLocationUpdate) (tracked bool, coordinateID uuid.UUID, err error) {
+	if err := s.checkFleet(ctx, partnerID, update.DriverID); err != nil {
+		return false, uuid.UUID{}, err
+	}
+
+	tracked, err = s.dispatcher.Track(ctx, update)
+	if err != nil || tracked {
+		return tracked, uuid.UUID{}, err
+	}
+
+	coordinateID, err = s.drivers.UpdateLocation(ctx, update)
+	return false, coordinateID, err
+}
+
+// RespondOffer принимает ответ партнёра на оффер, отправленный на вебхук
+func (s *Service) RespondOffer(ctx context.Context, partnerID, offerID uuid.UUID, accepted bool) error {
+	if err := s.dispatcher.RespondOffer(ctx, partnerID, offerID, accepted); err != nil {
+		return err
+	}
+
+	s.l.Info(wrap.WithAction(ctx, "partner_respond_offer"), "partner responded to ride offer", "partner_id", partnerID, "offer_id", offerID, "accepted", accepted)
+	return nil
+}
+
+func (s *Service) checkFleet(ctx context.Context, partnerID, driverID uuid.UUID) error {
+	owns, err := s.repo.OwnsDriver(ctx, partnerID, driverID)
+	if err != nil {
+		return err
+	}
+	if !owns {
+		return types.ErrDriverNotInFleet
+	}
+	return nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,125 @@
+package partner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memPartners хранит партнёров по хешу API ключа
+type memPartners struct {
+	PartnerRepo
+	byHash map[string]*models.Partner
+}
+
+func (r *memPartners) Create(_ context.Context, p *models.Partner, apiKeyHash string) error {
+	p.ID, p.IsActive = uuid.New(), true
+	r.byHash[apiKeyHash] = p
+	return nil
+}
+
+func (r *memPartners) GetByAPIKey(_ context.Context, apiKeyHash string) (*models.Partner, error) {
+	p, ok := r.byHash[apiKeyHash]
+	if !ok {
+		return nil, types.ErrInvalidAPIKey
+	}
+	return p, nil
+}
+
+// API ключ выдаётся один раз и хранится только хешем, по нему партнёр аутентифицируется
+func TestAuthenticate_APIKey(t *testing.T) {
+	repo := &memPartners{byHash: make(map[string]*models.Partner)}
+	s := New(repo, nil, nil, nil, nil, nil, logger.InitLogger("test", "error"))
+	ctx := context.Background()
+
+	creds, err := s.CreatePartner(ctx, "Fleet", "https://fleet.example/webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, stored := repo.byHash[creds.APIKey]; stored {
+		t.Fatal("API key stored in plaintext")
+	}
+
+	partner, err := s.Authenticate(ctx, creds.APIKey)
+	if err != nil || partner.ID != creds.Partner.ID {
+		t.Fatalf("got %v, %v; want partner %s", partner, err, creds.Partner.ID)
+	}
+
+	for _, key := range []string{"", "pk_unknown", creds.WebhookSecret} {
+		if _, err := s.Authenticate(ctx, key); !errors.Is(err, types.ErrInvalidAPIKey) {
+			t.Fatalf("key %q: got %v, want ErrInvalidAPIKey", key, err)
+		}
+	}
+}
+
+type inlineTxManager struct{}
+
+func (inlineTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type newUsers struct{ UserRepo }
+
+func (newUsers) GetUser(context.Context, string) (*models.User, error) { return nil, nil }
+
+func (newUsers) CreateUser(context.Context, *models.User) (uuid.UUID, error) { return uuid.New(), nil }
+
+type registeringDrivers struct{ DriverService }
+
+func (registeringDrivers) Register(context.Context, *models.Driver) error { return nil }
+
+type attachingPartners struct {
+	PartnerRepo
+	attached map[uuid.UUID]uuid.UUID
+}
+
+func (r *attachingPartners) AttachDriver(_ context.Context, partnerID, driverID uuid.UUID) error {
+	r.attached[driverID] = partnerID
+	return nil
+}
+
+// recordingBus запоминает рассылки сброса кэшей
+type recordingBus struct {
+	events []invalidation.Event
+}
+
+func (b *recordingBus) Publish(_ context.Context, cache, key string) error {
+	b.events = append(b.events, invalidation.Event{Cache: cache, Key: key})
+	return nil
+}
+
+// Закрепление водителя за партнёром сбрасывает его профиль в кэше driver-service
+func TestRegisterDrivers_InvalidatesDriverProfile(t *testing.T) {
+	repo := &attachingPartners{attached: make(map[uuid.UUID]uuid.UUID)}
+	bus := &recordingBus{}
+	s := New(repo, newUsers{}, registeringDrivers{}, nil, bus, inlineTxManager{}, logger.InitLogger("test", "error"))
+	partnerID := uuid.New()
+
+	results := s.RegisterDrivers(context.Background(), partnerID, []models.PartnerDriver{{Name: "Driver", Email: "d@fleet.example"}})
+	if len(results) != 1 || results[0].DriverID == nil {
+		t.Fatalf("got %+v, want one registered driver", results)
+	}
+
+	driverID := *results[0].DriverID
+	if repo.attached[driverID] != partnerID {
+		t.Fatalf("driver %s is not attached to partner %s", driverID, partnerID)
+	}
+	want := invalidation.Event{Cache: types.CacheDrivers, Key: driverID.String()}
+	if len(bus.events) != 1 || bus.events[0] != want {
+		t.Fatalf("published %v, want %v", bus.events, want)
+	}
+}
@@ -0,0 +1,39 @@
+package payout
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type (
+	Repo interface {
+		Balance(ctx context.Context, driverID uuid.UUID) (*models.DriverBalance, error)
+		Entries(ctx context.Context, driverID uuid.UUID, limit int) ([]models.DriverLedgerEntry, error)
+		// блокирует баланс водителя до конца транзакции
+		LockBalance(ctx context.Context, driverID uuid.UUID) (float64, error)
+		Credit(ctx context.Context, driverID uuid.UUID, rideID, payoutID *uuid.UUID, entryType types.DriverLedgerEntryType, amount float64) error
+		Debit(ctx context.Context, driverID, payoutID uuid.UUID, amount float64) (bool, error)
+
+		Eligibility(ctx context.Context, driverID uuid.UUID) (*models.PayoutEligibility, error)
+		Usage(ctx context.Context, driverID uuid.UUID, since time.Time) (models.PayoutUsage, error)
+
+		Create(ctx context.Context, p *models.Payout) error
+		Get(ctx context.Context, payoutID uuid.UUID) (*models.Payout, error)
+		List(ctx context.Context, driverID uuid.UUID, limit int) ([]models.Payout, error)
+		Stale(ctx context.Context, status types.PayoutStatus, before time.Time, limit int) ([]models.Payout, error)
+		Transition(ctx context.Context, payoutID uuid.UUID, status types.PayoutStatus, providerPayoutID, failureReason *string) (*models.Payout, error)
+		SaveWebhookEvent(ctx context.Context, eventID string, payoutID uuid.UUID, status types.PayoutStatus, payload []byte) (bool, error)
+	}
+
+	// Provider переводит деньги водителю. reference — ID вывода, ключ идемпотентности:
+	// повторный Payout с тем же reference возвращает уже созданный перевод.
+	// Отказ провайдера возвращается статусом FAILED, ошибка — перевод мог и не дойти до провайдера.
+	Provider interface {
+		Payout(ctx context.Context, reference, driverID uuid.UUID, amount float64) (*models.ProviderPayout, error)
+		Status(ctx context.Context, providerPayoutID string) (*models.ProviderPayout, error)
+	}
+)
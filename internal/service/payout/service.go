@@ -0,0 +1,360 @@
+// Package payout — мгновенный вывод заработка водителя через провайдера выплат.
+// Заработок за поездки и штрафы за отмену зачисляются на баланс водителя, вывод списывает
+// сумму с баланса сразу, а неудавшийся перевод возвращает ее записью PAYOUT_REVERSAL.
+package payout
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	// historyLimit — сколько последних записей журнала и выводов возвращается водителю
+	historyLimit = 20
+	// reconcileBatch — сколько зависших выводов сверяется за один проход
+	reconcileBatch = 100
+)
+
+// Options — комиссия, лимиты и антифрод-проверки вывода
+type Options struct {
+	FeeFixed   float64 // фиксированная часть комиссии
+	FeePercent float64 // комиссия от суммы вывода, %
+
+	MinAmount  float64 // минимальная сумма одного вывода
+	MaxAmount  float64 // максимальная сумма одного вывода
+	DailyLimit float64 // сумма выводов за последние 24 часа
+	DailyCount int     // число выводов за последние 24 часа
+
+	MinAccountAge     time.Duration // сколько водитель должен быть зарегистрирован
+	MinCompletedRides int           // сколько поездок водитель должен завершить
+
+	// WebhookSecret — секрет подписи вебхуков провайдера, пусто — вебхуки отклоняются
+	WebhookSecret string
+	// ReconcileAfter — через сколько без изменений вывод сверяется со статусом у провайдера
+	ReconcileAfter time.Duration
+}
+
+type Service struct {
+	repo     Repo
+	provider Provider // nil — провайдер не подключен, вывод недоступен
+	trm      trm.TxManager
+	opts     Options
+	l        logger.Logger
+}
+
+func New(repo Repo, provider Provider, trm trm.TxManager, opts Options, l logger.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		provider: provider,
+		trm:      trm,
+		opts:     opts,
+		l:        l,
+	}
+}
+
+// Balance возвращает баланс водителя с последними записями журнала
+func (s *Service) Balance(ctx context.Context, driverID uuid.UUID) (*models.DriverBalance, error) {
+	ctx = wrap.WithAction(wrap.WithDriverID(ctx, driverID.String()), "get_driver_balance")
+
+	balance, err := s.repo.Balance(ctx, driverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	balance.Entries, err = s.repo.Entries(ctx, driverID, historyLimit)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	return balance, nil
+}
+
+// Payouts возвращает последние выводы водителя
+func (s *Service) Payouts(ctx context.Context, driverID uuid.UUID) ([]models.Payout, error) {
+	return s.repo.List(ctx, driverID, historyLimit)
+}
+
+// Fee — комиссия за вывод amount, округленная до копеек
+func (s *Service) Fee(amount float64) float64 {
+	return math.Round((s.opts.FeeFixed+amount*s.opts.FeePercent/100)*100) / 100
+}
+
+// RequestPayout списывает amount с баланса водителя и отправляет перевод провайдеру.
+// amount nil — выводится весь доступный баланс. Комиссия удерживается из суммы вывода.
+func (s *Service) RequestPayout(ctx context.Context, driverID uuid.UUID, amount *float64) (*models.Payout, error) {
+	ctx = wrap.WithAction(wrap.WithDriverID(ctx, driverID.String()), "request_payout")
+
+	if s.provider == nil {
+		return nil, wrap.Error(ctx, types.ErrPayoutUnavailable)
+	}
+
+	if err := s.checkEligibility(ctx, driverID); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	payout := &models.Payout{
+		ID:       uuid.New(),
+		DriverID: driverID,
+		Status:   types.PayoutPending,
+	}
+
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		// блокировка баланса выстраивает одновременные запросы водителя в очередь
+		available, err := s.repo.LockBalance(ctx, driverID)
+		if err != nil {
+			return err
+		}
+
+		payout.Amount = available
+		if amount != nil {
+			payout.Amount = math.Round(*amount*100) / 100
+		}
+		if payout.Amount > available {
+			return types.ErrInsufficientBalance
+		}
+		payout.Fee = s.Fee(payout.Amount)
+		payout.NetAmount = math.Round((payout.Amount-payout.Fee)*100) / 100
+		if payout.Amount < s.opts.MinAmount || payout.Amount > s.opts.MaxAmount || payout.NetAmount <= 0 {
+			return fmt.Errorf("%w: from %.2f to %.2f", types.ErrInvalidPayoutAmount, s.opts.MinAmount, s.opts.MaxAmount)
+		}
+
+		usage, err := s.repo.Usage(ctx, driverID, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+		if usage.InFlight {
+			return types.ErrPayoutInProgress
+		}
+		if usage.Count >= s.opts.DailyCount || usage.Amount+payout.Amount > s.opts.DailyLimit {
+			return types.ErrPayoutLimitExceeded
+		}
+
+		if err := s.repo.Create(ctx, payout); err != nil {
+			return err
+		}
+		debited, err := s.repo.Debit(ctx, driverID, payout.ID, payout.Amount)
+		if err != nil {
+			return err
+		}
+		if !debited {
+			return types.ErrInsufficientBalance
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "instant payout requested", "payout_id", payout.ID, "amount", payout.Amount, "fee", payout.Fee)
+
+	// перевод — внешний вызов, поэтому после транзакции. Списание закоммичено вместе с выводом PENDING:
+	// если провайдер не ответил или экземпляр упал до ответа, вывод отправляется повторно при сверке,
+	// ключ идемпотентности не даст перевести дважды.
+	if updated := s.send(ctx, payout); updated != nil {
+		payout = updated
+	}
+	return payout, nil
+}
+
+// checkEligibility — антифрод-проверки: вывод доступен проверенным водителям с историей поездок
+func (s *Service) checkEligibility(ctx context.Context, driverID uuid.UUID) error {
+	e, err := s.repo.Eligibility(ctx, driverID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !e.Verified:
+		return fmt.Errorf("%w: driver is not verified", types.ErrPayoutNotAllowed)
+	case time.Since(e.RegisteredAt) < s.opts.MinAccountAge:
+		return fmt.Errorf("%w: account is younger than %s", types.ErrPayoutNotAllowed, s.opts.MinAccountAge)
+	case e.CompletedRides < s.opts.MinCompletedRides:
+		return fmt.Errorf("%w: at least %d completed rides required", types.ErrPayoutNotAllowed, s.opts.MinCompletedRides)
+	}
+	return nil
+}
+
+// send отправляет перевод провайдеру и записывает ответ. nil — ответа нет, вывод не изменился.
+func (s *Service) send(ctx context.Context, payout *models.Payout) *models.Payout {
+	res, err := s.provider.Payout(ctx, payout.ID, payout.DriverID, payout.NetAmount)
+	if err != nil {
+		s.l.Error(ctx, "failed to send payout to provider", err, "payout_id", payout.ID)
+		return nil
+	}
+
+	updated, err := s.apply(ctx, payout.ID, res)
+	if err != nil {
+		s.l.Error(ctx, "failed to record provider payout", err, "payout_id", payout.ID, "provider_payout_id", res.ID)
+		return nil
+	}
+	return updated
+}
+
+// apply переводит вывод в статус провайдера, неудавшийся вывод возвращается на баланс.
+// nil — вывод уже завершен.
+func (s *Service) apply(ctx context.Context, payoutID uuid.UUID, res *models.ProviderPayout) (*models.Payout, error) {
+	status := res.Status
+	if status == types.PayoutPending {
+		status = types.PayoutProcessing
+	}
+
+	var providerPayoutID, failureReason *string
+	if res.ID != "" {
+		providerPayoutID = &res.ID
+	}
+	if status == types.PayoutFailed {
+		reason := res.FailureReason
+		failureReason = &reason
+	}
+
+	var payout *models.Payout
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		var err error
+		payout, err = s.repo.Transition(ctx, payoutID, status, providerPayoutID, failureReason)
+		if err != nil || payout == nil || status != types.PayoutFailed {
+			return err
+		}
+		return s.repo.Credit(ctx, payout.DriverID, nil, &payout.ID, types.DriverLedgerPayoutReversal, payout.Amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if payout != nil && status.Final() {
+		s.l.Info(ctx, "instant payout settled", "payout_id", payout.ID, "status", status, "failure_reason", res.FailureReason)
+	}
+	return payout, nil
+}
+
+// HandleWebhook применяет событие провайдера о статусе перевода.
+// signature — hex HMAC-SHA256 тела секретом вебхука, повторно доставленное событие пропускается.
+func (s *Service) HandleWebhook(ctx context.Context, body []byte, signature string) error {
+	ctx = wrap.WithAction(ctx, "payout_webhook")
+
+	if !s.verify(body, signature) {
+		return wrap.Error(ctx, types.ErrInvalidPayoutSignature)
+	}
+
+	var event models.ProviderPayout
+	if err := json.Unmarshal(body, &event); err != nil {
+		return wrap.Error(ctx, fmt.Errorf("%w: %v", types.ErrInvalidPayoutEvent, err))
+	}
+	switch {
+	case event.EventID == "" || event.ID == "":
+		return wrap.Error(ctx, fmt.Errorf("%w: event_id and payout_id are required", types.ErrInvalidPayoutEvent))
+	case event.Status != types.PayoutProcessing && event.Status != types.PayoutPaid && event.Status != types.PayoutFailed:
+		return wrap.Error(ctx, fmt.Errorf("%w: unknown status %q", types.ErrInvalidPayoutEvent, event.Status))
+	}
+
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		payout, err := s.repo.Get(ctx, event.Reference)
+		if err != nil {
+			return err
+		}
+		// перевод, созданный не по этому выводу, считаем неизвестным
+		if payout.ProviderPayoutID != nil && *payout.ProviderPayoutID != event.ID {
+			return types.ErrPayoutNotFound
+		}
+
+		fresh, err := s.repo.SaveWebhookEvent(ctx, event.EventID, payout.ID, event.Status, body)
+		if err != nil || !fresh {
+			return err
+		}
+
+		_, err = s.apply(ctx, payout.ID, &event)
+		return err
+	})
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *Service) verify(body []byte, signature string) bool {
+	if s.opts.WebhookSecret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.opts.WebhookSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// RunReconcileJob периодически сверяет выводы, по которым не пришел ответ или вебхук провайдера
+func (s *Service) RunReconcileJob(ctx context.Context, interval time.Duration) {
+	if s.provider == nil || interval <= 0 {
+		s.l.Warn(ctx, "payout reconcile job disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reconcile(ctx); err != nil {
+			s.l.Error(ctx, "failed to reconcile payouts", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile повторно отправляет выводы PENDING и запрашивает статус выводов PROCESSING,
+// не менявшихся дольше ReconcileAfter. Вывод без ID перевода у провайдера (ответ пришел без него
+// или не был записан) тоже отправляется повторно: ключ идемпотентности вернет уже созданный перевод.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx = wrap.WithAction(ctx, "reconcile_payouts")
+	before := time.Now().Add(-s.opts.ReconcileAfter)
+
+	pending, err := s.repo.Stale(ctx, types.PayoutPending, before, reconcileBatch)
+	if err != nil {
+		return err
+	}
+	for i := range pending {
+		s.send(ctx, &pending[i])
+	}
+
+	processing, err := s.repo.Stale(ctx, types.PayoutProcessing, before, reconcileBatch)
+	if err != nil {
+		return err
+	}
+	for i, p := range processing {
+		if p.ProviderPayoutID == nil {
+			s.send(ctx, &processing[i])
+			continue
+		}
+		res, err := s.provider.Status(ctx, *p.ProviderPayoutID)
+		if err != nil {
+			s.l.Error(ctx, "failed to get payout status from provider", err, "payout_id", p.ID)
+			continue
+		}
+		if _, err := s.apply(ctx, p.ID, res); err != nil {
+			s.l.Error(ctx, "failed to record payout status", err, "payout_id", p.ID)
+		}
+	}
+
+	if len(pending)+len(processing) > 0 {
+		s.l.Info(ctx, "payouts reconciled", "pending", len(pending), "processing", len(processing))
+	}
+	return nil
+}
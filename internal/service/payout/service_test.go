@@ -0,0 +1,143 @@
+package payout
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+func TestServiceFee(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		amount float64
+		want   float64
+	}{
+		{"no fee", Options{}, 1000, 0},
+		{"fixed", Options{FeeFixed: 50}, 1000, 50},
+		{"percent", Options{FeePercent: 1.5}, 1000, 15},
+		{"fixed and percent", Options{FeeFixed: 50, FeePercent: 1}, 1234.56, 62.35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{opts: tt.opts}
+			if got := s.Fee(tt.amount); got != tt.want {
+				t.Fatalf("Fee(%v) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVerify(t *testing.T) {
+	body := []byte(`{"event_id":"evt-1","payout_id":"po-1","status":"PAID"}`)
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid", "whsec", body, signature, true},
+		{"no secret configured", "", body, signature, false},
+		{"wrong secret", "other", body, signature, false},
+		{"tampered body", "whsec", []byte(`{"event_id":"evt-1","payout_id":"po-1","status":"FAILED"}`), signature, false},
+		{"not hex", "whsec", body, "zz", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{opts: Options{WebhookSecret: tt.secret}}
+			if got := s.verify(tt.body, tt.signature); got != tt.want {
+				t.Fatalf("verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stalePayouts хранит выводы в памяти и повторяет Stale и Transition
+type stalePayouts struct {
+	Repo
+	payouts map[uuid.UUID]*models.Payout
+}
+
+func (r *stalePayouts) Stale(_ context.Context, status types.PayoutStatus, _ time.Time, _ int) ([]models.Payout, error) {
+	var res []models.Payout
+	for _, p := range r.payouts {
+		if p.Status == status {
+			res = append(res, *p)
+		}
+	}
+	return res, nil
+}
+
+func (r *stalePayouts) Transition(_ context.Context, payoutID uuid.UUID, status types.PayoutStatus, providerPayoutID, _ *string) (*models.Payout, error) {
+	p := r.payouts[payoutID]
+	if p.Status.Final() {
+		return nil, nil
+	}
+	p.Status = status
+	if providerPayoutID != nil {
+		p.ProviderPayoutID = providerPayoutID
+	}
+	return p, nil
+}
+
+// idempotentProvider возвращает один перевод на reference и не знает переводов без ID
+type idempotentProvider struct {
+	sent map[uuid.UUID]int
+}
+
+func (p *idempotentProvider) Payout(_ context.Context, reference, _ uuid.UUID, _ float64) (*models.ProviderPayout, error) {
+	p.sent[reference]++
+	return &models.ProviderPayout{ID: "po-" + reference.String(), Reference: reference, Status: types.PayoutPaid}, nil
+}
+
+func (p *idempotentProvider) Status(context.Context, string) (*models.ProviderPayout, error) {
+	panic("status requested without a provider payout id")
+}
+
+type inlineTxManager struct{}
+
+func (inlineTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Вывод PROCESSING без ID перевода у провайдера не блокирует следующие выводы водителя:
+// сверка находит перевод по ключу идемпотентности
+func TestReconcile_ProcessingWithoutProviderID(t *testing.T) {
+	payout := &models.Payout{ID: uuid.New(), DriverID: uuid.New(), Amount: 1000, NetAmount: 990, Status: types.PayoutProcessing}
+	repo := &stalePayouts{payouts: map[uuid.UUID]*models.Payout{payout.ID: payout}}
+	provider := &idempotentProvider{sent: map[uuid.UUID]int{}}
+	s := New(repo, provider, inlineTxManager{}, Options{}, logger.InitLogger("test", "error"))
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if provider.sent[payout.ID] != 1 {
+		t.Fatalf("payout sent %d times, want 1", provider.sent[payout.ID])
+	}
+	if payout.Status != types.PayoutPaid || payout.ProviderPayoutID == nil {
+		t.Fatalf("payout left %s with provider id %v", payout.Status, payout.ProviderPayoutID)
+	}
+}
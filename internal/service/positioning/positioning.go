@@ -0,0 +1,277 @@
+// Package positioning рекомендует свободным водителям районы, где спрос превышает предложение,
+// и оценивает, помогли ли рекомендации: доехал ли водитель и получил ли поездку.
+package positioning
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/geohash"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// cellPrecision — размер района рекомендации, примерно 1.2км x 0.6км
+const cellPrecision = 6
+
+type (
+	Repo interface {
+		RecentPickups(ctx context.Context, since time.Time) ([]models.Location, error)
+		IdleDrivers(ctx context.Context) ([]models.IdleDriver, error)
+		Save(ctx context.Context, rec *models.PositioningRecommendation) error
+		Evaluate(ctx context.Context, horizon time.Duration, radiusM float64) (int, error)
+	}
+
+	// Sender отправляет рекомендацию водителю по WebSocket
+	Sender interface {
+		SendPositioningTip(ctx context.Context, driverID uuid.UUID, tip models.PositioningTip) error
+		ConnectedDrivers() []uuid.UUID
+	}
+
+	GeoCoder interface {
+		GetAddress(ctx context.Context, longitude, latitude float64) (string, error)
+	}
+
+	Calculator interface {
+		Distance(p1, p2 models.Location) float64
+	}
+)
+
+// Options — параметры прогноза и рекомендаций
+type Options struct {
+	DemandWindow   time.Duration // окно запросов поездок для прогноза спроса
+	MinRequests    int           // минимум запросов в районе, чтобы его рекомендовать
+	MaxDistanceKm  float64       // максимальное расстояние до рекомендуемого района
+	MaxWait        time.Duration // рекомендуются только районы с ожиданием не дольше
+	EvaluateAfter  time.Duration // через сколько оценивать эффективность рекомендации
+	ArrivalRadiusM float64       // радиус, в котором водитель считается доехавшим
+}
+
+type Service struct {
+	repo      Repo
+	sender    Sender
+	geocoder  GeoCoder
+	calculate Calculator
+	opts      Options
+
+	l logger.Logger
+}
+
+func New(repo Repo, sender Sender, geocoder GeoCoder, calculate Calculator, opts Options, l logger.Logger) *Service {
+	return &Service{
+		repo:      repo,
+		sender:    sender,
+		geocoder:  geocoder,
+		calculate: calculate,
+		opts:      opts,
+		l:         l,
+	}
+}
+
+// RunJob периодически отправляет рекомендации и оценивает отправленные до отмены контекста
+func (s *Service) RunJob(ctx context.Context, interval time.Duration) {
+	ctx = wrap.WithAction(ctx, "positioning_job")
+	if interval <= 0 {
+		s.l.Warn(ctx, "positioning job disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sent, err := s.Recommend(ctx)
+		if err != nil {
+			s.l.Error(wrap.ErrorCtx(ctx, err), "failed to send positioning recommendations", err)
+		} else if sent > 0 {
+			s.l.Info(ctx, "positioning recommendations sent", "count", sent)
+		}
+
+		evaluated, err := s.repo.Evaluate(ctx, s.opts.EvaluateAfter, s.opts.ArrivalRadiusM)
+		if err != nil {
+			s.l.Error(wrap.ErrorCtx(ctx, err), "failed to evaluate positioning recommendations", err)
+		} else if evaluated > 0 {
+			s.l.Debug(ctx, "positioning recommendations evaluated", "count", evaluated)
+		}
+	}
+}
+
+// Recommend строит прогноз спроса по районам и отправляет подключенным свободным водителям
+// рекомендацию переместиться туда, где ожидание поездки меньше, чем на текущем месте.
+// Возвращает количество отправленных рекомендаций.
+func (s *Service) Recommend(ctx context.Context) (int, error) {
+	connected := make(map[uuid.UUID]struct{})
+	for _, id := range s.sender.ConnectedDrivers() {
+		connected[id] = struct{}{}
+	}
+	if len(connected) == 0 {
+		return 0, nil
+	}
+
+	pickups, err := s.repo.RecentPickups(ctx, time.Now().Add(-s.opts.DemandWindow))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recent pickups: %w", err)
+	}
+	drivers, err := s.repo.IdleDrivers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get idle drivers: %w", err)
+	}
+
+	cells := forecast(pickups, drivers)
+	labels := make(map[string]string)
+
+	sent := 0
+	for _, driver := range drivers {
+		if _, ok := connected[driver.ID]; !ok || !driver.TipsEnabled {
+			continue
+		}
+
+		target, wait, ok := s.bestCell(driver, cells)
+		if !ok {
+			continue
+		}
+
+		label, ok := labels[target.Cell]
+		if !ok {
+			label = s.label(ctx, target)
+			labels[target.Cell] = label
+		}
+
+		if err := s.send(ctx, driver, target, wait, label); err != nil {
+			s.l.Debug(wrap.WithDriverID(ctx, driver.ID.String()), "failed to send positioning tip", "error", err.Error())
+			continue
+		}
+
+		// водитель поедет в район, следующим водителям ожидание там считается с его учетом
+		cells[geohash.Encode(driver.Location.Latitude, driver.Location.Longitude, cellPrecision)].dec()
+		target.Drivers++
+		sent++
+	}
+
+	return sent, nil
+}
+
+// forecast группирует запросы и свободных водителей по районам.
+// Центр района — средняя точка посадки.
+func forecast(pickups []models.Location, drivers []models.IdleDriver) map[string]*demandCell {
+	cells := make(map[string]*demandCell)
+	for _, p := range pickups {
+		cell := geohash.Encode(p.Latitude, p.Longitude, cellPrecision)
+		c, ok := cells[cell]
+		if !ok {
+			c = &demandCell{Cell: cell}
+			cells[cell] = c
+		}
+		c.Requests++
+		c.Center.Latitude += (p.Latitude - c.Center.Latitude) / float64(c.Requests)
+		c.Center.Longitude += (p.Longitude - c.Center.Longitude) / float64(c.Requests)
+	}
+
+	for _, d := range drivers {
+		if c, ok := cells[geohash.Encode(d.Location.Latitude, d.Location.Longitude, cellPrecision)]; ok {
+			c.Drivers++
+		}
+	}
+
+	return cells
+}
+
+// demandCell — прогноз спроса и предложения в районе
+type demandCell struct {
+	Cell     string
+	Center   models.Location
+	Requests int // запросов поездок за окно прогноза
+	Drivers  int // свободных водителей в районе сейчас
+}
+
+func (c *demandCell) dec() {
+	if c != nil && c.Drivers > 0 {
+		c.Drivers--
+	}
+}
+
+// waitMinutes — ожидаемое время до поездки для водителя, если в районе уже others свободных водителей:
+// водители разбирают запросы по очереди с интенсивностью Requests / window.
+func (c *demandCell) waitMinutes(others int, window time.Duration) float64 {
+	if c.Requests == 0 {
+		return math.Inf(1)
+	}
+	perMinute := float64(c.Requests) / window.Minutes()
+	return float64(others+1) / perMinute
+}
+
+// bestCell выбирает район с минимальным ожиданием в пределах MaxDistanceKm,
+// если оно короче ожидания на текущем месте и не превышает MaxWait
+func (s *Service) bestCell(driver models.IdleDriver, cells map[string]*demandCell) (*demandCell, int, bool) {
+	own := geohash.Encode(driver.Location.Latitude, driver.Location.Longitude, cellPrecision)
+
+	current := math.Inf(1)
+	if c, ok := cells[own]; ok {
+		current = c.waitMinutes(c.Drivers-1, s.opts.DemandWindow)
+	}
+
+	var (
+		best     *demandCell
+		bestWait = math.Min(current, s.opts.MaxWait.Minutes())
+	)
+	for cell, c := range cells {
+		if cell == own || c.Requests < s.opts.MinRequests {
+			continue
+		}
+		if s.calculate.Distance(driver.Location, c.Center) > s.opts.MaxDistanceKm {
+			continue
+		}
+		if wait := c.waitMinutes(c.Drivers, s.opts.DemandWindow); wait < bestWait {
+			best, bestWait = c, wait
+		}
+	}
+
+	if best == nil {
+		return nil, 0, false
+	}
+	return best, int(math.Ceil(bestWait)), true
+}
+
+// label возвращает адрес центра района, при ошибке геокодера — код ячейки
+func (s *Service) label(ctx context.Context, c *demandCell) string {
+	if s.geocoder != nil {
+		address, err := s.geocoder.GetAddress(ctx, c.Center.Longitude, c.Center.Latitude)
+		if err == nil && address != "" {
+			return address
+		}
+	}
+	return "area " + c.Cell
+}
+
+func (s *Service) send(ctx context.Context, driver models.IdleDriver, target *demandCell, wait int, label string) error {
+	rec := &models.PositioningRecommendation{
+		DriverID:            driver.ID,
+		Cell:                target.Cell,
+		Target:              target.Center,
+		Origin:              driver.Location,
+		ExpectedWaitMinutes: wait,
+	}
+	if err := s.repo.Save(ctx, rec); err != nil {
+		return err
+	}
+
+	return s.sender.SendPositioningTip(ctx, driver.ID, models.PositioningTip{
+		RecommendationID:    rec.ID,
+		Area:                label,
+		Latitude:            target.Center.Latitude,
+		Longitude:           target.Center.Longitude,
+		DistanceKm:          math.Round(s.calculate.Distance(driver.Location, target.Center)*100) / 100,
+		ExpectedWaitMinutes: wait,
+		Message:             fmt.Sprintf("Head to %s, expected wait %d min", label, wait),
+	})
+}
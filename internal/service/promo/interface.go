@@ -0,0 +1,21 @@
+package promo
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type Repo interface {
+	Create(ctx context.Context, p *models.PromoCode) error
+	List(ctx context.Context) ([]models.PromoCode, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.PromoCode, error)
+	Update(ctx context.Context, id uuid.UUID, u models.PromoCodeUpdate) (*models.PromoCode, error)
+
+	// блокирует строку промокода до конца транзакции
+	GetByCodeForUpdate(ctx context.Context, code string) (*models.PromoCode, error)
+	PassengerUses(ctx context.Context, promoID, passengerID uuid.UUID) (int, error)
+	Redeem(ctx context.Context, d models.PromoDiscount, rideID uuid.UUID) error
+	Release(ctx context.Context, rideID uuid.UUID) (bool, error)
+}
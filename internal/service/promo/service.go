@@ -0,0 +1,160 @@
+// Package promo управляет промокодами: администратор создает и меняет коды,
+// ride-service применяет их к стоимости поездки при создании.
+package promo
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type Service struct {
+	repo Repo
+	l    logger.Logger
+}
+
+func New(repo Repo, l logger.Logger) *Service {
+	return &Service{
+		repo: repo,
+		l:    l,
+	}
+}
+
+// Create создает промокод. Код хранится в верхнем регистре.
+func (s *Service) Create(ctx context.Context, p *models.PromoCode) error {
+	ctx = wrap.WithAction(ctx, "create_promo_code")
+
+	p.Code = strings.ToUpper(strings.TrimSpace(p.Code))
+	if p.PerPassengerLimit == 0 {
+		p.PerPassengerLimit = 1
+	}
+	p.Active = true
+
+	if err := s.repo.Create(ctx, p); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "promo code created", "promo_id", p.ID, "code", p.Code, "discount_type", p.DiscountType, "value", p.Value)
+	return nil
+}
+
+func (s *Service) List(ctx context.Context) ([]models.PromoCode, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Update меняет условия промокода. Уже примененные скидки не пересчитываются.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, u models.PromoCodeUpdate) (*models.PromoCode, error) {
+	ctx = wrap.WithAction(ctx, "update_promo_code")
+
+	if u.Value != nil || u.MaxDiscount != nil {
+		current, err := s.repo.Get(ctx, id)
+		if err != nil {
+			return nil, wrap.Error(ctx, err)
+		}
+		if err := checkDiscount(current.DiscountType, u); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := s.repo.Update(ctx, id, u)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.l.Info(ctx, "promo code updated", "promo_id", p.ID, "code", p.Code, "active", p.Active)
+	return p, nil
+}
+
+// Deactivate выключает промокод. Код не удаляется, чтобы сохранить историю применений.
+func (s *Service) Deactivate(ctx context.Context, id uuid.UUID) (*models.PromoCode, error) {
+	active := false
+	return s.Update(ctx, id, models.PromoCodeUpdate{Active: &active})
+}
+
+// Apply проверяет промокод и рассчитывает скидку к стоимости поездки.
+// Вызывается в транзакции создания поездки: строка промокода блокируется до Redeem.
+func (s *Service) Apply(ctx context.Context, code string, passengerID uuid.UUID, fare float64) (*models.PromoDiscount, error) {
+	p, err := s.repo.GetByCodeForUpdate(ctx, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.Active {
+		return nil, types.ErrPromoInactive
+	}
+	if p.ExpiresAt != nil && !time.Now().Before(*p.ExpiresAt) {
+		return nil, types.ErrPromoExpired
+	}
+	if p.MaxUses != nil && p.UsedCount >= *p.MaxUses {
+		return nil, types.ErrPromoExhausted
+	}
+
+	uses, err := s.repo.PassengerUses(ctx, p.ID, passengerID)
+	if err != nil {
+		return nil, err
+	}
+	if uses >= p.PerPassengerLimit {
+		return nil, types.ErrPromoExhausted
+	}
+
+	return &models.PromoDiscount{
+		PromoID:     p.ID,
+		Code:        p.Code,
+		PassengerID: passengerID,
+		Discount:    discount(p, fare),
+	}, nil
+}
+
+// Redeem засчитывает применение промокода созданной поездке
+func (s *Service) Redeem(ctx context.Context, d *models.PromoDiscount, rideID uuid.UUID) error {
+	return s.repo.Redeem(ctx, *d, rideID)
+}
+
+// Release возвращает применение промокода при отмене поездки
+func (s *Service) Release(ctx context.Context, rideID uuid.UUID) error {
+	released, err := s.repo.Release(ctx, rideID)
+	if err != nil {
+		return err
+	}
+	if released {
+		s.l.Debug(ctx, "promo code use released", "ride_id", rideID)
+	}
+	return nil
+}
+
+// checkDiscount проверяет новые значения скидки для типа промокода, который не меняется
+func checkDiscount(discountType types.DiscountType, u models.PromoCodeUpdate) error {
+	if discountType == types.DiscountPercent && u.Value != nil && *u.Value > 100 {
+		return types.ErrInvalidPromoDiscount
+	}
+	if discountType == types.DiscountFlat && u.MaxDiscount != nil {
+		return types.ErrInvalidPromoDiscount
+	}
+	return nil
+}
+
+// discount считает скидку, она не больше стоимости поездки
+func discount(p *models.PromoCode, fare float64) float64 {
+	var d float64
+	switch p.DiscountType {
+	case types.DiscountPercent:
+		d = fare * p.Value / 100
+		if p.MaxDiscount != nil {
+			d = min(d, *p.MaxDiscount)
+		}
+	case types.DiscountFlat:
+		d = p.Value
+	}
+	return math.Round(min(d, fare)*100) / 100
+}
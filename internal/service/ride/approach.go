@@ -0,0 +1,110 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// ApproachOptions — пороги уведомления пассажира о скором прибытии водителя к точке посадки
+type ApproachOptions struct {
+	// DistanceKm — расстояние до точки посадки, 0 — порог не используется
+	DistanceKm float64
+	// ETA — расчетное время до точки посадки, 0 — порог не используется
+	ETA time.Duration
+	// HysteresisKm — насколько водитель должен отъехать за порог, чтобы уведомление повторилось.
+	// Без запаса GPS-шум на границе порога давал бы уведомление на каждое обновление координат.
+	HysteresisKm float64
+}
+
+func (o ApproachOptions) enabled() bool {
+	return o.DistanceKm > 0 || o.ETA > 0
+}
+
+// near — водитель внутри зоны уведомления хотя бы по одному из порогов
+func (o ApproachOptions) near(distanceKm float64, durationMin int) bool {
+	if o.DistanceKm > 0 && distanceKm <= o.DistanceKm {
+		return true
+	}
+	return o.ETA > 0 && time.Duration(durationMin)*time.Minute <= o.ETA
+}
+
+// checkApproach уведомляет пассажира, когда едущий к точке посадки водитель пересекает порог расстояния или ETA.
+// Отметка об уведомлении снимается, только когда водитель отъезжает дальше порога на HysteresisKm.
+// Ошибки не прерывают обработку координат.
+func (s *RideService) checkApproach(ctx context.Context, ride *models.Ride, driverLocation models.Location, distanceKm float64) {
+	if !s.approach.enabled() {
+		return
+	}
+	if ride.Status != types.StatusMatched.String() && ride.Status != types.StatusEnRoute.String() {
+		return
+	}
+
+	durationMin := s.calculate.Duration(distanceKm)
+
+	if ride.ApproachNotifiedAt != nil {
+		farKm := max(distanceKm-s.approach.HysteresisKm, 0)
+		if s.approach.near(farKm, s.calculate.Duration(farKm)) {
+			return
+		}
+		if _, err := s.repo.SetApproachNotified(ctx, ride.ID, false); err != nil {
+			s.logger.Warn(ctx, "failed to reset approach notification", "error", err)
+		}
+		return
+	}
+
+	if !s.approach.near(distanceKm, durationMin) {
+		return
+	}
+
+	// отметка атомарная: при нескольких экземплярах сервиса уведомление отправит только один
+	marked, err := s.repo.SetApproachNotified(ctx, ride.ID, true)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to mark approach notification", "error", err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	event := models.DriverApproachingEvent{
+		RideID:             ride.ID,
+		DriverLocation:     driverLocation,
+		DistanceToPickupKm: distanceKm,
+		EstimatedArrival:   s.clock.Now().Add(time.Duration(durationMin) * time.Minute),
+	}
+
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, models.StatusUpdateWebSocketMessage{
+		EventType: types.EventDriverApproaching,
+		Data:      event,
+	}); err != nil {
+		s.logger.Warn(ctx, "failed to send driver approaching to passenger via websocket", "error", err)
+	}
+
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Driver is almost there",
+		Body:   fmt.Sprintf("Your driver is about %s away from the pickup point for ride %s", approachETAText(durationMin), ride.RideNumber),
+		// озвучивается для пассажиров с включенным voice_readout
+		ReadAloud: fmt.Sprintf("Your driver is about %s away. Please head to the pickup point.", approachETAText(durationMin)),
+		Data:      rideNotificationData(ride, types.EventDriverApproaching),
+	})
+
+	eventData, _ := json.Marshal(event) // non fatal event so just ignore error
+	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventDriverApproaching, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventDriverApproaching, "error", err.Error())
+	}
+}
+
+func approachETAText(durationMin int) string {
+	if durationMin <= 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", durationMin)
+}
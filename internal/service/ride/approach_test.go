@@ -0,0 +1,30 @@
+package ride
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApproachOptionsNear(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        ApproachOptions
+		distanceKm  float64
+		durationMin int
+		want        bool
+	}{
+		{"disabled", ApproachOptions{}, 0, 0, false},
+		{"within distance", ApproachOptions{DistanceKm: 0.5}, 0.5, 3, true},
+		{"beyond distance", ApproachOptions{DistanceKm: 0.5}, 0.6, 1, false},
+		{"within eta", ApproachOptions{DistanceKm: 0.5, ETA: time.Minute}, 0.8, 1, true},
+		{"beyond eta", ApproachOptions{ETA: time.Minute}, 0.8, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.near(tt.distanceKm, tt.durationMin); got != tt.want {
+				t.Fatalf("near(%v, %d) = %v, want %v", tt.distanceKm, tt.durationMin, got, tt.want)
+			}
+		})
+	}
+}
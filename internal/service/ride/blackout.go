@@ -0,0 +1,126 @@
+package ride
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+)
+
+// blackoutRecheck — как долго запрос поиска водителя задерживается без повторной проверки окна.
+// Окно, которое администратор завершил досрочно, отпускает поездки не позже чем через этот интервал.
+const blackoutRecheck = time.Minute
+
+// BlackoutCache кэширует окна приостановки подбора, которые проверяются при каждом создании поездки.
+// Изменения администратора сбрасывают кэш событием invalidation, TTL страхует от потерянных событий.
+type BlackoutCache struct {
+	repo BlackoutRepo
+	ttl  time.Duration
+
+	mu        sync.RWMutex
+	blackouts []models.MatchingBlackout
+	expiresAt time.Time
+	// generation растет при каждом сбросе, чтобы чтение, начатое до сброса, не вернуло старые данные в кэш
+	generation uint64
+}
+
+func NewBlackoutCache(repo BlackoutRepo, ttl time.Duration) *BlackoutCache {
+	return &BlackoutCache{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Active возвращает окна, которые действовали на момент загрузки кэша или начнутся позже.
+// Окна, закончившиеся после загрузки, отсеиваются по времени поездки.
+func (c *BlackoutCache) Active(ctx context.Context, at time.Time) ([]models.MatchingBlackout, error) {
+	c.mu.RLock()
+	if at.Before(c.expiresAt) {
+		blackouts := c.blackouts
+		c.mu.RUnlock()
+		return blackouts, nil
+	}
+	generation := c.generation
+	c.mu.RUnlock()
+
+	blackouts, err := c.repo.Active(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.blackouts = blackouts
+		c.expiresAt = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+
+	return blackouts, nil
+}
+
+// Invalidate сбрасывает кэш, следующий запрос перечитает окна из БД
+func (c *BlackoutCache) Invalidate(_ context.Context, _ invalidation.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.blackouts = nil
+	c.expiresAt = time.Time{}
+	c.generation++
+}
+
+// activeBlackouts загружает окна приостановки подбора до транзакции.
+// Ошибка загрузки не мешает поездке: поиск водителя начинается сразу.
+func (s *RideService) activeBlackouts(ctx context.Context, at time.Time) []models.MatchingBlackout {
+	if s.blackouts == nil {
+		return nil
+	}
+
+	blackouts, err := s.blackouts.Active(ctx, at)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to load matching blackouts, driver search is not delayed", "error", err)
+		return nil
+	}
+	return blackouts
+}
+
+// matchingBlackout возвращает действующее окно, накрывающее точку посадки и класс поездки.
+// Из пересекающихся окон выбирается то, что закончится позже. nil — подбор не приостановлен.
+func (s *RideService) matchingBlackout(blackouts []models.MatchingBlackout, rideType string, pickup models.Location, at time.Time) *models.MatchingBlackout {
+	var match *models.MatchingBlackout
+	for i := range blackouts {
+		b := &blackouts[i]
+		if !b.ActiveAt(at) || !b.AppliesTo(rideType) {
+			continue
+		}
+		if s.calculate.Distance(b.Zone.Center, pickup) > b.Zone.RadiusKm {
+			continue
+		}
+		if match == nil || b.EndsAt.After(match.EndsAt) {
+			match = b
+		}
+	}
+	return match
+}
+
+// holdDispatch откладывает запрос поиска водителя до окончания окна, но не дольше blackoutRecheck:
+// relay перепроверит окно и задержит запрос снова, если оно еще действует
+func (s *RideService) holdDispatch(ctx context.Context, m *models.OutboxMessage, blackout *models.MatchingBlackout, at time.Time) error {
+	until := at.Add(blackoutRecheck)
+	if blackout.EndsAt.Before(until) {
+		until = blackout.EndsAt
+	}
+	return s.outbox.Hold(ctx, m.ID, until)
+}
+
+// newMatchingDelay описывает пассажиру задержку поиска водителя
+func newMatchingDelay(ride *models.Ride, blackout *models.MatchingBlackout, at time.Time) *models.MatchingDelay {
+	return &models.MatchingDelay{
+		RideID:               ride.ID,
+		Reason:               blackout.Reason,
+		Until:                blackout.EndsAt,
+		ExpectedDelayMinutes: int(math.Ceil(blackout.EndsAt.Sub(at).Minutes())),
+	}
+}
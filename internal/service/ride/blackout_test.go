@@ -0,0 +1,53 @@
+package ride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+)
+
+func TestServiceMatchingBlackout(t *testing.T) {
+	now := time.Date(2026, 12, 16, 12, 0, 0, 0, time.UTC)
+	center := models.Location{Latitude: 43.238949, Longitude: 76.945465}
+	zone := models.FareZone{Center: center, RadiusKm: 1}
+	inside := models.Location{Latitude: 43.2395, Longitude: 76.946}
+	outside := models.Location{Latitude: 43.26, Longitude: 76.97}
+
+	parade := models.MatchingBlackout{Reason: "parade", Zone: zone, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	premium := models.MatchingBlackout{Reason: "premium", VehicleType: string(types.ClassPremium), Zone: zone, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(3 * time.Hour)}
+	scheduled := models.MatchingBlackout{Reason: "scheduled", Zone: zone, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)}
+	ended := models.MatchingBlackout{Reason: "ended", Zone: zone, StartsAt: now.Add(-2 * time.Hour), EndsAt: now}
+
+	tests := []struct {
+		name      string
+		blackouts []models.MatchingBlackout
+		rideType  string
+		pickup    models.Location
+		want      string
+	}{
+		{"no blackouts", nil, string(types.ClassEconomy), inside, ""},
+		{"inside zone", []models.MatchingBlackout{parade}, string(types.ClassEconomy), inside, "parade"},
+		{"outside zone", []models.MatchingBlackout{parade}, string(types.ClassEconomy), outside, ""},
+		{"other class", []models.MatchingBlackout{premium}, string(types.ClassEconomy), inside, ""},
+		{"longest window wins", []models.MatchingBlackout{parade, premium}, string(types.ClassPremium), inside, "premium"},
+		{"not started", []models.MatchingBlackout{scheduled}, string(types.ClassEconomy), inside, ""},
+		{"ended", []models.MatchingBlackout{ended}, string(types.ClassEconomy), inside, ""},
+	}
+
+	s := &RideService{calculate: ridecalc.New()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.matchingBlackout(tt.blackouts, tt.rideType, tt.pickup, now)
+			var reason string
+			if got != nil {
+				reason = got.Reason
+			}
+			if reason != tt.want {
+				t.Fatalf("matchingBlackout() = %q, want %q", reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ride
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// EmissionFactors — выбросы CO2 в граммах на км по классам автомобилей
+type EmissionFactors map[types.VehicleClass]float64
+
+// Footprint возвращает выбросы поездки в граммах. false — для класса нет коэффициента.
+func (f EmissionFactors) Footprint(class types.VehicleClass, distanceKm float64) (float64, bool) {
+	factor, ok := f[class]
+	if !ok {
+		return 0, false
+	}
+	return math.Round(factor*distanceKm*100) / 100, true
+}
+
+// footprint считает углеродный след завершенной поездки. Берется фактическое расстояние
+// от водителя, без него — расстояние по прямой между точками посадки и назначения.
+func (s *RideService) footprint(ride *models.Ride, msg models.DriverStatusUpdateMessage) (distanceKm, co2Grams float64, ok bool) {
+	distanceKm = s.calculate.Distance(ride.Pickup, ride.Destination)
+	if msg.DistanceKm != nil && *msg.DistanceKm > 0 {
+		distanceKm = *msg.DistanceKm
+	}
+
+	co2Grams, ok = s.emissions.Footprint(types.VehicleClass(ride.RideType), distanceKm)
+	return math.Round(distanceKm*100) / 100, co2Grams, ok
+}
+
+// Impact возвращает углеродный след завершенных поездок пассажира за последние months месяцев (UTC),
+// включая текущий. Месяцы без поездок не возвращаются.
+func (s *RideService) Impact(ctx context.Context, passengerID uuid.UUID, months int) (*models.PassengerImpact, error) {
+	ctx = wrap.WithAction(wrap.WithPassengerID(ctx, passengerID.String()), "get_passenger_impact")
+
+	now := s.clock.Now().UTC()
+	since := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+
+	monthly, err := s.repo.GetMonthlyImpact(ctx, passengerID, since)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	impact := &models.PassengerImpact{
+		PassengerID: passengerID,
+		Since:       since,
+		Months:      monthly,
+	}
+	for _, m := range monthly {
+		impact.Total.Rides += m.Rides
+		impact.Total.DistanceKm += m.DistanceKm
+		impact.Total.CO2Grams += m.CO2Grams
+	}
+	impact.Total.DistanceKm = math.Round(impact.Total.DistanceKm*100) / 100
+	impact.Total.CO2Grams = math.Round(impact.Total.CO2Grams*100) / 100
+
+	return impact, nil
+}
@@ -0,0 +1,52 @@
+package ride
+
+import (
+	"context"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// cityFare применяет к стоимости правила города, в котором находится точка посадки.
+// Вне известных городов стоимость не меняется.
+func (s *RideService) cityFare(ctx context.Context, pickup models.Location, fare float64, at time.Time) (float64, float64, error) {
+	if s.cities == nil {
+		return fare, 1, nil
+	}
+
+	city, err := s.cities.FindByLocation(ctx, pickup)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fare, multiplier, err := s.calculate.CityFare(fare, city, at)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if multiplier > 1 {
+		s.logger.Info(ctx, "night surcharge applied", "city", city.Code, "multiplier", multiplier)
+	}
+
+	return fare, multiplier, nil
+}
+
+// checkMatchingPaused отклоняет новую поездку, если подбор водителей в городе точки посадки
+// приостановлен операционным действием PAUSE_MATCHING
+func (s *RideService) checkMatchingPaused(ctx context.Context, pickup models.Location) error {
+	if s.cities == nil {
+		return nil
+	}
+
+	city, err := s.cities.FindByLocation(ctx, pickup)
+	if err != nil {
+		return err
+	}
+
+	if city != nil && city.MatchingPaused {
+		s.logger.Warn(ctx, "ride rejected: matching is paused", "city", city.Code)
+		return types.ErrMatchingPaused
+	}
+	return nil
+}
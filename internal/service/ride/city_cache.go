@@ -0,0 +1,96 @@
+package ride
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+)
+
+// CityCache кэширует правила городов, которые читаются при каждом создании и оценке поездки.
+// Городов единицы, поэтому кэшируется весь список, а город точки ищется в памяти.
+// Изменения администратора сбрасывают кэш событием invalidation, TTL страхует от потерянных событий.
+type CityCache struct {
+	repo      CityRepo
+	calculate ridecalc.Calculator
+	ttl       time.Duration
+
+	mu        sync.RWMutex
+	cities    []models.CitySettings
+	expiresAt time.Time
+	// generation растет при каждом сбросе, чтобы чтение, начатое до сброса, не вернуло старые данные в кэш
+	generation uint64
+}
+
+func NewCityCache(repo CityRepo, calculate ridecalc.Calculator, ttl time.Duration) *CityCache {
+	return &CityCache{
+		repo:      repo,
+		calculate: calculate,
+		ttl:       ttl,
+	}
+}
+
+// List возвращает правила всех городов
+func (c *CityCache) List(ctx context.Context) ([]models.CitySettings, error) {
+	c.mu.RLock()
+	if time.Now().Before(c.expiresAt) {
+		cities := c.cities
+		c.mu.RUnlock()
+		return cities, nil
+	}
+	generation := c.generation
+	c.mu.RUnlock()
+
+	cities, err := c.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.cities = cities
+		c.expiresAt = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+
+	return cities, nil
+}
+
+// FindByLocation возвращает ближайший город, в радиус которого попадает точка, или nil.
+// Повторяет CityRepo.FindByLocation по закэшированному списку.
+func (c *CityCache) FindByLocation(ctx context.Context, location models.Location) (*models.CitySettings, error) {
+	cities, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		found   *models.CitySettings
+		nearest float64
+	)
+	for i := range cities {
+		distance := c.calculate.Distance(cities[i].Center, location)
+		if distance > cities[i].RadiusKm {
+			continue
+		}
+		if found == nil || distance < nearest {
+			city := cities[i]
+			found, nearest = &city, distance
+		}
+	}
+	return found, nil
+}
+
+// Invalidate сбрасывает кэш, следующий запрос перечитает города из БД.
+// Кэш хранит список целиком, поэтому ключ события не используется.
+func (c *CityCache) Invalidate(_ context.Context, _ invalidation.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cities = nil
+	c.expiresAt = time.Time{}
+	c.generation++
+}
@@ -0,0 +1,63 @@
+package ride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// DriverCard возвращает карточку водителя поездки пассажира.
+// ETA считается от текущей координаты водителя до точки посадки, пока водитель не прибыл.
+func (s *RideService) DriverCard(ctx context.Context, rideID, passengerID uuid.UUID) (*models.DriverCard, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "get_driver_card")
+
+	ride, err := s.repo.Get(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, wrap.Error(ctx, types.ErrRideNotFound)
+		}
+		return nil, wrap.Error(ctx, fmt.Errorf("could not find ride by id: %w", err))
+	}
+	if ride.PassengerID != passengerID {
+		return nil, wrap.Error(ctx, authSvc.ErrActionForbidden)
+	}
+	if ride.DriverID == nil {
+		return nil, wrap.Error(ctx, types.ErrRideHasNoDriver)
+	}
+
+	card, err := s.repo.DriverCard(ctx, *ride.DriverID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	switch ride.Status {
+	case types.StatusMatched.String(), types.StatusEnRoute.String():
+		if card.DriverLocation != nil {
+			eta := s.calculate.Duration(s.calculate.Distance(*card.DriverLocation, ride.Pickup))
+			card.ETAMinutes = &eta
+		}
+	}
+
+	return card, nil
+}
+
+// matchedDriverCard собирает карточку для события driver_matched, ETA берется из ответа водителя.
+// Ошибка не мешает сообщить пассажиру о назначении: событие уходит без карточки.
+func (s *RideService) matchedDriverCard(ctx context.Context, msg models.DriverMatchResponse) *models.DriverCard {
+	card, err := s.repo.DriverCard(ctx, msg.DriverID)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to build driver card", "driver_id", msg.DriverID, "error", err.Error())
+		return nil
+	}
+
+	eta := msg.EstimatedArrivalMinutes
+	card.ETAMinutes = &eta
+	card.DriverLocation = &msg.DriverLocation
+	return card
+}
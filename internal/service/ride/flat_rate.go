@@ -0,0 +1,104 @@
+package ride
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/invalidation"
+)
+
+// FlatRateCache кэширует фиксированные тарифы, которые проверяются при каждом создании и оценке поездки.
+// Хранятся действующие и запланированные версии, подходящая момент поездки выбирается калькулятором.
+// Изменения администратора сбрасывают кэш событием invalidation, TTL страхует от потерянных событий.
+type FlatRateCache struct {
+	repo FlatRateRepo
+	ttl  time.Duration
+
+	mu        sync.RWMutex
+	rates     []models.FlatRate
+	expiresAt time.Time
+	// generation растет при каждом сбросе, чтобы чтение, начатое до сброса, не вернуло старые данные в кэш
+	generation uint64
+}
+
+func NewFlatRateCache(repo FlatRateRepo, ttl time.Duration) *FlatRateCache {
+	return &FlatRateCache{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Active возвращает версии, которые действовали на момент загрузки кэша или начнут действовать позже.
+// Версии, закончившиеся после загрузки, отсеивает калькулятор по времени поездки.
+func (c *FlatRateCache) Active(ctx context.Context, at time.Time) ([]models.FlatRate, error) {
+	c.mu.RLock()
+	if at.Before(c.expiresAt) {
+		rates := c.rates
+		c.mu.RUnlock()
+		return rates, nil
+	}
+	generation := c.generation
+	c.mu.RUnlock()
+
+	rates, err := c.repo.Active(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.rates = rates
+		c.expiresAt = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+
+	return rates, nil
+}
+
+// Invalidate сбрасывает кэш, следующий запрос перечитает тарифы из БД.
+// Кэш хранит список целиком, поэтому ключ события не используется.
+func (c *FlatRateCache) Invalidate(_ context.Context, _ invalidation.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rates = nil
+	c.expiresAt = time.Time{}
+	c.generation++
+}
+
+// activeFlatRates загружает фиксированные тарифы до транзакции поездки.
+// Ошибка загрузки не мешает поездке: стоимость считается по формуле.
+func (s *RideService) activeFlatRates(ctx context.Context, at time.Time) []models.FlatRate {
+	if s.flatRates == nil {
+		return nil
+	}
+
+	rates, err := s.flatRates.Active(ctx, at)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to load flat rates, fare is calculated by formula", "error", err)
+		return nil
+	}
+	return rates
+}
+
+// flatFare применяет фиксированный тариф маршрута или минимальный тариф зоны посадки.
+// Возвращает итоговую стоимость и примененный тариф, nil — стоимость не изменилась.
+func (s *RideService) flatFare(ctx context.Context, rates []models.FlatRate, rideType string, pickup, destination models.Location, fare float64, at time.Time) (float64, *models.FlatRate) {
+	if len(rates) == 0 {
+		return fare, nil
+	}
+
+	fare, rate := s.calculate.FlatFare(fare, rideType, pickup, destination, rates, at)
+	if rate != nil {
+		s.logger.Info(ctx, "flat rate applied", "code", rate.Code, "rate_type", rate.RateType, "fare", fare)
+	}
+	return fare, rate
+}
+
+// isFixedFare сообщает, что стоимость задана маршрутом и надбавки к ней не применялись
+func isFixedFare(rate *models.FlatRate) bool {
+	return rate != nil && rate.RateType == types.FlatRateFixed
+}
@@ -8,14 +8,20 @@ import (
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 )
 
+// rideNumberDigits — ширина номера из последовательности. Номера одной ширины
+// сортируются как строки в порядке создания.
+const rideNumberDigits = 10
+
 // создать уникальный номер поездки
 func (s *RideService) generateRideNumber(ctx context.Context) (string, error) {
-	datePart := time.Now().Format("20060102")
-
-	count, err := s.repo.CountByDate(ctx)
+	seq, err := s.repo.NextRideNumber(ctx)
 	if err != nil {
 		return "", wrap.Error(ctx, err)
 	}
-	nextSequence := count + 1
-	return fmt.Sprintf("RIDE_%s_%03d", datePart, nextSequence), nil
+	return formatRideNumber(s.clock.Now(), seq), nil
+}
+
+// formatRideNumber — RIDE_<yyyymmdd>_<seq>. Дата в UTC, чтобы номера не шли назад при смене часового пояса.
+func formatRideNumber(now time.Time, seq int64) string {
+	return fmt.Sprintf("RIDE_%s_%0*d", now.UTC().Format("20060102"), rideNumberDigits, seq)
 }
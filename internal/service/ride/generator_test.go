@@ -0,0 +1,30 @@
+package ride
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestFormatRideNumber_SortsByCreation(t *testing.T) {
+	day := time.Date(2026, 10, 15, 23, 59, 0, 0, time.UTC)
+	created := []string{
+		formatRideNumber(day, 9),
+		formatRideNumber(day, 10),
+		formatRideNumber(day, 999),
+		formatRideNumber(day.Add(2*time.Minute), 1000),
+		formatRideNumber(day.AddDate(1, 0, 0), 123456789),
+	}
+
+	sorted := append([]string(nil), created...)
+	sort.Strings(sorted)
+	for i := range created {
+		if sorted[i] != created[i] {
+			t.Fatalf("ride numbers do not sort in creation order: %v", sorted)
+		}
+	}
+
+	if got, want := formatRideNumber(day, 42), "RIDE_20261015_0000000042"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
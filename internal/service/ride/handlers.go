@@ -38,32 +38,58 @@ func (s *RideService) HandleDriverResponse(ctx context.Context, msg models.Drive
 		return wrap.Error(ctx, types.ErrInvalidRideStatus)
 	}
 
-	// Изменяем статус поездки на matched, добавляем driver_id
-	if err := s.repo.DriverMatchedForRide(ctx, ride.ID, msg.DriverID, ride.EstimatedFare); err != nil {
-		return wrap.Error(ctx, fmt.Errorf("%w: failed to update ride status: %w", types.ErrDatabaseFailed, err))
-	}
-
+	// Изменяем статус поездки на matched, добавляем driver_id.
+	// Водитель смежного класса выполняет поездку по цене заказанного класса.
 	message := models.RideStatusUpdateMessage{
 		RideID:        ride.ID,
 		Status:        types.StatusMatched.String(),
-		Timestamp:     time.Now(),
+		Timestamp:     s.clock.Now(),
 		DriverID:      &msg.DriverID,
 		CorrelationID: wrap.GetRequestID(ctx),
 	}
 
-	if err := s.publisher.PublishRideStatus(ctx, message); err != nil {
-		return wrap.Error(ctx, fmt.Errorf("%w: %w", types.ErrFailedToPublishRideStatus, err))
+	var outboxMsg *models.OutboxMessage
+	if err := s.trm.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.DriverMatchedForRide(ctx, ride.ID, msg.DriverID, ride.EstimatedFare, msg.ServedVehicleClass); err != nil {
+			return fmt.Errorf("failed to update ride status: %w", err)
+		}
+
+		var err error
+		outboxMsg, err = s.enqueueRideStatus(ctx, &message)
+		return err
+	}); err != nil {
+		return wrap.Error(ctx, fmt.Errorf("%w: %w", types.ErrDatabaseFailed, err))
+	}
+
+	// при ошибке брокера смену статуса отправит relay
+	s.flushOutbox(ctx, outboxMsg)
+
+	body := fmt.Sprintf("A driver is on the way for ride %s", ride.RideNumber)
+	if msg.ServedVehicleClass != nil {
+		s.logger.Info(ctx, "ride matched with adjacent vehicle class", "requested_class", ride.RideType, "served_class", *msg.ServedVehicleClass)
+		body = fmt.Sprintf("A %s car is on the way for ride %s at the %s price", *msg.ServedVehicleClass, ride.RideNumber, ride.RideType)
 	}
 
 	data := models.StatusUpdateWebSocketMessage{
 		EventType: types.EventDriverMatched,
-		Data:      msg,
+		Data: models.DriverMatchedEvent{
+			DriverMatchResponse: msg,
+			DriverCard:          s.matchedDriverCard(ctx, msg),
+		},
 	}
 
 	// Уведомляем пассажира по вебсокету
-	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, data); err != nil {
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, data); err != nil {
 		s.logger.Warn(ctx, "failed to notify passenger about driver matching", "event_type", types.EventDriverMatched, "error", err.Error())
 	}
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Driver found",
+		Body:   body,
+		Data:   rideNotificationData(ride, types.EventDriverMatched),
+	})
 
 	// записываем ивент
 	eventData, _ := json.Marshal(msg) // non fatal event so just ignore error
@@ -127,6 +153,9 @@ func (s *RideService) HandleDriverLocationUpdate(ctx context.Context, msg models
 	distanceKm := s.calculate.Distance(driverCurrentLocation, targetLocation)
 	durationMin := s.calculate.Duration(distanceKm)
 
+	// пассажиру — заранее, что водитель почти у точки посадки
+	s.checkApproach(ctx, ride, driverCurrentLocation, distanceKm)
+
 	// 5. Формируем сообщение для WebSocket
 	wsMessage := models.PassengerLocationUpdateDTO{
 		Type:   types.EventLocationUpdated.String(),
@@ -136,7 +165,7 @@ func (s *RideService) HandleDriverLocationUpdate(ctx context.Context, msg models
 			Longitude: msg.Location.Longitude,
 		},
 		DistanceToPickupKm: distanceKm,
-		EstimatedArrival:   time.Now().Add(time.Duration(durationMin) * time.Minute),
+		EstimatedArrival:   s.clock.Now().Add(time.Duration(durationMin) * time.Minute),
 	}
 
 	// записываем ивент
@@ -145,6 +174,7 @@ func (s *RideService) HandleDriverLocationUpdate(ctx context.Context, msg models
 		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventLocationUpdated, "error", err.Error())
 	}
 
+	// обновления местоположения идут каждые несколько секунд и в журнал коммуникаций не пишутся
 	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, wsMessage); err != nil {
 		s.logger.Warn(ctx, "failed to send a driver location update to passenger via websocket", "error", err)
 	}
@@ -178,6 +208,8 @@ func (s *RideService) HandleDriverStatusUpdate(ctx context.Context, msg models.D
 		return wrap.Error(ctx, s.handleRideInProgress(ctx, ride, msg))
 	case types.StatusCompleted.String():
 		return wrap.Error(ctx, s.handleRideCompleted(ctx, ride, msg))
+	case types.StatusDriverOffline.String():
+		return wrap.Error(ctx, s.handleDriverOffline(ctx, ride, msg))
 	default:
 		// TODO: что вернуть
 		return wrap.Error(ctx, errors.New("invalid driver status"))
@@ -206,12 +238,12 @@ func (s *RideService) handleDriverEnRoute(ctx context.Context, ride *models.Ride
 		Data: models.RideStatusUpdateMessage{
 			RideID:        ride.ID,
 			Status:        types.StatusEnRoute.String(),
-			Timestamp:     time.Now(),
+			Timestamp:     s.clock.Now(),
 			DriverID:      &msg.DriverID,
 			CorrelationID: wrap.GetRequestID(ctx),
 		},
 	}
-	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, wsMessage); err != nil {
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
 		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
 	}
 
@@ -235,6 +267,15 @@ func (s *RideService) handleDriverArrived(ctx context.Context, ride *models.Ride
 		return fmt.Errorf("invalid ride status expected: %s", types.StatusEnRoute)
 	}
 
+	statusMessage := models.RideStatusUpdateMessage{
+		RideID:        ride.ID,
+		Status:        types.StatusArrived.String(),
+		Timestamp:     s.clock.Now(),
+		DriverID:      &msg.DriverID,
+		CorrelationID: wrap.GetRequestID(ctx),
+	}
+
+	var outboxMsg *models.OutboxMessage
 	if err := s.trm.Do(ctx, func(ctx context.Context) error {
 		if err := s.repo.UpdateStatus(ctx, ride.ID, types.StatusArrived); err != nil {
 			return err
@@ -244,33 +285,36 @@ func (s *RideService) handleDriverArrived(ctx context.Context, ride *models.Ride
 			return err
 		}
 
-		return nil
+		var err error
+		outboxMsg, err = s.enqueueRideStatus(ctx, &statusMessage)
+		return err
 	}); err != nil {
 		return wrap.Error(ctx, err)
 	}
 
 	s.logger.Info(ctx, "updated ride status to ARRIVED")
 
-	statusMessage := models.RideStatusUpdateMessage{
-		RideID:        ride.ID,
-		Status:        types.StatusArrived.String(),
-		Timestamp:     time.Now(),
-		DriverID:      &msg.DriverID,
-		CorrelationID: wrap.GetRequestID(ctx),
-	}
-
-	if err := s.publisher.PublishRideStatus(ctx, statusMessage); err != nil {
-		s.logger.Warn(ctx, "failed to publish ride status ARRIVED", "error", err)
-	}
+	// при ошибке брокера смену статуса отправит relay
+	s.flushOutbox(ctx, outboxMsg)
 
 	// отправляем пассажиру сообщение по вебсокету
 	wsMessage := models.StatusUpdateWebSocketMessage{
 		EventType: types.EventDriverArrived,
 		Data:      statusMessage,
 	}
-	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, wsMessage); err != nil {
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
 		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
 	}
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Driver has arrived",
+		Body:   fmt.Sprintf("Your driver is waiting at the pickup point for ride %s", ride.RideNumber),
+		// озвучивается для пассажиров с включенным voice_readout
+		ReadAloud: "Your driver has arrived and is waiting for you at the pickup point.",
+		Data:      rideNotificationData(ride, types.EventDriverArrived),
+	})
 
 	bytes, _ := json.Marshal(msg) // non fatal event so just ignore error
 	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventDriverArrived, bytes); err != nil {
@@ -315,12 +359,12 @@ func (s *RideService) handleRideInProgress(ctx context.Context, ride *models.Rid
 		Data: models.RideStatusUpdateMessage{
 			RideID:        ride.ID,
 			Status:        types.StatusInProgress.String(),
-			Timestamp:     time.Now(),
+			Timestamp:     s.clock.Now(),
 			DriverID:      &msg.DriverID,
 			CorrelationID: wrap.GetRequestID(ctx),
 		},
 	}
-	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, wsMessage); err != nil {
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
 		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
 	}
 
@@ -342,6 +386,23 @@ func (s *RideService) handleRideCompleted(ctx context.Context, ride *models.Ride
 		return fmt.Errorf("invalid ride status expected: %s", types.StatusInProgress)
 	}
 
+	distanceKm, co2Grams, ok := s.footprint(ride, msg)
+	if !ok {
+		s.logger.Warn(ctx, "no emission factor for vehicle class", "ride_type", ride.RideType)
+	}
+
+	fare := ride.EstimatedFare
+	if ride.FinalFare != nil {
+		fare = *ride.FinalFare
+	}
+
+	// чек выдает юрлицо города посадки, без него номер счета выдать нельзя — сообщение повторится
+	legalEntity, err := s.legalEntity(ctx, ride.Pickup)
+	if err != nil {
+		return wrap.Error(ctx, fmt.Errorf("failed to resolve legal entity: %w", err))
+	}
+
+	captured := true
 	if err := s.trm.Do(ctx, func(ctx context.Context) error {
 		if err := s.repo.UpdateStatus(ctx, ride.ID, types.StatusCompleted); err != nil {
 			return err
@@ -351,6 +412,26 @@ func (s *RideService) handleRideCompleted(ctx context.Context, ride *models.Ride
 			return err
 		}
 
+		if ok {
+			if err := s.repo.SetFootprint(ctx, ride.ID, distanceKm, co2Grams); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.CreateReceipt(ctx, s.newReceipt(ride, fare, legalEntity)); err != nil {
+			return err
+		}
+		if err := s.enqueueFiscalReceipt(ctx, ride.ID); err != nil {
+			return err
+		}
+
+		if ride.PaymentMethod == types.PaymentWallet {
+			var err error
+			if captured, err = s.captureFare(ctx, ride, fare); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	}); err != nil {
 		return wrap.Error(ctx, err)
@@ -358,21 +439,41 @@ func (s *RideService) handleRideCompleted(ctx context.Context, ride *models.Ride
 
 	s.logger.Info(ctx, "updated ride status to COMPLETED")
 
+	// баланса не хватило: списываем с карты вне транзакции, это внешний вызов
+	if !captured {
+		s.chargeCard(ctx, ride, fare)
+	} else if ride.PaymentMethod == types.PaymentCard {
+		s.captureCardHold(ctx, ride, fare)
+	}
+
 	// отправляем пассажиру сообщение по вебсокету
 	wsMessage := models.StatusUpdateWebSocketMessage{
 		EventType: types.EventRideCompleted,
 		Data: models.RideStatusUpdateMessage{
 			RideID:        ride.ID,
 			Status:        types.StatusCompleted.String(),
-			Timestamp:     time.Now(),
+			Timestamp:     s.clock.Now(),
 			DriverID:      &msg.DriverID,
 			CorrelationID: wrap.GetRequestID(ctx),
 		},
 	}
-	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, wsMessage); err != nil {
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
 		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
 	}
 
+	body := fmt.Sprintf("Ride %s completed. Total: %.2f", ride.RideNumber, fare)
+	if ok {
+		body += fmt.Sprintf(". Distance: %.1f km, CO2: %.0f g", distanceKm, co2Grams)
+	}
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyReceipts,
+		Title:  "Ride receipt",
+		Body:   body,
+		Data:   rideNotificationData(ride, types.EventRideCompleted),
+	})
+
 	bytes, _ := json.Marshal(msg) // non fatal event so just ignore error
 	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventRideCompleted, bytes); err != nil {
 		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventRideCompleted, "error", err.Error())
@@ -380,3 +481,76 @@ func (s *RideService) handleRideCompleted(ctx context.Context, ride *models.Ride
 
 	return nil
 }
+
+// handleDriverOffline возвращает поездку в поиск водителя, если назначенный водитель
+// ушел офлайн или потерял соединение, не доехав до пассажира
+func (s *RideService) handleDriverOffline(ctx context.Context, ride *models.Ride, msg models.DriverStatusUpdateMessage) error {
+	ctx = wrap.WithAction(ctx, "handle_driver_offline")
+
+	if ride.DriverID == nil || *ride.DriverID != msg.DriverID {
+		s.logger.Warn(ctx, "offline driver is not assigned to the ride")
+		return nil
+	}
+
+	if ride.Status != types.StatusMatched.String() && ride.Status != types.StatusEnRoute.String() {
+		s.logger.Warn(ctx, "ride is not waiting for the driver, skipping redispatch", "current_status", ride.Status)
+		return nil
+	}
+
+	correlationID := wrap.GetRequestID(ctx)
+	if correlationID == "" {
+		correlationID = newCorrelationID()
+	}
+	ride.Priority = s.calculate.Priority(ride)
+	request := newRideRequestedMessage(ride, correlationID)
+
+	if err := s.trm.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.ReleaseDriver(ctx, ride.ID, msg.DriverID); err != nil {
+			return err
+		}
+
+		// перезапускаем поиск водителя
+		if err := s.publisher.PublishRideRequested(ctx, request); err != nil {
+			return fmt.Errorf("failed to publish ride requested event: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	s.logger.Info(ctx, "driver went offline, ride returned to matching", "previous_status", ride.Status)
+
+	// отправляем пассажиру сообщение по вебсокету
+	wsMessage := models.StatusUpdateWebSocketMessage{
+		EventType: types.EventStatusChanged,
+		Data: models.RideStatusUpdateMessage{
+			RideID:        ride.ID,
+			Status:        types.StatusRequested.String(),
+			Timestamp:     s.clock.Now(),
+			CorrelationID: correlationID,
+		},
+	}
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
+		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
+	}
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Finding a new driver",
+		Body:   fmt.Sprintf("Your driver is unavailable. We are looking for another driver for ride %s", ride.RideNumber),
+	})
+
+	eventData, _ := json.Marshal(map[string]any{ // non fatal event so just ignore error
+		"status":             types.StatusRequested,
+		"previous_status":    ride.Status,
+		"released_driver_id": msg.DriverID,
+		"reason":             "driver went offline before pickup",
+	})
+	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventStatusChanged, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventStatusChanged, "error", err.Error())
+	}
+
+	s.awaitDriver(ctx, ride.ID, ride.PassengerID)
+	return nil
+}
@@ -0,0 +1,23 @@
+package ride
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// History возвращает страницу поездок пассажира с фильтрами по статусу и дате запроса
+func (s *RideService) History(ctx context.Context, passengerID uuid.UUID, filter models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error) {
+	ctx = wrap.WithAction(wrap.WithPassengerID(ctx, passengerID.String()), "passenger_ride_history")
+
+	filter.PassengerID = &passengerID
+	filter.DriverID = nil
+
+	history, err := s.repo.History(ctx, filter, filters)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return history, nil
+}
@@ -3,6 +3,7 @@ package ride
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/adapter/rabbit"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
@@ -21,18 +22,137 @@ type (
 		UpdateCompletedAt(ctx context.Context, rideID uuid.UUID) error
 		UpdateStartedAt(ctx context.Context, rideID uuid.UUID) error
 		// для генерации уникального номера поездки (ride_number)
-		CountByDate(ctx context.Context) (int, error)
+		NextRideNumber(ctx context.Context) (int64, error)
 
 		// проверить, есть ли у пассажира активная поездка
 		CheckActiveRideByPassengerID(ctx context.Context, passengerID uuid.UUID) (*models.Ride, error)
+		// разрешена ли пассажиру приоритетная посадка
+		IsPriorityBoardingEligible(ctx context.Context, passengerID uuid.UUID) (bool, error)
+		// переход оплаты с кошелька на карту
+		SetPaymentMethod(ctx context.Context, rideID uuid.UUID, method types.PaymentMethod) error
 
-		DriverMatchedForRide(ctx context.Context, rideID, driverID uuid.UUID, finalFare float64) error
+		DriverMatchedForRide(ctx context.Context, rideID, driverID uuid.UUID, finalFare float64, servedClass *types.VehicleClass) error
+		// снять водителя с еще не начатой поездки и вернуть ее в REQUESTED
+		ReleaseDriver(ctx context.Context, rideID, driverID uuid.UUID) error
+		// забрать на сверку поездки, зависшие в REQUESTED и MATCHED
+		ClaimStuckRides(ctx context.Context, requestedBefore, matchedBefore time.Time, limit int) ([]uuid.UUID, error)
+		// карточка водителя для пассажира
+		DriverCard(ctx context.Context, driverID uuid.UUID) (*models.DriverCard, error)
+		CreateReceipt(ctx context.Context, receipt *models.Receipt) error
+		GetReceipt(ctx context.Context, rideID uuid.UUID) (*models.Receipt, error)
+		SetFiscalReceipt(ctx context.Context, rideID uuid.UUID, fiscalReceiptID string) error
+		// путь водителя по истории координат, для штрафа за отмену
+		DriverDistanceSince(ctx context.Context, driverID uuid.UUID, since time.Time) (float64, error)
+
+		// отметка поездки, запрос поиска водителя для которой ждет отправки из outbox
+		SetPendingDispatch(ctx context.Context, rideID uuid.UUID) error
+		// запрос поиска ушел в брокер: отметка снимается, поиск отсчитывается заново
+		MarkDispatched(ctx context.Context, rideID uuid.UUID) error
+		// отметка об уведомлении пассажира о приближении водителя, false — уже в нужном состоянии
+		SetApproachNotified(ctx context.Context, rideID uuid.UUID, notified bool) (bool, error)
+
+		// углеродный след завершенной поездки
+		SetFootprint(ctx context.Context, rideID uuid.UUID, distanceKm, co2Grams float64) error
+		GetMonthlyImpact(ctx context.Context, passengerID uuid.UUID, since time.Time) ([]models.MonthlyImpact, error)
+
+		// история поездок пассажира
+		History(ctx context.Context, f models.RideHistoryFilter, filters models.Filters) (*models.RideHistoryResponse, error)
+
+		// анонимная телеметрия качества приложения
+		SaveTelemetry(ctx context.Context, t models.AppTelemetry) error
+
+		// спрос и свободные водители в зоне посадки для надбавки за спрос
+		ZoneDemand(ctx context.Context, zone, rideType string, since time.Time) (models.ZoneDemand, error)
+
+		// оценка водителя после завершенной поездки
+		CreateRating(ctx context.Context, rating *models.RideRating) error
+		UpdateDriverRating(ctx context.Context, driverID uuid.UUID) (*models.DriverRating, error)
 	}
 
 	RideMsgBroker interface {
 		PublishRideRequested(ctx context.Context, msg models.RideRequestedMessage) error
 		PublishRideStatus(ctx context.Context, msg models.RideStatusUpdateMessage) error
-		ConsumeDriverResponse(ctx context.Context, rideID uuid.UUID, handler rabbit.DriverResponseHandler) error
+		ConsumeDriverResponses(ctx context.Context, handler rabbit.DriverResponseHandler) error
+	}
+
+	// RoadSnapper находит ближайшую точку на дороге, возвращает (longitude, latitude)
+	RoadSnapper interface {
+		NearestRoad(ctx context.Context, longitude, latitude float64) (float64, float64, error)
+	}
+
+	// CityRepo хранит операционные правила городов
+	CityRepo interface {
+		FindByLocation(ctx context.Context, location models.Location) (*models.CitySettings, error)
+		List(ctx context.Context) ([]models.CitySettings, error)
+	}
+
+	// FlatRateRepo хранит версии фиксированных тарифов маршрутов и минимальных тарифов зон
+	FlatRateRepo interface {
+		Active(ctx context.Context, at time.Time) ([]models.FlatRate, error)
+	}
+
+	// BlackoutRepo хранит окна приостановки подбора водителей
+	BlackoutRepo interface {
+		Active(ctx context.Context, at time.Time) ([]models.MatchingBlackout, error)
+	}
+
+	// WalletRepo хранит кошельки пассажиров и журнал операций
+	WalletRepo interface {
+		Get(ctx context.Context, passengerID uuid.UUID) (*models.Wallet, error)
+		Entries(ctx context.Context, passengerID uuid.UUID, limit int) ([]models.LedgerEntry, error)
+		TopUp(ctx context.Context, passengerID uuid.UUID, amount float64, transactionID string) (*models.Wallet, error)
+		Hold(ctx context.Context, passengerID, rideID uuid.UUID, amount float64) (bool, error)
+		OpenHold(ctx context.Context, rideID uuid.UUID) (float64, bool, error)
+		Capture(ctx context.Context, passengerID, rideID uuid.UUID, held, amount float64) (bool, error)
+		Release(ctx context.Context, passengerID, rideID uuid.UUID, held float64) error
+		RecordCardFallback(ctx context.Context, passengerID, rideID uuid.UUID, amount float64, transactionID *string) error
+		CreateHold(ctx context.Context, hold *models.PaymentHold) error
+		GetHold(ctx context.Context, rideID uuid.UUID) (*models.PaymentHold, error)
+		SettleHold(ctx context.Context, rideID uuid.UUID, status types.PaymentHoldStatus, capturedAmount *float64, transactionID, errMsg *string) error
+	}
+
+	// PaymentProvider проводит платежи с карты пассажира
+	// FiscalProvider регистрирует чек у оператора фискальных данных и возвращает ID фискального чека.
+	// Повторный вызов с тем же номером счета должен возвращать тот же чек: фискализация повторяется до успеха.
+	FiscalProvider interface {
+		Fiscalize(ctx context.Context, receipt models.Receipt) (string, error)
+	}
+
+	PaymentProvider interface {
+		Charge(ctx context.Context, userID uuid.UUID, amount float64) (string, error)
+		Refund(ctx context.Context, transactionID string, amount float64) error
+		// Authorize блокирует сумму на карте, списание — CaptureAuthorization в пределах блокировки
+		Authorize(ctx context.Context, userID uuid.UUID, amount float64) (string, error)
+		CaptureAuthorization(ctx context.Context, authorizationID string, userID uuid.UUID, amount float64) (string, error)
+		VoidAuthorization(ctx context.Context, authorizationID string) error
+	}
+
+	// PromoService применяет промокоды к стоимости поездки, вызывается внутри транзакции
+	PromoService interface {
+		Apply(ctx context.Context, code string, passengerID uuid.UUID, fare float64) (*models.PromoDiscount, error)
+		Redeem(ctx context.Context, d *models.PromoDiscount, rideID uuid.UUID) error
+		Release(ctx context.Context, rideID uuid.UUID) error
+	}
+
+	// OutboxRepo хранит сообщения брокера, записанные в транзакции изменения поездки
+	OutboxRepo interface {
+		Add(ctx context.Context, m *models.OutboxMessage) (bool, error)
+		GetPendingForUpdate(ctx context.Context, id uuid.UUID) (*models.OutboxMessage, error)
+		ListPendingForUpdate(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+		MarkPublished(ctx context.Context, id uuid.UUID) error
+		MarkFailed(ctx context.Context, id uuid.UUID, reason string, retryAfter time.Duration) error
+		Hold(ctx context.Context, id uuid.UUID, until time.Time) error
+		Discard(ctx context.Context, id uuid.UUID, reason string) error
+	}
+
+	// Notifier отправляет push/SMS/email с учетом настроек пользователя
+	Notifier interface {
+		Notify(ctx context.Context, n models.Notification) error
+	}
+
+	// CommunicationRepo - журнал коммуникаций поездки
+	CommunicationRepo interface {
+		Create(ctx context.Context, c *models.Communication) error
 	}
 
 	RideWsHandler interface {
@@ -0,0 +1,268 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// AdminCancel отменяет зависшую поездку по решению оператора в любом незавершенном статусе.
+// Пассажир не платит штраф, водитель освобождается driver-service по статусу CANCELLED.
+func (s *RideService) AdminCancel(ctx context.Context, rideID, adminID uuid.UUID, reason string) (*models.Ride, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "admin_cancel_ride")
+
+	ride, err := s.cancel(ctx, rideID, reason, types.RoleAdmin, adminCancelCheck)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Warn(ctx, "ride cancelled by admin", "admin_id", adminID.String(), "reason", reason)
+	return ride, nil
+}
+
+// AdminCancelDryRun выполняет отмену оператором в транзакции с откатом и возвращает ее последствия.
+// Статус CANCELLED не публикуется, пассажир и водитель не уведомляются.
+func (s *RideService) AdminCancelDryRun(ctx context.Context, rideID uuid.UUID, reason string) (*models.AdminEffects, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "admin_cancel_ride_dry_run")
+
+	var res *cancelled
+	if err := s.trm.DoRollbackOnly(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.cancelInTx(ctx, rideID, reason, types.RoleAdmin, adminCancelCheck)
+		return err
+	}); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	effects := interventionEffects("ride cancelled", res.ride, res.message.DriverID, types.EventRideCancelled)
+	s.logger.Info(ctx, "admin cancel dry run", "reason", reason)
+	return effects, nil
+}
+
+// Reassign снимает назначенного водителя с поездки до посадки пассажира и перезапускает поиск.
+// Снятый водитель исключается из поиска, driver-service освобождает его по статусу REQUESTED.
+func (s *RideService) Reassign(ctx context.Context, rideID, adminID uuid.UUID, reason string) (*models.Ride, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "admin_reassign_ride")
+
+	res, err := s.redispatch(ctx, rideID, types.RoleAdmin, reason, canBeReassigned)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	ride := res.ride
+
+	s.logger.Warn(ctx, "ride reassigned by admin",
+		"admin_id", adminID.String(),
+		"released_driver_id", res.releasedDriverID.String(),
+		"previous_status", res.previousStatus,
+		"reason", reason,
+	)
+
+	eventData, _ := json.Marshal(map[string]any{ // non fatal event so just ignore error
+		"status":             types.StatusRequested,
+		"previous_status":    res.previousStatus,
+		"released_driver_id": res.releasedDriverID,
+		"released_by":        adminID,
+		"reason":             reason,
+	})
+	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventStatusChanged, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventStatusChanged, "error", err.Error())
+	}
+
+	wsMessage := models.StatusUpdateWebSocketMessage{
+		EventType: types.EventStatusChanged,
+		Data:      res.status,
+	}
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
+		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
+	}
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Finding a new driver",
+		Body:   fmt.Sprintf("Support is assigning another driver to ride %s", ride.RideNumber),
+	})
+
+	// при недоступном брокере ожидание водителя запустит relay после отправки запроса
+	if res.dispatched {
+		s.awaitDriver(ctx, ride.ID, ride.PassengerID)
+	}
+
+	return ride, nil
+}
+
+// ReassignDryRun снимает водителя в транзакции с откатом и возвращает последствия замены.
+// Статус и новый запрос поиска (RideRequested) не публикуются, уведомления не отправляются.
+func (s *RideService) ReassignDryRun(ctx context.Context, rideID uuid.UUID, reason string) (*models.AdminEffects, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "admin_reassign_ride_dry_run")
+
+	var res *redispatched
+	if err := s.trm.DoRollbackOnly(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.redispatchInTx(ctx, rideID, types.RoleAdmin, reason, canBeReassigned)
+		return err
+	}); err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	effects := interventionEffects("driver released, matching restarted", res.ride, &res.releasedDriverID, types.EventStatusChanged)
+	s.logger.Info(ctx, "admin reassign dry run", "released_driver_id", res.releasedDriverID.String(), "reason", reason)
+	return effects, nil
+}
+
+// interventionEffects — последствия вмешательства оператора в поездку для dry run:
+// пассажир получает событие по WebSocket и push, снятый водитель — ride_released от driver-service
+func interventionEffects(result string, ride *models.Ride, driverID *uuid.UUID, event types.RideEvent) *models.AdminEffects {
+	effects := &models.AdminEffects{
+		DryRun:          true,
+		Result:          result,
+		AffectedRides:   []uuid.UUID{ride.ID},
+		AffectedDrivers: []uuid.UUID{},
+		Notifications: []models.PlannedNotification{
+			{RecipientID: ride.PassengerID, Channel: "websocket", Event: event.String()},
+			{RecipientID: ride.PassengerID, Channel: "push", Event: string(types.NotifyRideUpdates)},
+		},
+	}
+	if driverID != nil {
+		effects.AffectedDrivers = append(effects.AffectedDrivers, *driverID)
+		effects.Notifications = append(effects.Notifications, models.PlannedNotification{
+			RecipientID: *driverID, Channel: "websocket", Event: "ride_released",
+		})
+	}
+	return effects
+}
+
+// redispatched — поездка, с которой снят водитель и поиск которой перезапущен
+type redispatched struct {
+	ride             *models.Ride
+	previousStatus   string
+	releasedDriverID uuid.UUID
+	status           models.RideStatusUpdateMessage // статус REQUESTED, отправленный driver-service
+	dispatched       bool                           // false — запрос поиска ждет брокера в outbox
+
+	statusOutbox, requestOutbox *models.OutboxMessage
+}
+
+// redispatch снимает водителя с поездки, если allowed разрешает ее статус, и перезапускает поиск
+// без него. Иначе возвращает types.ErrRideCannotBeReassigned. Уведомления остаются вызывающему.
+func (s *RideService) redispatch(ctx context.Context, rideID uuid.UUID, by types.UserRole, reason string, allowed func(status string) bool) (*redispatched, error) {
+	var res *redispatched
+	err := s.trm.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.redispatchInTx(ctx, rideID, by, reason, allowed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// сначала освобождается водитель, затем запускается новый поиск
+	s.flushOutbox(ctx, res.statusOutbox)
+	res.dispatched = s.flushOutbox(ctx, res.requestOutbox)
+
+	return res, nil
+}
+
+// redispatchInTx снимает водителя в транзакции вызывающего и записывает в outbox статус REQUESTED
+// и новый запрос поиска. Ничего не публикует.
+func (s *RideService) redispatchInTx(ctx context.Context, rideID uuid.UUID, by types.UserRole, reason string, allowed func(status string) bool) (*redispatched, error) {
+	correlationID := wrap.GetRequestID(ctx)
+	if correlationID == "" {
+		correlationID = newCorrelationID()
+	}
+
+	ride, err := s.repo.Get(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, types.ErrRideNotFound
+		}
+		return nil, fmt.Errorf("could not find ride by id: %w", err)
+	}
+
+	if ride.DriverID == nil || !allowed(ride.Status) {
+		return nil, types.ErrRideCannotBeReassigned
+	}
+	res := &redispatched{
+		previousStatus:   ride.Status,
+		releasedDriverID: *ride.DriverID,
+	}
+
+	if err := s.repo.ReleaseDriver(ctx, ride.ID, res.releasedDriverID); err != nil {
+		if errors.Is(err, types.ErrInvalidRideStatus) {
+			return nil, types.ErrRideCannotBeReassigned
+		}
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	res.status = models.RideStatusUpdateMessage{
+		RideID:        ride.ID,
+		Status:        types.StatusRequested.String(),
+		Timestamp:     now,
+		DriverID:      &res.releasedDriverID,
+		CorrelationID: correlationID,
+		ReleasedBy:    by,
+		Reason:        reason,
+	}
+	if res.statusOutbox, err = s.enqueueRideStatus(ctx, &res.status); err != nil {
+		return nil, err
+	}
+
+	ride.Status = types.StatusRequested.String()
+	ride.DriverID = nil
+	ride.MatchedAt = nil
+	ride.Priority = s.calculate.Priority(ride)
+	res.ride = ride
+
+	request := newRideRequestedMessage(ride, correlationID)
+	request.ExcludedDriverIDs = []uuid.UUID{res.releasedDriverID}
+	if res.requestOutbox, err = s.enqueueRideRedispatch(ctx, &request, now); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// enqueueRideRedispatch записывает повторный запрос поиска водителя в outbox.
+// Ключ включает время, иначе driver-service отбросит запрос как повтор первого.
+func (s *RideService) enqueueRideRedispatch(ctx context.Context, msg *models.RideRequestedMessage, at time.Time) (*models.OutboxMessage, error) {
+	msg.MessageID = fmt.Sprintf("%s:%s:%d", types.OutboxRideRequested, msg.RideID, at.UnixMilli())
+	return s.enqueue(ctx, types.OutboxRideRequested, msg.MessageID, msg)
+}
+
+// adminCancelCheck — отмена оператором бесплатна в любом незавершенном статусе
+func adminCancelCheck(_ context.Context, ride *models.Ride, _ time.Time) (*float64, error) {
+	if !canBeCancelledByAdmin(ride.Status) {
+		return nil, types.ErrRideCannotBeCancelled
+	}
+	return nil, nil
+}
+
+// canBeCancelledByAdmin — оператор может отменить поездку в любом незавершенном статусе
+func canBeCancelledByAdmin(status string) bool {
+	switch status {
+	case types.StatusCompleted.String(), types.StatusCancelled.String():
+		return false
+	default:
+		return true
+	}
+}
+
+// canBeReassigned — водителя можно заменить, пока пассажир не сел в машину
+func canBeReassigned(status string) bool {
+	switch status {
+	case types.StatusMatched.String(),
+		types.StatusEnRoute.String(),
+		types.StatusArrived.String():
+		return true
+	default:
+		return false
+	}
+}
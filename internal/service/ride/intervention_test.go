@@ -0,0 +1,96 @@
+package ride
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type matchedRideRepo struct {
+	RideRepo
+	ride     models.Ride
+	released int
+}
+
+func (r *matchedRideRepo) Get(context.Context, uuid.UUID) (*models.Ride, error) {
+	ride := r.ride
+	return &ride, nil
+}
+
+func (r *matchedRideRepo) ReleaseDriver(context.Context, uuid.UUID, uuid.UUID) error {
+	r.released++
+	return nil
+}
+
+type addingOutbox struct {
+	OutboxRepo
+	added int
+}
+
+func (o *addingOutbox) Add(context.Context, *models.OutboxMessage) (bool, error) {
+	o.added++
+	return true, nil
+}
+
+// rollbackTxManager запоминает, что изменения откачены
+type rollbackTxManager struct {
+	inlineTxManager
+	rolledBack bool
+}
+
+func (m *rollbackTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.rolledBack = true
+	return fn(ctx)
+}
+
+type countingPassengerSender struct{ sent int }
+
+func (s *countingPassengerSender) SendToPassenger(context.Context, uuid.UUID, any) error {
+	s.sent++
+	return nil
+}
+
+func TestReassignDryRun_PublishesNothing(t *testing.T) {
+	driverID := uuid.New()
+	repo := &matchedRideRepo{ride: models.Ride{
+		ID: uuid.New(), PassengerID: uuid.New(), DriverID: &driverID,
+		Status: types.StatusEnRoute.String(), RideType: string(types.ClassEconomy),
+	}}
+	outbox := &addingOutbox{}
+	publisher := &countingPublisher{}
+	sender := &countingPassengerSender{}
+	trm := &rollbackTxManager{}
+
+	s := &RideService{
+		repo: repo, outbox: outbox, publisher: publisher, passengerSender: sender, trm: trm,
+		calculate: ridecalc.New(), replies: newReplyDispatcher(), clock: clock.NewFake(time.Now()), logger: logger.InitLogger("test", "error"),
+	}
+
+	effects, err := s.ReassignDryRun(context.Background(), repo.ride.ID, "driver unreachable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !trm.rolledBack || repo.released != 1 || outbox.added != 2 {
+		t.Fatalf("reassign must run in a rolled back transaction: rolled back %v, released %d, outbox %d", trm.rolledBack, repo.released, outbox.added)
+	}
+	if publisher.requested != 0 || sender.sent != 0 {
+		t.Fatalf("dry run published %d requests and sent %d notifications", publisher.requested, sender.sent)
+	}
+	if !effects.DryRun || len(effects.AffectedDrivers) != 1 || effects.AffectedDrivers[0] != driverID || len(effects.Notifications) != 3 {
+		t.Fatalf("unexpected effects: %+v", effects)
+	}
+
+	// статус, недоступный для замены, проверяется и в dry run
+	repo.ride.Status = types.StatusInProgress.String()
+	if _, err := s.ReassignDryRun(context.Background(), repo.ride.ID, "driver unreachable"); err == nil {
+		t.Fatal("expected an error for a ride in progress")
+	}
+}
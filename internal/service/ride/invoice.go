@@ -0,0 +1,86 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// InvoiceOptions — нумерация счетов в чеках поездок
+type InvoiceOptions struct {
+	// DefaultLegalEntity — юрлицо для поездок вне городов и городов без своего юрлица
+	DefaultLegalEntity string
+}
+
+// fiscalReceiptMessage — запрос фискализации чека в outbox
+type fiscalReceiptMessage struct {
+	RideID uuid.UUID `json:"ride_id"`
+}
+
+// legalEntity возвращает юрлицо, выдающее чек поездки: юрлицо города точки посадки или по умолчанию
+func (s *RideService) legalEntity(ctx context.Context, pickup models.Location) (string, error) {
+	if s.cities == nil {
+		return s.invoice.DefaultLegalEntity, nil
+	}
+
+	city, err := s.cities.FindByLocation(ctx, pickup)
+	if err != nil {
+		return "", err
+	}
+	if city == nil || city.LegalEntity == "" {
+		return s.invoice.DefaultLegalEntity, nil
+	}
+	return city.LegalEntity, nil
+}
+
+// enqueueFiscalReceipt записывает фискализацию чека в outbox транзакции завершения поездки.
+// Фискализация внешняя и может быть недоступна, relay повторяет ее с нарастающей паузой.
+func (s *RideService) enqueueFiscalReceipt(ctx context.Context, rideID uuid.UUID) error {
+	if s.fiscal == nil {
+		return nil
+	}
+	msg := fiscalReceiptMessage{RideID: rideID}
+	_, err := s.enqueue(ctx, types.OutboxFiscalReceipt, fmt.Sprintf("%s:%s", types.OutboxFiscalReceipt, rideID), msg)
+	return err
+}
+
+// fiscalize регистрирует чек у провайдера фискализации и сохраняет ID фискального чека
+func (s *RideService) fiscalize(ctx context.Context, payload json.RawMessage) error {
+	var msg fiscalReceiptMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("%w: %w", errMalformedOutbox, err)
+	}
+	// провайдер отключен после записи сообщения — повторять бессмысленно
+	if s.fiscal == nil {
+		return fmt.Errorf("%w: fiscal provider is not configured", errMalformedOutbox)
+	}
+
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, msg.RideID.String()), "fiscalize_receipt")
+
+	receipt, err := s.repo.GetReceipt(ctx, msg.RideID)
+	if err != nil {
+		return err
+	}
+	if receipt.FiscalReceiptID != nil {
+		return nil
+	}
+
+	fiscalID, err := s.fiscal.Fiscalize(ctx, *receipt)
+	if err != nil {
+		metrics.FiscalReceiptsTotal.WithLabelValues("failed").Inc()
+		return fmt.Errorf("fiscalize receipt %s: %w", receipt.InvoiceNumber, err)
+	}
+	if err := s.repo.SetFiscalReceipt(ctx, msg.RideID, fiscalID); err != nil {
+		return err
+	}
+
+	metrics.FiscalReceiptsTotal.WithLabelValues("success").Inc()
+	s.logger.Info(ctx, "receipt fiscalized", "invoice_number", receipt.InvoiceNumber, "fiscal_receipt_id", fiscalID)
+	return nil
+}
@@ -0,0 +1,313 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+const (
+	// outboxRelayBatch — сколько сообщений outbox публикуется за один проход
+	outboxRelayBatch = 50
+	// outboxMaxBackoff — максимальная пауза перед повторной публикацией сообщения
+	outboxMaxBackoff = time.Minute
+)
+
+// errMalformedOutbox — сообщение outbox невозможно опубликовать, повторять бессмысленно
+var errMalformedOutbox = errors.New("malformed outbox message")
+
+// enqueueRideRequested записывает запрос поиска водителя в outbox транзакции создания поездки
+func (s *RideService) enqueueRideRequested(ctx context.Context, msg *models.RideRequestedMessage) (*models.OutboxMessage, error) {
+	msg.MessageID = fmt.Sprintf("%s:%s", types.OutboxRideRequested, msg.RideID)
+	return s.enqueue(ctx, types.OutboxRideRequested, msg.MessageID, msg)
+}
+
+// enqueueRideStatus записывает смену статуса поездки в outbox текущей транзакции.
+// Поездка может вернуться в статус повторно (водитель снят), поэтому ключ включает время смены.
+func (s *RideService) enqueueRideStatus(ctx context.Context, msg *models.RideStatusUpdateMessage) (*models.OutboxMessage, error) {
+	msg.MessageID = fmt.Sprintf("%s:%s:%s:%d", types.OutboxRideStatus, msg.RideID, msg.Status, msg.Timestamp.UnixMilli())
+	return s.enqueue(ctx, types.OutboxRideStatus, msg.MessageID, msg)
+}
+
+// enqueue возвращает nil, если сообщение с таким ключом уже записано
+func (s *RideService) enqueue(ctx context.Context, topic types.OutboxTopic, key string, payload any) (*models.OutboxMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal outbox message: %w", err)
+	}
+
+	m := &models.OutboxMessage{
+		IdempotencyKey: key,
+		Topic:          topic,
+		Payload:        data,
+	}
+	created, err := s.outbox.Add(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+
+	return m, nil
+}
+
+// flushOutbox публикует сообщение сразу после commit, не дожидаясь relay.
+// false — сообщение сейчас не опубликовано, его отправит relay.
+func (s *RideService) flushOutbox(ctx context.Context, m *models.OutboxMessage) bool {
+	if m == nil {
+		return false
+	}
+
+	var published bool
+	if err := s.trm.Do(ctx, func(ctx context.Context) error {
+		// nil — сообщение уже забрал relay
+		pending, err := s.outbox.GetPendingForUpdate(ctx, m.ID)
+		if err != nil || pending == nil {
+			return err
+		}
+
+		published, err = s.publishOutbox(ctx, pending)
+		return err
+	}); err != nil {
+		s.logger.Warn(ctx, "failed to flush outbox message", "topic", m.Topic, "key", m.IdempotencyKey, "error", err.Error())
+		return false
+	}
+
+	return published
+}
+
+// publishOutbox публикует заблокированное сообщение и записывает результат в той же транзакции.
+// Ошибка брокера не прерывает транзакцию: попытка записывается, сообщение ждет следующей.
+func (s *RideService) publishOutbox(ctx context.Context, m *models.OutboxMessage) (bool, error) {
+	err := s.publish(ctx, m)
+	switch {
+	case err == nil:
+		return true, s.outbox.MarkPublished(ctx, m.ID)
+	case errors.Is(err, errMalformedOutbox):
+		s.logger.Error(ctx, "discarding outbox message", err, "topic", m.Topic, "key", m.IdempotencyKey)
+		return false, s.outbox.Discard(ctx, m.ID, err.Error())
+	default:
+		s.logger.Warn(ctx, "failed to publish outbox message", "topic", m.Topic, "key", m.IdempotencyKey, "attempts", m.Attempts+1, "error", err.Error())
+		return false, s.outbox.MarkFailed(ctx, m.ID, err.Error(), outboxBackoff(m.Attempts))
+	}
+}
+
+func (s *RideService) publish(ctx context.Context, m *models.OutboxMessage) error {
+	switch m.Topic {
+	case types.OutboxRideRequested:
+		var msg models.RideRequestedMessage
+		if err := json.Unmarshal(m.Payload, &msg); err != nil {
+			return fmt.Errorf("%w: %w", errMalformedOutbox, err)
+		}
+		if err := s.publisher.PublishRideRequested(ctx, msg); err != nil {
+			return err
+		}
+		// поездка больше не ждет отправки, сверка отсчитывает поиск от этого момента;
+		// ошибка откатит проход, и сообщение уйдет повторно
+		return s.repo.MarkDispatched(ctx, msg.RideID)
+	case types.OutboxRideStatus:
+		var msg models.RideStatusUpdateMessage
+		if err := json.Unmarshal(m.Payload, &msg); err != nil {
+			return fmt.Errorf("%w: %w", errMalformedOutbox, err)
+		}
+		return s.publisher.PublishRideStatus(ctx, msg)
+	case types.OutboxFiscalReceipt:
+		return s.fiscalize(ctx, m.Payload)
+	case types.OutboxCardCharge:
+		return s.retryCardCharge(ctx, m.Payload)
+	case types.OutboxCardVoid:
+		return s.voidCancelledCardHold(ctx, m.Payload)
+	default:
+		return fmt.Errorf("%w: unknown topic %q", errMalformedOutbox, m.Topic)
+	}
+}
+
+// outboxBackoff удваивает паузу с каждой неудачной попыткой: 1s, 2s, 4s ... до outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	if attempts > 6 {
+		return outboxMaxBackoff
+	}
+	return min(time.Second<<attempts, outboxMaxBackoff)
+}
+
+// RunOutboxRelay периодически публикует сообщения outbox, которые не удалось отправить сразу после commit.
+// Запросы поиска водителя, ждущие дольше timeout, снимаются с отправки, а поездки отменяются.
+func (s *RideService) RunOutboxRelay(ctx context.Context, interval, timeout time.Duration) {
+	if interval <= 0 {
+		s.logger.Warn(ctx, "outbox relay disabled", "interval", interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RelayOutbox(ctx, timeout); err != nil {
+			s.logger.Warn(ctx, "failed to relay outbox", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOutbox публикует ждущие сообщения по порядку создания. Строки заблокированы до конца
+// прохода, поэтому параллельные relay других экземпляров их пропускают. Первая ошибка брокера
+// прерывает проход: остальные сообщения дождутся следующего.
+func (s *RideService) RelayOutbox(ctx context.Context, timeout time.Duration) error {
+	ctx = wrap.WithAction(ctx, "relay_outbox")
+
+	var dispatched []models.RideRequestedMessage
+	var expired []*models.Ride
+	var delayed []*models.Ride
+
+	now := s.clock.Now()
+	blackouts := s.activeBlackouts(ctx, now)
+
+	if err := s.trm.Do(ctx, func(ctx context.Context) error {
+		messages, err := s.outbox.ListPendingForUpdate(ctx, outboxRelayBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range messages {
+			var req models.RideRequestedMessage
+			if m.Topic == types.OutboxRideRequested {
+				if err := json.Unmarshal(m.Payload, &req); err != nil {
+					if err := s.outbox.Discard(ctx, m.ID, err.Error()); err != nil {
+						return err
+					}
+					continue
+				}
+
+				ride, err := s.repo.Get(ctx, req.RideID)
+				if err != nil && !errors.Is(err, types.ErrRideNotFound) {
+					return err
+				}
+				// поездку отменили, пока запрос ждал брокера
+				if ride == nil || ride.Status != types.StatusRequested.String() {
+					if err := s.outbox.Discard(ctx, m.ID, "ride is no longer requested"); err != nil {
+						return err
+					}
+					continue
+				}
+				// подбор в зоне приостановлен после создания поездки или окно еще не закончилось
+				if blackout := s.matchingBlackout(blackouts, ride.RideType, ride.Pickup, now); blackout != nil {
+					if err := s.holdDispatch(ctx, m, blackout, now); err != nil {
+						return err
+					}
+					// о задержке пассажир узнает один раз, при первом удержании запроса
+					if m.HeldUntil == nil {
+						ride.MatchingDelay = newMatchingDelay(ride, blackout, now)
+						delayed = append(delayed, ride)
+					}
+					continue
+				}
+				// время в окне приостановки не считается ожиданием брокера
+				if timeout > 0 && s.clock.Now().Sub(m.WaitingSince()) > timeout {
+					if err := s.outbox.Discard(ctx, m.ID, "dispatch timeout"); err != nil {
+						return err
+					}
+					expired = append(expired, ride)
+					continue
+				}
+			}
+
+			published, err := s.publishOutbox(ctx, m)
+			if err != nil {
+				return err
+			}
+			// недоступные провайдеры фискализации и платежей не задерживают сообщения брокера
+			if !published && (m.Topic == types.OutboxFiscalReceipt || m.Topic == types.OutboxCardCharge) {
+				continue
+			}
+			if !published {
+				break
+			}
+
+			if m.Topic == types.OutboxRideRequested {
+				dispatched = append(dispatched, req)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	for _, ride := range expired {
+		s.expirePendingDispatch(ctx, ride)
+	}
+	for _, ride := range delayed {
+		s.onMatchingDelayed(ctx, ride)
+	}
+	for _, msg := range dispatched {
+		s.onDispatched(ctx, msg)
+	}
+
+	return nil
+}
+
+// onDispatched сообщает пассажиру, что поиск водителя начался, и ждет ответа водителя
+func (s *RideService) onDispatched(ctx context.Context, msg models.RideRequestedMessage) {
+	ctx = wrap.WithPassengerID(wrap.WithRideID(ctx, msg.RideID.String()), msg.PassengerID.String())
+
+	s.logger.Info(ctx, "pending ride dispatched")
+
+	eventData, _ := json.Marshal(msg)
+	if err := s.eventRepo.CreateEvent(ctx, msg.RideID, types.EventRideRequested, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventRideRequested, "error", err.Error())
+	}
+
+	wsMessage := models.StatusUpdateWebSocketMessage{
+		EventType: types.EventRideRequested,
+		Data:      msg,
+	}
+	if err := s.sendToPassenger(ctx, msg.RideID, msg.PassengerID, wsMessage); err != nil {
+		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
+	}
+
+	s.awaitDriver(ctx, msg.RideID, msg.PassengerID)
+}
+
+// onMatchingDelayed сообщает пассажиру, что поиск водителя отложен до окончания окна приостановки подбора
+func (s *RideService) onMatchingDelayed(ctx context.Context, ride *models.Ride) {
+	ctx = wrap.WithPassengerID(wrap.WithRideID(ctx, ride.ID.String()), ride.PassengerID.String())
+
+	s.logger.Info(ctx, "matching paused in pickup zone, dispatch delayed", "until", ride.MatchingDelay.Until, "reason", ride.MatchingDelay.Reason)
+
+	eventData, _ := json.Marshal(ride.MatchingDelay)
+	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventMatchingDelayed, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventMatchingDelayed, "error", err.Error())
+	}
+
+	wsMessage := models.StatusUpdateWebSocketMessage{
+		EventType: types.EventMatchingDelayed,
+		Data:      ride.MatchingDelay,
+	}
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
+		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
+	}
+}
+
+// expirePendingDispatch отменяет поездку, которую так и не удалось отправить на поиск водителя
+func (s *RideService) expirePendingDispatch(ctx context.Context, ride *models.Ride) {
+	ctx = wrap.WithRideID(ctx, ride.ID.String())
+
+	if _, err := s.Cancel(ctx, ride.ID, ride.PassengerID, "driver search is temporarily unavailable"); err != nil {
+		s.logger.Warn(ctx, "failed to cancel expired pending ride", "error", err.Error())
+		return
+	}
+
+	s.logger.Warn(ctx, "pending ride cancelled, broker stayed unavailable", "waited", s.clock.Now().Sub(ride.CreatedAt).String())
+}
@@ -0,0 +1,99 @@
+package ride
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+const (
+	// maxSnapDistanceKm — если ближайшая дорога дальше, точку посадки не меняем
+	maxSnapDistanceKm = 0.2
+	// minSnapDistanceKm — смещения меньше не считаются корректировкой
+	minSnapDistanceKm = 0.005
+	// snapTimeout — сколько создание поездки ждет роутинг; дольше — предлагается исходная точка
+	snapTimeout = time.Second
+)
+
+// SuggestPickup притягивает точку посадки к ближайшей доступной для машины точке.
+// Ошибки роутинга не фатальны: в этом случае предлагается исходная точка.
+func (s *RideService) SuggestPickup(ctx context.Context, pickup models.Location) models.PickupSuggestion {
+	suggestion := models.PickupSuggestion{
+		Original:  pickup,
+		Suggested: pickup,
+	}
+
+	if s.snapper == nil {
+		return suggestion
+	}
+
+	snapCtx, cancel := context.WithTimeout(ctx, snapTimeout)
+	defer cancel()
+
+	lon, lat, err := s.snapper.NearestRoad(snapCtx, pickup.Longitude, pickup.Latitude)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to snap pickup to road", "error", err.Error())
+		return suggestion
+	}
+
+	snapped := models.Location{
+		Latitude:  lat,
+		Longitude: lon,
+		Address:   pickup.Address,
+	}
+
+	distance := s.calculate.Distance(pickup, snapped)
+	if distance < minSnapDistanceKm || distance > maxSnapDistanceKm {
+		return suggestion
+	}
+
+	suggestion.Suggested = snapped
+	suggestion.Snapped = true
+	suggestion.DistanceM = distance * 1000
+
+	return suggestion
+}
+
+// Estimate рассчитывает стоимость, длительность и точку посадки без создания поездки
+func (s *RideService) Estimate(ctx context.Context, ride *models.Ride) (*models.RideEstimate, error) {
+	ctx = wrap.WithAction(ctx, "estimate_ride")
+
+	if ride == nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("ride is nil"))
+	}
+
+	suggestion := s.SuggestPickup(ctx, ride.Pickup)
+
+	distance := s.calculate.Distance(suggestion.Suggested, ride.Destination)
+	duration := s.calculate.Duration(distance)
+
+	now := s.clock.Now()
+	surge := s.surgeMultiplier(ctx, ride.RideType, suggestion.Suggested, now)
+
+	fare, multiplier, err := s.cityFare(ctx, suggestion.Suggested, surgeFare(s.calculate.Fare(ride.RideType, distance, duration).Total, surge), now)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	fare, flatRate := s.flatFare(ctx, s.activeFlatRates(ctx, now), ride.RideType, suggestion.Suggested, ride.Destination, fare, now)
+	var flatCode string
+	if flatRate != nil {
+		flatCode = flatRate.Code
+	}
+	if isFixedFare(flatRate) {
+		multiplier, surge = 1, 1
+	}
+
+	return &models.RideEstimate{
+		Pickup:               suggestion,
+		RideType:             ride.RideType,
+		EstimatedFare:        fare,
+		FareMultiplier:       multiplier,
+		SurgeMultiplier:      surge,
+		FlatRate:             flatCode,
+		EstimatedDurationMin: duration,
+		EstimatedDistanceKm:  distance,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package ride
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+)
+
+// stalledSnapper не отвечает, пока не отменят контекст
+type stalledSnapper struct{}
+
+func (stalledSnapper) NearestRoad(ctx context.Context, _, _ float64) (float64, float64, error) {
+	<-ctx.Done()
+	return 0, 0, ctx.Err()
+}
+
+func TestSuggestPickup_StalledRoutingFallsBack(t *testing.T) {
+	s := &RideService{snapper: stalledSnapper{}, calculate: ridecalc.New(), logger: logger.InitLogger("test", "error")}
+	pickup := models.Location{Latitude: 43.238949, Longitude: 76.945465, Address: "Abay Ave 10"}
+
+	start := time.Now()
+	got := s.SuggestPickup(context.Background(), pickup)
+
+	if elapsed := time.Since(start); elapsed > 2*snapTimeout {
+		t.Fatalf("ride creation waited %s for routing", elapsed)
+	}
+	if got.Snapped || got.Suggested != pickup {
+		t.Fatalf("expected the original pickup, got %+v", got)
+	}
+}
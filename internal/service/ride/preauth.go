@@ -0,0 +1,249 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// PaymentOptions — настройки блокировки оплаты при создании поездки
+type PaymentOptions struct {
+	// PreAuthBuffer — запас предавторизации карты сверх расчетной стоимости (0.2 — +20%),
+	// покрывает рост финальной стоимости, списание не может превысить блокировку
+	PreAuthBuffer float64
+}
+
+// preAuthAmount — сумма предавторизации карты с запасом, округленная до копеек
+func (s *RideService) preAuthAmount(fare float64) float64 {
+	return math.Round(fare*(1+max(s.payment.PreAuthBuffer, 0))*100) / 100
+}
+
+// authorizeCard блокирует расчетную стоимость с запасом на карте пассажира.
+// Вызывается после транзакции создания поездки: это внешний вызов. Отказ провайдера не отменяет
+// поездку, блокировка записывается со статусом FAILED.
+func (s *RideService) authorizeCard(ctx context.Context, ride *models.Ride) {
+	if s.payments == nil || ride.PaymentMethod != types.PaymentCard {
+		return
+	}
+
+	hold := &models.PaymentHold{
+		RideID:        ride.ID,
+		PassengerID:   ride.PassengerID,
+		PaymentMethod: types.PaymentCard,
+		Amount:        s.preAuthAmount(ride.EstimatedFare),
+		Status:        types.HoldAuthorized,
+	}
+
+	authID, err := s.payments.Authorize(ctx, ride.PassengerID, hold.Amount)
+	if err != nil {
+		s.logger.Warn(ctx, "card pre-authorization declined", "amount", hold.Amount, "error", err.Error())
+		msg := err.Error()
+		hold.Status = types.HoldFailed
+		hold.Error = &msg
+	} else {
+		hold.AuthorizationID = &authID
+	}
+
+	if err := s.wallets.CreateHold(ctx, hold); err != nil {
+		s.logger.Error(ctx, "failed to record card pre-authorization", err)
+		// без записи блокировку нечем снять при завершении, поэтому возвращаем ее сразу
+		if hold.AuthorizationID != nil {
+			if err := s.payments.VoidAuthorization(ctx, authID); err != nil {
+				s.logger.Error(ctx, "failed to void unrecorded card pre-authorization", err, "authorization_id", authID)
+			}
+		}
+		return
+	}
+
+	if hold.Status == types.HoldAuthorized {
+		s.logger.Info(ctx, "card pre-authorized", "amount", hold.Amount, "authorization_id", authID)
+	}
+}
+
+// openCardHold возвращает действующую предавторизацию карты поездки или nil
+func (s *RideService) openCardHold(ctx context.Context, ride *models.Ride) (*models.PaymentHold, error) {
+	if s.payments == nil {
+		return nil, nil
+	}
+
+	hold, err := s.wallets.GetHold(ctx, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil || hold.PaymentMethod != types.PaymentCard || hold.Status != types.HoldAuthorized || hold.AuthorizationID == nil {
+		return nil, nil
+	}
+	return hold, nil
+}
+
+// voidCardHold снимает предавторизацию карты отмененной поездки
+func (s *RideService) voidCardHold(ctx context.Context, ride *models.Ride) {
+	hold, err := s.openCardHold(ctx, ride)
+	if err != nil || hold == nil {
+		if err != nil {
+			s.logger.Error(ctx, "failed to get card pre-authorization", err)
+		}
+		return
+	}
+
+	if err := s.payments.VoidAuthorization(ctx, *hold.AuthorizationID); err != nil {
+		s.logger.Error(ctx, "failed to void card pre-authorization", err, "authorization_id", *hold.AuthorizationID)
+		msg := err.Error()
+		if err := s.wallets.SettleHold(ctx, ride.ID, types.HoldFailed, nil, nil, &msg); err != nil {
+			s.logger.Error(ctx, "failed to record card pre-authorization failure", err)
+		}
+		return
+	}
+
+	if err := s.wallets.SettleHold(ctx, ride.ID, types.HoldReleased, nil, nil, nil); err != nil {
+		s.logger.Error(ctx, "failed to record released card pre-authorization", err)
+		return
+	}
+	s.logger.Info(ctx, "card pre-authorization released", "amount", hold.Amount)
+}
+
+// captureCardHold списывает финальную стоимость в пределах предавторизации.
+// Если стоимость превысила блокировку с запасом, разница списывается отдельным платежом.
+// Без действующей предавторизации (отклонена при заказе, истекла) или при ошибке списания
+// вся стоимость списывается платежом, неудавшийся платеж повторяет relay outbox.
+func (s *RideService) captureCardHold(ctx context.Context, ride *models.Ride, fare float64) {
+	if s.payments == nil {
+		return
+	}
+
+	hold, err := s.openCardHold(ctx, ride)
+	if err != nil {
+		s.logger.Error(ctx, "failed to get card pre-authorization", err)
+	}
+	if hold == nil {
+		s.logger.Warn(ctx, "no card pre-authorization, charging the fare", "amount", fare)
+		s.chargeFare(ctx, ride, fare)
+		return
+	}
+
+	captured := min(fare, hold.Amount)
+	txID, err := s.payments.CaptureAuthorization(ctx, *hold.AuthorizationID, ride.PassengerID, captured)
+	if err != nil {
+		s.logger.Error(ctx, "failed to capture card pre-authorization, charging the fare", err, "authorization_id", *hold.AuthorizationID)
+		msg := err.Error()
+		if err := s.wallets.SettleHold(ctx, ride.ID, types.HoldFailed, nil, nil, &msg); err != nil {
+			s.logger.Error(ctx, "failed to record card capture failure", err)
+		}
+		s.chargeFare(ctx, ride, fare)
+		return
+	}
+
+	if err := s.wallets.SettleHold(ctx, ride.ID, types.HoldCaptured, &captured, &txID, nil); err != nil {
+		s.logger.Error(ctx, "failed to record card capture", err, "transaction_id", txID)
+	}
+	s.logger.Info(ctx, "card pre-authorization captured", "amount", captured, "authorized", hold.Amount, "transaction_id", txID)
+
+	if extra := math.Round((fare-captured)*100) / 100; extra > 0 {
+		s.logger.Warn(ctx, "fare exceeded card pre-authorization, charging the difference", "amount", extra)
+		s.chargeFare(ctx, ride, extra)
+	}
+}
+
+// cardChargeMessage — списание с карты в outbox, которое не прошло сразу
+type cardChargeMessage struct {
+	RideID      uuid.UUID `json:"ride_id"`
+	PassengerID uuid.UUID `json:"passenger_id"`
+	Amount      float64   `json:"amount"`
+}
+
+// chargeFare списывает amount с карты пассажира, неудавшееся списание повторяется через outbox
+func (s *RideService) chargeFare(ctx context.Context, ride *models.Ride, amount float64) {
+	txID, err := s.payments.Charge(ctx, ride.PassengerID, amount)
+	if err == nil {
+		s.logger.Info(ctx, "card charged", "amount", amount, "transaction_id", txID)
+		return
+	}
+
+	s.logger.Error(ctx, "failed to charge card, retry scheduled", err, "amount", amount)
+	s.scheduleCardCharge(ctx, ride, amount)
+}
+
+// scheduleCardCharge записывает неудавшееся списание в outbox, relay повторяет его с нарастающей паузой.
+// Попытки и последняя ошибка списания видны в outbox.
+func (s *RideService) scheduleCardCharge(ctx context.Context, ride *models.Ride, amount float64) {
+	msg := cardChargeMessage{RideID: ride.ID, PassengerID: ride.PassengerID, Amount: amount}
+	if _, err := s.enqueue(ctx, types.OutboxCardCharge, fmt.Sprintf("%s:%s", types.OutboxCardCharge, ride.ID), msg); err != nil {
+		s.logger.Error(ctx, "failed to schedule card charge retry", err, "amount", amount)
+	}
+}
+
+// retryCardCharge повторяет списание с карты из outbox
+func (s *RideService) retryCardCharge(ctx context.Context, payload json.RawMessage) error {
+	var msg cardChargeMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("%w: %w", errMalformedOutbox, err)
+	}
+	// провайдер отключен после записи сообщения — повторять бессмысленно
+	if s.payments == nil {
+		return fmt.Errorf("%w: payment provider is not configured", errMalformedOutbox)
+	}
+
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, msg.RideID.String()), "retry_card_charge")
+
+	txID, err := s.payments.Charge(ctx, msg.PassengerID, msg.Amount)
+	if err != nil {
+		return fmt.Errorf("charge card: %w", err)
+	}
+	s.logger.Info(ctx, "card charged on retry", "amount", msg.Amount, "transaction_id", txID)
+	return nil
+}
+
+// cardVoidMessage — снятие предавторизации поездки, отмененной в обход ride-service (устранение аномалии)
+type cardVoidMessage struct {
+	RideID uuid.UUID `json:"ride_id"`
+}
+
+// voidCancelledCardHold снимает предавторизацию карты поездки из outbox
+func (s *RideService) voidCancelledCardHold(ctx context.Context, payload json.RawMessage) error {
+	var msg cardVoidMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("%w: %w", errMalformedOutbox, err)
+	}
+
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, msg.RideID.String()), "void_card_hold")
+	s.voidCardHold(ctx, &models.Ride{ID: msg.RideID})
+	return nil
+}
+
+// Payment возвращает способ оплаты поездки и состояние блокировки, доступно только пассажиру поездки
+func (s *RideService) Payment(ctx context.Context, rideID, passengerID uuid.UUID) (*models.RidePayment, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "get_ride_payment")
+
+	ride, err := s.repo.Get(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, wrap.Error(ctx, types.ErrRideNotFound)
+		}
+		return nil, wrap.Error(ctx, fmt.Errorf("could not find ride by id: %w", err))
+	}
+	if ride.PassengerID != passengerID {
+		return nil, wrap.Error(ctx, authSvc.ErrActionForbidden)
+	}
+
+	hold, err := s.wallets.GetHold(ctx, rideID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	return &models.RidePayment{
+		RideID:        ride.ID,
+		Status:        ride.Status,
+		PaymentMethod: ride.PaymentMethod,
+		EstimatedFare: ride.EstimatedFare,
+		FinalFare:     ride.FinalFare,
+		Hold:          hold,
+	}, nil
+}
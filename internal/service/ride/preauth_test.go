@@ -0,0 +1,114 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// fakePayments считает списания; captureErr и chargeErr — отказы провайдера
+type fakePayments struct {
+	PaymentProvider
+	captureErr, chargeErr error
+	captured, charged     float64
+}
+
+func (p *fakePayments) CaptureAuthorization(_ context.Context, _ string, _ uuid.UUID, amount float64) (string, error) {
+	if p.captureErr != nil {
+		return "", p.captureErr
+	}
+	p.captured += amount
+	return "tx-capture", nil
+}
+
+func (p *fakePayments) Charge(_ context.Context, _ uuid.UUID, amount float64) (string, error) {
+	if p.chargeErr != nil {
+		return "", p.chargeErr
+	}
+	p.charged += amount
+	return "tx-charge", nil
+}
+
+type cardHolds struct {
+	WalletRepo
+	hold *models.PaymentHold
+}
+
+func (w *cardHolds) GetHold(context.Context, uuid.UUID) (*models.PaymentHold, error) {
+	return w.hold, nil
+}
+
+func (w *cardHolds) SettleHold(_ context.Context, _ uuid.UUID, status types.PaymentHoldStatus, _ *float64, _, _ *string) error {
+	w.hold.Status = status
+	return nil
+}
+
+// Поездка по карте оплачивается и без действующей предавторизации, неудавшийся платеж повторяется
+func TestCaptureCardHold_FallsBackToCharge(t *testing.T) {
+	authID := "auth-1"
+	authorized := func() *models.PaymentHold {
+		return &models.PaymentHold{PaymentMethod: types.PaymentCard, Amount: 1200, Status: types.HoldAuthorized, AuthorizationID: &authID}
+	}
+
+	tests := []struct {
+		name         string
+		hold         *models.PaymentHold
+		payments     *fakePayments
+		wantCaptured float64
+		wantCharged  float64
+		wantRetries  int
+	}{
+		{"captured within the hold", authorized(), &fakePayments{}, 1000, 0, 0},
+		{"no hold", nil, &fakePayments{}, 0, 1000, 0},
+		{"declined pre-authorization", &models.PaymentHold{PaymentMethod: types.PaymentCard, Amount: 1200, Status: types.HoldFailed}, &fakePayments{}, 0, 1000, 0},
+		{"capture failed", authorized(), &fakePayments{captureErr: errors.New("authorization expired")}, 0, 1000, 0},
+		{"charge failed", nil, &fakePayments{chargeErr: errors.New("card declined")}, 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &addingOutbox{}
+			s := &RideService{wallets: &cardHolds{hold: tt.hold}, payments: tt.payments, outbox: outbox, logger: logger.InitLogger("test", "error")}
+			ride := &models.Ride{ID: uuid.New(), PassengerID: uuid.New(), PaymentMethod: types.PaymentCard}
+
+			s.captureCardHold(context.Background(), ride, 1000)
+
+			if tt.payments.captured != tt.wantCaptured || tt.payments.charged != tt.wantCharged || outbox.added != tt.wantRetries {
+				t.Fatalf("captured %v, charged %v, retries %d; want %v, %v, %d",
+					tt.payments.captured, tt.payments.charged, outbox.added, tt.wantCaptured, tt.wantCharged, tt.wantRetries)
+			}
+		})
+	}
+}
+
+type voidingPayments struct {
+	PaymentProvider
+	voided []string
+}
+
+func (p *voidingPayments) VoidAuthorization(_ context.Context, authorizationID string) error {
+	p.voided = append(p.voided, authorizationID)
+	return nil
+}
+
+// Предавторизация поездки, отмененной устранением аномалии, снимается relay outbox
+func TestPublishOutbox_VoidsCancelledCardHold(t *testing.T) {
+	authID := "auth-1"
+	holds := &cardHolds{hold: &models.PaymentHold{PaymentMethod: types.PaymentCard, Amount: 1200, Status: types.HoldAuthorized, AuthorizationID: &authID}}
+	payments := &voidingPayments{}
+	s := &RideService{wallets: holds, payments: payments, logger: logger.InitLogger("test", "error")}
+
+	payload, _ := json.Marshal(cardVoidMessage{RideID: uuid.New()})
+	if err := s.publish(context.Background(), &models.OutboxMessage{Topic: types.OutboxCardVoid, Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	if len(payments.voided) != 1 || holds.hold.Status != types.HoldReleased {
+		t.Fatalf("voided %v, hold %s; want [%s], RELEASED", payments.voided, holds.hold.Status, authID)
+	}
+}
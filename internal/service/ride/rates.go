@@ -0,0 +1,57 @@
+package ride
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+)
+
+// Rates возвращает тарифную сетку по классам автомобилей и городам.
+// Если cityCode задан, в ответ попадает только этот город.
+func (s *RideService) Rates(ctx context.Context, cityCode string) (*models.RateCard, error) {
+	card := &models.RateCard{
+		Rates:  s.calculate.Tariffs(),
+		Cities: []models.CityRates{},
+	}
+
+	if s.cities == nil {
+		if cityCode != "" {
+			return nil, fmt.Errorf("%w: %s", types.ErrUnknownCity, cityCode)
+		}
+		return card, nil
+	}
+
+	cities, err := s.cities.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, city := range cities {
+		if cityCode != "" && !strings.EqualFold(city.Code, cityCode) {
+			continue
+		}
+		card.Cities = append(card.Cities, models.CityRates{
+			Code:            city.Code,
+			Name:            city.Name,
+			Timezone:        city.Timezone,
+			OpenTime:        city.OpenTime,
+			CloseTime:       city.CloseTime,
+			NightStart:      city.NightStart,
+			NightEnd:        city.NightEnd,
+			NightMultiplier: city.NightMultiplier,
+			Rates:           s.calculate.Tariffs(),
+		})
+		if city.UpdatedAt.After(card.UpdatedAt) {
+			card.UpdatedAt = city.UpdatedAt
+		}
+	}
+
+	if cityCode != "" && len(card.Cities) == 0 {
+		return nil, fmt.Errorf("%w: %s", types.ErrUnknownCity, cityCode)
+	}
+
+	return card, nil
+}
@@ -0,0 +1,70 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Rate сохраняет оценку водителя пассажиром и пересчитывает средний рейтинг водителя.
+// Оценить можно только свою завершенную поездку и только один раз.
+func (s *RideService) Rate(ctx context.Context, rideID, passengerID uuid.UUID, score int, comment *string) (*models.RideRating, *models.DriverRating, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "rate_driver")
+
+	var (
+		rating       *models.RideRating
+		driverRating *models.DriverRating
+	)
+	if err := s.trm.Do(ctx, func(ctx context.Context) error {
+		ride, err := s.repo.Get(ctx, rideID)
+		if err != nil {
+			if errors.Is(err, types.ErrNotFound) {
+				return types.ErrRideNotFound
+			}
+			return fmt.Errorf("could not find ride by id: %w", err)
+		}
+
+		if ride.PassengerID != passengerID {
+			return authSvc.ErrActionForbidden
+		}
+		if ride.Status != types.StatusCompleted.String() || ride.DriverID == nil {
+			return types.ErrRideNotCompleted
+		}
+
+		rating = &models.RideRating{
+			RideID:      ride.ID,
+			DriverID:    *ride.DriverID,
+			PassengerID: passengerID,
+			Score:       score,
+			Comment:     comment,
+		}
+		if err := s.repo.CreateRating(ctx, rating); err != nil {
+			return err
+		}
+
+		driverRating, err = s.repo.UpdateDriverRating(ctx, rating.DriverID)
+		return err
+	}); err != nil {
+		return nil, nil, wrap.Error(ctx, err)
+	}
+
+	// записываем ивент
+	eventData, _ := json.Marshal(map[string]any{ // non fatal event so just ignore error
+		"driver_id":     rating.DriverID,
+		"score":         rating.Score,
+		"driver_rating": driverRating.Rating,
+	})
+	if err := s.eventRepo.CreateEvent(ctx, rating.RideID, types.EventDriverRated, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventDriverRated, "error", err.Error())
+	}
+
+	s.logger.Info(ctx, "driver rated", "driver_id", rating.DriverID, "score", rating.Score, "driver_rating", driverRating.Rating)
+	return rating, driverRating, nil
+}
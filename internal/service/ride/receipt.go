@@ -0,0 +1,64 @@
+package ride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Receipt возвращает чек завершенной поездки пассажира
+func (s *RideService) Receipt(ctx context.Context, rideID, passengerID uuid.UUID) (*models.Receipt, error) {
+	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "get_ride_receipt")
+
+	ride, err := s.repo.Get(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, wrap.Error(ctx, types.ErrRideNotFound)
+		}
+		return nil, wrap.Error(ctx, fmt.Errorf("could not find ride by id: %w", err))
+	}
+	if ride.PassengerID != passengerID {
+		return nil, wrap.Error(ctx, authSvc.ErrActionForbidden)
+	}
+
+	receipt, err := s.repo.GetReceipt(ctx, rideID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+	return receipt, nil
+}
+
+// newReceipt раскладывает стоимость fare завершенной поездки по строкам чека.
+// Стоимость фиксируется при заказе, поэтому тариф считается по маршруту заказа, а не по фактическому пути.
+func (s *RideService) newReceipt(ride *models.Ride, fare float64, legalEntity string) *models.Receipt {
+	distance := s.calculate.Distance(ride.Pickup, ride.Destination)
+	duration := s.calculate.Duration(distance)
+
+	vehicleClass := ride.RideType
+	if ride.ServedVehicleClass != nil {
+		vehicleClass = *ride.ServedVehicleClass
+	}
+
+	surge := max(ride.SurgeMultiplier, 1)
+
+	return &models.Receipt{
+		RideID:          ride.ID,
+		RideNumber:      ride.RideNumber,
+		PassengerID:     ride.PassengerID,
+		DriverID:        ride.DriverID,
+		VehicleClass:    vehicleClass,
+		PaymentMethod:   ride.PaymentMethod,
+		DistanceKm:      math.Round(distance*100) / 100,
+		DurationMin:     duration,
+		SurgeMultiplier: surge,
+		Fare:            s.calculate.SettleRide(ride, fare),
+		LegalEntity:     legalEntity,
+	}
+}
@@ -0,0 +1,176 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// reconcileBatch — сколько зависших поездок забирается за один проход
+const reconcileBatch = 50
+
+// reasonDriverNotStarted — причина снятия водителя, который так и не выехал к пассажиру
+const reasonDriverNotStarted = "driver did not start the trip to pickup"
+
+// ReconcileOptions — сверка поездок, которые остались без ожидающего экземпляра: ожидание
+// ответа водителя (awaitDriver) живет в памяти и пропадает при падении ride-service.
+type ReconcileOptions struct {
+	Interval       time.Duration // 0 — сверка выключена
+	RequestedAfter time.Duration // поездка в REQUESTED дольше отменяется: водитель не найден
+	MatchedAfter   time.Duration // водитель, не выехавший за это время, снимается, поиск перезапускается
+}
+
+// RunReconciler периодически сверяет зависшие поездки до отмены контекста
+func (s *RideService) RunReconciler(ctx context.Context, opts ReconcileOptions) {
+	if opts.Interval <= 0 {
+		s.logger.Warn(ctx, "stuck rides reconciler disabled", "interval", opts.Interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.ReconcileStuckRides(ctx, opts); err != nil {
+			s.logger.Warn(ctx, "failed to reconcile stuck rides", "error", err.Error())
+		}
+	}
+}
+
+// ReconcileStuckRides отменяет поездки, для которых водитель не нашелся за RequestedAfter, и снимает
+// водителей, не выехавших за MatchedAfter после назначения. Пассажир получает уведомление в обоих случаях.
+// Возвращает число обработанных поездок; ошибка одной поездки не останавливает остальные.
+func (s *RideService) ReconcileStuckRides(ctx context.Context, opts ReconcileOptions) (int, error) {
+	ctx = wrap.WithAction(ctx, "reconcile_stuck_rides")
+
+	now := s.clock.Now()
+	ids, err := s.repo.ClaimStuckRides(ctx, now.Add(-opts.RequestedAfter), now.Add(-opts.MatchedAfter), reconcileBatch)
+	if err != nil {
+		return 0, wrap.Error(ctx, err)
+	}
+
+	reconciled := 0
+	for _, id := range ids {
+		ok, err := s.reconcileRide(wrap.WithRideID(ctx, id.String()), id, opts)
+		if err != nil {
+			s.logger.Warn(wrap.WithRideID(ctx, id.String()), "failed to reconcile stuck ride", "error", err.Error())
+			continue
+		}
+		if ok {
+			reconciled++
+		}
+	}
+
+	if reconciled > 0 {
+		s.logger.Warn(ctx, "stuck rides reconciled", "count", reconciled)
+	}
+	return reconciled, nil
+}
+
+// reconcileRide обрабатывает забранную поездку по ее текущему статусу.
+// false — поездка успела сдвинуться сама, пока ждала сверки.
+func (s *RideService) reconcileRide(ctx context.Context, rideID uuid.UUID, opts ReconcileOptions) (bool, error) {
+	ride, err := s.repo.Get(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if ride == nil {
+		return false, nil
+	}
+
+	switch ride.Status {
+	case types.StatusRequested.String():
+		return s.expireStuckSearch(ctx, ride, opts.RequestedAfter)
+	case types.StatusMatched.String():
+		return s.redispatchStuckMatch(ctx, ride, opts.MatchedAfter)
+	default:
+		return false, nil
+	}
+}
+
+// expireStuckSearch отменяет поездку, поиск водителя для которой не закончился ответом.
+// Штрафа нет: водитель не назначен. Пассажира уведомляет cancel.
+func (s *RideService) expireStuckSearch(ctx context.Context, ride *models.Ride, after time.Duration) (bool, error) {
+	_, err := s.cancel(ctx, ride.ID, types.CancelReasonNoDriver, types.RoleSystem, func(_ context.Context, ride *models.Ride, _ time.Time) (*float64, error) {
+		if ride.Status != types.StatusRequested.String() {
+			return nil, types.ErrRideCannotBeCancelled
+		}
+		return nil, nil
+	})
+	if errors.Is(err, types.ErrRideCannotBeCancelled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	s.logger.Warn(ctx, "stuck ride cancelled, no driver response", "requested_after", after.String())
+	return true, nil
+}
+
+// redispatchStuckMatch снимает назначенного водителя, который не выехал к пассажиру: сообщение
+// о назначении или о выезде потерялось вместе с экземпляром. Поиск перезапускается без этого водителя.
+func (s *RideService) redispatchStuckMatch(ctx context.Context, ride *models.Ride, after time.Duration) (bool, error) {
+	res, err := s.redispatch(ctx, ride.ID, types.RoleSystem, reasonDriverNotStarted, func(status string) bool {
+		return status == types.StatusMatched.String()
+	})
+	if errors.Is(err, types.ErrRideCannotBeReassigned) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	ride = res.ride
+
+	s.logger.Warn(ctx, "stuck match released, ride returned to matching",
+		"released_driver_id", res.releasedDriverID.String(),
+		"matched_after", after.String(),
+	)
+
+	eventData, _ := json.Marshal(map[string]any{ // non fatal event so just ignore error
+		"status":             types.StatusRequested,
+		"previous_status":    res.previousStatus,
+		"released_driver_id": res.releasedDriverID,
+		"released_by":        types.RoleSystem,
+		"reason":             reasonDriverNotStarted,
+	})
+	if err := s.eventRepo.CreateEvent(ctx, ride.ID, types.EventStatusChanged, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventStatusChanged, "error", err.Error())
+	}
+
+	wsMessage := models.StatusUpdateWebSocketMessage{
+		EventType: types.EventStatusChanged,
+		Data:      res.status,
+	}
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, wsMessage); err != nil {
+		s.logger.Warn(ctx, "failed to notify passenger", "error", err)
+	}
+	s.notify(ctx, models.Notification{
+		UserID: ride.PassengerID,
+		RideID: &ride.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Finding a new driver",
+		Body:   fmt.Sprintf("Your driver is unavailable. We are looking for another driver for ride %s", ride.RideNumber),
+	})
+
+	if res.dispatched {
+		s.awaitDriver(ctx, ride.ID, ride.PassengerID)
+	}
+	return true, nil
+}
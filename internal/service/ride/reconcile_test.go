@@ -0,0 +1,189 @@
+package ride
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memOutbox повторяет выборку relay: неотправленные сообщения, чье next_attempt_at наступило
+type memOutbox struct {
+	OutboxRepo
+	clock     clock.Clock
+	messages  []*models.OutboxMessage
+	nextAt    map[uuid.UUID]time.Time
+	published map[uuid.UUID]bool
+}
+
+func (o *memOutbox) ListPendingForUpdate(context.Context, int) ([]*models.OutboxMessage, error) {
+	var pending []*models.OutboxMessage
+	for _, m := range o.messages {
+		if !o.published[m.ID] && !o.nextAt[m.ID].After(o.clock.Now()) {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memOutbox) Hold(_ context.Context, id uuid.UUID, until time.Time) error {
+	for _, m := range o.messages {
+		if m.ID == id {
+			m.HeldUntil = &until
+		}
+	}
+	o.nextAt[id] = until
+	return nil
+}
+
+func (o *memOutbox) MarkPublished(_ context.Context, id uuid.UUID) error {
+	o.published[id] = true
+	return nil
+}
+
+// unpublished — запрос поиска поездки еще ждет отправки
+func (o *memOutbox) unpublished(rideID uuid.UUID) bool {
+	for _, m := range o.messages {
+		var req models.RideRequestedMessage
+		if m.Topic == types.OutboxRideRequested && json.Unmarshal(m.Payload, &req) == nil && req.RideID == rideID && !o.published[m.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// stuckRideRepo повторяет ClaimStuckRides и MarkDispatched поверх updated_at поездок
+type stuckRideRepo struct {
+	RideRepo
+	clock     clock.Clock
+	outbox    *memOutbox
+	rides     map[uuid.UUID]*models.Ride
+	updatedAt map[uuid.UUID]time.Time
+	claimed   int
+}
+
+func (r *stuckRideRepo) Get(_ context.Context, rideID uuid.UUID) (*models.Ride, error) {
+	ride := *r.rides[rideID]
+	return &ride, nil
+}
+
+func (r *stuckRideRepo) MarkDispatched(_ context.Context, rideID uuid.UUID) error {
+	if r.rides[rideID].Status == types.StatusRequested.String() {
+		r.updatedAt[rideID] = r.clock.Now()
+	}
+	return nil
+}
+
+func (r *stuckRideRepo) ClaimStuckRides(_ context.Context, requestedBefore, _ time.Time, _ int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, ride := range r.rides {
+		if ride.Status == types.StatusRequested.String() && r.updatedAt[id].Before(requestedBefore) && !r.outbox.unpublished(id) {
+			r.updatedAt[id] = r.clock.Now()
+			r.claimed++
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+type fixedBlackouts []models.MatchingBlackout
+
+func (b fixedBlackouts) Active(context.Context, time.Time) ([]models.MatchingBlackout, error) {
+	return b, nil
+}
+
+type countingPublisher struct {
+	RideMsgBroker
+	requested int
+}
+
+func (p *countingPublisher) PublishRideRequested(context.Context, models.RideRequestedMessage) error {
+	p.requested++
+	return nil
+}
+
+type noopEvents struct{}
+
+func (noopEvents) CreateEvent(context.Context, uuid.UUID, types.RideEvent, json.RawMessage) error {
+	return nil
+}
+
+type noopPassengerSender struct{}
+
+func (noopPassengerSender) SendToPassenger(context.Context, uuid.UUID, any) error { return nil }
+
+type inlineTxManager struct{}
+
+func (inlineTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (inlineTxManager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Поездка, запрос которой простоял в окне приостановки подбора дольше порога сверки, после отправки
+// получает полный поиск: возраст отсчитывается от отправки, а не от создания.
+func TestReconcileStuckRides_HeldDispatchSurvives(t *testing.T) {
+	start := time.Date(2026, 12, 16, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	pickup := models.Location{Latitude: 43.238949, Longitude: 76.945465}
+
+	ride := &models.Ride{ID: uuid.New(), Status: types.StatusRequested.String(), PassengerID: uuid.New(), RideType: string(types.ClassEconomy), Pickup: pickup}
+	payload, _ := json.Marshal(models.RideRequestedMessage{RideID: ride.ID, PassengerID: ride.PassengerID})
+	msg := &models.OutboxMessage{ID: uuid.New(), Topic: types.OutboxRideRequested, Payload: payload, CreatedAt: start}
+
+	outbox := &memOutbox{clock: clk, messages: []*models.OutboxMessage{msg}, nextAt: map[uuid.UUID]time.Time{}, published: map[uuid.UUID]bool{}}
+	repo := &stuckRideRepo{clock: clk, outbox: outbox, rides: map[uuid.UUID]*models.Ride{ride.ID: ride}, updatedAt: map[uuid.UUID]time.Time{ride.ID: start}}
+	blackout := models.MatchingBlackout{Reason: "parade", Zone: models.FareZone{Center: pickup, RadiusKm: 1}, StartsAt: start, EndsAt: start.Add(10 * time.Minute)}
+	publisher := &countingPublisher{}
+
+	s := &RideService{
+		repo: repo, outbox: outbox, blackouts: fixedBlackouts{blackout}, publisher: publisher,
+		trm: inlineTxManager{}, eventRepo: noopEvents{}, passengerSender: noopPassengerSender{},
+		calculate: ridecalc.New(), replies: newReplyDispatcher(), clock: clk, logger: logger.InitLogger("test", "error"),
+	}
+	opts := ReconcileOptions{Interval: time.Minute, RequestedAfter: 5 * time.Minute, MatchedAfter: 3 * time.Minute}
+	ctx := context.Background()
+
+	// relay держит запрос, пока действует окно; сверка в это время поездку не трогает
+	for clk.Now().Before(blackout.EndsAt) {
+		if err := s.RelayOutbox(ctx, 5*time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := s.ReconcileStuckRides(ctx, opts); err != nil || n != 0 {
+			t.Fatalf("reconciled %d held rides at %s, err %v", n, clk.Now().Sub(start), err)
+		}
+		clk.Advance(time.Minute)
+	}
+	if publisher.requested != 0 {
+		t.Fatalf("published %d requests during the blackout", publisher.requested)
+	}
+
+	// окно закончилось: запрос отправлен, поиск только начался
+	if err := s.RelayOutbox(ctx, 5*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if publisher.requested != 1 {
+		t.Fatalf("published %d requests after the blackout, want 1", publisher.requested)
+	}
+
+	clk.Advance(time.Minute)
+	if n, err := s.ReconcileStuckRides(ctx, opts); err != nil || n != 0 {
+		t.Fatalf("reconciled %d rides right after dispatch, err %v", n, err)
+	}
+	if repo.claimed != 0 || ride.Status != types.StatusRequested.String() {
+		t.Fatalf("ride claimed %d times, status %s; want untouched REQUESTED", repo.claimed, ride.Status)
+	}
+}
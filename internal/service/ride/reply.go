@@ -0,0 +1,107 @@
+package ride
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// replyDispatcher передает ответы водителей горутинам, ожидающим их на этом экземпляре.
+// Ключ — ID поездки: по нему driver-service адресует ответ (driver.response.{ride_id}).
+type replyDispatcher struct {
+	mu      sync.Mutex
+	waiting map[uuid.UUID]chan models.DriverMatchResponse
+}
+
+func newReplyDispatcher() *replyDispatcher {
+	return &replyDispatcher{
+		waiting: make(map[uuid.UUID]chan models.DriverMatchResponse),
+	}
+}
+
+// register начинает ожидание ответа по поездке. release нужно вызвать по окончании ожидания.
+// Повторная регистрация той же поездки (переназначение) заменяет прежнее ожидание.
+func (d *replyDispatcher) register(rideID uuid.UUID) (replies <-chan models.DriverMatchResponse, release func()) {
+	ch := make(chan models.DriverMatchResponse, 1)
+
+	d.mu.Lock()
+	d.waiting[rideID] = ch
+	d.mu.Unlock()
+
+	return ch, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.waiting[rideID] == ch {
+			delete(d.waiting, rideID)
+		}
+	}
+}
+
+// deliver передает ответ ожидающей горутине. false — поездку ждут не на этом экземпляре или уже не ждут.
+func (d *replyDispatcher) deliver(resp models.DriverMatchResponse) bool {
+	d.mu.Lock()
+	ch, ok := d.waiting[resp.RideID]
+	if ok {
+		delete(d.waiting, resp.RideID)
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	ch <- resp
+	return true
+}
+
+// DispatchDriverResponse обрабатывает ответ водителя из общего потребителя ответов
+// и завершает ожидание поездки, если его ведет этот экземпляр
+func (s *RideService) DispatchDriverResponse(ctx context.Context, msg models.DriverMatchResponse) error {
+	if err := s.HandleDriverResponse(ctx, msg); err != nil {
+		return err
+	}
+
+	if !s.replies.deliver(msg) {
+		s.logger.Debug(ctx, "no local waiter for driver response")
+	}
+	return nil
+}
+
+// awaitDriver ждет ответа водителя в отдельной горутине. Без ответа поездка отменяется,
+// если она все еще в поиске: ответ мог обработать другой экземпляр ride-service.
+func (s *RideService) awaitDriver(ctx context.Context, rideID, passengerID uuid.UUID) {
+	replies, release := s.replies.register(rideID)
+
+	go func() {
+		defer release()
+		ctx := wrap.WithLogCtx(context.Background(), wrap.GetLogCtx(ctx))
+
+		timer := time.NewTimer(types.DriverResponseTimeout)
+		defer timer.Stop()
+
+		select {
+		case resp := <-replies:
+			s.logger.Debug(ctx, "driver response received", "driver_id", resp.DriverID)
+			return
+		case <-timer.C:
+		}
+
+		ride, err := s.repo.Get(ctx, rideID)
+		if err != nil {
+			s.logger.Error(ctx, "failed to get ride after driver response timeout", err)
+			return
+		}
+		if ride == nil || ride.Status != types.StatusRequested.String() {
+			return
+		}
+
+		s.logger.Warn(ctx, "no driver response, cancelling ride", "timeout", types.DriverResponseTimeout.String())
+		if _, err := s.Cancel(ctx, rideID, passengerID, types.CancelReasonNoDriver); err != nil {
+			s.logger.Error(ctx, "failed to cancel ride", err)
+		}
+	}()
+}
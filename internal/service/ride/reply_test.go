@@ -0,0 +1,41 @@
+package ride
+
+import (
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+func TestReplyDispatcher(t *testing.T) {
+	d := newReplyDispatcher()
+	rideID := uuid.New()
+
+	if d.deliver(models.DriverMatchResponse{RideID: rideID}) {
+		t.Fatal("response without a waiter must not be delivered")
+	}
+
+	replies, release := d.register(rideID)
+	driverID := uuid.New()
+	if !d.deliver(models.DriverMatchResponse{RideID: rideID, DriverID: driverID}) {
+		t.Fatal("response must be delivered to the waiter")
+	}
+	if got := <-replies; got.DriverID != driverID {
+		t.Fatalf("got driver %s, want %s", got.DriverID, driverID)
+	}
+	if d.deliver(models.DriverMatchResponse{RideID: rideID}) {
+		t.Fatal("second response must not be delivered to a finished wait")
+	}
+	release()
+
+	// переназначение: прежнее ожидание не снимает новое
+	_, releaseOld := d.register(rideID)
+	newReplies, releaseNew := d.register(rideID)
+	defer releaseNew()
+	releaseOld()
+
+	if !d.deliver(models.DriverMatchResponse{RideID: rideID}) {
+		t.Fatal("release of a replaced wait must keep the new one")
+	}
+	<-newReplies
+}
@@ -0,0 +1,18 @@
+package ride
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// CreateSandbox создает тестовую поездку от имени пассажира для smoke-тестов после деплоя.
+// Поездка проходит обычный путь, но подбирается только среди водителей-симуляторов
+// и не учитывается в выручке и метриках.
+func (s *RideService) CreateSandbox(ctx context.Context, ride *models.Ride) (*models.Ride, error) {
+	ride.IsTest = true
+
+	s.logger.Info(wrap.WithAction(ctx, "create_sandbox_ride"), "creating sandbox ride", "passenger_id", ride.PassengerID)
+	return s.Create(ctx, ride)
+}
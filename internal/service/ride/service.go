@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	authSvc "github.com/Temutjin2k/ride-hail-system/internal/service/auth"
 	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
@@ -26,11 +28,28 @@ type RideService struct {
 	calculate       ridecalc.Calculator
 	passengerSender RideWsHandler
 	eventRepo       RideEventRepository
+	snapper         RoadSnapper
+	cities          CityRepo
+	flatRates       FlatRateRepo
+	blackouts       BlackoutRepo
+	notifier        Notifier
+	comms           CommunicationRepo
+	emissions       EmissionFactors
+	wallets         WalletRepo
+	payments        PaymentProvider // nil — провайдер не подключен, пополнение недоступно
+	promos          PromoService
+	outbox          OutboxRepo
+	payment         PaymentOptions
+	fiscal          FiscalProvider // nil — фискализация не подключена, чеки только нумеруются
+	invoice         InvoiceOptions
+	approach        ApproachOptions
+	replies         *replyDispatcher
+	clock           clock.Clock
 
 	logger logger.Logger
 }
 
-func NewRideService(repo RideRepo, calculate ridecalc.Calculator, trm trm.TxManager, publisher RideMsgBroker, passengerSender RideWsHandler, eventRepo RideEventRepository, logger logger.Logger) *RideService {
+func NewRideService(repo RideRepo, calculate ridecalc.Calculator, trm trm.TxManager, publisher RideMsgBroker, passengerSender RideWsHandler, eventRepo RideEventRepository, snapper RoadSnapper, cities CityRepo, flatRates FlatRateRepo, blackouts BlackoutRepo, notifier Notifier, comms CommunicationRepo, emissions EmissionFactors, wallets WalletRepo, payments PaymentProvider, promos PromoService, outbox OutboxRepo, payment PaymentOptions, fiscal FiscalProvider, invoice InvoiceOptions, approach ApproachOptions, clk clock.Clock, logger logger.Logger) *RideService {
 	return &RideService{
 		repo:            repo,
 		calculate:       calculate,
@@ -38,6 +57,23 @@ func NewRideService(repo RideRepo, calculate ridecalc.Calculator, trm trm.TxMana
 		publisher:       publisher,
 		passengerSender: passengerSender,
 		eventRepo:       eventRepo,
+		snapper:         snapper,
+		cities:          cities,
+		flatRates:       flatRates,
+		blackouts:       blackouts,
+		notifier:        notifier,
+		comms:           comms,
+		emissions:       emissions,
+		wallets:         wallets,
+		payments:        payments,
+		promos:          promos,
+		outbox:          outbox,
+		payment:         payment,
+		fiscal:          fiscal,
+		invoice:         invoice,
+		approach:        approach,
+		replies:         newReplyDispatcher(),
+		clock:           clk,
 		logger:          logger,
 	}
 }
@@ -48,8 +84,23 @@ func (s *RideService) Create(ctx context.Context, ride *models.Ride) (*models.Ri
 
 	// go s.startRideTimeout(ctx, ride.ID, ride.PassengerID)
 
+	// притягиваем точку посадки к ближайшей дороге до открытия транзакции (внешний вызов)
+	suggestion := s.SuggestPickup(ctx, ride.Pickup)
+	ride.PickupSuggestion = &suggestion
+	ride.Pickup = suggestion.Suggested
+
+	// надбавка за спрос считается до транзакции, чтобы ошибка подсчета не прерывала ее.
+	// Приоритетные и тестовые поездки не дорожают от спроса.
+	ride.SurgeMultiplier = 1
+	if ride.PriorityBoarding == "" && !ride.IsTest {
+		ride.SurgeMultiplier = s.surgeMultiplier(ctx, ride.RideType, ride.Pickup, s.clock.Now())
+	}
+	flatRates := s.activeFlatRates(ctx, s.clock.Now())
+	blackouts := s.activeBlackouts(ctx, s.clock.Now())
+
 	var createdRide *models.Ride
 	var msg models.RideRequestedMessage
+	var outboxMsg *models.OutboxMessage
 	err := s.trm.Do(ctx, func(ctx context.Context) error {
 		// проверить, есть ли у пассажира активная поездка
 		activeRide, err := s.repo.CheckActiveRideByPassengerID(ctx, ride.PassengerID)
@@ -62,9 +113,50 @@ func (s *RideService) Create(ctx context.Context, ride *models.Ride) (*models.Ri
 			return types.ErrPassengerHasActiveRide
 		}
 
+		if err := s.checkMatchingPaused(ctx, ride.Pickup); err != nil {
+			return err
+		}
+
+		// приоритетную посадку разрешает администратор для аккаунта пассажира
+		if ride.PriorityBoarding != "" {
+			eligible, err := s.repo.IsPriorityBoardingEligible(ctx, ride.PassengerID)
+			if err != nil {
+				return err
+			}
+			if !eligible {
+				return types.ErrPriorityBoardingDenied
+			}
+		}
+
 		distance := s.calculate.Distance(ride.Pickup, ride.Destination)
 		duration := s.calculate.Duration(distance)
-		fare := s.calculate.Fare(ride.RideType, distance, duration)
+		fare := surgeFare(s.calculate.Fare(ride.RideType, distance, duration).Total, ride.SurgeMultiplier)
+		// приоритетная поездка не дорожает от надбавок: тариф остается базовым
+		if ride.PriorityBoarding == "" {
+			fare, _, err = s.cityFare(ctx, ride.Pickup, fare, s.clock.Now())
+			if err != nil {
+				return err
+			}
+		}
+		// фиксированный тариф маршрута заменяет надбавки, минимальный тариф зоны применяется до скидки
+		fare, flatRate := s.flatFare(ctx, flatRates, ride.RideType, ride.Pickup, ride.Destination, fare, s.clock.Now())
+		if isFixedFare(flatRate) {
+			ride.SurgeMultiplier = 1
+		}
+		if flatRate != nil {
+			ride.FlatRateID, ride.FlatRateCode = &flatRate.ID, flatRate.Code
+		}
+		// промокод проверяется в транзакции: строка кода заблокирована до записи применения
+		var promo *models.PromoDiscount
+		if ride.PromoCode != "" {
+			promo, err = s.promos.Apply(ctx, ride.PromoCode, ride.PassengerID, fare)
+			if err != nil {
+				return err
+			}
+			fare = math.Round((fare-promo.Discount)*100) / 100
+			ride.PromoCode = promo.Code
+			ride.Discount = promo.Discount
+		}
 		priority := s.calculate.Priority(ride)
 		rideNumber, err := s.generateRideNumber(ctx)
 		if err != nil {
@@ -78,40 +170,50 @@ func (s *RideService) Create(ctx context.Context, ride *models.Ride) (*models.Ri
 		ride.Status = types.StatusRequested.String()
 		ride.Priority = priority
 
+		if ride.PaymentMethod == "" {
+			ride.PaymentMethod = types.PaymentCard
+		}
+		// отметка снимается вместе с публикацией запроса из outbox
+		ride.PendingDispatch = true
+
 		createdRide, err = s.repo.Create(ctx, ride)
 		if err != nil {
 			return fmt.Errorf("could not create ride in repo: %w", err)
 		}
 		ctx = wrap.WithRideID(ctx, createdRide.ID.String())
 
+		if promo != nil {
+			if err := s.promos.Redeem(ctx, promo, createdRide.ID); err != nil {
+				return err
+			}
+		}
+
+		if createdRide.PaymentMethod == types.PaymentWallet {
+			if err := s.holdFare(ctx, createdRide); err != nil {
+				return err
+			}
+		}
+
 		correlationID := wrap.GetRequestID(ctx) // Используем RequestID как CorrelationID
 		if correlationID == "" {                // На случай, если RequestID отсутствует
 			correlationID = newCorrelationID()
 		}
 
-		message := models.RideRequestedMessage{
-			RideID:     createdRide.ID,
-			RideNumber: createdRide.RideNumber,
-			PickupLocation: models.Location{
-				Latitude:  createdRide.Pickup.Latitude,
-				Longitude: createdRide.Pickup.Longitude,
-				Address:   createdRide.Pickup.Address,
-			},
-			DestinationLocation: models.Location{
-				Latitude:  createdRide.Destination.Latitude,
-				Longitude: createdRide.Destination.Longitude,
-				Address:   createdRide.Destination.Address,
-			},
-			RideType:       createdRide.RideType,
-			EstimatedFare:  createdRide.EstimatedFare,
-			MaxDistanceKm:  5.0, // Это чтобы не ожидать драйвера из какого нибудь Мадагаскара
-			TimeoutSeconds: 120,
-			CorrelationID:  correlationID,
-			Priority:       uint8(createdRide.Priority),
+		message := newRideRequestedMessage(createdRide, correlationID)
+
+		// запрос поиска водителя записывается в outbox вместе с поездкой и публикуется после commit
+		outboxMsg, err = s.enqueueRideRequested(ctx, &message)
+		if err != nil {
+			return err
 		}
 
-		if err := s.publisher.PublishRideRequested(ctx, message); err != nil {
-			return wrap.Error(ctx, fmt.Errorf("failed to publish ride requested event: %w", err))
+		// подбор в зоне посадки приостановлен: запрос ждет в outbox окончания окна
+		now := s.clock.Now()
+		if blackout := s.matchingBlackout(blackouts, createdRide.RideType, createdRide.Pickup, now); blackout != nil && outboxMsg != nil {
+			if err := s.holdDispatch(ctx, outboxMsg, blackout, now); err != nil {
+				return err
+			}
+			createdRide.MatchingDelay = newMatchingDelay(createdRide, blackout, now)
 		}
 
 		msg = message
@@ -121,101 +223,131 @@ func (s *RideService) Create(ctx context.Context, ride *models.Ride) (*models.Ri
 		return nil, wrap.Error(ctx, err)
 	}
 
-	eventData, _ := json.Marshal(msg) // non fatal event so just ignore error
-	if err := s.eventRepo.CreateEvent(ctx, createdRide.ID, types.EventRideRequested, eventData); err != nil {
-		s.logger.Warn(ctx, "failed to create ride event", "event_type", types.EventRideRequested, "error", err.Error())
+	// при недоступном брокере поездка все равно создана, запрос отправит RunOutboxRelay
+	switch {
+	case createdRide.MatchingDelay != nil:
+		s.logger.Info(ctx, "matching paused in pickup zone, dispatch delayed", "until", createdRide.MatchingDelay.Until, "reason", createdRide.MatchingDelay.Reason)
+	case s.flushOutbox(ctx, outboxMsg):
+		createdRide.PendingDispatch = false
+	default:
+		s.logger.Warn(ctx, "ride requested event not published, dispatch deferred")
+	}
+
+	// предавторизация карты — внешний вызов, поэтому после commit
+	s.authorizeCard(ctx, createdRide)
+
+	eventType := types.EventRideRequested
+	var wsData any = msg
+	switch {
+	case createdRide.MatchingDelay != nil:
+		eventType = types.EventMatchingDelayed
+		wsData = createdRide.MatchingDelay
+	case createdRide.PendingDispatch:
+		eventType = types.EventDispatchPending
+	}
+
+	eventData, _ := json.Marshal(wsData) // non fatal event so just ignore error
+	if err := s.eventRepo.CreateEvent(ctx, createdRide.ID, eventType, eventData); err != nil {
+		s.logger.Warn(ctx, "failed to create ride event", "event_type", eventType, "error", err.Error())
 	}
 
 	// cancel message
 	rideRequestedMsg := models.StatusUpdateWebSocketMessage{
-		EventType: types.EventRideRequested,
-		Data:      msg,
+		EventType: eventType,
+		Data:      wsData,
 	}
 
 	// notify via websocket
-	if err := s.passengerSender.SendToPassenger(ctx, ride.PassengerID, rideRequestedMsg); err != nil {
+	if err := s.sendToPassenger(ctx, ride.ID, ride.PassengerID, rideRequestedMsg); err != nil {
 		s.logger.Error(ctx, "failed to notify passenger that ride requested", err)
 	}
 
-	s.logger.Info(ctx, "ride created successfully", "ride_id", createdRide.ID)
-
-	// Wait for driver response for 2 minutes
-	go func() {
-		s.logger.Debug(ctx, "start a gouroutine for waiting driver response")
-		ctx, cancel := context.WithTimeout(wrap.WithLogCtx(context.Background(), wrap.GetLogCtx(ctx)), time.Minute*2)
-		defer cancel()
-		// Start a goroutine to handle the driver's response
-		if err := s.publisher.ConsumeDriverResponse(ctx, createdRide.ID, s.HandleDriverResponse); err != nil {
-			ctxx := wrap.WithLogCtx(context.Background(), wrap.GetLogCtx(ctx))
-			s.logger.Error(ctxx, "failed to consume driver response", err)
+	s.logger.Info(ctx, "ride created successfully", "ride_id", createdRide.ID, "pending_dispatch", createdRide.PendingDispatch)
 
-			// cancel the ride
-			_, err := s.Cancel(ctxx, createdRide.ID, ride.PassengerID, "failed to find a driver")
-			if err != nil {
-				s.logger.Error(ctxx, "failed to cancel ride", err)
-			}
-			return
-		}
+	// Wait for driver response. Для отложенной поездки ожидание запустит relay после отправки.
+	if !createdRide.PendingDispatch {
+		s.awaitDriver(ctx, createdRide.ID, ride.PassengerID)
+	}
 
-		s.logger.Debug(ctx, "finished a gouroutine for waiting driver response")
-	}()
+	createdRide.PickupSuggestion = &suggestion
 
 	return createdRide, nil
 }
 
+// newRideRequestedMessage формирует запрос на поиск водителя для driver-service
+func newRideRequestedMessage(ride *models.Ride, correlationID string) models.RideRequestedMessage {
+	return models.RideRequestedMessage{
+		RideID:      ride.ID,
+		RideNumber:  ride.RideNumber,
+		PassengerID: ride.PassengerID,
+		PickupLocation: models.Location{
+			Latitude:  ride.Pickup.Latitude,
+			Longitude: ride.Pickup.Longitude,
+			Address:   ride.Pickup.Address,
+		},
+		DestinationLocation: models.Location{
+			Latitude:  ride.Destination.Latitude,
+			Longitude: ride.Destination.Longitude,
+			Address:   ride.Destination.Address,
+		},
+		RideType:       ride.RideType,
+		EstimatedFare:  ride.EstimatedFare,
+		MaxDistanceKm:  5.0, // Это чтобы не ожидать драйвера из какого нибудь Мадагаскара
+		TimeoutSeconds: 120,
+		CorrelationID:  correlationID,
+		Priority:       uint8(ride.Priority),
+		IsTest:         ride.IsTest,
+
+		PriorityBoarding: ride.PriorityBoarding,
+	}
+}
+
 // Cancel cancels a ride
 func (s *RideService) Cancel(ctx context.Context, rideID, passengerID uuid.UUID, reason string) (*models.Ride, error) {
 	ctx = wrap.WithAction(wrap.WithRideID(ctx, rideID.String()), "cancel_ride")
 
-	var cancelledRide *models.Ride
-	if err := s.trm.Do(ctx, func(ctx context.Context) error {
-		ride, err := s.repo.Get(ctx, rideID)
-		if err != nil {
-			if errors.Is(err, types.ErrNotFound) {
-				return types.ErrRideNotFound
-			}
-			return fmt.Errorf("could not find ride by id: %w", err)
-		}
-
+	return s.cancel(ctx, rideID, reason, types.RolePassenger, func(ctx context.Context, ride *models.Ride, now time.Time) (*float64, error) {
 		// проверяем если юзер хочет отменить именно свою поездку а не чужую
 		if ride.PassengerID != passengerID {
-			return authSvc.ErrActionForbidden
+			return nil, authSvc.ErrActionForbidden
 		}
 
 		if !canBeCancelled(ride.Status) {
-			return types.ErrRideCannotBeCancelled
+			return nil, types.ErrRideCannotBeCancelled
 		}
 
-		s.logger.Warn(ctx, "trying to cancel ride...", "current_status", ride.Status)
-
-		now := time.Now()
-		ride.Status = types.StatusCancelled.String()
-		ride.CancellationReason = &reason
-		ride.CancelledAt = &now
+		return s.cancellationFee(ctx, ride, now)
+	})
+}
 
-		err = s.repo.Update(ctx, ride)
-		if err != nil {
-			return fmt.Errorf("could not update ride: %w", err)
-		}
+// cancelCheck проверяет, можно ли отменить поездку, и возвращает штраф за отмену, nil — отмена бесплатна
+type cancelCheck func(ctx context.Context, ride *models.Ride, now time.Time) (*float64, error)
 
-		cancelledRide = ride
-		return nil
+// cancel отменяет поездку в транзакции, освобождает оплату и промокод, после commit
+// публикует статус CANCELLED (driver-service освобождает водителя) и уведомляет пассажира
+func (s *RideService) cancel(ctx context.Context, rideID uuid.UUID, reason string, by types.UserRole, check cancelCheck) (*models.Ride, error) {
+	var res *cancelled
+	if err := s.trm.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.cancelInTx(ctx, rideID, reason, by, check)
+		return err
 	}); err != nil {
 		return nil, wrap.Error(ctx, err)
 	}
-
-	message := models.RideStatusUpdateMessage{
-		RideID:        cancelledRide.ID,
-		Status:        cancelledRide.Status,
-		Timestamp:     *cancelledRide.CancelledAt,
-		DriverID:      cancelledRide.DriverID,
-		CorrelationID: wrap.GetRequestID(ctx),
+	cancelledRide, message := res.ride, res.message
+
+	// штраф за отмену списывается с карты, остаток предавторизации снимается
+	switch fee := cancelledRide.CancellationFee; {
+	case res.walletFallback:
+		s.chargeCard(ctx, cancelledRide, *fee)
+	case cancelledRide.PaymentMethod == types.PaymentCard && fee != nil:
+		s.captureCardHold(ctx, cancelledRide, *fee)
+	case cancelledRide.PaymentMethod == types.PaymentCard:
+		s.voidCardHold(ctx, cancelledRide)
 	}
 
-	// Publish about ride status
-	if err := s.publisher.PublishRideStatus(ctx, message); err != nil {
-		s.logger.Warn(ctx, "failed to publish ride cancelled event", "error", err)
-	}
+	// Publish about ride status, при ошибке брокера сообщение отправит relay
+	s.flushOutbox(ctx, res.outbox)
 
 	// Create event
 	eventData, _ := json.Marshal(message) // non fatal event so just ignore error
@@ -229,15 +361,128 @@ func (s *RideService) Cancel(ctx context.Context, rideID, passengerID uuid.UUID,
 		Data:      message,
 	}
 	// notify via websocket
-	if err := s.passengerSender.SendToPassenger(ctx, cancelledRide.PassengerID, cancelMsg); err != nil {
+	if err := s.sendToPassenger(ctx, cancelledRide.ID, cancelledRide.PassengerID, cancelMsg); err != nil {
 		s.logger.Error(ctx, "failed to notify passenger about ride cancelation", err)
 	}
 
+	body := fmt.Sprintf("Your ride %s has been cancelled", cancelledRide.RideNumber)
+	if by == types.RoleAdmin {
+		body = fmt.Sprintf("Your ride %s has been cancelled by support: %s", cancelledRide.RideNumber, reason)
+	}
+	s.notify(ctx, models.Notification{
+		UserID: cancelledRide.PassengerID,
+		RideID: &cancelledRide.ID,
+		Event:  types.NotifyRideUpdates,
+		Title:  "Ride cancelled",
+		Body:   body,
+		Data:   rideNotificationData(cancelledRide, types.EventRideCancelled),
+	})
+
 	s.logger.Info(ctx, "ride cancelled successfully")
 
 	return cancelledRide, nil
 }
 
+// cancelled — поездка, отмененная в транзакции, и ее статус CANCELLED для driver-service
+type cancelled struct {
+	ride    *models.Ride
+	message models.RideStatusUpdateMessage
+	outbox  *models.OutboxMessage
+	// walletFallback — баланса кошелька не хватило на штраф, он списывается с карты после commit
+	walletFallback bool
+}
+
+// cancelInTx отменяет поездку в транзакции вызывающего: обновляет поездку, списывает штраф из
+// блокировки кошелька и освобождает остаток, возвращает промокод, записывает статус CANCELLED в outbox.
+// Ничего не публикует и никого не уведомляет, карта списывается вызывающим после commit.
+func (s *RideService) cancelInTx(ctx context.Context, rideID uuid.UUID, reason string, by types.UserRole, check cancelCheck) (*cancelled, error) {
+	ride, err := s.repo.Get(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, types.ErrRideNotFound
+		}
+		return nil, fmt.Errorf("could not find ride by id: %w", err)
+	}
+
+	now := s.clock.Now()
+	fee, err := check(ctx, ride, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Warn(ctx, "trying to cancel ride...", "current_status", ride.Status, "cancelled_by", by)
+
+	ride.Status = types.StatusCancelled.String()
+	ride.CancellationReason = &reason
+	ride.CancelledAt = &now
+	ride.CancellationFee = fee
+
+	err = s.repo.Update(ctx, ride)
+	if err != nil {
+		return nil, fmt.Errorf("could not update ride: %w", err)
+	}
+
+	walletFallback := false
+	if ride.PaymentMethod == types.PaymentWallet {
+		if fee != nil {
+			// штраф списывается из блокировки, остаток возвращается на баланс
+			captured, err := s.captureFare(ctx, ride, *fee)
+			if err != nil {
+				return nil, err
+			}
+			walletFallback = !captured
+		} else if err := s.releaseHold(ctx, ride); err != nil {
+			return nil, err
+		}
+	}
+
+	// использование промокода возвращается пассажиру
+	if err := s.promos.Release(ctx, ride.ID); err != nil {
+		return nil, err
+	}
+
+	message := models.RideStatusUpdateMessage{
+		RideID:          ride.ID,
+		Status:          ride.Status,
+		Timestamp:       now,
+		DriverID:        ride.DriverID,
+		CorrelationID:   wrap.GetRequestID(ctx),
+		CancellationFee: ride.CancellationFee,
+		ReleasedBy:      by,
+		Reason:          reason,
+	}
+	outboxMsg, err := s.enqueueRideStatus(ctx, &message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cancelled{ride: ride, message: message, outbox: outboxMsg, walletFallback: walletFallback}, nil
+}
+
+// cancellationFee считает штраф за отмену поездки, на которую уже назначен водитель.
+// Время считается от назначения, путь — по координатам водителя с момента назначения. nil — отмена бесплатна.
+func (s *RideService) cancellationFee(ctx context.Context, ride *models.Ride, now time.Time) (*float64, error) {
+	if ride.DriverID == nil || ride.MatchedAt == nil {
+		return nil, nil
+	}
+	if ride.Status != types.StatusMatched.String() && ride.Status != types.StatusEnRoute.String() {
+		return nil, nil
+	}
+
+	distanceKm, err := s.repo.DriverDistanceSince(ctx, *ride.DriverID, *ride.MatchedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not get driver distance: %w", err)
+	}
+
+	fee := s.calculate.CancellationFee(ride.RideType, now.Sub(*ride.MatchedAt), distanceKm)
+	if fee <= 0 {
+		return nil, nil
+	}
+
+	s.logger.Info(ctx, "passenger cancellation fee applied", "fee", fee, "driver_distance_km", distanceKm)
+	return &fee, nil
+}
+
 func canBeCancelled(status string) bool {
 	switch status {
 	case types.StatusRequested.String(),
@@ -257,3 +502,46 @@ func newCorrelationID() string {
 	}
 	return hex.EncodeToString(b)
 }
+
+// rideNotificationData — данные push уведомления о поездке, по ним приложение открывает экран поездки
+func rideNotificationData(ride *models.Ride, event types.RideEvent) map[string]string {
+	return map[string]string{
+		"ride_id":     ride.ID.String(),
+		"ride_number": ride.RideNumber,
+		"event_type":  string(event),
+	}
+}
+
+// notify отправляет уведомление вне приложения, ошибки не влияют на поездку
+func (s *RideService) notify(ctx context.Context, n models.Notification) {
+	if s.notifier == nil {
+		return
+	}
+
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		s.logger.Warn(ctx, "failed to send notification", "event_type", n.Event, "error", err.Error())
+	}
+}
+
+// sendToPassenger отправляет пассажиру WS сообщение о поездке и пишет результат в журнал коммуникаций поездки
+func (s *RideService) sendToPassenger(ctx context.Context, rideID, passengerID uuid.UUID, msg models.StatusUpdateWebSocketMessage) error {
+	err := s.passengerSender.SendToPassenger(ctx, passengerID, msg)
+
+	if s.comms != nil {
+		c := &models.Communication{
+			RideID:      rideID,
+			RecipientID: passengerID,
+			Channel:     types.ChannelWebSocket,
+			MessageType: msg.EventType.String(),
+			Status:      types.CommunicationDelivered,
+		}
+		if err != nil {
+			c.Status, c.Detail = types.CommunicationFailed, err.Error()
+		}
+		if err := s.comms.Create(ctx, c); err != nil {
+			s.logger.Warn(ctx, "failed to record ride communication", "channel", c.Channel, "error", err.Error())
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,41 @@
+package ride
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+)
+
+// surgeMultiplier возвращает надбавку за спрос в зоне точки посадки.
+// Вызывается вне транзакции: если спрос посчитать не удалось, поездка считается без надбавки.
+func (s *RideService) surgeMultiplier(ctx context.Context, rideType string, pickup models.Location, at time.Time) float64 {
+	zone, since := s.calculate.SurgeZone(pickup, at)
+	if zone == "" {
+		return 1
+	}
+
+	demand, err := s.repo.ZoneDemand(ctx, zone, rideType, since)
+	if err != nil {
+		s.logger.Warn(ctx, "failed to count zone demand, surge is not applied", "zone", zone, "error", err)
+		return 1
+	}
+	// текущий запрос тоже входит в спрос
+	demand.Requests++
+
+	multiplier := s.calculate.SurgeMultiplier(rideType, demand)
+	if multiplier > 1 {
+		s.logger.Info(ctx, "surge applied",
+			"zone", zone,
+			"requests", demand.Requests,
+			"available_drivers", demand.AvailableDrivers,
+			"multiplier", multiplier,
+		)
+	}
+	return multiplier
+}
+
+func surgeFare(fare, multiplier float64) float64 {
+	return math.Round(fare*multiplier*100) / 100
+}
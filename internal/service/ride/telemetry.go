@@ -0,0 +1,41 @@
+package ride
+
+import (
+	"context"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// SubmitTelemetry принимает отчет о качестве приложения от участника поездки.
+// Участие проверяется по userID, но в отчет попадает только роль.
+func (s *RideService) SubmitTelemetry(ctx context.Context, userID uuid.UUID, t models.AppTelemetry) error {
+	ctx = wrap.WithRideID(wrap.WithAction(ctx, "submit_app_telemetry"), t.RideID.String())
+
+	ride, err := s.repo.Get(ctx, t.RideID)
+	if err != nil {
+		return wrap.Error(ctx, err)
+	}
+
+	switch t.Role {
+	case types.RolePassenger.String():
+		if ride.PassengerID != userID {
+			return wrap.Error(ctx, types.ErrRideNotFound)
+		}
+		// офферы получают только водители
+		t.OfferRoundTripMs = nil
+	case types.RoleDriver.String():
+		if ride.DriverID == nil || *ride.DriverID != userID {
+			return wrap.Error(ctx, types.ErrRideNotFound)
+		}
+	default:
+		return wrap.Error(ctx, types.ErrRideNotFound)
+	}
+
+	if err := s.repo.SaveTelemetry(ctx, t); err != nil {
+		return wrap.Error(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package ride
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// walletEntriesLimit — сколько последних записей журнала возвращать вместе с кошельком
+const walletEntriesLimit = 20
+
+// Wallet возвращает баланс кошелька пассажира и последние операции
+func (s *RideService) Wallet(ctx context.Context, passengerID uuid.UUID) (*models.Wallet, error) {
+	ctx = wrap.WithAction(wrap.WithPassengerID(ctx, passengerID.String()), "get_wallet")
+
+	wallet, err := s.wallets.Get(ctx, passengerID)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	wallet.Entries, err = s.wallets.Entries(ctx, passengerID, walletEntriesLimit)
+	if err != nil {
+		return nil, wrap.Error(ctx, err)
+	}
+
+	return wallet, nil
+}
+
+// TopUpWallet списывает amount с карты пассажира через платежного провайдера и зачисляет в кошелек.
+// Если зачисление не удалось, платеж возвращается.
+func (s *RideService) TopUpWallet(ctx context.Context, passengerID uuid.UUID, amount float64) (*models.Wallet, error) {
+	ctx = wrap.WithAction(wrap.WithPassengerID(ctx, passengerID.String()), "top_up_wallet")
+
+	if s.payments == nil {
+		return nil, wrap.Error(ctx, types.ErrPaymentUnavailable)
+	}
+
+	transactionID, err := s.payments.Charge(ctx, passengerID, amount)
+	if err != nil {
+		return nil, wrap.Error(ctx, fmt.Errorf("failed to charge top-up: %w", err))
+	}
+
+	wallet, err := s.wallets.TopUp(ctx, passengerID, amount, transactionID)
+	if err != nil {
+		if refundErr := s.payments.Refund(ctx, transactionID, amount); refundErr != nil {
+			s.logger.Error(ctx, "failed to refund top-up after wallet error", refundErr, "transaction_id", transactionID)
+		}
+		return nil, wrap.Error(ctx, err)
+	}
+
+	s.logger.Info(ctx, "wallet topped up", "amount", amount, "transaction_id", transactionID)
+	return wallet, nil
+}
+
+// holdFare блокирует расчетную стоимость поездки в кошельке.
+// При нехватке баланса поездка переводится на оплату картой.
+func (s *RideService) holdFare(ctx context.Context, ride *models.Ride) error {
+	held, err := s.wallets.Hold(ctx, ride.PassengerID, ride.ID, ride.EstimatedFare)
+	if err != nil {
+		return fmt.Errorf("failed to hold wallet funds: %w", err)
+	}
+	if held {
+		return s.wallets.CreateHold(ctx, &models.PaymentHold{
+			RideID:        ride.ID,
+			PassengerID:   ride.PassengerID,
+			PaymentMethod: types.PaymentWallet,
+			Amount:        ride.EstimatedFare,
+			Status:        types.HoldAuthorized,
+		})
+	}
+
+	s.logger.Info(ctx, "insufficient wallet balance, falling back to card", "fare", ride.EstimatedFare)
+	if err := s.repo.SetPaymentMethod(ctx, ride.ID, types.PaymentCard); err != nil {
+		return err
+	}
+	ride.PaymentMethod = types.PaymentCard
+
+	return s.wallets.RecordCardFallback(ctx, ride.PassengerID, ride.ID, ride.EstimatedFare, nil)
+}
+
+// releaseHold возвращает блокировку отмененной поездки на баланс
+func (s *RideService) releaseHold(ctx context.Context, ride *models.Ride) error {
+	held, ok, err := s.wallets.OpenHold(ctx, ride.ID)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := s.wallets.Release(ctx, ride.PassengerID, ride.ID, held); err != nil {
+		return err
+	}
+	return s.wallets.SettleHold(ctx, ride.ID, types.HoldReleased, nil, nil, nil)
+}
+
+// captureFare списывает стоимость завершенной поездки или штраф за отмену из кошелька.
+// false — баланса не хватило: блокировка возвращена, поездка переведена на карту.
+func (s *RideService) captureFare(ctx context.Context, ride *models.Ride, fare float64) (bool, error) {
+	held, ok, err := s.wallets.OpenHold(ctx, ride.ID)
+	if err != nil {
+		return false, err
+	}
+
+	captured, err := s.wallets.Capture(ctx, ride.PassengerID, ride.ID, held, fare)
+	if err != nil {
+		return false, err
+	}
+	if captured {
+		return true, s.wallets.SettleHold(ctx, ride.ID, types.HoldCaptured, &fare, nil, nil)
+	}
+
+	if ok {
+		if err := s.wallets.Release(ctx, ride.PassengerID, ride.ID, held); err != nil {
+			return false, err
+		}
+		if err := s.wallets.SettleHold(ctx, ride.ID, types.HoldReleased, nil, nil, nil); err != nil {
+			return false, err
+		}
+	}
+	if err := s.repo.SetPaymentMethod(ctx, ride.ID, types.PaymentCard); err != nil {
+		return false, err
+	}
+	ride.PaymentMethod = types.PaymentCard
+
+	return false, nil
+}
+
+// chargeCard списывает стоимость поездки или штраф за отмену с карты после неудачного списания
+// из кошелька и записывает переход на карту в журнал. Без провайдера списание пропускается.
+func (s *RideService) chargeCard(ctx context.Context, ride *models.Ride, fare float64) {
+	s.logger.Info(ctx, "insufficient wallet balance, charging card", "amount", fare)
+
+	var transactionID *string
+	if s.payments != nil {
+		txID, err := s.payments.Charge(ctx, ride.PassengerID, fare)
+		if err != nil {
+			s.logger.Error(ctx, "failed to charge card for ride, retry scheduled", err)
+			s.scheduleCardCharge(ctx, ride, fare)
+		} else {
+			transactionID = &txID
+		}
+	}
+
+	if err := s.wallets.RecordCardFallback(ctx, ride.PassengerID, ride.ID, fare, transactionID); err != nil {
+		s.logger.Error(ctx, "failed to record card fallback", err)
+	}
+}
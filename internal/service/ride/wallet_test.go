@@ -0,0 +1,107 @@
+package ride
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/clock"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+type updatingRideRepo struct {
+	RideRepo
+	ride models.Ride
+}
+
+func (r *updatingRideRepo) Get(context.Context, uuid.UUID) (*models.Ride, error) {
+	ride := r.ride
+	return &ride, nil
+}
+
+func (r *updatingRideRepo) Update(_ context.Context, ride *models.Ride) error {
+	r.ride = *ride
+	return nil
+}
+
+// memWallet — кошелек одного пассажира с блокировкой одной поездки
+type memWallet struct {
+	WalletRepo
+	balance, held float64
+	hold          types.PaymentHoldStatus
+}
+
+func (w *memWallet) OpenHold(context.Context, uuid.UUID) (float64, bool, error) {
+	return w.held, w.held > 0, nil
+}
+
+func (w *memWallet) Capture(_ context.Context, _, _ uuid.UUID, held, amount float64) (bool, error) {
+	if w.balance+held < amount {
+		return false, nil
+	}
+	w.held -= held
+	w.balance += held - amount
+	return true, nil
+}
+
+func (w *memWallet) Release(_ context.Context, _, _ uuid.UUID, held float64) error {
+	w.held -= held
+	w.balance += held
+	return nil
+}
+
+func (w *memWallet) SettleHold(_ context.Context, _ uuid.UUID, status types.PaymentHoldStatus, _ *float64, _, _ *string) error {
+	w.hold = status
+	return nil
+}
+
+type noopPromos struct{ PromoService }
+
+func (noopPromos) Release(context.Context, uuid.UUID) error { return nil }
+
+// Штраф за отмену списывается из блокировки кошелька, остаток возвращается на баланс
+func TestCancelInTx_WalletCancellationFee(t *testing.T) {
+	driverID := uuid.New()
+	ride := models.Ride{
+		ID: uuid.New(), PassengerID: uuid.New(), DriverID: &driverID,
+		Status: types.StatusEnRoute.String(), PaymentMethod: types.PaymentWallet, EstimatedFare: 1000,
+	}
+	fee := 300.0
+	withFee := func(context.Context, *models.Ride, time.Time) (*float64, error) { return &fee, nil }
+	free := func(context.Context, *models.Ride, time.Time) (*float64, error) { return nil, nil }
+
+	tests := []struct {
+		name        string
+		check       cancelCheck
+		wantBalance float64
+		wantHold    types.PaymentHoldStatus
+	}{
+		{"fee captured from the hold", withFee, 700, types.HoldCaptured},
+		{"free cancel releases the hold", free, 1000, types.HoldReleased},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet := &memWallet{held: 1000}
+			s := &RideService{
+				repo: &updatingRideRepo{ride: ride}, wallets: wallet, promos: noopPromos{}, outbox: &addingOutbox{},
+				calculate: ridecalc.New(), clock: clock.NewFake(time.Now()), logger: logger.InitLogger("test", "error"),
+			}
+
+			res, err := s.cancelInTx(context.Background(), ride.ID, "changed plans", types.RolePassenger, tt.check)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.walletFallback {
+				t.Fatal("fee covered by the hold must not fall back to card")
+			}
+			if wallet.held != 0 || wallet.balance != tt.wantBalance || wallet.hold != tt.wantHold {
+				t.Fatalf("held %v, balance %v, hold %s; want 0, %v, %s", wallet.held, wallet.balance, wallet.hold, tt.wantBalance, tt.wantHold)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Package supply следит за числом водителей онлайн по городам и классам автомобилей
+// и поднимает алерт при резком падении. Массовый уход водителей из сети — часто первый
+// признак сбоя авторизации или WebSocket, который задевает только водителей.
+package supply
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+)
+
+// alertName — имя алерта в системе оповещения
+const alertName = "driver_supply_drop"
+
+// noCity — метка города для водителей вне настроенных городов
+const noCity = "none"
+
+type Repo interface {
+	OnlineDrivers(ctx context.Context) ([]models.SupplyCount, error)
+}
+
+// Sink доставляет алерт дежурным. Алерт с тем же Key может прийти повторно, например от другого экземпляра.
+type Sink interface {
+	Send(ctx context.Context, alert models.Alert) error
+}
+
+type Options struct {
+	Interval    time.Duration // как часто считать водителей онлайн
+	Window      time.Duration // за какое время сравнивать с максимумом
+	DropPercent float64       // падение от максимума за окно в процентах, при котором срабатывает алерт
+	MinDrivers  int           // меньший максимум не проверяется: на малых числах проценты шумят
+}
+
+// key — город и класс автомобиля
+type key struct {
+	city  string
+	class types.VehicleClass
+}
+
+type sample struct {
+	at     time.Time
+	online int
+}
+
+// Monitor хранит историю за окно в памяти экземпляра.
+// Не потокобезопасен: Check вызывается только из RunJob.
+type Monitor struct {
+	repo Repo
+	sink Sink
+	opts Options
+	l    logger.Logger
+
+	history map[key][]sample
+	firing  map[key]int // максимум за окно на момент срабатывания алерта
+}
+
+func New(repo Repo, sink Sink, opts Options, l logger.Logger) *Monitor {
+	return &Monitor{
+		repo:    repo,
+		sink:    sink,
+		opts:    opts,
+		l:       l,
+		history: make(map[key][]sample),
+		firing:  make(map[key]int),
+	}
+}
+
+// RunJob считает водителей онлайн сразу и затем каждые Interval до отмены контекста
+func (m *Monitor) RunJob(ctx context.Context) {
+	ctx = wrap.WithAction(ctx, "driver_supply_monitor_job")
+	if m.opts.Interval <= 0 {
+		m.l.Warn(ctx, "driver supply monitor disabled", "interval", m.opts.Interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(ctx, time.Now()); err != nil {
+			m.l.Error(wrap.ErrorCtx(ctx, err), "failed to check driver supply", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check добавляет текущие числа водителей в историю и отправляет алерты об изменениях.
+// Пара город/класс, пропавшая из выборки, считается с нулем водителей.
+func (m *Monitor) Check(ctx context.Context, now time.Time) error {
+	counts, err := m.repo.OnlineDrivers(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[key]int, len(counts))
+	for k := range m.history {
+		current[k] = 0
+	}
+	for _, c := range counts {
+		current[key{city: c.City, class: c.Class}] = c.Online
+	}
+
+	for k, online := range current {
+		metrics.DriverSupplyOnlineGauge.WithLabelValues(cityLabel(k.city), string(k.class)).Set(float64(online))
+
+		peak := m.observe(k, online, now)
+		before, firing := m.firing[k]
+		if peak == 0 && !firing {
+			delete(m.history, k) // никого не было за все окно — пара больше не отслеживается
+			continue
+		}
+
+		switch {
+		case !firing && peak >= m.opts.MinDrivers && m.dropped(peak, online):
+			alert := m.alert(k, types.AlertFiring, now,
+				fmt.Sprintf("Online %s drivers in %s dropped %.0f%% in %s: %d -> %d", k.class, cityLabel(k.city), dropPercent(peak, online), m.opts.Window, peak, online))
+			if m.send(ctx, alert) {
+				metrics.DriverSupplyAlertsTotal.WithLabelValues(cityLabel(k.city), string(k.class)).Inc()
+				m.firing[k] = peak
+			}
+		// падение длиннее окна не закрывает алерт: сравниваем с уровнем до падения
+		case firing && !m.dropped(before, online):
+			alert := m.alert(k, types.AlertResolved, now,
+				fmt.Sprintf("Online %s drivers in %s recovered: %d of %d", k.class, cityLabel(k.city), online, before))
+			if m.send(ctx, alert) {
+				delete(m.firing, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Monitor) dropped(from, to int) bool {
+	return dropPercent(from, to) >= m.opts.DropPercent
+}
+
+func dropPercent(from, to int) float64 {
+	if from <= 0 {
+		return 0
+	}
+	return 100 * float64(from-to) / float64(from)
+}
+
+// observe запоминает число водителей, отбрасывает точки старше окна и возвращает максимум за окно
+func (m *Monitor) observe(k key, online int, now time.Time) int {
+	samples := append(m.history[k], sample{at: now, online: online})
+
+	cutoff := now.Add(-m.opts.Window)
+	i := 0
+	for i < len(samples) && samples[i].at.Before(cutoff) {
+		i++
+	}
+	samples = samples[i:]
+	m.history[k] = samples
+
+	peak := 0
+	for _, s := range samples {
+		peak = max(peak, s.online)
+	}
+	return peak
+}
+
+func (m *Monitor) alert(k key, status types.AlertStatus, now time.Time, summary string) models.Alert {
+	return models.Alert{
+		Key:     alertName + ":" + cityLabel(k.city) + ":" + string(k.class),
+		Name:    alertName,
+		Status:  status,
+		Summary: summary,
+		Labels: map[string]string{
+			"city":          cityLabel(k.city),
+			"vehicle_class": string(k.class),
+		},
+		At: now.UTC(),
+	}
+}
+
+// send доставляет алерт; при ошибке состояние не меняется и алерт повторяется на следующей проверке
+func (m *Monitor) send(ctx context.Context, alert models.Alert) bool {
+	if err := m.sink.Send(ctx, alert); err != nil {
+		m.l.Error(wrap.ErrorCtx(ctx, err), "failed to send driver supply alert", err, "key", alert.Key, "status", alert.Status)
+		return false
+	}
+	m.l.Warn(ctx, "driver supply alert sent", "key", alert.Key, "status", alert.Status, "summary", alert.Summary)
+	return true
+}
+
+func cityLabel(city string) string {
+	if city == "" {
+		return noCity
+	}
+	return city
+}
@@ -0,0 +1,67 @@
+package supply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+)
+
+type fakeRepo struct{ counts []models.SupplyCount }
+
+func (r *fakeRepo) OnlineDrivers(context.Context) ([]models.SupplyCount, error) {
+	return r.counts, nil
+}
+
+type fakeSink struct{ alerts []models.Alert }
+
+func (s *fakeSink) Send(_ context.Context, a models.Alert) error {
+	s.alerts = append(s.alerts, a)
+	return nil
+}
+
+func TestMonitor_DropAndRecover(t *testing.T) {
+	repo := &fakeRepo{}
+	sink := &fakeSink{}
+	m := New(repo, sink, Options{Window: 10 * time.Minute, DropPercent: 30, MinDrivers: 10}, logger.InitLogger("test", "error"))
+
+	ctx := context.Background()
+	start := time.Now()
+	check := func(minute int, counts ...models.SupplyCount) {
+		t.Helper()
+		repo.counts = counts
+		if err := m.Check(ctx, start.Add(time.Duration(minute)*time.Minute)); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+	}
+	almaty := func(online int) models.SupplyCount {
+		return models.SupplyCount{City: "ALA", Class: types.ClassEconomy, Online: online}
+	}
+
+	check(0, almaty(40), models.SupplyCount{Class: types.ClassXL, Online: 5})
+	check(1, almaty(35))
+	if len(sink.alerts) != 0 {
+		t.Fatalf("expected no alerts for a 12%% drop and a small class, got %+v", sink.alerts)
+	}
+
+	// все водители пропали из выборки — пара считается с нулем
+	check(2)
+	if len(sink.alerts) != 1 || sink.alerts[0].Status != types.AlertFiring || sink.alerts[0].Key != "driver_supply_drop:ALA:ECONOMY" {
+		t.Fatalf("expected one firing alert, got %+v", sink.alerts)
+	}
+
+	// падение длиннее окна не закрывает алерт
+	check(15)
+	check(16, almaty(20))
+	if len(sink.alerts) != 1 {
+		t.Fatalf("expected alert to stay firing, got %+v", sink.alerts)
+	}
+
+	check(17, almaty(30))
+	if len(sink.alerts) != 2 || sink.alerts[1].Status != types.AlertResolved {
+		t.Fatalf("expected resolved alert after recovery to 75%%, got %+v", sink.alerts)
+	}
+}
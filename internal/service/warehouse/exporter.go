@@ -0,0 +1,266 @@
+// Package warehouse по расписанию выгружает обезличенные поездки и события поездок
+// во внешнее хранилище аналитики (S3 или BigQuery), чтобы аналитика не читала основную базу.
+package warehouse
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Версии схем наборов данных. Версия увеличивается при любом несовместимом изменении
+// записи: новая версия пишется в отдельный префикс или таблицу и выгружается с начала.
+const (
+	RidesSchemaVersion      = 1
+	RideEventsSchemaVersion = 1
+)
+
+// coordinatePrecision — знаков после запятой в координатах, около 110 м
+const coordinatePrecision = 3
+
+// leaseTimeout — аренда набора на одну пачку, с запасом на запись пачки в хранилище.
+// Истекшую аренду забирает другой экземпляр, курсор от этого не сдвигается дважды.
+const leaseTimeout = 10 * time.Minute
+
+type Repo interface {
+	Lease(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int, lease time.Duration) (*models.WarehouseWatermark, error)
+	Release(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int) error
+	Advance(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int, from, to models.WarehouseCursor, rows int) (bool, error)
+	Fail(ctx context.Context, dataset types.WarehouseDataset, schemaVersion int, errMsg string) error
+	Rides(ctx context.Context, after models.WarehouseCursor, until time.Time, limit int) ([]models.ExportRide, error)
+	RideEvents(ctx context.Context, after models.WarehouseCursor, until time.Time, limit int) ([]models.ExportRideEvent, error)
+}
+
+// Sink записывает пачку в хранилище. Повторная запись той же пачки не должна дублировать строки.
+type Sink interface {
+	Write(ctx context.Context, batch models.WarehouseBatch) error
+}
+
+type Options struct {
+	Interval     time.Duration // как часто запускать выгрузку
+	SettleDelay  time.Duration // строки моложе не выгружаются: транзакции, начатые раньше, могли еще не завершиться
+	BatchSize    int           // строк в одной пачке
+	PseudonymKey string        // ключ HMAC псевдонимов ID и имен пачек
+}
+
+// dataset — набор данных и чтение его следующей пачки
+type dataset struct {
+	name    types.WarehouseDataset
+	version int
+	next    func(ctx context.Context, after models.WarehouseCursor, until time.Time) ([]models.WarehouseRow, models.WarehouseCursor, error)
+}
+
+type Exporter struct {
+	repo     Repo
+	sink     Sink
+	opts     Options
+	datasets []dataset
+	l        logger.Logger
+}
+
+func New(repo Repo, sink Sink, opts Options, l logger.Logger) *Exporter {
+	e := &Exporter{
+		repo: repo,
+		sink: sink,
+		opts: opts,
+		l:    l,
+	}
+	e.datasets = []dataset{
+		{name: types.WarehouseRides, version: RidesSchemaVersion, next: e.nextRides},
+		{name: types.WarehouseRideEvents, version: RideEventsSchemaVersion, next: e.nextRideEvents},
+	}
+	return e
+}
+
+// RunJob выгружает новые строки сразу и затем каждые Interval до отмены контекста
+func (e *Exporter) RunJob(ctx context.Context) {
+	ctx = wrap.WithAction(ctx, "warehouse_export_job")
+	if e.opts.Interval <= 0 {
+		e.l.Warn(ctx, "warehouse export job disabled", "interval", e.opts.Interval.String())
+		return
+	}
+
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		e.ExportAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExportAll выгружает все наборы данных. Ошибка одного набора не останавливает остальные.
+func (e *Exporter) ExportAll(ctx context.Context) {
+	until := time.Now().Add(-e.opts.SettleDelay)
+	for _, ds := range e.datasets {
+		rows, err := e.export(ctx, ds, until)
+		if err != nil {
+			e.l.Error(wrap.ErrorCtx(ctx, err), "failed to export dataset to warehouse", err, "dataset", ds.name)
+			if errFail := e.repo.Fail(ctx, ds.name, ds.version, err.Error()); errFail != nil {
+				e.l.Error(wrap.ErrorCtx(ctx, errFail), "failed to record warehouse export error", errFail)
+			}
+			continue
+		}
+		if rows > 0 {
+			e.l.Info(ctx, "dataset exported to warehouse", "dataset", ds.name, "schema_version", ds.version, "rows", rows)
+		}
+	}
+}
+
+// export выгружает набор пачками до конца. Пачка читается под арендой набора и пишется
+// в хранилище вне транзакции: медленный приемник не держит соединение с базой.
+// Курсор сдвигается только после успешной записи и только с позиции, с которой пачка прочитана.
+func (e *Exporter) export(ctx context.Context, ds dataset, until time.Time) (int, error) {
+	total := 0
+	for {
+		wm, err := e.repo.Lease(ctx, ds.name, ds.version, leaseTimeout)
+		if err != nil || wm == nil {
+			return total, err
+		}
+
+		rows, to, err := ds.next(ctx, wm.Cursor, until)
+		if err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			return total, e.repo.Release(ctx, ds.name, ds.version)
+		}
+
+		batch := models.WarehouseBatch{
+			Dataset:       ds.name,
+			SchemaVersion: ds.version,
+			Name:          e.batchName(wm.Cursor),
+			From:          wm.Cursor,
+			To:            to,
+			Rows:          rows,
+		}
+		if err := e.sink.Write(ctx, batch); err != nil {
+			return total, fmt.Errorf("failed to write batch: %w", err)
+		}
+
+		advanced, err := e.repo.Advance(ctx, ds.name, ds.version, wm.Cursor, to, len(rows))
+		if err != nil {
+			return total, err
+		}
+		if !advanced {
+			// аренда истекла во время записи, и пачку выгрузил другой экземпляр
+			e.l.Warn(ctx, "warehouse batch already exported by another instance", "dataset", ds.name)
+			return total, nil
+		}
+
+		total += len(rows)
+		if len(rows) < e.opts.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (e *Exporter) nextRides(ctx context.Context, after models.WarehouseCursor, until time.Time) ([]models.WarehouseRow, models.WarehouseCursor, error) {
+	rides, err := e.repo.Rides(ctx, after, until, e.opts.BatchSize)
+	if err != nil || len(rides) == 0 {
+		return nil, after, err
+	}
+
+	rows := make([]models.WarehouseRow, 0, len(rides))
+	for _, ride := range rides {
+		rec := e.rideRecord(ride)
+		rows = append(rows, models.WarehouseRow{ID: rec.RideRef, Data: rec})
+	}
+
+	last := rides[len(rides)-1]
+	return rows, models.WarehouseCursor{At: last.FinishedAt, ID: last.ID}, nil
+}
+
+func (e *Exporter) nextRideEvents(ctx context.Context, after models.WarehouseCursor, until time.Time) ([]models.WarehouseRow, models.WarehouseCursor, error) {
+	events, err := e.repo.RideEvents(ctx, after, until, e.opts.BatchSize)
+	if err != nil || len(events) == 0 {
+		return nil, after, err
+	}
+
+	rows := make([]models.WarehouseRow, 0, len(events))
+	for _, ev := range events {
+		rec := models.WarehouseRideEventRecord{
+			SchemaVersion: RideEventsSchemaVersion,
+			EventRef:      e.ref(ev.ID),
+			RideRef:       e.ref(ev.RideID),
+			EventType:     ev.EventType,
+			CreatedAt:     ev.CreatedAt,
+		}
+		rows = append(rows, models.WarehouseRow{ID: rec.EventRef, Data: rec})
+	}
+
+	last := events[len(events)-1]
+	return rows, models.WarehouseCursor{At: last.CreatedAt, ID: last.ID}, nil
+}
+
+// rideRecord обезличивает поездку: ID заменяются псевдонимами, координаты округляются
+func (e *Exporter) rideRecord(ride models.ExportRide) models.WarehouseRideRecord {
+	rec := models.WarehouseRideRecord{
+		SchemaVersion:   RidesSchemaVersion,
+		RideRef:         e.ref(ride.ID),
+		PassengerRef:    e.ref(ride.PassengerID),
+		VehicleType:     ride.VehicleType,
+		Status:          ride.Status,
+		PaymentMethod:   ride.PaymentMethod,
+		RequestedAt:     ride.RequestedAt,
+		MatchedAt:       ride.MatchedAt,
+		StartedAt:       ride.StartedAt,
+		CompletedAt:     ride.CompletedAt,
+		CancelledAt:     ride.CancelledAt,
+		EstimatedFare:   ride.EstimatedFare,
+		FinalFare:       ride.FinalFare,
+		CancellationFee: ride.CancellationFee,
+		SurgeMultiplier: ride.SurgeMultiplier,
+		DistanceKm:      ride.DistanceKm,
+		Co2Grams:        ride.Co2Grams,
+	}
+	if ride.DriverID != nil {
+		ref := e.ref(*ride.DriverID)
+		rec.DriverRef = &ref
+	}
+	if ride.ServedVehicleType != "" {
+		rec.ServedVehicleType = &ride.ServedVehicleType
+	}
+	if ride.Pickup != nil {
+		rec.PickupLat, rec.PickupLng = roundCoordinate(ride.Pickup.Latitude), roundCoordinate(ride.Pickup.Longitude)
+	}
+	if ride.Destination != nil {
+		rec.DestinationLat, rec.DestinationLng = roundCoordinate(ride.Destination.Latitude), roundCoordinate(ride.Destination.Longitude)
+	}
+	return rec
+}
+
+// batchName — имя пачки по курсору до нее. ID в курсоре заменен псевдонимом,
+// чтобы исходный ID не попал в имя объекта в хранилище.
+func (e *Exporter) batchName(from models.WarehouseCursor) string {
+	return fmt.Sprintf("%d-%s", from.At.UnixNano(), e.ref(from.ID))
+}
+
+// ref — стабильный псевдоним ID: один и тот же ID во всех наборах дает один псевдоним,
+// поэтому поездки и события соединяются в хранилище, а исходный ID не восстанавливается без ключа
+func (e *Exporter) ref(id uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(e.opts.PseudonymKey))
+	mac.Write([]byte(id.String()))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+func roundCoordinate(v float64) *float64 {
+	p := math.Pow10(coordinatePrecision)
+	r := math.Round(v*p) / p
+	return &r
+}
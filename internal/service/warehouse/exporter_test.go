@@ -0,0 +1,138 @@
+package warehouse
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// memRepo — прогресс выгрузки поездок в памяти; leased — набор арендован
+type memRepo struct {
+	Repo
+	rides  []models.ExportRide
+	wm     models.WarehouseWatermark
+	leased bool
+}
+
+func (r *memRepo) Lease(context.Context, types.WarehouseDataset, int, time.Duration) (*models.WarehouseWatermark, error) {
+	if r.leased {
+		return nil, nil
+	}
+	r.leased = true
+	wm := r.wm
+	return &wm, nil
+}
+
+func (r *memRepo) Release(context.Context, types.WarehouseDataset, int) error {
+	r.leased = false
+	return nil
+}
+
+func (r *memRepo) Advance(_ context.Context, _ types.WarehouseDataset, _ int, from, to models.WarehouseCursor, rows int) (bool, error) {
+	if r.wm.Cursor != from {
+		return false, nil
+	}
+	r.wm.Cursor = to
+	r.wm.RowsExported += int64(rows)
+	r.leased = false
+	return true, nil
+}
+
+func (r *memRepo) Rides(_ context.Context, after models.WarehouseCursor, _ time.Time, limit int) ([]models.ExportRide, error) {
+	var out []models.ExportRide
+	for _, ride := range r.rides {
+		if ride.FinishedAt.After(after.At) && len(out) < limit {
+			out = append(out, ride)
+		}
+	}
+	return out, nil
+}
+
+// recordingSink запоминает пачки; onWrite вызывается во время записи
+type recordingSink struct {
+	batches []models.WarehouseBatch
+	onWrite func()
+}
+
+func (s *recordingSink) Write(_ context.Context, batch models.WarehouseBatch) error {
+	s.batches = append(s.batches, batch)
+	if s.onWrite != nil {
+		s.onWrite()
+	}
+	return nil
+}
+
+func finishedRides(n int) []models.ExportRide {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rides := make([]models.ExportRide, n)
+	for i := range rides {
+		rides[i] = models.ExportRide{ID: uuid.New(), PassengerID: uuid.New(), Status: "COMPLETED", FinishedAt: start.Add(time.Duration(i+1) * time.Minute)}
+	}
+	return rides
+}
+
+func newExporter(repo Repo, sink Sink) *Exporter {
+	return New(repo, sink, Options{BatchSize: 2, PseudonymKey: "0123456789abcdef"}, logger.InitLogger("test", "error"))
+}
+
+// Пачки выгружаются до конца, курсор сдвигается после записи, имя пачки не раскрывает ID
+func TestExport_AdvancesAfterWrite(t *testing.T) {
+	repo := &memRepo{rides: finishedRides(3)}
+	sink := &recordingSink{}
+	e := newExporter(repo, sink)
+
+	total, err := e.export(context.Background(), e.datasets[0], time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(sink.batches) != 2 || repo.wm.RowsExported != 3 || repo.wm.Cursor.ID != repo.rides[2].ID {
+		t.Fatalf("exported %d rows in %d batches, watermark %+v; want 3 rows in 2 batches", total, len(sink.batches), repo.wm)
+	}
+	if repo.leased {
+		t.Fatal("lease must be released after the export")
+	}
+	for _, b := range sink.batches {
+		if strings.Contains(b.Name, b.From.ID.String()) {
+			t.Fatalf("batch name %q exposes the cursor id", b.Name)
+		}
+	}
+}
+
+// Набор, арендованный другим экземпляром, не выгружается
+func TestExport_SkipsLeasedDataset(t *testing.T) {
+	repo := &memRepo{rides: finishedRides(1), leased: true}
+	sink := &recordingSink{}
+	e := newExporter(repo, sink)
+
+	if _, err := e.export(context.Background(), e.datasets[0], time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.batches) != 0 {
+		t.Fatalf("leased dataset must not be written, got %d batches", len(sink.batches))
+	}
+}
+
+// Если аренда истекла во время записи и пачку выгрузил другой экземпляр, курсор не сдвигается второй раз
+func TestExport_LostLeaseDoesNotAdvanceTwice(t *testing.T) {
+	repo := &memRepo{rides: finishedRides(2)}
+	sink := &recordingSink{}
+	sink.onWrite = func() {
+		repo.wm.Cursor = models.WarehouseCursor{At: repo.rides[1].FinishedAt, ID: repo.rides[1].ID}
+		repo.wm.RowsExported = 2
+	}
+	e := newExporter(repo, sink)
+
+	total, err := e.export(context.Background(), e.datasets[0], time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || repo.wm.RowsExported != 2 {
+		t.Fatalf("exported %d, rows_exported %d; want 0, 2", total, repo.wm.RowsExported)
+	}
+}
@@ -0,0 +1,281 @@
+// Package simulation проигрывает исторические запросы поездок и координаты водителей
+// против альтернативных правил матчинга и surge ценообразования и считает KPI
+// (время до назначения, загрузка водителей, выручка) для сравнения политик.
+//
+// Модель упрощенная и детерминированная: водитель появляется на последней известной
+// координате, назначается ближайший свободный водитель нужного класса в радиусе,
+// занят он на время подачи (по расстоянию и средней скорости) плюс историческую
+// длительность поездки. Отказ пассажира из-за surge решается хэшем ride_id,
+// поэтому повторный запуск на тех же данных дает тот же результат.
+package simulation
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/geohash"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Calculator — расчеты расстояния, длительности и стоимости, как в ride-service
+type Calculator interface {
+	Distance(p1, p2 models.Location) float64
+	Duration(distanceKm float64) int
+	Fare(rideType string, distanceKm float64, durationMin int) models.FareBreakdown
+}
+
+type driverState struct {
+	class     types.VehicleClass
+	location  models.Location
+	lastSeen  time.Time
+	busyUntil time.Time
+}
+
+// attempt — попытка найти водителя для запроса в момент at
+type attempt struct {
+	at         time.Time
+	req        int // индекс в History.Requests
+	multiplier float64
+}
+
+type attemptQueue []attempt
+
+func (q attemptQueue) Len() int { return len(q) }
+func (q attemptQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].req < q[j].req
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q attemptQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *attemptQueue) Push(x any)   { *q = append(*q, x.(attempt)) }
+func (q *attemptQueue) Pop() any {
+	old := *q
+	a := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return a
+}
+
+type engine struct {
+	h      History
+	p      Policy
+	calc   Calculator
+	nextPg int // следующий непроигранный ping
+
+	drivers map[uuid.UUID]*driverState
+	demand  map[string][]time.Time // geohash ячейка -> время запросов в окне surge
+
+	kpi         KPI
+	waits       []time.Duration
+	multipliers float64
+}
+
+// Run проигрывает историю с политикой p
+func Run(h History, p Policy, calc Calculator) KPI {
+	e := &engine{
+		h:       h,
+		p:       p.withDefaults(),
+		calc:    calc,
+		drivers: make(map[uuid.UUID]*driverState),
+		demand:  make(map[string][]time.Time),
+	}
+	return e.run()
+}
+
+func (e *engine) run() KPI {
+	e.kpi.Policy = e.p.Name
+	e.kpi.Requests = len(e.h.Requests)
+
+	q := &attemptQueue{}
+	next := 0 // следующий запрос, еще не попавший в очередь
+
+	for next < len(e.h.Requests) || q.Len() > 0 {
+		// новые запросы обрабатываются в момент создания, повторы — по расписанию
+		if next < len(e.h.Requests) && (q.Len() == 0 || !(*q)[0].at.Before(e.h.Requests[next].RequestedAt)) {
+			req := e.h.Requests[next]
+			e.advance(req.RequestedAt)
+
+			multiplier := e.quote(req)
+			if !e.accepts(req.RideID, multiplier) {
+				e.kpi.PricedOut++
+			} else {
+				heap.Push(q, attempt{at: req.RequestedAt, req: next, multiplier: multiplier})
+			}
+			next++
+			continue
+		}
+
+		a := heap.Pop(q).(attempt)
+		e.advance(a.at)
+
+		if e.match(a) {
+			continue
+		}
+
+		retry := a.at.Add(time.Duration(e.p.Matching.RetryInterval))
+		if retry.Sub(e.h.Requests[a.req].RequestedAt) > time.Duration(e.p.Matching.MaxWait) {
+			e.kpi.Unmatched++
+			continue
+		}
+		a.at = retry
+		heap.Push(q, a)
+	}
+
+	e.finish()
+	return e.kpi
+}
+
+// advance проигрывает координаты водителей до момента t
+func (e *engine) advance(t time.Time) {
+	for ; e.nextPg < len(e.h.Pings) && !e.h.Pings[e.nextPg].At.After(t); e.nextPg++ {
+		ping := e.h.Pings[e.nextPg]
+
+		d, ok := e.drivers[ping.DriverID]
+		if !ok {
+			d = &driverState{class: ping.VehicleType}
+			e.drivers[ping.DriverID] = d
+		}
+		d.lastSeen = ping.At
+
+		// пока водитель занят в симуляции, его историческое положение не совпадает с симулируемым
+		if ping.At.Before(d.busyUntil) {
+			continue
+		}
+		d.location = ping.Location
+	}
+}
+
+// online сообщает, что водитель на линии и свободен в момент t
+func (e *engine) online(d *driverState, t time.Time) bool {
+	return !d.busyUntil.After(t) && t.Sub(d.lastSeen) <= time.Duration(e.p.Matching.StaleAfter)
+}
+
+// quote возвращает surge коэффициент для запроса по спросу и свободным водителям в ячейке
+func (e *engine) quote(req Request) float64 {
+	s := e.p.Surge
+	if !s.Enabled {
+		return 1
+	}
+
+	cell := geohash.Encode(req.Pickup.Latitude, req.Pickup.Longitude, s.CellPrecision)
+
+	// спрос — запросы в ячейке за окно, включая текущий
+	since := req.RequestedAt.Add(-time.Duration(s.Window))
+	times := e.demand[cell]
+	for len(times) > 0 && times[0].Before(since) {
+		times = times[1:]
+	}
+	times = append(times, req.RequestedAt)
+	e.demand[cell] = times
+
+	supply := 0
+	for _, d := range e.drivers {
+		if d.class == req.VehicleType && e.online(d, req.RequestedAt) &&
+			geohash.Encode(d.location.Latitude, d.location.Longitude, s.CellPrecision) == cell {
+			supply++
+		}
+	}
+
+	ratio := float64(len(times)) / math.Max(float64(supply), 1)
+	if ratio <= s.Threshold {
+		return 1
+	}
+	return math.Min(s.Cap, 1+s.Step*(ratio-s.Threshold))
+}
+
+// accepts решает, соглашается ли пассажир на коэффициент. Решение детерминировано по ride_id.
+func (e *engine) accepts(rideID uuid.UUID, multiplier float64) bool {
+	if multiplier <= 1 {
+		return true
+	}
+	keep := 1 - e.p.Surge.Elasticity*(multiplier-1)
+
+	h := fnv.New64a()
+	h.Write(rideID[:])
+	u := float64(h.Sum64()%10000) / 10000
+	return u < keep
+}
+
+// match назначает ближайшего свободного водителя нужного класса в радиусе
+func (e *engine) match(a attempt) bool {
+	req := e.h.Requests[a.req]
+
+	var (
+		best     *driverState
+		bestDist = math.Inf(1)
+	)
+	for _, d := range e.drivers {
+		if d.class != req.VehicleType || !e.online(d, a.at) {
+			continue
+		}
+		dist := e.calc.Distance(d.location, req.Pickup)
+		if dist <= e.p.Matching.RadiusKm && dist < bestDist {
+			best, bestDist = d, dist
+		}
+	}
+	if best == nil {
+		return false
+	}
+
+	tripKm := e.calc.Distance(req.Pickup, req.Destination)
+	trip := req.tripDuration()
+	if trip == 0 {
+		trip = time.Duration(e.calc.Duration(tripKm)) * time.Minute
+	}
+	approach := time.Duration(e.calc.Duration(bestDist)) * time.Minute
+
+	best.busyUntil = a.at.Add(approach + trip)
+	best.location = req.Destination
+	e.kpi.busy += approach + trip
+
+	fare := req.Fare
+	if fare == 0 {
+		fare = e.calc.Fare(string(req.VehicleType), tripKm, int(math.Ceil(trip.Minutes()))).Total
+	}
+
+	e.kpi.Matched++
+	e.kpi.Revenue += fare * a.multiplier
+	e.multipliers += a.multiplier
+	e.waits = append(e.waits, a.at.Sub(req.RequestedAt))
+	return true
+}
+
+func (e *engine) finish() {
+	e.kpi.online = onlineTime(e.h.Pings, time.Duration(e.p.Matching.StaleAfter))
+	if e.kpi.Matched > 0 {
+		e.kpi.AvgMultiplier = e.multipliers / float64(e.kpi.Matched)
+	}
+	e.kpi.summarize(e.waits)
+}
+
+// onlineTime суммирует время на линии водителей: интервалы между координатами
+// не длиннее staleAfter, после последней координаты водитель на линии еще staleAfter
+func onlineTime(pings []Ping, staleAfter time.Duration) time.Duration {
+	last := make(map[uuid.UUID]time.Time)
+	var total time.Duration
+	for _, p := range pings {
+		if prev, ok := last[p.DriverID]; ok {
+			total += min(p.At.Sub(prev), staleAfter)
+		}
+		last[p.DriverID] = p.At
+	}
+	return total + time.Duration(len(last))*staleAfter
+}
+
+// percentile возвращает q-квантиль отсортированных значений
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+func sortDurations(d []time.Duration) {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+}
@@ -0,0 +1,83 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	ridecalc "github.com/Temutjin2k/ride-hail-system/internal/service/calculator"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// history — один водитель в центре Алматы и два запроса подряд рядом с ним
+func history(t0 time.Time) History {
+	driverID := uuid.New()
+	center := models.Location{Latitude: 43.238949, Longitude: 76.889709}
+
+	var pings []Ping
+	for i := 0; i <= 30; i++ {
+		pings = append(pings, Ping{
+			DriverID:    driverID,
+			VehicleType: types.ClassEconomy,
+			Location:    center,
+			At:          t0.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	request := func(at time.Duration) Request {
+		return Request{
+			RideID:      uuid.New(),
+			VehicleType: types.ClassEconomy,
+			RequestedAt: t0.Add(at),
+			Pickup:      models.Location{Latitude: 43.2450, Longitude: 76.8950},
+			Destination: models.Location{Latitude: 43.2700, Longitude: 76.9300},
+		}
+	}
+
+	return History{
+		From:     t0,
+		To:       t0.Add(time.Hour),
+		Requests: []Request{request(time.Minute), request(2 * time.Minute)},
+		Pings:    pings,
+	}
+}
+
+func TestRun_MatchingPolicy(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := history(t0)
+	calc := ridecalc.New()
+
+	current := Run(h, DefaultPolicy(), calc)
+	if current.Matched != 1 || current.Unmatched != 1 {
+		t.Fatalf("current policy: matched %d, unmatched %d, want 1 and 1", current.Matched, current.Unmatched)
+	}
+	if current.AvgTimeToMatch != 0 {
+		t.Errorf("current policy: avg time to match %s, want 0", current.AvgTimeToMatch)
+	}
+
+	// второй пассажир дожидается, пока водитель освободится
+	patient := Policy{Name: "patient", Matching: MatchingPolicy{MaxWait: Duration(30 * time.Minute)}}
+	got := Run(h, patient, calc)
+	if got.Matched != 2 || got.Unmatched != 0 {
+		t.Fatalf("patient policy: matched %d, unmatched %d, want 2 and 0", got.Matched, got.Unmatched)
+	}
+	if got.P90TimeToMatch <= 0 || got.Utilization <= current.Utilization {
+		t.Errorf("patient policy: p90 %s, utilization %.2f, want waiting and higher utilization", got.P90TimeToMatch, got.Utilization)
+	}
+}
+
+func TestRun_SurgePricesOut(t *testing.T) {
+	h := history(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	// коэффициент всегда упирается в cap, а elasticity 1 при +1.0 теряет всех пассажиров
+	p := Policy{Name: "surge", Surge: SurgePolicy{Enabled: true, Threshold: 0.5, Step: 10, Cap: 2, Elasticity: 1}}
+
+	first := Run(h, p, ridecalc.New())
+	if first.PricedOut != 2 || first.Matched != 0 {
+		t.Fatalf("priced out %d, matched %d, want 2 and 0", first.PricedOut, first.Matched)
+	}
+	if again := Run(h, p, ridecalc.New()); again != first {
+		t.Errorf("simulation is not deterministic: %+v != %+v", again, first)
+	}
+}
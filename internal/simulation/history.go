@@ -0,0 +1,48 @@
+package simulation
+
+import (
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/models"
+	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+// Request — исторический запрос поездки, восстановленный по ride_events
+type Request struct {
+	RideID      uuid.UUID
+	VehicleType types.VehicleClass
+	RequestedAt time.Time
+	Pickup      models.Location
+	Destination models.Location
+
+	// исторический исход, nil — событие не произошло
+	MatchedAt   *time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	Fare        float64 // итоговая или оценочная стоимость, 0 — неизвестна
+}
+
+// tripDuration возвращает историческую длительность поездки, 0 — поездка не состоялась
+func (r Request) tripDuration() time.Duration {
+	if r.StartedAt == nil || r.CompletedAt == nil {
+		return 0
+	}
+	return r.CompletedAt.Sub(*r.StartedAt)
+}
+
+// Ping — координата водителя из location_history
+type Ping struct {
+	DriverID    uuid.UUID
+	VehicleType types.VehicleClass
+	Location    models.Location
+	At          time.Time
+}
+
+// History — данные за период симуляции. Requests и Pings должны быть отсортированы по времени.
+type History struct {
+	From     time.Time
+	To       time.Time
+	Requests []Request
+	Pings    []Ping
+}
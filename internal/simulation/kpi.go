@@ -0,0 +1,89 @@
+package simulation
+
+import (
+	"time"
+)
+
+// HistoricalPolicy — имя строки KPI, посчитанной по фактическим данным
+const HistoricalPolicy = "historical"
+
+// KPI — показатели прогона одной политики
+type KPI struct {
+	Policy string `json:"policy"`
+
+	Requests  int `json:"requests"`
+	Matched   int `json:"matched"`
+	Unmatched int `json:"unmatched"`  // водитель не найден за max_wait
+	PricedOut int `json:"priced_out"` // пассажир отказался из-за surge
+
+	MatchRate      float64       `json:"match_rate"`
+	AvgTimeToMatch time.Duration `json:"avg_time_to_match"`
+	P90TimeToMatch time.Duration `json:"p90_time_to_match"`
+
+	Utilization   float64 `json:"driver_utilization"` // доля времени на линии, проведенная на заказах
+	Revenue       float64 `json:"revenue"`
+	AvgMultiplier float64 `json:"avg_multiplier"`
+
+	busy   time.Duration
+	online time.Duration
+}
+
+func (k *KPI) summarize(waits []time.Duration) {
+	if k.Requests > 0 {
+		k.MatchRate = float64(k.Matched) / float64(k.Requests)
+	}
+	if k.online > 0 {
+		k.Utilization = min(k.busy.Seconds()/k.online.Seconds(), 1)
+	}
+
+	if len(waits) == 0 {
+		return
+	}
+	sortDurations(waits)
+
+	var total time.Duration
+	for _, w := range waits {
+		total += w
+	}
+	k.AvgTimeToMatch = total / time.Duration(len(waits))
+	k.P90TimeToMatch = percentile(waits, 0.9)
+}
+
+// Historical считает те же KPI по фактическому исходу поездок, чтобы сравнить
+// симуляцию текущей политики с реальностью и оценить погрешность модели
+func Historical(h History, staleAfter time.Duration) KPI {
+	k := KPI{
+		Policy:        HistoricalPolicy,
+		Requests:      len(h.Requests),
+		AvgMultiplier: 1,
+		online:        onlineTime(h.Pings, staleAfter),
+	}
+
+	var waits []time.Duration
+	for _, r := range h.Requests {
+		if r.MatchedAt == nil {
+			k.Unmatched++
+			continue
+		}
+		k.Matched++
+		waits = append(waits, r.MatchedAt.Sub(r.RequestedAt))
+
+		if r.CompletedAt != nil {
+			k.busy += r.CompletedAt.Sub(*r.MatchedAt)
+			k.Revenue += r.Fare
+		}
+	}
+
+	k.summarize(waits)
+	return k
+}
+
+// Compare проигрывает историю с каждой политикой. Первая строка — фактические KPI.
+func Compare(h History, policies []Policy, calc Calculator) []KPI {
+	result := make([]KPI, 0, len(policies)+1)
+	result = append(result, Historical(h, time.Duration(DefaultPolicy().Matching.StaleAfter)))
+	for _, p := range policies {
+		result = append(result, Run(h, p, calc))
+	}
+	return result
+}
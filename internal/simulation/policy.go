@@ -0,0 +1,140 @@
+package simulation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Duration — time.Duration, в JSON сценариев записывается строкой: "90s", "2m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"90s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Policy — проверяемая конфигурация матчинга и surge ценообразования
+type Policy struct {
+	Name     string         `json:"name"`
+	Matching MatchingPolicy `json:"matching"`
+	Surge    SurgePolicy    `json:"surge"`
+}
+
+// MatchingPolicy — правила поиска водителя. Нулевые поля берутся из DefaultPolicy.
+type MatchingPolicy struct {
+	RadiusKm      float64  `json:"radius_km"`      // радиус поиска водителей вокруг точки подачи
+	MaxWait       Duration `json:"max_wait"`       // сколько запрос ждет водителя, прежде чем считается несостоявшимся
+	RetryInterval Duration `json:"retry_interval"` // как часто повторяется поиск, пока свободных водителей нет
+	StaleAfter    Duration `json:"stale_after"`    // водитель без координат дольше этого считается офлайн
+}
+
+// SurgePolicy — повышающий коэффициент при нехватке водителей в ячейке.
+// Коэффициент = 1 + Step * (спрос/предложение - Threshold), не больше Cap.
+// Нулевые поля, кроме Enabled и Elasticity, берутся из DefaultPolicy.
+type SurgePolicy struct {
+	Enabled       bool     `json:"enabled"`
+	CellPrecision int      `json:"cell_precision"` // длина geohash ячейки, 5 — около 5км x 5км
+	Window        Duration `json:"window"`         // за какое время считается спрос в ячейке
+	Threshold     float64  `json:"threshold"`      // отношение спрос/предложение, с которого включается surge
+	Step          float64  `json:"step"`           // прирост коэффициента на единицу отношения выше порога
+	Cap           float64  `json:"cap"`            // максимальный коэффициент
+	Elasticity    float64  `json:"elasticity"`     // доля пассажиров, отказавшихся от поездки на каждые +1.0 коэффициента
+}
+
+// DefaultPolicy — правила, с которыми работает driver-service: радиус 5км,
+// поиск водителя до 2 минут, без surge
+func DefaultPolicy() Policy {
+	return Policy{
+		Name: "current",
+		Matching: MatchingPolicy{
+			RadiusKm:      5,
+			MaxWait:       Duration(2 * time.Minute),
+			RetryInterval: Duration(10 * time.Second),
+			StaleAfter:    Duration(5 * time.Minute),
+		},
+		Surge: SurgePolicy{
+			CellPrecision: 5,
+			Window:        Duration(10 * time.Minute),
+			Threshold:     1,
+			Step:          0.25,
+			Cap:           2,
+		},
+	}
+}
+
+// withDefaults дополняет не заданные поля значениями DefaultPolicy
+func (p Policy) withDefaults() Policy {
+	def := DefaultPolicy()
+	if p.Matching.RadiusKm == 0 {
+		p.Matching.RadiusKm = def.Matching.RadiusKm
+	}
+	if p.Matching.MaxWait == 0 {
+		p.Matching.MaxWait = def.Matching.MaxWait
+	}
+	if p.Matching.RetryInterval == 0 {
+		p.Matching.RetryInterval = def.Matching.RetryInterval
+	}
+	if p.Matching.StaleAfter == 0 {
+		p.Matching.StaleAfter = def.Matching.StaleAfter
+	}
+	if p.Surge.CellPrecision == 0 {
+		p.Surge.CellPrecision = def.Surge.CellPrecision
+	}
+	if p.Surge.Window == 0 {
+		p.Surge.Window = def.Surge.Window
+	}
+	if p.Surge.Threshold == 0 {
+		p.Surge.Threshold = def.Surge.Threshold
+	}
+	if p.Surge.Step == 0 {
+		p.Surge.Step = def.Surge.Step
+	}
+	if p.Surge.Cap == 0 {
+		p.Surge.Cap = def.Surge.Cap
+	}
+	return p
+}
+
+// Validate проверяет сценарий после подстановки значений по умолчанию
+func (p Policy) Validate() error {
+	p = p.withDefaults()
+
+	var errs []error
+	if p.Name == "" {
+		errs = append(errs, errors.New("name must be provided"))
+	}
+	if p.Matching.RadiusKm < 0 {
+		errs = append(errs, errors.New("matching.radius_km must be positive"))
+	}
+	if p.Matching.MaxWait < 0 || p.Matching.RetryInterval < 0 || p.Matching.StaleAfter < 0 {
+		errs = append(errs, errors.New("matching durations must be positive"))
+	}
+	if p.Surge.CellPrecision < 1 || p.Surge.CellPrecision > 12 {
+		errs = append(errs, errors.New("surge.cell_precision must be between 1 and 12"))
+	}
+	if p.Surge.Window < 0 || p.Surge.Threshold < 0 || p.Surge.Step < 0 || p.Surge.Elasticity < 0 {
+		errs = append(errs, errors.New("surge parameters must not be negative"))
+	}
+	if p.Surge.Cap < 1 {
+		errs = append(errs, errors.New("surge.cap must be at least 1"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("policy %q: %w", p.Name, err)
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package main
 
-import "github.com/Temutjin2k/ride-hail-system/cmd/ride"
+import (
+	_ "time/tzdata" // часовые пояса городов, в alpine образе нет tzdata
+
+	"github.com/Temutjin2k/ride-hail-system/cmd/ride"
+)
 
 func main() {
 	ride.Run()
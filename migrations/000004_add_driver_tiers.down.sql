@@ -0,0 +1,13 @@
+begin;
+
+DROP INDEX IF EXISTS idx_driver_offers_driver_created;
+DROP TABLE IF EXISTS driver_offers;
+DROP TABLE IF EXISTS "offer_outcome";
+
+ALTER TABLE drivers
+    DROP COLUMN IF EXISTS tier_updated_at,
+    DROP COLUMN IF EXISTS tier;
+
+DROP TABLE IF EXISTS "driver_tier";
+
+commit;
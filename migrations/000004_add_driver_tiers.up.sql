@@ -0,0 +1,39 @@
+begin;
+
+-- Driver tier enumeration
+create table "driver_tier"("value" text not null primary key);
+insert into
+    "driver_tier" ("value")
+values
+    ('BRONZE'),   -- Default tier for new drivers
+    ('SILVER'),   -- Good rating and acceptance
+    ('GOLD')      -- Top drivers, first access to PREMIUM offers
+;
+
+alter table drivers
+    add column tier text not null default 'BRONZE' references "driver_tier"(value),
+    add column tier_updated_at timestamptz;
+
+-- Offer outcome enumeration
+create table "offer_outcome"("value" text not null primary key);
+insert into
+    "offer_outcome" ("value")
+values
+    ('ACCEPTED'),  -- Driver accepted the offer
+    ('DECLINED'),  -- Driver declined the offer
+    ('EXPIRED')    -- Driver did not respond in time
+;
+
+-- Ride offers sent to drivers, used to compute acceptance rate
+create table driver_offers (
+    id uuid primary key default gen_random_uuid(),
+    offer_id uuid not null,
+    driver_id uuid not null references drivers(id),
+    ride_id uuid not null references rides(id),
+    outcome text not null references "offer_outcome"(value),
+    created_at timestamptz not null default now()
+);
+
+create index idx_driver_offers_driver_created on driver_offers(driver_id, created_at);
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP INDEX IF EXISTS idx_driver_blocked_passengers_passenger;
+DROP TABLE IF EXISTS driver_blocked_passengers;
+
+commit;
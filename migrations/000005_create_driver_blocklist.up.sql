@@ -0,0 +1,15 @@
+begin;
+
+-- Passengers blocked by drivers; matching never offers their rides to that driver
+create table driver_blocked_passengers (
+    driver_id uuid not null references drivers(id),
+    passenger_id uuid not null references users(id),
+    reason text,
+    created_at timestamptz not null default now(),
+    primary key (driver_id, passenger_id),
+    check (driver_id <> passenger_id)
+);
+
+create index idx_driver_blocked_passengers_passenger on driver_blocked_passengers(passenger_id);
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS "notification_event";
+DROP TABLE IF EXISTS "notification_channel";
+
+commit;
@@ -0,0 +1,30 @@
+begin;
+
+create table "notification_channel"("value" text not null primary key);
+insert into
+    "notification_channel" ("value")
+values
+    ('PUSH'),         -- Push notification to mobile app
+    ('SMS'),          -- SMS to the phone from user attrs
+    ('EMAIL')         -- Email to the user's address
+;
+
+create table "notification_event"("value" text not null primary key);
+insert into
+    "notification_event" ("value")
+values
+    ('RIDE_UPDATES'),     -- Driver matched/arrived, ride cancelled
+    ('RECEIPTS'),         -- Receipt after completed ride
+    ('PROMOTIONS'),       -- Marketing messages
+    ('ACCOUNT_SECURITY')  -- Critical, always delivered
+;
+
+-- Opted-in channels and event types; users without a row receive everything
+create table notification_preferences (
+    user_id uuid primary key references users(id),
+    channels text[] not null,
+    event_types text[] not null,
+    updated_at timestamptz not null default now()
+);
+
+commit;
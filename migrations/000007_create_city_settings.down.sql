@@ -0,0 +1,6 @@
+begin;
+
+DROP TABLE IF EXISTS city_settings;
+DROP TABLE IF EXISTS "outside_hours_policy";
+
+commit;
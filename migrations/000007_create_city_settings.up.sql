@@ -0,0 +1,34 @@
+begin;
+
+create table "outside_hours_policy"("value" text not null primary key);
+insert into
+    "outside_hours_policy" ("value")
+values
+    ('REJECT'),       -- Rides requested outside operational hours are rejected
+    ('SURCHARGE')     -- Rides requested outside operational hours get the night multiplier
+;
+
+-- Operational rules per city; times are local "HH:MM" in the city's timezone
+create table city_settings (
+    code varchar(20) primary key,
+    name varchar(100) not null,
+    center_latitude decimal(10,8) not null check (center_latitude between -90 and 90),
+    center_longitude decimal(11,8) not null check (center_longitude between -180 and 180),
+    radius_km decimal(6,2) not null check (radius_km > 0),
+    timezone text not null,
+    open_time text not null default '00:00' check (open_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    close_time text not null default '00:00' check (close_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    night_start text not null default '00:00' check (night_start ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    night_end text not null default '00:00' check (night_end ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    night_multiplier decimal(4,2) not null default 1.0 check (night_multiplier between 1.0 and 5.0),
+    outside_hours_policy text not null default 'REJECT' references "outside_hours_policy"(value),
+    updated_at timestamptz not null default now()
+);
+
+insert into
+    city_settings (code, name, center_latitude, center_longitude, radius_km, timezone, night_start, night_end, night_multiplier, outside_hours_policy)
+values
+    ('ALA', 'Almaty', 43.238949, 76.889709, 30, 'Asia/Almaty', '23:00', '06:00', 1.2, 'SURCHARGE')
+;
+
+commit;
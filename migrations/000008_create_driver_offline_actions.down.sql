@@ -0,0 +1,8 @@
+begin;
+
+DROP INDEX IF EXISTS idx_driver_offline_actions_performed;
+DROP TABLE IF EXISTS driver_offline_actions;
+DROP TABLE IF EXISTS "reconcile_status";
+DROP TABLE IF EXISTS "offline_action_type";
+
+commit;
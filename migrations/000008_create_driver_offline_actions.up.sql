@@ -0,0 +1,38 @@
+begin;
+
+create table "offline_action_type"("value" text not null primary key);
+insert into
+    "offline_action_type" ("value")
+values
+    ('GO_ONLINE'),      -- Driver went online without network
+    ('GO_OFFLINE'),     -- Driver went offline without network
+    ('START_RIDE'),     -- Ride started without network
+    ('COMPLETE_RIDE')   -- Ride completed without network
+;
+
+create table "reconcile_status"("value" text not null primary key);
+insert into
+    "reconcile_status" ("value")
+values
+    ('APPLIED'),    -- Action applied to server state
+    ('SKIPPED'),    -- Server state already matched the action
+    ('CONFLICT'),   -- Server state wins over the action
+    ('REJECTED')    -- Action failed ordering or time validation
+;
+
+-- Results of reconciled offline actions; replaying the same action_id returns the stored result
+create table driver_offline_actions (
+    driver_id uuid not null references drivers(id),
+    action_id varchar(64) not null,
+    action_type text references "offline_action_type"(value) not null,
+    performed_at timestamptz not null,
+    ride_id uuid,
+    status text references "reconcile_status"(value) not null,
+    message text,
+    processed_at timestamptz not null default now(),
+    primary key (driver_id, action_id)
+);
+
+create index idx_driver_offline_actions_performed on driver_offline_actions(driver_id, performed_at desc);
+
+commit;
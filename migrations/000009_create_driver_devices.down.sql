@@ -0,0 +1,5 @@
+begin;
+
+DROP TABLE IF EXISTS driver_devices;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,14 @@
+begin;
+
+-- Per-device secrets used to sign driver location updates (HMAC-SHA256).
+-- The secret is issued at driver login with device_id and stored through the PII keyring.
+create table driver_devices (
+    driver_id uuid not null references users(id) on delete cascade,
+    device_id varchar(128) not null,
+    secret text not null,
+    created_at timestamptz not null default now(),
+    last_used_at timestamptz,
+    primary key (driver_id, device_id)
+);
+
+commit;
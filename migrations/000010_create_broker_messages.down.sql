@@ -0,0 +1,6 @@
+begin;
+
+DROP INDEX IF EXISTS idx_broker_messages_ready;
+DROP TABLE IF EXISTS broker_messages;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,21 @@
+begin;
+
+-- Message queue for the Postgres broker backend (BROKER_BACKEND=postgres).
+-- Publishers insert a row and NOTIFY "broker" with the queue name; consumers
+-- claim rows with FOR UPDATE SKIP LOCKED and delete them on ack.
+create table broker_messages (
+    id bigserial primary key,
+    queue text not null,
+    routing_key text not null,
+    body jsonb not null,
+    correlation_id text,
+    priority smallint not null default 0,
+    attempts integer not null default 0,
+    available_at timestamptz not null default now(), -- claimed messages are hidden until the lease expires
+    dead_at timestamptz,                             -- discarded messages are kept like RabbitMQ dead letters
+    created_at timestamptz not null default now()
+);
+
+create index idx_broker_messages_ready on broker_messages(queue, priority desc, id) where dead_at is null;
+
+commit;
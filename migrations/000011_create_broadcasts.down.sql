@@ -0,0 +1,5 @@
+begin;
+
+DROP TABLE IF EXISTS broadcasts;
+
+commit;
\ No newline at end of file
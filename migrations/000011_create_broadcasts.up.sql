@@ -0,0 +1,20 @@
+begin;
+
+-- Admin announcements delivered over WebSocket to drivers and/or passengers.
+-- Delivery counters are updated by the ride and driver services after sending.
+create table broadcasts (
+    id uuid primary key default gen_random_uuid(),
+    title varchar(100) not null,
+    message text not null,
+    roles text[] not null,
+    city varchar(20) references city_settings(code) on delete set null,
+    created_by uuid not null references users(id),
+    recipients integer not null default 0 check (recipients >= 0),
+    delivered integer not null default 0 check (delivered >= 0),
+    queued integer not null default 0 check (queued >= 0),
+    created_at timestamptz not null default now()
+);
+
+create index idx_broadcasts_created_at on broadcasts(created_at desc);
+
+commit;
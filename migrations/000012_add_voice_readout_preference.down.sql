@@ -0,0 +1,5 @@
+begin;
+
+ALTER TABLE notification_preferences DROP COLUMN IF EXISTS voice_readout;
+
+commit;
\ No newline at end of file
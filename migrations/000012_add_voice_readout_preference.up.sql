@@ -0,0 +1,7 @@
+begin;
+
+-- Accessibility: duplicate driver arrival notifications with an automated voice call
+alter table notification_preferences
+    add column voice_readout boolean not null default false;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+ALTER TABLE drivers DROP COLUMN IF EXISTS partner_id;
+DROP TABLE IF EXISTS partners;
+
+commit;
\ No newline at end of file
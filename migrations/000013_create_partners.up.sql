@@ -0,0 +1,19 @@
+begin;
+
+-- Taxi fleet companies supplying drivers through the partner API.
+-- api_key_hash is sha256 of the issued key, webhook_secret is stored through the PII keyring.
+create table partners (
+    id uuid primary key default gen_random_uuid(),
+    name varchar(100) not null,
+    api_key_hash text not null unique,
+    webhook_url text not null,
+    webhook_secret text not null,
+    is_active boolean not null default true,
+    created_at timestamptz not null default now()
+);
+
+-- Drivers registered by a partner; offers for them are delivered to the partner webhook
+alter table drivers add column partner_id uuid references partners(id);
+create index idx_drivers_partner_id on drivers(partner_id) where partner_id is not null;
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+DROP INDEX IF EXISTS idx_rides_passenger_completed;
+ALTER TABLE rides DROP COLUMN IF EXISTS co2_grams;
+ALTER TABLE rides DROP COLUMN IF EXISTS distance_km;
+
+commit;
\ No newline at end of file
@@ -0,0 +1,11 @@
+begin;
+
+-- CO2 estimate of a completed ride: distance (actual from the driver, otherwise straight line)
+-- multiplied by the emission factor of the vehicle class at completion time
+alter table rides add column distance_km decimal(10,2);
+alter table rides add column co2_grams decimal(12,2);
+
+-- Monthly footprint of a passenger
+create index idx_rides_passenger_completed on rides(passenger_id, completed_at) where status = 'COMPLETED';
+
+commit;
@@ -0,0 +1,8 @@
+begin;
+
+DELETE FROM ride_events WHERE event_type = 'DISPATCH_PENDING';
+DELETE FROM "ride_event_type" WHERE value = 'DISPATCH_PENDING';
+DROP INDEX IF EXISTS idx_rides_pending_dispatch;
+ALTER TABLE rides DROP COLUMN IF EXISTS pending_dispatch;
+
+commit;
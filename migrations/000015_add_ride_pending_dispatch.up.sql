@@ -0,0 +1,11 @@
+begin;
+
+-- Ride was created while the broker was unavailable: the driver search request
+-- has not been published yet and will be sent by the dispatch relay
+alter table rides add column pending_dispatch boolean not null default false;
+
+create index idx_rides_pending_dispatch on rides(created_at) where pending_dispatch;
+
+insert into "ride_event_type" ("value") values ('DISPATCH_PENDING');
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+DROP INDEX IF EXISTS idx_rides_test;
+ALTER TABLE drivers DROP COLUMN IF EXISTS is_simulator;
+ALTER TABLE rides DROP COLUMN IF EXISTS is_test;
+
+commit;
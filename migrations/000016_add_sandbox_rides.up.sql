@@ -0,0 +1,10 @@
+begin;
+
+-- Synthetic rides created by admins for smoke testing. They are matched only
+-- against simulator drivers and excluded from revenue and metrics
+alter table rides add column is_test boolean not null default false;
+alter table drivers add column is_simulator boolean not null default false;
+
+create index idx_rides_test on rides(created_at) where is_test;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP TABLE IF EXISTS positioning_recommendations;
+DELETE FROM notification_event WHERE value = 'POSITIONING_TIPS';
+
+commit;
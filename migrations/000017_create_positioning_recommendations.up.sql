@@ -0,0 +1,26 @@
+begin;
+
+-- Drivers opt out of positioning tips by removing this event from their preferences
+insert into "notification_event" ("value") values ('POSITIONING_TIPS');
+
+-- "Head to area X" recommendations sent to idle drivers, with effectiveness
+-- evaluated after a horizon: did the driver move there, did they get a ride
+create table positioning_recommendations (
+    id uuid primary key default gen_random_uuid(),
+    driver_id uuid not null references drivers(id),
+    cell text not null,
+    target_latitude double precision not null,
+    target_longitude double precision not null,
+    origin_latitude double precision not null,
+    origin_longitude double precision not null,
+    expected_wait_minutes integer not null check (expected_wait_minutes >= 0),
+    sent_at timestamptz not null default now(),
+    evaluated_at timestamptz,
+    moved boolean,
+    got_ride boolean
+);
+
+create index idx_positioning_sent on positioning_recommendations(sent_at);
+create index idx_positioning_pending on positioning_recommendations(sent_at) where evaluated_at is null;
+
+commit;
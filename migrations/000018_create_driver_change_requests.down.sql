@@ -0,0 +1,5 @@
+begin;
+
+DROP TABLE IF EXISTS driver_change_requests;
+
+commit;
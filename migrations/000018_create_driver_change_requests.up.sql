@@ -0,0 +1,22 @@
+begin;
+
+-- Sensitive driver profile changes (license number) wait here for admin approval
+-- before they are applied to the drivers table
+create table driver_change_requests (
+    id uuid primary key default gen_random_uuid(),
+    driver_id uuid not null references drivers(id),
+    field text not null check (field in ('license_number')),
+    old_value text not null,
+    new_value text not null,
+    status text not null default 'PENDING' check (status in ('PENDING', 'APPROVED', 'REJECTED')),
+    reason text,
+    created_at timestamptz not null default now(),
+    reviewed_at timestamptz,
+    reviewed_by uuid references users(id)
+);
+
+-- A driver has at most one pending change per field, a new request replaces it
+create unique index idx_driver_change_pending on driver_change_requests(driver_id, field) where status = 'PENDING';
+create index idx_driver_change_created on driver_change_requests(created_at) where status = 'PENDING';
+
+commit;
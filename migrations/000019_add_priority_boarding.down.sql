@@ -0,0 +1,6 @@
+begin;
+
+ALTER TABLE rides DROP COLUMN IF EXISTS priority_boarding;
+ALTER TABLE users DROP COLUMN IF EXISTS priority_boarding_eligible;
+
+commit;
@@ -0,0 +1,9 @@
+begin;
+
+-- Admins allow a passenger account to request priority boarding
+alter table users add column priority_boarding_eligible boolean not null default false;
+
+-- Medical or accessibility ride: maximum dispatch priority, no surcharges
+alter table rides add column priority_boarding text check (priority_boarding in ('MEDICAL', 'ACCESSIBILITY'));
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+ALTER TABLE rides DROP COLUMN IF EXISTS payment_method;
+DROP TABLE IF EXISTS wallet_ledger;
+DROP TABLE IF EXISTS wallets;
+
+commit;
@@ -0,0 +1,28 @@
+begin;
+
+-- Passenger wallet, held is reserved for active rides and not included in balance
+create table wallets (
+    passenger_id uuid primary key references users(id),
+    balance numeric(10,2) not null default 0 check (balance >= 0),
+    held numeric(10,2) not null default 0 check (held >= 0),
+    updated_at timestamptz not null default now()
+);
+
+-- Every wallet movement and card fallback
+create table wallet_ledger (
+    id uuid primary key default gen_random_uuid(),
+    passenger_id uuid not null references users(id),
+    ride_id uuid references rides(id),
+    entry_type text not null check (entry_type in ('TOP_UP', 'HOLD', 'CAPTURE', 'RELEASE', 'CARD_FALLBACK')),
+    amount numeric(10,2) not null check (amount >= 0),
+    balance_after numeric(10,2) not null,
+    transaction_id text,
+    created_at timestamptz not null default now()
+);
+
+create index idx_wallet_ledger_passenger on wallet_ledger(passenger_id, created_at desc);
+create index idx_wallet_ledger_ride on wallet_ledger(ride_id) where ride_id is not null;
+
+alter table rides add column payment_method text not null default 'CARD' check (payment_method in ('CARD', 'WALLET'));
+
+commit;
@@ -0,0 +1,8 @@
+begin;
+
+ALTER TABLE city_settings DROP COLUMN IF EXISTS matching_paused;
+DROP INDEX IF EXISTS idx_ops_actions_pending;
+DROP INDEX IF EXISTS idx_ops_actions_created;
+DROP TABLE IF EXISTS ops_actions;
+
+commit;
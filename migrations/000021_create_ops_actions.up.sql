@@ -0,0 +1,25 @@
+begin;
+
+-- Audit trail of operational actions run by on-call engineers.
+-- Actions for a service instance stay PENDING until that instance applies them.
+create table ops_actions (
+    id uuid primary key default gen_random_uuid(),
+    action text not null check (action in ('DRAIN_WS', 'PAUSE_MATCHING')),
+    params jsonb not null default '{}',
+    status text not null default 'PENDING' check (status in ('PENDING', 'APPLIED', 'FAILED')),
+    target_service text,
+    target_instance text,
+    result jsonb,
+    error text,
+    requested_by uuid not null references users(id),
+    created_at timestamptz not null default now(),
+    completed_at timestamptz
+);
+
+create index idx_ops_actions_created on ops_actions(created_at desc);
+create index idx_ops_actions_pending on ops_actions(target_service, target_instance) where status = 'PENDING';
+
+-- New rides in the city are rejected while matching is paused
+alter table city_settings add column matching_paused boolean not null default false;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP INDEX IF EXISTS idx_ride_telemetry_created;
+DROP TABLE IF EXISTS ride_telemetry;
+
+commit;
@@ -0,0 +1,19 @@
+begin;
+
+-- App quality telemetry submitted by passenger and driver clients after a ride.
+-- Anonymous: stores the reporter role, not the user, one report per role and ride.
+create table ride_telemetry (
+    ride_id uuid not null references rides(id),
+    role text not null check (role in ('PASSENGER', 'DRIVER')),
+    platform text not null,
+    app_version text not null,
+    ws_disconnects integer not null check (ws_disconnects >= 0),
+    max_location_gap_sec numeric(10,1) not null check (max_location_gap_sec >= 0),
+    offer_round_trip_ms integer check (offer_round_trip_ms >= 0),
+    created_at timestamptz not null default now(),
+    primary key (ride_id, role)
+);
+
+create index idx_ride_telemetry_created on ride_telemetry(created_at);
+
+commit;
@@ -0,0 +1,9 @@
+begin;
+
+DELETE FROM ride_events WHERE event_type = 'DRIVER_RATED';
+DELETE FROM "ride_event_type" WHERE value = 'DRIVER_RATED';
+ALTER TABLE drivers DROP COLUMN IF EXISTS ratings_count;
+DROP INDEX IF EXISTS idx_ride_ratings_driver;
+DROP TABLE IF EXISTS ride_ratings;
+
+commit;
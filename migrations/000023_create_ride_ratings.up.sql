@@ -0,0 +1,20 @@
+begin;
+
+-- Passenger rating of the driver after a completed ride, one per ride
+create table ride_ratings (
+    ride_id uuid primary key references rides(id),
+    driver_id uuid not null references drivers(id),
+    passenger_id uuid not null references users(id),
+    score smallint not null check (score between 1 and 5),
+    comment text,
+    created_at timestamptz not null default now()
+);
+
+create index idx_ride_ratings_driver on ride_ratings(driver_id, created_at desc);
+
+-- drivers.rating is the average score, ratings_count the number of ratings it is based on
+alter table drivers add column ratings_count integer not null default 0;
+
+insert into "ride_event_type" ("value") values ('DRIVER_RATED');
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP INDEX IF EXISTS idx_state_exports_created;
+DROP TABLE IF EXISTS state_exports;
+
+commit;
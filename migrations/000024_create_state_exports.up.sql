@@ -0,0 +1,19 @@
+begin;
+
+-- Snapshots of live system state (active rides, online drivers, open sessions)
+-- generated in the background when the fleet is too large for a synchronous export
+create table state_exports (
+    id uuid primary key default gen_random_uuid(),
+    status text not null default 'PENDING' check (status in ('PENDING', 'READY', 'FAILED')),
+    requested_by uuid not null references users(id),
+    item_count integer not null default 0,
+    truncated boolean not null default false,
+    snapshot jsonb,
+    error text,
+    created_at timestamptz not null default now(),
+    completed_at timestamptz
+);
+
+create index idx_state_exports_created on state_exports(created_at desc);
+
+commit;
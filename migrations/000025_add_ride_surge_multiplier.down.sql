@@ -0,0 +1,6 @@
+begin;
+
+DROP INDEX IF EXISTS idx_rides_requested_created;
+ALTER TABLE rides DROP COLUMN IF EXISTS surge_multiplier;
+
+commit;
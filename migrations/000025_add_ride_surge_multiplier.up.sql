@@ -0,0 +1,9 @@
+begin;
+
+-- Demand surge applied to the estimated fare when the ride was requested, 1 - no surge
+alter table rides add column surge_multiplier decimal(4,2) not null default 1 check (surge_multiplier >= 1);
+
+-- Demand in a geohash cell is counted over recent requests
+create index idx_rides_requested_created on rides(created_at) where status = 'REQUESTED';
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+ALTER TABLE rides DROP COLUMN IF EXISTS served_vehicle_type;
+
+commit;
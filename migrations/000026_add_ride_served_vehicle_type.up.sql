@@ -0,0 +1,7 @@
+begin;
+
+-- Class of the vehicle that served the ride when the driver search fell back to an adjacent class,
+-- null - the ride was served by the requested class. The passenger pays the requested class price.
+alter table rides add column served_vehicle_type text references "vehicle_type"(value);
+
+commit;
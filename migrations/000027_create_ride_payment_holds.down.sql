@@ -0,0 +1,5 @@
+begin;
+
+DROP TABLE IF EXISTS ride_payment_holds;
+
+commit;
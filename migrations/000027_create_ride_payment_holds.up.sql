@@ -0,0 +1,21 @@
+begin;
+
+-- Payment hold placed when the ride is created: card pre-authorization or wallet hold.
+-- Captured with the final fare on completion, released on cancellation.
+create table ride_payment_holds (
+    ride_id uuid primary key references rides(id),
+    passenger_id uuid not null references users(id),
+    payment_method text not null check (payment_method in ('CARD', 'WALLET')),
+    amount numeric(10,2) not null check (amount >= 0),
+    status text not null check (status in ('AUTHORIZED', 'CAPTURED', 'RELEASED', 'FAILED')),
+    authorization_id text,
+    captured_amount numeric(10,2),
+    transaction_id text,
+    error text,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create index idx_ride_payment_holds_passenger on ride_payment_holds(passenger_id, created_at desc);
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
+
+commit;
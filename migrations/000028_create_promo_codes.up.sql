@@ -0,0 +1,35 @@
+begin;
+
+-- Promo codes managed by admins. Codes are stored uppercase and matched case-insensitively.
+create table promo_codes (
+    id uuid primary key default gen_random_uuid(),
+    code text not null unique check (code = upper(code)),
+    discount_type text not null check (discount_type in ('PERCENT', 'FLAT')),
+    value numeric(10,2) not null check (value > 0),
+    -- Upper bound of a percentage discount, null - no bound
+    max_discount numeric(10,2) check (max_discount > 0),
+    expires_at timestamptz,
+    -- Total redemptions allowed, null - unlimited
+    max_uses integer check (max_uses > 0),
+    per_passenger_limit integer not null default 1 check (per_passenger_limit > 0),
+    used_count integer not null default 0 check (used_count >= 0),
+    active boolean not null default true,
+    created_by uuid references users(id),
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    check (discount_type <> 'PERCENT' or value <= 100)
+);
+
+-- Promo code applied to a ride. Released on cancellation, the use is returned to the code.
+create table promo_redemptions (
+    ride_id uuid primary key references rides(id),
+    promo_id uuid not null references promo_codes(id),
+    passenger_id uuid not null references users(id),
+    discount numeric(10,2) not null check (discount >= 0),
+    created_at timestamptz not null default now(),
+    released_at timestamptz
+);
+
+create index idx_promo_redemptions_passenger on promo_redemptions(promo_id, passenger_id) where released_at is null;
+
+commit;
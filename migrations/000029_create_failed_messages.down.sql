@@ -0,0 +1,5 @@
+begin;
+
+DROP TABLE IF EXISTS failed_messages;
+
+commit;
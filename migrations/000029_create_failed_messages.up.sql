@@ -0,0 +1,23 @@
+begin;
+
+-- Messages dead-lettered by RabbitMQ consumers (Nack without requeue or expired).
+-- Collected from the dead_messages queue by admin-service and replayed to the original queue on request.
+create table failed_messages (
+    id uuid primary key default gen_random_uuid(),
+    queue text not null,
+    exchange text not null default '',
+    routing_key text not null default '',
+    reason text not null default '',
+    death_count integer not null default 1,
+    correlation_id text,
+    content_type text,
+    body bytea not null,
+    failed_at timestamptz not null default now(),
+    replay_count integer not null default 0,
+    replayed_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+create index idx_failed_messages_queue on failed_messages(queue, failed_at desc);
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP TABLE IF EXISTS processed_messages;
+DROP TABLE IF EXISTS outbox;
+
+commit;
@@ -0,0 +1,29 @@
+begin;
+
+-- Transactional outbox: broker messages are written in the same transaction as the ride change
+-- and published by the relay in ride-service (at-least-once). idempotency_key is sent as the
+-- message id, so consumers can skip redeliveries.
+create table outbox (
+    id uuid primary key default gen_random_uuid(),
+    idempotency_key text not null unique,
+    topic text not null,
+    payload jsonb not null,
+    attempts integer not null default 0,
+    last_error text,
+    next_attempt_at timestamptz not null default now(),
+    published_at timestamptz,
+    discarded_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+create index idx_outbox_pending on outbox(created_at) where published_at is null and discarded_at is null;
+
+-- Message ids already handled by a consumer, duplicates are acknowledged without processing
+create table processed_messages (
+    consumer text not null,
+    message_id text not null,
+    processed_at timestamptz not null default now(),
+    primary key (consumer, message_id)
+);
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+DROP SEQUENCE IF EXISTS ride_number_seq;
+
+commit;
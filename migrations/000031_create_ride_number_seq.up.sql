@@ -0,0 +1,9 @@
+begin;
+
+-- Ride numbers are RIDE_<yyyymmdd>_<10-digit sequence value>. The sequence replaces counting
+-- today's rides, which handed out the same number to concurrent requests. nextval is never
+-- rolled back, so numbers stay unique even when the ride transaction fails (gaps are expected).
+-- Numbers issued before this migration keep the old 3-digit suffix and cannot collide.
+create sequence if not exists ride_number_seq as bigint;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+DROP INDEX IF EXISTS idx_location_history_driver_recorded;
+DROP INDEX IF EXISTS idx_driver_sessions_driver_started;
+
+commit;
@@ -0,0 +1,8 @@
+begin;
+
+-- Session history is listed per driver, newest first; the distance of each session is summed
+-- from the driver's location history between its start and end.
+create index if not exists idx_driver_sessions_driver_started on driver_sessions(driver_id, started_at desc);
+create index if not exists idx_location_history_driver_recorded on location_history(driver_id, recorded_at);
+
+commit;
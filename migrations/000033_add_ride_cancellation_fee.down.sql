@@ -0,0 +1,5 @@
+begin;
+
+ALTER TABLE rides DROP COLUMN IF EXISTS cancellation_fee;
+
+commit;
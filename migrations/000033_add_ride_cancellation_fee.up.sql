@@ -0,0 +1,7 @@
+begin;
+
+-- Fee charged to the passenger for cancelling after a driver was matched, credited to the driver.
+-- Null when the cancellation was free.
+alter table rides add column cancellation_fee decimal(10,2) check (cancellation_fee >= 0);
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+DROP TABLE IF EXISTS flat_rates;
+
+commit;
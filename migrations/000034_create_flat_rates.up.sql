@@ -0,0 +1,38 @@
+begin;
+
+-- Fixed-price corridors (FLAT) and zone minimum fares (MINIMUM) managed by admins.
+-- Every change inserts a new version of the code; the previous version is closed at its effective_from.
+create table flat_rates (
+    id uuid primary key default gen_random_uuid(),
+    code varchar(40) not null check (code = upper(code)),
+    name varchar(100) not null,
+    rate_type text not null check (rate_type in ('FLAT', 'MINIMUM')),
+    -- Vehicle class the rate applies to, null - every class
+    vehicle_type text references "vehicle_type"(value),
+    origin_latitude decimal(10,8) not null check (origin_latitude between -90 and 90),
+    origin_longitude decimal(11,8) not null check (origin_longitude between -180 and 180),
+    origin_radius_km decimal(6,2) not null check (origin_radius_km > 0),
+    -- Destination zone, required for FLAT corridors and unused by MINIMUM fares
+    destination_latitude decimal(10,8) check (destination_latitude between -90 and 90),
+    destination_longitude decimal(11,8) check (destination_longitude between -180 and 180),
+    destination_radius_km decimal(6,2) check (destination_radius_km > 0),
+    -- The corridor also applies from destination to origin
+    bidirectional boolean not null default true,
+    fare decimal(10,2) not null check (fare > 0),
+    effective_from timestamptz not null default now(),
+    -- Null - the version is effective until a newer one replaces it
+    effective_to timestamptz,
+    created_by uuid references users(id),
+    created_at timestamptz not null default now(),
+    check (effective_to is null or effective_to > effective_from),
+    check (
+        (rate_type = 'FLAT' and destination_latitude is not null and destination_longitude is not null and destination_radius_km is not null)
+        or (rate_type = 'MINIMUM' and destination_latitude is null and destination_longitude is null and destination_radius_km is null)
+    )
+);
+
+create index idx_flat_rates_code on flat_rates(code, effective_from desc);
+-- ride-service loads versions that are effective now or scheduled
+create index idx_flat_rates_effective_to on flat_rates(effective_to);
+
+commit;
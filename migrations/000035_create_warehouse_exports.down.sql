@@ -0,0 +1,7 @@
+begin;
+
+DROP INDEX IF EXISTS idx_ride_events_created_export;
+DROP INDEX IF EXISTS idx_rides_finished_export;
+DROP TABLE IF EXISTS warehouse_exports;
+
+commit;
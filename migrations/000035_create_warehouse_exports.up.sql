@@ -0,0 +1,23 @@
+begin;
+
+-- Progress of the analytics warehouse exporter, one row per dataset and schema version.
+-- A new schema version starts from an empty cursor, so the dataset is exported again in the new shape.
+create table warehouse_exports (
+    dataset text not null check (dataset in ('rides', 'ride_events')),
+    schema_version integer not null check (schema_version > 0),
+    -- Keyset cursor: the last exported row's time and id
+    cursor_at timestamptz not null default 'epoch',
+    cursor_id uuid not null default '00000000-0000-0000-0000-000000000000',
+    rows_exported bigint not null default 0,
+    last_run_at timestamptz,
+    last_error text,
+    primary key (dataset, schema_version)
+);
+
+-- Finished rides in export order
+create index idx_rides_finished_export on rides ((coalesce(completed_at, cancelled_at)), id)
+    where status in ('COMPLETED', 'CANCELLED') and not is_test;
+
+create index idx_ride_events_created_export on ride_events (created_at, id);
+
+commit;
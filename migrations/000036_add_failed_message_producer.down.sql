@@ -0,0 +1,5 @@
+begin;
+
+ALTER TABLE failed_messages DROP COLUMN IF EXISTS producer;
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+-- Service that published the dead-lettered message, taken from a verified service signature.
+-- Null for unsigned messages or when the signature did not verify.
+alter table failed_messages add column producer text;
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+ALTER TABLE drivers DROP COLUMN IF EXISTS photo_url;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+-- Driver photo shown to the passenger on the driver card. Changes go through driver_change_requests.
+alter table drivers add column photo_url text;
+
+commit;
@@ -0,0 +1,31 @@
+begin;
+
+-- Receipt issued when the ride is completed. Amounts are fixed at completion and do not
+-- change with later tariff or commission updates.
+-- base_fare + distance_fare + time_fare + surge + adjustment - discount = total.
+-- tax is included in total; total - tax = driver_earnings + platform_commission.
+create table ride_receipts (
+    ride_id uuid primary key references rides(id),
+    passenger_id uuid not null references users(id),
+    driver_id uuid references users(id),
+    vehicle_type text not null,
+    payment_method text not null,
+    distance_km numeric(10,2) not null,
+    duration_min integer not null,
+    surge_multiplier numeric(4,2) not null default 1,
+    base_fare numeric(10,2) not null,
+    distance_fare numeric(10,2) not null,
+    time_fare numeric(10,2) not null,
+    surge numeric(10,2) not null default 0,
+    adjustment numeric(10,2) not null default 0,
+    discount numeric(10,2) not null default 0,
+    tax numeric(10,2) not null default 0,
+    total numeric(10,2) not null check (total >= 0),
+    driver_earnings numeric(10,2) not null,
+    platform_commission numeric(10,2) not null default 0,
+    created_at timestamptz not null default now()
+);
+
+create index idx_ride_receipts_driver on ride_receipts(driver_id, created_at);
+
+commit;
@@ -0,0 +1,37 @@
+begin;
+
+-- Vehicles of a driver. Exactly one vehicle is active; it is mirrored into drivers.vehicle_type
+-- and drivers.vehicle_attrs, which matching and the driver card read.
+create table driver_vehicles (
+    id uuid primary key default gen_random_uuid(),
+    driver_id uuid not null references drivers(id),
+    vehicle_type text not null references "vehicle_type"(value),
+    -- Body type given by the driver (SUV, VAN, ...), used to classify XL; null for migrated vehicles
+    body_type text,
+    make text not null,
+    model text not null,
+    color text not null,
+    plate text not null,
+    year integer not null,
+    is_active boolean not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create unique index ux_driver_vehicles_active on driver_vehicles(driver_id) where is_active;
+create unique index ux_driver_vehicles_plate on driver_vehicles(driver_id, upper(plate));
+
+-- Current vehicles of registered drivers become their active vehicles
+insert into driver_vehicles(driver_id, vehicle_type, make, model, color, plate, year, is_active)
+select id,
+       vehicle_type,
+       coalesce(vehicle_attrs->>'make', ''),
+       coalesce(vehicle_attrs->>'model', ''),
+       coalesce(vehicle_attrs->>'color', ''),
+       coalesce(vehicle_attrs->>'plate', ''),
+       coalesce((vehicle_attrs->>'year')::integer, 0),
+       true
+from drivers
+where vehicle_type is not null;
+
+commit;
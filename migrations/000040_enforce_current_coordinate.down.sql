@@ -0,0 +1,21 @@
+begin;
+
+create or replace function set_is_current_false()
+returns trigger as $$
+begin
+    update coordinates
+    set is_current = false
+    where entity_id = new.entity_id
+        and entity_type = new.entity_type
+        and is_current = true;
+
+    return new;
+end;
+$$ language plpgsql;
+
+drop index if exists ux_coordinates_current;
+create index idx_coordinates_current on coordinates(entity_id, entity_type) where is_current = true;
+
+alter table coordinates alter column is_current drop not null;
+
+commit;
@@ -0,0 +1,50 @@
+begin;
+
+-- Block coordinate writes while duplicates are repaired and the unique index is built
+lock table coordinates in share row exclusive mode;
+
+-- Keep is_current only on the latest coordinate of every entity
+with ranked as (
+    select id,
+           row_number() over (
+               partition by entity_id, entity_type
+               order by updated_at desc, created_at desc, id desc
+           ) as rn
+    from coordinates
+    where is_current
+)
+update coordinates c
+set is_current = false
+from ranked r
+where c.id = r.id and r.rn > 1;
+
+update coordinates set is_current = false where is_current is null;
+alter table coordinates alter column is_current set not null;
+
+-- At most one current coordinate per entity
+drop index if exists idx_coordinates_current;
+create unique index ux_coordinates_current on coordinates(entity_id, entity_type) where is_current;
+
+-- Inserts of one entity are serialized by a transaction-level advisory lock, so the update
+-- sees the row made current by a concurrent transaction instead of racing with it.
+-- Historical coordinates inserted with is_current = false keep the current one.
+create or replace function set_is_current_false()
+returns trigger as $$
+begin
+    if not new.is_current then
+        return new;
+    end if;
+
+    perform pg_advisory_xact_lock(hashtextextended(new.entity_type || ':' || new.entity_id::text, 0));
+
+    update coordinates
+    set is_current = false
+    where entity_id = new.entity_id
+        and entity_type = new.entity_type
+        and is_current = true;
+
+    return new;
+end;
+$$ language plpgsql;
+
+commit;
@@ -0,0 +1,15 @@
+begin;
+
+drop index if exists ux_ride_receipts_invoice;
+
+alter table ride_receipts
+    drop column if exists fiscalized_at,
+    drop column if exists fiscal_receipt_id,
+    drop column if exists invoice_number,
+    drop column if exists legal_entity;
+
+drop table if exists invoice_sequences;
+
+alter table city_settings drop column if exists legal_entity;
+
+commit;
@@ -0,0 +1,24 @@
+begin;
+
+-- Legal entity that issues receipts for rides picked up in the city.
+-- Null means the default entity from RIDE_INVOICE_LEGAL_ENTITY.
+alter table city_settings add column legal_entity text;
+
+-- Last issued invoice number per legal entity. The row is updated in the ride completion
+-- transaction, so numbers are sequential without gaps.
+create table invoice_sequences (
+    legal_entity text primary key,
+    last_number bigint not null
+);
+
+-- Invoice number and the receipt id returned by the fiscalization provider.
+-- Receipts issued before this migration have no invoice number.
+alter table ride_receipts
+    add column legal_entity text,
+    add column invoice_number text,
+    add column fiscal_receipt_id text,
+    add column fiscalized_at timestamptz;
+
+create unique index ux_ride_receipts_invoice on ride_receipts(legal_entity, invoice_number) where invoice_number is not null;
+
+commit;
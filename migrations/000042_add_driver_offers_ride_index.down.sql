@@ -0,0 +1,5 @@
+begin;
+
+drop index if exists idx_driver_offers_ride;
+
+commit;
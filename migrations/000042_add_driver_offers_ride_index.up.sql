@@ -0,0 +1,6 @@
+begin;
+
+-- Driver search reads recent declines of the ride on every attempt to skip those drivers
+create index idx_driver_offers_ride on driver_offers(ride_id, created_at);
+
+commit;
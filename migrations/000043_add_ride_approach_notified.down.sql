@@ -0,0 +1,8 @@
+begin;
+
+delete from ride_events where event_type = 'DRIVER_APPROACHING';
+delete from "ride_event_type" where "value" = 'DRIVER_APPROACHING';
+
+alter table rides drop column approach_notified_at;
+
+commit;
@@ -0,0 +1,9 @@
+begin;
+
+-- When the passenger was told the driver is about to arrive at pickup.
+-- Reset to null once the driver moves away again, so the notification can repeat.
+alter table rides add column approach_notified_at timestamptz;
+
+insert into "ride_event_type" ("value") values ('DRIVER_APPROACHING');
+
+commit;
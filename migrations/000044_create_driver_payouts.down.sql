@@ -0,0 +1,8 @@
+begin;
+
+drop table if exists payout_webhook_events;
+drop table if exists driver_ledger;
+drop table if exists driver_payouts;
+drop table if exists driver_balances;
+
+commit;
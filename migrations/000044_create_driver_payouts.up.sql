@@ -0,0 +1,52 @@
+begin;
+
+-- Driver balance available for instant payout. Credited with ride earnings and cancellation fees
+-- from this migration on; earlier earnings were settled by the regular payout outside the system.
+create table driver_balances (
+    driver_id uuid primary key references drivers(id),
+    available numeric(10,2) not null default 0 check (available >= 0),
+    updated_at timestamptz not null default now()
+);
+
+-- Instant payouts requested by drivers. amount is debited from the balance,
+-- net_amount = amount - fee is transferred by the payout provider.
+create table driver_payouts (
+    id uuid primary key default gen_random_uuid(),
+    driver_id uuid not null references drivers(id),
+    amount numeric(10,2) not null check (amount > 0),
+    fee numeric(10,2) not null check (fee >= 0),
+    net_amount numeric(10,2) not null check (net_amount > 0),
+    status text not null check (status in ('PENDING', 'PROCESSING', 'PAID', 'FAILED')),
+    provider_payout_id text unique,
+    failure_reason text,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create index idx_driver_payouts_driver on driver_payouts(driver_id, created_at desc);
+create index idx_driver_payouts_open on driver_payouts(status, updated_at) where status in ('PENDING', 'PROCESSING');
+
+-- Every movement of the driver balance
+create table driver_ledger (
+    id uuid primary key default gen_random_uuid(),
+    driver_id uuid not null references drivers(id),
+    ride_id uuid references rides(id),
+    payout_id uuid references driver_payouts(id),
+    entry_type text not null check (entry_type in ('EARNING', 'CANCELLATION_FEE', 'PAYOUT', 'PAYOUT_REVERSAL')),
+    amount numeric(10,2) not null check (amount >= 0),
+    balance_after numeric(10,2) not null,
+    created_at timestamptz not null default now()
+);
+
+create index idx_driver_ledger_driver on driver_ledger(driver_id, created_at desc);
+
+-- Payout provider webhooks, kept for idempotency and audit
+create table payout_webhook_events (
+    event_id text primary key,
+    payout_id uuid not null references driver_payouts(id),
+    status text not null,
+    payload jsonb not null,
+    received_at timestamptz not null default now()
+);
+
+commit;
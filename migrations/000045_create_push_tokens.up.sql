@@ -0,0 +1,18 @@
+begin;
+
+-- FCM registration tokens of the user's devices for push notifications.
+-- A token belongs to one app install: registering it for another user or device releases the old row.
+-- Tokens rejected by FCM as unregistered are deleted on send.
+create table push_tokens (
+    user_id uuid not null references users(id),
+    device_id text not null,
+    platform text not null check (platform in ('ANDROID', 'IOS')),
+    token text not null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    primary key (user_id, device_id)
+);
+
+create index idx_push_tokens_token on push_tokens(token);
+
+commit;
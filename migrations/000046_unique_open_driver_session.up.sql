@@ -0,0 +1,18 @@
+begin;
+
+-- Concurrent go-online requests could both see the driver OFFLINE and open two sessions.
+-- Close all but the latest open session of each driver as zero-length (totals are written
+-- to the latest session only), then allow a single open session per driver.
+update driver_sessions s
+set ended_at = s.started_at
+where s.ended_at is null
+  and exists (
+    select 1 from driver_sessions n
+    where n.driver_id = s.driver_id
+      and n.ended_at is null
+      and (n.started_at, n.id) > (s.started_at, s.id)
+  );
+
+create unique index idx_driver_sessions_open on driver_sessions(driver_id) where ended_at is null;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+alter table outbox drop column if exists held_until;
+drop table if exists matching_blackouts;
+
+commit;
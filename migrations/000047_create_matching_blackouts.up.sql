@@ -0,0 +1,29 @@
+begin;
+
+-- Windows when automatic matching is paused in a zone (parades, road closures).
+-- Rides requested in the zone are created, but the driver search request is held in the
+-- outbox until the window ends.
+create table matching_blackouts (
+    id uuid primary key default gen_random_uuid(),
+    reason varchar(200) not null,
+    -- Vehicle class the blackout applies to, null - every class
+    vehicle_type text references "vehicle_type"(value),
+    latitude decimal(10,8) not null check (latitude between -90 and 90),
+    longitude decimal(11,8) not null check (longitude between -180 and 180),
+    radius_km decimal(6,2) not null check (radius_km > 0),
+    starts_at timestamptz not null default now(),
+    ends_at timestamptz not null,
+    created_by uuid references users(id),
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    check (ends_at > starts_at)
+);
+
+-- ride-service loads blackouts that are active now or scheduled
+create index idx_matching_blackouts_ends_at on matching_blackouts(ends_at);
+
+-- The relay does not publish a held message before next_attempt_at; the dispatch timeout
+-- counts from the end of the hold, not from the ride creation
+alter table outbox add column held_until timestamptz;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+drop table if exists access_token_cutoffs;
+drop table if exists revoked_access_tokens;
+
+commit;
@@ -0,0 +1,20 @@
+begin;
+
+-- Access tokens revoked by logout before they expire. Rows are useless after expires_at
+-- and are removed on the next revocation.
+create table revoked_access_tokens (
+    jti uuid primary key,
+    user_id uuid not null references users(id) on delete cascade,
+    expires_at timestamptz not null,
+    created_at timestamptz not null default now()
+);
+
+create index idx_revoked_access_tokens_expires_at on revoked_access_tokens(expires_at);
+
+-- Logout from all devices: access tokens of the user issued before revoked_before are rejected
+create table access_token_cutoffs (
+    user_id uuid primary key references users(id) on delete cascade,
+    revoked_before timestamptz not null
+);
+
+commit;
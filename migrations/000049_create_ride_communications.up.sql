@@ -0,0 +1,18 @@
+begin;
+
+-- Every message sent to a ride participant (WebSocket, push, SMS, email, voice call)
+-- with its delivery result, used to resolve "I was never notified" disputes.
+create table ride_communications (
+    id uuid primary key default gen_random_uuid(),
+    ride_id uuid not null references rides(id) on delete cascade,
+    recipient_id uuid not null references users(id) on delete cascade,
+    channel varchar(16) not null,
+    message_type varchar(64) not null,
+    status varchar(16) not null check (status in ('DELIVERED', 'FAILED', 'SKIPPED')),
+    detail text,
+    created_at timestamptz not null default now()
+);
+
+create index idx_ride_communications_ride_id on ride_communications(ride_id, created_at);
+
+commit;
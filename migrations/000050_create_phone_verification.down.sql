@@ -0,0 +1,6 @@
+begin;
+
+drop table if exists phone_verification_codes;
+alter table users drop column if exists phone_verified_at;
+
+commit;
@@ -0,0 +1,16 @@
+begin;
+
+-- null until the user confirms the phone from attrs with a one-time code; reset when the phone changes
+alter table users add column phone_verified_at timestamptz;
+
+-- The last one-time code sent to the user. Only the hash is stored; it is bound to the phone
+-- the code was sent to, so a code stops working after the phone changes.
+create table phone_verification_codes (
+    user_id uuid primary key references users(id) on delete cascade,
+    code_hash text not null,
+    expires_at timestamptz not null,
+    attempts int not null default 0,
+    created_at timestamptz not null default now()
+);
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+DROP INDEX IF EXISTS idx_rides_driver_completed;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+-- The shift summary compares a driver's completed rides by day and by hour over the last week.
+create index if not exists idx_rides_driver_completed on rides(driver_id, completed_at) where status = 'COMPLETED';
+
+commit;
@@ -0,0 +1,8 @@
+begin;
+
+alter table broadcasts rename constraint broadcasts_stored_check to broadcasts_queued_check;
+alter table broadcasts rename column stored to queued;
+
+drop table if exists broadcast_inbox;
+
+commit;
@@ -0,0 +1,19 @@
+begin;
+
+-- Announcements for recipients without an open WebSocket connection are stored here
+-- and sent when the user connects, instead of being kept in the memory of the service.
+create table broadcast_inbox (
+    broadcast_id uuid not null references broadcasts(id) on delete cascade,
+    user_id uuid not null references users(id) on delete cascade,
+    created_at timestamptz not null default now(),
+    delivered_at timestamptz,
+    primary key (broadcast_id, user_id)
+);
+
+create index idx_broadcast_inbox_pending on broadcast_inbox(user_id) where delivered_at is null;
+
+-- queued counted messages buffered in memory; stored counts inbox rows still waiting for delivery
+alter table broadcasts rename column queued to stored;
+alter table broadcasts rename constraint broadcasts_queued_check to broadcasts_stored_check;
+
+commit;
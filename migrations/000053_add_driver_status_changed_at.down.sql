@@ -0,0 +1,7 @@
+begin;
+
+drop trigger if exists trg_drivers_status_changed_at on drivers;
+drop function if exists set_driver_status_changed_at();
+alter table drivers drop column if exists status_changed_at;
+
+commit;
@@ -0,0 +1,24 @@
+begin;
+
+-- Time of the last driver status change. Offline reconciliation compares performed_at with it:
+-- an action made before the server changed the status conflicts with the server state.
+alter table drivers add column status_changed_at timestamptz;
+update drivers set status_changed_at = updated_at;
+alter table drivers alter column status_changed_at set default now();
+alter table drivers alter column status_changed_at set not null;
+
+create or replace function set_driver_status_changed_at()
+returns trigger as $$
+begin
+    new.status_changed_at = now();
+    return new;
+end;
+$$ language plpgsql;
+
+create trigger trg_drivers_status_changed_at
+before update of status on drivers
+for each row
+when (old.status is distinct from new.status)
+execute function set_driver_status_changed_at();
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+drop table if exists partner_track_updates;
+drop table if exists partner_ride_tracking;
+drop table if exists partner_offers;
+
+commit;
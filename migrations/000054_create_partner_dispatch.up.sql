@@ -0,0 +1,36 @@
+begin;
+
+-- Offers sent to a partner webhook. The partner answers through the partner API on any
+-- driver-service instance; the answer is written here and the waiting instance is woken
+-- by a cache_invalidation event. The row is deleted when the offer is settled.
+create table partner_offers (
+    offer_id uuid primary key,
+    partner_id uuid not null references partners(id),
+    driver_id uuid not null references drivers(id),
+    ride_id uuid not null,
+    expires_at timestamptz not null,
+    accepted boolean,
+    responded_at timestamptz,
+    created_at timestamptz not null default now()
+);
+
+-- Rides of partner drivers that are being tracked, and locations reported by the partner
+-- that the tracking instance has not taken yet.
+create table partner_ride_tracking (
+    driver_id uuid primary key references drivers(id),
+    ride_id uuid not null,
+    expires_at timestamptz not null,
+    created_at timestamptz not null default now()
+);
+
+create table partner_track_updates (
+    id bigserial primary key,
+    driver_id uuid not null references drivers(id),
+    ride_id uuid not null,
+    payload jsonb not null,
+    created_at timestamptz not null default now()
+);
+
+create index idx_partner_track_updates_driver on partner_track_updates(driver_id, id);
+
+commit;
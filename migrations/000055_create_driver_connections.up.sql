@@ -0,0 +1,15 @@
+begin;
+
+-- Driver WebSocket connections across driver-service instances. connected_at is the time of
+-- the latest connection on any instance; release_at is set when the last connection closes
+-- and is cleared by a reconnect. A sweep on every instance releases the assignment of drivers
+-- whose release_at has passed, so a reconnect to another instance or a restart keeps the deadline.
+create table driver_connections (
+    driver_id uuid primary key references drivers(id),
+    connected_at timestamptz not null,
+    release_at timestamptz
+);
+
+create index idx_driver_connections_release on driver_connections(release_at) where release_at is not null;
+
+commit;
@@ -0,0 +1,8 @@
+begin;
+
+alter table broker_messages drop column if exists signature;
+alter table broker_messages drop column if exists key_id;
+alter table broker_messages drop column if exists sender;
+alter table broker_messages alter column body type jsonb using body::jsonb;
+
+commit;
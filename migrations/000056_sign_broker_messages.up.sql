@@ -0,0 +1,11 @@
+begin;
+
+-- Sender signature of Postgres broker messages (SERVICE_AUTH_PRIVATE_KEYS), like the RabbitMQ
+-- x-service headers. The signature covers the exact published bytes, so the body is kept as text:
+-- jsonb reorders keys and whitespace and would break it.
+alter table broker_messages alter column body type text using body::text;
+alter table broker_messages add column sender text;
+alter table broker_messages add column key_id text;
+alter table broker_messages add column signature text;
+
+commit;
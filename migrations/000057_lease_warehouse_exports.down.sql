@@ -0,0 +1,5 @@
+begin;
+
+alter table warehouse_exports drop column leased_until;
+
+commit;
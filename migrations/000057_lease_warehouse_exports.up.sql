@@ -0,0 +1,8 @@
+begin;
+
+-- The exporter uploads a batch outside of any transaction, so the transaction-scoped advisory
+-- lock no longer keeps instances apart. An instance leases the dataset for one batch instead;
+-- an expired lease is taken over, and the cursor moves only from the position the batch was read at.
+alter table warehouse_exports add column leased_until timestamptz;
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table rides drop column if exists flat_rate_id;
+
+commit;
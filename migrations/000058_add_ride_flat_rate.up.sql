@@ -0,0 +1,7 @@
+begin;
+
+-- Flat rate version that set the ride fare, null - the fare was calculated by the formula.
+-- The version is kept, so a later change of the rate does not change what the ride was charged by.
+alter table rides add column flat_rate_id uuid references flat_rates(id);
+
+commit;
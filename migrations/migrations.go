@@ -0,0 +1,8 @@
+// Package migrations встраивает SQL миграции в бинарник, чтобы сервисы могли
+// сверить схему базы с ожидаемой при старте.
+package migrations
+
+import "embed"
+
+//go:embed *.up.sql
+var FS embed.FS
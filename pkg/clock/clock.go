@@ -0,0 +1,56 @@
+// Package clock — источник текущего времени для сервисов. В коде сервисов время берется
+// из внедренного Clock, а не из time.Now, чтобы тесты могли управлять им через Fake.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock возвращает текущее время
+type Clock interface {
+	Now() time.Time
+}
+
+// Real — системные часы
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// New возвращает системные часы
+func New() Clock {
+	return Real{}
+}
+
+// Fake — часы для тестов: время стоит на месте, пока его не переведут
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake создает часы, показывающие now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set переводит часы на now
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	f.mu.Unlock()
+}
+
+// Advance переводит часы вперед на d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
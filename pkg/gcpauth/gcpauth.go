@@ -0,0 +1,120 @@
+// Package gcpauth получает OAuth токены сервисного аккаунта Google по JSON ключу (JWT bearer grant)
+// для REST API Google без клиентских библиотек.
+package gcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ServiceAccount — поля JSON ключа сервисного аккаунта, нужные для получения токена
+type ServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Load читает JSON ключ сервисного аккаунта
+func Load(credentialsFile string) (ServiceAccount, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return ServiceAccount{}, fmt.Errorf("read credentials: %w", err)
+	}
+
+	var account ServiceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return ServiceAccount{}, fmt.Errorf("parse credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" || account.TokenURI == "" {
+		return ServiceAccount{}, errors.New("credentials must contain client_email, private_key and token_uri")
+	}
+	return account, nil
+}
+
+// TokenSource выдает токен доступа с областью scope и обновляет его за минуту до истечения
+type TokenSource struct {
+	account ServiceAccount
+	scope   string
+	http    *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func NewTokenSource(account ServiceAccount, scope string, client *http.Client) *TokenSource {
+	return &TokenSource{
+		account: account,
+		scope:   scope,
+		http:    client,
+	}
+}
+
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expiresAt.Add(-time.Minute)) {
+		return s.token, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(s.account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("parse private key: %w", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.account.ClientEmail,
+		"scope": s.scope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("sign token assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || out.AccessToken == "" {
+		return "", errors.New("token request failed: " + out.Error)
+	}
+
+	s.token = out.AccessToken
+	s.expiresAt = now.Add(time.Duration(out.ExpiresIn) * time.Second)
+	return s.token, nil
+}
@@ -0,0 +1,143 @@
+package gcpauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenServer — OAuth сервер Google: проверяет assertion ключом аккаунта и выдает токен
+type tokenServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	expiresIn int
+	requests  int
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &tokenServer{key: key, expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests++
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error_description": "unsupported grant type"})
+			return
+		}
+
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(r.FormValue("assertion"), claims, func(*jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience(s.URL+"/token"))
+		if err != nil || claims["iss"] != "push@project.iam.gserviceaccount.com" || claims["scope"] != "scope" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error_description": "invalid assertion"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"access_token": fmt.Sprintf("token-%d", s.requests), "expires_in": s.expiresIn})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tokenServer) account() ServiceAccount {
+	der := x509.MarshalPKCS1PrivateKey(s.key)
+	return ServiceAccount{
+		ProjectID:   "project",
+		ClientEmail: "push@project.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})),
+		TokenURI:    s.URL + "/token",
+	}
+}
+
+func TestLoad(t *testing.T) {
+	account := newTokenServer(t, 3600).account()
+	raw, err := json.Marshal(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != account {
+		t.Fatalf("loaded %+v, want %+v", loaded, account)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"client_email":"push@project.iam.gserviceaccount.com"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("credentials without private key must be rejected")
+	}
+}
+
+// Токен обменивается по подписанному assertion и переиспользуется до минуты перед истечением
+func TestTokenSource_Exchange(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	source := NewTokenSource(server.account(), "scope", server.Client())
+
+	for range 2 {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "token-1" {
+			t.Fatalf("got token %q, want token-1", token)
+		}
+	}
+	if server.requests != 1 {
+		t.Fatalf("token requested %d times, want once", server.requests)
+	}
+}
+
+func TestTokenSource_RefreshesExpiring(t *testing.T) {
+	server := newTokenServer(t, 30)
+	source := NewTokenSource(server.account(), "scope", server.Client())
+
+	for range 2 {
+		if _, err := source.Token(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if server.requests != 2 {
+		t.Fatalf("token requested %d times, want a new token for each call", server.requests)
+	}
+}
+
+func TestTokenSource_Rejected(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	account := server.account()
+	account.ClientEmail = "other@project.iam.gserviceaccount.com"
+	source := NewTokenSource(account, "scope", server.Client())
+
+	if _, err := source.Token(context.Background()); err == nil {
+		t.Fatal("rejected assertion must fail")
+	}
+	// после ошибки токен не кэшируется
+	if _, err := source.Token(context.Background()); err == nil || server.requests != 2 {
+		t.Fatalf("got %v after %d requests, want a second failed request", err, server.requests)
+	}
+}
@@ -0,0 +1,49 @@
+// Package geohash кодирует координаты в geohash ячейки (base32)
+package geohash
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Encode возвращает geohash ячейку заданной длины для точки (lat, lon).
+// Точность 6 соответствует ячейке примерно 1.2км x 0.6км.
+func Encode(lat, lon float64, precision int) string {
+	if precision <= 0 {
+		return ""
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	hash := make([]byte, 0, precision)
+	even := true // чётные биты кодируют долготу
+	var bit, ch int
+
+	for len(hash) < precision {
+		if even {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				lonRange[0] = mid
+			} else {
+				ch <<= 1
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latRange[0] = mid
+			} else {
+				ch <<= 1
+				latRange[1] = mid
+			}
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			hash = append(hash, base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return string(hash)
+}
@@ -0,0 +1,31 @@
+package geohash
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{43.238949, 76.889709, 6, "txwtvx"},
+		{0, 0, 1, "s"},
+		{43.238949, 76.889709, 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Encode(tt.lat, tt.lon, tt.precision); got != tt.want {
+			t.Errorf("Encode(%v, %v, %d) = %q, want %q", tt.lat, tt.lon, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestEncode_SameCell(t *testing.T) {
+	// две точки в ~100м друг от друга попадают в одну ячейку точности 6
+	a := Encode(43.238949, 76.889709, 6)
+	b := Encode(43.239500, 76.890300, 6)
+	if a != b {
+		t.Fatalf("expected same cell, got %q and %q", a, b)
+	}
+}
@@ -0,0 +1,170 @@
+// Package geoindex хранит координаты объектов в памяти и ищет их в радиусе от точки.
+// Точки разложены по ячейкам сетки широта/долгота, поиск обходит только ячейки,
+// покрывающие радиус.
+package geoindex
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	kmPerDegree   = earthRadiusKm * math.Pi / 180
+)
+
+// Point — последняя известная координата объекта
+type Point struct {
+	Lat, Lon  float64
+	UpdatedAt time.Time
+}
+
+// Hit — объект в радиусе поиска
+type Hit struct {
+	ID         uuid.UUID
+	Lat, Lon   float64
+	DistanceKm float64
+}
+
+type cell struct {
+	lat, lon int
+}
+
+// Index — потокобезопасный индекс координат
+type Index struct {
+	mu      sync.RWMutex
+	cellDeg float64
+	points  map[uuid.UUID]Point
+	cells   map[cell]map[uuid.UUID]struct{}
+}
+
+// New создает индекс с ячейками стороной примерно cellKm по широте
+func New(cellKm float64) *Index {
+	return &Index{
+		cellDeg: cellKm / kmPerDegree,
+		points:  make(map[uuid.UUID]Point),
+		cells:   make(map[cell]map[uuid.UUID]struct{}),
+	}
+}
+
+func (x *Index) cellOf(lat, lon float64) cell {
+	return cell{lat: int(math.Floor(lat / x.cellDeg)), lon: int(math.Floor(lon / x.cellDeg))}
+}
+
+// Set записывает координату объекта. Координата старше уже известной игнорируется,
+// чтобы запоздавшее обновление не откатило объект назад.
+func (x *Index) Set(id uuid.UUID, p Point) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.set(id, p)
+}
+
+func (x *Index) set(id uuid.UUID, p Point) {
+	if old, ok := x.points[id]; ok {
+		if p.UpdatedAt.Before(old.UpdatedAt) {
+			return
+		}
+		x.unlink(id, old)
+	}
+
+	x.points[id] = p
+	c := x.cellOf(p.Lat, p.Lon)
+	ids, ok := x.cells[c]
+	if !ok {
+		ids = make(map[uuid.UUID]struct{})
+		x.cells[c] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (x *Index) unlink(id uuid.UUID, p Point) {
+	c := x.cellOf(p.Lat, p.Lon)
+	delete(x.cells[c], id)
+	if len(x.cells[c]) == 0 {
+		delete(x.cells, c)
+	}
+}
+
+// Remove удаляет объект из индекса
+func (x *Index) Remove(id uuid.UUID) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if old, ok := x.points[id]; ok {
+		x.unlink(id, old)
+		delete(x.points, id)
+	}
+}
+
+// Replace заменяет содержимое индекса снимком, прочитанным на момент asOf.
+// Координаты, записанные через Set после asOf, сохраняются: снимок их еще не видел.
+func (x *Index) Replace(snapshot map[uuid.UUID]Point, asOf time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	old := x.points
+	x.points = make(map[uuid.UUID]Point, len(snapshot))
+	x.cells = make(map[cell]map[uuid.UUID]struct{})
+
+	for id, p := range snapshot {
+		x.set(id, p)
+	}
+	for id, p := range old {
+		if p.UpdatedAt.After(asOf) {
+			x.set(id, p)
+		}
+	}
+}
+
+// Len возвращает число объектов в индексе
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.points)
+}
+
+// Nearby возвращает объекты в радиусе radiusKm от точки, ближайшие первыми
+func (x *Index) Nearby(lat, lon, radiusKm float64) []Hit {
+	dLat := radiusKm / kmPerDegree
+	// у полюсов градус долготы стремится к нулю, ограничиваем охват всей окружностью
+	dLon := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > radiusKm/(kmPerDegree*180) {
+		dLon = dLat / cos
+	}
+
+	lo := x.cellOf(lat-dLat, lon-dLon)
+	hi := x.cellOf(lat+dLat, lon+dLon)
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var hits []Hit
+	for cl := lo.lat; cl <= hi.lat; cl++ {
+		for cn := lo.lon; cn <= hi.lon; cn++ {
+			for id := range x.cells[cell{lat: cl, lon: cn}] {
+				p := x.points[id]
+				if d := distanceKm(lat, lon, p.Lat, p.Lon); d <= radiusKm {
+					hits = append(hits, Hit{ID: id, Lat: p.Lat, Lon: p.Lon, DistanceKm: d})
+				}
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].DistanceKm < hits[j].DistanceKm })
+	return hits
+}
+
+// distanceKm — расстояние по формуле гаверсинусов
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,55 @@
+package geoindex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+func TestNearby(t *testing.T) {
+	idx := New(2)
+	now := time.Now()
+
+	near, far, other := uuid.New(), uuid.New(), uuid.New()
+	idx.Set(near, Point{Lat: 43.2389, Lon: 76.8897, UpdatedAt: now})  // ~0.1км от центра
+	idx.Set(far, Point{Lat: 43.2650, Lon: 76.9300, UpdatedAt: now})   // ~4.4км, соседние ячейки
+	idx.Set(other, Point{Lat: 43.3500, Lon: 76.8897, UpdatedAt: now}) // ~12км
+
+	hits := idx.Nearby(43.2380, 76.8897, 5)
+	if len(hits) != 2 || hits[0].ID != near || hits[1].ID != far {
+		t.Fatalf("got %+v, want near then far", hits)
+	}
+
+	// водитель переехал: старая ячейка больше его не возвращает
+	idx.Set(near, Point{Lat: 43.3500, Lon: 76.8897, UpdatedAt: now.Add(time.Second)})
+	if hits := idx.Nearby(43.2380, 76.8897, 1); len(hits) != 0 {
+		t.Fatalf("moved point still found: %+v", hits)
+	}
+
+	// запоздавшее обновление не откатывает координату
+	idx.Set(near, Point{Lat: 43.2389, Lon: 76.8897, UpdatedAt: now})
+	if hits := idx.Nearby(43.2380, 76.8897, 1); len(hits) != 0 {
+		t.Fatalf("stale update applied: %+v", hits)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	idx := New(2)
+	asOf := time.Now()
+
+	gone, fresh, synced := uuid.New(), uuid.New(), uuid.New()
+	idx.Set(gone, Point{Lat: 43.238, Lon: 76.889, UpdatedAt: asOf.Add(-time.Minute)})
+	idx.Set(fresh, Point{Lat: 43.238, Lon: 76.889, UpdatedAt: asOf.Add(time.Second)})
+
+	idx.Replace(map[uuid.UUID]Point{synced: {Lat: 43.238, Lon: 76.889, UpdatedAt: asOf}}, asOf)
+
+	if idx.Len() != 2 {
+		t.Fatalf("len = %d, want 2 (snapshot + update after it)", idx.Len())
+	}
+	for _, h := range idx.Nearby(43.238, 76.889, 1) {
+		if h.ID == gone {
+			t.Fatal("point missing from the snapshot must be dropped")
+		}
+	}
+}
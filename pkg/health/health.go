@@ -0,0 +1,180 @@
+// Package health проверяет зависимости сервиса для проб liveness и readiness.
+//
+// Ready — сервис может принимать трафик: доступны все критичные зависимости.
+// Live — сервис работоспособен: ни одна критичная зависимость не недоступна дольше LivenessGrace.
+// Кратковременный сбой базы или брокера снимает экземпляр с балансировки, а перезапуск
+// оркестратор делает, только если зависимость не восстановилась за отведенное время.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded" // недоступны некритичные зависимости
+	StatusDown     Status = "down"
+)
+
+// CheckFunc проверяет зависимость. details попадают в ответ как есть, например статистика пула.
+type CheckFunc func(ctx context.Context) (details any, err error)
+
+type Options struct {
+	Timeout       time.Duration // таймаут одной проверки
+	LivenessGrace time.Duration // сколько критичная зависимость может быть недоступна до провала liveness
+}
+
+// Result — результат проверки одной зависимости
+type Result struct {
+	Status    Status     `json:"status"`
+	Critical  bool       `json:"critical"`
+	LatencyMs float64    `json:"latency_ms"`
+	Error     string     `json:"error,omitempty"`
+	DownSince *time.Time `json:"down_since,omitempty"`
+	Details   any        `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status  Status            `json:"status"`
+	Service string            `json:"service"`
+	Checks  map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+
+	downSince time.Time // нулевое — последняя проверка прошла
+}
+
+type Registry struct {
+	service string
+	opts    Options
+
+	mu     sync.Mutex
+	checks []*check
+}
+
+func New(service string, opts Options) *Registry {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	return &Registry{
+		service: service,
+		opts:    opts,
+	}
+}
+
+// Register добавляет проверку зависимости. Недоступная критичная зависимость проваливает readiness,
+// некритичная только переводит статус в degraded.
+func (r *Registry) Register(name string, critical bool, fn CheckFunc) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, &check{name: name, critical: critical, fn: fn})
+}
+
+// Ready проверяет все зависимости: down, если недоступна хотя бы одна критичная
+func (r *Registry) Ready(ctx context.Context) Report {
+	report := r.run(ctx)
+	for _, res := range report.Checks {
+		if res.Status != StatusDown {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// Live проверяет все зависимости: down, если критичная недоступна дольше LivenessGrace
+func (r *Registry) Live(ctx context.Context) Report {
+	report := r.run(ctx)
+	now := time.Now()
+	for _, res := range report.Checks {
+		if res.Status != StatusDown {
+			continue
+		}
+		if res.Critical && res.DownSince != nil && now.Sub(*res.DownSince) > r.opts.LivenessGrace {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// run выполняет проверки параллельно, каждая со своим таймаутом
+func (r *Registry) run(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]Result)}
+	if r == nil {
+		return report
+	}
+	report.Service = r.service
+
+	r.mu.Lock()
+	checks := append([]*check{}, r.checks...)
+	r.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+	}
+	return report
+}
+
+func (r *Registry) runCheck(parent context.Context, c *check) Result {
+	ctx, cancel := context.WithTimeout(parent, r.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := c.fn(ctx)
+	latency := time.Since(start)
+
+	res := Result{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		Details:   details,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		c.downSince = time.Time{}
+		return res
+	}
+
+	res.Status = StatusDown
+	res.Error = err.Error()
+	// пробу оборвал сам оркестратор — отсчет недоступности не начинается
+	if parent.Err() != nil && c.downSince.IsZero() {
+		return res
+	}
+	if c.downSince.IsZero() {
+		c.downSince = start
+	}
+	since := c.downSince
+	res.DownSince = &since
+	return res
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	var dbErr, cacheErr error
+	r := New("ride-service", Options{Timeout: time.Second, LivenessGrace: time.Minute})
+	r.Register("postgres", true, func(context.Context) (any, error) { return nil, dbErr })
+	r.Register("cache", false, func(context.Context) (any, error) { return nil, cacheErr })
+
+	if got := r.Ready(context.Background()); got.Status != StatusUp || len(got.Checks) != 2 {
+		t.Fatalf("all dependencies up: got %+v", got)
+	}
+
+	cacheErr = errors.New("unavailable")
+	if got := r.Ready(context.Background()); got.Status != StatusDegraded {
+		t.Fatalf("non-critical dependency down: got status %s, want degraded", got.Status)
+	}
+
+	dbErr = errors.New("connection refused")
+	got := r.Ready(context.Background())
+	if got.Status != StatusDown {
+		t.Fatalf("critical dependency down: got status %s, want down", got.Status)
+	}
+	if res := got.Checks["postgres"]; res.Error != dbErr.Error() || res.DownSince == nil {
+		t.Fatalf("unexpected postgres result: %+v", res)
+	}
+}
+
+func TestLive_Grace(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	r := New("ride-service", Options{Timeout: time.Second, LivenessGrace: time.Minute})
+	r.Register("postgres", true, func(context.Context) (any, error) { return nil, dbErr })
+
+	// только что упавшая зависимость не перезапускает сервис
+	if got := r.Live(context.Background()); got.Status != StatusDegraded {
+		t.Fatalf("within grace: got status %s, want degraded", got.Status)
+	}
+
+	r.checks[0].downSince = time.Now().Add(-2 * time.Minute)
+	if got := r.Live(context.Background()); got.Status != StatusDown {
+		t.Fatalf("after grace: got status %s, want down", got.Status)
+	}
+
+	dbErr = nil
+	if got := r.Live(context.Background()); got.Status != StatusUp || got.Checks["postgres"].DownSince != nil {
+		t.Fatalf("recovered: got %+v", got)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	r := New("ride-service", Options{Timeout: 10 * time.Millisecond})
+	r.Register("rabbitmq", true, func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	got := r.Ready(context.Background())
+	if got.Status != StatusDown || got.Checks["rabbitmq"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("hanging check must fail by timeout: got %+v", got)
+	}
+}
@@ -0,0 +1,188 @@
+// Package incident переключает сервис в режим только для чтения, пока основная база недоступна.
+//
+// Switch периодически проверяет основную базу. После FailureThreshold неудачных проверок подряд
+// включается режим инцидента: чтения идут в реплику (если она задана) и кэши, запросы на запись
+// отклоняются с 503 вместо каскада 500-х. После RecoveryThreshold успешных проверок режим выключается.
+package incident
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// Pinger — проверка доступности базы
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Options struct {
+	Enabled           bool
+	CheckInterval     time.Duration
+	CheckTimeout      time.Duration
+	FailureThreshold  int
+	RecoveryThreshold int
+}
+
+// State — текущее состояние переключателя
+type State struct {
+	ReadOnly bool       `json:"read_only"`
+	Since    *time.Time `json:"since,omitempty"`
+	Replica  bool       `json:"replica"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+type Switch struct {
+	primary Pinger
+	replica *pgxpool.Pool
+	opts    Options
+
+	mu        sync.RWMutex
+	readOnly  bool
+	since     time.Time
+	reason    string
+	failures  int
+	successes int
+
+	l logger.Logger
+}
+
+// New создает переключатель. replica может быть nil — тогда в режиме инцидента чтения
+// обслуживаются только кэшами, а запросы к базе завершаются ошибкой.
+func New(primary Pinger, replica *pgxpool.Pool, opts Options, l logger.Logger) *Switch {
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = 1
+	}
+	if opts.RecoveryThreshold < 1 {
+		opts.RecoveryThreshold = 1
+	}
+	return &Switch{
+		primary: primary,
+		replica: replica,
+		opts:    opts,
+		l:       l,
+	}
+}
+
+// ReadOnly сообщает, включен ли режим инцидента
+func (s *Switch) ReadOnly() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readOnly
+}
+
+// Replica возвращает пул реплики или nil
+func (s *Switch) Replica() *pgxpool.Pool {
+	if s == nil {
+		return nil
+	}
+	return s.replica
+}
+
+func (s *Switch) State() State {
+	if s == nil {
+		return State{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state := State{
+		ReadOnly: s.readOnly,
+		Replica:  s.replica != nil,
+		Reason:   s.reason,
+	}
+	if s.readOnly {
+		since := s.since
+		state.Since = &since
+	}
+	return state
+}
+
+// Run проверяет основную базу до отмены контекста
+func (s *Switch) Run(ctx context.Context) {
+	if s == nil || !s.opts.Enabled || s.opts.CheckInterval <= 0 {
+		return
+	}
+	ctx = wrap.WithAction(ctx, "incident_health_check")
+
+	ticker := time.NewTicker(s.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx)
+		}
+	}
+}
+
+func (s *Switch) check(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, s.opts.CheckTimeout)
+	err := s.primary.Ping(pingCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.observe(ctx, err)
+}
+
+// observe учитывает результат проверки и переключает режим по порогам
+func (s *Switch) observe(ctx context.Context, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.successes = 0
+		s.failures++
+		if !s.readOnly && s.failures >= s.opts.FailureThreshold {
+			s.readOnly = true
+			s.since = time.Now()
+			s.reason = err.Error()
+			s.l.Warn(ctx, "primary database is unhealthy, switching to read-only mode",
+				"failures", s.failures,
+				"replica", s.replica != nil,
+				"error", err.Error(),
+			)
+		}
+		return
+	}
+
+	s.failures = 0
+	if !s.readOnly {
+		return
+	}
+	s.successes++
+	if s.successes >= s.opts.RecoveryThreshold {
+		s.l.Info(ctx, "primary database recovered, leaving read-only mode",
+			"duration", time.Since(s.since).Round(time.Second).String(),
+		)
+		s.readOnly = false
+		s.since = time.Time{}
+		s.reason = ""
+		s.successes = 0
+	}
+}
+
+type ctxKeyReplica struct{}
+
+// WithReplica направляет запросы к базе вне транзакций в реплику
+func WithReplica(ctx context.Context, replica *pgxpool.Pool) context.Context {
+	return context.WithValue(ctx, ctxKeyReplica{}, replica)
+}
+
+// ReplicaFromContext возвращает реплику, выбранную для запроса
+func ReplicaFromContext(ctx context.Context) (*pgxpool.Pool, bool) {
+	replica, ok := ctx.Value(ctxKeyReplica{}).(*pgxpool.Pool)
+	return replica, ok && replica != nil
+}
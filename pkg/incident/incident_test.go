@@ -0,0 +1,54 @@
+package incident
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+)
+
+func TestSwitch_Thresholds(t *testing.T) {
+	ctx := context.Background()
+	s := New(nil, nil, Options{Enabled: true, FailureThreshold: 3, RecoveryThreshold: 2}, logger.InitLogger("test", "error"))
+	down := errors.New("connection refused")
+
+	s.observe(ctx, down)
+	s.observe(ctx, down)
+	if s.ReadOnly() {
+		t.Fatal("switched to read-only before failure threshold")
+	}
+
+	// успешная проверка сбрасывает счетчик неудач
+	s.observe(ctx, nil)
+	s.observe(ctx, down)
+	s.observe(ctx, down)
+	if s.ReadOnly() {
+		t.Fatal("failures must be consecutive")
+	}
+
+	s.observe(ctx, down)
+	state := s.State()
+	if !state.ReadOnly || state.Since == nil || state.Reason != down.Error() {
+		t.Fatalf("expected read-only state, got %+v", state)
+	}
+
+	s.observe(ctx, nil)
+	if !s.ReadOnly() {
+		t.Fatal("left read-only before recovery threshold")
+	}
+	s.observe(ctx, nil)
+	if s.ReadOnly() {
+		t.Fatal("expected recovery after two successful checks")
+	}
+	if s.State().Since != nil {
+		t.Fatal("since must be cleared after recovery")
+	}
+}
+
+func TestSwitch_Nil(t *testing.T) {
+	var s *Switch
+	if s.ReadOnly() || s.Replica() != nil {
+		t.Fatal("nil switch must behave as healthy")
+	}
+}
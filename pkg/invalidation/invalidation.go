@@ -0,0 +1,154 @@
+// Package invalidation рассылает события сброса кэшей всем экземплярам сервисов.
+//
+// События отправляются через Postgres NOTIFY в канал "cache_invalidation": все сервисы
+// работают с одной базой, поэтому отдельный брокер не нужен, а уведомление приходит
+// за миллисекунды. Внутри транзакции trm NOTIFY доставляется только после commit,
+// так что экземпляры не перечитают данные раньше, чем изменение станет видно.
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+)
+
+// Channel — канал NOTIFY событий сброса кэшей
+const Channel = "cache_invalidation"
+
+// Event — событие сброса. Пустой Key сбрасывает кэш целиком.
+type Event struct {
+	Cache string `json:"cache"`
+	Key   string `json:"key,omitempty"`
+}
+
+// Handler сбрасывает локальный кэш по событию
+type Handler func(ctx context.Context, e Event)
+
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+type Bus struct {
+	pool *pgxpool.Pool
+
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+
+	log logger.Logger
+}
+
+func New(pool *pgxpool.Pool, log logger.Logger) *Bus {
+	return &Bus{
+		pool:     pool,
+		handlers: make(map[string][]Handler),
+		log:      log,
+	}
+}
+
+// Subscribe регистрирует обработчик кэша. Вызывается до Listen.
+func (b *Bus) Subscribe(cache string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[cache] = append(b.handlers[cache], h)
+}
+
+// Publish отправляет событие сброса всем экземплярам, включая текущий
+func (b *Bus) Publish(ctx context.Context, cache, key string) error {
+	payload, err := json.Marshal(Event{Cache: cache, Key: key})
+	if err != nil {
+		return fmt.Errorf("invalidation: marshal event: %w", err)
+	}
+
+	if _, err := b.db(ctx).Exec(ctx, "SELECT pg_notify($1, $2)", Channel, string(payload)); err != nil {
+		return fmt.Errorf("invalidation: notify %s: %w", cache, err)
+	}
+	return nil
+}
+
+// Listen держит отдельное соединение с LISTEN до отмены ctx и переподключается при обрыве
+func (b *Bus) Listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := b.listenOnce(ctx); err != nil && ctx.Err() == nil {
+			b.log.Error(ctx, "cache invalidation listener failed, reconnecting", err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+}
+
+func (b *Bus) listenOnce(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire listener connection: %w", err)
+	}
+	// соединение с LISTEN не должно вернуться в пул
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{Channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	// события, отправленные пока слушателя не было, потеряны — сбрасываем все кэши
+	b.dispatchAll(ctx)
+	b.log.Debug(ctx, "cache invalidation listener started", "channel", Channel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		var e Event
+		if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+			b.log.Warn(ctx, "invalid cache invalidation event", "payload", n.Payload, "error", err)
+			continue
+		}
+		b.dispatch(ctx, e)
+	}
+}
+
+func (b *Bus) dispatch(ctx context.Context, e Event) {
+	b.mu.RLock()
+	handlers := b.handlers[e.Cache]
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+	b.log.Debug(ctx, "cache invalidated", "cache", e.Cache, "key", e.Key)
+}
+
+func (b *Bus) dispatchAll(ctx context.Context) {
+	b.mu.RLock()
+	caches := make([]string, 0, len(b.handlers))
+	for cache := range b.handlers {
+		caches = append(caches, cache)
+	}
+	b.mu.RUnlock()
+
+	for _, cache := range caches {
+		b.dispatch(ctx, Event{Cache: cache})
+	}
+}
+
+// db возвращает транзакцию из контекста или пул
+func (b *Bus) db(ctx context.Context) querier {
+	if tx, ok := ctx.Value(trm.TxKey).(pgx.Tx); ok {
+		return tx
+	}
+	return b.pool
+}
@@ -0,0 +1,167 @@
+// Package keyring шифрует персональные данные (PII) AES-GCM ключами с ротацией.
+//
+// Зашифрованное значение хранится строкой "enc:<key_id>:<base64(nonce|ciphertext)>",
+// поэтому старые ключи остаются в keyring для чтения, а новые записи шифруются активным ключом.
+// Значения без префикса считаются открытым текстом и возвращаются как есть.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const prefix = "enc:"
+
+var (
+	ErrUnknownKey       = errors.New("keyring: unknown key id")
+	ErrMalformed        = errors.New("keyring: malformed encrypted value")
+	ErrNoActiveKey      = errors.New("keyring: active key is not in the keyring")
+	ErrInvalidKeyLength = errors.New("keyring: key must be 16, 24 or 32 bytes")
+)
+
+// Keyring хранит AES-GCM ключи по идентификаторам. Пустой keyring не шифрует значения.
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// New создает keyring. keys — список "id:base64key" через запятую, active — id ключа для шифрования.
+// Без ключей keyring работает в режиме passthrough.
+func New(active, keys string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	for _, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("keyring: invalid key entry %q, expected id:base64key", entry)
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: decode key %s: %w", id, err)
+		}
+
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %s: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+
+	if len(k.keys) == 0 {
+		return k, nil
+	}
+	if _, ok := k.keys[active]; !ok {
+		return nil, ErrNoActiveKey
+	}
+	k.active = active
+
+	return k, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidKeyLength
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Enabled сообщает, настроено ли шифрование
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.active != ""
+}
+
+// ActiveKeyID возвращает идентификатор ключа, которым шифруются новые значения
+func (k *Keyring) ActiveKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.active
+}
+
+// Encrypt шифрует значение активным ключом. Пустая строка не шифруется.
+// Значение с префиксом "enc:" тоже шифруется: это ввод пользователя, а не шифротекст.
+// Уже зашифрованные значения пропускает только перешифровка (NeedsRotation).
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if !k.Enabled() || plaintext == "" {
+		return plaintext, nil
+	}
+
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("keyring: generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.active))
+	return prefix + k.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt расшифровывает значение ключом из его префикса. Открытый текст возвращается как есть.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+
+	var aead cipher.AEAD
+	if k != nil {
+		aead = k.keys[id]
+	}
+	if aead == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("keyring: decrypt with key %s: %w", id, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsRotation сообщает, что значение нужно перешифровать активным ключом:
+// оно еще не зашифровано или зашифровано старым ключом
+func (k *Keyring) NeedsRotation(value string) bool {
+	if !k.Enabled() || value == "" {
+		return false
+	}
+	return !strings.HasPrefix(value, k.ActivePrefix())
+}
+
+// ActivePrefix — префикс значений, зашифрованных активным ключом
+func (k *Keyring) ActivePrefix() string {
+	return prefix + k.ActiveKeyID() + ":"
+}
+
+// IsEncrypted сообщает, что значение зашифровано keyring
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
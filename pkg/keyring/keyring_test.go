@@ -0,0 +1,126 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	k, err := New("k1", "k1:"+key('a'))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := k.Encrypt("+77011234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "enc:k1:") {
+		t.Fatalf("unexpected ciphertext format: %s", enc)
+	}
+
+	dec, err := k.Decrypt(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec != "+77011234567" {
+		t.Fatalf("got %q", dec)
+	}
+}
+
+// Адрес, похожий на шифротекст, шифруется как обычный текст и читается без ошибки
+func TestEncrypt_PrefixedPlaintext(t *testing.T) {
+	k, _ := New("k1", "k1:"+key('a'))
+
+	enc, err := k.Encrypt("enc:a:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enc, "enc:k1:") {
+		t.Fatalf("prefixed plaintext must be encrypted, got %q", enc)
+	}
+
+	dec, err := k.Decrypt(enc)
+	if err != nil || dec != "enc:a:b" {
+		t.Fatalf("got %q, %v", dec, err)
+	}
+}
+
+func TestEncrypt_NonDeterministic(t *testing.T) {
+	k, _ := New("k1", "k1:"+key('a'))
+
+	a, _ := k.Encrypt("Abay Ave 10")
+	b, _ := k.Encrypt("Abay Ave 10")
+	if a == b {
+		t.Fatal("the same plaintext must produce different ciphertexts")
+	}
+}
+
+func TestRotation_OldKeyStillDecrypts(t *testing.T) {
+	old, _ := New("k1", "k1:"+key('a'))
+	enc, _ := old.Encrypt("secret")
+
+	rotated, err := New("k2", "k1:"+key('a')+",k2:"+key('b'))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rotated.NeedsRotation(enc) {
+		t.Fatal("value encrypted with an old key must need rotation")
+	}
+	dec, err := rotated.Decrypt(enc)
+	if err != nil || dec != "secret" {
+		t.Fatalf("decrypt with old key: %q, %v", dec, err)
+	}
+
+	reenc, _ := rotated.Encrypt(dec)
+	if rotated.NeedsRotation(reenc) {
+		t.Fatal("value encrypted with the active key must not need rotation")
+	}
+}
+
+func TestDecrypt_UnknownKey(t *testing.T) {
+	k1, _ := New("k1", "k1:"+key('a'))
+	enc, _ := k1.Encrypt("secret")
+
+	k2, _ := New("k2", "k2:"+key('b'))
+	if _, err := k2.Decrypt(enc); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	k, err := New("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, _ := k.Encrypt("plain")
+	if enc != "plain" {
+		t.Fatalf("disabled keyring must not encrypt, got %q", enc)
+	}
+
+	// значения, записанные до включения шифрования, читаются как есть
+	on, _ := New("k1", "k1:"+key('a'))
+	if dec, err := on.Decrypt("legacy address"); err != nil || dec != "legacy address" {
+		t.Fatalf("plaintext must pass through, got %q, %v", dec, err)
+	}
+	if !on.NeedsRotation("legacy address") {
+		t.Fatal("plaintext must need rotation once encryption is enabled")
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New("k2", "k1:"+key('a')); !errors.Is(err, ErrNoActiveKey) {
+		t.Fatalf("expected ErrNoActiveKey, got %v", err)
+	}
+	if _, err := New("k1", "k1:"+base64.StdEncoding.EncodeToString([]byte("short"))); !errors.Is(err, ErrInvalidKeyLength) {
+		t.Fatalf("expected ErrInvalidKeyLength, got %v", err)
+	}
+}
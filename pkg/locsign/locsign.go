@@ -0,0 +1,51 @@
+// Package locsign подписывает координаты водителя HMAC-SHA256 секретом устройства.
+//
+// Подписывается каноническая строка "<timestamp>\n<latitude>\n<longitude>",
+// где timestamp — unix секунды, а координаты округлены до 6 знаков после запятой.
+// Одна и та же строка используется и в HTTP, и в WebSocket, поэтому приложению
+// не нужно повторять сериализацию JSON сервера.
+package locsign
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// secretSize — длина секрета устройства в байтах
+const secretSize = 32
+
+// NewSecret генерирует секрет устройства в base64 (raw url).
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate device secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Payload собирает каноническую строку для подписи.
+func Payload(timestamp int64, latitude, longitude float64) []byte {
+	return fmt.Appendf(nil, "%d\n%.6f\n%.6f", timestamp, latitude, longitude)
+}
+
+// Sign возвращает hex HMAC-SHA256 подпись payload.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify сравнивает подпись за постоянное время.
+func Verify(secret string, payload []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
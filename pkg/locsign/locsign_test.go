@@ -0,0 +1,41 @@
+package locsign
+
+import "testing"
+
+func TestSignVerify(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := Payload(1735689600, 43.238949, 76.889709)
+	sig := Sign(secret, payload)
+	if !Verify(secret, payload, sig) {
+		t.Fatalf("signature must be valid")
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	secret := "device-secret"
+	sig := Sign(secret, Payload(1735689600, 43.238949, 76.889709))
+
+	if Verify(secret, Payload(1735689600, 43.238950, 76.889709), sig) {
+		t.Fatalf("changed latitude must not verify")
+	}
+	if Verify(secret, Payload(1735689601, 43.238949, 76.889709), sig) {
+		t.Fatalf("changed timestamp must not verify")
+	}
+	if Verify("other-secret", Payload(1735689600, 43.238949, 76.889709), sig) {
+		t.Fatalf("other secret must not verify")
+	}
+	if Verify(secret, Payload(1735689600, 43.238949, 76.889709), "not-hex") {
+		t.Fatalf("malformed signature must not verify")
+	}
+}
+
+func TestPayload_Canonical(t *testing.T) {
+	want := "1735689600\n43.238949\n76.889709"
+	if got := string(Payload(1735689600, 43.2389491, 76.8897089)); got != want {
+		t.Fatalf("unexpected payload: got %q want %q", got, want)
+	}
+}
@@ -106,6 +106,12 @@ func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
 		if c.PassengerID != "" {
 			r.AddAttrs(slog.String("passenger_id", c.PassengerID))
 		}
+		if c.CallerService != "" {
+			r.AddAttrs(slog.String("caller_service", c.CallerService))
+		}
+		if c.TraceID != "" {
+			r.AddAttrs(slog.String("trace_id", c.TraceID))
+		}
 	}
 
 	return h.handler.Handle(ctx, r)
@@ -15,6 +15,10 @@ type (
 		DriverID    string
 		RideNumber  string
 		OfferID     string
+		// CallerService — сервис, от которого пришел внутренний вызов или сообщение
+		CallerService string
+		// TraceID — трасса OpenTelemetry, по нему лог находится рядом со спанами запроса
+		TraceID string
 	}
 
 	// logCtxKeyStruct is an unexported type for context keys defined in this package.
@@ -43,6 +47,9 @@ func WithLogCtx(ctx context.Context, newLc LogCtx) context.Context {
 		if newLc.DriverID == "" {
 			newLc.DriverID = lc.DriverID
 		}
+		if newLc.TraceID == "" {
+			newLc.TraceID = lc.TraceID
+		}
 		return context.WithValue(ctx, LogCtxKey, newLc)
 	}
 	return context.WithValue(ctx, LogCtxKey, newLc)
@@ -120,6 +127,24 @@ func WithOfferID(ctx context.Context, offerID string) context.Context {
 	return context.WithValue(ctx, LogCtxKey, LogCtx{OfferID: offerID})
 }
 
+// WithCallerService adds or updates the CallerService in the LogCtx within the context
+func WithCallerService(ctx context.Context, service string) context.Context {
+	if lc, ok := ctx.Value(LogCtxKey).(LogCtx); ok {
+		lc.CallerService = service
+		return context.WithValue(ctx, LogCtxKey, lc)
+	}
+	return context.WithValue(ctx, LogCtxKey, LogCtx{CallerService: service})
+}
+
+// WithTraceID adds or updates the TraceID in the LogCtx within the context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	if lc, ok := ctx.Value(LogCtxKey).(LogCtx); ok {
+		lc.TraceID = traceID
+		return context.WithValue(ctx, LogCtxKey, lc)
+	}
+	return context.WithValue(ctx, LogCtxKey, LogCtx{TraceID: traceID})
+}
+
 func GetRequestID(ctx context.Context) string {
 	if lc, ok := ctx.Value(LogCtxKey).(LogCtx); ok {
 		return lc.RequestID
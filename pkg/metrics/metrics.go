@@ -8,6 +8,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// LatencyBuckets - DefBuckets + 0.3s граница, нужна для расчета SLO по p99 < 300ms
+var LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .3, .5, 1, 2.5, 5, 10}
+
 var (
 	// HTTP metrics
 	HttpRequestsTotal = promauto.NewCounterVec(
@@ -22,7 +25,7 @@ var (
 		prometheus.HistogramOpts{
 			Name:    "http_request_duration_seconds",
 			Help:    "HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
+			Buckets: LatencyBuckets,
 		},
 		[]string{"service", "method", "path", "status"},
 	)
@@ -59,6 +62,140 @@ var (
 		},
 		[]string{"service"},
 	)
+
+	WebSocketProtocolConnectionsGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "websocket_protocol_connections_total",
+			Help: "Current number of active WebSocket connections by negotiated protocol version",
+		},
+		[]string{"service", "protocol"},
+	)
+
+	WebSocketProtocolDowngradesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_protocol_downgrades_total",
+			Help: "WebSocket connections served an older protocol version than the client requested",
+		},
+		[]string{"service"},
+	)
+
+	WebSocketResumeMissesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_resume_misses_total",
+			Help: "WebSocket v2 clients resuming a session this instance does not hold",
+		},
+		[]string{"service"},
+	)
+
+	WebSocketDeliveryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "websocket_delivery_duration_seconds",
+			Help:    "Time spent delivering a message to a WebSocket client",
+			Buckets: LatencyBuckets,
+		},
+		[]string{"service"},
+	)
+
+	WebSocketBreakerTripsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_breaker_trips_total",
+			Help: "Times sends to a WebSocket client were suspended after consecutive failures",
+		},
+		[]string{"service"},
+	)
+
+	WebSocketDroppedMessagesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_dropped_messages_total",
+			Help: "WebSocket messages not written to the client by reason (slow_consumer/write_failed)",
+		},
+		[]string{"reason"},
+	)
+
+	WebSocketSlowConsumersTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "websocket_slow_consumers_total",
+			Help: "WebSocket connections closed because their send queue was full",
+		},
+	)
+
+	LocationUpdatesDiscardedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "location_updates_discarded_total",
+			Help: "Driver location updates dropped before persistence by reason (stale/duplicate)",
+		},
+		[]string{"service", "reason"},
+	)
+
+	CoordinateCurrentViolationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "coordinate_current_violations_total",
+			Help: "Entities found with more than one coordinate marked is_current and repaired",
+		},
+		[]string{"entity_type"},
+	)
+
+	DriverSupplyOnlineGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "driver_supply_online",
+			Help: "Online drivers per city and vehicle class, sampled by the supply monitor",
+		},
+		[]string{"city", "vehicle_class"},
+	)
+
+	DriverSupplyAlertsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "driver_supply_alerts_total",
+			Help: "Alerts fired for sharp drops in online drivers",
+		},
+		[]string{"city", "vehicle_class"},
+	)
+
+	DriverOffersRevokedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "driver_offers_revoked_total",
+			Help: "Ride offers revoked because another driver accepted the ride first",
+		},
+	)
+
+	FiscalReceiptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fiscal_receipts_total",
+			Help: "Ride receipt fiscalization attempts by result (success/failed)",
+		},
+		[]string{"result"},
+	)
+
+	DriverCandidateCacheTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "driver_candidate_cache_total",
+			Help: "Driver candidate cache lookups by result (hit/miss)",
+		},
+		[]string{"service", "result"},
+	)
+
+	DriverCacheTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "driver_cache_total",
+			Help: "Driver profile cache lookups by result (hit/miss), every miss is a database read",
+		},
+		[]string{"result"},
+	)
+
+	DriverGeoIndexLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "driver_geo_index_lookups_total",
+			Help: "Driver searches by source: in-memory geo index or PostGIS fallback while the index is stale (index/fallback)",
+		},
+		[]string{"result"},
+	)
+
+	DriverGeoIndexSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "driver_geo_index_size",
+			Help: "Drivers in the in-memory geo index after the last sync",
+		},
+	)
 )
 
 // RecordHTTPMetrics records HTTP request metrics
@@ -0,0 +1,390 @@
+// Package pgbroker — очередь сообщений поверх Postgres для установок без RabbitMQ.
+//
+// Сообщения хранятся в таблице broker_messages, публикация отправляет NOTIFY в канал
+// "broker" с именем очереди, поэтому потребители просыпаются сразу, а не по таймеру.
+// Потребитель забирает сообщение через FOR UPDATE SKIP LOCKED и скрывает его на время
+// visibility timeout: несколько экземпляров сервиса делят очередь как конкурирующие
+// потребители RabbitMQ, а сообщение упавшего экземпляра будет выдано снова.
+//
+// С заданной Identity сообщение подписывается сервисом-отправителем, как в RabbitMQ:
+// сообщение с неверной подписью или без подписи при requireSigned не выдается обработчику
+// и помечается dead_at.
+package pgbroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+	"github.com/Temutjin2k/ride-hail-system/pkg/trm"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel — канал NOTIFY, payload уведомления — имя очереди
+const Channel = "broker"
+
+// Outcome — результат обработки сообщения, аналог ack/nack в RabbitMQ
+type Outcome int
+
+const (
+	Ack     Outcome = iota // сообщение обработано и удаляется
+	Requeue                // сообщение сразу доступно другим потребителям
+	Discard                // сообщение помечается dead_at и больше не выдается
+)
+
+// Message — сообщение для публикации
+type Message struct {
+	Queue         string
+	RoutingKey    string
+	Body          []byte
+	CorrelationID string
+	Priority      uint8
+}
+
+// Delivery — полученное потребителем сообщение
+type Delivery struct {
+	ID            int64
+	RoutingKey    string
+	Body          []byte
+	CorrelationID string
+	Attempts      int
+
+	// подпись отправителя, пустая — сообщение не подписано
+	Sender    string
+	KeyID     string
+	Signature string
+}
+
+// ErrUnsignedMessage — сообщение без подписи при включенном requireSigned
+var ErrUnsignedMessage = errors.New("pgbroker: unsigned message")
+
+// Handler обрабатывает сообщение и решает его судьбу
+type Handler func(ctx context.Context, d Delivery) Outcome
+
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type Client struct {
+	pool         *pgxpool.Pool
+	pollInterval time.Duration
+	lease        time.Duration
+	prefetch     int
+
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+
+	// identity подписывает и проверяет сообщения, nil — подписи выключены
+	identity      *svcauth.Identity
+	requireSigned bool
+
+	log logger.Logger
+}
+
+// New создает клиент и запускает слушателя NOTIFY, который работает до отмены ctx.
+// pollInterval — страховочная проверка очереди на случай потерянного уведомления,
+// lease — время, на которое забранное сообщение скрыто от других потребителей,
+// prefetch — сколько сообщений одной очереди обрабатывается одновременно, как Qos в RabbitMQ.
+func New(ctx context.Context, pool *pgxpool.Pool, pollInterval, lease time.Duration, prefetch int, log logger.Logger) *Client {
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+
+	c := &Client{
+		pool:         pool,
+		pollInterval: pollInterval,
+		lease:        lease,
+		prefetch:     prefetch,
+		waiters:      make(map[string]map[chan struct{}]struct{}),
+		log:          log,
+	}
+
+	go c.listen(ctx)
+
+	return c
+}
+
+// SetIdentity включает подпись исходящих и проверку входящих сообщений.
+// requireSigned — отклонять сообщения без подписи, иначе они принимаются без имени отправителя.
+func (c *Client) SetIdentity(id *svcauth.Identity, requireSigned bool) {
+	c.identity = id
+	c.requireSigned = requireSigned
+}
+
+// Publish добавляет сообщение в очередь. Внутри транзакции trm сообщение
+// фиксируется вместе с ней, а NOTIFY доставляется только после commit.
+func (c *Client) Publish(ctx context.Context, msg Message) error {
+	const query = `
+		WITH m AS (
+			INSERT INTO broker_messages(queue, routing_key, body, correlation_id, priority, sender, key_id, signature)
+			VALUES($1, $2, $3, nullif($4, ''), $5, nullif($7, ''), nullif($8, ''), nullif($9, ''))
+			RETURNING queue
+		)
+		SELECT pg_notify($6, queue) FROM m`
+
+	var sender, kid, signature string
+	if c.identity.Enabled() {
+		sender = c.identity.Service()
+		kid, signature = c.identity.SignMessage(msg.Body)
+	}
+
+	if _, err := c.db(ctx).Exec(ctx, query,
+		msg.Queue,
+		msg.RoutingKey,
+		string(msg.Body),
+		msg.CorrelationID,
+		int16(msg.Priority),
+		Channel,
+		sender,
+		kid,
+		signature,
+	); err != nil {
+		return fmt.Errorf("publish to %s: %w", msg.Queue, err)
+	}
+
+	return nil
+}
+
+// Consume забирает сообщения очереди и обрабатывает каждое в отдельной горутине, как
+// потребители RabbitMQ. Одновременно обрабатывается не больше prefetch сообщений: забирается
+// столько, сколько свободных мест, остальные ждут в очереди и достаются другим экземплярам.
+// Возвращает nil после отмены ctx.
+func (c *Client) Consume(ctx context.Context, queue string, handle Handler) error {
+	wake, unsubscribe := c.subscribe(queue)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, c.prefetch)
+	freed := make(chan struct{}, 1)
+
+	for {
+		full := false
+		for {
+			free := c.prefetch - len(slots)
+			if free == 0 {
+				full = true
+				break
+			}
+
+			batch, err := c.claim(ctx, queue, free)
+			if err != nil {
+				if ctx.Err() == nil {
+					c.log.Error(ctx, "failed to claim messages", err, "queue", queue)
+				}
+				break
+			}
+
+			for _, d := range batch {
+				slots <- struct{}{}
+				go func(d Delivery) {
+					defer func() {
+						<-slots
+						select {
+						case freed <- struct{}{}:
+						default:
+						}
+					}()
+					ctx, err := c.verify(ctx, d)
+					if err != nil {
+						// повтор не сделает подпись верной
+						c.log.Warn(ctx, "rejected message with invalid signature", "queue", queue, "message_id", d.ID, "sender", d.Sender, "reason", err.Error())
+						c.settle(ctx, queue, d, Discard)
+						return
+					}
+					c.settle(ctx, queue, d, handle(ctx, d))
+				}(d)
+			}
+
+			if len(batch) < free {
+				break // очередь пуста
+			}
+		}
+
+		// освободившееся место важно, только если очередь упиралась в prefetch
+		var slotFreed <-chan struct{}
+		if full {
+			slotFreed = freed
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-ticker.C:
+		case <-slotFreed:
+		}
+	}
+}
+
+// claim забирает до limit доступных сообщений, пустой результат — очередь пуста
+func (c *Client) claim(ctx context.Context, queue string, limit int) ([]Delivery, error) {
+	const query = `
+		UPDATE broker_messages
+		SET attempts = attempts + 1, available_at = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM broker_messages
+			WHERE queue = $1
+			  AND dead_at IS NULL
+			  AND available_at <= now()
+			ORDER BY priority DESC, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT $3
+		)
+		RETURNING id, routing_key, body, coalesce(correlation_id, ''), attempts,
+			coalesce(sender, ''), coalesce(key_id, ''), coalesce(signature, '')`
+
+	rows, err := c.pool.Query(ctx, query, queue, c.lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Delivery, error) {
+		var d Delivery
+		err := row.Scan(
+			&d.ID,
+			&d.RoutingKey,
+			&d.Body,
+			&d.CorrelationID,
+			&d.Attempts,
+			&d.Sender,
+			&d.KeyID,
+			&d.Signature,
+		)
+		return d, err
+	})
+}
+
+// verify проверяет подпись сообщения и добавляет отправителя в контекст логов
+func (c *Client) verify(ctx context.Context, d Delivery) (context.Context, error) {
+	if !c.identity.Enabled() {
+		return ctx, nil
+	}
+	if d.Signature == "" {
+		if c.requireSigned {
+			return ctx, ErrUnsignedMessage
+		}
+		return ctx, nil
+	}
+
+	if err := c.identity.VerifyMessage(d.Sender, d.KeyID, d.Body, d.Signature); err != nil {
+		return ctx, err
+	}
+	return wrap.WithCallerService(ctx, d.Sender), nil
+}
+
+// settle применяет результат обработки. Выполняется и после отмены ctx потребителя,
+// иначе обработанное сообщение было бы выдано повторно после lease.
+func (c *Client) settle(ctx context.Context, queue string, d Delivery, outcome Outcome) {
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	switch outcome {
+	case Ack:
+		_, err = c.pool.Exec(ctx, `DELETE FROM broker_messages WHERE id = $1`, d.ID)
+	case Requeue:
+		_, err = c.pool.Exec(ctx, `
+			WITH m AS (
+				UPDATE broker_messages SET available_at = now() WHERE id = $1 RETURNING queue
+			)
+			SELECT pg_notify($2, queue) FROM m`, d.ID, Channel)
+	case Discard:
+		_, err = c.pool.Exec(ctx, `UPDATE broker_messages SET dead_at = now() WHERE id = $1`, d.ID)
+	}
+	if err != nil {
+		c.log.Error(ctx, "failed to settle message", err, "queue", queue, "message_id", d.ID)
+	}
+}
+
+// db возвращает транзакцию из контекста или пул
+func (c *Client) db(ctx context.Context) querier {
+	if tx, ok := ctx.Value(trm.TxKey).(pgx.Tx); ok {
+		return tx
+	}
+	return c.pool
+}
+
+// subscribe регистрирует ожидание уведомлений очереди
+func (c *Client) subscribe(queue string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	c.mu.Lock()
+	if c.waiters[queue] == nil {
+		c.waiters[queue] = make(map[chan struct{}]struct{})
+	}
+	c.waiters[queue][ch] = struct{}{}
+	c.mu.Unlock()
+
+	return ch, func() {
+		c.mu.Lock()
+		delete(c.waiters[queue], ch)
+		c.mu.Unlock()
+	}
+}
+
+// wake будит потребителей очереди. Пустое имя будит всех.
+func (c *Client) wake(queue string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for q, waiters := range c.waiters {
+		if queue != "" && q != queue {
+			continue
+		}
+		for ch := range waiters {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// listen держит отдельное соединение с LISTEN и переподключается при обрыве
+func (c *Client) listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := c.listenOnce(ctx); err != nil && ctx.Err() == nil {
+			c.log.Error(ctx, "broker listener failed, reconnecting", err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+}
+
+func (c *Client) listenOnce(ctx context.Context) error {
+	pooled, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire listener connection: %w", err)
+	}
+	// соединение с LISTEN не должно вернуться в пул
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{Channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	// уведомления, отправленные пока слушателя не было, потеряны — проверяем все очереди
+	c.wake("")
+	c.log.Debug(ctx, "broker listener started", "channel", Channel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		c.wake(n.Payload)
+	}
+}
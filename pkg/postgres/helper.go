@@ -24,3 +24,13 @@ func IsForeignKeyViolation(err error) bool {
 
 	return false
 }
+
+// IsUniqueViolation проверяет, является ли переданная ошибка нарушением уникальности PostgreSQL (SQLSTATE 23505).
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState() == "23505"
+	}
+
+	return false
+}
@@ -36,6 +36,7 @@ func New(ctx context.Context, config config.DatabaseConfig) (*PostgreDB, error)
 	dbConfig.MinConns = config.MinConns
 	dbConfig.MaxConnLifetime = config.MaxConnLifetime
 	dbConfig.MaxConnIdleTime = config.MaxConnIdleTime
+	dbConfig.ConnConfig.Tracer = queryTracer{}
 
 	pool, err := pgxpool.NewWithConfig(ctx, dbConfig)
 	if err != nil {
@@ -0,0 +1,265 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Schema — таблицы с колонками и индексы, которые должны существовать в базе
+type Schema struct {
+	Tables  map[string][]string
+	Indexes []string
+}
+
+// SchemaDrift — объекты ожидаемой схемы, которых нет в базе
+type SchemaDrift struct {
+	MissingTables  []string
+	MissingColumns []string // table.column
+	MissingIndexes []string
+}
+
+func (d *SchemaDrift) Empty() bool {
+	return len(d.MissingTables) == 0 && len(d.MissingColumns) == 0 && len(d.MissingIndexes) == 0
+}
+
+// Error возвращает отчет о расхождениях, по строке на каждый вид объектов
+func (d *SchemaDrift) Error() string {
+	var b strings.Builder
+	b.WriteString("database schema does not match migrations (run pending migrations or start with --skip-schema-check)")
+	if len(d.MissingTables) > 0 {
+		fmt.Fprintf(&b, "\n  missing tables: %s", strings.Join(d.MissingTables, ", "))
+	}
+	if len(d.MissingColumns) > 0 {
+		fmt.Fprintf(&b, "\n  missing columns: %s", strings.Join(d.MissingColumns, ", "))
+	}
+	if len(d.MissingIndexes) > 0 {
+		fmt.Fprintf(&b, "\n  missing indexes: %s", strings.Join(d.MissingIndexes, ", "))
+	}
+	return b.String()
+}
+
+var (
+	dollarQuotedRX = regexp.MustCompile(`(?s)\$\$.*?\$\$`)
+	// строчные и блочные комментарии одним выражением: "--" внутри /* */ и "/*" после "--" не ломают разбор
+	commentRX      = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+	createTableRX  = regexp.MustCompile(`(?is)^create\s+table\s+(?:if\s+not\s+exists\s+)?"?(\w+)"?\s*\((.*)\)$`)
+	alterTableRX   = regexp.MustCompile(`(?is)^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?"?(\w+)"?\s+(.*)$`)
+	createIndexRX  = regexp.MustCompile(`(?is)^create\s+(?:unique\s+)?index\s+(?:concurrently\s+)?(?:if\s+not\s+exists\s+)?"?(\w+)"?\s+on\s`)
+	dropTableRX    = regexp.MustCompile(`(?is)^drop\s+table\s+(?:if\s+exists\s+)?"?(\w+)"?`)
+	dropIndexRX    = regexp.MustCompile(`(?is)^drop\s+index\s+(?:concurrently\s+)?(?:if\s+exists\s+)?"?(\w+)"?`)
+	addColumnRX    = regexp.MustCompile(`(?is)^add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?"?(\w+)"?`)
+	dropColumnRX   = regexp.MustCompile(`(?is)^drop\s+(?:column\s+)?(?:if\s+exists\s+)?"?(\w+)"?`)
+	renameColumnRX = regexp.MustCompile(`(?is)^rename\s+(?:column\s+)?"?(\w+)"?\s+to\s+"?(\w+)"?`)
+)
+
+// tableConstraints — первые слова элементов CREATE TABLE, которые не являются колонками
+var tableConstraints = []string{"constraint", "primary", "unique", "foreign", "check", "exclude", "like"}
+
+// ExpectedSchema строит ожидаемую схему, применяя *.up.sql миграции из fsys по порядку имен.
+// Учитываются CREATE/DROP TABLE, ADD/DROP/RENAME COLUMN и CREATE/DROP INDEX.
+func ExpectedSchema(fsys fs.FS) (*Schema, error) {
+	files, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+
+	s := &Schema{Tables: make(map[string][]string)}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", file, err)
+		}
+		s.apply(string(data))
+	}
+
+	return s, nil
+}
+
+func (s *Schema) apply(sql string) {
+	sql = dollarQuotedRX.ReplaceAllString(sql, "''")
+	sql = commentRX.ReplaceAllString(sql, "")
+
+	for stmt := range strings.SplitSeq(sql, ";") {
+		stmt = strings.TrimSpace(stmt)
+
+		if m := createTableRX.FindStringSubmatch(stmt); m != nil {
+			table := strings.ToLower(m[1])
+			var columns []string
+			for _, item := range splitTopLevel(m[2]) {
+				name := firstWord(item)
+				if name == "" || slices.Contains(tableConstraints, name) {
+					continue
+				}
+				columns = append(columns, name)
+			}
+			s.Tables[table] = columns
+			continue
+		}
+
+		if m := alterTableRX.FindStringSubmatch(stmt); m != nil {
+			table := strings.ToLower(m[1])
+			for _, action := range splitTopLevel(m[2]) {
+				action = strings.TrimSpace(action)
+				switch {
+				case addColumnRX.MatchString(action) && !isConstraintAction(action):
+					col := strings.ToLower(addColumnRX.FindStringSubmatch(action)[1])
+					if !slices.Contains(s.Tables[table], col) {
+						s.Tables[table] = append(s.Tables[table], col)
+					}
+				case renameColumnRX.MatchString(action):
+					mm := renameColumnRX.FindStringSubmatch(action)
+					from, to := strings.ToLower(mm[1]), strings.ToLower(mm[2])
+					if i := slices.Index(s.Tables[table], from); i >= 0 {
+						s.Tables[table][i] = to
+					}
+				case dropColumnRX.MatchString(action) && !isConstraintAction(action):
+					col := strings.ToLower(dropColumnRX.FindStringSubmatch(action)[1])
+					s.Tables[table] = slices.DeleteFunc(s.Tables[table], func(c string) bool { return c == col })
+				}
+			}
+			continue
+		}
+
+		if m := createIndexRX.FindStringSubmatch(stmt); m != nil {
+			s.Indexes = append(s.Indexes, strings.ToLower(m[1]))
+			continue
+		}
+
+		if m := dropTableRX.FindStringSubmatch(stmt); m != nil {
+			delete(s.Tables, strings.ToLower(m[1]))
+			continue
+		}
+
+		if m := dropIndexRX.FindStringSubmatch(stmt); m != nil {
+			index := strings.ToLower(m[1])
+			s.Indexes = slices.DeleteFunc(s.Indexes, func(i string) bool { return i == index })
+		}
+	}
+}
+
+// isConstraintAction — ADD/DROP CONSTRAINT в ALTER TABLE не меняет колонки
+func isConstraintAction(action string) bool {
+	fields := strings.Fields(strings.ToLower(action))
+	return len(fields) > 1 && slices.Contains(tableConstraints, fields[1])
+}
+
+// splitTopLevel делит список по запятым вне скобок и строк
+func splitTopLevel(list string) []string {
+	var (
+		parts   []string
+		depth   int
+		inQuote bool
+		start   int
+	)
+	for i, r := range list {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, list[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, list[start:])
+}
+
+func firstWord(item string) string {
+	fields := strings.Fields(item)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(fields[0], `"`))
+}
+
+// CheckSchema сверяет схему базы с ожидаемой. Лишние объекты в базе не считаются расхождением.
+// Возвращает *SchemaDrift, если чего-то не хватает.
+func CheckSchema(ctx context.Context, db *pgxpool.Pool, expected *Schema) error {
+	actualColumns := make(map[string]map[string]bool)
+	rows, err := db.Query(ctx, `
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read columns: %w", err)
+		}
+		if actualColumns[table] == nil {
+			actualColumns[table] = make(map[string]bool)
+		}
+		actualColumns[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	actualIndexes := make(map[string]bool)
+	rows, err = db.Query(ctx, `SELECT indexname FROM pg_indexes WHERE schemaname = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("failed to read indexes: %w", err)
+	}
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read indexes: %w", err)
+		}
+		actualIndexes[index] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	drift := expected.diff(actualColumns, actualIndexes)
+	if !drift.Empty() {
+		return drift
+	}
+	return nil
+}
+
+func (s *Schema) diff(columns map[string]map[string]bool, indexes map[string]bool) *SchemaDrift {
+	drift := &SchemaDrift{}
+
+	tables := make([]string, 0, len(s.Tables))
+	for table := range s.Tables {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+
+	for _, table := range tables {
+		actual, ok := columns[table]
+		if !ok {
+			drift.MissingTables = append(drift.MissingTables, table)
+			continue
+		}
+		for _, col := range s.Tables[table] {
+			if !actual[col] {
+				drift.MissingColumns = append(drift.MissingColumns, table+"."+col)
+			}
+		}
+	}
+
+	for _, index := range s.Indexes {
+		if !indexes[index] {
+			drift.MissingIndexes = append(drift.MissingIndexes, index)
+		}
+	}
+
+	return drift
+}
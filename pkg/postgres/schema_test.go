@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExpectedSchema(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_init.up.sql": {Data: []byte(`
+			begin;
+			create table "roles"("value" text not null primary key);
+			create table users (
+				id uuid primary key,
+				email varchar(100) unique not null, -- comment, with comma
+				status text not null check (status in ('A', 'B')),
+				unique (email, status)
+			);
+			create index idx_users_email on users(email);
+			/* example; with semicolon -- and dashes
+			create table ghost (id uuid); */
+			create table sessions (id uuid, user_id uuid);
+			CREATE OR REPLACE FUNCTION f() RETURNS TRIGGER AS $$
+			BEGIN
+				UPDATE users SET status = 'A';
+				RETURN NEW;
+			END;
+			$$ LANGUAGE plpgsql;
+			commit;`)},
+		"000002_alter.up.sql": {Data: []byte(`
+			alter table users
+				add column tier text not null default 'BRONZE',
+				add column old text;
+			alter table users rename column email to login;
+			alter table users drop column old;
+			alter table users add constraint users_tier_check check (tier <> '');
+			drop index idx_users_email;
+			drop table roles;`)},
+		"000002_alter.down.sql": {Data: []byte(`drop table users;`)},
+	}
+
+	s, err := ExpectedSchema(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.Tables["roles"]; ok {
+		t.Errorf("dropped table roles is expected")
+	}
+	want := []string{"id", "login", "status", "tier"}
+	if got := s.Tables["users"]; !slices.Equal(got, want) {
+		t.Errorf("users columns = %v, want %v", got, want)
+	}
+	if _, ok := s.Tables["ghost"]; ok {
+		t.Errorf("table from a block comment is expected")
+	}
+	if got := s.Tables["sessions"]; !slices.Equal(got, []string{"id", "user_id"}) {
+		t.Errorf("sessions columns after a block comment = %v, want [id user_id]", got)
+	}
+	if len(s.Indexes) != 0 {
+		t.Errorf("indexes = %v, want none", s.Indexes)
+	}
+}
+
+// Каждая таблица, созданная миграциями репозитория и не удаленная позже, попадает в ожидаемую схему
+func TestExpectedSchema_Migrations(t *testing.T) {
+	fsys := os.DirFS("../../migrations")
+	s, err := ExpectedSchema(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	slices.Sort(files)
+
+	createRX := regexp.MustCompile(`(?im)^\s*create\s+table\s+(?:if\s+not\s+exists\s+)?"?(\w+)"?`)
+	dropRX := regexp.MustCompile(`(?im)^\s*drop\s+table\s+(?:if\s+exists\s+)?"?(\w+)"?`)
+	tables := make(map[string]string)
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range createRX.FindAllStringSubmatch(string(data), -1) {
+			tables[strings.ToLower(m[1])] = file
+		}
+		for _, m := range dropRX.FindAllStringSubmatch(string(data), -1) {
+			delete(tables, strings.ToLower(m[1]))
+		}
+	}
+
+	for table, file := range tables {
+		if len(s.Tables[table]) == 0 {
+			t.Errorf("table %s from %s is missing from the expected schema", table, file)
+		}
+	}
+	// таблицы сразу после блочного комментария в 000002
+	for _, table := range []string{"driver_sessions", "location_history"} {
+		if !slices.Contains(s.Tables[table], "id") {
+			t.Errorf("%s columns = %v, want id", table, s.Tables[table])
+		}
+	}
+}
+
+func TestSchemaDiff(t *testing.T) {
+	s := &Schema{
+		Tables: map[string][]string{
+			"users": {"id", "email"},
+			"rides": {"id"},
+		},
+		Indexes: []string{"idx_users_email"},
+	}
+
+	drift := s.diff(map[string]map[string]bool{"users": {"id": true, "extra": true}}, map[string]bool{})
+
+	if !slices.Equal(drift.MissingTables, []string{"rides"}) {
+		t.Errorf("missing tables = %v", drift.MissingTables)
+	}
+	if !slices.Equal(drift.MissingColumns, []string{"users.email"}) {
+		t.Errorf("missing columns = %v", drift.MissingColumns)
+	}
+	if !slices.Equal(drift.MissingIndexes, []string{"idx_users_email"}) {
+		t.Errorf("missing indexes = %v", drift.MissingIndexes)
+	}
+}
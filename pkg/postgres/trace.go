@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/tracing"
+)
+
+// maxStatementLength — предел текста запроса в атрибуте спана
+const maxStatementLength = 2048
+
+// queryTracer пишет спан на каждый запрос внутри трассы. Запросы фоновых задач без трассы не записываются,
+// иначе опрос outbox и таймеры заполнили бы коллектор трассами из одного запроса.
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if tracing.SpanFromContext(ctx) == nil {
+		return ctx
+	}
+
+	ctx, span := tracing.Start(ctx, "db "+operation(data.SQL), tracing.KindClient)
+	span.SetAttr("db.system", "postgresql")
+	// значения передаются параметрами, в тексте запроса их нет
+	sql := data.SQL
+	if len(sql) > maxStatementLength {
+		sql = sql[:maxStatementLength]
+	}
+	span.SetAttr("db.statement", sql)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := tracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.RecordError(data.Err)
+	span.SetAttr("db.rows_affected", data.CommandTag.RowsAffected())
+	span.End()
+}
+
+// operation возвращает первое слово запроса: SELECT, INSERT, WITH...
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
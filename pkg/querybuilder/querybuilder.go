@@ -0,0 +1,120 @@
+// Package querybuilder собирает WHERE и ORDER BY для динамических фильтров без
+// склейки пользовательских значений в SQL: значения передаются только аргументами,
+// а колонки сортировки берутся из заранее заданного списка.
+//
+// В условиях "?" заменяется на следующий по порядку плейсхолдер $n, поэтому
+// JSON операторы Postgres с "?" в условиях использовать нельзя.
+package querybuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownSortField = errors.New("unknown sort field")
+
+// Builder накапливает условия и аргументы запроса
+type Builder struct {
+	conds []string
+	args  []any
+}
+
+func New() *Builder {
+	return &Builder{}
+}
+
+// Where добавляет условие, объединяемое с остальными через AND
+func (b *Builder) Where(cond string, args ...any) *Builder {
+	b.conds = append(b.conds, "("+b.bind(cond, args)+")")
+	return b
+}
+
+// WhereIf добавляет условие, только если ok
+func (b *Builder) WhereIf(ok bool, cond string, args ...any) *Builder {
+	if ok {
+		b.Where(cond, args...)
+	}
+	return b
+}
+
+// Arg добавляет аргумент и возвращает его плейсхолдер, например для LIMIT
+func (b *Builder) Arg(v any) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// WhereSQL возвращает "WHERE ..." или пустую строку, если условий нет
+func (b *Builder) WhereSQL() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// Args возвращает аргументы в порядке плейсхолдеров
+func (b *Builder) Args() []any {
+	return b.args
+}
+
+func (b *Builder) bind(cond string, args []any) string {
+	var sb strings.Builder
+	i := 0
+	for _, r := range cond {
+		if r == '?' && i < len(args) {
+			sb.WriteString(b.Arg(args[i]))
+			i++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	if i != len(args) {
+		// ошибка программиста: число "?" не совпадает с числом аргументов
+		panic(fmt.Sprintf("querybuilder: %d placeholders for %d args in %q", i, len(args), cond))
+	}
+	return sb.String()
+}
+
+// SortField — поле сортировки, доступное клиенту
+type SortField struct {
+	Name string // имя в API, например "created_at"
+	Expr string // SQL выражение, например "r.created_at"
+	Cast string // тип курсора для keyset пагинации, например "timestamptz"
+}
+
+// Sort — проверенная сортировка с уникальным столбцом-разрешителем для keyset пагинации
+type Sort struct {
+	Field    SortField
+	Desc     bool
+	TieBreak string // SQL выражение уникального столбца, например "r.id"
+}
+
+// ParseSort находит поле по имени, "-" в начале означает сортировку по убыванию
+func ParseSort(value, tieBreak string, fields ...SortField) (Sort, error) {
+	name := strings.TrimPrefix(value, "-")
+	for _, f := range fields {
+		if f.Name == name {
+			return Sort{Field: f, Desc: strings.HasPrefix(value, "-"), TieBreak: tieBreak}, nil
+		}
+	}
+	return Sort{}, fmt.Errorf("%w: %s", ErrUnknownSortField, name)
+}
+
+// OrderBy возвращает выражение для ORDER BY
+func (s Sort) OrderBy() string {
+	dir := "ASC"
+	if s.Desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, %s %s", s.Field.Expr, dir, s.TieBreak, dir)
+}
+
+// After добавляет условие keyset пагинации: строки строго после (value, id) в порядке сортировки
+func (b *Builder) After(s Sort, value string, id any) *Builder {
+	op := ">"
+	if s.Desc {
+		op = "<"
+	}
+	cond := fmt.Sprintf("(%s, %s) %s (?::%s, ?)", s.Field.Expr, s.TieBreak, op, s.Field.Cast)
+	return b.Where(cond, value, id)
+}
@@ -0,0 +1,55 @@
+package querybuilder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	b := New().
+		Where("r.status = ANY(?)", []string{"COMPLETED"}).
+		WhereIf(false, "r.driver_id = ?", "skipped").
+		WhereIf(true, "r.fare BETWEEN ? AND ?", 100, 200)
+	limit := b.Arg(20)
+
+	if got, want := b.WhereSQL(), "WHERE (r.status = ANY($1)) AND (r.fare BETWEEN $2 AND $3)"; got != want {
+		t.Fatalf("WhereSQL() = %q, want %q", got, want)
+	}
+	if limit != "$4" {
+		t.Fatalf("Arg() = %q, want $4", limit)
+	}
+	if want := []any{[]string{"COMPLETED"}, 100, 200, 20}; !reflect.DeepEqual(b.Args(), want) {
+		t.Fatalf("Args() = %v, want %v", b.Args(), want)
+	}
+}
+
+func TestBuilder_Empty(t *testing.T) {
+	if got := New().WhereSQL(); got != "" {
+		t.Fatalf("WhereSQL() = %q, want empty", got)
+	}
+}
+
+func TestSort(t *testing.T) {
+	fields := []SortField{
+		{Name: "created_at", Expr: "r.created_at", Cast: "timestamptz"},
+		{Name: "fare", Expr: "r.fare", Cast: "numeric"},
+	}
+
+	s, err := ParseSort("-fare", "r.id", fields...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.OrderBy(), "r.fare DESC, r.id DESC"; got != want {
+		t.Fatalf("OrderBy() = %q, want %q", got, want)
+	}
+
+	b := New().After(s, "150.00", "id")
+	if got, want := b.WhereSQL(), "WHERE ((r.fare, r.id) < ($1::numeric, $2))"; got != want {
+		t.Fatalf("WhereSQL() = %q, want %q", got, want)
+	}
+
+	if _, err := ParseSort("r.id; DROP TABLE rides", "r.id", fields...); !errors.Is(err, ErrUnknownSortField) {
+		t.Fatalf("ParseSort() error = %v, want ErrUnknownSortField", err)
+	}
+}
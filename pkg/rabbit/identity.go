@@ -0,0 +1,70 @@
+package rabbit
+
+import (
+	"context"
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
+)
+
+// Заголовки подписи сообщения сервисом-отправителем
+const (
+	HeaderService   = "x-service"
+	HeaderKeyID     = "x-service-key"
+	HeaderSignature = "x-service-signature"
+)
+
+// ErrUnsignedMessage — сообщение без подписи при включенном RequireSigned
+var ErrUnsignedMessage = errors.New("rabbit: unsigned message")
+
+// SetIdentity включает подпись исходящих и проверку входящих сообщений.
+// requireSigned — отклонять сообщения без подписи, иначе они принимаются без имени отправителя.
+func (r *RabbitMQ) SetIdentity(id *svcauth.Identity, requireSigned bool) {
+	r.identity = id
+	r.requireSigned = requireSigned
+}
+
+// Sign ставит отправителя и подпись тела сообщения
+func (r *RabbitMQ) Sign(pub *amqp.Publishing) {
+	if r.identity == nil {
+		return
+	}
+
+	pub.AppId = r.identity.Service()
+	kid, signature := r.identity.SignMessage(pub.Body)
+	if signature == "" {
+		return
+	}
+	if pub.Headers == nil {
+		pub.Headers = amqp.Table{}
+	}
+	pub.Headers[HeaderService] = r.identity.Service()
+	pub.Headers[HeaderKeyID] = kid
+	pub.Headers[HeaderSignature] = signature
+}
+
+// Verify проверяет подпись сообщения и добавляет отправителя в контекст логов.
+// Ошибка — сообщение нужно отклонить без повтора: повтор не сделает подпись верной.
+func (r *RabbitMQ) Verify(ctx context.Context, d amqp.Delivery) (context.Context, error) {
+	if !r.identity.Enabled() {
+		return ctx, nil
+	}
+
+	service, _ := d.Headers[HeaderService].(string)
+	kid, _ := d.Headers[HeaderKeyID].(string)
+	signature, _ := d.Headers[HeaderSignature].(string)
+	if signature == "" {
+		if r.requireSigned {
+			return ctx, ErrUnsignedMessage
+		}
+		return ctx, nil
+	}
+
+	if err := r.identity.VerifyMessage(service, kid, d.Body, signature); err != nil {
+		return ctx, err
+	}
+	return wrap.WithCallerService(ctx, service), nil
+}
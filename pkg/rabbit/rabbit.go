@@ -9,6 +9,7 @@ import (
 	"github.com/Temutjin2k/ride-hail-system/internal/domain/types"
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+	"github.com/Temutjin2k/ride-hail-system/pkg/svcauth"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -20,6 +21,10 @@ type RabbitMQ struct {
 	mu        sync.Mutex
 	dsn       string
 
+	// identity подписывает и проверяет сообщения, nil — подписи выключены
+	identity      *svcauth.Identity
+	requireSigned bool
+
 	log logger.Logger
 }
 
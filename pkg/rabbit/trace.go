@@ -0,0 +1,57 @@
+package rabbit
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/tracing"
+)
+
+// headerCarrier передает контекст трассы в заголовках AMQP
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// StartPublish начинает спан публикации и кладет контекст трассы в заголовки сообщения,
+// чтобы потребитель в другом сервисе продолжил ту же трассу
+func StartPublish(ctx context.Context, exchange, key string, pub *amqp.Publishing) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, exchange+" publish", tracing.KindProducer)
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetAttr("messaging.system", "rabbitmq")
+	span.SetAttr("messaging.destination.name", exchange)
+	span.SetAttr("messaging.rabbitmq.destination.routing_key", key)
+	span.SetAttr("messaging.message.id", pub.MessageId)
+
+	if pub.Headers == nil {
+		pub.Headers = amqp.Table{}
+	}
+	tracing.Inject(ctx, headerCarrier(pub.Headers))
+	return ctx, span
+}
+
+// StartConsume продолжает трассу отправителя и начинает спан обработки сообщения
+func StartConsume(ctx context.Context, queue string, d amqp.Delivery) (context.Context, *tracing.Span) {
+	if d.Headers != nil {
+		ctx = tracing.Extract(ctx, headerCarrier(d.Headers))
+	}
+	ctx, span := tracing.Start(ctx, queue+" process", tracing.KindConsumer)
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetAttr("messaging.system", "rabbitmq")
+	span.SetAttr("messaging.destination.name", d.Exchange)
+	span.SetAttr("messaging.rabbitmq.destination.routing_key", d.RoutingKey)
+	span.SetAttr("messaging.message.id", d.MessageId)
+	span.SetAttr("messaging.consumer.queue", queue)
+	return ctx, span
+}
@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+)
+
+// Env читает секрет из переменной окружения: ${env:JWT_SECRET}
+type Env struct{}
+
+func (Env) Get(_ context.Context, key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// File читает секрет из файла: ${file:jwt_secret} — относительно Dir, ${file:/run/secrets/jwt} — абсолютный путь.
+// Подходит для Docker и Kubernetes secrets: смонтированный файл обновляется при ротации.
+type File struct {
+	Dir string
+}
+
+func (f File) Get(_ context.Context, key string) (string, error) {
+	path := key
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(f.Dir, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	// файлы секретов обычно пишутся с переводом строки в конце
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Encrypted расшифровывает значение, записанное прямо в конфиг: ${enc:<key id>:<base64>}.
+// Формат и ключи — как у шифрования PII, значение готовит cmd/sealsecret.
+type Encrypted struct {
+	Keyring *keyring.Keyring
+}
+
+func (e Encrypted) Get(_ context.Context, key string) (string, error) {
+	if !e.Keyring.Enabled() {
+		return "", fmt.Errorf("no decryption keys configured")
+	}
+	return e.Keyring.Decrypt("enc:" + key)
+}
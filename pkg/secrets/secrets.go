@@ -0,0 +1,202 @@
+// Package secrets подставляет секреты в конфиг из внешних хранилищ.
+//
+// Значение поля конфига вида ${provider:key} — ссылка на секрет: ${vault:ride-hail/auth#jwt_secret},
+// ${file:jwt_secret}, ${aws:prod/ride-hail#locationiq}. Ссылка заменяется значением при загрузке конфига,
+// а Watch перечитывает секреты, на смену которых подписаны сервисы, и вызывает их хуки ротации.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+var (
+	ErrNotFound        = errors.New("secrets: secret not found")
+	ErrUnknownProvider = errors.New("secrets: unknown provider")
+)
+
+// Provider читает секрет по ключу, формат ключа свой у каждого хранилища
+type Provider interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// ref — ссылка занимает все значение. Имя провайдера в нижнем регистре,
+// поэтому ${ENV_VAR:-default} из config.yaml ссылкой не считается.
+var ref = regexp.MustCompile(`^\$\{([a-z0-9]+):([^}]+)\}$`)
+
+// IsReference — значение является ссылкой на секрет
+func IsReference(value string) bool {
+	return ref.MatchString(value)
+}
+
+// Resolver подставляет секреты по ссылкам и следит за их ротацией
+type Resolver struct {
+	providers map[string]Provider
+
+	mu       sync.Mutex
+	bindings map[string]*binding // по env тегу поля
+}
+
+type binding struct {
+	ref   string
+	value string
+	hooks []func(value string)
+}
+
+// NewResolver создает резолвер с провайдерами по именам, которые используются в ссылках
+func NewResolver(providers map[string]Provider) *Resolver {
+	return &Resolver{
+		providers: providers,
+		bindings:  make(map[string]*binding),
+	}
+}
+
+// Resolve возвращает значение секрета по ссылке. Значение без ссылки возвращается как есть.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	m := ref.FindStringSubmatch(value)
+	if m == nil {
+		return value, nil
+	}
+
+	p, ok := r.providers[m[1]]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownProvider, m[1])
+	}
+	secret, err := p.Get(ctx, m[2])
+	if err != nil {
+		// сам ключ не секрет, а без него ошибку не найти
+		return "", fmt.Errorf("secrets: %s:%s: %w", m[1], m[2], err)
+	}
+	return secret, nil
+}
+
+// ResolveStruct заменяет ссылки в строковых полях структуры с тегом env, вложенные структуры обходятся.
+// Поля со ссылками запоминаются по env тегу для OnRotate.
+func (r *Resolver) ResolveStruct(ctx context.Context, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("secrets: expected pointer to struct")
+	}
+	return r.resolveStruct(ctx, rv.Elem())
+}
+
+func (r *Resolver) resolveStruct(ctx context.Context, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rv.NumField() {
+		field := rv.Field(i)
+		envTag := rt.Field(i).Tag.Get("env")
+
+		if field.Kind() == reflect.Struct && envTag == "" {
+			if err := r.resolveStruct(ctx, field); err != nil {
+				return err
+			}
+			continue
+		}
+		if envTag == "" || field.Kind() != reflect.String || !field.CanSet() || !IsReference(field.String()) {
+			continue
+		}
+
+		reference := field.String()
+		value, err := r.Resolve(ctx, reference)
+		if err != nil {
+			return fmt.Errorf("%s: %w", envTag, err)
+		}
+		field.SetString(value)
+
+		r.mu.Lock()
+		r.bindings[envTag] = &binding{ref: reference, value: value}
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// OnRotate вызывает fn с новым значением, когда Watch замечает смену секрета поля с env тегом name.
+// false — поле задано не ссылкой, его значение может смениться только с перезапуском. Безопасен для nil.
+func (r *Resolver) OnRotate(name string, fn func(value string)) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.bindings[name]
+	if !ok {
+		return false
+	}
+	b.hooks = append(b.hooks, fn)
+	return true
+}
+
+// Refresh перечитывает секреты с хуками ротации и вызывает хуки сменившихся.
+// Недоступное хранилище не сбрасывает секрет: остается прежнее значение.
+func (r *Resolver) Refresh(ctx context.Context) (rotated []string, err error) {
+	r.mu.Lock()
+	watched := make(map[string]*binding, len(r.bindings))
+	for name, b := range r.bindings {
+		if len(b.hooks) > 0 {
+			watched[name] = b
+		}
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for name, b := range watched {
+		value, err := r.Resolve(ctx, b.ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		r.mu.Lock()
+		changed := value != "" && value != b.value
+		if changed {
+			b.value = value
+		}
+		hooks := append([]func(string){}, b.hooks...)
+		r.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+		for _, fn := range hooks {
+			fn(value)
+		}
+		rotated = append(rotated, name)
+	}
+	return rotated, errors.Join(errs...)
+}
+
+// Watch вызывает Refresh каждые interval до отмены ctx. Значения секретов не логируются.
+func (r *Resolver) Watch(ctx context.Context, interval time.Duration, l logger.Logger) {
+	if r == nil || interval <= 0 {
+		return
+	}
+	ctx = wrap.WithAction(ctx, "refresh_secrets")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		rotated, err := r.Refresh(ctx)
+		if err != nil {
+			l.Warn(ctx, "failed to refresh secrets", "error", err.Error())
+		}
+		for _, name := range rotated {
+			l.Info(ctx, "secret rotated", "name", name)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/keyring"
+)
+
+type testConfig struct {
+	Auth struct {
+		JWTSecret string `env:"AUTH_JWT_SECRET"`
+		Default   string `env:"AUTH_DEFAULT"`
+	}
+	APIKey   string `env:"API_KEY"`
+	Sealed   string `env:"SEALED"`
+	Port     int    `env:"PORT"`
+	Untagged string
+}
+
+func TestResolveStruct(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "jwt"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_API_KEY", "from-env")
+
+	ring, err := keyring.New("k1", "k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := ring.Encrypt("from-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg testConfig
+	cfg.Auth.JWTSecret = "${file:jwt}"
+	cfg.Auth.Default = "${AUTH_DEFAULT:-plain}"
+	cfg.APIKey = "${env:TEST_API_KEY}"
+	cfg.Sealed = "${" + sealed + "}"
+	cfg.Untagged = "${env:TEST_API_KEY}"
+
+	r := NewResolver(map[string]Provider{"env": Env{}, "file": File{Dir: dir}, "enc": Encrypted{Keyring: ring}})
+	if err := r.ResolveStruct(context.Background(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Auth.JWTSecret != "from-file" || cfg.APIKey != "from-env" || cfg.Sealed != "from-config" {
+		t.Fatalf("secrets not resolved: %+v", cfg)
+	}
+	if cfg.Auth.Default != "${AUTH_DEFAULT:-plain}" || cfg.Untagged != "${env:TEST_API_KEY}" {
+		t.Fatalf("values that are not secret references must stay as is: %+v", cfg)
+	}
+}
+
+func TestResolveStruct_Errors(t *testing.T) {
+	r := NewResolver(map[string]Provider{"env": Env{}})
+
+	var cfg testConfig
+	cfg.APIKey = "${vault:ride-hail/geo#key}"
+	if err := r.ResolveStruct(context.Background(), &cfg); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("got %v, want ErrUnknownProvider", err)
+	}
+
+	cfg.APIKey = "${env:TEST_MISSING_SECRET}"
+	if err := r.ResolveStruct(context.Background(), &cfg); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestRefresh_CallsRotationHooks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jwt")
+	if err := os.WriteFile(path, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg testConfig
+	cfg.Auth.JWTSecret = "${file:jwt}"
+	r := NewResolver(map[string]Provider{"file": File{Dir: dir}})
+	if err := r.ResolveStruct(context.Background(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	if !r.OnRotate("AUTH_JWT_SECRET", func(v string) { got = append(got, v) }) {
+		t.Fatal("hook must be registered for a referenced field")
+	}
+	if r.OnRotate("API_KEY", func(string) {}) {
+		t.Fatal("plain fields cannot rotate")
+	}
+
+	if rotated, err := r.Refresh(context.Background()); err != nil || len(rotated) != 0 {
+		t.Fatalf("unchanged secret: rotated %v, err %v", rotated, err)
+	}
+
+	if err := os.WriteFile(path, []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// хранилище недоступно — прежнее значение остается
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Refresh(context.Background()); err == nil {
+		t.Fatal("missing secret must be reported")
+	}
+
+	if len(got) != 1 || got[0] != "v2" {
+		t.Fatalf("hooks got %v, want [v2]", got)
+	}
+}
+
+func TestVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/ride-hail/auth" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"jwt_secret":"s3cret"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	v := NewVault(srv.URL, "root", "secret", time.Second)
+	got, err := v.Get(context.Background(), "ride-hail/auth#jwt_secret")
+	if err != nil || got != "s3cret" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	if _, err := v.Get(context.Background(), "ride-hail/auth#missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing field: got %v, want ErrNotFound", err)
+	}
+	if _, err := v.Get(context.Background(), "ride-hail/other#jwt_secret"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing path: got %v, want ErrNotFound", err)
+	}
+	if _, err := NewVault(srv.URL, "wrong", "secret", time.Second).Get(context.Background(), "ride-hail/auth#jwt_secret"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("forbidden must be an error other than not found, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault читает секреты из HashiCorp Vault KV v2: ${vault:<path>#<field>}, например ${vault:ride-hail/auth#jwt_secret}
+type Vault struct {
+	addr  string
+	token string
+	mount string
+	http  *http.Client
+}
+
+// NewVault создает клиент Vault. mount — путь движка KV v2, обычно "secret".
+func NewVault(addr, token, mount string, timeout time.Duration) *Vault {
+	return &Vault{
+		addr:  strings.TrimRight(addr, "/"),
+		token: token,
+		mount: strings.Trim(mount, "/"),
+		http:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (v *Vault) Get(ctx context.Context, key string) (string, error) {
+	path, field, ok := strings.Cut(key, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault key must be <path>#<field>")
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", v.addr, v.mount, strings.Trim(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("vault responded %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var payload struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+
+	value, ok := payload.Data.Data[field].(string)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
@@ -0,0 +1,268 @@
+// Package svcauth подтверждает личность сервиса во внутренних вызовах и сообщениях очередей.
+//
+// У каждого сервиса своя пара ключей Ed25519. Закрытые ключи сервиса задаются списком
+// "id:base64 seed" через запятую, подписывает активный. Открытые ключи всех сервисов задаются
+// списком "service/id:base64 key": ключ принадлежит одному сервису, поэтому сервис не может
+// подписаться чужим именем — для этого нужен закрытый ключ другого сервиса.
+//
+// HTTP: вызывающий отправляет JWT (EdDSA, kid — id ключа, sub — имя сервиса, aud — имя
+// вызываемого сервиса), выпущенный на один запрос: htm и htu привязывают его к методу и пути,
+// jti — к одному использованию. Перехваченный токен не подходит к другому запросу или сервису,
+// а повтор того же запроса отклоняется до истечения токена.
+//
+// Очереди: сообщение подписывается Ed25519 от "<service>\n<body>". У сообщения нет
+// срока годности — оно может ждать в очереди или в dead letter дольше любого токена,
+// а подпись привязана к телу и не переносится на другое сообщение.
+package svcauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken     = errors.New("svcauth: invalid service token")
+	ErrReplayedToken    = errors.New("svcauth: service token already used")
+	ErrInvalidSignature = errors.New("svcauth: invalid message signature")
+	ErrUnknownKey       = errors.New("svcauth: unknown key id")
+	ErrNoActiveKey      = errors.New("svcauth: active key is not in the private key list")
+	ErrInvalidKey       = errors.New("svcauth: invalid key")
+	ErrKeysMismatch     = errors.New("svcauth: private and public keys must be set together")
+)
+
+// claims — токен сервиса, привязанный к одному запросу
+type claims struct {
+	jwt.RegisteredClaims
+	Method string `json:"htm"`
+	Path   string `json:"htu"`
+}
+
+// Identity подписывает вызовы от имени сервиса и проверяет вызовы других сервисов.
+// Identity без ключей выключена: Enabled() == false, подписи не ставятся и не проверяются.
+type Identity struct {
+	service string
+	active  string
+	private map[string]ed25519.PrivateKey
+	public  map[string]ed25519.PublicKey // по "service/id": ключ проверяет только подписи своего сервиса
+	ttl     time.Duration
+
+	// использованные jti до истечения их токенов
+	mu      sync.Mutex
+	used    map[string]time.Time
+	sweepAt time.Time
+}
+
+// New создает Identity сервиса service. privateKeys — закрытые ключи сервиса "id:base64 seed",
+// active — id ключа для подписи, publicKeys — открытые ключи всех сервисов "service/id:base64 key",
+// ttl — срок жизни токена.
+func New(service, active, privateKeys, publicKeys string, ttl time.Duration) (*Identity, error) {
+	id := &Identity{
+		service: service,
+		active:  active,
+		private: make(map[string]ed25519.PrivateKey),
+		public:  make(map[string]ed25519.PublicKey),
+		ttl:     ttl,
+		used:    make(map[string]time.Time),
+	}
+
+	for kid, raw := range entries(privateKeys) {
+		seed, err := decodeKey(kid, raw, ed25519.SeedSize)
+		if err != nil {
+			return nil, err
+		}
+		id.private[kid] = ed25519.NewKeyFromSeed(seed)
+	}
+
+	for owner, raw := range entries(publicKeys) {
+		if service, kid, ok := strings.Cut(owner, "/"); !ok || service == "" || kid == "" {
+			return nil, fmt.Errorf("%w: public key entry must be service/id:key", ErrInvalidKey)
+		}
+		key, err := decodeKey(owner, raw, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		id.public[owner] = key
+	}
+
+	if (len(id.private) == 0) != (len(id.public) == 0) {
+		return nil, ErrKeysMismatch
+	}
+	if len(id.private) > 0 {
+		if _, ok := id.private[active]; !ok {
+			return nil, ErrNoActiveKey
+		}
+	}
+	return id, nil
+}
+
+// entries разбирает список "id:value" через запятую
+func entries(list string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, value, _ := strings.Cut(entry, ":")
+		out[kid] = value
+	}
+	return out
+}
+
+func decodeKey(kid, raw string, size int) ([]byte, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("%w: key entry must be id:key", ErrInvalidKey)
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("%w: key %s must be %d bytes in base64", ErrInvalidKey, kid, size)
+	}
+	return key, nil
+}
+
+// GenerateKey создает пару ключей и возвращает закрытый (base64 seed) и открытый (base64) ключи
+func GenerateKey() (private, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.Seed()), base64.StdEncoding.EncodeToString(pub), nil
+}
+
+// Service — имя сервиса, от которого подписываются вызовы
+func (i *Identity) Service() string {
+	return i.service
+}
+
+// Enabled — заданы ли ключи
+func (i *Identity) Enabled() bool {
+	return i != nil && len(i.private) > 0
+}
+
+// Token выпускает токен на один запрос method path к сервису audience
+func (i *Identity) Token(audience, method, path string) (string, error) {
+	if !i.Enabled() {
+		return "", nil
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("svcauth: token id: %w", err)
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(jti),
+			Issuer:    i.service,
+			Subject:   i.service,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.ttl)),
+		},
+		Method: method,
+		Path:   path,
+	})
+	token.Header["kid"] = i.active
+
+	signed, err := token.SignedString(i.private[i.active])
+	if err != nil {
+		return "", fmt.Errorf("svcauth: sign token: %w", err)
+	}
+	return signed, nil
+}
+
+// VerifyToken проверяет токен запроса method path к этому сервису и возвращает имя вызывающего сервиса.
+// Токен принимается один раз: повтор отклоняется с ErrReplayedToken.
+func (i *Identity) VerifyToken(raw, method, path string) (string, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(raw, &c, func(t *jwt.Token) (any, error) {
+		// ключ ищется по заявленному сервису: чужим именем подписаться нельзя без его закрытого ключа
+		kid, _ := t.Header["kid"].(string)
+		pub, ok := i.public[t.Claims.(*claims).Subject+"/"+kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return pub, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithAudience(i.service),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	switch {
+	case c.Method != method || c.Path != path:
+		return "", fmt.Errorf("%w: issued for %s %s", ErrInvalidToken, c.Method, c.Path)
+	case c.ID == "":
+		return "", fmt.Errorf("%w: empty token id", ErrInvalidToken)
+	}
+
+	if !i.use(c.ID, c.ExpiresAt.Time) {
+		return "", ErrReplayedToken
+	}
+	return c.Subject, nil
+}
+
+// use отмечает jti использованным, false — он уже был. Раз в срок жизни токена истекшие jti
+// удаляются: их токены и так не пройдут проверку срока.
+func (i *Identity) use(jti string, expiresAt time.Time) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if now := time.Now(); !now.Before(i.sweepAt) {
+		for id, exp := range i.used {
+			if now.After(exp) {
+				delete(i.used, id)
+			}
+		}
+		i.sweepAt = now.Add(i.ttl)
+	}
+
+	if _, ok := i.used[jti]; ok {
+		return false
+	}
+	i.used[jti] = expiresAt
+	return true
+}
+
+// SignMessage подписывает тело сообщения активным ключом, возвращает id ключа и hex подпись
+func (i *Identity) SignMessage(body []byte) (kid, signature string) {
+	if !i.Enabled() {
+		return "", ""
+	}
+	sig := ed25519.Sign(i.private[i.active], payload(i.service, body))
+	return i.active, hex.EncodeToString(sig)
+}
+
+// VerifyMessage проверяет подпись сообщения, отправленного сервисом service
+func (i *Identity) VerifyMessage(service, kid string, body []byte, signature string) error {
+	pub, ok := i.public[service+"/"+kid]
+	if !ok {
+		return ErrUnknownKey
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil || !ed25519.Verify(pub, payload(service, body), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func payload(service string, body []byte) []byte {
+	out := make([]byte, 0, len(service)+1+len(body))
+	out = append(out, service...)
+	out = append(out, '\n')
+	return append(out, body...)
+}
@@ -0,0 +1,177 @@
+package svcauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// keyPair — ключи одного сервиса в формате конфига
+type keyPair struct{ private, public string }
+
+func newKeyPair(t *testing.T) keyPair {
+	t.Helper()
+	private, public, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyPair{private, public}
+}
+
+func mustNew(t *testing.T, service, active, privateKeys, publicKeys string) *Identity {
+	t.Helper()
+	id, err := New(service, active, privateKeys, publicKeys, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestToken_RoundTrip(t *testing.T) {
+	driver, location := newKeyPair(t), newKeyPair(t)
+	trusted := "driver-service/v1:" + driver.public + ",location-service/v1:" + location.public
+	caller := mustNew(t, "driver-service", "v1", "v1:"+driver.private, trusted)
+	callee := mustNew(t, "location-service", "v1", "v1:"+location.private, trusted)
+
+	token, err := caller.Token("location-service", "POST", "/internal/locations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := callee.VerifyToken(token, "POST", "/internal/locations")
+	if err != nil {
+		t.Fatalf("token must verify: %v", err)
+	}
+	if service != "driver-service" {
+		t.Fatalf("unexpected caller: %q", service)
+	}
+}
+
+// Токен принимается один раз и только для запроса и сервиса, на которые выпущен
+func TestToken_BoundToRequest(t *testing.T) {
+	driver, location := newKeyPair(t), newKeyPair(t)
+	trusted := "driver-service/v1:" + driver.public + ",location-service/v1:" + location.public
+	caller := mustNew(t, "driver-service", "v1", "v1:"+driver.private, trusted)
+	callee := mustNew(t, "location-service", "v1", "v1:"+location.private, trusted)
+	other := mustNew(t, "ride-service", "v1", "v1:"+newKeyPair(t).private, trusted)
+
+	token, err := caller.Token("location-service", "PUT", "/internal/locations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callee.VerifyToken(token, "POST", "/internal/locations"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("token for another request must not verify, got %v", err)
+	}
+	if _, err := other.VerifyToken(token, "PUT", "/internal/locations"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("token for another service must not verify, got %v", err)
+	}
+	if _, err := callee.VerifyToken(token, "PUT", "/internal/locations"); err != nil {
+		t.Fatalf("token must verify: %v", err)
+	}
+	if _, err := callee.VerifyToken(token, "PUT", "/internal/locations"); !errors.Is(err, ErrReplayedToken) {
+		t.Fatalf("replayed token must not verify, got %v", err)
+	}
+}
+
+// Сервис не может подписать токен или сообщение именем другого сервиса
+func TestImpersonation(t *testing.T) {
+	driver, ride, location := newKeyPair(t), newKeyPair(t), newKeyPair(t)
+	trusted := "driver-service/v1:" + driver.public + ",ride-service/v1:" + ride.public + ",location-service/v1:" + location.public
+	// ride-service знает только свой закрытый ключ, но называет себя driver-service
+	impostor := mustNew(t, "driver-service", "v1", "v1:"+ride.private, trusted)
+	callee := mustNew(t, "location-service", "v1", "v1:"+location.private, trusted)
+
+	token, err := impostor.Token("location-service", "POST", "/internal/locations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callee.VerifyToken(token, "POST", "/internal/locations"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("token signed with another service key must not verify, got %v", err)
+	}
+
+	body := []byte(`{"ride_id":"1"}`)
+	kid, sig := impostor.SignMessage(body)
+	if err := callee.VerifyMessage("driver-service", kid, body, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("message signed with another service key must not verify, got %v", err)
+	}
+}
+
+func TestToken_Rotation(t *testing.T) {
+	v1, v2, location := newKeyPair(t), newKeyPair(t), newKeyPair(t)
+	old := mustNew(t, "driver-service", "v1", "v1:"+v1.private, "driver-service/v1:"+v1.public)
+	rotated := mustNew(t, "location-service", "v1", "v1:"+location.private,
+		"driver-service/v1:"+v1.public+",driver-service/v2:"+v2.public)
+	retired := mustNew(t, "location-service", "v1", "v1:"+location.private, "driver-service/v2:"+v2.public)
+
+	token, err := old.Token("location-service", "PUT", "/internal/locations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.VerifyToken(token, "PUT", "/internal/locations"); err != nil {
+		t.Fatalf("token signed by previous key must verify during rotation: %v", err)
+	}
+	if _, err := retired.VerifyToken(token, "PUT", "/internal/locations"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("token signed by removed key must not verify, got %v", err)
+	}
+}
+
+func TestToken_Expired(t *testing.T) {
+	keys := newKeyPair(t)
+	caller, err := New("driver-service", "v1", "v1:"+keys.private, "driver-service/v1:"+keys.public, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := caller.Token("driver-service", "GET", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := caller.VerifyToken(token, "GET", "/"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expired token must not verify, got %v", err)
+	}
+}
+
+func TestMessage_Tampered(t *testing.T) {
+	keys := newKeyPair(t)
+	id := mustNew(t, "ride-service", "v1", "v1:"+keys.private, "ride-service/v1:"+keys.public)
+	body := []byte(`{"ride_id":"1"}`)
+
+	kid, sig := id.SignMessage(body)
+	if err := id.VerifyMessage("ride-service", kid, body, sig); err != nil {
+		t.Fatalf("signature must verify: %v", err)
+	}
+	if err := id.VerifyMessage("ride-service", kid, []byte(`{"ride_id":"2"}`), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("changed body must not verify, got %v", err)
+	}
+	if err := id.VerifyMessage("admin-service", kid, body, sig); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("other sender must not verify, got %v", err)
+	}
+	if err := id.VerifyMessage("ride-service", "v9", body, sig); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("unknown key must not verify, got %v", err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	keys := newKeyPair(t)
+	public := "ride-service/v1:" + keys.public
+
+	if _, err := New("ride-service", "v1", "v1:c2hvcnQ=", public, time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("short key must be rejected, got %v", err)
+	}
+	if _, err := New("ride-service", "v1", "v1:"+keys.private, "v1:"+keys.public, time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("public key without service must be rejected, got %v", err)
+	}
+	if _, err := New("ride-service", "v2", "v1:"+keys.private, public, time.Minute); !errors.Is(err, ErrNoActiveKey) {
+		t.Fatalf("missing active key must be rejected, got %v", err)
+	}
+	if _, err := New("ride-service", "v1", "v1:"+keys.private, "", time.Minute); !errors.Is(err, ErrKeysMismatch) {
+		t.Fatalf("private key without public keys must be rejected, got %v", err)
+	}
+
+	id, err := New("ride-service", "", "", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Enabled() {
+		t.Fatalf("identity without keys must be disabled")
+	}
+}
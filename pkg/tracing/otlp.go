@@ -0,0 +1,257 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	defaultTimeout       = 10 * time.Second
+	// queueBatches — сколько пачек ждут выгрузки, дальше спаны отбрасываются, а не тормозят запросы
+	queueBatches = 4
+
+	scopeName = "github.com/Temutjin2k/ride-hail-system/pkg/tracing"
+)
+
+// exporter копит завершенные спаны и отправляет их пачками в коллектор по OTLP/HTTP (JSON)
+type exporter struct {
+	endpoint string
+	service  string
+	batch    int
+	interval time.Duration
+	client   *http.Client
+	l        logger.Logger
+
+	spans   chan otlpSpan
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+func newExporter(cfg Config, l logger.Logger) *exporter {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	e := &exporter{
+		endpoint: cfg.Endpoint,
+		service:  cfg.ServiceName,
+		batch:    cfg.BatchSize,
+		interval: cfg.FlushInterval,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		l:        l,
+		spans:    make(chan otlpSpan, cfg.BatchSize*queueBatches),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *exporter) enqueue(s *Span, end time.Time) {
+	span := s.otlp(end)
+	select {
+	case e.spans <- span:
+	default:
+		// коллектор не успевает: трассы неполные, но сервис не ждет
+		e.dropped.Add(1)
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, e.batch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		e.export(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.spans:
+			if batch = append(batch, span); len(batch) >= e.batch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown выгружает оставшиеся спаны, ждет не дольше ctx
+func (e *exporter) shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("tracing: flush spans: %w", ctx.Err())
+	}
+}
+
+func (e *exporter) export(spans []otlpSpan) {
+	ctx := wrap.WithAction(context.Background(), "export_traces")
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{attr("service.name", e.service)}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: scopeName},
+			Spans: spans,
+		}},
+	}}})
+	if err != nil {
+		e.l.Error(ctx, "failed to encode spans", err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		e.l.Error(ctx, "failed to build OTLP request", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.l.Warn(ctx, "failed to export spans", "spans", len(spans), "error", err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		e.l.Warn(ctx, "collector rejected spans", "spans", len(spans), "status", resp.StatusCode)
+	}
+	if dropped := e.dropped.Swap(0); dropped > 0 {
+		e.l.Warn(ctx, "spans dropped, export queue was full", "dropped", dropped)
+	}
+}
+
+// Формат OTLP/JSON: ID в hex, время и целые атрибуты — строкой
+type (
+	otlpRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              Kind       `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            *status    `json:"status,omitempty"`
+	}
+	status struct {
+		Code    int    `json:"code"` // 2 — ошибка
+		Message string `json:"message,omitempty"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+const statusError = 2
+
+func (s *Span) otlp(end time.Time) otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := otlpSpan{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+	}
+	if s.parent != [8]byte{} {
+		out.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	for k, v := range s.attrs {
+		out.Attributes = append(out.Attributes, attr(k, v))
+	}
+	if s.errMsg != "" {
+		out.Status = &status{Code: statusError, Message: s.errMsg}
+	}
+	return out
+}
+
+func attr(key string, value any) keyValue {
+	var v anyValue
+	switch x := value.(type) {
+	case string:
+		v.StringValue = &x
+	case bool:
+		v.BoolValue = &x
+	case int:
+		i := strconv.Itoa(x)
+		v.IntValue = &i
+	case int64:
+		i := strconv.FormatInt(x, 10)
+		v.IntValue = &i
+	case float64:
+		v.DoubleValue = &x
+	default:
+		str := fmt.Sprint(x)
+		v.StringValue = &str
+	}
+	return keyValue{Key: key, Value: v}
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// HeaderTraceparent — заголовок W3C Trace Context: 00-<trace id>-<span id>-<flags>
+const HeaderTraceparent = "traceparent"
+
+// Carrier — заголовки, через которые контекст трассы передается между сервисами
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// HeaderCarrier передает контекст трассы в заголовках HTTP
+type HeaderCarrier http.Header
+
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Inject записывает текущий спан в заголовки, чтобы получатель продолжил трассу
+func Inject(ctx context.Context, c Carrier) {
+	sc := FromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	c.Set(HeaderTraceparent, formatTraceparent(sc))
+}
+
+// Extract читает контекст трассы из заголовков. Спаны, начатые от результата, продолжают трассу отправителя.
+// Некорректный traceparent игнорируется, трасса начнется заново.
+func Extract(ctx context.Context, c Carrier) context.Context {
+	sc, ok := parseTraceparent(c.Get(HeaderTraceparent))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func formatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+func parseTraceparent(v string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	// версии новее 00 могут добавлять поля в конец
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, sc.IsValid()
+}
+
+// Transport оборачивает HTTP транспорт: каждый запрос получает клиентский спан и traceparent.
+// nil — http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := Start(r.Context(), r.Method, KindClient)
+	defer span.End()
+	if span == nil {
+		return t.base.RoundTrip(r)
+	}
+
+	span.SetAttr("http.request.method", r.Method)
+	span.SetAttr("server.address", r.URL.Host)
+	span.SetAttr("url.path", r.URL.Path)
+
+	// RoundTripper не должен менять запрос вызывающего
+	r = r.Clone(ctx)
+	Inject(ctx, HeaderCarrier(r.Header))
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttr("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.RecordError(errors.New(resp.Status))
+	}
+	return resp, nil
+}
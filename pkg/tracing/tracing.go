@@ -0,0 +1,247 @@
+// Package tracing — распределенная трассировка, совместимая с OpenTelemetry.
+// Контекст трассы передается между сервисами в заголовке traceparent (W3C Trace Context),
+// спаны выгружаются в коллектор по OTLP/HTTP в JSON. Пока Init не вызван, Start ничего не записывает.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+// Kind — роль спана в вызове, значения совпадают с SpanKind OTLP
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2 // обработка входящего HTTP запроса
+	KindClient   Kind = 3 // исходящий HTTP запрос, запрос к базе
+	KindProducer Kind = 4 // публикация сообщения в брокер
+	KindConsumer Kind = 5 // обработка сообщения из брокера
+)
+
+// Config — куда и как выгружать спаны
+type Config struct {
+	ServiceName   string
+	Endpoint      string  // OTLP/HTTP, например http://otel-collector:4318/v1/traces
+	SampleRatio   float64 // доля новых трасс, продолжение чужой трассы следует решению вызывающего
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration // на одну выгрузку
+}
+
+// SpanContext — идентификаторы спана, которые передаются между сервисами
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid — у контекста есть трасса и спан
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceIDString возвращает ID трассы в hex, как его показывают коллекторы
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// Span — одна операция трассы. Методы безопасны для nil: при выключенной трассировке Start возвращает nil.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent [8]byte
+	kind   Kind
+	start  time.Time
+
+	mu     sync.Mutex
+	name   string
+	attrs  map[string]any
+	errMsg string
+	ended  bool
+}
+
+// SetName меняет имя спана, например на шаблон маршрута, известный после разбора запроса
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+// SetAttr добавляет атрибут. Поддерживаются string, bool, целые и float64, остальное пишется строкой.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError отмечает спан ошибочным, nil игнорируется
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// Context возвращает идентификаторы спана
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// End завершает спан и ставит его в очередь выгрузки, повторный вызов ничего не делает
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.mu.Unlock()
+
+	if s.sc.Sampled {
+		s.tracer.exporter.enqueue(s, end)
+	}
+}
+
+// Tracer создает спаны сервиса и выгружает их
+type Tracer struct {
+	service  string
+	ratio    float64
+	exporter *exporter
+}
+
+var global atomic.Pointer[Tracer]
+
+// Init включает трассировку для процесса. Вызывается один раз при старте сервиса.
+func Init(cfg Config, l logger.Logger) (*Tracer, error) {
+	if cfg.ServiceName == "" || cfg.Endpoint == "" {
+		return nil, errors.New("tracing: service name and endpoint are required")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing: sample ratio %v must be between 0 and 1", cfg.SampleRatio)
+	}
+
+	t := &Tracer{
+		service:  cfg.ServiceName,
+		ratio:    cfg.SampleRatio,
+		exporter: newExporter(cfg, l),
+	}
+	global.Store(t)
+	return t, nil
+}
+
+// Shutdown выключает трассировку и выгружает накопленные спаны
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	global.CompareAndSwap(t, nil)
+	return t.exporter.shutdown(ctx)
+}
+
+type (
+	spanKey   struct{}
+	remoteKey struct{}
+)
+
+// Start начинает спан, дочерний к спану из ctx или к контексту, пришедшему из другого сервиса.
+// ID трассы добавляется в контекст логов.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	t := global.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := FromContext(ctx)
+	sc := SpanContext{SpanID: randomID8()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = randomID16()
+		sc.Sampled = sampled(sc.TraceID, t.ratio)
+	}
+
+	span := &Span{
+		tracer: t,
+		sc:     sc,
+		parent: parent.SpanID,
+		kind:   kind,
+		start:  time.Now(),
+		name:   name,
+	}
+
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	if traceID := sc.TraceIDString(); wrap.GetLogCtx(ctx).TraceID != traceID {
+		ctx = wrap.WithTraceID(ctx, traceID)
+	}
+	return ctx, span
+}
+
+// SpanFromContext возвращает текущий спан процесса, nil — спана нет
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// FromContext возвращает идентификаторы текущего спана или контекста, пришедшего из другого сервиса
+func FromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// sampled решает по ID трассы, записывать ли новую трассу: решение одинаково для всех сервисов
+func sampled(traceID [16]byte, ratio float64) bool {
+	switch {
+	case ratio >= 1:
+		return true
+	case ratio <= 0:
+		return false
+	}
+	var x uint64
+	for _, b := range traceID[8:] {
+		x = x<<8 | uint64(b)
+	}
+	return float64(x>>1) < ratio*float64(math.MaxInt64)
+}
+
+func randomID16() (id [16]byte) {
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func randomID8() (id [8]byte) {
+	for id == [8]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
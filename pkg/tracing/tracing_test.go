@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
+)
+
+func TestTraceparent_RoundTrip(t *testing.T) {
+	sc := SpanContext{TraceID: randomID16(), SpanID: randomID8(), Sampled: true}
+
+	got, ok := parseTraceparent(formatTraceparent(sc))
+	if !ok || got != sc {
+		t.Fatalf("got %+v, want %+v", got, sc)
+	}
+
+	sc.Sampled = false
+	if got, _ := parseTraceparent(formatTraceparent(sc)); got.Sampled {
+		t.Fatalf("not sampled flag must survive round trip")
+	}
+}
+
+func TestTraceparent_Invalid(t *testing.T) {
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := parseTraceparent(v); ok {
+			t.Errorf("%q must be rejected", v)
+		}
+	}
+
+	// будущие версии могут добавлять поля
+	if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Errorf("newer version with extra fields must be accepted")
+	}
+}
+
+func TestStart_Disabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "op", KindInternal)
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Fatalf("no span expected before Init")
+	}
+	// методы nil спана ничего не делают
+	span.SetAttr("k", "v")
+	span.End()
+}
+
+func TestExport(t *testing.T) {
+	received := make(chan otlpRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		received <- req
+	}))
+	defer srv.Close()
+
+	tracer, err := Init(Config{
+		ServiceName:   "ride-service",
+		Endpoint:      srv.URL,
+		SampleRatio:   1,
+		FlushInterval: time.Hour,
+	}, logger.InitLogger("tracing-test", logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// контекст пришел из другого сервиса
+	remote := SpanContext{TraceID: randomID16(), SpanID: randomID8(), Sampled: true}
+	header := http.Header{}
+	header.Set(HeaderTraceparent, formatTraceparent(remote))
+	ctx := Extract(context.Background(), HeaderCarrier(header))
+
+	ctx, parent := Start(ctx, "POST /rides", KindServer)
+	if got := wrap.GetLogCtx(ctx).TraceID; got != remote.TraceIDString() {
+		t.Fatalf("log trace id = %q, want %q", got, remote.TraceIDString())
+	}
+	_, child := Start(ctx, "db INSERT", KindClient)
+	child.SetAttr("db.system", "postgresql")
+	child.End()
+	parent.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-received
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for _, s := range spans {
+		if s.TraceID != remote.TraceIDString() {
+			t.Errorf("span %q trace id = %s, want %s", s.Name, s.TraceID, remote.TraceIDString())
+		}
+	}
+	if spans[0].ParentSpanID != hexID(parent.Context().SpanID) || spans[1].ParentSpanID != hexID(remote.SpanID) {
+		t.Errorf("wrong parents: %q -> %s, %q -> %s", spans[0].Name, spans[0].ParentSpanID, spans[1].Name, spans[1].ParentSpanID)
+	}
+
+	if _, span := Start(context.Background(), "after shutdown", KindInternal); span != nil {
+		t.Fatalf("no span expected after Shutdown")
+	}
+}
+
+func hexID(id [8]byte) string {
+	return hex.EncodeToString(id[:])
+}
@@ -2,6 +2,7 @@ package trm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
@@ -11,6 +12,7 @@ import (
 type TxManager interface {
 	Do(ctx context.Context, fn func(ctx context.Context) error) error
 	DoReadOnly(ctx context.Context, fn func(ctx context.Context) error) error
+	DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // Manager implements a transaction manager using pgx
@@ -35,6 +37,12 @@ var (
 	txOptions = ctxTxOptions{}
 )
 
+// InTx reports whether ctx carries a transaction started by Do.
+func InTx(ctx context.Context) bool {
+	tx, ok := ctx.Value(TxKey).(pgx.Tx)
+	return ok && tx != nil
+}
+
 // Do executes the provided function within a transaction context.
 // It starts a new transaction if one does not already exist in the context.
 // If the function returns an error, the transaction is rolled back.
@@ -55,7 +63,7 @@ func (m *Manager) Do(ctx context.Context, fn func(ctx context.Context) error) (e
 		}
 		if err != nil {
 			if rbErr := tx.Rollback(ctx); rbErr != nil {
-				err = fmt.Errorf("rollback failed: %v (original error: %w)", rbErr, err)
+				err = fmt.Errorf("%w: %v (original error: %w)", errRollbackFailed, rbErr, err)
 			}
 			return
 		}
@@ -127,6 +135,30 @@ func (m *Manager) DoReadOnly(ctx context.Context, fn func(ctx context.Context) e
 	return m.Do(ctx, fn)
 }
 
+var (
+	// errRollbackOnly forces Do to roll back a transaction that completed successfully.
+	errRollbackOnly = errors.New("rollback-only transaction")
+	// errRollbackFailed marks errors where the rollback itself failed.
+	errRollbackFailed = errors.New("rollback failed")
+)
+
+// DoRollbackOnly executes the provided function within a transaction context that is
+// always rolled back. All statements and constraint checks run as usual, but nothing is committed.
+// Used for dry runs: the caller sees the would-be effects without persisting them.
+// Nested inside another transaction it rolls back only its own savepoint.
+func (m *Manager) DoRollbackOnly(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := m.Do(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return errRollbackOnly
+	})
+	if errors.Is(err, errRollbackOnly) && !errors.Is(err, errRollbackFailed) {
+		return nil
+	}
+	return err
+}
+
 type AccessMode uint8
 
 const (
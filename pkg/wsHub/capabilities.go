@@ -0,0 +1,92 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Capabilities — возможности клиента, объявленные в сообщении auth.
+// Старые версии приложения их не передают и получают сообщения в прежнем виде.
+type Capabilities struct {
+	DeltaUpdates bool   // частые обновления приходят только с изменившимися полями
+	Chat         bool   // клиент показывает сообщения чата
+	Locale       string // язык текстов в сообщениях, пусто — английский
+}
+
+// Adaptive — сообщение, вид которого зависит от возможностей клиента.
+// ForClient вызывается перед записью в сокет каждого соединения, nil — этому клиенту сообщение не отправляется.
+type Adaptive interface {
+	ForClient(caps Capabilities) any
+}
+
+// Delta — частое обновление одного потока, например местоположение водителя в поездке.
+// Клиенту с DeltaUpdates первое сообщение потока в соединении уходит целиком, следующие — с "delta": true
+// и только изменившимися полями верхнего уровня. Поля DeltaFixed отправляются всегда.
+type Delta interface {
+	DeltaKey() string
+	DeltaFixed() []string
+}
+
+// SetCapabilities задает возможности клиента, вызывается до добавления соединения в хаб
+func (c *Conn) SetCapabilities(caps Capabilities) {
+	c.caps = caps
+}
+
+// Capabilities возвращает возможности клиента соединения
+func (c *Conn) Capabilities() Capabilities {
+	return c.caps
+}
+
+// adapt готовит сообщение к записи под возможности клиента, nil — сообщение клиенту не отправляется
+func (c *Conn) adapt(msg any) (any, error) {
+	if a, ok := msg.(Adaptive); ok {
+		if msg = a.ForClient(c.caps); msg == nil {
+			return nil, nil
+		}
+	}
+	if d, ok := msg.(Delta); ok && c.caps.DeltaUpdates {
+		return c.delta(d)
+	}
+	return msg, nil
+}
+
+// delta оставляет поля, изменившиеся с прошлого сообщения потока в этом соединении.
+// Состояние потоков живет вместе с соединением: после переподключения первое сообщение снова полное.
+func (c *Conn) delta(d Delta) (any, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// не объект — сравнивать нечего
+		return json.RawMessage(data), nil
+	}
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+
+	if c.deltas == nil {
+		c.deltas = make(map[string]map[string]json.RawMessage)
+	}
+	key := d.DeltaKey()
+	prev, ok := c.deltas[key]
+	c.deltas[key] = fields
+	if !ok {
+		return json.RawMessage(data), nil
+	}
+
+	changed := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		if !bytes.Equal(prev[name], value) {
+			changed[name] = value
+		}
+	}
+	for _, name := range d.DeltaFixed() {
+		if value, ok := fields[name]; ok {
+			changed[name] = value
+		}
+	}
+	changed["delta"] = json.RawMessage("true")
+	return changed, nil
+}
@@ -2,6 +2,7 @@ package ws
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -16,12 +17,31 @@ import (
 )
 
 // Conn представляет собой одно соединение WebSocket, связанное с сущностью (например, драйвером)
+// и устройством, с которого она подключилась
 type Conn struct {
 	conn        *websocket.Conn
 	entityID    uuid.UUID
+	deviceID    string
+	connectedAt time.Time
 	lastPong    time.Time
 	subscribers map[string]chan map[string]any
 
+	protocol   Protocol
+	resumeFrom uint64   // v2: последний seq, полученный клиентом до переподключения
+	session    *session // v2: нумерация и неподтвержденные сообщения, выдается хабом в Add
+	// v2: клиент продолжает сессию, которой нет на этом экземпляре
+	resumeMissed bool
+
+	caps   Capabilities
+	dmu    sync.Mutex
+	deltas map[string]map[string]json.RawMessage // последнее отправленное сообщение каждого потока Delta
+
+	// очередь отправки, ее разбирает writePump; nil — соединение вне хаба пишет напрямую
+	send        chan outgoing
+	undelivered func(msg any) // получает сообщения v1, оставшиеся в очереди закрытого соединения
+	qmu         sync.Mutex
+	closed      bool
+
 	once   sync.Once
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -29,14 +49,23 @@ type Conn struct {
 	l      logger.Logger
 }
 
+// NewConn создает соединение без идентификатора устройства: новое такое соединение сущности заменяет прежнее
 func NewConn(entityID uuid.UUID, conn *websocket.Conn, l logger.Logger) *Conn {
+	return NewDeviceConn(entityID, DefaultDevice, conn, l)
+}
+
+// NewDeviceConn создает соединение устройства. Соединения разных устройств одной сущности работают одновременно.
+func NewDeviceConn(entityID uuid.UUID, deviceID string, conn *websocket.Conn, l logger.Logger) *Conn {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	c := &Conn{
 		conn:        conn,
 		entityID:    entityID,
+		deviceID:    deviceID,
+		connectedAt: time.Now(),
 		lastPong:    time.Now(),
 		subscribers: make(map[string]chan map[string]any),
+		protocol:    ProtocolV1,
 
 		ctx:    ctx,
 		cancel: cancel,
@@ -54,6 +83,29 @@ func NewConn(entityID uuid.UUID, conn *websocket.Conn, l logger.Logger) *Conn {
 	return c
 }
 
+// DeviceID возвращает устройство соединения, пусто — клиент не передал device_id
+func (c *Conn) DeviceID() string {
+	return c.deviceID
+}
+
+// SetProtocol задает согласованную версию протокола, вызывается до добавления соединения в хаб.
+// resumeFrom — последний seq, полученный клиентом по v2, 0 — клиент начинает без истории.
+func (c *Conn) SetProtocol(p Protocol, resumeFrom uint64) {
+	c.protocol = p
+	c.resumeFrom = resumeFrom
+}
+
+// ResumeMissed — клиент v2 передал resume_from, но сессии его устройства на этом экземпляре нет,
+// и неподтвержденные сообщения потеряны. Известно после добавления соединения в хаб.
+func (c *Conn) ResumeMissed() bool {
+	return c.resumeMissed
+}
+
+// Protocol возвращает версию протокола соединения
+func (c *Conn) Protocol() Protocol {
+	return c.protocol
+}
+
 // Subscribe добавляет новый канал подписки
 func (c *Conn) Subscribe(name string, ch chan map[string]any) {
 	c.mu.Lock()
@@ -118,12 +170,13 @@ func (c *Conn) sendPing() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	deadline := time.Now().Add(5 * time.Second)
-	if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
-		// На случай специфики транспорта — fallback
-		return c.conn.WriteMessage(websocket.PingMessage, nil)
+	if c.conn == nil {
+		return ErrConnClosed
 	}
-	return nil
+
+	// WriteControl можно вызывать параллельно с writePump, обычная запись — нет
+	deadline := time.Now().Add(5 * time.Second)
+	return c.conn.WriteControl(websocket.PingMessage, nil, deadline)
 }
 
 // Listen читает сообщения и рассылает подписчикам
@@ -151,6 +204,13 @@ mainLoop:
 				continue
 			}
 
+			if c.protocol == ProtocolV2 {
+				var ok bool
+				if msg, ok = c.unwrap(msg); !ok {
+					continue
+				}
+			}
+
 			c.mu.Lock()
 			c.lastPong = time.Now()
 			subs := make(map[string]chan map[string]any, len(c.subscribers))
@@ -183,16 +243,91 @@ func (c *Conn) isIdle(timeout time.Duration) bool {
 	return time.Since(c.lastPong) > timeout
 }
 
+// unwrap обрабатывает входящее сообщение v2. ack подтверждает доставку и не передается подписчикам,
+// поля data конверта поднимаются на верхний уровень, чтобы подписчики не зависели от версии.
+func (c *Conn) unwrap(msg map[string]any) (map[string]any, bool) {
+	msgType, _ := msg["type"].(string)
+	if msgType == "ack" {
+		if seq, ok := msg["seq"].(float64); ok && seq > 0 && c.session != nil {
+			c.session.ack(uint64(seq))
+		}
+		return nil, false
+	}
+
+	data, ok := msg["data"].(map[string]any)
+	if !ok {
+		return msg, true
+	}
+	flat := make(map[string]any, len(data)+1)
+	maps.Copy(flat, data)
+	flat["type"] = msgType
+	return flat, true
+}
+
+// Send ставит сообщение в очередь отправки соединения и не ждет записи в сокет.
+// Переполненная очередь означает медленного клиента: сообщение не принимается, соединение закрывается,
+// и клиент получит отложенные хабом сообщения при переподключении.
 func (c *Conn) Send(msg any) error {
+	if c.send == nil {
+		return c.writeNow(msg)
+	}
+	return c.enqueue(outgoing{msg: msg})
+}
+
+// resend ставит в очередь неподтвержденный конверт v2 с прежним seq
+func (c *Conn) resend(env Envelope) error {
+	if c.send == nil {
+		return c.writeEnvelope(c.conn, env)
+	}
+	return c.enqueue(outgoing{env: &env})
+}
+
+// writeNow пишет сообщение в сокет сразу, для соединений без очереди
+func (c *Conn) writeNow(msg any) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return ErrConnClosed
+	}
+
+	msg, err := c.adapt(msg)
+	if err != nil || msg == nil {
+		return err
+	}
+
+	if c.protocol != ProtocolV2 || c.session == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return conn.WriteJSON(msg)
+	}
+
+	env, err := c.session.wrap(msg)
+	if err != nil {
+		return err
+	}
+	return c.writeEnvelope(conn, env)
+}
+
+// writeEnvelope отправляет готовый конверт v2 без перенумерации
+func (c *Conn) writeEnvelope(conn *websocket.Conn, env Envelope) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	return c.conn.WriteJSON(msg)
+	if conn == nil {
+		return ErrConnClosed
+	}
+	return conn.WriteJSON(env)
 }
 
 func (c *Conn) Close() error {
 	var err error
 	c.once.Do(func() {
+		// после этого очередь не пополняется, writePump разбирает остаток
+		c.qmu.Lock()
+		c.closed = true
+		c.qmu.Unlock()
+
 		c.mu.Lock()
 		defer c.mu.Unlock()
 
@@ -3,28 +3,46 @@ package ws
 import (
 	"context"
 	"errors"
-	"maps"
 	"sync"
+	"time"
 
 	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
 	wrap "github.com/Temutjin2k/ride-hail-system/pkg/logger/wrapper"
 	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
 )
 
+// DefaultDevice — устройство клиента, не передавшего device_id
+const DefaultDevice = ""
+
 var (
 	ErrEmptyConn       = errors.New("connection is empty")
 	ErrConnIsNotFound  = errors.New("connection not found")
 	maxPendingMessages = 64
+	// maxOfflineDevices — для скольких отключившихся устройств одной сущности хранится буфер
+	maxOfflineDevices = 5
 )
 
 type pendingMsg struct {
 	Data any
 }
 
-// ConnectionHub хранит и управляет всеми активными WebSocket соединениями
+// deviceBuffer — непосланные сообщения устройства, отключившегося в since
+type deviceBuffer struct {
+	msgs  []pendingMsg
+	since time.Time
+}
+
+// ConnectionHub хранит и управляет всеми активными WebSocket соединениями.
+// У сущности может быть по одному соединению на каждое устройство, сообщения рассылаются во все.
 type ConnectionHub struct {
-	clients map[uuid.UUID]*Conn
-	pending map[uuid.UUID][]pendingMsg // буфер непросланных сообщений
+	clients  map[uuid.UUID]map[string]*Conn         // сущность → устройство → соединение
+	pending  map[uuid.UUID]map[string]*deviceBuffer // буфер непросланных сообщений по устройствам
+	sessions map[uuid.UUID]map[string]*session      // сессии протокола v2 по устройствам
+
+	maxProtocol Protocol
+	sendBuffer  int
+	closed      bool // после Close хаб не принимает трафик, readiness проваливается
+	onConnect   func(id uuid.UUID)
 
 	l  logger.Logger
 	mu sync.Mutex
@@ -33,14 +51,58 @@ type ConnectionHub struct {
 
 func NewConnHub(l logger.Logger) *ConnectionHub {
 	return &ConnectionHub{
-		clients: make(map[uuid.UUID]*Conn),
-		pending: make(map[uuid.UUID][]pendingMsg),
-		l:       l,
+		clients:  make(map[uuid.UUID]map[string]*Conn),
+		pending:  make(map[uuid.UUID]map[string]*deviceBuffer),
+		sessions: make(map[uuid.UUID]map[string]*session),
+
+		maxProtocol: LatestProtocol,
+		sendBuffer:  DefaultSendBuffer,
+		l:           l,
+	}
+}
+
+// WithMaxProtocol ограничивает версию протокола, которую получают клиенты.
+// Используется при поэтапном включении новой версии: клиенты, запросившие больше, получают p.
+func (h *ConnectionHub) WithMaxProtocol(p Protocol) *ConnectionHub {
+	if p >= ProtocolV1 && p <= LatestProtocol {
+		h.maxProtocol = p
+	}
+	return h
+}
+
+// WithSendBuffer задает размер очереди отправки каждого соединения.
+// Клиент, отставший больше чем на size сообщений, отключается как медленный.
+func (h *ConnectionHub) WithSendBuffer(size int) *ConnectionHub {
+	if size > 0 {
+		h.sendBuffer = size
+	}
+	return h
+}
+
+// WithOnConnect задает функцию, которая вызывается в отдельной горутине при каждом подключении клиента.
+// Через нее досылаются сообщения, хранящиеся вне хаба.
+func (h *ConnectionHub) WithOnConnect(fn func(id uuid.UUID)) *ConnectionHub {
+	h.onConnect = fn
+	return h
+}
+
+// Negotiate выбирает версию протокола для запрошенной клиентом в auth.
+// 0 — клиент версию не передал и получает v1, больше поддерживаемой — наибольшую доступную.
+func (h *ConnectionHub) Negotiate(requested int) (Protocol, error) {
+	switch {
+	case requested < 0:
+		return 0, ErrUnsupportedProtocol
+	case requested == 0:
+		return ProtocolV1, nil
+	case Protocol(requested) > h.maxProtocol:
+		return h.maxProtocol, nil
+	default:
+		return Protocol(requested), nil
 	}
 }
 
 // Add добавляет новое соединение в хаб.
-// Если соединение с этим entityID уже существует — оно закрывается.
+// Если у сущности уже есть соединение с этого устройства — оно закрывается.
 func (h *ConnectionHub) Add(newConn *Conn) error {
 	if newConn == nil {
 		return ErrEmptyConn
@@ -51,10 +113,17 @@ func (h *ConnectionHub) Add(newConn *Conn) error {
 
 	ctx := wrap.WithAction(context.Background(), "add_ws_connection")
 
-	if existing, ok := h.clients[newConn.entityID]; ok {
+	devices, ok := h.clients[newConn.entityID]
+	if !ok {
+		devices = make(map[string]*Conn)
+		h.clients[newConn.entityID] = devices
+	}
+
+	if existing, ok := devices[newConn.deviceID]; ok {
 		h.l.Warn(ctx,
 			"replacing existing connection",
 			"entity_ID", existing.entityID,
+			"device_ID", existing.deviceID,
 		)
 		if err := existing.Close(); err != nil {
 			h.l.Warn(ctx,
@@ -63,55 +132,142 @@ func (h *ConnectionHub) Add(newConn *Conn) error {
 				"err", err.Error(),
 			)
 		}
+		h.wg.Done()
 	}
 
-	h.clients[newConn.entityID] = newConn
+	devices[newConn.deviceID] = newConn
 	h.wg.Add(1)
 
-	go h.OnReconnect(newConn.entityID)
+	h.attachSessionLocked(newConn)
+
+	newConn.start(h.sendBuffer, func(msg any) {
+		h.redeliver(newConn, msg)
+	})
+
+	go h.OnReconnect(newConn)
+	if h.onConnect != nil {
+		go h.onConnect(newConn.entityID)
+	}
 
 	return nil
 }
 
 // OnReconnect вызывается при новом подключении клиента.
-// Отправляет все отложенные (pending) сообщения, если они есть.
-func (h *ConnectionHub) OnReconnect(id uuid.UUID) {
+// Отправляет отложенные сообщения устройства и сообщения, отложенные до подключения любого устройства.
+func (h *ConnectionHub) OnReconnect(conn *Conn) {
+	id := conn.entityID
+
 	h.mu.Lock()
-	pending, ok := h.pending[id]
-	conn, connOK := h.clients[id]
+	var pending []pendingMsg
+	if buffers, ok := h.pending[id]; ok {
+		if buf, ok := buffers[conn.deviceID]; ok {
+			pending = append(pending, buf.msgs...)
+			delete(buffers, conn.deviceID)
+		}
+		if buf, ok := buffers[DefaultDevice]; ok && conn.deviceID != DefaultDevice {
+			pending = append(pending, buf.msgs...)
+			delete(buffers, DefaultDevice)
+		}
+		if len(buffers) == 0 {
+			delete(h.pending, id)
+		}
+	}
 	h.mu.Unlock()
 
-	if !ok || !connOK || len(pending) == 0 {
+	// v2: сначала неподтвержденные сообщения прошлых соединений устройства с их прежними seq
+	var unacked []Envelope
+	if conn.session != nil {
+		unacked = conn.session.resume(conn.resumeFrom)
+	}
+
+	if len(pending) == 0 && len(unacked) == 0 {
 		return // нечего восстанавливать
 	}
 
 	ctx := wrap.WithAction(context.Background(), "ws_on_reconnect")
-	h.l.Info(ctx, "resending pending messages", "entity_ID", id, "count", len(pending))
+	h.l.Info(ctx, "resending pending messages", "entity_ID", id, "device_ID", conn.deviceID,
+		"count", len(pending), "unacked", len(unacked), "protocol", conn.protocol.String())
+
+	for _, env := range unacked {
+		if err := conn.resend(env); err != nil {
+			// сообщения остаются в сессии до следующего подключения устройства
+			h.l.Warn(ctx, "failed to resend unacked message", "entity_ID", id, "device_ID", conn.deviceID, "err", err.Error())
+			for _, rest := range pending {
+				h.BufferDevice(id, conn.deviceID, rest.Data)
+			}
+			return
+		}
+	}
 
 	// последовательно отсылаем буфер
-	for _, msg := range pending {
+	for i, msg := range pending {
 		if err := conn.Send(msg.Data); err != nil {
-			h.l.Warn(ctx, "failed to resend pending message", "entity_ID", id, "err", err.Error())
-			break // прерываем, если соединение снова умерло
+			h.l.Warn(ctx, "failed to resend pending message", "entity_ID", id, "device_ID", conn.deviceID, "err", err.Error())
+			// соединение снова умерло — остаток ждет следующего подключения устройства
+			for _, rest := range pending[i:] {
+				h.BufferDevice(id, conn.deviceID, rest.Data)
+			}
+			return
 		}
 	}
 
-	// если дошли до сюда — очистим буфер
+	h.l.Info(ctx, "pending messages delivered and cleared", "entity_ID", id, "device_ID", conn.deviceID)
+}
+
+// redeliver возвращает сообщение, не отправленное закрытым соединением: в новое соединение
+// того же устройства, если оно уже подключилось, иначе в буфер устройства
+func (h *ConnectionHub) redeliver(conn *Conn, msg any) {
+	h.mu.Lock()
+	current, ok := h.clients[conn.entityID][conn.deviceID]
+	h.mu.Unlock()
+
+	if ok && current != conn && current.Send(msg) == nil {
+		return
+	}
+	h.BufferDevice(conn.entityID, conn.deviceID, msg)
+}
+
+// Remove удаляет и закрывает соединение, если оно еще зарегистрировано в хабе.
+// Соединение, уже замененное новым с того же устройства, только закрывается.
+func (h *ConnectionHub) Remove(conn *Conn) error {
+	if conn == nil {
+		return ErrEmptyConn
+	}
+
 	h.mu.Lock()
-	delete(h.pending, id)
+	current, ok := h.clients[conn.entityID][conn.deviceID]
+	if ok && current == conn {
+		h.removeLocked(conn)
+	}
 	h.mu.Unlock()
 
-	h.l.Info(ctx, "pending messages delivered and cleared", "entity_ID", id)
+	if err := conn.Close(); err != nil {
+		h.l.Warn(wrap.WithAction(context.Background(), "ws_connection_remove"),
+			"failed to close conn",
+			"entity_ID", conn.entityID,
+			"err", err.Error(),
+		)
+	}
+
+	if !ok || current != conn {
+		return ErrConnIsNotFound
+	}
+	return nil
 }
 
-// Delete удаляет и закрывает соединение по ID
+// Delete удаляет и закрывает все соединения сущности
 func (h *ConnectionHub) Delete(entityID uuid.UUID) error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	devices, ok := h.clients[entityID]
+	conns := make([]*Conn, 0, len(devices))
+	for _, conn := range devices {
+		conns = append(conns, conn)
+		h.removeLocked(conn)
+	}
+	h.mu.Unlock()
 
 	ctx := wrap.WithAction(context.Background(), "ws_connection_delete")
 
-	conn, ok := h.clients[entityID]
 	if !ok {
 		h.l.Warn(ctx,
 			"delete called for unknown entity",
@@ -120,58 +276,200 @@ func (h *ConnectionHub) Delete(entityID uuid.UUID) error {
 		return ErrConnIsNotFound
 	}
 
-	if err := conn.Close(); err != nil {
-		h.l.Warn(ctx,
-			"failed to close conn",
-			"entity_ID", conn.entityID,
-			"err", err.Error(),
-		)
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil {
+			h.l.Warn(ctx,
+				"failed to close conn",
+				"entity_ID", conn.entityID,
+				"device_ID", conn.deviceID,
+				"err", err.Error(),
+			)
+		}
 	}
 
-	delete(h.clients, entityID)
+	return nil
+}
+
+// removeLocked убирает соединение из хаба и заводит буфер для отключившегося устройства
+func (h *ConnectionHub) removeLocked(conn *Conn) {
+	devices := h.clients[conn.entityID]
+	delete(devices, conn.deviceID)
+	if len(devices) == 0 {
+		delete(h.clients, conn.entityID)
+	}
 	h.wg.Done()
 
-	return nil
+	h.bufferLocked(conn.entityID, conn.deviceID)
+}
+
+// bufferLocked возвращает буфер устройства, создавая его. Буферы самых давно отключившихся
+// устройств удаляются сверх maxOfflineDevices.
+func (h *ConnectionHub) bufferLocked(id uuid.UUID, deviceID string) *deviceBuffer {
+	buffers, ok := h.pending[id]
+	if !ok {
+		buffers = make(map[string]*deviceBuffer)
+		h.pending[id] = buffers
+	}
+
+	buf, ok := buffers[deviceID]
+	if ok {
+		return buf
+	}
+
+	buf = &deviceBuffer{since: time.Now()}
+	buffers[deviceID] = buf
+
+	for len(buffers) > maxOfflineDevices {
+		oldest := ""
+		var oldestSince time.Time
+		for device, b := range buffers {
+			if b != buf && (oldestSince.IsZero() || b.since.Before(oldestSince)) {
+				oldest, oldestSince = device, b.since
+			}
+		}
+		delete(buffers, oldest)
+		h.dropSessionLocked(id, oldest)
+	}
+
+	return buf
+}
+
+// sessionLocked возвращает сессию v2 устройства, создавая ее
+// attachSessionLocked выдает соединению v2 сессию его устройства. Сессии живут в памяти
+// экземпляра, поэтому балансировщик направляет соединения сущности на один экземпляр.
+// Клиент, продолжающий сессию, которой здесь нет (ее держал другой экземпляр или этот
+// перезапустился), отмечается ResumeMissed: неподтвержденные сообщения ему не досылаются.
+func (h *ConnectionHub) attachSessionLocked(c *Conn) {
+	if c.protocol != ProtocolV2 {
+		// устройство вернулось на v1 — досылать по seq больше некому
+		h.dropSessionLocked(c.entityID, c.deviceID)
+		return
+	}
+
+	_, known := h.sessions[c.entityID][c.deviceID]
+	c.session = h.sessionLocked(c.entityID, c.deviceID)
+	c.resumeMissed = !known && c.resumeFrom > 0
+}
+
+func (h *ConnectionHub) sessionLocked(id uuid.UUID, deviceID string) *session {
+	sessions, ok := h.sessions[id]
+	if !ok {
+		sessions = make(map[string]*session)
+		h.sessions[id] = sessions
+	}
+
+	s, ok := sessions[deviceID]
+	if !ok {
+		s = &session{}
+		sessions[deviceID] = s
+	}
+	return s
+}
+
+func (h *ConnectionHub) dropSessionLocked(id uuid.UUID, deviceID string) {
+	sessions, ok := h.sessions[id]
+	if !ok {
+		return
+	}
+	delete(sessions, deviceID)
+	if len(sessions) == 0 {
+		delete(h.sessions, id)
+	}
+}
+
+func (buf *deviceBuffer) add(msg any) {
+	if len(buf.msgs) >= maxPendingMessages {
+		// удаляем самое старое
+		buf.msgs = buf.msgs[1:]
+	}
+	buf.msgs = append(buf.msgs, pendingMsg{Data: msg})
 }
 
+// cachePending откладывает сообщение для всех отключившихся устройств сущности,
+// а если ни одно не известно — до подключения любого устройства
 func (h *ConnectionHub) cachePending(id uuid.UUID, msg any) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if h.pending == nil {
-		h.pending = make(map[uuid.UUID][]pendingMsg)
+	buffers := h.pending[id]
+	if len(buffers) == 0 {
+		h.bufferLocked(id, DefaultDevice).add(msg)
+		return
 	}
 
-	pending := h.pending[id]
-	if len(pending) >= maxPendingMessages {
-		// удаляем самое старое
-		pending = pending[1:]
+	for device, buf := range buffers {
+		// у подключенного устройства буфера нет, кроме как после неудачной отправки в него
+		if _, online := h.clients[id][device]; online {
+			continue
+		}
+		buf.add(msg)
 	}
+}
 
-	// добавляем новое сообщение
-	pending = append(pending, pendingMsg{Data: msg})
-	h.pending[id] = pending
+// Buffer откладывает сообщение до следующего подключения клиента, не пытаясь отправить его сейчас
+func (h *ConnectionHub) Buffer(id uuid.UUID, msg any) {
+	h.cachePending(id, msg)
 }
 
-// SendTo отправляет сообщение определённому клиенту по ID
-// возвращает ошибку ErrConnIsNotFound, если соединение не найдена
-func (h *ConnectionHub) SendTo(id uuid.UUID, msg any) error {
+// BufferDevice откладывает сообщение до переподключения конкретного устройства
+func (h *ConnectionHub) BufferDevice(id uuid.UUID, deviceID string, msg any) {
 	h.mu.Lock()
-	conn, ok := h.clients[id]
-	h.mu.Unlock()
+	defer h.mu.Unlock()
 
-	if !ok {
-		// нет соединения — кешируем сообщение
-		h.cachePending(id, msg)
+	h.bufferLocked(id, deviceID).add(msg)
+}
+
+// SendTo отправляет сообщение во все соединения клиента по ID и откладывает его для отключившихся устройств.
+// Возвращает ErrConnIsNotFound, если соединений нет, и ошибку, если не удалась ни одна отправка.
+func (h *ConnectionHub) SendTo(id uuid.UUID, msg any) error {
+	conns := h.Conns(id)
+
+	// отключившиеся устройства получат сообщение при переподключении
+	h.cachePending(id, msg)
+
+	if len(conns) == 0 {
+		return ErrConnIsNotFound
+	}
+
+	var lastErr error
+	delivered := 0
+	for _, conn := range conns {
+		if err := conn.Send(msg); err != nil {
+			// соединение могло отвалиться в момент отправки
+			h.BufferDevice(id, conn.deviceID, msg)
+			lastErr = err
+			continue
+		}
+		delivered++
+	}
+
+	if delivered == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// SendOnline отправляет сообщение только в открытые соединения клиента, ничего не откладывая.
+// Возвращает ErrConnIsNotFound, если соединений нет, и ошибку, если не удалась ни одна отправка.
+func (h *ConnectionHub) SendOnline(id uuid.UUID, msg any) error {
+	conns := h.Conns(id)
+	if len(conns) == 0 {
 		return ErrConnIsNotFound
 	}
 
-	if err := conn.Send(msg); err != nil {
-		// соединение могло отвалиться в момент отправки
-		h.cachePending(id, msg)
-		return err
+	var lastErr error
+	delivered := 0
+	for _, conn := range conns {
+		if err := conn.Send(msg); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered++
 	}
 
+	if delivered == 0 {
+		return lastErr
+	}
 	return nil
 }
 
@@ -179,41 +477,103 @@ func (h *ConnectionHub) SendTo(id uuid.UUID, msg any) error {
 func (h *ConnectionHub) Close() {
 	ctx := wrap.WithAction(context.Background(), "hub_close")
 
-	// копируем клиентов под локом
 	h.mu.Lock()
-	clients := make([]*Conn, 0, len(h.clients))
-	for _, conn := range h.clients {
-		clients = append(clients, conn)
-	}
+	h.closed = true
 	h.mu.Unlock()
-	// закрываем вне локов
-	for _, conn := range clients {
-		_ = h.Delete(conn.entityID)
-	}
 
+	h.Drain()
 	h.wg.Wait()
 
 	h.l.Info(ctx, "all websocket connections closed gracefully")
 }
 
-// Clients возвращает копию списка клиентов
+// Drain закрывает все текущие соединения, не останавливая хаб: клиенты
+// переподключаются, возможно к другому экземпляру. Возвращает число закрытых соединений.
+func (h *ConnectionHub) Drain() int {
+	h.mu.Lock()
+	conns := make([]*Conn, 0, len(h.clients))
+	for _, devices := range h.clients {
+		for _, conn := range devices {
+			conns = append(conns, conn)
+		}
+	}
+	h.mu.Unlock()
+
+	closed := 0
+	for _, conn := range conns {
+		if h.Remove(conn) == nil {
+			closed++
+		}
+	}
+	return closed
+}
+
+// Status возвращает число открытых соединений, open — хаб еще не закрыт
+func (h *ConnectionHub) Status() (connections int, open bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, devices := range h.clients {
+		connections += len(devices)
+	}
+	return connections, !h.closed
+}
+
+// Clients возвращает копию списка клиентов с последним подключившимся соединением каждого
 func (h *ConnectionHub) Clients() map[uuid.UUID]*Conn {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	copyMap := make(map[uuid.UUID]*Conn, len(h.clients))
-	maps.Copy(copyMap, h.clients)
+	for id, devices := range h.clients {
+		copyMap[id] = latest(devices)
+	}
 	return copyMap
 }
 
-// GetConn возвращает нужное соединение по UUID
+// Online возвращает ID сущностей, у которых есть хотя бы одно открытое соединение
+func (h *ConnectionHub) Online() []uuid.UUID {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids := make([]uuid.UUID, 0, len(h.clients))
+	for id := range h.clients {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// GetConn возвращает последнее подключившееся соединение по UUID
 func (h *ConnectionHub) GetConn(id uuid.UUID) (*Conn, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	conn, ok := h.clients[id]
+	devices, ok := h.clients[id]
 	if !ok {
 		return nil, ErrConnIsNotFound
 	}
-	return conn, nil
+	return latest(devices), nil
+}
+
+// Conns возвращает все соединения сущности
+func (h *ConnectionHub) Conns(id uuid.UUID) []*Conn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	devices := h.clients[id]
+	conns := make([]*Conn, 0, len(devices))
+	for _, conn := range devices {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+func latest(devices map[string]*Conn) *Conn {
+	var last *Conn
+	for _, conn := range devices {
+		if last == nil || conn.connectedAt.After(last.connectedAt) {
+			last = conn
+		}
+	}
+	return last
 }
@@ -0,0 +1,204 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/logger"
+	"github.com/Temutjin2k/ride-hail-system/pkg/uuid"
+)
+
+func TestConnectionHub_BufferPerDevice(t *testing.T) {
+	h := NewConnHub(logger.InitLogger("test", "error"))
+	id := uuid.New()
+
+	phone := &Conn{entityID: id, deviceID: "phone"}
+	tablet := &Conn{entityID: id, deviceID: "tablet"}
+
+	h.mu.Lock()
+	h.clients[id] = map[string]*Conn{"phone": phone, "tablet": tablet}
+	h.wg.Add(2)
+	// телефон отключился, планшет остался онлайн
+	h.removeLocked(phone)
+	h.mu.Unlock()
+
+	h.Buffer(id, "ride_matched")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if got := len(h.pending[id]["phone"].msgs); got != 1 {
+		t.Fatalf("expected message buffered for offline phone, got %d", got)
+	}
+	if _, ok := h.pending[id]["tablet"]; ok {
+		t.Fatal("online tablet must not get a buffer")
+	}
+	if latest(h.clients[id]) != tablet {
+		t.Fatal("expected tablet to remain connected")
+	}
+}
+
+func TestConnectionHub_OfflineDevicesLimit(t *testing.T) {
+	h := NewConnHub(logger.InitLogger("test", "error"))
+	id := uuid.New()
+
+	for _, device := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		h.BufferDevice(id, device, device)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if got := len(h.pending[id]); got != maxOfflineDevices {
+		t.Fatalf("expected %d device buffers, got %d", maxOfflineDevices, got)
+	}
+	if _, ok := h.pending[id]["g"]; !ok {
+		t.Fatal("most recent device buffer must be kept")
+	}
+}
+
+func TestSession_AckResume(t *testing.T) {
+	s := &session{}
+	for _, msg := range []string{"a", "b", "c"} {
+		if _, err := s.wrap(map[string]string{"type": msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ack подтверждает все сообщения до seq включительно
+	s.ack(1)
+	resent := s.resume(0)
+	if len(resent) != 2 || resent[0].Seq != 2 || resent[0].Type != "b" {
+		t.Fatalf("expected b and c to be resent, got %+v", resent)
+	}
+
+	// resume_from подтверждает полученное клиентом до переподключения
+	if resent := s.resume(3); len(resent) != 0 {
+		t.Fatalf("expected nothing to resend, got %+v", resent)
+	}
+
+	// клиент видел больше номеров, чем выдал экземпляр — seq не идет назад
+	s.resume(10)
+	env, err := s.wrap(map[string]string{"type": "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Seq != 11 {
+		t.Fatalf("expected seq 11 after resume_from 10, got %d", env.Seq)
+	}
+}
+
+// Продолжение сессии, которой на экземпляре нет, отмечается: ее держал другой экземпляр
+func TestConnectionHub_ResumeMissed(t *testing.T) {
+	h := NewConnHub(logger.InitLogger("test", "error"))
+	id := uuid.New()
+
+	first := &Conn{entityID: id, deviceID: "phone", protocol: ProtocolV2}
+	h.attachSessionLocked(first)
+	if first.ResumeMissed() {
+		t.Fatal("new session without resume_from is not a miss")
+	}
+
+	again := &Conn{entityID: id, deviceID: "phone", protocol: ProtocolV2, resumeFrom: 5}
+	h.attachSessionLocked(again)
+	if again.ResumeMissed() || again.session != first.session {
+		t.Fatal("reconnect to the same instance must continue its session")
+	}
+
+	moved := &Conn{entityID: id, deviceID: "tablet", protocol: ProtocolV2, resumeFrom: 7}
+	h.attachSessionLocked(moved)
+	if !moved.ResumeMissed() {
+		t.Fatal("resume of a session this instance never held must be reported")
+	}
+}
+
+func TestConn_SlowConsumer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &Conn{
+		entityID: uuid.New(),
+		send:     make(chan outgoing, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		l:        logger.InitLogger("test", "error"),
+	}
+
+	var returned []any
+	conn.undelivered = func(msg any) { returned = append(returned, msg) }
+
+	if err := conn.Send("first"); err != nil {
+		t.Fatalf("first message must be queued, got %v", err)
+	}
+	// writePump не запущен — клиент не читает, очередь переполнена
+	if err := conn.Send("second"); !errors.Is(err, ErrSlowConsumer) {
+		t.Fatalf("expected ErrSlowConsumer, got %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("slow consumer connection must be closed")
+	}
+	if err := conn.Send("third"); !errors.Is(err, ErrConnClosed) {
+		t.Fatalf("expected ErrConnClosed after close, got %v", err)
+	}
+
+	// неотправленное из очереди возвращается хабу
+	conn.drain()
+	if len(returned) != 1 || returned[0] != "first" {
+		t.Fatalf("expected queued message to be returned, got %v", returned)
+	}
+}
+
+type testDelta struct {
+	Type   string  `json:"type"`
+	RideID string  `json:"ride_id"`
+	Lat    float64 `json:"lat"`
+	ETA    int     `json:"eta"`
+}
+
+func (d testDelta) DeltaKey() string     { return d.RideID }
+func (d testDelta) DeltaFixed() []string { return []string{"type", "ride_id"} }
+
+func TestConn_DeltaUpdates(t *testing.T) {
+	adapt := func(c *Conn, msg any) map[string]any {
+		t.Helper()
+		out, err := c.adapt(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(out)
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		return fields
+	}
+
+	first := testDelta{Type: "location", RideID: "r1", Lat: 43.2, ETA: 5}
+	next := testDelta{Type: "location", RideID: "r1", Lat: 43.3, ETA: 5}
+
+	t.Run("old client gets full messages", func(t *testing.T) {
+		c := &Conn{}
+		adapt(c, first)
+		if got := adapt(c, next); len(got) != 4 || got["delta"] != nil {
+			t.Fatalf("expected full message, got %v", got)
+		}
+	})
+
+	t.Run("delta client gets changed fields", func(t *testing.T) {
+		c := &Conn{caps: Capabilities{DeltaUpdates: true}}
+		if got := adapt(c, first); len(got) != 4 {
+			t.Fatalf("first message of a stream must be full, got %v", got)
+		}
+		got := adapt(c, next)
+		if got["delta"] != true || got["lat"] != 43.3 || got["ride_id"] != "r1" || got["type"] != "location" {
+			t.Fatalf("unexpected delta %v", got)
+		}
+		if _, ok := got["eta"]; ok {
+			t.Fatalf("unchanged field must be omitted, got %v", got)
+		}
+		// у другого потока своя история
+		if got := adapt(c, testDelta{Type: "location", RideID: "r2", Lat: 43.3, ETA: 5}); got["delta"] != nil {
+			t.Fatalf("first message of another stream must be full, got %v", got)
+		}
+	})
+}
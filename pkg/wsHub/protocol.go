@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// Protocol — версия протокола WebSocket, согласуется в сообщении auth
+type Protocol int
+
+const (
+	// ProtocolV1 — сообщения отправляются как есть, без подтверждений
+	ProtocolV1 Protocol = 1
+	// ProtocolV2 — сообщения в конверте с seq, клиент подтверждает их ack,
+	// после переподключения с resume_from неподтвержденные отправляются повторно
+	ProtocolV2 Protocol = 2
+
+	// LatestProtocol — последняя версия, которую умеет хаб
+	LatestProtocol = ProtocolV2
+)
+
+// ErrUnsupportedProtocol — клиент запросил некорректную версию протокола
+var ErrUnsupportedProtocol = errors.New("unsupported websocket protocol")
+
+func (p Protocol) String() string {
+	return "v" + strconv.Itoa(int(p))
+}
+
+// Envelope — сообщение сервера по протоколу v2
+type Envelope struct {
+	V    Protocol        `json:"v"`
+	Seq  uint64          `json:"seq"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// session — состояние протокола v2 устройства, переживает переподключения
+type session struct {
+	mu      sync.Mutex
+	seq     uint64     // последний выданный номер
+	unacked []Envelope // отправленные, но не подтвержденные клиентом
+}
+
+// wrap упаковывает сообщение в конверт со следующим номером и запоминает его до подтверждения
+func (s *session) wrap(msg any) (Envelope, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	var head struct {
+		Type string `json:"type"`
+	}
+	// у не-объектов типа нет, конверт уходит с пустым type
+	_ = json.Unmarshal(data, &head)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	env := Envelope{V: ProtocolV2, Seq: s.seq, Type: head.Type, Data: data}
+
+	if len(s.unacked) >= maxPendingMessages {
+		// клиент давно не подтверждает — самое старое уже не досылаем
+		s.unacked = s.unacked[1:]
+	}
+	s.unacked = append(s.unacked, env)
+
+	return env, nil
+}
+
+// ack подтверждает все сообщения до seq включительно
+func (s *session) ack(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := 0
+	for i < len(s.unacked) && s.unacked[i].Seq <= seq {
+		i++
+	}
+	s.unacked = s.unacked[i:]
+}
+
+// resume подтверждает сообщения до resumeFrom и возвращает остальные неподтвержденные.
+// Если клиент видел номера больше выданных (например, подключался к другому экземпляру),
+// нумерация продолжается после resumeFrom, чтобы seq у клиента не шел назад.
+func (s *session) resume(resumeFrom uint64) []Envelope {
+	s.ack(resumeFrom)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resumeFrom > s.seq {
+		s.seq = resumeFrom
+	}
+	return append([]Envelope(nil), s.unacked...)
+}
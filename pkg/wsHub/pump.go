@@ -0,0 +1,139 @@
+package ws
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Temutjin2k/ride-hail-system/pkg/metrics"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultSendBuffer — размер очереди отправки соединения по умолчанию
+	DefaultSendBuffer = 256
+	// writeWait — сколько ждать записи одного сообщения, дольше — клиент считается отвалившимся
+	writeWait = 10 * time.Second
+)
+
+var (
+	ErrConnClosed   = errors.New("connection is closed")
+	ErrSlowConsumer = errors.New("connection send queue is full")
+)
+
+// outgoing — сообщение в очереди отправки: новое или конверт v2 для повторной отправки
+type outgoing struct {
+	msg any
+	env *Envelope
+}
+
+// start заводит очередь отправки и запускает writePump. Вызывается хабом при добавлении соединения.
+func (c *Conn) start(size int, undelivered func(msg any)) {
+	if size <= 0 {
+		size = DefaultSendBuffer
+	}
+	c.send = make(chan outgoing, size)
+	c.undelivered = undelivered
+
+	go c.writePump(c.conn)
+}
+
+func (c *Conn) enqueue(out outgoing) error {
+	c.qmu.Lock()
+	if c.closed {
+		c.qmu.Unlock()
+		return ErrConnClosed
+	}
+	select {
+	case c.send <- out:
+		c.qmu.Unlock()
+		return nil
+	default:
+	}
+	c.qmu.Unlock()
+
+	// клиент не успевает читать: держать его очередь бессмысленно, пусть переподключится
+	metrics.WebSocketDroppedMessagesTotal.WithLabelValues("slow_consumer").Inc()
+	metrics.WebSocketSlowConsumersTotal.Inc()
+	c.l.Warn(c.ctx, "slow websocket consumer, closing connection",
+		"entity_ID", c.entityID,
+		"device_ID", c.deviceID,
+		"queue", cap(c.send),
+	)
+	_ = c.Close()
+
+	return ErrSlowConsumer
+}
+
+// writePump — единственный писатель сообщений в сокет. Бизнес-код не ждет медленного клиента,
+// seq v2 выдаются в порядке записи.
+func (c *Conn) writePump(conn *websocket.Conn) {
+	defer c.drain()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case out := <-c.send:
+			if err := c.writeOut(conn, out); err != nil {
+				metrics.WebSocketDroppedMessagesTotal.WithLabelValues("write_failed").Inc()
+				c.l.Warn(c.ctx, "failed to write websocket message, closing connection",
+					"entity_ID", c.entityID,
+					"device_ID", c.deviceID,
+					"err", err.Error(),
+				)
+				if c.protocol != ProtocolV2 || c.session == nil {
+					// конверт v2 уже хранится в сессии до ack
+					c.requeue(out)
+				}
+				_ = c.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) writeOut(conn *websocket.Conn, out outgoing) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+
+	if out.env != nil {
+		return conn.WriteJSON(out.env)
+	}
+
+	// в очереди и в буфере хаба лежит исходное сообщение, под клиента оно готовится при записи
+	msg, err := c.adapt(out.msg)
+	if err != nil || msg == nil {
+		return err
+	}
+
+	if c.protocol == ProtocolV2 && c.session != nil {
+		env, err := c.session.wrap(msg)
+		if err != nil {
+			return err
+		}
+		return conn.WriteJSON(env)
+	}
+	return conn.WriteJSON(msg)
+}
+
+// drain отдает хабу сообщения, оставшиеся в очереди закрытого соединения
+func (c *Conn) drain() {
+	for {
+		select {
+		case out := <-c.send:
+			c.requeue(out)
+		default:
+			return
+		}
+	}
+}
+
+// requeue возвращает неотправленное сообщение хабу. Конверты v2 уже хранятся в сессии
+// и будут отправлены повторно после переподключения с resume_from.
+func (c *Conn) requeue(out outgoing) {
+	if c.undelivered == nil || out.env != nil {
+		return
+	}
+	c.undelivered(out.msg)
+}